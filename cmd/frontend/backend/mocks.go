@@ -12,7 +12,9 @@ import (
 var Mocks MockServices
 
 type MockServices struct {
-	Repos MockRepos
+	Repos      MockRepos
+	RepoGroups MockRepoGroups
+	Symbols    MockSymbols
 }
 
 // testContext creates a new context.Context for use by tests
@@ -0,0 +1,120 @@
+package backend
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+var RepoGroups = &repoGroups{}
+
+type repoGroups struct {
+	// evaluating is held while all dynamic repository groups are evaluated,
+	// so that concurrent syncs don't evaluate them more than once.
+	evaluating sync.Mutex
+}
+
+// EvaluateRule returns the names of the repositories matching the rule of a
+// dynamic repository group, ordered by name.
+//
+// All repositories are considered, regardless of the permissions of the
+// current user, so that the stored evaluation is the same for everyone.
+// Repository permissions are enforced whenever the repositories of a group are
+// read or searched.
+func (s *repoGroups) EvaluateRule(ctx context.Context, rule types.RepoGroupRule) (names []string, err error) {
+	if Mocks.RepoGroups.EvaluateRule != nil {
+		return Mocks.RepoGroups.EvaluateRule(ctx, rule)
+	}
+
+	ctx, done := trace(ctx, "RepoGroups", "EvaluateRule", rule, &err)
+	defer done()
+
+	ctx = actor.WithActor(ctx, &actor.Actor{Internal: true})
+
+	opt := db.ReposListOptions{
+		ExternalServiceID: rule.ExternalServiceID,
+		Topics:            rule.Topics,
+		OrderBy:           db.RepoListOrderBy{{Field: db.RepoListName}},
+	}
+	if rule.RepoPattern != "" {
+		opt.IncludePatterns = []string{rule.RepoPattern}
+	}
+	repos, err := db.Repos.List(ctx, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rule.Languages) > 0 {
+		repos, err = filterReposByLanguage(ctx, repos, rule.Languages)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	names = make([]string, 0, len(repos))
+	for _, repo := range repos {
+		names = append(names, string(repo.Name))
+	}
+	return names, nil
+}
+
+// filterReposByLanguage returns the repositories whose most common language,
+// according to the stored inventory of their default branch, is one of the
+// given languages. Repositories whose inventory hasn't been computed yet (e.g.
+// because they are not cloned yet) are excluded.
+func filterReposByLanguage(ctx context.Context, repos []*types.Repo, languages []string) ([]*types.Repo, error) {
+	ids := make([]api.RepoID, len(repos))
+	for i, repo := range repos {
+		ids[i] = repo.ID
+	}
+	primary, err := db.RepoInventories.PrimaryLanguages(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := repos[:0]
+	for _, repo := range repos {
+		for _, lang := range languages {
+			if strings.EqualFold(primary[repo.ID], lang) {
+				filtered = append(filtered, repo)
+				break
+			}
+		}
+	}
+	return filtered, nil
+}
+
+// EvaluateAll evaluates the rules of all dynamic repository groups and
+// records the repositories they evaluate to. It is called whenever
+// repositories are synced.
+func (s *repoGroups) EvaluateAll(ctx context.Context) (err error) {
+	ctx, done := trace(ctx, "RepoGroups", "EvaluateAll", nil, &err)
+	defer done()
+
+	s.evaluating.Lock()
+	defer s.evaluating.Unlock()
+
+	groups, err := db.RepoGroups.List(ctx, db.RepoGroupsListOptions{OnlyDynamic: true})
+	if err != nil {
+		return err
+	}
+	for _, g := range groups {
+		evaluatedAt := time.Now()
+		names, err := s.EvaluateRule(ctx, *g.Rule)
+		if err != nil {
+			log15.Error("repo groups: failed to evaluate rule", "group", g.ID, "error", err)
+			continue
+		}
+		if err := db.RepoGroups.SetEvaluation(ctx, g.ID, names, evaluatedAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
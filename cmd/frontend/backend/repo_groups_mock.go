@@ -0,0 +1,11 @@
+package backend
+
+import (
+	"context"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+)
+
+type MockRepoGroups struct {
+	EvaluateRule func(ctx context.Context, rule types.RepoGroupRule) ([]string, error)
+}
@@ -0,0 +1,54 @@
+package backend
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+func TestRepoGroups_EvaluateRule(t *testing.T) {
+	ctx := testContext()
+
+	rule := types.RepoGroupRule{
+		RepoPattern:       "^github\\.com/org/",
+		ExternalServiceID: 2,
+		Topics:            []string{"backend"},
+		Languages:         []string{"go"},
+	}
+
+	db.Mocks.Repos.List = func(ctx context.Context, opt db.ReposListOptions) ([]*types.Repo, error) {
+		want := db.ReposListOptions{
+			IncludePatterns:   []string{rule.RepoPattern},
+			ExternalServiceID: rule.ExternalServiceID,
+			Topics:            rule.Topics,
+			OrderBy:           db.RepoListOrderBy{{Field: db.RepoListName}},
+		}
+		if !reflect.DeepEqual(opt, want) {
+			t.Errorf("got options %+v, want %+v", opt, want)
+		}
+		return []*types.Repo{
+			{ID: 1, Name: "github.com/org/go"},
+			{ID: 2, Name: "github.com/org/java"},
+			{ID: 3, Name: "github.com/org/uncloned"},
+		}, nil
+	}
+	// The uncloned repository has no inventory yet.
+	db.Mocks.RepoInventories.PrimaryLanguages = func(ctx context.Context, repoIDs []api.RepoID) (map[api.RepoID]string, error) {
+		if want := []api.RepoID{1, 2, 3}; !reflect.DeepEqual(repoIDs, want) {
+			t.Errorf("got repo IDs %v, want %v", repoIDs, want)
+		}
+		return map[api.RepoID]string{1: "Go", 2: "Java"}, nil
+	}
+
+	names, err := RepoGroups.EvaluateRule(ctx, rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"github.com/org/go"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("got %q, want %q", names, want)
+	}
+}
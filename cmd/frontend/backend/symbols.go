@@ -15,9 +15,26 @@ type symbols struct{}
 
 // ListTags returns symbols in a repository from ctags.
 func (symbols) ListTags(ctx context.Context, args search.SymbolsParameters) ([]protocol.Symbol, error) {
+	if Mocks.Symbols.ListTags != nil {
+		return Mocks.Symbols.ListTags(ctx, args)
+	}
+
 	result, err := symbolsclient.DefaultClient.Search(ctx, args)
 	if result == nil {
 		return nil, err
 	}
 	return result.Symbols, err
 }
+
+// Outline returns the tree of symbols defined in a file from ctags.
+func (symbols) Outline(ctx context.Context, args protocol.OutlineArgs) ([]protocol.OutlineSymbol, error) {
+	if Mocks.Symbols.Outline != nil {
+		return Mocks.Symbols.Outline(ctx, args)
+	}
+
+	result, err := symbolsclient.DefaultClient.Outline(ctx, args)
+	if result == nil {
+		return nil, err
+	}
+	return result.Symbols, err
+}
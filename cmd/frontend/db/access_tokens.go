@@ -6,12 +6,14 @@ import (
 	"crypto/sha256"
 	"database/sql"
 	"encoding/hex"
+	"strconv"
 	"time"
 
 	"github.com/keegancsmith/sqlf"
 	"github.com/lib/pq"
 	"github.com/pkg/errors"
 	"github.com/sourcegraph/sourcegraph/internal/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbutil"
 )
 
 // AccessToken describes an access token. The actual token (that a caller must supply to
@@ -65,10 +67,11 @@ func (s *accessTokens) Create(ctx context.Context, subjectUserID int32, scopes [
 		return 0, "", errors.New("access tokens without scopes are not supported")
 	}
 
-	if err := dbconn.Global.QueryRowContext(ctx,
-		// Include users table query (with "FOR UPDATE") to ensure that subject/creator users have
-		// not been deleted. If they were deleted, the query will return an error.
-		`
+	err = dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		if err := tx.QueryRowContext(ctx,
+			// Include users table query (with "FOR UPDATE") to ensure that subject/creator users have
+			// not been deleted. If they were deleted, the query will return an error.
+			`
 WITH subject_user AS (
   SELECT id FROM users WHERE id=$1 AND deleted_at IS NULL FOR UPDATE
 ),
@@ -81,8 +84,18 @@ insert_values AS (
 )
 INSERT INTO access_tokens(subject_user_id, scopes, value_sha256, note, creator_user_id) SELECT * FROM insert_values RETURNING id
 `,
-		subjectUserID, pq.Array(scopes), toSHA256Bytes(b[:]), note, creatorUserID,
-	).Scan(&id); err != nil {
+			subjectUserID, pq.Array(scopes), toSHA256Bytes(b[:]), note, creatorUserID,
+		).Scan(&id); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, "accessToken.create", "accessToken", strconv.FormatInt(id, 10), nil, map[string]interface{}{
+			"subjectUserID": subjectUserID,
+			"scopes":        scopes,
+			"note":          note,
+			"creatorUserID": creatorUserID,
+		})
+	})
+	if err != nil {
 		return 0, "", err
 	}
 	return id, token, nil
@@ -150,6 +163,30 @@ func (s *accessTokens) GetByID(ctx context.Context, id int64) (*AccessToken, err
 	return results[0], nil
 }
 
+// GetByToken retrieves the access token (if any) given its secret value.
+//
+// 🚨 SECURITY: This does not check the scopes of the access token. Use Lookup to
+// authenticate requests.
+func (s *accessTokens) GetByToken(ctx context.Context, tokenHexEncoded string) (*AccessToken, error) {
+	if Mocks.AccessTokens.GetByToken != nil {
+		return Mocks.AccessTokens.GetByToken(tokenHexEncoded)
+	}
+
+	token, err := hex.DecodeString(tokenHexEncoded)
+	if err != nil {
+		return nil, errors.Wrap(err, "AccessTokens.GetByToken")
+	}
+
+	results, err := s.list(ctx, []*sqlf.Query{sqlf.Sprintf("value_sha256=%s", toSHA256Bytes(token)), sqlf.Sprintf("deleted_at IS NULL")}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, ErrAccessTokenNotFound
+	}
+	return results[0], nil
+}
+
 // AccessTokensListOptions contains options for listing access tokens.
 type AccessTokensListOptions struct {
 	SubjectUserID  int32 // only list access tokens with this user as the subject
@@ -243,20 +280,21 @@ func (s *accessTokens) DeleteByToken(ctx context.Context, tokenHexEncoded string
 
 func (s *accessTokens) delete(ctx context.Context, cond *sqlf.Query) error {
 	conds := []*sqlf.Query{cond, sqlf.Sprintf("deleted_at IS NULL")}
-	q := sqlf.Sprintf("UPDATE access_tokens SET deleted_at=now() WHERE (%s)", sqlf.Join(conds, ") AND ("))
+	q := sqlf.Sprintf("UPDATE access_tokens SET deleted_at=now() WHERE (%s) RETURNING id, subject_user_id", sqlf.Join(conds, ") AND ("))
 
-	res, err := dbconn.Global.ExecContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
-	if err != nil {
-		return err
-	}
-	nrows, err := res.RowsAffected()
-	if err != nil {
-		return err
-	}
-	if nrows == 0 {
-		return ErrAccessTokenNotFound
-	}
-	return nil
+	return dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		var (
+			id            int64
+			subjectUserID int32
+		)
+		if err := tx.QueryRowContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...).Scan(&id, &subjectUserID); err != nil {
+			if err == sql.ErrNoRows {
+				return ErrAccessTokenNotFound
+			}
+			return err
+		}
+		return recordAudit(ctx, tx, "accessToken.delete", "accessToken", strconv.FormatInt(id, 10), map[string]int32{"subjectUserID": subjectUserID}, nil)
+	})
 }
 
 func toSHA256Bytes(input []byte) []byte {
@@ -269,4 +307,5 @@ type MockAccessTokens struct {
 	DeleteByID func(id int64, subjectUserID int32) error
 	Lookup     func(tokenHexEncoded, requiredScope string) (subjectUserID int32, err error)
 	GetByID    func(id int64) (*AccessToken, error)
+	GetByToken func(tokenHexEncoded string) (*AccessToken, error)
 }
@@ -61,6 +61,14 @@ func TestAccessTokens_Create(t *testing.T) {
 		t.Errorf("got %v, want %v", gotSubjectUserID, want)
 	}
 
+	gotByToken, err := AccessTokens.GetByToken(ctx, tv0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := tid0; gotByToken.ID != want {
+		t.Errorf("got %v, want %v", gotByToken.ID, want)
+	}
+
 	ts, err := AccessTokens.List(ctx, AccessTokensListOptions{SubjectUserID: subject.ID})
 	if err != nil {
 		t.Fatal(err)
@@ -0,0 +1,13 @@
+package db
+
+import (
+	"context"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+)
+
+type MockAuditLog struct {
+	Record func(ctx context.Context, e *types.AuditLogEntry) error
+	List   func(ctx context.Context, opt AuditLogListOptions) ([]*types.AuditLogEntry, error)
+	Count  func(ctx context.Context, opt AuditLogListOptions) (int, error)
+}
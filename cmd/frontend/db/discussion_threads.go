@@ -696,7 +696,12 @@ func (t *discussionThreads) getTargetRepo(ctx context.Context, targetRepoID int6
 			t.end_character,
 			t.lines_before,
 			t.lines,
-			t.lines_after
+			t.lines_after,
+			t.anchor_revision,
+			t.anchor_path,
+			t.anchor_start_line,
+			t.anchor_end_line,
+			t.outdated
 		FROM discussion_threads_target_repo t WHERE id=$1
 	`, targetRepoID).Scan(
 		&tr.ID,
@@ -712,6 +717,11 @@ func (t *discussionThreads) getTargetRepo(ctx context.Context, targetRepoID int6
 		&linesBefore,
 		&lines,
 		&linesAfter,
+		&tr.AnchorRevision,
+		&tr.AnchorPath,
+		&tr.AnchorStartLine,
+		&tr.AnchorEndLine,
+		&tr.Outdated,
 	)
 	if err != nil {
 		return nil, err
@@ -731,6 +741,80 @@ func (t *discussionThreads) getTargetRepo(ctx context.Context, targetRepoID int6
 	return tr, nil
 }
 
+// ListTargetReposToTrack returns the repo targets of threads whose selection
+// should be tracked through new commits: those of threads that aren't deleted,
+// with a path and a selection, whose selected lines are still present.
+func (t *discussionThreads) ListTargetReposToTrack(ctx context.Context) ([]*types.DiscussionThreadTargetRepo, error) {
+	if Mocks.DiscussionThreads.ListTargetReposToTrack != nil {
+		return Mocks.DiscussionThreads.ListTargetReposToTrack(ctx)
+	}
+
+	rows, err := dbconn.Global.QueryContext(ctx, `
+		SELECT tr.id
+		FROM discussion_threads_target_repo tr
+		JOIN discussion_threads t ON t.id = tr.thread_id
+		WHERE t.deleted_at IS NULL
+		AND tr.path IS NOT NULL
+		AND tr.start_line IS NOT NULL
+		AND NOT tr.outdated
+		ORDER BY tr.repo_id, tr.id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	var ids []int64
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	targets := make([]*types.DiscussionThreadTargetRepo, 0, len(ids))
+	for _, id := range ids {
+		tr, err := t.getTargetRepo(ctx, id)
+		if err != nil {
+			return nil, errors.Wrap(err, "getTargetRepo")
+		}
+		targets = append(targets, tr)
+	}
+	return targets, nil
+}
+
+// DiscussionThreadAnchor is where the selection of a thread's repo target is
+// at a revision.
+type DiscussionThreadAnchor struct {
+	Revision  string
+	Path      string
+	StartLine int32
+	EndLine   int32
+}
+
+// UpdateTargetRepoAnchor records that the selection of the repo target is at
+// the given anchor. If outdated is true, the selected lines were deleted after
+// the anchor and the target is no longer tracked.
+func (t *discussionThreads) UpdateTargetRepoAnchor(ctx context.Context, targetRepoID int64, anchor DiscussionThreadAnchor, outdated bool) error {
+	if Mocks.DiscussionThreads.UpdateTargetRepoAnchor != nil {
+		return Mocks.DiscussionThreads.UpdateTargetRepoAnchor(ctx, targetRepoID, anchor, outdated)
+	}
+
+	_, err := dbconn.Global.ExecContext(ctx, `
+		UPDATE discussion_threads_target_repo SET
+			anchor_revision=$1,
+			anchor_path=$2,
+			anchor_start_line=$3,
+			anchor_end_line=$4,
+			outdated=$5
+		WHERE id=$6
+	`, anchor.Revision, anchor.Path, anchor.StartLine, anchor.EndLine, outdated, targetRepoID)
+	return err
+}
+
 // extraFuzzy turns a string like "cat" into "%c%a%t%". It can be used with a
 // LIKE query to filter out results that cannot possibly match a fuzzy search
 // query. This returns 'extra fuzzy' results, which are usually subsequently
@@ -336,3 +336,83 @@ func boolPtr(b bool) *bool {
 func strPtr(s string) *string {
 	return &s
 }
+
+func TestDiscussionThreads_TargetRepoAnchor(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	dbtesting.SetupGlobalTestDB(t)
+	ctx := context.Background()
+
+	user, err := Users.Create(ctx, NewUser{
+		Email:                 "a@a.com",
+		Username:              "u",
+		Password:              "p",
+		EmailVerificationCode: "c",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create a repository to comply with the postgres repo constraint.
+	if err := Repos.Upsert(ctx, InsertRepoOp{Name: "myrepo", Description: "", Fork: false}); err != nil {
+		t.Fatal(err)
+	}
+	repo, err := Repos.GetByName(ctx, "myrepo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zero, one := int32(0), int32(1)
+	lines := []string{"x"}
+	thread, err := DiscussionThreads.Create(ctx, &types.DiscussionThread{
+		AuthorUserID: user.ID,
+		Title:        "Hello world!",
+		TargetRepo: &types.DiscussionThreadTargetRepo{
+			RepoID:         repo.ID,
+			Path:           strPtr("foo/bar/mux.go"),
+			Branch:         strPtr("master"),
+			Revision:       strPtr("0c1a96370c1a96370c1a96370c1a96370c1a9637"),
+			StartLine:      &zero,
+			EndLine:        &one,
+			StartCharacter: &zero,
+			EndCharacter:   &one,
+			LinesBefore:    &[]string{},
+			Lines:          &lines,
+			LinesAfter:     &[]string{},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	targets, err := DiscussionThreads.ListTargetReposToTrack(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 1 || targets[0].ID != thread.TargetRepo.ID {
+		t.Fatalf("got targets %+v, want the thread's target", targets)
+	}
+
+	anchor := DiscussionThreadAnchor{Revision: "1c1a96370c1a96370c1a96370c1a96370c1a9637", Path: "mux.go", StartLine: 3, EndLine: 4}
+	if err := DiscussionThreads.UpdateTargetRepoAnchor(ctx, thread.TargetRepo.ID, anchor, true); err != nil {
+		t.Fatal(err)
+	}
+	gotThread, err := DiscussionThreads.Get(ctx, thread.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := gotThread.TargetRepo
+	if *tr.AnchorRevision != anchor.Revision || *tr.AnchorPath != anchor.Path || *tr.AnchorStartLine != 3 || *tr.AnchorEndLine != 4 || !tr.Outdated {
+		t.Errorf("got target %+v, want anchor %+v and outdated", spew.Sdump(tr), anchor)
+	}
+
+	// Outdated threads are no longer tracked.
+	targets, err = DiscussionThreads.ListTargetReposToTrack(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 0 {
+		t.Errorf("got %d targets, want none", len(targets))
+	}
+}
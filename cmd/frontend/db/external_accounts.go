@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strconv"
 
 	multierror "github.com/hashicorp/go-multierror"
 	"github.com/inconshreveable/log15"
@@ -161,6 +162,9 @@ func (s *userExternalAccounts) CreateUserAndSave(ctx context.Context, newUser Ne
 	if err != nil {
 		return 0, err
 	}
+	if err := recordAudit(ctx, tx, "user.create", "user", strconv.Itoa(int(createdUser.ID)), nil, createdUser); err != nil {
+		return 0, err
+	}
 
 	err = s.insert(ctx, tx, createdUser.ID, spec, data)
 	return createdUser.ID, err
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -30,6 +31,8 @@ type ExternalServicesStore struct {
 	GitHubValidators          []func(*schema.GitHubConnection) error
 	GitLabValidators          []func(*schema.GitLabConnection, []schema.AuthProviders) error
 	BitbucketServerValidators []func(*schema.BitbucketServerConnection) error
+	GerritValidators          []func(*schema.GerritConnection) error
+	GiteaValidators           []func(*schema.GiteaConnection) error
 }
 
 // ExternalServiceKinds contains a map of all supported kinds of
@@ -38,6 +41,8 @@ var ExternalServiceKinds = map[string]ExternalServiceKind{
 	"AWSCODECOMMIT":   {CodeHost: true, JSONSchema: schema.AWSCodeCommitSchemaJSON},
 	"BITBUCKETCLOUD":  {CodeHost: true, JSONSchema: schema.BitbucketCloudSchemaJSON},
 	"BITBUCKETSERVER": {CodeHost: true, JSONSchema: schema.BitbucketServerSchemaJSON},
+	"GERRIT":          {CodeHost: true, JSONSchema: schema.GerritSchemaJSON},
+	"GITEA":           {CodeHost: true, JSONSchema: schema.GiteaSchemaJSON},
 	"GITHUB":          {CodeHost: true, JSONSchema: schema.GitHubSchemaJSON},
 	"GITLAB":          {CodeHost: true, JSONSchema: schema.GitLabSchemaJSON},
 	"GITOLITE":        {CodeHost: true, JSONSchema: schema.GitoliteSchemaJSON},
@@ -130,6 +135,20 @@ func (e *ExternalServicesStore) ValidateConfig(kind, config string, ps []schema.
 		}
 		err = e.validateBitbucketServerConnection(&c)
 
+	case "GERRIT":
+		var c schema.GerritConnection
+		if err = json.Unmarshal(normalized, &c); err != nil {
+			return err
+		}
+		err = e.validateGerritConnection(&c)
+
+	case "GITEA":
+		var c schema.GiteaConnection
+		if err = json.Unmarshal(normalized, &c); err != nil {
+			return err
+		}
+		err = e.validateGiteaConnection(&c)
+
 	case "OTHER":
 		var c schema.OtherExternalServiceConnection
 		if err = json.Unmarshal(normalized, &c); err != nil {
@@ -204,6 +223,22 @@ func (e *ExternalServicesStore) validateBitbucketServerConnection(c *schema.Bitb
 	return err.ErrorOrNil()
 }
 
+func (e *ExternalServicesStore) validateGerritConnection(c *schema.GerritConnection) error {
+	err := new(multierror.Error)
+	for _, validate := range e.GerritValidators {
+		err = multierror.Append(err, validate(c))
+	}
+	return err.ErrorOrNil()
+}
+
+func (e *ExternalServicesStore) validateGiteaConnection(c *schema.GiteaConnection) error {
+	err := new(multierror.Error)
+	for _, validate := range e.GiteaValidators {
+		err = multierror.Append(err, validate(c))
+	}
+	return err.ErrorOrNil()
+}
+
 // Create creates a external service.
 //
 // Since this method is used before the configuration server has started
@@ -222,11 +257,17 @@ func (c *ExternalServicesStore) Create(ctx context.Context, confGet func() *conf
 	externalService.CreatedAt = time.Now()
 	externalService.UpdatedAt = externalService.CreatedAt
 
-	return dbconn.Global.QueryRowContext(
-		ctx,
-		"INSERT INTO external_services(kind, display_name, config, created_at, updated_at) VALUES($1, $2, $3, $4, $5) RETURNING id",
-		externalService.Kind, externalService.DisplayName, externalService.Config, externalService.CreatedAt, externalService.UpdatedAt,
-	).Scan(&externalService.ID)
+	return dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(
+			ctx,
+			"INSERT INTO external_services(kind, display_name, config, created_at, updated_at) VALUES($1, $2, $3, $4, $5) RETURNING id",
+			externalService.Kind, externalService.DisplayName, externalService.Config, externalService.CreatedAt, externalService.UpdatedAt,
+		).Scan(&externalService.ID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, "externalService.create", "externalService", strconv.FormatInt(externalService.ID, 10), nil, externalService)
+	})
 }
 
 // ExternalServiceUpdate contains optional fields to update.
@@ -239,14 +280,15 @@ type ExternalServiceUpdate struct {
 //
 // 🚨 SECURITY: The caller must ensure that the actor is a site admin.
 func (c *ExternalServicesStore) Update(ctx context.Context, ps []schema.AuthProviders, id int64, update *ExternalServiceUpdate) error {
+	// Query to get the kind (which is immutable) so we can validate the new
+	// config, and to record the previous state in the audit log.
+	before, err := c.GetByID(ctx, id)
 	if update.Config != nil {
-		// Query to get the kind (which is immutable) so we can validate the new config.
-		externalService, err := c.GetByID(ctx, id)
 		if err != nil {
 			return err
 		}
 
-		if err := c.ValidateConfig(externalService.Kind, *update.Config, ps); err != nil {
+		if err := c.ValidateConfig(before.Kind, *update.Config, ps); err != nil {
 			return err
 		}
 	}
@@ -277,7 +319,20 @@ func (c *ExternalServicesStore) Update(ctx context.Context, ps []schema.AuthProv
 				return err
 			}
 		}
-		return nil
+
+		var after *types.ExternalService
+		if before != nil {
+			updated := *before
+			if update.DisplayName != nil {
+				updated.DisplayName = *update.DisplayName
+			}
+			if update.Config != nil {
+				updated.Config = *update.Config
+			}
+			updated.UpdatedAt = time.Now()
+			after = &updated
+		}
+		return recordAudit(ctx, tx, "externalService.update", "externalService", strconv.FormatInt(id, 10), before, after)
 	})
 }
 
@@ -296,19 +351,23 @@ func (e externalServiceNotFoundError) NotFound() bool {
 // Delete deletes an external service.
 //
 // 🚨 SECURITY: The caller must ensure that the actor is a site admin.
-func (*ExternalServicesStore) Delete(ctx context.Context, id int64) error {
-	res, err := dbconn.Global.ExecContext(ctx, "UPDATE external_services SET deleted_at=now() WHERE id=$1 AND deleted_at IS NULL", id)
-	if err != nil {
-		return err
-	}
-	nrows, err := res.RowsAffected()
-	if err != nil {
-		return err
-	}
-	if nrows == 0 {
-		return externalServiceNotFoundError{id: id}
-	}
-	return nil
+func (c *ExternalServicesStore) Delete(ctx context.Context, id int64) error {
+	before, _ := c.GetByID(ctx, id)
+
+	return dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, "UPDATE external_services SET deleted_at=now() WHERE id=$1 AND deleted_at IS NULL", id)
+		if err != nil {
+			return err
+		}
+		nrows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if nrows == 0 {
+			return externalServiceNotFoundError{id: id}
+		}
+		return recordAudit(ctx, tx, "externalService.delete", "externalService", strconv.FormatInt(id, 10), before, nil)
+	})
 }
 
 // GetByID returns the external service for id.
@@ -403,6 +462,28 @@ func (c *ExternalServicesStore) ListBitbucketServerConnections(ctx context.Conte
 	return connections, nil
 }
 
+// ListGerritConnections returns a list of GerritConnection configs.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (c *ExternalServicesStore) ListGerritConnections(ctx context.Context) ([]*schema.GerritConnection, error) {
+	var connections []*schema.GerritConnection
+	if err := c.listConfigs(ctx, "GERRIT", &connections); err != nil {
+		return nil, err
+	}
+	return connections, nil
+}
+
+// ListGiteaConnections returns a list of GiteaConnection configs.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (c *ExternalServicesStore) ListGiteaConnections(ctx context.Context) ([]*schema.GiteaConnection, error) {
+	var connections []*schema.GiteaConnection
+	if err := c.listConfigs(ctx, "GITEA", &connections); err != nil {
+		return nil, err
+	}
+	return connections, nil
+}
+
 // ListGitHubConnections returns a list of GitHubConnection configs.
 //
 // 🚨 SECURITY: The caller must ensure that the actor is a site admin.
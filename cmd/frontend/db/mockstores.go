@@ -5,18 +5,22 @@ var Mocks MockStores
 // MockStores has a field for each store interface with the concrete mock type (to obviate the need for tedious type assertions in test code).
 type MockStores struct {
 	AccessTokens MockAccessTokens
+	AuditLog     MockAuditLog
 
 	DiscussionThreads         MockDiscussionThreads
 	DiscussionComments        MockDiscussionComments
 	DiscussionMailReplyTokens MockDiscussionMailReplyTokens
 
-	Repos         MockRepos
-	Orgs          MockOrgs
-	OrgMembers    MockOrgMembers
-	SavedSearches MockSavedSearches
-	Settings      MockSettings
-	Users         MockUsers
-	UserEmails    MockUserEmails
+	Repos           MockRepos
+	RepoGroups      MockRepoGroups
+	RepoInventories MockRepoInventories
+	Orgs            MockOrgs
+	OrgMembers      MockOrgMembers
+	SavedSearches   MockSavedSearches
+	SavedSearchRuns MockSavedSearchRuns
+	Settings        MockSettings
+	Users           MockUsers
+	UserEmails      MockUserEmails
 
 	Phabricator MockPhabricator
 
@@ -5,9 +5,11 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strconv"
 
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/internal/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbutil"
 
 	"github.com/keegancsmith/sqlf"
 
@@ -21,16 +23,22 @@ func (*orgMembers) Create(ctx context.Context, orgID, userID int32) (*types.OrgM
 		OrgID:  orgID,
 		UserID: userID,
 	}
-	err := dbconn.Global.QueryRowContext(
-		ctx,
-		"INSERT INTO org_members(org_id, user_id) VALUES($1, $2) RETURNING id, created_at, updated_at",
-		m.OrgID, m.UserID).Scan(&m.ID, &m.CreatedAt, &m.UpdatedAt)
-	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok {
-			if pqErr.Constraint == "org_members_org_id_user_id_key" {
-				return nil, errors.New("user is already a member of the organization")
+	err := dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(
+			ctx,
+			"INSERT INTO org_members(org_id, user_id) VALUES($1, $2) RETURNING id, created_at, updated_at",
+			m.OrgID, m.UserID).Scan(&m.ID, &m.CreatedAt, &m.UpdatedAt)
+		if err != nil {
+			if pqErr, ok := err.(*pq.Error); ok {
+				if pqErr.Constraint == "org_members_org_id_user_id_key" {
+					return errors.New("user is already a member of the organization")
+				}
 			}
+			return err
 		}
+		return recordAudit(ctx, tx, "org.addMember", "org", strconv.Itoa(int(orgID)), nil, map[string]int32{"userID": userID})
+	})
+	if err != nil {
 		return nil, err
 	}
 	return &m, nil
@@ -48,12 +56,23 @@ func (m *orgMembers) GetByOrgIDAndUserID(ctx context.Context, orgID, userID int3
 }
 
 func (*orgMembers) Remove(ctx context.Context, orgID, userID int32) error {
-	_, err := dbconn.Global.ExecContext(ctx, "DELETE FROM org_members WHERE (org_id=$1 AND user_id=$2)", orgID, userID)
-	return err
+	return dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, "DELETE FROM org_members WHERE (org_id=$1 AND user_id=$2)", orgID, userID)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			return err
+		}
+		return recordAudit(ctx, tx, "org.removeMember", "org", strconv.Itoa(int(orgID)), map[string]int32{"userID": userID}, nil)
+	})
 }
 
 // GetByOrgID returns a list of all members of a given organization.
 func (*orgMembers) GetByOrgID(ctx context.Context, orgID int32) ([]*types.OrgMembership, error) {
+	if Mocks.OrgMembers.GetByOrgID != nil {
+		return Mocks.OrgMembers.GetByOrgID(ctx, orgID)
+	}
 	org, err := Orgs.GetByID(ctx, orgID)
 	if err != nil {
 		return nil, err
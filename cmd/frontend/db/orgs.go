@@ -2,13 +2,16 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	multierror "github.com/hashicorp/go-multierror"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/internal/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbutil"
 
 	"github.com/keegancsmith/sqlf"
 	"github.com/lib/pq"
@@ -30,6 +33,10 @@ type orgs struct{}
 // GetByUserID returns a list of all organizations for the user. An empty slice is
 // returned if the user is not authenticated or is not a member of any org.
 func (*orgs) GetByUserID(ctx context.Context, userID int32) ([]*types.Org, error) {
+	if Mocks.Orgs.GetByUserID != nil {
+		return Mocks.Orgs.GetByUserID(ctx, userID)
+	}
+
 	rows, err := dbconn.Global.QueryContext(ctx, "SELECT orgs.id, orgs.name, orgs.display_name,  orgs.created_at, orgs.updated_at FROM org_members LEFT OUTER JOIN orgs ON org_members.org_id = orgs.id WHERE user_id=$1 AND orgs.deleted_at IS NULL", userID)
 	if err != nil {
 		return []*types.Org{}, err
@@ -147,7 +154,7 @@ func (*orgs) getBySQL(ctx context.Context, query string, args ...interface{}) ([
 	return orgs, nil
 }
 
-func (*orgs) Create(ctx context.Context, name string, displayName *string) (*types.Org, error) {
+func (*orgs) Create(ctx context.Context, name string, displayName *string) (_ *types.Org, err error) {
 	tx, err := dbconn.Global.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
@@ -193,6 +200,9 @@ func (*orgs) Create(ctx context.Context, name string, displayName *string) (*typ
 		return nil, errOrgNameAlreadyExists
 	}
 
+	if err := recordAudit(ctx, tx, "org.create", "org", strconv.Itoa(int(newOrg.ID)), nil, newOrg); err != nil {
+		return nil, err
+	}
 	return &newOrg, nil
 }
 
@@ -201,26 +211,34 @@ func (o *orgs) Update(ctx context.Context, id int32, displayName *string) (*type
 	if err != nil {
 		return nil, err
 	}
+	before := *org
 
 	// NOTE: It is not possible to update an organization's name. If it becomes possible, we need to
 	// also update the `names` table to ensure the new name is available in the shared users+orgs
 	// namespace.
 
-	if displayName != nil {
-		org.DisplayName = displayName
-		if _, err := dbconn.Global.ExecContext(ctx, "UPDATE orgs SET display_name=$1 WHERE id=$2 AND deleted_at IS NULL", org.DisplayName, id); err != nil {
-			return nil, err
+	err = dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		if displayName != nil {
+			org.DisplayName = displayName
+			if _, err := tx.ExecContext(ctx, "UPDATE orgs SET display_name=$1 WHERE id=$2 AND deleted_at IS NULL", org.DisplayName, id); err != nil {
+				return err
+			}
 		}
-	}
-	org.UpdatedAt = time.Now()
-	if _, err := dbconn.Global.ExecContext(ctx, "UPDATE orgs SET updated_at=$1 WHERE id=$2 AND deleted_at IS NULL", org.UpdatedAt, id); err != nil {
+		org.UpdatedAt = time.Now()
+		if _, err := tx.ExecContext(ctx, "UPDATE orgs SET updated_at=$1 WHERE id=$2 AND deleted_at IS NULL", org.UpdatedAt, id); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, "org.update", "org", strconv.Itoa(int(id)), before, org)
+	})
+	if err != nil {
 		return nil, err
 	}
-
 	return org, nil
 }
 
-func (o *orgs) Delete(ctx context.Context, id int32) error {
+func (o *orgs) Delete(ctx context.Context, id int32) (err error) {
+	before, _ := o.GetByID(ctx, id)
+
 	// Wrap in transaction because we delete from multiple tables.
 	tx, err := dbconn.Global.BeginTx(ctx, nil)
 	if err != nil {
@@ -261,7 +279,7 @@ func (o *orgs) Delete(ctx context.Context, id int32) error {
 		return err
 	}
 
-	return nil
+	return recordAudit(ctx, tx, "org.delete", "org", strconv.Itoa(int(id)), before, nil)
 }
 
 // TmpListAllOrgsWithSlackWebhookURL is a temporary method to support migrating
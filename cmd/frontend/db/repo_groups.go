@@ -0,0 +1,313 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/keegancsmith/sqlf"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/internal/trace"
+)
+
+var errRepoGroupNameAlreadyExists = errors.New("repository group name is already taken by another group of the same owner")
+
+type repoGroupNotFoundErr struct {
+	ID int32
+}
+
+func (e *repoGroupNotFoundErr) Error() string {
+	return fmt.Sprintf("repository group not found: id=%d", e.ID)
+}
+
+func (e *repoGroupNotFoundErr) NotFound() bool {
+	return true
+}
+
+type repoGroups struct{}
+
+// RepoGroupsListOptions specifies the options for listing repository groups.
+type RepoGroupsListOptions struct {
+	// UserID and OrgIDs, if set, only include groups owned by the user or by
+	// any of the organizations.
+	UserID int32
+	OrgIDs []int32
+
+	// OnlyDynamic only includes groups defined by a rule.
+	OnlyDynamic bool
+}
+
+// GetByID returns the repository group with the given ID.
+//
+// 🚨 SECURITY: This method does NOT verify the user's identity or that the
+// user is an admin. It is the callers responsibility to ensure that only users
+// with the proper permissions can access the returned repository group.
+func (s *repoGroups) GetByID(ctx context.Context, id int32) (*types.RepoGroup, error) {
+	if Mocks.RepoGroups.GetByID != nil {
+		return Mocks.RepoGroups.GetByID(ctx, id)
+	}
+
+	groups, err := s.list(ctx, []*sqlf.Query{sqlf.Sprintf("id=%d", id)})
+	if err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 {
+		return nil, &repoGroupNotFoundErr{ID: id}
+	}
+	return groups[0], nil
+}
+
+// List lists repository groups, ordered by name.
+//
+// 🚨 SECURITY: This method does NOT verify the user's identity or that the
+// user is an admin. It is the callers responsibility to ensure that only users
+// with the proper permissions can access the returned repository groups.
+func (s *repoGroups) List(ctx context.Context, opt RepoGroupsListOptions) (groups []*types.RepoGroup, err error) {
+	if Mocks.RepoGroups.List != nil {
+		return Mocks.RepoGroups.List(ctx, opt)
+	}
+
+	tr, ctx := trace.New(ctx, "db.RepoGroups.List", "")
+	defer func() {
+		tr.SetError(err)
+		tr.Finish()
+	}()
+
+	var conds []*sqlf.Query
+	if opt.UserID != 0 || len(opt.OrgIDs) > 0 {
+		owners := []*sqlf.Query{sqlf.Sprintf("user_id=%d", opt.UserID)}
+		for _, orgID := range opt.OrgIDs {
+			owners = append(owners, sqlf.Sprintf("org_id=%d", orgID))
+		}
+		conds = append(conds, sqlf.Sprintf("(%s)", sqlf.Join(owners, " OR ")))
+	}
+	if opt.OnlyDynamic {
+		conds = append(conds, sqlf.Sprintf("rule IS NOT NULL"))
+	}
+	return s.list(ctx, conds)
+}
+
+func (s *repoGroups) list(ctx context.Context, conds []*sqlf.Query) ([]*types.RepoGroup, error) {
+	if len(conds) == 0 {
+		conds = []*sqlf.Query{sqlf.Sprintf("TRUE")}
+	}
+	q := sqlf.Sprintf(`SELECT
+		id,
+		name,
+		description,
+		user_id,
+		org_id,
+		rule,
+		repositories,
+		evaluated_at,
+		created_at,
+		updated_at
+		FROM repo_groups WHERE %s ORDER BY name ASC, id ASC`,
+		sqlf.Join(conds, "AND"),
+	)
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, errors.Wrap(err, "QueryContext")
+	}
+	defer rows.Close()
+
+	var groups []*types.RepoGroup
+	for rows.Next() {
+		var (
+			g           types.RepoGroup
+			ruleJSON    []byte
+			evaluatedAt pq.NullTime
+		)
+		if err := rows.Scan(&g.ID, &g.Name, &g.Description, &g.UserID, &g.OrgID, &ruleJSON, pq.Array(&g.Repositories), &evaluatedAt, &g.CreatedAt, &g.UpdatedAt); err != nil {
+			return nil, errors.Wrap(err, "Scan")
+		}
+		if ruleJSON != nil {
+			if err := json.Unmarshal(ruleJSON, &g.Rule); err != nil {
+				return nil, errors.Wrap(err, "Unmarshal")
+			}
+		}
+		if evaluatedAt.Valid {
+			g.EvaluatedAt = &evaluatedAt.Time
+		}
+		groups = append(groups, &g)
+	}
+	return groups, rows.Err()
+}
+
+// Create creates a new repository group. The ID field must be zero, or an
+// error will be returned.
+//
+// 🚨 SECURITY: This method does NOT verify the user's identity or that the
+// user is an admin. It is the callers responsibility to ensure the user has
+// proper permissions to create the repository group.
+func (s *repoGroups) Create(ctx context.Context, group *types.RepoGroup) (created *types.RepoGroup, err error) {
+	if Mocks.RepoGroups.Create != nil {
+		return Mocks.RepoGroups.Create(ctx, group)
+	}
+
+	if group.ID != 0 {
+		return nil, errors.New("group.ID must be zero")
+	}
+
+	tr, ctx := trace.New(ctx, "db.RepoGroups.Create", "")
+	defer func() {
+		tr.SetError(err)
+		tr.Finish()
+	}()
+
+	ruleJSON, err := marshalRepoGroupRule(group.Rule)
+	if err != nil {
+		return nil, err
+	}
+	repositories := group.Repositories
+	if repositories == nil {
+		repositories = []string{}
+	}
+
+	var id int32
+	err = dbconn.Global.QueryRowContext(ctx, `INSERT INTO repo_groups(
+			name,
+			description,
+			user_id,
+			org_id,
+			rule,
+			repositories,
+			evaluated_at
+		) VALUES($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+		group.Name,
+		group.Description,
+		group.UserID,
+		group.OrgID,
+		ruleJSON,
+		pq.Array(repositories),
+		group.EvaluatedAt,
+	).Scan(&id)
+	if err != nil {
+		return nil, repoGroupError(err)
+	}
+	return s.GetByID(ctx, id)
+}
+
+// Update updates the name, description, rule and repositories of an existing
+// repository group. Its owner can't be changed.
+//
+// 🚨 SECURITY: This method does NOT verify the user's identity or that the
+// user is an admin. It is the callers responsibility to ensure the user has
+// proper permissions to perform the update.
+func (s *repoGroups) Update(ctx context.Context, group *types.RepoGroup) (updated *types.RepoGroup, err error) {
+	if Mocks.RepoGroups.Update != nil {
+		return Mocks.RepoGroups.Update(ctx, group)
+	}
+
+	tr, ctx := trace.New(ctx, "db.RepoGroups.Update", "")
+	defer func() {
+		tr.SetError(err)
+		tr.Finish()
+	}()
+
+	ruleJSON, err := marshalRepoGroupRule(group.Rule)
+	if err != nil {
+		return nil, err
+	}
+	repositories := group.Repositories
+	if repositories == nil {
+		repositories = []string{}
+	}
+
+	res, err := dbconn.Global.ExecContext(ctx, `UPDATE repo_groups SET
+			name=$1,
+			description=$2,
+			rule=$3,
+			repositories=$4,
+			evaluated_at=$5,
+			updated_at=now()
+		WHERE id=$6`,
+		group.Name,
+		group.Description,
+		ruleJSON,
+		pq.Array(repositories),
+		group.EvaluatedAt,
+		group.ID,
+	)
+	if err != nil {
+		return nil, repoGroupError(err)
+	}
+	if nrows, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if nrows == 0 {
+		return nil, &repoGroupNotFoundErr{ID: group.ID}
+	}
+	return s.GetByID(ctx, group.ID)
+}
+
+// SetEvaluation records the repositories that the rule of a dynamic
+// repository group evaluated to.
+func (s *repoGroups) SetEvaluation(ctx context.Context, id int32, repositories []string, evaluatedAt time.Time) error {
+	if Mocks.RepoGroups.SetEvaluation != nil {
+		return Mocks.RepoGroups.SetEvaluation(ctx, id, repositories, evaluatedAt)
+	}
+
+	if repositories == nil {
+		repositories = []string{}
+	}
+	// The rule must still be set, in case the group was changed to an
+	// explicit list during the evaluation.
+	_, err := dbconn.Global.ExecContext(ctx, `UPDATE repo_groups SET repositories=$1, evaluated_at=$2 WHERE id=$3 AND rule IS NOT NULL`, pq.Array(repositories), evaluatedAt, id)
+	return err
+}
+
+// Delete hard-deletes an existing repository group.
+//
+// 🚨 SECURITY: This method does NOT verify the user's identity or that the
+// user is an admin. It is the callers responsibility to ensure the user has
+// proper permissions to perform the delete.
+func (s *repoGroups) Delete(ctx context.Context, id int32) (err error) {
+	if Mocks.RepoGroups.Delete != nil {
+		return Mocks.RepoGroups.Delete(ctx, id)
+	}
+
+	tr, ctx := trace.New(ctx, "db.RepoGroups.Delete", "")
+	defer func() {
+		tr.SetError(err)
+		tr.Finish()
+	}()
+
+	res, err := dbconn.Global.ExecContext(ctx, `DELETE FROM repo_groups WHERE id=$1`, id)
+	if err != nil {
+		return err
+	}
+	if nrows, err := res.RowsAffected(); err != nil {
+		return err
+	} else if nrows == 0 {
+		return &repoGroupNotFoundErr{ID: id}
+	}
+	return nil
+}
+
+func marshalRepoGroupRule(rule *types.RepoGroupRule) (sql.NullString, error) {
+	if rule == nil {
+		return sql.NullString{}, nil
+	}
+	b, err := json.Marshal(rule)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(b), Valid: true}, nil
+}
+
+func repoGroupError(err error) error {
+	if pqErr, ok := err.(*pq.Error); ok {
+		switch pqErr.Constraint {
+		case "repo_groups_user_id_name", "repo_groups_org_id_name":
+			return errRepoGroupNameAlreadyExists
+		case "repo_groups_name_nonempty":
+			return errors.New("repository group name must not be empty")
+		}
+	}
+	return err
+}
@@ -0,0 +1,17 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+)
+
+type MockRepoGroups struct {
+	GetByID       func(ctx context.Context, id int32) (*types.RepoGroup, error)
+	List          func(ctx context.Context, opt RepoGroupsListOptions) ([]*types.RepoGroup, error)
+	Create        func(ctx context.Context, group *types.RepoGroup) (*types.RepoGroup, error)
+	Update        func(ctx context.Context, group *types.RepoGroup) (*types.RepoGroup, error)
+	SetEvaluation func(ctx context.Context, id int32, repositories []string, evaluatedAt time.Time) error
+	Delete        func(ctx context.Context, id int32) error
+}
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbtesting"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+)
+
+func TestRepoGroups(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	dbtesting.SetupGlobalTestDB(t)
+	ctx := context.Background()
+	user, err := Users.Create(ctx, NewUser{DisplayName: "test", Email: "test@test.com", Username: "test", Password: "test", EmailVerificationCode: "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	org, err := Orgs.Create(ctx, "org", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	explicit, err := RepoGroups.Create(ctx, &types.RepoGroup{
+		Name:         "explicit",
+		UserID:       &user.ID,
+		Repositories: []string{"github.com/foo/bar"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dynamic, err := RepoGroups.Create(ctx, &types.RepoGroup{
+		Name:  "dynamic",
+		OrgID: &org.ID,
+		Rule:  &types.RepoGroupRule{RepoPattern: "^github\\.com/foo/", Topics: []string{"backend"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Names are unique per owner.
+	if _, err := RepoGroups.Create(ctx, &types.RepoGroup{Name: "explicit", UserID: &user.ID}); err != errRepoGroupNameAlreadyExists {
+		t.Errorf("got error %v, want %v", err, errRepoGroupNameAlreadyExists)
+	}
+	if _, err := RepoGroups.Create(ctx, &types.RepoGroup{Name: "explicit", OrgID: &org.ID}); err != nil {
+		t.Errorf("got error %v creating group with name of group of other owner", err)
+	}
+
+	evaluatedAt := time.Now().UTC().Truncate(time.Microsecond)
+	if err := RepoGroups.SetEvaluation(ctx, dynamic.ID, []string{"github.com/foo/baz"}, evaluatedAt); err != nil {
+		t.Fatal(err)
+	}
+	// Explicit groups are not changed by evaluations.
+	if err := RepoGroups.SetEvaluation(ctx, explicit.ID, nil, evaluatedAt); err != nil {
+		t.Fatal(err)
+	}
+
+	dynamic, err = RepoGroups.GetByID(ctx, dynamic.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"github.com/foo/baz"}; !reflect.DeepEqual(dynamic.Repositories, want) {
+		t.Errorf("got repositories %q, want %q", dynamic.Repositories, want)
+	}
+	if want := (&types.RepoGroupRule{RepoPattern: "^github\\.com/foo/", Topics: []string{"backend"}}); !reflect.DeepEqual(dynamic.Rule, want) {
+		t.Errorf("got rule %+v, want %+v", dynamic.Rule, want)
+	}
+	if dynamic.EvaluatedAt == nil || !dynamic.EvaluatedAt.Equal(evaluatedAt) {
+		t.Errorf("got evaluated at %v, want %v", dynamic.EvaluatedAt, evaluatedAt)
+	}
+
+	names := func(opt RepoGroupsListOptions) []string {
+		t.Helper()
+		groups, err := RepoGroups.List(ctx, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, g := range groups {
+			names = append(names, g.Name)
+		}
+		return names
+	}
+	if got, want := names(RepoGroupsListOptions{UserID: user.ID}), []string{"explicit"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := names(RepoGroupsListOptions{UserID: user.ID, OrgIDs: []int32{org.ID}}), []string{"dynamic", "explicit", "explicit"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := names(RepoGroupsListOptions{OnlyDynamic: true}), []string{"dynamic"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	explicit.Name = "renamed"
+	explicit.Repositories = []string{"github.com/foo/qux"}
+	updated, err := RepoGroups.Update(ctx, explicit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Name != "renamed" || !reflect.DeepEqual(updated.Repositories, []string{"github.com/foo/qux"}) {
+		t.Errorf("got %+v after update", updated)
+	}
+
+	if err := RepoGroups.Delete(ctx, explicit.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RepoGroups.GetByID(ctx, explicit.ID); !errcode.IsNotFound(err) {
+		t.Errorf("got error %v, want not found", err)
+	}
+}
@@ -0,0 +1,296 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/keegancsmith/sqlf"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/inventory"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbutil"
+	"github.com/sourcegraph/sourcegraph/internal/trace"
+)
+
+// repoInventories stores the language inventories of the default branches of
+// repositories, and the history of their language statistics.
+//
+// 🚨 SECURITY: None of its methods check that the current user can access the
+// repositories. Callers must only pass the IDs of repositories that the user
+// can access (e.g. as returned by Repos.List).
+type repoInventories struct{}
+
+// Get returns the inventory of the repository, or nil if it hasn't been
+// computed yet.
+func (s *repoInventories) Get(ctx context.Context, repoID api.RepoID) (inv *types.RepoInventory, err error) {
+	if Mocks.RepoInventories.Get != nil {
+		return Mocks.RepoInventories.Get(ctx, repoID)
+	}
+
+	tr, ctx := trace.New(ctx, "db.RepoInventories.Get", "")
+	defer func() {
+		tr.SetError(err)
+		tr.Finish()
+	}()
+
+	inv = &types.RepoInventory{RepoID: repoID}
+	err = dbconn.Global.QueryRowContext(ctx, `SELECT commit_id, updated_at FROM repo_inventories WHERE repo_id=$1`, repoID).Scan(&inv.CommitID, &inv.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := dbconn.Global.QueryContext(ctx, `SELECT language, total_lines, total_bytes FROM repo_languages WHERE repo_id=$1 ORDER BY total_lines DESC, language`, repoID)
+	if err != nil {
+		return nil, err
+	}
+	inv.Languages, err = scanLangs(rows)
+	if err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// Set stores the inventory of the repository, replacing the previous one, and
+// records the languages whose statistics changed in their history.
+func (s *repoInventories) Set(ctx context.Context, inv *types.RepoInventory) (err error) {
+	if Mocks.RepoInventories.Set != nil {
+		return Mocks.RepoInventories.Set(ctx, inv)
+	}
+
+	tr, ctx := trace.New(ctx, "db.RepoInventories.Set", "")
+	defer func() {
+		tr.SetError(err)
+		tr.Finish()
+	}()
+
+	return dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `SELECT language, total_lines, total_bytes FROM repo_languages WHERE repo_id=$1 FOR UPDATE`, inv.RepoID)
+		if err != nil {
+			return err
+		}
+		prev, err := scanLangs(rows)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `INSERT INTO repo_inventories(repo_id, commit_id) VALUES($1, $2)
+			ON CONFLICT (repo_id) DO UPDATE SET commit_id=excluded.commit_id, updated_at=now()`,
+			inv.RepoID, inv.CommitID,
+		); err != nil {
+			return errors.Wrap(err, "INSERT repo_inventories")
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM repo_languages WHERE repo_id=$1`, inv.RepoID); err != nil {
+			return errors.Wrap(err, "DELETE repo_languages")
+		}
+
+		// Record the changed languages in the history. Languages that were
+		// removed are recorded with 0 lines and bytes.
+		changed := make(map[string]inventory.Lang, len(prev))
+		for _, l := range prev {
+			changed[l.Name] = inventory.Lang{Name: l.Name}
+		}
+		for _, l := range inv.Languages {
+			if l.Name == "" {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, `INSERT INTO repo_languages(repo_id, language, total_lines, total_bytes) VALUES($1, $2, $3, $4)`,
+				inv.RepoID, l.Name, l.TotalLines, l.TotalBytes,
+			); err != nil {
+				return errors.Wrap(err, "INSERT repo_languages")
+			}
+			changed[l.Name] = l
+		}
+		for _, l := range prev {
+			if changed[l.Name] == l {
+				delete(changed, l.Name)
+			}
+		}
+		for _, l := range changed {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO repo_language_history(repo_id, language, recorded_on, total_lines, total_bytes) VALUES($1, $2, current_date, $3, $4)
+				ON CONFLICT (repo_id, language, recorded_on) DO UPDATE SET total_lines=excluded.total_lines, total_bytes=excluded.total_bytes`,
+				inv.RepoID, l.Name, l.TotalLines, l.TotalBytes,
+			); err != nil {
+				return errors.Wrap(err, "INSERT repo_language_history")
+			}
+		}
+		return nil
+	})
+}
+
+// Sum returns the statistics of the languages of the repositories, summed over
+// all of them and ordered by lines of code. Repositories whose inventory
+// hasn't been computed yet are not included.
+func (s *repoInventories) Sum(ctx context.Context, repoIDs []api.RepoID) (langs []inventory.Lang, err error) {
+	if Mocks.RepoInventories.Sum != nil {
+		return Mocks.RepoInventories.Sum(ctx, repoIDs)
+	}
+
+	tr, ctx := trace.New(ctx, "db.RepoInventories.Sum", "")
+	defer func() {
+		tr.SetError(err)
+		tr.Finish()
+	}()
+
+	q := sqlf.Sprintf(`SELECT language, SUM(total_lines)::bigint, SUM(total_bytes)::bigint FROM repo_languages
+		WHERE repo_id = ANY(%s) GROUP BY language ORDER BY 2 DESC, language`,
+		repoIDsArray(repoIDs),
+	)
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	return scanLangs(rows)
+}
+
+// History returns the statistics of the language summed over the
+// repositories at the end of each day since the given time, oldest first.
+// Languages are matched case-insensitively.
+func (s *repoInventories) History(ctx context.Context, repoIDs []api.RepoID, language string, since time.Time) (points []*types.LanguageStatisticsPoint, err error) {
+	if Mocks.RepoInventories.History != nil {
+		return Mocks.RepoInventories.History(ctx, repoIDs, language, since)
+	}
+
+	tr, ctx := trace.New(ctx, "db.RepoInventories.History", "")
+	defer func() {
+		tr.SetError(err)
+		tr.Finish()
+	}()
+
+	// For each day, sum the most recent statistics of each repository as of
+	// that day.
+	q := sqlf.Sprintf(`SELECT d.day, COALESCE(SUM(h.total_lines), 0)::bigint, COALESCE(SUM(h.total_bytes), 0)::bigint
+		FROM generate_series(%s::date, current_date, interval '1 day') AS d(day)
+		LEFT JOIN LATERAL (
+			SELECT DISTINCT ON (repo_id) total_lines, total_bytes FROM repo_language_history
+			WHERE lower(language) = lower(%s) AND recorded_on <= d.day AND repo_id = ANY(%s)
+			ORDER BY repo_id, recorded_on DESC
+		) h ON true
+		GROUP BY d.day ORDER BY d.day`,
+		since.UTC(),
+		language,
+		repoIDsArray(repoIDs),
+	)
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p types.LanguageStatisticsPoint
+		if err := rows.Scan(&p.Date, &p.TotalLines, &p.TotalBytes); err != nil {
+			return nil, err
+		}
+		points = append(points, &p)
+	}
+	return points, rows.Err()
+}
+
+// FilterByLanguages returns the IDs of the repositories that contain code in
+// any of the languages. Languages are matched case-insensitively.
+func (s *repoInventories) FilterByLanguages(ctx context.Context, repoIDs []api.RepoID, languages []string) (ids []api.RepoID, err error) {
+	if Mocks.RepoInventories.FilterByLanguages != nil {
+		return Mocks.RepoInventories.FilterByLanguages(ctx, repoIDs, languages)
+	}
+
+	tr, ctx := trace.New(ctx, "db.RepoInventories.FilterByLanguages", "")
+	defer func() {
+		tr.SetError(err)
+		tr.Finish()
+	}()
+
+	lower := make([]string, len(languages))
+	for i, l := range languages {
+		lower[i] = strings.ToLower(l)
+	}
+	q := sqlf.Sprintf(`SELECT DISTINCT repo_id FROM repo_languages WHERE repo_id = ANY(%s) AND lower(language) = ANY(%s) ORDER BY repo_id`,
+		repoIDsArray(repoIDs),
+		pq.Array(lower),
+	)
+	return scanRepoIDs(ctx, q)
+}
+
+// PrimaryLanguages returns the language with the most lines of code of each
+// of the repositories whose inventory has been computed, by repository ID.
+func (s *repoInventories) PrimaryLanguages(ctx context.Context, repoIDs []api.RepoID) (languages map[api.RepoID]string, err error) {
+	if Mocks.RepoInventories.PrimaryLanguages != nil {
+		return Mocks.RepoInventories.PrimaryLanguages(ctx, repoIDs)
+	}
+
+	tr, ctx := trace.New(ctx, "db.RepoInventories.PrimaryLanguages", "")
+	defer func() {
+		tr.SetError(err)
+		tr.Finish()
+	}()
+
+	q := sqlf.Sprintf(`SELECT DISTINCT ON (repo_id) repo_id, language FROM repo_languages
+		WHERE repo_id = ANY(%s) ORDER BY repo_id, total_lines DESC, language`,
+		repoIDsArray(repoIDs),
+	)
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	languages = make(map[api.RepoID]string)
+	for rows.Next() {
+		var (
+			id   api.RepoID
+			lang string
+		)
+		if err := rows.Scan(&id, &lang); err != nil {
+			return nil, err
+		}
+		languages[id] = lang
+	}
+	return languages, rows.Err()
+}
+
+// repoIDsArray returns the repository IDs as a Postgres array.
+func repoIDsArray(ids []api.RepoID) interface{} {
+	a := make([]int32, len(ids))
+	for i, id := range ids {
+		a[i] = int32(id)
+	}
+	return pq.Array(a)
+}
+
+func scanLangs(rows *sql.Rows) ([]inventory.Lang, error) {
+	defer rows.Close()
+	var langs []inventory.Lang
+	for rows.Next() {
+		var l inventory.Lang
+		if err := rows.Scan(&l.Name, &l.TotalLines, &l.TotalBytes); err != nil {
+			return nil, err
+		}
+		langs = append(langs, l)
+	}
+	return langs, rows.Err()
+}
+
+func scanRepoIDs(ctx context.Context, q *sqlf.Query) ([]api.RepoID, error) {
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []api.RepoID
+	for rows.Next() {
+		var id api.RepoID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,19 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/inventory"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+type MockRepoInventories struct {
+	Get               func(ctx context.Context, repoID api.RepoID) (*types.RepoInventory, error)
+	Set               func(ctx context.Context, inv *types.RepoInventory) error
+	Sum               func(ctx context.Context, repoIDs []api.RepoID) ([]inventory.Lang, error)
+	History           func(ctx context.Context, repoIDs []api.RepoID, language string, since time.Time) ([]*types.LanguageStatisticsPoint, error)
+	FilterByLanguages func(ctx context.Context, repoIDs []api.RepoID, languages []string) ([]api.RepoID, error)
+	PrimaryLanguages  func(ctx context.Context, repoIDs []api.RepoID) (map[api.RepoID]string, error)
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/inventory"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbtesting"
+)
+
+func TestRepoInventories(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	dbtesting.SetupGlobalTestDB(t)
+	ctx := context.Background()
+
+	repos := mustCreate(ctx, t, &types.Repo{Name: "github.com/foo/a"}, &types.Repo{Name: "github.com/foo/b"})
+	a, b := repos[0].ID, repos[1].ID
+	ids := []api.RepoID{a, b}
+
+	if inv, err := RepoInventories.Get(ctx, a); err != nil || inv != nil {
+		t.Fatalf("got %+v, %v before the inventory was set, want nil", inv, err)
+	}
+
+	set := func(repoID api.RepoID, commitID api.CommitID, langs ...inventory.Lang) {
+		t.Helper()
+		if err := RepoInventories.Set(ctx, &types.RepoInventory{RepoID: repoID, CommitID: commitID, Languages: langs}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	set(a, "c1", inventory.Lang{Name: "Go", TotalLines: 10, TotalBytes: 100}, inventory.Lang{Name: "Python", TotalLines: 20, TotalBytes: 200})
+	set(b, "c1", inventory.Lang{Name: "Go", TotalLines: 5, TotalBytes: 50})
+	// Python was removed from a.
+	set(a, "c2", inventory.Lang{Name: "Go", TotalLines: 12, TotalBytes: 120}, inventory.Lang{Name: "Java", TotalLines: 1, TotalBytes: 10})
+
+	inv, err := RepoInventories.Get(ctx, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inv.CommitID != "c2" {
+		t.Errorf("got commit %q, want c2", inv.CommitID)
+	}
+	if want := []inventory.Lang{{Name: "Go", TotalLines: 12, TotalBytes: 120}, {Name: "Java", TotalLines: 1, TotalBytes: 10}}; !reflect.DeepEqual(inv.Languages, want) {
+		t.Errorf("got languages %+v, want %+v", inv.Languages, want)
+	}
+
+	sum, err := RepoInventories.Sum(ctx, ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []inventory.Lang{{Name: "Go", TotalLines: 17, TotalBytes: 170}, {Name: "Java", TotalLines: 1, TotalBytes: 10}}; !reflect.DeepEqual(sum, want) {
+		t.Errorf("got sum %+v, want %+v", sum, want)
+	}
+
+	filtered, err := RepoInventories.FilterByLanguages(ctx, ids, []string{"java"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []api.RepoID{a}; !reflect.DeepEqual(filtered, want) {
+		t.Errorf("got filtered repos %v, want %v", filtered, want)
+	}
+
+	primary, err := RepoInventories.PrimaryLanguages(ctx, ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[api.RepoID]string{a: "Go", b: "Go"}; !reflect.DeepEqual(primary, want) {
+		t.Errorf("got primary languages %v, want %v", primary, want)
+	}
+
+	// All changes happened today, so the history has a single point with
+	// the latest statistics.
+	for lang, want := range map[string]uint64{"go": 17, "Python": 0} {
+		points, err := RepoInventories.History(ctx, ids, lang, time.Now().Add(-24*time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(points) != 2 {
+			t.Fatalf("got %d points, want 2 (yesterday and today)", len(points))
+		}
+		if points[0].TotalLines != 0 || points[1].TotalLines != want {
+			t.Errorf("%s: got lines %d and %d, want 0 and %d", lang, points[0].TotalLines, points[1].TotalLines, want)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/keegancsmith/sqlf"
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/db/query"
@@ -263,6 +264,23 @@ type ReposListOptions struct {
 	// OnlyPrivate excludes non-private repositories from the list.
 	OnlyPrivate bool
 
+	// ExternalServiceID, if set, only includes repositories synced from the
+	// external service with this ID.
+	ExternalServiceID int64
+
+	// Topics, if set, only includes repositories with any of these GitHub
+	// topics.
+	Topics []string
+
+	// Names, if set, only includes repositories with any of these names.
+	Names []string
+
+	// CodeHostNamespace, if set, only includes repositories in this namespace
+	// on their code host (e.g., "github.com/sourcegraph"). Repositories are
+	// matched by their URI, which doesn't depend on the
+	// repositoryPathPattern of their external service.
+	CodeHostNamespace string
+
 	// OnlyRepoIDs skips fetching of RepoFields in each Repo.
 	OnlyRepoIDs bool
 
@@ -457,6 +475,24 @@ func (*repos) listSQL(opt ReposListOptions) (conds []*sqlf.Query, err error) {
 	if opt.OnlyPrivate {
 		conds = append(conds, sqlf.Sprintf("private"))
 	}
+	if opt.ExternalServiceID != 0 {
+		// The keys of sources are the URNs of the external services, which
+		// are of the form "extsvc:{kind}:{id}".
+		conds = append(conds, sqlf.Sprintf(`EXISTS (
+			SELECT 1 FROM external_services es
+			WHERE es.id=%d AND repo.sources ? ('extsvc:' || lower(es.kind) || ':' || es.id)
+		)`, opt.ExternalServiceID))
+	}
+	if len(opt.Topics) > 0 {
+		conds = append(conds, sqlf.Sprintf("metadata->'Topics' ?| %s", pq.Array(opt.Topics)))
+	}
+	if len(opt.Names) > 0 {
+		conds = append(conds, sqlf.Sprintf("name = ANY(%s::citext[])", pq.Array(opt.Names)))
+	}
+	if opt.CodeHostNamespace != "" {
+		ns := strings.ToLower(strings.Trim(opt.CodeHostNamespace, "/"))
+		conds = append(conds, sqlf.Sprintf("lower(uri) LIKE %s", likeEscaper.Replace(ns)+"/%"))
+	}
 
 	if opt.Index != nil {
 		// We don't currently have an index column, but when we want the
@@ -471,6 +507,9 @@ func (*repos) listSQL(opt ReposListOptions) (conds []*sqlf.Query, err error) {
 	return conds, nil
 }
 
+// likeEscaper escapes the wildcards of LIKE patterns.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 // parseIncludePattern either (1) parses the pattern into a list of exact possible
 // string values and LIKE patterns if such a list can be determined from the pattern,
 // and (2) returns the original regexp if those patterns are not equivalent to the
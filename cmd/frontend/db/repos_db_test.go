@@ -306,6 +306,74 @@ func TestRepos_List_fork(t *testing.T) {
 	}
 }
 
+func TestRepos_List_names(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	MockAuthzFilter = func(ctx context.Context, repos []*types.Repo, p authz.Perms) ([]*types.Repo, error) {
+		return repos, nil
+	}
+	defer func() { MockAuthzFilter = nil }()
+	dbtesting.SetupGlobalTestDB(t)
+	ctx := context.Background()
+	ctx = actor.WithActor(ctx, &actor.Actor{})
+
+	mine := mustCreate(ctx, t, &types.Repo{Name: "a/r"})
+	mustCreate(ctx, t, &types.Repo{Name: "b/r"})
+
+	repos, err := Repos.List(ctx, ReposListOptions{Names: []string{"A/R", "c/r"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSONEqual(t, mine, repos)
+}
+
+func TestRepos_List_codeHostNamespace(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	MockAuthzFilter = func(ctx context.Context, repos []*types.Repo, p authz.Perms) ([]*types.Repo, error) {
+		return repos, nil
+	}
+	defer func() { MockAuthzFilter = nil }()
+	dbtesting.SetupGlobalTestDB(t)
+	ctx := context.Background()
+	ctx = actor.WithActor(ctx, &actor.Actor{})
+
+	// The names of the repositories don't contain their namespace, like with
+	// a custom repositoryPathPattern.
+	for name, uri := range map[api.RepoName]string{
+		"a": "github.com/Org/a",
+		"b": "github.com/org/sub/b",
+		"c": "github.com/org-other/c",
+		"d": "gitlab.com/org/d",
+		"e": "github.com/o_g/e",
+	} {
+		createRepo(ctx, t, &types.Repo{Name: name})
+		q := sqlf.Sprintf("UPDATE repo SET uri=%s WHERE name=%s", uri, name)
+		if _, err := dbconn.Global.ExecContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for ns, want := range map[string][]api.RepoName{
+		"github.com/org":  {"a", "b"},
+		"github.com/org/": {"a", "b"},
+		"github.com/o_g":  {"e"},
+		"github.com/o%":   nil,
+	} {
+		repos, err := Repos.List(ctx, ReposListOptions{CodeHostNamespace: ns})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := sortedRepoNames(repos); !reflect.DeepEqual(got, want) {
+			t.Errorf("namespace %q: got %v, want %v", ns, got, want)
+		}
+	}
+}
+
 func TestRepos_List_pagination(t *testing.T) {
 	if testing.Short() {
 		t.Skip()
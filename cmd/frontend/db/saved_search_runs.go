@@ -0,0 +1,153 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/keegancsmith/sqlf"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/internal/trace"
+)
+
+// maxSavedSearchRuns is the number of runs that are retained per saved
+// search. Older runs are deleted when a new run is recorded.
+const maxSavedSearchRuns = 100
+
+type savedSearchRuns struct{}
+
+// Create records a run of a saved search and deletes the oldest runs of the
+// saved search beyond maxSavedSearchRuns.
+func (s *savedSearchRuns) Create(ctx context.Context, run *api.SavedQueryRun) (err error) {
+	if Mocks.SavedSearchRuns.Create != nil {
+		return Mocks.SavedSearchRuns.Create(ctx, run)
+	}
+
+	tr, ctx := trace.New(ctx, "db.SavedSearchRuns.Create", "")
+	defer func() {
+		tr.SetError(err)
+		tr.Finish()
+	}()
+
+	newMatches := run.NewMatches
+	if newMatches == nil {
+		newMatches = []api.SavedQueryMatch{}
+	}
+	newMatchesJSON, err := json.Marshal(newMatches)
+	if err != nil {
+		return err
+	}
+	matches := run.Matches
+	if matches == nil {
+		matches = []string{}
+	}
+
+	err = dbconn.Global.QueryRowContext(ctx, `INSERT INTO saved_search_runs(
+			saved_search_id,
+			started_at,
+			finished_at,
+			matches,
+			new_matches,
+			error
+		) VALUES($1, $2, $3, $4, $5, $6) RETURNING id`,
+		run.SavedSearchID,
+		run.StartedAt,
+		run.FinishedAt,
+		pq.Array(matches),
+		newMatchesJSON,
+		sql.NullString{String: run.Error, Valid: run.Error != ""},
+	).Scan(&run.ID)
+	if err != nil {
+		return errors.Wrap(err, "INSERT")
+	}
+
+	_, err = dbconn.Global.ExecContext(ctx, `DELETE FROM saved_search_runs WHERE saved_search_id=$1 AND id NOT IN (
+			SELECT id FROM saved_search_runs WHERE saved_search_id=$1 ORDER BY finished_at DESC, id DESC LIMIT $2
+		)`,
+		run.SavedSearchID,
+		maxSavedSearchRuns,
+	)
+	if err != nil {
+		return errors.Wrap(err, "DELETE")
+	}
+	return nil
+}
+
+// LastSuccessful returns the most recent run of the given saved search that
+// did not fail. nil is returned if there is no such run.
+func (s *savedSearchRuns) LastSuccessful(ctx context.Context, savedSearchID int32) (*api.SavedQueryRun, error) {
+	runs, err := s.list(ctx, sqlf.Sprintf("saved_search_id=%d AND error IS NULL", savedSearchID), &LimitOffset{Limit: 1})
+	if err != nil || len(runs) == 0 {
+		return nil, err
+	}
+	return runs[0], nil
+}
+
+// List lists the runs of the given saved search, most recent first.
+//
+// 🚨 SECURITY: This method does NOT verify the user's identity or that the
+// user is an admin. It is the callers responsibility to ensure that only users
+// with the proper permissions to access the saved search can access its runs.
+func (s *savedSearchRuns) List(ctx context.Context, savedSearchID int32, opt *LimitOffset) (runs []*api.SavedQueryRun, err error) {
+	if Mocks.SavedSearchRuns.List != nil {
+		return Mocks.SavedSearchRuns.List(ctx, savedSearchID, opt)
+	}
+
+	tr, ctx := trace.New(ctx, "db.SavedSearchRuns.List", "")
+	defer func() {
+		tr.SetError(err)
+		tr.Finish()
+	}()
+
+	return s.list(ctx, sqlf.Sprintf("saved_search_id=%d", savedSearchID), opt)
+}
+
+func (s *savedSearchRuns) list(ctx context.Context, cond *sqlf.Query, opt *LimitOffset) (runs []*api.SavedQueryRun, err error) {
+	q := sqlf.Sprintf(`SELECT
+		id,
+		saved_search_id,
+		started_at,
+		finished_at,
+		matches,
+		new_matches,
+		error
+		FROM saved_search_runs WHERE %s ORDER BY finished_at DESC, id DESC %s`,
+		cond,
+		opt.SQL(),
+	)
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, errors.Wrap(err, "QueryContext")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			run            api.SavedQueryRun
+			newMatchesJSON []byte
+			runErr         sql.NullString
+		)
+		if err := rows.Scan(&run.ID, &run.SavedSearchID, &run.StartedAt, &run.FinishedAt, pq.Array(&run.Matches), &newMatchesJSON, &runErr); err != nil {
+			return nil, errors.Wrap(err, "Scan")
+		}
+		if err := json.Unmarshal(newMatchesJSON, &run.NewMatches); err != nil {
+			return nil, errors.Wrap(err, "Unmarshal")
+		}
+		run.Error = runErr.String
+		runs = append(runs, &run)
+	}
+	return runs, rows.Err()
+}
+
+// Count counts the runs of the given saved search.
+func (s *savedSearchRuns) Count(ctx context.Context, savedSearchID int32) (count int, err error) {
+	if Mocks.SavedSearchRuns.Count != nil {
+		return Mocks.SavedSearchRuns.Count(ctx, savedSearchID)
+	}
+
+	err = dbconn.Global.QueryRowContext(ctx, `SELECT COUNT(*) FROM saved_search_runs WHERE saved_search_id=$1`, savedSearchID).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbtesting"
+)
+
+func TestSavedSearchRuns(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	dbtesting.SetupGlobalTestDB(t)
+	ctx := context.Background()
+	_, err := Users.Create(ctx, NewUser{DisplayName: "test", Email: "test@test.com", Username: "test", Password: "test", EmailVerificationCode: "c2"})
+	if err != nil {
+		t.Fatal("can't create user", err)
+	}
+	userID := int32(1)
+	ss, err := SavedSearches.Create(ctx, &types.SavedSearch{
+		Query:       "test",
+		Description: "test",
+		UserID:      &userID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	last, err := SavedSearchRuns.LastSuccessful(ctx, ss.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last != nil {
+		t.Fatalf("got last run %+v before any run, want nil", last)
+	}
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	for i := 0; i < maxSavedSearchRuns+2; i++ {
+		run := &api.SavedQueryRun{
+			SavedSearchID: ss.ID,
+			StartedAt:     now.Add(time.Duration(i) * time.Minute),
+			FinishedAt:    now.Add(time.Duration(i)*time.Minute + time.Second),
+			Matches:       []string{"github.com/foo/bar/a.go"},
+		}
+		if i == maxSavedSearchRuns+1 {
+			run.NewMatches = []api.SavedQueryMatch{{Key: "github.com/foo/bar/b.go", Type: "file", Repository: "github.com/foo/bar", Path: "b.go"}}
+		}
+		if err := SavedSearchRuns.Create(ctx, run); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	count, err := SavedSearchRuns.Count(ctx, ss.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != maxSavedSearchRuns {
+		t.Errorf("got %d runs, want %d", count, maxSavedSearchRuns)
+	}
+
+	last, err = SavedSearchRuns.LastSuccessful(ctx, ss.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &api.SavedQueryRun{
+		ID:            last.ID,
+		SavedSearchID: ss.ID,
+		StartedAt:     now.Add(time.Duration(maxSavedSearchRuns+1) * time.Minute),
+		FinishedAt:    now.Add(time.Duration(maxSavedSearchRuns+1)*time.Minute + time.Second),
+		Matches:       []string{"github.com/foo/bar/a.go"},
+		NewMatches:    []api.SavedQueryMatch{{Key: "github.com/foo/bar/b.go", Type: "file", Repository: "github.com/foo/bar", Path: "b.go"}},
+	}
+	last.StartedAt, last.FinishedAt = last.StartedAt.UTC(), last.FinishedAt.UTC()
+	if !reflect.DeepEqual(last, want) {
+		t.Errorf("got last run %+v, want %+v", last, want)
+	}
+
+	failed := &api.SavedQueryRun{
+		SavedSearchID: ss.ID,
+		StartedAt:     now.Add(time.Hour * 24),
+		FinishedAt:    now.Add(time.Hour * 24),
+		Error:         "timed out",
+	}
+	if err := SavedSearchRuns.Create(ctx, failed); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := SavedSearchRuns.LastSuccessful(ctx, ss.ID); err != nil {
+		t.Fatal(err)
+	} else if got.ID != last.ID {
+		t.Errorf("got last successful run %d, want %d", got.ID, last.ID)
+	}
+
+	runs, err := SavedSearchRuns.List(ctx, ss.ID, &LimitOffset{Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 2 || runs[0].ID != failed.ID || runs[0].Error != "timed out" || runs[1].ID != last.ID {
+		t.Errorf("got runs %+v, want the 2 most recent runs", runs)
+	}
+}
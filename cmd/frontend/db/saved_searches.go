@@ -56,7 +56,9 @@ func (s *savedSearches) ListAll(ctx context.Context) (savedSearches []api.SavedQ
 		notify_slack,
 		user_id,
 		org_id,
-		slack_webhook_url FROM saved_searches
+		slack_webhook_url,
+		webhook_url,
+		webhook_secret FROM saved_searches
 	`)
 	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar))
 	if err != nil {
@@ -73,7 +75,9 @@ func (s *savedSearches) ListAll(ctx context.Context) (savedSearches []api.SavedQ
 			&sq.Config.NotifySlack,
 			&sq.Config.UserID,
 			&sq.Config.OrgID,
-			&sq.Config.SlackWebhookURL); err != nil {
+			&sq.Config.SlackWebhookURL,
+			&sq.Config.WebhookURL,
+			&sq.Config.WebhookSecret); err != nil {
 			return nil, errors.Wrap(err, "Scan")
 		}
 		sq.Spec.Key = sq.Config.Key
@@ -106,7 +110,9 @@ func (s *savedSearches) GetByID(ctx context.Context, id int32) (*api.SavedQueryS
 		notify_slack,
 		user_id,
 		org_id,
-		slack_webhook_url
+		slack_webhook_url,
+		webhook_url,
+		webhook_secret
 		FROM saved_searches WHERE id=$1`, id).Scan(
 		&sq.Config.Key,
 		&sq.Config.Description,
@@ -115,7 +121,9 @@ func (s *savedSearches) GetByID(ctx context.Context, id int32) (*api.SavedQueryS
 		&sq.Config.NotifySlack,
 		&sq.Config.UserID,
 		&sq.Config.OrgID,
-		&sq.Config.SlackWebhookURL)
+		&sq.Config.SlackWebhookURL,
+		&sq.Config.WebhookURL,
+		&sq.Config.WebhookSecret)
 	if err != nil {
 		return nil, err
 	}
@@ -166,7 +174,9 @@ func (s *savedSearches) ListSavedSearchesByUserID(ctx context.Context, userID in
 		notify_slack,
 		user_id,
 		org_id,
-		slack_webhook_url
+		slack_webhook_url,
+		webhook_url,
+		webhook_secret
 		FROM saved_searches %v`, conds)
 
 	rows, err := dbconn.Global.QueryContext(ctx, query.Query(sqlf.PostgresBindVar), query.Args()...)
@@ -175,7 +185,7 @@ func (s *savedSearches) ListSavedSearchesByUserID(ctx context.Context, userID in
 	}
 	for rows.Next() {
 		var ss types.SavedSearch
-		if err := rows.Scan(&ss.ID, &ss.Description, &ss.Query, &ss.Notify, &ss.NotifySlack, &ss.UserID, &ss.OrgID, &ss.SlackWebhookURL); err != nil {
+		if err := rows.Scan(&ss.ID, &ss.Description, &ss.Query, &ss.Notify, &ss.NotifySlack, &ss.UserID, &ss.OrgID, &ss.SlackWebhookURL, &ss.WebhookURL, &ss.WebhookSecret); err != nil {
 			return nil, errors.Wrap(err, "Scan(2)")
 		}
 		savedSearches = append(savedSearches, &ss)
@@ -201,7 +211,9 @@ func (s *savedSearches) ListSavedSearchesByOrgID(ctx context.Context, orgID int3
 		notify_slack,
 		user_id,
 		org_id,
-		slack_webhook_url
+		slack_webhook_url,
+		webhook_url,
+		webhook_secret
 		FROM saved_searches %v`, conds)
 
 	rows, err := dbconn.Global.QueryContext(ctx, query.Query(sqlf.PostgresBindVar), query.Args()...)
@@ -210,7 +222,7 @@ func (s *savedSearches) ListSavedSearchesByOrgID(ctx context.Context, orgID int3
 	}
 	for rows.Next() {
 		var ss types.SavedSearch
-		if err := rows.Scan(&ss.ID, &ss.Description, &ss.Query, &ss.Notify, &ss.NotifySlack, &ss.UserID, &ss.OrgID, &ss.SlackWebhookURL); err != nil {
+		if err := rows.Scan(&ss.ID, &ss.Description, &ss.Query, &ss.Notify, &ss.NotifySlack, &ss.UserID, &ss.OrgID, &ss.SlackWebhookURL, &ss.WebhookURL, &ss.WebhookSecret); err != nil {
 			return nil, errors.Wrap(err, "Scan")
 		}
 		savedSearches = append(savedSearches, &ss)
@@ -240,12 +252,14 @@ func (s *savedSearches) Create(ctx context.Context, newSavedSearch *types.SavedS
 	}()
 
 	savedQuery = &types.SavedSearch{
-		Description: newSavedSearch.Description,
-		Query:       newSavedSearch.Query,
-		Notify:      newSavedSearch.Notify,
-		NotifySlack: newSavedSearch.NotifySlack,
-		UserID:      newSavedSearch.UserID,
-		OrgID:       newSavedSearch.OrgID,
+		Description:   newSavedSearch.Description,
+		Query:         newSavedSearch.Query,
+		Notify:        newSavedSearch.Notify,
+		NotifySlack:   newSavedSearch.NotifySlack,
+		UserID:        newSavedSearch.UserID,
+		OrgID:         newSavedSearch.OrgID,
+		WebhookURL:    newSavedSearch.WebhookURL,
+		WebhookSecret: newSavedSearch.WebhookSecret,
 	}
 
 	err = dbconn.Global.QueryRowContext(ctx, `INSERT INTO saved_searches(
@@ -254,14 +268,18 @@ func (s *savedSearches) Create(ctx context.Context, newSavedSearch *types.SavedS
 			notify_owner,
 			notify_slack,
 			user_id,
-			org_id
-		) VALUES($1, $2, $3, $4, $5, $6) RETURNING id`,
+			org_id,
+			webhook_url,
+			webhook_secret
+		) VALUES($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`,
 		newSavedSearch.Description,
 		newSavedSearch.Query,
 		newSavedSearch.Notify,
 		newSavedSearch.NotifySlack,
 		newSavedSearch.UserID,
 		newSavedSearch.OrgID,
+		newSavedSearch.WebhookURL,
+		newSavedSearch.WebhookSecret,
 	).Scan(&savedQuery.ID)
 	if err != nil {
 		return nil, err
@@ -293,6 +311,8 @@ func (s *savedSearches) Update(ctx context.Context, savedSearch *types.SavedSear
 		UserID:          savedSearch.UserID,
 		OrgID:           savedSearch.OrgID,
 		SlackWebhookURL: savedSearch.SlackWebhookURL,
+		WebhookURL:      savedSearch.WebhookURL,
+		WebhookSecret:   savedSearch.WebhookSecret,
 	}
 
 	fieldUpdates := []*sqlf.Query{
@@ -304,6 +324,8 @@ func (s *savedSearches) Update(ctx context.Context, savedSearch *types.SavedSear
 		sqlf.Sprintf("user_id=%v", savedSearch.UserID),
 		sqlf.Sprintf("org_id=%v", savedSearch.OrgID),
 		sqlf.Sprintf("slack_webhook_url=%v", savedSearch.SlackWebhookURL),
+		sqlf.Sprintf("webhook_url=%v", savedSearch.WebhookURL),
+		sqlf.Sprintf("webhook_secret=%v", savedSearch.WebhookSecret),
 	}
 
 	updateQuery := sqlf.Sprintf(`UPDATE saved_searches SET %s WHERE ID=%v RETURNING id`, sqlf.Join(fieldUpdates, ", "), savedSearch.ID)
@@ -15,3 +15,9 @@ type MockSavedSearches struct {
 	Delete                    func(ctx context.Context, id int32) error
 	GetByID                   func(ctx context.Context, id int32) (*api.SavedQuerySpecAndConfig, error)
 }
+
+type MockSavedSearchRuns struct {
+	Create func(ctx context.Context, run *api.SavedQueryRun) error
+	List   func(ctx context.Context, savedSearchID int32, opt *LimitOffset) ([]*api.SavedQueryRun, error)
+	Count  func(ctx context.Context, savedSearchID int32) (int, error)
+}
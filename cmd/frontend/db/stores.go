@@ -2,17 +2,21 @@ package db
 
 var (
 	AccessTokens              = &accessTokens{}
+	AuditLog                  = &auditLog{}
 	ExternalServices          = &ExternalServicesStore{}
 	DefaultRepos              = &defaultRepos{}
 	DiscussionThreads         = &discussionThreads{}
 	DiscussionComments        = &discussionComments{}
 	DiscussionMailReplyTokens = &discussionMailReplyTokens{}
 	Repos                     = &repos{}
+	RepoGroups                = &repoGroups{}
+	RepoInventories           = &repoInventories{}
 	Phabricator               = &phabricator{}
 	QueryRunnerState          = &queryRunnerState{}
 	Orgs                      = &orgs{}
 	OrgMembers                = &orgMembers{}
 	SavedSearches             = &savedSearches{}
+	SavedSearchRuns           = &savedSearchRuns{}
 	Settings                  = &settings{}
 	Users                     = &users{}
 	UserEmails                = &userEmails{}
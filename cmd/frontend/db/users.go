@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 	"unicode/utf8"
 
@@ -16,6 +17,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/actor"
 	"github.com/sourcegraph/sourcegraph/internal/conf"
 	"github.com/sourcegraph/sourcegraph/internal/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbutil"
 	"github.com/sourcegraph/sourcegraph/internal/db/globalstatedb"
 	"github.com/sourcegraph/sourcegraph/internal/errcode"
 	"github.com/sourcegraph/sourcegraph/internal/trace"
@@ -145,7 +147,14 @@ func (u *users) Create(ctx context.Context, info NewUser) (newUser *types.User,
 		err = tx.Commit()
 	}()
 
-	return u.create(ctx, tx, info)
+	newUser, err = u.create(ctx, tx, info)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordAudit(ctx, tx, "user.create", "user", strconv.Itoa(int(newUser.ID)), nil, newUser); err != nil {
+		return nil, err
+	}
+	return newUser, nil
 }
 
 // maxPasswordRunes is the maximum number of UTF-8 runes that a password can contain.
@@ -323,11 +332,13 @@ type UserUpdate struct {
 }
 
 // Update updates a user's profile information.
-func (u *users) Update(ctx context.Context, id int32, update UserUpdate) error {
+func (u *users) Update(ctx context.Context, id int32, update UserUpdate) (err error) {
 	if Mocks.Users.Update != nil {
 		return Mocks.Users.Update(id, update)
 	}
 
+	before, _ := u.GetByID(ctx, id)
+
 	tx, err := dbconn.Global.BeginTx(ctx, nil)
 	if err != nil {
 		return err
@@ -381,14 +392,20 @@ func (u *users) Update(ctx context.Context, id int32, update UserUpdate) error {
 	if nrows == 0 {
 		return userNotFoundErr{args: []interface{}{id}}
 	}
-	return nil
+	after, err := u.getOneBySQLWith(ctx, tx, "WHERE id=$1", id)
+	if err != nil {
+		return err
+	}
+	return recordAudit(ctx, tx, "user.update", "user", strconv.Itoa(int(id)), before, after)
 }
 
-func (u *users) Delete(ctx context.Context, id int32) error {
+func (u *users) Delete(ctx context.Context, id int32) (err error) {
 	if Mocks.Users.Delete != nil {
 		return Mocks.Users.Delete(ctx, id)
 	}
 
+	before, _ := u.GetByID(ctx, id)
+
 	// Wrap in transaction because we delete from multiple tables.
 	tx, err := dbconn.Global.BeginTx(ctx, nil)
 	if err != nil {
@@ -449,14 +466,16 @@ func (u *users) Delete(ctx context.Context, id int32) error {
 		return err
 	}
 
-	return nil
+	return recordAudit(ctx, tx, "user.delete", "user", strconv.Itoa(int(id)), before, nil)
 }
 
-func (u *users) HardDelete(ctx context.Context, id int32) error {
+func (u *users) HardDelete(ctx context.Context, id int32) (err error) {
 	if Mocks.Users.HardDelete != nil {
 		return Mocks.Users.HardDelete(ctx, id)
 	}
 
+	before, _ := u.GetByID(ctx, id)
+
 	// Wrap in transaction because we delete from multiple tables.
 	tx, err := dbconn.Global.BeginTx(ctx, nil)
 	if err != nil {
@@ -539,15 +558,24 @@ func (u *users) HardDelete(ctx context.Context, id int32) error {
 	if rows == 0 {
 		return userNotFoundErr{args: []interface{}{id}}
 	}
-	return nil
+	return recordAudit(ctx, tx, "user.hardDelete", "user", strconv.Itoa(int(id)), before, nil)
 }
 
 func (u *users) SetIsSiteAdmin(ctx context.Context, id int32, isSiteAdmin bool) error {
 	if Mocks.Users.SetIsSiteAdmin != nil {
 		return Mocks.Users.SetIsSiteAdmin(id, isSiteAdmin)
 	}
-	_, err := dbconn.Global.ExecContext(ctx, "UPDATE users SET site_admin=$1 WHERE id=$2", isSiteAdmin, id)
-	return err
+	before, _ := u.GetByID(ctx, id)
+	var beforeState interface{}
+	if before != nil {
+		beforeState = map[string]bool{"siteAdmin": before.SiteAdmin}
+	}
+	return dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "UPDATE users SET site_admin=$1 WHERE id=$2", isSiteAdmin, id); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, "user.setIsSiteAdmin", "user", strconv.Itoa(int(id)), beforeState, map[string]bool{"siteAdmin": isSiteAdmin})
+	})
 }
 
 // CheckAndDecrementInviteQuota should be called before the user (identified
@@ -714,7 +742,11 @@ func (*users) listSQL(opt UsersListOptions) (conds []*sqlf.Query) {
 }
 
 func (u *users) getOneBySQL(ctx context.Context, query string, args ...interface{}) (*types.User, error) {
-	users, err := u.getBySQL(ctx, query, args...)
+	return u.getOneBySQLWith(ctx, dbconn.Global, query, args...)
+}
+
+func (u *users) getOneBySQLWith(ctx context.Context, q queryable, query string, args ...interface{}) (*types.User, error) {
+	users, err := u.getBySQLWith(ctx, q, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -725,8 +757,13 @@ func (u *users) getOneBySQL(ctx context.Context, query string, args ...interface
 }
 
 // getBySQL returns users matching the SQL query, if any exist.
-func (*users) getBySQL(ctx context.Context, query string, args ...interface{}) ([]*types.User, error) {
-	rows, err := dbconn.Global.QueryContext(ctx, "SELECT u.id, u.username, u.display_name, u.avatar_url, u.created_at, u.updated_at, u.site_admin, u.passwd IS NOT NULL, u.tags FROM users u "+query, args...)
+func (u *users) getBySQL(ctx context.Context, query string, args ...interface{}) ([]*types.User, error) {
+	return u.getBySQLWith(ctx, dbconn.Global, query, args...)
+}
+
+// getBySQLWith is like getBySQL, but runs the query with q (e.g. a transaction).
+func (*users) getBySQLWith(ctx context.Context, q queryable, query string, args ...interface{}) ([]*types.User, error) {
+	rows, err := q.QueryContext(ctx, "SELECT u.id, u.username, u.display_name, u.avatar_url, u.created_at, u.updated_at, u.site_admin, u.passwd IS NOT NULL, u.tags FROM users u "+query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -7,9 +7,11 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"strconv"
 
 	"github.com/sourcegraph/sourcegraph/internal/db/dbconn"
 	"github.com/sourcegraph/sourcegraph/internal/db/dbtesting"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbutil"
 	"github.com/sourcegraph/sourcegraph/internal/randstring"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -76,7 +78,7 @@ func (u *users) SetPassword(ctx context.Context, id int32, resetCode string, new
 		return false, err
 	}
 	// 🚨 SECURITY: set the new password and clear the reset code and expiry so the same code can't be reused.
-	if _, err := dbconn.Global.ExecContext(ctx, "UPDATE users SET passwd_reset_code=NULL, passwd_reset_time=NULL, passwd=$1 WHERE id=$2", passwd, id); err != nil {
+	if err := u.setPasswordAndRecord(ctx, id, passwd, "user.resetPassword"); err != nil {
 		return false, err
 	}
 	return true, nil
@@ -112,10 +114,7 @@ func (u *users) UpdatePassword(ctx context.Context, id int32, oldPassword, newPa
 		return err
 	}
 	// 🚨 SECURITY: Set the new password
-	if _, err := dbconn.Global.ExecContext(ctx, "UPDATE users SET passwd_reset_code=NULL, passwd_reset_time=NULL, passwd=$1 WHERE id=$2", passwd, id); err != nil {
-		return err
-	}
-	return nil
+	return u.setPasswordAndRecord(ctx, id, passwd, "user.updatePassword")
 }
 
 // RandomizePasswordAndClearPasswordResetRateLimit overwrites a user's password with a hard-to-guess
@@ -132,8 +131,18 @@ func (u *users) RandomizePasswordAndClearPasswordResetRateLimit(ctx context.Cont
 	}
 	// 🚨 SECURITY: Set the new random password and clear the reset code/expiry, so the old code
 	// can't be reused, and so a new valid reset code can be generated afterward.
-	_, err = dbconn.Global.ExecContext(ctx, "UPDATE users SET passwd_reset_code=NULL, passwd_reset_time=NULL, passwd=$1 WHERE id=$2", passwd, id)
-	return err
+	return u.setPasswordAndRecord(ctx, id, passwd, "user.randomizePassword")
+}
+
+// setPasswordAndRecord sets a user's password and clears their password reset code and expiry,
+// recording the action in the audit log.
+func (*users) setPasswordAndRecord(ctx context.Context, id int32, passwd sql.NullString, action string) error {
+	return dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "UPDATE users SET passwd_reset_code=NULL, passwd_reset_time=NULL, passwd=$1 WHERE id=$2", passwd, id); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, action, "user", strconv.Itoa(int(id)), nil, nil)
+	})
 }
 
 func hashPassword(password string) (sql.NullString, error) {
@@ -15,6 +15,8 @@ type AuthzResolver interface {
 	AuthorizedUserRepositories(ctx context.Context, args *AuthorizedRepoArgs) (RepositoryConnectionResolver, error)
 	UsersWithPendingPermissions(ctx context.Context) ([]string, error)
 	AuthorizedUsers(ctx context.Context, args *RepoAuthorizedUserArgs) (UserConnectionResolver, error)
+	ScheduleUserPermissionsSync(ctx context.Context, args *UserPermsSyncArgs) (*EmptyResponse, error)
+	ScheduleRepositoryPermissionsSync(ctx context.Context, args *RepoPermsSyncArgs) (*EmptyResponse, error)
 }
 
 var authzInEnterprise = errors.New("authorization mutations and queries are only available in enterprise")
@@ -37,6 +39,14 @@ func (defaultAuthzResolver) AuthorizedUsers(ctx context.Context, args *RepoAutho
 	return nil, authzInEnterprise
 }
 
+func (defaultAuthzResolver) ScheduleUserPermissionsSync(ctx context.Context, args *UserPermsSyncArgs) (*EmptyResponse, error) {
+	return nil, authzInEnterprise
+}
+
+func (defaultAuthzResolver) ScheduleRepositoryPermissionsSync(ctx context.Context, args *RepoPermsSyncArgs) (*EmptyResponse, error) {
+	return nil, authzInEnterprise
+}
+
 type RepoPermsArgs struct {
 	Repository graphql.ID
 	BindIDs    []string
@@ -50,3 +60,11 @@ type AuthorizedRepoArgs struct {
 	First    int32
 	After    *string
 }
+
+type UserPermsSyncArgs struct {
+	User graphql.ID
+}
+
+type RepoPermsSyncArgs struct {
+	Repository graphql.ID
+}
@@ -45,6 +45,10 @@ type CreatePatchSetFromPatchesArgs struct {
 	Patches []PatchInput
 }
 
+type CreatePatchSetFromCodemodArgs struct {
+	Query string
+}
+
 type PatchInput struct {
 	Repository   graphql.ID
 	BaseRevision api.CommitID
@@ -110,6 +114,7 @@ type CampaignsResolver interface {
 	AddChangesetsToCampaign(ctx context.Context, args *AddChangesetsToCampaignArgs) (CampaignResolver, error)
 
 	CreatePatchSetFromPatches(ctx context.Context, args CreatePatchSetFromPatchesArgs) (PatchSetResolver, error)
+	CreatePatchSetFromCodemod(ctx context.Context, args CreatePatchSetFromCodemodArgs) (PatchSetResolver, error)
 	PatchSetByID(ctx context.Context, id graphql.ID) (PatchSetResolver, error)
 
 	PatchByID(ctx context.Context, id graphql.ID) (PatchResolver, error)
@@ -179,6 +184,10 @@ func (defaultCampaignsResolver) CreatePatchSetFromPatches(ctx context.Context, a
 	return nil, campaignsOnlyInEnterprise
 }
 
+func (defaultCampaignsResolver) CreatePatchSetFromCodemod(ctx context.Context, args CreatePatchSetFromCodemodArgs) (PatchSetResolver, error) {
+	return nil, campaignsOnlyInEnterprise
+}
+
 func (defaultCampaignsResolver) PatchSetByID(ctx context.Context, id graphql.ID) (PatchSetResolver, error) {
 	return nil, campaignsOnlyInEnterprise
 }
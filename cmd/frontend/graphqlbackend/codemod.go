@@ -5,21 +5,24 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 
-	"github.com/inconshreveable/log15"
 	"github.com/opentracing-contrib/go-stdlib/nethttp"
 	otlog "github.com/opentracing/opentracing-go/log"
 	"github.com/pkg/errors"
 	"github.com/sourcegraph/go-diff/diff"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/goroutine"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/env"
 	"github.com/sourcegraph/sourcegraph/internal/errcode"
-	"github.com/sourcegraph/sourcegraph/internal/lazyregexp"
 	"github.com/sourcegraph/sourcegraph/internal/search"
 	"github.com/sourcegraph/sourcegraph/internal/search/query"
 	"github.com/sourcegraph/sourcegraph/internal/trace"
@@ -34,10 +37,8 @@ type rawCodemodResult struct {
 }
 
 type args struct {
-	matchTemplate     string
-	rewriteTemplate   string
-	includeFileFilter string
-	excludeFileFilter string
+	matchTemplate   string
+	rewriteTemplate string
 }
 
 // codemodResultResolver is a resolver for the GraphQL type `CodemodResult`
@@ -120,27 +121,7 @@ func validateQuery(q query.QueryInfo) (*args, error) {
 		rewriteTemplate = replacementValues[0]
 	}
 
-	includeFileFilter, excludeFileFilter := q.RegexpPatterns(query.FieldFile)
-	var includeFileFilterText string
-	if len(includeFileFilter) > 0 {
-		includeFileFilterText = includeFileFilter[0]
-		// only file names or files with extensions in the following characterset are allowed
-		IsAlphanumericWithPeriod := lazyregexp.New(`^[a-zA-Z0-9_.]+$`).MatchString
-		if !IsAlphanumericWithPeriod(includeFileFilterText) {
-			return nil, errors.New("the 'file:' filter cannot contain regex when using the 'replace:' filter currently. Only alphanumeric characters or '.'")
-		}
-	}
-
-	var excludeFileFilterText string
-	if len(excludeFileFilter) > 0 {
-		excludeFileFilterText = excludeFileFilter[0]
-		IsAlphanumericWithPeriod := lazyregexp.New(`^[a-zA-Z_.]+$`).MatchString
-		if !IsAlphanumericWithPeriod(includeFileFilterText) {
-			return nil, errors.New("the '-file:' filter cannot contain regex when using the 'replace:' filter currently. Only alphanumeric characters or '.'")
-		}
-	}
-
-	return &args{matchTemplate, rewriteTemplate, includeFileFilterText, excludeFileFilterText}, nil
+	return &args{matchTemplate, rewriteTemplate}, nil
 }
 
 // Calls the codemod backend replacer service for a set of repository revisions.
@@ -150,7 +131,7 @@ func performCodemod(ctx context.Context, args *search.TextParameters) ([]SearchR
 		return nil, nil, err
 	}
 
-	title := fmt.Sprintf("pattern: %+v, replace: %+v, includeFileFilter: %+v, excludeFileFilter: %+v, numRepoRevs: %d", cmodArgs.matchTemplate, cmodArgs.rewriteTemplate, cmodArgs.includeFileFilter, cmodArgs.excludeFileFilter, len(args.Repos))
+	title := fmt.Sprintf("pattern: %+v, replace: %+v, includePatterns: %+v, excludePattern: %+v, languages: %+v, numRepoRevs: %d", cmodArgs.matchTemplate, cmodArgs.rewriteTemplate, args.PatternInfo.IncludePatterns, args.PatternInfo.ExcludePattern, args.PatternInfo.Languages, len(args.Repos))
 	tr, ctx := trace.New(ctx, "callCodemod", title)
 	defer func() {
 		tr.SetError(err)
@@ -164,14 +145,14 @@ func performCodemod(ctx context.Context, args *search.TextParameters) ([]SearchR
 		wg          sync.WaitGroup
 		mu          sync.Mutex
 		unflattened [][]codemodResultResolver
-		common      = &searchResultsCommon{}
+		common      = &searchResultsCommon{partial: make(map[api.RepoName]struct{})}
 	)
 	for _, repoRev := range args.Repos {
 		wg.Add(1)
 		repoRev := repoRev // shadow variable so it doesn't change while goroutine is running
 		goroutine.Go(func() {
 			defer wg.Done()
-			results, searchErr := callCodemodInRepo(ctx, repoRev, cmodArgs)
+			results, partial, searchErr := callCodemodInRepo(ctx, repoRev, cmodArgs, args.PatternInfo)
 			if ctx.Err() == context.Canceled {
 				// Our request has been canceled (either because another one of args.repos had a
 				// fatal error, or otherwise), so we can just ignore these results.
@@ -183,6 +164,10 @@ func performCodemod(ctx context.Context, args *search.TextParameters) ([]SearchR
 			}
 			mu.Lock()
 			defer mu.Unlock()
+			if partial {
+				// Some results could not be decoded.
+				common.partial[repoRev.Repo.Name] = struct{}{}
+			}
 			if fatalErr := handleRepoSearchResult(common, repoRev, false, repoTimedOut, searchErr); fatalErr != nil {
 				err = errors.Wrapf(searchErr, "failed to call codemod %s", repoRev)
 				cancel()
@@ -226,7 +211,10 @@ func toMatchResolver(fileURL string, raw *rawCodemodResult) ([]*searchResultMatc
 		nil
 }
 
-func callCodemodInRepo(ctx context.Context, repoRevs *search.RepositoryRevisions, args *args) (results []codemodResultResolver, err error) {
+// callCodemodInRepo calls the replacer service for a single repository
+// revision. If some of the results could not be decoded, they are skipped and
+// partial is true.
+func callCodemodInRepo(ctx context.Context, repoRevs *search.RepositoryRevisions, args *args, patternInfo *search.TextPatternInfo) (results []codemodResultResolver, partial bool, err error) {
 	tr, ctx := trace.New(ctx, "callCodemodInRepo", fmt.Sprintf("repoRevs: %v, pattern %+v, replace: %+v", repoRevs, args.matchTemplate, args.rewriteTemplate))
 	defer func() {
 		tr.LazyPrintf("%d results", len(results))
@@ -237,25 +225,33 @@ func callCodemodInRepo(ctx context.Context, repoRevs *search.RepositoryRevisions
 	// For performance, assume repo is cloned in gitserver and do not trigger a repo-updater lookup (this call fails if repo is not on gitserver).
 	commit, err := git.ResolveRevision(ctx, repoRevs.GitserverRepo(), nil, repoRevs.Revs[0].RevSpec, &git.ResolveRevisionOptions{NoEnsureRevision: true})
 	if err != nil {
-		return nil, errors.Wrap(err, "codemod repo lookup failed: it's possible that the repo is not cloned in gitserver. Try force a repo update another way.")
+		return nil, false, errors.Wrap(err, "codemod repo lookup failed: it's possible that the repo is not cloned in gitserver. Try force a repo update another way.")
 	}
 
 	u, err := url.Parse(ReplacerURL)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 	q := u.Query()
 	q.Set("repo", string(repoRevs.Repo.Name))
 	q.Set("commit", string(commit))
 	q.Set("matchtemplate", args.matchTemplate)
 	q.Set("rewritetemplate", args.rewriteTemplate)
-	q.Set("fileextension", args.includeFileFilter)
-	q.Set("directoryexclude", args.excludeFileFilter)
+	// The path patterns from file: and lang: filters are always regular
+	// expressions, like for searcher.
+	for _, p := range patternInfo.IncludePatterns {
+		q.Add("includepatterns", p)
+	}
+	q.Set("excludepattern", patternInfo.ExcludePattern)
+	q.Set("pathpatternsarecasesensitive", strconv.FormatBool(patternInfo.PathPatternsAreCaseSensitive))
+	for _, lang := range patternInfo.Languages {
+		q.Add("languages", lang)
+	}
 	u.RawQuery = q.Encode()
 
 	req, err := http.NewRequest("GET", u.String(), nil)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 	req = req.WithContext(ctx)
 
@@ -274,42 +270,26 @@ func callCodemodInRepo(ctx context.Context, repoRevs *search.RepositoryRevisions
 		if ctx.Err() != nil {
 			err = ctx.Err()
 		}
-		return nil, errors.Wrap(err, "codemod request failed")
+		return nil, false, errors.Wrap(err, "codemod request failed")
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
 		body, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
-			return nil, err
+			return nil, false, err
 		}
-		return nil, errors.WithStack(&searcherError{StatusCode: resp.StatusCode, Message: string(body)})
+		return nil, false, errors.WithStack(&searcherError{StatusCode: resp.StatusCode, Message: string(body)})
 	}
 
-	scanner := bufio.NewScanner(resp.Body)
-	// TODO(RVT): Remove buffer inefficiency introduced here. Results are
-	// line encoded JSON. Malformed JSON can happen, for extremely long
-	// lines. Unless we know where this and subsequent malformed lines end,
-	// we can't continue decoding the next one. As an inefficient but robust
-	// solution, we allow to buffer a very high maximum length line and can
-	// skip over very long malformed lines. It is set to 10 * 64K.
-	scanner.Buffer(make([]byte, 100), 10*bufio.MaxScanTokenSize)
-
-	for scanner.Scan() {
-		var raw *rawCodemodResult
-		b := scanner.Bytes()
-		if err := scanner.Err(); err != nil {
-			log15.Info(fmt.Sprintf("Skipping codemod scanner error (line too long?): %s", err.Error()))
-			continue
-		}
-		if err := json.Unmarshal(b, &raw); err != nil {
-			// skip on other decode errors (including e.g., empty
-			// responses if dependencies are not installed)
-			continue
-		}
+	raws, partial, err := readCodemodResults(resp.Body)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, raw := range raws {
 		fileURL := fileMatchURI(repoRevs.Repo.Name, repoRevs.Revs[0].RevSpec, raw.URI)
 		matches, err := toMatchResolver(fileURL, raw)
 		if err != nil {
-			return nil, err
+			return nil, false, err
 		}
 		results = append(results, codemodResultResolver{
 			commit: &GitCommitResolver{
@@ -324,5 +304,140 @@ func callCodemodInRepo(ctx context.Context, repoRevs *search.RepositoryRevisions
 		})
 	}
 
-	return results, nil
+	return results, partial, nil
+}
+
+// readCodemodResults reads the line encoded JSON results of the replacer
+// service from r. Lines that can't be decoded (e.g. empty responses if
+// dependencies are not installed) are skipped, and partial is true. Any other
+// error, including lines longer than the 640K we are willing to buffer, fails
+// the whole response.
+func readCodemodResults(r io.Reader) (raws []*rawCodemodResult, partial bool, err error) {
+	scanner := bufio.NewScanner(r)
+	// TODO(RVT): Remove buffer inefficiency introduced here. We allow to
+	// buffer a very high maximum length line, set to 10 * 64K.
+	scanner.Buffer(make([]byte, 100), 10*bufio.MaxScanTokenSize)
+
+	for scanner.Scan() {
+		var raw *rawCodemodResult
+		if err := json.Unmarshal(scanner.Bytes(), &raw); err != nil || raw == nil {
+			partial = true
+			continue
+		}
+		raws = append(raws, raw)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, false, errors.Wrap(err, "failed to read codemod results")
+	}
+	return raws, partial, nil
+}
+
+// CodemodPatch is the rewrite of a codemod search in a single repository.
+type CodemodPatch struct {
+	Repo *types.Repo
+
+	// BaseRevision is the commit that was searched.
+	BaseRevision api.CommitID
+
+	// BaseRef is the ref that resolved to BaseRevision (the default branch if
+	// no revision was specified).
+	BaseRef string
+
+	// Diff is a unified diff of the rewrites of all files, relative to the
+	// repository root.
+	Diff string
+}
+
+// CodemodPatches runs a search query with a replace: filter and returns its
+// rewrites as one patch per repository revision. Unlike the search results,
+// the patches must be complete, so it fails if not all repositories could be
+// searched.
+func CodemodPatches(ctx context.Context, queryString string) ([]*CodemodPatch, error) {
+	q, err := query.ParseAndCheck(queryString)
+	if err != nil {
+		return nil, err
+	}
+	if len(q.Values(query.FieldReplace)) == 0 {
+		return nil, errors.New("the query must contain a 'replace:' filter")
+	}
+
+	sr, err := NewSearchImplementer(&SearchArgs{Version: "V2", Query: queryString})
+	if err != nil {
+		return nil, err
+	}
+	results, err := sr.Results(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if results.alert != nil {
+		return nil, errors.Errorf("%s: %s", results.alert.title, results.alert.description)
+	}
+	if results.LimitHit() {
+		return nil, errors.New("the search hit the result limit: add a 'count:' filter to the query to raise it")
+	}
+	if n := len(results.timedout) + len(results.cloning) + len(results.missing); n > 0 {
+		return nil, errors.Errorf("%d repositories could not be searched (they timed out, are still being cloned or are missing): try again, or add a 'timeout:' filter to the query", n)
+	}
+	if n := len(results.partial); n > 0 {
+		return nil, errors.Errorf("%d repositories returned rewrites that could not be read", n)
+	}
+
+	type key struct {
+		repo api.RepoID
+		rev  GitObjectID
+	}
+	var (
+		patches []*CodemodPatch
+		byKey   = map[key]*CodemodPatch{}
+		diffs   = map[*CodemodPatch][]*codemodResultResolver{}
+	)
+	for _, result := range results.SearchResults {
+		r, ok := result.ToCodemodResult()
+		if !ok {
+			continue
+		}
+		k := key{repo: r.commit.repo.repo.ID, rev: r.commit.oid}
+		p, ok := byKey[k]
+		if !ok {
+			baseRef, err := codemodBaseRef(ctx, r.commit)
+			if err != nil {
+				return nil, err
+			}
+			p = &CodemodPatch{
+				Repo:         r.commit.repo.repo,
+				BaseRevision: api.CommitID(r.commit.oid),
+				BaseRef:      baseRef,
+			}
+			byKey[k] = p
+			patches = append(patches, p)
+		}
+		diffs[p] = append(diffs[p], r)
+	}
+
+	for _, p := range patches {
+		rs := diffs[p]
+		sort.Slice(rs, func(i, j int) bool { return rs[i].path < rs[j].path })
+		var b strings.Builder
+		for _, r := range rs {
+			b.WriteString(r.diff)
+		}
+		p.Diff = b.String()
+	}
+	sort.Slice(patches, func(i, j int) bool { return patches[i].Repo.Name < patches[j].Repo.Name })
+	return patches, nil
+}
+
+// codemodBaseRef returns the ref that was searched to find the commit.
+func codemodBaseRef(ctx context.Context, commit *GitCommitResolver) (string, error) {
+	if commit.inputRev != nil && *commit.inputRev != "" {
+		return git.EnsureRefPrefix(*commit.inputRev), nil
+	}
+	ref, err := commit.repo.DefaultBranch(ctx)
+	if err != nil {
+		return "", err
+	}
+	if ref == nil {
+		return "", errors.Errorf("repository %s has no default branch", commit.repo.Name())
+	}
+	return ref.Name(), nil
 }
@@ -1,9 +1,12 @@
 package graphqlbackend
 
 import (
+	"bufio"
+	"context"
 	"strings"
 	"testing"
 
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/internal/search/query"
 )
 
@@ -39,3 +42,50 @@ func TestCodemod_resolver(t *testing.T) {
 		t.Fatalf("Expected error %q", err)
 	}
 }
+
+func TestReadCodemodResults(t *testing.T) {
+	ok := `{"uri":"a.go","diff":"@@ -1 +1 @@"}` + "\n"
+
+	raws, partial, err := readCodemodResults(strings.NewReader(ok + ok))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(raws) != 2 || partial {
+		t.Errorf("got %d results (partial %v), want 2 complete results", len(raws), partial)
+	}
+
+	raws, partial, err = readCodemodResults(strings.NewReader(ok + "{not json\n" + ok))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(raws) != 2 || !partial {
+		t.Errorf("got %d results (partial %v), want 2 partial results", len(raws), partial)
+	}
+
+	tooLong := `{"uri":"a.go","diff":"` + strings.Repeat("x", 10*bufio.MaxScanTokenSize) + `"}` + "\n"
+	if _, _, err := readCodemodResults(strings.NewReader(ok + tooLong + ok)); err == nil {
+		t.Error("want error for a line that is too long")
+	}
+}
+
+func TestCodemodPatches_noReplace(t *testing.T) {
+	_, err := CodemodPatches(context.Background(), `"fmt.Sprintf(:[x])" lang:go`)
+	if err == nil || !strings.Contains(err.Error(), "replace:") {
+		t.Fatalf("got err %v, want error about the missing replace: filter", err)
+	}
+}
+
+func TestCodemod_baseRef(t *testing.T) {
+	rev := "my-branch"
+	commit := &GitCommitResolver{
+		repo:     &RepositoryResolver{repo: &types.Repo{Name: "github.com/foo/bar"}},
+		inputRev: &rev,
+	}
+	got, err := codemodBaseRef(context.Background(), commit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "refs/heads/my-branch"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -616,6 +616,29 @@ func (r *discussionThreadTargetRepoResolver) RelativeSelection(ctx context.Conte
 	return discussionSelectionRelativeTo(r.t, newContent), nil
 }
 
+// discussionThreadLocationResolver resolves the tracked location of the
+// selection of a repo target. The anchor fields of the target must be set.
+type discussionThreadLocationResolver struct {
+	t *types.DiscussionThreadTargetRepo
+}
+
+func (r *discussionThreadLocationResolver) Revision() GitObjectID {
+	return GitObjectID(*r.t.AnchorRevision)
+}
+
+func (r *discussionThreadLocationResolver) Path() string { return *r.t.AnchorPath }
+
+func (r *discussionThreadLocationResolver) Selection() *discussionSelectionRangeResolver {
+	sel := &discussionSelectionRangeResolver{
+		startLine: *r.t.AnchorStartLine,
+		endLine:   *r.t.AnchorEndLine,
+	}
+	if r.t.StartCharacter != nil && r.t.EndCharacter != nil {
+		sel.startCharacter, sel.endCharacter = *r.t.StartCharacter, *r.t.EndCharacter
+	}
+	return sel
+}
+
 type discussionThreadTargetResolver struct {
 	t *types.DiscussionThread
 }
@@ -687,6 +710,18 @@ func (d *discussionThreadResolver) InlineURL(ctx context.Context) (*string, erro
 	return strptr(url.String()), nil
 }
 
+func (d *discussionThreadResolver) Location() *discussionThreadLocationResolver {
+	tr := d.t.TargetRepo
+	if tr == nil || tr.AnchorRevision == nil || tr.AnchorPath == nil || tr.AnchorStartLine == nil || tr.AnchorEndLine == nil {
+		return nil
+	}
+	return &discussionThreadLocationResolver{t: tr}
+}
+
+func (d *discussionThreadResolver) Outdated() bool {
+	return d.t.TargetRepo != nil && d.t.TargetRepo.Outdated
+}
+
 func (d *discussionThreadResolver) CreatedAt() DateTime {
 	return DateTime{Time: d.t.CreatedAt}
 }
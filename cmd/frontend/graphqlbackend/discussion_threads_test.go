@@ -49,6 +49,59 @@ func TestDiscussionThread_Get(t *testing.T) {
 	})
 }
 
+func TestDiscussionThread_Location(t *testing.T) {
+	resetMocks()
+	mockViewerCanUseDiscussions = func() error { return nil }
+	defer func() { mockViewerCanUseDiscussions = nil }()
+
+	strptr := func(s string) *string { return &s }
+	i32 := func(i int32) *int32 { return &i }
+	db.Mocks.DiscussionThreads.Get = func(threadID int64) (*types.DiscussionThread, error) {
+		return &types.DiscussionThread{ID: threadID, TargetRepo: &types.DiscussionThreadTargetRepo{
+			Path:            strptr("a.go"),
+			StartLine:       i32(3),
+			EndLine:         i32(5),
+			StartCharacter:  i32(1),
+			EndCharacter:    i32(2),
+			AnchorRevision:  strptr("2222222222222222222222222222222222222222"),
+			AnchorPath:      strptr("b.go"),
+			AnchorStartLine: i32(10),
+			AnchorEndLine:   i32(12),
+			Outdated:        true,
+		}}, nil
+	}
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: mustParseGraphQLSchema(t),
+			Query: `
+				{
+					discussionThread(idWithoutKind: "123") {
+						outdated
+						location {
+							revision
+							path
+							selection { startLine startCharacter endLine endCharacter }
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"discussionThread": {
+						"outdated": true,
+						"location": {
+							"revision": "2222222222222222222222222222222222222222",
+							"path": "b.go",
+							"selection": { "startLine": 10, "startCharacter": 1, "endLine": 12, "endCharacter": 2 }
+						}
+					}
+				}
+			`,
+		},
+	})
+}
+
 func TestDiscussionSelectionRelativeTo(t *testing.T) {
 	i32 := func(i int32) *int32 {
 		return &i
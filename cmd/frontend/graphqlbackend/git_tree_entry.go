@@ -179,6 +179,22 @@ func reposourceCloneURLToRepoName(ctx context.Context, cloneURL string) (repoNam
 		repoSources = append(repoSources, reposource.AWS{AWSCodeCommitConnection: c})
 	}
 
+	gerrits, err := db.ExternalServices.ListGerritConnections(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, c := range gerrits {
+		repoSources = append(repoSources, reposource.Gerrit{GerritConnection: c})
+	}
+
+	giteas, err := db.ExternalServices.ListGiteaConnections(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, c := range giteas {
+		repoSources = append(repoSources, reposource.Gitea{GiteaConnection: c})
+	}
+
 	gitolites, err := db.ExternalServices.ListGitoliteConnections(ctx)
 	if err != nil {
 		return "", err
@@ -150,6 +150,7 @@ var whitelistedPrometheusFieldNames = [][2]string{
 	{"FileMatch", "lineMatches"},
 	{"FileMatch", "repository"},
 	{"FileMatch", "revSpec"},
+	{"FileMatch", "structuralMatches"},
 	{"FileMatch", "symbols"},
 	{"GitBlob", "blame"},
 	{"GitBlob", "commit"},
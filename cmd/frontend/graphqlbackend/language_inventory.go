@@ -0,0 +1,146 @@
+package graphqlbackend
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/inventory"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+func (r *RepositoryResolver) LanguageInventory(ctx context.Context) (*repositoryLanguageInventoryResolver, error) {
+	inv, err := db.RepoInventories.Get(ctx, r.repo.ID)
+	if err != nil || inv == nil {
+		return nil, err
+	}
+	return &repositoryLanguageInventoryResolver{inv: inv}, nil
+}
+
+type repositoryLanguageInventoryResolver struct {
+	inv *types.RepoInventory
+}
+
+func (r *repositoryLanguageInventoryResolver) CommitOID() GitObjectID {
+	return GitObjectID(r.inv.CommitID)
+}
+
+func (r *repositoryLanguageInventoryResolver) Languages() []*languageStatisticsResolver {
+	return toLanguageStatisticsResolvers(r.inv.Languages)
+}
+
+func (r *repositoryLanguageInventoryResolver) UpdatedAt() DateTime {
+	return DateTime{Time: r.inv.UpdatedAt}
+}
+
+func (o *OrgResolver) LanguageStatistics(ctx context.Context, args *struct {
+	Namespace string
+}) (*languageInventoryResolver, error) {
+	// 🚨 SECURITY: Only organization members and site admins may view the
+	// statistics of an organization.
+	if err := backend.CheckOrgAccess(ctx, o.org.ID); err != nil {
+		return nil, err
+	}
+
+	ns := strings.Trim(args.Namespace, "/")
+	if i := strings.Index(ns, "/"); i <= 0 || i == len(ns)-1 {
+		return nil, fmt.Errorf("invalid namespace %q: must be a namespace on a code host (e.g., github.com/sourcegraph)", args.Namespace)
+	}
+	return &languageInventoryResolver{opt: db.ReposListOptions{CodeHostNamespace: ns}}, nil
+}
+
+func (r *siteResolver) LanguageStatistics() *languageInventoryResolver {
+	return &languageInventoryResolver{}
+}
+
+// languageInventoryResolver resolves the statistics of the languages of the
+// repositories that match opt.
+type languageInventoryResolver struct {
+	opt db.ReposListOptions
+
+	once    sync.Once
+	repoIDs []api.RepoID
+	err     error
+}
+
+// compute lists the IDs of the repositories that the statistics are about.
+func (r *languageInventoryResolver) compute(ctx context.Context) ([]api.RepoID, error) {
+	r.once.Do(func() {
+		// 🚨 SECURITY: Repos.List only returns the repositories that the
+		// current user can access, so that the statistics don't include
+		// private repositories that they can't.
+		repos, err := db.Repos.List(ctx, r.opt)
+		if err != nil {
+			r.err = err
+			return
+		}
+		r.repoIDs = make([]api.RepoID, len(repos))
+		for i, repo := range repos {
+			r.repoIDs[i] = repo.ID
+		}
+	})
+	return r.repoIDs, r.err
+}
+
+func (r *languageInventoryResolver) Languages(ctx context.Context) ([]*languageStatisticsResolver, error) {
+	repoIDs, err := r.compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	langs, err := db.RepoInventories.Sum(ctx, repoIDs)
+	if err != nil {
+		return nil, err
+	}
+	return toLanguageStatisticsResolvers(langs), nil
+}
+
+func (r *languageInventoryResolver) History(ctx context.Context, args *struct {
+	Language string
+	Days     int32
+}) ([]*languageStatisticsPointResolver, error) {
+	days := args.Days
+	if days < 1 {
+		days = 1
+	}
+	if days > 365 {
+		days = 365
+	}
+
+	repoIDs, err := r.compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	since := time.Now().AddDate(0, 0, -int(days-1))
+	points, err := db.RepoInventories.History(ctx, repoIDs, args.Language, since)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*languageStatisticsPointResolver, len(points))
+	for i, p := range points {
+		resolvers[i] = &languageStatisticsPointResolver{p: p}
+	}
+	return resolvers, nil
+}
+
+type languageStatisticsPointResolver struct {
+	p *types.LanguageStatisticsPoint
+}
+
+func (r *languageStatisticsPointResolver) Date() DateTime { return DateTime{Time: r.p.Date} }
+
+func (r *languageStatisticsPointResolver) TotalLines() float64 { return float64(r.p.TotalLines) }
+
+func (r *languageStatisticsPointResolver) TotalBytes() float64 { return float64(r.p.TotalBytes) }
+
+func toLanguageStatisticsResolvers(langs []inventory.Lang) []*languageStatisticsResolver {
+	resolvers := make([]*languageStatisticsResolver, len(langs))
+	for i, l := range langs {
+		resolvers[i] = &languageStatisticsResolver{l: l}
+	}
+	return resolvers
+}
@@ -0,0 +1,189 @@
+package graphqlbackend
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/graph-gophers/graphql-go/gqltesting"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/inventory"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+)
+
+func TestRepository_LanguageInventory(t *testing.T) {
+	resetMocks()
+	backend.Mocks.Repos.GetByName = func(ctx context.Context, name api.RepoName) (*types.Repo, error) {
+		return &types.Repo{ID: 2, Name: name}, nil
+	}
+	db.Mocks.RepoInventories.Get = func(ctx context.Context, repoID api.RepoID) (*types.RepoInventory, error) {
+		if repoID != 2 {
+			return nil, nil
+		}
+		return &types.RepoInventory{
+			RepoID:    2,
+			CommitID:  "2222222222222222222222222222222222222222",
+			Languages: []inventory.Lang{{Name: "Go", TotalLines: 10, TotalBytes: 100}},
+			UpdatedAt: time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC),
+		}, nil
+	}
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: mustParseGraphQLSchema(t),
+			Query: `
+				{
+					repository(name: "github.com/gorilla/mux") {
+						languageInventory {
+							commitOID
+							languages { name totalLines totalBytes }
+							updatedAt
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"repository": {
+						"languageInventory": {
+							"commitOID": "2222222222222222222222222222222222222222",
+							"languages": [{ "name": "Go", "totalLines": 10, "totalBytes": 100 }],
+							"updatedAt": "2020-04-01T00:00:00Z"
+						}
+					}
+				}
+			`,
+		},
+	})
+}
+
+func TestSite_LanguageStatistics(t *testing.T) {
+	resetMocks()
+	db.Mocks.Repos.List = func(ctx context.Context, opt db.ReposListOptions) ([]*types.Repo, error) {
+		if !reflect.DeepEqual(opt, db.ReposListOptions{}) {
+			t.Errorf("got options %+v, want all repositories", opt)
+		}
+		return []*types.Repo{{ID: 1}, {ID: 2}}, nil
+	}
+	wantIDs := []api.RepoID{1, 2}
+	db.Mocks.RepoInventories.Sum = func(ctx context.Context, repoIDs []api.RepoID) ([]inventory.Lang, error) {
+		if !reflect.DeepEqual(repoIDs, wantIDs) {
+			t.Errorf("got repo IDs %v, want %v", repoIDs, wantIDs)
+		}
+		return []inventory.Lang{{Name: "Go", TotalLines: 30, TotalBytes: 300}, {Name: "Java", TotalLines: 5, TotalBytes: 50}}, nil
+	}
+	db.Mocks.RepoInventories.History = func(ctx context.Context, repoIDs []api.RepoID, language string, since time.Time) ([]*types.LanguageStatisticsPoint, error) {
+		if !reflect.DeepEqual(repoIDs, wantIDs) {
+			t.Errorf("got repo IDs %v, want %v", repoIDs, wantIDs)
+		}
+		if language != "Go" {
+			t.Errorf("got language %q, want Go", language)
+		}
+		if d := time.Since(since); d < 24*time.Hour || d > 48*time.Hour {
+			t.Errorf("got history since %s, want 1 day ago", since)
+		}
+		return []*types.LanguageStatisticsPoint{
+			{Date: time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC), TotalLines: 20, TotalBytes: 200},
+			{Date: time.Date(2020, 4, 2, 0, 0, 0, 0, time.UTC), TotalLines: 30, TotalBytes: 300},
+		}, nil
+	}
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: mustParseGraphQLSchema(t),
+			Query: `
+				{
+					site {
+						languageStatistics {
+							languages { name totalLines }
+							history(language: "Go", days: 2) { date totalLines totalBytes }
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"site": {
+						"languageStatistics": {
+							"languages": [{ "name": "Go", "totalLines": 30 }, { "name": "Java", "totalLines": 5 }],
+							"history": [
+								{ "date": "2020-04-01T00:00:00Z", "totalLines": 20, "totalBytes": 200 },
+								{ "date": "2020-04-02T00:00:00Z", "totalLines": 30, "totalBytes": 300 }
+							]
+						}
+					}
+				}
+			`,
+		},
+	})
+}
+
+func TestOrg_LanguageStatistics(t *testing.T) {
+	resetMocks()
+	db.Mocks.Orgs.GetByName = func(context.Context, string) (*types.Org, error) {
+		return &types.Org{ID: 1, Name: "acme"}, nil
+	}
+	db.Mocks.Users.GetByCurrentAuthUser = func(ctx context.Context) (*types.User, error) {
+		return &types.User{ID: actor.FromContext(ctx).UID}, nil
+	}
+	db.Mocks.OrgMembers.GetByOrgIDAndUserID = func(ctx context.Context, orgID, userID int32) (*types.OrgMembership, error) {
+		if orgID != 1 || userID != 1 {
+			return nil, &errcode.Mock{Message: "org member not found", IsNotFound: true}
+		}
+		return &types.OrgMembership{OrgID: orgID, UserID: userID}, nil
+	}
+	db.Mocks.Repos.List = func(ctx context.Context, opt db.ReposListOptions) ([]*types.Repo, error) {
+		if want := (db.ReposListOptions{CodeHostNamespace: "github.com/acme"}); !reflect.DeepEqual(opt, want) {
+			t.Errorf("got options %+v, want %+v", opt, want)
+		}
+		return []*types.Repo{{ID: 1}, {ID: 2}}, nil
+	}
+	db.Mocks.RepoInventories.Sum = func(ctx context.Context, repoIDs []api.RepoID) ([]inventory.Lang, error) {
+		if want := []api.RepoID{1, 2}; !reflect.DeepEqual(repoIDs, want) {
+			t.Errorf("got repo IDs %v, want %v", repoIDs, want)
+		}
+		return []inventory.Lang{{Name: "Go", TotalLines: 30, TotalBytes: 300}}, nil
+	}
+
+	member := actor.WithActor(context.Background(), &actor.Actor{UID: 1})
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Context: member,
+			Schema:  mustParseGraphQLSchema(t),
+			Query: `
+				{
+					organization(name: "acme") {
+						languageStatistics(namespace: "github.com/acme/") {
+							languages { name totalLines }
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"organization": {
+						"languageStatistics": {
+							"languages": [{ "name": "Go", "totalLines": 30 }]
+						}
+					}
+				}
+			`,
+		},
+	})
+
+	org := &OrgResolver{org: &types.Org{ID: 1, Name: "acme"}}
+	nonMember := actor.WithActor(context.Background(), &actor.Actor{UID: 2})
+	if _, err := org.LanguageStatistics(nonMember, &struct{ Namespace string }{"github.com/acme"}); err != backend.ErrNotAnOrgMember {
+		t.Errorf("got error %v for a non-member, want %v", err, backend.ErrNotAnOrgMember)
+	}
+	for _, ns := range []string{"acme", "github.com", "/acme/", ""} {
+		if _, err := org.LanguageStatistics(member, &struct{ Namespace string }{ns}); err == nil {
+			t.Errorf("got no error for invalid namespace %q", ns)
+		}
+	}
+}
@@ -2,35 +2,399 @@ package graphqlbackend
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
 	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/schema"
 )
 
-type repoGroup struct {
-	name         string
-	repositories []api.RepoName
+type repoGroupResolver struct {
+	// g is the repository group. Groups defined in the search.repositoryGroups
+	// setting have a zero ID and no owner.
+	g *types.RepoGroup
 }
 
-func (g repoGroup) Name() string { return g.name }
+func marshalRepoGroupID(id int32) graphql.ID {
+	return relay.MarshalID("RepoGroup", id)
+}
+
+func unmarshalRepoGroupID(id graphql.ID) (repoGroupID int32, err error) {
+	err = relay.UnmarshalSpec(id, &repoGroupID)
+	return
+}
+
+func (r *repoGroupResolver) ID() *graphql.ID {
+	if r.g.ID == 0 {
+		return nil
+	}
+	id := marshalRepoGroupID(r.g.ID)
+	return &id
+}
+
+func (r *repoGroupResolver) Name() string { return r.g.Name }
+
+func (r *repoGroupResolver) Description() string { return r.g.Description }
+
+func (r *repoGroupResolver) Namespace(ctx context.Context) (*NamespaceResolver, error) {
+	if r.g.UserID != nil {
+		n, err := UserByIDInt32(ctx, *r.g.UserID)
+		if err != nil {
+			return nil, err
+		}
+		return &NamespaceResolver{n}, nil
+	}
+	if r.g.OrgID != nil {
+		n, err := OrgByIDInt32(ctx, *r.g.OrgID)
+		if err != nil {
+			return nil, err
+		}
+		return &NamespaceResolver{n}, nil
+	}
+	return nil, nil
+}
+
+func (r *repoGroupResolver) Rule() *repoGroupRuleResolver {
+	if r.g.Rule == nil {
+		return nil
+	}
+	return &repoGroupRuleResolver{rule: r.g.Rule}
+}
+
+func (r *repoGroupResolver) Repositories(ctx context.Context) ([]string, error) {
+	repos, err := readableRepoGroupRepos(ctx, r.g.Repositories)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(repos))
+	for i, repo := range repos {
+		names[i] = string(repo.Name)
+	}
+	return names, nil
+}
+
+func (r *repoGroupResolver) EvaluatedAt() *DateTime {
+	if r.g.EvaluatedAt == nil {
+		return nil
+	}
+	return &DateTime{Time: *r.g.EvaluatedAt}
+}
+
+func (r *repoGroupResolver) ViewerCanAdminister(ctx context.Context) bool {
+	return r.g.ID != 0 && checkRepoGroupOwnerAccess(ctx, r.g.UserID, r.g.OrgID) == nil
+}
+
+type repoGroupRuleResolver struct {
+	rule *types.RepoGroupRule
+}
+
+func (r *repoGroupRuleResolver) RepoPattern() *string {
+	return nonEmptyString(r.rule.RepoPattern)
+}
+
+func (r *repoGroupRuleResolver) ExternalServiceID() *graphql.ID {
+	if r.rule.ExternalServiceID == 0 {
+		return nil
+	}
+	id := marshalExternalServiceID(r.rule.ExternalServiceID)
+	return &id
+}
+
+func (r *repoGroupRuleResolver) Topics() []string {
+	if r.rule.Topics == nil {
+		return []string{}
+	}
+	return r.rule.Topics
+}
+
+func (r *repoGroupRuleResolver) Languages() []string {
+	if r.rule.Languages == nil {
+		return []string{}
+	}
+	return r.rule.Languages
+}
+
+func (r *schemaResolver) RepoGroups(ctx context.Context) ([]*repoGroupResolver, error) {
+	groups, err := viewerRepoGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*repoGroupResolver, len(groups))
+	for i, g := range groups {
+		resolvers[i] = &repoGroupResolver{g: g}
+	}
+	return resolvers, nil
+}
+
+// viewerRepoGroups returns the repository groups of the current user: the
+// groups defined in the search.repositoryGroups setting, then the groups owned
+// by the organizations the user is a member of, then the groups owned by the
+// user. Later groups take precedence over earlier groups of the same name.
+func viewerRepoGroups(ctx context.Context) ([]*types.RepoGroup, error) {
+	var groups []*types.RepoGroup
+
+	// Repo groups can be defined in the search.repositoryGroups settings
+	// field, which predates repo groups stored in the DB.
+	merged, err := viewerFinalSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var settings schema.Settings
+	if err := json.Unmarshal([]byte(merged.Contents()), &settings); err != nil {
+		return nil, err
+	}
+	for name, repoPaths := range settings.SearchRepositoryGroups {
+		groups = append(groups, &types.RepoGroup{Name: name, Repositories: repoPaths})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+
+	a := actor.FromContext(ctx)
+	if !a.IsAuthenticated() {
+		return groups, nil
+	}
+	orgs, err := db.Orgs.GetByUserID(ctx, a.UID)
+	if err != nil {
+		return nil, err
+	}
+	opt := db.RepoGroupsListOptions{UserID: a.UID}
+	for _, org := range orgs {
+		opt.OrgIDs = append(opt.OrgIDs, org.ID)
+	}
+	owned, err := db.RepoGroups.List(ctx, opt)
+	if err != nil {
+		return nil, err
+	}
+	// Groups owned by the user come after those owned by organizations.
+	sort.SliceStable(owned, func(i, j int) bool { return owned[i].UserID == nil && owned[j].UserID != nil })
+	return append(groups, owned...), nil
+}
+
+var mockResolveRepoGroups func() (map[string][]*types.Repo, error)
+
+// resolveRepoGroups returns the repositories of the repository groups of the
+// current user by name, which is how they are referenced by repogroup:.
+func resolveRepoGroups(ctx context.Context) (map[string][]*types.Repo, error) {
+	if mockResolveRepoGroups != nil {
+		return mockResolveRepoGroups()
+	}
+
+	groups, err := viewerRepoGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-func (g repoGroup) Repositories() []string { return repoNamesToStrings(g.repositories) }
+	groupsByName := make(map[string][]*types.Repo, len(groups))
+	for _, g := range groups {
+		repos, err := readableRepoGroupRepos(ctx, g.Repositories)
+		if err != nil {
+			return nil, err
+		}
+		groupsByName[g.Name] = repos
+	}
+	return groupsByName, nil
+}
+
+// readableRepoGroupRepos returns the repositories with the given names that
+// the current user can read, in the given order.
+func readableRepoGroupRepos(ctx context.Context, names []string) ([]*types.Repo, error) {
+	repos := []*types.Repo{}
+	if len(names) == 0 {
+		return repos, nil
+	}
 
-func (r *schemaResolver) RepoGroups(ctx context.Context) ([]*repoGroup, error) {
-	groupsByName, err := resolveRepoGroups(ctx)
+	// 🚨 SECURITY: The rules of dynamic groups are evaluated as the internal
+	// actor, and explicit lists can name any repository. db.Repos.List only
+	// returns the repositories the current user can read, so that the names
+	// of private repositories don't leak.
+	readable, err := db.Repos.List(ctx, db.ReposListOptions{Names: names, OnlyRepoIDs: true})
 	if err != nil {
 		return nil, err
 	}
+	byName := make(map[string]*types.Repo, len(readable))
+	for _, repo := range readable {
+		byName[strings.ToLower(string(repo.Name))] = repo
+	}
+	for _, name := range names {
+		if repo, ok := byName[strings.ToLower(name)]; ok {
+			repos = append(repos, &types.Repo{ID: repo.ID, Name: api.RepoName(name)})
+		}
+	}
+	return repos, nil
+}
+
+// checkRepoGroupOwnerAccess returns an error if the current user can't create
+// or administer repository groups owned by the given user or organization.
+func checkRepoGroupOwnerAccess(ctx context.Context, userID, orgID *int32) error {
+	// 🚨 SECURITY: Only the owner, members of the owning organization and site
+	// admins can administer a repository group.
+	if userID != nil {
+		return backend.CheckSiteAdminOrSameUser(ctx, *userID)
+	}
+	if orgID != nil {
+		return backend.CheckOrgAccess(ctx, *orgID)
+	}
+	return errors.New("repository group has no owner")
+}
+
+type repoGroupRuleInput struct {
+	RepoPattern     *string
+	ExternalService *graphql.ID
+	Topics          *[]string
+	Languages       *[]string
+}
+
+type repoGroupArgs struct {
+	Name         string
+	Description  *string
+	Repositories *[]string
+	Rule         *repoGroupRuleInput
+}
+
+// apply validates the arguments and sets the corresponding fields of the
+// repository group. The rule of a dynamic group is evaluated.
+func (args *repoGroupArgs) apply(ctx context.Context, g *types.RepoGroup) error {
+	if (args.Repositories == nil) == (args.Rule == nil) {
+		return errors.New("exactly one of repositories and rule must be given")
+	}
+
+	g.Name = args.Name
+	g.Description = ""
+	if args.Description != nil {
+		g.Description = *args.Description
+	}
+
+	if args.Repositories != nil {
+		g.Rule = nil
+		g.Repositories = *args.Repositories
+		g.EvaluatedAt = nil
+		return nil
+	}
+
+	var rule types.RepoGroupRule
+	if args.Rule.RepoPattern != nil && *args.Rule.RepoPattern != "" {
+		if _, err := regexp.Compile(*args.Rule.RepoPattern); err != nil {
+			return err
+		}
+		rule.RepoPattern = *args.Rule.RepoPattern
+	}
+	if args.Rule.ExternalService != nil {
+		id, err := unmarshalExternalServiceID(*args.Rule.ExternalService)
+		if err != nil {
+			return err
+		}
+		rule.ExternalServiceID = id
+	}
+	if args.Rule.Topics != nil && len(*args.Rule.Topics) > 0 {
+		rule.Topics = *args.Rule.Topics
+	}
+	if args.Rule.Languages != nil && len(*args.Rule.Languages) > 0 {
+		rule.Languages = *args.Rule.Languages
+	}
+	if rule.RepoPattern == "" && rule.ExternalServiceID == 0 && len(rule.Topics) == 0 && len(rule.Languages) == 0 {
+		return errors.New("rule must have at least one condition")
+	}
 
-	groups := make([]*repoGroup, 0, len(groupsByName))
-	for name, repos := range groupsByName {
-		repoPaths := make([]api.RepoName, len(repos))
-		for i, repo := range repos {
-			repoPaths[i] = repo.Name
+	evaluatedAt := time.Now()
+	repos, err := backend.RepoGroups.EvaluateRule(ctx, rule)
+	if err != nil {
+		return err
+	}
+	g.Rule = &rule
+	g.Repositories = repos
+	g.EvaluatedAt = &evaluatedAt
+	return nil
+}
+
+func (r *schemaResolver) CreateRepoGroup(ctx context.Context, args *struct {
+	Namespace graphql.ID
+	repoGroupArgs
+}) (*repoGroupResolver, error) {
+	var g types.RepoGroup
+	switch relay.UnmarshalKind(args.Namespace) {
+	case "User":
+		userID, err := UnmarshalUserID(args.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		g.UserID = &userID
+	case "Org":
+		orgID, err := UnmarshalOrgID(args.Namespace)
+		if err != nil {
+			return nil, err
 		}
-		groups = append(groups, &repoGroup{
-			name:         name,
-			repositories: repoPaths,
-		})
+		g.OrgID = &orgID
+	default:
+		return nil, errors.New("invalid ID for namespace")
+	}
+	// 🚨 SECURITY: Make sure the current user has permission to create a repository group for the specified user or org.
+	if err := checkRepoGroupOwnerAccess(ctx, g.UserID, g.OrgID); err != nil {
+		return nil, err
+	}
+
+	if err := args.apply(ctx, &g); err != nil {
+		return nil, err
+	}
+	created, err := db.RepoGroups.Create(ctx, &g)
+	if err != nil {
+		return nil, err
+	}
+	return &repoGroupResolver{g: created}, nil
+}
+
+func (r *schemaResolver) UpdateRepoGroup(ctx context.Context, args *struct {
+	ID graphql.ID
+	repoGroupArgs
+}) (*repoGroupResolver, error) {
+	id, err := unmarshalRepoGroupID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	g, err := db.RepoGroups.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// 🚨 SECURITY: Make sure the current user has permission to update the repository group.
+	if err := checkRepoGroupOwnerAccess(ctx, g.UserID, g.OrgID); err != nil {
+		return nil, err
+	}
+
+	if err := args.apply(ctx, g); err != nil {
+		return nil, err
+	}
+	updated, err := db.RepoGroups.Update(ctx, g)
+	if err != nil {
+		return nil, err
+	}
+	return &repoGroupResolver{g: updated}, nil
+}
+
+func (r *schemaResolver) DeleteRepoGroup(ctx context.Context, args *struct {
+	ID graphql.ID
+}) (*EmptyResponse, error) {
+	id, err := unmarshalRepoGroupID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	g, err := db.RepoGroups.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// 🚨 SECURITY: Make sure the current user has permission to delete the repository group.
+	if err := checkRepoGroupOwnerAccess(ctx, g.UserID, g.OrgID); err != nil {
+		return nil, err
+	}
+	if err := db.RepoGroups.Delete(ctx, id); err != nil {
+		return nil, err
 	}
-	return groups, nil
+	return &EmptyResponse{}, nil
 }
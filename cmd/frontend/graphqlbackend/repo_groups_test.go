@@ -0,0 +1,178 @@
+package graphqlbackend
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/gqltesting"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+// mockReadableRepos mocks db.Repos.List to return the repositories with the
+// requested names, except those in private.
+func mockReadableRepos(private ...string) {
+	db.Mocks.Repos.List = func(ctx context.Context, opt db.ReposListOptions) ([]*types.Repo, error) {
+		var repos []*types.Repo
+		for i, name := range opt.Names {
+			isPrivate := false
+			for _, p := range private {
+				isPrivate = isPrivate || name == p
+			}
+			if !isPrivate {
+				repos = append(repos, &types.Repo{ID: api.RepoID(i + 1), Name: api.RepoName(name)})
+			}
+		}
+		return repos, nil
+	}
+}
+
+func TestResolveRepoGroups(t *testing.T) {
+	resetMocks()
+	defer resetMocks()
+	mockReadableRepos("github.com/a/private")
+
+	mockSettingsCascadeSubjects = func() ([]*settingsSubject, error) {
+		return []*settingsSubject{{site: singletonSiteResolver}}, nil
+	}
+	defer func() { mockSettingsCascadeSubjects = nil }()
+	db.Mocks.Settings.GetLatest = func(context.Context, api.SettingsSubject) (*api.Settings, error) {
+		return &api.Settings{ID: 1, Contents: `{"search.repositoryGroups": {"settings": ["github.com/a/settings"], "team": ["github.com/a/settings"]}}`}, nil
+	}
+
+	userID, orgID := int32(1), int32(2)
+	db.Mocks.Orgs.GetByUserID = func(ctx context.Context, id int32) ([]*types.Org, error) {
+		return []*types.Org{{ID: orgID}}, nil
+	}
+	db.Mocks.RepoGroups.List = func(ctx context.Context, opt db.RepoGroupsListOptions) ([]*types.RepoGroup, error) {
+		if want := (db.RepoGroupsListOptions{UserID: userID, OrgIDs: []int32{orgID}}); !reflect.DeepEqual(opt, want) {
+			t.Errorf("got options %+v, want %+v", opt, want)
+		}
+		return []*types.RepoGroup{
+			{ID: 1, Name: "team", UserID: &userID, Repositories: []string{"github.com/a/private", "github.com/a/user"}},
+			{ID: 2, Name: "team", OrgID: &orgID, Repositories: []string{"github.com/a/org"}},
+		}, nil
+	}
+
+	ctx := actor.WithActor(context.Background(), &actor.Actor{UID: userID})
+	groups, err := resolveRepoGroups(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Groups owned by the user take precedence over groups owned by orgs,
+	// which take precedence over groups defined in settings. Repositories the
+	// user can't read are left out.
+	want := map[string][]*types.Repo{
+		"settings": {{ID: 1, Name: "github.com/a/settings"}},
+		"team":     {{ID: 2, Name: "github.com/a/user"}},
+	}
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("got %+v, want %+v", groups, want)
+	}
+
+	// Unauthenticated users only have the groups defined in settings.
+	groups, err = resolveRepoGroups(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := groups["settings"]; len(groups) != 2 || !ok {
+		t.Errorf("got %+v, want groups defined in settings", groups)
+	}
+}
+
+func TestCreateRepoGroup(t *testing.T) {
+	resetMocks()
+	defer resetMocks()
+	mockReadableRepos("github.com/a/private")
+
+	db.Mocks.Users.GetByCurrentAuthUser = func(context.Context) (*types.User, error) {
+		return &types.User{ID: 1}, nil
+	}
+	db.Mocks.Users.GetByID = func(ctx context.Context, id int32) (*types.User, error) {
+		return &types.User{ID: id}, nil
+	}
+	backend.Mocks.RepoGroups.EvaluateRule = func(ctx context.Context, rule types.RepoGroupRule) ([]string, error) {
+		if want := (types.RepoGroupRule{RepoPattern: "^github\\.com/a/", Languages: []string{"Go"}}); !reflect.DeepEqual(rule, want) {
+			t.Errorf("got rule %+v, want %+v", rule, want)
+		}
+		return []string{"github.com/a/b", "github.com/a/private"}, nil
+	}
+	db.Mocks.RepoGroups.Create = func(ctx context.Context, g *types.RepoGroup) (*types.RepoGroup, error) {
+		if g.UserID == nil || *g.UserID != 1 || g.EvaluatedAt == nil {
+			t.Errorf("got %+v, want evaluated group owned by user 1", g)
+		}
+		// The stored evaluation includes all repositories.
+		if want := []string{"github.com/a/b", "github.com/a/private"}; !reflect.DeepEqual(g.Repositories, want) {
+			t.Errorf("got repositories %q, want %q", g.Repositories, want)
+		}
+		created := *g
+		created.ID = 1
+		return &created, nil
+	}
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Context: actor.WithActor(context.Background(), &actor.Actor{UID: 1}),
+			Schema:  mustParseGraphQLSchema(t),
+			Query: `
+				mutation {
+					createRepoGroup(namespace: "VXNlcjox", name: "go", rule: {repoPattern: "^github\\.com/a/", languages: ["Go"]}) {
+						id
+						name
+						rule {
+							repoPattern
+							topics
+							languages
+						}
+						repositories
+						viewerCanAdminister
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"createRepoGroup": {
+						"id": "UmVwb0dyb3VwOjE=",
+						"name": "go",
+						"rule": {
+							"repoPattern": "^github\\.com/a/",
+							"topics": [],
+							"languages": ["Go"]
+						},
+						"repositories": ["github.com/a/b"],
+						"viewerCanAdminister": true
+					}
+				}
+			`,
+		},
+	})
+
+	// Users can't create groups owned by other users.
+	_, err := (&schemaResolver{}).CreateRepoGroup(actor.WithActor(context.Background(), &actor.Actor{UID: 1}), &struct {
+		Namespace graphql.ID
+		repoGroupArgs
+	}{
+		Namespace:     MarshalUserID(2),
+		repoGroupArgs: repoGroupArgs{Name: "other", Repositories: &[]string{"github.com/a/b"}},
+	})
+	if err == nil {
+		t.Error("got no error creating group owned by other user")
+	}
+
+	// Groups are either explicit lists or defined by a rule.
+	_, err = (&schemaResolver{}).CreateRepoGroup(actor.WithActor(context.Background(), &actor.Actor{UID: 1}), &struct {
+		Namespace graphql.ID
+		repoGroupArgs
+	}{
+		Namespace:     MarshalUserID(1),
+		repoGroupArgs: repoGroupArgs{Name: "both", Repositories: &[]string{"github.com/a/b"}, Rule: &repoGroupRuleInput{}},
+	})
+	if err == nil {
+		t.Error("got no error creating group with repositories and rule")
+	}
+}
@@ -3,6 +3,7 @@ package graphqlbackend
 import (
 	"context"
 	"errors"
+	"strings"
 	"sync"
 
 	graphql "github.com/graph-gophers/graphql-go"
@@ -106,6 +107,18 @@ func (r *repositoryMirrorInfoResolver) CloneProgress(ctx context.Context) (*stri
 	return strptr(info.CloneProgress), nil
 }
 
+func (r *repositoryMirrorInfoResolver) CloneMode(ctx context.Context) (*string, error) {
+	info, err := r.gitserverRepoInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if info.CloneMode == "" {
+		return nil, nil
+	}
+	mode := strings.ToUpper(string(info.CloneMode))
+	return &mode, nil
+}
+
 func (r *repositoryMirrorInfoResolver) UpdatedAt(ctx context.Context) (*DateTime, error) {
 	info, err := r.gitserverRepoInfo(ctx)
 	if err != nil {
@@ -0,0 +1,172 @@
+package graphqlbackend
+
+import (
+	"context"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend/graphqlutil"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+)
+
+func (r savedSearchResolver) Runs(ctx context.Context, args *struct {
+	graphqlutil.ConnectionArgs
+}) *savedSearchRunConnectionResolver {
+	// 🚨 SECURITY: A savedSearchResolver is only constructed after checking
+	// that the current user has access to the saved search.
+	var opt *db.LimitOffset
+	args.ConnectionArgs.Set(&opt)
+	return &savedSearchRunConnectionResolver{savedSearchID: r.s.ID, opt: opt}
+}
+
+type savedSearchRunConnectionResolver struct {
+	savedSearchID int32
+	opt           *db.LimitOffset
+}
+
+func (r *savedSearchRunConnectionResolver) Nodes(ctx context.Context) ([]*savedSearchRunResolver, error) {
+	runs, err := db.SavedSearchRuns.List(ctx, r.savedSearchID, r.opt)
+	if err != nil {
+		return nil, err
+	}
+
+	resolvers := make([]*savedSearchRunResolver, 0, len(runs))
+	for _, run := range runs {
+		resolvers = append(resolvers, &savedSearchRunResolver{run: run})
+	}
+	return resolvers, nil
+}
+
+func (r *savedSearchRunConnectionResolver) TotalCount(ctx context.Context) (int32, error) {
+	count, err := db.SavedSearchRuns.Count(ctx, r.savedSearchID)
+	return int32(count), err
+}
+
+func (r *savedSearchRunConnectionResolver) PageInfo(ctx context.Context) (*graphqlutil.PageInfo, error) {
+	count, err := db.SavedSearchRuns.Count(ctx, r.savedSearchID)
+	if err != nil {
+		return nil, err
+	}
+	return graphqlutil.HasNextPage(r.opt != nil && count > r.opt.Limit), nil
+}
+
+type savedSearchRunResolver struct {
+	run *api.SavedQueryRun
+}
+
+func (r *savedSearchRunResolver) StartedAt() DateTime { return DateTime{Time: r.run.StartedAt} }
+
+func (r *savedSearchRunResolver) FinishedAt() DateTime { return DateTime{Time: r.run.FinishedAt} }
+
+// MatchCount returns the number of matches found by the run in repositories
+// that are visible to the current user.
+func (r *savedSearchRunResolver) MatchCount(ctx context.Context) (int32, error) {
+	if len(r.run.Matches) == 0 {
+		return 0, nil
+	}
+
+	// 🚨 SECURITY: Like NewMatches, the matches of older runs may be in
+	// repositories the current user can't read, which must not be counted.
+	// Only the keys of the matches are stored, so we look up every repository
+	// name a key may start with and count it if one of them is readable.
+	var names []string
+	seen := map[string]bool{}
+	for _, key := range r.run.Matches {
+		for _, name := range matchKeyRepos(key) {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+
+	repos, err := db.Repos.List(ctx, db.ReposListOptions{Names: names})
+	if err != nil {
+		return 0, err
+	}
+	readable := make(map[string]bool, len(repos))
+	for _, repo := range repos {
+		readable[string(repo.Name)] = true
+	}
+
+	var count int32
+	for _, key := range r.run.Matches {
+		for _, name := range matchKeyRepos(key) {
+			if readable[name] {
+				count++
+				break
+			}
+		}
+	}
+	return count, nil
+}
+
+// matchKeyRepos returns the repository names the given match key may start
+// with. Keys of file matches are "repo/path", and keys of commit matches are
+// "repo@commit".
+func matchKeyRepos(key string) []string {
+	var names []string
+	if i := strings.LastIndexByte(key, '@'); i > 0 {
+		names = append(names, key[:i])
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] == '/' && i > 0 {
+			names = append(names, key[:i])
+		}
+	}
+	return names
+}
+
+func (r *savedSearchRunResolver) NewMatches(ctx context.Context) ([]*savedSearchMatchResolver, error) {
+	// 🚨 SECURITY: Runs recorded before the query runner filtered matches by
+	// the saved search owner's permissions may contain matches in repositories
+	// the current user can't read, so only return matches in repositories that
+	// are visible to the current user.
+	readable := map[api.RepoName]bool{}
+	matches := make([]*savedSearchMatchResolver, 0, len(r.run.NewMatches))
+	for _, m := range r.run.NewMatches {
+		name := api.RepoName(m.Repository)
+		ok, seen := readable[name]
+		if !seen {
+			_, err := db.Repos.GetByName(ctx, name)
+			if err != nil && !errcode.IsNotFound(err) {
+				return nil, err
+			}
+			ok = err == nil
+			readable[name] = ok
+		}
+		if ok {
+			matches = append(matches, &savedSearchMatchResolver{m: m})
+		}
+	}
+	return matches, nil
+}
+
+func (r *savedSearchRunResolver) Error() *string {
+	if r.run.Error == "" {
+		return nil
+	}
+	return &r.run.Error
+}
+
+type savedSearchMatchResolver struct {
+	m api.SavedQueryMatch
+}
+
+func (r *savedSearchMatchResolver) Type() string { return r.m.Type }
+
+func (r *savedSearchMatchResolver) Repository() string { return r.m.Repository }
+
+func (r *savedSearchMatchResolver) Path() *string { return nonEmptyString(r.m.Path) }
+
+func (r *savedSearchMatchResolver) Commit() *string { return nonEmptyString(r.m.Commit) }
+
+func (r *savedSearchMatchResolver) URL() string { return r.m.URL }
+
+func nonEmptyString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -3,6 +3,8 @@ package graphqlbackend
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/url"
 
 	graphql "github.com/graph-gophers/graphql-go"
 	"github.com/graph-gophers/graphql-go/relay"
@@ -59,6 +61,8 @@ func savedSearchByID(ctx context.Context, id graphql.ID) (*savedSearchResolver,
 			UserID:          ss.Config.UserID,
 			OrgID:           ss.Config.OrgID,
 			SlackWebhookURL: ss.Config.SlackWebhookURL,
+			WebhookURL:      ss.Config.WebhookURL,
+			WebhookSecret:   ss.Config.WebhookSecret,
 		},
 	}
 	return savedSearch, nil
@@ -97,6 +101,8 @@ func (r savedSearchResolver) OrgID() *graphql.ID {
 }
 func (r savedSearchResolver) SlackWebhookURL() *string { return r.s.SlackWebhookURL }
 
+func (r savedSearchResolver) WebhookURL() *string { return r.s.WebhookURL }
+
 func toSavedSearchResolver(entry types.SavedSearch) *savedSearchResolver {
 	return &savedSearchResolver{entry}
 }
@@ -142,12 +148,14 @@ func (r *schemaResolver) SendSavedSearchTestNotification(ctx context.Context, ar
 }
 
 func (r *schemaResolver) CreateSavedSearch(ctx context.Context, args *struct {
-	Description string
-	Query       string
-	NotifyOwner bool
-	NotifySlack bool
-	OrgID       *graphql.ID
-	UserID      *graphql.ID
+	Description   string
+	Query         string
+	NotifyOwner   bool
+	NotifySlack   bool
+	OrgID         *graphql.ID
+	UserID        *graphql.ID
+	WebhookURL    *string
+	WebhookSecret *string
 }) (*savedSearchResolver, error) {
 	var userID, orgID *int32
 	// 🚨 SECURITY: Make sure the current user has permission to create a saved search for the specified user or org.
@@ -177,13 +185,19 @@ func (r *schemaResolver) CreateSavedSearch(ctx context.Context, args *struct {
 		return nil, errMissingPatternType
 	}
 
+	if err := validateWebhookURL(args.WebhookURL); err != nil {
+		return nil, err
+	}
+
 	ss, err := db.SavedSearches.Create(ctx, &types.SavedSearch{
-		Description: args.Description,
-		Query:       args.Query,
-		Notify:      args.NotifyOwner,
-		NotifySlack: args.NotifySlack,
-		UserID:      userID,
-		OrgID:       orgID,
+		Description:   args.Description,
+		Query:         args.Query,
+		Notify:        args.NotifyOwner,
+		NotifySlack:   args.NotifySlack,
+		UserID:        userID,
+		OrgID:         orgID,
+		WebhookURL:    nonEmptyStringPtr(args.WebhookURL),
+		WebhookSecret: nonEmptyStringPtr(args.WebhookSecret),
 	})
 	if err != nil {
 		return nil, err
@@ -193,13 +207,15 @@ func (r *schemaResolver) CreateSavedSearch(ctx context.Context, args *struct {
 }
 
 func (r *schemaResolver) UpdateSavedSearch(ctx context.Context, args *struct {
-	ID          graphql.ID
-	Description string
-	Query       string
-	NotifyOwner bool
-	NotifySlack bool
-	OrgID       *graphql.ID
-	UserID      *graphql.ID
+	ID            graphql.ID
+	Description   string
+	Query         string
+	NotifyOwner   bool
+	NotifySlack   bool
+	OrgID         *graphql.ID
+	UserID        *graphql.ID
+	WebhookURL    *string
+	WebhookSecret *string
 }) (*savedSearchResolver, error) {
 	var userID, orgID *int32
 	// 🚨 SECURITY: Make sure the current user has permission to update a saved search for the specified user or org.
@@ -234,14 +250,32 @@ func (r *schemaResolver) UpdateSavedSearch(ctx context.Context, args *struct {
 		return nil, errMissingPatternType
 	}
 
+	if err := validateWebhookURL(args.WebhookURL); err != nil {
+		return nil, err
+	}
+
+	webhookURL, webhookSecret := nonEmptyStringPtr(args.WebhookURL), nonEmptyStringPtr(args.WebhookSecret)
+	if webhookURL == nil {
+		webhookSecret = nil
+	} else if args.WebhookSecret == nil {
+		// Keep the existing secret, which is never returned to clients.
+		old, err := db.SavedSearches.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		webhookSecret = old.Config.WebhookSecret
+	}
+
 	ss, err := db.SavedSearches.Update(ctx, &types.SavedSearch{
-		ID:          id,
-		Description: args.Description,
-		Query:       args.Query,
-		Notify:      args.NotifyOwner,
-		NotifySlack: args.NotifySlack,
-		UserID:      userID,
-		OrgID:       orgID,
+		ID:            id,
+		Description:   args.Description,
+		Query:         args.Query,
+		Notify:        args.NotifyOwner,
+		NotifySlack:   args.NotifySlack,
+		UserID:        userID,
+		OrgID:         orgID,
+		WebhookURL:    webhookURL,
+		WebhookSecret: webhookSecret,
 	})
 	if err != nil {
 		return nil, err
@@ -287,3 +321,23 @@ func queryHasPatternType(query string) bool {
 }
 
 var errMissingPatternType error = errors.New("a `patternType:` filter is required in the query for all saved searches. `patternType` can be \"literal\" or \"regexp\"")
+
+// validateWebhookURL returns an error if webhookURL is set and is not an
+// absolute HTTP(S) URL.
+func validateWebhookURL(webhookURL *string) error {
+	if webhookURL == nil || *webhookURL == "" {
+		return nil
+	}
+	u, err := url.Parse(*webhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid webhook URL %q: must be an absolute http or https URL", *webhookURL)
+	}
+	return nil
+}
+
+func nonEmptyStringPtr(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	return nonEmptyString(*s)
+}
@@ -4,11 +4,14 @@ import (
 	"context"
 	"reflect"
 	"testing"
+	"time"
 
 	graphql "github.com/graph-gophers/graphql-go"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend/graphqlutil"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
 )
 
 func TestSavedSearches(t *testing.T) {
@@ -61,12 +64,14 @@ func TestCreateSavedSearch(t *testing.T) {
 	}
 	userID := MarshalUserID(key)
 	savedSearches, err := (&schemaResolver{}).CreateSavedSearch(ctx, &struct {
-		Description string
-		Query       string
-		NotifyOwner bool
-		NotifySlack bool
-		OrgID       *graphql.ID
-		UserID      *graphql.ID
+		Description   string
+		Query         string
+		NotifyOwner   bool
+		NotifySlack   bool
+		OrgID         *graphql.ID
+		UserID        *graphql.ID
+		WebhookURL    *string
+		WebhookSecret *string
 	}{Description: "test query", Query: "test type:diff patternType:regexp", NotifyOwner: true, NotifySlack: false, OrgID: nil, UserID: &userID})
 	if err != nil {
 		t.Fatal(err)
@@ -91,12 +96,14 @@ func TestCreateSavedSearch(t *testing.T) {
 
 	// Ensure create saved search errors when patternType is not provided in the query.
 	_, err = (&schemaResolver{}).CreateSavedSearch(ctx, &struct {
-		Description string
-		Query       string
-		NotifyOwner bool
-		NotifySlack bool
-		OrgID       *graphql.ID
-		UserID      *graphql.ID
+		Description   string
+		Query         string
+		NotifyOwner   bool
+		NotifySlack   bool
+		OrgID         *graphql.ID
+		UserID        *graphql.ID
+		WebhookURL    *string
+		WebhookSecret *string
 	}{Description: "test query", Query: "test type:diff", NotifyOwner: true, NotifySlack: false, OrgID: nil, UserID: &userID})
 	if err == nil {
 		t.Error("Expected error for createSavedSearch when query does not provide a patternType: field.")
@@ -119,13 +126,15 @@ func TestUpdateSavedSearch(t *testing.T) {
 	}
 	userID := MarshalUserID(key)
 	savedSearches, err := (&schemaResolver{}).UpdateSavedSearch(ctx, &struct {
-		ID          graphql.ID
-		Description string
-		Query       string
-		NotifyOwner bool
-		NotifySlack bool
-		OrgID       *graphql.ID
-		UserID      *graphql.ID
+		ID            graphql.ID
+		Description   string
+		Query         string
+		NotifyOwner   bool
+		NotifySlack   bool
+		OrgID         *graphql.ID
+		UserID        *graphql.ID
+		WebhookURL    *string
+		WebhookSecret *string
 	}{ID: marshalSavedSearchID(key), Description: "updated query description", Query: "test type:diff patternType:regexp", NotifyOwner: true, NotifySlack: false, OrgID: nil, UserID: &userID})
 	if err != nil {
 		t.Fatal(err)
@@ -151,13 +160,15 @@ func TestUpdateSavedSearch(t *testing.T) {
 
 	// Ensure update saved search errors when patternType is not provided in the query.
 	_, err = (&schemaResolver{}).UpdateSavedSearch(ctx, &struct {
-		ID          graphql.ID
-		Description string
-		Query       string
-		NotifyOwner bool
-		NotifySlack bool
-		OrgID       *graphql.ID
-		UserID      *graphql.ID
+		ID            graphql.ID
+		Description   string
+		Query         string
+		NotifyOwner   bool
+		NotifySlack   bool
+		OrgID         *graphql.ID
+		UserID        *graphql.ID
+		WebhookURL    *string
+		WebhookSecret *string
 	}{ID: marshalSavedSearchID(key), Description: "updated query description", Query: "test type:diff", NotifyOwner: true, NotifySlack: false, OrgID: nil, UserID: &userID})
 	if err == nil {
 		t.Error("Expected error for updateSavedSearch when query does not provide a patternType: field.")
@@ -195,3 +206,137 @@ func TestDeleteSavedSearch(t *testing.T) {
 		t.Errorf("Database method db.SavedSearches.Delete not called")
 	}
 }
+
+func TestUpdateSavedSearchWebhook(t *testing.T) {
+	ctx := context.Background()
+	defer resetMocks()
+
+	key := int32(1)
+	db.Mocks.Users.GetByCurrentAuthUser = func(context.Context) (*types.User, error) {
+		return &types.User{SiteAdmin: true, ID: key}, nil
+	}
+	oldSecret := "old"
+	db.Mocks.SavedSearches.GetByID = func(ctx context.Context, id int32) (*api.SavedQuerySpecAndConfig, error) {
+		return &api.SavedQuerySpecAndConfig{Config: api.ConfigSavedQuery{Key: "1", UserID: &key, WebhookSecret: &oldSecret}}, nil
+	}
+	var updated *types.SavedSearch
+	db.Mocks.SavedSearches.Update = func(ctx context.Context, savedSearch *types.SavedSearch) (*types.SavedSearch, error) {
+		updated = savedSearch
+		return savedSearch, nil
+	}
+
+	update := func(webhookURL, webhookSecret *string) error {
+		userID := MarshalUserID(key)
+		_, err := (&schemaResolver{}).UpdateSavedSearch(ctx, &struct {
+			ID            graphql.ID
+			Description   string
+			Query         string
+			NotifyOwner   bool
+			NotifySlack   bool
+			OrgID         *graphql.ID
+			UserID        *graphql.ID
+			WebhookURL    *string
+			WebhookSecret *string
+		}{ID: marshalSavedSearchID(key), Query: "test patternType:regexp", UserID: &userID, WebhookURL: webhookURL, WebhookSecret: webhookSecret})
+		return err
+	}
+	strPtr := func(s string) *string { return &s }
+
+	if err := update(strPtr("ftp://example.com"), nil); err == nil {
+		t.Error("got nil error for an invalid webhook URL, want error")
+	}
+
+	if err := update(strPtr("https://example.com/hook"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if updated.WebhookSecret == nil || *updated.WebhookSecret != oldSecret {
+		t.Errorf("got webhook secret %v, want existing secret to be kept", updated.WebhookSecret)
+	}
+
+	if err := update(strPtr("https://example.com/hook"), strPtr("new")); err != nil {
+		t.Fatal(err)
+	}
+	if updated.WebhookSecret == nil || *updated.WebhookSecret != "new" {
+		t.Errorf("got webhook secret %v, want %q", updated.WebhookSecret, "new")
+	}
+
+	if err := update(nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if updated.WebhookURL != nil || updated.WebhookSecret != nil {
+		t.Errorf("got webhook %v %v, want the webhook to be removed", updated.WebhookURL, updated.WebhookSecret)
+	}
+}
+
+func TestSavedSearchRuns(t *testing.T) {
+	ctx := context.Background()
+	defer resetMocks()
+
+	now := time.Now()
+	db.Mocks.SavedSearchRuns.List = func(ctx context.Context, savedSearchID int32, opt *db.LimitOffset) ([]*api.SavedQueryRun, error) {
+		if savedSearchID != 1 {
+			t.Errorf("got saved search ID %d, want 1", savedSearchID)
+		}
+		if opt == nil || opt.Limit != 1 {
+			t.Errorf("got limit %+v, want 1", opt)
+		}
+		return []*api.SavedQueryRun{{
+			StartedAt:  now,
+			FinishedAt: now,
+			Matches:    []string{"r/a", "r/b", "r@abc", "private/c", "private@abc"},
+			NewMatches: []api.SavedQueryMatch{
+				{Key: "r/b", Type: "file", Repository: "r", Path: "b", URL: "https://example.com/r/-/blob/b"},
+				{Key: "private/c", Type: "file", Repository: "private", Path: "c", URL: "https://example.com/private/-/blob/c"},
+			},
+		}}, nil
+	}
+	db.Mocks.Repos.GetByName = func(ctx context.Context, name api.RepoName) (*types.Repo, error) {
+		if name == "private" {
+			return nil, &errcode.Mock{Message: "repo not found: private", IsNotFound: true}
+		}
+		return &types.Repo{Name: name}, nil
+	}
+	db.Mocks.Repos.List = func(ctx context.Context, opt db.ReposListOptions) ([]*types.Repo, error) {
+		var repos []*types.Repo
+		for _, name := range opt.Names {
+			if name != "private" {
+				repos = append(repos, &types.Repo{Name: api.RepoName(name)})
+			}
+		}
+		return repos, nil
+	}
+	db.Mocks.SavedSearchRuns.Count = func(ctx context.Context, savedSearchID int32) (int, error) {
+		return 3, nil
+	}
+
+	first := int32(1)
+	conn := savedSearchResolver{types.SavedSearch{ID: 1}}.Runs(ctx, &struct{ graphqlutil.ConnectionArgs }{graphqlutil.ConnectionArgs{First: &first}})
+	runs, err := conn.Nodes(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 {
+		t.Fatalf("got %d runs, want 1", len(runs))
+	}
+	if got, err := runs[0].MatchCount(ctx); err != nil || got != 3 {
+		t.Errorf("got match count (%d, %v), want 3", got, err)
+	}
+	if runs[0].Error() != nil {
+		t.Errorf("got error %q, want nil", *runs[0].Error())
+	}
+	matches, err := runs[0].NewMatches(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || *matches[0].Path() != "b" || matches[0].Commit() != nil {
+		t.Errorf("got new matches %+v, want the file match r/b", matches)
+	}
+
+	pageInfo, err := conn.PageInfo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pageInfo.HasNextPage() {
+		t.Error("got hasNextPage false, want true")
+	}
+}
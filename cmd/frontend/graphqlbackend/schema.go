@@ -48,6 +48,16 @@ type Mutation {
         # created from this PatchSet.
         patches: [PatchInput!]!
     ): PatchSet!
+    # Create a patch set from the rewrites of a search query with a replace: filter, with one patch
+    # per repository. Each patch applies to the revision that was searched. The query must be
+    # complete: it fails if it hits the result limit or if not all repositories could be searched.
+    #
+    # To create the campaign, call createCampaign with the returned PatchSet.id in the
+    # CreateCampaignInput.patchSet field.
+    createPatchSetFromCodemod(
+        # The search query, e.g. repo:^github.com/foo/ "fmt.Sprintf(:[x])" replace:"fmt.Sprint(:[x])" lang:go count:1000
+        query: String!
+    ): PatchSet!
     # Updates a campaign.
     # Note, updating is not allowed when:
     # The campaign has already been closed.
@@ -384,6 +394,12 @@ type Mutation {
         notifySlack: Boolean!
         orgID: ID
         userID: ID
+        # The URL that new results of the saved search are posted to as JSON. If null, no
+        # webhook notifications are sent.
+        webhookURL: String
+        # The key used to sign the webhook payloads with HMAC-SHA256. The signature is sent in the
+        # X-Sourcegraph-Signature header.
+        webhookSecret: String
     ): SavedSearch!
     # Updates a saved search
     updateSavedSearch(
@@ -394,9 +410,45 @@ type Mutation {
         notifySlack: Boolean!
         orgID: ID
         userID: ID
+        # The URL that new results of the saved search are posted to as JSON. If null, no
+        # webhook notifications are sent.
+        webhookURL: String
+        # The key used to sign the webhook payloads with HMAC-SHA256. The signature is sent in the
+        # X-Sourcegraph-Signature header. If null, the existing key is kept (unless webhookURL is null).
+        webhookSecret: String
     ): SavedSearch!
     # Deletes a saved search
     deleteSavedSearch(id: ID!): EmptyResponse
+    # Creates a repository group owned by a user or organization. Exactly one of repositories and rule must
+    # be given.
+    createRepoGroup(
+        # The ID of the user or organization that owns the group.
+        namespace: ID!
+        # The name, which is used to search the group with repogroup:. It must be unique among the groups of
+        # the owner.
+        name: String!
+        # The description.
+        description: String
+        # The names of the repositories in the group.
+        repositories: [String!]
+        # The rule that defines the repositories in the group.
+        rule: RepoGroupRuleInput
+    ): RepoGroup!
+    # Updates a repository group. Exactly one of repositories and rule must be given.
+    updateRepoGroup(
+        # The ID of the group.
+        id: ID!
+        # The new name.
+        name: String!
+        # The new description.
+        description: String
+        # The names of the repositories in the group.
+        repositories: [String!]
+        # The rule that defines the repositories in the group.
+        rule: RepoGroupRuleInput
+    ): RepoGroup!
+    # Deletes a repository group.
+    deleteRepoGroup(id: ID!): EmptyResponse
 
     # (experimental) The LSIF API may change substantially in the near future as we
     # continue to adjust it for our use cases. Changes will not be documented in the
@@ -413,6 +465,14 @@ type Mutation {
         # The level of repository permission.
         perm: RepositoryPermission = READ
     ): EmptyResponse!
+    # Schedule a permissions sync of the user with high priority, instead of
+    # waiting for the background permissions syncing to get to it. Only site
+    # admins may perform this mutation.
+    scheduleUserPermissionsSync(user: ID!): EmptyResponse!
+    # Schedule a permissions sync of the repository with high priority, instead
+    # of waiting for the background permissions syncing to get to it. Only site
+    # admins may perform this mutation.
+    scheduleRepositoryPermissionsSync(repository: ID!): EmptyResponse!
 }
 
 # A patch to apply to a repository (in a new branch) when a campaign is created
@@ -1350,7 +1410,8 @@ type Query {
     ): Search
     # All saved searches configured for the current user, merged from all configurations.
     savedSearches: [SavedSearch!]!
-    # All repository groups for the current user, merged from all configurations.
+    # All repository groups for the current user: the groups owned by the user and the organizations the user
+    # is a member of, and the groups defined in the search.repositoryGroups setting.
     repoGroups: [RepoGroup!]!
     # The current site.
     site: Site!
@@ -1625,6 +1686,54 @@ type SavedSearch implements Node {
     orgID: ID
     # The Slack webhook URL associated with this saved search, if any.
     slackWebhookURL: String
+    # The URL that new results of this saved search are posted to as JSON, if any.
+    webhookURL: String
+    # The runs of this saved search that checked for new results, most recent first. Only the
+    # most recent runs are retained.
+    runs(
+        # Returns the first n runs from the list.
+        first: Int
+    ): SavedSearchRunConnection!
+}
+
+# A list of saved search runs.
+type SavedSearchRunConnection {
+    # A list of saved search runs.
+    nodes: [SavedSearchRun!]!
+    # The total count of saved search runs in the connection. This total count may be larger than
+    # the number of nodes in this object when the result is paginated.
+    totalCount: Int!
+    # Pagination information.
+    pageInfo: PageInfo!
+}
+
+# A run of a saved search that checked for new results.
+type SavedSearchRun {
+    # The time when the run started.
+    startedAt: DateTime!
+    # The time when the run finished.
+    finishedAt: DateTime!
+    # The number of results found by the run.
+    matchCount: Int!
+    # The results found by the run that were not found by the previous successful run. Subscribers
+    # of the saved search were notified about these results.
+    newMatches: [SavedSearchMatch!]!
+    # The error that caused the run to fail, if any.
+    error: String
+}
+
+# A result of a saved search.
+type SavedSearchMatch {
+    # The type of the result, either "file" or "commit".
+    type: String!
+    # The name of the repository containing the result.
+    repository: String!
+    # The path of the matching file, for file results.
+    path: String
+    # The OID of the matching commit, for commit results.
+    commit: String
+    # The URL to the result.
+    url: String!
 }
 
 # A search query description.
@@ -1637,10 +1746,50 @@ type SearchQueryDescription {
 
 # A group of repositories.
 type RepoGroup {
+    # The unique ID of the group, or null if the group is defined in the search.repositoryGroups setting.
+    id: ID
     # The name.
     name: String!
-    # The repositories.
+    # The description.
+    description: String!
+    # The user or organization that owns the group, or null if the group is defined in the
+    # search.repositoryGroups setting.
+    namespace: Namespace
+    # The rule that defines the repositories in the group, or null if the group is an explicit list of
+    # repositories.
+    rule: RepoGroupRule
+    # The repositories that the viewer has access to. For groups defined by a rule, these are the
+    # repositories the rule matched when it was last evaluated.
     repositories: [String!]!
+    # When the rule of the group was last evaluated. Rules are evaluated whenever repositories are synced.
+    evaluatedAt: DateTime
+    # Whether the viewer can update and delete the group.
+    viewerCanAdminister: Boolean!
+}
+
+# A rule that defines the repositories in a repository group. A repository is in the group if it matches all
+# conditions that are set.
+type RepoGroupRule {
+    # A regular expression that repository names must match.
+    repoPattern: String
+    # The ID of the external service that repositories must be synced from.
+    externalServiceID: ID
+    # Code host topics (currently GitHub topics), of which repositories must have at least one.
+    topics: [String!]!
+    # Languages, one of which must be the most common language of repositories.
+    languages: [String!]!
+}
+
+# A rule that defines the repositories in a repository group. See RepoGroupRule.
+input RepoGroupRuleInput {
+    # A regular expression that repository names must match.
+    repoPattern: String
+    # The ID of the external service that repositories must be synced from.
+    externalService: ID
+    # Code host topics (currently GitHub topics), of which repositories must have at least one.
+    topics: [String!]
+    # Languages, one of which must be the most common language of repositories.
+    languages: [String!]
 }
 
 # A diff between two diffable Git objects.
@@ -1736,6 +1885,8 @@ enum ExternalServiceKind {
     AWSCODECOMMIT
     BITBUCKETCLOUD
     BITBUCKETSERVER
+    GERRIT
+    GITEA
     GITHUB
     GITLAB
     GITOLITE
@@ -1837,6 +1988,9 @@ type Repository implements Node & GenericSearchResultInterface {
     # Information about the text search index for this repository, or null if text search indexing
     # is not enabled or supported for this repository.
     textSearchIndex: RepositoryTextSearchIndex
+    # The language inventory of the repository's default branch, as computed in the background after the
+    # repository is fetched. This field is null if it hasn't been computed yet.
+    languageInventory: RepositoryLanguageInventory
     # The URL to this repository.
     url: String!
     # The URLs to this repository on external services associated with it.
@@ -1988,6 +2142,8 @@ type MirrorRepositoryInfo {
     cloneProgress: String
     # Whether the repository has ever been successfully cloned.
     cloned: Boolean!
+    # How the repository is cloned, or null if it is not cloned.
+    cloneMode: RepositoryCloneMode
     # When the repository was last successfully updated from the remote source repository..
     updatedAt: DateTime
     # The state of this repository in the update schedule.
@@ -1996,6 +2152,17 @@ type MirrorRepositoryInfo {
     updateQueue: UpdateQueue
 }
 
+# The mode in which a repository is cloned.
+enum RepositoryCloneMode {
+    # The complete history including all file contents.
+    FULL
+    # The complete history without file contents. File contents are fetched from the code host when they are
+    # first needed.
+    BLOBLESS
+    # Only the most recent commits of each branch and tag.
+    SHALLOW
+}
+
 # The state of a repository in the update schedule.
 type UpdateSchedule {
     # The interval that was used when scheduling the current due time.
@@ -2278,6 +2445,21 @@ type Symbol {
     canonicalURL: String!
     # Whether or not the symbol is local to the file it's defined in.
     fileLocal: Boolean!
+    # The symbols (in any file in the same repository and commit) whose container is this
+    # symbol, such as the methods and fields of a class or struct.
+    members(
+        # Returns the first n members from the list.
+        first: Int
+    ): SymbolConnection!
+}
+
+# A symbol in the outline of a file, along with the symbols that it contains.
+type SymbolOutlineNode {
+    # The symbol.
+    symbol: Symbol!
+    # The symbols that this symbol contains (such as the methods and fields of a class), ordered
+    # by line.
+    children: [SymbolOutlineNode!]!
 }
 
 # A location inside a resource (in a repository at a specific commit).
@@ -2494,6 +2676,40 @@ type LanguageStatistics {
     totalLines: Int!
 }
 
+# The language inventory of a repository's default branch.
+type RepositoryLanguageInventory {
+    # The OID of the commit that the inventory was computed at.
+    commitOID: GitObjectID!
+    # The languages in the repository, ordered by lines of code.
+    languages: [LanguageStatistics!]!
+    # When the inventory was last updated.
+    updatedAt: DateTime!
+}
+
+# Statistics about the languages of a set of repositories, summed over the language inventories of their
+# default branches. Repositories whose inventory hasn't been computed yet are not included.
+type LanguageInventory {
+    # The languages in the repositories, ordered by lines of code.
+    languages: [LanguageStatistics!]!
+    # The total size of the language in the repositories at the end of each day, oldest first.
+    history(
+        # The name of the language (e.g., "Go"), matched case-insensitively.
+        language: String!
+        # The number of days of history to return, up to 365.
+        days: Int = 30
+    ): [LanguageStatisticsPoint!]!
+}
+
+# The total size of a language in a set of repositories at the end of a day.
+type LanguageStatisticsPoint {
+    # The day.
+    date: DateTime!
+    # The total number of lines in the language.
+    totalLines: Float!
+    # The total bytes in the language.
+    totalBytes: Float!
+}
+
 # A Git commit.
 type GitCommit implements Node {
     # The globally addressable ID for this commit.
@@ -2800,6 +3016,9 @@ type GitBlob implements TreeEntry & File2 {
         # Return symbols matching the query.
         query: String
     ): SymbolConnection!
+    # The outline of this blob, which is the tree of symbols defined in it. The children of a
+    # symbol are the symbols that it contains (such as the methods and fields of a class).
+    symbolOutline: [SymbolOutlineNode!]!
     # Always false, since a blob is a file, not directory.
     isSingleChild(
         # Returns the first n files in the tree.
@@ -2857,6 +3076,44 @@ type LSIFQueryResolver {
         first: Int
     ): LocationConnection
 
+    # (experimental) The LSIF API may change substantially in the near future as we
+    # continue to adjust it for our use cases. Changes will not be documented in the
+    # CHANGELOG during this time.
+    # A list of implementations of the symbol under the given document position (e.g.
+    # the types implementing an interface), including implementations in other
+    # repositories.
+    implementations(
+        # The line on which the symbol occurs (zero-based, inclusive).
+        line: Int!
+
+        # The character (not byte) of the start line on which the symbol occurs (zero-based, inclusive).
+        character: Int!
+
+        # When specified, indicates that this request should be paginated and
+        # to fetch results starting at this cursor.
+        #
+        # A future request can be made for more results by passing in the
+        # 'LocationConnection.pageInfo.endCursor' that is returned.
+        after: String
+
+        # When specified, indicates that this request should be paginated and
+        # the first N results (relative to the cursor) should be returned. i.e.
+        # how many results to return per page.
+        first: Int
+    ): LocationConnection
+
+    # (experimental) The LSIF API may change substantially in the near future as we
+    # continue to adjust it for our use cases. Changes will not be documented in the
+    # CHANGELOG during this time.
+    # A list of definitions of the type of the symbol under the given document position.
+    typeDefinitions(
+        # The line on which the symbol occurs (zero-based, inclusive).
+        line: Int!
+
+        # The character (not byte) of the start line on which the symbol occurs (zero-based, inclusive).
+        character: Int!
+    ): LocationConnection
+
     # (experimental) The LSIF API may change substantially in the near future as we
     # continue to adjust it for our use cases. Changes will not be documented in the
     # CHANGELOG during this time.
@@ -2897,6 +3154,10 @@ type FileMatch {
     symbols: [Symbol!]!
     # The line matches.
     lineMatches: [LineMatch!]!
+    # The matches of a structural search pattern. Unlike line matches, each structural match
+    # corresponds to one match of the pattern, which may span multiple lines. It is empty for
+    # searches that are not structural searches.
+    structuralMatches: [StructuralMatch!]!
     # Whether or not the limit was hit.
     limitHit: Boolean!
 }
@@ -2913,6 +3174,35 @@ type LineMatch {
     limitHit: Boolean!
 }
 
+# A match of a structural search pattern.
+type StructuralMatch {
+    # The matched content.
+    matched: String!
+    # The range of the match in the file.
+    range: Range!
+    # The byte offset of the start of the match in the file.
+    startOffset: Int!
+    # The byte offset of the end of the match in the file (exclusive).
+    endOffset: Int!
+    # The values bound to the holes of the pattern, like :[arg], in this match, in the order
+    # they occur in the match.
+    holes: [StructuralMatchHole!]!
+}
+
+# The value bound to a hole of a structural search pattern in a match.
+type StructuralMatchHole {
+    # The name of the hole, e.g. "arg" for the hole :[arg].
+    variable: String!
+    # The content bound to the hole.
+    value: String!
+    # The range of the content in the file.
+    range: Range!
+    # The byte offset of the start of the content in the file.
+    startOffset: Int!
+    # The byte offset of the end of the content in the file (exclusive).
+    endOffset: Int!
+}
+
 # A hunk.
 type Hunk {
     # The startLine.
@@ -3250,6 +3540,14 @@ type Org implements Node & SettingsSubject & Namespace {
     url: String!
     # The URL to the organization's settings.
     settingsURL: String
+    # Statistics about the languages of the repositories in a namespace on a code host that the viewer can
+    # access. Only organization members and site admins may view them.
+    languageStatistics(
+        # The namespace of the organization's repositories on their code host (e.g., "github.com/sourcegraph"),
+        # including nested namespaces. Repositories are matched by their path on the code host, regardless of
+        # their name on Sourcegraph.
+        namespace: String!
+    ): LanguageInventory!
 
     # The name of this user namespace's component. For organizations, this is the organization's name.
     namespaceName: String!
@@ -3392,6 +3690,18 @@ type DiscussionThreadTargetRepo {
     relativeSelection(rev: String!): DiscussionSelectionRange
 }
 
+# Where the selection of a discussion thread is at a revision.
+type DiscussionThreadLocation {
+    # The revision that the location is at.
+    revision: GitObjectID!
+
+    # The path of the file that the selection is in at the revision.
+    path: String!
+
+    # The selection at the revision.
+    selection: DiscussionSelectionRange!
+}
+
 # The target of a discussion thread. Today, the only possible target is a
 # repository. In the future, this may be extended to include other targets such
 # as user profiles, extensions, etc. Clients should ignore target types they
@@ -3426,6 +3736,17 @@ type DiscussionThread implements Node {
     # OR if it was created without a path string.
     inlineURL: String
 
+    # Where the selection of the thread is at the most recent commit of the branch
+    # it targets (or of the default branch if it targets none), tracked through
+    # renames and changes of the file.
+    #
+    # This is null if the thread has no selection or it hasn't been tracked yet.
+    location: DiscussionThreadLocation
+
+    # Whether the lines that the thread is about were deleted. The location of an
+    # outdated thread is where the lines were last present.
+    outdated: Boolean!
+
     # The date when the discussion thread was created.
     createdAt: DateTime!
 
@@ -3623,6 +3944,9 @@ type Site implements SettingsSubject {
     productVersion: String!
     # Information about software updates for the version of Sourcegraph that this site is running.
     updateCheck: UpdateCheck!
+    # The progress of moving repositories between gitservers after the gitserver addresses changed. Only
+    # visible to site admins.
+    gitserverRebalance: GitserverRebalance!
     # Whether the site needs to be configured to add repositories.
     needsRepositoryConfiguration: Boolean!
     # Whether the site is over the limit for free user accounts, and a warning needs to be shown to all users.
@@ -3668,6 +3992,106 @@ type Site implements SettingsSubject {
         # Months of history (based on current UTC time).
         months: Int
     ): CodeIntelUsageStatistics!
+    # The use of the hourly API budgets of users, organizations and access tokens in the current
+    # hour, as configured in the apiRateLimits site configuration setting. Only users, organizations
+    # and access tokens that used the API in the current hour are included.
+    #
+    # Only site admins may view the use of API budgets.
+    apiUsage: [APIUsage!]!
+    # The audit log, which records security-relevant actions such as changes to users,
+    # organizations, access tokens, external services, the site configuration and repository
+    # permissions. Entries are ordered from newest to oldest.
+    #
+    # Only site admins may view the audit log.
+    auditLog(
+        # Returns the first n entries from the list.
+        first: Int
+        # Opaque pagination cursor, as returned in AuditLogEntryConnection.pageInfo.endCursor.
+        after: String
+        # Only include entries of actions performed by this user.
+        actor: ID
+        # Only include entries of this action (e.g., "user.create").
+        action: String
+        # Only include entries whose target is of this type (e.g., "user").
+        targetType: String
+        # Only include entries whose target has this ID. Requires targetType.
+        targetID: String
+        # Only include entries created at or after this time.
+        since: DateTime
+        # Only include entries created before this time.
+        until: DateTime
+    ): AuditLogEntryConnection!
+    # Statistics about the languages of all repositories that the viewer can access.
+    languageStatistics: LanguageInventory!
+}
+
+# A list of audit log entries.
+type AuditLogEntryConnection {
+    # A list of audit log entries.
+    nodes: [AuditLogEntry!]!
+    # The total number of audit log entries in the connection.
+    totalCount: Int!
+    # Pagination information.
+    pageInfo: PageInfo!
+}
+
+# An entry in the audit log, recording a security-relevant action.
+type AuditLogEntry {
+    # The unique ID of the entry.
+    id: ID!
+    # The user who performed the action, or null if it was performed by Sourcegraph itself, by an
+    # anonymous user, or by a user who has since been deleted.
+    actor: User
+    # The ID of the user who performed the action, if any. It is set even if the user has since been
+    # deleted.
+    actorUserID: Int
+    # Whether the action was performed by Sourcegraph itself.
+    actorInternal: Boolean!
+    # The IP address of the client that performed the action, if it was performed in an HTTP
+    # request. It is that of the proxy in front of Sourcegraph if there is one.
+    actorIP: String
+    # The X-Forwarded-For header of the HTTP request in which the action was performed, if any.
+    actorForwardedFor: String
+    # The action (e.g., "user.create").
+    action: String!
+    # The type of the target of the action (e.g., "user").
+    targetType: String!
+    # The ID of the target of the action, if any.
+    targetID: String
+    # The state of the target before the action, with secrets redacted, if any.
+    before: JSONValue
+    # The state of the target after the action, with secrets redacted, if any.
+    after: JSONValue
+    # The time at which the action was performed.
+    createdAt: DateTime!
+}
+
+# The use of an hourly API budget by a user, organization or access token.
+type APIUsage {
+    # The user whose budget this is, if any.
+    user: User
+    # The organization whose budget this is, if any.
+    organization: Org
+    # The access token whose budget this is, if any.
+    accessToken: AccessToken
+    # The API resource whose use the budget limits.
+    resource: APIResource!
+    # The amount of the resource used in the current hour.
+    used: Int!
+    # The budget, or null if the use of the resource is unlimited.
+    limit: Int
+    # The time at which the budget is replenished.
+    resetsAt: DateTime!
+}
+
+# An API resource whose use is limited by the budgets in the apiRateLimits site configuration setting.
+enum APIResource {
+    # Search requests.
+    SEARCH_REQUESTS
+    # The cost of GraphQL requests, which is the number of objects they may return.
+    GRAPHQL_COST
+    # API requests that are forwarded to code hosts.
+    CODE_HOST_REQUESTS
 }
 
 # The configuration for a site.
@@ -3704,6 +4128,39 @@ type UpdateCheck {
     updateVersionAvailable: String
 }
 
+# The progress of moving repositories between gitservers after the gitserver addresses changed.
+type GitserverRebalance {
+    # Whether repositories are being moved. While they are, requests for a repository go to the gitserver that
+    # held it before, until the move is complete and the previous gitserver addresses are unset.
+    inProgress: Boolean!
+    # When the gitservers were last checked for repositories to move, or null if they haven't been checked yet.
+    checkedAt: DateTime
+    # The number of repositories that are served by a gitserver other than the one they belong on.
+    totalCount: Int!
+    # The number of those repositories that have been copied to the gitserver they belong on.
+    movedCount: Int!
+    # The number of those repositories that failed to be copied in the last attempt.
+    failedCount: Int!
+    # The status of each gitserver.
+    shards: [GitserverShard!]!
+    # The most recent errors moving repositories.
+    errors: [String!]!
+}
+
+# The status of a gitserver in a GitserverRebalance.
+type GitserverShard {
+    # The address of the gitserver.
+    address: String!
+    # The number of repositories cloned on the gitserver that belong on it.
+    clonedCount: Int!
+    # The number of repositories that belong on the gitserver, but are served by another gitserver.
+    incomingCount: Int!
+    # The number of incoming repositories that have been copied to the gitserver.
+    movedCount: Int!
+    # The number of incoming repositories that failed to be copied to the gitserver in the last attempt.
+    failedCount: Int!
+}
+
 # The possible types of alerts (Alert.type values).
 enum AlertType {
     INFO
@@ -2,7 +2,6 @@ package graphqlbackend
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"math"
 	"regexp"
@@ -34,7 +33,6 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/trace"
 	"github.com/sourcegraph/sourcegraph/internal/vcs"
 	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
-	"github.com/sourcegraph/sourcegraph/schema"
 )
 
 // This file contains the root resolver for search. It currently has a lot of
@@ -57,6 +55,11 @@ type SearchArgs struct {
 	Query       string
 	After       *string
 	First       *int32
+
+	// Stream, if non-nil, receives results and progress as they are found
+	// while Results is running. The caller must keep receiving from Stream
+	// until Results returns.
+	Stream chan<- SearchEvent
 }
 
 type SearchImplementer interface {
@@ -103,8 +106,20 @@ func NewSearchImplementer(args *SearchArgs) (SearchImplementer, error) {
 		}
 	}
 
+	mode, err := rankingMode(queryInfo)
+	if err != nil {
+		return alertForQuery(queryString, err), nil
+	}
+
+	if _, err := selectedSymbolKinds(queryInfo); err != nil {
+		return alertForQuery(queryString, err), nil
+	}
+
 	// If stable:truthy is specified, make the query return a stable result ordering.
 	if queryInfo.BoolValue(query.FieldStable) {
+		if mode == rankRelevance {
+			return alertForQuery(queryString, errors.New("rank:relevance cannot be used with stable:yes, which orders results lexicographically")), nil
+		}
 		args, queryInfo, err = queryForStableResults(args, queryInfo)
 		if err != nil {
 			return alertForQuery(queryString, err), nil
@@ -127,6 +142,7 @@ func NewSearchImplementer(args *SearchArgs) (SearchImplementer, error) {
 		patternType:   searchType,
 		zoekt:         search.Indexed(),
 		searcherURLs:  search.SearcherURLs(),
+		stream:        args.Stream,
 	}, nil
 }
 
@@ -247,6 +263,10 @@ type searchResolver struct {
 
 	zoekt        *searchbackend.Zoekt
 	searcherURLs *endpoint.Map
+
+	// stream, if non-nil, receives results as they are found. See
+	// SearchArgs.Stream.
+	stream chan<- SearchEvent
 }
 
 // rawQuery returns the original query string input.
@@ -287,35 +307,6 @@ func (r *searchResolver) maxResults() int32 {
 	return defaultMaxSearchResults
 }
 
-var mockResolveRepoGroups func() (map[string][]*types.Repo, error)
-
-func resolveRepoGroups(ctx context.Context) (map[string][]*types.Repo, error) {
-	if mockResolveRepoGroups != nil {
-		return mockResolveRepoGroups()
-	}
-
-	groups := map[string][]*types.Repo{}
-
-	// Repo groups can be defined in the search.repoGroups settings field.
-	merged, err := viewerFinalSettings(ctx)
-	if err != nil {
-		return nil, err
-	}
-	var settings schema.Settings
-	if err := json.Unmarshal([]byte(merged.Contents()), &settings); err != nil {
-		return nil, err
-	}
-	for name, repoPaths := range settings.SearchRepositoryGroups {
-		repos := make([]*types.Repo, len(repoPaths))
-		for i, repoPath := range repoPaths {
-			repos[i] = &types.Repo{Name: api.RepoName(repoPath)}
-		}
-		groups[name] = repos
-	}
-
-	return groups, nil
-}
-
 // Cf. golang/go/src/regexp/syntax/parse.go.
 const regexpFlags regexpsyntax.Flags = regexpsyntax.ClassNL | regexpsyntax.PerlX | regexpsyntax.UnicodeGroups
 
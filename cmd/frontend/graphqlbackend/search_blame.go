@@ -0,0 +1,274 @@
+package graphqlbackend
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/groupcache/lru"
+	"github.com/inconshreveable/log15"
+	"github.com/neelance/parallel"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/goroutine"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+// blameFilter filters the line matches of file content search results by the
+// commit that last changed each line, according to git blame. It implements
+// the blameauthor:, blamebefore: and blameafter: query fields.
+type blameFilter struct {
+	authors    []*regexp.Regexp // the author must match all of these
+	notAuthors []*regexp.Regexp // the author must match none of these
+	before     time.Time        // if set, the line must be last changed before this time
+	after      time.Time        // if set, the line must be last changed after this time
+}
+
+// newBlameFilter returns the blame filter specified by the query, or nil if
+// the query has no blame fields.
+func newBlameFilter(q query.QueryInfo, now time.Time) (*blameFilter, error) {
+	var f blameFilter
+
+	authors, notAuthors := q.RegexpPatterns(query.FieldBlameAuthor)
+	for _, a := range authors {
+		re, err := regexp.Compile("(?i)" + a)
+		if err != nil {
+			return nil, err
+		}
+		f.authors = append(f.authors, re)
+	}
+	for _, a := range notAuthors {
+		re, err := regexp.Compile("(?i)" + a)
+		if err != nil {
+			return nil, err
+		}
+		f.notAuthors = append(f.notAuthors, re)
+	}
+
+	var err error
+	if before, _ := q.StringValue(query.FieldBlameBefore); before != "" {
+		if f.before, err = parseBlameDate(before, now); err != nil {
+			return nil, errors.Wrapf(err, "invalid %s value", query.FieldBlameBefore)
+		}
+	}
+	if after, _ := q.StringValue(query.FieldBlameAfter); after != "" {
+		if f.after, err = parseBlameDate(after, now); err != nil {
+			return nil, errors.Wrapf(err, "invalid %s value", query.FieldBlameAfter)
+		}
+	}
+
+	if len(f.authors) == 0 && len(f.notAuthors) == 0 && f.before.IsZero() && f.after.IsZero() {
+		return nil, nil
+	}
+	return &f, nil
+}
+
+// matchHunk reports whether the lines of the given hunk pass the filter.
+func (f *blameFilter) matchHunk(h *git.Hunk) bool {
+	// Like author: for commit search, match the whole "Full Name <email>"
+	// string so that authors can be filtered by email domain.
+	author := fmt.Sprintf("%s <%s>", h.Author.Name, h.Author.Email)
+	for _, re := range f.authors {
+		if !re.MatchString(author) {
+			return false
+		}
+	}
+	for _, re := range f.notAuthors {
+		if re.MatchString(author) {
+			return false
+		}
+	}
+	if !f.before.IsZero() && !h.Author.Date.Before(f.before) {
+		return false
+	}
+	if !f.after.IsZero() && !h.Author.Date.After(f.after) {
+		return false
+	}
+	return true
+}
+
+// filter returns the file matches with only the line matches that pass the
+// filter. File matches that have no such line matches left, including matches
+// on the file path only, are omitted. Files that can't be blamed are omitted
+// too, since we can't tell whether their lines pass the filter.
+func (f *blameFilter) filter(ctx context.Context, matches []*FileMatchResolver) []*FileMatchResolver {
+	var (
+		run  = parallel.NewRun(8) // number of concurrent blame ops
+		keep = make([]bool, len(matches))
+	)
+	for i, fm := range matches {
+		if len(fm.JLineMatches) == 0 {
+			continue
+		}
+		i, fm := i, fm
+		run.Acquire()
+		goroutine.Go(func() {
+			defer run.Release()
+			hunks, err := blameFileCached(ctx, fm.Repo.Name, fm.CommitID, fm.JPath)
+			if err != nil {
+				if ctx.Err() == nil {
+					log15.Warn("blame filter: failed to blame file", "repo", fm.Repo.Name, "commit", fm.CommitID, "path", fm.JPath, "error", err)
+				}
+				return
+			}
+			keep[i] = f.filterFileMatch(fm, hunks)
+		})
+	}
+	_ = run.Wait()
+
+	filtered := matches[:0]
+	for i, fm := range matches {
+		if keep[i] {
+			filtered = append(filtered, fm)
+		}
+	}
+	return filtered
+}
+
+// filterFileMatch removes the line and structural matches of fm on lines that
+// do not pass the filter, and reports whether any matches are left.
+func (f *blameFilter) filterFileMatch(fm *FileMatchResolver, hunks []*git.Hunk) bool {
+	// Memoize per hunk, since most hunks span several lines.
+	matched := make(map[*git.Hunk]bool, len(hunks))
+	matchLine := func(line int) bool {
+		h := hunkForLine(hunks, line)
+		if h == nil {
+			return false
+		}
+		m, ok := matched[h]
+		if !ok {
+			m = f.matchHunk(h)
+			matched[h] = m
+		}
+		return m
+	}
+
+	lineMatches := fm.JLineMatches[:0]
+	matchCount := 0
+	for _, lm := range fm.JLineMatches {
+		if matchLine(int(lm.JLineNumber)) {
+			lineMatches = append(lineMatches, lm)
+			matchCount += len(lm.JOffsetAndLengths)
+		}
+	}
+	fm.JLineMatches = lineMatches
+	fm.MatchCount = matchCount
+
+	if len(fm.JStructuralMatches) > 0 {
+		structuralMatches := fm.JStructuralMatches[:0]
+		for _, sm := range fm.JStructuralMatches {
+			if matchLine(sm.JRange.Start.Line) {
+				structuralMatches = append(structuralMatches, sm)
+			}
+		}
+		fm.JStructuralMatches = structuralMatches
+	}
+
+	return len(fm.JLineMatches) > 0
+}
+
+// hunkForLine returns the hunk containing the given 0-based line number, or
+// nil if there is none. Hunks are ordered by line.
+func hunkForLine(hunks []*git.Hunk, line int) *git.Hunk {
+	// Hunk line numbers are 1-based, with an exclusive end.
+	line++
+	lo, hi := 0, len(hunks)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		switch h := hunks[mid]; {
+		case line < h.StartLine:
+			hi = mid
+		case line >= h.EndLine:
+			lo = mid + 1
+		default:
+			return h
+		}
+	}
+	return nil
+}
+
+// blameCache caches the blame of files by (repo, commit, path). Since the
+// blame of a file at a commit never changes, entries only need to be evicted
+// to bound memory use.
+var (
+	blameCacheMu sync.Mutex
+	blameCache   = lru.New(1000)
+)
+
+func blameFileCached(ctx context.Context, repo api.RepoName, commit api.CommitID, path string) ([]*git.Hunk, error) {
+	key := string(repo) + ":" + string(commit) + ":" + path
+	blameCacheMu.Lock()
+	v, ok := blameCache.Get(key)
+	blameCacheMu.Unlock()
+	if ok {
+		return v.([]*git.Hunk), nil
+	}
+
+	hunks, err := git.BlameFile(ctx, gitserver.Repo{Name: repo}, path, &git.BlameOptions{NewestCommit: commit})
+	if err != nil {
+		return nil, err
+	}
+
+	blameCacheMu.Lock()
+	blameCache.Add(key, hunks)
+	blameCacheMu.Unlock()
+	return hunks, nil
+}
+
+var relativeDateRegexp = regexp.MustCompile(`^(\d+)\s*(second|minute|hour|day|week|month|year)s?\s+ago$`)
+
+// parseBlameDate parses the value of the blamebefore: and blameafter: fields.
+// It accepts absolute dates such as "2020-01-31" or RFC 3339 timestamps, and
+// dates relative to now such as "3 weeks ago", "yesterday" or "today".
+func parseBlameDate(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	switch strings.ToLower(s) {
+	case "now":
+		return now, nil
+	case "today":
+		return startOfDay(now), nil
+	case "yesterday":
+		return startOfDay(now).AddDate(0, 0, -1), nil
+	}
+
+	if m := relativeDateRegexp.FindStringSubmatch(strings.ToLower(s)); m != nil {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			return time.Time{}, err
+		}
+		switch m[2] {
+		case "second":
+			return now.Add(-time.Duration(n) * time.Second), nil
+		case "minute":
+			return now.Add(-time.Duration(n) * time.Minute), nil
+		case "hour":
+			return now.Add(-time.Duration(n) * time.Hour), nil
+		case "day":
+			return now.AddDate(0, 0, -n), nil
+		case "week":
+			return now.AddDate(0, 0, -7*n), nil
+		case "month":
+			return now.AddDate(0, -n, 0), nil
+		case "year":
+			return now.AddDate(-n, 0, 0), nil
+		}
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02", "2006/01/02"} {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %q (use e.g. \"2020-01-31\" or \"3 weeks ago\")", s)
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
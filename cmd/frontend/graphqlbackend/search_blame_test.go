@@ -0,0 +1,212 @@
+package graphqlbackend
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+func TestNewBlameFilter(t *testing.T) {
+	now := time.Date(2020, 4, 15, 12, 0, 0, 0, time.UTC)
+
+	q, err := query.ParseAndCheck("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f, err := newBlameFilter(q, now); err != nil || f != nil {
+		t.Fatalf("got (%v, %v), want (nil, nil) for a query without blame fields", f, err)
+	}
+
+	q, err = query.ParseAndCheck(`foo blameauthor:alice -blameauthor:bot blameafter:"2 weeks ago" blamebefore:2020-04-10`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := newBlameFilter(q, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.authors) != 1 || len(f.notAuthors) != 1 {
+		t.Errorf("got authors %v and not authors %v", f.authors, f.notAuthors)
+	}
+	if want := time.Date(2020, 4, 1, 12, 0, 0, 0, time.UTC); !f.after.Equal(want) {
+		t.Errorf("got after %v, want %v", f.after, want)
+	}
+	if want := time.Date(2020, 4, 10, 0, 0, 0, 0, time.UTC); !f.before.Equal(want) {
+		t.Errorf("got before %v, want %v", f.before, want)
+	}
+
+	q, err = query.ParseAndCheck(`foo blameafter:"a while ago"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newBlameFilter(q, now); err == nil {
+		t.Error("got nil error for an invalid date, want error")
+	}
+}
+
+func TestParseBlameDate(t *testing.T) {
+	now := time.Date(2020, 4, 15, 12, 30, 0, 0, time.UTC)
+	tests := map[string]time.Time{
+		"now":                  now,
+		"today":                time.Date(2020, 4, 15, 0, 0, 0, 0, time.UTC),
+		"Yesterday":            time.Date(2020, 4, 14, 0, 0, 0, 0, time.UTC),
+		"3 hours ago":          now.Add(-3 * time.Hour),
+		"1 day ago":            time.Date(2020, 4, 14, 12, 30, 0, 0, time.UTC),
+		"2 weeks ago":          time.Date(2020, 4, 1, 12, 30, 0, 0, time.UTC),
+		"6 months ago":         time.Date(2019, 10, 15, 12, 30, 0, 0, time.UTC),
+		"1 year ago":           time.Date(2019, 4, 15, 12, 30, 0, 0, time.UTC),
+		"2019-12-31":           time.Date(2019, 12, 31, 0, 0, 0, 0, time.UTC),
+		"2019/12/31":           time.Date(2019, 12, 31, 0, 0, 0, 0, time.UTC),
+		"2019-12-31T23:59:00Z": time.Date(2019, 12, 31, 23, 59, 0, 0, time.UTC),
+	}
+	for input, want := range tests {
+		got, err := parseBlameDate(input, now)
+		if err != nil {
+			t.Errorf("%q: %v", input, err)
+			continue
+		}
+		if !got.Equal(want) {
+			t.Errorf("%q: got %v, want %v", input, got, want)
+		}
+	}
+
+	for _, input := range []string{"", "last thursday", "3 fortnights ago"} {
+		if _, err := parseBlameDate(input, now); err == nil {
+			t.Errorf("%q: got nil error, want error", input)
+		}
+	}
+}
+
+func TestHunkForLine(t *testing.T) {
+	hunks := []*git.Hunk{
+		{StartLine: 1, EndLine: 3},
+		{StartLine: 3, EndLine: 4},
+		{StartLine: 4, EndLine: 10},
+	}
+	tests := []struct {
+		line int
+		want *git.Hunk
+	}{
+		{line: 0, want: hunks[0]},
+		{line: 1, want: hunks[0]},
+		{line: 2, want: hunks[1]},
+		{line: 3, want: hunks[2]},
+		{line: 8, want: hunks[2]},
+		{line: 9, want: nil},
+	}
+	for _, test := range tests {
+		if got := hunkForLine(hunks, test.line); got != test.want {
+			t.Errorf("line %d: got %+v, want %+v", test.line, got, test.want)
+		}
+	}
+}
+
+func TestBlameFilter_filter(t *testing.T) {
+	defer git.ResetMocks()
+	blameCache.Clear()
+
+	old := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	recent := time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC)
+	var blames int32
+	git.Mocks.BlameFile = func(path string, opt *git.BlameOptions) ([]*git.Hunk, error) {
+		atomic.AddInt32(&blames, 1)
+		switch path {
+		case "a.go":
+			return []*git.Hunk{
+				{StartLine: 1, EndLine: 3, Author: git.Signature{Name: "Alice", Email: "alice@example.com", Date: recent}},
+				{StartLine: 3, EndLine: 6, Author: git.Signature{Name: "Bob", Email: "bob@example.com", Date: old}},
+			}, nil
+		case "b.go":
+			return []*git.Hunk{
+				{StartLine: 1, EndLine: 6, Author: git.Signature{Name: "Bob", Email: "bob@example.com", Date: recent}},
+			}, nil
+		}
+		return nil, nil
+	}
+
+	newMatches := func() []*FileMatchResolver {
+		repo := &types.Repo{Name: "r"}
+		return []*FileMatchResolver{
+			{
+				JPath: "a.go",
+				JLineMatches: []*lineMatch{
+					{JLineNumber: 0, JOffsetAndLengths: [][2]int32{{0, 1}}},
+					{JLineNumber: 3, JOffsetAndLengths: [][2]int32{{0, 1}, {2, 1}}},
+				},
+				MatchCount: 3,
+				Repo:       repo,
+				CommitID:   "c",
+			},
+			{
+				JPath:        "b.go",
+				JLineMatches: []*lineMatch{{JLineNumber: 4, JOffsetAndLengths: [][2]int32{{0, 1}}}},
+				MatchCount:   1,
+				Repo:         repo,
+				CommitID:     "c",
+			},
+			{
+				// Path matches have no lines to blame.
+				JPath:    "alice.go",
+				Repo:     repo,
+				CommitID: "c",
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter *blameFilter
+		want   map[string][]int32 // path -> line numbers
+	}{
+		{
+			name:   "author",
+			filter: &blameFilter{authors: []*regexp.Regexp{regexp.MustCompile("(?i)alice")}},
+			want:   map[string][]int32{"a.go": {0}},
+		},
+		{
+			name:   "negated author by email domain",
+			filter: &blameFilter{notAuthors: []*regexp.Regexp{regexp.MustCompile("(?i)bob@example.com>$")}},
+			want:   map[string][]int32{"a.go": {0}},
+		},
+		{
+			name:   "after",
+			filter: &blameFilter{after: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)},
+			want:   map[string][]int32{"a.go": {0}, "b.go": {4}},
+		},
+		{
+			name:   "before",
+			filter: &blameFilter{before: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)},
+			want:   map[string][]int32{"a.go": {3}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := map[string][]int32{}
+			for _, fm := range test.filter.filter(context.Background(), newMatches()) {
+				count := 0
+				for _, lm := range fm.JLineMatches {
+					got[fm.JPath] = append(got[fm.JPath], lm.JLineNumber)
+					count += len(lm.JOffsetAndLengths)
+				}
+				if fm.MatchCount != count {
+					t.Errorf("%s: got match count %d, want %d", fm.JPath, fm.MatchCount, count)
+				}
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	// Blames are cached per repository, commit and path.
+	if blames != 2 {
+		t.Errorf("got %d blames, want 2", blames)
+	}
+}
@@ -11,13 +11,17 @@ import (
 	"sync"
 	"unicode/utf8"
 
+	"github.com/inconshreveable/log15"
 	otlog "github.com/opentracing/opentracing-go/log"
 	"github.com/xeonx/timeago"
 
 	"github.com/pkg/errors"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
 	"github.com/sourcegraph/sourcegraph/internal/errcode"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
 	"github.com/sourcegraph/sourcegraph/internal/search"
 	"github.com/sourcegraph/sourcegraph/internal/search/query"
 	"github.com/sourcegraph/sourcegraph/internal/trace"
@@ -108,6 +112,107 @@ func searchCommitsInRepo(ctx context.Context, op search.CommitParameters) (resul
 	repo := op.RepoRevs.Repo
 	maxResults := int(op.PatternInfo.FileMatchLimit)
 
+	var (
+		rawResults []*git.LogCommitSearchResult
+		complete   = true
+		indexed    bool
+	)
+	if useCommitIndex(op.RepoRevs) {
+		rawResults, indexed, err = searchCommitIndexInRepo(ctx, op, maxResults+1)
+		if err != nil {
+			return nil, false, false, err
+		}
+	}
+	if !indexed {
+		rawResults, complete, err = searchGitLogInRepo(ctx, op, maxResults)
+		if err != nil {
+			return nil, false, false, err
+		}
+	}
+
+	// if the result is incomplete, git log timed out and the client should be notified of that
+	timedOut = !complete
+	if len(rawResults) > maxResults {
+		limitHit = true
+		rawResults = rawResults[:maxResults]
+	}
+
+	repoResolver := &RepositoryResolver{repo: repo}
+	results = make([]*commitSearchResultResolver, len(rawResults))
+	for i, rawResult := range rawResults {
+		commit := rawResult.Commit
+		commitResolver := toGitCommitResolver(repoResolver, &commit)
+		results[i] = &commitSearchResultResolver{commit: commitResolver}
+
+		addRefs := func(dst *[]*GitRefResolver, src []string) {
+			for _, ref := range src {
+				*dst = append(*dst, &GitRefResolver{
+					repo: repoResolver,
+					name: ref,
+				})
+			}
+		}
+		addRefs(&results[i].refs, rawResult.Refs)
+		addRefs(&results[i].sourceRefs, rawResult.SourceRefs)
+		var matchBody string
+		var matchHighlights []*highlightedRange
+		// TODO(sqs): properly combine message: and term values for type:commit searches
+		if !op.Diff {
+			var patString string
+			if len(op.ExtraMessageValues) > 0 {
+				patString = orderedFuzzyRegexp(op.ExtraMessageValues)
+				if !op.Query.IsCaseSensitive() {
+					patString = "(?i:" + patString + ")"
+				}
+				pat, err := regexp.Compile(patString)
+				if err == nil {
+					results[i].messagePreview = highlightMatches(pat, []byte(commit.Message))
+					matchHighlights = results[i].messagePreview.highlights
+				}
+			} else {
+				results[i].messagePreview = &highlightedString{value: string(commit.Message)}
+			}
+			matchBody = "```COMMIT_EDITMSG\n" + rawResult.Commit.Message + "\n```"
+		}
+
+		if rawResult.Diff != nil && op.Diff {
+			results[i].diffPreview = &highlightedString{
+				value:      rawResult.Diff.Raw,
+				highlights: fromVCSHighlights(rawResult.DiffHighlights),
+			}
+			matchBody, matchHighlights = cleanDiffPreview(fromVCSHighlights(rawResult.DiffHighlights), rawResult.Diff.Raw)
+		}
+
+		commitIcon := "data:image/svg+xml;base64,PD94bWwgdmVyc2lvbj0iMS4wIiBlbmNvZGluZz0iVVRGLTgiPz48IURPQ1RZUEUgc3ZnIFBVQkxJQyAiLS8vVzNDLy9EVEQgU1ZHIDEuMS8vRU4iICJodHRwOi8vd3d3LnczLm9yZy9HcmFwaGljcy9TVkcvMS4xL0RURC9zdmcxMS5kdGQiPjxzdmcgeG1sbnM9Imh0dHA6Ly93d3cudzMub3JnLzIwMDAvc3ZnIiB4bWxuczp4bGluaz0iaHR0cDovL3d3dy53My5vcmcvMTk5OS94bGluayIgdmVyc2lvbj0iMS4xIiB3aWR0aD0iMjQiIGhlaWdodD0iMjQiIHZpZXdCb3g9IjAgMCAyNCAyNCI+PHBhdGggZD0iTTE3LDEyQzE3LDE0LjQyIDE1LjI4LDE2LjQ0IDEzLDE2LjlWMjFIMTFWMTYuOUM4LjcyLDE2LjQ0IDcsMTQuNDIgNywxMkM3LDkuNTggOC43Miw3LjU2IDExLDcuMVYzSDEzVjcuMUMxNS4yOCw3LjU2IDE3LDkuNTggMTcsMTJNMTIsOUEzLDMgMCAwLDAgOSwxMkEzLDMgMCAwLDAgMTIsMTVBMywzIDAgMCwwIDE1LDEyQTMsMyAwIDAsMCAxMiw5WiIgLz48L3N2Zz4="
+		results[i].label, err = createLabel(rawResult, commitResolver)
+		if err != nil {
+			return nil, false, false, err
+		}
+		commitHash := string(rawResult.Commit.ID)
+		if len(rawResult.Commit.ID) > 7 {
+			commitHash = string(rawResult.Commit.ID)[:7]
+		}
+		timeagoConfig := timeago.NoMax(timeago.English)
+
+		url, err := commitResolver.URL()
+		if err != nil {
+			return nil, false, false, err
+		}
+
+		results[i].detail = fmt.Sprintf("[`%v` %v](%v)", commitHash, timeagoConfig.Format(rawResult.Commit.Author.Date), url)
+		results[i].url = url
+		results[i].icon = commitIcon
+		match := &searchResultMatchResolver{body: matchBody, highlights: matchHighlights, url: url}
+		matches := []*searchResultMatchResolver{match}
+		results[i].matches = matches
+	}
+
+	return results, limitHit, timedOut, nil
+}
+
+// searchGitLogInRepo searches the commits of the repository revisions with
+// git log.
+func searchGitLogInRepo(ctx context.Context, op search.CommitParameters, maxResults int) ([]*git.LogCommitSearchResult, bool, error) {
 	args := []string{
 		"--no-prefix",
 		"--max-count=" + strconv.Itoa(maxResults+1),
@@ -133,7 +238,7 @@ func searchCommitsInRepo(ctx context.Context, op search.CommitParameters) (resul
 				// against a whitelist, but it could cause unexpected errors by (e.g.)
 				// changing the format of `git log` to a format that our parser doesn't
 				// expect.
-				return nil, false, false, fmt.Errorf("invalid revspec: %q", rev.RevSpec)
+				return nil, false, fmt.Errorf("invalid revspec: %q", rev.RevSpec)
 			}
 			args = append(args, rev.RevSpec)
 
@@ -200,13 +305,13 @@ func searchCommitsInRepo(ctx context.Context, op search.CommitParameters) (resul
 		return nil
 	}
 	if err := addGrepLikeFlags(&args, "--grep", query.FieldMessage, op.ExtraMessageValues, false); err != nil {
-		return nil, false, false, err
+		return nil, false, err
 	}
 	if err := addGrepLikeFlags(&args, "--author", query.FieldAuthor, nil, true); err != nil {
-		return nil, false, false, err
+		return nil, false, err
 	}
 	if err := addGrepLikeFlags(&args, "--committer", query.FieldCommitter, nil, true); err != nil {
-		return nil, false, false, err
+		return nil, false, err
 	}
 
 	textSearchOptions := git.TextSearchOptions{
@@ -230,89 +335,130 @@ func searchCommitsInRepo(ctx context.Context, op search.CommitParameters) (resul
 		},
 	}
 
-	rawResults, complete, err := git.RawLogDiffSearch(ctx, diffParameters.Repo, diffParameters.Options)
-	if err != nil {
-		return nil, false, false, err
+	return git.RawLogDiffSearch(ctx, diffParameters.Repo, diffParameters.Options)
+}
+
+// useCommitIndex reports whether the commits of the repository revisions are
+// searched with the commit index gitserver keeps of the default branch.
+func useCommitIndex(repoRevs *search.RepositoryRevisions) bool {
+	if !conf.CommitSearchIndexEnabled() {
+		return false
 	}
+	for _, rev := range repoRevs.Revs {
+		if rev.RefGlob != "" || rev.ExcludeRefGlob != "" || (rev.RevSpec != "" && rev.RevSpec != "HEAD") {
+			return false
+		}
+	}
+	return true
+}
 
-	// if the result is incomplete, git log timed out and the client should be notified of that
-	timedOut = !complete
-	if len(rawResults) > maxResults {
-		limitHit = true
-		rawResults = rawResults[:maxResults]
+// useCommitIndexForAll reports whether the commits of all repository
+// revisions are searched with the commit index.
+func useCommitIndexForAll(repos []*search.RepositoryRevisions) bool {
+	for _, repoRevs := range repos {
+		if !useCommitIndex(repoRevs) {
+			return false
+		}
 	}
+	return true
+}
 
-	repoResolver := &RepositoryResolver{repo: repo}
-	results = make([]*commitSearchResultResolver, len(rawResults))
-	for i, rawResult := range rawResults {
-		commit := rawResult.Commit
-		commitResolver := toGitCommitResolver(repoResolver, &commit)
-		results[i] = &commitSearchResultResolver{commit: commitResolver}
+var mockCommitIndexed func(repos []api.RepoName) map[api.RepoName]bool
 
-		addRefs := func(dst *[]*GitRefResolver, src []string) {
-			for _, ref := range src {
-				*dst = append(*dst, &GitRefResolver{
-					repo: repoResolver,
-					name: ref,
-				})
+// commitIndexedForAll reports whether the commits of all repository
+// revisions are searched with the commit index, and gitserver has a commit
+// index of each repository, so that none of them fall back to git log.
+func commitIndexedForAll(ctx context.Context, repos []*search.RepositoryRevisions) bool {
+	if !useCommitIndexForAll(repos) {
+		return false
+	}
+	names := make([]api.RepoName, 0, len(repos))
+	for _, repoRevs := range repos {
+		names = append(names, repoRevs.Repo.Name)
+	}
+
+	var indexed map[api.RepoName]bool
+	if mockCommitIndexed != nil {
+		indexed = mockCommitIndexed(names)
+	} else {
+		resp, err := gitserver.DefaultClient.RepoInfo(ctx, names...)
+		if err != nil {
+			if ctx.Err() == nil {
+				log15.Warn("commit search: failed to get repository info", "error", err)
 			}
+			return false
 		}
-		addRefs(&results[i].refs, rawResult.Refs)
-		addRefs(&results[i].sourceRefs, rawResult.SourceRefs)
-		var matchBody string
-		var matchHighlights []*highlightedRange
-		// TODO(sqs): properly combine message: and term values for type:commit searches
-		if !op.Diff {
-			var patString string
-			if len(op.ExtraMessageValues) > 0 {
-				patString = orderedFuzzyRegexp(op.ExtraMessageValues)
-				if !op.Query.IsCaseSensitive() {
-					patString = "(?i:" + patString + ")"
-				}
-				pat, err := regexp.Compile(patString)
-				if err == nil {
-					results[i].messagePreview = highlightMatches(pat, []byte(commit.Message))
-					matchHighlights = results[i].messagePreview.highlights
-				}
-			} else {
-				results[i].messagePreview = &highlightedString{value: string(commit.Message)}
-			}
-			matchBody = "```COMMIT_EDITMSG\n" + rawResult.Commit.Message + "\n```"
+		indexed = make(map[api.RepoName]bool, len(resp.Results))
+		for name, info := range resp.Results {
+			indexed[name] = info != nil && info.CommitIndexed
 		}
+	}
 
-		if rawResult.Diff != nil && op.Diff {
-			results[i].diffPreview = &highlightedString{
-				value:      rawResult.Diff.Raw,
-				highlights: fromVCSHighlights(rawResult.DiffHighlights),
-			}
-			matchBody, matchHighlights = cleanDiffPreview(fromVCSHighlights(rawResult.DiffHighlights), rawResult.Diff.Raw)
+	for _, name := range names {
+		if !indexed[name] {
+			return false
 		}
+	}
+	return true
+}
 
-		commitIcon := "data:image/svg+xml;base64,PD94bWwgdmVyc2lvbj0iMS4wIiBlbmNvZGluZz0iVVRGLTgiPz48IURPQ1RZUEUgc3ZnIFBVQkxJQyAiLS8vVzNDLy9EVEQgU1ZHIDEuMS8vRU4iICJodHRwOi8vd3d3LnczLm9yZy9HcmFwaGljcy9TVkcvMS4xL0RURC9zdmcxMS5kdGQiPjxzdmcgeG1sbnM9Imh0dHA6Ly93d3cudzMub3JnLzIwMDAvc3ZnIiB4bWxuczp4bGluaz0iaHR0cDovL3d3dy53My5vcmcvMTk5OS94bGluayIgdmVyc2lvbj0iMS4xIiB3aWR0aD0iMjQiIGhlaWdodD0iMjQiIHZpZXdCb3g9IjAgMCAyNCAyNCI+PHBhdGggZD0iTTE3LDEyQzE3LDE0LjQyIDE1LjI4LDE2LjQ0IDEzLDE2LjlWMjFIMTFWMTYuOUM4LjcyLDE2LjQ0IDcsMTQuNDIgNywxMkM3LDkuNTggOC43Miw3LjU2IDExLDcuMVYzSDEzVjcuMUMxNS4yOCw3LjU2IDE3LDkuNTggMTcsMTJNMTIsOUEzLDMgMCAwLDAgOSwxMkEzLDMgMCAwLDAgMTIsMTVBMywzIDAgMCwwIDE1LDEyQTMsMyAwIDAsMCAxMiw5WiIgLz48L3N2Zz4="
-		results[i].label, err = createLabel(rawResult, commitResolver)
-		if err != nil {
-			return nil, false, false, err
-		}
-		commitHash := string(rawResult.Commit.ID)
-		if len(rawResult.Commit.ID) > 7 {
-			commitHash = string(rawResult.Commit.ID)[:7]
+// searchCommitIndexInRepo searches the commits of the default branch of the
+// repository with the commit index. If gitserver has no commit index for the
+// repository, indexed is false.
+func searchCommitIndexInRepo(ctx context.Context, op search.CommitParameters, limit int) (results []*git.LogCommitSearchResult, indexed bool, err error) {
+	opt := git.IndexedLogDiffSearchOptions{
+		Query: git.TextSearchOptions{
+			Pattern:         op.PatternInfo.Pattern,
+			IsRegExp:        op.PatternInfo.IsRegExp,
+			IsCaseSensitive: op.PatternInfo.IsCaseSensitive,
+		},
+		Paths: git.PathOptions{
+			IncludePatterns: op.PatternInfo.IncludePatterns,
+			ExcludePattern:  op.PatternInfo.ExcludePattern,
+			IsCaseSensitive: op.PatternInfo.PathPatternsAreCaseSensitive,
+			IsRegExp:        op.PatternInfo.PathPatternsAreRegExps,
+		},
+		Diff:              op.Diff,
+		OnlyMatchingHunks: true,
+		Limit:             limit,
+	}
+	opt.Before, _ = op.Query.StringValues(query.FieldBefore)
+	opt.After, _ = op.Query.StringValues(query.FieldAfter)
+
+	// The extra message values are search patterns, which are only regular
+	// expressions if the search is.
+	extraMessageValues := make([]string, 0, len(op.ExtraMessageValues))
+	for _, v := range op.ExtraMessageValues {
+		if !op.PatternInfo.IsRegExp {
+			v = regexp.QuoteMeta(v)
 		}
-		timeagoConfig := timeago.NoMax(timeago.English)
-
-		url, err := commitResolver.URL()
-		if err != nil {
-			return nil, false, false, err
+		extraMessageValues = append(extraMessageValues, v)
+	}
+
+	for _, f := range []struct {
+		field           string
+		extraValues     []string
+		expandUsernames bool
+		values, minus   *[]string
+	}{
+		{query.FieldMessage, extraMessageValues, false, &opt.MessagePatterns, &opt.NegatedMessagePatterns},
+		{query.FieldAuthor, nil, true, &opt.AuthorPatterns, &opt.NegatedAuthorPatterns},
+		{query.FieldCommitter, nil, true, &opt.CommitterPatterns, &opt.NegatedCommitterPatterns},
+	} {
+		values, minusValues := op.Query.RegexpPatterns(f.field)
+		values = append(values, f.extraValues...)
+		if f.expandUsernames {
+			if values, err = expandUsernamesToEmails(ctx, values); err != nil {
+				return nil, false, errors.WithMessage(err, fmt.Sprintf("expanding usernames in field %s", f.field))
+			}
+			if minusValues, err = expandUsernamesToEmails(ctx, minusValues); err != nil {
+				return nil, false, errors.WithMessage(err, fmt.Sprintf("expanding usernames in field -%s", f.field))
+			}
 		}
-
-		results[i].detail = fmt.Sprintf("[`%v` %v](%v)", commitHash, timeagoConfig.Format(rawResult.Commit.Author.Date), url)
-		results[i].url = url
-		results[i].icon = commitIcon
-		match := &searchResultMatchResolver{body: matchBody, highlights: matchHighlights, url: url}
-		matches := []*searchResultMatchResolver{match}
-		results[i].matches = matches
+		*f.values, *f.minus = values, minusValues
 	}
 
-	return results, limitHit, timedOut, nil
+	return git.IndexedLogDiffSearch(ctx, op.RepoRevs.GitserverRepo(), opt)
 }
 
 func cleanDiffPreview(highlights []*highlightedRange, rawDiffResult string) (string, []*highlightedRange) {
@@ -14,9 +14,11 @@ import (
 	//"github.com/google/go-cmp/cmp"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
 	"github.com/sourcegraph/sourcegraph/internal/search"
 	"github.com/sourcegraph/sourcegraph/internal/search/query"
 	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+	"github.com/sourcegraph/sourcegraph/schema"
 )
 
 func TestSearchCommitsInRepo(t *testing.T) {
@@ -97,6 +99,68 @@ func TestSearchCommitsInRepo(t *testing.T) {
 	}
 }
 
+func TestSearchCommitsInRepo_CommitIndex(t *testing.T) {
+	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{ExperimentalFeatures: &schema.ExperimentalFeatures{CommitSearchIndex: "enabled"}}})
+	defer conf.Mock(nil)
+
+	var indexed, calledRawLogDiffSearch bool
+	git.Mocks.IndexedLogDiffSearch = func(opt git.IndexedLogDiffSearchOptions) ([]*git.LogCommitSearchResult, bool, error) {
+		if want := []string{"fix"}; !reflect.DeepEqual(opt.MessagePatterns, want) {
+			t.Errorf("got message patterns %q, want %q", opt.MessagePatterns, want)
+		}
+		if want := []string{"1 week ago"}; !reflect.DeepEqual(opt.After, want) {
+			t.Errorf("got after %q, want %q", opt.After, want)
+		}
+		if want := defaultMaxSearchResults + 1; opt.Limit != want {
+			t.Errorf("got limit %d, want %d", opt.Limit, want)
+		}
+		if !indexed {
+			return nil, false, nil
+		}
+		return []*git.LogCommitSearchResult{{Commit: git.Commit{ID: "c1"}}}, true, nil
+	}
+	git.Mocks.RawLogDiffSearch = func(opt git.RawLogDiffSearchOptions) ([]*git.LogCommitSearchResult, bool, error) {
+		calledRawLogDiffSearch = true
+		return nil, true, nil
+	}
+	defer git.ResetMocks()
+
+	q, err := query.ParseAndCheck("message:fix after:\"1 week ago\"")
+	if err != nil {
+		t.Fatal(err)
+	}
+	searchRevs := func(revs ...search.RevisionSpecifier) []*commitSearchResultResolver {
+		t.Helper()
+		calledRawLogDiffSearch = false
+		results, _, _, err := searchCommitsInRepo(context.Background(), search.CommitParameters{
+			RepoRevs:    &search.RepositoryRevisions{Repo: &types.Repo{ID: 1, Name: "repo"}, Revs: revs},
+			PatternInfo: &search.CommitPatternInfo{FileMatchLimit: int32(defaultMaxSearchResults)},
+			Query:       q,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return results
+	}
+
+	// Repositories without a commit index fall back to git log.
+	searchRevs()
+	if !calledRawLogDiffSearch {
+		t.Error("want git log search of repository without commit index")
+	}
+
+	indexed = true
+	if results := searchRevs(search.RevisionSpecifier{RevSpec: "HEAD"}); len(results) != 1 || calledRawLogDiffSearch {
+		t.Errorf("got %d results, git log search %v, want 1 result from commit index", len(results), calledRawLogDiffSearch)
+	}
+
+	// The commit index only covers the default branch.
+	searchRevs(search.RevisionSpecifier{RevSpec: "branch"})
+	if !calledRawLogDiffSearch {
+		t.Error("want git log search of non-default branch")
+	}
+}
+
 func (r *commitSearchResultResolver) String() string {
 	return fmt.Sprintf("{commit: %+v diffPreview: %+v messagePreview: %+v}", r.commit, r.diffPreview, r.messagePreview)
 }
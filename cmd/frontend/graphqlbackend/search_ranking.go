@@ -0,0 +1,344 @@
+package graphqlbackend
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/neelance/parallel"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
+	"github.com/sourcegraph/sourcegraph/internal/symbols/protocol"
+)
+
+// Search results are ordered by repository name and file path by default
+// (rank:lexicographic). With rank:relevance, results from indexed and
+// unindexed search are ordered together by a score computed from signals we
+// already have, so that e.g. the definition of a symbol outranks many
+// mentions of it in tests:
+//
+//   - whether a line match is on the definition of a symbol matching the
+//     search pattern, according to the symbols service
+//   - path heuristics that demote vendored, test and generated files
+//   - how recently the repository changed, and whether it is a fork or archived
+//   - the number of matches in a file
+//
+// Results with equal scores keep the default order.
+const (
+	rankLexicographic = "lexicographic"
+	rankRelevance     = "relevance"
+)
+
+// rankingMode returns the ranking mode selected with the rank: field.
+func rankingMode(q query.QueryInfo) (string, error) {
+	value, _ := q.StringValue(query.FieldRank)
+	switch mode := strings.ToLower(value); mode {
+	case "":
+		return rankLexicographic, nil
+	case rankLexicographic, rankRelevance:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid value %q for field %q, must be %q or %q", value, query.FieldRank, rankRelevance, rankLexicographic)
+}
+
+// Score contributions of the ranking signals. Definitions must outrank any
+// number of mentions in a demoted file, so definitionBoost is larger than
+// maxDensityBoost plus any penalty.
+const (
+	definitionBoost  = 8
+	maxDensityBoost  = 4
+	recencyBoost     = 2
+	vendoredPenalty  = 4
+	generatedPenalty = 5
+	testPenalty      = 3
+	forkPenalty      = 1
+	archivedPenalty  = 2
+
+	// recencyHalfLife is the age of the latest change to a repository at
+	// which recencyBoost is halved.
+	recencyHalfLife = 90 * 24 * time.Hour
+)
+
+const (
+	// rankingSignalsTimeout bounds the time spent fetching signals. Ranking
+	// uses whatever signals were fetched in time.
+	rankingSignalsTimeout = 2 * time.Second
+
+	// maxRankingSymbolsRepos is the maximum number of repository revisions
+	// for which definitions are looked up, and maxRankingSymbolsFiles the
+	// maximum number of files per repository revision.
+	maxRankingSymbolsRepos = 25
+	maxRankingSymbolsFiles = 100
+)
+
+// rankResults orders results by relevance. See the rank: field above.
+func rankResults(ctx context.Context, results []SearchResultResolver, patternInfo *search.TextPatternInfo) {
+	sortResults(results)
+
+	ctx, cancel := context.WithTimeout(ctx, rankingSignalsTimeout)
+	defer cancel()
+
+	var fileMatches []*FileMatchResolver
+	repos := map[api.RepoName]*types.Repo{}
+	for _, r := range results {
+		if fm, ok := r.ToFileMatch(); ok {
+			fileMatches = append(fileMatches, fm)
+		}
+		if repo := resultRepo(r); repo != nil {
+			repos[repo.Name] = repo
+		}
+	}
+
+	var (
+		wg          sync.WaitGroup
+		definitions map[*FileMatchResolver]bool
+		lastChanged map[api.RepoName]time.Time
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		definitions = fetchDefinitionMatches(ctx, fileMatches, patternInfo)
+	}()
+	go func() {
+		defer wg.Done()
+		lastChanged = fetchRepoLastChanged(ctx, repos)
+	}()
+	wg.Wait()
+
+	now := time.Now()
+	scores := make(map[SearchResultResolver]float64, len(results))
+	for _, r := range results {
+		var score float64
+		if repo := resultRepo(r); repo != nil {
+			score += repoScore(repo, lastChanged[repo.Name], now)
+		}
+		if fm, ok := r.ToFileMatch(); ok {
+			score += fileMatchScore(fm, definitions[fm])
+		}
+		scores[r] = score
+	}
+	sort.SliceStable(results, func(i, j int) bool { return scores[results[i]] > scores[results[j]] })
+}
+
+// resultRepo returns the repository of a search result.
+func resultRepo(r SearchResultResolver) *types.Repo {
+	switch r := r.(type) {
+	case *RepositoryResolver:
+		return r.repo
+	case *FileMatchResolver:
+		return r.Repo
+	case *commitSearchResultResolver:
+		return r.commit.repo.repo
+	case *codemodResultResolver:
+		return r.commit.repo.repo
+	}
+	return nil
+}
+
+func repoScore(repo *types.Repo, lastChanged, now time.Time) float64 {
+	var score float64
+	if !lastChanged.IsZero() {
+		age := now.Sub(lastChanged)
+		if age < 0 {
+			age = 0
+		}
+		score += recencyBoost * math.Pow(0.5, float64(age)/float64(recencyHalfLife))
+	}
+	if repo.RepoFields != nil {
+		if repo.Fork {
+			score -= forkPenalty
+		}
+		if repo.Archived {
+			score -= archivedPenalty
+		}
+	}
+	return score
+}
+
+func fileMatchScore(fm *FileMatchResolver, definition bool) float64 {
+	var score float64
+	if definition {
+		score += definitionBoost
+	}
+
+	matches := fm.MatchCount
+	if matches == 0 {
+		matches = len(fm.JLineMatches)
+	}
+	score += math.Min(math.Log2(1+float64(matches)), maxDensityBoost)
+
+	if isVendoredPath(fm.JPath) {
+		score -= vendoredPenalty
+	}
+	if isGeneratedPath(fm.JPath) {
+		score -= generatedPenalty
+	}
+	if isTestPath(fm.JPath) {
+		score -= testPenalty
+	}
+	return score
+}
+
+var (
+	vendoredDirs = map[string]bool{"vendor": true, "node_modules": true, "third_party": true, "bower_components": true}
+	testDirs     = map[string]bool{"test": true, "tests": true, "__tests__": true, "testdata": true, "spec": true, "__mocks__": true}
+
+	testFilePattern      = regexp.MustCompile(`(_test\.|\.test\.|\.spec\.|^test_)`)
+	generatedFilePattern = regexp.MustCompile(`(\.pb\.go|\.pb\.gw\.go|_generated\.go|\.gen\.go|_gen\.go|\.min\.js|\.min\.css|\.designer\.cs)$`)
+)
+
+func isVendoredPath(p string) bool {
+	return hasDir(p, vendoredDirs)
+}
+
+func isTestPath(p string) bool {
+	return hasDir(p, testDirs) || testFilePattern.MatchString(strings.ToLower(path.Base(p)))
+}
+
+func isGeneratedPath(p string) bool {
+	return generatedFilePattern.MatchString(strings.ToLower(p)) || hasDir(p, map[string]bool{"generated": true, "__generated__": true})
+}
+
+// hasDir reports whether any directory of the file path p is in dirs.
+func hasDir(p string, dirs map[string]bool) bool {
+	parts := strings.Split(strings.ToLower(p), "/")
+	for _, dir := range parts[:len(parts)-1] {
+		if dirs[dir] {
+			return true
+		}
+	}
+	return false
+}
+
+var mockRankingListSymbols func(ctx context.Context, args search.SymbolsParameters) ([]protocol.Symbol, error)
+
+// fetchDefinitionMatches returns the file matches that have a line match on
+// the definition of a symbol matching the search pattern.
+func fetchDefinitionMatches(ctx context.Context, fileMatches []*FileMatchResolver, patternInfo *search.TextPatternInfo) map[*FileMatchResolver]bool {
+	if patternInfo == nil || patternInfo.IsStructuralPat || patternInfo.Pattern == "" {
+		return nil
+	}
+	listSymbols := backend.Symbols.ListTags
+	if mockRankingListSymbols != nil {
+		listSymbols = mockRankingListSymbols
+	}
+
+	type repoCommit struct {
+		repo   api.RepoName
+		commit api.CommitID
+	}
+	var keys []repoCommit
+	byKey := map[repoCommit][]*FileMatchResolver{}
+	for _, fm := range fileMatches {
+		if len(fm.JLineMatches) == 0 || fm.CommitID == "" {
+			continue
+		}
+		k := repoCommit{repo: fm.Repo.Name, commit: fm.CommitID}
+		if _, ok := byKey[k]; !ok {
+			if len(keys) == maxRankingSymbolsRepos {
+				continue
+			}
+			keys = append(keys, k)
+		}
+		if len(byKey[k]) < maxRankingSymbolsFiles {
+			byKey[k] = append(byKey[k], fm)
+		}
+	}
+
+	var (
+		mu          sync.Mutex
+		definitions = map[*FileMatchResolver]bool{}
+		run         = parallel.NewRun(8)
+	)
+	for _, k := range keys {
+		k := k
+		run.Acquire()
+		go func() {
+			defer run.Release()
+
+			byPath := make(map[string]*FileMatchResolver, len(byKey[k]))
+			paths := make([]string, 0, len(byKey[k]))
+			for _, fm := range byKey[k] {
+				byPath[fm.JPath] = fm
+				paths = append(paths, regexp.QuoteMeta(fm.JPath))
+			}
+			symbols, err := listSymbols(ctx, search.SymbolsParameters{
+				Repo:            k.repo,
+				CommitID:        k.commit,
+				Query:           patternInfo.Pattern,
+				IsRegExp:        patternInfo.IsRegExp,
+				IsCaseSensitive: patternInfo.IsCaseSensitive,
+				IncludePatterns: []string{"^(" + strings.Join(paths, "|") + ")$"},
+				First:           len(paths) * 10,
+			})
+			if err != nil {
+				if ctx.Err() == nil {
+					log15.Warn("ranking: failed to list symbols", "repo", k.repo, "error", err)
+				}
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, s := range symbols {
+				fm := byPath[s.Path]
+				if fm == nil {
+					continue
+				}
+				for _, lm := range fm.JLineMatches {
+					// Symbol lines are 1-based, line matches 0-based.
+					if int(lm.JLineNumber) == s.Line-1 {
+						definitions[fm] = true
+						break
+					}
+				}
+			}
+		}()
+	}
+	_ = run.Wait()
+	return definitions
+}
+
+var mockRankingRepoLastChanged func(repos []api.RepoName) map[api.RepoName]time.Time
+
+// fetchRepoLastChanged returns the time of the latest change to each
+// repository, according to gitserver.
+func fetchRepoLastChanged(ctx context.Context, repos map[api.RepoName]*types.Repo) map[api.RepoName]time.Time {
+	if len(repos) == 0 {
+		return nil
+	}
+	names := make([]api.RepoName, 0, len(repos))
+	for name := range repos {
+		names = append(names, name)
+	}
+
+	if mockRankingRepoLastChanged != nil {
+		return mockRankingRepoLastChanged(names)
+	}
+
+	resp, err := gitserver.DefaultClient.RepoInfo(ctx, names...)
+	if err != nil {
+		if ctx.Err() == nil {
+			log15.Warn("ranking: failed to get repository info", "error", err)
+		}
+		return nil
+	}
+	lastChanged := make(map[api.RepoName]time.Time, len(resp.Results))
+	for name, info := range resp.Results {
+		if info != nil && info.LastChanged != nil {
+			lastChanged[name] = *info.LastChanged
+		}
+	}
+	return lastChanged
+}
@@ -0,0 +1,142 @@
+package graphqlbackend
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
+	"github.com/sourcegraph/sourcegraph/internal/symbols/protocol"
+)
+
+func TestRankingMode(t *testing.T) {
+	cases := []struct {
+		query   string
+		want    string
+		wantErr bool
+	}{
+		{query: "foo", want: rankLexicographic},
+		{query: "foo rank:relevance", want: rankRelevance},
+		{query: "foo rank:Relevance", want: rankRelevance},
+		{query: "foo rank:lexicographic", want: rankLexicographic},
+		{query: "foo rank:stars", wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.query, func(t *testing.T) {
+			q, err := query.ParseAndCheck(tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := rankingMode(q)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRankingPathHeuristics(t *testing.T) {
+	cases := []struct {
+		path                        string
+		vendored, test, isGenerated bool
+	}{
+		{path: "cmd/main.go"},
+		{path: "vendor/github.com/pkg/errors/errors.go", vendored: true},
+		{path: "web/node_modules/react/index.js", vendored: true},
+		{path: "internal/search/query/query_test.go", test: true},
+		{path: "src/foo.test.ts", test: true},
+		{path: "spec/models/user.rb", test: true},
+		{path: "tests/test_client.py", test: true},
+		{path: "proto/api.pb.go", isGenerated: true},
+		{path: "dist/app.min.js", isGenerated: true},
+		{path: "vendor.go"},
+		{path: "testing.go"},
+		{path: "contest/latest.go"},
+	}
+	for _, tc := range cases {
+		if got := isVendoredPath(tc.path); got != tc.vendored {
+			t.Errorf("isVendoredPath(%q) = %v, want %v", tc.path, got, tc.vendored)
+		}
+		if got := isTestPath(tc.path); got != tc.test {
+			t.Errorf("isTestPath(%q) = %v, want %v", tc.path, got, tc.test)
+		}
+		if got := isGeneratedPath(tc.path); got != tc.isGenerated {
+			t.Errorf("isGeneratedPath(%q) = %v, want %v", tc.path, got, tc.isGenerated)
+		}
+	}
+}
+
+func TestRankResults(t *testing.T) {
+	now := time.Now()
+	active := &types.Repo{Name: "github.com/org/active", RepoFields: &types.RepoFields{}}
+	stale := &types.Repo{Name: "github.com/org/stale", RepoFields: &types.RepoFields{}}
+	fork := &types.Repo{Name: "github.com/someone/active", RepoFields: &types.RepoFields{Fork: true}}
+
+	lineMatches := func(lines ...int32) []*lineMatch {
+		var lms []*lineMatch
+		for _, l := range lines {
+			lms = append(lms, &lineMatch{JLineNumber: l})
+		}
+		return lms
+	}
+	fileMatch := func(repo *types.Repo, path string, lines ...int32) *FileMatchResolver {
+		return &FileMatchResolver{
+			JPath:        path,
+			JLineMatches: lineMatches(lines...),
+			MatchCount:   len(lines),
+			Repo:         repo,
+			CommitID:     "deadbeef",
+		}
+	}
+
+	definition := fileMatch(stale, "client/client.go", 41)
+	mentions := fileMatch(stale, "client/client_test.go", 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16)
+	vendored := fileMatch(stale, "vendor/github.com/org/client/client.go", 10, 20)
+	activeMention := fileMatch(active, "main.go", 7)
+	forkMention := fileMatch(fork, "main.go", 7)
+
+	mockRankingListSymbols = func(ctx context.Context, args search.SymbolsParameters) ([]protocol.Symbol, error) {
+		if args.Query != "NewClient" {
+			t.Errorf("got symbols query %q, want %q", args.Query, "NewClient")
+		}
+		if args.Repo != stale.Name {
+			return nil, nil
+		}
+		return []protocol.Symbol{
+			{Name: "NewClient", Path: "client/client.go", Line: 42},
+			// Not on a matched line.
+			{Name: "NewClient", Path: "vendor/github.com/org/client/client.go", Line: 100},
+		}, nil
+	}
+	mockRankingRepoLastChanged = func(repos []api.RepoName) map[api.RepoName]time.Time {
+		return map[api.RepoName]time.Time{
+			active.Name: now,
+			fork.Name:   now,
+			stale.Name:  now.Add(-3 * 365 * 24 * time.Hour),
+		}
+	}
+	defer func() {
+		mockRankingListSymbols = nil
+		mockRankingRepoLastChanged = nil
+	}()
+
+	results := []SearchResultResolver{vendored, mentions, forkMention, activeMention, definition}
+	rankResults(context.Background(), results, &search.TextPatternInfo{Pattern: "NewClient"})
+
+	want := []SearchResultResolver{definition, activeMention, forkMention, mentions, vendored}
+	if !reflect.DeepEqual(results, want) {
+		var got []string
+		for _, r := range results {
+			fm, _ := r.ToFileMatch()
+			got = append(got, string(fm.Repo.Name)+"/"+fm.JPath)
+		}
+		t.Errorf("unexpected order: %v", got)
+	}
+}
@@ -2,11 +2,14 @@ package graphqlbackend
 
 import (
 	"context"
+	"fmt"
 	"math"
 	"regexp"
 
 	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/src-d/enry/v2"
 
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/internal/search"
 	"github.com/sourcegraph/sourcegraph/internal/search/query"
@@ -39,6 +42,7 @@ func searchRepositories(ctx context.Context, args *search.TextParameters, limit
 		query.FieldCase:               {},
 		query.FieldRepoHasFile:        {},
 		query.FieldRepoHasCommitAfter: {},
+		query.FieldLang:               {},
 	}
 	// Don't return repo results if the search contains fields that aren't on the whitelist.
 	// Matching repositories based whether they contain files at a certain path (etc.) is not yet implemented.
@@ -77,6 +81,15 @@ func searchRepositories(ctx context.Context, args *search.TextParameters, limit
 		}
 	}
 
+	// Filter the repos if there is a lang: or -lang: field.
+	languages, negatedLanguages := args.Query.StringValues(query.FieldLang)
+	if len(languages) > 0 || len(negatedLanguages) > 0 {
+		repos, err = reposWithLanguages(ctx, repos, languages, negatedLanguages)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
 	// Convert the repos to RepositoryResolvers.
 	results := make([]SearchResultResolver, 0, len(repos))
 	for _, r := range repos {
@@ -90,6 +103,63 @@ func searchRepositories(ctx context.Context, args *search.TextParameters, limit
 	return results, common, nil
 }
 
+// reposWithLanguages returns the repositories that contain code in all of the
+// languages and in none of the negated languages, according to the stored
+// language inventories of their default branches. Repositories whose
+// inventory hasn't been computed yet only match if no languages are required.
+func reposWithLanguages(ctx context.Context, repos []*search.RepositoryRevisions, languages, negatedLanguages []string) ([]*search.RepositoryRevisions, error) {
+	canonical := func(values []string) ([]string, error) {
+		langs := make([]string, len(values))
+		for i, value := range values {
+			lang, ok := enry.GetLanguageByAlias(value)
+			if !ok {
+				return nil, fmt.Errorf("unknown language: %q", value)
+			}
+			langs[i] = lang
+		}
+		return langs, nil
+	}
+	languages, err := canonical(languages)
+	if err != nil {
+		return nil, err
+	}
+	negatedLanguages, err = canonical(negatedLanguages)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]api.RepoID, len(repos))
+	for i, r := range repos {
+		ids[i] = r.Repo.ID
+	}
+	for _, lang := range languages {
+		if ids, err = db.RepoInventories.FilterByLanguages(ctx, ids, []string{lang}); err != nil {
+			return nil, err
+		}
+	}
+	match := make(map[api.RepoID]bool, len(ids))
+	for _, id := range ids {
+		match[id] = true
+	}
+	if len(negatedLanguages) > 0 {
+		excluded, err := db.RepoInventories.FilterByLanguages(ctx, ids, negatedLanguages)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range excluded {
+			delete(match, id)
+		}
+	}
+
+	filtered := repos[:0:0]
+	for _, r := range repos {
+		if match[r.Repo.ID] {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered, nil
+}
+
 // reposToAdd determines which repositories should be included in the result set based on whether they fit in the subset
 // of repostiories specified in the query's `repohasfile` and `-repohasfile` fields if they exist.
 func reposToAdd(ctx context.Context, args *search.TextParameters, repos []*search.RepositoryRevisions) ([]*search.RepositoryRevisions, error) {
@@ -8,7 +8,9 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/search"
 	searchbackend "github.com/sourcegraph/sourcegraph/internal/search/backend"
 	"github.com/sourcegraph/sourcegraph/internal/search/query"
@@ -47,6 +49,24 @@ func TestSearchRepositories(t *testing.T) {
 		}
 	}
 
+	// foo/one contains Go and bar/one contains Go and Java. The inventory
+	// of foo/no-match hasn't been computed yet.
+	db.Mocks.RepoInventories.FilterByLanguages = func(ctx context.Context, repoIDs []api.RepoID, languages []string) ([]api.RepoID, error) {
+		contains := map[api.RepoID][]string{123: {"Go"}, 789: {"Go", "Java"}}
+		var ids []api.RepoID
+		for _, id := range repoIDs {
+			for _, lang := range languages {
+				for _, l := range contains[id] {
+					if l == lang {
+						ids = append(ids, id)
+					}
+				}
+			}
+		}
+		return ids, nil
+	}
+	defer func() { db.Mocks = db.MockStores{} }()
+
 	cases := []struct {
 		name string
 		q    string
@@ -75,6 +95,18 @@ func TestSearchRepositories(t *testing.T) {
 		name: "case exclude all",
 		q:    "Foo case:yes",
 		want: []string{},
+	}, {
+		name: "lang",
+		q:    "type:repo lang:golang",
+		want: []string{"bar/one", "foo/one"},
+	}, {
+		name: "multiple langs",
+		q:    "type:repo lang:go lang:java",
+		want: []string{"bar/one"},
+	}, {
+		name: "negated lang",
+		q:    "type:repo -lang:java",
+		want: []string{"foo/no-match", "foo/one"},
 	}}
 
 	for _, tc := range cases {
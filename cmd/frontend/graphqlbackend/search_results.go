@@ -558,8 +558,10 @@ func (r *searchResolver) logSearchLatency(ctx context.Context, durationMs int32)
 // evaluation of leaf expression in a query.
 func (r *searchResolver) evaluateLeaf(ctx context.Context) (*SearchResultsResolver, error) {
 	// If the request specifies stable:truthy, use pagination to return a stable ordering.
-	if r.query.BoolValue("stable") {
-		result, err := r.paginatedResults(ctx)
+	// Leaves of and/or queries are evaluated without pagination, since the
+	// combined results are sorted and paginated instead.
+	if r.query.BoolValue("stable") && r.pagination != nil {
+		result, err := r.withoutStreaming(ctx, r.paginatedResults)
 		if err != nil {
 			return nil, err
 		}
@@ -582,7 +584,7 @@ func (r *searchResolver) evaluateLeaf(ctx context.Context) (*SearchResultsResolv
 	// If the request is a paginated one, we handle it separately. See
 	// paginatedResults for more details.
 	if r.pagination != nil {
-		return r.paginatedResults(ctx)
+		return r.withoutStreaming(ctx, r.paginatedResults)
 	}
 
 	rr, err := r.resultsWithTimeoutSuggestion(ctx)
@@ -612,7 +614,9 @@ func (r *searchResolver) evaluateLeaf(ctx context.Context) (*SearchResultsResolv
 	return rr, err
 }
 
-// union returns the union of two sets of search results and merges common search data.
+// union returns the union of two sets of search results and merges common
+// search data. File matches for the same file are merged into one result, and
+// so are repository results for the same repository.
 func union(left, right *SearchResultsResolver) *SearchResultsResolver {
 	if right == nil {
 		return left
@@ -620,63 +624,210 @@ func union(left, right *SearchResultsResolver) *SearchResultsResolver {
 	if left == nil {
 		return right
 	}
-	if left.SearchResults != nil && right.SearchResults != nil {
-		left.SearchResults = append(left.SearchResults, right.SearchResults...)
-		// merge common search data.
-		left.searchResultsCommon.update(right.searchResultsCommon)
+	if left.SearchResults == nil {
+		if right.SearchResults != nil {
+			return right
+		}
 		return left
-	} else if right.SearchResults != nil {
-		return right
 	}
+	if right.SearchResults == nil {
+		return left
+	}
+
+	var merged resultSet
+	for _, r := range left.SearchResults {
+		merged.add(r)
+	}
+	for _, r := range right.SearchResults {
+		merged.add(r)
+	}
+	left.SearchResults = merged.results
+	// merge common search data.
+	left.searchResultsCommon.update(right.searchResultsCommon)
+	left.searchResultsCommon.resultCount = left.MatchCount()
 	return left
 }
 
-// intersect returns the intersection of two sets of search result content
-// matches, based on whether a single file path contains content matches in both
-// sets.
+// intersect returns the intersection of two sets of search results. A file
+// match is part of the intersection if the same file matches in both sets, or
+// if its repository is a repository result of the other set, as is the case
+// for operands that only scope the search to repositories, like
+// (repo:foo or repo:bar) and pattern. Repository results are part of the
+// intersection if they are in both sets.
 func intersect(left, right *SearchResultsResolver) (*SearchResultsResolver, error) {
 	if left == nil || right == nil {
 		return nil, nil
 	}
 
-	rFileMatches := make(map[string]*FileMatchResolver)
-
+	var (
+		rFileMatches       = make(map[string]*FileMatchResolver)
+		rFileMatchesByRepo = make(map[api.RepoName][]*FileMatchResolver)
+		rRepos             = make(map[api.RepoName]bool)
+	)
 	for _, r := range right.SearchResults {
 		if fileMatch, ok := r.ToFileMatch(); ok {
 			rFileMatches[fileMatch.uri] = fileMatch
+			if fileMatch.Repo != nil {
+				rFileMatchesByRepo[fileMatch.Repo.Name] = append(rFileMatchesByRepo[fileMatch.Repo.Name], fileMatch)
+			}
+		} else if repo, ok := r.ToRepository(); ok {
+			rRepos[repo.repo.Name] = true
 		}
 	}
 
-	var merged []SearchResultResolver
+	var merged resultSet
 	for _, ltmp := range left.SearchResults {
-		ltmpFileMatch, ok := ltmp.ToFileMatch()
-		if !ok {
+		if ltmpFileMatch, ok := ltmp.ToFileMatch(); ok {
+			if rtmpFileMatch := rFileMatches[ltmpFileMatch.uri]; rtmpFileMatch != nil {
+				merged.add(ltmpFileMatch)
+				merged.add(rtmpFileMatch)
+			} else if ltmpFileMatch.Repo != nil && rRepos[ltmpFileMatch.Repo.Name] {
+				merged.add(ltmpFileMatch)
+			}
 			continue
 		}
 
-		rtmpFileMatch := rFileMatches[ltmpFileMatch.uri]
-		if rtmpFileMatch == nil {
-			continue
+		if repo, ok := ltmp.ToRepository(); ok {
+			if rRepos[repo.repo.Name] {
+				merged.add(repo)
+			}
+			for _, rtmpFileMatch := range rFileMatchesByRepo[repo.repo.Name] {
+				merged.add(rtmpFileMatch)
+			}
 		}
-
-		ltmpFileMatch.JLineMatches = append(ltmpFileMatch.JLineMatches, rtmpFileMatch.JLineMatches...)
-		merged = append(merged, ltmp)
 	}
-	left.SearchResults = merged
+	left.SearchResults = merged.results
 	left.searchResultsCommon.update(right.searchResultsCommon)
 	// for intersect we want the newly computed intersection size.
-	left.searchResultsCommon.resultCount = int32(len(merged))
+	left.searchResultsCommon.resultCount = left.MatchCount()
 	return left, nil
 }
 
+// resultSet is a list of search results in which file matches for the same
+// file and repository results for the same repository are deduplicated. The
+// zero value is an empty set.
+type resultSet struct {
+	results     []SearchResultResolver
+	fileMatches map[string]*FileMatchResolver
+	repos       map[api.RepoName]bool
+}
+
+// add adds r to the set. A file match for a file that is already in the set is
+// merged into the existing file match.
+func (s *resultSet) add(r SearchResultResolver) {
+	if fileMatch, ok := r.ToFileMatch(); ok {
+		if s.fileMatches == nil {
+			s.fileMatches = make(map[string]*FileMatchResolver)
+		}
+		if existing := s.fileMatches[fileMatch.uri]; existing != nil {
+			if existing != fileMatch {
+				mergeFileMatches(existing, fileMatch)
+			}
+			return
+		}
+		s.fileMatches[fileMatch.uri] = fileMatch
+	} else if repo, ok := r.ToRepository(); ok {
+		if s.repos == nil {
+			s.repos = make(map[api.RepoName]bool)
+		}
+		if s.repos[repo.repo.Name] {
+			return
+		}
+		s.repos[repo.repo.Name] = true
+	}
+	s.results = append(s.results, r)
+}
+
+// mergeFileMatches merges the line matches of src into dst, such that matches
+// found in both are only reported once.
+func mergeFileMatches(dst, src *FileMatchResolver) {
+	lines := make(map[int32]*lineMatch, len(dst.JLineMatches))
+	for _, lm := range dst.JLineMatches {
+		lines[lm.JLineNumber] = lm
+	}
+
+	for _, lm := range src.JLineMatches {
+		existing, ok := lines[lm.JLineNumber]
+		if !ok {
+			lm := *lm
+			dst.JLineMatches = append(dst.JLineMatches, &lm)
+			lines[lm.JLineNumber] = &lm
+			dst.MatchCount += len(lm.JOffsetAndLengths)
+			continue
+		}
+
+		for _, ol := range lm.JOffsetAndLengths {
+			if !containsOffsetAndLength(existing.JOffsetAndLengths, ol) {
+				existing.JOffsetAndLengths = append(existing.JOffsetAndLengths, ol)
+				dst.MatchCount++
+			}
+		}
+		sort.Slice(existing.JOffsetAndLengths, func(i, j int) bool {
+			return existing.JOffsetAndLengths[i][0] < existing.JOffsetAndLengths[j][0]
+		})
+		existing.JLimitHit = existing.JLimitHit || lm.JLimitHit
+	}
+
+	sort.Slice(dst.JLineMatches, func(i, j int) bool {
+		return dst.JLineMatches[i].JLineNumber < dst.JLineMatches[j].JLineNumber
+	})
+	for _, sm := range src.JStructuralMatches {
+		if !containsStructuralMatch(dst.JStructuralMatches, sm) {
+			dst.JStructuralMatches = append(dst.JStructuralMatches, sm)
+		}
+	}
+	sort.Slice(dst.JStructuralMatches, func(i, j int) bool {
+		return dst.JStructuralMatches[i].JRange.Start.Offset < dst.JStructuralMatches[j].JRange.Start.Offset
+	})
+
+	dst.symbols = append(dst.symbols, src.symbols...)
+	dst.JLimitHit = dst.JLimitHit || src.JLimitHit
+}
+
+func containsStructuralMatch(sms []*structuralMatch, sm *structuralMatch) bool {
+	for _, s := range sms {
+		if s.JRange == sm.JRange {
+			return true
+		}
+	}
+	return false
+}
+
+func containsOffsetAndLength(ols [][2]int32, ol [2]int32) bool {
+	for _, o := range ols {
+		if o == ol {
+			return true
+		}
+	}
+	return false
+}
+
+// limitResults truncates result to the first limit search results in sorted
+// order, and marks it as having hit the limit if any results were dropped.
+func limitResults(result *SearchResultsResolver, limit int) {
+	if len(result.SearchResults) <= limit {
+		return
+	}
+	sortResults(result.SearchResults)
+	result.SearchResults = result.SearchResults[:limit]
+	result.searchResultsCommon.resultCount = result.MatchCount()
+	result.searchResultsCommon.limitHit = true
+}
+
+// maxResultsForRetry caps the number of search results that evaluateAnd
+// requests for each expression when it retries because search continues to not
+// be exhaustive. It alerts if this is exceeded.
+const maxResultsForRetry = 20000
+
 // evaluateAnd performs set intersection on result sets. It collects results for
 // all expressions that are ANDed together by searching for each subexpression
 // and then intersects those results that are in the same repo/file path. To
 // collect N results for count:N, we need to opportunistically ask for more than
 // N results for each subexpression (since intersect can never yield more than N,
-// and likely yields fewer than N results). Thus, we perform a search of 2*N for
-// each expression, and if the intersection does not yield N results, and is not
-// exhaustive for every expression, we rerun the search by doubling count again.
+// and likely yields fewer than N results). Thus, we perform a search of 1000*N
+// for each expression, and if the intersection does not yield N results, and is
+// not exhaustive for every expression, we rerun the search by doubling count
+// again.
 func (r *searchResolver) evaluateAnd(ctx context.Context, scopeParameters []query.Node, operands []query.Node) (*SearchResultsResolver, error) {
 	if len(operands) == 0 {
 		return nil, nil
@@ -699,14 +850,22 @@ func (r *searchResolver) evaluateAnd(ctx context.Context, scopeParameters []quer
 		// Override the value of count, if specified.
 		want, _ = strconv.Atoi(countStr) // Invariant: count is validated.
 	} else {
-		scopeParameters = append(scopeParameters, query.Parameter{
+		scopeParameters = append(scopeParameters[:len(scopeParameters):len(scopeParameters)], query.Parameter{
 			Field: "count",
 			Value: strconv.FormatInt(int64(want), 10),
 		})
 	}
 
-	tryCount := want * 1000     // Opportunistic approximation for the number of results to get for an intersection.
-	maxResultsForRetry := 20000 // When we retry, cap the max search results we request for each expression if search continues to not be exhaustive. Alert if exceeded.
+	// Opportunistic approximation for the number of results to get for an
+	// intersection. It is capped, since want can be large, e.g. when it is
+	// the count needed to fill a page of results.
+	tryCount := want * 1000
+	if tryCount > maxResultsForRetry {
+		tryCount = maxResultsForRetry
+	}
+	if tryCount < want {
+		tryCount = want
+	}
 
 	var exhausted bool
 	for {
@@ -717,43 +876,59 @@ func (r *searchResolver) evaluateAnd(ctx context.Context, scopeParameters []quer
 			return query.Parameter{Field: field, Value: value, Negated: negated}
 		})
 
-		result, err = r.evaluatePatternExpression(ctx, scopeParameters, operands[0])
+		result, err = r.evaluateExpression(ctx, scopeParameters, operands[0])
 		if err != nil {
 			return nil, err
 		}
+		if result == nil || result.alert != nil {
+			return result, nil
+		}
 		exhausted = !result.limitHit
 		for _, term := range operands[1:] {
-			new, err = r.evaluatePatternExpression(ctx, scopeParameters, term)
+			if exhausted && len(result.SearchResults) == 0 {
+				// The intersection is empty, no matter what the
+				// remaining expressions yield.
+				break
+			}
+			new, err = r.evaluateExpression(ctx, scopeParameters, term)
 			if err != nil {
 				return nil, err
 			}
 			if new != nil {
+				if new.alert != nil {
+					return new, nil
+				}
 				exhausted = exhausted && !new.limitHit
 				result, err = intersect(result, new)
+				if err != nil {
+					return nil, err
+				}
 			}
 		}
 		if exhausted {
 			break
 		}
-		if result.searchResultsCommon.resultCount >= int32(want) {
+		if len(result.SearchResults) >= want {
 			break
 		}
 		// If the result size set is not big enough, and we haven't
 		// exhausted search on all expressions, double the tryCount and search more.
-		tryCount *= tryCount
+		tryCount *= 2
 		if tryCount > maxResultsForRetry {
 			// We've capped out what we're willing to do, throw alert.
 			return &SearchResultsResolver{alert: alertForCappedAndExpression()}, nil
 		}
 	}
 	result.limitHit = !exhausted
+	limitResults(result, want)
 	return result, nil
 }
 
 // evaluateOr performs set union on result sets. It collects results for all
-// expressions that are ORed together by searching for each subexpression. If
-// the maximum number of results are reached after evaluating a subexpression,
-// we shortcircuit and return results immediately.
+// expressions that are ORed together by searching for each subexpression, and
+// keeps the first count:N results in sorted order. Every subexpression is
+// evaluated, so that the kept results don't depend on the order of the
+// subexpressions, which keeps pages of paginated requests consistent.
 func (r *searchResolver) evaluateOr(ctx context.Context, scopeParameters []query.Node, operands []query.Node) (*SearchResultsResolver, error) {
 	if len(operands) == 0 {
 		return nil, nil
@@ -768,29 +943,16 @@ func (r *searchResolver) evaluateOr(ctx context.Context, scopeParameters []query
 		wantCount, _ = strconv.Atoi(countStr) // Invariant: count is validated.
 	}
 
-	result, err := r.evaluatePatternExpression(ctx, scopeParameters, operands[0])
-	if err != nil {
-		return nil, err
-	}
-	if result.searchResultsCommon.resultCount > int32(wantCount) {
-		result.SearchResults = result.SearchResults[:wantCount]
-		result.searchResultsCommon.resultCount = int32(wantCount)
-		return result, nil
-	}
-	var new *SearchResultsResolver
-	for _, term := range operands[1:] {
-		new, err = r.evaluatePatternExpression(ctx, scopeParameters, term)
+	var result *SearchResultsResolver
+	for _, term := range operands {
+		new, err := r.evaluateExpression(ctx, scopeParameters, term)
 		if err != nil {
 			return nil, err
 		}
-		if new != nil {
-			result = union(result, new)
-			if result.searchResultsCommon.resultCount > int32(wantCount) {
-				result.SearchResults = result.SearchResults[:wantCount]
-				result.searchResultsCommon.resultCount = int32(wantCount)
-				return result, nil
-			}
-		}
+		result = union(result, new)
+	}
+	if result != nil {
+		limitResults(result, wantCount)
 	}
 	return result, nil
 }
@@ -819,43 +981,171 @@ func (r *searchResolver) evaluatePatternExpression(ctx context.Context, scopePar
 		if term.Kind == query.And || term.Kind == query.Or {
 			return r.evaluateOperator(ctx, scopeParameters, term)
 		} else if term.Kind == query.Concat {
-			q := append(scopeParameters, term)
-			r.query = query.AndOrQuery{Query: q}
-			return r.evaluateLeaf(ctx)
+			return r.evaluateLeafQuery(ctx, scopeParameters, term)
 		}
 	case query.Parameter:
-		q := append(scopeParameters, term)
-		r.query = query.AndOrQuery{Query: q}
-		return r.evaluateLeaf(ctx)
+		return r.evaluateLeafQuery(ctx, scopeParameters, term)
 	}
 	// Unreachable.
 	return nil, fmt.Errorf("unrecognized type %s in evaluatePatternExpression", reflect.TypeOf(node).String())
 }
 
+// evaluateExpression evaluates an arbitrary and/or expression. Unlike
+// evaluatePatternExpression, the operands of the expression may scope their
+// own search patterns with parameters, as in (repo:foo a) or (repo:bar b).
+// Operands of an and-expression that are parameters scope all other operands.
+func (r *searchResolver) evaluateExpression(ctx context.Context, scopeParameters []query.Node, node query.Node) (*SearchResultsResolver, error) {
+	parameters, pattern, err := query.PartitionSearchPattern([]query.Node{node})
+	if err == nil {
+		scope := append(scopeParameters[:len(scopeParameters):len(scopeParameters)], parameters...)
+		if pattern == nil {
+			return r.evaluateLeafQuery(ctx, scope)
+		}
+		return r.evaluatePatternExpression(ctx, scope, pattern)
+	}
+
+	operator, ok := node.(query.Operator)
+	if !ok {
+		return nil, err
+	}
+	switch operator.Kind {
+	case query.And:
+		scope := scopeParameters[:len(scopeParameters):len(scopeParameters)]
+		var operands []query.Node
+		for _, operand := range operator.Operands {
+			if parameter, ok := operand.(query.Parameter); ok && parameter.Field != "" && parameter.Field != "content" {
+				scope = append(scope, parameter)
+				continue
+			}
+			operands = append(operands, operand)
+		}
+		if len(operands) == 0 {
+			return r.evaluateLeafQuery(ctx, scope)
+		}
+		if len(operands) == 1 {
+			return r.evaluateExpression(ctx, scope, operands[0])
+		}
+		return r.evaluateAnd(ctx, scope, operands)
+	case query.Or:
+		return r.evaluateOr(ctx, scopeParameters, operator.Operands)
+	}
+	return nil, err
+}
+
+// evaluateLeafQuery evaluates the query made up of the given nodes as a single
+// search operation.
+func (r *searchResolver) evaluateLeafQuery(ctx context.Context, scopeParameters []query.Node, nodes ...query.Node) (*SearchResultsResolver, error) {
+	q := make([]query.Node, 0, len(scopeParameters)+len(nodes))
+	q = append(q, scopeParameters...)
+	q = append(q, nodes...)
+	r.query = &query.AndOrQuery{Query: q}
+	if mockEvaluateLeaf != nil {
+		return mockEvaluateLeaf(r.query)
+	}
+	return r.evaluateLeaf(ctx)
+}
+
+// mockEvaluateLeaf mocks the search operations of the leaf expressions of
+// and/or queries.
+var mockEvaluateLeaf func(q query.QueryInfo) (*SearchResultsResolver, error)
+
 // evaluate evaluates all expressions of a search query.
 func (r *searchResolver) evaluate(ctx context.Context, q []query.Node) (*SearchResultsResolver, error) {
-	scopeParameters, pattern, err := query.PartitionSearchPattern(q)
-	if err != nil {
-		return &SearchResultsResolver{alert: alertForQuery("", err)}, nil
+	if len(q) == 0 {
+		return r.evaluateLeafQuery(ctx, nil)
 	}
-	if pattern == nil {
-		r.query = query.AndOrQuery{Query: scopeParameters}
-		return r.evaluateLeaf(ctx)
+
+	// Leaf expressions are evaluated without pagination. Instead, the
+	// combined results are paginated.
+	pagination := r.pagination
+	if pagination != nil {
+		r.pagination = nil
+		defer func() { r.pagination = pagination }()
+		q = withPaginationCount(q, pagination)
+	}
+
+	node := q[0]
+	if len(q) > 1 {
+		node = query.Operator{Kind: query.And, Operands: q}
 	}
-	result, err := r.evaluatePatternExpression(ctx, scopeParameters, pattern)
+	result, err := r.evaluateExpression(ctx, nil, node)
 	if err != nil {
+		if _, ok := err.(*query.UnsupportedError); ok {
+			return &SearchResultsResolver{alert: alertForQuery("", err)}, nil
+		}
 		return nil, err
 	}
+	if result == nil {
+		result = &SearchResultsResolver{}
+	}
 	sortResults(result.SearchResults)
+	if pagination != nil && result.alert == nil {
+		paginateResults(result, pagination)
+	}
 	return result, nil
 }
 
+// withPaginationCount returns q with a count: parameter that requests enough
+// results to fill the requested page, unless q already specifies a count.
+func withPaginationCount(q []query.Node, pagination *searchPaginationInfo) []query.Node {
+	var hasCount bool
+	query.VisitField(q, "count", func(_ string, _, _ bool) {
+		hasCount = true
+	})
+	if hasCount {
+		return q
+	}
+
+	count := pagination.limit
+	if pagination.cursor != nil {
+		count += pagination.cursor.ResultOffset
+	}
+	return append(q[:len(q):len(q)], query.Parameter{
+		Field: "count",
+		Value: strconv.FormatInt(int64(count), 10),
+	})
+}
+
+// paginateResults reduces the sorted results of an and/or query to the page
+// described by pagination. Unlike for other paginated searches, the cursor is
+// an offset into the combined results rather than into the searched
+// repositories.
+func paginateResults(result *SearchResultsResolver, pagination *searchPaginationInfo) {
+	var offset int32
+	if pagination.cursor != nil {
+		offset = pagination.cursor.ResultOffset
+	}
+
+	n := int32(len(result.SearchResults))
+	if offset > n {
+		offset = n
+	}
+	end := offset + pagination.limit
+	if end > n {
+		end = n
+	}
+
+	result.SearchResults = result.SearchResults[offset:end]
+	result.searchResultsCommon.resultCount = result.MatchCount()
+	result.cursor = &searchCursor{
+		ResultOffset: end,
+		Finished:     end == n && !result.limitHit,
+	}
+	// As for stable searches, limitHit = true implies there is a next
+	// cursor, and more results may exist.
+	result.searchResultsCommon.limitHit = !result.cursor.Finished
+}
+
 func (r *searchResolver) Results(ctx context.Context) (*SearchResultsResolver, error) {
 	switch q := r.query.(type) {
 	case *query.OrdinaryQuery:
 		return r.evaluateLeaf(ctx)
 	case *query.AndOrQuery:
-		return r.evaluate(ctx, q.Query)
+		// Results of and/or expressions can only be combined once every
+		// operand has been evaluated, so they are not streamed.
+		return r.withoutStreaming(ctx, func(ctx context.Context) (*SearchResultsResolver, error) {
+			return r.evaluate(ctx, q.Query)
+		})
 	}
 	// Unreachable.
 	return nil, fmt.Errorf("unrecognized type %s in searchResolver Results", reflect.TypeOf(r.query).String())
@@ -1273,10 +1563,13 @@ func (r *searchResolver) determineRepos(ctx context.Context, tr *trace.Trace, st
 }
 
 // Surface an alert if a query exceeds limits that we place on search. Currently limits
-// diff and commit searches where more than repoLimit repos need to be searched.
-func alertOnSearchLimit(resultTypes []string, args *search.TextParameters) ([]string, *searchAlert) {
+// diff and commit searches where more than repoLimit repos need to be searched. The
+// limit is higher when gitserver has a commit index of all repos, so that none of them
+// are searched with git log.
+func alertOnSearchLimit(ctx context.Context, resultTypes []string, args *search.TextParameters) ([]string, *searchAlert) {
 	var alert *searchAlert
 	repoLimit := 50
+	const indexedRepoLimit = 10000
 	if len(args.Repos) > repoLimit {
 		if len(resultTypes) == 1 {
 			resultType := resultTypes[0]
@@ -1288,6 +1581,13 @@ func alertOnSearchLimit(resultTypes []string, args *search.TextParameters) ([]st
 				if _, beforePresent := args.Query.Fields()["before"]; beforePresent {
 					break
 				}
+				if len(args.Repos) > indexedRepoLimit {
+					if useCommitIndexForAll(args.Repos) {
+						repoLimit = indexedRepoLimit
+					}
+				} else if commitIndexedForAll(ctx, args.Repos) {
+					break
+				}
 				resultTypes = []string{}
 				alert = &searchAlert{
 					prometheusType: "exceeded_diff_commit_search_limit",
@@ -1315,6 +1615,10 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 
 	start := time.Now()
 
+	// The search context is cancelled once the optional searches have had
+	// their budget, so ranking signals are fetched with the request context.
+	rankCtx := ctx
+
 	ctx, cancel, err := r.withTimeout(ctx)
 	if err != nil {
 		return nil, err
@@ -1388,6 +1692,26 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 		seenResultTypes = make(map[string]struct{})
 	)
 
+	// updateCommon merges other into common and reports the new totals to
+	// the stream, if any. The progress is sent without holding commonMu, so
+	// that a slow consumer of the stream doesn't block updates, but under
+	// progressMu, so that progress events are sent in order.
+	var progressMu sync.Mutex
+	updateCommon := func(other *searchResultsCommon) {
+		progressMu.Lock()
+		defer progressMu.Unlock()
+
+		commonMu.Lock()
+		common.update(*other)
+		var progress *SearchProgress
+		if r.stream != nil {
+			progress = common.progress()
+		}
+		commonMu.Unlock()
+
+		r.sendProgress(progress)
+	}
+
 	waitGroup := func(required bool) *sync.WaitGroup {
 		if args.UseFullDeadline {
 			// When a custom timeout is specified, all searches are required and get the full timeout.
@@ -1402,7 +1726,7 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 	// Apply search limits and generate warnings before firing off workers.
 	// This currently limits diff and commit search to a set number of
 	// repos, and removes the diff and commit resultTypes if it is breached.
-	resultTypes, alert = alertOnSearchLimit(resultTypes, &args)
+	resultTypes, alert = alertOnSearchLimit(ctx, resultTypes, &args)
 
 	searchedFileContentsOrPaths := false
 	for _, resultType := range resultTypes {
@@ -1430,11 +1754,10 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 					resultsMu.Lock()
 					results = append(results, repoResults...)
 					resultsMu.Unlock()
+					r.sendResults(repoResults)
 				}
 				if repoCommon != nil {
-					commonMu.Lock()
-					common.update(*repoCommon)
-					commonMu.Unlock()
+					updateCommon(repoCommon)
 				}
 			})
 		case "symbol":
@@ -1450,6 +1773,9 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 					multiErr = multierror.Append(multiErr, errors.Wrap(err, "symbol search failed"))
 					multiErrMu.Unlock()
 				}
+				// Stream before merging below, since merging may modify
+				// results that were already streamed by the file search.
+				r.sendFileMatches(symbolFileMatches)
 				for _, symbolFileMatch := range symbolFileMatches {
 					key := symbolFileMatch.uri
 					fileMatchesMu.Lock()
@@ -1464,9 +1790,7 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 					fileMatchesMu.Unlock()
 				}
 				if symbolsCommon != nil {
-					commonMu.Lock()
-					common.update(*symbolsCommon)
-					commonMu.Unlock()
+					updateCommon(symbolsCommon)
 				}
 			})
 		case "file", "path":
@@ -1480,7 +1804,7 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 			goroutine.Go(func() {
 				defer wg.Done()
 
-				fileResults, fileCommon, err := searchFilesInRepos(ctx, &args)
+				fileResults, fileCommon, err := searchFilesInReposStream(ctx, &args, r.sendFileMatches)
 				// Timeouts are reported through searchResultsCommon so don't report an error for them
 				if err != nil && !isContextError(ctx, err) {
 					multiErrMu.Lock()
@@ -1491,7 +1815,7 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 					// No results for structural search? Automatically search again and force Zoekt to resolve
 					// more potential file matches by setting a higher FileMatchLimit.
 					args.PatternInfo.FileMatchLimit = 1000
-					fileResults, fileCommon, err = searchFilesInRepos(ctx, &args)
+					fileResults, fileCommon, err = searchFilesInReposStream(ctx, &args, r.sendFileMatches)
 					if err != nil && !isContextError(ctx, err) {
 						multiErrMu.Lock()
 						multiErr = multierror.Append(multiErr, errors.Wrap(err, "text search failed"))
@@ -1511,6 +1835,7 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 						// merge line match results with an existing symbol result
 						m.JLimitHit = m.JLimitHit || r.JLimitHit
 						m.JLineMatches = r.JLineMatches
+						m.JStructuralMatches = r.JStructuralMatches
 					} else {
 						fileMatches[key] = r
 						resultsMu.Lock()
@@ -1520,9 +1845,7 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 					fileMatchesMu.Unlock()
 				}
 				if fileCommon != nil {
-					commonMu.Lock()
-					common.update(*fileCommon)
-					commonMu.Unlock()
+					updateCommon(fileCommon)
 				}
 			})
 		case "diff":
@@ -1557,11 +1880,10 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 					resultsMu.Lock()
 					results = append(results, diffResults...)
 					resultsMu.Unlock()
+					r.sendResults(diffResults)
 				}
 				if diffCommon != nil {
-					commonMu.Lock()
-					common.update(*diffCommon)
-					commonMu.Unlock()
+					updateCommon(diffCommon)
 				}
 			})
 		case "commit":
@@ -1597,11 +1919,10 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 					resultsMu.Lock()
 					results = append(results, commitResults...)
 					resultsMu.Unlock()
+					r.sendResults(commitResults)
 				}
 				if commitCommon != nil {
-					commonMu.Lock()
-					common.update(*commitCommon)
-					commonMu.Unlock()
+					updateCommon(commitCommon)
 				}
 			})
 		case "codemod":
@@ -1621,11 +1942,10 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 					resultsMu.Lock()
 					results = append(results, codemodResults...)
 					resultsMu.Unlock()
+					r.sendResults(codemodResults)
 				}
 				if codemodCommon != nil {
-					commonMu.Lock()
-					common.update(*codemodCommon)
-					commonMu.Unlock()
+					updateCommon(codemodCommon)
 				}
 			})
 		}
@@ -1667,7 +1987,11 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 		multiErr = nil
 	}
 
-	sortResults(results)
+	if mode, _ := rankingMode(r.query); mode == rankRelevance {
+		rankResults(rankCtx, results, args.PatternInfo)
+	} else {
+		sortResults(results)
+	}
 
 	resultsResolver := SearchResultsResolver{
 		start:               start,
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -19,6 +20,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
 	"github.com/sourcegraph/sourcegraph/internal/db/dbconn"
 	"github.com/sourcegraph/sourcegraph/internal/db/dbtesting"
 	"github.com/sourcegraph/sourcegraph/internal/endpoint"
@@ -27,6 +29,7 @@ import (
 	searchbackend "github.com/sourcegraph/sourcegraph/internal/search/backend"
 	"github.com/sourcegraph/sourcegraph/internal/search/query"
 	searchquerytypes "github.com/sourcegraph/sourcegraph/internal/search/query/types"
+	"github.com/sourcegraph/sourcegraph/schema"
 )
 
 func TestSearchResults(t *testing.T) {
@@ -1234,7 +1237,7 @@ func Test_commitAndDiffSearchLimits(t *testing.T) {
 			}
 		}
 
-		haveResultTypes, alert := alertOnSearchLimit(test.resultTypes, &search.TextParameters{
+		haveResultTypes, alert := alertOnSearchLimit(context.Background(), test.resultTypes, &search.TextParameters{
 			Repos: repoRevs,
 			Query: &query.OrdinaryQuery{Query: &query.Query{Fields: test.fields}},
 		})
@@ -1261,6 +1264,45 @@ func Test_commitAndDiffSearchLimits(t *testing.T) {
 	}
 }
 
+func Test_commitAndDiffSearchLimits_commitIndex(t *testing.T) {
+	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{ExperimentalFeatures: &schema.ExperimentalFeatures{CommitSearchIndex: "enabled"}}})
+	defer conf.Mock(nil)
+
+	repoRevs := make([]*search.RepositoryRevisions, 51)
+	for i := range repoRevs {
+		repoRevs[i] = &search.RepositoryRevisions{
+			Repo: &types.Repo{ID: api.RepoID(i), Name: api.RepoName(fmt.Sprintf("repo%d", i))},
+		}
+	}
+	notIndexed := map[api.RepoName]bool{}
+	mockCommitIndexed = func(repos []api.RepoName) map[api.RepoName]bool {
+		indexed := make(map[api.RepoName]bool, len(repos))
+		for _, repo := range repos {
+			indexed[repo] = !notIndexed[repo]
+		}
+		return indexed
+	}
+	defer func() { mockCommitIndexed = nil }()
+
+	args := &search.TextParameters{
+		Repos: repoRevs,
+		Query: &query.OrdinaryQuery{Query: &query.Query{}},
+	}
+
+	// All repositories are searched with the commit index.
+	resultTypes, alert := alertOnSearchLimit(context.Background(), []string{"commit"}, args)
+	if alert != nil || !reflect.DeepEqual(resultTypes, []string{"commit"}) {
+		t.Errorf("got result types %q and alert %v, want commit search without alert", resultTypes, alert)
+	}
+
+	// One repository would be searched with git log.
+	notIndexed["repo7"] = true
+	resultTypes, alert = alertOnSearchLimit(context.Background(), []string{"commit"}, args)
+	if alert == nil || len(resultTypes) != 0 {
+		t.Errorf("got result types %q and alert %v, want an alert", resultTypes, alert)
+	}
+}
+
 func Test_ZoektSingleIndexedRepo(t *testing.T) {
 	repoRev := func(revSpec string) *search.RepositoryRevisions {
 		return &search.RepositoryRevisions{
@@ -1435,21 +1477,182 @@ func Test_SearchResultsResolver_ApproximateResultCount(t *testing.T) {
 }
 
 func TestSearchResolver_evaluateWarning(t *testing.T) {
-	q, _ := query.ProcessAndOr("file:foo or file:bar")
 	wantPrefix := "I'm having trouble understsanding that query."
-	andOrQuery, _ := q.(*query.AndOrQuery)
-	got, _ := (&searchResolver{}).evaluate(context.Background(), andOrQuery.Query)
-	t.Run("warn for unsupported and/or query", func(t *testing.T) {
-		if !strings.HasPrefix(got.alert.description, wantPrefix) {
-			t.Fatalf("got alert description %s, want %s", got.alert.description, wantPrefix)
-		}
-	})
-
 	_, err := query.ProcessAndOr("file:foo or or or")
 	gotAlert := alertForQuery("", err)
 	t.Run("warn for unsupported ambiguous and/or query", func(t *testing.T) {
 		if !strings.HasPrefix(gotAlert.description, wantPrefix) {
-			t.Fatalf("got alert description %s, want %s", got.alert.description, wantPrefix)
+			t.Fatalf("got alert description %s, want %s", gotAlert.description, wantPrefix)
+		}
+	})
+}
+
+func TestSearchResolver_evaluateAndOr(t *testing.T) {
+	fileMatch := func(repo, path string, lines ...int32) *FileMatchResolver {
+		fm := &FileMatchResolver{
+			JPath:      path,
+			uri:        "git://" + repo + "#" + path,
+			Repo:       &types.Repo{Name: api.RepoName(repo)},
+			MatchCount: len(lines),
+		}
+		for _, line := range lines {
+			fm.JLineMatches = append(fm.JLineMatches, &lineMatch{JLineNumber: line, JOffsetAndLengths: [][2]int32{{0, 1}}})
+		}
+		return fm
+	}
+	repo := func(name string) *RepositoryResolver {
+		return &RepositoryResolver{repo: &types.Repo{Name: api.RepoName(name)}}
+	}
+
+	// Results of the leaf expressions, keyed by their query without count.
+	leaves := map[string]func() []SearchResultResolver{
+		"repo:foo a": func() []SearchResultResolver {
+			return []SearchResultResolver{fileMatch("foo", "a.go", 1)}
+		},
+		"repo:bar b": func() []SearchResultResolver {
+			return []SearchResultResolver{fileMatch("bar", "b.go", 2)}
+		},
+		"a": func() []SearchResultResolver {
+			return []SearchResultResolver{fileMatch("foo", "a.go", 1, 3), fileMatch("foo", "both.go", 1)}
+		},
+		"b": func() []SearchResultResolver {
+			return []SearchResultResolver{fileMatch("foo", "b.go", 2), fileMatch("foo", "both.go", 1, 5)}
+		},
+		"repo:foo": func() []SearchResultResolver { return []SearchResultResolver{repo("foo")} },
+		"repo:bar": func() []SearchResultResolver { return []SearchResultResolver{repo("bar")} },
+		"x": func() []SearchResultResolver {
+			return []SearchResultResolver{fileMatch("foo", "x.go", 1), fileMatch("baz", "x.go", 1)}
+		},
+	}
+	var maxCount int
+	mockEvaluateLeaf = func(q query.QueryInfo) (*SearchResultsResolver, error) {
+		var key []string
+		query.VisitParameter(q.(*query.AndOrQuery).Query, func(field, value string, _, _ bool) {
+			switch field {
+			case "count":
+				if count, _ := strconv.Atoi(value); count > maxCount {
+					maxCount = count
+				}
+			case "", "content":
+				key = append(key, value)
+			default:
+				key = append(key, field+":"+value)
+			}
+		})
+		results, ok := leaves[strings.Join(key, " ")]
+		if !ok {
+			return nil, fmt.Errorf("unexpected leaf query %v", key)
+		}
+		return &SearchResultsResolver{SearchResults: results()}, nil
+	}
+	defer func() { mockEvaluateLeaf = nil }()
+
+	// summary returns a short description of each result.
+	summary := func(results []SearchResultResolver) []string {
+		var s []string
+		for _, r := range results {
+			if fm, ok := r.ToFileMatch(); ok {
+				var lines []string
+				for _, lm := range fm.JLineMatches {
+					lines = append(lines, fmt.Sprint(lm.JLineNumber))
+				}
+				s = append(s, fmt.Sprintf("%s/%s:%s", fm.Repo.Name, fm.JPath, strings.Join(lines, ",")))
+			} else if repo, ok := r.ToRepository(); ok {
+				s = append(s, string(repo.repo.Name))
+			}
+		}
+		return s
+	}
+
+	for _, tc := range []struct {
+		query string
+		want  []string
+	}{
+		{
+			query: "(repo:foo a) or (repo:bar b)",
+			want:  []string{"bar/b.go:2", "foo/a.go:1"},
+		},
+		{
+			query: "a or b",
+			want:  []string{"foo/a.go:1,3", "foo/b.go:2", "foo/both.go:1,5"},
+		},
+		{
+			query: "a and b",
+			want:  []string{"foo/both.go:1,5"},
+		},
+		{
+			query: "(repo:foo or repo:bar) and x",
+			want:  []string{"foo/x.go:1"},
+		},
+		{
+			query: "repo:foo or repo:bar",
+			want:  []string{"bar", "foo"},
+		},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			q, err := query.ProcessAndOr(tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := (&searchResolver{}).evaluate(context.Background(), q.(*query.AndOrQuery).Query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.alert != nil {
+				t.Fatalf("unexpected alert: %s", result.alert.title)
+			}
+			if diff := cmp.Diff(tc.want, summary(result.SearchResults)); diff != "" {
+				t.Error(diff)
+			}
+			if have, want := result.MatchCount(), result.searchResultsCommon.resultCount; have != want {
+				t.Errorf("got result count %d, want %d", want, have)
+			}
+		})
+	}
+
+	t.Run("pagination", func(t *testing.T) {
+		q, err := query.ProcessAndOr("a or b")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var pages [][]string
+		var cursor *searchCursor
+		for i := 0; i < 3; i++ {
+			r := &searchResolver{pagination: &searchPaginationInfo{cursor: cursor, limit: 2}}
+			result, err := r.evaluate(context.Background(), q.(*query.AndOrQuery).Query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.pagination == nil {
+				t.Fatal("expected pagination to be restored after evaluation")
+			}
+			pages = append(pages, summary(result.SearchResults))
+			cursor = result.cursor
+			if cursor.Finished {
+				break
+			}
+		}
+
+		want := [][]string{{"foo/a.go:1,3", "foo/b.go:2"}, {"foo/both.go:1,5"}}
+		if diff := cmp.Diff(want, pages); diff != "" {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("pagination of intersections", func(t *testing.T) {
+		q, err := query.ProcessAndOr("a and b")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		maxCount = 0
+		r := &searchResolver{pagination: &searchPaginationInfo{cursor: &searchCursor{ResultOffset: 100}, limit: 50}}
+		if _, err := r.evaluate(context.Background(), q.(*query.AndOrQuery).Query); err != nil {
+			t.Fatal(err)
+		}
+		if maxCount != maxResultsForRetry {
+			t.Errorf("got count %d for the expressions, want it capped at %d", maxCount, maxResultsForRetry)
 		}
 	})
 }
@@ -0,0 +1,118 @@
+package graphqlbackend
+
+import (
+	"context"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+// SearchEvent is sent on SearchArgs.Stream while a search is running. Each
+// event carries either a batch of new results or a progress update.
+//
+// Results in a stream are not deduplicated across events: a file that has
+// both symbol and content matches may be sent twice.
+type SearchEvent struct {
+	Results  []SearchResultResolver
+	Progress *SearchProgress
+}
+
+// SearchProgress describes how far a streaming search has progressed. Each
+// progress event describes the whole search so far, not just the change
+// since the previous event.
+type SearchProgress struct {
+	MatchCount int32
+	LimitHit   bool
+
+	Searched int
+	Indexed  int
+	Cloning  []api.RepoName
+	Missing  []api.RepoName
+	Timedout []api.RepoName
+}
+
+// progress returns a snapshot of c suitable for sending to a stream.
+func (c *searchResultsCommon) progress() *SearchProgress {
+	count := func(repos types.Repos) int {
+		repos = append(types.Repos(nil), repos...)
+		dedupSort(&repos)
+		return len(repos)
+	}
+	names := func(repos types.Repos) []api.RepoName {
+		repos = append(types.Repos(nil), repos...)
+		dedupSort(&repos)
+		if len(repos) == 0 {
+			return nil
+		}
+		names := make([]api.RepoName, len(repos))
+		for i, repo := range repos {
+			names[i] = repo.Name
+		}
+		return names
+	}
+	return &SearchProgress{
+		MatchCount: c.resultCount,
+		LimitHit:   c.LimitHit(),
+		Searched:   count(c.searched),
+		Indexed:    count(c.indexed),
+		Cloning:    names(c.cloning),
+		Missing:    names(c.missing),
+		Timedout:   names(c.timedout),
+	}
+}
+
+// sendResults sends results to the stream, if the search is being streamed.
+// It must only be called while r.Results is running.
+func (r *searchResolver) sendResults(results []SearchResultResolver) {
+	if r.stream == nil || len(results) == 0 {
+		return
+	}
+	r.stream <- SearchEvent{Results: results}
+}
+
+// sendFileMatches sends file matches to the stream, if the search is being
+// streamed. It sends copies because doResults merges symbol and content
+// matches for the same file into one FileMatchResolver after the fact.
+func (r *searchResolver) sendFileMatches(matches []*FileMatchResolver) {
+	if r.stream == nil || len(matches) == 0 {
+		return
+	}
+	results := make([]SearchResultResolver, len(matches))
+	for i, m := range matches {
+		m := *m
+		results[i] = &m
+	}
+	r.sendResults(results)
+}
+
+// sendProgress sends a progress snapshot (see searchResultsCommon.progress)
+// to the stream, if the search is being streamed.
+func (r *searchResolver) sendProgress(progress *SearchProgress) {
+	if r.stream == nil || progress == nil {
+		return
+	}
+	r.stream <- SearchEvent{Progress: progress}
+}
+
+// withoutStreaming runs search with streaming disabled and then sends all
+// of its results to the stream in one event. It is used for searches whose
+// results can only be known once every sub-search has finished, such as
+// and/or queries and paginated searches.
+func (r *searchResolver) withoutStreaming(ctx context.Context, search func(context.Context) (*SearchResultsResolver, error)) (*SearchResultsResolver, error) {
+	stream := r.stream
+	if stream == nil {
+		return search(ctx)
+	}
+
+	r.stream = nil
+	defer func() { r.stream = stream }()
+
+	rr, err := search(ctx)
+	if rr != nil {
+		if len(rr.SearchResults) > 0 {
+			stream <- SearchEvent{Results: rr.SearchResults}
+		}
+		stream <- SearchEvent{Progress: rr.searchResultsCommon.progress()}
+	}
+	return rr, err
+}
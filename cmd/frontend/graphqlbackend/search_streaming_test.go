@@ -0,0 +1,128 @@
+package graphqlbackend
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+)
+
+func TestSearchResults_Stream(t *testing.T) {
+	db.Mocks.Repos.List = func(_ context.Context, op db.ReposListOptions) ([]*types.Repo, error) {
+		return []*types.Repo{{ID: 1, Name: "repo1"}, {ID: 2, Name: "repo2"}}, nil
+	}
+	defer func() { db.Mocks = db.MockStores{} }()
+
+	mockSearchRepositories = func(args *search.TextParameters) ([]SearchResultResolver, *searchResultsCommon, error) {
+		return nil, &searchResultsCommon{}, nil
+	}
+	defer func() { mockSearchRepositories = nil }()
+
+	mockSearchFilesInRepos = func(args *search.TextParameters) ([]*FileMatchResolver, *searchResultsCommon, error) {
+		repo1 := &types.Repo{ID: 1, Name: "repo1"}
+		repo2 := &types.Repo{ID: 2, Name: "repo2"}
+		return []*FileMatchResolver{
+			{uri: "git://repo1#a", JPath: "a", JLineMatches: []*lineMatch{{JLineNumber: 1}}, MatchCount: 1, Repo: repo1},
+			{uri: "git://repo2#b", JPath: "b", JLineMatches: []*lineMatch{{JLineNumber: 2}}, MatchCount: 1, Repo: repo2},
+		}, &searchResultsCommon{
+			repos:       []*types.Repo{repo1, repo2},
+			searched:    []*types.Repo{repo1},
+			timedout:    []*types.Repo{repo2},
+			resultCount: 2,
+		}, nil
+	}
+	defer func() { mockSearchFilesInRepos = nil }()
+
+	events := make(chan SearchEvent)
+	r, err := (&schemaResolver{}).Search(&SearchArgs{Query: "foo", Version: "V2", Stream: events})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		paths    []string
+		progress *SearchProgress
+		done     = make(chan struct{})
+	)
+	go func() {
+		defer close(done)
+		for event := range events {
+			for _, result := range event.Results {
+				if fm, ok := result.ToFileMatch(); ok {
+					paths = append(paths, fm.JPath)
+				}
+			}
+			if event.Progress != nil {
+				progress = event.Progress
+			}
+		}
+	}()
+
+	results, err := r.Results(context.Background())
+	close(events)
+	<-done
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(paths)
+	if want := []string{"a", "b"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("got streamed paths %v, want %v", paths, want)
+	}
+	if got, want := len(results.SearchResults), 2; got != want {
+		t.Errorf("got %d final results, want %d", got, want)
+	}
+
+	want := &SearchProgress{
+		MatchCount: 2,
+		Searched:   1,
+		Timedout:   []api.RepoName{"repo2"},
+	}
+	if !reflect.DeepEqual(progress, want) {
+		t.Errorf("got last progress %+v, want %+v", progress, want)
+	}
+}
+
+func TestSearchResults_StreamAndOr(t *testing.T) {
+	r := &searchResolver{}
+	events := make(chan SearchEvent, 2)
+	r.stream = events
+
+	want := &SearchResultsResolver{
+		SearchResults: []SearchResultResolver{&FileMatchResolver{JPath: "a", Repo: &types.Repo{Name: "repo"}}},
+	}
+	got, err := r.withoutStreaming(context.Background(), func(ctx context.Context) (*SearchResultsResolver, error) {
+		if r.stream != nil {
+			t.Error("expected streaming to be disabled while searching")
+		}
+		return want, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if r.stream == nil {
+		t.Error("expected streaming to be restored after searching")
+	}
+
+	close(events)
+	var results []SearchResultResolver
+	var sawProgress bool
+	for event := range events {
+		results = append(results, event.Results...)
+		sawProgress = sawProgress || event.Progress != nil
+	}
+	if !reflect.DeepEqual(results, want.SearchResults) {
+		t.Errorf("got streamed results %v, want %v", results, want.SearchResults)
+	}
+	if !sawProgress {
+		t.Error("expected a progress event")
+	}
+}
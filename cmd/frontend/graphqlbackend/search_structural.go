@@ -9,6 +9,7 @@ import (
 	"time"
 
 	zoektquery "github.com/google/zoekt/query"
+	"github.com/sourcegraph/go-lsp"
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/lazyregexp"
 	"github.com/sourcegraph/sourcegraph/internal/search"
@@ -244,3 +245,51 @@ func zoektSearchHEADOnlyFiles(ctx context.Context, args *search.TextParameters,
 
 	return matches, limitHit, reposLimitHit, nil
 }
+
+// structuralMatch is a match of a structural search pattern, as returned by
+// searcher.
+type structuralMatch struct {
+	JMatched     string                 `json:"Matched"`
+	JRange       structuralRange        `json:"Range"`
+	JEnvironment []*structuralMatchHole `json:"Environment"`
+}
+
+func (m *structuralMatch) Matched() string               { return m.JMatched }
+func (m *structuralMatch) Range() RangeResolver          { return m.JRange.resolver() }
+func (m *structuralMatch) StartOffset() int32            { return int32(m.JRange.Start.Offset) }
+func (m *structuralMatch) EndOffset() int32              { return int32(m.JRange.End.Offset) }
+func (m *structuralMatch) Holes() []*structuralMatchHole { return m.JEnvironment }
+
+// structuralMatchHole is the value bound to a hole of a structural search
+// pattern in a match.
+type structuralMatchHole struct {
+	JVariable string          `json:"Variable"`
+	JValue    string          `json:"Value"`
+	JRange    structuralRange `json:"Range"`
+}
+
+func (h *structuralMatchHole) Variable() string     { return h.JVariable }
+func (h *structuralMatchHole) Value() string        { return h.JValue }
+func (h *structuralMatchHole) Range() RangeResolver { return h.JRange.resolver() }
+func (h *structuralMatchHole) StartOffset() int32   { return int32(h.JRange.Start.Offset) }
+func (h *structuralMatchHole) EndOffset() int32     { return int32(h.JRange.End.Offset) }
+
+// structuralRange is a range in a file as returned by searcher. Lines and
+// columns are 0-based, and offsets are byte offsets from the start of the file.
+type structuralRange struct {
+	Start structuralLocation
+	End   structuralLocation
+}
+
+type structuralLocation struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+func (r structuralRange) resolver() RangeResolver {
+	return NewRangeResolver(lsp.Range{
+		Start: lsp.Position{Line: r.Start.Line, Character: r.Start.Column},
+		End:   lsp.Position{Line: r.End.Line, Character: r.End.Column},
+	})
+}
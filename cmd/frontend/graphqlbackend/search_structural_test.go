@@ -1,6 +1,9 @@
 package graphqlbackend
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	zoektquery "github.com/google/zoekt/query"
@@ -85,3 +88,51 @@ func TestStructuralPatToZoektQuery(t *testing.T) {
 		})
 	}
 }
+
+func TestTextSearchURL_structuralMatches(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"Matches": [{
+			"Path": "main.go",
+			"LineMatches": [{"Preview": "foo(a, b)", "LineNumber": 1, "OffsetAndLengths": [[0, 9]]}],
+			"StructuralMatches": [{
+				"Matched": "foo(a, b)",
+				"Range": {"Start": {"Offset": 5, "Line": 1, "Column": 0}, "End": {"Offset": 14, "Line": 1, "Column": 9}},
+				"Environment": [{
+					"Variable": "args",
+					"Value": "a, b",
+					"Range": {"Start": {"Offset": 9, "Line": 1, "Column": 4}, "End": {"Offset": 13, "Line": 1, "Column": 8}}
+				}]
+			}],
+			"MatchCount": 1
+		}]}`))
+	}))
+	defer srv.Close()
+
+	matches, _, err := textSearchURL(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || len(matches[0].StructuralMatches()) != 1 {
+		t.Fatalf("got %d file matches, want 1 with 1 structural match", len(matches))
+	}
+
+	m := matches[0].StructuralMatches()[0]
+	if m.Matched() != "foo(a, b)" || m.StartOffset() != 5 || m.EndOffset() != 14 {
+		t.Errorf("unexpected match %q at %d-%d", m.Matched(), m.StartOffset(), m.EndOffset())
+	}
+	if start, end := m.Range().Start(), m.Range().End(); start.Line() != 1 || start.Character() != 0 || end.Line() != 1 || end.Character() != 9 {
+		t.Errorf("unexpected range %d:%d-%d:%d", start.Line(), start.Character(), end.Line(), end.Character())
+	}
+
+	holes := m.Holes()
+	if len(holes) != 1 {
+		t.Fatalf("got %d holes, want 1", len(holes))
+	}
+	h := holes[0]
+	if h.Variable() != "args" || h.Value() != "a, b" || h.StartOffset() != 9 || h.EndOffset() != 13 {
+		t.Errorf("unexpected hole %s=%q at %d-%d", h.Variable(), h.Value(), h.StartOffset(), h.EndOffset())
+	}
+	if start := h.Range().Start(); start.Line() != 1 || start.Character() != 4 {
+		t.Errorf("unexpected hole start %d:%d", start.Line(), start.Character())
+	}
+}
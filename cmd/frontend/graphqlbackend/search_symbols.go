@@ -60,9 +60,23 @@ func searchSymbols(ctx context.Context, args *search.TextParameters, limit int)
 		tr.Finish()
 	}()
 
+	kinds, err := selectedSymbolKinds(args.Query)
+	if err != nil {
+		return nil, nil, err
+	}
 	if args.PatternInfo.Pattern == "" {
-		return nil, nil, nil
+		if len(kinds) == 0 {
+			return nil, nil, nil
+		}
+		// Return all symbols of the selected kinds.
+		patternInfo := *args.PatternInfo
+		patternInfo.Pattern = "."
+		patternInfo.IsRegExp = true
+		argsCopy := *args
+		argsCopy.PatternInfo = &patternInfo
+		args = &argsCopy
 	}
+	kindFilter := ctagsKindsOf(kinds)
 
 	ctx, cancelAll := context.WithCancel(ctx)
 	defer cancelAll()
@@ -161,6 +175,10 @@ func searchSymbols(ctx context.Context, args *search.TextParameters, limit int)
 	goroutine.Go(func() {
 		defer run.Release()
 		matches, limitHit, reposLimitHit, searchErr := zoektSearchHEAD(ctx, args, zoektRepos, true, time.Since)
+		if len(kinds) > 0 {
+			// Zoekt can't filter symbols by kind, so filter its results.
+			matches = filterSymbolKinds(matches, kinds)
+		}
 		mu.Lock()
 		defer mu.Unlock()
 		if ctx.Err() == nil {
@@ -194,7 +212,7 @@ func searchSymbols(ctx context.Context, args *search.TextParameters, limit int)
 		run.Acquire()
 		goroutine.Go(func() {
 			defer run.Release()
-			repoSymbols, repoErr := searchSymbolsInRepo(ctx, repoRevs, args.PatternInfo, args.Query, limit)
+			repoSymbols, repoErr := searchSymbolsInRepo(ctx, repoRevs, args.PatternInfo, kindFilter, limit)
 			if repoErr != nil {
 				tr.LogFields(otlog.String("repo", string(repoRevs.Repo.Name)), otlog.String("repoErr", repoErr.Error()), otlog.Bool("timeout", errcode.IsTimeout(repoErr)), otlog.Bool("temporary", errcode.IsTemporary(repoErr)))
 			}
@@ -251,7 +269,7 @@ func symbolCount(fmrs []*FileMatchResolver) int {
 	return nsym
 }
 
-func searchSymbolsInRepo(ctx context.Context, repoRevs *search.RepositoryRevisions, patternInfo *search.TextPatternInfo, query query.QueryInfo, limit int) (res []*FileMatchResolver, err error) {
+func searchSymbolsInRepo(ctx context.Context, repoRevs *search.RepositoryRevisions, patternInfo *search.TextPatternInfo, kinds []string, limit int) (res []*FileMatchResolver, err error) {
 	span, ctx := ot.StartSpanFromContext(ctx, "Search symbols in repo")
 	defer func() {
 		if err != nil {
@@ -286,6 +304,7 @@ func searchSymbolsInRepo(ctx context.Context, repoRevs *search.RepositoryRevisio
 		IsRegExp:        patternInfo.IsRegExp,
 		IncludePatterns: patternInfo.IncludePatterns,
 		ExcludePattern:  patternInfo.ExcludePattern,
+		Kinds:           kinds,
 		// Ask for limit + 1 so we can detect whether there are more results than the limit.
 		First: limit + 1,
 	})
@@ -357,62 +376,176 @@ func ctagsSymbolCharacter(s protocol.Symbol) int {
 	return 0
 }
 
+// ctagsKinds maps ctags kinds to LSP symbol kinds. Ctags kinds are determined by
+// the parser and do not (in general) match LSP symbol kinds.
+var ctagsKinds = map[string]lsp.SymbolKind{
+	"file":            lsp.SKFile,
+	"module":          lsp.SKModule,
+	"namespace":       lsp.SKNamespace,
+	"package":         lsp.SKPackage,
+	"packagename":     lsp.SKPackage,
+	"subprogspec":     lsp.SKPackage,
+	"class":           lsp.SKClass,
+	"type":            lsp.SKClass,
+	"service":         lsp.SKClass,
+	"typedef":         lsp.SKClass,
+	"union":           lsp.SKClass,
+	"section":         lsp.SKClass,
+	"subtype":         lsp.SKClass,
+	"component":       lsp.SKClass,
+	"method":          lsp.SKMethod,
+	"methodspec":      lsp.SKMethod,
+	"property":        lsp.SKProperty,
+	"field":           lsp.SKField,
+	"member":          lsp.SKField,
+	"anonmember":      lsp.SKField,
+	"recordfield":     lsp.SKField,
+	"constructor":     lsp.SKConstructor,
+	"enum":            lsp.SKEnum,
+	"enumerator":      lsp.SKEnum,
+	"interface":       lsp.SKInterface,
+	"function":        lsp.SKFunction,
+	"func":            lsp.SKFunction,
+	"subroutine":      lsp.SKFunction,
+	"macro":           lsp.SKFunction,
+	"subprogram":      lsp.SKFunction,
+	"procedure":       lsp.SKFunction,
+	"command":         lsp.SKFunction,
+	"singletonmethod": lsp.SKFunction,
+	"variable":        lsp.SKVariable,
+	"var":             lsp.SKVariable,
+	"functionvar":     lsp.SKVariable,
+	"define":          lsp.SKVariable,
+	"alias":           lsp.SKVariable,
+	"val":             lsp.SKVariable,
+	"constant":        lsp.SKConstant,
+	"const":           lsp.SKConstant,
+	"string":          lsp.SKString,
+	"message":         lsp.SKString,
+	"heredoc":         lsp.SKString,
+	"number":          lsp.SKNumber,
+	"bool":            lsp.SKBoolean,
+	"boolean":         lsp.SKBoolean,
+	"array":           lsp.SKArray,
+	"object":          lsp.SKObject,
+	"literal":         lsp.SKObject,
+	"map":             lsp.SKObject,
+	"key":             lsp.SKKey,
+	"label":           lsp.SKKey,
+	"target":          lsp.SKKey,
+	"selector":        lsp.SKKey,
+	"id":              lsp.SKKey,
+	"tag":             lsp.SKKey,
+	"null":            lsp.SKNull,
+	"enum member":     lsp.SKEnumMember,
+	"enumconstant":    lsp.SKEnumMember,
+	"struct":          lsp.SKStruct,
+	"event":           lsp.SKEvent,
+	"operator":        lsp.SKOperator,
+	"type parameter":  lsp.SKTypeParameter,
+	"annotation":      lsp.SKTypeParameter,
+}
+
 func ctagsKindToLSPSymbolKind(kind string) lsp.SymbolKind {
-	// Ctags kinds are determined by the parser and do not (in general) match LSP symbol kinds.
-	switch strings.ToLower(kind) {
-	case "file":
-		return lsp.SKFile
-	case "module":
-		return lsp.SKModule
-	case "namespace":
-		return lsp.SKNamespace
-	case "package", "packagename", "subprogspec":
-		return lsp.SKPackage
-	case "class", "type", "service", "typedef", "union", "section", "subtype", "component":
-		return lsp.SKClass
-	case "method", "methodspec":
-		return lsp.SKMethod
-	case "property":
-		return lsp.SKProperty
-	case "field", "member", "anonmember", "recordfield":
-		return lsp.SKField
-	case "constructor":
-		return lsp.SKConstructor
-	case "enum", "enumerator":
-		return lsp.SKEnum
-	case "interface":
-		return lsp.SKInterface
-	case "function", "func", "subroutine", "macro", "subprogram", "procedure", "command", "singletonmethod":
-		return lsp.SKFunction
-	case "variable", "var", "functionvar", "define", "alias", "val":
-		return lsp.SKVariable
-	case "constant", "const":
-		return lsp.SKConstant
-	case "string", "message", "heredoc":
-		return lsp.SKString
-	case "number":
-		return lsp.SKNumber
-	case "bool", "boolean":
-		return lsp.SKBoolean
-	case "array":
-		return lsp.SKArray
-	case "object", "literal", "map":
-		return lsp.SKObject
-	case "key", "label", "target", "selector", "id", "tag":
-		return lsp.SKKey
-	case "null":
-		return lsp.SKNull
-	case "enum member", "enumconstant":
-		return lsp.SKEnumMember
-	case "struct":
-		return lsp.SKStruct
-	case "event":
-		return lsp.SKEvent
-	case "operator":
-		return lsp.SKOperator
-	case "type parameter", "annotation":
-		return lsp.SKTypeParameter
+	if k, ok := ctagsKinds[strings.ToLower(kind)]; ok {
+		return k
 	}
 	log15.Debug("Unknown ctags kind", "kind", kind)
 	return 0
 }
+
+// selectSymbolPrefix is the prefix of select: values that select the kinds of
+// symbols to return, as in select:symbol.function.
+const selectSymbolPrefix = "symbol."
+
+// typeSymbolKinds are the symbol kinds selected by select:symbol.type.
+var typeSymbolKinds = []lsp.SymbolKind{lsp.SKClass, lsp.SKStruct, lsp.SKInterface, lsp.SKEnum}
+
+// selectedSymbolKinds returns the symbol kinds selected with the select:
+// field, which may be given multiple times to select symbols of any of the
+// kinds. It returns nil if no kinds are selected.
+func selectedSymbolKinds(q query.QueryInfo) ([]lsp.SymbolKind, error) {
+	values, _ := q.StringValues(query.FieldSelect)
+	if len(values) == 0 {
+		return nil, nil
+	}
+	resultTypes, _ := q.StringValues(query.FieldType)
+	if len(resultTypes) != 1 || resultTypes[0] != "symbol" {
+		return nil, fmt.Errorf("field %q can only be used with type:symbol", query.FieldSelect)
+	}
+
+	var kinds []lsp.SymbolKind
+	for _, value := range values {
+		name := strings.ToLower(value)
+		if name == strings.TrimSuffix(selectSymbolPrefix, ".") {
+			// select:symbol selects symbols of all kinds.
+			return nil, nil
+		}
+		if !strings.HasPrefix(name, selectSymbolPrefix) {
+			return nil, fmt.Errorf("invalid value %q for field %q, must be of the form %s<kind> (e.g. %sfunction)", value, query.FieldSelect, selectSymbolPrefix, selectSymbolPrefix)
+		}
+		name = strings.TrimPrefix(name, selectSymbolPrefix)
+		if name == "type" {
+			kinds = append(kinds, typeSymbolKinds...)
+			continue
+		}
+		kind := lspSymbolKind(name)
+		if kind == 0 {
+			return nil, fmt.Errorf("invalid value %q for field %q, unknown symbol kind %q", value, query.FieldSelect, name)
+		}
+		kinds = append(kinds, kind)
+	}
+	return kinds, nil
+}
+
+// lspSymbolKind returns the LSP symbol kind with the given name (compared
+// case-insensitively), or 0 if there is none.
+func lspSymbolKind(name string) lsp.SymbolKind {
+	for kind := lsp.SKFile; kind <= lsp.SKTypeParameter; kind++ {
+		if strings.EqualFold(kind.String(), name) {
+			return kind
+		}
+	}
+	return 0
+}
+
+// ctagsKindsOf returns the ctags kinds that map to any of the LSP symbol kinds,
+// for filtering symbols by kind in the symbols service.
+func ctagsKindsOf(kinds []lsp.SymbolKind) []string {
+	var ctags []string
+	for ctagsKind, kind := range ctagsKinds {
+		if hasSymbolKind(kinds, kind) {
+			ctags = append(ctags, ctagsKind)
+		}
+	}
+	sort.Strings(ctags)
+	return ctags
+}
+
+func hasSymbolKind(kinds []lsp.SymbolKind, kind lsp.SymbolKind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// filterSymbolKinds removes the symbols that are not of one of the kinds from
+// the file matches, and removes the file matches with no symbols left.
+func filterSymbolKinds(matches []*FileMatchResolver, kinds []lsp.SymbolKind) []*FileMatchResolver {
+	filtered := matches[:0]
+	for _, fm := range matches {
+		symbols := fm.symbols[:0]
+		for _, s := range fm.symbols {
+			if hasSymbolKind(kinds, ctagsKindToLSPSymbolKind(s.symbol.Kind)) {
+				symbols = append(symbols, s)
+			}
+		}
+		fm.symbols = symbols
+		if len(fm.symbols) > 0 {
+			filtered = append(filtered, fm)
+		}
+	}
+	return filtered
+}
@@ -6,9 +6,11 @@ import (
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	lsp "github.com/sourcegraph/go-lsp"
 
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/internal/gituri"
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
 	"github.com/sourcegraph/sourcegraph/internal/symbols/protocol"
 	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
 )
@@ -257,3 +259,60 @@ func Test_limitingSymbolResults(t *testing.T) {
 		}
 	})
 }
+
+func TestSelectedSymbolKinds(t *testing.T) {
+	cases := []struct {
+		query   string
+		want    []lsp.SymbolKind
+		wantErr bool
+	}{
+		{query: "foo type:symbol"},
+		{query: "foo type:symbol select:symbol"},
+		{query: "foo type:symbol select:symbol.function", want: []lsp.SymbolKind{lsp.SKFunction}},
+		{query: "foo type:symbol select:symbol.Function select:symbol.enummember", want: []lsp.SymbolKind{lsp.SKFunction, lsp.SKEnumMember}},
+		{query: "foo type:symbol select:symbol.type", want: typeSymbolKinds},
+		{query: "foo type:symbol select:symbol.foo", wantErr: true},
+		{query: "foo type:symbol select:function", wantErr: true},
+		{query: "foo select:symbol.function", wantErr: true},
+		{query: "foo type:file select:symbol.function", wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.query, func(t *testing.T) {
+			q, err := query.ParseAndCheck(tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := selectedSymbolKinds(q)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tc.wantErr)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCtagsKindsOf(t *testing.T) {
+	got := ctagsKindsOf([]lsp.SymbolKind{lsp.SKConstant, lsp.SKStruct})
+	if want := []string{"const", "constant", "struct"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := ctagsKindsOf(nil); got != nil {
+		t.Errorf("got %v, want nil", got)
+	}
+}
+
+func TestFilterSymbolKinds(t *testing.T) {
+	symbol := func(name, kind string) *searchSymbolResult {
+		return &searchSymbolResult{symbol: protocol.Symbol{Name: name, Kind: kind}}
+	}
+	matches := []*FileMatchResolver{
+		{JPath: "a.go", symbols: []*searchSymbolResult{symbol("A", "struct"), symbol("f", "func")}},
+		{JPath: "b.go", symbols: []*searchSymbolResult{symbol("c", "const")}},
+	}
+	got := filterSymbolKinds(matches, []lsp.SymbolKind{lsp.SKFunction})
+	if len(got) != 1 || got[0].JPath != "a.go" || len(got[0].symbols) != 1 || got[0].symbols[0].symbol.Name != "f" {
+		t.Errorf("got %+v, want only the function f in a.go", got)
+	}
+}
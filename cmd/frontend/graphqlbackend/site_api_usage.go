@@ -0,0 +1,95 @@
+package graphqlbackend
+
+import (
+	"context"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/quota"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+)
+
+var mockListAPIUsage func(ctx context.Context) ([]*quota.Usage, error)
+
+func (r *siteResolver) APIUsage(ctx context.Context) ([]*apiUsageResolver, error) {
+	// 🚨 SECURITY: Only site admins may view the use of API budgets.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	list := quota.List
+	if mockListAPIUsage != nil {
+		list = mockListAPIUsage
+	}
+	usages, err := list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*apiUsageResolver, len(usages))
+	for i, u := range usages {
+		resolvers[i] = &apiUsageResolver{usage: u}
+	}
+	return resolvers, nil
+}
+
+type apiUsageResolver struct {
+	usage *quota.Usage
+}
+
+func (r *apiUsageResolver) User(ctx context.Context) (*UserResolver, error) {
+	if r.usage.Subject.Kind != quota.SubjectUser {
+		return nil, nil
+	}
+	user, err := UserByIDInt32(ctx, int32(r.usage.Subject.ID))
+	if errcode.IsNotFound(err) {
+		return nil, nil
+	}
+	return user, err
+}
+
+func (r *apiUsageResolver) Organization(ctx context.Context) (*OrgResolver, error) {
+	if r.usage.Subject.Kind != quota.SubjectOrg {
+		return nil, nil
+	}
+	org, err := OrgByIDInt32(ctx, int32(r.usage.Subject.ID))
+	if errcode.IsNotFound(err) {
+		return nil, nil
+	}
+	return org, err
+}
+
+func (r *apiUsageResolver) AccessToken(ctx context.Context) (*accessTokenResolver, error) {
+	if r.usage.Subject.Kind != quota.SubjectAccessToken {
+		return nil, nil
+	}
+	accessToken, err := db.AccessTokens.GetByID(ctx, r.usage.Subject.ID)
+	if err == db.ErrAccessTokenNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &accessTokenResolver{accessToken: *accessToken}, nil
+}
+
+func (r *apiUsageResolver) Resource() string {
+	switch r.usage.Resource {
+	case quota.GraphQLCost:
+		return "GRAPHQL_COST"
+	case quota.CodeHostRequests:
+		return "CODE_HOST_REQUESTS"
+	default:
+		return "SEARCH_REQUESTS"
+	}
+}
+
+func (r *apiUsageResolver) Used() int32 { return int32(r.usage.Used) }
+
+func (r *apiUsageResolver) Limit() *int32 {
+	if r.usage.Limit == 0 {
+		return nil
+	}
+	limit := int32(r.usage.Limit)
+	return &limit
+}
+
+func (r *apiUsageResolver) ResetsAt() DateTime { return DateTime{Time: r.usage.Reset} }
@@ -0,0 +1,84 @@
+package graphqlbackend
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/graph-gophers/graphql-go/gqltesting"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/quota"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+)
+
+func TestSiteAPIUsage(t *testing.T) {
+	resetMocks()
+	t.Run("authenticated as non-site-admin", func(t *testing.T) {
+		db.Mocks.Users.GetByCurrentAuthUser = func(ctx context.Context) (*types.User, error) {
+			return &types.User{ID: 1, SiteAdmin: false}, nil
+		}
+		defer func() { db.Mocks.Users.GetByCurrentAuthUser = nil }()
+
+		result, err := (&siteResolver{}).APIUsage(context.Background())
+		if want := backend.ErrMustBeSiteAdmin; err != want {
+			t.Errorf("got err %v, want %v", err, want)
+		}
+		if result != nil {
+			t.Errorf("got result %v, want nil", result)
+		}
+	})
+
+	t.Run("site admin", func(t *testing.T) {
+		db.Mocks.Users.GetByCurrentAuthUser = func(ctx context.Context) (*types.User, error) {
+			return &types.User{ID: 1, SiteAdmin: true}, nil
+		}
+		db.Mocks.Users.GetByID = func(ctx context.Context, id int32) (*types.User, error) {
+			return &types.User{ID: id, Username: "alice"}, nil
+		}
+		db.Mocks.AccessTokens.GetByID = func(id int64) (*db.AccessToken, error) {
+			return nil, db.ErrAccessTokenNotFound
+		}
+		defer resetMocks()
+
+		reset := time.Date(2020, 4, 1, 13, 0, 0, 0, time.UTC)
+		mockListAPIUsage = func(ctx context.Context) ([]*quota.Usage, error) {
+			return []*quota.Usage{
+				{Subject: quota.Subject{Kind: quota.SubjectAccessToken, ID: 7}, Resource: quota.GraphQLCost, Used: 120, Reset: reset},
+				{Subject: quota.Subject{Kind: quota.SubjectUser, ID: 1}, Resource: quota.SearchRequests, Used: 3, Limit: 10, Reset: reset},
+			}, nil
+		}
+		defer func() { mockListAPIUsage = nil }()
+
+		gqltesting.RunTests(t, []*gqltesting.Test{
+			{
+				Schema: mustParseGraphQLSchema(t),
+				Query: `
+				{
+					site {
+						apiUsage {
+							user { username }
+							organization { name }
+							accessToken { id }
+							resource
+							used
+							limit
+							resetsAt
+						}
+					}
+				}
+			`,
+				ExpectedResult: `
+				{
+					"site": {
+						"apiUsage": [
+							{"user": null, "organization": null, "accessToken": null, "resource": "GRAPHQL_COST", "used": 120, "limit": null, "resetsAt": "2020-04-01T13:00:00Z"},
+							{"user": {"username": "alice"}, "organization": null, "accessToken": null, "resource": "SEARCH_REQUESTS", "used": 3, "limit": 10, "resetsAt": "2020-04-01T13:00:00Z"}
+						]
+					}
+				}
+			`,
+			},
+		})
+	})
+}
@@ -0,0 +1,179 @@
+package graphqlbackend
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend/graphqlutil"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+)
+
+type auditLogArgs struct {
+	graphqlutil.ConnectionArgs
+	After      *string
+	Actor      *graphql.ID
+	Action     *string
+	TargetType *string
+	TargetID   *string
+	Since      *DateTime
+	Until      *DateTime
+}
+
+func (r *siteResolver) AuditLog(ctx context.Context, args *auditLogArgs) (*auditLogEntryConnectionResolver, error) {
+	// 🚨 SECURITY: Only site admins may view the audit log.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	var opt db.AuditLogListOptions
+	if args.Actor != nil {
+		userID, err := UnmarshalUserID(*args.Actor)
+		if err != nil {
+			return nil, err
+		}
+		opt.ActorUserID = userID
+	}
+	if args.Action != nil {
+		opt.Action = *args.Action
+	}
+	if args.TargetType != nil {
+		opt.TargetType = *args.TargetType
+	}
+	if args.TargetID != nil {
+		if opt.TargetType == "" {
+			return nil, errors.New("targetID requires targetType")
+		}
+		opt.TargetID = *args.TargetID
+	}
+	if args.Since != nil {
+		opt.Since = &args.Since.Time
+	}
+	if args.Until != nil {
+		opt.Until = &args.Until.Time
+	}
+	if args.After != nil {
+		id, err := strconv.ParseInt(*args.After, 10, 64)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid cursor")
+		}
+		opt.OlderThanID = id
+	}
+	if args.First != nil {
+		opt.Limit = int(*args.First)
+	}
+	return &auditLogEntryConnectionResolver{opt: opt}, nil
+}
+
+type auditLogEntryConnectionResolver struct {
+	opt db.AuditLogListOptions
+
+	// cache results because they are used by multiple fields
+	once    sync.Once
+	entries []*types.AuditLogEntry
+	err     error
+}
+
+func (r *auditLogEntryConnectionResolver) compute(ctx context.Context) ([]*types.AuditLogEntry, error) {
+	r.once.Do(func() {
+		opt := r.opt
+		if opt.Limit > 0 {
+			opt.Limit++ // so we know if there is a next page
+		}
+		r.entries, r.err = db.AuditLog.List(ctx, opt)
+	})
+	return r.entries, r.err
+}
+
+func (r *auditLogEntryConnectionResolver) Nodes(ctx context.Context) ([]*auditLogEntryResolver, error) {
+	entries, err := r.compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if r.opt.Limit > 0 && len(entries) > r.opt.Limit {
+		entries = entries[:r.opt.Limit]
+	}
+	resolvers := make([]*auditLogEntryResolver, len(entries))
+	for i, e := range entries {
+		resolvers[i] = &auditLogEntryResolver{entry: e}
+	}
+	return resolvers, nil
+}
+
+func (r *auditLogEntryConnectionResolver) TotalCount(ctx context.Context) (int32, error) {
+	count, err := db.AuditLog.Count(ctx, r.opt)
+	return int32(count), err
+}
+
+func (r *auditLogEntryConnectionResolver) PageInfo(ctx context.Context) (*graphqlutil.PageInfo, error) {
+	entries, err := r.compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if r.opt.Limit == 0 || len(entries) <= r.opt.Limit {
+		return graphqlutil.HasNextPage(false), nil
+	}
+	return graphqlutil.NextPageCursor(strconv.FormatInt(entries[r.opt.Limit-1].ID, 10)), nil
+}
+
+type auditLogEntryResolver struct {
+	entry *types.AuditLogEntry
+}
+
+func (r *auditLogEntryResolver) ID() graphql.ID {
+	return relay.MarshalID("AuditLogEntry", r.entry.ID)
+}
+
+func (r *auditLogEntryResolver) Actor(ctx context.Context) (*UserResolver, error) {
+	if r.entry.ActorUserID == 0 {
+		return nil, nil
+	}
+	user, err := UserByIDInt32(ctx, r.entry.ActorUserID)
+	if errcode.IsNotFound(err) {
+		return nil, nil
+	}
+	return user, err
+}
+
+func (r *auditLogEntryResolver) ActorUserID() *int32 {
+	if r.entry.ActorUserID == 0 {
+		return nil
+	}
+	return &r.entry.ActorUserID
+}
+
+func (r *auditLogEntryResolver) ActorInternal() bool { return r.entry.ActorInternal }
+
+func (r *auditLogEntryResolver) ActorIP() *string { return nonEmptyString(r.entry.ActorIP) }
+
+func (r *auditLogEntryResolver) ActorForwardedFor() *string {
+	return nonEmptyString(r.entry.ActorForwardedFor)
+}
+
+func (r *auditLogEntryResolver) Action() string { return r.entry.Action }
+
+func (r *auditLogEntryResolver) TargetType() string { return r.entry.TargetType }
+
+func (r *auditLogEntryResolver) TargetID() *string { return nonEmptyString(r.entry.TargetID) }
+
+func (r *auditLogEntryResolver) Before() *JSONValue {
+	if r.entry.Before == nil {
+		return nil
+	}
+	return &JSONValue{r.entry.Before}
+}
+
+func (r *auditLogEntryResolver) After() *JSONValue {
+	if r.entry.After == nil {
+		return nil
+	}
+	return &JSONValue{r.entry.After}
+}
+
+func (r *auditLogEntryResolver) CreatedAt() DateTime { return DateTime{Time: r.entry.CreatedAt} }
@@ -0,0 +1,110 @@
+package graphqlbackend
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/graph-gophers/graphql-go/gqltesting"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+)
+
+func TestSiteAuditLog(t *testing.T) {
+	resetMocks()
+	t.Run("authenticated as non-site-admin", func(t *testing.T) {
+		db.Mocks.Users.GetByCurrentAuthUser = func(ctx context.Context) (*types.User, error) {
+			return &types.User{ID: 1, SiteAdmin: false}, nil
+		}
+		defer func() { db.Mocks.Users.GetByCurrentAuthUser = nil }()
+
+		result, err := (&siteResolver{}).AuditLog(context.Background(), &auditLogArgs{})
+		if want := backend.ErrMustBeSiteAdmin; err != want {
+			t.Errorf("got err %v, want %v", err, want)
+		}
+		if result != nil {
+			t.Errorf("got result %v, want nil", result)
+		}
+	})
+
+	t.Run("site admin", func(t *testing.T) {
+		db.Mocks.Users.GetByCurrentAuthUser = func(ctx context.Context) (*types.User, error) {
+			return &types.User{ID: 1, SiteAdmin: true}, nil
+		}
+		db.Mocks.Users.GetByID = func(ctx context.Context, id int32) (*types.User, error) {
+			return &types.User{ID: id, Username: "alice"}, nil
+		}
+		defer resetMocks()
+
+		createdAt := time.Date(2020, 4, 1, 13, 0, 0, 0, time.UTC)
+		db.Mocks.AuditLog.List = func(ctx context.Context, opt db.AuditLogListOptions) ([]*types.AuditLogEntry, error) {
+			if want := (db.AuditLogListOptions{ActorUserID: 1, TargetType: "user", OlderThanID: 10, Limit: 2}); !reflect.DeepEqual(opt, want) {
+				t.Errorf("got options %+v, want %+v", opt, want)
+			}
+			return []*types.AuditLogEntry{
+				{ID: 9, ActorUserID: 1, ActorIP: "10.0.0.1", Action: "user.setIsSiteAdmin", TargetType: "user", TargetID: "2", Before: json.RawMessage(`{"siteAdmin":false}`), After: json.RawMessage(`{"siteAdmin":true}`), CreatedAt: createdAt},
+				{ID: 8, ActorUserID: 1, Action: "user.delete", TargetType: "user", TargetID: "3", CreatedAt: createdAt},
+			}, nil
+		}
+		db.Mocks.AuditLog.Count = func(ctx context.Context, opt db.AuditLogListOptions) (int, error) {
+			return 5, nil
+		}
+
+		gqltesting.RunTests(t, []*gqltesting.Test{
+			{
+				Schema: mustParseGraphQLSchema(t),
+				Query: `
+				{
+					site {
+						auditLog(first: 1, after: "10", actor: "VXNlcjox", targetType: "user") {
+							nodes {
+								actor { username }
+								actorIP
+								action
+								targetType
+								targetID
+								before
+								after
+								createdAt
+							}
+							totalCount
+							pageInfo {
+								endCursor
+								hasNextPage
+							}
+						}
+					}
+				}
+			`,
+				ExpectedResult: `
+				{
+					"site": {
+						"auditLog": {
+							"nodes": [
+								{
+									"actor": {"username": "alice"},
+									"actorIP": "10.0.0.1",
+									"action": "user.setIsSiteAdmin",
+									"targetType": "user",
+									"targetID": "2",
+									"before": {"siteAdmin": false},
+									"after": {"siteAdmin": true},
+									"createdAt": "2020-04-01T13:00:00Z"
+								}
+							],
+							"totalCount": 5,
+							"pageInfo": {
+								"endCursor": "9",
+								"hasNextPage": true
+							}
+						}
+					}
+				}
+			`,
+			},
+		})
+	})
+}
@@ -0,0 +1,84 @@
+package graphqlbackend
+
+import (
+	"context"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/internal/repoupdater"
+	"github.com/sourcegraph/sourcegraph/internal/repoupdater/protocol"
+)
+
+func (r *siteResolver) GitserverRebalance(ctx context.Context) (*gitserverRebalanceResolver, error) {
+	// 🚨 SECURITY: Only site admins can view the gitserver addresses and
+	// the repositories that failed to move.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return nil, err
+	}
+	status, err := repoupdater.DefaultClient.GitserverRebalanceStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &gitserverRebalanceResolver{status: status}, nil
+}
+
+type gitserverRebalanceResolver struct {
+	status *protocol.GitserverRebalanceStatus
+}
+
+func (r *gitserverRebalanceResolver) InProgress() bool { return r.status.Rebalancing }
+
+func (r *gitserverRebalanceResolver) CheckedAt() *DateTime {
+	if r.status.UpdatedAt.IsZero() {
+		return nil
+	}
+	return &DateTime{Time: r.status.UpdatedAt}
+}
+
+func (r *gitserverRebalanceResolver) TotalCount() int32 {
+	var n int
+	for _, s := range r.status.Shards {
+		n += s.Incoming
+	}
+	return int32(n)
+}
+
+func (r *gitserverRebalanceResolver) MovedCount() int32 {
+	var n int
+	for _, s := range r.status.Shards {
+		n += s.Moved
+	}
+	return int32(n)
+}
+
+func (r *gitserverRebalanceResolver) FailedCount() int32 {
+	var n int
+	for _, s := range r.status.Shards {
+		n += s.Failed
+	}
+	return int32(n)
+}
+
+func (r *gitserverRebalanceResolver) Shards() []*gitserverShardResolver {
+	shards := make([]*gitserverShardResolver, len(r.status.Shards))
+	for i := range r.status.Shards {
+		shards[i] = &gitserverShardResolver{shard: &r.status.Shards[i]}
+	}
+	return shards
+}
+
+func (r *gitserverRebalanceResolver) Errors() []string {
+	if r.status.Errors == nil {
+		return []string{}
+	}
+	return r.status.Errors
+}
+
+type gitserverShardResolver struct {
+	shard *protocol.GitserverShardStatus
+}
+
+func (r *gitserverShardResolver) Address() string      { return r.shard.Addr }
+func (r *gitserverShardResolver) ClonedCount() int32   { return int32(r.shard.Cloned) }
+func (r *gitserverShardResolver) IncomingCount() int32 { return int32(r.shard.Incoming) }
+func (r *gitserverShardResolver) MovedCount() int32    { return int32(r.shard.Moved) }
+func (r *gitserverShardResolver) FailedCount() int32   { return int32(r.shard.Failed) }
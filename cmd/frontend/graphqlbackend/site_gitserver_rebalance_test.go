@@ -0,0 +1,97 @@
+package graphqlbackend
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/graph-gophers/graphql-go/gqltesting"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/repoupdater"
+	"github.com/sourcegraph/sourcegraph/internal/repoupdater/protocol"
+)
+
+func TestSiteGitserverRebalance(t *testing.T) {
+	resetMocks()
+	t.Run("authenticated as non-site-admin", func(t *testing.T) {
+		db.Mocks.Users.GetByCurrentAuthUser = func(ctx context.Context) (*types.User, error) {
+			return &types.User{ID: 1, SiteAdmin: false}, nil
+		}
+		defer func() { db.Mocks.Users.GetByCurrentAuthUser = nil }()
+
+		result, err := (&siteResolver{}).GitserverRebalance(context.Background())
+		if want := backend.ErrMustBeSiteAdmin; err != want {
+			t.Errorf("got err %v, want %v", err, want)
+		}
+		if result != nil {
+			t.Errorf("got result %v, want nil", result)
+		}
+	})
+
+	t.Run("site admin", func(t *testing.T) {
+		db.Mocks.Users.GetByCurrentAuthUser = func(ctx context.Context) (*types.User, error) {
+			return &types.User{ID: 1, SiteAdmin: true}, nil
+		}
+		defer func() { db.Mocks.Users.GetByCurrentAuthUser = nil }()
+
+		repoupdater.MockGitserverRebalanceStatus = func(_ context.Context) (*protocol.GitserverRebalanceStatus, error) {
+			return &protocol.GitserverRebalanceStatus{
+				Rebalancing: true,
+				UpdatedAt:   time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC),
+				Shards: []protocol.GitserverShardStatus{
+					{Addr: "gitserver-0:3178", Cloned: 10},
+					{Addr: "gitserver-1:3178", Cloned: 7, Incoming: 4, Moved: 2, Failed: 1},
+				},
+				Errors: []string{"github.com/foo/bar: boom"},
+			}, nil
+		}
+		defer func() { repoupdater.MockGitserverRebalanceStatus = nil }()
+
+		gqltesting.RunTests(t, []*gqltesting.Test{
+			{
+				Schema: mustParseGraphQLSchema(t),
+				Query: `
+				{
+					site {
+						gitserverRebalance {
+							inProgress
+							checkedAt
+							totalCount
+							movedCount
+							failedCount
+							shards {
+								address
+								clonedCount
+								incomingCount
+								movedCount
+								failedCount
+							}
+							errors
+						}
+					}
+				}
+			`,
+				ExpectedResult: `
+				{
+					"site": {
+						"gitserverRebalance": {
+							"inProgress": true,
+							"checkedAt": "2020-04-01T00:00:00Z",
+							"totalCount": 4,
+							"movedCount": 2,
+							"failedCount": 1,
+							"shards": [
+								{"address": "gitserver-0:3178", "clonedCount": 10, "incomingCount": 0, "movedCount": 0, "failedCount": 0},
+								{"address": "gitserver-1:3178", "clonedCount": 7, "incomingCount": 4, "movedCount": 2, "failedCount": 1}
+							],
+							"errors": ["github.com/foo/bar: boom"]
+						}
+					}
+				}
+			`,
+			},
+		})
+	})
+}
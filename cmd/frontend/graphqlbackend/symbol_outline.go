@@ -0,0 +1,57 @@
+package graphqlbackend
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gituri"
+	"github.com/sourcegraph/sourcegraph/internal/symbols/protocol"
+)
+
+func (r *GitTreeEntryResolver) SymbolOutline(ctx context.Context) (res []*symbolOutlineNodeResolver, err error) {
+	ctx, done := context.WithTimeout(ctx, 5*time.Second)
+	defer done()
+	defer func() {
+		if ctx.Err() != nil && len(res) == 0 {
+			err = errors.New("processing symbols is taking longer than expected. Try again in a while")
+		}
+	}()
+
+	baseURI, err := gituri.Parse("git://" + string(r.commit.repo.repo.Name) + "?" + string(r.commit.oid))
+	if err != nil {
+		return nil, err
+	}
+	outline, err := backend.Symbols.Outline(ctx, protocol.OutlineArgs{
+		Repo:     r.commit.repo.repo.Name,
+		CommitID: api.CommitID(r.commit.oid),
+		Path:     r.Path(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toSymbolOutlineNodeResolvers(outline, baseURI, r.commit), nil
+}
+
+func toSymbolOutlineNodeResolvers(outline []protocol.OutlineSymbol, baseURI *gituri.URI, commit *GitCommitResolver) []*symbolOutlineNodeResolver {
+	resolvers := make([]*symbolOutlineNodeResolver, len(outline))
+	for i, symbol := range outline {
+		resolvers[i] = &symbolOutlineNodeResolver{
+			symbol:   toSymbolResolver(symbol.Symbol, baseURI, strings.ToLower(symbol.Language), commit),
+			children: toSymbolOutlineNodeResolvers(symbol.Children, baseURI, commit),
+		}
+	}
+	return resolvers
+}
+
+type symbolOutlineNodeResolver struct {
+	symbol   *symbolResolver
+	children []*symbolOutlineNodeResolver
+}
+
+func (r *symbolOutlineNodeResolver) Symbol() *symbolResolver { return r.symbol }
+
+func (r *symbolOutlineNodeResolver) Children() []*symbolOutlineNodeResolver { return r.children }
@@ -0,0 +1,103 @@
+package graphqlbackend
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend/graphqlutil"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gituri"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/symbols/protocol"
+)
+
+func TestGitTreeEntry_SymbolOutline(t *testing.T) {
+	resetMocks()
+	defer resetMocks()
+
+	backend.Mocks.Symbols.Outline = func(ctx context.Context, args protocol.OutlineArgs) ([]protocol.OutlineSymbol, error) {
+		if want := (protocol.OutlineArgs{Repo: "r", CommitID: "c", Path: "a.go"}); args != want {
+			t.Errorf("got %+v, want %+v", args, want)
+		}
+		return []protocol.OutlineSymbol{
+			{
+				Symbol: protocol.Symbol{Name: "Server", Path: "a.go", Line: 3, Kind: "struct", Language: "Go"},
+				Children: []protocol.OutlineSymbol{
+					{Symbol: protocol.Symbol{Name: "Serve", Path: "a.go", Line: 7, Kind: "func", Parent: "Server", Language: "Go"}},
+				},
+			},
+			{Symbol: protocol.Symbol{Name: "maxConns", Path: "a.go", Line: 11, Kind: "constant", Language: "Go"}},
+		}, nil
+	}
+
+	entry := &GitTreeEntryResolver{
+		commit: &GitCommitResolver{repo: &RepositoryResolver{repo: &types.Repo{Name: "r"}}, oid: "c"},
+		stat:   CreateFileInfo("a.go", false),
+	}
+	outline, err := entry.SymbolOutline(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	var walk func(nodes []*symbolOutlineNodeResolver, indent string)
+	walk = func(nodes []*symbolOutlineNodeResolver, indent string) {
+		for _, node := range nodes {
+			got = append(got, indent+node.Symbol().Name()+" "+node.Symbol().Kind()+" "+node.Symbol().Language())
+			walk(node.Children(), indent+"  ")
+		}
+	}
+	walk(outline, "")
+	want := []string{"Server STRUCT go", "  Serve FUNCTION go", "maxConns CONSTANT go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSymbol_Members(t *testing.T) {
+	resetMocks()
+	defer resetMocks()
+
+	backend.Mocks.Symbols.ListTags = func(ctx context.Context, args search.SymbolsParameters) ([]protocol.Symbol, error) {
+		if args.Repo != "r" || args.CommitID != api.CommitID("c") || args.Parent != "Server" || args.ParentKind != "struct" || args.First != 2 {
+			t.Errorf("unexpected args %+v", args)
+		}
+		return []protocol.Symbol{
+			{Name: "addr", Path: "a.go", Kind: "member", Parent: "Server", ParentKind: "struct"},
+			{Name: "Serve", Path: "b.go", Kind: "func", Parent: "pkg.Server", ParentKind: "struct"},
+		}, nil
+	}
+
+	commit := &GitCommitResolver{repo: &RepositoryResolver{repo: &types.Repo{Name: "r"}}, oid: "c"}
+	baseURI, err := gituri.Parse("git://r?c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	symbol := toSymbolResolver(protocol.Symbol{Name: "Server", Path: "a.go", Kind: "struct"}, baseURI, "go", commit)
+	first := int32(1)
+	members, err := symbol.Members(context.Background(), &graphqlutil.ConnectionArgs{First: &first})
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes, err := members.Nodes(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, node := range nodes {
+		got = append(got, node.Name())
+	}
+	if want := []string{"addr"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	pageInfo, err := members.PageInfo(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pageInfo.HasNextPage() {
+		t.Error("want a next page")
+	}
+}
@@ -270,3 +270,36 @@ func (r *symbolResolver) URL(ctx context.Context) (string, error) { return r.loc
 func (r *symbolResolver) CanonicalURL() (string, error) { return r.location.CanonicalURL() }
 
 func (r *symbolResolver) FileLocal() bool { return r.symbol.FileLimited }
+
+func (r *symbolResolver) Members(ctx context.Context, args *graphqlutil.ConnectionArgs) (res *symbolConnectionResolver, err error) {
+	ctx, done := context.WithTimeout(ctx, 5*time.Second)
+	defer done()
+	defer func() {
+		if ctx.Err() != nil && (res == nil || len(res.symbols) == 0) {
+			err = errors.New("processing symbols is taking longer than expected. Try again in a while")
+		}
+	}()
+
+	commit := r.location.resource.commit
+	baseURI, err := gituri.Parse("git://" + string(commit.repo.repo.Name) + "?" + string(commit.oid))
+	if err != nil {
+		return nil, err
+	}
+	symbols, err := backend.Symbols.ListTags(ctx, search.SymbolsParameters{
+		Repo:     commit.repo.repo.Name,
+		CommitID: api.CommitID(commit.oid),
+		Parent:   r.symbol.Name,
+		// Exclude members of a different kind of symbol with the same name
+		// (e.g. a namespace and a class).
+		ParentKind: r.symbol.Kind,
+		First:      limitOrDefault(args.First) + 1, // add 1 so we can determine PageInfo.hasNextPage
+	})
+	if err != nil {
+		return nil, err
+	}
+	members := make([]*symbolResolver, 0, len(symbols))
+	for _, symbol := range symbols {
+		members = append(members, toSymbolResolver(symbol, baseURI, strings.ToLower(symbol.Language), commit))
+	}
+	return &symbolConnectionResolver{symbols: members, first: args.First}, nil
+}
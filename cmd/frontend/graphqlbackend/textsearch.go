@@ -66,12 +66,15 @@ var (
 type FileMatchResolver struct {
 	JPath        string       `json:"Path"`
 	JLineMatches []*lineMatch `json:"LineMatches"`
-	JLimitHit    bool         `json:"LimitHit"`
-	MatchCount   int          // Number of matches. Different from len(JLineMatches), as multiple lines may correspond to one logical match.
-	symbols      []*searchSymbolResult
-	uri          string
-	Repo         *types.Repo
-	CommitID     api.CommitID
+	// JStructuralMatches are the matches of a structural search pattern, which
+	// searcher only returns for structural searches.
+	JStructuralMatches []*structuralMatch `json:"StructuralMatches"`
+	JLimitHit          bool               `json:"LimitHit"`
+	MatchCount         int                // Number of matches. Different from len(JLineMatches), as multiple lines may correspond to one logical match.
+	symbols            []*searchSymbolResult
+	uri                string
+	Repo               *types.Repo
+	CommitID           api.CommitID
 	// InputRev is the Git revspec that the user originally requested to search. It is used to
 	// preserve the original revision specifier from the user instead of navigating them to the
 	// absolute commit ID when they select a result.
@@ -129,6 +132,10 @@ func (fm *FileMatchResolver) LineMatches() []*lineMatch {
 	return fm.JLineMatches
 }
 
+func (fm *FileMatchResolver) StructuralMatches() []*structuralMatch {
+	return fm.JStructuralMatches
+}
+
 func (fm *FileMatchResolver) LimitHit() bool {
 	return fm.JLimitHit
 }
@@ -457,8 +464,23 @@ var mockSearchFilesInRepos func(args *search.TextParameters) ([]*FileMatchResolv
 
 // searchFilesInRepos searches a set of repos for a pattern.
 func searchFilesInRepos(ctx context.Context, args *search.TextParameters) (res []*FileMatchResolver, common *searchResultsCommon, err error) {
+	return searchFilesInReposStream(ctx, args, nil)
+}
+
+// searchFilesInReposStream is like searchFilesInRepos, but additionally calls
+// stream (if non-nil) with the matches of each repository as soon as they are
+// found, up to the file match limit. stream is never called concurrently.
+func searchFilesInReposStream(ctx context.Context, args *search.TextParameters, stream func([]*FileMatchResolver)) (res []*FileMatchResolver, common *searchResultsCommon, err error) {
 	if mockSearchFilesInRepos != nil {
-		return mockSearchFilesInRepos(args)
+		res, common, err = mockSearchFilesInRepos(args)
+		if stream != nil && len(res) > 0 {
+			if limit := int(args.PatternInfo.FileMatchLimit); len(res) > limit {
+				stream(res[:limit:limit])
+			} else {
+				stream(res)
+			}
+		}
+		return res, common, err
 	}
 
 	tr, ctx := trace.New(ctx, "searchFilesInRepos", fmt.Sprintf("query: %s, numRepoRevs: %d", args.PatternInfo.Pattern, len(args.Repos)))
@@ -540,6 +562,13 @@ func searchFilesInRepos(ctx context.Context, args *search.TextParameters) (res [
 		}
 	}
 
+	// Line matches are post-filtered by blameauthor:, blamebefore: and
+	// blameafter:, if specified.
+	blame, err := newBlameFilter(args.Query, time.Now())
+	if err != nil {
+		return nil, common, err
+	}
+
 	var (
 		// TODO: convert wg to an errgroup
 		wg                sync.WaitGroup
@@ -548,10 +577,28 @@ func searchFilesInRepos(ctx context.Context, args *search.TextParameters) (res [
 		unflattened       [][]*FileMatchResolver
 		flattenedSize     int
 		overLimitCanceled bool // canceled because we were over the limit
+
+		// streamMu serializes the calls to stream, which are made without
+		// holding mu so that a slow consumer doesn't block other repositories.
+		streamMu sync.Mutex
+		streamed int // number of matches passed to stream, guarded by mu
 	)
 
-	// addMatches assumes the caller holds mu.
-	addMatches := func(matches []*FileMatchResolver) {
+	// streamMatches passes matches returned by addMatches to stream. It must
+	// be called without holding mu.
+	streamMatches := func(matches []*FileMatchResolver) {
+		if len(matches) == 0 {
+			return
+		}
+		streamMu.Lock()
+		defer streamMu.Unlock()
+		stream(matches)
+	}
+
+	// addMatches assumes the caller holds mu. It returns the matches to pass
+	// to streamMatches after releasing mu, which are copied so that they
+	// can be read without holding mu and are capped at the file match limit.
+	addMatches := func(matches []*FileMatchResolver) (toStream []*FileMatchResolver) {
 		if len(matches) > 0 {
 			common.resultCount += int32(len(matches))
 			sort.Slice(matches, func(i, j int) bool {
@@ -561,6 +608,14 @@ func searchFilesInRepos(ctx context.Context, args *search.TextParameters) (res [
 			unflattened = append(unflattened, matches)
 			flattenedSize += len(matches)
 
+			if n := int(args.PatternInfo.FileMatchLimit) - streamed; stream != nil && n > 0 {
+				if n > len(matches) {
+					n = len(matches)
+				}
+				toStream = append(toStream, matches[:n]...)
+				streamed += n
+			}
+
 			// Stop searching once we have found enough matches. This does
 			// lead to potentially unstable result ordering, but is worth
 			// it for the performance benefit.
@@ -571,6 +626,7 @@ func searchFilesInRepos(ctx context.Context, args *search.TextParameters) (res [
 				cancel()
 			}
 		}
+		return toStream
 	}
 
 	// callSearcherOverRepos calls searcher on a set of repos.
@@ -655,6 +711,11 @@ func searchFilesInRepos(ctx context.Context, args *search.TextParameters) (res [
 						tr.LogFields(otlog.String("repo", string(repoRev.Repo.Name)), otlog.Error(err), otlog.Bool("timeout", errcode.IsTimeout(err)), otlog.Bool("temporary", errcode.IsTemporary(err)))
 						log15.Warn("searchFilesInRepo failed", "error", err, "repo", repoRev.Repo.Name)
 					}
+					if blame != nil && len(matches) > 0 {
+						matches = blame.filter(ctx, matches)
+					}
+					var toStream []*FileMatchResolver
+					defer func() { streamMatches(toStream) }() // after mu is released
 					mu.Lock()
 					defer mu.Unlock()
 					if ctx.Err() == nil {
@@ -680,7 +741,7 @@ func searchFilesInRepos(ctx context.Context, args *search.TextParameters) (res [
 							cancel()
 						}
 					}
-					addMatches(matches)
+					toStream = addMatches(matches)
 				}(limitCtx, limitDone) // ends the Go routine for a call to searcher for a repo
 			} // ends the for loop iterating over repo's revs
 		} // ends the for loop iterating over repos
@@ -700,6 +761,13 @@ func searchFilesInRepos(ctx context.Context, args *search.TextParameters) (res [
 		} else {
 			matches, limitHit, reposLimitHit, err = zoektSearchHEADOnlyFiles(ctx, args, zoektRepos, false, time.Since)
 		}
+		if blame != nil && !args.PatternInfo.IsStructuralPat && len(matches) > 0 {
+			// Structural search matches are filtered once searcher has
+			// found them in the files returned by Zoekt.
+			matches = blame.filter(ctx, matches)
+		}
+		var toStream []*FileMatchResolver
+		defer func() { streamMatches(toStream) }() // after mu is released
 		mu.Lock()
 		defer mu.Unlock()
 		if ctx.Err() == nil {
@@ -765,7 +833,7 @@ func searchFilesInRepos(ctx context.Context, args *search.TextParameters) (res [
 				searchErr = err
 			}
 		} else {
-			addMatches(matches)
+			toStream = addMatches(matches)
 		}
 	}()
 
@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -371,6 +372,47 @@ func TestSearchFilesInRepos(t *testing.T) {
 	}
 }
 
+func TestSearchFilesInReposStream(t *testing.T) {
+	mockSearchFilesInRepo = func(ctx context.Context, repo *types.Repo, gitserverRepo gitserver.Repo, rev string, info *search.TextPatternInfo, fetchTimeout time.Duration) (matches []*FileMatchResolver, limitHit bool, err error) {
+		return []*FileMatchResolver{
+			{uri: "git://" + string(repo.Name) + "?" + rev + "#a.go"},
+			{uri: "git://" + string(repo.Name) + "?" + rev + "#b.go"},
+		}, false, nil
+	}
+	defer func() { mockSearchFilesInRepo = nil }()
+
+	q, err := query.ParseAndCheck("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := &search.TextParameters{
+		PatternInfo: &search.TextPatternInfo{
+			FileMatchLimit: 3,
+			Pattern:        "foo",
+		},
+		Repos:        makeRepositoryRevisions("foo/one", "foo/two", "foo/three", "foo/four"),
+		Query:        q,
+		Zoekt:        &searchbackend.Zoekt{Client: &fakeSearcher{repos: &zoekt.RepoList{}}},
+		SearcherURLs: endpoint.Static("test"),
+	}
+
+	var streamed, inFlight int32
+	stream := func(matches []*FileMatchResolver) {
+		if atomic.AddInt32(&inFlight, 1) != 1 {
+			t.Error("stream called concurrently")
+		}
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&streamed, int32(len(matches)))
+		atomic.AddInt32(&inFlight, -1)
+	}
+	if _, _, err := searchFilesInReposStream(context.Background(), args, stream); err != nil {
+		t.Fatal(err)
+	}
+	if streamed != 3 {
+		t.Errorf("streamed %d matches, want the file match limit 3", streamed)
+	}
+}
+
 func TestSearchFilesInRepos_multipleRevsPerRepo(t *testing.T) {
 	mockSearchFilesInRepo = func(ctx context.Context, repo *types.Repo, gitserverRepo gitserver.Repo, rev string, info *search.TextPatternInfo, fetchTimeout time.Duration) (matches []*FileMatchResolver, limitHit bool, err error) {
 		repoName := repo.Name
@@ -245,10 +245,22 @@ func (c configurationSource) Write(ctx context.Context, input conftypes.RawUnifi
 	if err != nil {
 		return errors.Wrap(err, "confdb.CriticalCreateIfUpToDate")
 	}
+	if input.Critical != critical.Contents {
+		// confdb saves the configuration in its own transaction, so the best we can
+		// do is fail the write if it can't be recorded.
+		if err := db.RecordAudit(ctx, "criticalConfiguration.update", "criticalConfiguration", "", critical.Contents, input.Critical); err != nil {
+			return errors.Wrap(err, "recording critical configuration update")
+		}
+	}
 	_, err = confdb.SiteCreateIfUpToDate(ctx, &site.ID, input.Site)
 	if err != nil {
 		return errors.Wrap(err, "confdb.SiteCreateIfUpToDate")
 	}
+	if input.Site != site.Contents {
+		if err := db.RecordAudit(ctx, "siteConfiguration.update", "siteConfiguration", "", site.Contents, input.Site); err != nil {
+			return errors.Wrap(err, "recording site configuration update")
+		}
+	}
 	return nil
 }
 
@@ -265,8 +277,11 @@ func serviceConnections() conftypes.ServiceConnections {
 		}
 
 		serviceConnectionsVal = conftypes.ServiceConnections{
-			GitServers:  gitServers(),
-			PostgresDSN: dbutil.PostgresDSN(username, os.Getenv),
+			GitServers:                 gitServers(),
+			GitServersSharding:         os.Getenv("SRC_GIT_SERVERS_SHARDING"),
+			GitServersPrevious:         strings.Fields(os.Getenv("SRC_GIT_SERVERS_PREVIOUS")),
+			GitServersPreviousSharding: os.Getenv("SRC_GIT_SERVERS_PREVIOUS_SHARDING"),
+			PostgresDSN:                dbutil.PostgresDSN(username, os.Getenv),
 		}
 	})
 	return serviceConnectionsVal
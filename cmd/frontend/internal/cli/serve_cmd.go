@@ -25,7 +25,9 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/bg"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/cli/loghandlers"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/goroutine"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/inventory/updater"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/discussions/mailreply"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/discussions/tracker"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/siteid"
 	"github.com/sourcegraph/sourcegraph/internal/conf"
 	"github.com/sourcegraph/sourcegraph/internal/db/dbconn"
@@ -113,7 +115,7 @@ func InitDB() error {
 }
 
 // Main is the main entrypoint for the frontend server program.
-func Main(githubWebhook, bitbucketServerWebhook http.Handler) error {
+func Main(githubWebhook, gitlabWebhook, bitbucketServerWebhook http.Handler) error {
 	log.SetFlags(0)
 	log.SetPrefix("")
 
@@ -195,6 +197,8 @@ func Main(githubWebhook, bitbucketServerWebhook http.Handler) error {
 	goroutine.Go(func() { bg.DeleteOldCacheDataInRedis() })
 	goroutine.Go(func() { bg.DeleteOldEventLogsInPostgres(context.Background()) })
 	goroutine.Go(mailreply.StartWorker)
+	goroutine.Go(tracker.StartWorker)
+	goroutine.Go(updater.StartWorker)
 	go updatecheck.Start()
 
 	// Parse GraphQL schema and set up resolvers that depend on dbconn.Global
@@ -236,7 +240,7 @@ func Main(githubWebhook, bitbucketServerWebhook http.Handler) error {
 	}
 
 	// Create the external HTTP handler.
-	externalHandler, err := newExternalHTTPHandler(schema, githubWebhook, bitbucketServerWebhook, lsifServerProxy)
+	externalHandler, err := newExternalHTTPHandler(schema, githubWebhook, gitlabWebhook, bitbucketServerWebhook, lsifServerProxy)
 	if err != nil {
 		return err
 	}
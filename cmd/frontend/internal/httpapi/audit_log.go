@@ -0,0 +1,86 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+)
+
+// auditLogExportBatchSize is the number of audit log entries that are loaded
+// from the database at a time when exporting the audit log.
+const auditLogExportBatchSize = 1000
+
+// serveAuditLogExport writes the audit log entries that match the filters in
+// the query string as JSON lines (one JSON object per line), newest first, for
+// ingestion by SIEM tools. The filters are the actorUserID, action, targetType
+// and targetID of entries, and the RFC 3339 times since (inclusive) and until
+// (exclusive) which they were created.
+func serveAuditLogExport(w http.ResponseWriter, r *http.Request) error {
+	// 🚨 SECURITY: Only site admins may export the audit log.
+	if err := backend.CheckCurrentUserIsSiteAdmin(r.Context()); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return nil
+	}
+
+	opt, err := parseAuditLogExportOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil
+	}
+	opt.Limit = auditLogExportBatchSize
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	enc := json.NewEncoder(w)
+	for {
+		entries, err := db.AuditLog.List(r.Context(), opt)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		if len(entries) < opt.Limit {
+			return nil
+		}
+		opt.OlderThanID = entries[len(entries)-1].ID
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+}
+
+func parseAuditLogExportOptions(r *http.Request) (db.AuditLogListOptions, error) {
+	q := r.URL.Query()
+	opt := db.AuditLogListOptions{
+		Action:     q.Get("action"),
+		TargetType: q.Get("targetType"),
+		TargetID:   q.Get("targetID"),
+	}
+	if opt.TargetID != "" && opt.TargetType == "" {
+		return opt, errors.New("targetID requires targetType")
+	}
+	if v := q.Get("actorUserID"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 32)
+		if err != nil {
+			return opt, errors.Wrap(err, "invalid actorUserID")
+		}
+		opt.ActorUserID = int32(id)
+	}
+	for name, dst := range map[string]**time.Time{"since": &opt.Since, "until": &opt.Until} {
+		if v := q.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return opt, errors.Wrapf(err, "invalid %s", name)
+			}
+			*dst = &t
+		}
+	}
+	return opt, nil
+}
@@ -0,0 +1,82 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+)
+
+func TestServeAuditLogExport(t *testing.T) {
+	defer func() { db.Mocks = db.MockStores{} }()
+
+	serve := func(query string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/.api/audit-log/export?"+query, nil)
+		rec := httptest.NewRecorder()
+		if err := serveAuditLogExport(rec, req); err != nil {
+			t.Fatal(err)
+		}
+		return rec
+	}
+
+	db.Mocks.Users.GetByCurrentAuthUser = func(context.Context) (*types.User, error) {
+		return &types.User{ID: 1}, nil
+	}
+	if rec := serve(""); rec.Code != http.StatusForbidden {
+		t.Errorf("got status %d for non-site-admin, want %d", rec.Code, http.StatusForbidden)
+	}
+
+	db.Mocks.Users.GetByCurrentAuthUser = func(context.Context) (*types.User, error) {
+		return &types.User{ID: 1, SiteAdmin: true}, nil
+	}
+	if rec := serve("targetID=1"); rec.Code != http.StatusBadRequest {
+		t.Errorf("got status %d for targetID without targetType, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	// Entries are exported in batches until a batch is not full.
+	createdAt := time.Date(2020, 4, 1, 13, 0, 0, 0, time.UTC)
+	var gotOpts []db.AuditLogListOptions
+	db.Mocks.AuditLog.List = func(ctx context.Context, opt db.AuditLogListOptions) ([]*types.AuditLogEntry, error) {
+		gotOpts = append(gotOpts, opt)
+		if opt.OlderThanID != 0 {
+			return []*types.AuditLogEntry{{ID: 1, Action: "user.delete", TargetType: "user", TargetID: "3", CreatedAt: createdAt}}, nil
+		}
+		entries := make([]*types.AuditLogEntry, opt.Limit)
+		for i := range entries {
+			entries[i] = &types.AuditLogEntry{ID: int64(opt.Limit - i + 1), Action: "user.create", TargetType: "user", CreatedAt: createdAt}
+		}
+		return entries, nil
+	}
+
+	rec := serve("actorUserID=2&targetType=user&since=2020-04-01T00:00:00Z")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusOK)
+	}
+	if got, want := rec.Header().Get("Content-Type"), "application/x-ndjson"; got != want {
+		t.Errorf("got content type %q, want %q", got, want)
+	}
+
+	since := time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC)
+	wantOpts := []db.AuditLogListOptions{
+		{ActorUserID: 2, TargetType: "user", Since: &since, Limit: auditLogExportBatchSize},
+		{ActorUserID: 2, TargetType: "user", Since: &since, Limit: auditLogExportBatchSize, OlderThanID: 2},
+	}
+	if !reflect.DeepEqual(gotOpts, wantOpts) {
+		t.Errorf("got options %+v, want %+v", gotOpts, wantOpts)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(rec.Body.String(), "\n"), "\n")
+	if len(lines) != auditLogExportBatchSize+1 {
+		t.Fatalf("got %d lines, want %d", len(lines), auditLogExportBatchSize+1)
+	}
+	if got, want := lines[len(lines)-1], `{"id":1,"action":"user.delete","targetType":"user","targetID":"3","createdAt":"2020-04-01T13:00:00Z"}`; got != want {
+		t.Errorf("got last line %s, want %s", got, want)
+	}
+}
@@ -106,6 +106,7 @@ func AccessTokenAuthMiddleware(next http.Handler) http.Handler {
 			}
 
 			r = r.WithContext(actor.WithActor(r.Context(), &actor.Actor{UID: actorUserID}))
+			r = r.WithContext(withAccessToken(r.Context(), token))
 		}
 
 		next.ServeHTTP(w, r)
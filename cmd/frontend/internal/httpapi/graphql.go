@@ -10,7 +10,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/trace"
 )
 
-func serveGraphQL(schema *graphql.Schema) func(w http.ResponseWriter, r *http.Request) (err error) {
+func serveGraphQL(schema *graphql.Schema, isInternal bool) func(w http.ResponseWriter, r *http.Request) (err error) {
 	relayHandler := &relay.Handler{Schema: schema}
 	return func(w http.ResponseWriter, r *http.Request) (err error) {
 		if r.Method != "POST" {
@@ -29,6 +29,11 @@ func serveGraphQL(schema *graphql.Schema) func(w http.ResponseWriter, r *http.Re
 
 		r = r.WithContext(trace.WithRequestSource(r.Context(), guessSource(r)))
 
+		// Only record the requests of users, not those of other services.
+		if c := getGraphQLCapture(); c != nil && !isInternal {
+			c.serve(w, r, requestName, relayHandler)
+			return nil
+		}
 		relayHandler.ServeHTTP(w, r)
 		return nil
 	}
@@ -0,0 +1,472 @@
+package httpapi
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/inconshreveable/log15"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/env"
+)
+
+var (
+	graphQLCaptureFile = env.Get("GRAPHQL_CAPTURE_FILE", "", "file to record GraphQL queries to, for replaying them with cmd/loadtest")
+	graphQLCaptureKey  = env.Get("GRAPHQL_CAPTURE_KEY", "", "secret key to derive the pseudonyms of repository names and search terms in GRAPHQL_CAPTURE_FILE from (random if unset)")
+)
+
+// capturedRequest is a GraphQL request recorded for replaying with
+// cmd/loadtest. It is written as a line of JSON.
+//
+// 🚨 SECURITY: It must never contain credentials or the contents of the
+// request, such as search terms or repository names, so only the sanitized
+// query and variables (see sanitizeQuery and pseudonymizer.sanitizeVariables)
+// and the ID of the user who made it are recorded.
+type capturedRequest struct {
+	// Time is when the request was received.
+	Time time.Time `json:"time"`
+	// UserID is the ID of the user who made the request, or 0 if it was made
+	// anonymously.
+	UserID int32 `json:"userID"`
+	// Name is the name of the request, e.g. "Search" for /.api/graphql?Search.
+	Name          string          `json:"name"`
+	Query         string          `json:"query"`
+	OperationName string          `json:"operationName,omitempty"`
+	Variables     json.RawMessage `json:"variables,omitempty"`
+	// DurationMS is how long the request took in milliseconds.
+	DurationMS float64 `json:"durationMs"`
+	// Status is the HTTP status code of the response.
+	Status int `json:"status"`
+}
+
+// graphQLCapture records the GraphQL queries (but not mutations) that are
+// served to a file.
+type graphQLCapture struct {
+	pseudonymizer
+
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+var (
+	captureOnce sync.Once
+	capture     *graphQLCapture
+)
+
+// getGraphQLCapture returns the capture configured with GRAPHQL_CAPTURE_FILE,
+// or nil if none is configured.
+func getGraphQLCapture() *graphQLCapture {
+	captureOnce.Do(func() {
+		if graphQLCaptureFile == "" {
+			return
+		}
+		f, err := os.OpenFile(graphQLCaptureFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			log15.Error("Failed to open GraphQL capture file. GraphQL requests will not be recorded.", "file", graphQLCaptureFile, "error", err)
+			return
+		}
+		key := []byte(graphQLCaptureKey)
+		if len(key) == 0 {
+			// Without a configured key, pseudonyms are only stable until
+			// the frontend restarts.
+			key = make([]byte, 32)
+			if _, err := rand.Read(key); err != nil {
+				log15.Error("Failed to generate GraphQL capture key. GraphQL requests will not be recorded.", "error", err)
+				return
+			}
+		}
+		capture = &graphQLCapture{pseudonymizer: pseudonymizer{key: key}, enc: json.NewEncoder(f)}
+	})
+	return capture
+}
+
+// serve serves the GraphQL request with h and records it.
+func (c *graphQLCapture) serve(w http.ResponseWriter, r *http.Request, name string, h http.Handler) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	start := time.Now()
+	sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+	h.ServeHTTP(sw, r)
+
+	var params struct {
+		Query         string          `json:"query"`
+		OperationName string          `json:"operationName"`
+		Variables     json.RawMessage `json:"variables"`
+	}
+	if err := json.Unmarshal(body, &params); err != nil || operationType(params.Query, params.OperationName) != "query" {
+		// Don't record mutations: replaying them would change data.
+		return
+	}
+
+	req := capturedRequest{
+		Time:          start.UTC(),
+		UserID:        actor.FromContext(r.Context()).UID,
+		Name:          name,
+		Query:         sanitizeQuery(params.Query),
+		OperationName: params.OperationName,
+		DurationMS:    float64(time.Since(start)) / float64(time.Millisecond),
+		Status:        sw.status,
+	}
+	if len(params.Variables) > 0 && string(params.Variables) != "null" {
+		vars, err := c.sanitizeVariables(params.Variables)
+		if err != nil {
+			return
+		}
+		req.Variables = vars
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.enc.Encode(req); err != nil {
+		log15.Warn("Failed to record GraphQL request.", "error", err)
+	}
+}
+
+// sanitizeQuery returns the GraphQL document with the contents of its string
+// literals removed.
+func sanitizeQuery(document string) string {
+	var b strings.Builder
+	b.Grow(len(document))
+	for i := 0; i < len(document); {
+		switch c := document[i]; {
+		case c == '#':
+			// Comments may contain anything, too.
+			for i < len(document) && document[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(document[i:], `"""`):
+			b.WriteString(`""`)
+			i += 3
+			for i < len(document) && !strings.HasPrefix(document[i:], `"""`) {
+				if strings.HasPrefix(document[i:], `\"""`) {
+					i += 3
+				}
+				i++
+			}
+			i += 3
+		case c == '"':
+			b.WriteString(`""`)
+			for i++; i < len(document) && document[i] != '"'; i++ {
+				if document[i] == '\\' {
+					i++
+				}
+			}
+			i++
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+// capturedVariables are the variables whose string values are recorded,
+// because they are one of a few known values.
+var capturedVariables = map[string]bool{
+	"patternType": true,
+	"version":     true,
+}
+
+// repoNameVariables are the variables whose string values are repository
+// names, which are recorded as pseudonyms.
+var repoNameVariables = map[string]bool{
+	"repo":       true,
+	"repoName":   true,
+	"repoPath":   true,
+	"repository": true,
+}
+
+// capturedSearchFilters are the search filters that are kept in search
+// queries, because their values are one of a few known values.
+var capturedSearchFilters = map[string]bool{
+	"type":        true,
+	"patterntype": true,
+	"case":        true,
+	"fork":        true,
+	"archived":    true,
+	"visibility":  true,
+	"count":       true,
+	"max":         true,
+	"timeout":     true,
+	"index":       true,
+	"stable":      true,
+	"rank":        true,
+	"select":      true,
+	"lang":        true,
+	"l":           true,
+}
+
+// pseudonymizedSearchFilters are the other search filters, whose values are
+// recorded as pseudonyms. Any other field of a search query is a search term.
+var pseudonymizedSearchFilters = map[string]bool{
+	"repo": true, "r": true,
+	"file": true, "f": true,
+	"repohasfile": true, "repogroup": true, "g": true, "repohascommitafter": true,
+	"author": true, "committer": true, "message": true, "m": true, "msg": true,
+	"before": true, "after": true, "since": true, "until": true,
+	"blameauthor": true, "blamebefore": true, "blameafter": true,
+	"content": true, "replace": true, "rule": true,
+}
+
+// repoPseudonymPrefix is the prefix of the pseudonyms of repository names.
+// cmd/loadtest replaces them with the names of repositories of the instance
+// that it replays the capture against.
+const repoPseudonymPrefix = "sgrepo-"
+
+// pseudonymizer derives pseudonyms for the repository names and search terms
+// in captured requests with a keyed hash, so that the same name or term
+// always gets the same pseudonym but can't be recovered from it.
+type pseudonymizer struct {
+	key []byte
+}
+
+func (p pseudonymizer) sum(s string, i int) []byte {
+	mac := hmac.New(sha256.New, p.key)
+	_, _ = fmt.Fprintf(mac, "%d:%s", i, s)
+	return mac.Sum(nil)
+}
+
+// repo returns the pseudonym of a repository name.
+func (p pseudonymizer) repo(name string) string {
+	return repoPseudonymPrefix + hex.EncodeToString(p.sum(strings.ToLower(name), 0))[:16]
+}
+
+// word returns a pseudonym of the word made of as many lowercase letters as
+// the word has characters.
+func (p pseudonymizer) word(w string) string {
+	n := utf8.RuneCountInString(w)
+	b := make([]byte, 0, n)
+	for i := 0; len(b) < n; i++ {
+		for _, c := range p.sum(w, i) {
+			if len(b) == n {
+				break
+			}
+			b = append(b, 'a'+c%26)
+		}
+	}
+	return string(b)
+}
+
+// shape returns s with each run of letters and digits replaced by its
+// pseudonym, keeping the shape of s: its length, whitespace and punctuation,
+// including the meta-characters of regular expressions.
+func (p pseudonymizer) shape(s string) string {
+	var b strings.Builder
+	start := -1
+	for i, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			b.WriteString(p.word(s[start:i]))
+			start = -1
+		}
+		b.WriteRune(r)
+	}
+	if start >= 0 {
+		b.WriteString(p.word(s[start:]))
+	}
+	return b.String()
+}
+
+// sanitizeVariables returns the GraphQL variables with their string values
+// removed, except for those in capturedVariables, repository names, which are
+// replaced by pseudonyms, and search queries (see sanitizeSearchQuery).
+func (p pseudonymizer) sanitizeVariables(variables json.RawMessage) (json.RawMessage, error) {
+	var v interface{}
+	if err := json.Unmarshal(variables, &v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(p.sanitizeVariable("", v))
+}
+
+func (p pseudonymizer) sanitizeVariable(name string, v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = p.sanitizeVariable(k, e)
+		}
+		return v
+	case []interface{}:
+		for i, e := range v {
+			v[i] = p.sanitizeVariable(name, e)
+		}
+		return v
+	case string:
+		switch {
+		case capturedVariables[name]:
+			return v
+		case repoNameVariables[name]:
+			return p.repo(v)
+		case name == "query":
+			return p.sanitizeSearchQuery(v)
+		}
+		return ""
+	}
+	return v
+}
+
+// sanitizeSearchQuery returns the search query with the values of its
+// repo: filters replaced by pseudonyms of repository names, and its terms and
+// the values of its other filters not in capturedSearchFilters replaced by
+// pseudonyms of the same shape.
+func (p pseudonymizer) sanitizeSearchQuery(query string) string {
+	fields := strings.Fields(query)
+	for i, field := range fields {
+		key, value := "", field
+		if j := strings.Index(field, ":"); j > 0 {
+			key, value = strings.ToLower(strings.TrimPrefix(field[:j], "-")), field[j+1:]
+		}
+		switch {
+		case capturedSearchFilters[key] && !strings.ContainsAny(value, `"'`):
+		case key == "repo" || key == "r":
+			fields[i] = field[:len(field)-len(value)] + p.repoPattern(value)
+		case pseudonymizedSearchFilters[key] || capturedSearchFilters[key]:
+			fields[i] = field[:len(field)-len(value)] + p.shape(value)
+		default:
+			fields[i] = p.shape(field)
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// repoPattern returns the pseudonym of the repository name matched by the
+// pattern of a repo: filter, keeping its anchors. Escapes are removed so that
+// a repository gets the same pseudonym in filters and variables. Revisions are
+// dropped, because they don't exist in the repositories the pseudonyms are
+// replaced with.
+func (p pseudonymizer) repoPattern(pattern string) string {
+	if i := strings.Index(pattern, "@"); i >= 0 {
+		pattern = pattern[:i]
+	}
+	var prefix, suffix string
+	if strings.HasPrefix(pattern, "^") {
+		prefix, pattern = "^", pattern[1:]
+	}
+	if strings.HasSuffix(pattern, "$") {
+		suffix, pattern = "$", pattern[:len(pattern)-1]
+	}
+	return prefix + p.repo(strings.ReplaceAll(pattern, `\`, "")) + suffix
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// operationType returns the type of the operation of the GraphQL document
+// ("query", "mutation" or "subscription") that would be executed with the
+// given operation name. It returns "" if it can't tell.
+func operationType(document, operationName string) string {
+	type operation struct{ typ, name string }
+	var ops []operation
+
+	// Scan the top-level definitions of the document, skipping comments,
+	// strings and everything in between braces.
+	depth := 0
+	for i := 0; i < len(document); {
+		switch c := document[i]; {
+		case c == '#':
+			for i < len(document) && document[i] != '\n' {
+				i++
+			}
+		case c == '"':
+			for i++; i < len(document) && document[i] != '"'; i++ {
+				if document[i] == '\\' {
+					i++
+				}
+			}
+			i++
+		case c == '{':
+			if depth == 0 {
+				// The shorthand form of a query.
+				ops = append(ops, operation{typ: "query"})
+			}
+			depth++
+			i++
+		case c == '}':
+			depth--
+			i++
+		case depth == 0 && isNameStart(c):
+			j := i
+			for j < len(document) && isNameChar(document[j]) {
+				j++
+			}
+			switch word := document[i:j]; word {
+			case "query", "mutation", "subscription":
+				op := operation{typ: word}
+				k := j
+				for k < len(document) && strings.ContainsRune(" \t\r\n,", rune(document[k])) {
+					k++
+				}
+				l := k
+				for l < len(document) && isNameChar(document[l]) {
+					l++
+				}
+				op.name = document[k:l]
+				ops = append(ops, op)
+				// Skip to the selection set, so that it isn't taken for a
+				// shorthand query.
+				for j < len(document) && document[j] != '{' {
+					j++
+				}
+				depth++
+				j++
+			case "fragment":
+				ops = append(ops, operation{typ: "fragment"})
+				for j < len(document) && document[j] != '{' {
+					j++
+				}
+				depth++
+				j++
+			}
+			i = j
+		default:
+			i++
+		}
+	}
+
+	var found []operation
+	for _, op := range ops {
+		if op.typ != "fragment" && (operationName == "" || op.name == operationName) {
+			found = append(found, op)
+		}
+	}
+	if len(found) != 1 {
+		return ""
+	}
+	return found[0].typ
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
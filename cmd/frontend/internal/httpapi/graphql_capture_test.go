@@ -0,0 +1,126 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+)
+
+func TestOperationType(t *testing.T) {
+	tests := []struct {
+		document, operationName, want string
+	}{
+		{document: `{ currentUser { username } }`, want: "query"},
+		{document: `query Search($query: String!) { search(query: $query) { results { alert { proposedQueries { query { query } } } } } }`, want: "query"},
+		{document: "# mutation Foo\nquery Foo { x }", want: "query"},
+		{document: `mutation DeleteUser($user: ID!) { deleteUser(user: $user) { alwaysNil } }`, want: "mutation"},
+		{document: `query A { x } mutation B { y }`, operationName: "B", want: "mutation"},
+		{document: `query A { x } mutation B { y }`, want: ""},
+		{document: `query A { ...F } fragment F on Query { x }`, want: "query"},
+		{document: `query A { x(s: "mutation B { y }") }`, operationName: "B", want: ""},
+	}
+	for _, test := range tests {
+		if got := operationType(test.document, test.operationName); got != test.want {
+			t.Errorf("operationType(%q, %q) = %q, want %q", test.document, test.operationName, got, test.want)
+		}
+	}
+}
+
+func TestGraphQLCapture(t *testing.T) {
+	var buf bytes.Buffer
+	c := &graphQLCapture{pseudonymizer: pseudonymizer{key: []byte("k")}, enc: json.NewEncoder(&buf)}
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"data":{}}`))
+	})
+
+	serve := func(body string) {
+		req := httptest.NewRequest("POST", "/.api/graphql?Search", strings.NewReader(body))
+		req.Header.Set("Authorization", "token secret")
+		req = req.WithContext(actor.WithActor(req.Context(), &actor.Actor{UID: 42}))
+		rec := httptest.NewRecorder()
+		c.serve(rec, req, "Search", h)
+		if rec.Body.String() != `{"data":{}}` {
+			t.Errorf("unexpected response %q", rec.Body.String())
+		}
+	}
+	serve(`{"query":"query Search($query: String!) { search(query: $query) { results { limitHit } } }","variables":{"query":"repo:secret-repo type:symbol secret-term","patternType":"regexp"}}`)
+	serve(`{"query":"mutation { logout }"}`)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("got %d recorded requests, want 1 (mutations must not be recorded): %s", len(lines), buf.String())
+	}
+	if strings.Contains(lines[0], "secret") {
+		t.Errorf("recorded request contains credentials or search terms: %s", lines[0])
+	}
+
+	var got capturedRequest
+	if err := json.Unmarshal([]byte(lines[0]), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.UserID != 42 || got.Name != "Search" || got.Status != http.StatusOK || string(got.Variables) != `{"patternType":"regexp","query":"repo:`+c.repo("secret-repo")+` type:symbol `+c.shape("secret-term")+`"}` {
+		t.Errorf("unexpected recorded request %+v", got)
+	}
+}
+
+func TestSanitizeQuery(t *testing.T) {
+	tests := map[string]string{
+		`{ repository(name: "github.com/foo/bar") { id } }`:            `{ repository(name: "") { id } }`,
+		`{ x(s: "a \"quoted\" b", t: "c") }`:                           `{ x(s: "", t: "") }`,
+		"{ x(s: \"\"\"block \\\"\"\" string\"\"\") }":                  `{ x(s: "") }`,
+		"# search for \"secret\"\nquery Search($query: String!) { x }": "\nquery Search($query: String!) { x }",
+	}
+	for document, want := range tests {
+		if got := sanitizeQuery(document); got != want {
+			t.Errorf("sanitizeQuery(%q) = %q, want %q", document, got, want)
+		}
+	}
+}
+
+func TestSanitizeVariables(t *testing.T) {
+	p := pseudonymizer{key: []byte("k")}
+	got, err := p.sanitizeVariables(json.RawMessage(`{"query":"repo:^foo$ TYPE:diff case:yes \"bar\"","patternType":"literal","repoName":"foo","first":10,"names":["a","b"],"input":{"token":"s3cr3t","enabled":true}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"first":10,"input":{"enabled":true,"token":""},"names":["",""],"patternType":"literal","query":"repo:^` + p.repo("foo") + `$ TYPE:diff case:yes \"` + p.word("bar") + `\"","repoName":"` + p.repo("foo") + `"}`
+	if string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestSanitizeSearchQuery(t *testing.T) {
+	p := pseudonymizer{key: []byte("k")}
+	tests := map[string]string{
+		`repo:^github\.com/foo/bar$@v1 -r:baz`: `repo:^` + p.repo("github.com/foo/bar") + `$ -r:` + p.repo("baz"),
+		`-file:\.go$ f:test lang:go`:           `-file:\.` + p.word("go") + `$ f:` + p.word("test") + ` lang:go`,
+		`type:commit author:alice fix`:         `type:commit author:` + p.word("alice") + ` ` + p.word("fix"),
+		`foo(\d+)\s*bar:baz`:                   p.word("foo") + `(\` + p.word("d") + `+)\` + p.word("s") + `*` + p.word("bar") + `:` + p.word("baz"),
+		`case:"yes"`:                           `case:"` + p.word("yes") + `"`,
+	}
+	for query, want := range tests {
+		if got := p.sanitizeSearchQuery(query); got != want {
+			t.Errorf("sanitizeSearchQuery(%q) = %q, want %q", query, got, want)
+		}
+	}
+}
+
+func TestPseudonymizer(t *testing.T) {
+	p := pseudonymizer{key: []byte("k")}
+	if got := p.shape("fooBar.*(x|ÿz)"); len([]rune(got)) != len([]rune("fooBar.*(x|ÿz)")) || got[6:9] != ".*(" || got == p.shape("fooBaz.*(x|ÿz)") {
+		t.Errorf("unexpected shape %q", got)
+	}
+	if p.repo("Foo") != p.repo("foo") || p.repo("foo") == p.repo("bar") || p.repo("foo") == (pseudonymizer{key: []byte("l")}).repo("foo") {
+		t.Error("repository pseudonyms must be stable for a key and distinct across names and keys")
+	}
+	if !strings.HasPrefix(p.repo("foo"), repoPseudonymPrefix) {
+		t.Errorf("unexpected repository pseudonym %q", p.repo("foo"))
+	}
+}
@@ -15,8 +15,10 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/globals"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
 	"github.com/sourcegraph/sourcegraph/internal/jsonc"
@@ -305,6 +307,14 @@ func serveReposListEnabled(w http.ResponseWriter, r *http.Request) error {
 	return json.NewEncoder(w).Encode(names)
 }
 
+func serveRepoGroupsEvaluate(w http.ResponseWriter, r *http.Request) error {
+	if err := backend.RepoGroups.EvaluateAll(r.Context()); err != nil {
+		return errors.Wrap(err, "RepoGroups.EvaluateAll")
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
 func serveSavedQueriesListAll(w http.ResponseWriter, r *http.Request) error {
 	// List settings for all users, orgs, etc.
 	settings, err := db.SavedSearches.ListAll(r.Context())
@@ -385,6 +395,104 @@ func serveSavedQueriesDeleteInfo(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
+func serveSavedQueriesLastSuccessfulRun(w http.ResponseWriter, r *http.Request) error {
+	var savedSearchID int32
+	err := json.NewDecoder(r.Body).Decode(&savedSearchID)
+	if err != nil {
+		return errors.Wrap(err, "Decode")
+	}
+	run, err := db.SavedSearchRuns.LastSuccessful(r.Context(), savedSearchID)
+	if err != nil {
+		return errors.Wrap(err, "SavedSearchRuns.LastSuccessful")
+	}
+	if err := json.NewEncoder(w).Encode(run); err != nil {
+		return errors.Wrap(err, "Encode")
+	}
+	return nil
+}
+
+func serveSavedQueriesRecordRun(w http.ResponseWriter, r *http.Request) error {
+	var run *api.SavedQueryRun
+	err := json.NewDecoder(r.Body).Decode(&run)
+	if err != nil {
+		return errors.Wrap(err, "Decode")
+	}
+	err = db.SavedSearchRuns.Create(r.Context(), run)
+	if err != nil {
+		return errors.Wrap(err, "SavedSearchRuns.Create")
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("OK"))
+	return nil
+}
+
+func serveSavedQueriesReadableRepos(w http.ResponseWriter, r *http.Request) error {
+	var args api.SavedQueriesReadableReposArgs
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		return errors.Wrap(err, "Decode")
+	}
+	readable, err := savedQueryReadableRepos(r.Context(), args.Subject, args.Repos)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(w).Encode(readable); err != nil {
+		return errors.Wrap(err, "Encode")
+	}
+	return nil
+}
+
+// savedQueryReadableRepos returns the repositories that the owner of a saved
+// query may read, in their original order. For an org, these are the
+// repositories that every member of the org may read, because its saved
+// queries notify all of them.
+func savedQueryReadableRepos(ctx context.Context, subject api.SettingsSubject, repos []api.RepoName) ([]api.RepoName, error) {
+	var userIDs []int32
+	switch {
+	case subject.User != nil:
+		userIDs = []int32{*subject.User}
+	case subject.Org != nil:
+		members, err := db.OrgMembers.GetByOrgID(ctx, *subject.Org)
+		if err != nil {
+			return nil, errors.Wrap(err, "OrgMembers.GetByOrgID")
+		}
+		for _, m := range members {
+			userIDs = append(userIDs, m.UserID)
+		}
+	default:
+		return nil, errors.Errorf("saved queries of %s can't be run", subject)
+	}
+	if len(userIDs) == 0 {
+		return []api.RepoName{}, nil
+	}
+
+	readable := make(map[api.RepoName]bool, len(repos))
+	for _, name := range repos {
+		readable[name] = true
+	}
+	for _, userID := range userIDs {
+		// 🚨 SECURITY: Repos.GetByName only returns the repositories that the
+		// actor may read.
+		userCtx := actor.WithActor(ctx, actor.FromUser(userID))
+		for name := range readable {
+			if _, err := db.Repos.GetByName(userCtx, name); err != nil {
+				if !errcode.IsNotFound(err) {
+					return nil, errors.Wrapf(err, "Repos.GetByName %q", name)
+				}
+				delete(readable, name)
+			}
+		}
+	}
+
+	result := make([]api.RepoName, 0, len(readable))
+	for _, name := range repos {
+		if readable[name] {
+			result = append(result, name)
+			delete(readable, name)
+		}
+	}
+	return result, nil
+}
+
 func serveSettingsGetForSubject(w http.ResponseWriter, r *http.Request) error {
 	var subject api.SettingsSubject
 	if err := json.NewDecoder(r.Body).Decode(&subject); err != nil {
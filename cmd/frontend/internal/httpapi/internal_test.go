@@ -12,7 +12,9 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
 	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
 
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
@@ -258,3 +260,46 @@ func (b suffixIndexers) ReposSubset(ctx context.Context, hostname string, indexe
 func (b suffixIndexers) Enabled() bool {
 	return bool(b)
 }
+
+func TestSavedQueryReadableRepos(t *testing.T) {
+	defer func() { db.Mocks = db.MockStores{} }()
+
+	// User 1 may read every repository, user 2 only public ones.
+	db.Mocks.Repos.GetByName = func(ctx context.Context, name api.RepoName) (*types.Repo, error) {
+		if name == "github.com/foo/private" && actor.FromContext(ctx).UID != 1 {
+			return nil, &errcode.Mock{IsNotFound: true}
+		}
+		return &types.Repo{Name: name}, nil
+	}
+	db.Mocks.OrgMembers.GetByOrgID = func(ctx context.Context, orgID int32) ([]*types.OrgMembership, error) {
+		return []*types.OrgMembership{{OrgID: orgID, UserID: 1}, {OrgID: orgID, UserID: 2}}, nil
+	}
+
+	repos := []api.RepoName{"github.com/foo/private", "github.com/foo/public"}
+	user := func(id int32) api.SettingsSubject { return api.SettingsSubject{User: &id} }
+	org := int32(1)
+	for name, tc := range map[string]struct {
+		subject api.SettingsSubject
+		want    []api.RepoName
+	}{
+		"user with access":    {subject: user(1), want: repos},
+		"user without access": {subject: user(2), want: []api.RepoName{"github.com/foo/public"}},
+		// An org's saved search notifies all members, so it only matches the
+		// repositories that all of them may read.
+		"org": {subject: api.SettingsSubject{Org: &org}, want: []api.RepoName{"github.com/foo/public"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := savedQueryReadableRepos(context.Background(), tc.subject, repos)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+
+	if _, err := savedQueryReadableRepos(context.Background(), api.SettingsSubject{Site: true}, repos); err == nil {
+		t.Error("got nil error for a site saved query, want error")
+	}
+}
@@ -0,0 +1,131 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/quota"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+)
+
+type accessTokenKey struct{}
+
+// withAccessToken returns a copy of ctx that records the access token that
+// authenticated the request, so that the budget of the access token can be
+// enforced.
+func withAccessToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, accessTokenKey{}, token)
+}
+
+func accessTokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(accessTokenKey{}).(string)
+	return token
+}
+
+// enforceQuota wraps an API handler to reject requests that would exceed the
+// API budgets of the user, their organizations or the access token that
+// authenticated the request, as configured in the apiRateLimits site
+// configuration setting. The amounts of API resources used by a request are
+// computed by amounts, which is only called if budgets apply to the request.
+//
+// Responses include the X-RateLimit-* headers for the budget that is closest to
+// being exhausted.
+func enforceQuota(amounts func(r *http.Request) map[quota.Resource]int, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg := conf.Get().ApiRateLimits
+		if cfg == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var accessTokenID int64
+		if token := accessTokenFromContext(r.Context()); token != "" && cfg.AccessToken != nil {
+			t, err := db.AccessTokens.GetByToken(r.Context(), token)
+			if err != nil {
+				log15.Error("Failed to get access token for API rate limits.", "error", err)
+				http.Error(w, "Failed to get access token.", http.StatusInternalServerError)
+				return
+			}
+			accessTokenID = t.ID
+		}
+
+		subjects, err := quota.Subjects(r.Context(), accessTokenID)
+		if err != nil {
+			log15.Error("Failed to determine API rate limits.", "error", err)
+			http.Error(w, "Failed to determine API rate limits.", http.StatusInternalServerError)
+			return
+		}
+		if len(subjects) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		usage, err := quota.Consume(r.Context(), subjects, amounts(r))
+		if e, ok := err.(*quota.ExceededError); ok {
+			setRateLimitHeaders(w.Header(), &e.Usage)
+			w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(e.Usage.Reset).Seconds())+1))
+			http.Error(w, e.Error(), http.StatusTooManyRequests)
+			return
+		}
+		if err != nil {
+			// Don't fail requests if the usage of the API can't be recorded.
+			log15.Error("Failed to record API usage.", "error", err)
+		}
+		if usage != nil {
+			setRateLimitHeaders(w.Header(), usage)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func setRateLimitHeaders(h http.Header, u *quota.Usage) {
+	h.Set("X-RateLimit-Resource", string(u.Resource))
+	h.Set("X-RateLimit-Limit", strconv.Itoa(u.Limit))
+	h.Set("X-RateLimit-Remaining", strconv.Itoa(u.Remaining()))
+	h.Set("X-RateLimit-Reset", strconv.FormatInt(u.Reset.Unix(), 10))
+}
+
+// quotaAmount returns a function for enforceQuota that reports that each
+// request uses n of the resource.
+func quotaAmount(resource quota.Resource, n int) func(*http.Request) map[quota.Resource]int {
+	return func(*http.Request) map[quota.Resource]int {
+		return map[quota.Resource]int{resource: n}
+	}
+}
+
+// graphQLQuotaAmounts reports the cost of a GraphQL request and the number of
+// searches it performs. Requests whose cost can't be computed (e.g. because
+// the query is invalid) cost 1, since they fail.
+func graphQLQuotaAmounts(r *http.Request) map[quota.Resource]int {
+	amounts := map[quota.Resource]int{quota.GraphQLCost: 1}
+
+	body, err := ioutil.ReadAll(r.Body)
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return amounts
+	}
+	var params struct {
+		Query         string                 `json:"query"`
+		OperationName string                 `json:"operationName"`
+		Variables     map[string]interface{} `json:"variables"`
+	}
+	if err := json.Unmarshal(body, &params); err != nil {
+		return amounts
+	}
+	cost, searches, err := quota.GraphQLRequestCost(params.Query, params.OperationName, params.Variables)
+	if err != nil {
+		return amounts
+	}
+	if cost > 1 {
+		amounts[quota.GraphQLCost] = cost
+	}
+	amounts[quota.SearchRequests] = searches
+	return amounts
+}
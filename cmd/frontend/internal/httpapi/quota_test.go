@@ -0,0 +1,95 @@
+package httpapi
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/quota"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestEnforceQuota(t *testing.T) {
+	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{ApiRateLimits: &schema.ApiRateLimits{
+		User:        &schema.APIBudget{SearchRequests: 10},
+		AccessToken: &schema.APIBudget{GraphQLCost: 100},
+	}}})
+	defer conf.Mock(nil)
+	db.Mocks.AccessTokens.GetByToken = func(tokenHexEncoded string) (*db.AccessToken, error) {
+		return &db.AccessToken{ID: 7}, nil
+	}
+	defer func() { db.Mocks = db.MockStores{} }()
+
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+	var gotSubjects []quota.Subject
+	var gotAmounts map[quota.Resource]int
+	quota.MockConsume = func(subjects []quota.Subject, amounts map[quota.Resource]int) (*quota.Usage, error) {
+		gotSubjects, gotAmounts = subjects, amounts
+		if amounts[quota.SearchRequests] > 1 {
+			return nil, &quota.ExceededError{Usage: quota.Usage{Resource: quota.SearchRequests, Used: 10, Limit: 10, Reset: reset}}
+		}
+		return &quota.Usage{Resource: quota.GraphQLCost, Used: 21, Limit: 100, Reset: reset}, nil
+	}
+	defer func() { quota.MockConsume = nil }()
+
+	var body string
+	handler := enforceQuota(graphQLQuotaAmounts, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body = string(b)
+	}))
+	serve := func(query string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/.api/graphql", strings.NewReader(query))
+		ctx := actor.WithActor(context.Background(), &actor.Actor{UID: 1})
+		req = req.WithContext(withAccessToken(ctx, "abcd"))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	query := `{"query": "{ repositories(first: 10) { nodes { name, commit(rev: \"HEAD\") { oid } } } }"}`
+	rr := serve(query)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", rr.Code, http.StatusOK)
+	}
+	if body != query {
+		t.Errorf("got body %q, want the request body to be passed on", body)
+	}
+	if want := []quota.Subject{{Kind: quota.SubjectUser, ID: 1}, {Kind: quota.SubjectAccessToken, ID: 7}}; !reflect.DeepEqual(gotSubjects, want) {
+		t.Errorf("got subjects %+v, want %+v", gotSubjects, want)
+	}
+	if want := map[quota.Resource]int{quota.GraphQLCost: 21, quota.SearchRequests: 0}; !reflect.DeepEqual(gotAmounts, want) {
+		t.Errorf("got amounts %v, want %v", gotAmounts, want)
+	}
+	for header, want := range map[string]string{
+		"X-RateLimit-Resource":  "graphQLCost",
+		"X-RateLimit-Limit":     "100",
+		"X-RateLimit-Remaining": "79",
+	} {
+		if got := rr.Header().Get(header); got != want {
+			t.Errorf("got %s %q, want %q", header, got, want)
+		}
+	}
+
+	body = ""
+	rr = serve(`{"query": "{ a: search(query: \"a\") { __typename } b: search(query: \"b\") { __typename } }"}`)
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("got status %d, want %d", rr.Code, http.StatusTooManyRequests)
+	}
+	if body != "" {
+		t.Error("got request passed on after exceeding budget")
+	}
+	if got := rr.Header().Get("X-RateLimit-Remaining"); got != "0" {
+		t.Errorf("got X-RateLimit-Remaining %q, want 0", got)
+	}
+	if rr.Header().Get("Retry-After") == "" {
+		t.Error("got no Retry-After header")
+	}
+}
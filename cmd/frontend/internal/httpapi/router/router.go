@@ -15,40 +15,49 @@ const (
 
 	Registry = "registry"
 
+	SearchStream = "search.stream"
+
 	RepoShield  = "repo.shield"
 	RepoRefresh = "repo.refresh"
 	Telemetry   = "telemetry"
 
+	AuditLogExport = "audit-log.export"
+
 	GitHubWebhooks          = "github.webhooks"
+	GitLabWebhooks          = "gitlab.webhooks"
 	BitbucketServerWebhooks = "bitbucketServer.webhooks"
 
-	SavedQueriesListAll    = "internal.saved-queries.list-all"
-	SavedQueriesGetInfo    = "internal.saved-queries.get-info"
-	SavedQueriesSetInfo    = "internal.saved-queries.set-info"
-	SavedQueriesDeleteInfo = "internal.saved-queries.delete-info"
-	SettingsGetForSubject  = "internal.settings.get-for-subject"
-	OrgsListUsers          = "internal.orgs.list-users"
-	OrgsGetByName          = "internal.orgs.get-by-name"
-	UsersGetByUsername     = "internal.users.get-by-username"
-	UserEmailsGetEmail     = "internal.user-emails.get-email"
-	ExternalURL            = "internal.app-url"
-	CanSendEmail           = "internal.can-send-email"
-	SendEmail              = "internal.send-email"
-	Extension              = "internal.extension"
-	GitResolveRevision     = "internal.git.resolve-revision"
-	GitTar                 = "internal.git.tar"
-	GitExec                = "internal.git.exec"
-	PhabricatorRepoCreate  = "internal.phabricator.repo.create"
-	ReposGetByName         = "internal.repos.get-by-name"
-	ReposInventoryUncached = "internal.repos.inventory-uncached"
-	ReposInventory         = "internal.repos.inventory"
-	ReposList              = "internal.repos.list"
-	ReposIndex             = "internal.repos.index"
-	ReposListEnabled       = "internal.repos.list-enabled"
-	Configuration          = "internal.configuration"
-	SearchConfiguration    = "internal.search-configuration"
-	ExternalServiceConfigs = "internal.external-services.configs"
-	ExternalServicesList   = "internal.external-services.list"
+	SavedQueriesListAll           = "internal.saved-queries.list-all"
+	SavedQueriesGetInfo           = "internal.saved-queries.get-info"
+	SavedQueriesSetInfo           = "internal.saved-queries.set-info"
+	SavedQueriesDeleteInfo        = "internal.saved-queries.delete-info"
+	SavedQueriesLastSuccessfulRun = "internal.saved-queries.last-successful-run"
+	SavedQueriesRecordRun         = "internal.saved-queries.record-run"
+	SavedQueriesReadableRepos     = "internal.saved-queries.readable-repos"
+	SettingsGetForSubject         = "internal.settings.get-for-subject"
+	OrgsListUsers                 = "internal.orgs.list-users"
+	OrgsGetByName                 = "internal.orgs.get-by-name"
+	UsersGetByUsername            = "internal.users.get-by-username"
+	UserEmailsGetEmail            = "internal.user-emails.get-email"
+	ExternalURL                   = "internal.app-url"
+	CanSendEmail                  = "internal.can-send-email"
+	SendEmail                     = "internal.send-email"
+	Extension                     = "internal.extension"
+	GitResolveRevision            = "internal.git.resolve-revision"
+	GitTar                        = "internal.git.tar"
+	GitExec                       = "internal.git.exec"
+	PhabricatorRepoCreate         = "internal.phabricator.repo.create"
+	ReposGetByName                = "internal.repos.get-by-name"
+	ReposInventoryUncached        = "internal.repos.inventory-uncached"
+	ReposInventory                = "internal.repos.inventory"
+	ReposList                     = "internal.repos.list"
+	ReposIndex                    = "internal.repos.index"
+	ReposListEnabled              = "internal.repos.list-enabled"
+	RepoGroupsEvaluate            = "internal.repo-groups.evaluate"
+	Configuration                 = "internal.configuration"
+	SearchConfiguration           = "internal.search-configuration"
+	ExternalServiceConfigs        = "internal.external-services.configs"
+	ExternalServicesList          = "internal.external-services.list"
 )
 
 // New creates a new API router with route URL pattern definitions but
@@ -63,10 +72,13 @@ func New(base *mux.Router) *mux.Router {
 	addRegistryRoute(base)
 	addGraphQLRoute(base)
 	base.Path("/github-webhooks").Methods("POST").Name(GitHubWebhooks)
+	base.Path("/gitlab-webhooks").Methods("POST").Name(GitLabWebhooks)
 	base.Path("/bitbucket-server-webhooks").Methods("POST").Name(BitbucketServerWebhooks)
 	base.Path("/lsif/upload").Methods("POST").Name(LSIFUpload)
 	base.Path("/src-cli/version").Methods("GET").Name(SrcCliVersion)
 	base.Path("/src-cli/{rest:.*}").Methods("GET").Name(SrcCliDownload)
+	base.Path("/search/stream").Methods("GET").Name(SearchStream)
+	base.Path("/audit-log/export").Methods("GET").Name(AuditLogExport)
 
 	// repo contains routes that are NOT specific to a revision. In these routes, the URL may not contain a revspec after the repo (that is, no "github.com/foo/bar@myrevspec").
 	repoPath := `/repos/` + routevar.Repo
@@ -92,6 +104,9 @@ func NewInternal(base *mux.Router) *mux.Router {
 	base.Path("/saved-queries/get-info").Methods("POST").Name(SavedQueriesGetInfo)
 	base.Path("/saved-queries/set-info").Methods("POST").Name(SavedQueriesSetInfo)
 	base.Path("/saved-queries/delete-info").Methods("POST").Name(SavedQueriesDeleteInfo)
+	base.Path("/saved-queries/last-successful-run").Methods("POST").Name(SavedQueriesLastSuccessfulRun)
+	base.Path("/saved-queries/record-run").Methods("POST").Name(SavedQueriesRecordRun)
+	base.Path("/saved-queries/readable-repos").Methods("POST").Name(SavedQueriesReadableRepos)
 	base.Path("/settings/get-for-subject").Methods("POST").Name(SettingsGetForSubject)
 	base.Path("/orgs/list-users").Methods("POST").Name(OrgsListUsers)
 	base.Path("/orgs/get-by-name").Methods("POST").Name(OrgsGetByName)
@@ -112,6 +127,7 @@ func NewInternal(base *mux.Router) *mux.Router {
 	base.Path("/repos/list").Methods("POST").Name(ReposList)
 	base.Path("/repos/index").Methods("POST").Name(ReposIndex)
 	base.Path("/repos/list-enabled").Methods("POST").Name(ReposListEnabled)
+	base.Path("/repo-groups/evaluate").Methods("POST").Name(RepoGroupsEvaluate)
 	base.Path("/repos/{RepoName:.*}").Methods("POST").Name(ReposGetByName)
 	base.Path("/configuration").Methods("POST").Name(Configuration)
 	base.Path("/search/configuration").Methods("GET").Name(SearchConfiguration)
@@ -0,0 +1,320 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/inconshreveable/log15"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+// mockNewSearchImplementer is used by tests to stub out the search pipeline.
+var mockNewSearchImplementer func(args *graphqlbackend.SearchArgs) (graphqlbackend.SearchImplementer, error)
+
+// serveSearchStream runs a search and streams its results to the client as
+// Server-Sent Events (https://html.spec.whatwg.org/multipage/server-sent-events.html).
+//
+// The query is read from the "q" URL parameter. The optional "v" and "t"
+// parameters are the search version and pattern type, as in the GraphQL
+// search field.
+//
+// Results are sent as they are found in events named after the kind of match
+// ("filematches", "symbolmatches", "diffmatches", "commitmatches" and
+// "repomatches"). Progress events are sent as each search backend finishes,
+// and a single "done" event with the final statistics and alert (if any) is
+// sent last.
+func serveSearchStream(w http.ResponseWriter, r *http.Request) error {
+	q := r.URL.Query().Get("q")
+	if q == "" {
+		return errors.New("no query specified (use the q URL parameter)")
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return errors.New("http flushing not supported")
+	}
+
+	events := make(chan graphqlbackend.SearchEvent)
+	args := &graphqlbackend.SearchArgs{
+		Query:  q,
+		Stream: events,
+	}
+	if v := r.URL.Query().Get("v"); v != "" {
+		args.Version = v
+	} else {
+		args.Version = "V2"
+	}
+	if t := r.URL.Query().Get("t"); t != "" {
+		args.PatternType = &t
+	}
+
+	newSearchImplementer := graphqlbackend.NewSearchImplementer
+	if mockNewSearchImplementer != nil {
+		newSearchImplementer = mockNewSearchImplementer
+	}
+	search, err := newSearchImplementer(args)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	ew := &eventWriter{w: w, flush: flusher.Flush}
+
+	var (
+		results *graphqlbackend.SearchResultsResolver
+		done    = make(chan error, 1)
+	)
+	go func() {
+		defer close(events)
+		var err error
+		results, err = search.Results(r.Context())
+		done <- err
+	}()
+
+	// We must drain events until the search is done, even if the client has
+	// gone away, otherwise the search goroutines block forever.
+	for event := range events {
+		if ew.err != nil {
+			continue
+		}
+		if len(event.Results) > 0 {
+			writeSearchResults(r.Context(), ew, event.Results)
+		}
+		if event.Progress != nil {
+			ew.event("progress", toStreamProgress(event.Progress))
+		}
+	}
+
+	if err := <-done; err != nil {
+		ew.event("error", streamError{Message: err.Error()})
+		return nil
+	}
+
+	final := streamDone{}
+	if results != nil {
+		final.MatchCount = results.MatchCount()
+		final.LimitHit = results.LimitHit()
+		final.ElapsedMilliseconds = results.ElapsedMilliseconds()
+		if alert := results.Alert(); alert != nil {
+			final.Alert = &streamAlert{Title: alert.Title()}
+			if description := alert.Description(); description != nil {
+				final.Alert.Description = *description
+			}
+			if proposed := alert.ProposedQueries(); proposed != nil {
+				for _, pq := range *proposed {
+					final.Alert.ProposedQueries = append(final.Alert.ProposedQueries, streamProposedQuery{
+						Description: derefString(pq.Description()),
+						Query:       pq.Query(),
+					})
+				}
+			}
+		}
+	}
+	ew.event("done", final)
+	return nil
+}
+
+// writeSearchResults groups results by kind and writes one event per kind.
+func writeSearchResults(ctx context.Context, ew *eventWriter, results []graphqlbackend.SearchResultResolver) {
+	var (
+		fileMatches   []streamFileMatch
+		symbolMatches []streamSymbolMatch
+		diffMatches   []streamCommitMatch
+		commitMatches []streamCommitMatch
+		repoMatches   []streamRepoMatch
+	)
+	for _, result := range results {
+		if fm, ok := result.ToFileMatch(); ok {
+			if symbols := fm.Symbols(); len(symbols) > 0 {
+				sm := streamSymbolMatch{
+					Repository: fm.Repo.Name,
+					Commit:     fm.CommitID,
+					Path:       fm.JPath,
+				}
+				for _, s := range symbols {
+					sm.Symbols = append(sm.Symbols, streamSymbol{
+						Name:          s.Name(),
+						ContainerName: derefString(s.ContainerName()),
+						Kind:          s.Kind(),
+						Line:          s.Location().Range().Start().Line(),
+					})
+				}
+				symbolMatches = append(symbolMatches, sm)
+			}
+			if lineMatches := fm.LineMatches(); len(lineMatches) > 0 || len(fm.Symbols()) == 0 {
+				m := streamFileMatch{
+					Repository: fm.Repo.Name,
+					Commit:     fm.CommitID,
+					Path:       fm.JPath,
+					LimitHit:   fm.LimitHit(),
+				}
+				for _, lm := range lineMatches {
+					m.LineMatches = append(m.LineMatches, streamLineMatch{
+						Line:             lm.Preview(),
+						LineNumber:       lm.LineNumber(),
+						OffsetAndLengths: lm.OffsetAndLengths(),
+					})
+				}
+				fileMatches = append(fileMatches, m)
+			}
+		} else if cm, ok := result.ToCommitSearchResult(); ok {
+			commit := cm.Commit()
+			m := streamCommitMatch{
+				Repository: api.RepoName(commit.Repository().Name()),
+				Commit:     api.CommitID(commit.OID()),
+			}
+			m.URL, _ = commit.URL()
+			m.Subject, _ = commit.Subject(ctx)
+			if diff := cm.DiffPreview(); diff != nil {
+				m.Preview = diff.Value()
+				diffMatches = append(diffMatches, m)
+			} else {
+				if message := cm.MessagePreview(); message != nil {
+					m.Preview = message.Value()
+				}
+				commitMatches = append(commitMatches, m)
+			}
+		} else if repo, ok := result.ToRepository(); ok {
+			repoMatches = append(repoMatches, streamRepoMatch{Repository: api.RepoName(repo.Name())})
+		}
+	}
+
+	if len(fileMatches) > 0 {
+		ew.event("filematches", fileMatches)
+	}
+	if len(symbolMatches) > 0 {
+		ew.event("symbolmatches", symbolMatches)
+	}
+	if len(diffMatches) > 0 {
+		ew.event("diffmatches", diffMatches)
+	}
+	if len(commitMatches) > 0 {
+		ew.event("commitmatches", commitMatches)
+	}
+	if len(repoMatches) > 0 {
+		ew.event("repomatches", repoMatches)
+	}
+}
+
+func toStreamProgress(p *graphqlbackend.SearchProgress) streamProgress {
+	return streamProgress{
+		MatchCount: p.MatchCount,
+		LimitHit:   p.LimitHit,
+		Searched:   p.Searched,
+		Indexed:    p.Indexed,
+		Cloning:    p.Cloning,
+		Missing:    p.Missing,
+		Timedout:   p.Timedout,
+	}
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// eventWriter writes Server-Sent Events. After the first write error, all
+// further writes are dropped and the error is kept in err.
+type eventWriter struct {
+	w     http.ResponseWriter
+	flush func()
+	err   error
+}
+
+func (e *eventWriter) event(name string, v interface{}) {
+	if e.err != nil {
+		return
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		log15.Error("search stream: failed to marshal event", "event", name, "error", err)
+		return
+	}
+	if _, err := fmt.Fprintf(e.w, "event: %s\ndata: %s\n\n", name, data); err != nil {
+		e.err = err
+		return
+	}
+	e.flush()
+}
+
+type streamLineMatch struct {
+	Line             string    `json:"line"`
+	LineNumber       int32     `json:"lineNumber"`
+	OffsetAndLengths [][]int32 `json:"offsetAndLengths"`
+}
+
+type streamFileMatch struct {
+	Repository  api.RepoName      `json:"repository"`
+	Commit      api.CommitID      `json:"commit,omitempty"`
+	Path        string            `json:"path"`
+	LineMatches []streamLineMatch `json:"lineMatches,omitempty"`
+	LimitHit    bool              `json:"limitHit,omitempty"`
+}
+
+type streamSymbol struct {
+	Name          string `json:"name"`
+	ContainerName string `json:"containerName,omitempty"`
+	Kind          string `json:"kind"`
+	Line          int32  `json:"line"`
+}
+
+type streamSymbolMatch struct {
+	Repository api.RepoName   `json:"repository"`
+	Commit     api.CommitID   `json:"commit,omitempty"`
+	Path       string         `json:"path"`
+	Symbols    []streamSymbol `json:"symbols"`
+}
+
+type streamCommitMatch struct {
+	Repository api.RepoName `json:"repository"`
+	Commit     api.CommitID `json:"commit"`
+	Subject    string       `json:"subject"`
+	URL        string       `json:"url"`
+	Preview    string       `json:"preview"`
+}
+
+type streamRepoMatch struct {
+	Repository api.RepoName `json:"repository"`
+}
+
+type streamProgress struct {
+	MatchCount int32          `json:"matchCount"`
+	LimitHit   bool           `json:"limitHit"`
+	Searched   int            `json:"searched"`
+	Indexed    int            `json:"indexed"`
+	Cloning    []api.RepoName `json:"cloning,omitempty"`
+	Missing    []api.RepoName `json:"missing,omitempty"`
+	Timedout   []api.RepoName `json:"timedout,omitempty"`
+}
+
+type streamProposedQuery struct {
+	Description string `json:"description,omitempty"`
+	Query       string `json:"query"`
+}
+
+type streamAlert struct {
+	Title           string                `json:"title"`
+	Description     string                `json:"description,omitempty"`
+	ProposedQueries []streamProposedQuery `json:"proposedQueries,omitempty"`
+}
+
+type streamDone struct {
+	MatchCount          int32        `json:"matchCount"`
+	LimitHit            bool         `json:"limitHit"`
+	ElapsedMilliseconds int32        `json:"elapsedMilliseconds"`
+	Alert               *streamAlert `json:"alert,omitempty"`
+}
+
+type streamError struct {
+	Message string `json:"message"`
+}
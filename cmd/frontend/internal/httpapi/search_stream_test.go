@@ -0,0 +1,75 @@
+package httpapi
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+type mockSearch struct {
+	graphqlbackend.SearchImplementer
+	stream chan<- graphqlbackend.SearchEvent
+}
+
+func (m *mockSearch) Results(context.Context) (*graphqlbackend.SearchResultsResolver, error) {
+	fm := &graphqlbackend.FileMatchResolver{
+		JPath:    "a.go",
+		Repo:     &types.Repo{Name: "repo"},
+		CommitID: "deadbeef",
+	}
+	m.stream <- graphqlbackend.SearchEvent{Results: []graphqlbackend.SearchResultResolver{fm}}
+	m.stream <- graphqlbackend.SearchEvent{Progress: &graphqlbackend.SearchProgress{
+		MatchCount: 1,
+		Searched:   1,
+		Cloning:    []api.RepoName{"cloning"},
+	}}
+	return &graphqlbackend.SearchResultsResolver{
+		SearchResults: []graphqlbackend.SearchResultResolver{fm},
+	}, nil
+}
+
+func TestServeSearchStream(t *testing.T) {
+	mockNewSearchImplementer = func(args *graphqlbackend.SearchArgs) (graphqlbackend.SearchImplementer, error) {
+		if args.Query != "foo" {
+			t.Errorf("got query %q, want %q", args.Query, "foo")
+		}
+		return &mockSearch{stream: args.Stream}, nil
+	}
+	defer func() { mockNewSearchImplementer = nil }()
+
+	req := httptest.NewRequest("GET", "/search/stream?q=foo", nil)
+	rec := httptest.NewRecorder()
+	if err := serveSearchStream(rec, req); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := rec.Header().Get("Content-Type"), "text/event-stream"; got != want {
+		t.Errorf("got Content-Type %q, want %q", got, want)
+	}
+
+	want := strings.Join([]string{
+		"event: filematches",
+		`data: [{"repository":"repo","commit":"deadbeef","path":"a.go"}]`,
+		"",
+		"event: progress",
+		`data: {"matchCount":1,"limitHit":false,"searched":1,"indexed":0,"cloning":["cloning"]}`,
+		"",
+		"event: done",
+		`data: {"matchCount":1,"limitHit":false,"elapsedMilliseconds":`,
+	}, "\n")
+	if got := rec.Body.String(); !strings.HasPrefix(got, want) {
+		t.Errorf("unexpected body\ngot:\n%s\nwant prefix:\n%s", got, want)
+	}
+}
+
+func TestServeSearchStream_NoQuery(t *testing.T) {
+	req := httptest.NewRequest("GET", "/search/stream", nil)
+	if err := serveSearchStream(httptest.NewRecorder(), req); err == nil {
+		t.Fatal("expected an error for a missing query")
+	}
+}
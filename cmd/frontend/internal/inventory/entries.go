@@ -117,6 +117,40 @@ func (c *Context) file(ctx context.Context, file os.FileInfo, buf []byte) (inv I
 	return Inventory{Languages: []Lang{lang}}, nil
 }
 
+// Subtract returns the inventory inv without the contents of other, e.g. to
+// remove the files that were deleted from a tree from the inventory of the
+// tree. Languages without any lines or bytes left are removed.
+func Subtract(inv, other Inventory) Inventory {
+	remove := make(map[string]Lang, len(other.Languages))
+	for _, lang := range other.Languages {
+		x := remove[lang.Name]
+		x.TotalBytes += lang.TotalBytes
+		x.TotalLines += lang.TotalLines
+		remove[lang.Name] = x
+	}
+
+	diff := Inventory{Languages: make([]Lang, 0, len(inv.Languages))}
+	for _, lang := range inv.Languages {
+		r := remove[lang.Name]
+		lang.TotalBytes = sub(lang.TotalBytes, r.TotalBytes)
+		lang.TotalLines = sub(lang.TotalLines, r.TotalLines)
+		if lang.TotalBytes == 0 && lang.TotalLines == 0 {
+			continue
+		}
+		diff.Languages = append(diff.Languages, lang)
+	}
+	// Reorder by lines of code.
+	return Sum([]Inventory{diff})
+}
+
+// sub returns a-b, or 0 if b is greater than a.
+func sub(a, b uint64) uint64 {
+	if b > a {
+		return 0
+	}
+	return a - b
+}
+
 func Sum(invs []Inventory) Inventory {
 	byLang := map[string]*Lang{}
 	for _, inv := range invs {
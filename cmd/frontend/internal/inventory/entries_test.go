@@ -113,3 +113,30 @@ func TestContext_Entries(t *testing.T) {
 		t.Errorf("CacheGet calls: got %+v, want %+v", cacheSetCalls, want)
 	}
 }
+
+func TestSubtract(t *testing.T) {
+	inv := Inventory{
+		Languages: []Lang{
+			{Name: "Go", TotalBytes: 100, TotalLines: 10},
+			{Name: "Markdown", TotalBytes: 50, TotalLines: 8},
+			{Name: "Objective-C", TotalBytes: 24, TotalLines: 1},
+		},
+	}
+	other := Inventory{
+		Languages: []Lang{
+			{Name: "Go", TotalBytes: 60, TotalLines: 4},
+			{Name: "Go", TotalBytes: 10, TotalLines: 1},
+			{Name: "Objective-C", TotalBytes: 24, TotalLines: 1},
+			{Name: "Python", TotalBytes: 5, TotalLines: 1},
+		},
+	}
+	want := Inventory{
+		Languages: []Lang{
+			{Name: "Markdown", TotalBytes: 50, TotalLines: 8},
+			{Name: "Go", TotalBytes: 30, TotalLines: 5},
+		},
+	}
+	if got := Subtract(inv, other); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,255 @@
+// Package updater keeps the language inventories of the default branches of
+// repositories that are stored in the DB up to date.
+package updater
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/inventory"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/rcache"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+// interval is how often repositories are checked for new fetches.
+const interval = 5 * time.Minute
+
+// repoInfoBatchSize is the number of repositories that gitserver is asked
+// about at once.
+const repoInfoBatchSize = 500
+
+// StartWorker should be invoked only after the DB has been initialized. It
+// starts the background worker which updates the stored language inventory
+// of each repository after it was fetched.
+//
+// It should be invoked in a separate goroutine.
+func StartWorker() {
+	u := newUpdater()
+
+	// Only one frontend instance should ever run this worker, so we use a
+	// distributed lock to guarantee this. If the frontend with the lock
+	// acquired dies, it will be released after 1 minute.
+	for {
+		ctx, release, ok := rcache.TryAcquireMutex(context.Background(), "inventoryUpdaterWorker")
+		if !ok {
+			// Failed to acquire the mutex. Wait before trying again.
+			time.Sleep(30 * time.Second)
+			continue
+		}
+
+		log15.Debug("inventory: updater worker running")
+		for ctx.Err() == nil {
+			if err := u.updateAll(ctx); err != nil {
+				log15.Error("inventory: updater worker: error while updating inventories", "error", err)
+			}
+			time.Sleep(interval)
+		}
+		log15.Debug("inventory: updater worker stopped", "ctx", ctx.Err())
+		release()
+	}
+}
+
+// updater updates the stored inventories of repositories that were fetched
+// since they were last checked.
+type updater struct {
+	// checked is when each repository was last checked for a new commit on
+	// its default branch.
+	checked map[api.RepoID]time.Time
+}
+
+func newUpdater() *updater {
+	return &updater{checked: make(map[api.RepoID]time.Time)}
+}
+
+// mockRepoInfo, when non-nil, is called instead of gitserver.
+var mockRepoInfo func(repos ...api.RepoName) (*protocol.RepoInfoResponse, error)
+
+func repoInfo(ctx context.Context, repos ...api.RepoName) (*protocol.RepoInfoResponse, error) {
+	if mockRepoInfo != nil {
+		return mockRepoInfo(repos...)
+	}
+	return gitserver.DefaultClient.RepoInfo(ctx, repos...)
+}
+
+// updateAll updates the inventories of all repositories that were fetched
+// since they were last checked.
+func (u *updater) updateAll(ctx context.Context) error {
+	ctx = actor.WithActor(ctx, &actor.Actor{Internal: true})
+
+	repos, err := db.Repos.List(ctx, db.ReposListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "listing repositories")
+	}
+
+	for len(repos) > 0 {
+		batch := repos
+		if len(batch) > repoInfoBatchSize {
+			batch = batch[:repoInfoBatchSize]
+		}
+		repos = repos[len(batch):]
+
+		names := make([]api.RepoName, len(batch))
+		for i, repo := range batch {
+			names[i] = repo.Name
+		}
+		infos, err := repoInfo(ctx, names...)
+		if err != nil {
+			// Some of the gitservers may have responded.
+			log15.Warn("inventory: updater worker: error while getting repository info", "error", err)
+			if infos == nil {
+				continue
+			}
+		}
+
+		for _, repo := range batch {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			info := infos.Results[repo.Name]
+			if info == nil || !info.Cloned || info.LastFetched == nil {
+				continue
+			}
+			if checked, ok := u.checked[repo.ID]; ok && !info.LastFetched.After(checked) {
+				continue
+			}
+			checked := time.Now()
+			if err := update(ctx, repo); err != nil {
+				log15.Warn("inventory: updater worker: error while updating inventory", "repo", repo.Name, "error", err)
+				continue
+			}
+			u.checked[repo.ID] = checked
+		}
+	}
+	return nil
+}
+
+// update updates the stored inventory of the repository to the current head
+// of its default branch. If an inventory was stored for a previous commit, it
+// is updated from the files that changed since that commit.
+func update(ctx context.Context, repo *types.Repo) error {
+	head, err := backend.Repos.ResolveRev(ctx, repo, "")
+	if err != nil {
+		if gitserver.IsRevisionNotFound(err) {
+			// The repository is empty.
+			return nil
+		}
+		return err
+	}
+
+	prev, err := db.RepoInventories.Get(ctx, repo.ID)
+	if err != nil {
+		return err
+	}
+	if prev != nil && prev.CommitID == head {
+		return nil
+	}
+
+	var inv *inventory.Inventory
+	if prev != nil {
+		inv, err = incremental(ctx, repo, prev, head)
+		if err != nil {
+			// The previous commit may be gone, e.g. after a force push.
+			log15.Debug("inventory: updater worker: computing inventory from scratch", "repo", repo.Name, "error", err)
+			inv = nil
+		}
+	}
+	if inv == nil {
+		// Always detect languages from file contents, so that lines of code
+		// are counted.
+		inv, err = backend.Repos.GetInventory(ctx, repo, head, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	return db.RepoInventories.Set(ctx, &types.RepoInventory{
+		RepoID:    repo.ID,
+		CommitID:  head,
+		Languages: inv.Languages,
+	})
+}
+
+// incremental returns the inventory at head, computed from the inventory at
+// a previous commit by removing the files that were changed or deleted since
+// then and adding the files that were changed or added.
+func incremental(ctx context.Context, repo *types.Repo, prev *types.RepoInventory, head api.CommitID) (*inventory.Inventory, error) {
+	var (
+		changes *git.Changes
+		err     error
+	)
+	if mockChangedFiles != nil {
+		changes, err = mockChangedFiles(repo, prev.CommitID, head)
+	} else {
+		var cachedRepo *gitserver.Repo
+		cachedRepo, err = backend.CachedGitRepo(ctx, repo)
+		if err != nil {
+			return nil, err
+		}
+		changes, err = git.ChangedFiles(ctx, *cachedRepo, prev.CommitID, head)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	removed := append(append([]string{}, changes.Modified...), changes.Deleted...)
+	added := append(append([]string{}, changes.Modified...), changes.Added...)
+	removedInv, err := filesInventory(ctx, repo, prev.CommitID, removed)
+	if err != nil {
+		return nil, err
+	}
+	addedInv, err := filesInventory(ctx, repo, head, added)
+	if err != nil {
+		return nil, err
+	}
+
+	inv := inventory.Sum([]inventory.Inventory{
+		inventory.Subtract(inventory.Inventory{Languages: prev.Languages}, removedInv),
+		addedInv,
+	})
+	return &inv, nil
+}
+
+// mockChangedFiles, when non-nil, is called instead of git.ChangedFiles.
+var mockChangedFiles func(repo *types.Repo, base, head api.CommitID) (*git.Changes, error)
+
+// mockFilesInventory, when non-nil, is called instead of computing the
+// inventory of the files with git.
+var mockFilesInventory func(repo *types.Repo, commitID api.CommitID, paths []string) (inventory.Inventory, error)
+
+// filesInventory returns the inventory of the files at the commit.
+func filesInventory(ctx context.Context, repo *types.Repo, commitID api.CommitID, paths []string) (inventory.Inventory, error) {
+	if len(paths) == 0 {
+		return inventory.Inventory{}, nil
+	}
+	if mockFilesInventory != nil {
+		return mockFilesInventory(repo, commitID, paths)
+	}
+
+	cachedRepo, err := backend.CachedGitRepo(ctx, repo)
+	if err != nil {
+		return inventory.Inventory{}, err
+	}
+	invCtx, err := backend.InventoryContext(*cachedRepo, commitID, true)
+	if err != nil {
+		return inventory.Inventory{}, err
+	}
+	entries := make([]os.FileInfo, 0, len(paths))
+	for _, path := range paths {
+		fi, err := git.Stat(ctx, *cachedRepo, commitID, path)
+		if err != nil {
+			return inventory.Inventory{}, err
+		}
+		entries = append(entries, fi)
+	}
+	return invCtx.Entries(ctx, entries...)
+}
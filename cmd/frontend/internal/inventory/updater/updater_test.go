@@ -0,0 +1,233 @@
+package updater
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/inventory"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/cmd/gitserver/server"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+const (
+	base = "1111111111111111111111111111111111111111"
+	head = "2222222222222222222222222222222222222222"
+)
+
+func TestUpdater(t *testing.T) {
+	defer func() {
+		db.Mocks = db.MockStores{}
+		backend.Mocks = backend.MockServices{}
+		mockRepoInfo = nil
+		mockChangedFiles = nil
+		mockFilesInventory = nil
+	}()
+
+	repos := []*types.Repo{
+		{ID: 1, Name: "github.com/foo/new"},
+		{ID: 2, Name: "github.com/foo/changed"},
+		{ID: 3, Name: "github.com/foo/unchanged"},
+		{ID: 4, Name: "github.com/foo/notcloned"},
+	}
+	db.Mocks.Repos.List = func(ctx context.Context, opt db.ReposListOptions) ([]*types.Repo, error) {
+		return repos, nil
+	}
+
+	fetched := time.Now().Add(-time.Minute)
+	mockRepoInfo = func(names ...api.RepoName) (*protocol.RepoInfoResponse, error) {
+		res := &protocol.RepoInfoResponse{Results: map[api.RepoName]*protocol.RepoInfo{}}
+		for _, name := range names {
+			res.Results[name] = &protocol.RepoInfo{Cloned: name != "github.com/foo/notcloned", LastFetched: &fetched}
+		}
+		return res, nil
+	}
+
+	backend.Mocks.Repos.ResolveRev = func(ctx context.Context, repo *types.Repo, rev string) (api.CommitID, error) {
+		if rev != "" {
+			t.Errorf("want the default branch to be resolved, got %q", rev)
+		}
+		if repo.ID == 3 {
+			return base, nil
+		}
+		return head, nil
+	}
+
+	stored := map[api.RepoID]*types.RepoInventory{
+		2: {RepoID: 2, CommitID: base, Languages: []inventory.Lang{
+			{Name: "Go", TotalLines: 100, TotalBytes: 1000},
+			{Name: "Python", TotalLines: 10, TotalBytes: 100},
+		}},
+		3: {RepoID: 3, CommitID: base, Languages: []inventory.Lang{{Name: "Go", TotalLines: 1, TotalBytes: 1}}},
+	}
+	db.Mocks.RepoInventories.Get = func(ctx context.Context, repoID api.RepoID) (*types.RepoInventory, error) {
+		return stored[repoID], nil
+	}
+	var set []*types.RepoInventory
+	db.Mocks.RepoInventories.Set = func(ctx context.Context, inv *types.RepoInventory) error {
+		set = append(set, inv)
+		return nil
+	}
+
+	// The new repository's inventory is computed from scratch.
+	backend.Mocks.Repos.GetInventory = func(ctx context.Context, repo *types.Repo, commitID api.CommitID) (*inventory.Inventory, error) {
+		if repo.ID != 1 || commitID != head {
+			t.Errorf("unexpected full inventory of %s at %s", repo.Name, commitID)
+		}
+		return &inventory.Inventory{Languages: []inventory.Lang{{Name: "Java", TotalLines: 5, TotalBytes: 50}}}, nil
+	}
+
+	// The changed repository's inventory is updated from the changed files:
+	// a.go was modified, b.py deleted and c.ts added.
+	mockChangedFiles = func(repo *types.Repo, b, h api.CommitID) (*git.Changes, error) {
+		if repo.ID != 2 || b != base || h != head {
+			t.Errorf("unexpected diff of %s %s..%s", repo.Name, b, h)
+		}
+		return &git.Changes{Added: []string{"c.ts"}, Modified: []string{"a.go"}, Deleted: []string{"b.py"}}, nil
+	}
+	mockFilesInventory = func(repo *types.Repo, commitID api.CommitID, paths []string) (inventory.Inventory, error) {
+		switch commitID {
+		case base:
+			if want := []string{"a.go", "b.py"}; !reflect.DeepEqual(paths, want) {
+				t.Errorf("got removed files %q, want %q", paths, want)
+			}
+			return inventory.Inventory{Languages: []inventory.Lang{
+				{Name: "Go", TotalLines: 20, TotalBytes: 200},
+				{Name: "Python", TotalLines: 10, TotalBytes: 100},
+			}}, nil
+		case head:
+			if want := []string{"a.go", "c.ts"}; !reflect.DeepEqual(paths, want) {
+				t.Errorf("got added files %q, want %q", paths, want)
+			}
+			return inventory.Inventory{Languages: []inventory.Lang{
+				{Name: "Go", TotalLines: 30, TotalBytes: 300},
+				{Name: "TypeScript", TotalLines: 5, TotalBytes: 50},
+			}}, nil
+		}
+		t.Fatalf("unexpected commit %s", commitID)
+		return inventory.Inventory{}, nil
+	}
+
+	u := newUpdater()
+	if err := u.updateAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*types.RepoInventory{
+		{RepoID: 1, CommitID: head, Languages: []inventory.Lang{{Name: "Java", TotalLines: 5, TotalBytes: 50}}},
+		{RepoID: 2, CommitID: head, Languages: []inventory.Lang{
+			{Name: "Go", TotalLines: 110, TotalBytes: 1100},
+			{Name: "TypeScript", TotalLines: 5, TotalBytes: 50},
+		}},
+	}
+	if diff := cmp.Diff(want, set); diff != "" {
+		t.Error(diff)
+	}
+
+	// Repositories that weren't fetched since they were checked are skipped.
+	set = nil
+	backend.Mocks.Repos.ResolveRev = func(ctx context.Context, repo *types.Repo, rev string) (api.CommitID, error) {
+		t.Errorf("unexpected check of %s", repo.Name)
+		return "", nil
+	}
+	if err := u.updateAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(set) != 0 {
+		t.Errorf("got unexpected updates %+v", set)
+	}
+}
+
+func TestIncremental(t *testing.T) {
+	// The changed files and inventories are read from a real gitserver.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: (&server.Server{ReposDir: filepath.Join(t.TempDir(), "repos")}).Handler()}
+	go func() { _ = srv.Serve(l) }()
+	defer srv.Close()
+
+	addrs := gitserver.DefaultClient.Addrs
+	gitserver.DefaultClient.Addrs = func(ctx context.Context) []string { return []string{l.Addr().String()} }
+	defer func() { gitserver.DefaultClient.Addrs = addrs }()
+
+	dir := t.TempDir()
+	for _, cmd := range []string{
+		"git init",
+		"printf 'package a\\n\\nfunc A() {}\\n' > a.go",
+		"printf 'print(1)\\nprint(2)\\n' > b.py",
+		"printf 'package c\\n' > c.go",
+		"git add a.go b.py c.go",
+		"git commit -m base",
+		"git tag base",
+		"printf 'func B() {}\\n' >> a.go",
+		"git rm b.py",
+		"git mv c.go d.go",
+		"printf 'let x = 1;\\n' > e.ts",
+		"git add a.go e.ts",
+		"git commit -m head",
+	} {
+		c := exec.Command("bash", "-c", cmd)
+		c.Dir = dir
+		c.Env = append(os.Environ(),
+			"GIT_CONFIG_NOSYSTEM=1", "HOME=/dev/null",
+			"GIT_AUTHOR_NAME=a", "GIT_AUTHOR_EMAIL=a@a.com",
+			"GIT_COMMITTER_NAME=a", "GIT_COMMITTER_EMAIL=a@a.com",
+		)
+		if out, err := c.CombinedOutput(); err != nil {
+			t.Fatalf("%s failed: %s: %s", cmd, err, out)
+		}
+	}
+
+	ctx := context.Background()
+	repo := &types.Repo{ID: 1, Name: "example.com/incremental"}
+	if _, err := gitserver.DefaultClient.RequestRepoUpdate(ctx, gitserver.Repo{Name: repo.Name, URL: dir}, 0); err != nil {
+		t.Fatal(err)
+	}
+	gitRepo := gitserver.Repo{Name: repo.Name}
+	baseID, err := git.ResolveRevision(ctx, gitRepo, nil, "base", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	headID, err := git.ResolveRevision(ctx, gitRepo, nil, "master", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prevInv, err := filesInventory(ctx, repo, baseID, []string{"a.go", "b.py", "c.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := &types.RepoInventory{RepoID: repo.ID, CommitID: baseID, Languages: prevInv.Languages}
+
+	have, err := incremental(ctx, repo, prev, headID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The incremental inventory matches the inventory of all files at head.
+	want, err := filesInventory(ctx, repo, headID, []string{"a.go", "d.go", "e.ts"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want.Languages, have.Languages); diff != "" {
+		t.Error(diff)
+	}
+	if len(have.Languages) != 2 {
+		t.Errorf("got languages %+v, want Go and TypeScript", have.Languages)
+	}
+}
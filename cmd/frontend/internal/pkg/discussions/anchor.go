@@ -0,0 +1,91 @@
+package discussions
+
+import (
+	"bytes"
+
+	"github.com/sourcegraph/go-diff/diff"
+)
+
+// TrackLineRange returns where the line range of a file is after the given
+// hunks of a diff of the file were applied. The second return value is false
+// if any of the lines in the range were deleted (or modified), in which case
+// the range is not tracked any further.
+//
+// An empty range (StartLine == EndLine) is tracked as the position before its
+// start line, and is never deleted.
+func TrackLineRange(r LineRange, hunks []*diff.Hunk) (LineRange, bool) {
+	if r.EndLine <= r.StartLine {
+		start, _ := trackLine(r.StartLine, hunks)
+		return LineRange{StartLine: start, EndLine: start}, true
+	}
+
+	var tracked LineRange
+	for line := r.StartLine; line < r.EndLine; line++ {
+		newLine, ok := trackLine(line, hunks)
+		if !ok {
+			return r, false
+		}
+		if line == r.StartLine {
+			tracked.StartLine = newLine
+		}
+		tracked.EndLine = newLine + 1
+	}
+	return tracked, true
+}
+
+// trackLine returns the line number (zero-based) that the given line of the
+// original file has after the hunks were applied. The second return value is
+// false if the line was deleted, in which case the returned line is where it
+// would have been.
+func trackLine(line int, hunks []*diff.Hunk) (int, bool) {
+	// offset is the number of lines added minus the number of lines deleted
+	// by the hunks before line.
+	offset := 0
+	for _, h := range hunks {
+		// Hunk line numbers are one-based, except that a hunk without lines
+		// on one side starts after the given line.
+		orig, cur := int(h.OrigStartLine)-1, int(h.NewStartLine)-1
+		if h.OrigLines == 0 {
+			orig++
+		}
+		if h.NewLines == 0 {
+			cur++
+		}
+		if line < orig {
+			break
+		}
+
+		for _, l := range splitLines(h.Body) {
+			if len(l) == 0 {
+				// Treat an empty line as unchanged context.
+				l = []byte{' '}
+			}
+			switch l[0] {
+			case ' ':
+				if orig == line {
+					return cur, true
+				}
+				orig++
+				cur++
+			case '-':
+				if orig == line {
+					return cur, false
+				}
+				orig++
+			case '+':
+				cur++
+			}
+		}
+		offset = cur - orig
+	}
+	return line + offset, true
+}
+
+// splitLines splits the body of a hunk into lines without their line
+// terminators.
+func splitLines(body []byte) [][]byte {
+	if len(body) == 0 {
+		return nil
+	}
+	return bytes.Split(bytes.TrimSuffix(body, []byte("\n")), []byte("\n"))
+}
@@ -0,0 +1,77 @@
+package discussions
+
+import (
+	"testing"
+
+	"github.com/sourcegraph/go-diff/diff"
+)
+
+func TestTrackLineRange(t *testing.T) {
+	// The diff of a 10 line file (lines "1" to "10") that inserts two lines
+	// after line 2, deletes line 5 and modifies line 8.
+	fileDiff, err := diff.ParseFileDiff([]byte(`--- a.go
++++ a.go
+@@ -2,0 +3,2 @@
++new1
++new2
+@@ -5 +6,0 @@
+-5
+@@ -8 +9 @@
+-8
++eight
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		r         LineRange
+		want      LineRange
+		wantFound bool
+	}{
+		{name: "before all hunks", r: LineRange{StartLine: 0, EndLine: 2}, want: LineRange{StartLine: 0, EndLine: 2}, wantFound: true},
+		{name: "after insertion", r: LineRange{StartLine: 2, EndLine: 4}, want: LineRange{StartLine: 4, EndLine: 6}, wantFound: true},
+		{name: "spanning insertion", r: LineRange{StartLine: 1, EndLine: 3}, want: LineRange{StartLine: 1, EndLine: 5}, wantFound: true},
+		{name: "deleted line", r: LineRange{StartLine: 3, EndLine: 5}, wantFound: false},
+		{name: "between hunks", r: LineRange{StartLine: 5, EndLine: 7}, want: LineRange{StartLine: 6, EndLine: 8}, wantFound: true},
+		{name: "modified line", r: LineRange{StartLine: 7, EndLine: 8}, wantFound: false},
+		{name: "after all hunks", r: LineRange{StartLine: 8, EndLine: 10}, want: LineRange{StartLine: 9, EndLine: 11}, wantFound: true},
+		{name: "empty range on deleted line", r: LineRange{StartLine: 4, EndLine: 4}, want: LineRange{StartLine: 6, EndLine: 6}, wantFound: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, found := TrackLineRange(test.r, fileDiff.Hunks)
+			if found != test.wantFound {
+				t.Fatalf("got found %v, want %v", found, test.wantFound)
+			}
+			if found && got != test.want {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestTrackLineRange_context(t *testing.T) {
+	// The same change with context lines, as produced by git diff without
+	// --unified=0.
+	fileDiff, err := diff.ParseFileDiff([]byte(`--- a.go
++++ a.go
+@@ -1,6 +1,7 @@
+ 1
+ 2
++new1
++new2
+ 3
+ 4
+-5
+ 6
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, found := TrackLineRange(LineRange{StartLine: 5, EndLine: 9}, fileDiff.Hunks)
+	if want := (LineRange{StartLine: 6, EndLine: 10}); !found || got != want {
+		t.Errorf("got %+v (found %v), want %+v", got, found, want)
+	}
+}
@@ -0,0 +1,272 @@
+// Package tracker keeps the selections of discussion threads anchored to the
+// lines they are about as new commits are made on the branches they target.
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/go-diff/diff"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/discussions"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/rcache"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+// interval is how often the selections are tracked through new commits.
+const interval = time.Minute
+
+// StartWorker should be invoked only after the DB has been initialized. It
+// starts the background worker which re-anchors the selections of discussion
+// threads on each new commit on the branches they target.
+//
+// It should be invoked in a separate goroutine.
+func StartWorker() {
+	// Only one frontend instance should ever run this worker, so we use a
+	// distributed lock to guarantee this. If the frontend with the lock
+	// acquired dies, it will be released after 1 minute.
+	for {
+		ctx, release, ok := rcache.TryAcquireMutex(context.Background(), "discussionsTrackerWorker")
+		if !ok {
+			// Failed to acquire the mutex. Wait before trying again.
+			time.Sleep(30 * time.Second)
+			continue
+		}
+
+		log15.Debug("discussions: tracker worker running")
+		for ctx.Err() == nil {
+			if err := newTracker().trackAll(ctx); err != nil {
+				log15.Error("discussions: tracker worker: error while tracking threads", "error", err)
+			}
+			time.Sleep(interval)
+		}
+		log15.Debug("discussions: tracker worker stopped", "ctx", ctx.Err())
+		release()
+	}
+}
+
+// tracker tracks selections through commits. It caches the branch heads and
+// diffs it looks up, so that threads on the same branch share them.
+type tracker struct {
+	repos map[api.RepoID]*types.Repo
+	heads map[headKey]api.CommitID
+	diffs map[diffKey][]*diff.FileDiff
+}
+
+type headKey struct {
+	repo   api.RepoID
+	branch string
+}
+
+type diffKey struct {
+	repo       api.RepoID
+	base, head api.CommitID
+}
+
+func newTracker() *tracker {
+	return &tracker{
+		repos: make(map[api.RepoID]*types.Repo),
+		heads: make(map[headKey]api.CommitID),
+		diffs: make(map[diffKey][]*diff.FileDiff),
+	}
+}
+
+// trackAll re-anchors the selections of all threads that have one to the
+// current heads of the branches they target.
+func (t *tracker) trackAll(ctx context.Context) error {
+	// Threads are tracked in all repositories, including private ones.
+	ctx = actor.WithActor(ctx, &actor.Actor{Internal: true})
+
+	targets, err := db.DiscussionThreads.ListTargetReposToTrack(ctx)
+	if err != nil {
+		return errors.Wrap(err, "ListTargetReposToTrack")
+	}
+	for _, tr := range targets {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := t.track(ctx, tr); err != nil {
+			log15.Warn("discussions: tracker worker: error while tracking thread", "thread", tr.ThreadID, "error", err)
+		}
+	}
+	return nil
+}
+
+// track re-anchors the selection of the repo target to the current head of
+// the branch it targets, or of the default branch if it doesn't target one.
+func (t *tracker) track(ctx context.Context, tr *types.DiscussionThreadTargetRepo) error {
+	repo, err := t.repo(ctx, tr.RepoID)
+	if err != nil {
+		return err
+	}
+
+	branch := "HEAD"
+	if tr.Branch != nil {
+		branch = *tr.Branch
+	}
+	head, err := t.head(ctx, repo, branch)
+	if err != nil {
+		return err
+	}
+
+	anchor := currentAnchor(tr)
+	if anchor.Revision == "" {
+		// The thread doesn't reference a revision, so its selection is about
+		// the branch as it was when we first saw it.
+		anchor.Revision = string(head)
+		return db.DiscussionThreads.UpdateTargetRepoAnchor(ctx, tr.ID, anchor, false)
+	}
+	if anchor.Revision == string(head) {
+		return nil
+	}
+
+	fileDiffs, err := t.diff(ctx, repo, api.CommitID(anchor.Revision), head)
+	if err != nil {
+		return err
+	}
+	newAnchor, ok := trackAnchor(anchor, fileDiffs)
+	if !ok {
+		// Keep the anchor where the lines were last present, so that the
+		// outdated thread can still be shown at the lines it is about.
+		return db.DiscussionThreads.UpdateTargetRepoAnchor(ctx, tr.ID, anchor, true)
+	}
+	newAnchor.Revision = string(head)
+	return db.DiscussionThreads.UpdateTargetRepoAnchor(ctx, tr.ID, newAnchor, false)
+}
+
+// currentAnchor returns the anchor of the selection of the repo target. If it
+// hasn't been tracked yet, that is the selection as the thread was created.
+func currentAnchor(tr *types.DiscussionThreadTargetRepo) db.DiscussionThreadAnchor {
+	if tr.AnchorRevision != nil && tr.AnchorPath != nil && tr.AnchorStartLine != nil && tr.AnchorEndLine != nil {
+		return db.DiscussionThreadAnchor{
+			Revision:  *tr.AnchorRevision,
+			Path:      *tr.AnchorPath,
+			StartLine: *tr.AnchorStartLine,
+			EndLine:   *tr.AnchorEndLine,
+		}
+	}
+	anchor := db.DiscussionThreadAnchor{
+		Path:      *tr.Path,
+		StartLine: *tr.StartLine,
+		EndLine:   *tr.EndLine,
+	}
+	if tr.Revision != nil {
+		anchor.Revision = *tr.Revision
+	}
+	return anchor
+}
+
+// trackAnchor returns where the anchored lines are after the changes of the
+// diff, following renames of the file. It returns false if the file or any of
+// the anchored lines were deleted.
+func trackAnchor(anchor db.DiscussionThreadAnchor, fileDiffs []*diff.FileDiff) (db.DiscussionThreadAnchor, bool) {
+	for _, fd := range fileDiffs {
+		if fd.OrigName != anchor.Path {
+			continue
+		}
+		if fd.NewName == "/dev/null" {
+			return anchor, false
+		}
+		r, ok := discussions.TrackLineRange(discussions.LineRange{
+			StartLine: int(anchor.StartLine),
+			EndLine:   int(anchor.EndLine),
+		}, fd.Hunks)
+		if !ok {
+			return anchor, false
+		}
+		anchor.Path = fd.NewName
+		anchor.StartLine, anchor.EndLine = int32(r.StartLine), int32(r.EndLine)
+		return anchor, true
+	}
+	// The file wasn't changed.
+	return anchor, true
+}
+
+func (t *tracker) repo(ctx context.Context, id api.RepoID) (*types.Repo, error) {
+	if repo, ok := t.repos[id]; ok {
+		return repo, nil
+	}
+	repo, err := backend.Repos.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	t.repos[id] = repo
+	return repo, nil
+}
+
+func (t *tracker) head(ctx context.Context, repo *types.Repo, branch string) (api.CommitID, error) {
+	key := headKey{repo: repo.ID, branch: branch}
+	if head, ok := t.heads[key]; ok {
+		return head, nil
+	}
+	head, err := backend.Repos.ResolveRev(ctx, repo, branch)
+	if err != nil {
+		return "", err
+	}
+	t.heads[key] = head
+	return head, nil
+}
+
+// mockDiff, when non-nil, is called instead of git diff.
+var mockDiff func(repo *types.Repo, base, head api.CommitID) ([]*diff.FileDiff, error)
+
+// diff returns the diff between the base and head commits, with renames
+// detected and without context lines.
+func (t *tracker) diff(ctx context.Context, repo *types.Repo, base, head api.CommitID) ([]*diff.FileDiff, error) {
+	key := diffKey{repo: repo.ID, base: base, head: head}
+	if fileDiffs, ok := t.diffs[key]; ok {
+		return fileDiffs, nil
+	}
+
+	var fileDiffs []*diff.FileDiff
+	if mockDiff != nil {
+		var err error
+		if fileDiffs, err = mockDiff(repo, base, head); err != nil {
+			return nil, err
+		}
+	} else {
+		// 🚨 SECURITY: Only pass absolute revisions to git, so that they can't
+		// be interpreted as flags.
+		if !git.IsAbsoluteRevision(string(base)) || !git.IsAbsoluteRevision(string(head)) {
+			return nil, fmt.Errorf("invalid diff range %q..%q", base, head)
+		}
+		cachedRepo, err := backend.CachedGitRepo(ctx, repo)
+		if err != nil {
+			return nil, err
+		}
+		rdr, err := git.ExecReader(ctx, *cachedRepo, []string{
+			"diff",
+			"--find-renames",
+			"--unified=0",
+			"--no-prefix",
+			string(base) + ".." + string(head),
+			"--",
+		})
+		if err != nil {
+			return nil, err
+		}
+		defer rdr.Close()
+
+		dr := diff.NewMultiFileDiffReader(rdr)
+		for {
+			fd, err := dr.ReadFile()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			fileDiffs = append(fileDiffs, fd)
+		}
+	}
+	t.diffs[key] = fileDiffs
+	return fileDiffs, nil
+}
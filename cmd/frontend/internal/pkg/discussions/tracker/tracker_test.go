@@ -0,0 +1,167 @@
+package tracker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sourcegraph/go-diff/diff"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+)
+
+const (
+	base = "1111111111111111111111111111111111111111"
+	head = "2222222222222222222222222222222222222222"
+)
+
+func strptr(s string) *string { return &s }
+func int32ptr(i int32) *int32 { return &i }
+
+func TestTracker(t *testing.T) {
+	defer func() {
+		db.Mocks = db.MockStores{}
+		backend.Mocks = backend.MockServices{}
+		mockDiff = nil
+	}()
+
+	backend.Mocks.Repos.Get = func(ctx context.Context, id api.RepoID) (*types.Repo, error) {
+		return &types.Repo{ID: id, Name: "github.com/foo/bar"}, nil
+	}
+	backend.Mocks.Repos.ResolveRev = func(ctx context.Context, repo *types.Repo, rev string) (api.CommitID, error) {
+		return head, nil
+	}
+
+	// The diff renames a.go to b.go, inserting a line before line 3 and
+	// deleting line 10, and deletes c.go.
+	diffs := 0
+	mockDiff = func(repo *types.Repo, b, h api.CommitID) ([]*diff.FileDiff, error) {
+		diffs++
+		if b != base || h != head {
+			t.Errorf("unexpected diff range %s..%s", b, h)
+		}
+		return diff.ParseMultiFileDiff([]byte(`diff --git a.go b.go
+similarity index 90%
+rename from a.go
+rename to b.go
+--- a.go
++++ b.go
+@@ -2,0 +3 @@
++inserted
+@@ -10 +10,0 @@
+-deleted
+diff --git c.go c.go
+deleted file mode 100644
+--- c.go
++++ /dev/null
+@@ -1 +0,0 @@
+-c
+`))
+	}
+
+	target := func(id int64, path string, start, end int32) *types.DiscussionThreadTargetRepo {
+		return &types.DiscussionThreadTargetRepo{
+			ID:        id,
+			RepoID:    1,
+			Path:      strptr(path),
+			Branch:    strptr("master"),
+			Revision:  strptr(base),
+			StartLine: int32ptr(start),
+			EndLine:   int32ptr(end),
+		}
+	}
+	targets := []*types.DiscussionThreadTargetRepo{
+		target(1, "a.go", 4, 6),
+		target(2, "a.go", 8, 10),
+		target(3, "c.go", 0, 1),
+		target(4, "d.go", 1, 2),
+		// Already tracked to the head.
+		{
+			ID:              5,
+			RepoID:          1,
+			Path:            strptr("a.go"),
+			StartLine:       int32ptr(0),
+			EndLine:         int32ptr(1),
+			AnchorRevision:  strptr(head),
+			AnchorPath:      strptr("b.go"),
+			AnchorStartLine: int32ptr(0),
+			AnchorEndLine:   int32ptr(1),
+		},
+		// Without a revision, the anchor is initialized at the head.
+		{ID: 6, RepoID: 1, Path: strptr("a.go"), StartLine: int32ptr(1), EndLine: int32ptr(2)},
+	}
+	db.Mocks.DiscussionThreads.ListTargetReposToTrack = func(ctx context.Context) ([]*types.DiscussionThreadTargetRepo, error) {
+		return targets, nil
+	}
+
+	type update struct {
+		Anchor   db.DiscussionThreadAnchor
+		Outdated bool
+	}
+	updates := map[int64]update{}
+	db.Mocks.DiscussionThreads.UpdateTargetRepoAnchor = func(ctx context.Context, id int64, anchor db.DiscussionThreadAnchor, outdated bool) error {
+		updates[id] = update{Anchor: anchor, Outdated: outdated}
+		return nil
+	}
+
+	if err := newTracker().trackAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[int64]update{
+		// Moved down by the inserted line and renamed.
+		1: {Anchor: db.DiscussionThreadAnchor{Revision: head, Path: "b.go", StartLine: 5, EndLine: 7}},
+		// Line 10 was deleted.
+		2: {Anchor: db.DiscussionThreadAnchor{Revision: base, Path: "a.go", StartLine: 8, EndLine: 10}, Outdated: true},
+		// The file was deleted.
+		3: {Anchor: db.DiscussionThreadAnchor{Revision: base, Path: "c.go", StartLine: 0, EndLine: 1}, Outdated: true},
+		// The file wasn't changed.
+		4: {Anchor: db.DiscussionThreadAnchor{Revision: head, Path: "d.go", StartLine: 1, EndLine: 2}},
+		6: {Anchor: db.DiscussionThreadAnchor{Revision: head, Path: "a.go", StartLine: 1, EndLine: 2}},
+	}
+	if diff := cmp.Diff(want, updates); diff != "" {
+		t.Error(diff)
+	}
+	if diffs != 1 {
+		t.Errorf("want the diff to be computed once, got %d", diffs)
+	}
+}
+
+func TestTracker_privateRepo(t *testing.T) {
+	defer func() {
+		db.Mocks = db.MockStores{}
+		backend.Mocks = backend.MockServices{}
+	}()
+
+	// The repository is private, so only internal actors can read it.
+	backend.Mocks.Repos.Get = func(ctx context.Context, id api.RepoID) (*types.Repo, error) {
+		if !actor.FromContext(ctx).Internal {
+			return nil, &errcode.Mock{Message: "repo not found", IsNotFound: true}
+		}
+		return &types.Repo{ID: id, Name: "github.com/foo/private"}, nil
+	}
+	backend.Mocks.Repos.ResolveRev = func(ctx context.Context, repo *types.Repo, rev string) (api.CommitID, error) {
+		return head, nil
+	}
+	db.Mocks.DiscussionThreads.ListTargetReposToTrack = func(ctx context.Context) ([]*types.DiscussionThreadTargetRepo, error) {
+		return []*types.DiscussionThreadTargetRepo{
+			{ID: 1, RepoID: 1, Path: strptr("a.go"), StartLine: int32ptr(1), EndLine: int32ptr(2)},
+		}, nil
+	}
+	var updated []int64
+	db.Mocks.DiscussionThreads.UpdateTargetRepoAnchor = func(ctx context.Context, id int64, anchor db.DiscussionThreadAnchor, outdated bool) error {
+		updated = append(updated, id)
+		return nil
+	}
+
+	if err := newTracker().trackAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(updated) != 1 {
+		t.Errorf("want the thread in the private repository to be tracked, got updates %v", updated)
+	}
+}
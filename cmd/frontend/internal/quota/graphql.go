@@ -0,0 +1,480 @@
+package quota
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// GraphQLRequestCost returns the cost of a GraphQL request, which is the
+// number of objects it may return, and the number of searches it performs.
+//
+// Each field with a selection counts once for each object that may contain it.
+// A field with a first: argument is a connection that contains up to that many
+// objects, so the fields selected below it count that many times. For example,
+// the cost of
+//
+//	{ repositories(first: 10) { nodes { name, commit(rev: "HEAD") { oid } } } }
+//
+// is 1 (repositories) + 10 (nodes) + 10 (commit) = 21. The searches are the
+// search fields of a query operation.
+func GraphQLRequestCost(query, operationName string, variables map[string]interface{}) (cost, searches int, err error) {
+	doc, err := parseGraphQLDocument(query)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var op *gqlOperation
+	for _, o := range doc.operations {
+		if operationName == "" || o.name == operationName {
+			if op != nil {
+				return 0, 0, fmt.Errorf("more than one operation in GraphQL document and no operation name given")
+			}
+			op = o
+		}
+	}
+	if op == nil {
+		return 0, 0, fmt.Errorf("no operation %q in GraphQL document", operationName)
+	}
+
+	e := &gqlCostEvaluator{fragments: doc.fragments, variables: variables, visiting: map[string]bool{}}
+	cost = e.cost(op.selections, 1)
+	if op.typ == "query" {
+		e.visit(op.selections, func(s *gqlSelection) {
+			if s.name == "search" {
+				searches++
+			}
+		})
+	}
+	return cost, searches, nil
+}
+
+type gqlCostEvaluator struct {
+	fragments map[string][]*gqlSelection
+	variables map[string]interface{}
+	visiting  map[string]bool // fragments being evaluated, to guard against cycles
+}
+
+func (e *gqlCostEvaluator) cost(selections []*gqlSelection, multiplier int) int {
+	cost := 0
+	e.visit(selections, func(s *gqlSelection) {
+		if s.selections == nil {
+			return // scalar fields are free
+		}
+		cost = saturatingAdd(cost, multiplier)
+		n := multiplier
+		if first := e.first(s); first > 0 {
+			n = saturatingMul(n, first)
+		}
+		cost = saturatingAdd(cost, e.cost(s.selections, n))
+	})
+	return cost
+}
+
+// visit calls f for each field in the selections, including the fields of the
+// fragments they spread.
+func (e *gqlCostEvaluator) visit(selections []*gqlSelection, f func(*gqlSelection)) {
+	for _, s := range selections {
+		if s.spread == "" {
+			f(s)
+			continue
+		}
+		if e.visiting[s.spread] {
+			continue
+		}
+		e.visiting[s.spread] = true
+		e.visit(e.fragments[s.spread], f)
+		delete(e.visiting, s.spread)
+	}
+}
+
+func (e *gqlCostEvaluator) first(s *gqlSelection) int {
+	v, ok := s.arguments["first"]
+	if !ok {
+		return 0
+	}
+	if name, ok := v.(gqlVariable); ok {
+		v = e.variables[string(name)]
+	}
+	switch v := v.(type) {
+	case int:
+		return v
+	case float64:
+		if v > math.MaxInt32 {
+			return math.MaxInt32
+		}
+		return int(v)
+	case json.Number:
+		n, _ := strconv.Atoi(string(v))
+		return n
+	}
+	return 0
+}
+
+func saturatingAdd(a, b int) int {
+	if a > math.MaxInt32-b {
+		return math.MaxInt32
+	}
+	return a + b
+}
+
+func saturatingMul(a, b int) int {
+	if b != 0 && a > math.MaxInt32/b {
+		return math.MaxInt32
+	}
+	return a * b
+}
+
+// The following is a parser for the parts of GraphQL documents that determine
+// their cost (see https://spec.graphql.org/June2018/#sec-Language). It does not
+// validate documents; that is done when they are executed.
+
+type gqlDocument struct {
+	operations []*gqlOperation
+	fragments  map[string][]*gqlSelection
+}
+
+type gqlOperation struct {
+	typ        string // query, mutation or subscription
+	name       string
+	selections []*gqlSelection
+}
+
+// gqlSelection is a field or a fragment spread. The selections of inline
+// fragments are merged into the enclosing selection set.
+type gqlSelection struct {
+	name       string
+	arguments  map[string]interface{}
+	selections []*gqlSelection // nil for scalar fields
+
+	spread string // the name of the spread fragment, if this is a fragment spread
+}
+
+// gqlVariable is a reference to a variable in a GraphQL document.
+type gqlVariable string
+
+type gqlParser struct {
+	src string
+	pos int
+
+	// The current token.
+	kind  tokenKind
+	value string
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunctuator
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+func parseGraphQLDocument(src string) (doc *gqlDocument, err error) {
+	p := &gqlParser{src: src}
+	defer func() {
+		if r := recover(); r != nil {
+			perr, ok := r.(gqlSyntaxError)
+			if !ok {
+				panic(r)
+			}
+			err = perr
+		}
+	}()
+	p.next()
+
+	doc = &gqlDocument{fragments: map[string][]*gqlSelection{}}
+	for p.kind != tokenEOF {
+		switch {
+		case p.peek(tokenPunctuator, "{"):
+			doc.operations = append(doc.operations, &gqlOperation{typ: "query", selections: p.parseSelectionSet()})
+		case p.peek(tokenName, "fragment"):
+			p.next()
+			name := p.expect(tokenName, "")
+			p.expect(tokenName, "on")
+			p.expect(tokenName, "")
+			p.skipDirectives()
+			doc.fragments[name] = p.parseSelectionSet()
+		case p.peek(tokenName, "query"), p.peek(tokenName, "mutation"), p.peek(tokenName, "subscription"):
+			op := &gqlOperation{typ: p.value}
+			p.next()
+			if p.kind == tokenName {
+				op.name = p.value
+				p.next()
+			}
+			if p.peek(tokenPunctuator, "(") {
+				p.skipVariableDefinitions()
+			}
+			p.skipDirectives()
+			op.selections = p.parseSelectionSet()
+			doc.operations = append(doc.operations, op)
+		default:
+			p.errorf("unexpected %q", p.value)
+		}
+	}
+	return doc, nil
+}
+
+type gqlSyntaxError struct {
+	msg string
+	pos int
+}
+
+func (e gqlSyntaxError) Error() string {
+	return fmt.Sprintf("GraphQL syntax error at offset %d: %s", e.pos, e.msg)
+}
+
+func (p *gqlParser) errorf(format string, args ...interface{}) {
+	panic(gqlSyntaxError{msg: fmt.Sprintf(format, args...), pos: p.pos})
+}
+
+func (p *gqlParser) parseSelectionSet() []*gqlSelection {
+	p.expect(tokenPunctuator, "{")
+	selections := []*gqlSelection{}
+	for !p.peek(tokenPunctuator, "}") {
+		if p.peek(tokenPunctuator, "...") {
+			p.next()
+			if p.kind == tokenName && p.value != "on" {
+				selections = append(selections, &gqlSelection{spread: p.value})
+				p.next()
+				p.skipDirectives()
+				continue
+			}
+			if p.peek(tokenName, "on") {
+				p.next()
+				p.expect(tokenName, "")
+			}
+			p.skipDirectives()
+			selections = append(selections, p.parseSelectionSet()...)
+			continue
+		}
+
+		s := &gqlSelection{name: p.expect(tokenName, "")}
+		if p.peek(tokenPunctuator, ":") {
+			p.next() // the name was an alias
+			s.name = p.expect(tokenName, "")
+		}
+		if p.peek(tokenPunctuator, "(") {
+			s.arguments = p.parseArguments()
+		}
+		p.skipDirectives()
+		if p.peek(tokenPunctuator, "{") {
+			s.selections = p.parseSelectionSet()
+		}
+		selections = append(selections, s)
+	}
+	p.next()
+	return selections
+}
+
+func (p *gqlParser) parseArguments() map[string]interface{} {
+	p.expect(tokenPunctuator, "(")
+	args := map[string]interface{}{}
+	for !p.peek(tokenPunctuator, ")") {
+		name := p.expect(tokenName, "")
+		p.expect(tokenPunctuator, ":")
+		args[name] = p.parseValue()
+	}
+	p.next()
+	return args
+}
+
+// parseValue returns the value if it is an integer or a variable reference.
+// Other values are skipped and nil is returned.
+func (p *gqlParser) parseValue() interface{} {
+	switch {
+	case p.peek(tokenPunctuator, "$"):
+		p.next()
+		return gqlVariable(p.expect(tokenName, ""))
+	case p.kind == tokenInt:
+		n, err := strconv.Atoi(p.value)
+		if err != nil {
+			n = math.MaxInt32
+		}
+		p.next()
+		return n
+	case p.peek(tokenPunctuator, "["):
+		p.next()
+		for !p.peek(tokenPunctuator, "]") {
+			p.parseValue()
+		}
+		p.next()
+	case p.peek(tokenPunctuator, "{"):
+		p.next()
+		for !p.peek(tokenPunctuator, "}") {
+			p.expect(tokenName, "")
+			p.expect(tokenPunctuator, ":")
+			p.parseValue()
+		}
+		p.next()
+	case p.kind == tokenFloat, p.kind == tokenString, p.kind == tokenName:
+		p.next()
+	default:
+		p.errorf("unexpected %q in value", p.value)
+	}
+	return nil
+}
+
+func (p *gqlParser) skipVariableDefinitions() {
+	p.expect(tokenPunctuator, "(")
+	for !p.peek(tokenPunctuator, ")") {
+		p.expect(tokenPunctuator, "$")
+		p.expect(tokenName, "")
+		p.expect(tokenPunctuator, ":")
+		p.skipType()
+		if p.peek(tokenPunctuator, "=") {
+			p.next()
+			p.parseValue()
+		}
+	}
+	p.next()
+}
+
+func (p *gqlParser) skipType() {
+	if p.peek(tokenPunctuator, "[") {
+		p.next()
+		p.skipType()
+		p.expect(tokenPunctuator, "]")
+	} else {
+		p.expect(tokenName, "")
+	}
+	if p.peek(tokenPunctuator, "!") {
+		p.next()
+	}
+}
+
+func (p *gqlParser) skipDirectives() {
+	for p.peek(tokenPunctuator, "@") {
+		p.next()
+		p.expect(tokenName, "")
+		if p.peek(tokenPunctuator, "(") {
+			p.parseArguments()
+		}
+	}
+}
+
+func (p *gqlParser) peek(kind tokenKind, value string) bool {
+	return p.kind == kind && p.value == value
+}
+
+// expect consumes the current token, which must be of the given kind and, if
+// value is not empty, have the given value. It returns the value of the token.
+func (p *gqlParser) expect(kind tokenKind, value string) string {
+	if p.kind != kind || (value != "" && p.value != value) {
+		if p.kind == tokenEOF {
+			p.errorf("unexpected end of document")
+		}
+		p.errorf("unexpected %q", p.value)
+	}
+	v := p.value
+	p.next()
+	return v
+}
+
+// next scans the next token.
+func (p *gqlParser) next() {
+	// Skip ignored tokens: white space, line terminators, commas, comments and
+	// the byte order mark.
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',' {
+			p.pos++
+		} else if c == '#' {
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' && p.src[p.pos] != '\r' {
+				p.pos++
+			}
+		} else if strings.HasPrefix(p.src[p.pos:], "\ufeff") {
+			p.pos += len("\ufeff")
+		} else {
+			break
+		}
+	}
+	if p.pos == len(p.src) {
+		p.kind, p.value = tokenEOF, ""
+		return
+	}
+
+	start := p.pos
+	c := p.src[p.pos]
+	switch {
+	case strings.HasPrefix(p.src[p.pos:], "..."):
+		p.pos += 3
+		p.kind = tokenPunctuator
+	case strings.IndexByte("!$():=@[]{|}", c) >= 0:
+		p.pos++
+		p.kind = tokenPunctuator
+	case c == '_' || isLetter(c):
+		for p.pos < len(p.src) && (p.src[p.pos] == '_' || isLetter(p.src[p.pos]) || isDigit(p.src[p.pos])) {
+			p.pos++
+		}
+		p.kind = tokenName
+	case c == '-' || isDigit(c):
+		p.scanNumber()
+	case strings.HasPrefix(p.src[p.pos:], `"""`):
+		end := strings.Index(strings.Replace(p.src[p.pos+3:], `\"""`, "xxxx", -1), `"""`)
+		if end < 0 {
+			p.errorf("unterminated block string")
+		}
+		p.pos += 3 + end + 3
+		p.kind = tokenString
+	case c == '"':
+		p.pos++
+		for {
+			if p.pos >= len(p.src) || p.src[p.pos] == '\n' || p.src[p.pos] == '\r' {
+				p.errorf("unterminated string")
+			}
+			if p.src[p.pos] == '\\' {
+				p.pos += 2
+				continue
+			}
+			p.pos++
+			if p.src[p.pos-1] == '"' {
+				break
+			}
+		}
+		p.kind = tokenString
+	default:
+		p.errorf("unexpected character %q", c)
+	}
+	p.value = p.src[start:p.pos]
+}
+
+func (p *gqlParser) scanNumber() {
+	p.kind = tokenInt
+	if p.src[p.pos] == '-' {
+		p.pos++
+	}
+	p.scanDigits()
+	if p.pos < len(p.src) && p.src[p.pos] == '.' {
+		p.kind = tokenFloat
+		p.pos++
+		p.scanDigits()
+	}
+	if p.pos < len(p.src) && (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') {
+		p.kind = tokenFloat
+		p.pos++
+		if p.pos < len(p.src) && (p.src[p.pos] == '+' || p.src[p.pos] == '-') {
+			p.pos++
+		}
+		p.scanDigits()
+	}
+}
+
+func (p *gqlParser) scanDigits() {
+	start := p.pos
+	for p.pos < len(p.src) && isDigit(p.src[p.pos]) {
+		p.pos++
+	}
+	if p.pos == start {
+		p.errorf("invalid number")
+	}
+}
+
+func isLetter(c byte) bool { return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') }
+
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
@@ -0,0 +1,104 @@
+package quota
+
+import "testing"
+
+func TestGraphQLRequestCost(t *testing.T) {
+	tests := map[string]struct {
+		query         string
+		operationName string
+		variables     map[string]interface{}
+		wantCost      int
+		wantSearches  int
+	}{
+		"scalars": {
+			query:    `{ currentUser { username } }`,
+			wantCost: 1,
+		},
+		"connection": {
+			query:    `{ repositories(first: 10) { nodes { name, commit(rev: "HEAD") { oid } } } }`,
+			wantCost: 21,
+		},
+		"nested connections": {
+			query:    `{ repositories(first: 10) { nodes { commit(rev: "HEAD") { ancestors(first: 100) { nodes { oid } } } } } }`,
+			wantCost: 1 + 10 + 10 + 10 + 1000,
+		},
+		"variables": {
+			query:     `query Repos($first: Int!) { repositories(first: $first) { nodes { name } } }`,
+			variables: map[string]interface{}{"first": float64(50)},
+			wantCost:  51,
+		},
+		"aliases and fragments": {
+			query: `
+				query {
+					a: repositories(first: 2) { ...Repos }
+					b: repositories(first: 3) { ... on RepositoryConnection { nodes { name } } }
+				}
+				fragment Repos on RepositoryConnection { nodes { name } }
+			`,
+			wantCost: (1 + 2) + (1 + 3),
+		},
+		"cyclic fragments": {
+			query: `
+				{ node(id: "x") { ...A } }
+				fragment A on Node { ... on Repository { owner { ...A } } }
+			`,
+			wantCost: 2,
+		},
+		"operation name": {
+			query:         `query A { currentUser { username } } query B { site { id } currentUser { username } }`,
+			operationName: "B",
+			wantCost:      2,
+		},
+		"searches": {
+			query: `
+				query Search($query: String!) {
+					search(query: $query, version: V2) { results { matchCount } }
+					other: search(query: "b") { results { matchCount } }
+				}
+			`,
+			wantCost:     4,
+			wantSearches: 2,
+		},
+		"mutation": {
+			query:    `mutation { createSavedSearch(description: "d", query: "q", notifyOwner: true, notifySlack: false, orgID: null) { id } }`,
+			wantCost: 1,
+		},
+		"strings and comments": {
+			query: `
+				# a comment with { braces
+				{ search(query: "{ \" }", patternType: literal) { results { matchCount } } a: search(query: """ { """) { __typename } }
+			`,
+			wantCost:     3,
+			wantSearches: 2,
+		},
+		"overflow": {
+			query:    `{ a(first: 100000) { b(first: 100000) { c(first: 100000) { d { id } } } } }`,
+			wantCost: 2147483647,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cost, searches, err := GraphQLRequestCost(test.query, test.operationName, test.variables)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cost != test.wantCost {
+				t.Errorf("got cost %d, want %d", cost, test.wantCost)
+			}
+			if searches != test.wantSearches {
+				t.Errorf("got %d searches, want %d", searches, test.wantSearches)
+			}
+		})
+	}
+
+	for _, query := range []string{
+		`{ a `,
+		`{ a(first: ) }`,
+		`{ a(b: "unterminated) }`,
+		`query A { a } query B { b }`,
+	} {
+		if _, _, err := GraphQLRequestCost(query, "", nil); err == nil {
+			t.Errorf("got no error for %q", query)
+		}
+	}
+}
@@ -0,0 +1,354 @@
+// Package quota enforces the hourly API budgets of users, organizations and
+// access tokens that are configured in the apiRateLimits site configuration
+// setting.
+package quota
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// Window is the period after which all budgets are replenished.
+const Window = time.Hour
+
+// Resource is an API resource whose use is limited by budgets.
+type Resource string
+
+const (
+	SearchRequests   Resource = "searchRequests"
+	GraphQLCost      Resource = "graphQLCost"
+	CodeHostRequests Resource = "codeHostRequests"
+)
+
+// Resources are all resources whose use is limited by budgets.
+var Resources = []Resource{SearchRequests, GraphQLCost, CodeHostRequests}
+
+// SubjectKind is the kind of a subject.
+type SubjectKind string
+
+const (
+	SubjectUser        SubjectKind = "user"
+	SubjectOrg         SubjectKind = "org"
+	SubjectAccessToken SubjectKind = "accessToken"
+)
+
+// Subject is a user, organization or access token that has a budget.
+type Subject struct {
+	Kind SubjectKind
+	ID   int64
+
+	// Name is the username of a user or the name of an organization, which is
+	// used to find the overrides of its budget.
+	Name string
+}
+
+func (s Subject) String() string {
+	return string(s.Kind) + ":" + strconv.FormatInt(s.ID, 10)
+}
+
+// Usage is the use of the budget of a subject for a resource in the current
+// window.
+type Usage struct {
+	Subject  Subject
+	Resource Resource
+	Used     int
+	Limit    int // zero if the use of the resource is unlimited
+	Reset    time.Time
+}
+
+// Remaining returns the remaining budget, or -1 if the use of the resource is
+// unlimited.
+func (u *Usage) Remaining() int {
+	if u.Limit == 0 {
+		return -1
+	}
+	if u.Used >= u.Limit {
+		return 0
+	}
+	return u.Limit - u.Used
+}
+
+// ExceededError is returned by Consume when a request would exceed a budget.
+type ExceededError struct {
+	Usage Usage
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("API rate limit exceeded: the hourly %s budget of %d of %s is exhausted until %s", e.Usage.Resource, e.Usage.Limit, e.Usage.Subject, e.Usage.Reset.UTC().Format(time.RFC3339))
+}
+
+var timeNow = time.Now
+
+// Subjects returns the subjects whose budgets apply to requests by the current
+// actor, made with the access token with the given ID (or zero if no access
+// token was used). Subjects of a kind are only returned if the site
+// configuration defines budgets for that kind, so that no work is done if API
+// rate limits are not configured. Internal and anonymous actors have no
+// budgets.
+func Subjects(ctx context.Context, accessTokenID int64) ([]Subject, error) {
+	cfg := conf.Get().ApiRateLimits
+	a := actor.FromContext(ctx)
+	if cfg == nil || a.Internal || !a.IsAuthenticated() {
+		return nil, nil
+	}
+
+	var userOverrides, orgOverrides bool
+	for _, o := range cfg.Overrides {
+		userOverrides = userOverrides || o.User != ""
+		orgOverrides = orgOverrides || o.Org != ""
+	}
+
+	var subjects []Subject
+	if cfg.User != nil || userOverrides {
+		s := Subject{Kind: SubjectUser, ID: int64(a.UID)}
+		if userOverrides {
+			user, err := db.Users.GetByID(ctx, a.UID)
+			if err != nil {
+				return nil, err
+			}
+			s.Name = user.Username
+		}
+		subjects = append(subjects, s)
+	}
+	if cfg.Org != nil || orgOverrides {
+		orgs, err := db.Orgs.GetByUserID(ctx, a.UID)
+		if err != nil {
+			return nil, err
+		}
+		for _, org := range orgs {
+			subjects = append(subjects, Subject{Kind: SubjectOrg, ID: int64(org.ID), Name: org.Name})
+		}
+	}
+	if cfg.AccessToken != nil && accessTokenID != 0 {
+		subjects = append(subjects, Subject{Kind: SubjectAccessToken, ID: accessTokenID})
+	}
+	return subjects, nil
+}
+
+// limit returns the budget of the subject for the resource, or zero if its use
+// is unlimited.
+func limit(cfg *schema.ApiRateLimits, s Subject, r Resource) int {
+	if cfg == nil {
+		return 0
+	}
+
+	var budget *schema.APIBudget
+	switch s.Kind {
+	case SubjectUser:
+		budget = cfg.User
+	case SubjectOrg:
+		budget = cfg.Org
+	case SubjectAccessToken:
+		budget = cfg.AccessToken
+	}
+	if s.Name != "" {
+		for _, o := range cfg.Overrides {
+			if (s.Kind == SubjectUser && o.User == s.Name) || (s.Kind == SubjectOrg && o.Org == s.Name) {
+				budget = &o.Budget
+			}
+		}
+	}
+	if budget == nil {
+		return 0
+	}
+
+	switch r {
+	case SearchRequests:
+		return budget.SearchRequests
+	case GraphQLCost:
+		return budget.GraphQLCost
+	case CodeHostRequests:
+		return budget.CodeHostRequests
+	}
+	return 0
+}
+
+// MockConsume mocks Consume in tests.
+var MockConsume func(subjects []Subject, amounts map[Resource]int) (*Usage, error)
+
+// Consume records the use of the given amounts of resources by a request whose
+// budgets are those of the subjects. It returns the usage of the budget that is
+// closest to being exhausted, or nil if none of the budgets are limited.
+//
+// If the request would exceed any of the budgets, nothing is recorded and an
+// *ExceededError is returned.
+func Consume(ctx context.Context, subjects []Subject, amounts map[Resource]int) (*Usage, error) {
+	if MockConsume != nil {
+		return MockConsume(subjects, amounts)
+	}
+	if len(subjects) == 0 {
+		return nil, nil
+	}
+	cfg := conf.Get().ApiRateLimits
+
+	now := timeNow()
+	window := now.Truncate(Window)
+	reset := window.Add(Window)
+	ttl := reset.Sub(now) + time.Minute
+
+	type increment struct {
+		key string
+		n   int
+	}
+	var done []increment
+	rollback := func() {
+		for _, inc := range done {
+			_, _ = store.incrBy(inc.key, -inc.n, ttl)
+		}
+	}
+
+	var closest *Usage
+	for _, s := range subjects {
+		if err := store.addMember(subjectsKey(window), s.String(), ttl); err != nil {
+			return nil, err
+		}
+		for _, r := range Resources {
+			n := amounts[r]
+			if n == 0 {
+				continue
+			}
+			key := usageKey(window, s, r)
+			used, err := store.incrBy(key, n, ttl)
+			if err != nil {
+				rollback()
+				return nil, err
+			}
+			done = append(done, increment{key: key, n: n})
+
+			u := Usage{Subject: s, Resource: r, Used: used, Limit: limit(cfg, s, r), Reset: reset}
+			if u.Limit == 0 {
+				continue
+			}
+			if u.Used > u.Limit {
+				rollback()
+				u.Used -= n
+				return nil, &ExceededError{Usage: u}
+			}
+			if closest == nil || u.Remaining() < closest.Remaining() {
+				closest = &u
+			}
+		}
+	}
+	return closest, nil
+}
+
+// List returns the usage of the budgets of all subjects that used the API in
+// the current window, ordered by subject and resource.
+func List(ctx context.Context) ([]*Usage, error) {
+	cfg := conf.Get().ApiRateLimits
+
+	window := timeNow().Truncate(Window)
+	members, err := store.members(subjectsKey(window))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(members)
+
+	var subjects []Subject
+	var keys []string
+	for _, m := range members {
+		s, err := parseSubject(m)
+		if err != nil {
+			return nil, err
+		}
+		subjects = append(subjects, s)
+		for _, r := range Resources {
+			keys = append(keys, usageKey(window, s, r))
+		}
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	values, err := store.get(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	usages := make([]*Usage, 0, len(keys))
+	for i, s := range subjects {
+		if s.Kind != SubjectAccessToken && hasOverrides(cfg) {
+			s.Name, err = subjectName(ctx, s)
+			if err != nil {
+				return nil, err
+			}
+		}
+		for j, r := range Resources {
+			usages = append(usages, &Usage{
+				Subject:  s,
+				Resource: r,
+				Used:     values[i*len(Resources)+j],
+				Limit:    limit(cfg, s, r),
+				Reset:    window.Add(Window),
+			})
+		}
+	}
+	return usages, nil
+}
+
+func hasOverrides(cfg *schema.ApiRateLimits) bool {
+	return cfg != nil && len(cfg.Overrides) > 0
+}
+
+// subjectName returns the name of a user or organization subject, or an empty
+// string if it no longer exists.
+func subjectName(ctx context.Context, s Subject) (string, error) {
+	switch s.Kind {
+	case SubjectUser:
+		user, err := db.Users.GetByID(ctx, int32(s.ID))
+		if err != nil {
+			if errcode.IsNotFound(err) {
+				return "", nil
+			}
+			return "", err
+		}
+		return user.Username, nil
+	case SubjectOrg:
+		org, err := db.Orgs.GetByID(ctx, int32(s.ID))
+		if err != nil {
+			if errcode.IsNotFound(err) {
+				return "", nil
+			}
+			return "", err
+		}
+		return org.Name, nil
+	}
+	return "", nil
+}
+
+func parseSubject(s string) (Subject, error) {
+	i := strings.LastIndex(s, ":")
+	if i < 0 {
+		return Subject{}, fmt.Errorf("invalid quota subject %q", s)
+	}
+	id, err := strconv.ParseInt(s[i+1:], 10, 64)
+	if err != nil {
+		return Subject{}, fmt.Errorf("invalid quota subject %q", s)
+	}
+	kind := SubjectKind(s[:i])
+	switch kind {
+	case SubjectUser, SubjectOrg, SubjectAccessToken:
+	default:
+		return Subject{}, fmt.Errorf("invalid quota subject %q", s)
+	}
+	return Subject{Kind: kind, ID: id}, nil
+}
+
+const keyPrefix = "quota:"
+
+func subjectsKey(window time.Time) string {
+	return keyPrefix + strconv.FormatInt(window.Unix(), 10) + ":subjects"
+}
+
+func usageKey(window time.Time, s Subject, r Resource) string {
+	return keyPrefix + strconv.FormatInt(window.Unix(), 10) + ":" + s.String() + ":" + string(r)
+}
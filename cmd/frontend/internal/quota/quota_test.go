@@ -0,0 +1,192 @@
+package quota
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+type memStore struct {
+	counters map[string]int
+	sets     map[string]map[string]bool
+}
+
+func (s *memStore) incrBy(key string, n int, ttl time.Duration) (int, error) {
+	s.counters[key] += n
+	return s.counters[key], nil
+}
+
+func (s *memStore) get(keys []string) ([]int, error) {
+	values := make([]int, len(keys))
+	for i, key := range keys {
+		values[i] = s.counters[key]
+	}
+	return values, nil
+}
+
+func (s *memStore) addMember(key, member string, ttl time.Duration) error {
+	if s.sets[key] == nil {
+		s.sets[key] = map[string]bool{}
+	}
+	s.sets[key][member] = true
+	return nil
+}
+
+func (s *memStore) members(key string) ([]string, error) {
+	var members []string
+	for m := range s.sets[key] {
+		members = append(members, m)
+	}
+	return members, nil
+}
+
+func setup(t *testing.T, cfg *schema.ApiRateLimits) {
+	t.Helper()
+
+	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{ApiRateLimits: cfg}})
+	origStore, origTimeNow := store, timeNow
+	store = &memStore{counters: map[string]int{}, sets: map[string]map[string]bool{}}
+	now := time.Date(2020, 4, 1, 12, 30, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+
+	t.Cleanup(func() {
+		conf.Mock(nil)
+		store, timeNow = origStore, origTimeNow
+		db.Mocks = db.MockStores{}
+	})
+}
+
+func TestSubjects(t *testing.T) {
+	setup(t, &schema.ApiRateLimits{
+		User:        &schema.APIBudget{SearchRequests: 10},
+		AccessToken: &schema.APIBudget{SearchRequests: 5},
+		Overrides:   []*schema.APIBudgetOverride{{Org: "acme", Budget: schema.APIBudget{SearchRequests: 100}}},
+	})
+	db.Mocks.Orgs.GetByUserID = func(ctx context.Context, userID int32) ([]*types.Org, error) {
+		return []*types.Org{{ID: 3, Name: "acme"}}, nil
+	}
+
+	ctx := actor.WithActor(context.Background(), &actor.Actor{UID: 1})
+	subjects, err := Subjects(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Subject{
+		{Kind: SubjectUser, ID: 1},
+		{Kind: SubjectOrg, ID: 3, Name: "acme"},
+		{Kind: SubjectAccessToken, ID: 2},
+	}
+	if !reflect.DeepEqual(subjects, want) {
+		t.Errorf("got %+v, want %+v", subjects, want)
+	}
+
+	// Anonymous and internal actors have no budgets.
+	for _, a := range []*actor.Actor{{}, {Internal: true}} {
+		subjects, err := Subjects(actor.WithActor(context.Background(), a), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(subjects) != 0 {
+			t.Errorf("got subjects %+v for actor %v, want none", subjects, a)
+		}
+	}
+}
+
+func TestConsume(t *testing.T) {
+	setup(t, &schema.ApiRateLimits{
+		User:      &schema.APIBudget{SearchRequests: 3, GraphQLCost: 100},
+		Org:       &schema.APIBudget{SearchRequests: 5},
+		Overrides: []*schema.APIBudgetOverride{{User: "bot", Budget: schema.APIBudget{SearchRequests: 1}}},
+	})
+	ctx := context.Background()
+	alice := Subject{Kind: SubjectUser, ID: 1, Name: "alice"}
+	bob := Subject{Kind: SubjectUser, ID: 2, Name: "bob"}
+	bot := Subject{Kind: SubjectUser, ID: 3, Name: "bot"}
+	org := Subject{Kind: SubjectOrg, ID: 1, Name: "acme"}
+	reset := time.Date(2020, 4, 1, 13, 0, 0, 0, time.UTC)
+
+	usage, err := Consume(ctx, []Subject{alice, org}, map[Resource]int{SearchRequests: 1, GraphQLCost: 40})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (&Usage{Subject: alice, Resource: SearchRequests, Used: 1, Limit: 3, Reset: reset}); !reflect.DeepEqual(usage, want) {
+		t.Errorf("got usage %+v, want %+v", usage, want)
+	}
+
+	// The budget of the org is shared by its members.
+	for i := 0; i < 3; i++ {
+		if _, err := Consume(ctx, []Subject{bob, org}, map[Resource]int{SearchRequests: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	usage, err = Consume(ctx, []Subject{alice, org}, map[Resource]int{SearchRequests: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.Subject != org || usage.Remaining() != 0 {
+		t.Errorf("got usage %+v, want exhausted org budget", usage)
+	}
+	_, err = Consume(ctx, []Subject{alice, org}, map[Resource]int{SearchRequests: 1})
+	if e, ok := err.(*ExceededError); !ok || e.Usage.Subject != org || e.Usage.Used != 5 {
+		t.Fatalf("got error %v, want exceeded org budget", err)
+	}
+
+	// Requests that exceed a budget are not recorded.
+	if _, err := Consume(ctx, []Subject{alice}, map[Resource]int{GraphQLCost: 70}); err == nil {
+		t.Fatal("got no error exceeding GraphQL cost budget")
+	}
+	if _, err := Consume(ctx, []Subject{alice}, map[Resource]int{GraphQLCost: 60}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Overrides replace the budget of specific users.
+	if _, err := Consume(ctx, []Subject{bot}, map[Resource]int{SearchRequests: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Consume(ctx, []Subject{bot}, map[Resource]int{SearchRequests: 1}); err == nil {
+		t.Fatal("got no error exceeding overridden budget")
+	}
+
+	// Budgets are replenished in the next window.
+	timeNow = func() time.Time { return reset.Add(time.Minute) }
+	if _, err := Consume(ctx, []Subject{bot}, map[Resource]int{SearchRequests: 1}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestList(t *testing.T) {
+	setup(t, &schema.ApiRateLimits{
+		User: &schema.APIBudget{SearchRequests: 3},
+	})
+	ctx := context.Background()
+	user := Subject{Kind: SubjectUser, ID: 1}
+	token := Subject{Kind: SubjectAccessToken, ID: 7}
+
+	if _, err := Consume(ctx, []Subject{user, token}, map[Resource]int{SearchRequests: 2, CodeHostRequests: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	usages, err := List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reset := time.Date(2020, 4, 1, 13, 0, 0, 0, time.UTC)
+	want := []*Usage{
+		{Subject: token, Resource: SearchRequests, Used: 2, Reset: reset},
+		{Subject: token, Resource: GraphQLCost, Reset: reset},
+		{Subject: token, Resource: CodeHostRequests, Used: 1, Reset: reset},
+		{Subject: user, Resource: SearchRequests, Used: 2, Limit: 3, Reset: reset},
+		{Subject: user, Resource: GraphQLCost, Reset: reset},
+		{Subject: user, Resource: CodeHostRequests, Used: 1, Reset: reset},
+	}
+	if !reflect.DeepEqual(usages, want) {
+		t.Errorf("got %+v, want %+v", usages, want)
+	}
+}
@@ -0,0 +1,100 @@
+package quota
+
+import (
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/sourcegraph/sourcegraph/internal/redispool"
+)
+
+// store keeps the usage of budgets. It is shared by all frontend instances.
+var store counterStore = &redisStore{pool: redispool.Store}
+
+type counterStore interface {
+	// incrBy adds n to the counter with the given key and returns its new
+	// value. The counter expires after ttl.
+	incrBy(key string, n int, ttl time.Duration) (int, error)
+
+	// get returns the values of the counters with the given keys, which are
+	// zero for counters that don't exist.
+	get(keys []string) ([]int, error)
+
+	// addMember adds a member to the set with the given key, which expires
+	// after ttl.
+	addMember(key, member string, ttl time.Duration) error
+
+	// members returns the members of the set with the given key.
+	members(key string) ([]string, error)
+}
+
+type redisStore struct {
+	pool *redis.Pool
+}
+
+func (s *redisStore) incrBy(key string, n int, ttl time.Duration) (int, error) {
+	c := s.pool.Get()
+	defer c.Close()
+
+	if err := c.Send("MULTI"); err != nil {
+		return 0, err
+	}
+	if err := c.Send("INCRBY", key, n); err != nil {
+		return 0, err
+	}
+	if err := c.Send("EXPIRE", key, int(ttl.Seconds())); err != nil {
+		return 0, err
+	}
+	values, err := redis.Values(c.Do("EXEC"))
+	if err != nil {
+		return 0, err
+	}
+	return redis.Int(values[0], nil)
+}
+
+func (s *redisStore) get(keys []string) ([]int, error) {
+	c := s.pool.Get()
+	defer c.Close()
+
+	args := make([]interface{}, len(keys))
+	for i, key := range keys {
+		args[i] = key
+	}
+	values, err := redis.Values(c.Do("MGET", args...))
+	if err != nil {
+		return nil, err
+	}
+	counts := make([]int, len(values))
+	for i, v := range values {
+		if v == nil {
+			continue
+		}
+		if counts[i], err = redis.Int(v, nil); err != nil {
+			return nil, err
+		}
+	}
+	return counts, nil
+}
+
+func (s *redisStore) addMember(key, member string, ttl time.Duration) error {
+	c := s.pool.Get()
+	defer c.Close()
+
+	if err := c.Send("MULTI"); err != nil {
+		return err
+	}
+	if err := c.Send("SADD", key, member); err != nil {
+		return err
+	}
+	if err := c.Send("EXPIRE", key, int(ttl.Seconds())); err != nil {
+		return err
+	}
+	_, err := c.Do("EXEC")
+	return err
+}
+
+func (s *redisStore) members(key string) ([]string, error) {
+	c := s.pool.Get()
+	defer c.Close()
+
+	return redis.Strings(c.Do("SMEMBERS", key))
+}
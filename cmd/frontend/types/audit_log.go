@@ -0,0 +1,35 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditLogEntry is an entry in the audit log, which records security-relevant
+// actions such as changes to users, organizations, access tokens, external
+// services, the site configuration and repository permissions.
+type AuditLogEntry struct {
+	ID int64 `json:"id"`
+
+	// ActorUserID is the ID of the user who performed the action, or zero if
+	// it was performed by Sourcegraph itself or by an anonymous user.
+	ActorUserID   int32 `json:"actorUserID,omitempty"`
+	ActorInternal bool  `json:"actorInternal,omitempty"`
+
+	// ActorIP and ActorForwardedFor are the IP address and the X-Forwarded-For
+	// header of the HTTP request in which the action was performed, if any.
+	ActorIP           string `json:"actorIP,omitempty"`
+	ActorForwardedFor string `json:"actorForwardedFor,omitempty"`
+
+	Action     string `json:"action"`     // e.g. "user.create"
+	TargetType string `json:"targetType"` // e.g. "user"
+	TargetID   string `json:"targetID,omitempty"`
+
+	// Before and After are the state of the target before and after the
+	// action, with secrets redacted. They are nil if the action did not
+	// change (or create or delete) any state worth recording.
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -36,6 +36,17 @@ type DiscussionThreadTargetRepo struct {
 	LinesBefore    *[]string
 	Lines          *[]string
 	LinesAfter     *[]string
+
+	// AnchorRevision, AnchorPath, AnchorStartLine and AnchorEndLine are where
+	// the selection is at the most recent commit of the branch that it was
+	// tracked through. They are nil if the selection hasn't been tracked yet.
+	AnchorRevision  *string
+	AnchorPath      *string
+	AnchorStartLine *int32
+	AnchorEndLine   *int32
+	// Outdated is whether the selected lines were deleted after the anchor
+	// revision.
+	Outdated bool
 }
 
 // HasSelection tells if the selection fields are present or not. If one field
@@ -0,0 +1,25 @@
+package types
+
+import (
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/inventory"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+// RepoInventory is the language inventory of the default branch of a
+// repository, as computed in the background and stored in the DB.
+type RepoInventory struct {
+	RepoID    api.RepoID
+	CommitID  api.CommitID     // the commit the inventory was computed at
+	Languages []inventory.Lang // ordered by lines of code
+	UpdatedAt time.Time
+}
+
+// LanguageStatisticsPoint is the total size of a language in a set of
+// repositories at the end of a day.
+type LanguageStatisticsPoint struct {
+	Date       time.Time
+	TotalLines uint64
+	TotalBytes uint64
+}
@@ -0,0 +1,41 @@
+package types
+
+import "time"
+
+// RepoGroup is a named group of repositories that can be referenced in search
+// queries with repogroup:.
+type RepoGroup struct {
+	ID          int32 // the globally unique DB ID
+	Name        string
+	Description string
+	UserID      *int32         // if non-nil, the owner is this user. UserID/OrgID are mutually exclusive.
+	OrgID       *int32         // if non-nil, the owner is this organization. UserID/OrgID are mutually exclusive.
+	Rule        *RepoGroupRule // if non-nil, the group is dynamic and Repositories is the result of evaluating the rule.
+
+	// Repositories are the names of the repositories in the group. For
+	// dynamic groups, they are updated whenever the rule is evaluated.
+	Repositories []string
+
+	EvaluatedAt *time.Time // when the rule of a dynamic group was last evaluated
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// RepoGroupRule defines the repositories of a dynamic repository group. A
+// repository is in the group if it matches all conditions that are set.
+type RepoGroupRule struct {
+	// RepoPattern is a regular expression that repository names must match.
+	RepoPattern string `json:"repoPattern,omitempty"`
+
+	// ExternalServiceID is the ID of the external service that repositories
+	// must be synced from.
+	ExternalServiceID int64 `json:"externalServiceID,omitempty"`
+
+	// Topics are code host topics, of which repositories must have at least
+	// one.
+	Topics []string `json:"topics,omitempty"`
+
+	// Languages are languages, one of which must be the most common language
+	// of repositories.
+	Languages []string `json:"languages,omitempty"`
+}
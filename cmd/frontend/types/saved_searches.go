@@ -10,4 +10,6 @@ type SavedSearch struct {
 	UserID          *int32  // if non-nil, the owner is this user. UserID/OrgID are mutually exclusive.
 	OrgID           *int32  // if non-nil, the owner is this organization. UserID/OrgID are mutually exclusive.
 	SlackWebhookURL *string // if non-nil && NotifySlack == true, indicates that this Slack webhook URL should be used instead of the owners default Slack webhook.
+	WebhookURL      *string // if non-nil, new matches of this saved search are posted as JSON to this URL.
+	WebhookSecret   *string // if non-nil, the key used to sign the JSON payloads posted to WebhookURL.
 }
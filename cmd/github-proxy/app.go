@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// installationsTTL is how long the list of installations of the GitHub App
+	// is cached. Owners without an installation trigger a refresh at most every
+	// installationsMinRefresh, so that new installations are picked up quickly.
+	installationsTTL        = 10 * time.Minute
+	installationsMinRefresh = time.Minute
+
+	// installationsListTimeout is the timeout for listing the installations.
+	installationsListTimeout = time.Minute
+
+	// tokenRefreshMargin is how long before their expiry installation access
+	// tokens are refreshed.
+	tokenRefreshMargin = 5 * time.Minute
+)
+
+// githubApp authenticates as a GitHub App and mints access tokens for its
+// installations.
+//
+// See https://developer.github.com/apps/building-github-apps/authenticating-with-github-apps/
+type githubApp struct {
+	id     string
+	key    *rsa.PrivateKey
+	apiURL *url.URL
+	client *http.Client
+	now    func() time.Time
+
+	// concurrency is the maximum number of concurrent requests per
+	// installation.
+	concurrency int
+
+	// refresh deduplicates concurrent refreshes of the installations.
+	refresh singleflight.Group
+
+	mu            sync.Mutex
+	installations map[string]*credential // lower-cased account login -> credential
+	listedAt      time.Time
+}
+
+// parsePrivateKey parses a PEM-encoded RSA private key, which may be base64
+// encoded so that it can be passed in an environment variable.
+func parsePrivateKey(s string) (*rsa.PrivateKey, error) {
+	data := []byte(s)
+	if !strings.Contains(s, "-----BEGIN") {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+		if err != nil {
+			return nil, errors.Wrap(err, "private key is neither PEM nor base64-encoded PEM")
+		}
+		data = decoded
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found in private key")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing private key")
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+// jwt returns a JSON Web Token that authenticates requests as the GitHub App.
+func (a *githubApp) jwt() (string, error) {
+	now := a.now()
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		// Allow for clock drift between us and GitHub.
+		"iat": now.Add(-time.Minute).Unix(),
+		// GitHub rejects tokens that expire more than 10 minutes in the future.
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": a.id,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, a.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + enc.EncodeToString(sig), nil
+}
+
+// installation returns the credential of the installation of the GitHub App on
+// the account with the given login, or nil if the app isn't installed on it.
+func (a *githubApp) installation(ctx context.Context, owner string) (*credential, error) {
+	owner = strings.ToLower(owner)
+
+	cred, fresh := a.cachedInstallation(owner)
+	if fresh {
+		return cred, nil
+	}
+
+	// The installations are listed without holding a.mu and only once for
+	// concurrent requests. The listing doesn't use ctx, since the requests
+	// waiting for it may give up.
+	ch := a.refresh.DoChan("", func() (interface{}, error) {
+		if _, fresh := a.cachedInstallation(owner); fresh {
+			// Refreshed since we checked.
+			return nil, nil
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), installationsListTimeout)
+		defer cancel()
+		return nil, a.listInstallations(ctx)
+	})
+	select {
+	case res := <-ch:
+		// Keep using the installations we know about if listing failed.
+		cred, _ := a.cachedInstallation(owner)
+		return cred, res.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// cachedInstallation returns the cached credential of the installation on the
+// account with the given lower-cased login and whether it is fresh.
+func (a *githubApp) cachedInstallation(owner string) (cred *credential, fresh bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	cred, ok := a.installations[owner]
+	age := a.now().Sub(a.listedAt)
+	return cred, (ok && age < installationsTTL) || (!ok && age < installationsMinRefresh)
+}
+
+// listInstallations refreshes the installations of the GitHub App.
+func (a *githubApp) listInstallations(ctx context.Context) error {
+	const perPage = 100
+
+	type installation struct {
+		ID      int64 `json:"id"`
+		Account struct {
+			Login string `json:"login"`
+		} `json:"account"`
+	}
+
+	var installations []installation
+	for page := 1; ; page++ {
+		var res []installation
+		path := fmt.Sprintf("app/installations?per_page=%d&page=%d", perPage, page)
+		if err := a.do(ctx, "GET", path, &res); err != nil {
+			return errors.Wrap(err, "listing GitHub App installations")
+		}
+		installations = append(installations, res...)
+		if len(res) < perPage {
+			break
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	byOwner := make(map[string]*credential, len(installations))
+	for _, inst := range installations {
+		owner := strings.ToLower(inst.Account.Login)
+		// Keep the credentials of known installations, which hold their
+		// access tokens and rate limits.
+		if cred, ok := a.installations[owner]; ok && cred.source.(*installationToken).id == inst.ID {
+			byOwner[owner] = cred
+			continue
+		}
+		byOwner[owner] = newCredential(
+			"installation-"+strconv.FormatInt(inst.ID, 10),
+			&installationToken{app: a, id: inst.ID},
+			a.concurrency,
+		)
+	}
+	a.installations = byOwner
+	a.listedAt = a.now()
+	return nil
+}
+
+// do sends a request authenticated as the GitHub App and decodes the JSON
+// response into result.
+func (a *githubApp) do(ctx context.Context, method, path string, result interface{}) error {
+	token, err := a.jwt()
+	if err != nil {
+		return err
+	}
+
+	u, err := a.apiURL.Parse(path)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(method, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github.machine-man-preview+json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("unexpected status %d from %s %s: %s", resp.StatusCode, method, u.Path, body)
+	}
+	return json.Unmarshal(body, result)
+}
+
+// installationToken is a tokenSource of access tokens for an installation of
+// a GitHub App. Tokens are refreshed shortly before they expire.
+type installationToken struct {
+	app *githubApp
+	id  int64
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+func (t *installationToken) Token(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.token != "" && t.app.now().Add(tokenRefreshMargin).Before(t.expiresAt) {
+		return t.token, nil
+	}
+
+	var res struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	path := "app/installations/" + strconv.FormatInt(t.id, 10) + "/access_tokens"
+	if err := t.app.do(ctx, "POST", path, &res); err != nil {
+		return "", errors.Wrapf(err, "creating access token for installation %d", t.id)
+	}
+	t.token, t.expiresAt = res.Token, res.ExpiresAt
+	return t.token, nil
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParsePrivateKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkcs1PEM := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+	pkcs8PEM := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}))
+
+	for name, s := range map[string]string{
+		"PKCS1":        pkcs1PEM,
+		"PKCS8":        pkcs8PEM,
+		"base64 PKCS1": base64.StdEncoding.EncodeToString([]byte(pkcs1PEM)),
+	} {
+		parsed, err := parsePrivateKey(s)
+		if err != nil {
+			t.Errorf("%s: %s", name, err)
+			continue
+		}
+		if parsed.N.Cmp(key.N) != 0 {
+			t.Errorf("%s: parsed a different key", name)
+		}
+	}
+
+	if _, err := parsePrivateKey("not a key"); err == nil {
+		t.Error("expected an error for an invalid key")
+	}
+}
+
+// fakeGitHubApp serves the GitHub App endpoints for one installation on the
+// "sourcegraph" organization and verifies the JWTs it is sent.
+type fakeGitHubApp struct {
+	t   *testing.T
+	key *rsa.PublicKey
+	now time.Time
+
+	tokens       int // number of access tokens created
+	listRequests int
+	// listBlock, if set, blocks listing the installations until it is closed.
+	listBlock chan struct{}
+}
+
+func (f *fakeGitHubApp) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := f.verify(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")); err != nil {
+		f.t.Errorf("%s %s: %s", r.Method, r.URL.Path, err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.Method == "GET" && r.URL.Path == "/app/installations":
+		f.listRequests++
+		if f.listBlock != nil {
+			<-f.listBlock
+		}
+		_, _ = fmt.Fprint(w, `[{"id": 42, "account": {"login": "sourcegraph"}}]`)
+	case r.Method == "POST" && r.URL.Path == "/app/installations/42/access_tokens":
+		f.tokens++
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"token":      fmt.Sprintf("v1.%d", f.tokens),
+			"expires_at": f.now.Add(time.Hour),
+		})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeGitHubApp) verify(token string) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("malformed JWT %q", token)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(f.key, crypto.SHA256, digest[:], sig); err != nil {
+		return err
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return err
+	}
+	var claims struct {
+		Iat int64  `json:"iat"`
+		Exp int64  `json:"exp"`
+		Iss string `json:"iss"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return err
+	}
+	if claims.Iss != "1234" {
+		return fmt.Errorf("unexpected issuer %q", claims.Iss)
+	}
+	if now := f.now.Unix(); claims.Iat > now || claims.Exp <= now || claims.Exp-claims.Iat > 600 {
+		return fmt.Errorf("invalid JWT validity [%d, %d] at %d", claims.Iat, claims.Exp, now)
+	}
+	return nil
+}
+
+func TestGitHubApp(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Unix(1000000, 0)
+	fake := &fakeGitHubApp{t: t, key: &key.PublicKey, now: now}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	apiURL, _ := url.Parse(srv.URL + "/")
+	app := &githubApp{
+		id:          "1234",
+		key:         key,
+		apiURL:      apiURL,
+		client:      srv.Client(),
+		now:         func() time.Time { return fake.now },
+		concurrency: 1,
+	}
+	ctx := context.Background()
+
+	inst, err := app.installation(ctx, "SourceGraph")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inst == nil || inst.name != "installation-42" {
+		t.Fatalf("unexpected installation %+v", inst)
+	}
+
+	if inst, err := app.installation(ctx, "gorilla"); err != nil || inst != nil {
+		t.Errorf("got (%+v, %v), want no installation", inst, err)
+	}
+	if fake.listRequests != 1 {
+		t.Errorf("want installations to be cached, have %d requests", fake.listRequests)
+	}
+
+	token := func() string {
+		t.Helper()
+		tok, err := inst.source.Token(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tok
+	}
+
+	if have := token(); have != "v1.1" {
+		t.Errorf("have token %q, want v1.1", have)
+	}
+	// The token is reused until shortly before it expires.
+	fake.now = now.Add(50 * time.Minute)
+	if have := token(); have != "v1.1" {
+		t.Errorf("have token %q, want v1.1 to be reused", have)
+	}
+	fake.now = now.Add(56 * time.Minute)
+	if have := token(); have != "v1.2" {
+		t.Errorf("have token %q, want a refreshed token v1.2", have)
+	}
+
+	// Refreshing the installations keeps the credential and its token.
+	fake.now = now.Add(installationsTTL + time.Hour)
+	refreshed, err := app.installation(ctx, "sourcegraph")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refreshed != inst || fake.listRequests != 2 {
+		t.Errorf("want the installation to be refreshed and kept, have %d requests", fake.listRequests)
+	}
+}
+
+func TestGitHubApp_ConcurrentRefresh(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fake := &fakeGitHubApp{t: t, key: &key.PublicKey, now: time.Unix(1000000, 0), listBlock: make(chan struct{})}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	apiURL, _ := url.Parse(srv.URL + "/")
+	app := &githubApp{
+		id:          "1234",
+		key:         key,
+		apiURL:      apiURL,
+		client:      srv.Client(),
+		now:         func() time.Time { return fake.now },
+		concurrency: 1,
+	}
+
+	// Requests that give up don't wait for the listing.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := app.installation(ctx, "sourcegraph"); err != context.Canceled {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+
+	// Concurrent requests share the listing.
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if inst, err := app.installation(context.Background(), "sourcegraph"); err != nil || inst == nil {
+				t.Errorf("got (%+v, %v), want the installation", inst, err)
+			}
+		}()
+	}
+	close(fake.listBlock)
+	wg.Wait()
+	if fake.listRequests != 1 {
+		t.Errorf("want the installations to be listed once, have %d requests", fake.listRequests)
+	}
+}
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var secondaryRateLimitCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "src",
+	Subsystem: "githubproxy",
+	Name:      "secondary_rate_limits_total",
+	Help:      "Number of responses from GitHub that hit a secondary (abuse) rate limit.",
+}, []string{"credential"})
+
+func init() {
+	prometheus.MustRegister(secondaryRateLimitCounter)
+}
+
+const (
+	// unknownRemaining is the number of remaining requests we assume for a
+	// credential before we have seen its rate limit, so that fresh credentials
+	// are tried first.
+	unknownRemaining = 5000
+
+	// defaultRetryAfter is how long a credential isn't used after it hit a
+	// secondary rate limit without GitHub telling us when to retry.
+	defaultRetryAfter = time.Minute
+
+	// clientCredentialName is the metric label of the credentials of clients,
+	// whose requests are forwarded with their own Authorization header. They
+	// share one label to bound the cardinality of the metrics.
+	clientCredentialName = "client"
+
+	// maxClientCredentials bounds the number of client credentials we track.
+	maxClientCredentials = 1000
+)
+
+// tokenSource returns the access token to authenticate a request with.
+type tokenSource interface {
+	Token(context.Context) (string, error)
+}
+
+// staticToken is a tokenSource of a configured access token.
+type staticToken string
+
+func (t staticToken) Token(context.Context) (string, error) { return string(t), nil }
+
+// rateLimit is the state of the primary rate limit of a credential for a
+// resource, as reported by GitHub in the X-RateLimit-* response headers.
+type rateLimit struct {
+	remaining int
+	reset     time.Time
+}
+
+// credential is something requests to GitHub are authenticated with, such as
+// an access token or a GitHub App installation. It tracks its rate limits and
+// bounds the number of concurrent requests made with it.
+type credential struct {
+	// name identifies the credential in metrics and logs. It never contains a
+	// secret.
+	name string
+	// source returns the token to authenticate requests with. If it is nil,
+	// the Authorization header of the client's request is forwarded.
+	source tokenSource
+	// sem bounds the number of concurrent requests.
+	sem chan struct{}
+
+	mu     sync.Mutex
+	limits map[string]rateLimit // resource -> primary rate limit
+	// blockedUntil is when the credential may be used again after it hit a
+	// secondary rate limit.
+	blockedUntil time.Time
+}
+
+func newCredential(name string, source tokenSource, concurrency int) *credential {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &credential{
+		name:   name,
+		source: source,
+		sem:    make(chan struct{}, concurrency),
+		limits: make(map[string]rateLimit),
+	}
+}
+
+// acquire blocks until a request may be made with the credential.
+func (c *credential) acquire(ctx context.Context) error {
+	select {
+	case c.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *credential) release() { <-c.sem }
+
+// readyAt returns when the credential may be used for the resource again, which
+// is not after now if it can be used right away.
+func (c *credential) readyAt(resource string, now time.Time) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ready := c.blockedUntil
+	if l, ok := c.limits[resource]; ok && l.remaining <= 0 && now.Before(l.reset) && l.reset.After(ready) {
+		ready = l.reset
+	}
+	return ready
+}
+
+// remaining returns the number of requests for the resource that the credential
+// has left.
+func (c *credential) remaining(resource string, now time.Time) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	l, ok := c.limits[resource]
+	if !ok || !now.Before(l.reset) {
+		return unknownRemaining
+	}
+	return l.remaining
+}
+
+// observe updates the rate limits of the credential from a response to a
+// request for the resource.
+func (c *credential) observe(resource string, resp *http.Response, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	h := resp.Header
+	if r := h.Get("X-Ratelimit-Resource"); r != "" {
+		resource = r
+	}
+
+	remaining, err := strconv.Atoi(h.Get("X-Ratelimit-Remaining"))
+	if err == nil {
+		l := rateLimit{remaining: remaining}
+		if reset, err := strconv.ParseInt(h.Get("X-Ratelimit-Reset"), 10, 64); err == nil {
+			l.reset = time.Unix(reset, 0)
+		} else {
+			l.reset = now.Add(time.Hour)
+		}
+		c.limits[resource] = l
+		rateLimitRemainingGauge.WithLabelValues(resource, c.name).Set(float64(remaining))
+	}
+
+	// GitHub responds to requests that hit a secondary rate limit with 403 or
+	// 429, usually with a Retry-After header. A 403 without one is either an
+	// exhausted primary rate limit, which readyAt already accounts for, or a
+	// permission error, which we don't want to back off from.
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return
+	}
+	retryAfter := defaultRetryAfter
+	if s := h.Get("Retry-After"); s != "" {
+		if secs, err := strconv.Atoi(s); err == nil {
+			retryAfter = time.Duration(secs) * time.Second
+		}
+	} else if resp.StatusCode == http.StatusForbidden {
+		return
+	}
+	if until := now.Add(retryAfter); until.After(c.blockedUntil) {
+		c.blockedUntil = until
+	}
+	secondaryRateLimitCounter.WithLabelValues(c.name).Inc()
+}
+
+// pool holds the credentials github-proxy can authenticate requests with.
+type pool struct {
+	// shared are the configured tokens that may be used for requests to any
+	// owner.
+	shared []*credential
+	// byOwner are the configured tokens restricted to an organization or
+	// user, by lower-cased login.
+	byOwner map[string][]*credential
+	// tokens is the set of all configured tokens.
+	tokens map[string]bool
+	// app is the GitHub App to authenticate as, if configured.
+	app *githubApp
+
+	// concurrency is the maximum number of concurrent requests per credential.
+	concurrency int
+
+	mu      sync.Mutex
+	clients map[[sha256.Size]byte]*credential // hash of Authorization header -> credential
+}
+
+func newPool(concurrency int) *pool {
+	return &pool{
+		byOwner:     make(map[string][]*credential),
+		tokens:      make(map[string]bool),
+		concurrency: concurrency,
+		clients:     make(map[[sha256.Size]byte]*credential),
+	}
+}
+
+// addTokens adds the tokens in the given comma-separated list to the pool. A
+// token of the form "owner:token" is only used for requests to the
+// organization or user with that login.
+func (p *pool) addTokens(list string) {
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		var owner string
+		token := entry
+		if i := strings.LastIndex(entry, ":"); i >= 0 {
+			owner, token = strings.ToLower(entry[:i]), entry[i+1:]
+		}
+		if p.tokens[token] {
+			continue
+		}
+		p.tokens[token] = true
+
+		cred := newCredential("token-"+strconv.Itoa(len(p.tokens)), staticToken(token), p.concurrency)
+		if owner != "" {
+			p.byOwner[owner] = append(p.byOwner[owner], cred)
+		} else {
+			p.shared = append(p.shared, cred)
+		}
+	}
+}
+
+// empty reports whether no tokens and no GitHub App are configured.
+func (p *pool) empty() bool {
+	return len(p.tokens) == 0 && p.app == nil
+}
+
+// credentialFor returns the credential to authenticate the given request for
+// the resource with.
+//
+// 🚨 SECURITY: Only requests authenticated with a pool token are spread across
+// the pool. Anonymous requests are forwarded without credentials, because the
+// pool's credentials may be able to read private repositories that the client
+// can't. Requests authenticated with any other token, such as a user's OAuth
+// token used to check their repository permissions, are always forwarded with
+// that token.
+func (p *pool) credentialFor(ctx context.Context, r *http.Request, resource string, now time.Time) *credential {
+	auth := r.Header.Get("Authorization")
+	if p.empty() || auth == "" || !p.tokens[tokenOf(auth)] {
+		return p.client(auth, now)
+	}
+
+	owner := ownerOf(r.URL.Path)
+
+	var candidates []*credential
+	if owner != "" && p.app != nil {
+		inst, err := p.app.installation(ctx, owner)
+		if err != nil {
+			log15.Warn("github-proxy: listing GitHub App installations failed", "error", err)
+		}
+		if inst != nil {
+			candidates = append(candidates, inst)
+		}
+	}
+	candidates = append(candidates, p.byOwner[strings.ToLower(owner)]...)
+	candidates = append(candidates, p.shared...)
+
+	if len(candidates) == 0 {
+		return p.client(auth, now)
+	}
+	return pick(candidates, resource, now)
+}
+
+// client returns the credential of clients sending the given Authorization
+// header, whose requests are forwarded as is.
+func (p *pool) client(auth string, now time.Time) *credential {
+	key := sha256.Sum256([]byte(auth))
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if cred, ok := p.clients[key]; ok {
+		return cred
+	}
+
+	if len(p.clients) >= maxClientCredentials {
+		// Forget the credentials that aren't in use and don't need to be
+		// held back.
+		for k, cred := range p.clients {
+			if len(cred.sem) == 0 && !cred.readyAt("core", now).After(now) {
+				delete(p.clients, k)
+			}
+		}
+	}
+
+	cred := newCredential(clientCredentialName, nil, p.concurrency)
+	p.clients[key] = cred
+	return cred
+}
+
+// pick returns the credential to use for a request for the resource. It prefers
+// credentials with the most remaining requests, and then those with the fewest
+// requests in flight. If all credentials are rate limited, the one that may be
+// used again first is returned.
+func pick(candidates []*credential, resource string, now time.Time) *credential {
+	var (
+		best          *credential
+		bestRemaining int
+		bestInFlight  int
+
+		earliest      *credential
+		earliestReady time.Time
+	)
+	for _, c := range candidates {
+		if ready := c.readyAt(resource, now); ready.After(now) {
+			if earliest == nil || ready.Before(earliestReady) {
+				earliest, earliestReady = c, ready
+			}
+			continue
+		}
+
+		remaining, inFlight := c.remaining(resource, now), len(c.sem)
+		if best == nil || remaining > bestRemaining || (remaining == bestRemaining && inFlight < bestInFlight) {
+			best, bestRemaining, bestInFlight = c, remaining, inFlight
+		}
+	}
+	if best == nil {
+		return earliest
+	}
+	return best
+}
+
+// tokenOf returns the token in the value of an Authorization header.
+func tokenOf(auth string) string {
+	if i := strings.IndexByte(auth, ' '); i >= 0 {
+		switch strings.ToLower(auth[:i]) {
+		case "token", "bearer":
+			return strings.TrimSpace(auth[i+1:])
+		}
+	}
+	return ""
+}
+
+// ownerOf returns the login of the organization or user that a request to the
+// given GitHub API path is about, or "" if it isn't about one, like GraphQL
+// and search requests.
+func ownerOf(path string) string {
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(parts) < 2 {
+		return ""
+	}
+	switch parts[0] {
+	case "repos", "orgs", "users":
+		return parts[1]
+	}
+	return ""
+}
+
+// resourceOf returns the rate limit resource of a request to the given GitHub API
+// path.
+func resourceOf(path string) string {
+	switch {
+	case strings.HasPrefix(path, "/search/"):
+		return "search"
+	case path == "/graphql":
+		return "graphql"
+	}
+	return "core"
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOwnerOf(t *testing.T) {
+	for path, want := range map[string]string{
+		"/repos/sourcegraph/sourcegraph":       "sourcegraph",
+		"/repos/sourcegraph/sourcegraph/pulls": "sourcegraph",
+		"/orgs/sourcegraph/repos":              "sourcegraph",
+		"/users/alice":                         "alice",
+		"/user/repos":                          "",
+		"/graphql":                             "",
+		"/search/repositories":                 "",
+		"/":                                    "",
+	} {
+		if have := ownerOf(path); have != want {
+			t.Errorf("ownerOf(%q): have %q, want %q", path, have, want)
+		}
+	}
+}
+
+func TestCredential_Observe(t *testing.T) {
+	now := time.Unix(1000, 0)
+	response := func(status int, headers ...string) *http.Response {
+		resp := &http.Response{StatusCode: status, Header: make(http.Header)}
+		for i := 0; i < len(headers); i += 2 {
+			resp.Header.Set(headers[i], headers[i+1])
+		}
+		return resp
+	}
+
+	t.Run("primary rate limit", func(t *testing.T) {
+		c := newCredential("test", staticToken("t"), 1)
+		if have := c.remaining("core", now); have != unknownRemaining {
+			t.Errorf("remaining before any response: have %d", have)
+		}
+
+		c.observe("core", response(200, "X-Ratelimit-Remaining", "0", "X-Ratelimit-Reset", "1100"), now)
+		if have := c.remaining("core", now); have != 0 {
+			t.Errorf("remaining: have %d, want 0", have)
+		}
+		if have := c.readyAt("core", now); !have.Equal(time.Unix(1100, 0)) {
+			t.Errorf("readyAt: have %v", have)
+		}
+		// Other resources have their own limits.
+		if have := c.readyAt("search", now); have.After(now) {
+			t.Errorf("readyAt(search): have %v", have)
+		}
+		// The limit is reset after the reset time.
+		later := time.Unix(1100, 0)
+		if have := c.readyAt("core", later); have.After(later) {
+			t.Errorf("readyAt after reset: have %v", have)
+		}
+		if have := c.remaining("core", later); have != unknownRemaining {
+			t.Errorf("remaining after reset: have %d", have)
+		}
+	})
+
+	t.Run("resource header", func(t *testing.T) {
+		c := newCredential("test", staticToken("t"), 1)
+		c.observe("core", response(200, "X-Ratelimit-Resource", "search", "X-Ratelimit-Remaining", "7", "X-Ratelimit-Reset", "1100"), now)
+		if have := c.remaining("search", now); have != 7 {
+			t.Errorf("remaining(search): have %d, want 7", have)
+		}
+	})
+
+	t.Run("secondary rate limit", func(t *testing.T) {
+		c := newCredential("test", staticToken("t"), 1)
+		c.observe("core", response(403, "Retry-After", "30"), now)
+		if have := c.readyAt("core", now); !have.Equal(now.Add(30 * time.Second)) {
+			t.Errorf("readyAt: have %v", have)
+		}
+
+		c = newCredential("test", staticToken("t"), 1)
+		c.observe("core", response(429), now)
+		if have := c.readyAt("search", now); !have.Equal(now.Add(defaultRetryAfter)) {
+			t.Errorf("readyAt after 429: have %v", have)
+		}
+	})
+
+	t.Run("permission error", func(t *testing.T) {
+		c := newCredential("test", staticToken("t"), 1)
+		c.observe("core", response(403), now)
+		if have := c.readyAt("core", now); have.After(now) {
+			t.Errorf("readyAt: have %v, want no backoff", have)
+		}
+	})
+}
+
+func TestPick(t *testing.T) {
+	now := time.Unix(1000, 0)
+	limited := func(name string, remaining int, reset int64) *credential {
+		c := newCredential(name, staticToken(name), 2)
+		c.limits["core"] = rateLimit{remaining: remaining, reset: time.Unix(reset, 0)}
+		return c
+	}
+
+	a, b := limited("a", 10, 2000), limited("b", 20, 2000)
+	if have := pick([]*credential{a, b}, "core", now); have != b {
+		t.Errorf("have %s, want the credential with the most remaining requests", have.name)
+	}
+
+	a, b = limited("a", 10, 2000), limited("b", 10, 2000)
+	a.sem <- struct{}{}
+	if have := pick([]*credential{a, b}, "core", now); have != b {
+		t.Errorf("have %s, want the credential with the fewest requests in flight", have.name)
+	}
+
+	a, b = limited("a", 0, 1500), limited("b", 0, 1200)
+	if have := pick([]*credential{a, b}, "core", now); have != b {
+		t.Errorf("have %s, want the credential that resets first", have.name)
+	}
+
+	a, b = limited("a", 0, 1500), limited("b", 1, 2000)
+	b.blockedUntil = now.Add(time.Minute)
+	if have := pick([]*credential{a, b}, "core", now); have != b {
+		t.Errorf("have %s, want the credential that is blocked for less long", have.name)
+	}
+}
+
+// fakeGitHub is a GitHub API that gives every token a rate limit of limit
+// requests. It records the Authorization header of each request.
+type fakeGitHub struct {
+	limit int
+
+	mu    sync.Mutex
+	used  map[string]int
+	auths []string
+}
+
+func (f *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	auth := r.Header.Get("Authorization")
+	f.auths = append(f.auths, auth)
+	f.used[auth]++
+
+	remaining := f.limit - f.used[auth]
+	if remaining < 0 {
+		remaining = 0
+	}
+	w.Header().Set("X-Ratelimit-Remaining", strconv.Itoa(remaining))
+	w.Header().Set("X-Ratelimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+	if f.used[auth] > f.limit {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	_, _ = w.Write([]byte("{}"))
+}
+
+func (f *fakeGitHub) requests() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	auths := f.auths
+	f.auths = nil
+	return auths
+}
+
+func TestProxy(t *testing.T) {
+	gh := &fakeGitHub{limit: 2, used: make(map[string]int)}
+	srv := httptest.NewServer(gh)
+	defer srv.Close()
+	apiURL, _ := url.Parse(srv.URL)
+
+	p := newPool(1)
+	p.addTokens("a, b, sourcegraph:c")
+	h := newProxy(srv.Client(), apiURL, p, 4)
+
+	get := func(path, auth string) int {
+		req := httptest.NewRequest("GET", path, nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	t.Run("rotation", func(t *testing.T) {
+		for i := 0; i < 4; i++ {
+			if code := get("/repos/gorilla/mux", "token a"); code != http.StatusOK {
+				t.Fatalf("request %d: status %d", i, code)
+			}
+		}
+		used := map[string]int{}
+		for _, auth := range gh.requests() {
+			used[auth]++
+		}
+		if used["token a"] != 2 || used["token b"] != 2 {
+			t.Errorf("want requests spread across the shared tokens, have %v", used)
+		}
+	})
+
+	t.Run("owner", func(t *testing.T) {
+		if code := get("/repos/SourceGraph/sourcegraph", "token b"); code != http.StatusOK {
+			t.Fatalf("status %d", code)
+		}
+		if have := gh.requests(); len(have) != 1 || have[0] != "token c" {
+			t.Errorf("want the token of the owner to be used, have %v", have)
+		}
+	})
+
+	t.Run("anonymous", func(t *testing.T) {
+		get("/repos/sourcegraph/sourcegraph", "")
+		get("/repos/gorilla/mux", "")
+		if have := gh.requests(); len(have) != 2 || have[0] != "" || have[1] != "" {
+			t.Errorf("want anonymous requests to be forwarded without credentials, have %v", have)
+		}
+	})
+
+	t.Run("client token", func(t *testing.T) {
+		get("/repos/sourcegraph/sourcegraph", "token user-oauth-token")
+		if have := gh.requests(); len(have) != 1 || have[0] != "token user-oauth-token" {
+			t.Errorf("want the client's token to be forwarded, have %v", have)
+		}
+	})
+}
+
+func TestProxy_BusyCredential(t *testing.T) {
+	started := make(chan struct{}, 1)
+	unblock := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "token c" {
+			started <- struct{}{}
+			<-unblock
+		}
+	}))
+	defer srv.Close()
+	apiURL, _ := url.Parse(srv.URL)
+
+	p := newPool(1)
+	p.addTokens("sourcegraph:c, gorilla:d")
+	h := newProxy(srv.Client(), apiURL, p, 2)
+
+	get := func(path string) <-chan int {
+		code := make(chan int, 1)
+		go func() {
+			req := httptest.NewRequest("GET", path, nil)
+			req.Header.Set("Authorization", "token c")
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			code <- rec.Code
+		}()
+		return code
+	}
+
+	// The first request holds the credential of sourcegraph and the second
+	// one waits for it, which must not keep other credentials from being
+	// used.
+	first := get("/repos/sourcegraph/sourcegraph")
+	<-started
+	second := get("/repos/sourcegraph/about")
+	time.Sleep(50 * time.Millisecond)
+
+	select {
+	case code := <-get("/repos/gorilla/mux"):
+		if code != http.StatusOK {
+			t.Errorf("status %d", code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("request with an idle credential waited for a busy one")
+	}
+
+	close(unblock)
+	if code := <-first; code != http.StatusOK {
+		t.Errorf("status %d", code)
+	}
+	if code := <-second; code != http.StatusOK {
+		t.Errorf("status %d", code)
+	}
+}
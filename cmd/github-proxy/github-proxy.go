@@ -11,8 +11,6 @@ import (
 	"os"
 	"os/signal"
 	"strconv"
-	"strings"
-	"sync"
 	"syscall"
 	"time"
 
@@ -26,23 +24,27 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/tracer"
 )
 
-var logRequests, _ = strconv.ParseBool(env.Get("LOG_REQUESTS", "", "log HTTP requests"))
+var (
+	logRequests, _ = strconv.ParseBool(env.Get("LOG_REQUESTS", "", "log HTTP requests"))
 
-const port = "3180"
+	tokens        = env.Get("GITHUB_PROXY_TOKENS", "", "comma-separated list of GitHub access tokens to spread requests across. A token of the form owner:token is only used for requests to that organization or user.")
+	appID         = env.Get("GITHUB_APP_ID", "", "ID of the GitHub App to authenticate requests as")
+	appPrivateKey = env.Get("GITHUB_APP_PRIVATE_KEY", "", "PEM-encoded (optionally base64-encoded) private key of the GitHub App")
+
+	maxConcurrency, _              = strconv.Atoi(env.Get("GITHUB_PROXY_MAX_CONCURRENCY", "10", "maximum number of concurrent requests to GitHub"))
+	maxConcurrencyPerCredential, _ = strconv.Atoi(env.Get("GITHUB_PROXY_MAX_CONCURRENCY_PER_CREDENTIAL", "1", "maximum number of concurrent requests to GitHub per token or GitHub App installation"))
+)
 
-// requestMu ensures we only do one request at a time to prevent tripping abuse detection.
-var requestMu sync.Mutex
+const port = "3180"
 
 var rateLimitRemainingGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 	Namespace: "src",
 	Subsystem: "github",
 	Name:      "rate_limit_remaining",
 	Help:      "Number of calls to GitHub's API remaining before hitting the rate limit.",
-}, []string{"resource"})
+}, []string{"resource", "credential"})
 
 func init() {
-	rateLimitRemainingGauge.WithLabelValues("core").Set(5000)
-	rateLimitRemainingGauge.WithLabelValues("search").Set(30)
 	prometheus.MustRegister(rateLimitRemainingGauge)
 }
 
@@ -80,61 +82,26 @@ func main() {
 		IdleConnTimeout: 30 * time.Second,
 	}}
 
-	var h http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		q2 := r.URL.Query()
-		h2 := make(http.Header)
-		for k, v := range r.Header {
-			if _, found := hopHeaders[k]; !found {
-				h2[k] = v
-			}
-		}
+	apiURL, _ := url.Parse("https://api.github.com/")
 
-		req2 := &http.Request{
-			Method: r.Method,
-			Body:   r.Body,
-			URL: &url.URL{
-				Scheme:   "https",
-				Host:     "api.github.com",
-				Path:     r.URL.Path,
-				RawQuery: q2.Encode(),
-			},
-			Header: h2,
-		}
-
-		requestMu.Lock()
-		resp, err := client.Do(req2)
-		requestMu.Unlock()
+	p := newPool(maxConcurrencyPerCredential)
+	p.addTokens(tokens)
+	if appID != "" {
+		key, err := parsePrivateKey(appPrivateKey)
 		if err != nil {
-			log15.Warn("proxy error", "err", err)
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
+			log.Fatalf("github-proxy: invalid GITHUB_APP_PRIVATE_KEY: %s", err)
 		}
-		defer resp.Body.Close()
-
-		if limit := resp.Header.Get("X-Ratelimit-Remaining"); limit != "" {
-			limit, _ := strconv.Atoi(limit)
-
-			resource := "core"
-			if strings.HasPrefix(r.URL.Path, "/search/") {
-				resource = "search"
-			} else if r.URL.Path == "/graphql" {
-				resource = "graphql"
-			}
-			rateLimitRemainingGauge.WithLabelValues(resource).Set(float64(limit))
+		p.app = &githubApp{
+			id:          appID,
+			key:         key,
+			apiURL:      apiURL,
+			client:      client,
+			now:         time.Now,
+			concurrency: maxConcurrencyPerCredential,
 		}
+	}
 
-		for k, v := range resp.Header {
-			w.Header()[k] = v
-		}
-		w.WriteHeader(resp.StatusCode)
-		if resp.StatusCode < 400 || !logRequests {
-			_, _ = io.Copy(w, resp.Body)
-			return
-		}
-		b, err := ioutil.ReadAll(resp.Body)
-		log15.Warn("proxy error", "status", resp.StatusCode, "body", string(b), "bodyErr", err)
-		_, _ = io.Copy(w, bytes.NewReader(b))
-	})
+	var h http.Handler = newProxy(client, apiURL, p, maxConcurrency)
 	if logRequests {
 		h = handlers.LoggingHandler(os.Stdout, h)
 	}
@@ -150,6 +117,106 @@ func main() {
 	log.Fatal(http.ListenAndServe(addr, nil))
 }
 
+// proxy forwards requests to the GitHub API, authenticating them with the
+// credentials of its pool.
+type proxy struct {
+	client *http.Client
+	apiURL *url.URL
+	pool   *pool
+	// sem bounds the number of concurrent requests to GitHub, to prevent
+	// tripping its abuse detection.
+	sem chan struct{}
+	now func() time.Time
+}
+
+func newProxy(client *http.Client, apiURL *url.URL, p *pool, concurrency int) *proxy {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &proxy{
+		client: client,
+		apiURL: apiURL,
+		pool:   p,
+		sem:    make(chan struct{}, concurrency),
+		now:    time.Now,
+	}
+}
+
+func (p *proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Acquire the credential before a slot of the global limit, so that
+	// requests waiting for a busy credential don't hold slots that requests
+	// with other credentials could use.
+	resource := resourceOf(r.URL.Path)
+	cred := p.pool.credentialFor(ctx, r, resource, p.now())
+	if err := cred.acquire(ctx); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer cred.release()
+
+	select {
+	case p.sem <- struct{}{}:
+		defer func() { <-p.sem }()
+	case <-ctx.Done():
+		http.Error(w, ctx.Err().Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	q2 := r.URL.Query()
+	h2 := make(http.Header)
+	for k, v := range r.Header {
+		if _, found := hopHeaders[k]; !found {
+			h2[k] = v
+		}
+	}
+	if cred.source != nil {
+		token, err := cred.source.Token(ctx)
+		if err != nil {
+			log15.Warn("proxy error", "credential", cred.name, "err", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		h2.Set("Authorization", "token "+token)
+	}
+
+	req2 := &http.Request{
+		Method: r.Method,
+		Body:   r.Body,
+		URL: &url.URL{
+			Scheme:   p.apiURL.Scheme,
+			Host:     p.apiURL.Host,
+			Path:     r.URL.Path,
+			RawQuery: q2.Encode(),
+		},
+		Header: h2,
+	}
+	req2 = req2.WithContext(ctx)
+
+	resp, err := p.client.Do(req2)
+	if err != nil {
+		log15.Warn("proxy error", "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer resp.Body.Close()
+
+	cred.observe(resource, resp, p.now())
+
+	for k, v := range resp.Header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(resp.StatusCode)
+	if resp.StatusCode < 400 || !logRequests {
+		_, _ = io.Copy(w, resp.Body)
+		return
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	log15.Warn("proxy error", "status", resp.StatusCode, "credential", cred.name, "body", string(b), "bodyErr", err)
+	_, _ = io.Copy(w, bytes.NewReader(b))
+}
+
 func instrumentHandler(r prometheus.Registerer, h http.Handler) http.Handler {
 	var (
 		inFlightGauge = prometheus.NewGauge(prometheus.GaugeOpts{
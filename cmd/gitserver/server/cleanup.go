@@ -105,6 +105,15 @@ func (s *Server) cleanupRepos() {
 				reason = fmt.Sprintf("git gc %s", string(bytes.TrimSpace(gclog)))
 			}
 		}
+		// git cannot convert a clone between modes in place, so we reclone
+		// repositories whose configured clone mode changed.
+		if reason == "" && !useRefspecOverrides() {
+			if changed, err := cloneModeChanged(bCtx, dir); err != nil {
+				log15.Warn("failed to determine if clone mode changed", "repo", dir, "error", err)
+			} else if changed {
+				reason = "clone mode changed"
+			}
+		}
 		if reason == "" {
 			return false, nil
 		}
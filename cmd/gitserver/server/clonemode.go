@@ -0,0 +1,225 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// cloneMode describes how a repository is cloned and fetched.
+type cloneMode struct {
+	Mode protocol.CloneMode
+
+	// Depth is the number of commits to fetch. It is only set for
+	// protocol.CloneModeShallow.
+	Depth int
+}
+
+var fullCloneMode = cloneMode{Mode: protocol.CloneModeFull}
+
+var gitCloneModes = conf.Cached(func() interface{} {
+	var c []*schema.GitCloneModeMapping
+	if ef := conf.Get().ExperimentalFeatures; ef != nil {
+		c = ef.GitCloneModes
+	}
+	return buildCloneModeMappings(c)
+})
+
+func buildCloneModeMappings(c []*schema.GitCloneModeMapping) map[string]cloneMode {
+	m := make(map[string]cloneMode, len(c))
+	for _, mapping := range c {
+		mode := cloneMode{Mode: protocol.CloneMode(mapping.Mode)}
+		switch mode.Mode {
+		case protocol.CloneModeFull, protocol.CloneModeBlobless:
+		case protocol.CloneModeShallow:
+			mode.Depth = mapping.Depth
+			if mode.Depth <= 0 {
+				mode.Depth = 1
+			}
+		default:
+			continue
+		}
+		m[strings.Trim(mapping.DomainPath, "/")] = mode
+	}
+	return m
+}
+
+// configuredCloneMode returns the clone mode configured for the repository
+// with the given clone URL. The mapping with the longest domain/path prefix
+// of the clone URL wins. Repositories without a mapping are fully cloned.
+func configuredCloneMode(cloneURL string) cloneMode {
+	m := gitCloneModes().(map[string]cloneMode)
+	if len(m) == 0 {
+		return fullCloneMode
+	}
+
+	dp, err := extractDomainPath(cloneURL)
+	if err != nil {
+		return fullCloneMode
+	}
+
+	for p := strings.TrimSuffix(strings.Trim(dp, "/"), ".git"); p != "" && p != "."; p = path.Dir(p) {
+		if mode, ok := m[p]; ok {
+			return mode
+		}
+	}
+	return fullCloneMode
+}
+
+// fetchArgs returns the arguments to pass to git clone and git fetch for the
+// clone mode.
+func (m cloneMode) fetchArgs() []string {
+	switch m.Mode {
+	case protocol.CloneModeBlobless:
+		return []string{"--filter=blob:none"}
+	case protocol.CloneModeShallow:
+		return []string{"--depth=" + strconv.Itoa(m.Depth)}
+	default:
+		return nil
+	}
+}
+
+// setRepoCloneMode records the mode the repository at dir was cloned with.
+func setRepoCloneMode(dir GitDir, m cloneMode) error {
+	if err := gitConfigSet(dir, "sourcegraph.cloneMode", string(m.Mode)); err != nil {
+		return err
+	}
+	if m.Mode != protocol.CloneModeShallow {
+		return gitConfigUnset(dir, "sourcegraph.cloneDepth")
+	}
+	return gitConfigSet(dir, "sourcegraph.cloneDepth", strconv.Itoa(m.Depth))
+}
+
+// getRepoCloneMode returns the mode the repository at dir was cloned with.
+// Repositories cloned before clone modes were recorded are full clones.
+func getRepoCloneMode(dir GitDir) (cloneMode, error) {
+	value, err := gitConfigGet(dir, "sourcegraph.cloneMode")
+	if err != nil {
+		return fullCloneMode, err
+	}
+
+	m := cloneMode{Mode: protocol.CloneMode(strings.TrimSpace(value))}
+	switch m.Mode {
+	case "":
+		return fullCloneMode, nil
+	case protocol.CloneModeShallow:
+		value, err := gitConfigGet(dir, "sourcegraph.cloneDepth")
+		if err != nil {
+			return fullCloneMode, err
+		}
+		if m.Depth, err = strconv.Atoi(strings.TrimSpace(value)); err != nil || m.Depth <= 0 {
+			m.Depth = 1
+		}
+	}
+	return m, nil
+}
+
+// isPartialClone reports whether the repository at dir is a partial clone,
+// i.e. objects missing from it are fetched from its remote on demand. It
+// reads the git config file directly since it is called for every exec
+// request.
+func isPartialClone(dir GitDir) bool {
+	b, err := ioutil.ReadFile(dir.Path("config"))
+	if err != nil {
+		return false
+	}
+	return bytes.Contains(bytes.ToLower(b), []byte("partialclone"))
+}
+
+// maxPrefetchArgs is the maximum number of objects fetched per git fetch
+// command by prefetchMissingBlobs.
+const maxPrefetchArgs = 1000
+
+// prefetchMissingBlobs fetches the blobs below treeish and paths which are
+// missing from the partial clone at dir in batches. Without prefetching, git
+// fetches every missing blob with a separate request to the remote as it
+// encounters them, which is very slow for commands like git archive.
+func prefetchMissingBlobs(ctx context.Context, dir GitDir, treeish string, paths []string) error {
+	objects := []string{treeish}
+	if len(paths) > 0 {
+		objects = objects[:0]
+		for _, p := range paths {
+			objects = append(objects, treeish+":"+p)
+		}
+	}
+
+	// --missing=print lists missing objects prefixed with "?" instead of
+	// fetching them.
+	cmd := exec.CommandContext(ctx, "git", append([]string{"rev-list", "--objects", "--no-walk", "--missing=print"}, objects...)...)
+	cmd.Dir = string(dir)
+	out, err := cmd.Output()
+	if err != nil {
+		return errors.Wrap(wrapCmdError(cmd, err), "listing missing objects")
+	}
+
+	var missing []string
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, "?") {
+			missing = append(missing, line[1:])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	for len(missing) > 0 {
+		n := len(missing)
+		if n > maxPrefetchArgs {
+			n = maxPrefetchArgs
+		}
+
+		// These are the same arguments git uses when it lazily fetches
+		// missing objects from the promisor remote.
+		args := []string{"-c", "fetch.negotiationAlgorithm=noop", "fetch", "--no-tags", "--recurse-submodules=no", "--filter=blob:none", "origin"}
+		cmd := exec.CommandContext(ctx, "git", append(args, missing[:n]...)...)
+		cmd.Dir = string(dir)
+		if output, err := runWithRemoteOpts(ctx, cmd, nil); err != nil {
+			return errors.Wrapf(err, "fetching missing objects. Output: %s", string(output))
+		}
+		missing = missing[n:]
+	}
+	return nil
+}
+
+// archiveTreeishAndPaths returns the treeish and paths of the git archive
+// command with the given arguments, which end with "<treeish> -- [paths...]".
+func archiveTreeishAndPaths(args []string) (treeish string, paths []string, ok bool) {
+	if len(args) == 0 || args[0] != "archive" {
+		return "", nil, false
+	}
+	for i := 2; i < len(args); i++ {
+		if args[i] == "--" {
+			return args[i-1], args[i+1:], true
+		}
+	}
+	return "", nil, false
+}
+
+// cloneModeChanged reports whether the repository at dir was cloned with a
+// different mode than the one currently configured for it.
+func cloneModeChanged(ctx context.Context, dir GitDir) (bool, error) {
+	current, err := getRepoCloneMode(dir)
+	if err != nil {
+		return false, err
+	}
+	if current == fullCloneMode && len(gitCloneModes().(map[string]cloneMode)) == 0 {
+		return false, nil
+	}
+
+	remoteURL, err := repoRemoteURL(ctx, dir)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to get remote URL")
+	}
+	return configuredCloneMode(remoteURL) != current, nil
+}
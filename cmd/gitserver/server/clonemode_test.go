@@ -0,0 +1,222 @@
+package server
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/mutablelimiter"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestConfiguredCloneMode(t *testing.T) {
+	mappings := []*schema.GitCloneModeMapping{
+		{DomainPath: "github.com", Mode: "blobless"},
+		{DomainPath: "github.com/foo", Mode: "shallow"},
+		{DomainPath: "github.com/foo/full", Mode: "full"},
+		{DomainPath: "github.com/bar/deep/", Mode: "shallow", Depth: 10},
+		{DomainPath: "gitlab.com", Mode: "invalid"},
+	}
+
+	orig := gitCloneModes
+	gitCloneModes = func() interface{} {
+		return buildCloneModeMappings(mappings)
+	}
+	defer func() { gitCloneModes = orig }()
+
+	tests := []struct {
+		url  string
+		want cloneMode
+	}{
+		{
+			url:  "https://github.com/baz/qux",
+			want: cloneMode{Mode: protocol.CloneModeBlobless},
+		},
+		{
+			url:  "https://token@github.com/foo/qux.git",
+			want: cloneMode{Mode: protocol.CloneModeShallow, Depth: 1},
+		},
+		{
+			url:  "https://github.com/foo/full",
+			want: cloneMode{Mode: protocol.CloneModeFull},
+		},
+		{
+			url:  "https://github.com/foo/fuller",
+			want: cloneMode{Mode: protocol.CloneModeShallow, Depth: 1},
+		},
+		{
+			url:  "https://github.com/bar/deep",
+			want: cloneMode{Mode: protocol.CloneModeShallow, Depth: 10},
+		},
+		{
+			url:  "https://gitlab.com/foo/bar",
+			want: fullCloneMode,
+		},
+		{
+			url:  "git@github.com:foo/bar.git",
+			want: fullCloneMode,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.url, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, configuredCloneMode(tc.url)); diff != "" {
+				t.Errorf("unexpected clone mode (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestArchiveTreeishAndPaths(t *testing.T) {
+	tests := []struct {
+		args        []string
+		wantTreeish string
+		wantPaths   []string
+		wantOK      bool
+	}{
+		{
+			args:        []string{"archive", "--worktree-attributes", "--format=zip", "-0", "HEAD", "--"},
+			wantTreeish: "HEAD",
+			wantPaths:   []string{},
+			wantOK:      true,
+		},
+		{
+			args:        []string{"archive", "--format=tar", "abc", "--", "a", "b/c"},
+			wantTreeish: "abc",
+			wantPaths:   []string{"a", "b/c"},
+			wantOK:      true,
+		},
+		{
+			args: []string{"archive", "--", "a"},
+		},
+		{
+			args: []string{"log", "HEAD", "--"},
+		},
+	}
+	for _, tc := range tests {
+		treeish, paths, ok := archiveTreeishAndPaths(tc.args)
+		if treeish != tc.wantTreeish || ok != tc.wantOK || !cmp.Equal(paths, tc.wantPaths) {
+			t.Errorf("archiveTreeishAndPaths(%q) = (%q, %q, %v), want (%q, %q, %v)", tc.args, treeish, paths, ok, tc.wantTreeish, tc.wantPaths, tc.wantOK)
+		}
+	}
+}
+
+func TestCloneRepo_blobless(t *testing.T) {
+	remote, cleanup1 := tmpDir(t)
+	defer cleanup1()
+
+	repo := remote
+	cmd := func(name string, arg ...string) string {
+		t.Helper()
+		c := exec.Command(name, arg...)
+		c.Dir = repo
+		c.Env = []string{
+			"GIT_COMMITTER_NAME=a",
+			"GIT_COMMITTER_EMAIL=a@a.com",
+			"GIT_AUTHOR_NAME=a",
+			"GIT_AUTHOR_EMAIL=a@a.com",
+		}
+		b, err := c.CombinedOutput()
+		if err != nil {
+			t.Fatalf("%s %s failed: %s: %s", name, strings.Join(arg, " "), err, b)
+		}
+		return strings.TrimSpace(string(b))
+	}
+
+	cmd("git", "init", ".")
+	cmd("git", "config", "uploadpack.allowFilter", "true")
+	cmd("git", "config", "uploadpack.allowAnySHA1InWant", "true")
+	cmd("sh", "-c", "echo hello world > hello.txt")
+	cmd("mkdir", "dir")
+	cmd("sh", "-c", "echo goodbye > dir/goodbye.txt")
+	cmd("git", "add", "hello.txt", "dir/goodbye.txt")
+	cmd("git", "commit", "-m", "hello")
+	helloBlob := cmd("git", "rev-parse", "HEAD:hello.txt")
+	goodbyeBlob := cmd("git", "rev-parse", "HEAD:dir/goodbye.txt")
+
+	remoteURL := "file://" + filepath.ToSlash(remote)
+	orig := gitCloneModes
+	gitCloneModes = func() interface{} {
+		return buildCloneModeMappings([]*schema.GitCloneModeMapping{
+			{DomainPath: filepath.ToSlash(remote), Mode: "blobless"},
+		})
+	}
+	defer func() { gitCloneModes = orig }()
+
+	reposDir, cleanup2 := tmpDir(t)
+	defer cleanup2()
+
+	s := &Server{
+		ReposDir:         reposDir,
+		ctx:              context.Background(),
+		locker:           &RepositoryLocker{},
+		cloneLimiter:     mutablelimiter.New(1),
+		cloneableLimiter: mutablelimiter.New(1),
+	}
+	_, err := s.cloneRepo(context.Background(), "example.com/foo/bar", remoteURL, &cloneOptions{Block: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := s.dir(api.RepoName("example.com/foo/bar"))
+	if !isPartialClone(dst) {
+		t.Fatal("expected a partial clone")
+	}
+	mode, err := getRepoCloneMode(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (cloneMode{Mode: protocol.CloneModeBlobless}); mode != want {
+		t.Fatalf("got clone mode %+v, want %+v", mode, want)
+	}
+	if changed, err := cloneModeChanged(context.Background(), dst); err != nil || changed {
+		t.Fatalf("got cloneModeChanged() = (%v, %v), want (false, nil)", changed, err)
+	}
+
+	repo = string(dst)
+	hasObject := func(oid string) bool {
+		// cat-file would lazily fetch missing objects, so we list them
+		// instead.
+		return !strings.Contains(cmd("git", "rev-list", "--objects", "--no-walk", "--missing=print", "HEAD"), "?"+oid)
+	}
+	if hasObject(helloBlob) || hasObject(goodbyeBlob) {
+		t.Fatal("expected blobs to be missing after a blobless clone")
+	}
+
+	if err := prefetchMissingBlobs(context.Background(), dst, "HEAD", []string{"dir"}); err != nil {
+		t.Fatal(err)
+	}
+	if hasObject(helloBlob) || !hasObject(goodbyeBlob) {
+		t.Fatal("expected only the blobs below dir to be fetched")
+	}
+
+	if err := prefetchMissingBlobs(context.Background(), dst, "HEAD", nil); err != nil {
+		t.Fatal(err)
+	}
+	if !hasObject(helloBlob) {
+		t.Fatal("expected all blobs to be fetched")
+	}
+
+	// Fetches keep the clone partial.
+	repo = remote
+	cmd("sh", "-c", "echo hello again > hello.txt")
+	cmd("git", "commit", "-am", "hello again")
+	wantCommit := cmd("git", "rev-parse", "HEAD")
+	newBlob := cmd("git", "rev-parse", "HEAD:hello.txt")
+
+	if err := s.doRepoUpdate2("example.com/foo/bar", remoteURL); err != nil {
+		t.Fatal(err)
+	}
+
+	repo = string(dst)
+	if gotCommit := cmd("git", "rev-parse", "HEAD"); gotCommit != wantCommit {
+		t.Fatalf("got HEAD %s after update, want %s", gotCommit, wantCommit)
+	}
+	if hasObject(newBlob) {
+		t.Fatal("expected new blobs to be missing after a fetch")
+	}
+}
@@ -0,0 +1,753 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/pathmatch"
+)
+
+// The commit index of a repository lists the commits reachable from HEAD,
+// except merge commits, together with their diffs, so that commit and diff
+// searches don't need to run git log. It is stored in the commitIndexDir
+// directory of the git directory and consists of a state file and segments
+// of JSON lines. Each update adds a segment with the commits that became
+// reachable since the previous update, so segments are ordered oldest first
+// and each segment lists its commits newest first, like git log. If HEAD no
+// longer contains the previously indexed commit (e.g. after a force push),
+// the index is rebuilt.
+const commitIndexDir = "sg_commitindex"
+
+// commitIndexVersion is the version of the commit index format. Indexes in
+// other versions are rebuilt on the next update.
+const commitIndexVersion = 1
+
+// maxIndexedDiffSize is the maximum size of an indexed diff. Larger diffs are
+// truncated after the last file diff that fits.
+const maxIndexedDiffSize = 256 * 1024
+
+// commitIndexLogFormat is the git log format of indexed commits, which
+// readCommitLog parses.
+const commitIndexLogFormat = "--format=format:%H%x00%P%x00%an%x00%ae%x00%at%x00%cn%x00%ce%x00%ct%x00%B%x00"
+
+// commitIndexWorkers is the number of commit index updates that run at once.
+const commitIndexWorkers = 2
+
+// maxQueuedCommitIndexUpdates is the maximum number of repositories waiting for
+// a commit index update.
+const maxQueuedCommitIndexUpdates = 1000
+
+var (
+	commitIndexUpdateDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "src",
+		Subsystem: "gitserver",
+		Name:      "commit_index_update_duration_seconds",
+		Help:      "Time spent updating the commit index of a repository.",
+		Buckets:   []float64{0.1, 0.5, 1, 5, 10, 30, 60, 300, 900, 1800},
+	})
+	commitIndexUpdatesDropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "src",
+		Subsystem: "gitserver",
+		Name:      "commit_index_updates_dropped_total",
+		Help:      "Number of commit index updates dropped because the queue was full.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(commitIndexUpdateDuration)
+	prometheus.MustRegister(commitIndexUpdatesDropped)
+}
+
+// commitIndexUpdate is a queued update of the commit index of a repository.
+type commitIndexUpdate struct {
+	repo api.RepoName
+	dir  GitDir
+}
+
+// commitIndexQueue queues the repositories whose commit index needs an
+// update after a clone or fetch. A repository is queued at most once, and
+// updates are dropped when the queue is full: the next fetch of the
+// repository queues it again, and searches fall back to git log meanwhile.
+type commitIndexQueue struct {
+	mu      sync.Mutex
+	queued  map[GitDir]bool
+	updates chan commitIndexUpdate
+}
+
+func newCommitIndexQueue(size int) *commitIndexQueue {
+	return &commitIndexQueue{
+		queued:  map[GitDir]bool{},
+		updates: make(chan commitIndexUpdate, size),
+	}
+}
+
+// enqueue queues an update of the commit index of the repository at dir,
+// unless one is already queued. It reports whether the update was queued.
+func (q *commitIndexQueue) enqueue(repo api.RepoName, dir GitDir) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.queued[dir] {
+		return true
+	}
+	select {
+	case q.updates <- commitIndexUpdate{repo: repo, dir: dir}:
+		q.queued[dir] = true
+		return true
+	default:
+		commitIndexUpdatesDropped.Inc()
+		return false
+	}
+}
+
+// dequeue waits for the next queued update. It returns false if ctx is done
+// first.
+func (q *commitIndexQueue) dequeue(ctx context.Context) (commitIndexUpdate, bool) {
+	select {
+	case u := <-q.updates:
+		// Allow queueing the repository again while it's updated, so that
+		// a fetch during the update isn't missed.
+		q.mu.Lock()
+		delete(q.queued, u.dir)
+		q.mu.Unlock()
+		return u, true
+	case <-ctx.Done():
+		return commitIndexUpdate{}, false
+	}
+}
+
+// queueCommitIndexUpdate queues an update of the commit index of the
+// repository at dir after it was cloned or fetched.
+func (s *Server) queueCommitIndexUpdate(repo api.RepoName, dir GitDir) {
+	if s.commitIndexQueue == nil || !conf.CommitSearchIndexEnabled() {
+		return
+	}
+	if !s.commitIndexQueue.enqueue(repo, dir) {
+		log15.Debug("Commit index update queue is full", "repo", repo)
+	}
+}
+
+// runCommitIndexWorker runs the queued commit index updates until the server
+// is stopped.
+func (s *Server) runCommitIndexWorker() {
+	for {
+		u, ok := s.commitIndexQueue.dequeue(s.ctx)
+		if !ok {
+			return
+		}
+		ctx, cancel := s.serverContext()
+		maybeUpdateCommitIndex(ctx, u.repo, u.dir)
+		cancel()
+	}
+}
+
+type commitIndexState struct {
+	Version int
+
+	// Head is the commit HEAD pointed to when the index was last updated.
+	Head string
+
+	// Segments are the file names of the segments, oldest first.
+	Segments []string
+}
+
+// commitIndexLocks serializes updates of the commit index of a repository.
+var commitIndexLocks = struct {
+	sync.Mutex
+	m map[GitDir]*sync.Mutex
+}{m: map[GitDir]*sync.Mutex{}}
+
+func lockCommitIndex(dir GitDir) (unlock func()) {
+	commitIndexLocks.Lock()
+	mu, ok := commitIndexLocks.m[dir]
+	if !ok {
+		mu = new(sync.Mutex)
+		commitIndexLocks.m[dir] = mu
+	}
+	commitIndexLocks.Unlock()
+
+	mu.Lock()
+	return mu.Unlock
+}
+
+// maybeUpdateCommitIndex updates the commit index of the repository at dir
+// if indexing is enabled. Only full clones are indexed, since computing the
+// diffs of partial clones would fetch all of their contents.
+func maybeUpdateCommitIndex(ctx context.Context, repo api.RepoName, dir GitDir) {
+	if !conf.CommitSearchIndexEnabled() {
+		return
+	}
+	if mode, err := getRepoCloneMode(dir); err != nil || mode.Mode != protocol.CloneModeFull {
+		return
+	}
+
+	start := time.Now()
+	if err := updateCommitIndex(ctx, dir); err != nil {
+		log15.Warn("Failed to update commit index", "repo", repo, "error", err)
+		return
+	}
+	commitIndexUpdateDuration.Observe(time.Since(start).Seconds())
+}
+
+// hasCommitIndex reports whether the repository at dir has a commit index
+// that can be searched.
+func hasCommitIndex(dir GitDir) bool {
+	state, err := readCommitIndexState(dir)
+	return err == nil && state != nil && state.Version == commitIndexVersion
+}
+
+// updateCommitIndex updates the commit index of the repository at dir to
+// cover the commits reachable from HEAD.
+func updateCommitIndex(ctx context.Context, dir GitDir) error {
+	defer lockCommitIndex(dir)()
+
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--verify", "HEAD^{commit}")
+	cmd.Dir = string(dir)
+	out, err := cmd.Output()
+	if err != nil {
+		// The repository is empty, so there is nothing to search.
+		return os.RemoveAll(dir.Path(commitIndexDir))
+	}
+	head := string(bytes.TrimSpace(out))
+
+	state, err := readCommitIndexState(dir)
+	if err != nil {
+		return err
+	}
+	incremental := state != nil && state.Version == commitIndexVersion && state.Head != ""
+	if incremental && state.Head == head {
+		return nil
+	}
+	if incremental {
+		cmd := exec.CommandContext(ctx, "git", "merge-base", "--is-ancestor", state.Head, head)
+		cmd.Dir = string(dir)
+		incremental = cmd.Run() == nil
+	}
+
+	if err := os.MkdirAll(dir.Path(commitIndexDir), os.ModePerm); err != nil {
+		return err
+	}
+	args := []string{"log", "--no-merges", "-z", "--patch", "--unified=0", "--no-prefix", "--no-renames", "--no-color", "--no-ext-diff", commitIndexLogFormat, head}
+	if incremental {
+		args = append(args, "^"+state.Head)
+	}
+	segment, err := writeCommitIndexSegment(ctx, dir, args)
+	if err != nil {
+		return err
+	}
+
+	newState := commitIndexState{Version: commitIndexVersion, Head: head}
+	if incremental {
+		newState.Segments = append(newState.Segments, state.Segments...)
+	}
+	if segment != "" {
+		newState.Segments = append(newState.Segments, segment)
+	}
+	if err := writeCommitIndexState(dir, &newState); err != nil {
+		return err
+	}
+
+	if !incremental && state != nil {
+		for _, name := range state.Segments {
+			_ = os.Remove(dir.Path(commitIndexDir, name))
+		}
+	}
+	return nil
+}
+
+func readCommitIndexState(dir GitDir) (*commitIndexState, error) {
+	b, err := ioutil.ReadFile(dir.Path(commitIndexDir, "state.json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var state commitIndexState
+	if err := json.Unmarshal(b, &state); err != nil {
+		return nil, errors.Wrap(err, "invalid commit index state")
+	}
+	return &state, nil
+}
+
+func writeCommitIndexState(dir GitDir, state *commitIndexState) error {
+	b, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	_, err = updateFileIfDifferent(dir.Path(commitIndexDir, "state.json"), b)
+	return err
+}
+
+// writeCommitIndexSegment writes the commits listed by the git log command
+// with the given arguments to a new segment and returns its file name. It
+// returns "" if there are no commits.
+func writeCommitIndexSegment(ctx context.Context, dir GitDir, args []string) (string, error) {
+	f, err := ioutil.TempFile(dir.Path(commitIndexDir), "segment")
+	if err != nil {
+		return "", err
+	}
+	// In the happy case the tempfile is renamed, so this is a noop.
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = string(dir)
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", err
+	}
+	if err := cmd.Start(); err != nil {
+		return "", err
+	}
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	var count int
+	err = readCommitLog(bufio.NewReader(stdout), func(c *protocol.IndexedCommit) error {
+		count++
+		return enc.Encode(c)
+	})
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return "", err
+	}
+	if err := cmd.Wait(); err != nil {
+		return "", errors.Wrapf(err, "git log failed: %s", bytes.TrimSpace(stderr.Bytes()))
+	}
+	if count == 0 {
+		return "", nil
+	}
+
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+	if err := f.Sync(); err != nil {
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	name := strconv.FormatInt(time.Now().UnixNano(), 10) + ".jsonl"
+	return name, renameAndSync(f.Name(), dir.Path(commitIndexDir, name))
+}
+
+// readCommitLog parses the output of git log -z --patch with the
+// commitIndexLogFormat and calls f for each commit.
+func readCommitLog(r *bufio.Reader, f func(*protocol.IndexedCommit) error) error {
+	for {
+		var fields [9]string
+		for i := range fields {
+			b, err := r.ReadBytes(0)
+			if err == io.EOF && i == 0 && len(b) == 0 {
+				return nil
+			} else if err != nil {
+				return errors.Wrap(err, "reading git log output")
+			}
+			fields[i] = string(b[:len(b)-1])
+		}
+
+		authorTime, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return errors.Wrap(err, "invalid author time")
+		}
+		committerTime, err := strconv.ParseInt(fields[7], 10, 64)
+		if err != nil {
+			return errors.Wrap(err, "invalid committer time")
+		}
+		c := &protocol.IndexedCommit{
+			ID:             api.CommitID(fields[0]),
+			AuthorName:     fields[2],
+			AuthorEmail:    fields[3],
+			AuthorDate:     time.Unix(authorTime, 0).UTC(),
+			CommitterName:  fields[5],
+			CommitterEmail: fields[6],
+			CommitterDate:  time.Unix(committerTime, 0).UTC(),
+			Message:        strings.TrimSpace(fields[8]),
+		}
+		for _, p := range strings.Fields(fields[1]) {
+			c.Parents = append(c.Parents, api.CommitID(p))
+		}
+
+		// The commit is followed by a newline and its diff if it has one,
+		// and by a NUL if another commit follows.
+		sep, err := r.ReadByte()
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if sep == '\n' {
+			if c.Diff, err = readCommitDiff(r); err != nil {
+				return err
+			}
+		}
+		if err := f(c); err != nil {
+			return err
+		}
+	}
+}
+
+// readCommitDiff reads a diff terminated by a NUL or the end of the output.
+// Diffs larger than maxIndexedDiffSize are truncated after the last file diff
+// that fits.
+func readCommitDiff(r *bufio.Reader) (string, error) {
+	var buf []byte
+	truncated := false
+	for {
+		b, err := r.ReadSlice(0)
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return "", err
+		}
+		if err == nil {
+			b = b[:len(b)-1]
+		}
+		if len(buf)+len(b) <= maxIndexedDiffSize {
+			buf = append(buf, b...)
+		} else {
+			truncated = true
+		}
+		if err != bufio.ErrBufferFull {
+			break
+		}
+	}
+	if truncated {
+		if i := bytes.LastIndex(buf, []byte("\ndiff --git ")); i >= 0 {
+			buf = buf[:i+1]
+		} else {
+			buf = nil
+		}
+	}
+	return string(buf), nil
+}
+
+func (s *Server) handleCommitSearch(w http.ResponseWriter, r *http.Request) {
+	var req protocol.CommitSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := searchCommitIndex(r.Context(), s.dir(protocol.NormalizeRepo(req.Repo)), &req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// searchCommitIndex returns the indexed commits of the repository at dir
+// that match req, newest first.
+func searchCommitIndex(ctx context.Context, dir GitDir, req *protocol.CommitSearchRequest) (*protocol.CommitSearchResponse, error) {
+	state, err := readCommitIndexState(dir)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil || state.Version != commitIndexVersion {
+		return &protocol.CommitSearchResponse{}, nil
+	}
+
+	m, err := newCommitMatcher(ctx, dir, req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &protocol.CommitSearchResponse{Indexed: true}
+	limitHit := func() bool { return req.Limit > 0 && len(resp.Commits) >= req.Limit }
+	for i := len(state.Segments) - 1; i >= 0 && !limitHit(); i-- {
+		f, err := os.Open(dir.Path(commitIndexDir, state.Segments[i]))
+		if os.IsNotExist(err) {
+			// The index was rebuilt since we read its state. Let the
+			// caller fall back to git log rather than retrying.
+			return &protocol.CommitSearchResponse{}, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		dec := json.NewDecoder(bufio.NewReader(f))
+		for n := 0; !limitHit(); n++ {
+			if n%1000 == 0 && ctx.Err() != nil {
+				f.Close()
+				return nil, ctx.Err()
+			}
+			var c protocol.IndexedCommit
+			if err := dec.Decode(&c); err == io.EOF {
+				break
+			} else if err != nil {
+				f.Close()
+				return nil, errors.Wrapf(err, "reading commit index segment %s", state.Segments[i])
+			}
+			if m.match(&c) {
+				resp.Commits = append(resp.Commits, &c)
+			}
+		}
+		f.Close()
+	}
+	return resp, nil
+}
+
+// commitMatcher matches indexed commits against the filters of a
+// CommitSearchRequest.
+type commitMatcher struct {
+	diff                    *regexp.Regexp
+	message, notMessage     []*regexp.Regexp
+	author, notAuthor       []*regexp.Regexp
+	committer, notCommitter []*regexp.Regexp
+
+	paths     pathmatch.PathMatcher // nil if there are no path filters
+	notAfter  time.Time             // the zero time if unset
+	notBefore time.Time
+
+	includeDiff bool
+}
+
+func newCommitMatcher(ctx context.Context, dir GitDir, req *protocol.CommitSearchRequest) (*commitMatcher, error) {
+	m := &commitMatcher{includeDiff: req.IncludeDiff}
+
+	compile := func(pattern string) (*regexp.Regexp, error) {
+		if !req.IsCaseSensitive {
+			pattern = "(?i:" + pattern + ")"
+		}
+		return regexp.Compile(pattern)
+	}
+	compileAll := func(dst *[]*regexp.Regexp, patterns []string) error {
+		for _, p := range patterns {
+			re, err := compile(p)
+			if err != nil {
+				return err
+			}
+			*dst = append(*dst, re)
+		}
+		return nil
+	}
+
+	if req.DiffPattern != "" {
+		var err error
+		if m.diff, err = compile(req.DiffPattern); err != nil {
+			return nil, err
+		}
+	}
+	for _, c := range []struct {
+		dst      *[]*regexp.Regexp
+		patterns []string
+	}{
+		{&m.message, req.MessagePatterns},
+		{&m.notMessage, req.NegatedMessagePatterns},
+		{&m.author, req.AuthorPatterns},
+		{&m.notAuthor, req.NegatedAuthorPatterns},
+		{&m.committer, req.CommitterPatterns},
+		{&m.notCommitter, req.NegatedCommitterPatterns},
+	} {
+		if err := compileAll(c.dst, c.patterns); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(req.IncludePatterns) > 0 || req.ExcludePattern != "" {
+		var err error
+		m.paths, err = pathmatch.CompilePathPatterns(req.IncludePatterns, req.ExcludePattern, pathmatch.CompileOptions{
+			RegExp:        req.PathPatternsAreRegExps,
+			CaseSensitive: req.PathPatternsAreCaseSensitive,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Like git log, use the latest of the --since dates and the earliest of
+	// the --until dates.
+	for _, date := range req.After {
+		t, err := gitApproxidate(ctx, dir, date)
+		if err != nil {
+			return nil, err
+		}
+		if t.After(m.notBefore) {
+			m.notBefore = t
+		}
+	}
+	for _, date := range req.Before {
+		t, err := gitApproxidate(ctx, dir, date)
+		if err != nil {
+			return nil, err
+		}
+		if m.notAfter.IsZero() || t.Before(m.notAfter) {
+			m.notAfter = t
+		}
+	}
+	return m, nil
+}
+
+// gitApproxidate returns the time denoted by a date in any format understood
+// by git log --since, such as "1 year ago".
+func gitApproxidate(ctx context.Context, dir GitDir, date string) (time.Time, error) {
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--since="+date)
+	cmd.Dir = string(dir)
+	out, err := cmd.Output()
+	if err != nil {
+		return time.Time{}, errors.Wrapf(wrapCmdError(cmd, err), "parsing date %q", date)
+	}
+	// git rev-parse translates --since to --max-age=<unix time>.
+	value := strings.TrimPrefix(strings.TrimSpace(string(out)), "--max-age=")
+	sec, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parsing date %q: unexpected git rev-parse output %q", date, out)
+	}
+	return time.Unix(sec, 0), nil
+}
+
+// match reports whether c matches. It sets c.Diff to the diffs of the files
+// that match the path filters and diff pattern, or clears it if the request
+// does not include diffs.
+func (m *commitMatcher) match(c *protocol.IndexedCommit) bool {
+	if !m.notBefore.IsZero() && c.CommitterDate.Before(m.notBefore) {
+		return false
+	}
+	if !m.notAfter.IsZero() && c.CommitterDate.After(m.notAfter) {
+		return false
+	}
+
+	author := c.AuthorName + " <" + c.AuthorEmail + ">"
+	committer := c.CommitterName + " <" + c.CommitterEmail + ">"
+	if !matchAll(m.message, c.Message) || matchAny(m.notMessage, c.Message) ||
+		!matchAll(m.author, author) || matchAny(m.notAuthor, author) ||
+		!matchAll(m.committer, committer) || matchAny(m.notCommitter, committer) {
+		return false
+	}
+
+	if m.diff != nil || m.paths != nil {
+		c.Diff = m.filterDiff(c.Diff)
+		if c.Diff == "" {
+			return false
+		}
+	}
+	if !m.includeDiff {
+		c.Diff = ""
+	}
+	return true
+}
+
+func matchAll(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if !re.MatchString(s) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchAny(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterDiff returns the file diffs of diff that match the path filters and
+// have an added or removed line that matches the diff pattern.
+func (m *commitMatcher) filterDiff(diff string) string {
+	var b strings.Builder
+	for _, fileDiff := range splitFileDiffs(diff) {
+		if m.paths != nil {
+			oldPath, newPath := fileDiffPaths(fileDiff)
+			if !(oldPath != "" && m.paths.MatchPath(oldPath)) && !(newPath != "" && m.paths.MatchPath(newPath)) {
+				continue
+			}
+		}
+		if m.diff != nil && !m.matchChangedLines(fileDiff) {
+			continue
+		}
+		b.WriteString(fileDiff)
+	}
+	return b.String()
+}
+
+func (m *commitMatcher) matchChangedLines(fileDiff string) bool {
+	inHunk := false
+	for _, line := range strings.Split(fileDiff, "\n") {
+		if strings.HasPrefix(line, "@@ ") {
+			inHunk = true
+			continue
+		}
+		if inHunk && (strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-")) && m.diff.MatchString(line[1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitFileDiffs splits a diff into the diffs of each file.
+func splitFileDiffs(diff string) []string {
+	var fileDiffs []string
+	for diff != "" {
+		i := strings.Index(diff, "\ndiff --git ")
+		if i < 0 {
+			fileDiffs = append(fileDiffs, diff)
+			break
+		}
+		fileDiffs = append(fileDiffs, diff[:i+1])
+		diff = diff[i+1:]
+	}
+	return fileDiffs
+}
+
+// fileDiffPaths returns the old and new path of a file diff without file name
+// prefixes. A path is "" if the file was added or deleted.
+func fileDiffPaths(fileDiff string) (oldPath, newPath string) {
+	lines := strings.Split(fileDiff, "\n")
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "--- "):
+			oldPath = unquoteDiffPath(strings.TrimPrefix(line, "--- "))
+		case strings.HasPrefix(line, "+++ "):
+			newPath = unquoteDiffPath(strings.TrimPrefix(line, "+++ "))
+		case strings.HasPrefix(line, "@@ "):
+			return oldPath, newPath
+		}
+	}
+	if oldPath == "" && newPath == "" {
+		// Binary files and mode changes have no ---/+++ lines. The header
+		// is "diff --git <path> <path>" since the index is built with
+		// --no-renames.
+		header := strings.TrimPrefix(lines[0], "diff --git ")
+		if n := len(header); n%2 == 1 && header[:n/2] == header[n/2+1:] {
+			oldPath = unquoteDiffPath(header[:n/2])
+			newPath = oldPath
+		}
+	}
+	return oldPath, newPath
+}
+
+func unquoteDiffPath(p string) string {
+	if p == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(p, `"`) {
+		if unquoted, err := strconv.Unquote(p); err == nil {
+			return unquoted
+		}
+	}
+	return p
+}
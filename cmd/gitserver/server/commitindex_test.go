@@ -0,0 +1,236 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+)
+
+func TestCommitIndex(t *testing.T) {
+	dir, cleanup := tmpDir(t)
+	defer cleanup()
+	gitDir := GitDir(filepath.Join(dir, ".git"))
+
+	cmd := func(env []string, name string, arg ...string) string {
+		t.Helper()
+		c := exec.Command(name, arg...)
+		c.Dir = dir
+		c.Env = append([]string{
+			"GIT_COMMITTER_NAME=a",
+			"GIT_COMMITTER_EMAIL=a@a.com",
+			"GIT_AUTHOR_NAME=a",
+			"GIT_AUTHOR_EMAIL=a@a.com",
+		}, env...)
+		b, err := c.CombinedOutput()
+		if err != nil {
+			t.Fatalf("%s %s failed: %s\n%s", name, strings.Join(arg, " "), err, b)
+		}
+		return string(b)
+	}
+	commit := func(date, message string, authorEnv ...string) {
+		t.Helper()
+		env := append([]string{"GIT_AUTHOR_DATE=" + date, "GIT_COMMITTER_DATE=" + date}, authorEnv...)
+		cmd(nil, "git", "add", "-A")
+		cmd(env, "git", "commit", "--allow-empty", "-m", message)
+	}
+	search := func(req protocol.CommitSearchRequest) []string {
+		t.Helper()
+		resp, err := searchCommitIndex(context.Background(), gitDir, &req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !resp.Indexed {
+			t.Fatal("repository is not indexed")
+		}
+		var messages []string
+		for _, c := range resp.Commits {
+			messages = append(messages, c.Message)
+		}
+		return messages
+	}
+	update := func() {
+		t.Helper()
+		if err := updateCommitIndex(context.Background(), gitDir); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd(nil, "git", "init", ".")
+
+	// Repositories without commits have no index.
+	update()
+	if resp, err := searchCommitIndex(context.Background(), gitDir, &protocol.CommitSearchRequest{}); err != nil || resp.Indexed {
+		t.Fatalf("got %+v, %v, want not indexed", resp, err)
+	}
+	if hasCommitIndex(gitDir) {
+		t.Fatal("want no commit index")
+	}
+
+	cmd(nil, "sh", "-c", "echo 'func foo()' > main.go")
+	commit("2019-01-01T00:00:00Z", "add foo")
+	cmd(nil, "sh", "-c", "echo 'func foo()\nfunc bar()' > main.go && echo bar > README")
+	commit("2019-06-01T00:00:00Z", "add bar", "GIT_AUTHOR_NAME=b", "GIT_AUTHOR_EMAIL=b@b.com")
+	update()
+	if !hasCommitIndex(gitDir) {
+		t.Fatal("want commit index")
+	}
+
+	if got, want := search(protocol.CommitSearchRequest{DiffPattern: "func"}), []string{"add bar", "add foo"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// Incremental updates add a segment with the new commits.
+	cmd(nil, "sh", "-c", "echo 'func baz()' > baz.go")
+	commit("2020-01-01T00:00:00Z", "add baz")
+	update()
+	state, err := readCommitIndexState(gitDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Segments) != 2 {
+		t.Fatalf("got %d segments, want 2", len(state.Segments))
+	}
+
+	tests := []struct {
+		name string
+		req  protocol.CommitSearchRequest
+		want []string
+	}{
+		{
+			name: "diff pattern",
+			req:  protocol.CommitSearchRequest{DiffPattern: "func b"},
+			want: []string{"add baz", "add bar"},
+		},
+		{
+			name: "case sensitive",
+			req:  protocol.CommitSearchRequest{DiffPattern: "FUNC", IsCaseSensitive: true},
+		},
+		{
+			name: "limit",
+			req:  protocol.CommitSearchRequest{Limit: 2},
+			want: []string{"add baz", "add bar"},
+		},
+		{
+			name: "message",
+			req:  protocol.CommitSearchRequest{MessagePatterns: []string{"^add", "ba"}, NegatedMessagePatterns: []string{"baz"}},
+			want: []string{"add bar"},
+		},
+		{
+			name: "author",
+			req:  protocol.CommitSearchRequest{AuthorPatterns: []string{"b@b\\.com"}},
+			want: []string{"add bar"},
+		},
+		{
+			name: "negated author",
+			req:  protocol.CommitSearchRequest{NegatedAuthorPatterns: []string{"^b "}},
+			want: []string{"add baz", "add foo"},
+		},
+		{
+			name: "dates",
+			req:  protocol.CommitSearchRequest{After: []string{"2019-03-01"}, Before: []string{"2019-12-01"}},
+			want: []string{"add bar"},
+		},
+		{
+			name: "include paths",
+			req:  protocol.CommitSearchRequest{IncludePatterns: []string{"\\.go$"}, PathPatternsAreRegExps: true, DiffPattern: "bar"},
+			want: []string{"add bar"},
+		},
+		{
+			name: "exclude paths",
+			req:  protocol.CommitSearchRequest{ExcludePattern: "\\.go$", PathPatternsAreRegExps: true},
+			want: []string{"add bar"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := search(test.req); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+
+	t.Run("diff", func(t *testing.T) {
+		resp, err := searchCommitIndex(context.Background(), gitDir, &protocol.CommitSearchRequest{DiffPattern: "func bar", IncludeDiff: true, Limit: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Only the diffs of files with matching lines are returned.
+		want := "diff --git main.go main.go\nindex 16289b0..6dec0a6 100644\n--- main.go\n+++ main.go\n@@ -1,0 +2 @@ func foo()\n+func bar()\n"
+		if len(resp.Commits) != 1 || resp.Commits[0].Diff != want {
+			t.Fatalf("got %q, want diff %q", resp.Commits[0].Diff, want)
+		}
+	})
+
+	// Rewriting history rebuilds the index.
+	cmd(nil, "git", "reset", "--hard", "HEAD~2")
+	cmd(nil, "sh", "-c", "echo 'func qux()' > qux.go")
+	commit("2020-02-01T00:00:00Z", "add qux")
+	update()
+	state, err = readCommitIndexState(gitDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Segments) != 1 {
+		t.Fatalf("got %d segments, want 1", len(state.Segments))
+	}
+	if got, want := search(protocol.CommitSearchRequest{DiffPattern: "func"}), []string{"add qux", "add foo"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestReadCommitDiff_Truncate(t *testing.T) {
+	file := func(name string, size int) string {
+		return "diff --git " + name + " " + name + "\n@@ -0,0 +1 @@\n+" + strings.Repeat("x", size) + "\n"
+	}
+	small := file("a", 10)
+	diff := small + file("b", maxIndexedDiffSize)
+
+	log := "deadbeef\x00\x00a\x00a@a.com\x001\x00a\x00a@a.com\x001\x00message\x00\n" + diff
+	var got string
+	err := readCommitLog(bufio.NewReader(strings.NewReader(log)), func(c *protocol.IndexedCommit) error {
+		got = c.Diff
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != small {
+		t.Errorf("got diff of %d bytes, want %q", len(got), small)
+	}
+}
+
+func TestCommitIndexQueue(t *testing.T) {
+	q := newCommitIndexQueue(2)
+	if !q.enqueue("a", "/a") || !q.enqueue("a", "/a") || !q.enqueue("b", "/b") {
+		t.Fatal("want updates queued")
+	}
+	if q.enqueue("c", "/c") {
+		t.Error("want update dropped when the queue is full")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if u, ok := q.dequeue(ctx); !ok || u.repo != "a" {
+		t.Errorf("got %+v, want the update of a", u)
+	}
+	// a can be queued again once its update started.
+	if !q.enqueue("a", "/a") {
+		t.Error("want update of a queued again")
+	}
+	if u, ok := q.dequeue(ctx); !ok || u.repo != "b" {
+		t.Errorf("got %+v, want the update of b", u)
+	}
+	if u, ok := q.dequeue(ctx); !ok || u.repo != "a" {
+		t.Errorf("got %+v, want the update of a", u)
+	}
+
+	cancel()
+	if _, ok := q.dequeue(ctx); ok {
+		t.Error("want no update after the context is done")
+	}
+}
@@ -47,6 +47,15 @@ func (s *Server) repoInfo(ctx context.Context, repo api.RepoName) (*protocol.Rep
 		} else {
 			resp.LastChanged = &lastChanged
 		}
+
+		if mode, err := getRepoCloneMode(dir); err != nil {
+			log15.Warn("error getting clone mode", "repo", repo, "err", err)
+		} else {
+			resp.CloneMode = mode.Mode
+			resp.CloneDepth = mode.Depth
+		}
+
+		resp.CommitIndexed = hasCommitIndex(dir)
 	}
 	return &resp, nil
 }
@@ -138,6 +138,10 @@ type Server struct {
 
 	repoUpdateLocksMu sync.Mutex // protects the map below and also updates to locks.once
 	repoUpdateLocks   map[api.RepoName]*locks
+
+	// commitIndexQueue queues the repositories whose commit index needs an
+	// update. It is nil until Handler is called.
+	commitIndexQueue *commitIndexQueue
 }
 
 type locks struct {
@@ -222,6 +226,11 @@ func (s *Server) Handler() http.Handler {
 		s.cloneableLimiter.SetLimit(limit)
 	})
 
+	s.commitIndexQueue = newCommitIndexQueue(maxQueuedCommitIndexUpdates)
+	for i := 0; i < commitIndexWorkers; i++ {
+		go s.runCommitIndexWorker()
+	}
+
 	mux := http.NewServeMux()
 	mux.HandleFunc("/archive", s.handleArchive)
 	mux.HandleFunc("/exec", s.handleExec)
@@ -232,8 +241,11 @@ func (s *Server) Handler() http.Handler {
 	mux.HandleFunc("/repos", s.handleRepoInfo)
 	mux.HandleFunc("/delete", s.handleRepoDelete)
 	mux.HandleFunc("/repo-update", s.handleRepoUpdate)
+	mux.HandleFunc("/repo-transfer", s.handleRepoTransfer)
+	mux.HandleFunc("/transfer", s.handleTransfer)
 	mux.HandleFunc("/getGitolitePhabricatorMetadata", s.handleGetGitolitePhabricatorMetadata)
 	mux.HandleFunc("/create-commit-from-patch", s.handleCreateCommitFromPatch)
+	mux.HandleFunc("/commit-search", s.handleCommitSearch)
 	mux.HandleFunc("/ping", func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
@@ -671,12 +683,29 @@ func (s *Server) exec(w http.ResponseWriter, r *http.Request, req *protocol.Exec
 	stdoutW := &writeCounter{w: w}
 	stderrW := &writeCounter{w: &limitWriter{W: &stderrBuf, N: 1024}}
 
+	partialClone := isPartialClone(dir)
+	if partialClone {
+		if treeish, paths, ok := archiveTreeishAndPaths(req.Args); ok {
+			if err := prefetchMissingBlobs(ctx, dir, treeish, paths); err != nil {
+				// Not fatal, git archive fetches missing blobs one by one.
+				log15.Warn("failed to prefetch missing blobs", "repo", req.Repo, "treeish", treeish, "error", err)
+			}
+		}
+	}
+
 	cmdStart = time.Now()
 	cmd := exec.CommandContext(ctx, "git", req.Args...)
 	cmd.Dir = string(dir)
 	cmd.Stdout = stdoutW
 	cmd.Stderr = stderrW
 
+	// Partial clones fetch missing file contents from the remote, so the
+	// command needs the same configuration as our other remote commands.
+	if partialClone {
+		cmd.Env = os.Environ()
+		configureRemoteGitCommand(cmd, tlsExternal().(*tlsConfig))
+	}
+
 	exitStatus, execErr = runCommand(ctx, cmd)
 
 	status = strconv.Itoa(exitStatus)
@@ -808,17 +837,20 @@ func (s *Server) cloneRepo(ctx context.Context, repo api.RepoName, url string, o
 		tmp := GitDir(tmpPath)
 
 		var cmd *exec.Cmd
+		mode := fullCloneMode
 		if useRefspecOverrides() {
 			cmd, err = refspecOverridesCloneCmd(ctx, url, tmpPath)
 			if err != nil {
 				return err
 			}
 		} else {
-			cmd = exec.CommandContext(ctx, "git", "clone", "--mirror", "--progress", url, tmpPath)
+			mode = configuredCloneMode(url)
+			args := append([]string{"clone", "--mirror", "--progress"}, mode.fetchArgs()...)
+			cmd = exec.CommandContext(ctx, "git", append(args, url, tmpPath)...)
 		}
 		// see issue #7322: skip LFS content in repositories with Git LFS configured
 		cmd.Env = append(os.Environ(), "GIT_LFS_SKIP_SMUDGE=1")
-		log15.Info("cloning repo", "repo", repo, "tmp", tmpPath, "dst", dstPath)
+		log15.Info("cloning repo", "repo", repo, "tmp", tmpPath, "dst", dstPath, "mode", mode.Mode)
 
 		pr, pw := io.Pipe()
 		defer pw.Close()
@@ -840,6 +872,12 @@ func (s *Server) cloneRepo(ctx context.Context, repo api.RepoName, url string, o
 			return err
 		}
 
+		// Record the clone mode, so that fetches and reclones can tell how
+		// the repository was cloned.
+		if err := setRepoCloneMode(tmp, mode); err != nil {
+			return err
+		}
+
 		if overwrite {
 			// remove the current repo by putting it into our temporary directory
 			err := renameAndSync(dstPath, filepath.Join(filepath.Dir(tmpPath), "old"))
@@ -858,6 +896,8 @@ func (s *Server) cloneRepo(ctx context.Context, repo api.RepoName, url string, o
 		log15.Info("repo cloned", "repo", repo)
 		repoClonedCounter.Inc()
 
+		s.queueCommitIndexUpdate(repo, dir)
+
 		return nil
 	}
 
@@ -1054,14 +1094,7 @@ func (s *Server) doRepoUpdate(ctx context.Context, repo api.RepoName, url string
 	defer span.Finish()
 
 	s.repoUpdateLocksMu.Lock()
-	l, ok := s.repoUpdateLocks[repo]
-	if !ok {
-		l = &locks{
-			once: new(sync.Once),
-			mu:   new(sync.Mutex),
-		}
-		s.repoUpdateLocks[repo] = l
-	}
+	l := s.repoUpdateLock(repo)
 	once := l.once
 	mu := l.mu
 	s.repoUpdateLocksMu.Unlock()
@@ -1094,6 +1127,20 @@ func (s *Server) doRepoUpdate(ctx context.Context, repo api.RepoName, url string
 	}
 }
 
+// repoUpdateLock returns the locks used to serialize updates of repo. The
+// caller must hold s.repoUpdateLocksMu.
+func (s *Server) repoUpdateLock(repo api.RepoName) *locks {
+	l, ok := s.repoUpdateLocks[repo]
+	if !ok {
+		l = &locks{
+			once: new(sync.Once),
+			mu:   new(sync.Mutex),
+		}
+		s.repoUpdateLocks[repo] = l
+	}
+	return l
+}
+
 var (
 	badRefsOnce sync.Once
 	badRefs     []string
@@ -1310,7 +1357,21 @@ func (s *Server) doRepoUpdate2(repo api.RepoName, url string) error {
 	} else if useRefspecOverrides() {
 		cmd = refspecOverridesFetchCmd(ctx, url)
 	} else {
-		cmd = exec.CommandContext(ctx, "git", "fetch", "--prune", url, "+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*", "+refs/pull/*:refs/pull/*", "+refs/sourcegraph/*:refs/sourcegraph/*")
+		mode, err := getRepoCloneMode(dir)
+		if err != nil {
+			log15.Warn("Failed to determine clone mode", "repo", repo, "error", err)
+		}
+
+		// git only allows filtered fetches from the promisor remote of a
+		// partial clone, so we fetch from origin, which is set to url above.
+		remote := url
+		if mode.Mode == protocol.CloneModeBlobless {
+			remote = "origin"
+		}
+
+		args := append([]string{"fetch", "--prune"}, mode.fetchArgs()...)
+		args = append(args, remote, "+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*", "+refs/pull/*:refs/pull/*", "+refs/sourcegraph/*:refs/sourcegraph/*")
+		cmd = exec.CommandContext(ctx, "git", args...)
 	}
 	cmd.Dir = string(dir)
 
@@ -1376,6 +1437,11 @@ func (s *Server) doRepoUpdate2(repo api.RepoName, url string) error {
 		log15.Error("Failed to set HEAD", "repo", repo, "error", err, "output", string(output))
 		return errors.Wrap(err, "Failed to set HEAD")
 	}
+
+	// Don't hold up the update (and the lock on further updates) while
+	// indexing.
+	s.queueCommitIndexUpdate(repo, dir)
+
 	return nil
 }
 
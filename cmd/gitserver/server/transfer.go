@@ -0,0 +1,274 @@
+package server
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/inconshreveable/log15"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+)
+
+// When the gitserver addresses change, repo-updater moves repositories to the
+// gitserver they belong on by asking it to copy them from the gitserver that
+// held them before (handleRepoTransfer), which streams the repository's
+// $GIT_DIR as a tar archive (handleTransfer). The copy is a plain directory
+// copy, so the new gitserver doesn't need to talk to the code host, and the
+// repository keeps its configuration and metadata such as the clone mode.
+
+// transferErrorTrailer is the HTTP trailer set by handleTransfer if the
+// archive is incomplete. Truncated tar archives can look like complete ones.
+const transferErrorTrailer = "X-Transfer-Error"
+
+var repoTransferredCounter = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "src",
+	Subsystem: "gitserver",
+	Name:      "repo_transferred",
+	Help:      "number of repositories successfully copied from another gitserver",
+})
+
+func init() {
+	prometheus.MustRegister(repoTransferredCounter)
+}
+
+// handleTransfer streams a tar archive of the $GIT_DIR of a repository.
+func (s *Server) handleTransfer(w http.ResponseWriter, r *http.Request) {
+	repo := protocol.NormalizeRepo(api.RepoName(r.URL.Query().Get("repo")))
+	if repo == "" {
+		http.Error(w, "missing repo", http.StatusBadRequest)
+		return
+	}
+
+	dir := s.dir(repo)
+	if _, cloning := s.locker.Status(dir); cloning || !repoCloned(dir) {
+		http.Error(w, "repository not cloned", http.StatusNotFound)
+		return
+	}
+
+	// Hold the update lock while copying, so that a concurrent fetch (or the
+	// git gc it triggers) doesn't change refs and objects halfway through.
+	s.repoUpdateLocksMu.Lock()
+	mu := s.repoUpdateLock(repo).mu
+	s.repoUpdateLocksMu.Unlock()
+	mu.Lock()
+	defer mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/x-tar")
+	w.Header().Set("Trailer", transferErrorTrailer)
+	w.WriteHeader(http.StatusOK)
+
+	if err := writeGitDirTar(w, dir); err != nil {
+		log15.Error("failed to transfer repository", "repo", repo, "error", err)
+		w.Header().Set(transferErrorTrailer, err.Error())
+	}
+}
+
+// writeGitDirTar writes a tar archive of the files in dir to w. The caller
+// must prevent the repository from being updated while the archive is
+// written. Files that disappear regardless (e.g. because the repository was
+// recloned) fail the archive rather than yielding an incomplete copy.
+func writeGitDirTar(w io.Writer, dir GitDir) error {
+	tw := tar.NewWriter(w)
+	root := string(dir)
+	err := filepath.Walk(root, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+		// Lock files belong to git processes running on this gitserver.
+		if (!fi.Mode().IsDir() && !fi.Mode().IsRegular()) || strings.HasSuffix(path, ".lock") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		hdr, err := tar.FileInfoHeader(fi, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+
+		if fi.IsDir() {
+			return tw.WriteHeader(hdr)
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err = io.CopyN(tw, f, hdr.Size)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// handleRepoTransfer copies a repository from another gitserver. It is
+// synchronous, like handleRepoUpdate, so it can yield errors.
+func (s *Server) handleRepoTransfer(w http.ResponseWriter, r *http.Request) {
+	var req protocol.RepoTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.Repo = protocol.NormalizeRepo(req.Repo)
+	if req.Repo == "" || req.From == "" {
+		http.Error(w, "repo and from are required", http.StatusBadRequest)
+		return
+	}
+
+	// Like repo updates, we don't want to cancel the copy partway through if
+	// the request terminates.
+	ctx, cancel1 := s.serverContext()
+	defer cancel1()
+	ctx, cancel2 := context.WithTimeout(ctx, longGitCommandTimeout)
+	defer cancel2()
+
+	if err := s.transferRepo(ctx, req.Repo, req.From); err != nil {
+		log15.Error("failed to transfer repository", "repo", req.Repo, "from", req.From, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// transferRepo copies repo from the gitserver at addr from, unless it is
+// already cloned.
+func (s *Server) transferRepo(ctx context.Context, repo api.RepoName, from string) error {
+	dir := s.dir(repo)
+	if repoCloned(dir) {
+		return nil
+	}
+
+	lock, ok := s.locker.TryAcquire(dir, "copying from "+from)
+	if !ok {
+		return errors.New("repository is already being cloned")
+	}
+	defer lock.Release()
+
+	// Copies compete with clones for disk and network IO.
+	ctx, cancel, err := s.acquireCloneLimiter(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	tmpPath, err := s.tempDir("transfer-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpPath)
+	tmpPath = filepath.Join(tmpPath, ".git")
+
+	u := "http://" + from + "/transfer?repo=" + url.QueryEscape(string(repo))
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: http status %d", u, resp.StatusCode)
+	}
+
+	if err := extractTar(resp.Body, tmpPath); err != nil {
+		return errors.Wrapf(err, "failed to extract %s", u)
+	}
+	// The trailer is only available once the body has been read in full.
+	if msg := resp.Trailer.Get(transferErrorTrailer); msg != "" {
+		return fmt.Errorf("%s: %s", u, msg)
+	}
+	if !repoCloned(GitDir(tmpPath)) {
+		return fmt.Errorf("%s: not a git repository", u)
+	}
+
+	// Make sure every ref can be resolved before the copy is visible.
+	cmd := exec.CommandContext(ctx, "git", "fsck", "--connectivity-only", "--no-dangling")
+	cmd.Dir = tmpPath
+	if output, err := runWith(ctx, cmd, false, nil); err != nil {
+		return errors.Wrapf(err, "%s: incomplete repository: %s", u, output)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(string(dir)), os.ModePerm); err != nil {
+		return err
+	}
+	if err := renameAndSync(tmpPath, string(dir)); err != nil {
+		return err
+	}
+
+	log15.Info("repo transferred", "repo", repo, "from", from)
+	repoTransferredCounter.Inc()
+	return nil
+}
+
+// extractTar extracts the directories and regular files of the tar archive
+// read from r into dir.
+func extractTar(r io.Reader, dir string) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// 🚨 SECURITY: Don't let the archive write outside of dir.
+		name := filepath.FromSlash(hdr.Name)
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) || filepath.Clean(name) != name {
+			return fmt.Errorf("invalid path in archive: %q", hdr.Name)
+		}
+		path := filepath.Join(dir, name)
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, os.ModePerm); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(hdr.Mode).Perm())
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			if err1 := f.Close(); err == nil {
+				err = err1
+			}
+			if err != nil {
+				return err
+			}
+			if err := os.Chtimes(path, hdr.ModTime, hdr.ModTime); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unexpected file type %c in archive: %q", hdr.Typeflag, hdr.Name)
+		}
+	}
+}
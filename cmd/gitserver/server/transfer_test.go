@@ -0,0 +1,118 @@
+package server
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+func TestTransferRepo(t *testing.T) {
+	srcReposDir, cleanup1 := tmpDir(t)
+	defer cleanup1()
+	dstReposDir, cleanup2 := tmpDir(t)
+	defer cleanup2()
+
+	src := &Server{ReposDir: srcReposDir}
+	srcServer := httptest.NewServer(src.Handler())
+	defer srcServer.Close()
+	srcURL, _ := url.Parse(srcServer.URL)
+
+	dst := &Server{ReposDir: dstReposDir}
+	_ = dst.Handler()
+
+	const repo = api.RepoName("example.com/foo/bar")
+	cmd := func(dir string, name string, arg ...string) string {
+		t.Helper()
+		c := exec.Command(name, arg...)
+		c.Dir = dir
+		c.Env = []string{
+			"GIT_COMMITTER_NAME=a",
+			"GIT_COMMITTER_EMAIL=a@a.com",
+			"GIT_AUTHOR_NAME=a",
+			"GIT_AUTHOR_EMAIL=a@a.com",
+		}
+		b, err := c.CombinedOutput()
+		if err != nil {
+			t.Fatalf("%s %s failed: %s: %s", name, strings.Join(arg, " "), err, b)
+		}
+		return string(b)
+	}
+
+	// Not cloned on the source.
+	if err := dst.transferRepo(context.Background(), repo, srcURL.Host); err == nil {
+		t.Fatal("want error transferring a repository that is not cloned")
+	}
+
+	workDir := filepath.Dir(string(src.dir(repo)))
+	mkFiles(t, workDir, "hello.txt")
+	cmd(workDir, "git", "init", ".")
+	cmd(workDir, "git", "add", "hello.txt")
+	cmd(workDir, "git", "commit", "-m", "hello")
+	cmd(workDir, "git", "config", "sourcegraph.test", "yes")
+	want := cmd(workDir, "git", "rev-parse", "HEAD")
+
+	if err := dst.transferRepo(context.Background(), repo, srcURL.Host); err != nil {
+		t.Fatal(err)
+	}
+
+	dstWorkDir := filepath.Dir(string(dst.dir(repo)))
+	if got := cmd(dstWorkDir, "git", "rev-parse", "HEAD"); got != want {
+		t.Errorf("got HEAD %q, want %q", got, want)
+	}
+	if got := cmd(dstWorkDir, "git", "config", "sourcegraph.test"); got != "yes\n" {
+		t.Errorf("got config %q, want the config of the source repository", got)
+	}
+	cmd(dstWorkDir, "git", "fsck")
+
+	// Already cloned repositories are left alone.
+	if err := dst.transferRepo(context.Background(), repo, "invalid"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Copies that are missing objects are rejected.
+	const brokenRepo = api.RepoName("example.com/foo/broken")
+	brokenWorkDir := filepath.Dir(string(src.dir(brokenRepo)))
+	mkFiles(t, brokenWorkDir, "hello.txt")
+	cmd(brokenWorkDir, "git", "init", ".")
+	cmd(brokenWorkDir, "git", "add", "hello.txt")
+	cmd(brokenWorkDir, "git", "commit", "-m", "hello")
+	blob := strings.TrimSpace(cmd(brokenWorkDir, "git", "rev-parse", "HEAD:hello.txt"))
+	if err := os.Remove(src.dir(brokenRepo).Path("objects", blob[:2], blob[2:])); err != nil {
+		t.Fatal(err)
+	}
+	if err := dst.transferRepo(context.Background(), brokenRepo, srcURL.Host); err == nil {
+		t.Error("want error transferring a repository with missing objects")
+	}
+	if repoCloned(dst.dir(brokenRepo)) {
+		t.Error("want the incomplete copy to be discarded")
+	}
+}
+
+func TestExtractTar_invalidPath(t *testing.T) {
+	dir, cleanup := tmpDir(t)
+	defer cleanup()
+
+	for _, name := range []string{"../escape", "/abs", "a/../../escape"} {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		if err := tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0600}); err != nil {
+			t.Fatal(err)
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := extractTar(&buf, dir); err == nil {
+			t.Errorf("%q: want error", name)
+		}
+	}
+}
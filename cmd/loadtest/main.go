@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"strconv"
 	"time"
 
@@ -25,7 +26,20 @@ type GQLSearchVars struct {
 }
 
 func main() {
-	if err := run(); err != nil {
+	var err error
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "replay":
+			err = replayCmd(os.Args[2:])
+		case "compare":
+			err = compareCmd(os.Args[2:])
+		default:
+			err = fmt.Errorf("unknown command %q (expected replay or compare)", os.Args[1])
+		}
+	} else {
+		err = run()
+	}
+	if err != nil {
 		log.Fatal(err)
 	}
 }
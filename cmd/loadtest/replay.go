@@ -0,0 +1,478 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// capturedRequest is a GraphQL request recorded by the frontend when
+// GRAPHQL_CAPTURE_FILE is set. See cmd/frontend/internal/httpapi/graphql_capture.go.
+type capturedRequest struct {
+	Time          time.Time       `json:"time"`
+	UserID        int32           `json:"userID"`
+	Name          string          `json:"name"`
+	Query         string          `json:"query"`
+	OperationName string          `json:"operationName,omitempty"`
+	Variables     json.RawMessage `json:"variables,omitempty"`
+	DurationMS    float64         `json:"durationMs"`
+	Status        int             `json:"status"`
+}
+
+// readCapture reads the requests of a capture file, ordered by time.
+func readCapture(r io.Reader) ([]*capturedRequest, error) {
+	var reqs []*capturedRequest
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		var req capturedRequest
+		if err := json.Unmarshal(sc.Bytes(), &req); err != nil {
+			return nil, errors.Wrapf(err, "line %d", line)
+		}
+		reqs = append(reqs, &req)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(reqs, func(i, j int) bool { return reqs[i].Time.Before(reqs[j].Time) })
+	return reqs, nil
+}
+
+// repoPseudonym matches the pseudonyms that captures contain instead of
+// repository names.
+var repoPseudonym = regexp.MustCompile(`sgrepo-[0-9a-f]{16}`)
+
+// mapRepos maps the repository pseudonyms of the requests to the names of
+// repositories, in turn, in the order in which the pseudonyms first appear.
+func mapRepos(reqs []*capturedRequest, names []string) map[string]string {
+	repos := map[string]string{}
+	if len(names) == 0 {
+		return repos
+	}
+	for _, req := range reqs {
+		for _, p := range repoPseudonym.FindAllString(string(req.Variables), -1) {
+			if _, ok := repos[p]; !ok {
+				repos[p] = names[len(repos)%len(names)]
+			}
+		}
+	}
+	return repos
+}
+
+func hasRepoPseudonyms(reqs []*capturedRequest) bool {
+	for _, req := range reqs {
+		if repoPseudonym.Match(req.Variables) {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchRepoNames returns the names of (up to 1000) repositories of the
+// instance.
+func fetchRepoNames(ctx context.Context, client *http.Client, frontendURL, token string) ([]string, error) {
+	body, err := json.Marshal(map[string]string{"query": "query Repositories { repositories(first: 1000) { nodes { name } } }"})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", frontendURL+"/.api/graphql?Repositories", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "token "+token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	var result struct {
+		Data struct {
+			Repositories struct {
+				Nodes []struct {
+					Name string `json:"name"`
+				} `json:"nodes"`
+			} `json:"repositories"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if len(result.Errors) > 0 {
+		return nil, errors.New(result.Errors[0].Message)
+	}
+	var names []string
+	for _, n := range result.Data.Repositories.Nodes {
+		names = append(names, n.Name)
+	}
+	return names, nil
+}
+
+// replayer replays captured requests against a Sourcegraph instance.
+type replayer struct {
+	client      *http.Client
+	frontendURL string
+	// tokens are the access tokens to make the requests of users with, by user
+	// ID. The token for "*" is used for users without one. Requests of users
+	// without a token are made anonymously.
+	tokens map[string]string
+	// speed is the factor by which the original timing is sped up.
+	speed float64
+	// concurrency is the maximum number of requests in flight.
+	concurrency int
+	// timeout is the timeout of each request.
+	timeout time.Duration
+	// repos maps the repository pseudonyms of the capture to the names of
+	// repositories of the instance (see mapRepos).
+	repos map[string]string
+}
+
+// result is the outcome of replaying a request.
+type result struct {
+	class   string
+	latency time.Duration
+	// lag is how much later than scheduled the request was made, because the
+	// maximum number of requests were in flight.
+	lag     time.Duration
+	err     error
+	timeout bool
+	alert   bool
+}
+
+// replay replays the requests with their original timing and returns a
+// report of the results.
+func (r *replayer) replay(ctx context.Context, reqs []*capturedRequest) *report {
+	rep := newReport()
+	if len(reqs) == 0 {
+		return rep
+	}
+
+	sem := make(chan struct{}, r.concurrency)
+	results := make(chan result)
+
+	var wg sync.WaitGroup
+	go func() {
+		defer close(results)
+		defer wg.Wait()
+
+		start := time.Now()
+		first := reqs[0].Time
+		for _, req := range reqs {
+			scheduled := start.Add(time.Duration(float64(req.Time.Sub(first)) / r.speed))
+			select {
+			case <-time.After(time.Until(scheduled)):
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			wg.Add(1)
+			go func(req *capturedRequest, lag time.Duration) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				res := r.do(ctx, req)
+				res.lag = lag
+				results <- res
+			}(req, time.Since(scheduled))
+		}
+	}()
+
+	for res := range results {
+		rep.add(res)
+	}
+	rep.finish()
+	return rep
+}
+
+// do makes the request and classifies its outcome.
+func (r *replayer) do(ctx context.Context, req *capturedRequest) result {
+	res := result{class: classify(req)}
+
+	vars, err := r.variables(req.Variables)
+	if err != nil {
+		res.err = err
+		return res
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"query":         req.Query,
+		"operationName": req.OperationName,
+		"variables":     vars,
+	})
+	if err != nil {
+		res.err = err
+		return res
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	hr, err := http.NewRequest("POST", r.frontendURL+"/.api/graphql?"+req.Name, bytes.NewReader(body))
+	if err != nil {
+		res.err = err
+		return res
+	}
+	hr = hr.WithContext(ctx)
+	hr.Header.Set("Content-Type", "application/json")
+	if token := r.token(req.UserID); token != "" {
+		hr.Header.Set("Authorization", "token "+token)
+	}
+
+	start := time.Now()
+	resp, err := r.client.Do(hr)
+	if err == nil {
+		defer resp.Body.Close()
+		body, err = ioutil.ReadAll(resp.Body)
+	}
+	res.latency = time.Since(start)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			res.timeout = true
+		} else {
+			res.err = err
+		}
+		return res
+	}
+	if resp.StatusCode != http.StatusOK {
+		res.err = fmt.Errorf("unexpected status %d", resp.StatusCode)
+		return res
+	}
+
+	var gqlResp struct {
+		Data struct {
+			Search *struct {
+				Results *struct {
+					Alert    json.RawMessage   `json:"alert"`
+					Timedout []json.RawMessage `json:"timedout"`
+				} `json:"results"`
+			} `json:"search"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &gqlResp); err != nil {
+		res.err = errors.Wrap(err, "decoding response")
+		return res
+	}
+	if len(gqlResp.Errors) > 0 {
+		res.err = errors.New(gqlResp.Errors[0].Message)
+		return res
+	}
+	if s := gqlResp.Data.Search; s != nil && s.Results != nil {
+		res.alert = len(s.Results.Alert) > 0 && string(s.Results.Alert) != "null"
+		// Searches that time out on some repositories report them instead
+		// of failing.
+		res.timeout = len(s.Results.Timedout) > 0
+	}
+	return res
+}
+
+// variables returns the variables of a captured request with the repository
+// pseudonyms replaced by the names of the repositories they are mapped to.
+func (r *replayer) variables(vars json.RawMessage) (json.RawMessage, error) {
+	if len(r.repos) == 0 || !repoPseudonym.Match(vars) {
+		return vars, nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(vars, &v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(r.replaceRepos("", v))
+}
+
+func (r *replayer) replaceRepos(name string, v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = r.replaceRepos(k, e)
+		}
+		return v
+	case []interface{}:
+		for i, e := range v {
+			v[i] = r.replaceRepos(name, e)
+		}
+		return v
+	case string:
+		return repoPseudonym.ReplaceAllStringFunc(v, func(p string) string {
+			repo, ok := r.repos[p]
+			if !ok {
+				return p
+			}
+			// Search queries contain the pseudonyms in repo: filters,
+			// which are regular expressions.
+			if name == "query" {
+				return regexp.QuoteMeta(repo)
+			}
+			return repo
+		})
+	}
+	return v
+}
+
+func (r *replayer) token(userID int32) string {
+	if userID == 0 {
+		return r.tokens["0"]
+	}
+	if token, ok := r.tokens[strconv.Itoa(int(userID))]; ok {
+		return token
+	}
+	return r.tokens["*"]
+}
+
+// classify returns the class of the request that its results are reported
+// under. Searches are classified by their type, pattern type and whether they
+// are scoped to repositories, other requests by their name.
+func classify(req *capturedRequest) string {
+	var vars struct {
+		Query       *string `json:"query"`
+		PatternType *string `json:"patternType"`
+	}
+	_ = json.Unmarshal(req.Variables, &vars)
+	if vars.Query != nil && strings.Contains(req.Query, "search(") {
+		patternType := "literal"
+		if vars.PatternType != nil && *vars.PatternType != "" {
+			patternType = strings.ToLower(*vars.PatternType)
+		}
+		return "search:" + searchClass(*vars.Query, patternType)
+	}
+
+	name := req.OperationName
+	if name == "" {
+		name = req.Name
+	}
+	if name == "" {
+		name = "unknown"
+	}
+	return "graphql:" + name
+}
+
+// searchClass returns the class of a search query, e.g. "file:regexp:repo".
+func searchClass(query, patternType string) string {
+	typ, scope := "file", "global"
+	for _, field := range strings.Fields(query) {
+		field = strings.ToLower(field)
+		switch {
+		case strings.HasPrefix(field, "type:"):
+			typ = strings.TrimPrefix(field, "type:")
+		case strings.HasPrefix(field, "patterntype:"):
+			patternType = strings.TrimPrefix(field, "patterntype:")
+		case strings.HasPrefix(field, "repo:") || strings.HasPrefix(field, "r:"):
+			scope = "repo"
+		}
+	}
+	return typ + ":" + patternType + ":" + scope
+}
+
+func replayCmd(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	var (
+		captureFile = fs.String("capture", "", "capture file of GraphQL requests recorded with GRAPHQL_CAPTURE_FILE (required)")
+		reposFile   = fs.String("repos", "", "file with the names of the repositories, one per line, to replace the repository pseudonyms of the capture with (default: the first 1000 repositories of the instance)")
+		tokensFile  = fs.String("tokens", "", `JSON file mapping user IDs to access tokens to make their requests with, e.g. {"42": "token"}. The token for "*" is used for other users. Requests without a token are made anonymously.`)
+		url         = fs.String("url", FrontendHost+":"+FrontendPort, "URL of the Sourcegraph instance to replay the requests against")
+		speed       = fs.Float64("speed", 1, "factor by which to speed up the original timing of the requests")
+		concurrency = fs.Int("concurrency", 50, "maximum number of requests in flight")
+		timeout     = fs.Duration("timeout", time.Minute, "timeout of each request")
+		out         = fs.String("out", "", "file to write the JSON report of the run to, for comparing it with another run")
+	)
+	_ = fs.Parse(args)
+	if *captureFile == "" {
+		fs.Usage()
+		return errors.New("-capture is required")
+	}
+	if *speed <= 0 || *concurrency <= 0 {
+		return errors.New("-speed and -concurrency must be positive")
+	}
+
+	f, err := os.Open(*captureFile)
+	if err != nil {
+		return err
+	}
+	reqs, err := readCapture(f)
+	f.Close()
+	if err != nil {
+		return errors.Wrap(err, "reading capture")
+	}
+
+	tokens := map[string]string{}
+	if *tokensFile != "" {
+		b, err := ioutil.ReadFile(*tokensFile)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(b, &tokens); err != nil {
+			return errors.Wrap(err, "reading tokens")
+		}
+	}
+
+	r := &replayer{
+		client:      &http.Client{},
+		frontendURL: strings.TrimSuffix(*url, "/"),
+		tokens:      tokens,
+		speed:       *speed,
+		concurrency: *concurrency,
+		timeout:     *timeout,
+	}
+
+	if hasRepoPseudonyms(reqs) {
+		var repoNames []string
+		if *reposFile != "" {
+			b, err := ioutil.ReadFile(*reposFile)
+			if err != nil {
+				return err
+			}
+			repoNames = strings.Fields(string(b))
+		} else {
+			repoNames, err = fetchRepoNames(context.Background(), r.client, r.frontendURL, tokens["*"])
+			if err != nil {
+				return errors.Wrap(err, "fetching repositories")
+			}
+		}
+		if len(repoNames) == 0 {
+			return errors.New("no repositories to replace the repository pseudonyms of the capture with")
+		}
+		r.repos = mapRepos(reqs, repoNames)
+	}
+
+	rep := r.replay(context.Background(), reqs)
+	rep.print(os.Stdout)
+
+	if *out != "" {
+		b, err := json.MarshalIndent(rep, "", "  ")
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(*out, b, 0644)
+	}
+	return nil
+}
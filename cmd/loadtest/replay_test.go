@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		req  capturedRequest
+		want string
+	}{
+		{
+			req:  capturedRequest{Name: "Search", Query: "query Search($query: String!) { search(query: $query) { results { limitHit } } }", Variables: json.RawMessage(`{"query":"foo"}`)},
+			want: "search:file:literal:global",
+		},
+		{
+			req:  capturedRequest{Name: "Search", Query: "query Search($query: String!) { search(query: $query) { results { limitHit } } }", Variables: json.RawMessage(`{"query":"repo:^foo$ type:symbol bar","patternType":"regexp"}`)},
+			want: "search:symbol:regexp:repo",
+		},
+		{
+			req:  capturedRequest{Name: "CurrentUser", OperationName: "CurrentUser", Query: "query CurrentUser { currentUser { username } }"},
+			want: "graphql:CurrentUser",
+		},
+		{
+			req:  capturedRequest{Name: "Blob", Query: "{ repository(name: $repo) { name } }", Variables: json.RawMessage(`{"repo":"foo"}`)},
+			want: "graphql:Blob",
+		},
+	}
+	for _, test := range tests {
+		if got := classify(&test.req); got != test.want {
+			t.Errorf("classify(%+v) = %q, want %q", test.req, got, test.want)
+		}
+	}
+}
+
+func TestReplay(t *testing.T) {
+	capture := `{"time":"2019-01-01T00:00:00.020Z","userID":0,"name":"Search","query":"query Search($query: String!) { search(query: $query) { results { limitHit } } }","variables":{"query":"repo:^sgrepo-0123456789abcdef$ bar"}}
+{"time":"2019-01-01T00:00:00Z","userID":42,"name":"CurrentUser","query":"query CurrentUser { currentUser { username } }","operationName":"CurrentUser","variables":{"repoName":"sgrepo-fedcba9876543210"}}
+
+{"time":"2019-01-01T00:00:00.010Z","userID":7,"name":"Search","query":"query Search($query: String!) { search(query: $query) { results { limitHit } } }","variables":{"query":"bad"}}
+`
+	reqs, err := readCapture(strings.NewReader(capture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reqs) != 3 || reqs[0].Name != "CurrentUser" {
+		t.Fatalf("want 3 requests ordered by time, got %+v", reqs)
+	}
+
+	var (
+		mu    sync.Mutex
+		auths = map[string]string{}
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var params struct {
+			Variables struct {
+				Query    string `json:"query"`
+				RepoName string `json:"repoName"`
+			} `json:"variables"`
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &params)
+		mu.Lock()
+		auths[r.URL.RawQuery+":"+params.Variables.Query+params.Variables.RepoName] = r.Header.Get("Authorization")
+		mu.Unlock()
+
+		switch params.Variables.Query {
+		case "bad":
+			_, _ = w.Write([]byte(`{"errors":[{"message":"invalid query"}]}`))
+		case `repo:^github\.com/c/d$ bar`:
+			_, _ = w.Write([]byte(`{"data":{"search":{"results":{"alert":{"title":"x"},"timedout":[]}}}}`))
+		default:
+			_, _ = w.Write([]byte(`{"data":{"currentUser":null}}`))
+		}
+	}))
+	defer ts.Close()
+
+	r := &replayer{
+		client:      ts.Client(),
+		frontendURL: ts.URL,
+		tokens:      map[string]string{"42": "t42", "*": "tdefault"},
+		speed:       10,
+		concurrency: 2,
+		timeout:     time.Second,
+		repos:       mapRepos(reqs, []string{"github.com/a/b", "github.com/c/d"}),
+	}
+	rep := r.replay(context.Background(), reqs)
+
+	wantAuths := map[string]string{
+		"CurrentUser:github.com/a/b":        "token t42",
+		"Search:bad":                        "token tdefault",
+		`Search:repo:^github\.com/c/d$ bar`: "",
+	}
+	for k, want := range wantAuths {
+		if got := auths[k]; got != want {
+			t.Errorf("request %q: got Authorization %q, want %q", k, got, want)
+		}
+	}
+
+	if c := rep.Classes["graphql:CurrentUser"]; c == nil || c.Count != 1 || c.Errors != 0 {
+		t.Errorf("unexpected graphql:CurrentUser report %+v", c)
+	}
+	if c := rep.Classes["search:file:literal:repo"]; c == nil || c.Count != 1 || c.Alerts != 1 || c.Timeouts != 0 {
+		t.Errorf("unexpected search:file:literal:repo report %+v", c)
+	}
+	if c := rep.Classes["search:file:literal:global"]; c == nil || c.Count != 1 || c.Errors != 1 {
+		t.Errorf("unexpected search:file:literal:global report %+v", c)
+	}
+}
+
+func TestCompareReports(t *testing.T) {
+	base := &report{Classes: map[string]*classReport{
+		"graphql:A": {Count: 10, P90MS: 100},
+		"graphql:B": {Count: 10, P90MS: 100},
+		"graphql:C": {Count: 10, P90MS: 100},
+	}}
+	head := &report{Classes: map[string]*classReport{
+		"graphql:A": {Count: 10, P90MS: 110},
+		"graphql:B": {Count: 10, P90MS: 150},
+		"graphql:C": {Count: 10, P90MS: 100, Errors: 1},
+		"graphql:D": {Count: 10, P90MS: 1000},
+	}}
+	var buf bytes.Buffer
+	regressions := compareReports(&buf, base, head, 0.2)
+	var classes []string
+	for _, r := range regressions {
+		classes = append(classes, r.class)
+	}
+	if got, want := strings.Join(classes, ","), "graphql:B,graphql:C"; got != want {
+		t.Errorf("got regressions %s, want %s\n%s", got, want, buf.String())
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var latencies []time.Duration
+	for i := 1; i <= 100; i++ {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	for p, want := range map[int]time.Duration{50: 50 * time.Millisecond, 90: 90 * time.Millisecond, 99: 99 * time.Millisecond, 100: 100 * time.Millisecond} {
+		if got := percentile(latencies, p); got != want {
+			t.Errorf("percentile(%d) = %s, want %s", p, got, want)
+		}
+	}
+	if got := percentile(latencies[:1], 50); got != time.Millisecond {
+		t.Errorf("percentile of one latency = %s", got)
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// report summarizes the results of a replay by the class of the requests.
+type report struct {
+	Classes map[string]*classReport `json:"classes"`
+	// MaxLagMS is the longest a request was made later than scheduled in
+	// milliseconds. If it is high, the replay didn't keep up with the original
+	// traffic and -concurrency should be raised.
+	MaxLagMS float64 `json:"maxLagMs"`
+
+	latencies map[string][]time.Duration
+}
+
+// classReport summarizes the results of the requests of a class.
+type classReport struct {
+	Count    int     `json:"count"`
+	Errors   int     `json:"errors"`
+	Timeouts int     `json:"timeouts"`
+	Alerts   int     `json:"alerts"`
+	P50MS    float64 `json:"p50Ms"`
+	P90MS    float64 `json:"p90Ms"`
+	P99MS    float64 `json:"p99Ms"`
+	MaxMS    float64 `json:"maxMs"`
+}
+
+func newReport() *report {
+	return &report{
+		Classes:   make(map[string]*classReport),
+		latencies: make(map[string][]time.Duration),
+	}
+}
+
+func (r *report) add(res result) {
+	c, ok := r.Classes[res.class]
+	if !ok {
+		c = &classReport{}
+		r.Classes[res.class] = c
+	}
+	c.Count++
+	switch {
+	case res.err != nil:
+		c.Errors++
+	case res.timeout:
+		c.Timeouts++
+	}
+	if res.alert {
+		c.Alerts++
+	}
+	if lag := ms(res.lag); lag > r.MaxLagMS {
+		r.MaxLagMS = lag
+	}
+	r.latencies[res.class] = append(r.latencies[res.class], res.latency)
+}
+
+// finish computes the latency percentiles of the classes.
+func (r *report) finish() {
+	for class, latencies := range r.latencies {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		c := r.Classes[class]
+		c.P50MS = ms(percentile(latencies, 50))
+		c.P90MS = ms(percentile(latencies, 90))
+		c.P99MS = ms(percentile(latencies, 99))
+		c.MaxMS = ms(latencies[len(latencies)-1])
+	}
+}
+
+// percentile returns the p-th percentile of the sorted latencies, using the
+// nearest-rank method.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func (r *report) classNames() []string {
+	names := make([]string, 0, len(r.Classes))
+	for name := range r.Classes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r *report) print(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "CLASS\tCOUNT\tERRORS\tTIMEOUTS\tALERTS\tP50 (ms)\tP90 (ms)\tP99 (ms)\tMAX (ms)\t")
+	for _, name := range r.classNames() {
+		c := r.Classes[name]
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%.0f\t%.0f\t%.0f\t%.0f\t\n",
+			name, c.Count, c.Errors, c.Timeouts, c.Alerts, c.P50MS, c.P90MS, c.P99MS, c.MaxMS)
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "\nmax scheduling lag: %.0fms\n", r.MaxLagMS)
+}
+
+func readReport(path string) (*report, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r := newReport()
+	if err := json.Unmarshal(b, r); err != nil {
+		return nil, errors.Wrapf(err, "reading report %s", path)
+	}
+	return r, nil
+}
+
+// regression is a class whose p90 latency or error rate got worse.
+type regression struct {
+	class  string
+	reason string
+}
+
+// compareReports prints the per-class differences between the base and head
+// reports and returns the classes whose p90 latency grew by more than
+// maxRegression (e.g. 0.2 for 20%) or whose errors or timeouts grew.
+func compareReports(w io.Writer, base, head *report, maxRegression float64) []regression {
+	var regressions []regression
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "CLASS\tCOUNT\tERRORS\tTIMEOUTS\tP50 (ms)\tP90 (ms)\tP99 (ms)\tP90 CHANGE\t")
+	for _, name := range head.classNames() {
+		h := head.Classes[name]
+		b, ok := base.Classes[name]
+		if !ok {
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%.0f\t%.0f\t%.0f\tnew\t\n",
+				name, h.Count, h.Errors, h.Timeouts, h.P50MS, h.P90MS, h.P99MS)
+			continue
+		}
+		change := 0.0
+		if b.P90MS > 0 {
+			change = (h.P90MS - b.P90MS) / b.P90MS
+		}
+		fmt.Fprintf(tw, "%s\t%d → %d\t%d → %d\t%d → %d\t%.0f → %.0f\t%.0f → %.0f\t%.0f → %.0f\t%+.1f%%\t\n",
+			name, b.Count, h.Count, b.Errors, h.Errors, b.Timeouts, h.Timeouts,
+			b.P50MS, h.P50MS, b.P90MS, h.P90MS, b.P99MS, h.P99MS, change*100)
+
+		switch {
+		case change > maxRegression:
+			regressions = append(regressions, regression{class: name, reason: fmt.Sprintf("p90 latency grew by %.1f%%", change*100)})
+		case rate(h.Errors, h.Count) > rate(b.Errors, b.Count):
+			regressions = append(regressions, regression{class: name, reason: "error rate grew"})
+		case rate(h.Timeouts, h.Count) > rate(b.Timeouts, b.Count):
+			regressions = append(regressions, regression{class: name, reason: "timeout rate grew"})
+		}
+	}
+	tw.Flush()
+	return regressions
+}
+
+func rate(n, count int) float64 {
+	if count == 0 {
+		return 0
+	}
+	return float64(n) / float64(count)
+}
+
+func compareCmd(args []string) error {
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: loadtest compare [flags] base.json head.json")
+		fs.PrintDefaults()
+	}
+	maxRegression := fs.Float64("max-regression", -1, "if non-negative, exit with an error if the p90 latency of any class grew by more than this fraction (e.g. 0.2 for 20%), or its error or timeout rate grew")
+	_ = fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errors.New("expected 2 reports to compare")
+	}
+
+	base, err := readReport(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	head, err := readReport(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	threshold := *maxRegression
+	if threshold < 0 {
+		threshold = 1 << 30
+	}
+	regressions := compareReports(os.Stdout, base, head, threshold)
+	if *maxRegression < 0 || len(regressions) == 0 {
+		return nil
+	}
+	fmt.Println()
+	for _, r := range regressions {
+		fmt.Printf("regression in %s: %s\n", r.class, r.reason)
+	}
+	return fmt.Errorf("%d classes regressed", len(regressions))
+}
@@ -9,6 +9,7 @@ import { DependencyManager } from '../../shared/store/dependencies'
 import { isDefined } from '../../shared/util'
 import {
     DefinitionMonikersReferenceCursor,
+    PaginatedLocationKind,
     ReferencePaginationContext,
     ReferencePaginationCursor,
     RemoteDumpReferenceCursor,
@@ -100,86 +101,28 @@ export class Backend {
 
         // Try to find definitions in other dumps
         const rangeMonikers = await database.monikersByPosition(pathInDb, position, ctx)
-        if (rangeMonikers.length === 0) {
-            return []
-        }
-
-        // First, we find the monikers for each range, from innermost to
-        // outermost, such that the set of monikers for reach range is sorted by
-        // priority. Then, we perform a search for each moniker, in sequence,
-        // until valid results are found.
-        for (const monikers of rangeMonikers) {
-            for (const moniker of monikers) {
-                if (moniker.kind === 'import') {
-                    // This symbol was imported from another database. See if we have
-                    // a remote definition for it.
-
-                    const { locations: remoteDefinitions } = await this.lookupMoniker(
-                        dumpId,
-                        pathInDb,
-                        moniker,
-                        sqliteModels.DefinitionModel,
-                        {},
-                        ctx
-                    )
-                    if (remoteDefinitions.length > 0) {
-                        return this.resolveLocations(remoteDefinitions)
-                    }
-                } else {
-                    // This symbol was not imported from another database. We search the definitions
-                    // table of our own database in case there was a definition that wasn't properly
-                    // attached to a result set but did have the correct monikers attached.
-
-                    const { locations: monikerResults } = await database.monikerResults(
-                        sqliteModels.DefinitionModel,
-                        moniker,
-                        {},
-                        ctx
-                    )
-                    const localDefinitions = monikerResults.map(loc => locationFromDatabase(dump.root, loc))
-                    if (localDefinitions.length > 0) {
-                        return this.resolveLocations(localDefinitions)
-                    }
-                }
-            }
-        }
-        return []
+        return this.definitionsOfMonikers(dump, database, pathInDb, rangeMonikers, ctx)
     }
 
     /**
-     * Return a list of locations which reference the symbol at the given position. Returns
-     * undefined if no dump can be loaded to answer this query.
+     * Return the location of the type of the symbol at the given position. Returns undefined if
+     * no dump can be loaded to answer this query.
      *
      * @param repositoryId The repository identifier.
      * @param commit The commit.
      * @param path The path of the document to which the position belongs.
      * @param position The current hover position.
-     * @param paginationContext Context describing the current request for paginated results.
-     * @param remoteDumpLimit The maximum number of remote dumps to query in one operation.
      * @param dumpId The identifier of the dump to load.
      * @param ctx The tracing context.
      */
-    public async references(
+    public async typeDefinitions(
         repositoryId: number,
         commit: string,
         path: string,
         position: lsp.Position,
-        paginationContext: ReferencePaginationContext = { limit: 10 },
-        remoteDumpLimit = DEFAULT_REFERENCES_REMOTE_DUMP_LIMIT,
         dumpId: number,
         ctx: TracingContext = {}
-    ): Promise<PaginatedInternalLocations | undefined> {
-        if (paginationContext.cursor) {
-            return this.handleReferencePaginationCursor(
-                repositoryId,
-                commit,
-                remoteDumpLimit,
-                paginationContext.limit,
-                paginationContext.cursor,
-                ctx
-            )
-        }
-
+    ): Promise<ResolvedInternalLocation[] | undefined> {
         const closestDumpAndDatabase = await this.closestDatabase(dumpId, ctx)
         if (!closestDumpAndDatabase) {
             if (ctx.logger) {
@@ -193,26 +136,89 @@ export class Backend {
         // Construct path within dump
         const pathInDb = pathToDatabase(dump.root, path)
 
-        // Get the ranges of for this position and the document in which they occur
-        const rangeMonikers = await database.monikersByPosition(pathInDb, position, ctx)
+        // Try to find type definitions in the same dump
+        const dbTypeDefinitions = await database.typeDefinitions(pathInDb, position, newCtx)
+        const typeDefinitions = dbTypeDefinitions.map(loc => locationFromDatabase(dump.root, loc))
+        if (typeDefinitions.length > 0) {
+            return this.resolveLocations(typeDefinitions)
+        }
 
-        const cursor: ReferencePaginationCursor = {
-            phase: 'same-dump',
-            dumpId: dump.id,
-            path: pathInDb,
+        // The type may be defined in a file that is not part of this dump (e.g. in a dependency).
+        // Try to find the definitions of the monikers attached to the type in other dumps.
+        const rangeMonikers = await database.typeDefinitionMonikersByPosition(pathInDb, position, ctx)
+        return this.definitionsOfMonikers(dump, database, pathInDb, rangeMonikers, ctx)
+    }
+
+    /**
+     * Return a list of locations which reference the symbol at the given position. Returns
+     * undefined if no dump can be loaded to answer this query.
+     *
+     * @param repositoryId The repository identifier.
+     * @param commit The commit.
+     * @param path The path of the document to which the position belongs.
+     * @param position The current hover position.
+     * @param paginationContext Context describing the current request for paginated results.
+     * @param remoteDumpLimit The maximum number of remote dumps to query in one operation.
+     * @param dumpId The identifier of the dump to load.
+     * @param ctx The tracing context.
+     */
+    public references(
+        repositoryId: number,
+        commit: string,
+        path: string,
+        position: lsp.Position,
+        paginationContext: ReferencePaginationContext = { limit: 10 },
+        remoteDumpLimit = DEFAULT_REFERENCES_REMOTE_DUMP_LIMIT,
+        dumpId: number,
+        ctx: TracingContext = {}
+    ): Promise<PaginatedInternalLocations | undefined> {
+        return this.paginatedLocations(
+            'references',
+            repositoryId,
+            commit,
+            path,
             position,
-            monikers: sortMonikers(rangeMonikers.flatMap(m => m)),
-            skipResults: 0,
-        }
+            paginationContext,
+            remoteDumpLimit,
+            dumpId,
+            ctx
+        )
+    }
 
-        // Request the first page of results
-        return this.handleReferencePaginationCursor(
+    /**
+     * Return a list of locations which implement the symbol at the given position. Implementations
+     * are searched for in the same dumps as references, so that implementations in other
+     * repositories are found. Returns undefined if no dump can be loaded to answer this query.
+     *
+     * @param repositoryId The repository identifier.
+     * @param commit The commit.
+     * @param path The path of the document to which the position belongs.
+     * @param position The current hover position.
+     * @param paginationContext Context describing the current request for paginated results.
+     * @param remoteDumpLimit The maximum number of remote dumps to query in one operation.
+     * @param dumpId The identifier of the dump to load.
+     * @param ctx The tracing context.
+     */
+    public implementations(
+        repositoryId: number,
+        commit: string,
+        path: string,
+        position: lsp.Position,
+        paginationContext: ReferencePaginationContext = { limit: 10 },
+        remoteDumpLimit = DEFAULT_REFERENCES_REMOTE_DUMP_LIMIT,
+        dumpId: number,
+        ctx: TracingContext = {}
+    ): Promise<PaginatedInternalLocations | undefined> {
+        return this.paginatedLocations(
+            'implementations',
             repositoryId,
             commit,
+            path,
+            position,
+            paginationContext,
             remoteDumpLimit,
-            paginationContext.limit,
-            cursor,
-            newCtx
+            dumpId,
+            ctx
         )
     }
 
@@ -265,9 +271,82 @@ export class Backend {
         return definitionDatabase.hover(pathToDatabase(definitionDump.root, definitionPath), range.start, newCtx)
     }
 
+    /**
+     * Return a list of locations of the given kind for the symbol at the given position. Returns
+     * undefined if no dump can be loaded to answer this query.
+     *
+     * @param kind The kind of locations to return.
+     * @param repositoryId The repository identifier.
+     * @param commit The commit.
+     * @param path The path of the document to which the position belongs.
+     * @param position The current hover position.
+     * @param paginationContext Context describing the current request for paginated results.
+     * @param remoteDumpLimit The maximum number of remote dumps to query in one operation.
+     * @param dumpId The identifier of the dump to load.
+     * @param ctx The tracing context.
+     */
+    private async paginatedLocations(
+        kind: PaginatedLocationKind,
+        repositoryId: number,
+        commit: string,
+        path: string,
+        position: lsp.Position,
+        paginationContext: ReferencePaginationContext,
+        remoteDumpLimit: number,
+        dumpId: number,
+        ctx: TracingContext
+    ): Promise<PaginatedInternalLocations | undefined> {
+        if (paginationContext.cursor) {
+            return this.handleReferencePaginationCursor(
+                repositoryId,
+                commit,
+                remoteDumpLimit,
+                paginationContext.limit,
+                paginationContext.cursor,
+                ctx
+            )
+        }
+
+        const closestDumpAndDatabase = await this.closestDatabase(dumpId, ctx)
+        if (!closestDumpAndDatabase) {
+            if (ctx.logger) {
+                ctx.logger.warn('No database could be loaded', { repositoryId, commit, path })
+            }
+
+            return undefined
+        }
+        const { dump, database, ctx: newCtx } = closestDumpAndDatabase
+
+        // Construct path within dump
+        const pathInDb = pathToDatabase(dump.root, path)
+
+        // Get the ranges of for this position and the document in which they occur
+        const rangeMonikers = await database.monikersByPosition(pathInDb, position, ctx)
+
+        const cursor: ReferencePaginationCursor = {
+            phase: 'same-dump',
+            kind,
+            dumpId: dump.id,
+            path: pathInDb,
+            position,
+            monikers: sortMonikers(rangeMonikers.flatMap(m => m)),
+            skipResults: 0,
+        }
+
+        // Request the first page of results
+        return this.handleReferencePaginationCursor(
+            repositoryId,
+            commit,
+            remoteDumpLimit,
+            paginationContext.limit,
+            cursor,
+            newCtx
+        )
+    }
+
     /**
      * Using the current state of the pagination cursor, determine what we need to query next. The
-     * four major phases of a reference request are:
+     * four major phases of a reference (or implementation) request are:
      *
      *   (1) 'same-dump': query the original dump's LSIF reference results and references table
      *   (2) 'definition-monikers': query the monikers in the dump that defines them
@@ -336,6 +415,7 @@ export class Backend {
                     () => ({
                         dumpId: cursor.dumpId,
                         phase: 'definition-monikers',
+                        kind: cursor.kind,
                         path: cursor.path,
                         position: cursor.position,
                         monikers: cursor.monikers,
@@ -359,6 +439,7 @@ export class Backend {
                                 return {
                                     dumpId: cursor.dumpId,
                                     phase: 'same-repo',
+                                    kind: cursor.kind,
                                     scheme: moniker.scheme,
                                     identifier: moniker.identifier,
                                     name: packageInformation.name,
@@ -390,6 +471,7 @@ export class Backend {
                     (): ReferencePaginationCursor | undefined => ({
                         dumpId: cursor.dumpId,
                         phase: 'remote-repo',
+                        kind: cursor.kind,
                         scheme: cursor.scheme,
                         identifier: cursor.identifier,
                         name: cursor.name,
@@ -439,8 +521,11 @@ export class Backend {
         }
         const { dump, database } = dumpAndDatabase
 
-        // First get all LSIF reference result locations for the given position.
-        const locationSet = await database.references(cursor.path, cursor.position, ctx)
+        // First get all LSIF reference (or implementation) result locations for the given position.
+        const locationSet =
+            cursor.kind === 'implementations'
+                ? await database.implementations(cursor.path, cursor.position, ctx)
+                : await database.references(cursor.path, cursor.position, ctx)
 
         // Search the references table of the current dump. This search is necessary because
         // we want a 'Find References' operation on a reference to also return references to
@@ -448,7 +533,7 @@ export class Backend {
         // method returns a cursor if there are reference rows remaining for a subsequent page.
         for (const moniker of cursor.monikers) {
             const { locations: monikerLocations } = await database.monikerResults(
-                sqliteModels.ReferenceModel,
+                monikerModel(cursor.kind),
                 moniker,
                 {},
                 ctx
@@ -496,7 +581,7 @@ export class Backend {
                 cursor.dumpId,
                 cursor.path,
                 moniker,
-                sqliteModels.ReferenceModel,
+                monikerModel(cursor.kind),
                 { take: limit, skip: cursor.skipResults },
                 ctx
             )
@@ -603,7 +688,7 @@ export class Backend {
     }
 
     /**
-     * Query the given dumps for references to the given moniker.
+     * Query the given dumps for references to (or implementations of) the given moniker.
      *
      * @param args Parameter bag.
      */
@@ -662,7 +747,7 @@ export class Backend {
             const { dump, database } = dumpAndDatabase
 
             const { locations, count } = await database.monikerResults(
-                sqliteModels.ReferenceModel,
+                monikerModel(cursor.kind),
                 moniker,
                 { take: limit, skip: cursor.skipResultsInDump },
                 ctx
@@ -700,8 +785,8 @@ export class Backend {
     /**
      * Find the locations attached to the target moniker in the dump where it is defined. If
      * the moniker has attached package information, then query Postgres for the target
-     * package. Open that package's database and query its definitions or references table
-     * for the target moniker (depending on the given model).
+     * package. Open that package's database and query its definitions, references, or
+     * implementations table for the target moniker (depending on the given model).
      *
      * @param dumpId The identifier of the dump containing the document.
      * @param path The path of the document.
@@ -714,7 +799,10 @@ export class Backend {
         dumpId: pgModels.DumpId,
         path: string,
         moniker: sqliteModels.MonikerData,
-        model: typeof sqliteModels.DefinitionModel | typeof sqliteModels.ReferenceModel,
+        model:
+            | typeof sqliteModels.DefinitionModel
+            | typeof sqliteModels.ReferenceModel
+            | typeof sqliteModels.ImplementationModel,
         pagination: { skip?: number; take?: number },
         ctx: TracingContext = {}
     ): Promise<{ locations: InternalLocation[]; count: number }> {
@@ -748,6 +836,63 @@ export class Backend {
         return { locations: locations.map(loc => locationFromDatabase(packageEntity.dump.root, loc)), count }
     }
 
+    /**
+     * Search for the definitions of the given monikers, grouped by range from innermost to
+     * outermost, such that the set of monikers for reach range is sorted by priority. A search
+     * is performed for each moniker, in sequence, until valid results are found.
+     *
+     * @param dump The dump containing the document.
+     * @param database The database of the dump.
+     * @param path The path of the document within the dump.
+     * @param rangeMonikers The monikers of each range.
+     * @param ctx The tracing context.
+     */
+    private async definitionsOfMonikers(
+        dump: pgModels.LsifDump,
+        database: Database,
+        path: string,
+        rangeMonikers: sqliteModels.MonikerData[][],
+        ctx: TracingContext = {}
+    ): Promise<ResolvedInternalLocation[]> {
+        for (const monikers of rangeMonikers) {
+            for (const moniker of monikers) {
+                if (moniker.kind === 'import') {
+                    // This symbol was imported from another database. See if we have
+                    // a remote definition for it.
+
+                    const { locations: remoteDefinitions } = await this.lookupMoniker(
+                        dump.id,
+                        path,
+                        moniker,
+                        sqliteModels.DefinitionModel,
+                        {},
+                        ctx
+                    )
+                    if (remoteDefinitions.length > 0) {
+                        return this.resolveLocations(remoteDefinitions)
+                    }
+                } else {
+                    // This symbol was not imported from another database. We search the definitions
+                    // table of our own database in case there was a definition that wasn't properly
+                    // attached to a result set but did have the correct monikers attached.
+
+                    const { locations: monikerResults } = await database.monikerResults(
+                        sqliteModels.DefinitionModel,
+                        moniker,
+                        {},
+                        ctx
+                    )
+                    const localDefinitions = monikerResults.map(loc => locationFromDatabase(dump.root, loc))
+                    if (localDefinitions.length > 0) {
+                        return this.resolveLocations(localDefinitions)
+                    }
+                }
+            }
+        }
+
+        return []
+    }
+
     /**
      * Retrieve the package information associated with the given moniker.
      *
@@ -884,6 +1029,17 @@ export class Backend {
     }
 }
 
+/**
+ * Returns the model of the moniker table that contains locations of the given kind.
+ *
+ * @param kind The kind of locations.
+ */
+function monikerModel(
+    kind: PaginatedLocationKind
+): typeof sqliteModels.ReferenceModel | typeof sqliteModels.ImplementationModel {
+    return kind === 'implementations' ? sqliteModels.ImplementationModel : sqliteModels.ReferenceModel
+}
+
 /**
  * Converts a file in the repository to the corresponding file in the
  * database.
@@ -16,6 +16,13 @@ export type ReferencePaginationCursor =
     | DefinitionMonikersReferenceCursor
     | RemoteDumpReferenceCursor
 
+/**
+ * The kind of locations that are paginated. Implementations are found in the same dumps
+ * as references, but are read from the LSIF implementation results and the implementations
+ * table instead.
+ */
+export type PaginatedLocationKind = 'references' | 'implementations'
+
 /** A label that indicates which pagination phase is being expanded. */
 export type ReferencePaginationPhase = 'same-dump' | 'definition-monikers' | 'same-repo' | 'remote-repo'
 
@@ -26,6 +33,9 @@ interface ReferencePaginationCursorCommon {
 
     /** The phase of the pagination. */
     phase: ReferencePaginationPhase
+
+    /** The kind of locations that are paginated. */
+    kind: PaginatedLocationKind
 }
 
 /** Bookkeeping data for the reference results that come from the initial dump. */
@@ -63,6 +63,48 @@ export class Database {
         return new OrderedLocationSet(locations.map(location => ({ ...location, dumpId: this.dumpId })))
     }
 
+    /**
+     * Return a list of locations that define the type of the symbol at the given position.
+     *
+     * @param path The path of the document to which the position belongs.
+     * @param position The current hover position.
+     * @param ctx The tracing context.
+     */
+    public async typeDefinitions(
+        path: string,
+        position: lsp.Position,
+        ctx: TracingContext = {}
+    ): Promise<InternalLocation[]> {
+        const locations = await this.request<{ path: string; range: lsp.Range }[]>(
+            'typeDefinitions',
+            new URLSearchParams({ path, line: String(position.line), character: String(position.character) }),
+            ctx
+        )
+
+        return locations.map(location => ({ ...location, dumpId: this.dumpId }))
+    }
+
+    /**
+     * Return a list of unique locations that implement the symbol at the given position.
+     *
+     * @param path The path of the document to which the position belongs.
+     * @param position The current hover position.
+     * @param ctx The tracing context.
+     */
+    public async implementations(
+        path: string,
+        position: lsp.Position,
+        ctx: TracingContext = {}
+    ): Promise<OrderedLocationSet> {
+        const locations = await this.request<{ path: string; range: lsp.Range }[]>(
+            'implementations',
+            new URLSearchParams({ path, line: String(position.line), character: String(position.character) }),
+            ctx
+        )
+
+        return new OrderedLocationSet(locations.map(location => ({ ...location, dumpId: this.dumpId })))
+    }
+
     /**
      * Return the hover content for the symbol at the given position.
      *
@@ -104,9 +146,29 @@ export class Database {
     }
 
     /**
-     * Query the definitions or references table of `db` for items that match the given moniker.
-     * Convert each result into an `InternalLocation`. The `pathTransformer` function is invoked
-     * on each result item to modify the resulting locations.
+     * Return the monikers attached to the type definitions of all ranges that contain the given
+     * position. The resulting list is grouped by range, ordered like `monikersByPosition`.
+     *
+     * @param path The path of the document.
+     * @param position The user's hover position.
+     * @param ctx The tracing context.
+     */
+    public typeDefinitionMonikersByPosition(
+        path: string,
+        position: lsp.Position,
+        ctx: TracingContext = {}
+    ): Promise<sqliteModels.MonikerData[][]> {
+        return this.request(
+            'typeDefinitionMonikersByPosition',
+            new URLSearchParams({ path, line: String(position.line), character: String(position.character) }),
+            ctx
+        )
+    }
+
+    /**
+     * Query the definitions, references, or implementations table of `db` for items that match
+     * the given moniker. Convert each result into an `InternalLocation`. The `pathTransformer`
+     * function is invoked on each result item to modify the resulting locations.
      *
      * @param model The constructor for the model type.
      * @param moniker The target moniker.
@@ -114,7 +176,10 @@ export class Database {
      * @param ctx The tracing context.
      */
     public async monikerResults(
-        model: typeof sqliteModels.DefinitionModel | typeof sqliteModels.ReferenceModel,
+        model:
+            | typeof sqliteModels.DefinitionModel
+            | typeof sqliteModels.ReferenceModel
+            | typeof sqliteModels.ImplementationModel,
         moniker: Pick<sqliteModels.MonikerData, 'scheme' | 'identifier'>,
         pagination: { skip?: number; take?: number },
         ctx: TracingContext = {}
@@ -133,7 +198,12 @@ export class Database {
         }>(
             'monikerResults',
             new URLSearchParams({
-                modelType: model === sqliteModels.DefinitionModel ? 'definition' : 'reference',
+                modelType:
+                    model === sqliteModels.DefinitionModel
+                        ? 'definition'
+                        : model === sqliteModels.ImplementationModel
+                        ? 'implementation'
+                        : 'reference',
                 scheme: moniker.scheme,
                 identifier: moniker.identifier,
                 ...p,
@@ -220,6 +220,45 @@ export function createLsifRouter(
         )
     )
 
+    router.get(
+        '/typeDefinitions',
+        validation.validationMiddleware([
+            validation.validateInt('repositoryId'),
+            validation.validateNonEmptyString('commit'),
+            validation.validateNonEmptyString('path'),
+            validation.validateInt('line'),
+            validation.validateInt('character'),
+            validation.validateInt('uploadId'),
+        ]),
+        wrap(
+            async (req: express.Request, res: express.Response<LocationsResponse>): Promise<void> => {
+                const { repositoryId, commit, path, line, character, uploadId }: FilePositionArgs = req.query
+                const ctx = createTracingContext(req, { repositoryId, commit, path })
+
+                const locations = await backend.typeDefinitions(
+                    repositoryId,
+                    commit,
+                    path,
+                    { line, character },
+                    uploadId,
+                    ctx
+                )
+                if (locations === undefined) {
+                    throw Object.assign(new Error('LSIF upload not found'), { status: 404 })
+                }
+
+                res.send({
+                    locations: locations.map(l => ({
+                        repositoryId: l.dump.repositoryId,
+                        commit: l.dump.commit,
+                        path: l.path,
+                        range: l.range,
+                    })),
+                })
+            }
+        )
+    )
+
     interface ReferencesQueryArgs extends FilePositionArgs {
         commit: string
         cursor: ReferencePaginationCursor | undefined
@@ -275,6 +314,56 @@ export function createLsifRouter(
         )
     )
 
+    router.get(
+        '/implementations',
+        validation.validationMiddleware([
+            validation.validateInt('repositoryId'),
+            validation.validateNonEmptyString('commit'),
+            validation.validateNonEmptyString('path'),
+            validation.validateInt('line'),
+            validation.validateInt('character'),
+            validation.validateInt('uploadId'),
+            validation.validateLimit,
+            validation.validateCursor<ReferencePaginationCursor>(),
+        ]),
+        wrap(
+            async (req: express.Request, res: express.Response<LocationsResponse>): Promise<void> => {
+                const { repositoryId, commit, path, line, character, uploadId, cursor }: ReferencesQueryArgs = req.query
+                const { limit } = extractLimitOffset(req.query, settings.DEFAULT_REFERENCES_PAGE_SIZE)
+                const ctx = createTracingContext(req, { repositoryId, commit, path })
+
+                const result = await backend.implementations(
+                    repositoryId,
+                    commit,
+                    path,
+                    { line, character },
+                    { limit, cursor },
+                    constants.DEFAULT_REFERENCES_REMOTE_DUMP_LIMIT,
+                    uploadId,
+                    ctx
+                )
+                if (result === undefined) {
+                    throw Object.assign(new Error('LSIF upload not found'), { status: 404 })
+                }
+
+                const { locations, newCursor } = result
+                const encodedCursor = encodeCursor<ReferencePaginationCursor>(newCursor)
+                if (encodedCursor) {
+                    res.set('Link', nextLink(req, { limit, cursor: encodedCursor }))
+                }
+
+                res.json({
+                    locations: locations.map(l => ({
+                        repositoryId: l.dump.repositoryId,
+                        commit: l.dump.commit,
+                        path: l.path,
+                        range: l.range,
+                    })),
+                })
+            }
+        )
+    )
+
     type HoverResponse = { text: string; range: lsp.Range } | null
 
     router.get(
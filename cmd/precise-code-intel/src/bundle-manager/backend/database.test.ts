@@ -6,12 +6,13 @@ import { convertLsif } from '../../worker/conversion/importer'
 import { PathExistenceChecker } from '../../worker/conversion/existence'
 import rmfr from 'rmfr'
 import * as uuid from 'uuid'
+import { createConnection } from 'typeorm'
 
 describe('Database', () => {
     let storageRoot!: string
     let database!: Database
 
-    const makeDatabase = async (filename: string): Promise<Database> => {
+    const makeDatabaseFile = async (filename: string): Promise<string> => {
         // Create a filesystem read stream for the given test file. This will cover
         // the cases where `yarn test` is run from the root or from the lsif directory.
         const sourceFile = nodepath.join(
@@ -32,9 +33,12 @@ describe('Database', () => {
             }),
         })
 
-        return new Database(1, databaseFile)
+        return databaseFile
     }
 
+    const makeDatabase = async (filename: string): Promise<Database> =>
+        new Database(1, await makeDatabaseFile(filename))
+
     beforeAll(async () => {
         storageRoot = await fs.mkdtemp('test-', { encoding: 'utf8' })
         database = await makeDatabase('lsif-go@ad3507cb.lsif.gz')
@@ -221,6 +225,34 @@ describe('Database', () => {
             ])
             expect(count).toEqual(1)
         })
+
+        it('should treat a missing implementations table as empty', async () => {
+            const databaseFile = await makeDatabaseFile('lsif-go@ad3507cb.lsif.gz')
+            const oldDatabase = new Database(2, databaseFile)
+
+            // Open the connection first, as opening it creates missing tables
+            expect(await oldDatabase.exists('cmd/lsif-go/main.go')).toEqual(true)
+
+            // Drop the table to mimic a dump converted before implementations were indexed
+            const connection = await createConnection({ type: 'sqlite', name: uuid.v4(), database: databaseFile })
+            try {
+                await connection.query('DROP TABLE implementations')
+            } finally {
+                await connection.close()
+            }
+
+            const { locations, count } = await oldDatabase.monikerResults(
+                sqliteModels.ImplementationModel,
+                {
+                    scheme: 'gomod',
+                    identifier: 'github.com/sourcegraph/lsif-go/protocol:Edge',
+                },
+                {}
+            )
+
+            expect(locations).toEqual([])
+            expect(count).toEqual(0)
+        })
     })
 })
 
@@ -133,6 +133,85 @@ export class Database {
         })
     }
 
+    /**
+     * Return a list of locations that define the type of the symbol at the given position.
+     *
+     * @param path The path of the document to which the position belongs.
+     * @param position The current hover position.
+     * @param ctx The tracing context.
+     */
+    public async typeDefinitions(
+        path: string,
+        position: lsp.Position,
+        ctx: TracingContext = {}
+    ): Promise<InternalLocation[]> {
+        return this.logAndTraceCall(ctx, 'Fetching type definitions', async ctx => {
+            const { document, ranges } = await this.getRangeByPosition(path, position, ctx)
+            if (!document || ranges.length === 0) {
+                return []
+            }
+
+            for (const range of ranges) {
+                if (!range.typeDefinitionResultId) {
+                    continue
+                }
+
+                const typeDefinitionResults = await this.getResultById(range.typeDefinitionResultId)
+                this.logSpan(ctx, 'type_definition_results', {
+                    typeDefinitionResultId: range.typeDefinitionResultId,
+                    typeDefinitionResults: typeDefinitionResults.slice(0, MAX_SPAN_ARRAY_LENGTH),
+                    numTypeDefinitionResults: typeDefinitionResults.length,
+                })
+
+                return this.convertRangesToInternalLocations(path, document, typeDefinitionResults)
+            }
+
+            return []
+        })
+    }
+
+    /**
+     * Return a list of unique locations that implement the symbol at the given position.
+     *
+     * @param path The path of the document to which the position belongs.
+     * @param position The current hover position.
+     * @param ctx The tracing context.
+     */
+    public async implementations(
+        path: string,
+        position: lsp.Position,
+        ctx: TracingContext = {}
+    ): Promise<OrderedLocationSet> {
+        return this.logAndTraceCall(ctx, 'Fetching implementations', async ctx => {
+            const { document, ranges } = await this.getRangeByPosition(path, position, ctx)
+            if (!document || ranges.length === 0) {
+                return new OrderedLocationSet()
+            }
+
+            const locationSet = new OrderedLocationSet()
+            for (const range of ranges) {
+                if (range.implementationResultId) {
+                    const implementationResults = await this.getResultById(range.implementationResultId)
+                    this.logSpan(ctx, 'implementation_results', {
+                        implementationResultId: range.implementationResultId,
+                        implementationResults: implementationResults.slice(0, MAX_SPAN_ARRAY_LENGTH),
+                        numImplementationResults: implementationResults.length,
+                    })
+
+                    for (const location of await this.convertRangesToInternalLocations(
+                        path,
+                        document,
+                        implementationResults
+                    )) {
+                        locationSet.push(location)
+                    }
+                }
+            }
+
+            return locationSet
+        })
+    }
+
     /**
      * Return the hover content for the symbol at the given position.
      *
@@ -196,9 +275,37 @@ export class Database {
     }
 
     /**
-     * Query the definitions or references table of `db` for items that match the given moniker.
-     * Convert each result into an `InternalLocation`. The `pathTransformer` function is invoked
-     * on each result item to modify the resulting locations.
+     * Return the monikers attached to the type definitions of all ranges that contain the given
+     * position. The resulting list is grouped by range, ordered like `monikersByPosition`. These
+     * monikers can be used to find type definitions that are not in this dump.
+     *
+     * @param path The path of the document.
+     * @param position The user's hover position.
+     * @param ctx The tracing context.
+     */
+    public async typeDefinitionMonikersByPosition(
+        path: string,
+        position: lsp.Position,
+        ctx: TracingContext = {}
+    ): Promise<sqliteModels.MonikerData[][]> {
+        const { document, ranges } = await this.getRangeByPosition(path, position, ctx)
+        if (!document) {
+            return []
+        }
+
+        return ranges.map(range =>
+            Array.from(range.typeDefinitionMonikerIds || []).map(monikerId =>
+                mustGet(document.monikers, monikerId, 'moniker')
+            )
+        )
+    }
+
+    /**
+     * Query the definitions, references, or implementations table of `db` for items that match
+     * the given moniker. Convert each result into an `InternalLocation`. The `pathTransformer`
+     * function is invoked on each result item to modify the resulting locations. Dumps converted
+     * before implementations were indexed have no implementations table, which is treated like
+     * an empty one.
      *
      * @param model The constructor for the model type.
      * @param moniker The target moniker.
@@ -206,25 +313,33 @@ export class Database {
      * @param ctx The tracing context.
      */
     public monikerResults(
-        model: typeof sqliteModels.DefinitionModel | typeof sqliteModels.ReferenceModel,
+        model:
+            | typeof sqliteModels.DefinitionModel
+            | typeof sqliteModels.ReferenceModel
+            | typeof sqliteModels.ImplementationModel,
         moniker: Pick<sqliteModels.MonikerData, 'scheme' | 'identifier'>,
         pagination: { skip?: number; take?: number },
         ctx: TracingContext = {}
     ): Promise<{ locations: InternalLocation[]; count: number }> {
         return this.logAndTraceCall(ctx, 'Fetching moniker results', async ctx => {
-            const [results, count] = await this.withConnection(
-                connection =>
-                    connection
-                        .getRepository<sqliteModels.DefinitionModel | sqliteModels.ReferenceModel>(model)
-                        .findAndCount({
-                            where: {
-                                scheme: moniker.scheme,
-                                identifier: moniker.identifier,
-                            },
-                            ...pagination,
-                        }),
-                ctx.logger
-            )
+            type SymbolModel =
+                | sqliteModels.DefinitionModel
+                | sqliteModels.ReferenceModel
+                | sqliteModels.ImplementationModel
+
+            const [results, count] = await this.withConnection<[SymbolModel[], number]>(async connection => {
+                if (!(await hasTable(connection, connection.getMetadata(model).tableName))) {
+                    return [[], 0]
+                }
+
+                return connection.getRepository<SymbolModel>(model).findAndCount({
+                    where: {
+                        scheme: moniker.scheme,
+                        identifier: moniker.identifier,
+                    },
+                    ...pagination,
+                })
+            }, ctx.logger)
 
             this.logSpan(ctx, 'symbol_results', {
                 moniker,
@@ -332,11 +447,11 @@ export class Database {
     }
 
     /**
-     * Convert a set of range-document pairs (from a definition or reference query) into
-     * a set of `InternalLocation` object. Each pair holds the range identifier as well as
-     * the document path. For document paths matching the loaded document, find the range
-     * data locally. For all other paths, find the document in this database and find the
-     * range in that document.
+     * Convert a set of range-document pairs (from a definition, reference, type definition, or
+     * implementation query) into a set of `InternalLocation` object. Each pair holds the range
+     * identifier as well as the document path. For document paths matching the loaded document,
+     * find the range data locally. For all other paths, find the document in this database and
+     * find the range in that document.
      *
      * @param path The path of the document for this query.
      * @param document The document object for this query.
@@ -380,7 +495,7 @@ export class Database {
      * document paths by looking into the result chunks table and parsing the
      * data associated with the given identifier.
      *
-     * @param id The identifier of the definition or reference result.
+     * @param id The identifier of the definition, reference, type definition, or implementation result.
      */
     private async getResultById(
         id: sqliteModels.DefinitionReferenceResultId
@@ -538,6 +653,19 @@ export function comparePosition(range: sqliteModels.RangeData, position: lsp.Pos
     return 0
 }
 
+/**
+ * Determine if the SQLite database has a table with the given name.
+ *
+ * @param connection The SQLite connection.
+ * @param tableName The name of the table.
+ */
+async function hasTable(connection: Connection, tableName: string): Promise<boolean> {
+    const rows: unknown[] = await connection.query("SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = ?", [
+        tableName,
+    ])
+    return rows.length > 0
+}
+
 /**
  * Construct an LSP range from a flat range.
  *
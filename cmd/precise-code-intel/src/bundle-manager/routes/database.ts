@@ -115,6 +115,58 @@ export function createDatabaseRouter(logger: Logger): express.Router {
         )
     )
 
+    interface TypeDefinitionsQueryArgs {
+        path: string
+        line: number
+        character: number
+    }
+
+    type TypeDefinitionsResponse = InternalLocation[]
+
+    router.get(
+        '/dbs/:id([0-9]+)/typeDefinitions',
+        validation.validationMiddleware([
+            validation.validateNonEmptyString('path'),
+            validation.validateInt('line'),
+            validation.validateInt('character'),
+        ]),
+        wrap(
+            async (req: express.Request, res: express.Response<TypeDefinitionsResponse>): Promise<void> => {
+                const { path, line, character }: TypeDefinitionsQueryArgs = req.query
+                await withDatabase(req, res, (database, ctx) =>
+                    database.typeDefinitions(path, { line, character }, ctx)
+                )
+            }
+        )
+    )
+
+    interface ImplementationsQueryArgs {
+        path: string
+        line: number
+        character: number
+    }
+
+    type ImplementationsResponse = InternalLocation[]
+
+    router.get(
+        '/dbs/:id([0-9]+)/implementations',
+        validation.validationMiddleware([
+            validation.validateNonEmptyString('path'),
+            validation.validateInt('line'),
+            validation.validateInt('character'),
+        ]),
+        wrap(
+            async (req: express.Request, res: express.Response<ImplementationsResponse>): Promise<void> => {
+                const { path, line, character }: ImplementationsQueryArgs = req.query
+                await withDatabase(
+                    req,
+                    res,
+                    async (database, ctx) => (await database.implementations(path, { line, character }, ctx)).values
+                )
+            }
+        )
+    )
+
     interface HoverQueryArgs {
         path: string
         line: number
@@ -163,6 +215,34 @@ export function createDatabaseRouter(logger: Logger): express.Router {
         )
     )
 
+    interface TypeDefinitionMonikersByPositionQueryArgs {
+        path: string
+        line: number
+        character: number
+    }
+
+    type TypeDefinitionMonikersByPositionResponse = sqliteModels.MonikerData[][]
+
+    router.get(
+        '/dbs/:id([0-9]+)/typeDefinitionMonikersByPosition',
+        validation.validationMiddleware([
+            validation.validateNonEmptyString('path'),
+            validation.validateInt('line'),
+            validation.validateInt('character'),
+        ]),
+        wrap(
+            async (
+                req: express.Request,
+                res: express.Response<TypeDefinitionMonikersByPositionResponse>
+            ): Promise<void> => {
+                const { path, line, character }: TypeDefinitionMonikersByPositionQueryArgs = req.query
+                await withDatabase(req, res, (database, ctx) =>
+                    database.typeDefinitionMonikersByPosition(path, { line, character }, ctx)
+                )
+            }
+        )
+    )
+
     interface MonikerResultsQueryArgs {
         modelType: string
         scheme: string
@@ -190,7 +270,11 @@ export function createDatabaseRouter(logger: Logger): express.Router {
                 const { modelType, scheme, identifier, skip, take }: MonikerResultsQueryArgs = req.query
                 await withDatabase(req, res, (database, ctx) =>
                     database.monikerResults(
-                        modelType === 'definition' ? sqliteModels.DefinitionModel : sqliteModels.ReferenceModel,
+                        modelType === 'definition'
+                            ? sqliteModels.DefinitionModel
+                            : modelType === 'implementation'
+                            ? sqliteModels.ImplementationModel
+                            : sqliteModels.ReferenceModel,
                         { scheme, identifier },
                         { skip, take },
                         ctx
@@ -7,7 +7,13 @@ export type DocumentPath = string
 export type RangeId = lsif.Id
 export type DefinitionResultId = lsif.Id
 export type ReferenceResultId = lsif.Id
-export type DefinitionReferenceResultId = DefinitionResultId | ReferenceResultId
+export type TypeDefinitionResultId = lsif.Id
+export type ImplementationResultId = lsif.Id
+export type DefinitionReferenceResultId =
+    | DefinitionResultId
+    | ReferenceResultId
+    | TypeDefinitionResultId
+    | ImplementationResultId
 export type HoverResultId = lsif.Id
 export type MonikerId = lsif.Id
 export type PackageInformationId = lsif.Id
@@ -75,7 +81,8 @@ export class DocumentModel {
 /**
  * An entity within the database describing LSIF data for a single repository and
  * commit pair. This contains a JSON-encoded `ResultChunk` object that describes
- * a subset of the definition and reference results of the dump.
+ * a subset of the definition, reference, type definition, and implementation
+ * results of the dump.
  */
 @Entity({ name: 'resultChunks' })
 export class ResultChunkModel {
@@ -96,8 +103,8 @@ export class ResultChunkModel {
 }
 
 /**
- * The base class for `DefinitionModel` and `ReferenceModel` as they have identical
- * column descriptions.
+ * The base class for `DefinitionModel`, `ReferenceModel`, and `ImplementationModel`
+ * as they have identical column descriptions.
  */
 class Symbols {
     /** The number of model instances that can be inserted at once. */
@@ -154,6 +161,15 @@ export class DefinitionModel extends Symbols {}
 @Index(['scheme', 'identifier'])
 export class ReferenceModel extends Symbols {}
 
+/**
+ * An entity within the database describing LSIF data for a single repository and commit
+ * pair. This maps monikers to the range and the document of an implementation of the
+ * moniker (e.g. a class implementing an imported interface).
+ */
+@Entity({ name: 'implementations' })
+@Index(['scheme', 'identifier'])
+export class ImplementationModel extends Symbols {}
+
 /**
  * Data for a single document within an LSIF dump. The data here can answer definitions,
  * references, and hover queries if the results are all contained within the same document.
@@ -206,8 +222,8 @@ export interface DocumentPathRangeId {
 }
 
 /**
- * A result chunk is a subset of the definition and reference result data for the
- * LSIF dump. Results are inserted into chunks based on the hash code of their
+ * A result chunk is a subset of the definition, reference, type definition, and
+ * implementation result data for the LSIF dump. Results are inserted into chunks based on the hash code of their
  * identifier (thus every chunk has a roughly proportional amount of data).
  */
 export interface ResultChunkData {
@@ -218,8 +234,8 @@ export interface ResultChunkData {
     documentPaths: Map<DocumentId, DocumentPath>
 
     /**
-     * A map from definition or reference result identifiers to the ranges that
-     * compose the result set. Each range is paired with the identifier of the
+     * A map from definition, reference, type definition, or implementation result
+     * identifiers to the ranges that compose the result set. Each range is paired with the identifier of the
      * document in which it can be found.
      */
     documentIdRangeIds: Map<DefinitionReferenceResultId, DocumentIdRangeId[]>
@@ -258,6 +274,28 @@ export interface RangeData {
      */
     referenceResultId?: ReferenceResultId
 
+    /**
+     * The identifier of the type definition result attached to this range, if one exists.
+     * The type definition result object can be queried by its identifier within the
+     * containing document.
+     */
+    typeDefinitionResultId?: TypeDefinitionResultId
+
+    /**
+     * The non-local monikers attached to the ranges of the type definition result of this
+     * range. These are used to find the type definition in another dump when the type is
+     * defined outside of this one. The moniker objects can be queried by their identifiers
+     * within the containing document.
+     */
+    typeDefinitionMonikerIds?: Set<MonikerId>
+
+    /**
+     * The identifier of the implementation result attached to this range, if one exists.
+     * The implementation result object can be queried by its identifier within the
+     * containing document.
+     */
+    implementationResultId?: ImplementationResultId
+
     /**
      * The identifier of the hover result attached to this range, if one exists. The
      * hover result object can be queried by its identifier within the containing
@@ -302,4 +340,11 @@ export interface PackageInformationData {
 }
 
 /** The entities composing the SQLite database models. */
-export const entities = [DefinitionModel, DocumentModel, MetaModel, ReferenceModel, ResultChunkModel]
+export const entities = [
+    DefinitionModel,
+    DocumentModel,
+    ImplementationModel,
+    MetaModel,
+    ReferenceModel,
+    ResultChunkModel,
+]
@@ -118,6 +118,99 @@ describe('Correlator', () => {
         expect(refs?.get('2')).toEqual(['3'])
     })
 
+    it('should attach type definition and implementation results', () => {
+        const c = new Correlator()
+        c.insert({
+            id: '1',
+            type: lsif.ElementTypes.vertex,
+            label: lsif.VertexLabels.metaData,
+            positionEncoding: 'utf-16',
+            version: '0.4.3',
+            projectRoot: 'file:///lsif-test',
+        })
+
+        c.insert({
+            id: '2',
+            type: lsif.ElementTypes.vertex,
+            label: lsif.VertexLabels.document,
+            uri: 'file:///lsif-test/index.ts',
+            languageId: 'typescript',
+        })
+
+        c.insert({
+            id: '3',
+            type: lsif.ElementTypes.vertex,
+            label: lsif.VertexLabels.range,
+            start: { line: 1, character: 10 },
+            end: { line: 1, character: 13 },
+        })
+
+        c.insert({
+            id: '4',
+            type: lsif.ElementTypes.vertex,
+            label: lsif.VertexLabels.range,
+            start: { line: 5, character: 6 },
+            end: { line: 5, character: 9 },
+        })
+
+        c.insert({
+            id: '5',
+            type: lsif.ElementTypes.vertex,
+            label: lsif.VertexLabels.resultSet,
+        })
+
+        c.insert({
+            id: '6',
+            type: lsif.ElementTypes.vertex,
+            label: lsif.VertexLabels.typeDefinitionResult,
+        })
+
+        c.insert({
+            id: '7',
+            type: lsif.ElementTypes.vertex,
+            label: lsif.VertexLabels.implementationResult,
+        })
+
+        c.insert({
+            id: '8',
+            type: lsif.ElementTypes.edge,
+            label: lsif.EdgeLabels.textDocument_typeDefinition,
+            outV: '3',
+            inV: '6',
+        })
+
+        c.insert({
+            id: '9',
+            type: lsif.ElementTypes.edge,
+            label: lsif.EdgeLabels.textDocument_implementation,
+            outV: '5',
+            inV: '7',
+        })
+
+        c.insert({
+            id: '10',
+            type: lsif.ElementTypes.edge,
+            label: lsif.EdgeLabels.item,
+            outV: '6',
+            inVs: ['4'],
+            document: '2',
+        })
+
+        c.insert({
+            id: '11',
+            type: lsif.ElementTypes.edge,
+            label: lsif.EdgeLabels.item,
+            outV: '7',
+            inVs: ['4'],
+            document: '2',
+        })
+
+        expect(c.rangeData.get('3')?.typeDefinitionResultId).toEqual('6')
+        expect(c.resultSetData.get('5')?.implementationResultId).toEqual('7')
+        expect(c.typeDefinitionData.get('6')?.get('2')).toEqual(['4'])
+        expect(c.implementationData.get('7')?.get('2')).toEqual(['4'])
+    })
+
     it('should correlate linked reference results', () => {
         const c = new Correlator()
 
@@ -23,6 +23,12 @@ export interface ResultSetData {
     /** The identifier of the reference result attached to this result set. */
     referenceResultId?: sqliteModels.ReferenceResultId
 
+    /** The identifier of the type definition result attached to this result set. */
+    typeDefinitionResultId?: sqliteModels.TypeDefinitionResultId
+
+    /** The identifier of the implementation result attached to this result set. */
+    implementationResultId?: sqliteModels.ImplementationResultId
+
     /** The identifier of the hover result attached to this result set. */
     hoverResultId?: sqliteModels.HoverResultId
 
@@ -69,6 +75,14 @@ export class Correlator {
         sqliteModels.ReferenceResultId,
         DefaultMap<sqliteModels.DocumentId, lsif.RangeId[]>
     >()
+    public typeDefinitionData = new Map<
+        sqliteModels.TypeDefinitionResultId,
+        DefaultMap<sqliteModels.DocumentId, lsif.RangeId[]>
+    >()
+    public implementationData = new Map<
+        sqliteModels.ImplementationResultId,
+        DefaultMap<sqliteModels.DocumentId, lsif.RangeId[]>
+    >()
 
     /** A disjoint set of monikers linked by `nextMoniker` edges. */
     public linkedMonikers = new DisjointSet<sqliteModels.MonikerId>()
@@ -145,6 +159,20 @@ export class Correlator {
                     )
                     break
 
+                case lsif.VertexLabels.typeDefinitionResult:
+                    this.typeDefinitionData.set(
+                        element.id,
+                        new DefaultMap<sqliteModels.DocumentId, lsif.RangeId[]>(() => [])
+                    )
+                    break
+
+                case lsif.VertexLabels.implementationResult:
+                    this.implementationData.set(
+                        element.id,
+                        new DefaultMap<sqliteModels.DocumentId, lsif.RangeId[]>(() => [])
+                    )
+                    break
+
                 case lsif.VertexLabels.hoverResult:
                     this.hoverData.set(element.id, normalizeHover(element.result))
                     break
@@ -167,8 +195,7 @@ export class Correlator {
                 default:
                     // Some vertex labels are not yet supported:
                     //
-                    // - typeDefinitionResult
-                    // - implementationResult
+                    // - declarationResult
                     // - ... others in the future
                     //
                     // We keep track of these unsupported vertexes so that we
@@ -201,6 +228,14 @@ export class Correlator {
                     this.handleReferenceEdge(element)
                     break
 
+                case lsif.EdgeLabels.textDocument_typeDefinition:
+                    this.handleTypeDefinitionEdge(element)
+                    break
+
+                case lsif.EdgeLabels.textDocument_implementation:
+                    this.handleImplementationEdge(element)
+                    break
+
                 case lsif.EdgeLabels.textDocument_hover:
                     this.handleHoverEdge(element)
                     break
@@ -271,8 +306,8 @@ export class Correlator {
     }
 
     /**
-     * Update definition and reference fields from an item edge. Ensures all
-     * referenced vertices are defined.
+     * Update definition, reference, type definition, and implementation fields from
+     * an item edge. Ensures all referenced vertices are defined.
      *
      * @param edge The item edge.
      */
@@ -303,6 +338,35 @@ export class Correlator {
             return
         }
 
+        if (this.typeDefinitionData.has(edge.outV)) {
+            const documentMap = mustGet(this.typeDefinitionData, edge.outV, 'typeDefinitionResult')
+            const rangeIds = documentMap.getOrDefault(edge.document)
+            for (const inV of edge.inVs) {
+                mustGet(this.rangeData, inV, 'range')
+                rangeIds.push(inV)
+            }
+
+            return
+        }
+
+        if (this.implementationData.has(edge.outV)) {
+            const documentMap = mustGet(this.implementationData, edge.outV, 'implementationResult')
+            const rangeIds = documentMap.getOrDefault(edge.document)
+            for (const inV of edge.inVs) {
+                // Implementation results may also refer to other implementation
+                // results. These are not yet supported.
+                if (this.implementationData.has(inV)) {
+                    this.logger.debug('Skipping item edge to an implementation result', { edge })
+                    continue
+                }
+
+                mustGet(this.rangeData, inV, 'range')
+                rangeIds.push(inV)
+            }
+
+            return
+        }
+
         if (this.unsupportedVertexes.has(edge.outV)) {
             this.logger.debug('Skipping edge from an unsupported vertex', { edge })
             return
@@ -416,6 +480,42 @@ export class Correlator {
         outV.referenceResultId = edge.inV
     }
 
+    /**
+     * Sets the type definition result of the specified range or result set. Ensures all
+     * referenced vertices are defined.
+     *
+     * @param edge The textDocument/typeDefinition edge.
+     */
+    private handleTypeDefinitionEdge(edge: lsif.textDocument_typeDefinition): void {
+        const outV = mustGetFromEither<lsif.RangeId, sqliteModels.RangeData, ResultSetId, ResultSetData>(
+            this.rangeData,
+            this.resultSetData,
+            edge.outV,
+            'range/resultSet'
+        )
+
+        mustGet(this.typeDefinitionData, edge.inV, 'typeDefinitionResult')
+        outV.typeDefinitionResultId = edge.inV
+    }
+
+    /**
+     * Sets the implementation result of the specified range or result set. Ensures all
+     * referenced vertices are defined.
+     *
+     * @param edge The textDocument/implementation edge.
+     */
+    private handleImplementationEdge(edge: lsif.textDocument_implementation): void {
+        const outV = mustGetFromEither<lsif.RangeId, sqliteModels.RangeData, ResultSetId, ResultSetData>(
+            this.rangeData,
+            this.resultSetData,
+            edge.outV,
+            'range/resultSet'
+        )
+
+        mustGet(this.implementationData, edge.inV, 'implementationResult')
+        outV.implementationResultId = edge.inV
+    }
+
     /**
      * Sets the hover result of the specified range or result set. Ensures all referenced
      * vertices are defined.
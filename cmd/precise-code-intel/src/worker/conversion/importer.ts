@@ -29,7 +29,7 @@ const inserterMetrics = {
  * something in the future we'll need to consider a number of previous version
  * while we update or re-process the already-uploaded data.
  */
-const INTERNAL_LSIF_VERSION = '0.1.0'
+const INTERNAL_LSIF_VERSION = '0.2.0'
 
 /**
  * Populate a SQLite database with the given input stream. Returns the
@@ -71,8 +71,8 @@ export async function convertLsif({
 
 /**
  * Correlate each vertex and edge together, then populate the provided entity manager
- * with the document, definition, and reference information. Returns the package and
- * external reference data needed to populate the dependency tables in Postgres.
+ * with the document, definition, reference, and implementation information. Returns the
+ * package and external reference data needed to populate the dependency tables in Postgres.
  *
  * @param entityManager A transactional SQLite entity manager.
  * @param path The filepath containing a gzipped compressed stream of JSON lines composing the LSIF dump.
@@ -103,7 +103,8 @@ export async function importLsif(
     // some indexers (such as lsif-tsc) that index dependent projects into the same
     // dump as the target project. For each set of documents that share a path, we
     // choose one document to be the canonical representative and merge the contains,
-    // definition, and reference data into the unique canonical document.
+    // definition, reference, type definition, and implementation data into the unique
+    // canonical document.
     await logAndTraceCall(ctx, 'Merging documents', () => mergeDocuments(correlator))
 
     // Determine which reference results are linked together. Determine a canonical
@@ -121,7 +122,11 @@ export async function importLsif(
     await pathExistenceChecker.warmCache(Array.from(correlator.documentPaths.values()))
 
     // Calculate the number of result chunks that we'll attempt to populate
-    const numResults = correlator.definitionData.size + correlator.referenceData.size
+    const numResults =
+        correlator.definitionData.size +
+        correlator.referenceData.size +
+        correlator.typeDefinitionData.size +
+        correlator.implementationData.size
     const numResultChunks = Math.min(
         settings.MAX_NUM_RESULT_CHUNKS,
         Math.floor(numResults / settings.RESULTS_PER_RESULT_CHUNK) || 1
@@ -161,8 +166,8 @@ export async function importLsif(
         await resultChunkInserter.flush()
     })
 
-    // Insert definitions and references
-    await logAndTraceCall(ctx, 'Populating definitions and references', async () => {
+    // Insert definitions, references, and implementations
+    await logAndTraceCall(ctx, 'Populating definitions, references, and implementations', async () => {
         const definitionInserter = new TableInserter(
             entityManager,
             sqliteModels.DefinitionModel,
@@ -175,14 +180,22 @@ export async function importLsif(
             sqliteModels.ReferenceModel.BatchSize,
             inserterMetrics
         )
+        const implementationInserter = new TableInserter(
+            entityManager,
+            sqliteModels.ImplementationModel,
+            sqliteModels.ImplementationModel.BatchSize,
+            inserterMetrics
+        )
         await populateDefinitionsAndReferencesTables(
             correlator,
             definitionInserter,
             referenceInserter,
+            implementationInserter,
             pathExistenceChecker
         )
         await definitionInserter.flush()
         await referenceInserter.flush()
+        await implementationInserter.flush()
     })
 
     // Return data to populate dependency tables in Postgres
@@ -210,6 +223,22 @@ async function populateDocumentsTable(
         canonicalizeItem(correlator, canonicalReferenceResultIds, rangeId, range)
     }
 
+    // Attach the monikers of the type definition of each range to the range. The ranges
+    // of a type definition result may be in documents that are not part of the dump (e.g.
+    // declaration files of dependencies), so these monikers are used to find the type
+    // definition in the dump that defines it.
+    const typeDefinitionMonikers = gatherTypeDefinitionMonikers(correlator)
+    for (const range of correlator.rangeData.values()) {
+        if (range.typeDefinitionResultId === undefined) {
+            continue
+        }
+
+        const monikerIds = typeDefinitionMonikers.get(range.typeDefinitionResultId)
+        if (monikerIds !== undefined && monikerIds.size > 0) {
+            range.typeDefinitionMonikerIds = monikerIds
+        }
+    }
+
     // Gather and insert document data that includes the ranges contained in the document,
     // any associated hover data, and any associated moniker data/package information.
     // Each range also has identifiers that correlate to a definition or reference result
@@ -300,9 +329,11 @@ async function populateResultChunksTable(
         }
     }
 
-    // Add definitions and references to result chunks
+    // Add definitions, references, type definitions, and implementations to result chunks
     chunkResults(correlator.definitionData)
     chunkResults(correlator.referenceData)
+    chunkResults(correlator.typeDefinitionData)
+    chunkResults(correlator.implementationData)
 
     for (const [id, resultChunk] of resultChunks.entries()) {
         // Empty chunk, no need to serialize as it will never be queried
@@ -321,22 +352,24 @@ async function populateResultChunksTable(
 }
 
 /**
- * Correlate and insert all definition and reference entries for this dump.
+ * Correlate and insert all definition, reference, and implementation entries for this dump.
  *
  * @param correlator The correlator with all vertices and edges inserted.
  * @param definitionInserter The inserter for the definitions table.
  * @param referenceInserter The inserter for the references table.
+ * @param implementationInserter The inserter for the implementations table.
  * @param pathExistenceChecker An object that tracks whether a path is visible within the LSIF dump.
  */
 async function populateDefinitionsAndReferencesTables(
     correlator: Correlator,
     definitionInserter: TableInserter<sqliteModels.DefinitionModel, new () => sqliteModels.DefinitionModel>,
     referenceInserter: TableInserter<sqliteModels.ReferenceModel, new () => sqliteModels.ReferenceModel>,
+    implementationInserter: TableInserter<sqliteModels.ImplementationModel, new () => sqliteModels.ImplementationModel>,
     pathExistenceChecker: PathExistenceChecker
 ): Promise<void> {
-    // Determine the set of monikers that are attached to a definition or a
-    // reference result. Correlating information in this way has two benefits:
-    //   (1) it reduces duplicates in the definitions and references tables
+    // Determine the set of monikers that are attached to a definition, reference, or
+    // implementation result. Correlating information in this way has two benefits:
+    //   (1) it reduces duplicates in the definitions, references, and implementations tables
     //   (2) it stop us from re-iterating over the range data of the entire
     //       LSIF dump, which is by far the largest proportion of data.
 
@@ -346,6 +379,9 @@ async function populateDefinitionsAndReferencesTables(
     const referenceMonikers = new DefaultMap<sqliteModels.ReferenceResultId, Set<sqliteModels.MonikerId>>(
         () => new Set()
     )
+    const implementationMonikers = new DefaultMap<sqliteModels.ImplementationResultId, Set<sqliteModels.MonikerId>>(
+        () => new Set()
+    )
 
     for (const range of correlator.rangeData.values()) {
         if (range.monikerIds.size === 0) {
@@ -365,14 +401,21 @@ async function populateDefinitionsAndReferencesTables(
                 set.add(monikerId)
             }
         }
+
+        if (range.implementationResultId !== undefined) {
+            const set = implementationMonikers.getOrDefault(range.implementationResultId)
+            for (const monikerId of range.monikerIds) {
+                set.add(monikerId)
+            }
+        }
     }
 
     const insertMonikerRanges = async (
         data: Map<sqliteModels.DefinitionReferenceResultId, Map<sqliteModels.DocumentId, lsif.RangeId[]>>,
         monikers: Map<sqliteModels.DefinitionReferenceResultId, Set<lsif.RangeId>>,
         inserter: TableInserter<
-            sqliteModels.DefinitionModel | sqliteModels.ReferenceModel,
-            new () => sqliteModels.DefinitionModel | sqliteModels.ReferenceModel
+            sqliteModels.DefinitionModel | sqliteModels.ReferenceModel | sqliteModels.ImplementationModel,
+            new () => sqliteModels.DefinitionModel | sqliteModels.ReferenceModel | sqliteModels.ImplementationModel
         >
     ): Promise<void> => {
         for (const [id, documentRanges] of data) {
@@ -391,8 +434,8 @@ async function populateDefinitionsAndReferencesTables(
                 for (const [documentId, rangeIds] of documentRanges) {
                     const documentPath = mustGet(correlator.documentPaths, documentId, 'documentPath')
 
-                    // Skip definitions or references that point to a document that are not
-                    // present in the dump. Including this would cause a query that always
+                    // Skip definitions, references, or implementations that point to a document that
+                    // are not present in the dump. Including this would cause a query that always
                     // fails when it cannot resolve the missing document data.
                     if (!pathExistenceChecker.shouldIncludePath(documentPath)) {
                         continue
@@ -413,9 +456,36 @@ async function populateDefinitionsAndReferencesTables(
         }
     }
 
-    // Insert definitions and references records
+    // Insert definitions, references, and implementations records
     await insertMonikerRanges(correlator.definitionData, definitionMonikers, definitionInserter)
     await insertMonikerRanges(correlator.referenceData, referenceMonikers, referenceInserter)
+    await insertMonikerRanges(correlator.implementationData, implementationMonikers, implementationInserter)
+}
+
+/**
+ * Return the set of non-local monikers attached to the ranges of each type definition
+ * result. This must be run after the monikers of each range have been canonicalized.
+ *
+ * @param correlator The correlator with all vertices and edges inserted.
+ */
+function gatherTypeDefinitionMonikers(
+    correlator: Correlator
+): Map<sqliteModels.TypeDefinitionResultId, Set<sqliteModels.MonikerId>> {
+    const typeDefinitionMonikers = new Map<sqliteModels.TypeDefinitionResultId, Set<sqliteModels.MonikerId>>()
+    for (const [id, documentRanges] of correlator.typeDefinitionData) {
+        const monikerIds = new Set<sqliteModels.MonikerId>()
+        for (const rangeIds of documentRanges.values()) {
+            for (const rangeId of rangeIds) {
+                for (const monikerId of mustGet(correlator.rangeData, rangeId, 'range').monikerIds) {
+                    monikerIds.add(monikerId)
+                }
+            }
+        }
+
+        typeDefinitionMonikers.set(id, monikerIds)
+    }
+
+    return typeDefinitionMonikers
 }
 
 /**
@@ -518,6 +588,8 @@ function mergeDocuments(correlator: Correlator): void {
         mergeContains(id, canonicalId, correlator.containsData)
         mergeDefinitionReferences(id, canonicalId, correlator.definitionData)
         mergeDefinitionReferences(id, canonicalId, correlator.referenceData)
+        mergeDefinitionReferences(id, canonicalId, correlator.typeDefinitionData)
+        mergeDefinitionReferences(id, canonicalId, correlator.implementationData)
 
         // Discard the document data as a flag to prevent inserting one
         // of the documents subsumed by the canonical representative.
@@ -631,9 +703,9 @@ function canonicalizeReferenceResults(
     return canonicalReferenceResultIds
 }
 /**
- * Flatten the definition result, reference result, hover results, and monikers of range
- * and result set items by following next links in the graph. This needs to be run over
- * each range before committing them to a document.
+ * Flatten the definition result, reference result, type definition result, implementation
+ * result, hover results, and monikers of range and result set items by following next links
+ * in the graph. This needs to be run over each range before committing them to a document.
  *
  * @param correlator The correlator with all vertices and edges inserted.
  * @param canonicalReferenceResultIds A map from reference result identifiers to its canonical identifier.
@@ -673,8 +745,8 @@ function canonicalizeItem(
             monikers.add(monikerId)
         }
 
-        // If we do not have a definition, reference, or hover result, take the result
-        // value from the next item.
+        // If we do not have a definition, reference, type definition, implementation, or
+        // hover result, take the result value from the next item.
 
         if (item.definitionResultId === undefined) {
             item.definitionResultId = nextItem.definitionResultId
@@ -684,6 +756,14 @@ function canonicalizeItem(
             item.referenceResultId = nextItem.referenceResultId
         }
 
+        if (item.typeDefinitionResultId === undefined) {
+            item.typeDefinitionResultId = nextItem.typeDefinitionResultId
+        }
+
+        if (item.implementationResultId === undefined) {
+            item.implementationResultId = nextItem.implementationResultId
+        }
+
         if (item.hoverResultId === undefined) {
             item.hoverResultId = nextItem.hoverResultId
         }
@@ -762,6 +842,9 @@ function gatherDocument(
         for (const monikerId of range.monikerIds) {
             addMoniker(monikerId)
         }
+        for (const monikerId of range.typeDefinitionMonikerIds || []) {
+            addMoniker(monikerId)
+        }
 
         document.ranges.set(id, range)
     }
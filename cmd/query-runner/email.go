@@ -29,6 +29,14 @@ func (n *notifier) emailNotify(ctx context.Context) {
 		return
 	}
 
+	matches := make([]emailMatch, 0, maxNotificationMatches)
+	for _, m := range n.newMatches {
+		if len(matches) == maxNotificationMatches {
+			break
+		}
+		matches = append(matches, emailMatch{Name: matchName(m), URL: m.URL})
+	}
+
 	// Send tx emails asynchronously.
 	go func() {
 		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
@@ -44,23 +52,27 @@ func (n *notifier) emailNotify(ctx context.Context) {
 			}
 
 			plural := ""
-			if n.results.Data.Search.Results.ApproximateResultCount != "1" {
+			if len(n.newMatches) != 1 {
 				plural = "s"
 			}
 			if err := sendEmail(ctx, recipient.spec.userID, "results", newSearchResultsEmailTemplates, struct {
-				URL                    string
-				Description            string
-				Query                  string
-				ApproximateResultCount string
-				Ownership              string
-				PluralResults          string
+				URL           string
+				Description   string
+				Query         string
+				ResultCount   int
+				Matches       []emailMatch
+				MoreCount     int
+				Ownership     string
+				PluralResults string
 			}{
-				URL:                    searchURL(n.newQuery, utmSourceEmail),
-				Description:            n.query.Description,
-				Query:                  n.query.Query,
-				ApproximateResultCount: n.results.Data.Search.Results.ApproximateResultCount,
-				Ownership:              ownership,
-				PluralResults:          plural,
+				URL:           searchURL(n.newQuery, utmSourceEmail),
+				Description:   n.query.Description,
+				Query:         n.query.Query,
+				ResultCount:   len(n.newMatches),
+				Matches:       matches,
+				MoreCount:     len(n.newMatches) - len(matches),
+				Ownership:     ownership,
+				PluralResults: plural,
 			}); err != nil {
 				log15.Error("Failed to send email notification for new saved search results.", "userID", recipient.spec.userID, "error", err)
 			}
@@ -68,20 +80,33 @@ func (n *notifier) emailNotify(ctx context.Context) {
 	}()
 }
 
+type emailMatch struct {
+	Name string
+	URL  string
+}
+
 var newSearchResultsEmailTemplates = txemail.MustValidate(txtypes.Templates{
-	Subject: `[{{.ApproximateResultCount}} new result{{.PluralResults}}] {{.Description}}`,
+	Subject: `[{{.ResultCount}} new result{{.PluralResults}}] {{.Description}}`,
 	Text: `
-{{.ApproximateResultCount}} new search result{{.PluralResults}} found for {{.Ownership}} saved search:
+{{.ResultCount}} new search result{{.PluralResults}} found for {{.Ownership}} saved search:
 
   "{{.Description}}"
+{{range .Matches}}
+  - {{.Name}}: {{.URL}}{{end}}{{if .MoreCount}}
+  - ...and {{.MoreCount}} more{{end}}
 
 View the new result{{.PluralResults}} on Sourcegraph: {{.URL}}
 `,
 	HTML: `
-<strong>{{.ApproximateResultCount}}</strong> new search result{{.PluralResults}} found for {{.Ownership}} saved search:
+<strong>{{.ResultCount}}</strong> new search result{{.PluralResults}} found for {{.Ownership}} saved search:
 
 <p style="padding-left: 16px">&quot;{{.Description}}&quot;</p>
 
+<ul>{{range .Matches}}
+<li><a href="{{.URL}}">{{.Name}}</a></li>{{end}}{{if .MoreCount}}
+<li>...and {{.MoreCount}} more</li>{{end}}
+</ul>
+
 <p><a href="{{.URL}}">View the new result{{.PluralResults}} on Sourcegraph</a></p>
 `,
 })
@@ -35,6 +35,13 @@ const gqlSearchQuery = `query Search(
 				__typename
 				... on FileMatch {
 					resource
+					repository {
+						name
+					}
+					file {
+						path
+						url
+					}
 					limitHit
 					lineMatches {
 						preview
@@ -81,6 +88,7 @@ const gqlSearchQuery = `query Search(
 						}
 						oid
 						abbreviatedOID
+						url
 						author {
 							person {
 								displayName
@@ -138,15 +138,14 @@ func (e *executorT) run(ctx context.Context) error {
 // runQuery runs the given query if an appropriate amount of time has elapsed
 // since it last ran.
 func (e *executorT) runQuery(ctx context.Context, spec api.SavedQueryIDSpec, query api.ConfigSavedQuery) error {
-	if !query.Notify && !query.NotifySlack {
+	if !query.Notify && !query.NotifySlack && !hasWebhook(query) {
 		// No need to run this query because there will be nobody to notify.
 		return nil
 	}
-	if !strings.Contains(query.Query, "type:diff") && !strings.Contains(query.Query, "type:commit") {
-		// TODO(slimsag): we temporarily do not support non-commit search
-		// queries, since those do not support the after:"time" operator.
-		return nil
-	}
+	// Only commit search queries support the after:"time" operator. New
+	// results of other queries are found by comparing the matches of each run
+	// to those of the previous run.
+	isCommitQuery := strings.Contains(query.Query, "type:diff") || strings.Contains(query.Query, "type:commit")
 
 	info, err := api.InternalClient.SavedQueriesGetInfo(ctx, query.Query)
 	if err != nil {
@@ -185,8 +184,11 @@ func (e *executorT) runQuery(ctx context.Context, spec api.SavedQueryIDSpec, que
 		// time. We'll most certainly find nothing, which is okay.
 		latestKnownResult = time.Now()
 	}
-	afterTime := latestKnownResult.UTC().Format(time.RFC3339)
-	newQuery := strings.Join([]string{query.Query, fmt.Sprintf(`after:"%s"`, afterTime)}, " ")
+	newQuery := query.Query
+	if isCommitQuery {
+		afterTime := latestKnownResult.UTC().Format(time.RFC3339)
+		newQuery = strings.Join([]string{query.Query, fmt.Sprintf(`after:"%s"`, afterTime)}, " ")
+	}
 	if debugPretendSavedQueryResultsExist {
 		debugPretendSavedQueryResultsExist = false
 		newQuery = query.Query
@@ -197,16 +199,35 @@ func (e *executorT) runQuery(ctx context.Context, spec api.SavedQueryIDSpec, que
 	// fails in order to avoid e.g. failed saved queries from executing
 	// constantly and potentially causing harm to the system. We'll retry at
 	// our normal interval, regardless of errors.
+	startedAt := time.Now()
 	v, execDuration, searchErr := performSearch(ctx, newQuery)
+	latestResult := time.Now()
+	if isCommitQuery {
+		latestResult = latestResultTime(info, v, searchErr)
+	}
 	if err := api.InternalClient.SavedQueriesSetInfo(ctx, &api.SavedQueryInfo{
 		Query:        query.Query,
 		LastExecuted: time.Now(),
-		LatestResult: latestResultTime(info, v, searchErr),
+		LatestResult: latestResult,
 		ExecDuration: execDuration,
 	}); err != nil {
 		return errors.Wrap(err, "SavedQueriesSetInfo")
 	}
 
+	// Record the run, including failed ones, so that the run history of the
+	// saved search reflects what happened.
+	var matches []api.SavedQueryMatch
+	if searchErr == nil {
+		matches, searchErr = searchMatches(v.Data.Search.Results.Results)
+	}
+	if searchErr == nil {
+		matches, searchErr = readableMatches(ctx, spec.Subject, matches)
+	}
+	newMatches, err := recordRun(ctx, spec.Key, startedAt, matches, searchErr, !isCommitQuery)
+	if err != nil {
+		return err
+	}
+
 	if searchErr != nil {
 		return searchErr
 	}
@@ -215,7 +236,7 @@ func (e *executorT) runQuery(ctx context.Context, spec api.SavedQueryIDSpec, que
 	// that we don't block other search queries from running in sequence (which
 	// is done intentionally, to ensure no overloading of searcher/gitserver).
 	go func() {
-		if err := notify(context.Background(), spec, query, newQuery, v); err != nil {
+		if err := notify(context.Background(), spec, query, newQuery, newMatches); err != nil {
 			log15.Error("executor: failed to send notifications", "error", err)
 		}
 	}()
@@ -276,11 +297,11 @@ func latestResultTime(prevInfo *api.SavedQueryInfo, v *gqlSearchResponse, search
 var externalURL *url.URL
 
 // notify handles sending notifications for new search results.
-func notify(ctx context.Context, spec api.SavedQueryIDSpec, query api.ConfigSavedQuery, newQuery string, results *gqlSearchResponse) error {
-	if len(results.Data.Search.Results.Results) == 0 {
+func notify(ctx context.Context, spec api.SavedQueryIDSpec, query api.ConfigSavedQuery, newQuery string, newMatches []api.SavedQueryMatch) error {
+	if len(newMatches) == 0 {
 		return nil
 	}
-	log15.Info("sending notifications", "new_results", len(results.Data.Search.Results.Results), "description", query.Description)
+	log15.Info("sending notifications", "new_results", len(newMatches), "description", query.Description)
 
 	// Determine which users to notify.
 	recipients, err := getNotificationRecipients(ctx, spec, query)
@@ -288,18 +309,26 @@ func notify(ctx context.Context, spec api.SavedQueryIDSpec, query api.ConfigSave
 		return err
 	}
 
-	// Send slack notifications.
 	n := &notifier{
 		spec:       spec,
 		query:      query,
 		newQuery:   newQuery,
-		results:    results,
+		newMatches: newMatches,
 		recipients: recipients,
 	}
 
-	// Send Slack and email notifications.
-	n.slackNotify(ctx)
-	n.emailNotify(ctx)
+	// Send Slack, email and webhook notifications.
+	if query.NotifySlack {
+		n.slackNotify(ctx)
+	}
+	if query.Notify {
+		n.emailNotify(ctx)
+	}
+	if hasWebhook(query) {
+		if err := n.webhookNotify(ctx); err != nil {
+			log15.Error("Failed to post webhook notification for saved search.", "description", query.Description, "error", err)
+		}
+	}
 	return nil
 }
 
@@ -307,37 +336,62 @@ type notifier struct {
 	spec       api.SavedQueryIDSpec
 	query      api.ConfigSavedQuery
 	newQuery   string
-	results    *gqlSearchResponse
+	newMatches []api.SavedQueryMatch
 	recipients recipients
 }
 
+// maxNotificationMatches is the maximum number of new matches that are listed
+// in Slack and email notifications.
+const maxNotificationMatches = 10
+
 const (
-	utmSourceEmail = "saved-search-email"
-	utmSourceSlack = "saved-search-slack"
+	utmSourceEmail   = "saved-search-email"
+	utmSourceSlack   = "saved-search-slack"
+	utmSourceWebhook = "saved-search-webhook"
 )
 
 func searchURL(query, utmSource string) string {
+	u := resolveExternalURL(&url.URL{Path: "search"})
+	if u == nil {
+		return ""
+	}
+
+	// Construct URL to the search query.
+	q := u.Query()
+	q.Set("q", query)
+	q.Set("utm_source", utmSource)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// absoluteURL returns the absolute URL for the given URL path on Sourcegraph.
+func absoluteURL(path string) string {
+	ref, err := url.Parse(path)
+	if err != nil {
+		return path
+	}
+	u := resolveExternalURL(ref)
+	if u == nil {
+		return path
+	}
+	return u.String()
+}
+
+func resolveExternalURL(ref *url.URL) *url.URL {
 	if externalURL == nil {
 		// Determine the external URL.
 		externalURLStr, err := api.InternalClient.ExternalURL(context.Background())
 		if err != nil {
 			log15.Error("failed to get ExternalURL", err)
-			return ""
+			return nil
 		}
 		externalURL, err = url.Parse(externalURLStr)
 		if err != nil {
 			log15.Error("failed to parse ExternalURL", err)
-			return ""
+			return nil
 		}
 	}
-
-	// Construct URL to the search query.
-	u := externalURL.ResolveReference(&url.URL{Path: "search"})
-	q := u.Query()
-	q.Set("q", query)
-	q.Set("utm_source", utmSource)
-	u.RawQuery = q.Encode()
-	return u.String()
+	return externalURL.ResolveReference(ref)
 }
 
 func logEvent(userID int32, eventName, eventType string) {
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/pkg/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+// gqlFileMatch and gqlCommitSearchResult are the fields of search results
+// (see gqlSearchQuery) that identify a match across runs of a saved search.
+type gqlFileMatch struct {
+	Repository struct{ Name string }
+	File       struct{ Path, URL string }
+}
+
+type gqlCommitSearchResult struct {
+	Commit struct {
+		Repository struct{ Name string }
+		OID        string
+		URL        string
+	}
+}
+
+// searchMatches returns the matches of the given search results, in the order
+// in which they were returned. Results that are neither file nor commit
+// matches are ignored.
+func searchMatches(results []interface{}) ([]api.SavedQueryMatch, error) {
+	matches := make([]api.SavedQueryMatch, 0, len(results))
+	for _, result := range results {
+		m, ok := result.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		// Round-trip through JSON instead of asserting on the shape of the
+		// untyped result.
+		b, err := json.Marshal(m)
+		if err != nil {
+			return nil, err
+		}
+		switch m["__typename"] {
+		case "FileMatch":
+			var fm gqlFileMatch
+			if err := json.Unmarshal(b, &fm); err != nil {
+				return nil, errors.Wrap(err, "FileMatch")
+			}
+			matches = append(matches, api.SavedQueryMatch{
+				Key:        fm.Repository.Name + "/" + fm.File.Path,
+				Type:       "file",
+				Repository: fm.Repository.Name,
+				Path:       fm.File.Path,
+				URL:        absoluteURL(fm.File.URL),
+			})
+		case "CommitSearchResult":
+			var cr gqlCommitSearchResult
+			if err := json.Unmarshal(b, &cr); err != nil {
+				return nil, errors.Wrap(err, "CommitSearchResult")
+			}
+			matches = append(matches, api.SavedQueryMatch{
+				Key:        cr.Commit.Repository.Name + "@" + cr.Commit.OID,
+				Type:       "commit",
+				Repository: cr.Commit.Repository.Name,
+				Commit:     cr.Commit.OID,
+				URL:        absoluteURL(cr.Commit.URL),
+			})
+		}
+	}
+	return matches, nil
+}
+
+// readableMatches returns the matches in repositories that the owner of the
+// saved search, a user or an org, may read.
+//
+// 🚨 SECURITY: query-runner searches as an internal actor, which bypasses
+// repository permissions, but the matches are stored in the run history of
+// the saved search and sent in its notifications. They must be filtered
+// before either happens.
+func readableMatches(ctx context.Context, subject api.SettingsSubject, matches []api.SavedQueryMatch) ([]api.SavedQueryMatch, error) {
+	if len(matches) == 0 {
+		return matches, nil
+	}
+
+	var repos []api.RepoName
+	seen := map[string]bool{}
+	for _, m := range matches {
+		if !seen[m.Repository] {
+			seen[m.Repository] = true
+			repos = append(repos, api.RepoName(m.Repository))
+		}
+	}
+	readableRepos, err := api.InternalClient.SavedQueriesReadableRepos(ctx, subject, repos)
+	if err != nil {
+		return nil, errors.Wrap(err, "SavedQueriesReadableRepos")
+	}
+	readable := make(map[string]bool, len(readableRepos))
+	for _, repo := range readableRepos {
+		readable[string(repo)] = true
+	}
+
+	filtered := make([]api.SavedQueryMatch, 0, len(matches))
+	for _, m := range matches {
+		if readable[m.Repository] {
+			filtered = append(filtered, m)
+		}
+	}
+	return filtered, nil
+}
+
+// diffMatches returns the matches that were not found by the previous run of
+// a saved search.
+//
+// If there is no previous run and baseline is true, no matches are considered
+// new: the first run of a saved search only records the matches that exist at
+// the time it was created.
+func diffMatches(prev *api.SavedQueryRun, matches []api.SavedQueryMatch, baseline bool) []api.SavedQueryMatch {
+	if prev == nil && baseline {
+		return nil
+	}
+	seen := map[string]bool{}
+	if prev != nil {
+		for _, key := range prev.Matches {
+			seen[key] = true
+		}
+	}
+	var newMatches []api.SavedQueryMatch
+	for _, m := range matches {
+		if seen[m.Key] {
+			continue
+		}
+		seen[m.Key] = true
+		newMatches = append(newMatches, m)
+	}
+	return newMatches
+}
+
+// recordRun records a run of the saved search with the given key and returns
+// the matches that are new since its previous successful run.
+func recordRun(ctx context.Context, key string, startedAt time.Time, matches []api.SavedQueryMatch, searchErr error, baseline bool) ([]api.SavedQueryMatch, error) {
+	savedSearchID, err := strconv.ParseInt(key, 10, 32)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid saved search key %q", key)
+	}
+
+	prev, err := api.InternalClient.SavedQueriesLastSuccessfulRun(ctx, int32(savedSearchID))
+	if err != nil {
+		return nil, errors.Wrap(err, "SavedQueriesLastSuccessfulRun")
+	}
+
+	run := &api.SavedQueryRun{
+		SavedSearchID: int32(savedSearchID),
+		StartedAt:     startedAt,
+		FinishedAt:    time.Now(),
+	}
+	if searchErr != nil {
+		run.Error = searchErr.Error()
+	} else {
+		run.Matches = make([]string, 0, len(matches))
+		for _, m := range matches {
+			run.Matches = append(run.Matches, m.Key)
+		}
+		run.NewMatches = diffMatches(prev, matches, baseline)
+	}
+	if err := api.InternalClient.SavedQueriesRecordRun(ctx, run); err != nil {
+		return nil, errors.Wrap(err, "SavedQueriesRecordRun")
+	}
+	if len(run.NewMatches) > 0 {
+		log15.Debug("executor: found new matches", "saved_search", key, "new_matches", len(run.NewMatches))
+	}
+	return run.NewMatches, nil
+}
+
+// matchName returns a short human-readable name for the match, such as
+// "github.com/foo/bar › README.md" or "github.com/foo/bar@1a2b3c4".
+func matchName(m api.SavedQueryMatch) string {
+	if m.Type == "commit" {
+		commit := m.Commit
+		if len(commit) > 7 {
+			commit = commit[:7]
+		}
+		return m.Repository + "@" + commit
+	}
+	return m.Repository + " › " + m.Path
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+func TestSearchMatches(t *testing.T) {
+	externalURL, _ = url.Parse("https://sourcegraph.example.com")
+	defer func() { externalURL = nil }()
+
+	var results []interface{}
+	if err := json.Unmarshal([]byte(`[
+		{
+			"__typename": "FileMatch",
+			"repository": {"name": "github.com/foo/bar"},
+			"file": {"path": "a/b.go", "url": "/github.com/foo/bar/-/blob/a/b.go"}
+		},
+		{
+			"__typename": "CommitSearchResult",
+			"commit": {
+				"repository": {"name": "github.com/foo/baz"},
+				"oid": "1a2b3c4d5e6f",
+				"url": "/github.com/foo/baz/-/commit/1a2b3c4d5e6f"
+			}
+		},
+		{"__typename": "Repository", "name": "github.com/foo/qux"}
+	]`), &results); err != nil {
+		t.Fatal(err)
+	}
+
+	matches, err := searchMatches(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []api.SavedQueryMatch{
+		{
+			Key:        "github.com/foo/bar/a/b.go",
+			Type:       "file",
+			Repository: "github.com/foo/bar",
+			Path:       "a/b.go",
+			URL:        "https://sourcegraph.example.com/github.com/foo/bar/-/blob/a/b.go",
+		},
+		{
+			Key:        "github.com/foo/baz@1a2b3c4d5e6f",
+			Type:       "commit",
+			Repository: "github.com/foo/baz",
+			Commit:     "1a2b3c4d5e6f",
+			URL:        "https://sourcegraph.example.com/github.com/foo/baz/-/commit/1a2b3c4d5e6f",
+		},
+	}
+	if !reflect.DeepEqual(matches, want) {
+		t.Errorf("got %+v, want %+v", matches, want)
+	}
+
+	if got, want := matchName(matches[0]), "github.com/foo/bar › a/b.go"; got != want {
+		t.Errorf("got name %q, want %q", got, want)
+	}
+	if got, want := matchName(matches[1]), "github.com/foo/baz@1a2b3c4"; got != want {
+		t.Errorf("got name %q, want %q", got, want)
+	}
+}
+
+func TestDiffMatches(t *testing.T) {
+	a := api.SavedQueryMatch{Key: "r/a"}
+	b := api.SavedQueryMatch{Key: "r/b"}
+	c := api.SavedQueryMatch{Key: "r/c"}
+
+	tests := []struct {
+		name     string
+		prev     *api.SavedQueryRun
+		matches  []api.SavedQueryMatch
+		baseline bool
+		want     []api.SavedQueryMatch
+	}{
+		{
+			name:     "first run records a baseline",
+			matches:  []api.SavedQueryMatch{a, b},
+			baseline: true,
+		},
+		{
+			name:    "first run without baseline",
+			matches: []api.SavedQueryMatch{a, b},
+			want:    []api.SavedQueryMatch{a, b},
+		},
+		{
+			name:     "no new matches",
+			prev:     &api.SavedQueryRun{Matches: []string{"r/a", "r/b"}},
+			matches:  []api.SavedQueryMatch{b, a},
+			baseline: true,
+		},
+		{
+			name:     "new and removed matches",
+			prev:     &api.SavedQueryRun{Matches: []string{"r/a", "r/b"}},
+			matches:  []api.SavedQueryMatch{c, a, c},
+			baseline: true,
+			want:     []api.SavedQueryMatch{c},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := diffMatches(test.prev, test.matches, test.baseline)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPostWebhook(t *testing.T) {
+	var (
+		gotBody      []byte
+		gotSignature string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("got Content-Type %q, want application/json", ct)
+		}
+		gotBody, _ = ioutil.ReadAll(r.Body)
+		gotSignature = r.Header.Get(webhookSignatureHeader)
+	}))
+	defer ts.Close()
+
+	payload := webhookPayload{NewMatches: []api.SavedQueryMatch{{Key: "r/a", Type: "file", Repository: "r", Path: "a"}}}
+
+	// The test server listens on a loopback address, which webhooks may not
+	// be posted to.
+	if err := postWebhook(context.Background(), ts.URL, "", payload); err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Fatalf("got error %v for a loopback webhook, want not allowed", err)
+	}
+	if gotBody != nil {
+		t.Fatal("loopback webhook was posted")
+	}
+
+	defer func(c *http.Client) { webhookClient = c }(webhookClient)
+	webhookClient = ts.Client()
+
+	if err := postWebhook(context.Background(), ts.URL, "s3cr3t", payload); err != nil {
+		t.Fatal(err)
+	}
+
+	var got webhookPayload
+	if err := json.Unmarshal(gotBody, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, payload) {
+		t.Errorf("got payload %+v, want %+v", got, payload)
+	}
+	if want := "sha256=" + webhookSignature("s3cr3t", gotBody); gotSignature != want {
+		t.Errorf("got signature %q, want %q", gotSignature, want)
+	}
+
+	// Payloads are not signed without a secret.
+	if err := postWebhook(context.Background(), ts.URL, "", payload); err != nil {
+		t.Fatal(err)
+	}
+	if gotSignature != "" {
+		t.Errorf("got signature %q, want none", gotSignature)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	if err := postWebhook(context.Background(), failing.URL, "", payload); err == nil {
+		t.Error("got nil error for a failing webhook, want error")
+	}
+}
+
+func TestReadableMatches(t *testing.T) {
+	userID := int32(1)
+	subject := api.SettingsSubject{User: &userID}
+	api.MockSavedQueriesReadableRepos = func(s api.SettingsSubject, repos []api.RepoName) ([]api.RepoName, error) {
+		if !reflect.DeepEqual(s, subject) {
+			t.Errorf("got subject %v, want %v", s, subject)
+		}
+		if want := []api.RepoName{"github.com/foo/public", "github.com/foo/private"}; !reflect.DeepEqual(repos, want) {
+			t.Errorf("got repos %v, want %v", repos, want)
+		}
+		return []api.RepoName{"github.com/foo/public"}, nil
+	}
+	defer func() { api.MockSavedQueriesReadableRepos = nil }()
+
+	matches := []api.SavedQueryMatch{
+		{Key: "github.com/foo/public/a.go", Type: "file", Repository: "github.com/foo/public", Path: "a.go"},
+		{Key: "github.com/foo/private@1a2b3c4", Type: "commit", Repository: "github.com/foo/private", Commit: "1a2b3c4"},
+		{Key: "github.com/foo/public/b.go", Type: "file", Repository: "github.com/foo/public", Path: "b.go"},
+	}
+	got, err := readableMatches(context.Background(), subject, matches)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []api.SavedQueryMatch{matches[0], matches[2]}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestWebhookSignature(t *testing.T) {
+	// Known HMAC-SHA256 test vector (RFC 4231, test case 2).
+	got := webhookSignature("Jefe", []byte("what do ya want for nothing?"))
+	want := "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestCheckWebhookAddr(t *testing.T) {
+	for addr, allowed := range map[string]bool{
+		"93.184.216.34:443":           true,
+		"[2606:2800:220:1::1]:443":    true,
+		"127.0.0.1:80":                false,
+		"[::1]:80":                    false,
+		"10.1.2.3:80":                 false,
+		"172.20.0.1:80":               false,
+		"192.168.1.1:80":              false,
+		"169.254.169.254:80":          false,
+		"0.0.0.0:80":                  false,
+		"[fd00::1]:80":                false,
+		"[fe80::1]:80":                false,
+		"[::ffff:169.254.169.254]:80": false,
+	} {
+		if err := checkWebhookAddr("tcp", addr, nil); (err == nil) != allowed {
+			t.Errorf("%s: got error %v, want allowed %v", addr, err, allowed)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/inconshreveable/log15"
 
@@ -12,16 +13,25 @@ import (
 
 func (n *notifier) slackNotify(ctx context.Context) {
 	plural := ""
-	if n.results.Data.Search.Results.ApproximateResultCount != "1" {
+	if len(n.newMatches) != 1 {
 		plural = "s"
 	}
 
-	text := fmt.Sprintf(`*%s* new result%s found for saved search <%s|"%s">`,
-		n.results.Data.Search.Results.ApproximateResultCount,
+	var b strings.Builder
+	fmt.Fprintf(&b, `*%d* new result%s found for saved search <%s|"%s">`,
+		len(n.newMatches),
 		plural,
 		searchURL(n.newQuery, utmSourceSlack),
 		n.query.Description,
 	)
+	for i, m := range n.newMatches {
+		if i == maxNotificationMatches {
+			fmt.Fprintf(&b, "\n…and %d more", len(n.newMatches)-i)
+			break
+		}
+		fmt.Fprintf(&b, "\n• <%s|%s>", m.URL, matchName(m))
+	}
+	text := b.String()
 	for _, recipient := range n.recipients {
 		if err := slackNotify(ctx, recipient, text, n.query.SlackWebhookURL); err != nil {
 			log15.Error("Failed to post Slack notification message.", "recipient", recipient, "text", text, "error", err)
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/graph-gophers/graphql-go/relay"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context/ctxhttp"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+// webhookSignatureHeader is the HTTP header that contains the HMAC-SHA256
+// signature of the webhook payload, if the saved search has a webhook secret.
+const webhookSignatureHeader = "X-Sourcegraph-Signature"
+
+// webhookPayload is the JSON payload posted to the webhook of a saved search
+// when new matches are found.
+type webhookPayload struct {
+	SavedSearch webhookSavedSearch    `json:"savedSearch"`
+	SearchURL   string                `json:"searchURL"`
+	NewMatches  []api.SavedQueryMatch `json:"newMatches"`
+}
+
+type webhookSavedSearch struct {
+	ID          string `json:"id"` // GraphQL ID of the saved search
+	Description string `json:"description"`
+	Query       string `json:"query"`
+}
+
+// webhookClient is the HTTP client used to post webhooks.
+//
+// 🚨 SECURITY: Webhook URLs are set by users, so they must not be able to make
+// query-runner send requests to services on the internal network. The
+// addresses are checked when dialing, after DNS resolution, so that neither
+// host names resolving to internal addresses nor redirects to them get
+// through. Proxies are not used because they would dial on our behalf.
+var webhookClient = &http.Client{
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+			Control:   checkWebhookAddr,
+		}).DialContext,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 30 * time.Second,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       90 * time.Second,
+	},
+}
+
+// internalNetworks are the private networks webhooks may not be posted to, in
+// addition to loopback, link-local and unspecified addresses.
+var internalNetworks = func() []*net.IPNet {
+	var nets []*net.IPNet
+	for _, cidr := range []string{
+		"10.0.0.0/8",
+		"172.16.0.0/12",
+		"192.168.0.0/16",
+		"100.64.0.0/10", // carrier-grade NAT
+		"fc00::/7",      // unique local addresses
+	} {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}()
+
+// checkWebhookAddr is the net.Dialer Control function of webhookClient. It
+// returns an error if address is not a public IP address.
+func checkWebhookAddr(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("webhook address %q is not an IP address", host)
+	}
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("webhook address %s is not allowed", ip)
+	}
+	for _, n := range internalNetworks {
+		if n.Contains(ip) {
+			return fmt.Errorf("webhook address %s is not allowed", ip)
+		}
+	}
+	return nil
+}
+
+func hasWebhook(query api.ConfigSavedQuery) bool {
+	return query.WebhookURL != nil && *query.WebhookURL != ""
+}
+
+func (n *notifier) webhookNotify(ctx context.Context) error {
+	payload := webhookPayload{
+		SavedSearch: webhookSavedSearch{
+			Description: n.query.Description,
+			Query:       n.query.Query,
+		},
+		SearchURL:  searchURL(n.newQuery, utmSourceWebhook),
+		NewMatches: n.newMatches,
+	}
+	if id, err := strconv.ParseInt(n.spec.Key, 10, 32); err == nil {
+		payload.SavedSearch.ID = string(relay.MarshalID("SavedSearch", int32(id)))
+	}
+
+	var secret string
+	if n.query.WebhookSecret != nil {
+		secret = *n.query.WebhookSecret
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	if err := postWebhook(ctx, *n.query.WebhookURL, secret, payload); err != nil {
+		return err
+	}
+	logEvent(0, "SavedSearchWebhookNotificationSent", "results")
+	return nil
+}
+
+// postWebhook posts the JSON-encoded payload to the given URL. If secret is
+// non-empty, the payload is signed with it.
+func postWebhook(ctx context.Context, webhookURL, secret string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrap(err, "Marshal")
+	}
+
+	req, err := http.NewRequest("POST", webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		req.Header.Set(webhookSignatureHeader, "sha256="+webhookSignature(secret, body))
+	}
+
+	resp, err := ctxhttp.Do(ctx, webhookClient, req)
+	if err != nil {
+		return errors.Wrap(err, "Post")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s responded with HTTP %d", webhookURL, resp.StatusCode)
+	}
+	return nil
+}
+
+// webhookSignature returns the hex-encoded HMAC-SHA256 of body keyed with
+// secret. Receivers verify it by computing the same HMAC over the raw request
+// body.
+func webhookSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
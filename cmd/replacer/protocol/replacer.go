@@ -44,11 +44,33 @@ type RewriteSpecification struct {
 	// A template pattern that expresses how matches should be rewritten.
 	RewriteTemplate string
 
-	// A file extension suffix filtering which files to process (e.g., ".go")
-	FileExtension string
+	// IncludePatterns is a list of regular expressions that must *all* match
+	// the paths of the files to rewrite. eg `\.go$`
+	IncludePatterns []string
 
-	// A directory prefix to exclude (e.g., vendor)
-	DirectoryExclude string
+	// ExcludePattern is a regular expression that may not match the paths of
+	// the files to rewrite. eg `^vendor/`
+	ExcludePattern string
+
+	// PathPatternsAreCaseSensitive indicates that ExcludePattern and
+	// IncludePatterns are case sensitive.
+	PathPatternsAreCaseSensitive bool
+
+	// Languages is the languages passed via the lang filters (e.g.,
+	// "lang:go"). The first one selects the comby matcher. Like for searcher,
+	// the caller is expected to also add include patterns for them.
+	Languages []string
+}
+
+// FileDiff is the result of a replace request for a single file. Results are
+// streamed back as JSON lines.
+type FileDiff struct {
+	// URI is the path of the file in the repository.
+	URI string `json:"uri"`
+
+	// Diff is a unified diff of the rewrite of the file, relative to the
+	// repository root (i.e. it applies with `git apply -p0`).
+	Diff string `json:"diff"`
 }
 
 // GitserverRepo returns the repository information necessary to perform gitserver requests.
@@ -0,0 +1,214 @@
+package replace
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around changes.
+const diffContext = 3
+
+// edit is a line in a diff. Kind is ' ' for unchanged lines, '-' for removed
+// lines and '+' for added lines. Line includes the trailing newline, if any.
+type edit struct {
+	kind byte
+	line string
+
+	// aPos and bPos are the number of lines of a and b before the line.
+	aPos, bPos int
+}
+
+// unifiedDiff returns a unified diff from a to b of the file at path. The
+// file names in the diff are not prefixed (like `git diff --no-prefix`), so
+// it applies with `git apply -p0`. It returns "" if a and b are equal.
+func unifiedDiff(path, a, b string) string {
+	if a == b {
+		return ""
+	}
+	edits := diffLines(splitLines(a), splitLines(b))
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "--- %s\n+++ %s\n", path, path)
+	for i := 0; i < len(edits); {
+		if edits[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		// Extend the hunk over all changes that are close enough for their
+		// context to overlap.
+		start := max(i-diffContext, 0)
+		end := i
+		for {
+			for end < len(edits) && edits[end].kind != ' ' {
+				end++
+			}
+			next := end
+			for next < len(edits) && edits[next].kind == ' ' {
+				next++
+			}
+			if next == len(edits) || next-end > 2*diffContext {
+				break
+			}
+			end = next
+		}
+		end = min(end+diffContext, len(edits))
+
+		writeHunk(&buf, edits[start:end])
+		i = end
+	}
+	return buf.String()
+}
+
+func writeHunk(buf *strings.Builder, edits []edit) {
+	var aCount, bCount int
+	for _, e := range edits {
+		if e.kind != '+' {
+			aCount++
+		}
+		if e.kind != '-' {
+			bCount++
+		}
+	}
+	// Empty ranges start at the line before them.
+	aStart, bStart := edits[0].aPos, edits[0].bPos
+	if aCount > 0 {
+		aStart++
+	}
+	if bCount > 0 {
+		bStart++
+	}
+
+	fmt.Fprintf(buf, "@@ -%d,%d +%d,%d @@\n", aStart, aCount, bStart, bCount)
+	for _, e := range edits {
+		buf.WriteByte(e.kind)
+		buf.WriteString(e.line)
+		if !strings.HasSuffix(e.line, "\n") {
+			buf.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// splitLines splits s after each newline.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns a shortest edit script from a to b, computed with Myers'
+// algorithm.
+func diffLines(a, b []string) []edit {
+	// Rewrites typically change few lines, so trimming the common prefix and
+	// suffix keeps the search below small.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var edits []edit
+	for i := 0; i < prefix; i++ {
+		edits = append(edits, edit{kind: ' ', line: a[i], aPos: i, bPos: i})
+	}
+	for _, e := range myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]) {
+		e.aPos += prefix
+		e.bPos += prefix
+		edits = append(edits, e)
+	}
+	for i := 0; i < suffix; i++ {
+		aPos, bPos := len(a)-suffix+i, len(b)-suffix+i
+		edits = append(edits, edit{kind: ' ', line: a[aPos], aPos: aPos, bPos: bPos})
+	}
+	return edits
+}
+
+func myers(a, b []string) []edit {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+
+	// trace[d] is v before step d, which is needed to walk back from the end.
+	var trace [][]int
+	var d int
+search:
+	for d = 0; d <= n+m; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	var edits []edit
+	x, y := n, m
+	for ; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			edits = append(edits, edit{kind: ' ', line: a[x], aPos: x, bPos: y})
+		}
+		if x == prevX {
+			y--
+			edits = append(edits, edit{kind: '+', line: b[y], aPos: x, bPos: y})
+		} else {
+			x--
+			edits = append(edits, edit{kind: '-', line: a[x], aPos: x, bPos: y})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		edits = append(edits, edit{kind: ' ', line: a[x], aPos: x, bPos: y})
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
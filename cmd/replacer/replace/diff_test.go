@@ -0,0 +1,122 @@
+package replace
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/go-diff/diff"
+	"github.com/sourcegraph/sourcegraph/internal/testutil"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	lines := func(n int) string {
+		var b strings.Builder
+		for i := 1; i <= n; i++ {
+			b.WriteString(strings.Repeat("x", i) + "\n")
+		}
+		return b.String()
+	}
+
+	cases := []struct {
+		name string
+		a, b string
+		want string
+	}{{
+		name: "equal",
+		a:    "a\nb\n",
+		b:    "a\nb\n",
+		want: "",
+	}, {
+		name: "change",
+		a:    "a\nb\nc\n",
+		b:    "a\nB\nc\n",
+		want: `--- f
++++ f
+@@ -1,3 +1,3 @@
+ a
+-b
++B
+ c
+`,
+	}, {
+		name: "from empty",
+		a:    "",
+		b:    "a\n",
+		want: `--- f
++++ f
+@@ -0,0 +1,1 @@
++a
+`,
+	}, {
+		name: "no newline at end of file",
+		a:    "a\nb",
+		b:    "a\nb\n",
+		want: `--- f
++++ f
+@@ -1,2 +1,2 @@
+ a
+-b
+\ No newline at end of file
++b
+`,
+	}, {
+		name: "separate hunks",
+		a:    lines(20),
+		b:    strings.Replace(strings.Replace(lines(20), "xx\n", "yy\n", 1), "xxxxxxxxxxxxxxxxxxx\n", "", 1),
+		want: `--- f
++++ f
+@@ -1,5 +1,5 @@
+ x
+-xx
++yy
+ xxx
+ xxxx
+ xxxxx
+@@ -16,5 +16,4 @@
+ xxxxxxxxxxxxxxxx
+ xxxxxxxxxxxxxxxxx
+ xxxxxxxxxxxxxxxxxx
+-xxxxxxxxxxxxxxxxxxx
+ xxxxxxxxxxxxxxxxxxxx
+`,
+	}, {
+		name: "merged hunks",
+		a:    lines(10),
+		b:    strings.Replace(strings.Replace(lines(10), "xx\n", "yy\n", 1), "xxxxxxxx\n", "yyyyyyyy\n", 1),
+		want: `--- f
++++ f
+@@ -1,10 +1,10 @@
+ x
+-xx
++yy
+ xxx
+ xxxx
+ xxxxx
+ xxxxxx
+ xxxxxxx
+-xxxxxxxx
++yyyyyyyy
+ xxxxxxxxx
+ xxxxxxxxxx
+`,
+	}}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := unifiedDiff("f", tc.a, tc.b)
+			if got != tc.want {
+				d, err := testutil.Diff(tc.want, got)
+				if err != nil {
+					t.Fatal(err)
+				}
+				t.Fatalf("unexpected diff:\n%s", d)
+			}
+			if got == "" {
+				return
+			}
+			// The diff must be readable by the campaigns patch validation.
+			if _, err := diff.ParseMultiFileDiff([]byte(got)); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -9,20 +9,22 @@
 // * On disk cache of fetched archives to reduce load on gitserver
 //
 // - Here is where replacer.go differs
-// * Pass the zip file path to external replacer tool(s) after validating
-// * Read tool stdout and write it out on the HTTP connection
-// * Input from stdout is expected to use JSON lines format, but the format isn't checked here: line-buffering is done on the frontend
+// * Write the files matching the path filters to a separate zip file, and pass it to comby
+// * Diff the rewritten content comby outputs against the archive
+// * Write a unified diff per file out on the HTTP connection, as JSON lines (protocol.FileDiff)
 
 package replace
 
 import (
+	"archive/zip"
 	"context"
+	"encoding/json"
 	"fmt"
-	"io"
+	"io/ioutil"
 	"log"
 	"net/http"
-	"os/exec"
-	"strings"
+	"os"
+	"sort"
 	"time"
 
 	nettrace "golang.org/x/net/trace"
@@ -33,6 +35,8 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sourcegraph/sourcegraph/cmd/replacer/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/comby"
+	"github.com/sourcegraph/sourcegraph/internal/pathmatch"
 	"github.com/sourcegraph/sourcegraph/internal/store"
 	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
 
@@ -44,41 +48,6 @@ type Service struct {
 	Log   log15.Logger
 }
 
-type ExternalTool struct {
-	Name       string
-	BinaryPath string
-}
-
-// Configure the command line options and return the command to execute using an external tool
-func (t *ExternalTool) command(ctx context.Context, spec *protocol.RewriteSpecification, zipPath string) (cmd *exec.Cmd, err error) {
-	switch t.Name {
-	case "comby":
-		_, err = exec.LookPath("comby")
-		if err != nil {
-			return nil, errors.New("comby is not installed on the PATH. Try running 'bash <(curl -sL get.comby.dev)'.")
-		}
-
-		var args []string
-		args = append(args, spec.MatchTemplate, spec.RewriteTemplate)
-
-		if spec.FileExtension != "" {
-			args = append(args, spec.FileExtension)
-		}
-
-		args = append(args, "-zip", zipPath, "-json-lines", "-json-only-diff")
-
-		if spec.DirectoryExclude != "" {
-			args = append(args, "-exclude-dir", spec.DirectoryExclude)
-		}
-
-		log15.Info(fmt.Sprintf("running command: comby %q", strings.Join(args[:], " ")))
-		return exec.CommandContext(ctx, t.BinaryPath, args...), nil
-
-	default:
-		return nil, errors.Errorf("Unknown external replace tool %q.", t.Name)
-	}
-}
-
 var decoder = schema.NewDecoder()
 
 func init() {
@@ -177,6 +146,14 @@ func (s *Service) replace(ctx context.Context, p *protocol.Request, w http.Respo
 		}
 	}(time.Now())
 
+	matchPath, err := pathmatch.CompilePathPatterns(p.IncludePatterns, p.ExcludePattern, pathmatch.CompileOptions{
+		RegExp:        true,
+		CaseSensitive: p.PathPatternsAreCaseSensitive,
+	})
+	if err != nil {
+		return false, badRequestError{err.Error()}
+	}
+
 	if p.FetchTimeout == "" {
 		p.FetchTimeout = "500ms"
 	}
@@ -196,7 +173,7 @@ func (s *Service) replace(ctx context.Context, p *protocol.Request, w http.Respo
 		return path, zf, err
 	}
 
-	zipPath, zf, err := store.GetZipFileWithRetry(getZf)
+	_, zf, err := store.GetZipFileWithRetry(getZf)
 	if err != nil {
 		return false, errors.Wrap(err, "failed to get archive")
 	}
@@ -211,42 +188,92 @@ func (s *Service) replace(ctx context.Context, p *protocol.Request, w http.Respo
 	archiveFiles.Observe(float64(nFiles))
 	archiveSize.Observe(float64(bytes))
 
-	w.Header().Set("Transfer-Encoding", "chunked")
-	w.WriteHeader(http.StatusOK)
-
-	t := &ExternalTool{
-		Name:       "comby",
-		BinaryPath: "comby",
-	}
-
-	cmd, err := t.command(ctx, &p.RewriteSpecification, zipPath)
+	// Comby can only filter files by suffix, so we give it an archive of
+	// only the files to rewrite.
+	files, filteredZipPath, err := writeFilteredZip(zf, matchPath)
 	if err != nil {
-		log15.Info("Invalid command: " + err.Error())
-		return false, errors.Wrap(err, "invalid command")
+		return false, errors.Wrap(err, "failed to filter archive")
 	}
-
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		log15.Info("Could not connect to command stdout: " + err.Error())
-		return false, errors.Wrap(err, "failed to connect to command stdout")
+	defer os.Remove(filteredZipPath)
+	tr.LazyPrintf("filtered files=%d", len(files))
+
+	var diffs []protocol.FileDiff
+	if len(files) > 0 {
+		var matcher string
+		if len(p.Languages) > 0 {
+			// Like searcher, pick the first language: comby only applies a
+			// single matcher.
+			matcher = comby.MatcherForLanguage(p.Languages[0])
+		}
+		replacements, err := comby.Replacements(ctx, comby.Args{
+			Input:           comby.ZipPath(filteredZipPath),
+			MatchTemplate:   p.MatchTemplate,
+			RewriteTemplate: p.RewriteTemplate,
+			Matcher:         matcher,
+			NumWorkers:      numWorkers,
+		})
+		if err != nil {
+			return false, errors.Wrap(err, "failed to rewrite files")
+		}
+		for _, r := range replacements {
+			f, ok := files[r.URI]
+			if !ok {
+				return false, errors.Errorf("comby rewrote unknown file %q", r.URI)
+			}
+			if diff := unifiedDiff(r.URI, string(zf.DataFor(f)), r.RewrittenSource); diff != "" {
+				diffs = append(diffs, protocol.FileDiff{URI: r.URI, Diff: diff})
+			}
+		}
+		sort.Slice(diffs, func(i, j int) bool { return diffs[i].URI < diffs[j].URI })
 	}
 
-	if err := cmd.Start(); err != nil {
-		log15.Info("Error starting command: " + err.Error())
-		return false, errors.Wrap(err, "failed to start command")
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(w)
+	for _, d := range diffs {
+		if err := enc.Encode(d); err != nil {
+			return false, errors.Wrap(err, "failed to write diff")
+		}
 	}
+	return false, nil
+}
 
-	_, err = io.Copy(w, stdout)
+// writeFilteredZip writes the files in zf whose paths match matchPath to a
+// temporary zip file. The caller is responsible for removing it.
+func writeFilteredZip(zf *store.ZipFile, matchPath pathmatch.PathMatcher) (files map[string]*store.SrcFile, path string, err error) {
+	f, err := ioutil.TempFile("", "replacer-*.zip")
 	if err != nil {
-		log15.Info("Error copying external command output to HTTP writer: " + err.Error())
-		return false, errors.Wrap(err, "failed while copying command output to HTTP")
+		return nil, "", err
 	}
-
-	if err := cmd.Wait(); err != nil {
-		log15.Info("Error after executing command: " + string(err.(*exec.ExitError).Stderr))
+	defer func() {
+		if err1 := f.Close(); err == nil {
+			err = err1
+		}
+		if err != nil {
+			os.Remove(f.Name())
+		}
+	}()
+
+	files = make(map[string]*store.SrcFile)
+	zw := zip.NewWriter(f)
+	for i := range zf.Files {
+		sf := &zf.Files[i]
+		if !matchPath.MatchPath(sf.Name) {
+			continue
+		}
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: sf.Name, Method: zip.Store})
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := w.Write(zf.DataFor(sf)); err != nil {
+			return nil, "", err
+		}
+		files[sf.Name] = sf
 	}
-
-	return false, nil
+	if err := zw.Close(); err != nil {
+		return nil, "", err
+	}
+	return files, f.Name(), nil
 }
 
 func validateParams(p *protocol.Request) error {
@@ -264,6 +291,10 @@ func validateParams(p *protocol.Request) error {
 	return nil
 }
 
+// numWorkers caps the number of processes comby forks, which limits the size
+// of zip contents being mapped to memory.
+const numWorkers = 4
+
 const megabyte = float64(1000 * 1000)
 
 var (
@@ -302,6 +333,11 @@ func init() {
 	prometheus.MustRegister(requestTotal)
 }
 
+type badRequestError struct{ msg string }
+
+func (e badRequestError) Error() string    { return e.msg }
+func (e badRequestError) BadRequest() bool { return true }
+
 func isBadRequest(err error) bool {
 	e, ok := errors.Cause(err).(interface {
 		BadRequest() bool
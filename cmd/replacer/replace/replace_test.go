@@ -44,9 +44,16 @@ func main() {
 		{protocol.RewriteSpecification{
 			MatchTemplate:   "func",
 			RewriteTemplate: "derp",
-			FileExtension:   ".go",
+			IncludePatterns: []string{`\.go$`},
 		}, `
-{"uri":"main.go","diff":"--- main.go\n+++ main.go\n@@ -2,6 +2,6 @@\n \n import \"fmt\"\n \n-func main() {\n+derp main() {\n \tfmt.Println(\"Hello foo\")\n }"}
+{"uri":"main.go","diff":"--- main.go\n+++ main.go\n@@ -2,6 +2,6 @@\n \n import \"fmt\"\n \n-func main() {\n+derp main() {\n \tfmt.Println(\"Hello foo\")\n }\n"}
+`},
+		{protocol.RewriteSpecification{
+			MatchTemplate:   "Hello",
+			RewriteTemplate: "Bye",
+			ExcludePattern:  `\.go$`,
+		}, `
+{"uri":"README.md","diff":"--- README.md\n+++ README.md\n@@ -1,3 +1,3 @@\n-# Hello World\n+# Bye World\n \n-Hello world example in go\n\\ No newline at end of file\n+Bye world example in go\n\\ No newline at end of file\n"}
 `},
 	}
 
@@ -97,6 +104,15 @@ func TestReplace_badrequest(t *testing.T) {
 			Commit: "deadbeefdeadbeefdeadbeefdeadbeefdeadbeef",
 			// No MatchTemplate
 		},
+		{
+			Repo:   "foo",
+			URL:    "u",
+			Commit: "deadbeefdeadbeefdeadbeefdeadbeefdeadbeef",
+			RewriteSpecification: protocol.RewriteSpecification{
+				MatchTemplate:   "func",
+				IncludePatterns: []string{"("},
+			},
+		},
 	}
 
 	store, cleanup, err := testutil.NewStore(nil)
@@ -126,7 +142,8 @@ func doReplace(u string, p *protocol.Request) (string, error) {
 		"FetchTimeout":    []string{p.FetchTimeout},
 		"MatchTemplate":   []string{p.RewriteSpecification.MatchTemplate},
 		"RewriteTemplate": []string{p.RewriteSpecification.RewriteTemplate},
-		"FileExtension":   []string{p.RewriteSpecification.FileExtension},
+		"IncludePatterns": p.RewriteSpecification.IncludePatterns,
+		"ExcludePattern":  []string{p.RewriteSpecification.ExcludePattern},
 	}
 	resp, err := http.PostForm(u, form)
 	if err != nil {
@@ -0,0 +1,210 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gerrit"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/jsonc"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// A GerritSource yields repositories from a single Gerrit connection configured
+// in Sourcegraph via the external services configuration.
+type GerritSource struct {
+	svc     *ExternalService
+	config  *schema.GerritConnection
+	exclude excludeFunc
+	baseURL *url.URL
+	client  *gerrit.Client
+
+	// anonClient is used to determine which projects are visible to
+	// anonymous users, i.e. which projects are public.
+	anonClient *gerrit.Client
+
+	// pageSize is the number of projects requested per page.
+	pageSize int
+}
+
+// NewGerritSource returns a new GerritSource from the given external service.
+func NewGerritSource(svc *ExternalService, cf *httpcli.Factory) (*GerritSource, error) {
+	var c schema.GerritConnection
+	if err := jsonc.Unmarshal(svc.Config, &c); err != nil {
+		return nil, fmt.Errorf("external service id=%d config error: %s", svc.ID, err)
+	}
+	return newGerritSource(svc, &c, cf)
+}
+
+func newGerritSource(svc *ExternalService, c *schema.GerritConnection, cf *httpcli.Factory) (*GerritSource, error) {
+	baseURL, err := url.Parse(c.Url)
+	if err != nil {
+		return nil, err
+	}
+	baseURL = extsvc.NormalizeBaseURL(baseURL)
+
+	if cf == nil {
+		cf = httpcli.NewExternalHTTPClientFactory()
+	}
+
+	cli, err := cf.Doer()
+	if err != nil {
+		return nil, err
+	}
+
+	var eb excludeBuilder
+	for _, r := range c.Exclude {
+		eb.Exact(r.Name)
+		eb.Pattern(r.Pattern)
+	}
+	exclude, err := eb.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	client := gerrit.NewClient(baseURL, cli)
+	client.Username = c.Username
+	client.Password = c.Password
+
+	return &GerritSource{
+		svc:        svc,
+		config:     c,
+		exclude:    exclude,
+		baseURL:    baseURL,
+		client:     client,
+		anonClient: gerrit.NewClient(baseURL, cli),
+		pageSize:   500,
+	}, nil
+}
+
+// ListRepos returns all Gerrit projects accessible to all connections configured
+// in Sourcegraph via the external services configuration.
+func (s GerritSource) ListRepos(ctx context.Context, results chan SourceResult) {
+	public, err := s.listPublicProjects(ctx)
+	if err != nil {
+		// Without knowing which projects are public, all of them are
+		// treated as private so that their permissions are enforced.
+		results <- SourceResult{Source: s, Err: errors.Wrap(err, "gerrit.publicProjects")}
+	}
+
+	prefixes := s.config.Projects
+	if len(prefixes) == 0 {
+		prefixes = []string{""}
+	}
+
+	seen := make(map[string]bool)
+	for _, prefix := range prefixes {
+		err := s.listProjects(ctx, s.client, prefix, func(p *gerrit.Project) {
+			if seen[p.Name] || s.exclude(p.Name) || p.State == gerrit.ProjectStateHidden {
+				return
+			}
+			seen[p.Name] = true
+			results <- SourceResult{Source: s, Repo: s.makeRepo(p, !public[p.Name])}
+		})
+		if err != nil {
+			results <- SourceResult{Source: s, Err: errors.Wrapf(err, "gerrit.projects: prefix=%q", prefix)}
+		}
+	}
+}
+
+// ExternalServices returns a singleton slice containing the external service.
+func (s GerritSource) ExternalServices() ExternalServices {
+	return ExternalServices{s.svc}
+}
+
+func (s GerritSource) listProjects(ctx context.Context, cli *gerrit.Client, prefix string, f func(*gerrit.Project)) error {
+	args := gerrit.ListProjectsArgs{Prefix: prefix, Limit: s.pageSize}
+	for {
+		projects, more, err := cli.ListProjects(ctx, args)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range projects {
+			f(p)
+		}
+
+		if !more {
+			return nil
+		}
+		args.Skip += len(projects)
+	}
+}
+
+// listPublicProjects returns the set of names of projects which anonymous users
+// can read.
+func (s GerritSource) listPublicProjects(ctx context.Context) (map[string]bool, error) {
+	public := make(map[string]bool)
+	err := s.listProjects(ctx, s.anonClient, "", func(p *gerrit.Project) {
+		public[p.Name] = true
+	})
+	if err != nil {
+		if e, ok := errors.Cause(err).(interface{ Unauthorized() bool }); ok && e.Unauthorized() {
+			// Anonymous access is disabled, so no project is public.
+			return public, nil
+		}
+		return nil, err
+	}
+	return public, nil
+}
+
+func (s GerritSource) makeRepo(p *gerrit.Project, private bool) *Repo {
+	urn := s.svc.URN()
+	return &Repo{
+		Name: string(reposource.GerritRepoName(
+			s.config.RepositoryPathPattern,
+			s.baseURL.Hostname(),
+			p.Name,
+		)),
+		URI: string(reposource.GerritRepoName(
+			"",
+			s.baseURL.Hostname(),
+			p.Name,
+		)),
+		ExternalRepo: api.ExternalRepoSpec{
+			ID:          p.Name,
+			ServiceType: gerrit.ServiceType,
+			ServiceID:   s.baseURL.String(),
+		},
+		Description: p.Description,
+		Archived:    p.State == gerrit.ProjectStateReadOnly,
+		Private:     private,
+		Sources: map[string]*SourceInfo{
+			urn: {
+				ID:       urn,
+				CloneURL: s.authenticatedRemoteURL(p),
+			},
+		},
+		Metadata: p,
+	}
+}
+
+// authenticatedRemoteURL returns the project's Git remote URL with the configured
+// Gerrit username and HTTP password inserted in the URL userinfo.
+func (s *GerritSource) authenticatedRemoteURL(p *gerrit.Project) string {
+	if s.config.GitURLType == "ssh" {
+		port := s.config.SshPort
+		if port == 0 {
+			port = 29418
+		}
+		return (&url.URL{
+			Scheme: "ssh",
+			User:   url.User(s.config.Username),
+			Host:   net.JoinHostPort(s.baseURL.Hostname(), strconv.Itoa(port)),
+			Path:   "/" + p.Name,
+		}).String()
+	}
+
+	u := *s.baseURL
+	u.User = url.UserPassword(s.config.Username, s.config.Password)
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/a/" + p.Name
+	return u.String()
+}
@@ -0,0 +1,274 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitea"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/jsonc"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// A GiteaSource yields repositories from a single Gitea or Gogs connection
+// configured in Sourcegraph via the external services configuration.
+type GiteaSource struct {
+	svc             *ExternalService
+	config          *schema.GiteaConnection
+	exclude         excludeFunc
+	excludeArchived bool
+	excludeForks    bool
+	baseURL         *url.URL
+	client          *gitea.Client
+
+	// pageSize is the number of repositories requested per page.
+	pageSize int
+}
+
+// NewGiteaSource returns a new GiteaSource from the given external service.
+func NewGiteaSource(svc *ExternalService, cf *httpcli.Factory) (*GiteaSource, error) {
+	var c schema.GiteaConnection
+	if err := jsonc.Unmarshal(svc.Config, &c); err != nil {
+		return nil, fmt.Errorf("external service id=%d config error: %s", svc.ID, err)
+	}
+	return newGiteaSource(svc, &c, cf)
+}
+
+func newGiteaSource(svc *ExternalService, c *schema.GiteaConnection, cf *httpcli.Factory) (*GiteaSource, error) {
+	baseURL, err := url.Parse(c.Url)
+	if err != nil {
+		return nil, err
+	}
+	baseURL = extsvc.NormalizeBaseURL(baseURL)
+
+	if cf == nil {
+		cf = httpcli.NewExternalHTTPClientFactory()
+	}
+
+	cli, err := cf.Doer()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		eb              excludeBuilder
+		excludeArchived bool
+		excludeForks    bool
+	)
+
+	for _, r := range c.Exclude {
+		eb.Exact(r.Name)
+		if r.Id != 0 {
+			eb.Exact(strconv.Itoa(r.Id))
+		}
+		eb.Pattern(r.Pattern)
+
+		if r.Archived {
+			excludeArchived = true
+		}
+
+		if r.Forks {
+			excludeForks = true
+		}
+	}
+
+	exclude, err := eb.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	client := gitea.NewClient(baseURL, cli)
+	client.Token = c.Token
+
+	return &GiteaSource{
+		svc:             svc,
+		config:          c,
+		exclude:         exclude,
+		excludeArchived: excludeArchived,
+		excludeForks:    excludeForks,
+		baseURL:         baseURL,
+		client:          client,
+		pageSize:        50,
+	}, nil
+}
+
+// ListRepos returns all Gitea repositories accessible to all connections configured
+// in Sourcegraph via the external services configuration.
+func (s GiteaSource) ListRepos(ctx context.Context, results chan SourceResult) {
+	seen := make(map[int64]bool)
+	publicOwners := make(map[string]bool)
+	emit := func(r *gitea.Repository) bool {
+		if seen[r.ID] {
+			return false
+		}
+		seen[r.ID] = true
+		if s.excludes(r) {
+			return true
+		}
+
+		repo := s.makeRepo(r)
+		// 🚨 SECURITY: Repositories of limited or private owners are hidden
+		// from anonymous visitors, so they must not be marked as public.
+		if !repo.Private {
+			public, err := s.ownerPublic(ctx, r, publicOwners)
+			if err != nil {
+				results <- SourceResult{Source: s, Err: errors.Wrapf(err, "gitea.owner: repo=%q", r.FullName)}
+			}
+			repo.Private = !public
+		}
+		results <- SourceResult{Source: s, Repo: repo}
+		return true
+	}
+
+	if len(s.config.Orgs) == 0 && len(s.config.Users) == 0 {
+		if err := s.listRepos(ctx, s.client.ListAuthenticatedUserRepos, emit); err != nil {
+			results <- SourceResult{Source: s, Err: errors.Wrap(err, "gitea.userRepos")}
+		}
+		return
+	}
+
+	for _, org := range s.config.Orgs {
+		err := s.listRepos(ctx, func(ctx context.Context, args gitea.ListArgs) ([]*gitea.Repository, bool, error) {
+			return s.client.ListOrgRepos(ctx, org, args)
+		}, emit)
+		if err != nil {
+			results <- SourceResult{Source: s, Err: errors.Wrapf(err, "gitea.orgRepos: org=%q", org)}
+		}
+	}
+
+	for _, user := range s.config.Users {
+		err := s.listRepos(ctx, func(ctx context.Context, args gitea.ListArgs) ([]*gitea.Repository, bool, error) {
+			return s.client.ListUserRepos(ctx, user, args)
+		}, emit)
+		if err != nil {
+			results <- SourceResult{Source: s, Err: errors.Wrapf(err, "gitea.userRepos: user=%q", user)}
+		}
+	}
+}
+
+// ExternalServices returns a singleton slice containing the external service.
+func (s GiteaSource) ExternalServices() ExternalServices {
+	return ExternalServices{s.svc}
+}
+
+// listRepos calls f with the repositories on every page returned by list. f
+// returns whether the repository wasn't seen before.
+func (s GiteaSource) listRepos(
+	ctx context.Context,
+	list func(context.Context, gitea.ListArgs) ([]*gitea.Repository, bool, error),
+	f func(*gitea.Repository) bool,
+) error {
+	args := gitea.ListArgs{Page: 1, Limit: s.pageSize}
+	for {
+		repos, more, err := list(ctx, args)
+		if err != nil {
+			return err
+		}
+
+		fresh := false
+		for _, r := range repos {
+			if f(r) {
+				fresh = true
+			}
+		}
+
+		// Gogs ignores the pagination arguments of some endpoints and
+		// returns all repositories on every page, so we stop once a page
+		// has nothing new.
+		if !more || !fresh {
+			return nil
+		}
+		args.Page++
+	}
+}
+
+// ownerPublic reports whether the owner of the given repository is public,
+// caching the result by owner name in public.
+func (s GiteaSource) ownerPublic(ctx context.Context, r *gitea.Repository, public map[string]bool) (bool, error) {
+	if r.Owner == nil {
+		return false, errors.New("repository has no owner")
+	}
+
+	name := r.Owner.Name()
+	if p, ok := public[name]; ok {
+		return p, nil
+	}
+
+	owner, err := s.client.GetOwner(ctx, name)
+	if err != nil {
+		return false, err
+	}
+	public[name] = owner.Public()
+	return public[name], nil
+}
+
+func (s GiteaSource) excludes(r *gitea.Repository) bool {
+	if s.exclude(r.FullName) || s.exclude(strconv.FormatInt(r.ID, 10)) {
+		return true
+	}
+
+	if s.excludeArchived && r.Archived {
+		return true
+	}
+
+	if s.excludeForks && r.Fork {
+		return true
+	}
+
+	return false
+}
+
+func (s GiteaSource) makeRepo(r *gitea.Repository) *Repo {
+	urn := s.svc.URN()
+	return &Repo{
+		Name: string(reposource.GiteaRepoName(
+			s.config.RepositoryPathPattern,
+			s.baseURL.Hostname(),
+			r.FullName,
+		)),
+		URI: string(reposource.GiteaRepoName(
+			"",
+			s.baseURL.Hostname(),
+			r.FullName,
+		)),
+		ExternalRepo: api.ExternalRepoSpec{
+			ID:          strconv.FormatInt(r.ID, 10),
+			ServiceType: gitea.ServiceType,
+			ServiceID:   s.baseURL.String(),
+		},
+		Description: r.Description,
+		Fork:        r.Fork,
+		Archived:    r.Archived,
+		Private:     r.Private || r.Internal,
+		Sources: map[string]*SourceInfo{
+			urn: {
+				ID:       urn,
+				CloneURL: s.authenticatedRemoteURL(r),
+			},
+		},
+		Metadata: r,
+	}
+}
+
+// authenticatedRemoteURL returns the repository's Git remote URL with the
+// configured Gitea access token inserted in the URL userinfo.
+func (s *GiteaSource) authenticatedRemoteURL(r *gitea.Repository) string {
+	if s.config.GitURLType == "ssh" && r.SSHURL != "" {
+		return r.SSHURL
+	}
+
+	// The clone URL reported by Gitea is based on its configured ROOT_URL,
+	// which may not be reachable from Sourcegraph, so we use the configured
+	// URL instead.
+	u := *s.baseURL
+	u.User = url.User(s.config.Token)
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + r.FullName + ".git"
+	return u.String()
+}
@@ -0,0 +1,256 @@
+package repos
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitea"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// fakeGitea serves the Gitea repository list and owner endpoints for the given
+// repositories. If paginate is false, it ignores the pagination arguments like
+// Gogs does.
+func fakeGitea(t *testing.T, repos []*gitea.Repository, paginate bool) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var match func(*gitea.Repository) bool
+		switch p := strings.TrimPrefix(r.URL.Path, "/api/v1/"); {
+		case strings.Count(p, "/") == 1 && (strings.HasPrefix(p, "orgs/") || strings.HasPrefix(p, "users/")):
+			for _, repo := range repos {
+				if repo.Owner.Login == strings.Split(p, "/")[1] {
+					if err := json.NewEncoder(w).Encode(repo.Owner); err != nil {
+						t.Error(err)
+					}
+					return
+				}
+			}
+			http.NotFound(w, r)
+			return
+		case p == "user/repos":
+			match = func(*gitea.Repository) bool { return true }
+		case strings.HasPrefix(p, "orgs/") && strings.HasSuffix(p, "/repos"),
+			strings.HasPrefix(p, "users/") && strings.HasSuffix(p, "/repos"):
+			owner := strings.Split(p, "/")[1]
+			match = func(r *gitea.Repository) bool { return r.Owner.Login == owner }
+		default:
+			http.NotFound(w, r)
+			return
+		}
+
+		var visible []*gitea.Repository
+		for _, r := range repos {
+			if match(r) {
+				visible = append(visible, r)
+			}
+		}
+
+		if paginate {
+			q := r.URL.Query()
+			page, _ := strconv.Atoi(q.Get("page"))
+			limit, _ := strconv.Atoi(q.Get("limit"))
+			start, end := (page-1)*limit, page*limit
+			if start > len(visible) {
+				start = len(visible)
+			}
+			if end > len(visible) {
+				end = len(visible)
+			}
+			visible = visible[start:end]
+		}
+
+		if err := json.NewEncoder(w).Encode(visible); err != nil {
+			t.Error(err)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGiteaSource_ListRepos(t *testing.T) {
+	repo := func(id int64, owner, name string, f func(*gitea.Repository)) *gitea.Repository {
+		r := &gitea.Repository{
+			ID:       id,
+			Owner:    &gitea.User{ID: id * 100, Login: owner},
+			Name:     name,
+			FullName: owner + "/" + name,
+		}
+		if f != nil {
+			f(r)
+		}
+		return r
+	}
+	repos := []*gitea.Repository{
+		repo(1, "myteam", "foo", nil),
+		repo(2, "myteam", "secret", func(r *gitea.Repository) { r.Private = true }),
+		repo(3, "myteam", "old", func(r *gitea.Repository) { r.Archived = true }),
+		repo(4, "myteam", "foo-fork", func(r *gitea.Repository) { r.Fork = true }),
+		repo(5, "alice", "dotfiles", nil),
+		repo(6, "experimental", "bar", nil),
+		repo(7, "myteam", "internal", func(r *gitea.Repository) { r.Internal = true }),
+		repo(8, "hidden", "unlisted", func(r *gitea.Repository) { r.Owner.Visibility = gitea.VisibilityLimited }),
+	}
+
+	type result struct {
+		Name     string
+		Private  bool
+		Archived bool
+		Fork     bool
+	}
+
+	for _, tc := range []struct {
+		name     string
+		conf     *schema.GiteaConnection
+		paginate bool
+		want     []result
+	}{
+		{
+			name:     "authenticated user repos",
+			conf:     &schema.GiteaConnection{Exclude: []*schema.ExcludedGiteaRepo{{Pattern: "^experimental/"}}},
+			paginate: true,
+			want: []result{
+				{Name: "myteam/foo"},
+				{Name: "myteam/secret", Private: true},
+				{Name: "myteam/old", Archived: true},
+				{Name: "myteam/foo-fork", Fork: true},
+				{Name: "alice/dotfiles"},
+				{Name: "myteam/internal", Private: true},
+				{Name: "hidden/unlisted", Private: true},
+			},
+		},
+		{
+			name: "orgs and users",
+			conf: &schema.GiteaConnection{
+				Orgs:  []string{"myteam"},
+				Users: []string{"alice"},
+				Exclude: []*schema.ExcludedGiteaRepo{
+					{Name: "MyTeam/Secret"},
+					{Id: 3},
+					{Forks: true},
+				},
+			},
+			paginate: true,
+			want: []result{
+				{Name: "myteam/foo"},
+				{Name: "myteam/internal", Private: true},
+				{Name: "alice/dotfiles"},
+			},
+		},
+		{
+			name: "archived excluded without pagination",
+			conf: &schema.GiteaConnection{
+				Orgs:    []string{"myteam"},
+				Exclude: []*schema.ExcludedGiteaRepo{{Archived: true}},
+			},
+			want: []result{
+				{Name: "myteam/foo"},
+				{Name: "myteam/secret", Private: true},
+				{Name: "myteam/foo-fork", Fork: true},
+				{Name: "myteam/internal", Private: true},
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			srv := fakeGitea(t, repos, tc.paginate)
+			tc.conf.Url = srv.URL
+			tc.conf.Token = "secret"
+			tc.conf.RepositoryPathPattern = "{nameWithOwner}"
+
+			svc := ExternalService{ID: 1, Kind: "GITEA"}
+			src, err := newGiteaSource(&svc, tc.conf, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// A small page size exercises pagination.
+			src.pageSize = 2
+
+			rs, err := listAll(context.Background(), src)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var have []result
+			for _, r := range rs {
+				have = append(have, result{Name: r.Name, Private: r.Private, Archived: r.Archived, Fork: r.Fork})
+			}
+			if diff := cmp.Diff(tc.want, have); diff != "" {
+				t.Errorf("repos: %s", diff)
+			}
+		})
+	}
+}
+
+func TestGiteaSource_makeRepo(t *testing.T) {
+	repo := &gitea.Repository{
+		ID:          42,
+		Owner:       &gitea.User{ID: 1, Login: "myteam"},
+		Name:        "myproject",
+		FullName:    "myteam/myproject",
+		Description: "My project",
+		SSHURL:      "git@gitea.example.com:myteam/myproject.git",
+		CloneURL:    "http://localhost:3000/myteam/myproject.git",
+	}
+
+	for _, tc := range []struct {
+		name         string
+		conf         *schema.GiteaConnection
+		wantName     string
+		wantCloneURL string
+	}{
+		{
+			name: "http",
+			conf: &schema.GiteaConnection{
+				Url:   "https://Gitea.example.com/gitea",
+				Token: "secret",
+			},
+			wantName:     "gitea.example.com/myteam/myproject",
+			wantCloneURL: "https://secret@gitea.example.com/gitea/myteam/myproject.git",
+		},
+		{
+			name: "ssh",
+			conf: &schema.GiteaConnection{
+				Url:                   "https://gitea.example.com",
+				Token:                 "secret",
+				GitURLType:            "ssh",
+				RepositoryPathPattern: "gitea/{nameWithOwner}",
+			},
+			wantName:     "gitea/myteam/myproject",
+			wantCloneURL: "git@gitea.example.com:myteam/myproject.git",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := ExternalService{ID: 1, Kind: "GITEA"}
+			src, err := newGiteaSource(&svc, tc.conf, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := src.makeRepo(repo)
+			if r.Name != tc.wantName {
+				t.Errorf("name: have %q, want %q", r.Name, tc.wantName)
+			}
+			if have := r.CloneURLs(); len(have) != 1 || have[0] != tc.wantCloneURL {
+				t.Errorf("clone URLs: have %q, want %q", have, tc.wantCloneURL)
+			}
+			if r.ExternalRepo.ID != "42" || r.ExternalRepo.ServiceType != gitea.ServiceType {
+				t.Errorf("unexpected external repo: %+v", r.ExternalRepo)
+			}
+			if r.Description != "My project" {
+				t.Errorf("description: have %q", r.Description)
+			}
+		})
+	}
+}
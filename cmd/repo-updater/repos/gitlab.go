@@ -16,7 +16,9 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
 	"github.com/sourcegraph/sourcegraph/internal/httpcli"
 	"github.com/sourcegraph/sourcegraph/internal/jsonc"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
 	"github.com/sourcegraph/sourcegraph/schema"
+	"golang.org/x/time/rate"
 )
 
 // A GitLabSource yields repositories from a single GitLab connection configured
@@ -28,18 +30,24 @@ type GitLabSource struct {
 	baseURL             *url.URL // URL with path /api/v4 (no trailing slash)
 	nameTransformations reposource.NameTransformations
 	client              *gitlab.Client
+	// rateLimiter should be used to limit requests made to the external service
+	rateLimiter *rate.Limiter
 }
 
 // NewGitLabSource returns a new GitLabSource from the given external service.
-func NewGitLabSource(svc *ExternalService, cf *httpcli.Factory) (*GitLabSource, error) {
+// rl is optional
+func NewGitLabSource(svc *ExternalService, cf *httpcli.Factory, rl *rate.Limiter) (*GitLabSource, error) {
 	var c schema.GitLabConnection
 	if err := jsonc.Unmarshal(svc.Config, &c); err != nil {
 		return nil, fmt.Errorf("external service id=%d config error: %s", svc.ID, err)
 	}
-	return newGitLabSource(svc, &c, cf)
+	if rl == nil {
+		rl = rate.NewLimiter(rate.Inf, 0)
+	}
+	return newGitLabSource(svc, &c, cf, rl)
 }
 
-func newGitLabSource(svc *ExternalService, c *schema.GitLabConnection, cf *httpcli.Factory) (*GitLabSource, error) {
+func newGitLabSource(svc *ExternalService, c *schema.GitLabConnection, cf *httpcli.Factory, rl *rate.Limiter) (*GitLabSource, error) {
 	baseURL, err := url.Parse(c.Url)
 	if err != nil {
 		return nil, err
@@ -83,6 +91,7 @@ func newGitLabSource(svc *ExternalService, c *schema.GitLabConnection, cf *httpc
 		baseURL:             baseURL,
 		nameTransformations: nts,
 		client:              gitlab.NewClientProvider(baseURL, cli).GetPATClient(c.Token, ""),
+		rateLimiter:         rl,
 	}, nil
 }
 
@@ -111,6 +120,165 @@ func (s GitLabSource) ExternalServices() ExternalServices {
 	return ExternalServices{s.svc}
 }
 
+var _ ChangesetSource = GitLabSource{}
+
+// CreateChangeset creates the given *Changeset in the code host as a merge
+// request.
+func (s GitLabSource) CreateChangeset(ctx context.Context, c *Changeset) (bool, error) {
+	var exists bool
+	project := c.Repo.Metadata.(*gitlab.Project)
+	source := git.AbbreviateRef(c.HeadRef)
+	target := git.AbbreviateRef(c.BaseRef)
+
+	if err := s.rateLimiter.Wait(ctx); err != nil {
+		return false, errors.Wrap(err, "waiting for rate limiter")
+	}
+
+	mr, err := s.client.CreateMergeRequest(ctx, project.ID, gitlab.CreateMergeRequestOpts{
+		SourceBranch: source,
+		TargetBranch: target,
+		Title:        c.Title,
+		Description:  c.Body,
+	})
+	if err != nil {
+		if err != gitlab.ErrMergeRequestAlreadyExists {
+			return exists, errors.Wrap(err, "creating merge request")
+		}
+
+		if err := s.rateLimiter.Wait(ctx); err != nil {
+			return false, errors.Wrap(err, "waiting for rate limiter")
+		}
+		mr, err = s.client.GetOpenMergeRequestByRefs(ctx, project.ID, source, target)
+		if err != nil {
+			return exists, errors.Wrap(err, "fetching existing merge request")
+		}
+		exists = true
+	}
+
+	if err := s.loadMergeRequestData(ctx, mr); err != nil {
+		return false, errors.Wrap(err, "loading extra metadata")
+	}
+	if err := c.SetMetadata(mr); err != nil {
+		return false, errors.Wrap(err, "setting changeset metadata")
+	}
+
+	return exists, nil
+}
+
+// CloseChangeset closes the merge request of the given *Changeset on the code
+// host and updates the Metadata column in the *campaigns.Changeset to the
+// newly closed merge request.
+func (s GitLabSource) CloseChangeset(ctx context.Context, c *Changeset) error {
+	mr, ok := c.Changeset.Metadata.(*gitlab.MergeRequest)
+	if !ok {
+		return errors.New("Changeset is not a GitLab merge request")
+	}
+
+	if err := s.rateLimiter.Wait(ctx); err != nil {
+		return errors.Wrap(err, "waiting for rate limiter")
+	}
+	updated, err := s.client.UpdateMergeRequest(ctx, mr, gitlab.UpdateMergeRequestOpts{StateEvent: "close"})
+	if err != nil {
+		return errors.Wrap(err, "closing merge request")
+	}
+
+	// The update response doesn't contain the notes and pipelines, so we keep
+	// the ones we already have until the next sync.
+	updated.Notes = mr.Notes
+	updated.Pipelines = mr.Pipelines
+	c.Changeset.Metadata = updated
+
+	return nil
+}
+
+// LoadChangesets loads the latest state of the given Changesets from the codehost.
+func (s GitLabSource) LoadChangesets(ctx context.Context, cs ...*Changeset) error {
+	var notFound []*Changeset
+
+	for i := range cs {
+		project := cs[i].Repo.Metadata.(*gitlab.Project)
+		iid, err := strconv.Atoi(cs[i].ExternalID)
+		if err != nil {
+			return errors.Wrap(err, "parsing changeset external id")
+		}
+
+		if err := s.rateLimiter.Wait(ctx); err != nil {
+			return errors.Wrap(err, "waiting for rate limiter")
+		}
+		mr, err := s.client.GetMergeRequest(ctx, project.ID, iid)
+		if err != nil {
+			if err == gitlab.ErrMergeRequestNotFound {
+				notFound = append(notFound, cs[i])
+				if cs[i].Changeset.Metadata == nil {
+					cs[i].Changeset.Metadata = &gitlab.MergeRequest{ProjectID: project.ID, IID: iid}
+				}
+				continue
+			}
+
+			return errors.Wrapf(err, "retrieving merge request %d", iid)
+		}
+
+		if err := s.loadMergeRequestData(ctx, mr); err != nil {
+			return errors.Wrap(err, "loading merge request data")
+		}
+		if err := cs[i].SetMetadata(mr); err != nil {
+			return errors.Wrap(err, "setting changeset metadata")
+		}
+	}
+
+	if len(notFound) > 0 {
+		return ChangesetsNotFoundError{Changesets: notFound}
+	}
+
+	return nil
+}
+
+// loadMergeRequestData loads the notes and pipelines of the merge request,
+// which aren't included in the merge request API response.
+func (s GitLabSource) loadMergeRequestData(ctx context.Context, mr *gitlab.MergeRequest) error {
+	// We make at least 2 API calls, so wait until the rate limiter allows them.
+	if err := s.rateLimiter.WaitN(ctx, 2); err != nil {
+		return errors.Wrap(err, "waiting for rate limiter")
+	}
+
+	if err := s.client.LoadMergeRequestNotes(ctx, mr); err != nil {
+		return errors.Wrap(err, "loading merge request notes")
+	}
+
+	if err := s.client.LoadMergeRequestPipelines(ctx, mr); err != nil {
+		return errors.Wrap(err, "loading merge request pipelines")
+	}
+
+	return nil
+}
+
+// UpdateChangeset updates the merge request of the given *Changeset in the
+// code host.
+func (s GitLabSource) UpdateChangeset(ctx context.Context, c *Changeset) error {
+	mr, ok := c.Changeset.Metadata.(*gitlab.MergeRequest)
+	if !ok {
+		return errors.New("Changeset is not a GitLab merge request")
+	}
+
+	if err := s.rateLimiter.Wait(ctx); err != nil {
+		return errors.Wrap(err, "waiting for rate limiter")
+	}
+	updated, err := s.client.UpdateMergeRequest(ctx, mr, gitlab.UpdateMergeRequestOpts{
+		Title:        c.Title,
+		Description:  c.Body,
+		TargetBranch: git.AbbreviateRef(c.BaseRef),
+	})
+	if err != nil {
+		return errors.Wrap(err, "updating merge request")
+	}
+
+	updated.Notes = mr.Notes
+	updated.Pipelines = mr.Pipelines
+	c.Changeset.Metadata = updated
+
+	return nil
+}
+
 func (s GitLabSource) makeRepo(proj *gitlab.Project) *Repo {
 	urn := s.svc.URN()
 	return &Repo{
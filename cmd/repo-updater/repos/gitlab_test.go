@@ -140,7 +140,7 @@ func TestGitLabSource_GetRepo(t *testing.T) {
 				}),
 			}
 
-			gitlabSrc, err := NewGitLabSource(svc, cf)
+			gitlabSrc, err := NewGitLabSource(svc, cf, nil)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -198,7 +198,7 @@ func TestGitLabSource_makeRepo(t *testing.T) {
 			lg := log15.New()
 			lg.SetHandler(log15.DiscardHandler())
 
-			s, err := newGitLabSource(&svc, test.schmea, nil)
+			s, err := newGitLabSource(&svc, test.schmea, nil, nil)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -0,0 +1,210 @@
+package repos
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/neelance/parallel"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/repoupdater/protocol"
+)
+
+// GitserverRebalanceClient is the part of the gitserver client used by the
+// GitserverRebalancer.
+type GitserverRebalanceClient interface {
+	Rebalancing(ctx context.Context) bool
+	AllAddrs(ctx context.Context) []string
+	AddrForRepo(ctx context.Context, repo api.RepoName) string
+	TargetAddrForRepo(ctx context.Context, repo api.RepoName) string
+	ListClonedOn(ctx context.Context, addr string) ([]string, error)
+	TransferRepo(ctx context.Context, repo api.RepoName, from, to string) error
+	RemoveFrom(ctx context.Context, repo api.RepoName, addr string) error
+}
+
+// GitserverRebalancer moves repositories to the gitserver they belong on after
+// the gitserver addresses changed.
+//
+// While repositories are being moved, requests for a repository go to the
+// gitserver that held it before (see gitserver.Client.PreviousAddrs). The
+// rebalancer copies each repository to the gitserver it belongs on, and
+// reports its progress in Status. Once all repositories are copied, the
+// previous gitserver addresses can be unset, and the rebalancer then removes
+// the copies that are no longer used.
+type GitserverRebalancer struct {
+	Client GitserverRebalanceClient
+
+	// Concurrency is the number of repositories copied at the same time.
+	Concurrency int
+
+	mu     sync.Mutex
+	status protocol.GitserverRebalanceStatus
+}
+
+// maxRebalanceErrors is the number of recent errors kept in the status.
+const maxRebalanceErrors = 10
+
+// Run moves repositories until ctx is done. It checks for repositories to
+// move every minute while rebalancing, and every hour otherwise.
+func (r *GitserverRebalancer) Run(ctx context.Context) {
+	log := log15.Root().New("worker", "gitserver-rebalancer")
+	for {
+		if err := r.rebalance(ctx, log); err != nil {
+			log.Error("failed to rebalance gitserver repositories", "error", err)
+		}
+
+		interval := time.Hour
+		if r.Client.Rebalancing(ctx) {
+			interval = time.Minute
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Status returns the progress of moving repositories between gitservers.
+func (r *GitserverRebalancer) Status() *protocol.GitserverRebalanceStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := r.status
+	status.Shards = append([]protocol.GitserverShardStatus(nil), r.status.Shards...)
+	status.Errors = append([]string(nil), r.status.Errors...)
+	return &status
+}
+
+type repoMove struct {
+	repo     api.RepoName
+	from, to string
+}
+
+// rebalance copies all repositories that are served by a gitserver other than
+// the one they belong on, and removes copies of repositories that are neither
+// served by nor belong on the gitserver they are on.
+func (r *GitserverRebalancer) rebalance(ctx context.Context, log log15.Logger) error {
+	rebalancing := r.Client.Rebalancing(ctx)
+	addrs := r.Client.AllAddrs(ctx)
+
+	cloned := make(map[string]map[api.RepoName]bool, len(addrs))
+	for _, addr := range addrs {
+		list, err := r.Client.ListClonedOn(ctx, addr)
+		if err != nil {
+			return errors.Wrapf(err, "listing cloned repositories on %s", addr)
+		}
+		cloned[addr] = make(map[api.RepoName]bool, len(list))
+		for _, repo := range list {
+			cloned[addr][api.RepoName(repo)] = true
+		}
+	}
+
+	var (
+		shards  = make(map[string]*protocol.GitserverShardStatus, len(addrs))
+		moves   []repoMove
+		removes []repoMove
+	)
+	for _, addr := range addrs {
+		shards[addr] = &protocol.GitserverShardStatus{Addr: addr}
+	}
+	for addr, repos := range cloned {
+		for repo := range repos {
+			target := r.Client.TargetAddrForRepo(ctx, repo)
+			if target == addr {
+				shards[addr].Cloned++
+				continue
+			}
+
+			serving := r.Client.AddrForRepo(ctx, repo) == addr
+			switch {
+			case serving && cloned[target][repo]:
+				shards[target].Incoming++
+				shards[target].Moved++
+			case serving:
+				shards[target].Incoming++
+				moves = append(moves, repoMove{repo: repo, from: addr, to: target})
+			case cloned[target][repo]:
+				// Neither served by nor belongs on addr, so it is safe to
+				// remove now that the gitserver it belongs on has it.
+				removes = append(removes, repoMove{repo: repo, from: addr})
+			}
+		}
+	}
+
+	r.mu.Lock()
+	r.status.Rebalancing = rebalancing
+	r.status.UpdatedAt = time.Now()
+	r.status.Shards = r.status.Shards[:0]
+	for _, addr := range addrs {
+		r.status.Shards = append(r.status.Shards, *shards[addr])
+	}
+	r.mu.Unlock()
+
+	for _, rm := range removes {
+		if err := r.Client.RemoveFrom(ctx, rm.repo, rm.from); err != nil {
+			log.Error("failed to remove moved repository", "repo", rm.repo, "addr", rm.from, "error", err)
+			continue
+		}
+		log.Info("removed moved repository", "repo", rm.repo, "addr", rm.from)
+	}
+
+	if len(moves) == 0 {
+		return nil
+	}
+	log.Info("moving repositories between gitservers", "count", len(moves))
+
+	// Spread the moves over the gitservers rather than moving one
+	// gitserver's repositories at a time.
+	sort.Slice(moves, func(i, j int) bool { return moves[i].repo < moves[j].repo })
+
+	concurrency := r.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	run := parallel.NewRun(concurrency)
+	for _, m := range moves {
+		if ctx.Err() != nil {
+			break
+		}
+		m := m
+		run.Acquire()
+		go func() {
+			defer run.Release()
+			err := r.Client.TransferRepo(ctx, m.repo, m.from, m.to)
+			r.recordMove(m, err)
+			if err != nil {
+				log.Error("failed to move repository", "repo", m.repo, "from", m.from, "to", m.to, "error", err)
+			}
+		}()
+	}
+	_ = run.Wait()
+	return ctx.Err()
+}
+
+func (r *GitserverRebalancer) recordMove(m repoMove, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.status.Shards {
+		s := &r.status.Shards[i]
+		if s.Addr != m.to {
+			continue
+		}
+		if err != nil {
+			s.Failed++
+		} else {
+			s.Moved++
+		}
+	}
+
+	if err != nil {
+		r.status.Errors = append(r.status.Errors, string(m.repo)+": "+err.Error())
+		if n := len(r.status.Errors); n > maxRebalanceErrors {
+			r.status.Errors = r.status.Errors[n-maxRebalanceErrors:]
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/inconshreveable/log15"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/repoupdater/protocol"
+)
+
+type fakeRebalanceClient struct {
+	rebalancing bool
+	addrs       []string
+	serving     map[api.RepoName]string
+	target      map[api.RepoName]string
+	cloned      map[string][]string
+
+	mu          sync.Mutex
+	transferred []string
+	removed     []string
+}
+
+func (c *fakeRebalanceClient) Rebalancing(context.Context) bool  { return c.rebalancing }
+func (c *fakeRebalanceClient) AllAddrs(context.Context) []string { return c.addrs }
+
+func (c *fakeRebalanceClient) AddrForRepo(_ context.Context, repo api.RepoName) string {
+	if addr, ok := c.serving[repo]; ok {
+		return addr
+	}
+	return c.target[repo]
+}
+
+func (c *fakeRebalanceClient) TargetAddrForRepo(_ context.Context, repo api.RepoName) string {
+	return c.target[repo]
+}
+
+func (c *fakeRebalanceClient) ListClonedOn(_ context.Context, addr string) ([]string, error) {
+	return c.cloned[addr], nil
+}
+
+func (c *fakeRebalanceClient) TransferRepo(_ context.Context, repo api.RepoName, from, to string) error {
+	if repo == "broken" {
+		return errors.New("boom")
+	}
+	c.mu.Lock()
+	c.transferred = append(c.transferred, string(repo)+" "+from+"->"+to)
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *fakeRebalanceClient) RemoveFrom(_ context.Context, repo api.RepoName, addr string) error {
+	c.removed = append(c.removed, string(repo)+" "+addr)
+	return nil
+}
+
+func TestGitserverRebalancer(t *testing.T) {
+	client := &fakeRebalanceClient{
+		rebalancing: true,
+		addrs:       []string{"gs0", "gs1", "gs2"},
+		target: map[api.RepoName]string{
+			"stays":  "gs0",
+			"move":   "gs2",
+			"moved":  "gs2",
+			"stale":  "gs2",
+			"broken": "gs1",
+		},
+		serving: map[api.RepoName]string{
+			"move":   "gs0",
+			"moved":  "gs1",
+			"stale":  "gs0",
+			"broken": "gs0",
+		},
+		cloned: map[string][]string{
+			"gs0": {"stays", "move", "stale", "broken"},
+			"gs1": {"moved", "stale"},
+			"gs2": {"moved", "stale"},
+		},
+	}
+	r := &GitserverRebalancer{Client: client, Concurrency: 2}
+
+	if err := r.rebalance(context.Background(), log15.Root()); err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(client.transferred)
+	if diff := cmp.Diff([]string{"move gs0->gs2"}, client.transferred); diff != "" {
+		t.Errorf("transferred mismatch (-want +got):\n%s", diff)
+	}
+	// The stale copy on gs1 is neither served nor belongs there.
+	if diff := cmp.Diff([]string{"stale gs1"}, client.removed); diff != "" {
+		t.Errorf("removed mismatch (-want +got):\n%s", diff)
+	}
+
+	status := r.Status()
+	if status.UpdatedAt.IsZero() {
+		t.Error("want UpdatedAt to be set")
+	}
+	want := &protocol.GitserverRebalanceStatus{
+		Rebalancing: true,
+		UpdatedAt:   status.UpdatedAt,
+		Shards: []protocol.GitserverShardStatus{
+			{Addr: "gs0", Cloned: 1},
+			{Addr: "gs1", Incoming: 1, Failed: 1},
+			{Addr: "gs2", Cloned: 2, Incoming: 3, Moved: 3},
+		},
+		Errors: []string{"broken: boom"},
+	}
+	if diff := cmp.Diff(want, status); diff != "" {
+		t.Errorf("status mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestGitserverRebalancer_notRebalancing(t *testing.T) {
+	client := &fakeRebalanceClient{
+		addrs: []string{"gs0", "gs1"},
+		target: map[api.RepoName]string{
+			"done":   "gs1",
+			"orphan": "gs1",
+		},
+		cloned: map[string][]string{
+			"gs0": {"done", "orphan"},
+			"gs1": {"done"},
+		},
+	}
+	r := &GitserverRebalancer{Client: client}
+
+	if err := r.rebalance(context.Background(), log15.Root()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(client.transferred) != 0 {
+		t.Errorf("unexpected transfers: %v", client.transferred)
+	}
+	// Copies are only removed once the gitserver the repository belongs on
+	// has it.
+	if diff := cmp.Diff([]string{"done gs0"}, client.removed); diff != "" {
+		t.Errorf("removed mismatch (-want +got):\n%s", diff)
+	}
+}
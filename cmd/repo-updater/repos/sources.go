@@ -75,11 +75,15 @@ func newSource(svc *ExternalService, cf *httpcli.Factory, rl *rate.Limiter) (Sou
 	case "github":
 		return NewGithubSource(svc, cf, rl)
 	case "gitlab":
-		return NewGitLabSource(svc, cf)
+		return NewGitLabSource(svc, cf, rl)
 	case "bitbucketserver":
 		return NewBitbucketServerSource(svc, cf, rl)
 	case "bitbucketcloud":
 		return NewBitbucketCloudSource(svc, cf)
+	case "gerrit":
+		return NewGerritSource(svc, cf)
+	case "gitea":
+		return NewGiteaSource(svc, cf)
 	case "gitolite":
 		return NewGitoliteSource(svc, cf)
 	case "phabricator":
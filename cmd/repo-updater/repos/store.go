@@ -15,6 +15,8 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/awscodecommit"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketcloud"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gerrit"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitea"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitolite"
@@ -849,6 +851,10 @@ func scanRepo(r *Repo, s scanner) error {
 		r.Metadata = new(bitbucketserver.Repo)
 	case "bitbucketcloud":
 		r.Metadata = new(bitbucketcloud.Repo)
+	case "gerrit":
+		r.Metadata = new(gerrit.Project)
+	case "gitea":
+		r.Metadata = new(gitea.Repository)
 	case "awscodecommit":
 		r.Metadata = new(awscodecommit.Repository)
 	case "gitolite":
@@ -19,6 +19,8 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/campaigns"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/awscodecommit"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gerrit"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitea"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitolite"
@@ -96,6 +98,10 @@ func (e ExternalService) Configuration() (cfg interface{}, _ error) {
 		cfg = &schema.AWSCodeCommitConnection{}
 	case "bitbucketserver":
 		cfg = &schema.BitbucketServerConnection{}
+	case "gerrit":
+		cfg = &schema.GerritConnection{}
+	case "gitea":
+		cfg = &schema.GiteaConnection{}
 	case "github":
 		cfg = &schema.GitHubConnection{}
 	case "gitlab":
@@ -124,6 +130,10 @@ func (e *ExternalService) Exclude(rs ...*Repo) error {
 		return e.excludeBitbucketServerRepos(rs...)
 	case "awscodecommit":
 		return e.excludeAWSCodeCommitRepos(rs...)
+	case "gerrit":
+		return e.excludeGerritRepos(rs...)
+	case "gitea":
+		return e.excludeGiteaRepos(rs...)
 	case "gitolite":
 		return e.excludeGitoliteRepos(rs...)
 	case "other":
@@ -426,6 +436,90 @@ func (e *ExternalService) excludeAWSCodeCommitRepos(rs ...*Repo) error {
 	})
 }
 
+// excludeGerritRepos changes the configuration of a Gerrit external service to exclude the
+// given repos from being synced.
+func (e *ExternalService) excludeGerritRepos(rs ...*Repo) error {
+	if len(rs) == 0 {
+		return nil
+	}
+
+	return e.config("gerrit", func(v interface{}) (string, interface{}, error) {
+		c := v.(*schema.GerritConnection)
+		set := make(map[string]bool, len(c.Exclude))
+		for _, ex := range c.Exclude {
+			if ex.Name != "" {
+				set[ex.Name] = true
+			}
+		}
+
+		for _, r := range rs {
+			project, ok := r.Metadata.(*gerrit.Project)
+			if !ok {
+				continue
+			}
+
+			// Gerrit project names are case sensitive, so unlike other
+			// code hosts we don't lower case them.
+			if name := project.Name; name != "" && !set[name] {
+				c.Exclude = append(c.Exclude, &schema.ExcludedGerritProject{Name: name})
+				set[name] = true
+			}
+		}
+
+		return "exclude", c.Exclude, nil
+	})
+}
+
+// excludeGiteaRepos changes the configuration of a Gitea external service to exclude the
+// given repos from being synced.
+func (e *ExternalService) excludeGiteaRepos(rs ...*Repo) error {
+	if len(rs) == 0 {
+		return nil
+	}
+
+	return e.config("gitea", func(v interface{}) (string, interface{}, error) {
+		c := v.(*schema.GiteaConnection)
+		ids := make(map[int64]bool, len(c.Exclude))
+		names := make(map[string]bool, len(c.Exclude))
+		for _, ex := range c.Exclude {
+			if ex.Id != 0 {
+				ids[int64(ex.Id)] = true
+			}
+
+			if ex.Name != "" {
+				names[strings.ToLower(ex.Name)] = true
+			}
+		}
+
+		for _, r := range rs {
+			repo, ok := r.Metadata.(*gitea.Repository)
+			if !ok {
+				continue
+			}
+
+			id := repo.ID
+			name := strings.ToLower(repo.FullName)
+
+			if !names[name] && !ids[id] {
+				c.Exclude = append(c.Exclude, &schema.ExcludedGiteaRepo{
+					Name: repo.FullName,
+					Id:   int(id),
+				})
+
+				if id != 0 {
+					ids[id] = true
+				}
+
+				if name != "" {
+					names[name] = true
+				}
+			}
+		}
+
+		return "exclude", c.Exclude, nil
+	})
+}
+
 func nameWithOwner(name string) string {
 	u, _ := urlx.Parse(name)
 	if u != nil {
@@ -466,6 +560,10 @@ func (e ExternalService) schema() string {
 		return schema.AWSCodeCommitSchemaJSON
 	case "bitbucketserver":
 		return schema.BitbucketServerSchemaJSON
+	case "gerrit":
+		return schema.GerritSchemaJSON
+	case "gitea":
+		return schema.GiteaSchemaJSON
 	case "github":
 		return schema.GitHubSchemaJSON
 	case "gitlab":
@@ -7,6 +7,8 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/awscodecommit"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gerrit"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitea"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitolite"
@@ -78,6 +80,31 @@ func TestExternalService_Exclude(t *testing.T) {
 		UpdatedAt: now,
 	}
 
+	gerritService := ExternalService{
+		Kind:        "GERRIT",
+		DisplayName: "Gerrit",
+		Config: `{
+			// Some comment
+			"url": "https://gerrit.mycorp.com",
+			"username": "admin",
+			"password": "secret"
+		}`,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	giteaService := ExternalService{
+		Kind:        "GITEA",
+		DisplayName: "Gitea",
+		Config: `{
+			// Some comment
+			"url": "https://gitea.mycorp.com",
+			"token": "secret"
+		}`,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
 	gitoliteService := ExternalService{
 		Kind:        "GITOLITE",
 		DisplayName: "Gitolite",
@@ -175,6 +202,18 @@ func TestExternalService_Exclude(t *testing.T) {
 		{
 			Metadata: &gitolite.Repo{Name: "foo"},
 		},
+		{
+			Metadata: &gerrit.Project{Name: "org/foo"},
+		},
+		{
+			Metadata: &gerrit.Project{Name: "org/baz"},
+		},
+		{
+			Metadata: &gitea.Repository{ID: 1, FullName: "org/foo"},
+		},
+		{
+			Metadata: &gitea.Repository{FullName: "org/baz"},
+		},
 	}
 
 	var testCases []testCase
@@ -234,6 +273,32 @@ func TestExternalService_Exclude(t *testing.T) {
 					]
 				}`)
 			}),
+			gerritService.With(func(e *ExternalService) {
+				e.Config = formatJSON(t, `
+				{
+					// Some comment
+					"url": "https://gerrit.mycorp.com",
+					"username": "admin",
+					"password": "secret",
+					"exclude": [
+						{"name": "org/foo"},
+						{"pattern": "^unrelated/"},
+						{"name": "org/baz"}
+					]
+				}`)
+			}),
+			giteaService.With(func(e *ExternalService) {
+				e.Config = formatJSON(t, `
+				{
+					// Some comment
+					"url": "https://gitea.mycorp.com",
+					"token": "secret",
+					"exclude": [
+						{"id": 1},
+						{"name": "org/BAZ"}
+					]
+				}`)
+			}),
 			gitoliteService.With(func(e *ExternalService) {
 				e.Config = formatJSON(t, `
 				{
@@ -307,6 +372,29 @@ func TestExternalService_Exclude(t *testing.T) {
 					]
 				}`)
 			}),
+			gerritService.With(func(e *ExternalService) {
+				e.Config = formatJSON(t, `
+				{
+					// Some comment
+					"url": "https://gerrit.mycorp.com",
+					"username": "admin",
+					"password": "secret",
+					"exclude": [
+						{"name": "org/boo"}
+					]
+				}`)
+			}),
+			giteaService.With(func(e *ExternalService) {
+				e.Config = formatJSON(t, `
+				{
+					// Some comment
+					"url": "https://gitea.mycorp.com",
+					"token": "secret",
+					"exclude": [
+						{"name": "org/boo"}
+					]
+				}`)
+			}),
 			gitoliteService.With(func(e *ExternalService) {
 				e.Config = formatJSON(t, `
 				{
@@ -394,6 +482,33 @@ func TestExternalService_Exclude(t *testing.T) {
 						]
 					}`)
 				}),
+				gerritService.With(func(e *ExternalService) {
+					e.Config = formatJSON(t, `
+					{
+						// Some comment
+						"url": "https://gerrit.mycorp.com",
+						"username": "admin",
+						"password": "secret",
+						"exclude": [
+							{"name": "org/boo"},
+							{"name": "org/foo"},
+							{"name": "org/baz"}
+						]
+					}`)
+				}),
+				giteaService.With(func(e *ExternalService) {
+					e.Config = formatJSON(t, `
+					{
+						// Some comment
+						"url": "https://gitea.mycorp.com",
+						"token": "secret",
+						"exclude": [
+							{"name": "org/boo"},
+							{"id": 1, "name": "org/foo"},
+							{"name": "org/baz"}
+						]
+					}`)
+				}),
 				gitoliteService.With(func(e *ExternalService) {
 					e.Config = formatJSON(t, `
 					{
@@ -19,6 +19,8 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/extsvc"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/awscodecommit"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gerrit"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitea"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
 	"github.com/sourcegraph/sourcegraph/internal/httpcli"
@@ -51,6 +53,19 @@ type Server struct {
 		// the registry can start or stop the syncer associated with the service
 		HandleExternalServiceSync(es api.ExternalService)
 	}
+	PermsSyncer interface {
+		// ScheduleUsers schedules permissions syncing of the given users with
+		// high priority.
+		ScheduleUsers(ctx context.Context, userIDs ...int32)
+		// ScheduleRepos schedules permissions syncing of the given repositories
+		// with high priority.
+		ScheduleRepos(ctx context.Context, repoIDs ...api.RepoID)
+	}
+	GitserverRebalancer interface {
+		// Status returns the progress of moving repositories between
+		// gitservers.
+		Status() *protocol.GitserverRebalanceStatus
+	}
 	RateLimiterRegistry interface {
 		// HandleExternalServiceSync should be called when an external service changes so that
 		// our internal rate limiter are kept in sync
@@ -73,6 +88,8 @@ func (s *Server) Handler() http.Handler {
 	mux.HandleFunc("/sync-external-service", s.handleExternalServiceSync)
 	mux.HandleFunc("/status-messages", s.handleStatusMessages)
 	mux.HandleFunc("/enqueue-changeset-sync", s.handleEnqueueChangesetSync)
+	mux.HandleFunc("/schedule-perms-sync", s.handleSchedulePermsSync)
+	mux.HandleFunc("/gitserver-rebalance-status", s.handleGitserverRebalanceStatus)
 	return mux
 }
 
@@ -612,6 +629,38 @@ func (s *Server) handleEnqueueChangesetSync(w http.ResponseWriter, r *http.Reque
 	respond(w, http.StatusOK, nil)
 }
 
+func (s *Server) handleSchedulePermsSync(w http.ResponseWriter, r *http.Request) {
+	if s.PermsSyncer == nil {
+		log15.Warn("PermsSyncer is nil")
+		respond(w, http.StatusForbidden, nil)
+		return
+	}
+
+	var req protocol.PermsSyncRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond(w, http.StatusBadRequest, err)
+		return
+	}
+	if len(req.UserIDs) == 0 && len(req.RepoIDs) == 0 {
+		respond(w, http.StatusBadRequest, errors.New("neither user IDs nor repo IDs was provided in request (must provide at least one)"))
+		return
+	}
+
+	s.PermsSyncer.ScheduleUsers(r.Context(), req.UserIDs...)
+	s.PermsSyncer.ScheduleRepos(r.Context(), req.RepoIDs...)
+
+	respond(w, http.StatusOK, nil)
+}
+
+func (s *Server) handleGitserverRebalanceStatus(w http.ResponseWriter, r *http.Request) {
+	if s.GitserverRebalancer == nil {
+		log15.Warn("GitserverRebalancer is nil")
+		respond(w, http.StatusForbidden, nil)
+		return
+	}
+	respond(w, http.StatusOK, s.GitserverRebalancer.Status())
+}
+
 func newRepoInfo(r *repos.Repo) (*protocol.RepoInfo, error) {
 	urls := r.CloneURLs()
 	if len(urls) == 0 {
@@ -659,6 +708,38 @@ func newRepoInfo(r *repos.Repo) (*protocol.RepoInfo, error) {
 			Blob:   pathAppend(root, "/browse/{path}?at={rev}"),
 			Commit: pathAppend(root, "/commits/{commit}"),
 		}
+	case "gerrit":
+		proj := r.Metadata.(*gerrit.Project)
+		if gitiles := proj.Gitiles(); gitiles != nil {
+			info.Links = &protocol.RepoLinks{
+				Root:   gitiles.URL,
+				Tree:   pathAppend(gitiles.URL, "/+/{rev}/{path}"),
+				Blob:   pathAppend(gitiles.URL, "/+/{rev}/{path}"),
+				Commit: pathAppend(gitiles.URL, "/+/{commit}"),
+			}
+			break
+		}
+
+		// Without a repository browser, Gerrit can only link to the
+		// project's settings and to the changes of a commit.
+		info.Links = &protocol.RepoLinks{
+			Root:   pathAppend(r.ExternalRepo.ServiceID, "/admin/repos/"+proj.Name),
+			Commit: pathAppend(r.ExternalRepo.ServiceID, "/q/{commit}"),
+		}
+	case "gitea":
+		repo := r.Metadata.(*gitea.Repository)
+		root := repo.HTMLURL
+		if root == "" {
+			root = pathAppend(r.ExternalRepo.ServiceID, "/"+repo.FullName)
+		}
+		// Gogs and Gitea both resolve any revision in "/src/{rev}", Gitea by
+		// redirecting to the branch, tag or commit URL.
+		info.Links = &protocol.RepoLinks{
+			Root:   root,
+			Tree:   pathAppend(root, "/src/{rev}/{path}"),
+			Blob:   pathAppend(root, "/src/{rev}/{path}"),
+			Commit: pathAppend(root, "/commit/{commit}"),
+		}
 	case "awscodecommit":
 		repo := r.Metadata.(*awscodecommit.Repository)
 		if repo.ARN == "" {
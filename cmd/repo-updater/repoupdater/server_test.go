@@ -23,6 +23,8 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/awscodecommit"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gerrit"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitea"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver"
@@ -541,6 +543,74 @@ func TestServer_RepoExternalServices(t *testing.T) {
 	}
 }
 
+type fakePermsSyncer struct {
+	userIDs []int32
+	repoIDs []api.RepoID
+}
+
+func (s *fakePermsSyncer) ScheduleUsers(ctx context.Context, userIDs ...int32) {
+	s.userIDs = append(s.userIDs, userIDs...)
+}
+
+func (s *fakePermsSyncer) ScheduleRepos(ctx context.Context, repoIDs ...api.RepoID) {
+	s.repoIDs = append(s.repoIDs, repoIDs...)
+}
+
+func TestServer_SchedulePermsSync(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("no perms syncer", func(t *testing.T) {
+		s := &Server{}
+		srv := httptest.NewServer(s.Handler())
+		defer srv.Close()
+		cli := repoupdater.Client{URL: srv.URL}
+
+		err := cli.SchedulePermsSync(ctx, protocol.PermsSyncRequest{UserIDs: []int32{1}})
+		if err == nil {
+			t.Fatal("expected an error, got nil")
+		}
+	})
+
+	t.Run("empty request", func(t *testing.T) {
+		syncer := &fakePermsSyncer{}
+		s := &Server{PermsSyncer: syncer}
+		srv := httptest.NewServer(s.Handler())
+		defer srv.Close()
+		cli := repoupdater.Client{URL: srv.URL}
+
+		err := cli.SchedulePermsSync(ctx, protocol.PermsSyncRequest{})
+		if err == nil {
+			t.Fatal("expected an error, got nil")
+		}
+		if len(syncer.userIDs) > 0 || len(syncer.repoIDs) > 0 {
+			t.Fatalf("unexpected scheduled requests: %+v", syncer)
+		}
+	})
+
+	t.Run("users and repos", func(t *testing.T) {
+		syncer := &fakePermsSyncer{}
+		s := &Server{PermsSyncer: syncer}
+		srv := httptest.NewServer(s.Handler())
+		defer srv.Close()
+		cli := repoupdater.Client{URL: srv.URL}
+
+		err := cli.SchedulePermsSync(ctx, protocol.PermsSyncRequest{
+			UserIDs: []int32{1, 2},
+			RepoIDs: []api.RepoID{3},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if have, want := syncer.userIDs, []int32{1, 2}; !reflect.DeepEqual(have, want) {
+			t.Errorf("userIDs: %s", cmp.Diff(have, want))
+		}
+		if have, want := syncer.repoIDs, []api.RepoID{3}; !reflect.DeepEqual(have, want) {
+			t.Errorf("repoIDs: %s", cmp.Diff(have, want))
+		}
+	})
+}
+
 func TestServer_StatusMessages(t *testing.T) {
 	githubService := &repos.ExternalService{
 		ID:          1,
@@ -813,6 +883,55 @@ func TestRepoLookup(t *testing.T) {
 		},
 	}
 
+	gerritRepository := &repos.Repo{
+		Name:        "gerrit.example.com/platform/build",
+		Description: "Build system",
+		CreatedAt:   now,
+		ExternalRepo: api.ExternalRepoSpec{
+			ID:          "platform/build",
+			ServiceType: gerrit.ServiceType,
+			ServiceID:   "https://gerrit.example.com/",
+		},
+		Sources: map[string]*repos.SourceInfo{
+			"extsvc:789": {
+				ID:       "extsvc:789",
+				CloneURL: "https://gerrit.example.com/a/platform/build",
+			},
+		},
+		Metadata: &gerrit.Project{
+			ID:          "platform%2Fbuild",
+			Name:        "platform/build",
+			Description: "Build system",
+			WebLinks: []*gerrit.WebLink{
+				{Name: "gitiles", URL: "https://gerrit.example.com/plugins/gitiles/platform/build"},
+			},
+		},
+	}
+
+	giteaRepository := &repos.Repo{
+		Name:        "gitea.example.com/myteam/myproject",
+		Description: "My project",
+		CreatedAt:   now,
+		ExternalRepo: api.ExternalRepoSpec{
+			ID:          "42",
+			ServiceType: gitea.ServiceType,
+			ServiceID:   "https://gitea.example.com/",
+		},
+		Sources: map[string]*repos.SourceInfo{
+			"extsvc:790": {
+				ID:       "extsvc:790",
+				CloneURL: "https://gitea.example.com/myteam/myproject.git",
+			},
+		},
+		Metadata: &gitea.Repository{
+			ID:          42,
+			Name:        "myproject",
+			FullName:    "myteam/myproject",
+			Description: "My project",
+			HTMLURL:     "https://gitea.example.com/myteam/myproject",
+		},
+	}
+
 	gitlabRepository := &repos.Repo{
 		Name:        "gitlab.com/gitlab-org/gitaly",
 		Description: "Gitaly is a Git RPC service for handling all the git calls made by GitLab",
@@ -908,6 +1027,52 @@ func TestRepoLookup(t *testing.T) {
 				},
 			}},
 		},
+		{
+			name: "found - Gerrit",
+			args: protocol.RepoLookupArgs{
+				Repo: api.RepoName("gerrit.example.com/platform/build"),
+			},
+			stored: []*repos.Repo{gerritRepository},
+			result: &protocol.RepoLookupResult{Repo: &protocol.RepoInfo{
+				ExternalRepo: api.ExternalRepoSpec{
+					ID:          "platform/build",
+					ServiceType: gerrit.ServiceType,
+					ServiceID:   "https://gerrit.example.com/",
+				},
+				Name:        "gerrit.example.com/platform/build",
+				Description: "Build system",
+				VCS:         protocol.VCSInfo{URL: "https://gerrit.example.com/a/platform/build"},
+				Links: &protocol.RepoLinks{
+					Root:   "https://gerrit.example.com/plugins/gitiles/platform/build",
+					Tree:   "https://gerrit.example.com/plugins/gitiles/platform/build/+/{rev}/{path}",
+					Blob:   "https://gerrit.example.com/plugins/gitiles/platform/build/+/{rev}/{path}",
+					Commit: "https://gerrit.example.com/plugins/gitiles/platform/build/+/{commit}",
+				},
+			}},
+		},
+		{
+			name: "found - Gitea",
+			args: protocol.RepoLookupArgs{
+				Repo: api.RepoName("gitea.example.com/myteam/myproject"),
+			},
+			stored: []*repos.Repo{giteaRepository},
+			result: &protocol.RepoLookupResult{Repo: &protocol.RepoInfo{
+				ExternalRepo: api.ExternalRepoSpec{
+					ID:          "42",
+					ServiceType: gitea.ServiceType,
+					ServiceID:   "https://gitea.example.com/",
+				},
+				Name:        "gitea.example.com/myteam/myproject",
+				Description: "My project",
+				VCS:         protocol.VCSInfo{URL: "https://gitea.example.com/myteam/myproject.git"},
+				Links: &protocol.RepoLinks{
+					Root:   "https://gitea.example.com/myteam/myproject",
+					Tree:   "https://gitea.example.com/myteam/myproject/src/{rev}/{path}",
+					Blob:   "https://gitea.example.com/myteam/myproject/src/{rev}/{path}",
+					Commit: "https://gitea.example.com/myteam/myproject/commit/{commit}",
+				},
+			}},
+		},
 		{
 			name: "found - GitHub.com on Sourcegraph.com",
 			args: protocol.RepoLookupArgs{
@@ -37,6 +37,7 @@ type EnterpriseInit func(db *sql.DB, store repos.Store, cf *httpcli.Factory, ser
 
 func Main(enterpriseInit EnterpriseInit) {
 	streamingSyncer, _ := strconv.ParseBool(env.Get("SRC_STREAMING_SYNCER_ENABLED", "true", "Use the new, streaming repo metadata syncer."))
+	rebalanceConcurrency, _ := strconv.Atoi(env.Get("SRC_GITSERVER_REBALANCE_CONCURRENCY", "4", "Number of repositories copied at the same time when moving repositories between gitservers."))
 
 	ctx := context.Background()
 	env.Lock()
@@ -153,7 +154,7 @@ func Main(enterpriseInit EnterpriseInit) {
 				}
 			case *schema.GitLabConnection:
 				if strings.HasPrefix(c.Url, "https://gitlab.com") && c.Token != "" {
-					server.GitLabDotComSource, err = repos.NewGitLabSource(e, cf)
+					server.GitLabDotComSource, err = repos.NewGitLabSource(e, cf, nil)
 				}
 			}
 
@@ -202,6 +203,14 @@ func Main(enterpriseInit EnterpriseInit) {
 	go repos.RunScheduler(ctx, scheduler)
 	log15.Debug("started scheduler")
 
+	// Moves repositories between gitservers when the gitserver addresses change
+	rebalancer := &repos.GitserverRebalancer{
+		Client:      gitserver.DefaultClient,
+		Concurrency: rebalanceConcurrency,
+	}
+	server.GitserverRebalancer = rebalancer
+	go rebalancer.Run(ctx)
+
 	host := ""
 	if env.InsecureDev {
 		host = "127.0.0.1"
@@ -257,6 +266,14 @@ func watchSyncer(ctx context.Context, syncer *repos.Syncer, sched scheduler, gps
 				}
 			}()
 
+			// Dynamic repository groups are defined by rules over repository
+			// metadata, which may have changed.
+			go func() {
+				if err := api.InternalClient.RepoGroupsEvaluate(ctx); err != nil {
+					log15.Error("RepoGroupsEvaluate", "error", err)
+				}
+			}()
+
 		case diff := <-syncer.SubsetSynced:
 			if !conf.Get().DisableAutoGitUpdates {
 				sched.UpdateFromDiff(diff)
@@ -175,6 +175,56 @@ type FileMatch struct {
 
 	// LimitHit is true if LineMatches may not include all LineMatches.
 	LimitHit bool
+
+	// StructuralMatches are the matches of a structural search pattern,
+	// each of which may span multiple lines. It is only set for structural
+	// searches, in which case LineMatches holds the same matches split up
+	// into lines.
+	StructuralMatches []StructuralMatch `json:",omitempty"`
+}
+
+// StructuralMatch is a single match of a structural search pattern.
+type StructuralMatch struct {
+	// Matched is the matched content.
+	Matched string
+
+	// Range is the range of the match.
+	Range Range
+
+	// Environment holds the values bound to the holes of the pattern, like
+	// :[arg], in this match.
+	Environment []HoleBinding
+}
+
+// HoleBinding is the value bound to a hole of a structural search pattern.
+type HoleBinding struct {
+	// Variable is the name of the hole, e.g. "arg" for :[arg].
+	Variable string
+
+	// Value is the content bound to the hole.
+	Value string
+
+	// Range is the range of Value.
+	Range Range
+}
+
+// Range is a range in a file, from Start (inclusive) to End (exclusive).
+type Range struct {
+	Start Location
+	End   Location
+}
+
+// Location is a location in a file.
+type Location struct {
+	// Offset is the 0-based byte offset from the start of the file.
+	Offset int
+
+	// Line is the 0-based line number.
+	Line int
+
+	// Column is the 0-based offset from the start of the line, measured
+	// like the offsets of LineMatch.OffsetAndLengths.
+	Column int
 }
 
 // LineMatch is the struct used by vscode to receive search results for a line.
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/inconshreveable/log15"
@@ -65,105 +66,64 @@ func highlightMultipleLines(r *comby.Match) (matches []protocol.LineMatch) {
 	return matches
 }
 
+// toStructuralMatch converts a comby match to a protocol.StructuralMatch,
+// which keeps the match as a unit together with the values bound to holes.
+func toStructuralMatch(r *comby.Match) protocol.StructuralMatch {
+	environment := make([]protocol.HoleBinding, 0, len(r.Environment))
+	for _, e := range r.Environment {
+		environment = append(environment, protocol.HoleBinding{
+			Variable: e.Variable,
+			Value:    e.Value,
+			Range:    toRange(e.Range),
+		})
+	}
+	// Report the bindings in the order the holes occur in the match.
+	sort.SliceStable(environment, func(i, j int) bool {
+		return environment[i].Range.Start.Offset < environment[j].Range.Start.Offset
+	})
+	return protocol.StructuralMatch{
+		Matched:     r.Matched,
+		Range:       toRange(r.Range),
+		Environment: environment,
+	}
+}
+
+// toRange converts a comby range, whose lines and columns are 1-based, to a
+// protocol.Range, whose lines and columns are 0-based.
+func toRange(r comby.Range) protocol.Range {
+	toLocation := func(l comby.Location) protocol.Location {
+		return protocol.Location{
+			Offset: l.Offset,
+			Line:   l.Line - 1,
+			Column: l.Column - 1,
+		}
+	}
+	return protocol.Range{
+		Start: toLocation(r.Start),
+		End:   toLocation(r.End),
+	}
+}
+
 func ToFileMatch(combyMatches []comby.FileMatch) (matches []protocol.FileMatch) {
 	for _, m := range combyMatches {
 		var lineMatches []protocol.LineMatch
+		structuralMatches := make([]protocol.StructuralMatch, 0, len(m.Matches))
 		for _, r := range m.Matches {
 			lineMatches = append(lineMatches, highlightMultipleLines(&r)...)
+			structuralMatches = append(structuralMatches, toStructuralMatch(&r))
 		}
 		matches = append(matches,
 			protocol.FileMatch{
-				Path:        m.URI,
-				LineMatches: lineMatches,
-				MatchCount:  len(m.Matches),
-				LimitHit:    false,
+				Path:              m.URI,
+				LineMatches:       lineMatches,
+				StructuralMatches: structuralMatches,
+				MatchCount:        len(m.Matches),
+				LimitHit:          false,
 			})
 	}
 	return matches
 }
 
-// lookupMatcher looks up a key for specifying -matcher in comby. Comby accepts
-// a representative file extension to set a language, so this lookup does not
-// need to consider all possible file extensions for a language. There is a generic
-// fallback language, so this lookup does not need to be exhaustive either.
-func lookupMatcher(language string) string {
-	switch strings.ToLower(language) {
-	case "assembly", "asm":
-		return ".s"
-	case "bash":
-		return ".sh"
-	case "c":
-		return ".c"
-	case "c#, csharp":
-		return ".cs"
-	case "css":
-		return ".css"
-	case "dart":
-		return ".dart"
-	case "clojure":
-		return ".clj"
-	case "elm":
-		return ".elm"
-	case "erlang":
-		return ".erl"
-	case "elixir":
-		return ".ex"
-	case "fortran":
-		return ".f"
-	case "f#", "fsharp":
-		return ".fsx"
-	case "go":
-		return ".go"
-	case "html":
-		return ".html"
-	case "haskell":
-		return ".hs"
-	case "java":
-		return ".java"
-	case "javascript":
-		return ".js"
-	case "json":
-		return ".json"
-	case "julia":
-		return ".jl"
-	case "kotlin":
-		return ".kt"
-	case "laTeX":
-		return ".tex"
-	case "lisp":
-		return ".lisp"
-	case "nim":
-		return ".nim"
-	case "ocaml":
-		return ".ml"
-	case "pascal":
-		return ".pas"
-	case "php":
-		return ".php"
-	case "python":
-		return ".py"
-	case "reason":
-		return ".re"
-	case "ruby":
-		return ".rb"
-	case "rust":
-		return ".rs"
-	case "scala":
-		return ".scala"
-	case "sql":
-		return ".sql"
-	case "swift":
-		return ".swift"
-	case "text":
-		return ".txt"
-	case "typescript", "ts":
-		return ".ts"
-	case "xml":
-		return ".xml"
-	}
-	return ""
-}
-
 // languageMetric takes an extension and list of include patterns and returns a
 // label that describes which language is inferred for structural matching.
 func languageMetric(matcher string, includePatterns *[]string) string {
@@ -190,7 +150,7 @@ func structuralSearch(ctx context.Context, zipPath, pattern, rule string, langua
 	if len(languages) > 0 {
 		// Pick the first language, there is no support for applying
 		// multiple language matchers in a single search query.
-		matcher = lookupMatcher(languages[0])
+		matcher = comby.MatcherForLanguage(languages[0])
 		log15.Debug("structural search", "language", languages[0], "matcher", matcher)
 	}
 
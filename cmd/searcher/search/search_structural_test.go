@@ -276,6 +276,33 @@ func TestRule(t *testing.T) {
 					Preview:          "func foo(success)",
 				},
 			},
+			StructuralMatches: []protocol.StructuralMatch{
+				{
+					Matched: "func foo(success)",
+					Range: protocol.Range{
+						Start: protocol.Location{Offset: 0, Line: 0, Column: 0},
+						End:   protocol.Location{Offset: 17, Line: 0, Column: 17},
+					},
+					Environment: []protocol.HoleBinding{
+						{
+							Variable: "fn",
+							Value:    "foo",
+							Range: protocol.Range{
+								Start: protocol.Location{Offset: 5, Line: 0, Column: 5},
+								End:   protocol.Location{Offset: 8, Line: 0, Column: 8},
+							},
+						},
+						{
+							Variable: "args",
+							Value:    "success",
+							Range: protocol.Range{
+								Start: protocol.Location{Offset: 9, Line: 0, Column: 9},
+								End:   protocol.Location{Offset: 16, Line: 0, Column: 16},
+							},
+						},
+					},
+				},
+			},
 			MatchCount: 1,
 		},
 	}
@@ -381,6 +408,50 @@ func TestHighlightMultipleLines(t *testing.T) {
 	}
 }
 
+func TestToStructuralMatch(t *testing.T) {
+	loc := func(offset, line, column int) comby.Location {
+		return comby.Location{Offset: offset, Line: line, Column: column}
+	}
+	match := &comby.Match{
+		Range:   comby.Range{Start: loc(4, 2, 1), End: loc(24, 3, 7)},
+		Matched: "foo(a,\n  b) {}",
+		Environment: []comby.Environment{
+			{Variable: "second", Value: "b", Range: comby.Range{Start: loc(13, 3, 3), End: loc(14, 3, 4)}},
+			{Variable: "first", Value: "a", Range: comby.Range{Start: loc(8, 2, 5), End: loc(9, 2, 6)}},
+		},
+	}
+
+	got := toStructuralMatch(match)
+	want := protocol.StructuralMatch{
+		Matched: "foo(a,\n  b) {}",
+		Range: protocol.Range{
+			Start: protocol.Location{Offset: 4, Line: 1, Column: 0},
+			End:   protocol.Location{Offset: 24, Line: 2, Column: 6},
+		},
+		Environment: []protocol.HoleBinding{
+			{
+				Variable: "first",
+				Value:    "a",
+				Range: protocol.Range{
+					Start: protocol.Location{Offset: 8, Line: 1, Column: 4},
+					End:   protocol.Location{Offset: 9, Line: 1, Column: 5},
+				},
+			},
+			{
+				Variable: "second",
+				Value:    "b",
+				Range: protocol.Range{
+					Start: protocol.Location{Offset: 13, Line: 2, Column: 2},
+					End:   protocol.Location{Offset: 14, Line: 2, Column: 3},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+}
+
 func TestMatchCountForMultilineMatches(t *testing.T) {
 	// If we are not on CI skip the test.
 	if os.Getenv("CI") == "" {
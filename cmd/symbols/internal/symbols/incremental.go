@@ -0,0 +1,207 @@
+package symbols
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/inconshreveable/log15"
+	"github.com/jmoiron/sqlx"
+	"github.com/opentracing/opentracing-go/ext"
+	otlog "github.com/opentracing/opentracing-go/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/symbols/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
+)
+
+// maxIncrementalChanges is the maximum number of changed files for which a
+// symbols database is built from a previously indexed commit. Above this, it
+// is usually cheaper to parse the whole archive.
+const maxIncrementalChanges = 1000
+
+// indexedCommit is a commit whose symbols database is in the disk cache.
+type indexedCommit struct {
+	commitID api.CommitID
+	path     string // path of the database in the disk cache
+}
+
+func (s *Service) setIndexed(repo api.RepoName, c indexedCommit) {
+	s.indexedMu.Lock()
+	defer s.indexedMu.Unlock()
+	if s.indexed == nil {
+		s.indexed = make(map[api.RepoName]indexedCommit)
+	}
+	s.indexed[repo] = c
+}
+
+// maxAncestorLookups is the number of ancestors of a commit whose symbols
+// databases are looked up in the disk cache to build the database of the
+// commit incrementally.
+const maxAncestorLookups = 100
+
+// incrementalBase returns the indexed commit to build the symbols of
+// repo@commitID from, if any. This is the nearest ancestor of commitID whose
+// database is in the disk cache or, failing that, the most recently used
+// database of the repository.
+func (s *Service) incrementalBase(ctx context.Context, repo api.RepoName, commitID api.CommitID) (indexedCommit, bool) {
+	if s.FetchTarPaths == nil || s.ChangedFiles == nil {
+		return indexedCommit{}, false
+	}
+
+	if s.Ancestors != nil {
+		ancestors, err := s.Ancestors(ctx, gitserver.Repo{Name: repo}, commitID, maxAncestorLookups)
+		if err != nil {
+			log15.Warn("Unable to list ancestors to build symbols incrementally.", "repo", repo, "commit", commitID, "error", err)
+		}
+		for _, ancestor := range ancestors {
+			if ancestor == commitID {
+				continue
+			}
+			if path, ok := s.cache.Lookup(dbKey(repo, ancestor)); ok {
+				return indexedCommit{commitID: ancestor, path: path}, true
+			}
+		}
+	}
+
+	s.indexedMu.Lock()
+	defer s.indexedMu.Unlock()
+	base, ok := s.indexed[repo]
+	if !ok || base.commitID == commitID {
+		return indexedCommit{}, false
+	}
+	return base, true
+}
+
+// writeSymbolsToNewDB writes the symbols of repo@commitID to the blank
+// database file dbFile. When a database of another commit of the repository
+// is available, only the files that changed since that commit are parsed.
+// Otherwise, or if that fails, all files are parsed.
+func (s *Service) writeSymbolsToNewDB(ctx context.Context, dbFile string, repoName api.RepoName, commitID api.CommitID) error {
+	if base, ok := s.incrementalBase(ctx, repoName, commitID); ok {
+		err := s.writeIncrementalSymbolsToNewDB(ctx, dbFile, repoName, base, commitID)
+		if err == nil {
+			dbBuilds.WithLabelValues("incremental").Inc()
+			return nil
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		log15.Warn("Unable to build symbols incrementally, parsing all files.", "repo", repoName, "base", base.commitID, "commit", commitID, "error", err)
+
+		// Start over from a blank database.
+		if err := os.Truncate(dbFile, 0); err != nil {
+			return err
+		}
+	}
+
+	dbBuilds.WithLabelValues("full").Inc()
+	return s.writeAllSymbolsToNewDB(ctx, dbFile, repoName, commitID)
+}
+
+// writeIncrementalSymbolsToNewDB writes the symbols of repo@commitID to the
+// blank database file dbFile by copying the database of base and reparsing
+// only the files that differ between base and commitID. The symbols of files
+// whose content did not change are reused as is.
+func (s *Service) writeIncrementalSymbolsToNewDB(ctx context.Context, dbFile string, repoName api.RepoName, base indexedCommit, commitID api.CommitID) (err error) {
+	span, ctx := ot.StartSpanFromContext(ctx, "writeIncrementalSymbolsToNewDB")
+	defer func() {
+		if err != nil {
+			ext.Error.Set(span, true)
+			span.LogFields(otlog.Error(err))
+		}
+		span.Finish()
+	}()
+	span.SetTag("repo", string(repoName))
+	span.SetTag("base", string(base.commitID))
+	span.SetTag("commit", string(commitID))
+
+	changes, err := s.ChangedFiles(ctx, gitserver.Repo{Name: repoName}, base.commitID, commitID)
+	if err != nil {
+		return errors.Wrap(err, "ChangedFiles")
+	}
+	span.SetTag("changes", changes.Len())
+	if changes.Len() > maxIncrementalChanges {
+		return fmt.Errorf("too many changed files (%d > %d)", changes.Len(), maxIncrementalChanges)
+	}
+
+	if err := copyFile(dbFile, base.path); err != nil {
+		return errors.Wrap(err, "copying base database")
+	}
+
+	db, err := sqlx.Open("sqlite3_with_pcre", dbFile)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	for _, paths := range [][]string{changes.Modified, changes.Deleted} {
+		for _, path := range paths {
+			if _, err := tx.Exec(`DELETE FROM symbols WHERE path = ?`, path); err != nil {
+				return err
+			}
+		}
+	}
+
+	parsePaths := append(append([]string(nil), changes.Added...), changes.Modified...)
+	if len(parsePaths) > 0 {
+		insertStatement, err := prepareInsertSymbol(tx)
+		if err != nil {
+			return err
+		}
+
+		err = s.parseUncached(ctx, repoName, commitID, parsePaths, func(symbol protocol.Symbol) error {
+			symbolInDBValue := symbolToSymbolInDB(symbol)
+			_, err := insertStatement.Exec(&symbolInDBValue)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// copyFile overwrites the file dst with the contents of src.
+func copyFile(dst, src string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+var dbBuilds = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "symbols",
+	Subsystem: "store",
+	Name:      "db_builds",
+	Help:      "The total number of symbols databases built, by whether they were built incrementally or from scratch.",
+}, []string{"type"})
+
+func init() {
+	prometheus.MustRegister(dbBuilds)
+}
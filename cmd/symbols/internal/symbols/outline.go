@@ -0,0 +1,172 @@
+package symbols
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/jmoiron/sqlx"
+	"github.com/opentracing/opentracing-go/ext"
+	otlog "github.com/opentracing/opentracing-go/log"
+	"github.com/sourcegraph/sourcegraph/internal/symbols/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
+	nettrace "golang.org/x/net/trace"
+)
+
+func (s *Service) handleOutline(w http.ResponseWriter, r *http.Request) {
+	var args protocol.OutlineArgs
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if args.Path == "" {
+		http.Error(w, "path is required", http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.outline(r.Context(), args)
+	if err != nil {
+		if err == context.Canceled && r.Context().Err() == context.Canceled {
+			return // client went away
+		}
+		log15.Error("Symbol outline failed", "args", args, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Service) outline(ctx context.Context, args protocol.OutlineArgs) (result *protocol.OutlineResult, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	span, ctx := ot.StartSpanFromContext(ctx, "outline")
+	span.SetTag("repo", args.Repo)
+	span.SetTag("commitID", args.CommitID)
+	span.SetTag("path", args.Path)
+	defer func() {
+		if err != nil {
+			ext.Error.Set(span, true)
+			span.LogFields(otlog.Error(err))
+		}
+		span.Finish()
+	}()
+
+	tr := nettrace.New("symbols.outline", fmt.Sprintf("args:%+v", args))
+	defer func() {
+		if err != nil {
+			tr.LazyPrintf("error: %v", err)
+			tr.SetError()
+		}
+		tr.Finish()
+	}()
+
+	dbFile, err := s.getDBFile(ctx, protocol.SearchArgs{Repo: args.Repo, CommitID: args.CommitID})
+	if err != nil {
+		return nil, err
+	}
+	db, err := sqlx.Open("sqlite3_with_pcre", dbFile)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var symbolsInDB []symbolInDB
+	if err := db.Select(&symbolsInDB, `SELECT * FROM symbols WHERE path = ? ORDER BY line`, args.Path); err != nil {
+		return nil, err
+	}
+	symbols := make([]protocol.Symbol, len(symbolsInDB))
+	for i, symbolInDB := range symbolsInDB {
+		symbols[i] = symbolInDBToSymbol(symbolInDB)
+	}
+	return &protocol.OutlineResult{Symbols: buildOutline(symbols)}, nil
+}
+
+// buildOutline arranges the symbols of a single file into a tree, in which
+// each symbol is a child of the symbol that contains it.
+//
+// ctags only records the name (and usually the kind) of a symbol's parent, so
+// the container of a symbol is taken to be the nearest preceding symbol with
+// that name and kind. If there is none (e.g. a Go method declared before its
+// receiver type), a top-level symbol with that name and kind that follows it
+// is used instead. Symbols whose container can't be found are top-level.
+func buildOutline(symbols []protocol.Symbol) []protocol.OutlineSymbol {
+	symbols = append([]protocol.Symbol(nil), symbols...)
+	sort.SliceStable(symbols, func(i, j int) bool { return symbols[i].Line < symbols[j].Line })
+
+	type node struct {
+		symbol   protocol.Symbol
+		children []*node
+	}
+	nodes := make([]*node, len(symbols))
+	for i, symbol := range symbols {
+		nodes[i] = &node{symbol: symbol}
+	}
+
+	isContainer := func(parent, child protocol.Symbol) bool {
+		return parent.Name == parentName(child.Parent) && (child.ParentKind == "" || strings.EqualFold(parent.Kind, child.ParentKind))
+	}
+	container := func(i int) *node {
+		symbol := symbols[i]
+		if symbol.Parent == "" {
+			return nil
+		}
+		for j := i - 1; j >= 0; j-- {
+			if isContainer(symbols[j], symbol) {
+				return nodes[j]
+			}
+		}
+		// Only consider top-level symbols that follow, so that there are no
+		// cycles.
+		for j := i + 1; j < len(symbols); j++ {
+			if symbols[j].Parent == "" && isContainer(symbols[j], symbol) {
+				return nodes[j]
+			}
+		}
+		return nil
+	}
+
+	var roots []*node
+	for i, n := range nodes {
+		if c := container(i); c != nil {
+			c.children = append(c.children, n)
+		} else {
+			roots = append(roots, n)
+		}
+	}
+
+	var toOutline func(nodes []*node) []protocol.OutlineSymbol
+	toOutline = func(nodes []*node) []protocol.OutlineSymbol {
+		if len(nodes) == 0 {
+			return nil
+		}
+		outline := make([]protocol.OutlineSymbol, len(nodes))
+		for i, n := range nodes {
+			outline[i] = protocol.OutlineSymbol{Symbol: n.symbol, Children: toOutline(n.children)}
+		}
+		return outline
+	}
+	return toOutline(roots)
+}
+
+// parentName returns the unqualified name of a symbol's parent. ctags
+// qualifies the parents of nested symbols in some languages, such as
+// "Outer.Inner" in Java and Python or "ns::Class" in C++.
+func parentName(parent string) string {
+	if i := strings.LastIndex(parent, "::"); i >= 0 {
+		parent = parent[i+len("::"):]
+	}
+	if i := strings.LastIndex(parent, "."); i >= 0 {
+		parent = parent[i+len("."):]
+	}
+	return parent
+}
@@ -0,0 +1,79 @@
+package symbols
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/symbols/protocol"
+)
+
+func TestBuildOutline(t *testing.T) {
+	tests := map[string]struct {
+		symbols []protocol.Symbol
+		want    []string // symbol names, indented by depth
+	}{
+		"flat": {
+			symbols: []protocol.Symbol{{Name: "b", Line: 2}, {Name: "a", Line: 1}},
+			want:    []string{"a", "b"},
+		},
+		"nested": {
+			symbols: []protocol.Symbol{
+				{Name: "Outer", Line: 1, Kind: "class"},
+				{Name: "Inner", Line: 2, Kind: "class", Parent: "Outer", ParentKind: "class"},
+				{Name: "run", Line: 3, Kind: "method", Parent: "Outer.Inner", ParentKind: "class"},
+				{Name: "stop", Line: 5, Kind: "method", Parent: "Outer", ParentKind: "class"},
+			},
+			want: []string{"Outer", "  Inner", "    run", "  stop"},
+		},
+		"nearest preceding container": {
+			symbols: []protocol.Symbol{
+				{Name: "T", Line: 1, Kind: "class"},
+				{Name: "T", Line: 5, Kind: "class"},
+				{Name: "f", Line: 6, Kind: "method", Parent: "T"},
+			},
+			want: []string{"T", "T", "  f"},
+		},
+		"container declared later": {
+			symbols: []protocol.Symbol{
+				{Name: "Serve", Line: 1, Kind: "func", Parent: "Server", ParentKind: "struct"},
+				{Name: "Server", Line: 5, Kind: "struct"},
+			},
+			want: []string{"Server", "  Serve"},
+		},
+		"parent kind mismatch": {
+			symbols: []protocol.Symbol{
+				{Name: "ns", Line: 1, Kind: "namespace"},
+				{Name: "f", Line: 2, Kind: "function", Parent: "ns", ParentKind: "class"},
+			},
+			want: []string{"ns", "f"},
+		},
+		"unknown container": {
+			symbols: []protocol.Symbol{{Name: "f", Line: 1, Parent: "missing"}},
+			want:    []string{"f"},
+		},
+		"qualified parent": {
+			symbols: []protocol.Symbol{
+				{Name: "Widget", Line: 1, Kind: "class"},
+				{Name: "draw", Line: 2, Kind: "function", Parent: "ui::Widget", ParentKind: "class"},
+			},
+			want: []string{"Widget", "  draw"},
+		},
+	}
+	for label, test := range tests {
+		t.Run(label, func(t *testing.T) {
+			var got []string
+			var walk func(symbols []protocol.OutlineSymbol, depth int)
+			walk = func(symbols []protocol.OutlineSymbol, depth int) {
+				for _, symbol := range symbols {
+					got = append(got, strings.Repeat("  ", depth)+symbol.Name)
+					walk(symbol.Children, depth+1)
+				}
+			}
+			walk(buildOutline(test.symbols), 0)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
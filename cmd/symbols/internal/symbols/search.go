@@ -114,8 +114,8 @@ func (s *Service) search(ctx context.Context, args protocol.SearchArgs) (result
 // specified in `args`. If the database doesn't already exist in the disk cache,
 // it will create a new one and write all the symbols into it.
 func (s *Service) getDBFile(ctx context.Context, args protocol.SearchArgs) (string, error) {
-	diskcacheFile, err := s.cache.OpenWithPath(ctx, fmt.Sprintf("%d-%s@%s", symbolsDBVersion, args.Repo, args.CommitID), func(fetcherCtx context.Context, tempDBFile string) error {
-		err := s.writeAllSymbolsToNewDB(fetcherCtx, tempDBFile, args.Repo, args.CommitID)
+	diskcacheFile, err := s.cache.OpenWithPath(ctx, dbKey(args.Repo, args.CommitID), func(fetcherCtx context.Context, tempDBFile string) error {
+		err := s.writeSymbolsToNewDB(fetcherCtx, tempDBFile, args.Repo, args.CommitID)
 		if err != nil {
 			if err == context.Canceled {
 				log15.Error("Unable to parse repository symbols within the context", "repo", args.Repo, "commit", args.CommitID, "query", args.Query)
@@ -129,9 +129,16 @@ func (s *Service) getDBFile(ctx context.Context, args protocol.SearchArgs) (stri
 	}
 	defer diskcacheFile.File.Close()
 
+	s.setIndexed(args.Repo, indexedCommit{commitID: args.CommitID, path: diskcacheFile.Path})
+
 	return diskcacheFile.File.Name(), err
 }
 
+// dbKey returns the disk cache key of the symbols database of repo@commitID.
+func dbKey(repo api.RepoName, commitID api.CommitID) string {
+	return fmt.Sprintf("%d-%s@%s", symbolsDBVersion, repo, commitID)
+}
+
 // isLiteralEquality checks if the given regex matches literal strings exactly.
 // Returns whether or not the regex is exact, along with the literal string if
 // so.
@@ -206,6 +213,20 @@ func filterSymbols(ctx context.Context, db *sqlx.DB, args protocol.SearchArgs) (
 		conditions = append(conditions, makeCondition("path", includePattern)...)
 	}
 	conditions = append(conditions, negateAll(makeCondition("path", args.ExcludePattern))...)
+	if len(args.Kinds) > 0 {
+		kinds := make([]*sqlf.Query, len(args.Kinds))
+		for i, kind := range args.Kinds {
+			kinds[i] = sqlf.Sprintf("%s", strings.ToLower(kind))
+		}
+		conditions = append(conditions, sqlf.Sprintf("LOWER(kind) IN (%s)", sqlf.Join(kinds, ",")))
+	}
+	if args.Parent != "" {
+		conditions = append(conditions, sqlf.Sprintf("parentname = %s", args.Parent))
+	}
+	if args.ParentKind != "" {
+		// ctags doesn't record the kind of the parent in all languages.
+		conditions = append(conditions, sqlf.Sprintf("(parentkind = '' OR LOWER(parentkind) = %s)", strings.ToLower(args.ParentKind)))
+	}
 
 	var sqlQuery *sqlf.Query
 	if len(conditions) == 0 {
@@ -232,11 +253,12 @@ func filterSymbols(ctx context.Context, db *sqlx.DB, args protocol.SearchArgs) (
 // filenames to prevent a newer version of the symbols service from attempting
 // to read from a database created by an older (and likely incompatible) symbols
 // service. Increment this when you change the database schema.
-const symbolsDBVersion = 3
+const symbolsDBVersion = 5
 
-// symbolInDB is the same as `protocol.Symbol`, but with two additional columns:
-// namelowercase and pathlowercase, which enable indexed case insensitive
-// queries.
+// symbolInDB is the same as `protocol.Symbol`, but with three additional
+// columns: namelowercase and pathlowercase, which enable indexed case
+// insensitive queries, and parentname, which enables indexed queries for the
+// members of a symbol.
 type symbolInDB struct {
 	Name          string
 	NameLowercase string // derived from `Name`
@@ -246,6 +268,7 @@ type symbolInDB struct {
 	Kind          string
 	Language      string
 	Parent        string
+	ParentName    string // derived from `Parent`
 	ParentKind    string
 	Signature     string
 	Pattern       string
@@ -263,6 +286,7 @@ func symbolToSymbolInDB(symbol protocol.Symbol) symbolInDB {
 		Kind:          symbol.Kind,
 		Language:      symbol.Language,
 		Parent:        symbol.Parent,
+		ParentName:    parentName(symbol.Parent),
 		ParentKind:    symbol.ParentKind,
 		Signature:     symbol.Signature,
 		Pattern:       symbol.Pattern,
@@ -302,10 +326,38 @@ func (s *Service) writeAllSymbolsToNewDB(ctx context.Context, dbFile string, rep
 		return err
 	}
 
+	if err := createSymbolsTable(tx); err != nil {
+		return err
+	}
+
+	insertStatement, err := prepareInsertSymbol(tx)
+	if err != nil {
+		return err
+	}
+
+	err = s.parseUncached(ctx, repoName, commitID, nil, func(symbol protocol.Symbol) error {
+		symbolInDBValue := symbolToSymbolInDB(symbol)
+		_, err := insertStatement.Exec(&symbolInDBValue)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// createSymbolsTable creates the symbols table and its indexes.
+func createSymbolsTable(tx *sqlx.Tx) error {
 	// The column names are the lowercase version of fields in `symbolInDB`
 	// because sqlx lowercases struct fields by default. See
 	// http://jmoiron.github.io/sqlx/#query
-	_, err = tx.Exec(
+	_, err := tx.Exec(
 		`CREATE TABLE IF NOT EXISTS symbols (
 			name VARCHAR(256) NOT NULL,
 			namelowercase VARCHAR(256) NOT NULL,
@@ -315,6 +367,7 @@ func (s *Service) writeAllSymbolsToNewDB(ctx context.Context, dbFile string, rep
 			kind VARCHAR(255) NOT NULL,
 			language VARCHAR(255) NOT NULL,
 			parent VARCHAR(255) NOT NULL,
+			parentname VARCHAR(255) NOT NULL,
 			parentkind VARCHAR(255) NOT NULL,
 			signature VARCHAR(255) NOT NULL,
 			pattern VARCHAR(255) NOT NULL,
@@ -334,39 +387,32 @@ func (s *Service) writeAllSymbolsToNewDB(ctx context.Context, dbFile string, rep
 		return err
 	}
 
-	// `*lowercase_index` enables indexed case insensitive queries.
-	_, err = tx.Exec(`CREATE INDEX namelowercase_index ON symbols(namelowercase);`)
-	if err != nil {
-		return err
-	}
-
-	_, err = tx.Exec(`CREATE INDEX pathlowercase_index ON symbols(pathlowercase);`)
+	// `parentname_index` enables indexed queries for the members of a symbol.
+	_, err = tx.Exec(`CREATE INDEX parentname_index ON symbols(parentname);`)
 	if err != nil {
 		return err
 	}
 
-	insertStatement, err := tx.PrepareNamed(
-		fmt.Sprintf(
-			"INSERT INTO symbols %s VALUES %s",
-			"( name,  namelowercase,  path,  pathlowercase,  line,  kind,  language,  parent,  parentkind,  signature,  pattern,  filelimited)",
-			"(:name, :namelowercase, :path, :pathlowercase, :line, :kind, :language, :parent, :parentkind, :signature, :pattern, :filelimited)"))
-	if err != nil {
-		return err
-	}
-
-	err = s.parseUncached(ctx, repoName, commitID, func(symbol protocol.Symbol) error {
-		symbolInDBValue := symbolToSymbolInDB(symbol)
-		_, err := insertStatement.Exec(&symbolInDBValue)
-		return err
-	})
+	// `*lowercase_index` enables indexed case insensitive queries.
+	_, err = tx.Exec(`CREATE INDEX namelowercase_index ON symbols(namelowercase);`)
 	if err != nil {
 		return err
 	}
 
-	err = tx.Commit()
+	_, err = tx.Exec(`CREATE INDEX pathlowercase_index ON symbols(pathlowercase);`)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// prepareInsertSymbol prepares a statement that inserts a symbolInDB into the
+// symbols table.
+func prepareInsertSymbol(tx *sqlx.Tx) (*sqlx.NamedStmt, error) {
+	return tx.PrepareNamed(
+		fmt.Sprintf(
+			"INSERT INTO symbols %s VALUES %s",
+			"( name,  namelowercase,  path,  pathlowercase,  line,  kind,  language,  parent,  parentname,  parentkind,  signature,  pattern,  filelimited)",
+			"(:name, :namelowercase, :path, :pathlowercase, :line, :kind, :language, :parent, :parentname, :parentkind, :signature, :pattern, :filelimited)"))
+}
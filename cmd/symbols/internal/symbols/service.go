@@ -6,6 +6,7 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -13,6 +14,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/diskcache"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
 )
 
 // Service is the symbols service.
@@ -22,6 +24,22 @@ type Service struct {
 	// determine if the error is a bad request (eg invalid repo).
 	FetchTar func(context.Context, gitserver.Repo, api.CommitID) (io.ReadCloser, error)
 
+	// FetchTarPaths is like FetchTar, but the archive only contains the given
+	// paths. Together with ChangedFiles it is used to build the symbols of a
+	// commit incrementally from those of a previously indexed commit. If
+	// either is nil, every commit is parsed from scratch.
+	FetchTarPaths func(context.Context, gitserver.Repo, api.CommitID, []string) (io.ReadCloser, error)
+
+	// ChangedFiles returns the files that differ between two commits. See
+	// FetchTarPaths.
+	ChangedFiles func(ctx context.Context, repo gitserver.Repo, base, head api.CommitID) (*git.Changes, error)
+
+	// Ancestors returns the IDs of up to n ancestors of a commit, nearest
+	// first. When set, the symbols databases of ancestors that are in the disk
+	// cache are used to build the symbols of a commit incrementally, so that
+	// the base survives restarts. See FetchTarPaths.
+	Ancestors func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, n int) ([]api.CommitID, error)
+
 	// MaxConcurrentFetchTar is the maximum number of concurrent calls allowed
 	// to FetchTar. It defaults to 15.
 	MaxConcurrentFetchTar int
@@ -49,6 +67,15 @@ type Service struct {
 
 	// pool of ctags parser child processes
 	parsers chan ctags.Parser
+
+	// indexedMu protects indexed.
+	indexedMu sync.Mutex
+
+	// indexed is the most recently used symbols database of each repository.
+	// It is the base for incrementally building the database of the next
+	// commit that is requested when no ancestor of that commit is in the disk
+	// cache.
+	indexed map[api.RepoName]indexedCommit
 }
 
 // Start must be called before any requests are handled.
@@ -81,6 +108,7 @@ func (s *Service) Handler() http.Handler {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/search", s.handleSearch)
+	mux.HandleFunc("/outline", s.handleOutline)
 	mux.HandleFunc("/healthz", s.handleHealthCheck)
 
 	return mux
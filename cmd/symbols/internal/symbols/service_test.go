@@ -13,6 +13,7 @@ import (
 	"path"
 	"reflect"
 	"runtime"
+	"sort"
 	"strings"
 	"testing"
 
@@ -22,6 +23,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/search"
 	symbolsclient "github.com/sourcegraph/sourcegraph/internal/symbols"
 	"github.com/sourcegraph/sourcegraph/internal/symbols/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
 )
 
 func init() {
@@ -160,6 +162,242 @@ func TestService(t *testing.T) {
 	}
 }
 
+func TestService_incremental(t *testing.T) {
+	MustRegisterSqlite3WithPcre()
+
+	tmpDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { os.RemoveAll(tmpDir) }()
+
+	commits := map[api.CommitID]map[string]string{
+		"c1": {"a.js": "a", "b.js": "b", "c.js": "c"},
+		"c2": {"a.js": "a2", "c.js": "c", "d.js": "d"},
+	}
+	var fetchedAll []api.CommitID
+	var fetchedPaths []string
+	service := Service{
+		FetchTar: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (io.ReadCloser, error) {
+			fetchedAll = append(fetchedAll, commit)
+			return createTar(commits[commit])
+		},
+		FetchTarPaths: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, paths []string) (io.ReadCloser, error) {
+			fetchedPaths = append(fetchedPaths, paths...)
+			files := map[string]string{}
+			for _, p := range paths {
+				files[p] = commits[commit][p]
+			}
+			return createTar(files)
+		},
+		ChangedFiles: func(ctx context.Context, repo gitserver.Repo, base, head api.CommitID) (*git.Changes, error) {
+			if base != "c1" || head != "c2" {
+				t.Errorf("unexpected ChangedFiles(%s, %s)", base, head)
+			}
+			return &git.Changes{Added: []string{"d.js"}, Modified: []string{"a.js"}, Deleted: []string{"b.js"}}, nil
+		},
+		NewParser: func() (ctags.Parser, error) {
+			return contentParser{}, nil
+		},
+		Path: tmpDir,
+	}
+	if err := service.Start(); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(service.Handler())
+	defer server.Close()
+	client := symbolsclient.Client{URL: server.URL}
+
+	symbolNames := func(commit api.CommitID) []string {
+		result, err := client.Search(context.Background(), search.SymbolsParameters{Repo: "r", CommitID: commit, First: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, symbol := range result.Symbols {
+			names = append(names, symbol.Path+":"+symbol.Name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	if got, want := symbolNames("c1"), []string{"a.js:a", "b.js:b", "c.js:c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("c1: got %v, want %v", got, want)
+	}
+	if got, want := symbolNames("c2"), []string{"a.js:a2", "c.js:c", "d.js:d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("c2: got %v, want %v", got, want)
+	}
+	if want := []api.CommitID{"c1"}; !reflect.DeepEqual(fetchedAll, want) {
+		t.Errorf("got full fetches %v, want %v", fetchedAll, want)
+	}
+	if want := []string{"d.js", "a.js"}; !reflect.DeepEqual(fetchedPaths, want) {
+		t.Errorf("got fetched paths %v, want %v", fetchedPaths, want)
+	}
+}
+
+func TestService_incrementalFromCachedAncestor(t *testing.T) {
+	MustRegisterSqlite3WithPcre()
+
+	tmpDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { os.RemoveAll(tmpDir) }()
+
+	commits := map[api.CommitID]map[string]string{
+		"c1": {"a.js": "a", "b.js": "b"},
+		"c2": {"a.js": "a", "b.js": "b", "c.js": "c"},
+		"c3": {"a.js": "a", "b.js": "b2", "c.js": "c"},
+	}
+	var fetchedAll []api.CommitID
+	var changedFiles [][2]api.CommitID
+	newService := func() *Service {
+		return &Service{
+			FetchTar: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (io.ReadCloser, error) {
+				fetchedAll = append(fetchedAll, commit)
+				return createTar(commits[commit])
+			},
+			FetchTarPaths: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, paths []string) (io.ReadCloser, error) {
+				files := map[string]string{}
+				for _, p := range paths {
+					files[p] = commits[commit][p]
+				}
+				return createTar(files)
+			},
+			ChangedFiles: func(ctx context.Context, repo gitserver.Repo, base, head api.CommitID) (*git.Changes, error) {
+				changedFiles = append(changedFiles, [2]api.CommitID{base, head})
+				if base == "c1" && head == "c3" {
+					return &git.Changes{Added: []string{"c.js"}, Modified: []string{"b.js"}}, nil
+				}
+				t.Fatalf("unexpected ChangedFiles(%s, %s)", base, head)
+				return nil, nil
+			},
+			Ancestors: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, n int) ([]api.CommitID, error) {
+				if commit != "c3" {
+					return nil, nil
+				}
+				return []api.CommitID{"c3", "c2", "c1"}, nil
+			},
+			NewParser: func() (ctags.Parser, error) {
+				return contentParser{}, nil
+			},
+			Path: tmpDir,
+		}
+	}
+	symbolNames := func(service *Service, commit api.CommitID) []string {
+		if err := service.Start(); err != nil {
+			t.Fatal(err)
+		}
+		server := httptest.NewServer(service.Handler())
+		defer server.Close()
+		client := symbolsclient.Client{URL: server.URL}
+		result, err := client.Search(context.Background(), search.SymbolsParameters{Repo: "r", CommitID: commit, First: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, symbol := range result.Symbols {
+			names = append(names, symbol.Path+":"+symbol.Name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	// c1 is indexed, then the service restarts and c3 is requested. c2 isn't
+	// in the cache, so c3 is built from its nearest cached ancestor c1.
+	if got, want := symbolNames(newService(), "c1"), []string{"a.js:a", "b.js:b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("c1: got %v, want %v", got, want)
+	}
+	if got, want := symbolNames(newService(), "c3"), []string{"a.js:a", "b.js:b2", "c.js:c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("c3: got %v, want %v", got, want)
+	}
+	if want := []api.CommitID{"c1"}; !reflect.DeepEqual(fetchedAll, want) {
+		t.Errorf("got full fetches %v, want %v", fetchedAll, want)
+	}
+	if want := [][2]api.CommitID{{"c1", "c3"}}; !reflect.DeepEqual(changedFiles, want) {
+		t.Errorf("got ChangedFiles calls %v, want %v", changedFiles, want)
+	}
+}
+
+func TestService_structural(t *testing.T) {
+	MustRegisterSqlite3WithPcre()
+
+	tmpDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { os.RemoveAll(tmpDir) }()
+
+	entries := entriesParser{
+		{Name: "Server", Path: "a.go", Line: 3, Kind: "struct"},
+		{Name: "addr", Path: "a.go", Line: 4, Kind: "member", Parent: "Server", ParentKind: "struct"},
+		{Name: "Serve", Path: "a.go", Line: 7, Kind: "func", Parent: "Server", ParentKind: "struct"},
+		{Name: "maxConns", Path: "a.go", Line: 11, Kind: "constant"},
+		{Name: "Close", Path: "b.go", Line: 3, Kind: "func", Parent: "pkg.Server", ParentKind: "struct"},
+		{Name: "Stop", Path: "b.go", Line: 5, Kind: "func", Parent: "ns::Server"},
+		{Name: "helper", Path: "b.go", Line: 7, Kind: "func", Parent: "Server", ParentKind: "namespace"},
+	}
+	service := Service{
+		FetchTar: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (io.ReadCloser, error) {
+			return createTar(map[string]string{"a.go": "package a", "b.go": "package a"})
+		},
+		NewParser: func() (ctags.Parser, error) {
+			return entries, nil
+		},
+		Path: tmpDir,
+	}
+	if err := service.Start(); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(service.Handler())
+	defer server.Close()
+	client := symbolsclient.Client{URL: server.URL}
+
+	symbolNames := func(args search.SymbolsParameters) []string {
+		args.First = 10
+		result, err := client.Search(context.Background(), args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, symbol := range result.Symbols {
+			names = append(names, symbol.Name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	if got, want := symbolNames(search.SymbolsParameters{Kinds: []string{"FUNC", "constant"}}), []string{"Serve", "maxConns"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("kinds: got %v, want %v", got, want)
+	}
+	if got, want := symbolNames(search.SymbolsParameters{Parent: "Server"}), []string{"Close", "Serve", "Stop", "addr", "helper"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("parent: got %v, want %v", got, want)
+	}
+	if got, want := symbolNames(search.SymbolsParameters{Parent: "Server", ParentKind: "STRUCT"}), []string{"Close", "Serve", "Stop", "addr"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("parent and parent kind: got %v, want %v", got, want)
+	}
+	if got, want := symbolNames(search.SymbolsParameters{Parent: "Server", Kinds: []string{"func"}}), []string{"Serve"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("parent and kinds: got %v, want %v", got, want)
+	}
+
+	outline, err := client.Outline(context.Background(), protocol.OutlineArgs{Path: "a.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	var walk func(symbols []protocol.OutlineSymbol, depth int)
+	walk = func(symbols []protocol.OutlineSymbol, depth int) {
+		for _, symbol := range symbols {
+			got = append(got, strings.Repeat("  ", depth)+symbol.Name)
+			walk(symbol.Children, depth+1)
+		}
+	}
+	walk(outline.Symbols, 0)
+	if want := []string{"Server", "  addr", "  Serve", "maxConns"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("outline: got %q, want %q", got, want)
+	}
+}
+
 func createTar(files map[string]string) (io.ReadCloser, error) {
 	buf := new(bytes.Buffer)
 	w := tar.NewWriter(buf)
@@ -195,3 +433,22 @@ func (m mockParser) Parse(name string, content []byte) ([]ctags.Entry, error) {
 }
 
 func (mockParser) Close() {}
+
+// contentParser returns a single symbol per file, named after the file's
+// contents.
+type contentParser struct{}
+
+func (contentParser) Parse(name string, content []byte) ([]ctags.Entry, error) {
+	return []ctags.Entry{{Name: string(content), Path: name}}, nil
+}
+
+func (contentParser) Close() {}
+
+// entriesParser returns the same entries for every file.
+type entriesParser []ctags.Entry
+
+func (p entriesParser) Parse(name string, content []byte) ([]ctags.Entry, error) {
+	return p, nil
+}
+
+func (entriesParser) Close() {}
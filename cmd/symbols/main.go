@@ -26,6 +26,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/gitserver"
 	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
 	"github.com/sourcegraph/sourcegraph/internal/tracer"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
 )
 
 const port = "3184"
@@ -50,6 +51,21 @@ func main() {
 		FetchTar: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (io.ReadCloser, error) {
 			return gitserver.DefaultClient.Archive(ctx, repo, gitserver.ArchiveOptions{Treeish: string(commit), Format: "tar"})
 		},
+		FetchTarPaths: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, paths []string) (io.ReadCloser, error) {
+			return gitserver.DefaultClient.Archive(ctx, repo, gitserver.ArchiveOptions{Treeish: string(commit), Format: "tar", Paths: paths})
+		},
+		ChangedFiles: git.ChangedFiles,
+		Ancestors: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, n int) ([]api.CommitID, error) {
+			commits, err := git.Commits(ctx, repo, git.CommitsOptions{Range: string(commit), N: uint(n)})
+			if err != nil {
+				return nil, err
+			}
+			ids := make([]api.CommitID, len(commits))
+			for i, c := range commits {
+				ids[i] = c.ID
+			}
+			return ids, nil
+		},
 		NewParser: func() (ctags.Parser, error) {
 			parser, err := ctags.NewParser(ctags.GetCommand())
 			if err != nil {
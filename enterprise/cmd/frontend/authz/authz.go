@@ -16,6 +16,8 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/hooks"
 	edb "github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/db"
 	"github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/internal/authz/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/internal/authz/gerrit"
+	"github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/internal/authz/gitea"
 	"github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/internal/authz/github"
 	"github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/internal/authz/gitlab"
 	"github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/internal/licensing"
@@ -136,6 +138,8 @@ type ExternalServicesStore interface {
 	ListGitLabConnections(context.Context) ([]*schema.GitLabConnection, error)
 	ListGitHubConnections(context.Context) ([]*schema.GitHubConnection, error)
 	ListBitbucketServerConnections(context.Context) ([]*schema.BitbucketServerConnection, error)
+	ListGerritConnections(context.Context) ([]*schema.GerritConnection, error)
+	ListGiteaConnections(context.Context) ([]*schema.GiteaConnection, error)
 }
 
 // ProvidersFromConfig returns the set of permission-related providers derived from the site config.
@@ -188,6 +192,24 @@ func ProvidersFromConfig(
 		warnings = append(warnings, bbsWarnings...)
 	}
 
+	if gerritConns, err := s.ListGerritConnections(ctx); err != nil {
+		seriousProblems = append(seriousProblems, fmt.Sprintf("Could not load Gerrit external service configs: %s", err))
+	} else {
+		gerritProviders, gerritProblems, gerritWarnings := gerrit.NewAuthzProviders(gerritConns)
+		providers = append(providers, gerritProviders...)
+		seriousProblems = append(seriousProblems, gerritProblems...)
+		warnings = append(warnings, gerritWarnings...)
+	}
+
+	if giteaConns, err := s.ListGiteaConnections(ctx); err != nil {
+		seriousProblems = append(seriousProblems, fmt.Sprintf("Could not load Gitea external service configs: %s", err))
+	} else {
+		giteaProviders, giteaProblems, giteaWarnings := gitea.NewAuthzProviders(giteaConns)
+		providers = append(providers, giteaProviders...)
+		seriousProblems = append(seriousProblems, giteaProblems...)
+		warnings = append(warnings, giteaWarnings...)
+	}
+
 	// 🚨 SECURITY: Warn the admin when both code host authz provider and the permissions user mapping are configured.
 	if cfg.SiteConfiguration.PermissionsUserMapping != nil &&
 		cfg.SiteConfiguration.PermissionsUserMapping.Enabled && len(providers) > 0 {
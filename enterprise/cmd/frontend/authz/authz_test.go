@@ -563,6 +563,8 @@ type fakeStore struct {
 	gitlabs          []*schema.GitLabConnection
 	githubs          []*schema.GitHubConnection
 	bitbucketServers []*schema.BitbucketServerConnection
+	gerrits          []*schema.GerritConnection
+	giteas           []*schema.GiteaConnection
 }
 
 func (s fakeStore) ListGitHubConnections(context.Context) ([]*schema.GitHubConnection, error) {
@@ -576,3 +578,11 @@ func (s fakeStore) ListGitLabConnections(context.Context) ([]*schema.GitLabConne
 func (s fakeStore) ListBitbucketServerConnections(context.Context) ([]*schema.BitbucketServerConnection, error) {
 	return s.bitbucketServers, nil
 }
+
+func (s fakeStore) ListGerritConnections(context.Context) ([]*schema.GerritConnection, error) {
+	return s.gerrits, nil
+}
+
+func (s fakeStore) ListGiteaConnections(context.Context) ([]*schema.GiteaConnection, error) {
+	return s.giteas, nil
+}
@@ -3,6 +3,8 @@ package db
 import (
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
 	"github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/internal/authz/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/internal/authz/gerrit"
+	"github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/internal/authz/gitea"
 	"github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/internal/authz/github"
 	"github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/internal/authz/gitlab"
 	"github.com/sourcegraph/sourcegraph/schema"
@@ -21,5 +23,11 @@ func NewExternalServicesStore() *db.ExternalServicesStore {
 		BitbucketServerValidators: []func(*schema.BitbucketServerConnection) error{
 			bitbucketserver.ValidateAuthz,
 		},
+		GerritValidators: []func(*schema.GerritConnection) error{
+			gerrit.ValidateAuthz,
+		},
+		GiteaValidators: []func(*schema.GiteaConnection) error{
+			gitea.ValidateAuthz,
+		},
 	}
 }
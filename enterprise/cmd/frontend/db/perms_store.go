@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/RoaringBitmap/roaring"
@@ -11,6 +12,7 @@ import (
 	otlog "github.com/opentracing/opentracing-go/log"
 	"github.com/pkg/errors"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/db/dbutil"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc"
@@ -224,6 +226,9 @@ func (s *PermsStore) SetUserPermissions(ctx context.Context, p *authz.UserPermis
 		return errors.Wrap(err, "execute upsert user permissions query")
 	}
 
+	if err = txs.recordAudit(ctx, "permissions.setUser", "user", strconv.Itoa(int(p.UserID)), p.Perm, added, removed); err != nil {
+		return errors.Wrap(err, "record audit log entry")
+	}
 	return nil
 }
 
@@ -385,6 +390,9 @@ func (s *PermsStore) SetRepoPermissions(ctx context.Context, p *authz.RepoPermis
 		return errors.Wrap(err, "execute upsert repo permissions query")
 	}
 
+	if err = txs.recordAudit(ctx, "permissions.setRepo", "repo", strconv.Itoa(int(p.RepoID)), p.Perm, added, removed); err != nil {
+		return errors.Wrap(err, "record audit log entry")
+	}
 	return nil
 }
 
@@ -676,9 +684,31 @@ func (s *PermsStore) SetRepoPendingPermissions(ctx context.Context, accounts *ex
 		return errors.Wrap(err, "execute upsert repo pending permissions batch query")
 	}
 
+	if err = txs.recordAudit(ctx, "permissions.setRepoPending", "repo", strconv.Itoa(int(p.RepoID)), p.Perm, added, removed); err != nil {
+		return errors.Wrap(err, "record audit log entry")
+	}
 	return nil
 }
 
+// recordAudit records a change of permissions in the audit log, in the same
+// transaction as the change. The IDs that were added and removed are the user
+// IDs or repository IDs that gained or lost the permission on the target.
+// Nothing is recorded if the permissions didn't change.
+func (s *PermsStore) recordAudit(ctx context.Context, action, targetType, targetID string, perm authz.Perms, added, removed *roaring.Bitmap) error {
+	if added.IsEmpty() && removed.IsEmpty() {
+		return nil
+	}
+	e, err := db.NewAuditLogEntry(ctx, action, targetType, targetID, nil, map[string]interface{}{
+		"permission": perm.String(),
+		"added":      added.ToArray(),
+		"removed":    removed.ToArray(),
+	})
+	if err != nil {
+		return err
+	}
+	return s.execute(ctx, db.AuditLogInsertQuery(e))
+}
+
 func (s *PermsStore) loadUserPendingPermissionsIDs(ctx context.Context, q *sqlf.Query) (ids []uint32, err error) {
 	ctx, save := s.observe(ctx, "loadUserPendingPermissionsIDs", "")
 	defer func() {
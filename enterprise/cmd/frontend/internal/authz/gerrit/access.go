@@ -0,0 +1,107 @@
+package gerrit
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gerrit"
+)
+
+// readRefPatterns are the ref patterns whose read permission makes a project
+// visible. Read access to any other refs is not enough for Sourcegraph, which
+// needs to read the default branch.
+var readRefPatterns = []string{"refs/*", "refs/heads/*"}
+
+// maxInheritanceDepth bounds the number of parents readGroups follows, to
+// guard against inheritance cycles.
+const maxInheritanceDepth = 32
+
+// readGroups returns the UUIDs of the groups that may read the project with
+// the given name, as defined by the access rights of the project and the
+// projects it inherits from, and the UUIDs of the groups that are blocked from
+// reading it.
+//
+// It implements the subset of Gerrit's permission evaluation that applies to
+// the read permission: rules of a project take precedence over rules
+// inherited from its parents, unless the parent blocks the group. An
+// exclusive read permission stops inheritance for its ref pattern. A block
+// denies every member of the blocked group, even those who are also members
+// of an allowed group, so callers must check both sets.
+func readGroups(ctx context.Context, cli client, project string) (allowed, blocked []string, err error) {
+	var (
+		decided = make(map[string]map[string]bool, len(readRefPatterns)) // ref pattern -> group -> allowed
+		closed  = make(map[string]bool, len(readRefPatterns))            // ref patterns with exclusive permissions
+		blocks  = make(map[string]bool)
+		seen    = make(map[string]bool)
+	)
+	for _, ref := range readRefPatterns {
+		decided[ref] = make(map[string]bool)
+	}
+
+	for name := project; name != ""; {
+		if seen[name] || len(seen) >= maxInheritanceDepth {
+			return nil, nil, errors.Errorf("project %q inherits access rights from too many projects", project)
+		}
+		seen[name] = true
+
+		access, err := cli.GetProjectAccess(ctx, name)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "access rights of project %q", name)
+		}
+
+		for _, ref := range readRefPatterns {
+			section, ok := access.Local[ref]
+			if !ok || section == nil {
+				continue
+			}
+			perm, ok := section.Permissions["read"]
+			if !ok || perm == nil {
+				continue
+			}
+
+			for group, rule := range perm.Rules {
+				if rule == nil {
+					continue
+				}
+				switch rule.Action {
+				case gerrit.PermissionActionBlock:
+					// Blocks can't be overridden by child projects.
+					blocks[group] = true
+				case gerrit.PermissionActionAllow, gerrit.PermissionActionDeny:
+					if closed[ref] {
+						continue
+					}
+					if _, ok := decided[ref][group]; !ok {
+						decided[ref][group] = rule.Action == gerrit.PermissionActionAllow
+					}
+				}
+			}
+
+			if perm.Exclusive {
+				closed[ref] = true
+			}
+		}
+
+		name = access.Parent()
+	}
+
+	set := make(map[string]bool)
+	for _, groups := range decided {
+		for group, ok := range groups {
+			if ok && !blocks[group] {
+				set[group] = true
+			}
+		}
+	}
+	return sortedKeys(set), sortedKeys(blocks), nil
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
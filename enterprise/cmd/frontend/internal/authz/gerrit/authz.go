@@ -0,0 +1,81 @@
+package gerrit
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
+	iauthz "github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/internal/authz"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gerrit"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// NewAuthzProviders returns the set of Gerrit authz providers derived from the connections.
+// It also returns any validation problems with the config, separating these into "serious problems" and
+// "warnings". "Serious problems" are those that should make Sourcegraph set authz.allowAccessByDefault
+// to false. "Warnings" are all other validation problems.
+func NewAuthzProviders(
+	conns []*schema.GerritConnection,
+) (ps []authz.Provider, problems []string, warnings []string) {
+	// Authorization (i.e., permissions) providers
+	for _, c := range conns {
+		p, err := newAuthzProvider(c)
+		if err != nil {
+			problems = append(problems, err.Error())
+		} else if p != nil {
+			ps = append(ps, p)
+		}
+	}
+
+	for _, p := range ps {
+		for _, problem := range p.Validate() {
+			warnings = append(warnings, fmt.Sprintf("Gerrit config for %s was invalid: %s", p.ServiceID(), problem))
+		}
+	}
+
+	return ps, problems, warnings
+}
+
+func newAuthzProvider(c *schema.GerritConnection) (authz.Provider, error) {
+	if c.Authorization == nil {
+		return nil, nil
+	}
+
+	errs := new(multierror.Error)
+
+	ttl, err := iauthz.ParseTTL(c.Authorization.Ttl)
+	if err != nil {
+		errs = multierror.Append(errs, err)
+	}
+
+	baseURL, err := url.Parse(c.Url)
+	if err != nil {
+		errs = multierror.Append(errs, errors.Errorf("Could not parse URL for Gerrit instance %q: %s", c.Url, err))
+		return nil, errs.ErrorOrNil()
+	}
+	baseURL = extsvc.NormalizeBaseURL(baseURL)
+
+	cli := gerrit.NewClient(baseURL, nil)
+	cli.Username = c.Username
+	cli.Password = c.Password
+
+	var p authz.Provider
+	switch idp := c.Authorization.IdentityProvider; {
+	case idp.Username != nil:
+		p = NewProvider(cli, ttl, nil)
+	default:
+		errs = multierror.Append(errs, errors.Errorf("No identityProvider was specified"))
+	}
+
+	return p, errs.ErrorOrNil()
+}
+
+// ValidateAuthz validates the authorization fields of the given Gerrit external
+// service config.
+func ValidateAuthz(c *schema.GerritConnection) error {
+	_, err := newAuthzProvider(c)
+	return err
+}
@@ -0,0 +1,380 @@
+// Package gerrit contains an authorization provider for Gerrit.
+package gerrit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gerrit"
+	"github.com/sourcegraph/sourcegraph/internal/rcache"
+)
+
+// client is the subset of the Gerrit API client used by Provider.
+type client interface {
+	ListProjects(ctx context.Context, args gerrit.ListProjectsArgs) ([]*gerrit.Project, bool, error)
+	GetProjectAccess(ctx context.Context, name string) (*gerrit.ProjectAccess, error)
+	GetAccount(ctx context.Context, id string) (*gerrit.Account, error)
+	ListAccountGroups(ctx context.Context, accountID string) ([]*gerrit.Group, error)
+	ListGroupMembers(ctx context.Context, groupUUID string) ([]*gerrit.Account, error)
+}
+
+// cache describes the shape of the cache that Provider uses internally.
+type cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, b []byte)
+}
+
+// cacheVal is a cached list of group UUIDs, either the groups an account is a
+// member of or the groups that may read a project. For a project, Blocked are
+// the groups that are blocked from reading it.
+type cacheVal struct {
+	Groups  []string
+	Blocked []string
+	TTL     time.Duration
+}
+
+// Provider implements authz.Provider for Gerrit project permissions. A user
+// may read a project if they are a member of a group that is granted the read
+// permission on all refs or branches of the project or of one of the projects
+// it inherits access rights from, and not a member of any group that is
+// blocked from reading it.
+type Provider struct {
+	client   client
+	codeHost *extsvc.CodeHost
+	cacheTTL time.Duration
+	cache    cache
+	pageSize int
+}
+
+var _ authz.Provider = (*Provider)(nil)
+
+// NewProvider returns a new Gerrit authorization provider that uses the given
+// gerrit.Client to read group memberships and project access rights. The
+// client's account must be able to see the access rights of all projects and
+// the members of all groups. It assumes usernames of Sourcegraph accounts
+// match 1-1 with usernames of Gerrit accounts.
+func NewProvider(cli *gerrit.Client, cacheTTL time.Duration, mockCache cache) *Provider {
+	p := &Provider{
+		client:   cli,
+		codeHost: extsvc.NewCodeHost(cli.URL, gerrit.ServiceType),
+		cacheTTL: cacheTTL,
+		cache:    mockCache,
+		pageSize: 500,
+	}
+	// Note: this will use the same underlying Redis instance and key namespace for every instance
+	// of Provider.  This is by design, so that different instances, even in different processes,
+	// will share cache entries.
+	if p.cache == nil {
+		p.cache = rcache.NewWithTTL(fmt.Sprintf("gerritAuthz:%s", p.codeHost.ServiceID), int(math.Ceil(cacheTTL.Seconds())))
+	}
+	return p
+}
+
+// Validate validates that the Provider has access to the Gerrit API with the
+// credentials it was configured with.
+func (p *Provider) Validate() []string {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := p.client.GetAccount(ctx, "self"); err != nil {
+		return []string{err.Error()}
+	}
+	return nil
+}
+
+// ServiceID returns the absolute URL that identifies the Gerrit instance this
+// provider is configured with.
+func (p *Provider) ServiceID() string { return p.codeHost.ServiceID }
+
+// ServiceType returns the type of this Provider, namely, "gerrit".
+func (p *Provider) ServiceType() string { return p.codeHost.ServiceType }
+
+// RepoPerms returns the permissions the given external account has in relation
+// to the given set of repos. Without an account, only projects readable by
+// anonymous users are authorized.
+func (p *Provider) RepoPerms(ctx context.Context, acct *extsvc.Account, repos []*types.Repo) ([]authz.RepoPerms, error) {
+	if len(repos) == 0 {
+		return nil, nil
+	}
+
+	var accountID string
+	if acct != nil && extsvc.IsHostOfAccount(p.codeHost, acct) {
+		accountID = acct.AccountID
+	}
+
+	member, err := p.memberOf(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	perms := make([]authz.RepoPerms, 0, len(repos))
+	for _, repo := range repos {
+		if !extsvc.IsHostOfRepo(p.codeHost, &repo.ExternalRepo) {
+			continue
+		}
+
+		groups, err := p.readGroups(ctx, repo.ExternalRepo.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if canRead(member, groups) {
+			perms = append(perms, authz.RepoPerms{Repo: repo, Perms: authz.Read})
+		}
+	}
+	return perms, nil
+}
+
+// FetchAccount returns the Gerrit account with the same username as the given
+// user, or nil if there is none.
+func (p *Provider) FetchAccount(ctx context.Context, user *types.User, _ []*extsvc.Account) (*extsvc.Account, error) {
+	if user == nil {
+		return nil, nil
+	}
+
+	gerritAccount, err := p.client.GetAccount(ctx, user.Username)
+	if err != nil {
+		if gerrit.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	accountData, err := json.Marshal(gerritAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	return &extsvc.Account{
+		UserID: user.ID,
+		AccountSpec: extsvc.AccountSpec{
+			ServiceType: p.codeHost.ServiceType,
+			ServiceID:   p.codeHost.ServiceID,
+			AccountID:   strconv.Itoa(gerritAccount.ID),
+		},
+		AccountData: extsvc.AccountData{
+			Data: (*json.RawMessage)(&accountData),
+		},
+	}, nil
+}
+
+// FetchUserPerms returns a list of project names that the given account can
+// read on the code host. The project name has the same value as it would be
+// used as api.ExternalRepoSpec.ID. The returned list only includes projects
+// which anonymous users can't read.
+//
+// This method may return partial but valid results in case of error, and it is up to
+// callers to decide whether to discard.
+func (p *Provider) FetchUserPerms(ctx context.Context, account *extsvc.Account) ([]extsvc.RepoID, error) {
+	switch {
+	case account == nil:
+		return nil, errors.New("no account provided")
+	case !extsvc.IsHostOfAccount(p.codeHost, account):
+		return nil, fmt.Errorf("not a code host of the account: want %q but have %q",
+			p.codeHost.ServiceID, account.AccountSpec.ServiceID)
+	}
+
+	member, err := p.memberOf(ctx, account.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []extsvc.RepoID
+	args := gerrit.ListProjectsArgs{Limit: p.pageSize}
+	for {
+		projects, more, err := p.client.ListProjects(ctx, args)
+		if err != nil {
+			return ids, err
+		}
+
+		for _, project := range projects {
+			groups, err := p.readGroups(ctx, project.Name)
+			if err != nil {
+				return ids, err
+			}
+			if !contains(groups.Groups, gerrit.GroupAnonymousUsers) && canRead(member, groups) {
+				ids = append(ids, extsvc.RepoID(project.Name))
+			}
+		}
+
+		if !more {
+			return ids, nil
+		}
+		args.Skip += len(projects)
+	}
+}
+
+// FetchRepoPerms returns a list of account IDs (on code host) who can read the
+// given project on the code host. The account ID has the same value as it would
+// be used as extsvc.Account.AccountID. The returned list includes the members of
+// all groups that may read the project, including members of subgroups, except
+// the members of groups that are blocked from reading it.
+//
+// Members of system groups like Registered Users can't be listed, so an error is
+// returned along with the listed accounts if such a group may read the project.
+func (p *Provider) FetchRepoPerms(ctx context.Context, repo *extsvc.Repository) ([]extsvc.AccountID, error) {
+	switch {
+	case repo == nil:
+		return nil, errors.New("no repo provided")
+	case !extsvc.IsHostOfRepo(p.codeHost, &repo.ExternalRepoSpec):
+		return nil, fmt.Errorf("not a code host of the repo: want %q but have %q",
+			p.codeHost.ServiceID, repo.ServiceID)
+	}
+
+	groups, err := p.readGroups(ctx, repo.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// seen starts with the members of blocked groups, so that they are never
+	// returned. Partial results are not valid if they can't all be listed.
+	seen := make(map[int]bool)
+	for _, group := range groups.Blocked {
+		switch group {
+		case gerrit.GroupAnonymousUsers, gerrit.GroupRegisteredUsers:
+			// Every account is blocked.
+			return nil, nil
+		case gerrit.GroupProjectOwners:
+			return nil, errors.Errorf("members of blocked system group %q can't be listed", group)
+		}
+
+		members, err := p.client.ListGroupMembers(ctx, group)
+		if err != nil {
+			return nil, errors.Wrapf(err, "members of blocked group %q", group)
+		}
+		for _, m := range members {
+			seen[m.ID] = true
+		}
+	}
+
+	var (
+		ids      []extsvc.AccountID
+		unlisted []string
+		listErr  error
+	)
+	for _, group := range groups.Groups {
+		switch group {
+		case gerrit.GroupAnonymousUsers, gerrit.GroupRegisteredUsers, gerrit.GroupProjectOwners:
+			unlisted = append(unlisted, group)
+			continue
+		}
+
+		members, err := p.client.ListGroupMembers(ctx, group)
+		if err != nil {
+			listErr = errors.Wrapf(err, "members of group %q", group)
+			continue
+		}
+		for _, m := range members {
+			if !seen[m.ID] {
+				seen[m.ID] = true
+				ids = append(ids, extsvc.AccountID(strconv.Itoa(m.ID)))
+			}
+		}
+	}
+
+	if listErr != nil {
+		return ids, listErr
+	}
+	if len(unlisted) > 0 {
+		return ids, errors.Errorf("members of system groups %q can't be listed", unlisted)
+	}
+	return ids, nil
+}
+
+// memberOf returns the set of UUIDs of the groups the account with the given ID
+// is a member of, including the system groups. An empty account ID denotes an
+// anonymous user.
+func (p *Provider) memberOf(ctx context.Context, accountID string) (map[string]bool, error) {
+	member := map[string]bool{gerrit.GroupAnonymousUsers: true}
+	if accountID == "" {
+		return member, nil
+	}
+	member[gerrit.GroupRegisteredUsers] = true
+
+	groups, err := p.cached(ctx, "a:"+accountID, func(ctx context.Context) (cacheVal, error) {
+		gs, err := p.client.ListAccountGroups(ctx, accountID)
+		if err != nil {
+			return cacheVal{}, err
+		}
+		uuids := make([]string, 0, len(gs))
+		for _, g := range gs {
+			uuids = append(uuids, g.UUID())
+		}
+		return cacheVal{Groups: uuids}, nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "groups of account %q", accountID)
+	}
+
+	for _, g := range groups.Groups {
+		member[g] = true
+	}
+	return member, nil
+}
+
+// readGroups returns the UUIDs of the groups that may read the given project
+// and of the groups that are blocked from reading it.
+func (p *Provider) readGroups(ctx context.Context, project string) (cacheVal, error) {
+	// The "pb:" prefix replaces "p:", whose entries lack the blocked groups.
+	return p.cached(ctx, "pb:"+project, func(ctx context.Context) (cacheVal, error) {
+		allowed, blocked, err := readGroups(ctx, p.client, project)
+		return cacheVal{Groups: allowed, Blocked: blocked}, err
+	})
+}
+
+// cached returns the group UUIDs cached at key, or computes and caches them
+// with fetch.
+func (p *Provider) cached(ctx context.Context, key string, fetch func(context.Context) (cacheVal, error)) (cacheVal, error) {
+	if b, ok := p.cache.Get(key); ok {
+		var val cacheVal
+		// Entries cached with a longer TTL than the current one are
+		// considered stale.
+		if err := json.Unmarshal(b, &val); err == nil && val.TTL <= p.cacheTTL {
+			return val, nil
+		}
+	}
+
+	val, err := fetch(ctx)
+	if err != nil {
+		return cacheVal{}, err
+	}
+
+	val.TTL = p.cacheTTL
+	if b, err := json.Marshal(val); err == nil {
+		p.cache.Set(key, b)
+	}
+	return val, nil
+}
+
+// canRead returns whether a member of the given groups may read a project
+// with the given read groups: they must be a member of an allowed group and
+// of no blocked group.
+func canRead(member map[string]bool, groups cacheVal) bool {
+	return anyMember(member, groups.Groups) && !anyMember(member, groups.Blocked)
+}
+
+func anyMember(member map[string]bool, groups []string) bool {
+	for _, g := range groups {
+		if member[g] {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(groups []string, group string) bool {
+	for _, g := range groups {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,339 @@
+package gerrit
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gerrit"
+)
+
+type fakeClient struct {
+	projects []string
+	access   map[string]*gerrit.ProjectAccess
+	accounts map[string]*gerrit.Account
+	groups   map[string][]string // account ID -> group UUIDs
+	members  map[string][]int    // group UUID -> account IDs
+
+	accessCalls int
+}
+
+func (c *fakeClient) ListProjects(ctx context.Context, args gerrit.ListProjectsArgs) ([]*gerrit.Project, bool, error) {
+	var ps []*gerrit.Project
+	for i := args.Skip; i < len(c.projects) && i < args.Skip+args.Limit; i++ {
+		ps = append(ps, &gerrit.Project{Name: c.projects[i]})
+	}
+	return ps, args.Skip+len(ps) < len(c.projects), nil
+}
+
+func (c *fakeClient) GetProjectAccess(ctx context.Context, name string) (*gerrit.ProjectAccess, error) {
+	c.accessCalls++
+	a, ok := c.access[name]
+	if !ok {
+		return nil, errors.Errorf("project %q not found", name)
+	}
+	return a, nil
+}
+
+func (c *fakeClient) GetAccount(ctx context.Context, id string) (*gerrit.Account, error) {
+	a, ok := c.accounts[id]
+	if !ok {
+		return nil, errNotFound
+	}
+	return a, nil
+}
+
+func (c *fakeClient) ListAccountGroups(ctx context.Context, accountID string) ([]*gerrit.Group, error) {
+	var gs []*gerrit.Group
+	for _, uuid := range c.groups[accountID] {
+		gs = append(gs, &gerrit.Group{ID: url.PathEscape(uuid)})
+	}
+	return gs, nil
+}
+
+func (c *fakeClient) ListGroupMembers(ctx context.Context, groupUUID string) ([]*gerrit.Account, error) {
+	var as []*gerrit.Account
+	for _, id := range c.members[groupUUID] {
+		as = append(as, &gerrit.Account{ID: id})
+	}
+	return as, nil
+}
+
+// errNotFound is returned by fakeClient for unknown accounts, like the Gerrit
+// client does for a 404 response.
+var errNotFound = func() error {
+	_, err := gerrit.NewClient(&url.URL{Scheme: "http", Host: "gerrit.invalid"}, notFoundDoer{}).GetAccount(context.Background(), "x")
+	return err
+}()
+
+type notFoundDoer struct{}
+
+func (notFoundDoer) Do(r *http.Request) (*http.Response, error) {
+	return (&http.Response{StatusCode: http.StatusNotFound, Body: http.NoBody, Request: r}), nil
+}
+
+type memCache map[string][]byte
+
+func (c memCache) Get(key string) ([]byte, bool) { b, ok := c[key]; return b, ok }
+func (c memCache) Set(key string, b []byte)      { c[key] = b }
+
+func access(parent string, exclusive bool, rules map[string]gerrit.PermissionAction) *gerrit.ProjectAccess {
+	perm := &gerrit.Permission{Exclusive: exclusive, Rules: map[string]*gerrit.PermissionRule{}}
+	for group, action := range rules {
+		perm.Rules[group] = &gerrit.PermissionRule{Action: action}
+	}
+	a := &gerrit.ProjectAccess{
+		Local: map[string]*gerrit.AccessSection{
+			"refs/*": {Permissions: map[string]*gerrit.Permission{"read": perm}},
+		},
+	}
+	if parent != "" {
+		a.InheritsFrom = &struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		}{ID: parent, Name: parent}
+	}
+	return a
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{
+		projects: []string{"public", "internal", "secret", "blocked", "exclusive"},
+		access: map[string]*gerrit.ProjectAccess{
+			"All-Projects": access("", false, map[string]gerrit.PermissionAction{
+				gerrit.GroupAnonymousUsers: gerrit.PermissionActionAllow,
+				"admins":                   gerrit.PermissionActionAllow,
+				"contractors":              gerrit.PermissionActionBlock,
+			}),
+			"public": access("All-Projects", false, nil),
+			"internal": access("All-Projects", false, map[string]gerrit.PermissionAction{
+				gerrit.GroupAnonymousUsers:  gerrit.PermissionActionDeny,
+				gerrit.GroupRegisteredUsers: gerrit.PermissionActionAllow,
+			}),
+			"secret-parent": access("All-Projects", false, map[string]gerrit.PermissionAction{
+				gerrit.GroupAnonymousUsers: gerrit.PermissionActionDeny,
+				"developers":               gerrit.PermissionActionAllow,
+			}),
+			"secret": access("secret-parent", false, nil),
+			"blocked": access("All-Projects", false, map[string]gerrit.PermissionAction{
+				gerrit.GroupAnonymousUsers: gerrit.PermissionActionDeny,
+				"contractors":              gerrit.PermissionActionAllow,
+			}),
+			"exclusive": access("All-Projects", true, map[string]gerrit.PermissionAction{
+				"developers": gerrit.PermissionActionAllow,
+			}),
+		},
+		accounts: map[string]*gerrit.Account{
+			"alice": {ID: 1000, Username: "alice"},
+			"bob":   {ID: 1001, Username: "bob"},
+			"carol": {ID: 1004, Username: "carol"},
+		},
+		groups: map[string][]string{
+			"1000": {"developers"},
+			"1001": {"contractors"},
+			"1004": {"developers", "contractors"},
+		},
+		members: map[string][]int{
+			"developers":  {1000, 1002, 1004},
+			"admins":      {1002, 1003},
+			"contractors": {1001, 1004},
+		},
+	}
+}
+
+func newTestProvider(cli *fakeClient) *Provider {
+	p := NewProvider(gerrit.NewClient(&url.URL{Scheme: "https", Host: "gerrit.example.com"}, nil), time.Hour, memCache{})
+	p.client = cli
+	p.pageSize = 2
+	return p
+}
+
+func TestReadGroups(t *testing.T) {
+	cli := newFakeClient()
+	for project, want := range map[string][]string{
+		"public":    {"admins", gerrit.GroupAnonymousUsers},
+		"internal":  {"admins", gerrit.GroupRegisteredUsers},
+		"secret":    {"admins", "developers"},
+		"blocked":   {"admins"},
+		"exclusive": {"developers"},
+	} {
+		have, blocked, err := readGroups(context.Background(), cli, project)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, have); diff != "" {
+			t.Errorf("%s: %s", project, diff)
+		}
+		// All-Projects blocks contractors, which applies to every project.
+		if diff := cmp.Diff([]string{"contractors"}, blocked); diff != "" {
+			t.Errorf("%s: blocked: %s", project, diff)
+		}
+	}
+
+	cli.access["cycle-a"] = access("cycle-b", false, nil)
+	cli.access["cycle-b"] = access("cycle-a", false, nil)
+	if _, _, err := readGroups(context.Background(), cli, "cycle-a"); err == nil {
+		t.Error("expected an error for an inheritance cycle")
+	}
+}
+
+func TestProvider_RepoPerms(t *testing.T) {
+	cli := newFakeClient()
+	p := newTestProvider(cli)
+
+	repo := func(name string) *types.Repo {
+		return &types.Repo{
+			Name: api.RepoName("gerrit.example.com/" + name),
+			ExternalRepo: api.ExternalRepoSpec{
+				ID:          name,
+				ServiceType: gerrit.ServiceType,
+				ServiceID:   "https://gerrit.example.com/",
+			},
+		}
+	}
+	repos := []*types.Repo{repo("public"), repo("internal"), repo("secret"), repo("blocked"), repo("exclusive")}
+	repos = append(repos, &types.Repo{
+		Name:         "github.com/foo/bar",
+		ExternalRepo: api.ExternalRepoSpec{ID: "public", ServiceType: "github", ServiceID: "https://github.com/"},
+	})
+
+	account := func(id string) *extsvc.Account {
+		return &extsvc.Account{AccountSpec: extsvc.AccountSpec{
+			ServiceType: gerrit.ServiceType,
+			ServiceID:   "https://gerrit.example.com/",
+			AccountID:   id,
+		}}
+	}
+
+	for _, tc := range []struct {
+		name    string
+		account *extsvc.Account
+		want    []string
+	}{
+		{name: "anonymous", want: []string{"public"}},
+		{name: "developer", account: account("1000"), want: []string{"public", "internal", "secret", "exclusive"}},
+		// Contractors are blocked from reading any project, even those that
+		// anonymous and registered users may read.
+		{name: "contractor", account: account("1001")},
+		{name: "developer and contractor", account: account("1004")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			perms, err := p.RepoPerms(context.Background(), tc.account, repos)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var have []string
+			for _, perm := range perms {
+				if perm.Perms != authz.Read {
+					t.Errorf("unexpected perms %v for %s", perm.Perms, perm.Repo.Name)
+				}
+				have = append(have, perm.Repo.ExternalRepo.ID)
+			}
+			if diff := cmp.Diff(tc.want, have); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+
+	// Access rights are cached.
+	calls := cli.accessCalls
+	if _, err := p.RepoPerms(context.Background(), nil, repos); err != nil {
+		t.Fatal(err)
+	}
+	if cli.accessCalls != calls {
+		t.Errorf("expected access rights to be cached, got %d more calls", cli.accessCalls-calls)
+	}
+}
+
+func TestProvider_FetchAccount(t *testing.T) {
+	p := newTestProvider(newFakeClient())
+
+	acct, err := p.FetchAccount(context.Background(), &types.User{ID: 42, Username: "alice"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := extsvc.AccountSpec{ServiceType: gerrit.ServiceType, ServiceID: "https://gerrit.example.com/", AccountID: "1000"}
+	if acct == nil || acct.UserID != 42 || acct.AccountSpec != want {
+		t.Errorf("unexpected account: %+v", acct)
+	}
+
+	acct, err = p.FetchAccount(context.Background(), &types.User{ID: 43, Username: "mallory"}, nil)
+	if err != nil || acct != nil {
+		t.Errorf("got (%+v, %v), want (nil, nil) for an unknown user", acct, err)
+	}
+}
+
+func TestProvider_FetchUserPerms(t *testing.T) {
+	p := newTestProvider(newFakeClient())
+
+	ids, err := p.FetchUserPerms(context.Background(), &extsvc.Account{AccountSpec: extsvc.AccountSpec{
+		ServiceType: gerrit.ServiceType,
+		ServiceID:   "https://gerrit.example.com/",
+		AccountID:   "1000",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]extsvc.RepoID{"internal", "secret", "exclusive"}, ids); diff != "" {
+		t.Error(diff)
+	}
+
+	// A member of both an allowed and a blocked group can't read anything.
+	ids, err = p.FetchUserPerms(context.Background(), &extsvc.Account{AccountSpec: extsvc.AccountSpec{
+		ServiceType: gerrit.ServiceType,
+		ServiceID:   "https://gerrit.example.com/",
+		AccountID:   "1004",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 0 {
+		t.Errorf("got %v for a blocked account, want none", ids)
+	}
+
+	if _, err := p.FetchUserPerms(context.Background(), &extsvc.Account{AccountSpec: extsvc.AccountSpec{
+		ServiceType: "github",
+		ServiceID:   "https://github.com/",
+	}}); err == nil {
+		t.Error("expected an error for an account of another code host")
+	}
+}
+
+func TestProvider_FetchRepoPerms(t *testing.T) {
+	p := newTestProvider(newFakeClient())
+
+	repo := func(name string) *extsvc.Repository {
+		return &extsvc.Repository{ExternalRepoSpec: api.ExternalRepoSpec{
+			ID:          name,
+			ServiceType: gerrit.ServiceType,
+			ServiceID:   "https://gerrit.example.com/",
+		}}
+	}
+
+	ids, err := p.FetchRepoPerms(context.Background(), repo("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	if diff := cmp.Diff([]extsvc.AccountID{"1000", "1002", "1003"}, ids); diff != "" {
+		t.Error(diff)
+	}
+
+	ids, err = p.FetchRepoPerms(context.Background(), repo("internal"))
+	if err == nil {
+		t.Error("expected an error for a project readable by registered users")
+	}
+	if diff := cmp.Diff([]extsvc.AccountID{"1002", "1003"}, ids); diff != "" {
+		t.Error(diff)
+	}
+}
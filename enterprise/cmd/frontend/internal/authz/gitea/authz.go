@@ -0,0 +1,80 @@
+package gitea
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
+	iauthz "github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/internal/authz"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitea"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// NewAuthzProviders returns the set of Gitea authz providers derived from the connections.
+// It also returns any validation problems with the config, separating these into "serious problems" and
+// "warnings". "Serious problems" are those that should make Sourcegraph set authz.allowAccessByDefault
+// to false. "Warnings" are all other validation problems.
+func NewAuthzProviders(
+	conns []*schema.GiteaConnection,
+) (ps []authz.Provider, problems []string, warnings []string) {
+	// Authorization (i.e., permissions) providers
+	for _, c := range conns {
+		p, err := newAuthzProvider(c)
+		if err != nil {
+			problems = append(problems, err.Error())
+		} else if p != nil {
+			ps = append(ps, p)
+		}
+	}
+
+	for _, p := range ps {
+		for _, problem := range p.Validate() {
+			warnings = append(warnings, fmt.Sprintf("Gitea config for %s was invalid: %s", p.ServiceID(), problem))
+		}
+	}
+
+	return ps, problems, warnings
+}
+
+func newAuthzProvider(c *schema.GiteaConnection) (authz.Provider, error) {
+	if c.Authorization == nil {
+		return nil, nil
+	}
+
+	errs := new(multierror.Error)
+
+	ttl, err := iauthz.ParseTTL(c.Authorization.Ttl)
+	if err != nil {
+		errs = multierror.Append(errs, err)
+	}
+
+	baseURL, err := url.Parse(c.Url)
+	if err != nil {
+		errs = multierror.Append(errs, errors.Errorf("Could not parse URL for Gitea instance %q: %s", c.Url, err))
+		return nil, errs.ErrorOrNil()
+	}
+	baseURL = extsvc.NormalizeBaseURL(baseURL)
+
+	cli := gitea.NewClient(baseURL, nil)
+	cli.Token = c.Token
+
+	var p authz.Provider
+	switch idp := c.Authorization.IdentityProvider; {
+	case idp.Username != nil:
+		p = NewProvider(cli, ttl, nil)
+	default:
+		errs = multierror.Append(errs, errors.Errorf("No identityProvider was specified"))
+	}
+
+	return p, errs.ErrorOrNil()
+}
+
+// ValidateAuthz validates the authorization fields of the given Gitea external
+// service config.
+func ValidateAuthz(c *schema.GiteaConnection) error {
+	_, err := newAuthzProvider(c)
+	return err
+}
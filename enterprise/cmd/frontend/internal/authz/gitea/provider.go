@@ -0,0 +1,379 @@
+// Package gitea contains an authorization provider for Gitea.
+package gitea
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitea"
+	"github.com/sourcegraph/sourcegraph/internal/rcache"
+)
+
+// client is the subset of the Gitea API client used by Provider.
+type client interface {
+	SearchRepos(ctx context.Context, args gitea.ListArgs) ([]*gitea.Repository, bool, error)
+	GetRepoByID(ctx context.Context, id int64) (*gitea.Repository, error)
+	GetUser(ctx context.Context, username string) (*gitea.User, error)
+	GetOwner(ctx context.Context, name string) (*gitea.User, error)
+	GetAuthenticatedUser(ctx context.Context) (*gitea.User, error)
+	ListCollaborators(ctx context.Context, owner, name string, args gitea.ListArgs) ([]*gitea.User, bool, error)
+	ListRepoTeams(ctx context.Context, owner, name string) ([]*gitea.Team, error)
+	ListTeamMembers(ctx context.Context, teamID int64, args gitea.ListArgs) ([]*gitea.User, bool, error)
+}
+
+// cache describes the shape of the cache that Provider uses internally.
+type cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, b []byte)
+}
+
+// cacheVal is the cached set of users who may read a repository.
+type cacheVal struct {
+	// Public is whether the repository is public, in which case Readers is
+	// empty.
+	Public  bool
+	Readers []int64
+	TTL     time.Duration
+}
+
+// Provider implements authz.Provider for Gitea repository permissions. A
+// repository is public if it is neither private nor internal and its owner is
+// public. A user may read any other repository if they own it, if they are one
+// of its collaborators or if they are a member of one of its teams.
+type Provider struct {
+	client   client
+	codeHost *extsvc.CodeHost
+	cacheTTL time.Duration
+	cache    cache
+	pageSize int
+}
+
+var _ authz.Provider = (*Provider)(nil)
+
+// NewProvider returns a new Gitea authorization provider that uses the given
+// gitea.Client to read repository collaborators and team memberships. The
+// client's account must be able to see all repositories and their
+// collaborators and teams, which usually requires a site administrator. It
+// assumes usernames of Sourcegraph accounts match 1-1 with usernames of Gitea
+// accounts.
+func NewProvider(cli *gitea.Client, cacheTTL time.Duration, mockCache cache) *Provider {
+	p := &Provider{
+		client:   cli,
+		codeHost: extsvc.NewCodeHost(cli.URL, gitea.ServiceType),
+		cacheTTL: cacheTTL,
+		cache:    mockCache,
+		pageSize: 50,
+	}
+	// Note: this will use the same underlying Redis instance and key namespace for every instance
+	// of Provider.  This is by design, so that different instances, even in different processes,
+	// will share cache entries.
+	if p.cache == nil {
+		p.cache = rcache.NewWithTTL(fmt.Sprintf("giteaAuthz:%s", p.codeHost.ServiceID), int(math.Ceil(cacheTTL.Seconds())))
+	}
+	return p
+}
+
+// Validate validates that the Provider has access to the Gitea API with the
+// token it was configured with.
+func (p *Provider) Validate() []string {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := p.client.GetAuthenticatedUser(ctx); err != nil {
+		return []string{err.Error()}
+	}
+	return nil
+}
+
+// ServiceID returns the absolute URL that identifies the Gitea instance this
+// provider is configured with.
+func (p *Provider) ServiceID() string { return p.codeHost.ServiceID }
+
+// ServiceType returns the type of this Provider, namely, "gitea".
+func (p *Provider) ServiceType() string { return p.codeHost.ServiceType }
+
+// RepoPerms returns the permissions the given external account has in relation
+// to the given set of repos. Without an account, only public repositories are
+// authorized.
+func (p *Provider) RepoPerms(ctx context.Context, acct *extsvc.Account, repos []*types.Repo) ([]authz.RepoPerms, error) {
+	if len(repos) == 0 {
+		return nil, nil
+	}
+
+	var userID int64
+	if acct != nil && extsvc.IsHostOfAccount(p.codeHost, acct) {
+		id, err := strconv.ParseInt(acct.AccountID, 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid account ID %q", acct.AccountID)
+		}
+		userID = id
+	}
+
+	perms := make([]authz.RepoPerms, 0, len(repos))
+	for _, repo := range repos {
+		if !extsvc.IsHostOfRepo(p.codeHost, &repo.ExternalRepo) {
+			continue
+		}
+
+		val, err := p.readers(ctx, repo.ExternalRepo.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if val.Public || (userID != 0 && contains(val.Readers, userID)) {
+			perms = append(perms, authz.RepoPerms{Repo: repo, Perms: authz.Read})
+		}
+	}
+	return perms, nil
+}
+
+// FetchAccount returns the Gitea account with the same username as the given
+// user, or nil if there is none.
+func (p *Provider) FetchAccount(ctx context.Context, user *types.User, _ []*extsvc.Account) (*extsvc.Account, error) {
+	if user == nil {
+		return nil, nil
+	}
+
+	giteaUser, err := p.client.GetUser(ctx, user.Username)
+	if err != nil {
+		if gitea.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	accountData, err := json.Marshal(giteaUser)
+	if err != nil {
+		return nil, err
+	}
+
+	return &extsvc.Account{
+		UserID: user.ID,
+		AccountSpec: extsvc.AccountSpec{
+			ServiceType: p.codeHost.ServiceType,
+			ServiceID:   p.codeHost.ServiceID,
+			AccountID:   strconv.FormatInt(giteaUser.ID, 10),
+		},
+		AccountData: extsvc.AccountData{
+			Data: (*json.RawMessage)(&accountData),
+		},
+	}, nil
+}
+
+// FetchUserPerms returns a list of repository IDs that the given account can
+// read on the code host. The repository ID has the same value as it would be
+// used as api.ExternalRepoSpec.ID. The returned list only includes repositories
+// that aren't public.
+//
+// This method may return partial but valid results in case of error, and it is up to
+// callers to decide whether to discard.
+func (p *Provider) FetchUserPerms(ctx context.Context, account *extsvc.Account) ([]extsvc.RepoID, error) {
+	switch {
+	case account == nil:
+		return nil, errors.New("no account provided")
+	case !extsvc.IsHostOfAccount(p.codeHost, account):
+		return nil, fmt.Errorf("not a code host of the account: want %q but have %q",
+			p.codeHost.ServiceID, account.AccountSpec.ServiceID)
+	}
+
+	userID, err := strconv.ParseInt(account.AccountID, 10, 64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid account ID %q", account.AccountID)
+	}
+
+	var ids []extsvc.RepoID
+	args := gitea.ListArgs{Page: 1, Limit: p.pageSize}
+	for {
+		repos, more, err := p.client.SearchRepos(ctx, args)
+		if err != nil {
+			return ids, err
+		}
+
+		for _, repo := range repos {
+			repo := repo
+			val, err := p.cached(ctx, repoKey(repo.ID), func(ctx context.Context) (*cacheVal, error) {
+				return p.fetchReaders(ctx, repo)
+			})
+			if err != nil {
+				return ids, err
+			}
+			if !val.Public && contains(val.Readers, userID) {
+				ids = append(ids, extsvc.RepoID(strconv.FormatInt(repo.ID, 10)))
+			}
+		}
+
+		if !more {
+			return ids, nil
+		}
+		args.Page++
+	}
+}
+
+// FetchRepoPerms returns a list of account IDs (on code host) who can read the
+// given repository on the code host. The account ID has the same value as it
+// would be used as extsvc.Account.AccountID. The returned list includes the
+// owner, the collaborators and the members of all teams of the repository.
+//
+// Public repositories can be read by everyone, so an error is returned for them.
+func (p *Provider) FetchRepoPerms(ctx context.Context, repo *extsvc.Repository) ([]extsvc.AccountID, error) {
+	switch {
+	case repo == nil:
+		return nil, errors.New("no repo provided")
+	case !extsvc.IsHostOfRepo(p.codeHost, &repo.ExternalRepoSpec):
+		return nil, fmt.Errorf("not a code host of the repo: want %q but have %q",
+			p.codeHost.ServiceID, repo.ServiceID)
+	}
+
+	val, err := p.readers(ctx, repo.ID)
+	if err != nil {
+		return nil, err
+	}
+	if val.Public {
+		return nil, errors.Errorf("readers of public repository %q can't be listed", repo.ID)
+	}
+
+	ids := make([]extsvc.AccountID, 0, len(val.Readers))
+	for _, id := range val.Readers {
+		ids = append(ids, extsvc.AccountID(strconv.FormatInt(id, 10)))
+	}
+	return ids, nil
+}
+
+// readers returns the users who may read the repository with the given ID.
+func (p *Provider) readers(ctx context.Context, repoID string) (*cacheVal, error) {
+	id, err := strconv.ParseInt(repoID, 10, 64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid repository ID %q", repoID)
+	}
+
+	return p.cached(ctx, repoKey(id), func(ctx context.Context) (*cacheVal, error) {
+		repo, err := p.client.GetRepoByID(ctx, id)
+		if err != nil {
+			return nil, errors.Wrapf(err, "repository %d", id)
+		}
+		return p.fetchReaders(ctx, repo)
+	})
+}
+
+// fetchReaders lists the users who may read the given repository.
+func (p *Provider) fetchReaders(ctx context.Context, repo *gitea.Repository) (*cacheVal, error) {
+	if repo.Owner == nil {
+		return nil, errors.Errorf("repository %q has no owner", repo.FullName)
+	}
+	owner := repo.Owner.Name()
+
+	// 🚨 SECURITY: Repositories that aren't private are still hidden from
+	// anonymous visitors if they are internal or if their owner is limited or
+	// private, so we only treat them as public if their owner is public too.
+	if !repo.Private && !repo.Internal {
+		o, err := p.client.GetOwner(ctx, owner)
+		if err != nil {
+			return nil, errors.Wrapf(err, "owner of repository %q", repo.FullName)
+		}
+		if o.Public() {
+			return &cacheVal{Public: true}, nil
+		}
+	}
+
+	var (
+		readers []int64
+		seen    = make(map[int64]bool)
+	)
+	add := func(users []*gitea.User) {
+		for _, u := range users {
+			if !seen[u.ID] {
+				seen[u.ID] = true
+				readers = append(readers, u.ID)
+			}
+		}
+	}
+
+	add([]*gitea.User{repo.Owner})
+
+	err := p.listUsers(ctx, func(ctx context.Context, args gitea.ListArgs) ([]*gitea.User, bool, error) {
+		return p.client.ListCollaborators(ctx, owner, repo.Name, args)
+	}, add)
+	if err != nil {
+		return nil, errors.Wrapf(err, "collaborators of repository %q", repo.FullName)
+	}
+
+	teams, err := p.client.ListRepoTeams(ctx, owner, repo.Name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "teams of repository %q", repo.FullName)
+	}
+	for _, team := range teams {
+		teamID := team.ID
+		err := p.listUsers(ctx, func(ctx context.Context, args gitea.ListArgs) ([]*gitea.User, bool, error) {
+			return p.client.ListTeamMembers(ctx, teamID, args)
+		}, add)
+		if err != nil {
+			return nil, errors.Wrapf(err, "members of team %q", team.Name)
+		}
+	}
+
+	return &cacheVal{Readers: readers}, nil
+}
+
+// listUsers calls f with the users on every page returned by list.
+func (p *Provider) listUsers(
+	ctx context.Context,
+	list func(context.Context, gitea.ListArgs) ([]*gitea.User, bool, error),
+	f func([]*gitea.User),
+) error {
+	args := gitea.ListArgs{Page: 1, Limit: p.pageSize}
+	for {
+		users, more, err := list(ctx, args)
+		if err != nil {
+			return err
+		}
+		f(users)
+		if !more || len(users) == 0 {
+			return nil
+		}
+		args.Page++
+	}
+}
+
+// cached returns the value cached at key, or computes and caches it with fetch.
+func (p *Provider) cached(ctx context.Context, key string, fetch func(context.Context) (*cacheVal, error)) (*cacheVal, error) {
+	if b, ok := p.cache.Get(key); ok {
+		var val cacheVal
+		// Entries cached with a longer TTL than the current one are
+		// considered stale.
+		if err := json.Unmarshal(b, &val); err == nil && val.TTL <= p.cacheTTL {
+			return &val, nil
+		}
+	}
+
+	val, err := fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	val.TTL = p.cacheTTL
+	if b, err := json.Marshal(val); err == nil {
+		p.cache.Set(key, b)
+	}
+	return val, nil
+}
+
+func repoKey(id int64) string {
+	return "r:" + strconv.FormatInt(id, 10)
+}
+
+func contains(ids []int64, id int64) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,310 @@
+package gitea
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitea"
+)
+
+type fakeClient struct {
+	repos         []*gitea.Repository
+	users         map[string]*gitea.User
+	collaborators map[int64][]int64 // repo ID -> user IDs
+	teams         map[int64][]int64 // repo ID -> team IDs
+	members       map[int64][]int64 // team ID -> user IDs
+
+	repoCalls int
+}
+
+func (c *fakeClient) SearchRepos(ctx context.Context, args gitea.ListArgs) ([]*gitea.Repository, bool, error) {
+	start := (args.Page - 1) * args.Limit
+	var rs []*gitea.Repository
+	for i := start; i < len(c.repos) && i < start+args.Limit; i++ {
+		rs = append(rs, c.repos[i])
+	}
+	return rs, start+len(rs) < len(c.repos), nil
+}
+
+func (c *fakeClient) GetRepoByID(ctx context.Context, id int64) (*gitea.Repository, error) {
+	c.repoCalls++
+	for _, r := range c.repos {
+		if r.ID == id {
+			return r, nil
+		}
+	}
+	return nil, errors.Errorf("repository %d not found", id)
+}
+
+func (c *fakeClient) GetUser(ctx context.Context, username string) (*gitea.User, error) {
+	u, ok := c.users[username]
+	if !ok {
+		return nil, errNotFound
+	}
+	return u, nil
+}
+
+func (c *fakeClient) GetOwner(ctx context.Context, name string) (*gitea.User, error) {
+	for _, r := range c.repos {
+		if r.Owner.Name() == name {
+			return r.Owner, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (c *fakeClient) GetAuthenticatedUser(ctx context.Context) (*gitea.User, error) {
+	return &gitea.User{ID: 1, Login: "admin"}, nil
+}
+
+func (c *fakeClient) ListCollaborators(ctx context.Context, owner, name string, args gitea.ListArgs) ([]*gitea.User, bool, error) {
+	r, err := c.repo(owner, name)
+	if err != nil {
+		return nil, false, err
+	}
+	return page(c.collaborators[r.ID], args)
+}
+
+func (c *fakeClient) ListRepoTeams(ctx context.Context, owner, name string) ([]*gitea.Team, error) {
+	r, err := c.repo(owner, name)
+	if err != nil {
+		return nil, err
+	}
+	var ts []*gitea.Team
+	for _, id := range c.teams[r.ID] {
+		ts = append(ts, &gitea.Team{ID: id, Name: "team" + strconv.FormatInt(id, 10)})
+	}
+	return ts, nil
+}
+
+func (c *fakeClient) ListTeamMembers(ctx context.Context, teamID int64, args gitea.ListArgs) ([]*gitea.User, bool, error) {
+	return page(c.members[teamID], args)
+}
+
+func (c *fakeClient) repo(owner, name string) (*gitea.Repository, error) {
+	for _, r := range c.repos {
+		if r.Owner.Name() == owner && r.Name == name {
+			return r, nil
+		}
+	}
+	return nil, errors.Errorf("repository %s/%s not found", owner, name)
+}
+
+func page(ids []int64, args gitea.ListArgs) ([]*gitea.User, bool, error) {
+	start := (args.Page - 1) * args.Limit
+	var us []*gitea.User
+	for i := start; i < len(ids) && i < start+args.Limit; i++ {
+		us = append(us, &gitea.User{ID: ids[i]})
+	}
+	return us, start+len(us) < len(ids), nil
+}
+
+// errNotFound is returned by fakeClient for unknown users, like the Gitea
+// client does for a 404 response.
+var errNotFound = func() error {
+	_, err := gitea.NewClient(&url.URL{Scheme: "http", Host: "gitea.invalid"}, notFoundDoer{}).GetUser(context.Background(), "x")
+	return err
+}()
+
+type notFoundDoer struct{}
+
+func (notFoundDoer) Do(r *http.Request) (*http.Response, error) {
+	return (&http.Response{StatusCode: http.StatusNotFound, Body: http.NoBody, Request: r}), nil
+}
+
+type memCache map[string][]byte
+
+func (c memCache) Get(key string) ([]byte, bool) { b, ok := c[key]; return b, ok }
+func (c memCache) Set(key string, b []byte)      { c[key] = b }
+
+func newFakeClient() *fakeClient {
+	alice := &gitea.User{ID: 1000, Login: "alice"}
+	myteam := &gitea.User{ID: 2000, Login: "myteam"}
+	hidden := &gitea.User{ID: 3000, Login: "hidden", Visibility: gitea.VisibilityPrivate}
+	return &fakeClient{
+		repos: []*gitea.Repository{
+			{ID: 1, Owner: alice, Name: "public", FullName: "alice/public"},
+			{ID: 2, Owner: alice, Name: "private", FullName: "alice/private", Private: true},
+			{ID: 3, Owner: myteam, Name: "secret", FullName: "myteam/secret", Private: true},
+			{ID: 4, Owner: myteam, Name: "shared", FullName: "myteam/shared", Private: true},
+			{ID: 5, Owner: myteam, Name: "internal", FullName: "myteam/internal", Internal: true},
+			{ID: 6, Owner: hidden, Name: "unlisted", FullName: "hidden/unlisted"},
+		},
+		users: map[string]*gitea.User{
+			"alice": alice,
+			"bob":   {ID: 1001, Login: "bob"},
+		},
+		collaborators: map[int64][]int64{
+			2: {1001},
+			4: {1003, 1004, 1005},
+		},
+		teams: map[int64][]int64{
+			3: {10},
+			5: {10},
+			6: {20},
+		},
+		members: map[int64][]int64{
+			10: {1002, 1000},
+			20: {1001},
+		},
+	}
+}
+
+func newTestProvider(cli *fakeClient) *Provider {
+	p := NewProvider(gitea.NewClient(&url.URL{Scheme: "https", Host: "gitea.example.com"}, nil), time.Hour, memCache{})
+	p.client = cli
+	p.pageSize = 2
+	return p
+}
+
+func TestProvider_RepoPerms(t *testing.T) {
+	cli := newFakeClient()
+	p := newTestProvider(cli)
+
+	repo := func(id string) *types.Repo {
+		return &types.Repo{
+			Name: api.RepoName("gitea.example.com/" + id),
+			ExternalRepo: api.ExternalRepoSpec{
+				ID:          id,
+				ServiceType: gitea.ServiceType,
+				ServiceID:   "https://gitea.example.com/",
+			},
+		}
+	}
+	repos := []*types.Repo{repo("1"), repo("2"), repo("3"), repo("4"), repo("5"), repo("6")}
+	repos = append(repos, &types.Repo{
+		Name:         "github.com/foo/bar",
+		ExternalRepo: api.ExternalRepoSpec{ID: "1", ServiceType: "github", ServiceID: "https://github.com/"},
+	})
+
+	account := func(id string) *extsvc.Account {
+		return &extsvc.Account{AccountSpec: extsvc.AccountSpec{
+			ServiceType: gitea.ServiceType,
+			ServiceID:   "https://gitea.example.com/",
+			AccountID:   id,
+		}}
+	}
+
+	for _, tc := range []struct {
+		name    string
+		account *extsvc.Account
+		want    []string
+	}{
+		{name: "anonymous", want: []string{"1"}},
+		{name: "owner and team member", account: account("1000"), want: []string{"1", "2", "3", "5"}},
+		{name: "collaborator and member of a private org", account: account("1001"), want: []string{"1", "2", "6"}},
+		{name: "collaborator on a later page", account: account("1005"), want: []string{"1", "4"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			perms, err := p.RepoPerms(context.Background(), tc.account, repos)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var have []string
+			for _, perm := range perms {
+				if perm.Perms != authz.Read {
+					t.Errorf("unexpected perms %v for %s", perm.Perms, perm.Repo.Name)
+				}
+				have = append(have, perm.Repo.ExternalRepo.ID)
+			}
+			if diff := cmp.Diff(tc.want, have); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+
+	// Readers are cached.
+	calls := cli.repoCalls
+	if _, err := p.RepoPerms(context.Background(), nil, repos); err != nil {
+		t.Fatal(err)
+	}
+	if cli.repoCalls != calls {
+		t.Errorf("expected readers to be cached, got %d more calls", cli.repoCalls-calls)
+	}
+}
+
+func TestProvider_FetchAccount(t *testing.T) {
+	p := newTestProvider(newFakeClient())
+
+	acct, err := p.FetchAccount(context.Background(), &types.User{ID: 42, Username: "alice"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := extsvc.AccountSpec{ServiceType: gitea.ServiceType, ServiceID: "https://gitea.example.com/", AccountID: "1000"}
+	if acct == nil || acct.UserID != 42 || acct.AccountSpec != want {
+		t.Errorf("unexpected account: %+v", acct)
+	}
+
+	acct, err = p.FetchAccount(context.Background(), &types.User{ID: 43, Username: "mallory"}, nil)
+	if err != nil || acct != nil {
+		t.Errorf("got (%+v, %v), want (nil, nil) for an unknown user", acct, err)
+	}
+}
+
+func TestProvider_FetchUserPerms(t *testing.T) {
+	p := newTestProvider(newFakeClient())
+
+	ids, err := p.FetchUserPerms(context.Background(), &extsvc.Account{AccountSpec: extsvc.AccountSpec{
+		ServiceType: gitea.ServiceType,
+		ServiceID:   "https://gitea.example.com/",
+		AccountID:   "1000",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]extsvc.RepoID{"2", "3", "5"}, ids); diff != "" {
+		t.Error(diff)
+	}
+
+	if _, err := p.FetchUserPerms(context.Background(), &extsvc.Account{AccountSpec: extsvc.AccountSpec{
+		ServiceType: "github",
+		ServiceID:   "https://github.com/",
+	}}); err == nil {
+		t.Error("expected an error for an account of another code host")
+	}
+}
+
+func TestProvider_FetchRepoPerms(t *testing.T) {
+	p := newTestProvider(newFakeClient())
+
+	repo := func(id string) *extsvc.Repository {
+		return &extsvc.Repository{ExternalRepoSpec: api.ExternalRepoSpec{
+			ID:          id,
+			ServiceType: gitea.ServiceType,
+			ServiceID:   "https://gitea.example.com/",
+		}}
+	}
+
+	for id, want := range map[string][]extsvc.AccountID{
+		"2": {"1000", "1001"},
+		"3": {"1000", "1002", "2000"},
+		"4": {"1003", "1004", "1005", "2000"},
+		"5": {"1000", "1002", "2000"},
+		"6": {"1001", "3000"},
+	} {
+		ids, err := p.FetchRepoPerms(context.Background(), repo(id))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		if diff := cmp.Diff(want, ids); diff != "" {
+			t.Errorf("repo %s: %s", id, diff)
+		}
+	}
+
+	if _, err := p.FetchRepoPerms(context.Background(), repo("1")); err == nil {
+		t.Error("expected an error for a public repository")
+	}
+}
@@ -22,6 +22,8 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/actor"
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/repoupdater"
+	"github.com/sourcegraph/sourcegraph/internal/repoupdater/protocol"
 	"github.com/sourcegraph/sourcegraph/schema"
 )
 
@@ -523,3 +525,85 @@ func TestResolver_AuthorizedUsers(t *testing.T) {
 		})
 	}
 }
+
+func TestResolver_SchedulePermissionsSync(t *testing.T) {
+	t.Run("authenticated as non-admin", func(t *testing.T) {
+		db.Mocks.Users.GetByCurrentAuthUser = func(context.Context) (*types.User, error) {
+			return &types.User{}, nil
+		}
+		defer func() {
+			db.Mocks.Users.GetByCurrentAuthUser = nil
+		}()
+
+		ctx := actor.WithActor(context.Background(), &actor.Actor{UID: 1})
+		result, err := (&Resolver{}).ScheduleUserPermissionsSync(ctx, &graphqlbackend.UserPermsSyncArgs{})
+		if want := backend.ErrMustBeSiteAdmin; err != want {
+			t.Errorf("err: want %q but got %v", want, err)
+		}
+		if result != nil {
+			t.Errorf("result: want nil but got %v", result)
+		}
+
+		result, err = (&Resolver{}).ScheduleRepositoryPermissionsSync(ctx, &graphqlbackend.RepoPermsSyncArgs{})
+		if want := backend.ErrMustBeSiteAdmin; err != want {
+			t.Errorf("err: want %q but got %v", want, err)
+		}
+		if result != nil {
+			t.Errorf("result: want nil but got %v", result)
+		}
+	})
+
+	db.Mocks.Users.GetByCurrentAuthUser = func(context.Context) (*types.User, error) {
+		return &types.User{SiteAdmin: true}, nil
+	}
+	db.Mocks.Users.GetByID = func(_ context.Context, id int32) (*types.User, error) {
+		return &types.User{ID: id}, nil
+	}
+	db.Mocks.Repos.Get = func(_ context.Context, id api.RepoID) (*types.Repo, error) {
+		return &types.Repo{ID: id}, nil
+	}
+	var scheduled []protocol.PermsSyncRequest
+	repoupdater.MockSchedulePermsSync = func(_ context.Context, req protocol.PermsSyncRequest) error {
+		scheduled = append(scheduled, req)
+		return nil
+	}
+	defer func() {
+		db.Mocks.Users = db.MockUsers{}
+		db.Mocks.Repos = db.MockRepos{}
+		repoupdater.MockSchedulePermsSync = nil
+	}()
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: mustParseGraphQLSchema(t, nil),
+			Query: `
+				mutation {
+					scheduleUserPermissionsSync(user: "VXNlcjox") {
+						alwaysNil
+					}
+					scheduleRepositoryPermissionsSync(repository: "UmVwb3NpdG9yeToy") {
+						alwaysNil
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"scheduleUserPermissionsSync": {
+						"alwaysNil": null
+					},
+					"scheduleRepositoryPermissionsSync": {
+						"alwaysNil": null
+					}
+				}
+			`,
+		},
+	})
+
+	want := []protocol.PermsSyncRequest{
+		{UserIDs: []int32{1}},
+		{RepoIDs: []api.RepoID{2}},
+	}
+	if diff := cmp.Diff(want, scheduled); diff != "" {
+		t.Fatalf("scheduled: %v", diff)
+	}
+}
@@ -0,0 +1,111 @@
+package webhooks
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	gh "github.com/google/go-github/v28/github"
+	"github.com/inconshreveable/log15"
+	"github.com/sourcegraph/sourcegraph/cmd/repo-updater/repos"
+	edb "github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/db"
+	bbs "github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// BitbucketServerWebhook receives permission events sent by the Sourcegraph
+// Bitbucket Server plugin and schedules permissions syncing of the affected
+// users and repositories. All other events are passed on to Next.
+type BitbucketServerWebhook struct {
+	*Webhook
+}
+
+func NewBitbucketServerWebhook(repos repos.Store, perms *edb.PermsStore, next http.Handler) *BitbucketServerWebhook {
+	return &BitbucketServerWebhook{&Webhook{Repos: repos, Perms: perms, Next: next, ServiceType: bbs.ServiceType}}
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *BitbucketServerWebhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(bbs.WebhookEventType(r), "permission:") {
+		if h.Next != nil {
+			h.Next.ServeHTTP(w, r)
+		}
+		return
+	}
+
+	e, extSvc, httpErr := h.parseEvent(r)
+	if httpErr != nil {
+		respondError(w, httpErr.code, httpErr)
+		return
+	}
+
+	if err := h.schedule(r.Context(), extSvc, h.convertEvent(e)); err != nil {
+		respondError(w, http.StatusInternalServerError, err)
+	}
+}
+
+func (h *BitbucketServerWebhook) parseEvent(r *http.Request) (interface{}, *repos.ExternalService, *httpError) {
+	payload, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, nil, &httpError{http.StatusInternalServerError, err}
+	}
+
+	args := repos.StoreListExternalServicesArgs{Kinds: []string{"BITBUCKETSERVER"}}
+	es, err := h.Repos.ListExternalServices(r.Context(), args)
+	if err != nil {
+		return nil, nil, &httpError{http.StatusInternalServerError, err}
+	}
+
+	sig := r.Header.Get("X-Hub-Signature")
+
+	var extSvc *repos.ExternalService
+	for _, e := range es {
+		c, _ := e.Configuration()
+		con, ok := c.(*schema.BitbucketServerConnection)
+		if !ok {
+			continue
+		}
+
+		if secret := con.WebhookSecret(); secret != "" {
+			if err = gh.ValidateSignature(sig, payload, []byte(secret)); err == nil {
+				extSvc = e
+				break
+			}
+		}
+	}
+
+	if extSvc == nil {
+		return nil, nil, &httpError{http.StatusUnauthorized, err}
+	}
+
+	e, err := bbs.ParseWebhookEvent(bbs.WebhookEventType(r), payload)
+	if err != nil {
+		return nil, nil, &httpError{http.StatusBadRequest, err}
+	}
+	return e, extSvc, nil
+}
+
+// convertEvent returns the accounts and repositories whose permissions may have
+// changed by the given event. Repository permissions affect the repository,
+// and the user if granted to a user rather than a group. Project and global
+// permissions of a user affect the user only, since they may cover any number
+// of repositories. Project and global permissions of groups are left to the
+// background permissions syncing.
+func (h *BitbucketServerWebhook) convertEvent(theirs interface{}) (a affected) {
+	log15.Debug("Bitbucket Server permissions webhook received", "type", fmt.Sprintf("%T", theirs))
+
+	e, ok := theirs.(*bbs.PermissionEvent)
+	if !ok {
+		return a
+	}
+
+	if e.User != nil && e.User.ID != 0 {
+		a.accountIDs = append(a.accountIDs, strconv.Itoa(e.User.ID))
+	}
+	if e.Repository != nil && e.Repository.ID != 0 {
+		a.repoIDs = append(a.repoIDs, strconv.Itoa(e.Repository.ID))
+	}
+	return a
+}
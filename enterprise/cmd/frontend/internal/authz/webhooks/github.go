@@ -0,0 +1,152 @@
+package webhooks
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	gh "github.com/google/go-github/v28/github"
+	"github.com/inconshreveable/log15"
+	"github.com/sourcegraph/sourcegraph/cmd/repo-updater/repos"
+	edb "github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// GitHubWebhook receives GitHub organization, team and collaborator events and
+// schedules permissions syncing of the affected users and repositories. All
+// other events are passed on to Next.
+type GitHubWebhook struct {
+	*Webhook
+}
+
+func NewGitHubWebhook(repos repos.Store, perms *edb.PermsStore, next http.Handler) *GitHubWebhook {
+	return &GitHubWebhook{&Webhook{Repos: repos, Perms: perms, Next: next, ServiceType: github.ServiceType}}
+}
+
+// githubPermsEvents is the set of GitHub event types that may change
+// permissions.
+var githubPermsEvents = map[string]bool{
+	"member":       true,
+	"membership":   true,
+	"organization": true,
+	"repository":   true,
+	"team":         true,
+	"team_add":     true,
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *GitHubWebhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !githubPermsEvents[gh.WebHookType(r)] {
+		if h.Next != nil {
+			h.Next.ServeHTTP(w, r)
+		}
+		return
+	}
+
+	e, extSvc, httpErr := h.parseEvent(r)
+	if httpErr != nil {
+		respondError(w, httpErr.code, httpErr)
+		return
+	}
+
+	if err := h.schedule(r.Context(), extSvc, h.convertEvent(e)); err != nil {
+		respondError(w, http.StatusInternalServerError, err)
+	}
+}
+
+func (h *GitHubWebhook) parseEvent(r *http.Request) (interface{}, *repos.ExternalService, *httpError) {
+	payload, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, nil, &httpError{http.StatusInternalServerError, err}
+	}
+
+	// 🚨 SECURITY: Try to authenticate the request with any of the stored secrets
+	// in GitHub external services config. If there are no secrets or no secret
+	// managed to authenticate the request, we return a 401 to the client.
+	args := repos.StoreListExternalServicesArgs{Kinds: []string{"GITHUB"}}
+	es, err := h.Repos.ListExternalServices(r.Context(), args)
+	if err != nil {
+		return nil, nil, &httpError{http.StatusInternalServerError, err}
+	}
+
+	sig := r.Header.Get("X-Hub-Signature")
+
+	var extSvc *repos.ExternalService
+	for _, e := range es {
+		c, _ := e.Configuration()
+		con, ok := c.(*schema.GitHubConnection)
+		if !ok {
+			continue
+		}
+
+		for _, hook := range con.Webhooks {
+			if hook.Secret == "" {
+				continue
+			}
+
+			if err = gh.ValidateSignature(sig, payload, []byte(hook.Secret)); err == nil {
+				extSvc = e
+				break
+			}
+		}
+		if extSvc != nil {
+			break
+		}
+	}
+
+	if extSvc == nil {
+		return nil, nil, &httpError{http.StatusUnauthorized, err}
+	}
+
+	e, err := gh.ParseWebHook(gh.WebHookType(r), payload)
+	if err != nil {
+		return nil, nil, &httpError{http.StatusBadRequest, err}
+	}
+	return e, extSvc, nil
+}
+
+// convertEvent returns the accounts and repositories whose permissions may have
+// changed by the given event. Changes to team membership affect the member,
+// while changes to the repositories of a team affect the repository.
+func (h *GitHubWebhook) convertEvent(theirs interface{}) (a affected) {
+	log15.Debug("GitHub permissions webhook received", "type", fmt.Sprintf("%T", theirs))
+
+	addAccount := func(u *gh.User) {
+		if u.GetID() != 0 {
+			a.accountIDs = append(a.accountIDs, strconv.FormatInt(u.GetID(), 10))
+		}
+	}
+	addRepo := func(r *gh.Repository) {
+		if r.GetNodeID() != "" {
+			a.repoIDs = append(a.repoIDs, r.GetNodeID())
+		}
+	}
+
+	switch e := theirs.(type) {
+	case *gh.MembershipEvent:
+		addAccount(e.Member)
+	case *gh.OrganizationEvent:
+		switch e.GetAction() {
+		case "member_added", "member_removed":
+			addAccount(e.GetMembership().GetUser())
+		}
+	case *gh.MemberEvent:
+		addAccount(e.Member)
+		addRepo(e.Repo)
+	case *gh.TeamEvent:
+		switch e.GetAction() {
+		case "added_to_repository", "removed_from_repository", "edited", "deleted":
+			addRepo(e.Repo)
+		}
+	case *gh.TeamAddEvent:
+		addRepo(e.Repo)
+	case *gh.RepositoryEvent:
+		switch e.GetAction() {
+		case "privatized", "publicized", "transferred":
+			addRepo(e.Repo)
+		}
+	}
+	return a
+}
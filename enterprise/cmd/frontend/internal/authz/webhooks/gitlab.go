@@ -0,0 +1,121 @@
+package webhooks
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/inconshreveable/log15"
+	"github.com/sourcegraph/sourcegraph/cmd/repo-updater/repos"
+	edb "github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// GitLabWebhook receives GitLab system hook and group member hook events about
+// project and group membership, and schedules permissions syncing of the
+// affected users and repositories. All other events are passed on to Next.
+type GitLabWebhook struct {
+	*Webhook
+}
+
+func NewGitLabWebhook(repos repos.Store, perms *edb.PermsStore, next http.Handler) *GitLabWebhook {
+	return &GitLabWebhook{&Webhook{Repos: repos, Perms: perms, Next: next, ServiceType: gitlab.ServiceType}}
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *GitLabWebhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch gitlab.WebhookEventType(r) {
+	case "System Hook", "Member Hook":
+	default:
+		if h.Next != nil {
+			h.Next.ServeHTTP(w, r)
+		}
+		return
+	}
+
+	e, extSvc, httpErr := h.parseEvent(r)
+	if httpErr != nil {
+		respondError(w, httpErr.code, httpErr)
+		return
+	}
+
+	if err := h.schedule(r.Context(), extSvc, h.convertEvent(e)); err != nil {
+		respondError(w, http.StatusInternalServerError, err)
+	}
+}
+
+func (h *GitLabWebhook) parseEvent(r *http.Request) (interface{}, *repos.ExternalService, *httpError) {
+	payload, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, nil, &httpError{http.StatusInternalServerError, err}
+	}
+
+	// 🚨 SECURITY: GitLab sends the webhook secret as is in a header, so we
+	// authenticate the request by comparing it with the secrets in the GitLab
+	// external services config. If there are no secrets or none of them
+	// matches, we return a 401 to the client.
+	args := repos.StoreListExternalServicesArgs{Kinds: []string{"GITLAB"}}
+	es, err := h.Repos.ListExternalServices(r.Context(), args)
+	if err != nil {
+		return nil, nil, &httpError{http.StatusInternalServerError, err}
+	}
+
+	token := gitlab.WebhookToken(r)
+
+	var extSvc *repos.ExternalService
+	for _, e := range es {
+		c, _ := e.Configuration()
+		con, ok := c.(*schema.GitLabConnection)
+		if !ok {
+			continue
+		}
+
+		for _, hook := range con.Webhooks {
+			if hook.Secret == "" {
+				continue
+			}
+
+			if subtle.ConstantTimeCompare([]byte(token), []byte(hook.Secret)) == 1 {
+				extSvc = e
+				break
+			}
+		}
+		if extSvc != nil {
+			break
+		}
+	}
+
+	if extSvc == nil {
+		return nil, nil, &httpError{http.StatusUnauthorized, nil}
+	}
+
+	e, err := gitlab.ParseWebhookEvent(gitlab.WebhookEventType(r), payload)
+	if err != nil {
+		return nil, nil, &httpError{http.StatusBadRequest, err}
+	}
+	return e, extSvc, nil
+}
+
+// convertEvent returns the accounts and repositories whose permissions may have
+// changed by the given event. A change to the members of a project affects
+// both the user and the project, while a change to the members of a group
+// affects the user only: the group may contain any number of projects.
+func (h *GitLabWebhook) convertEvent(theirs interface{}) (a affected) {
+	log15.Debug("GitLab permissions webhook received", "type", fmt.Sprintf("%T", theirs))
+
+	e, ok := theirs.(*gitlab.MemberEvent)
+	if !ok {
+		return a
+	}
+
+	if e.UserID != 0 {
+		a.accountIDs = append(a.accountIDs, strconv.Itoa(e.UserID))
+	}
+	if e.IsProjectEvent() && e.ProjectID != 0 {
+		a.repoIDs = append(a.repoIDs, strconv.Itoa(e.ProjectID))
+	}
+	return a
+}
@@ -0,0 +1,148 @@
+// Package webhooks receives code host webhook events that change who has access
+// to which repositories, and schedules permissions syncing of just the affected
+// users and repositories instead of waiting for the background syncing to get
+// to them.
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/inconshreveable/log15"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/repo-updater/repos"
+	edb "github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/repoupdater"
+	"github.com/sourcegraph/sourcegraph/internal/repoupdater/protocol"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// Webhook contains the dependencies shared by the permissions webhook handlers
+// of all code hosts.
+type Webhook struct {
+	Repos repos.Store
+	Perms *edb.PermsStore
+
+	// Next handles the events that are not relevant to permissions, e.g. the
+	// campaigns webhook of the same code host.
+	Next http.Handler
+
+	// ServiceType corresponds to api.ExternalRepoSpec.ServiceType
+	ServiceType string
+}
+
+// affected is the set of code host accounts and repositories whose permissions
+// have changed by an event, identified by their IDs on the code host.
+type affected struct {
+	accountIDs []string
+	repoIDs    []string
+}
+
+func (a affected) empty() bool {
+	return len(a.accountIDs) == 0 && len(a.repoIDs) == 0
+}
+
+// schedule schedules permissions syncing for the users and repositories on
+// Sourcegraph that correspond to the affected accounts and repositories of the
+// given external service.
+func (h *Webhook) schedule(ctx context.Context, extSvc *repos.ExternalService, a affected) error {
+	if a.empty() {
+		return nil
+	}
+
+	serviceID, err := extractExternalServiceID(extSvc)
+	if err != nil {
+		return err
+	}
+
+	var req protocol.PermsSyncRequest
+	if len(a.accountIDs) > 0 {
+		ids, err := h.Perms.GetUserIDsByExternalAccounts(ctx, &extsvc.Accounts{
+			ServiceType: h.ServiceType,
+			ServiceID:   serviceID,
+			AccountIDs:  a.accountIDs,
+		})
+		if err != nil {
+			return errors.Wrap(err, "get user IDs by external accounts")
+		}
+		for _, id := range ids {
+			req.UserIDs = append(req.UserIDs, id)
+		}
+		sort.Slice(req.UserIDs, func(i, j int) bool { return req.UserIDs[i] < req.UserIDs[j] })
+	}
+
+	if len(a.repoIDs) > 0 {
+		specs := make([]api.ExternalRepoSpec, len(a.repoIDs))
+		for i, id := range a.repoIDs {
+			specs[i] = api.ExternalRepoSpec{
+				ID:          id,
+				ServiceType: h.ServiceType,
+				ServiceID:   serviceID,
+			}
+		}
+		rs, err := h.Repos.ListRepos(ctx, repos.StoreListReposArgs{ExternalRepos: specs})
+		if err != nil {
+			return errors.Wrap(err, "list repos")
+		}
+		for _, r := range rs {
+			req.RepoIDs = append(req.RepoIDs, r.ID)
+		}
+	}
+
+	if len(req.UserIDs) == 0 && len(req.RepoIDs) == 0 {
+		log15.Debug("Permissions webhook event did not match any users or repositories", "serviceType", h.ServiceType)
+		return nil
+	}
+
+	log15.Debug("Scheduling permissions sync from webhook", "serviceType", h.ServiceType, "users", req.UserIDs, "repos", req.RepoIDs)
+	return repoupdater.DefaultClient.SchedulePermsSync(ctx, req)
+}
+
+func extractExternalServiceID(extSvc *repos.ExternalService) (string, error) {
+	c, err := extSvc.Configuration()
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to get external service config")
+	}
+
+	var serviceID string
+	switch c := c.(type) {
+	case *schema.GitHubConnection:
+		serviceID = c.Url
+	case *schema.BitbucketServerConnection:
+		serviceID = c.Url
+	case *schema.GitLabConnection:
+		serviceID = c.Url
+	}
+	if serviceID == "" {
+		return "", errors.New("could not determine service id")
+	}
+
+	u, err := url.Parse(serviceID)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to parse service ID")
+	}
+
+	return extsvc.NormalizeBaseURL(u).String(), nil
+}
+
+type httpError struct {
+	code int
+	err  error
+}
+
+func (e httpError) Error() string {
+	if e.err != nil {
+		return fmt.Sprintf("HTTP %d: %v", e.code, e.err)
+	}
+	return fmt.Sprintf("HTTP %d: %s", e.code, http.StatusText(e.code))
+}
+
+func respondError(w http.ResponseWriter, code int, err error) {
+	log15.Error(err.Error())
+	http.Error(w, err.Error(), code)
+}
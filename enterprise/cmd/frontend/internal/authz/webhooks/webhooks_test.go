@@ -0,0 +1,243 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	gh "github.com/google/go-github/v28/github"
+	"github.com/sourcegraph/sourcegraph/cmd/repo-updater/repos"
+	edb "github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	bbs "github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
+	"github.com/sourcegraph/sourcegraph/internal/repoupdater"
+	"github.com/sourcegraph/sourcegraph/internal/repoupdater/protocol"
+)
+
+func TestGitHubWebhook(t *testing.T) {
+	ctx := context.Background()
+	store := new(repos.FakeStore)
+
+	err := store.UpsertExternalServices(ctx, &repos.ExternalService{
+		Kind:        "GITHUB",
+		DisplayName: "GitHub",
+		Config:      `{"url": "https://github.com", "token": "abc", "repos": ["owner/name"], "webhooks": [{"org": "owner", "secret": "s3cr3t"}]}`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.UpsertRepos(ctx, &repos.Repo{
+		Name: "github.com/owner/name",
+		ExternalRepo: api.ExternalRepoSpec{
+			ID:          "MDEwOlJlcG9zaXRvcnky",
+			ServiceType: github.ServiceType,
+			ServiceID:   "https://github.com/",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs, err := store.ListRepos(ctx, repos.StoreListReposArgs{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edb.Mocks.Perms.GetUserIDsByExternalAccounts = func(_ context.Context, accounts *extsvc.Accounts) (map[string]int32, error) {
+		want := &extsvc.Accounts{ServiceType: github.ServiceType, ServiceID: "https://github.com/", AccountIDs: []string{"42"}}
+		if diff := cmp.Diff(want, accounts); diff != "" {
+			t.Fatalf("accounts: %v", diff)
+		}
+		return map[string]int32{"42": 7}, nil
+	}
+	var scheduled []protocol.PermsSyncRequest
+	repoupdater.MockSchedulePermsSync = func(_ context.Context, req protocol.PermsSyncRequest) error {
+		scheduled = append(scheduled, req)
+		return nil
+	}
+	defer func() {
+		edb.Mocks.Perms = edb.MockPerms{}
+		repoupdater.MockSchedulePermsSync = nil
+	}()
+
+	var nextCalled bool
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { nextCalled = true })
+	h := NewGitHubWebhook(store, edb.NewPermsStore(nil, nil), next)
+
+	post := func(event, secret, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/.api/github-webhooks", bytes.NewBufferString(body))
+		req.Header.Set("X-GitHub-Event", event)
+		mac := hmac.New(sha1.New, []byte(secret))
+		_, _ = mac.Write([]byte(body))
+		req.Header.Set("X-Hub-Signature", "sha1="+hex.EncodeToString(mac.Sum(nil)))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	const memberEvent = `{"action": "added", "member": {"id": 42}, "repository": {"node_id": "MDEwOlJlcG9zaXRvcnky"}}`
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		scheduled = nil
+		if rec := post("member", "wrong", memberEvent); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("code: want %d but got %d", http.StatusUnauthorized, rec.Code)
+		}
+		if len(scheduled) != 0 {
+			t.Fatalf("unexpected scheduled requests: %v", scheduled)
+		}
+	})
+
+	t.Run("member event", func(t *testing.T) {
+		scheduled = nil
+		if rec := post("member", "s3cr3t", memberEvent); rec.Code != http.StatusOK {
+			t.Fatalf("code: want %d but got %d: %s", http.StatusOK, rec.Code, rec.Body)
+		}
+		want := []protocol.PermsSyncRequest{{UserIDs: []int32{7}, RepoIDs: []api.RepoID{rs[0].ID}}}
+		if diff := cmp.Diff(want, scheduled); diff != "" {
+			t.Fatalf("scheduled: %v", diff)
+		}
+	})
+
+	t.Run("other events are passed on", func(t *testing.T) {
+		scheduled = nil
+		if post("pull_request", "s3cr3t", `{}`); !nextCalled {
+			t.Fatal("want next handler to be called")
+		}
+		if len(scheduled) != 0 {
+			t.Fatalf("unexpected scheduled requests: %v", scheduled)
+		}
+	})
+}
+
+func TestGitHubWebhook_convertEvent(t *testing.T) {
+	user := &gh.User{ID: gh.Int64(42)}
+	repo := &gh.Repository{NodeID: gh.String("MDEwOlJlcG9zaXRvcnky")}
+
+	tests := []struct {
+		name  string
+		event interface{}
+		want  affected
+	}{
+		{
+			name:  "team membership",
+			event: &gh.MembershipEvent{Action: gh.String("added"), Member: user},
+			want:  affected{accountIDs: []string{"42"}},
+		},
+		{
+			name:  "organization member added",
+			event: &gh.OrganizationEvent{Action: gh.String("member_added"), Membership: &gh.Membership{User: user}},
+			want:  affected{accountIDs: []string{"42"}},
+		},
+		{
+			name:  "organization renamed",
+			event: &gh.OrganizationEvent{Action: gh.String("renamed"), Membership: &gh.Membership{User: user}},
+		},
+		{
+			name:  "collaborator",
+			event: &gh.MemberEvent{Action: gh.String("removed"), Member: user, Repo: repo},
+			want:  affected{accountIDs: []string{"42"}, repoIDs: []string{"MDEwOlJlcG9zaXRvcnky"}},
+		},
+		{
+			name:  "team added to repository",
+			event: &gh.TeamEvent{Action: gh.String("added_to_repository"), Repo: repo},
+			want:  affected{repoIDs: []string{"MDEwOlJlcG9zaXRvcnky"}},
+		},
+		{
+			name:  "team created",
+			event: &gh.TeamEvent{Action: gh.String("created")},
+		},
+		{
+			name:  "team add",
+			event: &gh.TeamAddEvent{Repo: repo},
+			want:  affected{repoIDs: []string{"MDEwOlJlcG9zaXRvcnky"}},
+		},
+		{
+			name:  "repository privatized",
+			event: &gh.RepositoryEvent{Action: gh.String("privatized"), Repo: repo},
+			want:  affected{repoIDs: []string{"MDEwOlJlcG9zaXRvcnky"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := (&GitHubWebhook{}).convertEvent(test.event)
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(affected{})); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestGitLabWebhook_convertEvent(t *testing.T) {
+	tests := []struct {
+		name  string
+		event interface{}
+		want  affected
+	}{
+		{
+			name:  "project member",
+			event: &gitlab.MemberEvent{EventName: "user_add_to_team", UserID: 41, ProjectID: 74},
+			want:  affected{accountIDs: []string{"41"}, repoIDs: []string{"74"}},
+		},
+		{
+			name:  "group member",
+			event: &gitlab.MemberEvent{EventName: "user_remove_from_group", UserID: 41, GroupID: 78},
+			want:  affected{accountIDs: []string{"41"}},
+		},
+		{
+			name:  "unrelated event",
+			event: &gitlab.NoteEvent{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := (&GitLabWebhook{}).convertEvent(test.event)
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(affected{})); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestBitbucketServerWebhook_convertEvent(t *testing.T) {
+	tests := []struct {
+		name  string
+		event interface{}
+		want  affected
+	}{
+		{
+			name:  "repository permission of a user",
+			event: &bbs.PermissionEvent{User: &bbs.User{ID: 3}, Repository: &bbs.Repo{ID: 9}},
+			want:  affected{accountIDs: []string{"3"}, repoIDs: []string{"9"}},
+		},
+		{
+			name:  "repository permission of a group",
+			event: &bbs.PermissionEvent{Group: &bbs.Group{Name: "devs"}, Repository: &bbs.Repo{ID: 9}},
+			want:  affected{repoIDs: []string{"9"}},
+		},
+		{
+			name:  "project permission of a user",
+			event: &bbs.PermissionEvent{User: &bbs.User{ID: 3}, Project: &bbs.Project{ID: 1}},
+			want:  affected{accountIDs: []string{"3"}},
+		},
+		{
+			name:  "project permission of a group",
+			event: &bbs.PermissionEvent{Group: &bbs.Group{Name: "devs"}, Project: &bbs.Project{ID: 1}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := (&BitbucketServerWebhook{}).convertEvent(test.event)
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(affected{})); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
@@ -21,7 +21,9 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/repo-updater/repos"
 	_ "github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/auth"
 	eauthz "github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/authz"
+	edb "github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/db"
 	authzResolvers "github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/internal/authz/resolvers"
+	authzWebhooks "github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/internal/authz/webhooks"
 	_ "github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/internal/graphqlbackend"
 	"github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/internal/licensing"
 	_ "github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/internal/registry"
@@ -75,6 +77,7 @@ func main() {
 	repositories := repos.NewDBStore(dbconn.Global, sql.TxOptions{})
 
 	githubWebhook := campaigns.NewGitHubWebhook(campaignsStore, repositories, clock)
+	gitlabWebhook := campaigns.NewGitLabWebhook(campaignsStore, repositories, clock)
 
 	bitbucketWebhookName := "sourcegraph-" + globalState.SiteID
 	bitbucketServerWebhook := campaigns.NewBitbucketServerWebhook(
@@ -86,7 +89,14 @@ func main() {
 
 	go bitbucketServerWebhook.Upsert(30 * time.Second)
 
-	shared.Main(githubWebhook, bitbucketServerWebhook)
+	// Permissions events are handled before campaigns events since they share
+	// the same webhook endpoints.
+	permsStore := edb.NewPermsStore(dbconn.Global, clock)
+	shared.Main(
+		authzWebhooks.NewGitHubWebhook(repositories, permsStore, githubWebhook),
+		authzWebhooks.NewGitLabWebhook(repositories, permsStore, gitlabWebhook),
+		authzWebhooks.NewBitbucketServerWebhook(repositories, permsStore, bitbucketServerWebhook),
+	)
 }
 
 func initLicensing() {
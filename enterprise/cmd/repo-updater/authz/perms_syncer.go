@@ -44,6 +44,7 @@ type PermsSyncer struct {
 		permsGap     *prometheus.GaugeVec
 		syncErrors   *prometheus.CounterVec
 		syncDuration *prometheus.HistogramVec
+		syncLatency  *prometheus.HistogramVec
 		queueSize    prometheus.Gauge
 	}
 }
@@ -65,14 +66,14 @@ func NewPermsSyncer(
 }
 
 // ScheduleUsers schedules new permissions syncing requests for given users
-// in desired priority.
+// in high priority.
 //
-// This method implements the authz.PermsSyncer in the OSS namespace.
-func (s *PermsSyncer) ScheduleUsers(ctx context.Context, priority Priority, userIDs ...int32) {
+// This method implements the PermsSyncer of repoupdater.Server in the OSS namespace.
+func (s *PermsSyncer) ScheduleUsers(ctx context.Context, userIDs ...int32) {
 	users := make([]scheduledUser, len(userIDs))
 	for i := range userIDs {
 		users[i] = scheduledUser{
-			priority: priority,
+			priority: PriorityHigh,
 			userID:   userIDs[i],
 			// NOTE: Have nextSyncAt with zero value (i.e. not set) gives it higher priority,
 			// as the request is most likely triggered by a user action from OSS namespace.
@@ -97,20 +98,21 @@ func (s *PermsSyncer) scheduleUsers(ctx context.Context, users ...scheduledUser)
 			ID:         u.userID,
 			NextSyncAt: u.nextSyncAt,
 			NoPerms:    u.noPerms,
+			EnqueuedAt: s.clock(),
 		})
 		log15.Debug("PermsSyncer.queue.enqueued", "userID", u.userID, "updated", updated)
 	}
 }
 
 // ScheduleRepos schedules new permissions syncing requests for given repositories
-// in desired priority.
+// in high priority.
 //
-// This method implements the authz.PermsSyncer in the OSS namespace.
-func (s *PermsSyncer) ScheduleRepos(ctx context.Context, priority Priority, repoIDs ...api.RepoID) {
+// This method implements the PermsSyncer of repoupdater.Server in the OSS namespace.
+func (s *PermsSyncer) ScheduleRepos(ctx context.Context, repoIDs ...api.RepoID) {
 	repos := make([]scheduledRepo, len(repoIDs))
 	for i := range repoIDs {
 		repos[i] = scheduledRepo{
-			priority: priority,
+			priority: PriorityHigh,
 			repoID:   repoIDs[i],
 			// NOTE: Have nextSyncAt with zero value (i.e. not set) gives it higher priority,
 			// as the request is most likely triggered by a user action from OSS namespace.
@@ -135,6 +137,7 @@ func (s *PermsSyncer) scheduleRepos(ctx context.Context, repos ...scheduledRepo)
 			ID:         int32(r.repoID),
 			NextSyncAt: r.nextSyncAt,
 			NoPerms:    r.noPerms,
+			EnqueuedAt: s.clock(),
 		})
 		log15.Debug("PermsSyncer.queue.enqueued", "repoID", r.repoID, "updated", updated)
 	}
@@ -383,9 +386,37 @@ func (s *PermsSyncer) runSync(ctx context.Context) {
 			log15.Warn("Failed to sync permissions", "type", request.Type, "id", request.ID, "err", err)
 			continue
 		}
+		s.observeLatency(request.requestMeta)
 	}
 }
 
+// observeLatency records the time between a request became due (i.e. was
+// enqueued, or reached its NextSyncAt if that is later) and its completion.
+func (s *PermsSyncer) observeLatency(meta *requestMeta) {
+	dueAt := meta.EnqueuedAt
+	if meta.NextSyncAt.After(dueAt) {
+		dueAt = meta.NextSyncAt
+	}
+	if dueAt.IsZero() {
+		return
+	}
+
+	var typLabel string
+	switch meta.Type {
+	case requestTypeRepo:
+		typLabel = "repo"
+	case requestTypeUser:
+		typLabel = "user"
+	default:
+		return
+	}
+	priorityLabel := "low"
+	if meta.Priority == PriorityHigh {
+		priorityLabel = "high"
+	}
+	s.metrics.syncLatency.WithLabelValues(typLabel, priorityLabel).Observe(s.clock().Sub(dueAt).Seconds())
+}
+
 // scheduleUsersWithNoPerms returns computed schedules for users who have no permissions
 // found in database.
 func (s *PermsSyncer) scheduleUsersWithNoPerms(ctx context.Context) ([]scheduledUser, error) {
@@ -676,6 +707,13 @@ func (s *PermsSyncer) registerMetrics() {
 		Help:      "Time spent on syncing permissions",
 		Buckets:   []float64{1, 2, 5, 10, 30, 60, 120},
 	}, []string{"type", "success"})
+	s.metrics.syncLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "src",
+		Subsystem: "repoupdater",
+		Name:      "perms_syncer_sync_latency_seconds",
+		Help:      "Time between a permissions syncing request was due and it was completed",
+		Buckets:   []float64{1, 5, 10, 30, 60, 300, 900, 3600, 21600},
+	}, []string{"type", "priority"})
 	s.metrics.syncErrors = promauto.NewCounterVec(prometheus.CounterOpts{
 		Namespace: "src",
 		Subsystem: "repoupdater",
@@ -19,14 +19,16 @@ import (
 )
 
 func TestPermsSyncer_ScheduleUsers(t *testing.T) {
-	s := NewPermsSyncer(nil, nil, nil)
-	s.ScheduleUsers(context.Background(), PriorityHigh, 1)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	s := NewPermsSyncer(nil, nil, func() time.Time { return now })
+	s.ScheduleUsers(context.Background(), 1)
 
 	expHeap := []*syncRequest{
 		{requestMeta: &requestMeta{
-			Priority: PriorityHigh,
-			Type:     requestTypeUser,
-			ID:       1,
+			Priority:   PriorityHigh,
+			Type:       requestTypeUser,
+			ID:         1,
+			EnqueuedAt: now,
 		}, acquired: false, index: 0},
 	}
 	if diff := cmp.Diff(expHeap, s.queue.heap, cmpOpts); diff != "" {
@@ -35,14 +37,16 @@ func TestPermsSyncer_ScheduleUsers(t *testing.T) {
 }
 
 func TestPermsSyncer_ScheduleRepos(t *testing.T) {
-	s := NewPermsSyncer(nil, nil, nil)
-	s.ScheduleRepos(context.Background(), PriorityHigh, 1)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	s := NewPermsSyncer(nil, nil, func() time.Time { return now })
+	s.ScheduleRepos(context.Background(), 1)
 
 	expHeap := []*syncRequest{
 		{requestMeta: &requestMeta{
-			Priority: PriorityHigh,
-			Type:     requestTypeRepo,
-			ID:       1,
+			Priority:   PriorityHigh,
+			Type:       requestTypeRepo,
+			ID:         1,
+			EnqueuedAt: now,
 		}, acquired: false, index: 0},
 	}
 	if diff := cmp.Diff(expHeap, s.queue.heap, cmpOpts); diff != "" {
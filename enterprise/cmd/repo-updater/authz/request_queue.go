@@ -41,6 +41,9 @@ type requestMeta struct {
 	ID         int32
 	NextSyncAt time.Time
 	NoPerms    bool
+	// EnqueuedAt is the time when the request was first enqueued, it is used to
+	// measure the latency of permissions syncing.
+	EnqueuedAt time.Time
 }
 
 // syncRequest is a permissions syncing request with its current status in the queue.
@@ -121,6 +124,11 @@ func (q *requestQueue) enqueue(meta *requestMeta) (updated bool) {
 		return false
 	}
 
+	// Keep the time of the first enqueue so latency is measured from the
+	// earliest request.
+	if !request.EnqueuedAt.IsZero() && (meta.EnqueuedAt.IsZero() || request.EnqueuedAt.Before(meta.EnqueuedAt)) {
+		meta.EnqueuedAt = request.EnqueuedAt
+	}
 	request.requestMeta = meta
 	heap.Fix(q, request.index)
 	notify(q.notifyEnqueue)
@@ -231,6 +231,22 @@ func Test_requestQueue_enqueue(t *testing.T) {
 	}
 }
 
+func Test_requestQueue_enqueue_keepsEnqueuedAt(t *testing.T) {
+	first := time.Now().Add(-time.Hour)
+	q := newRequestQueue()
+	q.enqueue(&requestMeta{Priority: PriorityLow, Type: requestTypeUser, ID: 1, EnqueuedAt: first})
+	if !q.enqueue(&requestMeta{Priority: PriorityHigh, Type: requestTypeUser, ID: 1, EnqueuedAt: time.Now()}) {
+		t.Fatal("want request to be updated")
+	}
+
+	if got := q.heap[0].EnqueuedAt; !got.Equal(first) {
+		t.Fatalf("EnqueuedAt: want %v but got %v", first, got)
+	}
+	if got := q.heap[0].Priority; got != PriorityHigh {
+		t.Fatalf("Priority: want %v but got %v", PriorityHigh, got)
+	}
+}
+
 func Test_requestQueue_remove(t *testing.T) {
 	repo1 := &requestMeta{Type: requestTypeRepo, ID: 1}
 	repo1Key := requestQueueKey{typ: requestTypeRepo, id: 1}
@@ -78,6 +78,9 @@ func enterpriseInit(
 	permsStore := frontendDB.NewPermsStore(db, clock)
 	permsSyncer := authz.NewPermsSyncer(repoStore, permsStore, clock)
 	go startBackgroundPermsSync(ctx, permsSyncer, db)
+	if server != nil {
+		server.PermsSyncer = permsSyncer
+	}
 	debugDumpers = append(debugDumpers, permsSyncer)
 
 	return debugDumpers
@@ -56,12 +56,18 @@ func (ce ChangesetEvents) State() cmpgn.ChangesetState {
 	state := cmpgn.ChangesetStateOpen
 	for _, e := range ce {
 		switch e.Kind {
-		case cmpgn.ChangesetEventKindGitHubClosed, cmpgn.ChangesetEventKindBitbucketServerDeclined:
+		case cmpgn.ChangesetEventKindGitHubClosed,
+			cmpgn.ChangesetEventKindBitbucketServerDeclined,
+			cmpgn.ChangesetEventKindGitLabClosed:
 			state = cmpgn.ChangesetStateClosed
-		case cmpgn.ChangesetEventKindGitHubMerged, cmpgn.ChangesetEventKindBitbucketServerMerged:
+		case cmpgn.ChangesetEventKindGitHubMerged,
+			cmpgn.ChangesetEventKindBitbucketServerMerged,
+			cmpgn.ChangesetEventKindGitLabMerged:
 			// Merged is a final state. We can ignore everything after.
 			return cmpgn.ChangesetStateMerged
-		case cmpgn.ChangesetEventKindGitHubReopened, cmpgn.ChangesetEventKindBitbucketServerReopened:
+		case cmpgn.ChangesetEventKindGitHubReopened,
+			cmpgn.ChangesetEventKindBitbucketServerReopened,
+			cmpgn.ChangesetEventKindGitLabReopened:
 			state = cmpgn.ChangesetStateOpen
 		}
 	}
@@ -166,7 +166,8 @@ func computeCounts(c *ChangesetCounts, csEvents Events) error {
 
 		switch e.Type() {
 		case campaigns.ChangesetEventKindGitHubClosed,
-			campaigns.ChangesetEventKindBitbucketServerDeclined:
+			campaigns.ChangesetEventKindBitbucketServerDeclined,
+			campaigns.ChangesetEventKindGitLabClosed:
 
 			c.Open--
 			c.Closed++
@@ -175,7 +176,8 @@ func computeCounts(c *ChangesetCounts, csEvents Events) error {
 			c.AddReviewState(currentReviewState, -1)
 
 		case campaigns.ChangesetEventKindGitHubReopened,
-			campaigns.ChangesetEventKindBitbucketServerReopened:
+			campaigns.ChangesetEventKindBitbucketServerReopened,
+			campaigns.ChangesetEventKindGitLabReopened:
 
 			c.Open++
 			c.Closed--
@@ -184,7 +186,8 @@ func computeCounts(c *ChangesetCounts, csEvents Events) error {
 			c.AddReviewState(currentReviewState, 1)
 
 		case campaigns.ChangesetEventKindGitHubMerged,
-			campaigns.ChangesetEventKindBitbucketServerMerged:
+			campaigns.ChangesetEventKindBitbucketServerMerged,
+			campaigns.ChangesetEventKindGitLabMerged:
 
 			// If it was closed, all "review counts" have been updated by the
 			// closed events and we just need to reverse these two counts
@@ -205,7 +208,8 @@ func computeCounts(c *ChangesetCounts, csEvents Events) error {
 
 		case campaigns.ChangesetEventKindGitHubReviewed,
 			campaigns.ChangesetEventKindBitbucketServerApproved,
-			campaigns.ChangesetEventKindBitbucketServerReviewed:
+			campaigns.ChangesetEventKindBitbucketServerReviewed,
+			campaigns.ChangesetEventKindGitLabApproved:
 
 			s, err := reviewState(e)
 			if err != nil {
@@ -250,7 +254,8 @@ func computeCounts(c *ChangesetCounts, csEvents Events) error {
 				c.AddReviewState(newReviewState, 1)
 			}
 
-		case campaigns.ChangesetEventKindBitbucketServerUnapproved:
+		case campaigns.ChangesetEventKindBitbucketServerUnapproved,
+			campaigns.ChangesetEventKindGitLabUnapproved:
 			// We specifically ignore ChangesetEventKindGitHubReviewDismissed
 			// events since GitHub updates the original
 			// ChangesetEventKindGitHubReviewed event when a review has been
@@ -264,12 +269,13 @@ func computeCounts(c *ChangesetCounts, csEvents Events) error {
 				continue
 			}
 
-			if e.Type() == campaigns.ChangesetEventKindBitbucketServerUnapproved {
-				// A BitbucketServer Unapproved can only follow a previous Approved by
-				// the same author.
+			if e.Type() == campaigns.ChangesetEventKindBitbucketServerUnapproved ||
+				e.Type() == campaigns.ChangesetEventKindGitLabUnapproved {
+				// A BitbucketServer or GitLab Unapproved can only follow a
+				// previous Approved by the same author.
 				lastReview, ok := lastReviewByAuthor[author]
 				if !ok || lastReview != campaigns.ChangesetReviewStateApproved {
-					log15.Warn("Bitbucket Server Unapproval not following an Approval", "event", e)
+					log15.Warn("Unapproval not following an Approval", "event", e)
 					continue
 				}
 			}
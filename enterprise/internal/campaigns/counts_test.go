@@ -9,6 +9,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/campaigns"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
 )
 
 func TestCalcCounts(t *testing.T) {
@@ -1182,6 +1183,84 @@ func TestCalcCounts(t *testing.T) {
 				{Time: daysAgo(0), Total: 1, Open: 1, OpenPending: 1},
 			},
 		},
+		{
+			codehosts: "gitlab",
+			name:      "single changeset open, approved, closed, reopened, merged",
+			changesets: []*campaigns.Changeset{
+				glChangeset(1, daysAgo(5)),
+			},
+			start: daysAgo(6),
+			events: []Event{
+				glNote(1, daysAgo(4), "user1", campaigns.ChangesetEventKindGitLabApproved),
+				glNote(1, daysAgo(3), "user2", campaigns.ChangesetEventKindGitLabClosed),
+				glNote(1, daysAgo(2), "user2", campaigns.ChangesetEventKindGitLabReopened),
+				glNote(1, daysAgo(1), "user2", campaigns.ChangesetEventKindGitLabMerged),
+			},
+			want: []*ChangesetCounts{
+				{Time: daysAgo(6), Total: 0, Open: 0},
+				{Time: daysAgo(5), Total: 1, Open: 1, OpenPending: 1},
+				{Time: daysAgo(4), Total: 1, Open: 1, OpenApproved: 1},
+				{Time: daysAgo(3), Total: 1, Closed: 1},
+				{Time: daysAgo(2), Total: 1, Open: 1, OpenApproved: 1},
+				{Time: daysAgo(1), Total: 1, Merged: 1},
+				{Time: daysAgo(0), Total: 1, Merged: 1},
+			},
+		},
+		{
+			codehosts: "gitlab",
+			name:      "single changeset open, closed, merged",
+			changesets: []*campaigns.Changeset{
+				glChangeset(1, daysAgo(3)),
+			},
+			start: daysAgo(3),
+			events: []Event{
+				glNote(1, daysAgo(2), "user1", campaigns.ChangesetEventKindGitLabClosed),
+				glNote(1, daysAgo(1), "user1", campaigns.ChangesetEventKindGitLabMerged),
+			},
+			want: []*ChangesetCounts{
+				{Time: daysAgo(3), Total: 1, Open: 1, OpenPending: 1},
+				{Time: daysAgo(2), Total: 1, Closed: 1},
+				{Time: daysAgo(1), Total: 1, Merged: 1},
+				{Time: daysAgo(0), Total: 1, Merged: 1},
+			},
+		},
+		{
+			codehosts: "gitlab",
+			name:      "single changeset open, approved by two, unapproved by one",
+			changesets: []*campaigns.Changeset{
+				glChangeset(1, daysAgo(4)),
+			},
+			start: daysAgo(4),
+			events: []Event{
+				glNote(1, daysAgo(3), "user1", campaigns.ChangesetEventKindGitLabApproved),
+				glNote(1, daysAgo(2), "user2", campaigns.ChangesetEventKindGitLabApproved),
+				glNote(1, daysAgo(1), "user1", campaigns.ChangesetEventKindGitLabUnapproved),
+				glNote(1, daysAgo(0), "user2", campaigns.ChangesetEventKindGitLabUnapproved),
+			},
+			want: []*ChangesetCounts{
+				{Time: daysAgo(4), Total: 1, Open: 1, OpenPending: 1},
+				{Time: daysAgo(3), Total: 1, Open: 1, OpenApproved: 1},
+				{Time: daysAgo(2), Total: 1, Open: 1, OpenApproved: 1},
+				{Time: daysAgo(1), Total: 1, Open: 1, OpenApproved: 1},
+				{Time: daysAgo(0), Total: 1, Open: 1, OpenPending: 1},
+			},
+		},
+		{
+			codehosts: "gitlab",
+			name:      "single changeset open, unapproved without approval",
+			changesets: []*campaigns.Changeset{
+				glChangeset(1, daysAgo(2)),
+			},
+			start: daysAgo(2),
+			events: []Event{
+				glNote(1, daysAgo(1), "user1", campaigns.ChangesetEventKindGitLabUnapproved),
+			},
+			want: []*ChangesetCounts{
+				{Time: daysAgo(2), Total: 1, Open: 1, OpenPending: 1},
+				{Time: daysAgo(1), Total: 1, Open: 1, OpenPending: 1},
+				{Time: daysAgo(0), Total: 1, Open: 1, OpenPending: 1},
+			},
+		},
 	}
 
 	for _, tc := range tests {
@@ -1278,3 +1357,29 @@ func bbsActivity(id int64, t time.Time, username string, kind campaigns.Changese
 		},
 	}
 }
+
+func glChangeset(id int64, t time.Time) *campaigns.Changeset {
+	return &campaigns.Changeset{ID: id, Metadata: &gitlab.MergeRequest{CreatedAt: t}}
+}
+
+func glNote(id int64, t time.Time, username string, kind campaigns.ChangesetEventKind) *campaigns.ChangesetEvent {
+	note := gitlab.Note{CreatedAt: t, UpdatedAt: t, Author: gitlab.User{Username: username}, System: true}
+
+	var meta interface{}
+	switch kind {
+	case campaigns.ChangesetEventKindGitLabApproved:
+		meta = &gitlab.ReviewApprovedEvent{Note: note}
+	case campaigns.ChangesetEventKindGitLabUnapproved:
+		meta = &gitlab.ReviewUnapprovedEvent{Note: note}
+	case campaigns.ChangesetEventKindGitLabClosed:
+		meta = &gitlab.MergeRequestClosedEvent{Note: note}
+	case campaigns.ChangesetEventKindGitLabReopened:
+		meta = &gitlab.MergeRequestReopenedEvent{Note: note}
+	case campaigns.ChangesetEventKindGitLabMerged:
+		meta = &gitlab.MergeRequestMergedEvent{Note: note}
+	default:
+		meta = &note
+	}
+
+	return &campaigns.ChangesetEvent{ChangesetID: id, Kind: kind, Metadata: meta}
+}
@@ -586,6 +586,53 @@ func (r *Resolver) CreatePatchSetFromPatches(ctx context.Context, args graphqlba
 	return &patchSetResolver{store: r.store, patchSet: patchSet}, nil
 }
 
+func (r *Resolver) CreatePatchSetFromCodemod(ctx context.Context, args graphqlbackend.CreatePatchSetFromCodemodArgs) (_ graphqlbackend.PatchSetResolver, err error) {
+	tr, ctx := trace.New(ctx, "Resolver.CreatePatchSetFromCodemod", args.Query)
+	defer func() {
+		tr.SetError(err)
+		tr.Finish()
+	}()
+
+	// 🚨 SECURITY: Only site admins may create patch sets for now.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	user, err := backend.CurrentUser(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%v", backend.ErrNotAuthenticated)
+	}
+	if user == nil {
+		return nil, backend.ErrNotAuthenticated
+	}
+
+	codemodPatches, err := graphqlbackend.CodemodPatches(ctx, args.Query)
+	if err != nil {
+		return nil, err
+	}
+	if len(codemodPatches) == 0 {
+		return nil, errors.New("the query does not rewrite any files")
+	}
+
+	patches := make([]*campaigns.Patch, len(codemodPatches))
+	for i, p := range codemodPatches {
+		patches[i] = &campaigns.Patch{
+			RepoID:  p.Repo.ID,
+			Rev:     p.BaseRevision,
+			BaseRef: p.BaseRef,
+			Diff:    p.Diff,
+		}
+	}
+
+	svc := ee.NewService(r.store, gitserver.DefaultClient, r.httpFactory)
+	patchSet, err := svc.CreatePatchSetFromPatches(ctx, patches, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &patchSetResolver{store: r.store, patchSet: patchSet}, nil
+}
+
 func (r *Resolver) CloseCampaign(ctx context.Context, args *graphqlbackend.CloseCampaignArgs) (_ graphqlbackend.CampaignResolver, err error) {
 	tr, ctx := trace.New(ctx, "Resolver.CloseCampaign", fmt.Sprintf("Campaign: %q", args.Campaign))
 	defer func() {
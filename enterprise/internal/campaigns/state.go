@@ -11,6 +11,7 @@ import (
 	cmpgn "github.com/sourcegraph/sourcegraph/internal/campaigns"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
 )
 
 // SetDerivedState will update the external state fields on the Changeset based
@@ -43,6 +44,9 @@ func ComputeCheckState(c *cmpgn.Changeset, events ChangesetEvents) cmpgn.Changes
 
 	case *bitbucketserver.PullRequest:
 		return computeBitbucketBuildStatus(c.UpdatedAt, m, events)
+
+	case *gitlab.MergeRequest:
+		return computeGitLabPipelineState(m, events)
 	}
 
 	return cmpgn.ChangesetCheckStateUnknown
@@ -68,9 +72,9 @@ func ComputeReviewState(c *cmpgn.Changeset, events ChangesetEvents) (cmpgn.Chang
 		return computeSingleChangesetReviewState(c)
 	}
 
-	// GitHub only stores the ReviewState in events, we can't look at the
-	// Changeset.
-	if c.ExternalServiceType == github.ServiceType {
+	// GitHub and GitLab only store the ReviewState in events, we can't look
+	// at the Changeset.
+	if c.ExternalServiceType == github.ServiceType || c.ExternalServiceType == gitlab.ServiceType {
 		return events.reviewState()
 	}
 
@@ -134,6 +138,54 @@ func parseBitbucketBuildState(s string) cmpgn.ChangesetCheckState {
 	}
 }
 
+// computeGitLabPipelineState returns the check state of the most recent
+// pipeline of the merge request, taking into account any pipeline events
+// received since the last sync.
+func computeGitLabPipelineState(mr *gitlab.MergeRequest, events []*cmpgn.ChangesetEvent) cmpgn.ChangesetCheckState {
+	var latest *gitlab.Pipeline
+	consider := func(p *gitlab.Pipeline) {
+		// Pipeline IDs increase monotonically, so the pipeline with the
+		// highest ID is the most recent one. Of two versions of the same
+		// pipeline, we prefer the one that was updated last.
+		if latest == nil || p.ID > latest.ID || (p.ID == latest.ID && p.UpdatedAt.After(latest.UpdatedAt)) {
+			latest = p
+		}
+	}
+
+	for _, p := range mr.Pipelines {
+		consider(p)
+	}
+	for _, e := range events {
+		if p, ok := e.Metadata.(*gitlab.Pipeline); ok {
+			consider(p)
+		}
+	}
+
+	if latest == nil {
+		return cmpgn.ChangesetCheckStateUnknown
+	}
+	return parseGitLabPipelineStatus(latest.Status)
+}
+
+func parseGitLabPipelineStatus(status gitlab.PipelineStatus) cmpgn.ChangesetCheckState {
+	switch status {
+	case gitlab.PipelineStatusSuccess:
+		return cmpgn.ChangesetCheckStatePassed
+	case gitlab.PipelineStatusFailed, gitlab.PipelineStatusCanceled:
+		return cmpgn.ChangesetCheckStateFailed
+	case gitlab.PipelineStatusCreated,
+		gitlab.PipelineStatusWaitingForResource,
+		gitlab.PipelineStatusPreparing,
+		gitlab.PipelineStatusPending,
+		gitlab.PipelineStatusRunning,
+		gitlab.PipelineStatusManual,
+		gitlab.PipelineStatusScheduled:
+		return cmpgn.ChangesetCheckStatePending
+	default:
+		return cmpgn.ChangesetCheckStateUnknown
+	}
+}
+
 func computeGitHubCheckState(lastSynced time.Time, pr *github.PullRequest, events []*cmpgn.ChangesetEvent) cmpgn.ChangesetCheckState {
 	// We should only consider the latest commit. This could be from a sync or a webhook that
 	// has occurred later
@@ -306,6 +358,17 @@ func computeSingleChangesetState(c *cmpgn.Changeset) (s cmpgn.ChangesetState, er
 		} else {
 			s = cmpgn.ChangesetState(m.State)
 		}
+	case *gitlab.MergeRequest:
+		switch m.State {
+		case gitlab.MergeRequestStateOpened:
+			s = cmpgn.ChangesetStateOpen
+		case gitlab.MergeRequestStateClosed, gitlab.MergeRequestStateLocked:
+			s = cmpgn.ChangesetStateClosed
+		case gitlab.MergeRequestStateMerged:
+			s = cmpgn.ChangesetStateMerged
+		default:
+			s = cmpgn.ChangesetState(m.State)
+		}
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -342,6 +405,21 @@ func computeSingleChangesetReviewState(c *cmpgn.Changeset) (s cmpgn.ChangesetRev
 				states[cmpgn.ChangesetReviewStateApproved] = true
 			}
 		}
+
+	case *gitlab.MergeRequest:
+		// GitLab records approvals as system notes, so we replay them to
+		// get the current approvals.
+		byAuthor := map[string]cmpgn.ChangesetReviewState{}
+		for _, n := range m.Notes {
+			switch e := n.ToEvent().(type) {
+			case *gitlab.ReviewApprovedEvent:
+				byAuthor[e.Author.Username] = cmpgn.ChangesetReviewStateApproved
+			case *gitlab.ReviewUnapprovedEvent:
+				delete(byAuthor, e.Author.Username)
+			}
+		}
+		return computeReviewState(byAuthor), nil
+
 	default:
 		return "", errors.New("unknown changeset type")
 	}
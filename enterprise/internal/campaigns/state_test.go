@@ -8,6 +8,7 @@ import (
 	cmpgn "github.com/sourcegraph/sourcegraph/internal/campaigns"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
 )
 
 func TestComputeGithubCheckState(t *testing.T) {
@@ -258,3 +259,144 @@ func TestComputeBitbucketBuildStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestComputeGitLabPipelineState(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	pipeline := func(id int, status gitlab.PipelineStatus, minutesSinceSync int) *gitlab.Pipeline {
+		return &gitlab.Pipeline{
+			ID:        id,
+			Status:    status,
+			UpdatedAt: now.Add(time.Duration(minutesSinceSync) * time.Minute),
+		}
+	}
+	pipelineEvent := func(id int, status gitlab.PipelineStatus, minutesSinceSync int) *cmpgn.ChangesetEvent {
+		return &cmpgn.ChangesetEvent{
+			Kind:     cmpgn.ChangesetEventKindGitLabPipeline,
+			Metadata: pipeline(id, status, minutesSinceSync),
+		}
+	}
+
+	tests := []struct {
+		name      string
+		pipelines []*gitlab.Pipeline
+		events    []*cmpgn.ChangesetEvent
+		want      cmpgn.ChangesetCheckState
+	}{
+		{
+			name: "no pipelines",
+			want: cmpgn.ChangesetCheckStateUnknown,
+		},
+		{
+			name:      "synced pipeline",
+			pipelines: []*gitlab.Pipeline{pipeline(1, gitlab.PipelineStatusSuccess, -1)},
+			want:      cmpgn.ChangesetCheckStatePassed,
+		},
+		{
+			name: "latest synced pipeline wins",
+			pipelines: []*gitlab.Pipeline{
+				pipeline(2, gitlab.PipelineStatusFailed, -1),
+				pipeline(1, gitlab.PipelineStatusSuccess, -1),
+			},
+			want: cmpgn.ChangesetCheckStateFailed,
+		},
+		{
+			name:      "newer pipeline event",
+			pipelines: []*gitlab.Pipeline{pipeline(1, gitlab.PipelineStatusSuccess, -1)},
+			events:    []*cmpgn.ChangesetEvent{pipelineEvent(2, gitlab.PipelineStatusRunning, 1)},
+			want:      cmpgn.ChangesetCheckStatePending,
+		},
+		{
+			name:      "updated pipeline event",
+			pipelines: []*gitlab.Pipeline{pipeline(1, gitlab.PipelineStatusRunning, -1)},
+			events:    []*cmpgn.ChangesetEvent{pipelineEvent(1, gitlab.PipelineStatusCanceled, 1)},
+			want:      cmpgn.ChangesetCheckStateFailed,
+		},
+		{
+			name:      "stale pipeline event",
+			pipelines: []*gitlab.Pipeline{pipeline(1, gitlab.PipelineStatusSuccess, 0)},
+			events:    []*cmpgn.ChangesetEvent{pipelineEvent(1, gitlab.PipelineStatusRunning, -1)},
+			want:      cmpgn.ChangesetCheckStatePassed,
+		},
+		{
+			name:   "skipped pipeline",
+			events: []*cmpgn.ChangesetEvent{pipelineEvent(1, gitlab.PipelineStatusSkipped, 1)},
+			want:   cmpgn.ChangesetCheckStateUnknown,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mr := &gitlab.MergeRequest{Pipelines: tc.pipelines}
+			have := computeGitLabPipelineState(mr, tc.events)
+			if diff := cmp.Diff(tc.want, have); diff != "" {
+				t.Fatalf(diff)
+			}
+		})
+	}
+}
+
+func TestComputeGitLabReviewState(t *testing.T) {
+	note := func(id int, username, body string) *gitlab.Note {
+		return &gitlab.Note{ID: id, Author: gitlab.User{Username: username}, Body: body, System: true}
+	}
+
+	tests := []struct {
+		name  string
+		notes []*gitlab.Note
+		want  cmpgn.ChangesetReviewState
+	}{
+		{
+			name: "no notes",
+			want: cmpgn.ChangesetReviewStatePending,
+		},
+		{
+			name:  "approved",
+			notes: []*gitlab.Note{note(1, "alice", "approved this merge request")},
+			want:  cmpgn.ChangesetReviewStateApproved,
+		},
+		{
+			name: "approval removed",
+			notes: []*gitlab.Note{
+				note(1, "alice", "approved this merge request"),
+				note(2, "alice", "unapproved this merge request"),
+			},
+			want: cmpgn.ChangesetReviewStatePending,
+		},
+		{
+			name: "approval by someone else remains",
+			notes: []*gitlab.Note{
+				note(1, "alice", "approved this merge request"),
+				note(2, "bob", "approved this merge request"),
+				note(3, "alice", "unapproved this merge request"),
+			},
+			want: cmpgn.ChangesetReviewStateApproved,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &cmpgn.Changeset{
+				ExternalServiceType: gitlab.ServiceType,
+				Metadata:            &gitlab.MergeRequest{Notes: tc.notes},
+			}
+
+			have, err := ComputeReviewState(c, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, have); diff != "" {
+				t.Errorf("from changeset: %s", diff)
+			}
+
+			// The same review state is computed from the changeset events.
+			events := ChangesetEvents(c.Events())
+			have, err = ComputeReviewState(c, events)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, have); diff != "" {
+				t.Errorf("from events: %s", diff)
+			}
+		})
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/db/dbutil"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
 )
 
 // Store exposes methods to read and write campaigns domain models
@@ -2667,6 +2668,8 @@ func scanChangeset(t *campaigns.Changeset, s scanner) error {
 		t.Metadata = new(github.PullRequest)
 	case bitbucketserver.ServiceType:
 		t.Metadata = new(bitbucketserver.PullRequest)
+	case gitlab.ServiceType:
+		t.Metadata = new(gitlab.MergeRequest)
 	default:
 		return errors.New("unknown external service type")
 	}
@@ -2,6 +2,7 @@ package campaigns
 
 import (
 	"context"
+	"crypto/subtle"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -22,6 +23,8 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/extsvc"
 	bbs "github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
+	"github.com/sourcegraph/sourcegraph/internal/repoupdater"
 	"github.com/sourcegraph/sourcegraph/schema"
 )
 
@@ -31,7 +34,7 @@ type Webhook struct {
 	Now   func() time.Time
 
 	// ServiceType corresponds to api.ExternalRepoSpec.ServiceType
-	// Example values: bitbucketserver.ServiceType, github.ServiceType
+	// Example values: bitbucketserver.ServiceType, github.ServiceType, gitlab.ServiceType
 	ServiceType string
 }
 
@@ -79,6 +82,8 @@ func extractExternalServiceID(extSvc *repos.ExternalService) (string, error) {
 		serviceID = c.Url
 	case *schema.BitbucketServerConnection:
 		serviceID = c.Url
+	case *schema.GitLabConnection:
+		serviceID = c.Url
 	}
 	if serviceID == "" {
 		return "", errors.New("could not determine service id")
@@ -184,6 +189,14 @@ type BitbucketServerWebhook struct {
 	Name string
 }
 
+// GitLabWebhook receives GitLab project webhook events that are relevant to
+// campaigns. Note and pipeline events are normalized into ChangesetEvents and
+// upserted to the database. Merge request events, which don't carry enough
+// information to build a ChangesetEvent, cause the changeset to be synced.
+type GitLabWebhook struct {
+	*Webhook
+}
+
 func NewGitHubWebhook(store *Store, repos repos.Store, now func() time.Time) *GitHubWebhook {
 	return &GitHubWebhook{&Webhook{store, repos, now, github.ServiceType}}
 }
@@ -195,6 +208,10 @@ func NewBitbucketServerWebhook(store *Store, repos repos.Store, now func() time.
 	}
 }
 
+func NewGitLabWebhook(store *Store, repos repos.Store, now func() time.Time) *GitLabWebhook {
+	return &GitLabWebhook{&Webhook{store, repos, now, gitlab.ServiceType}}
+}
+
 // ServeHTTP implements the http.Handler interface.
 func (h *GitHubWebhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	e, extSvc, httpErr := h.parseEvent(r)
@@ -787,7 +804,7 @@ func (h *BitbucketServerWebhook) Upsert(every time.Duration) {
 			wh := bbs.Webhook{
 				Name:     h.Name,
 				Scope:    "global",
-				Events:   []string{"pr", "repo"},
+				Events:   []string{"pr", "repo", "permission"},
 				Endpoint: endpoint,
 				Secret:   secret,
 			}
@@ -902,6 +919,187 @@ func (h *BitbucketServerWebhook) convertEvent(theirs interface{}) (prs []PR, our
 	return
 }
 
+// ServeHTTP implements the http.Handler interface.
+func (h *GitLabWebhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e, extSvc, hErr := h.parseEvent(r)
+	if hErr != nil {
+		respond(w, hErr.code, hErr)
+		return
+	}
+
+	externalServiceID, err := extractExternalServiceID(extSvc)
+	if err != nil {
+		respond(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if mre, ok := e.(*gitlab.MergeRequestEvent); ok {
+		if err := h.enqueueChangesetSync(r.Context(), externalServiceID, h.mergeRequestPR(mre.Project, &mre.ObjectAttributes)); err != nil {
+			respond(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	prs, ev := h.convertEvent(r.Context(), externalServiceID, e)
+	if len(prs) == 0 || ev == nil {
+		respond(w, http.StatusOK, nil) // Nothing to do
+		return
+	}
+
+	m := new(multierror.Error)
+	for _, pr := range prs {
+		if pr == (PR{}) {
+			continue
+		}
+
+		err := h.upsertChangesetEvent(r.Context(), externalServiceID, pr, ev)
+		if err != nil {
+			m = multierror.Append(m, err)
+		}
+	}
+	if m.ErrorOrNil() != nil {
+		respond(w, http.StatusInternalServerError, m)
+	}
+}
+
+func (h *GitLabWebhook) parseEvent(r *http.Request) (interface{}, *repos.ExternalService, *httpError) {
+	payload, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, nil, &httpError{http.StatusInternalServerError, err}
+	}
+
+	// 🚨 SECURITY: GitLab sends the webhook secret as is in a header, so we
+	// authenticate the request by comparing it with the secrets in the GitLab
+	// external services config. If there are no secrets or none of them
+	// matches, we return a 401 to the client.
+	args := repos.StoreListExternalServicesArgs{Kinds: []string{"GITLAB"}}
+	es, err := h.Repos.ListExternalServices(r.Context(), args)
+	if err != nil {
+		return nil, nil, &httpError{http.StatusInternalServerError, err}
+	}
+
+	token := gitlab.WebhookToken(r)
+
+	var extSvc *repos.ExternalService
+	for _, e := range es {
+		c, _ := e.Configuration()
+		con, ok := c.(*schema.GitLabConnection)
+		if !ok {
+			continue
+		}
+
+		for _, hook := range con.Webhooks {
+			if hook.Secret == "" {
+				continue
+			}
+
+			if subtle.ConstantTimeCompare([]byte(token), []byte(hook.Secret)) == 1 {
+				extSvc = e
+				break
+			}
+		}
+		if extSvc != nil {
+			break
+		}
+	}
+
+	if extSvc == nil {
+		return nil, nil, &httpError{http.StatusUnauthorized, nil}
+	}
+
+	e, err := gitlab.ParseWebhookEvent(gitlab.WebhookEventType(r), payload)
+	if err != nil {
+		return nil, nil, &httpError{http.StatusBadRequest, err}
+	}
+
+	return e, extSvc, nil
+}
+
+func (h *GitLabWebhook) convertEvent(ctx context.Context, externalServiceID string, theirs interface{}) (prs []PR, ours interface{ Key() string }) {
+	log15.Debug("GitLab webhook received", "type", fmt.Sprintf("%T", theirs))
+
+	switch e := theirs.(type) {
+	case *gitlab.NoteEvent:
+		if e.MergeRequest == nil || e.ObjectAttributes.NoteableType != "MergeRequest" {
+			return nil, nil
+		}
+
+		ours = e.ToNote().ToEvent()
+		if ours == nil {
+			return nil, nil
+		}
+		return append(prs, h.mergeRequestPR(e.Project, e.MergeRequest)), ours
+
+	case *gitlab.PipelineEvent:
+		ours = e.ToPipeline(h.Now())
+
+		if e.MergeRequest != nil {
+			return append(prs, h.mergeRequestPR(e.Project, e.MergeRequest)), ours
+		}
+
+		// Branch pipelines aren't linked to a merge request, so we look
+		// for changesets whose source branch the pipeline ran on.
+		repoExternalID := strconv.Itoa(e.Project.ID)
+		spec := api.ExternalRepoSpec{
+			ID:          repoExternalID,
+			ServiceID:   externalServiceID,
+			ServiceType: gitlab.ServiceType,
+		}
+
+		ids, err := h.Store.GetChangesetExternalIDs(ctx, spec, []string{e.ObjectAttributes.Ref})
+		if err != nil {
+			log15.Error("Error executing GetChangesetExternalIDs", "err", err)
+			return nil, nil
+		}
+
+		for _, id := range ids {
+			i, err := strconv.ParseInt(id, 10, 64)
+			if err != nil {
+				log15.Error("Error parsing external id", "err", err)
+				continue
+			}
+			prs = append(prs, PR{ID: i, RepoExternalID: repoExternalID})
+		}
+		return prs, ours
+	}
+
+	return nil, nil
+}
+
+// mergeRequestPR returns the PR identifying the merge request in the project
+// the webhook belongs to.
+func (*GitLabWebhook) mergeRequestPR(project gitlab.ProjectCommon, mr *gitlab.MergeRequestAttributes) PR {
+	projectID := mr.TargetProjectID
+	if projectID == 0 {
+		projectID = project.ID
+	}
+	return PR{ID: int64(mr.IID), RepoExternalID: strconv.Itoa(projectID)}
+}
+
+// enqueueChangesetSync enqueues a sync of the changeset of the given merge
+// request, if there is one.
+func (h *GitLabWebhook) enqueueChangesetSync(ctx context.Context, externalServiceID string, pr PR) error {
+	r, err := h.getRepoForPR(ctx, h.Store, pr, externalServiceID)
+	if err != nil {
+		log15.Debug("Webhook event could not be matched to repo", "err", err)
+		return nil
+	}
+
+	cs, err := h.Store.GetChangeset(ctx, GetChangesetOpts{
+		RepoID:              r.ID,
+		ExternalID:          strconv.FormatInt(pr.ID, 10),
+		ExternalServiceType: h.ServiceType,
+	})
+	if err != nil {
+		if err == ErrNoResults {
+			return nil // Nothing to do
+		}
+		return err
+	}
+
+	return repoupdater.DefaultClient.EnqueueChangesetSync(ctx, []int64{cs.ID})
+}
+
 type httpError struct {
 	code int
 	err  error
@@ -136,6 +136,68 @@ func (c *Client) References(ctx context.Context, args *struct {
 	})
 }
 
+func (c *Client) TypeDefinitions(ctx context.Context, args *struct {
+	RepoID    api.RepoID
+	Commit    graphqlbackend.GitObjectID
+	Path      string
+	Line      int32
+	Character int32
+	UploadID  int64
+}) ([]*lsif.LSIFLocation, string, error) {
+	return c.locationQuery(ctx, &struct {
+		Operation string
+		RepoID    api.RepoID
+		Commit    graphqlbackend.GitObjectID
+		Path      string
+		Line      int32
+		Character int32
+		UploadID  int64
+		Limit     *int32
+		Cursor    *string
+	}{
+		Operation: "typeDefinitions",
+		RepoID:    args.RepoID,
+		Commit:    args.Commit,
+		Path:      args.Path,
+		Line:      args.Line,
+		Character: args.Character,
+		UploadID:  args.UploadID,
+	})
+}
+
+func (c *Client) Implementations(ctx context.Context, args *struct {
+	RepoID    api.RepoID
+	Commit    graphqlbackend.GitObjectID
+	Path      string
+	Line      int32
+	Character int32
+	UploadID  int64
+	Limit     *int32
+	Cursor    *string
+}) ([]*lsif.LSIFLocation, string, error) {
+	return c.locationQuery(ctx, &struct {
+		Operation string
+		RepoID    api.RepoID
+		Commit    graphqlbackend.GitObjectID
+		Path      string
+		Line      int32
+		Character int32
+		UploadID  int64
+		Limit     *int32
+		Cursor    *string
+	}{
+		Operation: "implementations",
+		RepoID:    args.RepoID,
+		Commit:    args.Commit,
+		Path:      args.Path,
+		Line:      args.Line,
+		Character: args.Character,
+		UploadID:  args.UploadID,
+		Limit:     args.Limit,
+		Cursor:    args.Cursor,
+	})
+}
+
 func (c *Client) locationQuery(ctx context.Context, args *struct {
 	Operation string
 	RepoID    api.RepoID
@@ -24,6 +24,27 @@ type lsifQueryResolver struct {
 var _ graphqlbackend.LSIFQueryResolver = &lsifQueryResolver{}
 
 func (r *lsifQueryResolver) Definitions(ctx context.Context, args *graphqlbackend.LSIFQueryPositionArgs) (graphqlbackend.LocationConnectionResolver, error) {
+	return r.firstLocations(ctx, args, client.DefaultClient.Definitions)
+}
+
+func (r *lsifQueryResolver) TypeDefinitions(ctx context.Context, args *graphqlbackend.LSIFQueryPositionArgs) (graphqlbackend.LocationConnectionResolver, error) {
+	return r.firstLocations(ctx, args, client.DefaultClient.TypeDefinitions)
+}
+
+// locationQueryFunc is the signature of the lsifserver client methods that
+// return the locations for a position in a single upload.
+type locationQueryFunc func(ctx context.Context, args *struct {
+	RepoID    api.RepoID
+	Commit    graphqlbackend.GitObjectID
+	Path      string
+	Line      int32
+	Character int32
+	UploadID  int64
+}) ([]*lsif.LSIFLocation, string, error)
+
+// firstLocations returns the locations from the first upload (ordered by
+// commit distance) for which query returns a non-empty result.
+func (r *lsifQueryResolver) firstLocations(ctx context.Context, args *graphqlbackend.LSIFQueryPositionArgs, query locationQueryFunc) (graphqlbackend.LocationConnectionResolver, error) {
 	for _, upload := range r.uploads {
 		// TODO(efritz) - we should also detect renames/copies on position adjustment
 		adjustedPosition, ok, err := r.adjustPosition(ctx, upload.Commit, args.Line, args.Character)
@@ -50,7 +71,7 @@ func (r *lsifQueryResolver) Definitions(ctx context.Context, args *graphqlbacken
 			UploadID:  upload.ID,
 		}
 
-		locations, _, err := client.DefaultClient.Definitions(ctx, opts)
+		locations, _, err := query(ctx, opts)
 		if err != nil {
 			return nil, err
 		}
@@ -68,6 +89,29 @@ func (r *lsifQueryResolver) Definitions(ctx context.Context, args *graphqlbacken
 }
 
 func (r *lsifQueryResolver) References(ctx context.Context, args *graphqlbackend.LSIFPagedQueryPositionArgs) (graphqlbackend.LocationConnectionResolver, error) {
+	return r.pagedLocations(ctx, args, client.DefaultClient.References)
+}
+
+func (r *lsifQueryResolver) Implementations(ctx context.Context, args *graphqlbackend.LSIFPagedQueryPositionArgs) (graphqlbackend.LocationConnectionResolver, error) {
+	return r.pagedLocations(ctx, args, client.DefaultClient.Implementations)
+}
+
+// pagedLocationQueryFunc is the signature of the lsifserver client methods
+// that return a page of locations for a position in a single upload, along
+// with the URL of the next page.
+type pagedLocationQueryFunc func(ctx context.Context, args *struct {
+	RepoID    api.RepoID
+	Commit    graphqlbackend.GitObjectID
+	Path      string
+	Line      int32
+	Character int32
+	UploadID  int64
+	Limit     *int32
+	Cursor    *string
+}) ([]*lsif.LSIFLocation, string, error)
+
+// pagedLocations returns the locations returned by query for every upload.
+func (r *lsifQueryResolver) pagedLocations(ctx context.Context, args *graphqlbackend.LSIFPagedQueryPositionArgs, query pagedLocationQueryFunc) (graphqlbackend.LocationConnectionResolver, error) {
 	// Decode a map of upload ids to the next url that serves
 	// the new page of results. This may not include an entry
 	// for every upload if their result sets have already been
@@ -121,7 +165,7 @@ func (r *lsifQueryResolver) References(ctx context.Context, args *graphqlbackend
 			continue
 		}
 
-		locations, nextURL, err := client.DefaultClient.References(ctx, opts)
+		locations, nextURL, err := query(ctx, opts)
 		if err != nil {
 			return nil, err
 		}
@@ -100,6 +100,8 @@ type ConfigSavedQuery struct {
 	UserID          *int32  `json:"userID"`
 	OrgID           *int32  `json:"orgID"`
 	SlackWebhookURL *string `json:"slackWebhookURL"`
+	WebhookURL      *string `json:"webhookURL"`
+	WebhookSecret   *string `json:"webhookSecret,omitempty"`
 }
 
 func (sq ConfigSavedQuery) Equals(other ConfigSavedQuery) bool {
@@ -172,6 +174,82 @@ func (c *internalClient) SavedQueriesDeleteInfo(ctx context.Context, query strin
 	return c.postInternal(ctx, "saved-queries/delete-info", query, nil)
 }
 
+// SavedQueryMatch is a single search result of a saved query, as recorded by
+// a run of the saved query.
+type SavedQueryMatch struct {
+	// Key identifies the match across runs of the saved query. It is
+	// "repo/path" for file matches and "repo@commit" for commit matches.
+	Key string `json:"key"`
+
+	// Type is the type of the match, either "file" or "commit".
+	Type string `json:"type"`
+
+	Repository string `json:"repository"`
+	Path       string `json:"path,omitempty"`
+	Commit     string `json:"commit,omitempty"`
+
+	// URL is the absolute URL of the match on Sourcegraph.
+	URL string `json:"url"`
+}
+
+// SavedQueryRun represents a single execution of a saved query by
+// query-runner.
+type SavedQueryRun struct {
+	ID            int64
+	SavedSearchID int32
+	StartedAt     time.Time
+	FinishedAt    time.Time
+
+	// Matches are the keys of all matches found by the run. They are
+	// compared against the matches of the next run to find new matches.
+	Matches []string
+
+	// NewMatches are the matches that were not found by the previous run.
+	NewMatches []SavedQueryMatch
+
+	// Error is the error that occurred during the run, if any.
+	Error string
+}
+
+// SavedQueriesLastSuccessfulRun returns the most recent run of the given saved
+// search that did not fail. nil is returned if there is no such run.
+func (c *internalClient) SavedQueriesLastSuccessfulRun(ctx context.Context, savedSearchID int32) (*SavedQueryRun, error) {
+	var result *SavedQueryRun
+	err := c.postInternal(ctx, "saved-queries/last-successful-run", savedSearchID, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SavedQueriesRecordRun records a run of a saved search.
+func (c *internalClient) SavedQueriesRecordRun(ctx context.Context, run *SavedQueryRun) error {
+	return c.postInternal(ctx, "saved-queries/record-run", run, nil)
+}
+
+// SavedQueriesReadableReposArgs are the arguments of SavedQueriesReadableRepos.
+type SavedQueriesReadableReposArgs struct {
+	Subject SettingsSubject
+	Repos   []RepoName
+}
+
+var MockSavedQueriesReadableRepos func(subject SettingsSubject, repos []RepoName) ([]RepoName, error)
+
+// SavedQueriesReadableRepos returns the repositories that the owner of a saved
+// query, a user or an org, may read. For an org, these are the repositories
+// that every member of the org may read.
+func (c *internalClient) SavedQueriesReadableRepos(ctx context.Context, subject SettingsSubject, repos []RepoName) ([]RepoName, error) {
+	if MockSavedQueriesReadableRepos != nil {
+		return MockSavedQueriesReadableRepos(subject, repos)
+	}
+	var readable []RepoName
+	err := c.postInternal(ctx, "saved-queries/readable-repos", SavedQueriesReadableReposArgs{Subject: subject, Repos: repos}, &readable)
+	if err != nil {
+		return nil, err
+	}
+	return readable, nil
+}
+
 func (c *internalClient) SettingsGetForSubject(ctx context.Context, subject SettingsSubject) (parsed *schema.Settings, settings *Settings, err error) {
 	err = c.postInternal(ctx, "settings/get-for-subject", subject, &settings)
 	if err == nil {
@@ -281,6 +359,11 @@ func (c *internalClient) PhabricatorRepoCreate(ctx context.Context, repo RepoNam
 	}, nil)
 }
 
+// RepoGroupsEvaluate evaluates the rules of all dynamic repository groups.
+func (c *internalClient) RepoGroupsEvaluate(ctx context.Context) error {
+	return c.postInternal(ctx, "repo-groups/evaluate", nil, nil)
+}
+
 var MockExternalServiceConfigs func(kind string, result interface{}) error
 
 // ExternalServiceConfigs fetches external service configs of a single kind into the result parameter,
@@ -13,6 +13,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
 )
 
 // SupportedExternalServices are the external service types currently supported
@@ -22,6 +23,7 @@ import (
 var SupportedExternalServices = map[string]struct{}{
 	github.ServiceType:          {},
 	bitbucketserver.ServiceType: {},
+	gitlab.ServiceType:          {},
 }
 
 // IsRepoSupported returns whether the given ExternalRepoSpec is supported by
@@ -305,6 +307,12 @@ func (c *Changeset) SetMetadata(meta interface{}) error {
 		c.ExternalServiceType = bitbucketserver.ServiceType
 		c.ExternalBranch = git.AbbreviateRef(pr.FromRef.ID)
 		c.ExternalUpdatedAt = unixMilliToTime(int64(pr.UpdatedDate))
+	case *gitlab.MergeRequest:
+		c.Metadata = pr
+		c.ExternalID = strconv.Itoa(pr.IID)
+		c.ExternalServiceType = gitlab.ServiceType
+		c.ExternalBranch = pr.SourceBranch
+		c.ExternalUpdatedAt = pr.UpdatedAt
 	default:
 		return errors.New("unknown changeset type")
 	}
@@ -328,6 +336,8 @@ func (c *Changeset) Title() (string, error) {
 		return m.Title, nil
 	case *bitbucketserver.PullRequest:
 		return m.Title, nil
+	case *gitlab.MergeRequest:
+		return m.Title, nil
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -342,6 +352,8 @@ func (c *Changeset) ExternalCreatedAt() time.Time {
 		return m.CreatedAt
 	case *bitbucketserver.PullRequest:
 		return unixMilliToTime(int64(m.CreatedDate))
+	case *gitlab.MergeRequest:
+		return m.CreatedAt
 	default:
 		return time.Time{}
 	}
@@ -354,6 +366,8 @@ func (c *Changeset) Body() (string, error) {
 		return m.Body, nil
 	case *bitbucketserver.PullRequest:
 		return m.Description, nil
+	case *gitlab.MergeRequest:
+		return m.Description, nil
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -387,6 +401,8 @@ func (c *Changeset) state() (s ChangesetState, err error) {
 		} else {
 			s = ChangesetState(m.State)
 		}
+	case *gitlab.MergeRequest:
+		s = gitLabMergeRequestState(m)
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -409,6 +425,8 @@ func (c *Changeset) URL() (s string, err error) {
 		}
 		selfLink := m.Links.Self[0]
 		return selfLink.Href, nil
+	case *gitlab.MergeRequest:
+		return m.WebURL, nil
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -461,6 +479,25 @@ func (c *Changeset) Events() (events []*ChangesetEvent) {
 			addEvent(s)
 		}
 
+	case *gitlab.MergeRequest:
+		events = make([]*ChangesetEvent, 0, len(m.Notes)+len(m.Pipelines))
+		addEvent := func(e Keyer) {
+			events = append(events, &ChangesetEvent{
+				ChangesetID: c.ID,
+				Key:         e.Key(),
+				Kind:        ChangesetEventKindFor(e),
+				Metadata:    e,
+			})
+		}
+		for _, n := range m.Notes {
+			// System notes that don't correspond to an event are skipped.
+			if e := n.ToEvent(); e != nil {
+				addEvent(e)
+			}
+		}
+		for _, p := range m.Pipelines {
+			addEvent(p)
+		}
 	}
 	return events
 }
@@ -474,6 +511,8 @@ func (c *Changeset) HeadRefOid() (string, error) {
 		return m.HeadRefOid, nil
 	case *bitbucketserver.PullRequest:
 		return "", nil
+	case *gitlab.MergeRequest:
+		return m.DiffRefs.HeadSHA, nil
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -487,6 +526,8 @@ func (c *Changeset) HeadRef() (string, error) {
 		return "refs/heads/" + m.HeadRefName, nil
 	case *bitbucketserver.PullRequest:
 		return m.FromRef.ID, nil
+	case *gitlab.MergeRequest:
+		return "refs/heads/" + m.SourceBranch, nil
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -501,6 +542,8 @@ func (c *Changeset) BaseRefOid() (string, error) {
 		return m.BaseRefOid, nil
 	case *bitbucketserver.PullRequest:
 		return "", nil
+	case *gitlab.MergeRequest:
+		return m.DiffRefs.BaseSHA, nil
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -514,6 +557,8 @@ func (c *Changeset) BaseRef() (string, error) {
 		return "refs/heads/" + m.BaseRefName, nil
 	case *bitbucketserver.PullRequest:
 		return m.ToRef.ID, nil
+	case *gitlab.MergeRequest:
+		return "refs/heads/" + m.TargetBranch, nil
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -531,11 +576,33 @@ func (c *Changeset) Labels() []ChangesetLabel {
 			}
 		}
 		return labels
+	case *gitlab.MergeRequest:
+		// GitLab only returns the names of the labels of a merge request.
+		labels := make([]ChangesetLabel, len(m.Labels))
+		for i, name := range m.Labels {
+			labels[i] = ChangesetLabel{Name: name}
+		}
+		return labels
 	default:
 		return []ChangesetLabel{}
 	}
 }
 
+// gitLabMergeRequestState maps the state of a GitLab merge request to a
+// ChangesetState. Locked merge requests are treated as closed.
+func gitLabMergeRequestState(mr *gitlab.MergeRequest) ChangesetState {
+	switch mr.State {
+	case gitlab.MergeRequestStateOpened:
+		return ChangesetStateOpen
+	case gitlab.MergeRequestStateClosed, gitlab.MergeRequestStateLocked:
+		return ChangesetStateClosed
+	case gitlab.MergeRequestStateMerged:
+		return ChangesetStateMerged
+	default:
+		return ChangesetState(mr.State)
+	}
+}
+
 // A ChangesetEvent is an event that happened in the lifetime
 // and context of a Changeset.
 type ChangesetEvent struct {
@@ -585,6 +652,18 @@ func (e *ChangesetEvent) Actor() string {
 		a = e.Actor.Login
 	case *github.LabelEvent:
 		a = e.Actor.Login
+	case *gitlab.Note:
+		a = e.Author.Username
+	case *gitlab.ReviewApprovedEvent:
+		a = e.Author.Username
+	case *gitlab.ReviewUnapprovedEvent:
+		a = e.Author.Username
+	case *gitlab.MergeRequestClosedEvent:
+		a = e.Author.Username
+	case *gitlab.MergeRequestReopenedEvent:
+		a = e.Author.Username
+	case *gitlab.MergeRequestMergedEvent:
+		a = e.Author.Username
 	}
 
 	return a
@@ -613,6 +692,20 @@ func (e *ChangesetEvent) ReviewAuthor() (string, error) {
 			return "", errors.New("activity user is blank")
 		}
 		return username, nil
+
+	case *gitlab.ReviewApprovedEvent:
+		username := meta.Author.Username
+		if username == "" {
+			return "", errors.New("approval author is blank")
+		}
+		return username, nil
+
+	case *gitlab.ReviewUnapprovedEvent:
+		username := meta.Author.Username
+		if username == "" {
+			return "", errors.New("unapproval author is blank")
+		}
+		return username, nil
 	default:
 		return "", nil
 	}
@@ -621,7 +714,8 @@ func (e *ChangesetEvent) ReviewAuthor() (string, error) {
 // ReviewState returns the review state of the ChangesetEvent if it is a review event.
 func (e *ChangesetEvent) ReviewState() (ChangesetReviewState, error) {
 	switch e.Kind {
-	case ChangesetEventKindBitbucketServerApproved:
+	case ChangesetEventKindBitbucketServerApproved,
+		ChangesetEventKindGitLabApproved:
 		return ChangesetReviewStateApproved, nil
 
 	// BitbucketServer's "REVIEWED" activity is created when someone clicks
@@ -644,7 +738,8 @@ func (e *ChangesetEvent) ReviewState() (ChangesetReviewState, error) {
 		return s, nil
 
 	case ChangesetEventKindGitHubReviewDismissed,
-		ChangesetEventKindBitbucketServerUnapproved:
+		ChangesetEventKindBitbucketServerUnapproved,
+		ChangesetEventKindGitLabUnapproved:
 		return ChangesetReviewStateDismissed, nil
 
 	default:
@@ -704,6 +799,20 @@ func (e *ChangesetEvent) Timestamp() time.Time {
 		t = unixMilliToTime(int64(e.CreatedDate))
 	case *bitbucketserver.CommitStatus:
 		t = unixMilliToTime(int64(e.Status.DateAdded))
+	case *gitlab.Note:
+		t = e.UpdatedAt
+	case *gitlab.ReviewApprovedEvent:
+		t = e.CreatedAt
+	case *gitlab.ReviewUnapprovedEvent:
+		t = e.CreatedAt
+	case *gitlab.MergeRequestClosedEvent:
+		t = e.CreatedAt
+	case *gitlab.MergeRequestReopenedEvent:
+		t = e.CreatedAt
+	case *gitlab.MergeRequestMergedEvent:
+		t = e.CreatedAt
+	case *gitlab.Pipeline:
+		t = e.UpdatedAt
 	}
 
 	return t
@@ -1025,11 +1134,63 @@ func (e *ChangesetEvent) Update(o *ChangesetEvent) {
 		}
 		e.CheckRuns = o.CheckRuns
 
+	case *gitlab.Note:
+		o := o.Metadata.(*gitlab.Note)
+		updateGitLabNote(e, o)
+
+	case *gitlab.ReviewApprovedEvent:
+		o := o.Metadata.(*gitlab.ReviewApprovedEvent)
+		updateGitLabNote(&e.Note, &o.Note)
+
+	case *gitlab.ReviewUnapprovedEvent:
+		o := o.Metadata.(*gitlab.ReviewUnapprovedEvent)
+		updateGitLabNote(&e.Note, &o.Note)
+
+	case *gitlab.MergeRequestClosedEvent:
+		o := o.Metadata.(*gitlab.MergeRequestClosedEvent)
+		updateGitLabNote(&e.Note, &o.Note)
+
+	case *gitlab.MergeRequestReopenedEvent:
+		o := o.Metadata.(*gitlab.MergeRequestReopenedEvent)
+		updateGitLabNote(&e.Note, &o.Note)
+
+	case *gitlab.MergeRequestMergedEvent:
+		o := o.Metadata.(*gitlab.MergeRequestMergedEvent)
+		updateGitLabNote(&e.Note, &o.Note)
+
+	case *gitlab.Pipeline:
+		o := o.Metadata.(*gitlab.Pipeline)
+		// Pipeline events always contain the full pipeline, but the webhook
+		// payload doesn't contain the web URL on older GitLab versions.
+		webURL := e.WebURL
+		*e = *o
+		if e.WebURL == "" {
+			e.WebURL = webURL
+		}
+
 	default:
 		panic(errors.Errorf("unknown changeset event metadata %T", e))
 	}
 }
 
+func updateGitLabNote(e, o *gitlab.Note) {
+	if e.Author.ID == 0 {
+		e.Author = o.Author
+	}
+
+	if o.Body != "" && e.Body != o.Body {
+		e.Body = o.Body
+	}
+
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = o.CreatedAt
+	}
+
+	if e.UpdatedAt.Before(o.UpdatedAt) {
+		e.UpdatedAt = o.UpdatedAt
+	}
+}
+
 func updateGithubCheckRun(e, o *github.CheckRun) {
 	if e.Status == "" {
 		e.Status = o.Status
@@ -1156,6 +1317,20 @@ func ChangesetEventKindFor(e interface{}) ChangesetEventKind {
 		return ChangesetEventKind("bitbucketserver:" + strings.ToLower(string(e.Action)))
 	case *bitbucketserver.CommitStatus:
 		return ChangesetEventKindBitbucketServerCommitStatus
+	case *gitlab.Note:
+		return ChangesetEventKindGitLabCommented
+	case *gitlab.ReviewApprovedEvent:
+		return ChangesetEventKindGitLabApproved
+	case *gitlab.ReviewUnapprovedEvent:
+		return ChangesetEventKindGitLabUnapproved
+	case *gitlab.MergeRequestClosedEvent:
+		return ChangesetEventKindGitLabClosed
+	case *gitlab.MergeRequestReopenedEvent:
+		return ChangesetEventKindGitLabReopened
+	case *gitlab.MergeRequestMergedEvent:
+		return ChangesetEventKindGitLabMerged
+	case *gitlab.Pipeline:
+		return ChangesetEventKindGitLabPipeline
 	default:
 		panic(errors.Errorf("unknown changeset event kind for %T", e))
 	}
@@ -1211,6 +1386,23 @@ func NewChangesetEventMetadata(k ChangesetEventKind) (interface{}, error) {
 		case ChangesetEventKindCheckRun:
 			return new(github.CheckRun), nil
 		}
+	case strings.HasPrefix(string(k), "gitlab"):
+		switch k {
+		case ChangesetEventKindGitLabCommented:
+			return new(gitlab.Note), nil
+		case ChangesetEventKindGitLabApproved:
+			return new(gitlab.ReviewApprovedEvent), nil
+		case ChangesetEventKindGitLabUnapproved:
+			return new(gitlab.ReviewUnapprovedEvent), nil
+		case ChangesetEventKindGitLabClosed:
+			return new(gitlab.MergeRequestClosedEvent), nil
+		case ChangesetEventKindGitLabReopened:
+			return new(gitlab.MergeRequestReopenedEvent), nil
+		case ChangesetEventKindGitLabMerged:
+			return new(gitlab.MergeRequestMergedEvent), nil
+		case ChangesetEventKindGitLabPipeline:
+			return new(gitlab.Pipeline), nil
+		}
 	}
 	return nil, errors.Errorf("unknown changeset event kind %q", k)
 }
@@ -1252,6 +1444,14 @@ const (
 	ChangesetEventKindBitbucketServerCommented    ChangesetEventKind = "bitbucketserver:commented"
 	ChangesetEventKindBitbucketServerMerged       ChangesetEventKind = "bitbucketserver:merged"
 	ChangesetEventKindBitbucketServerCommitStatus ChangesetEventKind = "bitbucketserver:commit_status"
+
+	ChangesetEventKindGitLabCommented  ChangesetEventKind = "gitlab:commented"
+	ChangesetEventKindGitLabApproved   ChangesetEventKind = "gitlab:approved"
+	ChangesetEventKindGitLabUnapproved ChangesetEventKind = "gitlab:unapproved"
+	ChangesetEventKindGitLabClosed     ChangesetEventKind = "gitlab:closed"
+	ChangesetEventKindGitLabReopened   ChangesetEventKind = "gitlab:reopened"
+	ChangesetEventKindGitLabMerged     ChangesetEventKind = "gitlab:merged"
+	ChangesetEventKindGitLabPipeline   ChangesetEventKind = "gitlab:pipeline"
 )
 
 // ChangesetSyncData represents data about the sync status of a changeset
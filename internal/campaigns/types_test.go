@@ -7,6 +7,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
 )
 
 func TestChangesetMetadata(t *testing.T) {
@@ -242,6 +243,69 @@ func TestChangesetEvents(t *testing.T) {
 		})
 	}
 
+	{ // GitLab
+
+		user := gitlab.User{ID: 1, Username: "john-doe"}
+		reviewer := gitlab.User{ID: 2, Username: "jane-doe"}
+
+		notes := []*gitlab.Note{
+			{ID: 1, Author: reviewer, Body: "looks good"},
+			{ID: 2, Author: reviewer, Body: "approved this merge request", System: true},
+			{ID: 3, Author: user, Body: "added 1 commit", System: true},
+			{ID: 4, Author: reviewer, Body: "unapproved this merge request", System: true},
+			{ID: 5, Author: user, Body: "closed", System: true},
+			{ID: 6, Author: user, Body: "reopened", System: true},
+			{ID: 7, Author: user, Body: "merged", System: true},
+		}
+		pipeline := &gitlab.Pipeline{ID: 8, Status: gitlab.PipelineStatusSuccess}
+
+		cases = append(cases, testCase{"gitlab",
+			Changeset{
+				ID: 25,
+				Metadata: &gitlab.MergeRequest{
+					Notes:     notes,
+					Pipelines: []*gitlab.Pipeline{pipeline},
+				},
+			},
+			[]*ChangesetEvent{{
+				ChangesetID: 25,
+				Kind:        ChangesetEventKindGitLabCommented,
+				Key:         "1",
+				Metadata:    notes[0],
+			}, {
+				ChangesetID: 25,
+				Kind:        ChangesetEventKindGitLabApproved,
+				Key:         "2",
+				Metadata:    &gitlab.ReviewApprovedEvent{Note: *notes[1]},
+			}, {
+				ChangesetID: 25,
+				Kind:        ChangesetEventKindGitLabUnapproved,
+				Key:         "4",
+				Metadata:    &gitlab.ReviewUnapprovedEvent{Note: *notes[3]},
+			}, {
+				ChangesetID: 25,
+				Kind:        ChangesetEventKindGitLabClosed,
+				Key:         "5",
+				Metadata:    &gitlab.MergeRequestClosedEvent{Note: *notes[4]},
+			}, {
+				ChangesetID: 25,
+				Kind:        ChangesetEventKindGitLabReopened,
+				Key:         "6",
+				Metadata:    &gitlab.MergeRequestReopenedEvent{Note: *notes[5]},
+			}, {
+				ChangesetID: 25,
+				Kind:        ChangesetEventKindGitLabMerged,
+				Key:         "7",
+				Metadata:    &gitlab.MergeRequestMergedEvent{Note: *notes[6]},
+			}, {
+				ChangesetID: 25,
+				Kind:        ChangesetEventKindGitLabPipeline,
+				Key:         "8",
+				Metadata:    pipeline,
+			}},
+		})
+	}
+
 	for _, tc := range cases {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
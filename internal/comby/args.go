@@ -17,7 +17,7 @@ func (args Args) String() string {
 	}
 	if args.MatchOnly {
 		s = append(s, "-match-only")
-	} else {
+	} else if !args.RewrittenSource {
 		s = append(s, "-json-only-diff")
 	}
 
@@ -39,7 +39,7 @@ func rawArgs(args Args) (rawArgs []string) {
 
 	if args.MatchOnly {
 		rawArgs = append(rawArgs, "-match-only")
-	} else {
+	} else if !args.RewrittenSource {
 		rawArgs = append(rawArgs, "-json-only-diff")
 	}
 
@@ -191,3 +191,42 @@ func Matches(ctx context.Context, args Args) (matches []FileMatch, err error) {
 	}
 	return matches, nil
 }
+
+// Replacements returns the rewritten content of all files in which comby
+// replaces matches.
+func Replacements(ctx context.Context, args Args) (replacements []FileReplacement, err error) {
+	b := new(bytes.Buffer)
+	w := bufio.NewWriter(b)
+
+	args.MatchOnly = false
+	args.RewrittenSource = true
+
+	err = PipeTo(ctx, args, w)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(b)
+	// increase the scanner buffer size for potentially long lines
+	scanner.Buffer(make([]byte, 100), 10*bufio.MaxScanTokenSize)
+	for scanner.Scan() {
+		b := scanner.Bytes()
+		var r *FileReplacement
+		if err := json.Unmarshal(b, &r); err != nil {
+			// warn on decode errors and skip
+			log15.Warn("comby error: skipping unmarshaling error", "err", err.Error())
+			continue
+		}
+		replacements = append(replacements, *r)
+	}
+	if err := scanner.Err(); err != nil {
+		// Unlike for matches, a file that is skipped would silently be
+		// left out of the rewrite.
+		return nil, errors.Wrap(err, "failed to read comby output")
+	}
+
+	return replacements, nil
+}
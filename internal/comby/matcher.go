@@ -0,0 +1,85 @@
+package comby
+
+import "strings"
+
+// MatcherForLanguage looks up a key for specifying -matcher in comby. Comby accepts
+// a representative file extension to set a language, so this lookup does not
+// need to consider all possible file extensions for a language. There is a generic
+// fallback language, so this lookup does not need to be exhaustive either.
+func MatcherForLanguage(language string) string {
+	switch strings.ToLower(language) {
+	case "assembly", "asm":
+		return ".s"
+	case "bash":
+		return ".sh"
+	case "c":
+		return ".c"
+	case "c#, csharp":
+		return ".cs"
+	case "css":
+		return ".css"
+	case "dart":
+		return ".dart"
+	case "clojure":
+		return ".clj"
+	case "elm":
+		return ".elm"
+	case "erlang":
+		return ".erl"
+	case "elixir":
+		return ".ex"
+	case "fortran":
+		return ".f"
+	case "f#", "fsharp":
+		return ".fsx"
+	case "go":
+		return ".go"
+	case "html":
+		return ".html"
+	case "haskell":
+		return ".hs"
+	case "java":
+		return ".java"
+	case "javascript":
+		return ".js"
+	case "json":
+		return ".json"
+	case "julia":
+		return ".jl"
+	case "kotlin":
+		return ".kt"
+	case "laTeX":
+		return ".tex"
+	case "lisp":
+		return ".lisp"
+	case "nim":
+		return ".nim"
+	case "ocaml":
+		return ".ml"
+	case "pascal":
+		return ".pas"
+	case "php":
+		return ".php"
+	case "python":
+		return ".py"
+	case "reason":
+		return ".re"
+	case "ruby":
+		return ".rb"
+	case "rust":
+		return ".rs"
+	case "scala":
+		return ".scala"
+	case "sql":
+		return ".sql"
+	case "swift":
+		return ".swift"
+	case "text":
+		return ".txt"
+	case "typescript", "ts":
+		return ".ts"
+	case "xml":
+		return ".xml"
+	}
+	return ""
+}
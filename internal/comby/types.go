@@ -29,6 +29,10 @@ type Args struct {
 	// If MatchOnly is set to true, then comby will only find matches and not perform replacement
 	MatchOnly bool
 
+	// If RewrittenSource is set to true (and MatchOnly is not), then comby
+	// outputs the rewritten content of files instead of a diff
+	RewrittenSource bool
+
 	// FilePatterns is a list of file patterns (suffixes) to filter and process
 	FilePatterns []string
 
@@ -51,8 +55,16 @@ type Range struct {
 
 // Match represents a range of matched characters and the matched content
 type Match struct {
-	Range   Range  `json:"range"`
-	Matched string `json:"matched"`
+	Range       Range         `json:"range"`
+	Matched     string        `json:"matched"`
+	Environment []Environment `json:"environment"`
+}
+
+// Environment represents the value bound to a hole, like :[arg], in a match
+type Environment struct {
+	Variable string `json:"variable"`
+	Value    string `json:"value"`
+	Range    Range  `json:"range"`
 }
 
 // FileMatch represents all the matches in a single file
@@ -61,6 +73,12 @@ type FileMatch struct {
 	Matches []Match `json:"matches"`
 }
 
+// FileReplacement represents the rewritten content of a file
+type FileReplacement struct {
+	URI             string `json:"uri"`
+	RewrittenSource string `json:"rewritten_source"`
+}
+
 // FileDiff represents a diff for a file
 type FileDiff struct {
 	URI  string `json:"uri"`
@@ -328,6 +328,13 @@ func AndOrQueryEnabled() bool {
 	return e.AndOrQuery == "enabled"
 }
 
+// CommitSearchIndexEnabled reports whether gitserver indexes commits for
+// commit and diff search.
+func CommitSearchIndexEnabled() bool {
+	e := Get().ExperimentalFeatures
+	return e != nil && e.CommitSearchIndex == "enabled"
+}
+
 func SearchMultipleRevisionsPerRepository() bool {
 	x := ExperimentalFeatures()
 	return x.SearchMultipleRevisionsPerRepository != nil && *x.SearchMultipleRevisionsPerRepository
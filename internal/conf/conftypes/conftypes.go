@@ -11,6 +11,22 @@ type ServiceConnections struct {
 	// to.
 	GitServers []string `json:"gitServers"`
 
+	// GitServersSharding is how repositories are assigned to GitServers:
+	// "modulo" (the default) or "consistent".
+	GitServersSharding string `json:"gitServersSharding"`
+
+	// GitServersPrevious is the addresses of gitserver instances before the
+	// most recent change to GitServers. While it is set, repositories are
+	// moved to the gitserver they belong on according to GitServers, and
+	// requests for a repository keep going to the gitserver that held it
+	// before. It is empty when no repositories are being moved.
+	GitServersPrevious []string `json:"gitServersPrevious"`
+
+	// GitServersPreviousSharding is how repositories were assigned to
+	// GitServersPrevious: "modulo" or "consistent". It defaults to
+	// GitServersSharding.
+	GitServersPreviousSharding string `json:"gitServersPreviousSharding"`
+
 	// PostgresDSN is the PostgreSQL DB data source name.
 	// eg: "postgres://sg@pgsql/sourcegraph?sslmode=false"
 	PostgresDSN string `json:"postgresDSN"`
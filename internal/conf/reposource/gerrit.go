@@ -0,0 +1,45 @@
+package reposource
+
+import (
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+type Gerrit struct {
+	*schema.GerritConnection
+}
+
+var _ RepoSource = Gerrit{}
+
+func (c Gerrit) CloneURLToRepoName(cloneURL string) (repoName api.RepoName, err error) {
+	parsedCloneURL, baseURL, match, err := parseURLs(cloneURL, c.Url)
+	if err != nil {
+		return "", err
+	}
+	if !match {
+		return "", nil
+	}
+
+	// Gerrit serves projects below its base URL over HTTP, and below "/a/"
+	// for authenticated requests. The SSH daemon serves them at the root.
+	project := strings.TrimPrefix(parsedCloneURL.Path, "/")
+	if parsedCloneURL.Scheme == "http" || parsedCloneURL.Scheme == "https" {
+		project = strings.TrimPrefix(project, strings.Trim(baseURL.Path, "/")+"/")
+		project = strings.TrimPrefix(project, "a/")
+	}
+	project = strings.TrimSuffix(project, ".git")
+	return GerritRepoName(c.RepositoryPathPattern, baseURL.Hostname(), project), nil
+}
+
+func GerritRepoName(repositoryPathPattern, host, project string) api.RepoName {
+	if repositoryPathPattern == "" {
+		repositoryPathPattern = "{host}/{project}"
+	}
+
+	return api.RepoName(strings.NewReplacer(
+		"{host}", host,
+		"{project}", project,
+	).Replace(repositoryPathPattern))
+}
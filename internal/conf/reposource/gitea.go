@@ -0,0 +1,44 @@
+package reposource
+
+import (
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+type Gitea struct {
+	*schema.GiteaConnection
+}
+
+var _ RepoSource = Gitea{}
+
+func (c Gitea) CloneURLToRepoName(cloneURL string) (repoName api.RepoName, err error) {
+	parsedCloneURL, baseURL, match, err := parseURLs(cloneURL, c.Url)
+	if err != nil {
+		return "", err
+	}
+	if !match {
+		return "", nil
+	}
+
+	// Gitea may be served below a path prefix over HTTP, but its SSH server
+	// serves repositories at the root.
+	nameWithOwner := strings.TrimPrefix(parsedCloneURL.Path, "/")
+	if parsedCloneURL.Scheme == "http" || parsedCloneURL.Scheme == "https" {
+		nameWithOwner = strings.TrimPrefix(nameWithOwner, strings.Trim(baseURL.Path, "/")+"/")
+	}
+	nameWithOwner = strings.TrimSuffix(nameWithOwner, ".git")
+	return GiteaRepoName(c.RepositoryPathPattern, baseURL.Hostname(), nameWithOwner), nil
+}
+
+func GiteaRepoName(repositoryPathPattern, host, nameWithOwner string) api.RepoName {
+	if repositoryPathPattern == "" {
+		repositoryPathPattern = "{host}/{nameWithOwner}"
+	}
+
+	return api.RepoName(strings.NewReplacer(
+		"{host}", host,
+		"{nameWithOwner}", nameWithOwner,
+	).Replace(repositoryPathPattern))
+}
@@ -0,0 +1,51 @@
+package reposource
+
+import (
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestGitea_cloneURLToRepoName(t *testing.T) {
+	tests := []struct {
+		conn schema.GiteaConnection
+		urls []urlToRepoName
+	}{
+		{
+			conn: schema.GiteaConnection{
+				Url: "https://gitea.example.com",
+			},
+			urls: []urlToRepoName{
+				{"https://gitea.example.com/myteam/myproject", "gitea.example.com/myteam/myproject"},
+				{"https://gitea.example.com/myteam/myproject.git", "gitea.example.com/myteam/myproject"},
+				{"https://token@gitea.example.com/myteam/myproject.git", "gitea.example.com/myteam/myproject"},
+				{"git@gitea.example.com:myteam/myproject.git", "gitea.example.com/myteam/myproject"},
+				{"ssh://git@gitea.example.com:2222/myteam/myproject.git", "gitea.example.com/myteam/myproject"},
+
+				{"https://asdf.com/myteam/myproject", ""},
+			},
+		},
+		{
+			conn: schema.GiteaConnection{
+				Url:                   "https://git.example.com/gitea/",
+				RepositoryPathPattern: "gitea/{nameWithOwner}",
+			},
+			urls: []urlToRepoName{
+				{"https://git.example.com/gitea/myteam/myproject.git", "gitea/myteam/myproject"},
+				{"git@git.example.com:myteam/myproject.git", "gitea/myteam/myproject"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		for _, u := range test.urls {
+			repoName, err := Gitea{&test.conn}.CloneURLToRepoName(u.cloneURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if u.repoName != string(repoName) {
+				t.Errorf("expected %q but got %q for clone URL %q (connection: %+v)", u.repoName, repoName, u.cloneURL, test.conn)
+			}
+		}
+	}
+}
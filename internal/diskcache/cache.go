@@ -138,6 +138,20 @@ func (s *Store) OpenWithPath(ctx context.Context, key string, fetcher FetcherWit
 	}
 }
 
+// Lookup returns the path of the item with key if it is in the cache. Unlike
+// Open, it never fetches a missing item, and it does not update the modified
+// time of the item.
+func (s *Store) Lookup(key string) (path string, ok bool) {
+	if s.Dir == "" {
+		return "", false
+	}
+	path = s.path(key)
+	if _, err := os.Stat(path); err != nil {
+		return "", false
+	}
+	return path, true
+}
+
 // path returns the path for key.
 func (s *Store) path(key string) string {
 	// path uses a sha256 hash of the key since we want to use it for the
@@ -23,6 +23,9 @@ func ParseWebhookEvent(eventType string, payload []byte) (e interface{}, err err
 	case "repo:build_status":
 		e = &BuildStatusEvent{}
 		return e, json.Unmarshal(payload, e)
+	case "permission:granted", "permission:revoked":
+		e = &PermissionEvent{}
+		return e, json.Unmarshal(payload, e)
 	default:
 		e = &PullRequestEvent{}
 		return e, json.Unmarshal(payload, e)
@@ -44,6 +47,20 @@ type BuildStatusEvent struct {
 	PullRequests []PullRequest `json:"pullRequests"`
 }
 
+// PermissionEvent is sent when a user or group is granted or revoked a
+// permission. Exactly one of User and Group is set. Repository and Project
+// are set depending on whether it's a repository, project or global
+// permission.
+type PermissionEvent struct {
+	Date       time.Time `json:"date"`
+	Actor      User      `json:"actor"`
+	Permission string    `json:"permission"`
+	User       *User     `json:"user,omitempty"`
+	Group      *Group    `json:"group,omitempty"`
+	Project    *Project  `json:"project,omitempty"`
+	Repository *Repo     `json:"repository,omitempty"`
+}
+
 // Webhook defines the JSON schema from the BBS Sourcegraph plugin.
 // This is not the native BBS webhook.
 type Webhook struct {
@@ -0,0 +1,359 @@
+package gerrit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/opentracing-contrib/go-stdlib/nethttp"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/metrics"
+	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
+)
+
+var requestCounter = metrics.NewRequestMeter("gerrit_requests_count", "Total number of requests sent to the Gerrit API.")
+
+// xssiPrefix is prepended by Gerrit to every JSON response to prevent cross-site script
+// inclusion attacks. It has to be stripped before decoding the response.
+var xssiPrefix = []byte(")]}'")
+
+// Client accesses a Gerrit instance via its REST API.
+//
+// See https://gerrit-review.googlesource.com/Documentation/rest-api.html
+type Client struct {
+	// HTTP Client used to communicate with the API
+	httpClient httpcli.Doer
+
+	// URL is the base URL of the Gerrit instance.
+	URL *url.URL
+
+	// The username and HTTP password credentials for accessing the server. When they are
+	// set, requests are sent to the authenticated "/a/" endpoints.
+	Username, Password string
+}
+
+// NewClient creates a new Gerrit API client for the Gerrit instance at baseURL. If a nil
+// httpClient is provided, http.DefaultClient will be used.
+func NewClient(baseURL *url.URL, httpClient httpcli.Doer) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	httpClient = requestCounter.Doer(httpClient, func(u *url.URL) string {
+		// The first component of the Path after the optional "/a" prefix maps
+		// to the type of API request we are making.
+		p := strings.TrimPrefix(strings.TrimPrefix(u.Path, strings.TrimSuffix(baseURL.Path, "/")), "/a")
+		if parts := strings.SplitN(p, "/", 3); len(parts) > 1 {
+			return parts[1]
+		}
+		return ""
+	})
+
+	return &Client{
+		httpClient: httpClient,
+		URL:        baseURL,
+	}
+}
+
+// ListProjectsArgs are the arguments of ListProjects.
+type ListProjectsArgs struct {
+	// Prefix limits the results to projects whose names start with it.
+	Prefix string
+	// Skip is the number of projects to skip.
+	Skip int
+	// Limit is the maximum number of projects to return.
+	Limit int
+}
+
+// ListProjects returns the code projects visible to the client's user, sorted by name. The
+// returned bool reports whether there may be more projects after the returned ones.
+func (c *Client) ListProjects(ctx context.Context, args ListProjectsArgs) ([]*Project, bool, error) {
+	qry := url.Values{"d": {""}, "type": {"CODE"}}
+	if args.Prefix != "" {
+		qry.Set("p", args.Prefix)
+	}
+	if args.Skip > 0 {
+		qry.Set("S", strconv.Itoa(args.Skip))
+	}
+	if args.Limit > 0 {
+		qry.Set("n", strconv.Itoa(args.Limit))
+	}
+
+	var res map[string]*Project
+	if err := c.get(ctx, "projects/", qry, &res); err != nil {
+		return nil, false, err
+	}
+
+	projects := make([]*Project, 0, len(res))
+	for name, p := range res {
+		p.Name = name
+		projects = append(projects, p)
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].Name < projects[j].Name })
+
+	// Gerrit doesn't indicate whether there are more projects in the map
+	// format, so we assume there are until we get a page shorter than the
+	// limit.
+	return projects, args.Limit > 0 && len(projects) >= args.Limit, nil
+}
+
+// GetProjectAccess returns the access rights of the project with the given name. Only the
+// rights defined on the project itself are returned; inherited rights have to be looked up on
+// the project's parent, which is given by ProjectAccess.InheritsFrom.
+func (c *Client) GetProjectAccess(ctx context.Context, name string) (*ProjectAccess, error) {
+	var res map[string]*ProjectAccess
+	if err := c.get(ctx, "access/", url.Values{"project": {name}}, &res); err != nil {
+		return nil, err
+	}
+	access, ok := res[name]
+	if !ok {
+		return nil, &httpError{StatusCode: http.StatusNotFound, URL: c.URL, Body: []byte("project access not found: " + name)}
+	}
+	return access, nil
+}
+
+// GetAccount returns the account with the given ID, which may be the numeric account ID or
+// the username.
+func (c *Client) GetAccount(ctx context.Context, id string) (*Account, error) {
+	var a Account
+	if err := c.get(ctx, "accounts/"+url.PathEscape(id), nil, &a); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// ListAccountGroups returns the groups the account with the given ID is a direct or indirect
+// member of. System groups like Registered Users are not included.
+func (c *Client) ListAccountGroups(ctx context.Context, accountID string) ([]*Group, error) {
+	var groups []*Group
+	if err := c.get(ctx, "accounts/"+url.PathEscape(accountID)+"/groups", nil, &groups); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// ListGroupMembers returns the accounts which are direct or indirect members of the group
+// with the given UUID. Members of system groups and of external groups can't be listed.
+func (c *Client) ListGroupMembers(ctx context.Context, groupUUID string) ([]*Account, error) {
+	var accounts []*Account
+	if err := c.get(ctx, "groups/"+url.PathEscape(groupUUID)+"/members/", url.Values{"recursive": {""}}, &accounts); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// get requests the API endpoint at path, whose segments must be escaped with
+// url.PathEscape, so that names containing "/" stay a single segment.
+func (c *Client) get(ctx context.Context, path string, qry url.Values, result interface{}) error {
+	if c.Username != "" {
+		path = "a/" + path
+	}
+	unescaped, err := url.PathUnescape(path)
+	if err != nil {
+		return err
+	}
+	u := &url.URL{Path: unescaped, RawPath: path}
+	if len(qry) > 0 {
+		// Gerrit expects flags like "d" without a value.
+		u.RawQuery = strings.Replace(qry.Encode(), "=&", "&", -1)
+		u.RawQuery = strings.TrimSuffix(u.RawQuery, "=")
+	}
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, req, result)
+}
+
+func (c *Client) do(ctx context.Context, req *http.Request, result interface{}) error {
+	base := *c.URL
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path += "/"
+	}
+	req.URL = base.ResolveReference(req.URL)
+	req.Header.Set("Accept", "application/json")
+
+	req, ht := nethttp.TraceRequest(ot.GetTracer(ctx),
+		req.WithContext(ctx),
+		nethttp.OperationName("Gerrit"),
+		nethttp.ClientTrace(false))
+	defer ht.Finish()
+
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	bs, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return errors.WithStack(&httpError{
+			URL:        req.URL,
+			StatusCode: resp.StatusCode,
+			Body:       bs,
+		})
+	}
+
+	if result != nil {
+		return json.Unmarshal(bytes.TrimPrefix(bs, xssiPrefix), result)
+	}
+
+	return nil
+}
+
+// ProjectState is the state of a Gerrit project.
+type ProjectState string
+
+const (
+	ProjectStateActive   ProjectState = "ACTIVE"
+	ProjectStateReadOnly ProjectState = "READ_ONLY"
+	ProjectStateHidden   ProjectState = "HIDDEN"
+)
+
+// Project is a Gerrit project, i.e. a Git repository.
+type Project struct {
+	// ID is the URL encoded project name.
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	Parent      string       `json:"parent,omitempty"`
+	Description string       `json:"description,omitempty"`
+	State       ProjectState `json:"state,omitempty"`
+	WebLinks    []*WebLink   `json:"web_links,omitempty"`
+}
+
+// WebLink is a link to an external repository browser, such as Gitiles.
+type WebLink struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// Gitiles returns the link to the project in the Gitiles repository browser, or nil if Gitiles
+// is not configured.
+func (p *Project) Gitiles() *WebLink {
+	for _, l := range p.WebLinks {
+		if strings.EqualFold(l.Name, "gitiles") || strings.EqualFold(l.Name, "browse") {
+			return l
+		}
+	}
+	return nil
+}
+
+// ProjectAccess describes the access rights of a project.
+type ProjectAccess struct {
+	Revision     string `json:"revision"`
+	InheritsFrom *struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"inherits_from,omitempty"`
+	// Local maps ref patterns, such as "refs/*", to their access sections.
+	Local  map[string]*AccessSection `json:"local"`
+	Groups map[string]*Group         `json:"groups,omitempty"`
+}
+
+// Parent returns the name of the project the project inherits access rights from. It is empty
+// for the root project All-Projects.
+func (a *ProjectAccess) Parent() string {
+	if a.InheritsFrom == nil {
+		return ""
+	}
+	if a.InheritsFrom.Name != "" {
+		return a.InheritsFrom.Name
+	}
+	name, _ := url.PathUnescape(a.InheritsFrom.ID)
+	return name
+}
+
+// AccessSection holds the permissions defined for a ref pattern.
+type AccessSection struct {
+	// Permissions maps permission names, such as "read", to the permission.
+	Permissions map[string]*Permission `json:"permissions"`
+}
+
+// Permission is a permission of an access section.
+type Permission struct {
+	Exclusive bool `json:"exclusive,omitempty"`
+	// Rules maps group UUIDs to the rule for the group.
+	Rules map[string]*PermissionRule `json:"rules"`
+}
+
+// PermissionAction is the action of a permission rule.
+type PermissionAction string
+
+const (
+	PermissionActionAllow PermissionAction = "ALLOW"
+	PermissionActionDeny  PermissionAction = "DENY"
+	PermissionActionBlock PermissionAction = "BLOCK"
+)
+
+// PermissionRule is the rule of a permission for a group.
+type PermissionRule struct {
+	Action PermissionAction `json:"action"`
+	Force  bool             `json:"force,omitempty"`
+}
+
+// Account is a Gerrit user account.
+type Account struct {
+	ID       int    `json:"_account_id"`
+	Name     string `json:"name,omitempty"`
+	Email    string `json:"email,omitempty"`
+	Username string `json:"username,omitempty"`
+}
+
+// Group is a Gerrit group.
+type Group struct {
+	// ID is the URL encoded UUID of the group.
+	ID      string `json:"id"`
+	Name    string `json:"name,omitempty"`
+	GroupID int    `json:"group_id,omitempty"`
+}
+
+// UUID returns the decoded UUID of the group.
+func (g *Group) UUID() string {
+	uuid, err := url.PathUnescape(g.ID)
+	if err != nil {
+		return g.ID
+	}
+	return uuid
+}
+
+type httpError struct {
+	StatusCode int
+	URL        *url.URL
+	Body       []byte
+}
+
+func (e *httpError) Error() string {
+	return fmt.Sprintf("Gerrit API HTTP error: code=%d url=%q body=%q", e.StatusCode, e.URL, e.Body)
+}
+
+func (e *httpError) Unauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized
+}
+
+func (e *httpError) NotFound() bool {
+	return e.StatusCode == http.StatusNotFound
+}
+
+// IsNotFound reports whether err is a Gerrit API error of type NOT_FOUND.
+func IsNotFound(err error) bool {
+	e, ok := errors.Cause(err).(*httpError)
+	return ok && e.NotFound()
+}
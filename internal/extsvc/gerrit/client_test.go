@@ -0,0 +1,182 @@
+package gerrit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL + "/r")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cli := NewClient(u, nil)
+	cli.Username = "admin"
+	cli.Password = "secret"
+	return cli
+}
+
+func TestClient_ListProjects(t *testing.T) {
+	cli := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "admin" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if have, want := r.URL.Path, "/r/a/projects/"; have != want {
+			t.Errorf("path: have %q, want %q", have, want)
+		}
+		if have, want := r.URL.RawQuery, "S=2&d&n=2&type=CODE"; have != want {
+			t.Errorf("query: have %q, want %q", have, want)
+		}
+		_, _ = w.Write([]byte(`)]}'
+{
+  "foo/bar": {"id": "foo%2Fbar", "description": "Bar", "state": "ACTIVE", "web_links": [{"name": "gitiles", "url": "https://gerrit.example.com/plugins/gitiles/foo/bar"}]},
+  "baz": {"id": "baz", "state": "READ_ONLY"}
+}`))
+	})
+
+	projects, more, err := cli.ListProjects(context.Background(), ListProjectsArgs{Skip: 2, Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*Project{
+		{ID: "baz", Name: "baz", State: ProjectStateReadOnly},
+		{
+			ID:          "foo%2Fbar",
+			Name:        "foo/bar",
+			Description: "Bar",
+			State:       ProjectStateActive,
+			WebLinks:    []*WebLink{{Name: "gitiles", URL: "https://gerrit.example.com/plugins/gitiles/foo/bar"}},
+		},
+	}
+	if diff := cmp.Diff(want, projects); diff != "" {
+		t.Errorf("projects: %s", diff)
+	}
+	if !more {
+		t.Error("expected more projects for a full page")
+	}
+	if have, want := projects[1].Gitiles(), projects[1].WebLinks[0]; have != want {
+		t.Errorf("gitiles link: have %v, want %v", have, want)
+	}
+}
+
+func TestClient_GetProjectAccess(t *testing.T) {
+	cli := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("project") != "foo/bar" {
+			// Gerrit omits projects it doesn't know about.
+			_, _ = w.Write([]byte(")]}'\n{}"))
+			return
+		}
+		_, _ = w.Write([]byte(`)]}'
+{
+  "foo/bar": {
+    "revision": "61157ed63e14d261b6dca40650472a9b0bd88474",
+    "inherits_from": {"id": "All-Projects", "name": "All-Projects"},
+    "local": {
+      "refs/*": {"permissions": {"read": {"rules": {"c2ce4749a32ceb82cd6adcce65b8216e12afb41c": {"action": "ALLOW"}}}}}
+    },
+    "groups": {"c2ce4749a32ceb82cd6adcce65b8216e12afb41c": {"name": "Developers"}}
+  }
+}`))
+	})
+
+	access, err := cli.GetProjectAccess(context.Background(), "foo/bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := access.Parent(), "All-Projects"; have != want {
+		t.Errorf("parent: have %q, want %q", have, want)
+	}
+	rule := access.Local["refs/*"].Permissions["read"].Rules["c2ce4749a32ceb82cd6adcce65b8216e12afb41c"]
+	if rule == nil || rule.Action != PermissionActionAllow {
+		t.Errorf("unexpected read rule: %+v", rule)
+	}
+
+	if _, err := cli.GetProjectAccess(context.Background(), "missing"); !IsNotFound(err) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func TestClient_GetAccount(t *testing.T) {
+	cli := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/r/a/accounts/alice":
+			_, _ = w.Write([]byte(`)]}'
+{"_account_id": 1000096, "name": "Alice", "email": "alice@example.com", "username": "alice"}`))
+		case "/r/a/accounts/1000096/groups":
+			_, _ = w.Write([]byte(`)]}'
+[{"id": "c2ce4749a32ceb82cd6adcce65b8216e12afb41c", "name": "Developers", "group_id": 3}, {"id": "ldap%3Acn%3Dops", "name": "ops"}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	account, err := cli.GetAccount(context.Background(), "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(&Account{ID: 1000096, Name: "Alice", Email: "alice@example.com", Username: "alice"}, account); diff != "" {
+		t.Errorf("account: %s", diff)
+	}
+
+	groups, err := cli.ListAccountGroups(context.Background(), "1000096")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var uuids []string
+	for _, g := range groups {
+		uuids = append(uuids, g.UUID())
+	}
+	if diff := cmp.Diff([]string{"c2ce4749a32ceb82cd6adcce65b8216e12afb41c", "ldap:cn=ops"}, uuids); diff != "" {
+		t.Errorf("groups: %s", diff)
+	}
+
+	if _, err := cli.GetAccount(context.Background(), "bob"); !IsNotFound(err) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func TestClient_EscapedNames(t *testing.T) {
+	cli := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.RequestURI {
+		case "/r/a/accounts/ops%2Falice":
+			_, _ = w.Write([]byte(`)]}'
+{"_account_id": 1000097, "username": "ops/alice"}`))
+		case "/r/a/groups/ldap:cn=ops%2Fdev%25/members/?recursive":
+			_, _ = w.Write([]byte(`)]}'
+[{"_account_id": 1000097, "username": "ops/alice"}]`))
+		default:
+			t.Errorf("unexpected request URI %q", r.RequestURI)
+			http.NotFound(w, r)
+		}
+	})
+
+	account, err := cli.GetAccount(context.Background(), "ops/alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.ID != 1000097 {
+		t.Errorf("unexpected account %+v", account)
+	}
+
+	members, err := cli.ListGroupMembers(context.Background(), "ldap:cn=ops/dev%")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 1 || members[0].Username != "ops/alice" {
+		t.Errorf("unexpected members %+v", members)
+	}
+}
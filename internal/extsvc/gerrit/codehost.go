@@ -0,0 +1,12 @@
+package gerrit
+
+// ServiceType is the (api.ExternalRepoSpec).ServiceType value for Gerrit projects. The ServiceID
+// value is the base URL to the Gerrit instance.
+const ServiceType = "gerrit"
+
+// The UUIDs of the system groups every Gerrit instance has. Their members can't be listed.
+const (
+	GroupAnonymousUsers  = "global:Anonymous-Users"
+	GroupRegisteredUsers = "global:Registered-Users"
+	GroupProjectOwners   = "global:Project-Owners"
+)
@@ -0,0 +1,364 @@
+package gitea
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/opentracing-contrib/go-stdlib/nethttp"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/metrics"
+	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
+)
+
+var requestCounter = metrics.NewRequestMeter("gitea_requests_count", "Total number of requests sent to the Gitea API.")
+
+// apiPath is the path of the REST API relative to the base URL of a Gitea or Gogs instance.
+const apiPath = "api/v1/"
+
+// Client accesses a Gitea or Gogs instance via its REST API. Gogs implements a subset of
+// the Gitea API, so not all methods work with Gogs.
+//
+// See https://try.gitea.io/api/swagger
+type Client struct {
+	// HTTP Client used to communicate with the API
+	httpClient httpcli.Doer
+
+	// URL is the base URL of the Gitea instance.
+	URL *url.URL
+
+	// Token is the access token used to authenticate requests. If it is empty, requests
+	// are sent anonymously.
+	Token string
+}
+
+// NewClient creates a new Gitea API client for the Gitea instance at baseURL. If a nil
+// httpClient is provided, http.DefaultClient will be used.
+func NewClient(baseURL *url.URL, httpClient httpcli.Doer) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	httpClient = requestCounter.Doer(httpClient, func(u *url.URL) string {
+		// The first component of the Path after the API prefix maps to the
+		// type of API request we are making.
+		p := strings.TrimPrefix(u.Path, strings.TrimSuffix(baseURL.Path, "/")+"/"+apiPath)
+		return strings.SplitN(p, "/", 2)[0]
+	})
+
+	return &Client{
+		httpClient: httpClient,
+		URL:        baseURL,
+	}
+}
+
+// ListArgs are the pagination arguments of the list methods.
+type ListArgs struct {
+	// Page is the 1-based number of the page to return.
+	Page int
+	// Limit is the maximum number of results per page. Gitea caps it at the
+	// instance's MAX_RESPONSE_ITEMS setting, which is 50 by default.
+	Limit int
+}
+
+func (a ListArgs) values() url.Values {
+	qry := url.Values{}
+	if a.Page > 0 {
+		qry.Set("page", strconv.Itoa(a.Page))
+	}
+	if a.Limit > 0 {
+		qry.Set("limit", strconv.Itoa(a.Limit))
+	}
+	return qry
+}
+
+// ListOrgRepos returns a page of the repositories of the given organization that are
+// visible to the client's user. The returned bool reports whether there may be more
+// repositories after the returned ones.
+func (c *Client) ListOrgRepos(ctx context.Context, org string, args ListArgs) ([]*Repository, bool, error) {
+	return c.listRepos(ctx, "orgs/"+url.PathEscape(org)+"/repos", args)
+}
+
+// ListUserRepos returns a page of the repositories owned by the given user that are
+// visible to the client's user. The returned bool reports whether there may be more
+// repositories after the returned ones.
+func (c *Client) ListUserRepos(ctx context.Context, username string, args ListArgs) ([]*Repository, bool, error) {
+	return c.listRepos(ctx, "users/"+url.PathEscape(username)+"/repos", args)
+}
+
+// ListAuthenticatedUserRepos returns a page of the repositories the client's user owns
+// or can access as a collaborator or organization member. The returned bool reports
+// whether there may be more repositories after the returned ones.
+func (c *Client) ListAuthenticatedUserRepos(ctx context.Context, args ListArgs) ([]*Repository, bool, error) {
+	return c.listRepos(ctx, "user/repos", args)
+}
+
+// SearchRepos returns a page of all repositories visible to the client's user. For site
+// administrators, these are all repositories on the instance. The returned bool reports
+// whether there may be more repositories after the returned ones.
+func (c *Client) SearchRepos(ctx context.Context, args ListArgs) ([]*Repository, bool, error) {
+	var res struct {
+		OK   bool          `json:"ok"`
+		Data []*Repository `json:"data"`
+	}
+	h, err := c.get(ctx, "repos/search", args.values(), &res)
+	if err != nil {
+		return nil, false, err
+	}
+	return res.Data, hasNextPage(h, len(res.Data), args.Limit), nil
+}
+
+func (c *Client) listRepos(ctx context.Context, path string, args ListArgs) ([]*Repository, bool, error) {
+	var repos []*Repository
+	h, err := c.get(ctx, path, args.values(), &repos)
+	if err != nil {
+		return nil, false, err
+	}
+	return repos, hasNextPage(h, len(repos), args.Limit), nil
+}
+
+// GetRepoByID returns the repository with the given ID. It is not supported by Gogs.
+func (c *Client) GetRepoByID(ctx context.Context, id int64) (*Repository, error) {
+	var r Repository
+	if _, err := c.get(ctx, "repositories/"+strconv.FormatInt(id, 10), nil, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// GetUser returns the user with the given username.
+func (c *Client) GetUser(ctx context.Context, username string) (*User, error) {
+	var u User
+	if _, err := c.get(ctx, "users/"+url.PathEscape(username), nil, &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// GetOrg returns the organization with the given name. It is not supported by Gogs.
+func (c *Client) GetOrg(ctx context.Context, name string) (*User, error) {
+	var u User
+	if _, err := c.get(ctx, "orgs/"+url.PathEscape(name), nil, &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// GetOwner returns the organization or user with the given name, which can own
+// repositories.
+func (c *Client) GetOwner(ctx context.Context, name string) (*User, error) {
+	u, err := c.GetOrg(ctx, name)
+	if IsNotFound(err) {
+		return c.GetUser(ctx, name)
+	}
+	return u, err
+}
+
+// GetAuthenticatedUser returns the client's user.
+func (c *Client) GetAuthenticatedUser(ctx context.Context) (*User, error) {
+	var u User
+	if _, err := c.get(ctx, "user", nil, &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// ListCollaborators returns a page of the collaborators of the given repository. The
+// returned bool reports whether there may be more collaborators after the returned ones.
+func (c *Client) ListCollaborators(ctx context.Context, owner, name string, args ListArgs) ([]*User, bool, error) {
+	var users []*User
+	h, err := c.get(ctx, "repos/"+url.PathEscape(owner)+"/"+url.PathEscape(name)+"/collaborators", args.values(), &users)
+	if err != nil {
+		return nil, false, err
+	}
+	return users, hasNextPage(h, len(users), args.Limit), nil
+}
+
+// ListRepoTeams returns the teams of the organization owning the given repository that
+// have access to it. Repositories owned by users have no teams. It is not supported by
+// Gogs.
+func (c *Client) ListRepoTeams(ctx context.Context, owner, name string) ([]*Team, error) {
+	var teams []*Team
+	if _, err := c.get(ctx, "repos/"+url.PathEscape(owner)+"/"+url.PathEscape(name)+"/teams", nil, &teams); err != nil {
+		// Gitea rejects the request if the owner isn't an organization.
+		if e, ok := errors.Cause(err).(*httpError); ok && e.StatusCode == http.StatusMethodNotAllowed {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return teams, nil
+}
+
+// ListTeamMembers returns a page of the members of the team with the given ID. The
+// returned bool reports whether there may be more members after the returned ones.
+func (c *Client) ListTeamMembers(ctx context.Context, teamID int64, args ListArgs) ([]*User, bool, error) {
+	var users []*User
+	h, err := c.get(ctx, "teams/"+strconv.FormatInt(teamID, 10)+"/members", args.values(), &users)
+	if err != nil {
+		return nil, false, err
+	}
+	return users, hasNextPage(h, len(users), args.Limit), nil
+}
+
+// hasNextPage reports whether there may be a page after the one with n results that was
+// requested with the given limit. Gitea links to the next page in the Link header, which
+// Gogs and older versions of Gitea don't set, in which case we assume there are more
+// results until we get a page shorter than the limit.
+func hasNextPage(h http.Header, n, limit int) bool {
+	if link := h.Get("Link"); link != "" {
+		return strings.Contains(link, `rel="next"`)
+	}
+	return limit > 0 && n >= limit
+}
+
+func (c *Client) get(ctx context.Context, path string, qry url.Values, result interface{}) (http.Header, error) {
+	// The path segments are already escaped.
+	u, err := url.Parse(apiPath + path)
+	if err != nil {
+		return nil, err
+	}
+	if len(qry) > 0 {
+		u.RawQuery = qry.Encode()
+	}
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(ctx, req, result)
+}
+
+func (c *Client) do(ctx context.Context, req *http.Request, result interface{}) (http.Header, error) {
+	base := *c.URL
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path += "/"
+	}
+	req.URL = base.ResolveReference(req.URL)
+	req.Header.Set("Accept", "application/json")
+
+	req, ht := nethttp.TraceRequest(ot.GetTracer(ctx),
+		req.WithContext(ctx),
+		nethttp.OperationName("Gitea"),
+		nethttp.ClientTrace(false))
+	defer ht.Finish()
+
+	if c.Token != "" {
+		req.Header.Set("Authorization", "token "+c.Token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	bs, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return nil, errors.WithStack(&httpError{
+			URL:        req.URL,
+			StatusCode: resp.StatusCode,
+			Body:       bs,
+		})
+	}
+
+	if result != nil {
+		return resp.Header, json.Unmarshal(bs, result)
+	}
+
+	return resp.Header, nil
+}
+
+// Repository is a Gitea repository.
+type Repository struct {
+	ID            int64  `json:"id"`
+	Owner         *User  `json:"owner"`
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	Description   string `json:"description,omitempty"`
+	Private       bool   `json:"private"`
+	Internal      bool   `json:"internal"` // visible to signed-in users
+	Fork          bool   `json:"fork"`
+	Mirror        bool   `json:"mirror"`
+	Archived      bool   `json:"archived"`
+	HTMLURL       string `json:"html_url"`
+	SSHURL        string `json:"ssh_url"`
+	CloneURL      string `json:"clone_url"`
+	DefaultBranch string `json:"default_branch,omitempty"`
+}
+
+// User is a Gitea user or organization.
+type User struct {
+	ID    int64  `json:"id"`
+	Login string `json:"login,omitempty"`
+	// Username is the name of the user as reported by Gogs and older versions
+	// of Gitea, which don't always set Login.
+	Username string `json:"username,omitempty"`
+	FullName string `json:"full_name,omitempty"`
+	Email    string `json:"email,omitempty"`
+	// Visibility is one of the Visibility* constants. Gogs and older versions
+	// of Gitea don't report it.
+	Visibility string `json:"visibility,omitempty"`
+}
+
+// Visibility levels of users and organizations.
+const (
+	VisibilityPublic  = "public"
+	VisibilityLimited = "limited" // visible to signed-in users
+	VisibilityPrivate = "private" // visible to members
+)
+
+// Name returns the login name of the user.
+func (u *User) Name() string {
+	if u.Login != "" {
+		return u.Login
+	}
+	return u.Username
+}
+
+// Public reports whether the user is visible to everyone, including anonymous
+// visitors. Only public users and organizations can own public repositories.
+func (u *User) Public() bool {
+	return u.Visibility == "" || u.Visibility == VisibilityPublic
+}
+
+// Team is a team of a Gitea organization.
+type Team struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	Permission string `json:"permission,omitempty"`
+}
+
+type httpError struct {
+	StatusCode int
+	URL        *url.URL
+	Body       []byte
+}
+
+func (e *httpError) Error() string {
+	return fmt.Sprintf("Gitea API HTTP error: code=%d url=%q body=%q", e.StatusCode, e.URL, e.Body)
+}
+
+func (e *httpError) Unauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized
+}
+
+func (e *httpError) NotFound() bool {
+	return e.StatusCode == http.StatusNotFound
+}
+
+// IsNotFound reports whether err is a Gitea API error of type NOT_FOUND.
+func IsNotFound(err error) bool {
+	e, ok := errors.Cause(err).(*httpError)
+	return ok && e.NotFound()
+}
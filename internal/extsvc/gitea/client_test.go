@@ -0,0 +1,141 @@
+package gitea
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL + "/gitea")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cli := NewClient(u, nil)
+	cli.Token = "secret"
+	return cli
+}
+
+func TestClient_ListOrgRepos(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		link     string
+		wantMore bool
+	}{
+		{name: "link to next page", link: `<https://gitea.example.com/api/v1/orgs/my%20team/repos?limit=2&page=3>; rel="next"`, wantMore: true},
+		{name: "link to last page only", link: `<https://gitea.example.com/api/v1/orgs/my%20team/repos?limit=2&page=1>; rel="first"`, wantMore: false},
+		{name: "no link header", wantMore: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cli := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if have, want := r.Header.Get("Authorization"), "token secret"; have != want {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				if have, want := r.URL.EscapedPath(), "/gitea/api/v1/orgs/my%20team/repos"; have != want {
+					t.Errorf("path: have %q, want %q", have, want)
+				}
+				if have, want := r.URL.RawQuery, "limit=2&page=2"; have != want {
+					t.Errorf("query: have %q, want %q", have, want)
+				}
+				if tc.link != "" {
+					w.Header().Set("Link", tc.link)
+				}
+				_, _ = w.Write([]byte(`[
+  {"id": 1, "owner": {"id": 10, "login": "my team"}, "name": "foo", "full_name": "my team/foo", "description": "Foo", "fork": true},
+  {"id": 2, "owner": {"id": 10, "username": "my team"}, "name": "bar", "full_name": "my team/bar", "private": true, "archived": true}
+]`))
+			})
+
+			repos, more, err := cli.ListOrgRepos(context.Background(), "my team", ListArgs{Page: 2, Limit: 2})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := []*Repository{
+				{ID: 1, Owner: &User{ID: 10, Login: "my team"}, Name: "foo", FullName: "my team/foo", Description: "Foo", Fork: true},
+				{ID: 2, Owner: &User{ID: 10, Username: "my team"}, Name: "bar", FullName: "my team/bar", Private: true, Archived: true},
+			}
+			if diff := cmp.Diff(want, repos); diff != "" {
+				t.Errorf("repos: %s", diff)
+			}
+			if more != tc.wantMore {
+				t.Errorf("more: have %v, want %v", more, tc.wantMore)
+			}
+			if have, want := repos[1].Owner.Name(), "my team"; have != want {
+				t.Errorf("owner name: have %q, want %q", have, want)
+			}
+		})
+	}
+}
+
+func TestClient_SearchRepos(t *testing.T) {
+	cli := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if have, want := r.URL.Path, "/gitea/api/v1/repos/search"; have != want {
+			t.Errorf("path: have %q, want %q", have, want)
+		}
+		_, _ = w.Write([]byte(`{"ok": true, "data": [{"id": 1, "full_name": "alice/foo"}]}`))
+	})
+
+	repos, more, err := cli.SearchRepos(context.Background(), ListArgs{Page: 1, Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]*Repository{{ID: 1, FullName: "alice/foo"}}, repos); diff != "" {
+		t.Errorf("repos: %s", diff)
+	}
+	if more {
+		t.Error("expected no more repos for a short page")
+	}
+}
+
+func TestClient_GetUser_NotFound(t *testing.T) {
+	cli := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "user does not exist"}`, http.StatusNotFound)
+	})
+
+	_, err := cli.GetUser(context.Background(), "nobody")
+	if !IsNotFound(err) {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+}
+
+func TestClient_GetOwner(t *testing.T) {
+	cli := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gitea/api/v1/orgs/myteam":
+			_, _ = w.Write([]byte(`{"id": 10, "username": "myteam", "visibility": "private"}`))
+		case "/gitea/api/v1/users/alice":
+			_, _ = w.Write([]byte(`{"id": 11, "login": "alice"}`))
+		default:
+			http.Error(w, `{"message": "not found"}`, http.StatusNotFound)
+		}
+	})
+
+	for name, want := range map[string]*User{
+		"myteam": {ID: 10, Username: "myteam", Visibility: VisibilityPrivate},
+		"alice":  {ID: 11, Login: "alice"},
+	} {
+		owner, err := cli.GetOwner(context.Background(), name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, owner); diff != "" {
+			t.Errorf("owner %q: %s", name, diff)
+		}
+	}
+
+	if _, err := cli.GetOwner(context.Background(), "nobody"); !IsNotFound(err) {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+}
@@ -26,15 +26,16 @@ func SplitRepositoryNameWithOwner(nameWithOwner string) (owner, repo string, err
 
 // Repository is a GitHub repository.
 type Repository struct {
-	ID               string // ID of repository (GitHub GraphQL ID, not GitHub database ID)
-	DatabaseID       int64  // The integer database id
-	NameWithOwner    string // full name of repository ("owner/name")
-	Description      string // description of repository
-	URL              string // the web URL of this repository ("https://github.com/foo/bar")
-	IsPrivate        bool   // whether the repository is private
-	IsFork           bool   // whether the repository is a fork of another repository
-	IsArchived       bool   // whether the repository is archived on the code host
-	ViewerPermission string // ADMIN, WRITE, READ, or empty if unknown. Only the graphql api populates this. https://developer.github.com/v4/enum/repositorypermission/
+	ID               string   // ID of repository (GitHub GraphQL ID, not GitHub database ID)
+	DatabaseID       int64    // The integer database id
+	NameWithOwner    string   // full name of repository ("owner/name")
+	Description      string   // description of repository
+	URL              string   // the web URL of this repository ("https://github.com/foo/bar")
+	IsPrivate        bool     // whether the repository is private
+	IsFork           bool     // whether the repository is a fork of another repository
+	IsArchived       bool     // whether the repository is archived on the code host
+	ViewerPermission string   // ADMIN, WRITE, READ, or empty if unknown. Only the graphql api populates this. https://developer.github.com/v4/enum/repositorypermission/
+	Topics           []string `json:",omitempty"` // topics of the repository. Only the rest api populates this.
 }
 
 // repositoryFieldsGraphQLFragment returns a GraphQL fragment that contains the fields needed to populate the
@@ -252,6 +253,7 @@ type restRepository struct {
 	Fork        bool
 	Archived    bool
 	Permissions restRepositoryPermissions `json:"permissions"`
+	Topics      []string                  `json:"topics"`
 }
 
 // getRepositoryFromAPI attempts to fetch a repository from the GitHub API without use of the redis cache.
@@ -283,6 +285,7 @@ func convertRestRepo(restRepo restRepository) *Repository {
 		IsFork:           restRepo.Fork,
 		IsArchived:       restRepo.Archived,
 		ViewerPermission: convertRestRepoPermissions(restRepo.Permissions),
+		Topics:           restRepo.Topics,
 	}
 }
 
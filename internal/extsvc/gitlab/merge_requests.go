@@ -0,0 +1,244 @@
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/peterhellberg/link"
+	"github.com/pkg/errors"
+)
+
+type MergeRequestState string
+
+const (
+	MergeRequestStateOpened MergeRequestState = "opened"
+	MergeRequestStateClosed MergeRequestState = "closed"
+	MergeRequestStateLocked MergeRequestState = "locked"
+	MergeRequestStateMerged MergeRequestState = "merged"
+)
+
+// MergeRequest is a GitLab merge request (equivalent to a GitHub pull request).
+type MergeRequest struct {
+	ID           int               `json:"id"`
+	IID          int               `json:"iid"`
+	ProjectID    int               `json:"project_id"`
+	Title        string            `json:"title"`
+	Description  string            `json:"description"`
+	State        MergeRequestState `json:"state"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+	WebURL       string            `json:"web_url"`
+	TargetBranch string            `json:"target_branch"`
+	SourceBranch string            `json:"source_branch"`
+	SHA          string            `json:"sha"`
+	DiffRefs     DiffRefs          `json:"diff_refs"`
+	Author       User              `json:"author"`
+	Labels       []string          `json:"labels"`
+
+	// Notes and Pipelines are not part of the merge request API response. They
+	// are loaded with separate requests by LoadMergeRequestNotes and
+	// LoadMergeRequestPipelines.
+	Notes     []*Note     `json:"notes,omitempty"`
+	Pipelines []*Pipeline `json:"pipelines,omitempty"`
+}
+
+// DiffRefs are the commits a merge request's diff is computed from.
+type DiffRefs struct {
+	BaseSHA  string `json:"base_sha"`
+	HeadSHA  string `json:"head_sha"`
+	StartSHA string `json:"start_sha"`
+}
+
+// ErrMergeRequestAlreadyExists is returned by CreateMergeRequest when an open
+// merge request already exists for the same source and target branches.
+var ErrMergeRequestAlreadyExists = errors.New("merge request already exists")
+
+// ErrMergeRequestNotFound is returned when the requested merge request does
+// not exist.
+var ErrMergeRequestNotFound = errors.New("merge request not found")
+
+// CreateMergeRequestOpts are the options used to create a merge request.
+type CreateMergeRequestOpts struct {
+	SourceBranch string `json:"source_branch"`
+	TargetBranch string `json:"target_branch"`
+	Title        string `json:"title"`
+	Description  string `json:"description,omitempty"`
+}
+
+// CreateMergeRequest creates a merge request in the project with the given
+// ID. If an open merge request for the same branches already exists,
+// ErrMergeRequestAlreadyExists is returned.
+func (c *Client) CreateMergeRequest(ctx context.Context, projectID int, opts CreateMergeRequestOpts) (*MergeRequest, error) {
+	if MockCreateMergeRequest != nil {
+		return MockCreateMergeRequest(c, ctx, projectID, opts)
+	}
+
+	req, err := newJSONRequest("POST", fmt.Sprintf("projects/%d/merge_requests", projectID), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var mr MergeRequest
+	if _, err := c.do(ctx, req, &mr); err != nil {
+		if HTTPErrorCode(err) == http.StatusConflict {
+			return nil, ErrMergeRequestAlreadyExists
+		}
+		return nil, err
+	}
+	return &mr, nil
+}
+
+// GetMergeRequest gets the merge request with the given IID in the project
+// with the given ID.
+func (c *Client) GetMergeRequest(ctx context.Context, projectID, iid int) (*MergeRequest, error) {
+	if MockGetMergeRequest != nil {
+		return MockGetMergeRequest(c, ctx, projectID, iid)
+	}
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("projects/%d/merge_requests/%d", projectID, iid), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var mr MergeRequest
+	if _, err := c.do(ctx, req, &mr); err != nil {
+		if HTTPErrorCode(err) == http.StatusNotFound {
+			return nil, ErrMergeRequestNotFound
+		}
+		return nil, err
+	}
+	return &mr, nil
+}
+
+// GetOpenMergeRequestByRefs returns the open merge request from the source
+// branch to the target branch in the project with the given ID.
+func (c *Client) GetOpenMergeRequestByRefs(ctx context.Context, projectID int, sourceBranch, targetBranch string) (*MergeRequest, error) {
+	if MockGetOpenMergeRequestByRefs != nil {
+		return MockGetOpenMergeRequestByRefs(c, ctx, projectID, sourceBranch, targetBranch)
+	}
+
+	q := url.Values{}
+	q.Set("state", string(MergeRequestStateOpened))
+	q.Set("source_branch", sourceBranch)
+	q.Set("target_branch", targetBranch)
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("projects/%d/merge_requests?%s", projectID, q.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var mrs []*MergeRequest
+	if _, err := c.do(ctx, req, &mrs); err != nil {
+		return nil, err
+	}
+	if len(mrs) == 0 {
+		return nil, ErrMergeRequestNotFound
+	}
+	return mrs[0], nil
+}
+
+// UpdateMergeRequestOpts are the options used to update a merge request. Empty
+// fields are left unchanged.
+type UpdateMergeRequestOpts struct {
+	TargetBranch string `json:"target_branch,omitempty"`
+	Title        string `json:"title,omitempty"`
+	Description  string `json:"description,omitempty"`
+	// StateEvent is either "close" or "reopen".
+	StateEvent string `json:"state_event,omitempty"`
+}
+
+// UpdateMergeRequest updates the given merge request and returns the updated
+// merge request.
+func (c *Client) UpdateMergeRequest(ctx context.Context, mr *MergeRequest, opts UpdateMergeRequestOpts) (*MergeRequest, error) {
+	if MockUpdateMergeRequest != nil {
+		return MockUpdateMergeRequest(c, ctx, mr, opts)
+	}
+
+	req, err := newJSONRequest("PUT", fmt.Sprintf("projects/%d/merge_requests/%d", mr.ProjectID, mr.IID), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var updated MergeRequest
+	if _, err := c.do(ctx, req, &updated); err != nil {
+		if HTTPErrorCode(err) == http.StatusNotFound {
+			return nil, ErrMergeRequestNotFound
+		}
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// LoadMergeRequestNotes loads all notes of the merge request into mr.Notes.
+func (c *Client) LoadMergeRequestNotes(ctx context.Context, mr *MergeRequest) error {
+	if MockLoadMergeRequestNotes != nil {
+		return MockLoadMergeRequestNotes(c, ctx, mr)
+	}
+
+	var notes []*Note
+	urlStr := fmt.Sprintf("projects/%d/merge_requests/%d/notes?per_page=100", mr.ProjectID, mr.IID)
+	for urlStr != "" {
+		var page []*Note
+		next, err := c.getPage(ctx, urlStr, &page)
+		if err != nil {
+			return errors.Wrap(err, "listing merge request notes")
+		}
+		notes = append(notes, page...)
+		urlStr = next
+	}
+	mr.Notes = notes
+	return nil
+}
+
+// LoadMergeRequestPipelines loads all pipelines of the merge request into
+// mr.Pipelines.
+func (c *Client) LoadMergeRequestPipelines(ctx context.Context, mr *MergeRequest) error {
+	if MockLoadMergeRequestPipelines != nil {
+		return MockLoadMergeRequestPipelines(c, ctx, mr)
+	}
+
+	var pipelines []*Pipeline
+	urlStr := fmt.Sprintf("projects/%d/merge_requests/%d/pipelines?per_page=100", mr.ProjectID, mr.IID)
+	for urlStr != "" {
+		var page []*Pipeline
+		next, err := c.getPage(ctx, urlStr, &page)
+		if err != nil {
+			return errors.Wrap(err, "listing merge request pipelines")
+		}
+		pipelines = append(pipelines, page...)
+		urlStr = next
+	}
+	mr.Pipelines = pipelines
+	return nil
+}
+
+// getPage decodes the page at urlStr into result and returns the URL of the
+// next page, which is empty on the last page. See
+// https://docs.gitlab.com/ee/api/README.html#pagination-link-header.
+func (c *Client) getPage(ctx context.Context, urlStr string, result interface{}) (next string, err error) {
+	req, err := http.NewRequest("GET", urlStr, nil)
+	if err != nil {
+		return "", err
+	}
+	respHeader, err := c.do(ctx, req, result)
+	if err != nil {
+		return "", err
+	}
+	if l := link.Parse(respHeader.Get("Link"))["next"]; l != nil {
+		next = l.URI
+	}
+	return next, nil
+}
+
+func newJSONRequest(method, urlStr string, body interface{}) (*http.Request, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling request body")
+	}
+	return http.NewRequest(method, urlStr, bytes.NewReader(data))
+}
@@ -0,0 +1,106 @@
+package gitlab
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestClient_CreateMergeRequest_alreadyExists(t *testing.T) {
+	c := newTestClient(t)
+	c.httpClient = mockHTTPEmptyResponse{http.StatusConflict}
+
+	mr, err := c.CreateMergeRequest(context.Background(), 1, CreateMergeRequestOpts{
+		SourceBranch: "feature",
+		TargetBranch: "master",
+		Title:        "t",
+	})
+	if err != ErrMergeRequestAlreadyExists {
+		t.Errorf("got err == %v, want %v", err, ErrMergeRequestAlreadyExists)
+	}
+	if mr != nil {
+		t.Error("mr != nil")
+	}
+}
+
+func TestClient_GetMergeRequest(t *testing.T) {
+	mock := mockHTTPResponseBody{
+		responseBody: `
+{
+	"id": 10,
+	"iid": 2,
+	"project_id": 1,
+	"title": "t",
+	"state": "opened",
+	"source_branch": "feature",
+	"target_branch": "master",
+	"diff_refs": {"base_sha": "b", "head_sha": "h", "start_sha": "s"}
+}
+`,
+	}
+	c := newTestClient(t)
+	c.httpClient = &mock
+
+	mr, err := c.GetMergeRequest(context.Background(), 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mr.IID != 2 || mr.State != MergeRequestStateOpened || mr.DiffRefs.HeadSHA != "h" {
+		t.Errorf("unexpected merge request %+v", mr)
+	}
+
+	c.httpClient = mockHTTPEmptyResponse{http.StatusNotFound}
+	if _, err := c.GetMergeRequest(context.Background(), 1, 3); err != ErrMergeRequestNotFound {
+		t.Errorf("got err == %v, want %v", err, ErrMergeRequestNotFound)
+	}
+}
+
+func TestNote_ToEvent(t *testing.T) {
+	for _, tc := range []struct {
+		note *Note
+		want interface{ Key() string }
+	}{
+		{
+			note: &Note{ID: 1, Body: "LGTM"},
+			want: &Note{ID: 1, Body: "LGTM"},
+		},
+		{
+			note: &Note{ID: 2, Body: "approved this merge request", System: true},
+			want: &ReviewApprovedEvent{Note{ID: 2, Body: "approved this merge request", System: true}},
+		},
+		{
+			note: &Note{ID: 3, Body: "unapproved this merge request", System: true},
+			want: &ReviewUnapprovedEvent{Note{ID: 3, Body: "unapproved this merge request", System: true}},
+		},
+		{
+			note: &Note{ID: 4, Body: "closed", System: true},
+			want: &MergeRequestClosedEvent{Note{ID: 4, Body: "closed", System: true}},
+		},
+		{
+			note: &Note{ID: 5, Body: "reopened", System: true},
+			want: &MergeRequestReopenedEvent{Note{ID: 5, Body: "reopened", System: true}},
+		},
+		{
+			note: &Note{ID: 6, Body: "merged via !42", System: true},
+			want: &MergeRequestMergedEvent{Note{ID: 6, Body: "merged via !42", System: true}},
+		},
+		{
+			note: &Note{ID: 7, Body: "added 1 commit", System: true},
+			want: nil,
+		},
+	} {
+		t.Run(tc.note.Body, func(t *testing.T) {
+			have := tc.note.ToEvent()
+			if tc.want == nil {
+				if have != nil {
+					t.Errorf("got %#v, want nil", have)
+				}
+				return
+			}
+			if !reflect.DeepEqual(have, tc.want) {
+				t.Errorf("got %#v, want %#v", have, tc.want)
+			}
+		})
+	}
+}
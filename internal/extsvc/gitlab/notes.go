@@ -0,0 +1,73 @@
+package gitlab
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Note is a comment on a merge request. System notes are created by GitLab
+// to record changes to the merge request, such as approvals or state changes.
+type Note struct {
+	ID        int       `json:"id"`
+	Body      string    `json:"body"`
+	Author    User      `json:"author"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	System    bool      `json:"system"`
+}
+
+// Key is a unique key identifying this note.
+func (n *Note) Key() string {
+	return strconv.Itoa(n.ID)
+}
+
+// ToEvent returns the event the note represents. Regular notes are returned
+// as is. System notes recording an approval or a state change are returned as
+// the corresponding event type, and all other system notes return nil.
+func (n *Note) ToEvent() interface{ Key() string } {
+	if !n.System {
+		return n
+	}
+
+	switch body := n.Body; {
+	case body == "approved this merge request":
+		return &ReviewApprovedEvent{Note: *n}
+	case body == "unapproved this merge request":
+		return &ReviewUnapprovedEvent{Note: *n}
+	case isStateChange(body, "closed"):
+		return &MergeRequestClosedEvent{Note: *n}
+	case isStateChange(body, "reopened"):
+		return &MergeRequestReopenedEvent{Note: *n}
+	case isStateChange(body, "merged"):
+		return &MergeRequestMergedEvent{Note: *n}
+	}
+	return nil
+}
+
+// isStateChange reports whether the system note body records the given state
+// change. GitLab appends the source of the change (e.g. "closed via !42") if
+// there is one.
+func isStateChange(body, state string) bool {
+	return body == state || strings.HasPrefix(body, state+" via ")
+}
+
+// ReviewApprovedEvent is a system note recording the approval of a merge
+// request.
+type ReviewApprovedEvent struct{ Note }
+
+// ReviewUnapprovedEvent is a system note recording the removal of an approval
+// of a merge request.
+type ReviewUnapprovedEvent struct{ Note }
+
+// MergeRequestClosedEvent is a system note recording that a merge request was
+// closed.
+type MergeRequestClosedEvent struct{ Note }
+
+// MergeRequestReopenedEvent is a system note recording that a merge request
+// was reopened.
+type MergeRequestReopenedEvent struct{ Note }
+
+// MergeRequestMergedEvent is a system note recording that a merge request was
+// merged.
+type MergeRequestMergedEvent struct{ Note }
@@ -0,0 +1,38 @@
+package gitlab
+
+import (
+	"strconv"
+	"time"
+)
+
+type PipelineStatus string
+
+const (
+	PipelineStatusCreated            PipelineStatus = "created"
+	PipelineStatusWaitingForResource PipelineStatus = "waiting_for_resource"
+	PipelineStatusPreparing          PipelineStatus = "preparing"
+	PipelineStatusPending            PipelineStatus = "pending"
+	PipelineStatusRunning            PipelineStatus = "running"
+	PipelineStatusSuccess            PipelineStatus = "success"
+	PipelineStatusFailed             PipelineStatus = "failed"
+	PipelineStatusCanceled           PipelineStatus = "canceled"
+	PipelineStatusSkipped            PipelineStatus = "skipped"
+	PipelineStatusManual             PipelineStatus = "manual"
+	PipelineStatusScheduled          PipelineStatus = "scheduled"
+)
+
+// Pipeline is a CI pipeline run for a merge request.
+type Pipeline struct {
+	ID        int            `json:"id"`
+	SHA       string         `json:"sha"`
+	Ref       string         `json:"ref"`
+	Status    PipelineStatus `json:"status"`
+	WebURL    string         `json:"web_url"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// Key is a unique key identifying this pipeline.
+func (p *Pipeline) Key() string {
+	return strconv.Itoa(p.ID)
+}
@@ -0,0 +1,201 @@
+package gitlab
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	eventTypeHeader = "X-Gitlab-Event"
+	tokenHeader     = "X-Gitlab-Token"
+)
+
+// WebhookEventType returns the type of the GitLab webhook event sent in r.
+func WebhookEventType(r *http.Request) string {
+	return r.Header.Get(eventTypeHeader)
+}
+
+// WebhookToken returns the secret token sent with the GitLab webhook event in
+// r. GitLab sends the secret as is, rather than signing the payload with it.
+func WebhookToken(r *http.Request) string {
+	return r.Header.Get(tokenHeader)
+}
+
+// ParseWebhookEvent parses the payload of a GitLab webhook event of the given
+// type. It returns nil without an error for event types that are not relevant
+// to merge requests or permissions.
+func ParseWebhookEvent(eventType string, payload []byte) (e interface{}, err error) {
+	switch eventType {
+	case "Merge Request Hook":
+		e = &MergeRequestEvent{}
+	case "Note Hook":
+		e = &NoteEvent{}
+	case "Pipeline Hook":
+		e = &PipelineEvent{}
+	case "System Hook", "Member Hook":
+		// System hooks and group member hooks share the same endpoint for all
+		// their events, which are told apart by their event_name.
+		var probe struct {
+			EventName string `json:"event_name"`
+		}
+		if err := json.Unmarshal(payload, &probe); err != nil {
+			return nil, err
+		}
+		if !IsMemberEventName(probe.EventName) {
+			return nil, nil
+		}
+		e = &MemberEvent{}
+	default:
+		return nil, nil
+	}
+	return e, json.Unmarshal(payload, e)
+}
+
+// IsMemberEventName returns true if the given system or group hook event name
+// is a change to the members of a project or group.
+func IsMemberEventName(name string) bool {
+	switch name {
+	case "user_add_to_team", "user_remove_from_team", "user_update_for_team",
+		"user_add_to_group", "user_remove_from_group", "user_update_for_group":
+		return true
+	}
+	return false
+}
+
+// MemberEvent is sent when a user is added to, removed from or has their
+// access level changed in a project ("team" events) or a group ("group"
+// events). Project events are only sent as system hooks.
+type MemberEvent struct {
+	EventName    string `json:"event_name"`
+	UserID       int    `json:"user_id"`
+	UserUsername string `json:"user_username"`
+	ProjectID    int    `json:"project_id,omitempty"`
+	GroupID      int    `json:"group_id,omitempty"`
+}
+
+// IsProjectEvent returns true if the membership of a project changed, as
+// opposed to the membership of a group.
+func (e *MemberEvent) IsProjectEvent() bool {
+	return strings.HasSuffix(e.EventName, "_team")
+}
+
+// MergeRequestEvent is sent when a merge request is created, updated,
+// approved or changes state.
+type MergeRequestEvent struct {
+	User             User                   `json:"user"`
+	Project          ProjectCommon          `json:"project"`
+	ObjectAttributes MergeRequestAttributes `json:"object_attributes"`
+}
+
+// MergeRequestAttributes describe the merge request a webhook event relates
+// to.
+type MergeRequestAttributes struct {
+	ID              int               `json:"id"`
+	IID             int               `json:"iid"`
+	TargetProjectID int               `json:"target_project_id"`
+	SourceBranch    string            `json:"source_branch"`
+	TargetBranch    string            `json:"target_branch"`
+	State           MergeRequestState `json:"state"`
+	// Action is one of "open", "update", "close", "reopen", "merge",
+	// "approved" or "unapproved". It is only set in MergeRequestEvents.
+	Action    string `json:"action,omitempty"`
+	UpdatedAt Time   `json:"updated_at"`
+}
+
+// NoteEvent is sent when a note is added to a merge request, issue, commit
+// or snippet.
+type NoteEvent struct {
+	User             User                    `json:"user"`
+	Project          ProjectCommon           `json:"project"`
+	ObjectAttributes NoteAttributes          `json:"object_attributes"`
+	MergeRequest     *MergeRequestAttributes `json:"merge_request,omitempty"`
+}
+
+// NoteAttributes describe the note of a NoteEvent.
+type NoteAttributes struct {
+	ID           int    `json:"id"`
+	Note         string `json:"note"`
+	NoteableType string `json:"noteable_type"`
+	System       bool   `json:"system"`
+	CreatedAt    Time   `json:"created_at"`
+	UpdatedAt    Time   `json:"updated_at"`
+}
+
+// ToNote returns the note the event was sent for.
+func (e *NoteEvent) ToNote() *Note {
+	return &Note{
+		ID:        e.ObjectAttributes.ID,
+		Body:      e.ObjectAttributes.Note,
+		Author:    e.User,
+		CreatedAt: e.ObjectAttributes.CreatedAt.Time,
+		UpdatedAt: e.ObjectAttributes.UpdatedAt.Time,
+		System:    e.ObjectAttributes.System,
+	}
+}
+
+// PipelineEvent is sent when the status of a pipeline changes. MergeRequest is
+// only set for merge request pipelines.
+type PipelineEvent struct {
+	User             User                    `json:"user"`
+	Project          ProjectCommon           `json:"project"`
+	ObjectAttributes PipelineAttributes      `json:"object_attributes"`
+	MergeRequest     *MergeRequestAttributes `json:"merge_request,omitempty"`
+}
+
+// PipelineAttributes describe the pipeline of a PipelineEvent.
+type PipelineAttributes struct {
+	ID        int            `json:"id"`
+	Ref       string         `json:"ref"`
+	SHA       string         `json:"sha"`
+	Status    PipelineStatus `json:"status"`
+	CreatedAt Time           `json:"created_at"`
+}
+
+// ToPipeline returns the pipeline the event was sent for.
+func (e *PipelineEvent) ToPipeline(receivedAt time.Time) *Pipeline {
+	p := &Pipeline{
+		ID:        e.ObjectAttributes.ID,
+		SHA:       e.ObjectAttributes.SHA,
+		Ref:       e.ObjectAttributes.Ref,
+		Status:    e.ObjectAttributes.Status,
+		CreatedAt: e.ObjectAttributes.CreatedAt.Time,
+		UpdatedAt: receivedAt,
+	}
+	if e.Project.WebURL != "" {
+		p.WebURL = fmt.Sprintf("%s/-/pipelines/%d", strings.TrimSuffix(e.Project.WebURL, "/"), p.ID)
+	}
+	return p
+}
+
+// Time is a timestamp in a webhook payload. Depending on the GitLab version
+// and the event type, webhook timestamps are either in RFC 3339 format or in
+// the format "2006-01-02 15:04:05 UTC".
+type Time struct {
+	time.Time
+}
+
+const webhookTimeFormat = "2006-01-02 15:04:05 MST"
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (t *Time) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s == "" {
+		t.Time = time.Time{}
+		return nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		if parsed, err = time.Parse(webhookTimeFormat, s); err != nil {
+			return err
+		}
+	}
+	t.Time = parsed.UTC()
+	return nil
+}
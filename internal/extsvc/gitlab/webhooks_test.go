@@ -0,0 +1,130 @@
+package gitlab
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseWebhookEvent(t *testing.T) {
+	t.Run("pipeline", func(t *testing.T) {
+		payload := []byte(`{
+			"object_kind": "pipeline",
+			"project": {"id": 1, "path_with_namespace": "a/b", "web_url": "https://gitlab.example.com/a/b"},
+			"object_attributes": {"id": 31, "ref": "feature", "sha": "abc", "status": "success", "created_at": "2020-03-23 10:20:30 UTC"},
+			"merge_request": {"id": 10, "iid": 2, "target_project_id": 1, "source_branch": "feature", "target_branch": "master"}
+		}`)
+
+		e, err := ParseWebhookEvent("Pipeline Hook", payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pe, ok := e.(*PipelineEvent)
+		if !ok {
+			t.Fatalf("got %T, want *PipelineEvent", e)
+		}
+
+		receivedAt := time.Date(2020, 3, 23, 10, 25, 0, 0, time.UTC)
+		have := pe.ToPipeline(receivedAt)
+		want := &Pipeline{
+			ID:        31,
+			SHA:       "abc",
+			Ref:       "feature",
+			Status:    PipelineStatusSuccess,
+			WebURL:    "https://gitlab.example.com/a/b/-/pipelines/31",
+			CreatedAt: time.Date(2020, 3, 23, 10, 20, 30, 0, time.UTC),
+			UpdatedAt: receivedAt,
+		}
+		if *have != *want {
+			t.Errorf("got %+v, want %+v", have, want)
+		}
+		if pe.MergeRequest == nil || pe.MergeRequest.IID != 2 {
+			t.Errorf("unexpected merge request %+v", pe.MergeRequest)
+		}
+	})
+
+	t.Run("note", func(t *testing.T) {
+		payload := []byte(`{
+			"object_kind": "note",
+			"user": {"id": 5, "username": "alice"},
+			"object_attributes": {"id": 1244, "note": "LGTM", "noteable_type": "MergeRequest", "created_at": "2020-03-23T10:20:30Z", "updated_at": "2020-03-23T10:20:30Z"},
+			"merge_request": {"id": 10, "iid": 2, "target_project_id": 1}
+		}`)
+
+		e, err := ParseWebhookEvent("Note Hook", payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ne, ok := e.(*NoteEvent)
+		if !ok {
+			t.Fatalf("got %T, want *NoteEvent", e)
+		}
+		n := ne.ToNote()
+		if n.Key() != "1244" || n.Body != "LGTM" || n.Author.Username != "alice" || n.System {
+			t.Errorf("unexpected note %+v", n)
+		}
+	})
+
+	t.Run("project member", func(t *testing.T) {
+		payload := []byte(`{
+			"event_name": "user_add_to_team",
+			"access_level": "Developer",
+			"project_id": 74,
+			"project_path_with_namespace": "jsmith/storecloud",
+			"user_id": 41,
+			"user_username": "johnsmith"
+		}`)
+
+		e, err := ParseWebhookEvent("System Hook", payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		me, ok := e.(*MemberEvent)
+		if !ok {
+			t.Fatalf("got %T, want *MemberEvent", e)
+		}
+		want := MemberEvent{EventName: "user_add_to_team", UserID: 41, UserUsername: "johnsmith", ProjectID: 74}
+		if *me != want {
+			t.Errorf("got %+v, want %+v", me, want)
+		}
+		if !me.IsProjectEvent() {
+			t.Error("want project event")
+		}
+	})
+
+	t.Run("group member", func(t *testing.T) {
+		payload := []byte(`{
+			"event_name": "user_remove_from_group",
+			"group_access": "Guest",
+			"group_id": 78,
+			"group_path": "storefront",
+			"user_id": 42,
+			"user_username": "johnsmith"
+		}`)
+
+		e, err := ParseWebhookEvent("Member Hook", payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		me, ok := e.(*MemberEvent)
+		if !ok {
+			t.Fatalf("got %T, want *MemberEvent", e)
+		}
+		if me.GroupID != 78 || me.UserID != 42 || me.IsProjectEvent() {
+			t.Errorf("unexpected member event %+v", me)
+		}
+	})
+
+	t.Run("unrelated system hook", func(t *testing.T) {
+		e, err := ParseWebhookEvent("System Hook", []byte(`{"event_name": "project_create", "project_id": 74}`))
+		if err != nil || e != nil {
+			t.Errorf("got (%v, %v), want (nil, nil)", e, err)
+		}
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		e, err := ParseWebhookEvent("Push Hook", []byte(`{}`))
+		if err != nil || e != nil {
+			t.Errorf("got (%v, %v), want (nil, nil)", e, err)
+		}
+	})
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/endpoint"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitolite"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
 	"github.com/sourcegraph/sourcegraph/internal/httpcli"
@@ -60,6 +61,17 @@ func NewClient(cli httpcli.Doer) *Client {
 		Addrs: func(ctx context.Context) []string {
 			return conf.Get().ServiceConnections.GitServers
 		},
+		ConsistentHashing: func(ctx context.Context) bool {
+			return conf.Get().ServiceConnections.GitServersSharding == "consistent"
+		},
+		PreviousAddrs: func(ctx context.Context) ([]string, bool) {
+			sc := conf.Get().ServiceConnections
+			sharding := sc.GitServersPreviousSharding
+			if sharding == "" {
+				sharding = sc.GitServersSharding
+			}
+			return sc.GitServersPrevious, sharding != "consistent"
+		},
 		HTTPClient:  cli,
 		HTTPLimiter: parallel.NewRun(500),
 		// Use the binary name for UserAgent. This should effectively identify
@@ -82,12 +94,29 @@ type Client struct {
 	// concurrent use. It may return different results at different times.
 	Addrs func(ctx context.Context) []string
 
+	// ConsistentHashing is a function which reports whether repositories are
+	// assigned to Addrs with consistent hashing rather than the legacy modulo
+	// sharding. Switching changes the assigned gitserver of most repositories,
+	// so it is only enabled together with moving them (see PreviousAddrs). If
+	// nil, modulo sharding is used.
+	ConsistentHashing func(ctx context.Context) bool
+
+	// PreviousAddrs is a function which returns the addresses of gitservers
+	// before the most recent change of Addrs, and whether repositories were
+	// assigned to them with the legacy modulo sharding. While it returns
+	// addresses, repositories are being moved to the gitserver they belong on
+	// according to Addrs, and requests for a repository keep going to the
+	// gitserver that held it before. It may be nil.
+	PreviousAddrs func(ctx context.Context) (addrs []string, modulo bool)
+
 	// UserAgent is a string identifing who the client is. It will be logged in
 	// the telemetry in gitserver.
 	UserAgent string
 }
 
 // AddrForRepo returns the gitserver address to use for the given repo name.
+// While repositories are being moved between gitservers, this is the address
+// of the gitserver that held the repo before the move (see PreviousAddrs).
 func (c *Client) AddrForRepo(ctx context.Context, repo api.RepoName) string {
 	repo = protocol.NormalizeRepo(repo) // in case the caller didn't already normalize it
 	return c.addrForKey(ctx, string(repo))
@@ -96,14 +125,65 @@ func (c *Client) AddrForRepo(ctx context.Context, repo api.RepoName) string {
 // addrForKey returns the gitserver address to use for the given string key,
 // which is hashed for sharding purposes.
 func (c *Client) addrForKey(ctx context.Context, key string) string {
+	if c.PreviousAddrs != nil {
+		if addrs, modulo := c.PreviousAddrs(ctx); len(addrs) > 0 {
+			if modulo {
+				return moduloAddrForKey(addrs, key)
+			}
+			return addrForKey(addrs, key)
+		}
+	}
+	return c.targetAddrForKey(ctx, key)
+}
+
+// targetAddrForKey returns the gitserver address the given string key belongs
+// on according to the current gitserver addresses.
+func (c *Client) targetAddrForKey(ctx context.Context, key string) string {
 	addrs := c.Addrs(ctx)
 	if len(addrs) == 0 {
 		panic("unexpected state: no gitserver addresses")
 	}
+	if c.ConsistentHashing == nil || !c.ConsistentHashing(ctx) {
+		return moduloAddrForKey(addrs, key)
+	}
 	return addrForKey(addrs, key)
 }
 
+// addrForKey consistently hashes key over addrs, so that adding or removing
+// a gitserver only moves the keys of that gitserver.
 func addrForKey(addrs []string, key string) string {
+	addr, _ := hashMapForAddrs(addrs).Get(key, nil) // static maps never fail
+	return addr
+}
+
+// hashMaps caches the consistent hash maps of gitserver addresses. Building a
+// map is much more expensive than a lookup, and the addresses rarely change.
+var hashMaps struct {
+	sync.Mutex
+	m map[string]*endpoint.Map
+}
+
+func hashMapForAddrs(addrs []string) *endpoint.Map {
+	key := strings.Join(addrs, " ")
+
+	hashMaps.Lock()
+	defer hashMaps.Unlock()
+	m, ok := hashMaps.m[key]
+	if !ok {
+		// Only the current and previous addresses are in use at any time,
+		// so there is no need for anything fancier than starting over.
+		if hashMaps.m == nil || len(hashMaps.m) >= 10 {
+			hashMaps.m = make(map[string]*endpoint.Map)
+		}
+		m = endpoint.Static(addrs...)
+		hashMaps.m[key] = m
+	}
+	return m
+}
+
+// moduloAddrForKey is how keys are sharded unless consistent hashing is
+// enabled. Adding or removing a gitserver moves almost all keys.
+func moduloAddrForKey(addrs []string, key string) string {
 	sum := md5.Sum([]byte(key))
 	serverIndex := binary.BigEndian.Uint64(sum[:]) % uint64(len(addrs))
 	return addrs[serverIndex]
@@ -486,8 +566,7 @@ func (c *Client) ListCloned(ctx context.Context) ([]string, error) {
 		err   error
 		repos []string
 	)
-	addrs := c.Addrs(ctx)
-	for _, addr := range addrs {
+	for _, addr := range c.AllAddrs(ctx) {
 		wg.Add(1)
 		go func(addr string) {
 			defer wg.Done()
@@ -497,7 +576,7 @@ func (c *Client) ListCloned(ctx context.Context) ([]string, error) {
 			if len(r) > 0 {
 				filtered := r[:0]
 				for _, repo := range r {
-					if addrForKey(addrs, repo) == addr {
+					if c.addrForKey(ctx, repo) == addr {
 						filtered = append(filtered, repo)
 					}
 				}
@@ -736,6 +815,28 @@ func (c *Client) RepoInfo(ctx context.Context, repos ...api.RepoName) (*protocol
 	return &res, err.ErrorOrNil()
 }
 
+// SearchCommits searches the commit index of a repository on gitserver. If
+// gitserver has no commit index for the repository, the response is not
+// Indexed.
+func (c *Client) SearchCommits(ctx context.Context, req *protocol.CommitSearchRequest) (*protocol.CommitSearchResponse, error) {
+	resp, err := c.httpPost(ctx, req.Repo, "commit-search", req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		// best-effort inclusion of body in error message
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 200))
+		return nil, &url.Error{URL: resp.Request.URL.String(), Op: "SearchCommits", Err: fmt.Errorf("SearchCommits: http status %d: %s", resp.StatusCode, string(body))}
+	}
+
+	var res protocol.CommitSearchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
 // Remove removes the repository clone from gitserver.
 func (c *Client) Remove(ctx context.Context, repo api.RepoName) error {
 	req := &protocol.RepoDeleteRequest{
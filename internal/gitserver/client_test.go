@@ -57,6 +57,118 @@ func TestClient_ListCloned(t *testing.T) {
 	}
 }
 
+func TestClient_AddrForRepo_rebalancing(t *testing.T) {
+	ctx := context.Background()
+	addrs := []string{"gitserver-0", "gitserver-1", "gitserver-2"}
+	var (
+		previous   []string
+		modulo     bool
+		consistent = true
+	)
+	cli := &gitserver.Client{
+		Addrs:             func(ctx context.Context) []string { return addrs },
+		ConsistentHashing: func(ctx context.Context) bool { return consistent },
+		PreviousAddrs:     func(ctx context.Context) ([]string, bool) { return previous, modulo },
+	}
+
+	type placement struct{ Addr, Target string }
+	check := func(want map[api.RepoName]placement) {
+		t.Helper()
+		got := map[api.RepoName]placement{}
+		for repo := range want {
+			got[repo] = placement{cli.AddrForRepo(ctx, repo), cli.TargetAddrForRepo(ctx, repo)}
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	}
+
+	// Adding a gitserver only moves the repositories that now belong on it.
+	check(map[api.RepoName]placement{
+		"github.com/sourcegraph/sourcegraph": {"gitserver-0", "gitserver-0"},
+		"github.com/golang/go":               {"gitserver-2", "gitserver-2"},
+		"github.com/torvalds/linux":          {"gitserver-1", "gitserver-1"},
+	})
+
+	// While moving, requests go to the gitserver that held the repository.
+	previous = []string{"gitserver-0", "gitserver-1"}
+	if !cli.Rebalancing(ctx) {
+		t.Fatal("want rebalancing")
+	}
+	check(map[api.RepoName]placement{
+		"github.com/sourcegraph/sourcegraph": {"gitserver-0", "gitserver-0"},
+		"github.com/golang/go":               {"gitserver-1", "gitserver-2"},
+		"github.com/torvalds/linux":          {"gitserver-1", "gitserver-1"},
+	})
+
+	modulo = true
+	check(map[api.RepoName]placement{
+		"github.com/sourcegraph/sourcegraph": {"gitserver-0", "gitserver-0"},
+		"github.com/golang/go":               {"gitserver-0", "gitserver-2"},
+		"github.com/torvalds/linux":          {"gitserver-0", "gitserver-1"},
+	})
+
+	// Removed gitservers keep serving their repositories until moved.
+	addrs = []string{"gitserver-0"}
+	modulo = false
+	if diff := cmp.Diff([]string{"gitserver-0", "gitserver-1"}, cli.AllAddrs(ctx)); diff != "" {
+		t.Errorf("AllAddrs mismatch (-want +got):\n%s", diff)
+	}
+	check(map[api.RepoName]placement{
+		"github.com/golang/go": {"gitserver-1", "gitserver-0"},
+	})
+
+	// Without consistent hashing, repositories are assigned with modulo
+	// sharding.
+	addrs = []string{"gitserver-0", "gitserver-1"}
+	previous = nil
+	consistent = false
+	check(map[api.RepoName]placement{
+		"github.com/sourcegraph/sourcegraph": {"gitserver-0", "gitserver-0"},
+		"github.com/golang/go":               {"gitserver-0", "gitserver-0"},
+		"github.com/torvalds/linux":          {"gitserver-0", "gitserver-0"},
+	})
+}
+
+func TestClient_ListCloned_rebalancing(t *testing.T) {
+	cli := &gitserver.Client{
+		Addrs:             func(ctx context.Context) []string { return []string{"gitserver-0", "gitserver-1", "gitserver-2"} },
+		ConsistentHashing: func(ctx context.Context) bool { return true },
+		PreviousAddrs: func(ctx context.Context) ([]string, bool) {
+			return []string{"gitserver-0", "gitserver-1"}, false
+		},
+		HTTPClient: httpcli.DoerFunc(func(r *http.Request) (*http.Response, error) {
+			switch r.URL.String() {
+			case "http://gitserver-0/list?cloned":
+				return &http.Response{
+					Body: ioutil.NopCloser(bytes.NewBufferString(`["github.com/sourcegraph/sourcegraph"]`)),
+				}, nil
+			case "http://gitserver-1/list?cloned":
+				return &http.Response{
+					Body: ioutil.NopCloser(bytes.NewBufferString(`["github.com/golang/go", "github.com/torvalds/linux"]`)),
+				}, nil
+			case "http://gitserver-2/list?cloned":
+				// Already moved, but still served by gitserver-1.
+				return &http.Response{
+					Body: ioutil.NopCloser(bytes.NewBufferString(`["github.com/golang/go"]`)),
+				}, nil
+			default:
+				return nil, fmt.Errorf("unexpected url: %s", r.URL.String())
+			}
+		}),
+	}
+
+	want := []string{"github.com/golang/go", "github.com/sourcegraph/sourcegraph", "github.com/torvalds/linux"}
+	got, err := cli.ListCloned(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(got)
+	if !cmp.Equal(want, got, cmpopts.EquateEmpty()) {
+		t.Errorf("mismatch for (-want +got):\n%s", cmp.Diff(want, got))
+	}
+}
+
 func TestClient_Archive(t *testing.T) {
 	root, err := ioutil.TempDir("", t.Name())
 	if err != nil {
@@ -108,6 +108,15 @@ type RepoDeleteRequest struct {
 	Repo api.RepoName
 }
 
+// RepoTransferRequest is a request to copy a repository clone from another
+// gitserver, when repositories are moved between gitservers.
+type RepoTransferRequest struct {
+	// Repo is the repository to copy.
+	Repo api.RepoName
+	// From is the address of the gitserver to copy the repository from.
+	From string
+}
+
 // RepoInfo is the information requests about a single repository
 // via a RepoInfoRequest.
 type RepoInfo struct {
@@ -122,8 +131,30 @@ type RepoInfo struct {
 	// recloned automatically, so this time is likely to move forward
 	// periodically.
 	CloneTime *time.Time
+
+	CloneMode  CloneMode // how the repository is cloned, empty if not cloned
+	CloneDepth int       // the number of commits fetched for shallow clones
+
+	CommitIndexed bool // whether commit and diff searches can use the commit index
 }
 
+// CloneMode is the mode gitserver uses to clone and fetch a repository.
+type CloneMode string
+
+const (
+	// CloneModeFull clones the complete history including all file contents.
+	CloneModeFull CloneMode = "full"
+
+	// CloneModeBlobless clones the complete history without file contents
+	// (git clone --filter=blob:none). File contents are fetched from the
+	// remote when they are first needed.
+	CloneModeBlobless CloneMode = "blobless"
+
+	// CloneModeShallow clones only the most recent commits of each ref (git
+	// clone --depth).
+	CloneModeShallow CloneMode = "shallow"
+)
+
 // RepoInfoResponse is the response to a repository information request
 // for multiple repositories at the same time.
 type RepoInfoResponse struct {
@@ -203,3 +234,77 @@ type CreateCommitFromPatchError struct {
 func (e *CreateCommitFromPatchError) Error() string {
 	return e.InternalError
 }
+
+// CommitSearchRequest is a request to search the commit index of a
+// repository, which covers the commits on its default branch except merge
+// commits. Commits must match all of the given filters.
+type CommitSearchRequest struct {
+	// Repo is the repository to search.
+	Repo api.RepoName
+
+	// DiffPattern is a regular expression matched against the added and
+	// removed lines of the diffs of commits.
+	DiffPattern string
+
+	// MessagePatterns are regular expressions matched against commit
+	// messages. AuthorPatterns and CommitterPatterns are matched against the
+	// author and committer in the "Name <email>" form. Commits must match
+	// all of them, and none of the negated patterns.
+	MessagePatterns          []string
+	NegatedMessagePatterns   []string
+	AuthorPatterns           []string
+	NegatedAuthorPatterns    []string
+	CommitterPatterns        []string
+	NegatedCommitterPatterns []string
+
+	// IsCaseSensitive is whether the patterns above are matched case
+	// sensitively.
+	IsCaseSensitive bool
+
+	// IncludePatterns and ExcludePattern restrict the search to commits
+	// whose diffs change files with matching paths. Only the diffs of those
+	// files are matched against DiffPattern and returned.
+	IncludePatterns              []string
+	ExcludePattern               string
+	PathPatternsAreRegExps       bool
+	PathPatternsAreCaseSensitive bool
+
+	// Before and After restrict the search to commits committed before and
+	// after the given dates, in any format understood by git log --until
+	// and --since.
+	Before []string
+	After  []string
+
+	// IncludeDiff is whether the diffs of matching commits are returned.
+	IncludeDiff bool
+
+	// Limit is the maximum number of commits returned.
+	Limit int
+}
+
+// CommitSearchResponse is the response to a CommitSearchRequest.
+type CommitSearchResponse struct {
+	// Indexed is false if gitserver has no commit index for the
+	// repository, in which case it must be searched with git log.
+	Indexed bool
+
+	// Commits are the matching commits, newest first.
+	Commits []*IndexedCommit
+}
+
+// IndexedCommit is a commit in the commit index of a repository.
+type IndexedCommit struct {
+	ID             api.CommitID
+	Parents        []api.CommitID `json:",omitempty"`
+	AuthorName     string
+	AuthorEmail    string
+	AuthorDate     time.Time
+	CommitterName  string
+	CommitterEmail string
+	CommitterDate  time.Time
+	Message        string
+
+	// Diff is the diff of the commit without context lines and file name
+	// prefixes, like the output of git log --patch --unified=0 --no-prefix.
+	Diff string `json:",omitempty"`
+}
@@ -0,0 +1,105 @@
+package gitserver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+)
+
+// Rebalancing reports whether repositories are being moved between
+// gitservers, because the gitserver addresses changed (see PreviousAddrs).
+func (c *Client) Rebalancing(ctx context.Context) bool {
+	if c.PreviousAddrs == nil {
+		return false
+	}
+	addrs, _ := c.PreviousAddrs(ctx)
+	return len(addrs) > 0
+}
+
+// TargetAddrForRepo returns the address of the gitserver the given repo
+// belongs on according to the current gitserver addresses. Unless
+// repositories are being moved between gitservers, it is the same as
+// AddrForRepo.
+func (c *Client) TargetAddrForRepo(ctx context.Context, repo api.RepoName) string {
+	repo = protocol.NormalizeRepo(repo)
+	return c.targetAddrForKey(ctx, string(repo))
+}
+
+// AllAddrs returns the current gitserver addresses followed by the previous
+// addresses that are no longer current, if repositories are being moved
+// between gitservers.
+func (c *Client) AllAddrs(ctx context.Context) []string {
+	addrs := c.Addrs(ctx)
+	if c.PreviousAddrs == nil {
+		return addrs
+	}
+	previous, _ := c.PreviousAddrs(ctx)
+	if len(previous) == 0 {
+		return addrs
+	}
+
+	all := append([]string{}, addrs...)
+	seen := make(map[string]bool, len(addrs))
+	for _, addr := range addrs {
+		seen[addr] = true
+	}
+	for _, addr := range previous {
+		if !seen[addr] {
+			seen[addr] = true
+			all = append(all, addr)
+		}
+	}
+	return all
+}
+
+// ListClonedOn lists the repositories cloned on the gitserver at addr,
+// including those that don't belong on it.
+func (c *Client) ListClonedOn(ctx context.Context, addr string) ([]string, error) {
+	return c.doListOne(ctx, "?cloned", addr)
+}
+
+// TransferRepo copies the clone of repo from the gitserver at from to the
+// gitserver at to. It returns once the copy is complete. The clone on from is
+// left in place.
+func (c *Client) TransferRepo(ctx context.Context, repo api.RepoName, from, to string) error {
+	req := &protocol.RepoTransferRequest{
+		Repo: repo,
+		From: from,
+	}
+	resp, err := c.httpPost(ctx, repo, "http://"+to+"/repo-transfer", req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		// best-effort inclusion of body in error message
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 200))
+		return &url.Error{URL: resp.Request.URL.String(), Op: "TransferRepo", Err: fmt.Errorf("TransferRepo: http status %d: %s", resp.StatusCode, string(body))}
+	}
+	return nil
+}
+
+// RemoveFrom removes the clone of repo from the gitserver at addr, regardless
+// of whether repo belongs on it.
+func (c *Client) RemoveFrom(ctx context.Context, repo api.RepoName, addr string) error {
+	req := &protocol.RepoDeleteRequest{
+		Repo: repo,
+	}
+	resp, err := c.httpPost(ctx, repo, "http://"+addr+"/delete", req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		// best-effort inclusion of body in error message
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 200))
+		return &url.Error{URL: resp.Request.URL.String(), Op: "RepoRemove", Err: fmt.Errorf("RepoRemove: http status %d: %s", resp.StatusCode, string(body))}
+	}
+	return nil
+}
@@ -210,6 +210,40 @@ func (c *Client) EnqueueChangesetSync(ctx context.Context, ids []int64) error {
 	return errors.New(res.Error)
 }
 
+// MockSchedulePermsSync mocks (*Client).SchedulePermsSync for tests.
+var MockSchedulePermsSync func(ctx context.Context, args protocol.PermsSyncRequest) error
+
+// SchedulePermsSync schedules permissions syncing requests for the given
+// users and repositories with high priority.
+func (c *Client) SchedulePermsSync(ctx context.Context, args protocol.PermsSyncRequest) error {
+	if MockSchedulePermsSync != nil {
+		return MockSchedulePermsSync(ctx, args)
+	}
+
+	resp, err := c.httpPost(ctx, "schedule-perms-sync", args)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	bs, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read response body")
+	}
+
+	var res protocol.PermsSyncResponse
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return errors.New(string(bs))
+	} else if err = json.Unmarshal(bs, &res); err != nil {
+		return err
+	}
+
+	if res.Error == "" {
+		return nil
+	}
+	return errors.New(res.Error)
+}
+
 // SyncExternalService requests the given external service to be synced.
 func (c *Client) SyncExternalService(ctx context.Context, svc api.ExternalService) (*protocol.ExternalServiceSyncResult, error) {
 	req := &protocol.ExternalServiceSyncRequest{ExternalService: svc}
@@ -326,6 +360,36 @@ func (c *Client) StatusMessages(ctx context.Context) (*protocol.StatusMessagesRe
 	return &res, nil
 }
 
+// MockGitserverRebalanceStatus mocks (*Client).GitserverRebalanceStatus for tests.
+var MockGitserverRebalanceStatus func(ctx context.Context) (*protocol.GitserverRebalanceStatus, error)
+
+// GitserverRebalanceStatus returns the progress of moving repositories between
+// gitservers after the gitserver addresses changed.
+func (c *Client) GitserverRebalanceStatus(ctx context.Context) (*protocol.GitserverRebalanceStatus, error) {
+	if MockGitserverRebalanceStatus != nil {
+		return MockGitserverRebalanceStatus(ctx)
+	}
+
+	resp, err := c.httpGet(ctx, "gitserver-rebalance-status")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	bs, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read response body")
+	}
+
+	var res protocol.GitserverRebalanceStatus
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return nil, errors.New(string(bs))
+	} else if err = json.Unmarshal(bs, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
 func (c *Client) httpPost(ctx context.Context, method string, payload interface{}) (resp *http.Response, err error) {
 	reqBody, err := json.Marshal(payload)
 	if err != nil {
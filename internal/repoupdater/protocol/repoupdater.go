@@ -170,6 +170,18 @@ type ChangesetSyncResponse struct {
 	Error string
 }
 
+// PermsSyncRequest is a request to sync permissions of the given users and
+// repositories as soon as possible.
+type PermsSyncRequest struct {
+	UserIDs []int32      `json:"user_ids"`
+	RepoIDs []api.RepoID `json:"repo_ids"`
+}
+
+// PermsSyncResponse is a response to sync permissions.
+type PermsSyncResponse struct {
+	Error string
+}
+
 // ExternalServiceSyncRequest is a request to sync a specific external service eagerly.
 //
 // The FrontendAPI is one of the issuers of this request. It does so when creating or
@@ -207,3 +219,38 @@ type StatusMessage struct {
 type StatusMessagesResponse struct {
 	Messages []StatusMessage `json:"messages"`
 }
+
+// GitserverRebalanceStatus is the progress of moving repositories between
+// gitservers after the gitserver addresses changed.
+type GitserverRebalanceStatus struct {
+	// Rebalancing is true while repositories are being moved. Requests for a
+	// repository go to the gitserver that held it before until the move is
+	// complete and the previous gitserver addresses are unset.
+	Rebalancing bool
+	// UpdatedAt is when the gitservers were last checked for repositories to
+	// move. It is zero if they haven't been checked yet.
+	UpdatedAt time.Time
+	// Shards is the status of each gitserver.
+	Shards []GitserverShardStatus
+	// Errors are the most recent errors moving repositories.
+	Errors []string
+}
+
+// GitserverShardStatus is the status of a single gitserver in a
+// GitserverRebalanceStatus.
+type GitserverShardStatus struct {
+	// Addr is the address of the gitserver.
+	Addr string
+	// Cloned is the number of repositories cloned on the gitserver that
+	// belong on it.
+	Cloned int
+	// Incoming is the number of repositories that belong on the gitserver,
+	// but are served by another gitserver.
+	Incoming int
+	// Moved is the number of incoming repositories that have been copied to
+	// the gitserver.
+	Moved int
+	// Failed is the number of incoming repositories that failed to be copied
+	// to the gitserver in the last attempt.
+	Failed int
+}
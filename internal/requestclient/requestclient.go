@@ -0,0 +1,51 @@
+// Package requestclient records the client that made an HTTP request in the
+// request context, so that it can be attributed by code that doesn't have
+// access to the request (e.g. the audit log).
+package requestclient
+
+import (
+	"context"
+	"net"
+	"net/http"
+)
+
+// Client describes the client that made a request.
+type Client struct {
+	// IP is the IP address of the client, which is that of the proxy in front of
+	// Sourcegraph if there is one.
+	IP string
+
+	// ForwardedFor is the value of the X-Forwarded-For header of the request,
+	// which is set by proxies to the IP addresses of the client and of the
+	// proxies it passed through. It is not verified.
+	ForwardedFor string
+}
+
+type clientKey struct{}
+
+// WithClient returns a copy of ctx with the given client.
+func WithClient(ctx context.Context, client *Client) context.Context {
+	return context.WithValue(ctx, clientKey{}, client)
+}
+
+// FromContext returns the client of the request of ctx, or nil if ctx does not
+// belong to an HTTP request.
+func FromContext(ctx context.Context) *Client {
+	client, _ := ctx.Value(clientKey{}).(*Client)
+	return client
+}
+
+// HTTPMiddleware records the client of each request in the request context.
+func HTTPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+		ctx := WithClient(r.Context(), &Client{
+			IP:           ip,
+			ForwardedFor: r.Header.Get("X-Forwarded-For"),
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
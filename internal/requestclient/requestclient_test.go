@@ -0,0 +1,29 @@
+package requestclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestHTTPMiddleware(t *testing.T) {
+	var got *Client
+	h := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = FromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.0.0.1:51234"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if want := (&Client{IP: "10.0.0.1", ForwardedFor: "203.0.113.7, 10.0.0.1"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got client %+v, want %+v", got, want)
+	}
+
+	if client := FromContext(context.Background()); client != nil {
+		t.Errorf("got client %+v for context without client, want nil", client)
+	}
+}
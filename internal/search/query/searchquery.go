@@ -27,6 +27,9 @@ const (
 	FieldContent            = "content"
 	FieldVisibility         = "visibility"
 
+	// For symbol search only:
+	FieldSelect = "select" // Selects the kinds of symbols to return, e.g. select:symbol.function.
+
 	// For diff and commit search only:
 	FieldBefore    = "before"
 	FieldAfter     = "after"
@@ -34,10 +37,16 @@ const (
 	FieldCommitter = "committer"
 	FieldMessage   = "message"
 
+	// For file content search only:
+	FieldBlameAuthor = "blameauthor" // Author of the commit that last changed a matching line.
+	FieldBlameBefore = "blamebefore" // Date before which a matching line was last changed.
+	FieldBlameAfter  = "blameafter"  // Date after which a matching line was last changed.
+
 	// Temporary experimental fields:
 	FieldIndex     = "index"
 	FieldCount     = "count"  // Searches that specify `count:` will fetch at least that number of results, or the full result set
 	FieldStable    = "stable" // Forces search to return a stable result ordering (currently limited to file content matches).
+	FieldRank      = "rank"   // Selects how results are ordered: by relevance or lexicographically (the default).
 	FieldMax       = "max"    // Deprecated alias for count
 	FieldTimeout   = "timeout"
 	FieldReplace   = "replace"
@@ -63,6 +72,8 @@ var (
 			FieldContent:     {Literal: types.StringType, Quoted: types.StringType, Singular: true},
 			FieldVisibility:  {Literal: types.StringType, Quoted: types.StringType, Singular: true},
 
+			FieldSelect: stringFieldType,
+
 			FieldRepoHasFile:        regexpNegatableFieldType,
 			FieldRepoHasCommitAfter: {Literal: types.StringType, Quoted: types.StringType, Singular: true},
 
@@ -72,10 +83,15 @@ var (
 			FieldCommitter: regexpNegatableFieldType,
 			FieldMessage:   regexpNegatableFieldType,
 
+			FieldBlameAuthor: regexpNegatableFieldType,
+			FieldBlameBefore: {Literal: types.StringType, Quoted: types.StringType, Singular: true},
+			FieldBlameAfter:  {Literal: types.StringType, Quoted: types.StringType, Singular: true},
+
 			// Experimental fields:
 			FieldIndex:     {Literal: types.StringType, Quoted: types.StringType, Singular: true},
 			FieldCount:     {Literal: types.StringType, Quoted: types.StringType, Singular: true},
 			FieldStable:    {Literal: types.BoolType, Quoted: types.BoolType, Singular: true},
+			FieldRank:      {Literal: types.StringType, Quoted: types.StringType, Singular: true},
 			FieldMax:       {Literal: types.StringType, Quoted: types.StringType, Singular: true},
 			FieldTimeout:   {Literal: types.StringType, Quoted: types.StringType, Singular: true},
 			FieldReplace:   {Literal: types.StringType, Quoted: types.StringType, Singular: true},
@@ -190,7 +190,8 @@ func valueToTypedValue(field, value string, quoted bool) []*types.Value {
 		FieldLang, "l", "language",
 		FieldType,
 		FieldPatternType,
-		FieldContent:
+		FieldContent,
+		FieldSelect:
 		return []*types.Value{{String: &value}}
 
 	case FieldRepoHasFile:
@@ -205,12 +206,19 @@ func valueToTypedValue(field, value string, quoted bool) []*types.Value {
 	case
 		FieldAuthor,
 		FieldCommitter,
-		FieldMessage, "m", "msg":
+		FieldMessage, "m", "msg",
+		FieldBlameAuthor:
 		return []*types.Value{{Regexp: parseRegexpOrPanic(field, value)}}
 
+	case
+		FieldBlameBefore,
+		FieldBlameAfter:
+		return []*types.Value{{String: &value}}
+
 	case
 		FieldIndex,
 		FieldCount,
+		FieldRank,
 		FieldMax,
 		FieldTimeout,
 		FieldReplace,
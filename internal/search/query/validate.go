@@ -225,7 +225,8 @@ func validateField(field, value string, negated bool, seen map[string]struct{})
 		FieldLang, "l", "language":
 		return satisfies(isLanguage)
 	case
-		FieldType:
+		FieldType,
+		FieldSelect:
 		return satisfies(isNotNegated)
 	case
 		FieldPatternType,
@@ -244,8 +245,13 @@ func validateField(field, value string, negated bool, seen map[string]struct{})
 	case
 		FieldAuthor,
 		FieldCommitter,
-		FieldMessage, "m", "msg":
+		FieldMessage, "m", "msg",
+		FieldBlameAuthor:
 		return satisfies(isValidRegexp)
+	case
+		FieldBlameBefore,
+		FieldBlameAfter:
+		return satisfies(isSingular, isNotNegated)
 	case
 		FieldIndex:
 		return satisfies(isSingular, isNotNegated)
@@ -256,6 +262,7 @@ func validateField(field, value string, negated bool, seen map[string]struct{})
 		FieldStable:
 		return satisfies(isSingular, isBoolean, isNotNegated)
 	case
+		FieldRank,
 		FieldMax,
 		FieldTimeout,
 		FieldReplace,
@@ -64,6 +64,23 @@ type SymbolsParameters struct {
 	// need to match to get included in the result
 	ExcludePattern string
 
+	// Kinds, if non-empty, is a list of ctags kinds (such as "function" or
+	// "struct"). Only symbols of one of these kinds are included in the result.
+	// Kinds are compared case-insensitively.
+	Kinds []string
+
+	// Parent, if non-empty, is the name of the symbol (such as a class or
+	// struct) whose members to return. Only symbols whose parent has this
+	// name, unqualified (such as "Inner" for "Outer.Inner" or "Class" for
+	// "ns::Class"), are included in the result.
+	Parent string
+
+	// ParentKind, if non-empty, is the ctags kind of the symbol named Parent.
+	// Symbols whose parent is of another kind (such as a namespace with the
+	// same name as a class) are excluded from the result. It is compared
+	// case-insensitively.
+	ParentKind string
+
 	// First indicates that only the first n symbols should be returned.
 	First int
 }
@@ -102,6 +102,37 @@ func (c *Client) Search(ctx context.Context, args search.SymbolsParameters) (res
 	return result, err
 }
 
+// Outline returns the outline of a file, which is the tree of symbols defined
+// in it.
+func (c *Client) Outline(ctx context.Context, args protocol.OutlineArgs) (result *protocol.OutlineResult, err error) {
+	span, ctx := ot.StartSpanFromContext(ctx, "symbols.Client.Outline")
+	defer func() {
+		if err != nil {
+			ext.Error.Set(span, true)
+			span.LogFields(otlog.Error(err))
+		}
+		span.Finish()
+	}()
+	span.SetTag("Repo", string(args.Repo))
+	span.SetTag("CommitID", string(args.CommitID))
+	span.SetTag("Path", args.Path)
+
+	resp, err := c.httpPost(ctx, "outline", key{repo: args.Repo, commitID: args.CommitID}, args)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// best-effort inclusion of body in error message
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 200))
+		return nil, errors.Errorf("Symbol.Outline http status %d for %+v: %s", resp.StatusCode, args, string(body))
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	return result, err
+}
+
 func (c *Client) httpPost(ctx context.Context, method string, key key, payload interface{}) (resp *http.Response, err error) {
 	span, ctx := ot.StartSpanFromContext(ctx, "symbols.Client.httpPost")
 	defer func() {
@@ -33,6 +33,23 @@ type SearchArgs struct {
 	// need to match to get included in the result
 	ExcludePattern string
 
+	// Kinds, if non-empty, is a list of ctags kinds (such as "function" or
+	// "struct"). Only symbols of one of these kinds are included in the result.
+	// Kinds are compared case-insensitively.
+	Kinds []string
+
+	// Parent, if non-empty, is the name of the symbol (such as a class or
+	// struct) whose members to return. Only symbols whose parent has this
+	// name, unqualified (such as "Inner" for "Outer.Inner" or "Class" for
+	// "ns::Class"), are included in the result.
+	Parent string
+
+	// ParentKind, if non-empty, is the ctags kind of the symbol named Parent.
+	// Symbols whose parent is of another kind (such as a namespace with the
+	// same name as a class) are excluded from the result. It is compared
+	// case-insensitively.
+	ParentKind string
+
 	// First indicates that only the first n symbols should be returned.
 	First int
 }
@@ -42,6 +59,31 @@ type SearchResult struct {
 	Symbols []Symbol // code symbols
 }
 
+// OutlineArgs are the arguments to get the outline of a file from the symbols
+// service.
+type OutlineArgs struct {
+	// Repo is the name of the repository containing the file.
+	Repo api.RepoName `json:"repo"`
+
+	// CommitID is the commit containing the file.
+	CommitID api.CommitID `json:"commitID"`
+
+	// Path is the path of the file.
+	Path string
+}
+
+// OutlineResult is the outline of a file.
+type OutlineResult struct {
+	Symbols []OutlineSymbol // the top-level symbols, ordered by line
+}
+
+// OutlineSymbol is a symbol in the outline of a file, along with the symbols
+// that it contains (such as the methods and fields of a class).
+type OutlineSymbol struct {
+	Symbol
+	Children []OutlineSymbol `json:",omitempty"` // ordered by line
+}
+
 // Symbol is a code symbol.
 type Symbol struct {
 	Name       string
@@ -36,6 +36,10 @@ type Hunk struct {
 
 // BlameFile returns Git blame information about a file.
 func BlameFile(ctx context.Context, repo gitserver.Repo, path string, opt *BlameOptions) ([]*Hunk, error) {
+	if Mocks.BlameFile != nil {
+		return Mocks.BlameFile(path, opt)
+	}
+
 	span, ctx := ot.StartSpanFromContext(ctx, "Git: BlameFile")
 	span.SetTag("repo", repo.Name)
 	span.SetTag("path", path)
@@ -0,0 +1,79 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
+)
+
+// Changes describes the files that differ between the trees of two commits.
+type Changes struct {
+	Added    []string
+	Modified []string
+	Deleted  []string
+}
+
+// Len returns the total number of changed files.
+func (c *Changes) Len() int {
+	return len(c.Added) + len(c.Modified) + len(c.Deleted)
+}
+
+// ChangedFiles returns the files that differ between the trees of commits
+// base and head. Renames are reported as a deletion of the old path and an
+// addition of the new path.
+func ChangedFiles(ctx context.Context, repo gitserver.Repo, base, head api.CommitID) (*Changes, error) {
+	span, ctx := ot.StartSpanFromContext(ctx, "Git: ChangedFiles")
+	span.SetTag("Base", base)
+	span.SetTag("Head", head)
+	defer span.Finish()
+
+	if err := checkSpecArgSafety(string(base)); err != nil {
+		return nil, err
+	}
+	if err := checkSpecArgSafety(string(head)); err != nil {
+		return nil, err
+	}
+
+	cmd := gitserver.DefaultClient.Command("git", "diff", "-z", "--name-status", "--no-renames", string(base), string(head), "--")
+	cmd.Repo = repo
+	out, err := cmd.CombinedOutput(ctx)
+	if err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("git command %v failed (output: %q)", cmd.Args, out))
+	}
+	return parseNameStatus(out)
+}
+
+// parseNameStatus parses the output of `git diff -z --name-status
+// --no-renames`, which is a NUL-separated list of status and path pairs.
+func parseNameStatus(out []byte) (*Changes, error) {
+	var changes Changes
+	fields := bytes.Split(bytes.TrimSuffix(out, []byte{0}), []byte{0})
+	if len(fields) == 1 && len(fields[0]) == 0 {
+		return &changes, nil
+	}
+	if len(fields)%2 != 0 {
+		return nil, errors.Errorf("unexpected git diff --name-status output: %q", out)
+	}
+	for i := 0; i < len(fields); i += 2 {
+		status, path := fields[i], string(fields[i+1])
+		if len(status) == 0 {
+			return nil, errors.Errorf("empty status for path %q in git diff output", path)
+		}
+		switch status[0] {
+		case 'A':
+			changes.Added = append(changes.Added, path)
+		case 'M', 'T':
+			changes.Modified = append(changes.Modified, path)
+		case 'D':
+			changes.Deleted = append(changes.Deleted, path)
+		default:
+			return nil, errors.Errorf("unexpected status %q for path %q in git diff output", status, path)
+		}
+	}
+	return &changes, nil
+}
@@ -0,0 +1,91 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChangedFiles(t *testing.T) {
+	t.Parallel()
+
+	repo := MakeGitRepository(t,
+		"echo a > a",
+		"echo b > b",
+		"echo c > c",
+		"git add a b c",
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com GIT_COMMITTER_DATE=2006-01-02T15:04:05Z git commit -m base --author='a <a@a.com>' --date 2006-01-02T15:04:05Z",
+		"git tag base",
+		"echo a2 >> a",
+		"git rm b",
+		"git mv c d",
+		"echo e > e",
+		"git add a e",
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com GIT_COMMITTER_DATE=2006-01-02T15:04:05Z git commit -m head --author='a <a@a.com>' --date 2006-01-02T15:04:05Z",
+	)
+
+	base, err := ResolveRevision(ctx, repo, nil, "base", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := ResolveRevision(ctx, repo, nil, "master", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := ChangedFiles(ctx, repo, base, head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Changes{
+		Added:    []string{"d", "e"},
+		Modified: []string{"a"},
+		Deleted:  []string{"b", "c"},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("got %+v, want %+v", changes, want)
+	}
+
+	if _, err := ChangedFiles(ctx, repo, "-foo", head); err == nil {
+		t.Error("expected error for unsafe revision")
+	}
+}
+
+func TestParseNameStatus(t *testing.T) {
+	tests := map[string]struct {
+		out     string
+		want    *Changes
+		wantErr bool
+	}{
+		"empty": {
+			out:  "",
+			want: &Changes{},
+		},
+		"all kinds": {
+			out: "A\x00new file.go\x00M\x00a.go\x00T\x00link\x00D\x00old.go\x00",
+			want: &Changes{
+				Added:    []string{"new file.go"},
+				Modified: []string{"a.go", "link"},
+				Deleted:  []string{"old.go"},
+			},
+		},
+		"odd fields": {
+			out:     "A\x00a.go\x00M\x00",
+			wantErr: true,
+		},
+		"unknown status": {
+			out:     "X\x00a.go\x00",
+			wantErr: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := parseNameStatus([]byte(test.out))
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want error %v", err, test.wantErr)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/trace"
+)
+
+// IndexedLogDiffSearchOptions specifies options to IndexedLogDiffSearch.
+type IndexedLogDiffSearchOptions struct {
+	// Query specifies the search query to find in the added and removed
+	// lines of diffs. Its case sensitivity also applies to the message,
+	// author and committer patterns.
+	Query TextSearchOptions
+
+	// Diff is whether the diff should be returned.
+	Diff bool
+
+	// OnlyMatchingHunks makes the diff only include hunks that match the query. If false,
+	// all hunks from files that match the query are included.
+	OnlyMatchingHunks bool
+
+	// Paths specifies the paths to include/exclude.
+	Paths PathOptions
+
+	// MessagePatterns, AuthorPatterns and CommitterPatterns are regular
+	// expressions that commits must match, like the git log flags --grep,
+	// --author and --committer with --all-match. Commits must match none of
+	// the negated patterns.
+	MessagePatterns          []string
+	NegatedMessagePatterns   []string
+	AuthorPatterns           []string
+	NegatedAuthorPatterns    []string
+	CommitterPatterns        []string
+	NegatedCommitterPatterns []string
+
+	// Before and After are dates like those of the git log flags --until and
+	// --since.
+	Before []string
+	After  []string
+
+	// Limit is the maximum number of results.
+	Limit int
+}
+
+// IndexedLogDiffSearch is like RawLogDiffSearch for the default branch of
+// repo, but it searches the commit index gitserver keeps instead of running
+// git log. If gitserver has no commit index for repo, indexed is false and
+// callers should fall back to RawLogDiffSearch.
+func IndexedLogDiffSearch(ctx context.Context, repo gitserver.Repo, opt IndexedLogDiffSearchOptions) (results []*LogCommitSearchResult, indexed bool, err error) {
+	if Mocks.IndexedLogDiffSearch != nil {
+		return Mocks.IndexedLogDiffSearch(opt)
+	}
+
+	tr, ctx := trace.New(ctx, "Git: IndexedLogDiffSearch", fmt.Sprintf("%+v", opt))
+	defer func() {
+		tr.LazyPrintf("%d results, indexed=%v", len(results), indexed)
+		tr.SetError(err)
+		tr.Finish()
+	}()
+
+	// Even though gitserver already searched the diffs using the query, we
+	// need to search them again to filter to only matching hunks and to
+	// highlight matches.
+	var diffPattern string
+	var query *regexp.Regexp
+	if diffPattern = opt.Query.Pattern; diffPattern != "" {
+		if !opt.Query.IsRegExp {
+			diffPattern = regexp.QuoteMeta(diffPattern)
+		}
+		pattern := diffPattern
+		if !opt.Query.IsCaseSensitive {
+			pattern = "(?i:" + pattern + ")"
+		}
+		query, err = regexp.Compile(pattern)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	pathMatcher, err := compilePathMatcher(opt.Paths)
+	if err != nil {
+		return nil, false, err
+	}
+
+	req := &protocol.CommitSearchRequest{
+		Repo:                         repo.Name,
+		DiffPattern:                  diffPattern,
+		MessagePatterns:              opt.MessagePatterns,
+		NegatedMessagePatterns:       opt.NegatedMessagePatterns,
+		AuthorPatterns:               opt.AuthorPatterns,
+		NegatedAuthorPatterns:        opt.NegatedAuthorPatterns,
+		CommitterPatterns:            opt.CommitterPatterns,
+		NegatedCommitterPatterns:     opt.NegatedCommitterPatterns,
+		IsCaseSensitive:              opt.Query.IsCaseSensitive,
+		IncludePatterns:              opt.Paths.IncludePatterns,
+		ExcludePattern:               opt.Paths.ExcludePattern,
+		PathPatternsAreRegExps:       opt.Paths.IsRegExp,
+		PathPatternsAreCaseSensitive: opt.Paths.IsCaseSensitive,
+		Before:                       opt.Before,
+		After:                        opt.After,
+		IncludeDiff:                  opt.Diff,
+		Limit:                        opt.Limit,
+	}
+	resp, err := gitserver.DefaultClient.SearchCommits(ctx, req)
+	if err != nil || !resp.Indexed {
+		return nil, false, err
+	}
+
+	// The index covers the commits reachable from HEAD, so that is the ref
+	// by which all results were reached.
+	var cache refResolveCache
+	sourceRefs, err := filterAndResolveRefs(ctx, repo, []string{"HEAD"}, &cache)
+	if err != nil {
+		return nil, true, err
+	}
+
+	results = make([]*LogCommitSearchResult, 0, len(resp.Commits))
+	for _, c := range resp.Commits {
+		result := &LogCommitSearchResult{
+			Commit: Commit{
+				ID:        c.ID,
+				Author:    Signature{Name: c.AuthorName, Email: c.AuthorEmail, Date: c.AuthorDate},
+				Committer: &Signature{Name: c.CommitterName, Email: c.CommitterEmail, Date: c.CommitterDate},
+				Message:   c.Message,
+				Parents:   c.Parents,
+			},
+			SourceRefs: sourceRefs,
+		}
+		if opt.Diff && c.Diff != "" {
+			rawDiff, highlights, err := filterAndHighlightDiff([]byte(c.Diff), query, opt.OnlyMatchingHunks, pathMatcher)
+			if err != nil {
+				return nil, true, err
+			}
+			if rawDiff != nil {
+				result.Diff = &Diff{Raw: string(rawDiff)}
+				result.DiffHighlights = highlights
+			}
+		}
+		results = append(results, result)
+	}
+	return results, true, nil
+}
@@ -0,0 +1,79 @@
+package git
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestRepository_IndexedLogDiffSearch(t *testing.T) {
+	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{
+		ExperimentalFeatures: &schema.ExperimentalFeatures{CommitSearchIndex: "enabled"},
+	}})
+	defer conf.Mock(nil)
+
+	repo := MakeGitRepository(t,
+		"echo root > f",
+		"git add f",
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com GIT_COMMITTER_DATE=2006-01-02T15:04:05Z git commit -m root --author='a <a@a.com>' --date 2006-01-02T15:04:05Z",
+		"echo branch1 > f",
+		"git add f",
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com GIT_COMMITTER_DATE=2006-01-02T15:04:06Z git commit -m branch1 --author='a <a@a.com>' --date 2006-01-02T15:04:06Z",
+	)
+
+	opt := IndexedLogDiffSearchOptions{
+		Query:             TextSearchOptions{Pattern: "root"},
+		Diff:              true,
+		OnlyMatchingHunks: true,
+		Limit:             10,
+	}
+
+	// The repository is indexed in the background after it is cloned.
+	var results []*LogCommitSearchResult
+	for start := time.Now(); ; {
+		var indexed bool
+		var err error
+		results, indexed, err = IndexedLogDiffSearch(context.Background(), repo, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if indexed {
+			break
+		}
+		if time.Since(start) > 10*time.Second {
+			t.Fatal("repository was not indexed")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	want := []*LogCommitSearchResult{{
+		Commit: Commit{
+			ID:        "b9b2349a02271ca96e82c70f384812f9c62c26ab",
+			Author:    Signature{Name: "a", Email: "a@a.com", Date: MustParseTime(time.RFC3339, "2006-01-02T15:04:06Z")},
+			Committer: &Signature{Name: "a", Email: "a@a.com", Date: MustParseTime(time.RFC3339, "2006-01-02T15:04:06Z")},
+			Message:   "branch1",
+			Parents:   []api.CommitID{"ce72ece27fd5c8180cfbc1c412021d32fd1cda0d"},
+		},
+		SourceRefs:     []string{"refs/heads/master"},
+		Diff:           &Diff{Raw: "diff --git f f\nindex d8649da..1193ff4 100644\n--- f\n+++ f\n@@ -1,1 +1,1 @@\n-root\n+branch1\n"},
+		DiffHighlights: []Highlight{{Line: 6, Character: 1, Length: 4}},
+	}, {
+		Commit: Commit{
+			ID:        "ce72ece27fd5c8180cfbc1c412021d32fd1cda0d",
+			Author:    Signature{Name: "a", Email: "a@a.com", Date: MustParseTime(time.RFC3339, "2006-01-02T15:04:05Z")},
+			Committer: &Signature{Name: "a", Email: "a@a.com", Date: MustParseTime(time.RFC3339, "2006-01-02T15:04:05Z")},
+			Message:   "root",
+		},
+		SourceRefs:     []string{"refs/heads/master"},
+		Diff:           &Diff{Raw: "diff --git f f\nnew file mode 100644\nindex 0000000..d8649da\n--- /dev/null\n+++ f\n@@ -0,0 +1,1 @@\n+root\n"},
+		DiffHighlights: []Highlight{{Line: 7, Character: 1, Length: 4}},
+	}}
+	if diff := cmp.Diff(want, results); diff != "" {
+		t.Errorf("unexpected results (-want +got):\n%s", diff)
+	}
+}
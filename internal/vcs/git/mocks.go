@@ -12,15 +12,17 @@ import (
 //
 // (The emptyMocks is used by ResetMocks to zero out Mocks without needing to use a named type.)
 var Mocks, emptyMocks struct {
-	GetCommit        func(api.CommitID) (*Commit, error)
-	ExecSafe         func(params []string) (stdout, stderr []byte, exitCode int, err error)
-	RawLogDiffSearch func(opt RawLogDiffSearchOptions) ([]*LogCommitSearchResult, bool, error)
-	NewFileReader    func(commit api.CommitID, name string) (io.ReadCloser, error)
-	ReadFile         func(commit api.CommitID, name string) ([]byte, error)
-	ReadDir          func(commit api.CommitID, name string, recurse bool) ([]os.FileInfo, error)
-	ResolveRevision  func(spec string, opt *ResolveRevisionOptions) (api.CommitID, error)
-	Stat             func(commit api.CommitID, name string) (os.FileInfo, error)
-	GetObject        func(objectName string) (OID, ObjectType, error)
+	GetCommit            func(api.CommitID) (*Commit, error)
+	ExecSafe             func(params []string) (stdout, stderr []byte, exitCode int, err error)
+	RawLogDiffSearch     func(opt RawLogDiffSearchOptions) ([]*LogCommitSearchResult, bool, error)
+	IndexedLogDiffSearch func(opt IndexedLogDiffSearchOptions) ([]*LogCommitSearchResult, bool, error)
+	NewFileReader        func(commit api.CommitID, name string) (io.ReadCloser, error)
+	ReadFile             func(commit api.CommitID, name string) ([]byte, error)
+	ReadDir              func(commit api.CommitID, name string, recurse bool) ([]os.FileInfo, error)
+	ResolveRevision      func(spec string, opt *ResolveRevisionOptions) (api.CommitID, error)
+	Stat                 func(commit api.CommitID, name string) (os.FileInfo, error)
+	GetObject            func(objectName string) (OID, ObjectType, error)
+	BlameFile            func(path string, opt *BlameOptions) ([]*Hunk, error)
 }
 
 // ResetMocks clears the mock functions set on Mocks (so that subsequent tests don't inadvertently
@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP TABLE IF EXISTS saved_search_runs;
+
+ALTER TABLE saved_searches DROP COLUMN IF EXISTS webhook_url;
+ALTER TABLE saved_searches DROP COLUMN IF EXISTS webhook_secret;
+
+COMMIT;
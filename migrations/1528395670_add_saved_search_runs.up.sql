@@ -0,0 +1,18 @@
+BEGIN;
+
+ALTER TABLE saved_searches ADD COLUMN webhook_url text;
+ALTER TABLE saved_searches ADD COLUMN webhook_secret text;
+
+CREATE TABLE saved_search_runs (
+    id bigserial PRIMARY KEY,
+    saved_search_id integer NOT NULL REFERENCES saved_searches(id) ON DELETE CASCADE,
+    started_at timestamp with time zone NOT NULL,
+    finished_at timestamp with time zone NOT NULL,
+    matches text[] NOT NULL DEFAULT '{}',
+    new_matches jsonb NOT NULL DEFAULT '[]',
+    error text
+);
+
+CREATE INDEX saved_search_runs_saved_search_id_finished_at ON saved_search_runs(saved_search_id, finished_at DESC);
+
+COMMIT;
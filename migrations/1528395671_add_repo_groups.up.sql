@@ -0,0 +1,21 @@
+BEGIN;
+
+CREATE TABLE repo_groups (
+    id serial PRIMARY KEY,
+    name text NOT NULL,
+    description text NOT NULL DEFAULT '',
+    user_id integer REFERENCES users(id) ON DELETE CASCADE,
+    org_id integer REFERENCES orgs(id) ON DELETE CASCADE,
+    rule jsonb,
+    repositories text[] NOT NULL DEFAULT '{}',
+    evaluated_at timestamp with time zone,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT repo_groups_name_nonempty CHECK (name <> ''),
+    CONSTRAINT repo_groups_user_or_org_id_not_null CHECK ((user_id IS NULL) <> (org_id IS NULL))
+);
+
+CREATE UNIQUE INDEX repo_groups_user_id_name ON repo_groups(user_id, name) WHERE user_id IS NOT NULL;
+CREATE UNIQUE INDEX repo_groups_org_id_name ON repo_groups(org_id, name) WHERE org_id IS NOT NULL;
+
+COMMIT;
@@ -0,0 +1,33 @@
+BEGIN;
+
+CREATE TABLE audit_log (
+    id bigserial PRIMARY KEY,
+    actor_user_id integer,
+    actor_internal boolean NOT NULL DEFAULT false,
+    actor_ip text NOT NULL DEFAULT '',
+    actor_forwarded_for text NOT NULL DEFAULT '',
+    action text NOT NULL,
+    target_type text NOT NULL,
+    target_id text NOT NULL DEFAULT '',
+    before jsonb,
+    after jsonb,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT audit_log_action_nonempty CHECK (action <> '')
+);
+
+CREATE INDEX audit_log_created_at ON audit_log(created_at);
+CREATE INDEX audit_log_actor_user_id ON audit_log(actor_user_id);
+CREATE INDEX audit_log_target ON audit_log(target_type, target_id);
+
+-- The audit log is append-only: entries can't be changed or removed.
+CREATE FUNCTION audit_log_append_only() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+    BEGIN
+        RAISE EXCEPTION 'audit_log is append-only';
+    END;
+$$;
+
+CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE ON audit_log FOR EACH ROW EXECUTE PROCEDURE audit_log_append_only();
+
+COMMIT;
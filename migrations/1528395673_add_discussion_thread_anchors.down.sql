@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE discussion_threads_target_repo DROP COLUMN IF EXISTS anchor_revision;
+ALTER TABLE discussion_threads_target_repo DROP COLUMN IF EXISTS anchor_path;
+ALTER TABLE discussion_threads_target_repo DROP COLUMN IF EXISTS anchor_start_line;
+ALTER TABLE discussion_threads_target_repo DROP COLUMN IF EXISTS anchor_end_line;
+ALTER TABLE discussion_threads_target_repo DROP COLUMN IF EXISTS outdated;
+
+COMMIT;
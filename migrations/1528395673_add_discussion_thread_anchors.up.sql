@@ -0,0 +1,11 @@
+BEGIN;
+
+-- The anchor of a thread is where its selection is at the most recent commit
+-- of the branch it targets, as tracked through the commits on that branch.
+ALTER TABLE discussion_threads_target_repo ADD COLUMN anchor_revision text;
+ALTER TABLE discussion_threads_target_repo ADD COLUMN anchor_path text;
+ALTER TABLE discussion_threads_target_repo ADD COLUMN anchor_start_line integer;
+ALTER TABLE discussion_threads_target_repo ADD COLUMN anchor_end_line integer;
+ALTER TABLE discussion_threads_target_repo ADD COLUMN outdated boolean NOT NULL DEFAULT false;
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS repo_language_history;
+DROP TABLE IF EXISTS repo_languages;
+DROP TABLE IF EXISTS repo_inventories;
+
+COMMIT;
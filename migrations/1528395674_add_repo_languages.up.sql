@@ -0,0 +1,35 @@
+BEGIN;
+
+-- The language inventory of the default branch of each repository, as of
+-- commit_id.
+CREATE TABLE repo_inventories (
+    repo_id integer PRIMARY KEY REFERENCES repo(id) ON DELETE CASCADE,
+    commit_id text NOT NULL,
+    updated_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+CREATE TABLE repo_languages (
+    repo_id integer NOT NULL REFERENCES repo_inventories(repo_id) ON DELETE CASCADE,
+    language text NOT NULL,
+    total_lines bigint NOT NULL,
+    total_bytes bigint NOT NULL,
+    PRIMARY KEY (repo_id, language)
+);
+
+CREATE INDEX repo_languages_language ON repo_languages(lower(language));
+
+-- The statistics of each language of each repository at the end of each day
+-- they changed. A language that was removed from a repository is recorded with
+-- 0 lines and bytes.
+CREATE TABLE repo_language_history (
+    repo_id integer NOT NULL REFERENCES repo(id) ON DELETE CASCADE,
+    language text NOT NULL,
+    recorded_on date NOT NULL,
+    total_lines bigint NOT NULL,
+    total_bytes bigint NOT NULL,
+    PRIMARY KEY (repo_id, language, recorded_on)
+);
+
+CREATE INDEX repo_language_history_language_recorded_on ON repo_language_history(lower(language), recorded_on);
+
+COMMIT;
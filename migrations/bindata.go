@@ -106,6 +106,16 @@
 // 1528395668_campaign_description_nullable.up.sql (143B)
 // 1528395669_add_synced_at_to_perms_tables.down.sql (121B)
 // 1528395669_add_synced_at_to_perms_tables.up.sql (143B)
+// 1528395670_add_saved_search_runs.down.sql (185B)
+// 1528395670_add_saved_search_runs.up.sql (605B)
+// 1528395671_add_repo_groups.down.sql (51B)
+// 1528395671_add_repo_groups.up.sql (852B)
+// 1528395672_add_audit_log.down.sql (98B)
+// 1528395672_add_audit_log.up.sql (1.059kB)
+// 1528395673_add_discussion_thread_anchors.down.sql (418B)
+// 1528395673_add_discussion_thread_anchors.up.sql (574B)
+// 1528395674_add_repo_languages.down.sql (137B)
+// 1528395674_add_repo_languages.up.sql (1.201kB)
 
 package migrations
 
@@ -174,7 +184,7 @@ func (fi bindataFileInfo) Sys() interface{} {
 	return nil
 }
 
-var __1528395617_squashed_migrationsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x3c\xcc\xcd\x0a\x82\x40\x14\xc5\xf1\xfd\x3c\xc5\x59\x16\xf4\x06\xae\xa6\xf1\x46\x92\x5f\xcc\x4c\x90\xab\x50\x13\xbb\xa0\x33\xa1\x16\xf4\xf6\x91\x31\x6d\xef\xfd\xfd\x4f\xac\x8b\x12\x46\x1d\x29\x93\x48\x0e\xa0\x4b\x62\xac\xc1\xe3\xd9\x0c\xdc\x42\x49\xa3\x64\x4c\x91\x50\x9a\xa4\xa5\xe0\x7e\xdf\x48\x84\xb3\x95\xfb\x94\xbe\x75\x5e\xd8\xb0\x30\xb7\xf7\x6e\xac\xaf\x23\xf7\x53\xbd\xb0\x77\x33\x36\x02\x00\x5e\xdd\x34\xb3\x77\x68\xb8\x67\xb7\xac\x45\x7e\x4e\x53\x94\x3a\xc9\xa4\xae\x70\xa2\x6a\xb7\xc2\x1b\x4f\xcb\x1b\x8d\xf7\x43\x57\xbb\xbf\x13\xdb\x48\x7c\x02\x00\x00\xff\xff\x2a\x5a\x7a\xd1\xb3\x00\x00\x00")
+var __1528395617_squashed_migrationsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x3c\xcc\xcd\x0a\x82\x40\x14\xc5\xf1\xfd\x3c\xc5\x59\x16\xf4\x06\xae\xa6\xf1\x46\x92\x5f\xcc\x4c\x90\xab\x50\x13\xbb\xa0\x33\xa1\x16\xf4\xf6\x91\x31\x6d\xef\xfd\xfd\x4f\xac\x8b\x12\x46\x1d\x29\x93\x48\x0e\xa0\x4b\x62\xac\xc1\xe3\xd9\x0c\xdc\x42\x49\xa3\x64\x4c\x91\x50\x9a\xa4\xa5\xe0\x7e\xdf\x48\x84\xb3\x95\xfb\x94\xbe\x75\x5e\xd8\xb0\x30\xb7\xf7\x6e\xac\xaf\x23\xf7\x53\xbd\xb0\x77\x33\x36\x02\x00\x5e\xdd\x34\xb3\x77\x68\xb8\x67\xb7\xac\x45\x7e\x4e\x53\x94\x3a\xc9\xa4\xae\x70\xa2\x6a\xb7\xc2\x1b\x4f\xcb\x1b\x8d\xf7\x43\x57\xbb\xbf\x13\xdb\x48\x7c\x06\x00\x2a\x5a\x7a\xd1\xb3\x00\x00\x00")
 
 func _1528395617_squashed_migrationsDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -194,7 +204,7 @@ func _1528395617_squashed_migrationsDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395617_squashed_migrationsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xd4\x7d\x6d\x73\x1b\x37\xf2\xe7\x7b\x7d\x0a\x5c\xca\x5b\x22\x73\xb2\x2a\xce\x26\x9b\x3b\x3b\x4e\x4a\x91\xc6\x0e\x2f\x32\xe9\x3f\x45\xad\x9d\xdb\xda\x9b\x82\x38\x20\x89\xd5\x70\x86\x99\x07\xc9\xca\xee\xfe\x3f\xfb\x15\x80\x79\x00\x30\x8d\x87\x19\x91\x72\x76\x5f\x6c\x2c\x4e\xe3\xd7\x0d\xa0\x01\x74\x37\x1a\xc0\x4f\xc1\xdb\xc9\xf4\xd5\xd1\xd1\xd9\xe5\x22\x98\xa3\xc5\xd9\x4f\x97\x01\x9a\xbc\x41\xc1\xc7\xc9\xd5\xe2\x0a\xcd\xa6\x97\xbf\xa2\x32\x27\x59\x48\x3e\x15\x24\x4b\x70\x1c\xe2\xe5\x32\x2d\x93\x22\x47\x17\xf3\xd9\x7b\x74\x3e\x9b\x5e\x2d\xe6\x67\x93\xe9\x42\x2a\x05\x17\x08\xf9\xcf\x34\x0a\x57\xb7\xe4\xe1\x95\x9b\xe1\x16\xd3\xd8\xcd\x85\x53\xf9\x43\xe7\x65\x76\x47\x1e\xc2\x8c\xe4\xbb\x34\xc9\x89\x0d\x5f\x27\xed\xc1\x84\x14\x05\x4d\xd6\x56\xf0\x8a\xe4\x30\xa0\x19\x59\x91\x8c\x24\x4b\x92\x87\x69\xb6\xce\xf7\x86\x8b\xcb\x62\x93\x66\x3d\x64\xc6\x77\x24\x0a\x73\x82\xb3\xe5\xc6\xde\xd6\x0a\xe1\xe1\x19\xa4\xd9\xda\x0b\x3f\x23\x6b\x9a\x17\xd9\x03\xd7\xe6\x24\xa7\x69\x62\x63\x02\x50\x87\xbb\xf2\x26\xa6\xf9\x86\xf4\x68\xb5\x7d\x32\x1d\x5e\xd1\x30\x23\x31\xc1\xf6\x21\x62\x29\x15\x02\xdf\x3e\x8f\x20\xcb\x8c\xe0\xa2\x8f\xd6\xee\xb2\x34\x2a\x97\x45\x98\x97\x37\xf9\x32\xa3\xbb\xc2\xd1\x03\x20\x7d\x7f\x76\x31\x5d\x12\xc7\x94\xa4\x93\x86\x10\x6b\x2f\xa6\x4c\x2f\xb6\x64\x7b\x43\x32\x1b\x3f\x89\xca\xbf\x3e\xfd\xa1\xfb\x4c\x57\x5c\xa1\x93\x3b\x5a\x60\x57\xb7\x68\x94\x61\x4e\x92\xa8\xcf\x30\x1c\xce\x29\x23\x4b\xba\xa3\x24\x29\x9e\x82\x99\xef\x08\x4f\xf0\xd6\xaa\x5b\xfc\xbb\xbf\xc0\x7e\x70\xbe\xc2\xc5\x39\x5d\x49\x6a\x60\xc1\xd5\x28\xc3\xa8\xdc\xee\xfc\x59\xec\xf0\xf2\x16\xaf\xdd\x0c\x6a\x3a\x7f\xf8\x88\xe6\xcb\x32\xe7\x33\x4f\xb1\xc9\x08\x8e\xf2\xb0\xc0\xd9\x9a\x14\x61\x46\x76\xa9\x85\x9f\xbd\x60\xf5\xdb\x67\x15\x81\xff\xdf\x30\x01\x86\x33\xe5\xfc\x0e\xca\xad\xaf\x31\x23\x41\x30\xa3\x93\x89\x19\x3f\x84\x45\x7a\x4b\xac\xe3\xd5\x56\xec\xf3\x32\x1f\xa6\x5a\xcb\x74\xbb\x25\x76\xfb\x1f\xa0\x7e\x5a\x66\xbd\xbb\x96\xac\x70\x19\x0b\xcd\xb3\xf2\x92\xe9\xfc\xc7\xc5\x72\x83\x93\x35\xc9\x89\xb5\x1e\x2d\xd1\x00\xe0\xf0\x1f\xe9\x8d\x17\x38\x27\x0c\xdb\x3f\x0f\xca\x05\x6f\x77\x98\xae\x13\xf6\xd7\xd3\x30\xea\xc7\x84\xdc\x39\x54\x4b\x27\xed\xdb\x6e\x95\x58\x56\x16\x35\x4d\xc8\x17\xcc\x1d\x5e\x12\x7f\xb5\x1d\xca\xc0\x77\x55\xee\x87\xdf\xf4\xc2\x2e\xc6\x9e\x5d\xd1\x0b\xbf\x1a\xd4\x7d\x80\x9d\x7a\x24\xd3\xf5\x18\x76\x83\xe0\xfb\xb7\x0f\x5e\x2e\x49\x9e\xbb\x67\x79\x85\x8e\xf9\x02\xff\x20\xcb\x1e\xb6\xe7\x30\x36\xb0\x5b\xc5\x4b\x2f\xe6\x93\xb7\x6f\x83\xb9\x54\xb4\xc8\xe8\x3a\xbc\xc3\x31\x8d\x70\x41\xf4\x96\xc8\xc3\x15\x4d\x98\xbb\x1a\xa1\xd9\xb4\xed\x70\x3b\x58\x44\x62\xc2\xa0\x9a\x11\xd9\xd8\x84\x21\x5b\x09\x1a\xcd\x62\x88\xcd\xcc\xea\x07\x59\x4b\xa7\x22\xb6\x73\x38\x20\xe4\x64\x7a\x11\x7c\xd4\x62\x53\x62\x75\x67\xa3\xce\x4a\x74\x43\xe3\x98\x26\xeb\x70\x59\xe6\x45\xba\xe5\x6d\x69\xa1\x07\xc2\x6a\xd5\x3f\x0c\x85\x44\xe0\xe3\xb7\x92\x64\x94\xe4\xfc\xbf\x0f\x61\x99\xd0\xdf\x4a\x93\x54\x7c\x14\x94\x19\x0d\x69\xf4\xc9\x46\x92\xa7\x65\xc6\x0c\xf0\x35\x4d\x5c\xa4\xac\x0d\xc2\x22\x5b\x6f\x6d\x44\x5b\x52\xe0\x08\x17\xd8\x07\xb0\x69\x84\x9c\x64\x77\x94\x4d\x99\xbc\x46\xd6\x62\xdd\x28\x49\x59\x1a\x9b\xda\x1e\x54\xb1\xf4\xa9\x2d\x10\x71\x47\x32\xf6\xc3\x80\x92\x60\x2c\xc5\x80\xc3\x3c\x68\x9b\x84\x1d\xb7\x98\x26\xeb\x98\xac\x62\xba\xde\x98\x74\xc8\xe9\xdf\x7a\x96\x13\xeb\x8e\x81\x58\x77\xec\x2a\x17\xcc\x46\xdd\x78\x69\xd5\x3f\xec\x8a\xcd\x8b\x30\x7f\x2e\x0f\xef\x68\x4e\x6f\x62\x22\xec\x38\x5a\xa4\xd9\x03\xb7\x1d\xa9\xa9\x01\xa4\xa2\xe5\x2e\x4e\x71\x44\xa2\x10\x5b\x89\x05\x5c\xb5\xa6\x88\x3f\xc2\x1d\xce\x58\x9b\x55\x7f\xb9\x65\xad\x31\x94\x72\x86\x02\xdc\x40\x09\xe3\xb4\x8d\x28\xbb\x09\x0b\xba\x25\x79\x81\xb7\x3b\x37\xa9\x45\x9f\x3c\x9d\xc7\x1d\x2e\x36\x96\xf1\x09\xa0\xd0\xa8\x1f\xbd\x66\xf4\x7b\x95\x35\x7b\x64\xad\xbf\x32\x10\x88\xff\xc7\xaf\x30\xe0\x24\xf5\x2b\xc7\x1a\x39\x63\x4b\x41\x96\xe1\x87\x30\x26\xc9\xda\xb7\xb1\x4d\x2e\x93\xb9\xf0\x32\xa3\x05\x5d\xb2\x05\x28\x89\xc2\x9c\xb2\x05\x33\x4d\x56\x74\x6d\x57\x68\xcd\x62\xcf\x0b\x9c\x15\xb6\x41\xa4\xd1\xd7\x06\x82\x77\x01\x92\x65\x69\x66\x22\x35\xdb\xdc\x3d\x4a\x58\x27\x33\xc9\x2a\x96\x5c\x06\xd7\x62\xa9\xda\x8b\xee\x36\x52\xc8\x3d\x9a\xc8\x6a\x8e\x1a\x0a\xa9\x46\x5f\x9c\xa6\xb7\xe5\xce\xb9\x79\xe7\xda\xb6\xcb\xc3\x9d\xd7\x1e\xe0\x8e\x64\x5b\x9a\xbb\x76\x40\x74\x52\xfe\xef\x30\xad\xac\xe0\x4a\x2b\x9f\x60\x7f\xd3\xaf\x4e\xfd\xf6\x35\x85\x5d\x73\x47\x32\xba\xa2\x24\x12\x3f\xef\x99\x47\x92\x86\x51\xb9\x8b\xe9\x12\x17\x84\xb7\x1d\x1f\x0c\x07\xdb\x45\x75\xb6\x52\x9f\x0d\x49\x37\xd8\x72\x43\xb6\x38\xdc\xd2\x75\xe6\x8c\xac\x77\x68\x3d\xe0\x07\xee\x3f\x3a\x81\x1d\x11\x5b\xbe\xaa\xee\x05\x84\xdb\xe6\x1e\x83\x64\x0f\x9b\x92\x4f\xbf\xf5\xe7\x64\xb9\x9f\xad\x3e\x6f\x36\x83\xb6\xf8\x9c\xe0\x1b\x7c\x93\xb1\xd1\x9b\x66\xce\x00\x66\x87\x36\x6c\x95\xe0\xb0\x7c\x9c\xd5\x60\x7e\x8b\x7d\xdb\xcb\x0f\xa4\xe7\xde\xe3\x01\x20\xab\xf0\x5a\x6d\x4a\x1d\x6e\xeb\xcf\x29\xbb\xdf\x26\x9d\x13\x66\xf8\xee\x9c\x1f\x74\xef\x5d\x39\x3f\x58\xee\xb0\xb9\x30\x85\x57\xd7\x71\x04\xc3\x2c\x4d\x8b\xbd\xb2\xf0\x93\xb9\xf2\xf9\x5c\x90\x8d\x6b\xe8\x02\x5d\xc7\xe9\x0d\x8e\x99\x2d\x59\x10\x0b\xa8\x4c\xe6\x06\xd5\xc3\x2f\x36\x71\x3b\xb4\x1e\xf0\x8d\xdf\x69\xc3\x6d\x9d\x53\x27\xe0\xa1\x36\x46\x07\x30\xee\xc7\xac\x0f\x83\xbd\x6d\x0b\xf6\x61\x3a\x70\x7f\xce\xcd\x62\xd0\x86\x9c\x13\xd6\xe4\xbb\xda\x62\xf8\x26\x77\xd7\xcd\x6c\xc8\xae\x5f\x33\x5c\xd8\x02\xe2\xb6\xca\xfa\xf2\xf0\x17\xba\xe7\x16\x5c\x1f\x59\x7b\x42\xf7\x90\xb9\xff\x8e\xde\x41\xc1\x95\xed\xc2\x5b\x9a\x70\x5b\xc0\xab\xa9\x7a\x6d\x8c\xb9\xeb\x20\xbb\xfa\x5e\x1b\x56\x9c\xb0\x07\x70\xaf\x8d\xb0\x03\xc1\xaa\x01\x8d\x2a\xf8\x78\xe7\xd3\xdc\xc3\x36\xc0\xee\x70\x5c\x92\x30\xdf\xe0\xaf\xbf\xfd\x8b\xd3\xc8\x1b\xc6\xc2\xd6\x52\x22\xce\x22\xbe\x9d\xcf\x2e\xaf\xdf\x4d\x11\x8d\x04\xf2\x45\xf0\xe6\xec\xfa\x72\x61\x2b\x09\xc4\x3b\x86\x40\x75\x02\x01\x83\x40\x6a\x87\x7f\x50\x61\xd5\x03\x1f\x02\xc1\x8d\x80\x61\x05\xbb\x3e\xf1\x7e\x70\x5a\xc7\x77\x08\x5e\xd7\x53\x1b\x82\xc2\x1d\xb2\x81\x05\x1b\x8f\x69\x68\x79\xd9\x21\x1a\x82\xa1\xbb\x2c\x83\x31\x1a\xdf\x64\x30\x82\xf0\x11\x06\x17\xaf\x3d\x82\x21\x00\x5d\x43\x7d\x10\x4a\x6b\x8f\x0f\x29\xee\xb0\xbe\xf7\x03\xf9\x58\x98\xc6\x90\x1d\x82\x63\x34\x2b\x07\x81\xb5\x26\xdd\xa3\x8a\x8b\xc5\xf3\x71\x10\x95\xc9\x33\x08\xa4\x31\x61\x1e\x53\xba\xb2\x58\x1e\x05\x31\xb8\x1d\xd4\x35\xdb\x8e\xc0\xff\xba\x0a\xfe\xeb\x3a\x98\x9e\x77\x56\x69\x66\xfe\xe5\xe4\xb7\x3a\xfb\x04\x5a\xc8\x2d\xdf\xe4\xad\x0d\x3b\x23\x60\x4f\xc2\xc9\xb9\x5b\xc6\x4a\xcc\xb7\x0c\xcc\x52\x74\xa2\xfd\x56\xfe\x3a\x75\x45\xf6\xd7\x49\xf0\x41\xa6\x6a\x07\x94\x85\x71\xbd\x23\x60\x67\x58\x53\xdd\xdc\x96\xbb\xf0\xc5\xb7\x2f\xbe\xf9\xee\xab\xaf\xbf\xfb\xee\x2f\x0e\x72\xd3\x67\x7d\xbf\xc0\x22\x9e\x1a\xfe\xb7\x0b\xa9\xd0\x5a\x89\xaa\xb4\x1e\x33\xdf\x7a\xf7\xdd\xcc\x8d\x51\xd8\xca\x77\xe3\xf8\x0e\xb8\x4e\x81\x3e\xe8\x6d\xe8\xbe\x27\x97\xa6\xa0\xa1\x84\x48\x7c\xca\xca\x24\x21\x99\x08\x73\x19\x08\xc1\x08\xbf\x83\xb6\x8e\xd5\x9b\x6b\xda\x8d\x87\x5b\xeb\xd7\x21\x37\x23\xf3\xb8\xb8\x15\x4c\x1c\xa9\xb1\x94\x6f\x02\xd7\x2e\x98\x86\xb0\x19\x3e\xdf\xfe\xf9\x2f\xdf\xfd\xf9\x85\xbb\x84\x9d\xbf\x1c\xd3\x76\xca\x20\x11\x1b\xa8\x78\x54\xdb\xcc\x51\x8f\x51\x5b\x39\x6a\xc4\x0e\xd4\x26\x40\xed\xc6\xac\x49\x1d\x88\x22\x76\xac\xc0\x99\x48\xeb\x98\xb0\x9b\x77\x45\x69\x20\x91\x63\xc1\x66\x96\xdd\xb8\xae\x95\x6f\x87\xdc\x82\xdc\x46\x76\xed\x90\x0d\x9d\x19\xcb\x7d\xc8\xc4\x8c\x6f\x2f\xdb\x8b\x67\x4f\x3e\x6e\xc2\x4e\xd4\xd6\x4b\x9c\x26\xfe\xea\x2b\x4f\x5d\xc0\x44\x29\x47\x5f\xcd\x12\x18\x43\xa8\x56\x31\x4c\xa5\x2c\x7c\xda\x38\xa7\x1d\x59\xcf\x07\xb6\x61\x89\xd8\x92\x1f\x1e\xa7\xf5\xc1\xac\xe2\x83\x9e\xa8\x82\xda\x82\xdb\x44\x02\xed\x80\x5a\xce\xb2\x05\xa9\x8a\xff\x79\xc1\x09\x5a\x0f\x4c\x8f\x86\x94\x49\xcd\x88\x6a\x8c\xcc\x8a\xa8\x90\x56\x34\x6f\xae\xa7\xe7\x8b\xc9\x6c\x2a\x91\xb9\x33\xd3\x47\x63\x73\x69\xcf\x54\x74\x1f\x08\x47\xea\xb9\x0d\x62\x19\xa7\x39\xc9\x0b\xbe\x62\x8c\xda\xfd\x4c\x54\x90\x4f\xc5\x09\x12\x53\x7e\xf5\xc7\x0e\x17\x9b\xea\x9f\x45\x86\xef\x48\x96\xe3\x38\x8c\x29\x23\xa0\x49\x41\xd6\x24\x1b\xdb\x96\x0f\xbe\x26\xd5\x04\xbf\xbe\x07\x07\x6d\x9a\xf1\x31\xfb\xea\xe8\xe8\x7c\x1e\x9c\x2d\x02\x14\x7c\x5c\x04\xd3\xab\x4a\xe4\xe9\x6c\xd1\x94\xa0\x4c\x10\x37\xdd\x26\x2f\xd2\xcc\x03\x6f\xb7\xae\x32\xcd\x6b\x42\x2e\xa1\x2e\x17\x3a\xbb\x42\xc1\xf4\xfa\x1d\x1a\x1d\x21\x84\xd0\x71\xfd\xfd\xf8\x44\xfc\xcd\x68\x8e\x8f\xc6\x12\x0a\x6f\x08\x29\x9e\x23\x0a\xd2\xa8\x6e\x31\x2e\xc3\xf4\xfa\xf2\x52\x20\x68\x1d\xa0\x7d\x95\x7a\x43\x2f\x97\xa6\xd5\xef\x95\x6b\x89\x8e\x8f\x5f\xbe\x04\x08\xeb\x14\x66\x5c\x84\x05\xdd\xa1\x9b\x34\x8d\x09\x4e\x9a\x52\x2b\x1c\xe7\x44\x2b\xb2\xcb\x52\x36\x1a\x78\x8a\x20\x6a\x92\x7f\xd1\x3d\x65\xda\x40\xb7\x04\xfd\x9e\x26\xa4\x41\x48\xd2\xfb\xd1\x58\x43\x90\x92\x9f\xcd\x00\x6a\x11\x29\xca\x2d\x19\x34\xd5\x26\x3b\x1f\x77\x4c\xbf\xb3\x1c\x9d\xff\x1c\x9c\xff\x82\x46\xa3\xaa\x6d\xfe\x1b\x1d\xff\xbf\xbf\xe1\xe7\xbf\x7f\xf5\xfc\x7f\xff\xfd\x9f\xdf\x7c\xf5\xef\x67\x55\x33\x8c\xc7\x36\x5c\x79\x1f\x7f\x43\x96\xb7\x0d\xaa\xd4\x1f\xdf\xff\xd0\xb4\xe9\x78\x2c\xf7\x71\x33\xa8\xf6\x3b\x94\xd0\x3c\x58\x5c\xcf\xa7\x57\xe8\x2a\x58\xcc\xde\x48\xd2\xf2\x7a\x5c\x9e\x4d\xdf\x5e\x9f\xbd\x0d\xd0\x2e\xde\xad\xf3\xdf\x62\xfe\xe3\xd9\x15\x7a\x16\x3e\xe3\xff\xbc\x08\xce\x2f\xcf\xe6\x01\xff\x37\xfb\x5f\x4c\x13\x82\xd7\x24\xcc\xd2\x7b\x94\x91\x65\x9a\x45\xaf\x90\xf4\xbf\xe7\xcf\x6b\x0a\x94\xa5\xf7\x79\x53\x8c\xa2\x55\x9c\xe2\xe2\x1b\xf4\xf2\x35\xfa\x4a\x29\x51\x17\x6b\xc4\x47\x3c\x0c\x40\xb2\xa6\xec\x2a\x2d\x93\x88\x8b\x2c\x09\xff\xa7\xf9\xec\x03\x1b\x5a\xaf\x38\xcb\x9c\xae\x10\x27\x60\x62\x8d\x32\x52\x94\x59\x42\xa2\x31\x87\xe0\x37\xcc\x34\x60\x6f\x66\x73\xa5\x0e\xd2\x27\x4d\x7e\x9a\x23\xcc\xaa\x58\x66\x39\xbd\x23\xf1\x03\x33\x37\x68\x42\x22\x74\xbe\x08\x50\xb1\xc1\x05\x12\x7c\x72\x84\xe3\x18\x61\x66\x9e\x17\x69\x86\x70\x82\x22\x92\x2f\x49\x12\xe1\xa4\xc8\x75\xf0\x74\x85\x8a\x0d\x41\x6b\x7a\x47\x92\xba\x27\x57\x69\x26\xfd\xd8\xf6\xf7\x29\x5a\x6c\x68\x8e\x36\x78\xb7\x23\x49\x8e\x8a\x14\x91\x3b\x1c\x97\xb8\x20\x88\x26\x3a\xf0\xfb\x34\x2f\xd6\x19\xc9\x11\x66\x62\xc7\xf8\xf7\x07\xb4\x26\x09\xc9\x98\x0f\x77\x82\xee\x37\x74\xb9\x61\x72\xa6\xf7\x39\x2a\x39\xd6\xae\x8c\x63\xce\xf6\x8b\x84\x7c\x2a\xbe\xa8\x95\xae\x12\x4a\x87\xa7\x09\x22\xb4\xd8\x90\x0c\x45\x34\x23\x4b\xe6\xfe\xa0\x55\x96\x6e\x39\x82\x38\x8a\x53\x57\x07\xe7\x28\x21\x24\x22\xd1\xa9\x02\xf2\x61\xb2\xf8\x19\xcd\x83\xf3\xeb\xf9\xd5\xe4\xaf\x41\xdd\xca\x23\x1a\x9d\x48\x55\x3e\x41\x5f\x08\x94\x2f\x98\x6a\x4b\xc7\x0e\x4e\x5a\xbe\x63\xa6\x9e\xa3\x23\x5d\x85\xae\x82\xcb\xe0\x7c\x81\xe2\xd3\x2f\xd1\x9b\xf9\xec\x1d\x40\x51\x55\x25\x27\x24\x6a\xfb\x15\xe5\xa4\x10\x33\x48\x25\x7e\x9c\xa6\xb7\x34\x59\xb3\x1a\x37\x7d\xda\xf0\x06\x31\x2b\xce\xcb\xd3\x2f\x4f\xd0\xf1\xd9\xb1\x60\xaf\xc4\xce\x97\xe8\xc3\xcf\xc1\x3c\x40\xcb\x53\x69\x34\xbf\x46\xcf\x5e\xa0\xb3\xe9\x05\x5a\x9e\xd6\x95\x66\xbf\x7d\x0d\xf2\xb8\x9e\x4e\x66\xd3\xc7\xd7\xa8\xd5\xcc\x3e\x75\xba\xd8\x7f\x9d\xc6\x28\x3e\xea\xfc\x28\x2a\x69\xea\x59\x6b\xbf\x72\xed\x8a\x43\x9a\x24\x24\xe3\xfa\xa1\x16\xaa\xb4\x6d\x6c\x6a\xc1\x35\x29\x10\x1b\x06\x4d\x97\x3b\x1b\x25\x3e\x6d\x07\x82\x60\x51\x31\x8f\xd1\xff\x99\x4d\xa6\x7a\x5b\xcd\xa6\x4a\x89\xd7\x5c\x51\x44\x43\x29\xcd\x17\xcb\x7f\x76\x1a\x32\x3e\x55\x06\x45\x7f\x4d\x69\xea\xd9\x2a\xc2\xe1\x6b\x7a\x71\x8c\x70\x12\x79\xd4\x54\xa9\x1c\x27\xa9\xeb\x0e\xe8\x4f\x5e\xde\xf0\xa0\x9a\xf2\x69\xac\x6a\x0f\xa8\x04\x28\x6e\x68\x2e\x67\xb3\xf7\xfa\x4c\xf7\x0b\x21\x3b\xb6\x18\x2d\x6f\xd9\x64\xbd\x49\xef\xd1\x16\x27\x0f\x7c\x35\x43\xf7\x44\x4c\x9b\x7c\xe6\xab\xe0\x4e\xd1\x64\xc5\x3e\x6c\xd8\x9a\xbc\x21\xed\x3a\xd6\x5d\x57\xf8\x3c\x76\x83\x69\x8c\xd2\xb2\x60\x06\x0e\x6f\x17\xb1\x8a\xb0\x75\x83\x6c\x77\x05\xe7\x74\x8a\x2e\xd2\xe4\xb8\x40\xb7\x4c\x96\x7a\xf8\xb2\x65\x02\xd7\xd3\xb4\x0e\xde\x2e\x25\xe4\x8e\x64\x27\x6c\x12\x66\xc2\xac\x70\xc6\x27\xed\xfb\x4a\x2e\x31\xe3\x6f\xd3\x8c\xa0\x98\xde\xb2\x45\x8d\x19\x0a\x7c\x3d\xb8\x21\x88\x6e\x77\x19\x59\xd2\x9c\xa8\x33\x37\x65\x8b\x36\x45\xff\x13\xbd\x78\xa5\xfc\x3e\x79\x83\x28\xfa\x01\x3d\xfb\x06\x2d\x7e\x0e\xba\x2a\x27\xcc\x0e\xb5\x48\x30\xbd\x40\x93\x37\xaf\x8e\x74\xf1\x17\xcc\xba\x49\xd1\x8a\x26\x11\x97\x90\xaf\xe5\x38\xcf\xd3\x25\xc5\x05\x89\x2a\x13\x8f\xad\x86\xa2\xa2\xbc\xd1\x59\xcd\xf8\x32\x9d\xa7\x5b\x52\x6c\x68\xb2\xd6\x61\xab\xa6\x65\x8b\x52\x12\x21\x52\x81\xaf\xca\x84\xeb\x66\xb5\xbe\xde\xd3\x38\xe6\xdf\xca\x5d\x55\x80\xb5\x36\x27\xa4\x59\x5e\xb0\xfe\xd0\x71\xef\x09\x1f\x49\xb2\x1a\x08\x11\x2f\xaf\x26\x6f\x50\x84\x0b\x2c\x56\x5c\xde\x0f\xb5\xd2\x70\x0d\xba\x61\x7a\xc5\xca\x19\xad\x8e\x04\xa5\x59\x44\x32\xa6\x7c\x11\xcd\x0b\x36\x21\x19\x56\xda\x93\xaa\xf3\x12\xc2\xcc\x6a\x9c\xd1\xf8\x41\x47\x65\x65\xd4\x85\x5d\x93\xf3\x14\x1c\x30\xac\xf9\x4f\xbf\x44\x93\xe9\x62\x26\x5b\x5f\xed\x22\x20\x7c\x10\xfe\xa3\x58\x05\x78\x09\x75\x7c\xb7\x46\x96\x3e\xd2\x39\x71\x3b\xca\x25\xc2\xea\x47\x46\xf4\xec\xcf\xe8\x72\xf2\x4b\x80\x46\x02\x9a\xf9\x25\xff\xfa\x17\x3a\xfe\xd3\xf1\xb8\xa3\x85\xad\x88\xa7\x34\x42\x93\xab\xc6\x05\xb0\x29\x26\x9a\x06\x1f\x17\x52\xd1\x57\x92\xc2\xe8\x5d\x2e\xa9\xb3\xfe\x33\x2b\x45\x3e\xb1\xa1\x57\x69\x15\xa8\xef\xf2\xdf\x6c\xd2\x11\xbf\x04\xd3\x8b\x57\x47\xcf\xc2\x67\x80\x0b\xe0\xed\x90\x37\xd6\x7d\x91\xd1\xf5\xba\xb2\x9a\x8d\x46\xfd\x33\xc0\x22\xbe\x7e\x7f\x71\xb6\x08\x24\xb1\x5b\xf8\xa3\x56\x2f\x16\x32\x41\x7b\xdf\x45\x8e\x5e\x4b\xf4\xa7\xca\x97\xe7\x68\x76\x79\x71\x4a\x23\xe1\xeb\x34\xe5\xb9\xc2\x80\xec\xd4\xe2\x3f\x2a\xc5\xa5\x39\xa3\xea\xbf\xd9\xe5\x85\xdc\x8c\xb6\x56\x74\x46\x46\x0e\xd1\x8a\x35\xba\xa9\x11\xe5\x83\x79\xac\x15\x6b\xfa\x53\xf5\x8b\x6f\x2b\x1a\x8a\xef\xa1\x15\x7d\xa2\x53\x7d\x1b\xb0\x75\x2b\xb3\x32\x49\x84\x75\x5a\xbc\x3a\xaa\xdb\xb4\xfe\xf1\xe5\xeb\xca\xde\xab\x26\xa6\xf3\xd9\xf5\x74\x31\xfa\x72\x2c\xa6\x22\x25\x5e\x77\xd4\xb4\x0a\xd2\x8f\x16\xa2\xd7\x68\x1a\x7c\x38\xd5\x7f\x16\xe2\x4c\x2f\x90\x74\x74\x91\xcf\x1e\xd7\x97\x97\x47\x08\x8d\x79\x63\x4d\xde\xa0\x51\x2d\xcd\xff\x78\x8d\xbe\x1a\xb7\x73\xca\xfc\x6c\x72\x15\xa0\xe0\xe3\x79\xf0\x9e\xb7\xd3\xf1\x79\xc5\xe0\x7d\x8c\x93\x7f\x4e\x2e\x5e\xa2\x3f\xfd\x1b\x6d\x70\x8e\xfe\x84\xca\xa4\xb9\x62\x82\xc9\x5a\x85\x79\x10\x28\xd6\x49\x5d\x7b\xd6\x2f\xd2\x82\xd9\x4c\x5b\x1f\x5e\x1d\x75\x3a\x4b\xc4\x87\xd4\x8c\x81\x26\x44\x74\x43\xd7\x34\xd1\x03\x38\xda\x35\x1d\x86\x38\x92\x9c\x64\x88\x6e\x1e\x0a\x82\x35\x82\x24\x2d\x08\x18\x62\xca\x08\x2e\x1e\x11\xea\x89\x71\xce\x65\xb3\x22\x08\x52\x31\xca\x3d\x08\xb5\x2b\x43\x0c\x55\xce\x97\xe9\x8e\xe4\xbc\x4e\x7f\xfb\x7b\xf3\x4d\x0e\xd4\x34\x31\x61\x28\x12\x2c\xd4\x75\x71\x36\x5f\x08\xa7\xe4\x05\xff\x61\x32\x3d\x9f\x07\xef\x82\xe9\x02\xfd\xf4\x6b\xf5\xd3\x74\x86\xde\x4d\xa6\x7f\x3d\xbb\xbc\x0e\x9a\xbf\xcf\x3e\xb6\x7f\x9f\x9f\x9d\xff\x1c\x30\x9b\xab\x4a\x0f\xb1\x72\x45\xb3\x0f\xd3\xe0\x82\x81\x2b\x5f\x4f\x69\xa4\xeb\x88\x9a\x97\x62\xd7\x91\xce\x40\x02\xa9\xaa\xad\x2c\xd3\xc7\x3b\x48\x3d\x22\xba\x5a\x41\xbf\xf3\x73\xd3\xd0\x87\xf6\x34\xb2\xa3\x93\xe5\xc1\xec\xa1\x0e\x8f\x8a\x46\x46\x8f\x2a\x7f\x83\x73\xc2\x56\x24\xa8\xba\x52\x78\x51\xdd\xc0\xa8\x0b\x69\xf1\xc5\x06\xcb\x18\x5d\x6c\xd4\x07\xda\x10\x39\xbc\xd2\x42\x5c\x5b\xa5\x55\xbe\xda\x94\x56\xe4\x63\x79\x6a\x6d\xf1\xb0\x3b\xc8\xdc\xf4\xd8\x8e\xc7\xd9\xba\x14\xd9\x7e\x9e\x3d\x2f\xb6\xc3\x94\x7a\x69\xfd\xaf\xd6\xb9\x87\x12\xc8\x3b\x6d\x4f\xa8\x05\x32\x5b\x40\x0d\xf8\x67\x8b\x1e\xb8\x54\x20\xc1\x5b\xb0\xe7\x23\xd2\x24\xd5\x88\xa8\xb9\xe8\x8f\xfa\x66\x2f\xc3\x8a\xd0\xb9\xa6\xa1\xa6\xd3\x3f\x8b\x93\x97\xea\xd7\xcf\xad\x6c\xaa\x39\xf8\x8f\x3c\x4d\x6e\xda\xbd\x9d\x7f\xfe\xfb\xf8\xe5\x4b\xf1\x9b\x63\xe6\x57\xea\x04\xe8\xa8\x7e\xd3\x84\xaa\x9e\x9c\x05\xd7\xcd\x74\x35\x52\x08\xc7\xe8\x35\x3a\x16\x77\x25\x98\x77\x58\x5a\x26\x1b\x9c\x87\x2f\xda\x5b\x30\x1a\x06\xa3\x6e\x1f\x55\x96\xdc\x98\x8d\x86\x51\xa7\x8f\xea\xaf\x8e\xf1\xf1\xf4\x43\xc3\x3c\x2a\xc0\x01\xa1\xe7\xca\x3a\xa6\x46\xa9\xe9\x61\x8a\x5b\x1e\x82\xe9\x8e\x9c\x5b\x02\xef\x24\x3e\x52\xbb\xeb\x5b\xb7\xbc\x15\xf3\xb1\xc3\x41\xd6\x2a\x3d\x1f\xe2\x96\xe8\xdb\x76\xac\xd6\xca\x64\xea\x01\x42\x93\x48\x47\x61\x6d\xda\x13\xa6\xb9\x8e\xcc\x32\x90\x6a\x1a\x68\x0c\xc1\x5a\x0d\x67\x80\x3c\x81\x72\xc3\x8c\x25\x1d\xd7\x08\xac\xaa\xde\xc7\x72\x75\x12\x88\xcb\x41\xbd\x87\x8b\x6e\xa6\xfe\x31\x26\xf9\xbd\x5a\xc7\x0e\xd5\x79\x5a\xb3\x11\x62\x0b\xa9\x8d\xc9\x70\x6c\x4f\x33\x78\x2b\x8c\xff\x2a\x59\x7b\x3e\xb0\xd9\xf0\xb9\x95\xa2\xf7\xdc\x2a\x1d\xfe\x05\x7d\x33\xfd\xd6\x43\x93\x7d\x0d\xcd\x6b\xca\x2d\xb9\x76\xf3\x40\xa2\xf3\xb3\x0e\x5a\x1e\xf2\xf1\x65\x95\x85\x5c\x37\xcf\xa9\x38\xef\xde\xf3\xc8\x2d\xef\x24\x2d\xc2\x9b\x18\x27\x00\xb8\xd2\x32\xde\x6c\x0e\x3b\xd7\x7f\x86\xc1\x6a\x19\xa8\xe0\x20\x35\x9d\x5f\x72\x24\x39\xf1\x56\xee\xa4\x56\xe9\xa9\x4e\x49\x61\x72\xb5\x3e\xdf\x00\x85\x7b\xcc\x9e\xa7\xfa\x04\xfd\x67\x17\x40\xea\x4d\x03\x21\xd0\xb7\xea\x4d\x0a\x23\xeb\xb4\xd9\x4d\x78\x83\x4e\xc8\xd9\xe7\xf1\xe6\x0a\x43\xf8\xb3\x7a\xcd\xa0\x69\xe2\xfe\x43\xea\x4c\xcf\x60\x67\x75\x27\x63\x1d\xc7\x54\x67\x7f\x9f\xe0\xa6\x39\x71\xfb\xf0\x9a\x68\xe6\xdd\x2a\x21\x40\x03\xe9\x9f\xed\x86\x12\xa1\x4a\xfc\x0f\xa8\xaf\xed\x5a\xe2\xd0\x34\x8f\x8e\xb2\xea\x7b\x7d\xb0\xd4\xae\xee\x5e\xfa\x5c\xd0\x22\x26\x92\xb5\xaa\x1e\x41\x50\x0c\xdb\xc7\xaa\x37\xce\x96\x1b\x7a\xe7\xa3\x9f\x4f\x30\x10\x5c\x6a\xad\x1e\x8f\x78\x52\xad\x56\x59\x83\x4a\x5d\x91\xd8\x75\x1a\x3a\xd1\xfc\xa8\x09\xd2\x6e\xd2\xb6\xe9\xa6\x22\x82\x9d\xe1\x64\x29\xff\x90\x91\x3b\x9a\xab\xc1\x35\xee\x9a\x84\x31\x4d\x88\x1a\x43\x22\x49\x04\xfc\x2a\xa8\x97\x1b\x9c\xe1\x65\x41\xb2\x6e\x11\xc3\x27\x86\x94\x87\x37\x64\x95\x66\xb2\xa6\xf3\x9f\xf5\xbf\x43\xbc\x62\xe5\xf9\x76\xaa\xa7\x86\x74\x0f\xed\x7c\x16\x6d\xe9\x8a\x61\xd3\x1c\x99\x1c\xd0\x22\xe9\x58\xfd\xb0\x60\x6a\x99\xc5\xfd\x67\x4d\x9c\xa4\xc9\xc3\x36\x2d\x9b\x9b\x8e\xc1\xcd\x1e\x91\xe6\x02\x7c\xa9\x43\xe7\xd0\xb7\xea\x7e\x73\xe8\xd3\x17\xcd\x14\xf1\xc5\xa3\xc3\x46\xd2\xf9\x30\x6e\xaf\xf3\x98\x24\xab\x4c\x1b\x8c\x1c\xd5\x75\xe3\x1b\xc6\x67\xd3\x0b\x34\xea\xd6\x5b\x71\x0f\xd0\x6c\x8e\xda\x62\xdf\xff\x60\x29\xf7\x7a\x58\x31\x85\x1d\xe0\x8f\x74\xaa\xc5\x2f\x65\x64\xbe\x8e\x48\x4b\xab\x2b\xc7\xb5\xc1\xee\xda\x74\xa0\x44\x7f\x02\x60\x55\x47\xf7\x84\x2b\xb3\x18\xc0\x62\xea\xd8\x13\xa8\xd2\x18\x00\xac\xd6\x25\xf7\x4e\x4a\xe7\xb8\xe0\xe1\x67\x86\x0e\xcb\x76\x16\x68\x3f\x41\x23\xbe\x73\x1d\x87\x7d\xe0\x9b\x62\xc1\x11\xcd\x77\x31\x7e\x08\x4d\x13\x43\xe5\xbd\xfd\x27\x5b\xcf\x8a\xa3\xce\x47\x43\x9a\x08\x15\xa9\x7d\xd3\x66\xe7\xb5\xc8\xe8\x76\x24\x7e\x1c\xfb\xe8\x8b\xe1\xe0\xea\x13\xa8\x8d\x81\xb3\xa4\x3d\x3a\x05\xa0\x44\xca\x8d\x8e\x42\x7f\xb8\x07\x48\x23\x54\x96\x34\xd2\x1a\x9c\x26\xb4\xa0\x38\xa6\xbf\x93\xc8\xeb\x8c\xd0\x76\xbd\x2d\xc2\x1d\xce\xf3\xfb\x34\x8b\xc2\x5d\x8c\xd9\x2a\xf2\xc9\xef\x48\x92\x5a\xf6\x66\x99\x3d\xec\x5c\x05\x0d\x47\xac\xea\x6c\xe6\x03\x1d\xb2\x52\xf3\x9c\x5b\xeb\x44\x3f\x53\x54\x9f\xa8\xde\xff\x69\x25\xf0\x69\x07\x90\x81\x2a\xab\x99\x0f\xa8\xeb\xc0\xb9\xf0\xc3\xab\x39\xc0\xb4\xd5\x70\xf9\xa3\xa2\xdc\x6a\x71\xf9\xd8\xfb\x13\x49\x2c\xb3\xd4\xe4\xe5\x9f\x80\xa1\xa8\x5e\x10\xe5\x50\x56\x7e\x5b\x08\x38\x5d\x9b\xa6\x71\xd9\x9e\xaa\xa6\x4f\xf1\x1a\xa5\x35\x70\xa3\xd6\x4a\xbb\x91\xe0\x89\xda\x52\xe3\xaa\x35\x67\xfd\xd5\xd4\xa2\xd2\xb5\x5d\x4f\xd0\xa6\x2b\x1a\x33\x6f\x04\xca\xad\xeb\xdf\xdc\x9d\x6b\x25\x9e\xa8\xc1\x3b\x7c\xb5\x26\x6f\xbf\x03\x8d\x2e\x6e\x89\x1e\xb5\xed\x26\xce\xe4\xda\xdd\x0a\xf1\x23\x94\x70\x21\xcd\x75\xe2\x7e\x69\x35\xaa\x3e\x92\xf3\x03\x2a\x0e\xc2\x8e\x96\x32\x03\xea\xdf\xd5\x79\x4d\x88\xab\x5f\x0f\x67\xb7\xa1\x54\x09\x75\xfd\x51\x9e\x04\x36\xae\x32\xda\x6b\x47\x07\xda\x6e\x4a\xd2\x42\x3c\xf2\xe0\x11\x5a\xcc\x77\x69\xe2\x38\x87\x2b\x93\xe6\xd5\x8e\x48\x65\x01\x34\xb1\x82\xf4\x76\xaf\xf9\x9d\x1d\xa3\x0d\x17\xe9\x96\x2e\x9b\x0b\x9e\x5a\x1d\x50\x6a\xd0\x24\x8a\xbc\x46\x23\x55\xde\x36\x49\xc4\xc0\x40\x3c\x5e\xc5\x3a\x46\x72\xfe\x60\x70\xee\x96\x19\xe0\xb9\xfa\x49\xed\xe1\x48\x4e\x81\xef\xac\x39\xfc\x50\x87\xf9\xb6\x43\x5d\xfb\x0e\x0c\x75\xf9\x6a\x46\xc7\xdc\x6a\x1d\x38\x9f\xdb\x8f\x30\x8d\x44\x78\xb6\x30\x5c\x5b\xd4\x69\x01\xf3\x9c\x56\xdf\xe5\x9f\xc6\x51\xbf\xac\x03\xef\xea\x82\xf5\x32\x6a\x9f\x7a\x63\xd3\xd3\x68\x9e\xca\x53\xd5\xba\xea\x1b\xac\x71\x4e\x55\x33\x2f\x3a\x9f\x5b\xcf\x3a\x9e\x76\xb5\x6e\xc4\x78\x79\x1b\xde\x93\x9b\x4d\x9a\xf2\x70\x88\x6c\xa3\x0d\x98\x2d\xf9\x25\x5e\x32\xaf\x70\x8b\x3f\x55\xef\x78\xb5\xae\xc6\x06\x67\xd5\x6f\x23\x99\x76\x8c\xbe\x7f\x8d\xbe\xfe\xf6\x5b\x60\x92\x6c\xde\x02\x74\xe1\xf1\xa8\xd2\xb8\xf2\x25\x7c\xf0\x20\x57\x85\x37\xd2\xec\x7d\x30\x3f\x5b\xcc\xe6\xa3\xff\x1e\x57\xbe\xca\xd9\xf3\xff\xcb\xdc\x95\xd1\x8f\x2f\xa5\xbf\xfe\xf5\xb7\xe7\xa7\x7f\x1f\xfd\xf8\x5a\xfa\x69\x3c\xfe\xf2\xf9\x8f\xcc\xa1\x11\x9a\x60\x9e\x7b\x9f\x54\xed\x41\x7d\x87\x14\xbd\x7b\xe9\xae\x43\xeb\x97\x38\x8e\x73\xba\x4e\x60\xcd\x6f\xde\x65\xf9\x83\x0e\x0c\x5f\x2d\x6f\x82\xd4\x5e\x31\x80\xa6\xe5\x4d\x17\xe6\x1d\xbe\xcf\x4d\x9c\x5b\x05\xe8\x50\x40\xda\xa0\xbf\xf8\xd3\x28\x03\x10\xa4\x81\xee\x1c\x34\xc4\x73\x2a\xbc\x70\xef\xe9\xa0\xdd\x95\x13\x7e\x1b\xc9\x56\x0f\xbb\x7d\x5c\x3f\x74\xab\xd7\xf2\x8f\x93\xc5\xe7\xb3\x79\xda\x6d\xa7\xee\xd5\x92\x55\x23\xf1\x0f\xf2\xe6\x17\xce\x8b\x90\x7c\x22\xcb\xb2\x20\x91\x63\xd8\xc4\xb8\x20\x79\xc1\x2c\xe7\x32\x76\x0d\x31\x06\x19\x46\xa5\xb8\x84\x34\x4c\xf2\xca\x11\xea\x4a\x6a\xbb\x67\xdc\xee\x45\x81\x0f\xd0\xda\x6c\x43\xe7\x9e\x78\xc5\xb8\xde\x02\xa8\x66\x39\xf5\x5b\x81\xd7\xf0\xec\xb7\xc5\x09\x5d\x91\xbc\x40\x50\x16\xdd\x4d\x99\x44\xca\x76\xfb\x63\xd5\xca\x7b\xae\xab\xb6\x59\xb6\x78\x67\xde\xdc\x74\xdf\x79\x7a\xf8\x29\xce\x2d\x43\x3b\xd9\x59\x68\x81\x69\x0f\xba\x11\xdf\xb1\x0c\xf2\x79\x04\x9a\x14\x9b\xe7\x96\xc1\xc8\x43\xfb\x19\xb2\xd7\xcd\x0b\x67\xa3\x3a\x7f\x9c\x69\x67\x88\xc5\x08\x5d\xcc\xcb\x0d\x33\xcd\xc8\xb3\x58\x79\xed\x66\xa5\xd5\x12\x7c\xf1\xf5\xff\x82\xfc\x6f\xa3\x00\x8f\xb7\x0c\xc3\xd3\xe7\x5d\xd3\x50\x36\x0c\xbd\x84\xa9\x42\x03\x8d\x9e\xb4\x4d\xd2\xd5\x2c\xe5\xa8\x4a\x47\xb3\x1c\xd1\x00\xf3\x1d\xc9\x9f\x63\x24\xfb\x8c\x60\x78\xe4\xca\x99\x2b\x7d\xfd\x34\xf8\x5c\x57\x8c\x93\x75\x89\xd7\xf2\x5c\xbc\x4a\xb3\x5b\x35\x0c\xf5\x04\xa3\xaf\x5e\x26\xd5\xec\x6a\xed\xd7\x4e\x52\xb5\xe1\xbb\x5a\x3a\xc1\x37\x31\xb0\xb5\x56\x64\xa5\xbe\xb3\x56\x1b\x17\x5e\xdb\x70\x65\x46\x95\x15\xb1\xe7\x12\xe4\x9f\x41\xdf\x3b\x47\xbd\xbb\x31\x2b\xd2\x14\x12\x20\x4b\xa1\x19\xf1\xdf\xff\x30\xe6\x76\xbf\x71\x00\xb7\xf5\x0b\xcb\xa4\xcc\x49\xd4\x00\x49\x35\x6f\xc6\x21\x30\xfe\x77\xe9\xa3\xb3\xb8\x61\xd4\xaa\x41\x6d\xa0\x15\x89\x77\x66\xf8\x93\x66\x31\x81\xb9\x4a\x86\x8c\x24\xe5\x52\xf9\x8e\x11\xab\x7b\x22\x5e\xd6\x6c\xa7\x90\x8f\x59\xdb\x39\xf7\x00\xda\xb7\x16\xd7\x45\x7b\x14\xc8\x31\xad\xe9\x93\x97\xf6\xd9\xd8\x02\x9f\xdb\x6e\xe0\x1b\x06\x0f\x61\x7a\x9f\x90\xac\x99\x55\x40\x12\x1e\xaf\x32\x90\x78\xef\xeb\xd8\x82\x5e\xd2\xa8\xe1\x78\x69\xbd\x78\xf2\xd4\x9e\xa4\x8c\x63\x20\x39\x4b\xd9\x00\xe2\x86\x88\xbe\xe0\x8a\xf4\x2a\x60\x5f\x48\x90\xeb\xcb\xb7\x69\x85\x06\x5f\x5f\x38\xfc\xd0\x03\xd9\xb6\x83\x50\xfd\x0c\x0d\xc7\xce\xdb\xd5\x42\x91\x6b\x97\x09\x4e\x7b\xa6\x59\xf1\xd0\xe9\x6a\x60\x88\xd4\x8f\x6e\xf5\xda\x06\x68\xf2\x8b\xda\xfc\xfc\x3d\x8d\x05\x48\x0b\xe1\xe4\x6a\x73\x5d\xf4\x67\x3d\x7a\x04\xf8\x55\x56\x7a\x9c\x7f\x50\x7d\x1f\x55\xb1\x56\x89\xd4\x87\x4d\x9e\x40\x6b\x55\x86\x92\xbe\x56\x1f\x14\x4d\xe5\x6f\xb5\x28\x4f\x1e\x5d\x1d\xd5\x97\x05\xc9\x99\x4a\xa7\x55\x8e\x92\xa8\xbf\xf2\x45\x4a\x51\x62\x1f\xf9\xcd\x42\xea\x1b\x04\x5a\x6f\xeb\x6f\xce\xd9\x43\x17\x60\x07\xf0\x77\x6c\xe4\x80\xfe\x32\xcd\x88\x31\x52\x81\x73\xc5\xac\xbd\x21\x45\x9d\xbf\xbc\xcf\xa8\x5b\xdb\x03\xf0\x23\x3a\x4f\xd0\xf5\x30\x63\x49\x05\x34\x02\x60\xd2\x92\x9e\x09\xaa\x3a\xc6\x1e\x07\x12\x3d\x71\x90\x18\xf7\x1d\xc9\xe8\x8a\x2e\x85\xd9\xb0\x4c\x23\xd9\xac\x17\xdf\xfa\x86\xfa\x0c\x2f\x27\xba\x22\x1c\xf6\xeb\x8e\x1c\x87\x39\x35\xbf\x43\xf7\x2a\x84\x0c\xa6\xaf\x65\xb1\x09\x5b\xf3\x5e\x2d\xa2\xff\xfe\xb9\xcd\x19\xff\x6b\xa4\x62\x9e\x8d\xa1\x57\x19\x1c\x4b\xd6\x67\xb1\x0e\x3f\xa2\xac\xec\xdb\x71\x05\x93\x99\x46\x97\xf4\x1e\x98\xd7\x10\x6b\xe9\x21\x1d\x11\x1e\x8b\x51\xfd\xaa\xcf\x34\xca\xc1\x14\x29\x9f\x4e\x37\x1b\x21\xe2\x0d\x53\x8f\xe1\x63\x89\x3c\xc0\x3b\xb4\xf8\x0e\x17\x38\xd3\xac\xd4\xff\x18\x0d\xe7\x49\x1c\x24\xfc\xad\x4c\x0b\xdc\xb4\x4a\x8d\xfc\xe2\xdb\x4e\x3a\x6b\x9e\xdf\xcb\xf6\x8a\xf8\x81\x4d\xd3\xa4\xd0\x27\x3e\xe5\x1b\x67\xeb\x08\x28\x30\x41\x70\xb4\xa5\x89\xdf\x1b\x03\x78\x4d\xc2\x3b\x4a\xee\xf3\x8e\xdc\x5f\x75\x26\x36\x66\xf9\x36\xee\xa6\x8b\xbc\xc0\x6b\xeb\x61\x4a\x75\x93\x69\x59\xe6\x45\xba\x6d\x0f\xb8\x80\x6e\xca\x41\xf6\xdc\x05\x70\xad\xb4\xce\x8d\xf7\x9a\xd0\xbd\xf9\xae\x01\x43\x71\xd6\x66\xa8\x1c\x7a\x17\x5e\x7e\xd6\xf0\x69\x66\x51\x78\xd2\xac\xe6\x48\xf9\xfd\x46\xe0\xb9\x65\xfd\xe9\xc6\xab\x60\xd1\x8e\x53\xf2\xa9\xb8\xc3\xf1\xe8\x18\xba\xd5\xef\xf8\xe5\xcb\x8c\xac\x97\x31\xce\xf3\x31\xc4\xc6\xfe\xc6\x24\xc8\x06\xba\x87\xcd\x9b\x0d\xfc\x1a\xa6\x9d\x8f\x7c\xd3\x97\x2f\xa3\x9e\x3c\xbc\xe1\x5d\x0f\x8b\xc2\x5c\xe0\xbb\x6b\xfc\x99\xf5\xe8\x1c\xe8\xb6\x13\x6f\x46\x7d\x99\x78\x33\xf0\x7d\x5e\x16\x66\x67\xbf\x54\xc0\xc5\xdc\xe7\x8d\x5c\x90\xaf\xf9\x08\x79\x0f\x96\xa6\xd7\x7d\x5d\x1c\xd5\xe3\xbd\xfd\x19\x5a\x5f\x28\xf6\x65\xde\x3d\x2d\xea\x12\xc4\xf2\xd2\x32\xc8\xb4\x73\x10\xcd\xc9\xc0\xf9\x20\x34\xcc\xc7\x70\x72\xc9\xc5\xce\xfa\x80\x35\xc8\x09\x38\x3c\xe2\xc5\x04\x7e\x64\xdb\xcc\x42\x3e\xed\xe1\xc5\xc0\xf8\x0e\xb8\x99\x87\x76\x0a\xc2\x8b\x8d\xe5\xc9\x72\x33\xa3\x4e\xf6\xbf\x8b\x95\xeb\x85\x75\x90\x15\x9c\x7d\xec\xc3\xca\xf4\x18\xbc\x91\x8d\x9a\x6a\xea\xc1\xc2\x1f\xdb\x17\xd4\xfd\x86\x3e\xc8\xc1\x94\x3d\xe6\x62\x67\x4b\xcd\xf1\x62\xec\xce\xe9\xe8\x2f\xc2\x60\xd6\xfe\x2c\x7d\xa7\xd7\x1e\x93\xa8\xb6\xf9\xe3\x05\x0f\x86\xea\x9d\x8c\xea\x10\xba\x1f\x0b\x35\xae\xea\x04\xd7\xa3\x9c\x7e\x4c\xe0\x08\x9e\x8b\x99\x21\xae\xe5\xc5\xd2\x1a\xe2\xf0\x61\xdc\x83\x8f\x2f\xac\x62\xcb\x73\xdf\xe2\xec\xe2\x42\xf6\xa5\x54\x63\x7f\x77\x4b\x1e\xd0\xfb\xf9\xe4\xdd\xd9\xfc\x57\xf4\x4b\xf0\x2b\x1a\xd1\x68\x1f\xb8\xf2\xe5\xed\x3c\x63\xf3\x7a\x3a\xf9\xaf\xeb\x00\x8d\xe4\x0f\x6e\xc7\x02\x62\xa4\xba\x11\xea\x6d\xb1\x7c\xac\x64\xe4\x2e\x2c\x13\xfa\x5b\x49\x1a\xae\xc0\x45\xf7\x62\x54\xb1\x7f\xdc\x8d\x59\x93\x07\xf3\x39\x13\x62\x0f\x22\x79\xb7\xa9\xea\xa8\x58\x81\x85\x2b\xd3\x1b\xd9\x0a\xda\x07\x4f\x73\x44\x40\x58\xdd\x59\x91\xaf\xb0\x14\x77\x93\xde\x92\x87\x4e\xcf\x48\x44\x27\xfc\xd8\xff\x09\xba\x25\x0f\xfb\x93\x62\x40\x1d\x8d\xdd\xac\xfa\x48\x87\x43\x36\x69\x2f\x6b\x22\xed\xf6\x50\x87\x5f\x66\x65\x36\xa4\x0a\x2e\x44\x3e\xae\xe4\xbb\x10\xb5\xba\x34\xe3\x4e\xa2\xe9\xe5\xfa\x81\xfc\x4d\x8e\x9e\x77\xfd\x94\xbb\xe2\x20\x16\x0a\x01\x80\x5b\xd5\xcb\xd7\x91\x04\x59\x00\x6e\xa3\x7f\x05\x2c\x97\x8d\x39\x98\x75\xe8\x01\xae\xfc\x83\xa7\x07\xe9\x60\x57\xfb\x88\x43\xaa\x06\xf8\x97\x9e\xdc\x64\x8f\xd4\x9b\x73\xeb\x64\x42\x5c\x24\x17\xd4\x1f\x51\x77\x27\x41\xe0\x8e\xcf\xe9\x8d\x2f\xef\x1e\x43\xd0\xf2\x77\x00\xb5\xda\xaa\x76\x7a\xb4\x10\xb4\x7a\xbb\x83\xaf\xc0\xda\x9b\xb0\x10\xa6\x70\x58\xf7\x8f\x28\xbf\x98\x2b\x6e\xa1\xe0\x4f\x7d\xc9\x33\x55\xfd\x34\x68\xfd\xea\x19\x23\x70\x3b\xca\x46\xbe\x8d\x5b\xdc\xaf\x32\xad\x93\x6b\x44\x96\xfc\x60\x6f\x6c\x7e\x44\x1d\x42\x14\x67\xd7\xbb\x38\x3c\x58\xef\xe1\x54\x43\x98\xba\x0b\xed\x2d\xa5\xe4\x14\x9b\x70\x6b\x9f\xb9\xca\xbb\xaa\x33\x59\x64\xb3\x53\x7c\x3a\xa9\xb7\x0b\x1f\xcb\xa9\x8f\xf4\x26\xb0\x1e\x28\x1d\xd7\x1a\x82\xec\xfa\xdf\x87\xc6\x6f\x0e\xea\x29\x4d\xdd\xfc\x0a\xb3\xd2\x4e\x87\x81\x9c\x34\x9a\x1e\x15\x81\x0e\x6e\xd9\x58\x28\x84\xfe\x7c\x2c\x21\x07\x88\x9b\x2d\x42\xf1\x08\x9e\x9e\xbc\x7a\xf1\x80\x17\xd4\xb6\xa7\x35\x73\x4e\xec\x08\xda\xdd\x27\x2b\xa8\xb7\x68\x6a\x9c\x02\xc2\xd3\x22\x19\xfe\xc8\x7a\xb2\x21\x08\xae\x13\x01\xf8\x55\x76\xa2\x35\x62\x02\x62\xd7\xf1\x11\x7f\x91\xb5\x60\x07\x88\xaa\x07\x44\xbc\xd1\xa5\x44\x26\x08\x58\xfa\x1c\x26\x69\x18\x95\xbb\x98\x4d\x0b\x8c\x43\x75\xb0\xa4\x51\x8f\x6a\xba\x3d\x11\xb9\x4e\x8f\x65\x26\x74\x2f\x6c\x12\x98\x44\x02\x55\xf0\xf1\xfc\xf2\xfa\x22\x40\xd7\x57\x93\xe9\x5b\x74\x53\x64\x84\xa0\x91\xf8\xc4\x37\x65\x9b\x0d\xe1\xd7\xe3\x71\xf5\x4a\x1f\xbf\x64\xaf\x49\x82\x52\x6e\x5d\xf1\x8f\x11\x99\xa5\xed\x44\x84\xfa\xb5\xbc\x94\xe4\x62\xe4\x21\xd1\xf0\x7f\x87\x55\xb2\x8a\x36\x3c\x9b\xf6\x6f\xe9\x4f\xe4\xb4\x17\x73\x64\xca\xc4\xd9\x5c\x99\x6a\xa3\x7c\x32\xbd\x08\x3e\x6a\xd1\xa0\x38\x4d\x6f\xcb\x1d\x9a\x4d\xb5\xdd\x69\xd1\x65\x1b\x9c\x6f\xb4\xc0\x50\xdd\x4f\xc0\x11\x08\x9d\x93\x2d\x1c\x44\x23\xc6\x53\xdd\xaa\x56\xd4\x44\x27\x77\xa0\xcb\x4f\x58\xd8\x81\x25\x4a\x07\xa6\xf4\x7e\x86\x1d\xb2\x25\x34\x22\xea\x2f\x21\xad\x29\xab\xd4\x27\x19\xb8\x06\x5d\xd3\x04\x69\xcf\x21\x99\x51\x3b\x4f\x18\x01\x80\x95\x94\x3a\xa9\x17\x6a\x9d\xc4\xe5\x03\x2b\x59\x70\x2a\xae\x1a\x42\x11\xcf\xa4\x30\x40\x75\x33\x5c\x9d\x25\x18\x91\x0b\x49\xef\x74\x0b\x9e\xb5\xd7\x55\x54\xad\xdb\x2d\xa0\x60\xbf\x57\x03\xbc\x82\x36\x85\x3f\xaa\xd9\x80\x31\x30\x6d\xa4\x2b\xac\xd8\x4c\x51\x4f\x0b\x8a\xf0\x50\x68\x42\xcb\x1b\xaf\xf4\x0c\xda\x34\x57\x78\xa8\xc5\xbc\x38\x55\xf7\xc9\x87\x38\xcb\xf0\x43\x95\x3e\xe4\xcf\x4f\x2a\x34\xaa\x90\x4e\xd0\x8b\xb1\x17\xe7\xe6\xc6\x6a\x6f\x76\x4d\x09\x1b\x7e\x37\xe2\xc2\xff\x03\x30\xe9\x5e\x1c\xaf\x72\xab\xc3\x32\xfe\x9c\xea\xfe\xb2\x55\xcd\xc1\xb5\x59\x55\xbc\x2a\x5b\x47\x60\x9c\xfa\x52\x67\x3c\x0c\x54\x17\x29\xf1\xc1\x13\xdb\x0f\x4f\x8e\x1c\xd5\x7b\x71\x3b\x0c\x6a\x20\x94\x42\xa1\x70\x6c\xa2\x9f\x0c\xa0\xc3\x5c\x0a\x28\x89\x54\xb6\xa9\x9c\x16\xd1\x99\x0f\x6d\xe5\xdb\xcc\x46\x0b\x88\x74\x35\xb5\x0d\x4b\x9a\x9d\x4d\x48\xa6\xee\x31\xdf\xf8\xc9\xd0\x94\x1c\x89\x4e\x3b\xd5\xb1\x17\xa5\x98\x69\x0a\x54\x18\xf1\x56\xae\x42\x3a\xea\x35\xa3\xed\x84\xe8\xc7\xbb\x8e\xfb\x98\x64\x90\x98\x8b\x98\x52\xb9\x8b\x53\x1c\x35\xf3\xba\x94\xa0\xa1\xb6\x57\x4b\x66\x43\xbb\xa3\x39\xbd\x89\x49\x37\x52\x65\xc3\xee\xca\x5f\x9b\x53\x35\x1c\x2e\xc2\x82\xee\x6c\x2d\xd9\x06\xaa\xc4\x3f\xf4\x86\x6b\x52\x42\xd4\x95\x8a\xdf\x49\x79\xc2\xe3\x47\x27\x48\xf2\x86\xba\xf5\x93\xe3\x55\x02\xab\xc1\x96\xf2\x40\x06\xa0\xeb\x71\xa6\xd6\x60\xd1\x33\x3f\x14\xf0\xca\x58\xe9\x61\x77\xea\x8c\xba\x77\x25\xba\x78\x76\x4a\xf8\xb1\x57\x7a\x4b\x97\x42\x1c\xfd\x5f\xc5\x74\xbd\x29\x3c\x2b\x7d\xd2\xbd\xe6\xb1\xf5\x93\xac\x17\x0a\x76\x2e\x0d\x14\x1f\xa0\x73\xcb\x66\xf1\xdb\xb9\x8e\x27\xb3\x74\x67\xb9\x1e\x7d\x62\x8b\xb3\xc0\xf7\xb8\xcc\xa6\xd6\xfc\x13\xad\xbf\x00\x84\x13\xf9\xca\x96\x13\x39\xcf\xfe\x22\xb8\x3a\x1f\xd0\xa3\xb6\x3a\xd4\xc7\x1f\xf7\x28\x75\x3d\x8e\x6a\x41\xf5\xcb\x69\x64\xe7\xd8\x5b\xe2\xbc\xbd\x7b\xa2\xe9\x5d\x28\xc7\x46\x11\x74\x74\x3e\x3b\xbb\x0c\xae\xce\x83\xee\x2d\x15\x27\xe8\xab\xf1\xf8\x04\x41\x14\xb5\x0e\x73\x02\x7f\x7d\x71\x56\x80\x5f\xcb\xe2\x23\x36\x23\x34\x03\xcb\x9b\xa1\xf5\x71\xa6\x2a\x8c\x51\x99\x0f\x5d\x23\x01\xbc\x97\xe1\x04\xba\x8e\x41\xdd\x45\x6d\x06\x2d\x7c\xb1\x43\xf7\x10\x31\x74\xc1\x83\x85\x4a\xbf\xa0\xb6\x3b\xfa\xe4\xab\x08\x24\xdf\x53\xaa\x22\x77\x3b\x9b\xab\x08\x40\x04\x1e\x66\x2c\xb2\xf5\x16\x2a\x1a\xa7\xf7\x24\xd3\x6e\x6a\x61\x9c\x18\x7d\x98\xee\xba\x4e\xac\x72\x93\x81\x45\xa6\xfa\x26\x03\xb0\x7c\x99\x51\x73\x77\x95\x19\x35\xf5\xbf\x72\xab\x40\x28\x6e\xcb\x6a\x57\x52\xf5\xce\x01\x05\x93\x93\x9a\x50\x0d\x41\xa6\xea\x1f\x0c\xd9\x90\xd4\xa4\x2e\xa7\x8a\x72\xc9\x3a\xd5\x1c\x32\x3b\x91\xce\xd8\x0d\x18\x56\x22\x5c\x04\x1d\x4b\xa9\x44\xd4\x24\x02\x28\x07\x73\x6d\x8f\x83\x80\xac\x9a\xa3\x27\x5e\xf8\x8b\xf9\xe4\xed\xdb\x60\x8e\x8a\x8c\xae\x43\x41\xd9\x06\x9a\x1a\x73\x25\x64\x3e\xa1\x94\x10\xff\x66\x11\xcc\xd1\x45\x70\x19\x2c\x02\x35\xb0\xf1\x66\x36\x47\xc1\xd9\xf9\xcf\x68\x3e\xfb\x80\x82\x8f\xc1\xf9\xf5\x22\x40\xef\xe7\xb3\xf3\xe0\xe2\x7a\x1e\x20\x4f\x06\x23\x97\x80\x4d\x48\x41\x05\x68\xcf\x39\xe8\x02\xb6\xb2\x7b\x4a\xe8\xe0\x60\x94\x90\x9f\xdf\xc1\x72\x1d\x45\xf8\xad\x8d\xb5\xa0\x9f\x82\x37\xb3\x39\xeb\xd2\xab\x60\xbe\x40\xb3\x39\xba\x7e\x7f\x71\xd6\xb3\x21\xdd\x6c\x46\xfb\x48\x75\xd3\x2e\x7d\x0b\x57\xb7\xe4\x81\x89\x16\x4c\xde\x4e\x45\x7c\x54\xa3\x18\xa3\x79\xf0\x26\x98\x07\xd3\xf3\xe0\x4a\x28\xe7\x9e\x92\xee\xf2\xb2\x0a\x02\x1b\x25\xd1\x28\xfc\x25\x79\x54\x56\x1e\xdc\x28\x7a\xe8\x55\x96\x45\xcd\x7b\x63\x42\xb1\x9e\xaf\x74\xf5\xfc\xec\xea\xfc\xec\x22\xd8\x6f\xd2\x5e\xed\xd9\x77\x05\xad\x33\x7b\x64\xf9\xd8\x6f\x5c\x2a\x3f\x19\x1c\x69\x78\x55\x8c\x03\xe4\xde\x7c\x83\xfb\x6a\x70\xb3\x38\x44\x3a\x40\x07\xee\x45\x2e\x3d\xbc\x0c\xc8\xd5\x89\x40\xcb\x72\x31\x1f\xe3\x90\xed\xd6\x89\x53\x5b\x05\xb4\x0e\xc3\xfe\x22\x3e\x3a\x49\x13\xe8\x64\xe9\xb3\xda\xc1\xed\x42\xf4\x38\x59\x3d\x53\x22\xa5\x1c\x48\x9b\x2e\x1a\xd4\xd0\x4b\x03\x07\x8b\x24\x32\x31\x6d\x62\x55\xb9\x9a\xe0\x08\x61\x58\x07\x11\x6f\x3f\x3d\xeb\x29\x95\x57\x5e\x68\xff\xf9\xb5\xaf\x5e\xf5\xcc\xe3\x1c\x26\xd5\x9e\x73\x3c\xb5\xc0\xb8\x71\x0d\xf0\x9d\x2d\xe6\xc1\xd5\x62\x3e\x39\x5f\xec\x59\xcc\x76\xc3\xa0\x2b\x61\xbb\x11\x20\x0b\xd7\x8d\x8c\xc3\x7d\x7a\xf0\x7c\xd6\xff\x60\xd1\xcd\x5a\xb1\x67\x75\xe8\x97\xbc\xfb\x99\x74\xb6\x9f\x90\xda\x29\xd4\xd5\xad\xd6\xf3\xca\x67\x47\xf7\xcb\x60\x83\x54\x61\x3f\xa9\xcb\xfb\x9a\x46\x9f\x44\xd8\xa7\x1d\x76\x3d\x53\x70\xab\x17\xae\x00\xd1\xaa\x2f\x8a\x60\xed\x66\x4b\x4f\x81\xfa\xe5\xee\x1e\x5e\x28\x47\xd2\xaf\xd1\xaa\x76\xd8\xd2\x95\x7b\x5e\x2f\xd2\xfb\x10\xe5\x11\x33\xdf\x10\x69\x06\xe4\x30\x0f\x69\xac\x3d\xb1\xee\x6c\xd9\x80\x33\x42\x67\x5b\xc7\xd7\xdd\x1f\x20\x91\xfa\xa0\x18\x14\x7b\x50\x08\xfa\xc9\xe2\x99\x96\x2d\x8d\x24\xbe\x99\xd4\x47\x83\x3d\x16\xa2\x1e\x92\x1c\x76\xd9\x1e\x96\x44\x0d\x3f\xb5\x01\x88\x68\xa0\x54\x44\x06\x13\xa8\x0f\x94\x96\x3d\xb0\x31\x0f\x9c\xb8\x7d\xc0\xb8\xdf\x1e\xa5\x04\xb7\xff\xc0\xc9\x02\xa0\xd3\x8c\x89\xce\x4e\xd8\x63\xe6\xdb\xc7\xa4\xb3\x6b\xbb\x7f\x90\x0a\x6b\x24\xde\x73\xf1\x5e\xc4\x32\xab\x44\x87\xc6\x5f\x29\x7a\x27\xc0\xef\x6b\x79\xea\xcd\x78\x8f\xe3\xd5\x2b\x6d\xfe\x29\x9d\x11\x2f\x81\x0e\xbc\x0e\x79\xc9\x70\xd8\x15\x68\xd0\x01\x84\x3d\xea\x45\x9f\x53\x03\xfb\xe6\xfa\x88\x83\x00\xc3\x65\x39\x9f\xbd\x7b\x37\x59\xbc\x3a\xfa\xff\x01\x00\x00\xff\xff\x3a\xd4\x4a\x9e\xb5\xf4\x00\x00")
+var __1528395617_squashed_migrationsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xd4\x7d\xfd\x72\xe3\x36\xb2\xef\xff\x7e\x0a\xdc\xd4\xa4\x2c\xe5\xca\xae\x4c\x36\xd9\xdc\x3b\x93\x49\xca\xb1\x39\x13\x9d\x38\xd2\xac\x2c\xef\x4c\xce\xd6\x1e\x16\x25\x42\x12\xd6\x14\xa9\xf0\xc3\x1e\x67\x3f\x9e\xfd\x14\x40\x80\x04\xc0\x06\x08\xd2\x92\xbc\x9b\x6c\x6d\x2c\xa2\xf1\xeb\x46\xa3\x01\x74\x37\x40\xf0\x47\xef\xdd\x78\xf2\xfa\xe4\xe4\xe2\x7a\xee\xcd\xd0\xfc\xe2\xc7\x6b\x0f\x8d\xdf\x22\xef\xe3\xf8\x66\x7e\x83\xa6\x93\xeb\x5f\x51\x91\xe1\xd4\xc7\x9f\x72\x9c\xc6\x41\xe4\x07\xcb\x65\x52\xc4\x79\x86\xae\x66\xd3\xf7\xe8\x72\x3a\xb9\x99\xcf\x2e\xc6\x93\xb9\x54\x0b\xae\xe0\xb3\xc7\x24\xf4\x57\x77\xf8\xf1\x75\x3b\xc3\x6d\x40\xa2\x76\x2e\x8c\xca\x1d\x3a\x2b\xd2\x7b\xfc\xe8\xa7\x38\xdb\x25\x71\x86\x6d\xf8\x3a\x69\x07\x26\x38\xcf\x49\xbc\xb6\x82\x73\x92\xc3\x80\xa6\x78\x85\x53\x1c\x2f\x71\xe6\x27\xe9\x3a\xdb\x1b\x6e\x50\xe4\x9b\x24\xed\x20\x73\x70\x8f\x43\x3f\xc3\x41\xba\xdc\xd8\x75\xad\x10\x1e\x9e\x41\x92\xae\x9d\xf0\x53\xbc\x26\x59\x9e\x3e\x32\x6b\x8e\x33\x92\xc4\x36\x26\x00\xb5\xbf\x2b\x16\x11\xc9\x36\xb8\x83\xd6\xf6\xc9\xb4\x7f\x43\xfd\x14\x47\x38\xc8\x70\x37\xde\x55\x2d\x1f\x28\x7b\x1e\x41\x96\x29\x0e\xf2\x2e\x56\xbb\x4b\x93\xb0\x58\xe6\x7e\x56\x2c\xb2\x65\x4a\x76\x79\x4b\x0f\x80\xf4\xdd\xd9\x45\x64\x89\x5b\xa6\x24\x9d\xd4\x87\x58\x3b\x31\xa5\x76\xb1\xc5\xdb\x05\x4e\x6d\xfc\x24\x2a\xf7\xf6\x74\x87\xee\x32\x5d\xd1\x7a\x24\xbe\x27\x79\xd0\xd6\x2d\x1a\xa5\x9f\xe1\x38\xec\x32\x0c\xfb\x73\x4a\xf1\x92\xec\x08\x8e\xf3\x63\x30\x73\x1d\xe1\x71\xb0\xb5\xda\x16\x2b\x77\x17\xd8\x0d\xce\x55\xb8\x28\x23\x2b\xc9\x0c\x2c\xb8\x1a\xa5\x1f\x16\xdb\x9d\x3b\x8b\x5d\xb0\xbc\x0b\xd6\xed\x0c\x04\x9d\x3b\x7c\x48\xb2\x65\x91\xb1\x99\x27\xdf\xa4\x38\x08\x33\x3f\x0f\xd2\x35\xce\xfd\x14\xef\x12\x0b\x3f\x7b\x45\xfe\xec\x59\x45\x60\xff\xd7\x4f\x80\xfe\x4c\x19\xbf\x83\x72\xeb\xea\xcc\x48\x10\xd4\xe9\xa4\x62\x46\x8f\x7e\x9e\xdc\xe1\xd8\x91\x73\xa3\xda\xf3\x32\xef\x67\x5a\xcb\x64\xbb\xc5\x71\xee\xc8\x55\x50\x1f\x97\x59\xe7\xae\xc5\xab\xa0\x88\x4a\x73\xb7\xf2\x92\xe9\x24\x3b\x6d\x81\x5f\x6e\x82\x78\x8d\x33\x6c\x6d\x47\x4d\xd4\x03\xd8\xff\x5b\xb2\x70\x02\x67\x84\x7e\xfd\xf3\xa0\x5c\x82\xed\x2e\x20\xeb\x98\xfe\x3a\x0e\xa3\x6e\x4c\xf0\x7d\x8b\x69\xe9\xa4\x5d\xf5\xc6\xc5\xb2\xb2\x10\x34\x3e\x5b\x30\x77\xc1\x12\xbb\x9b\x6d\x5f\x06\xae\xab\x72\x37\x7c\xf1\x97\xbf\x8b\x02\xc7\xae\xe8\x84\xcf\x07\x75\x17\xe0\x56\x3b\x92\xe9\x3a\x0c\xbb\x5e\xf0\xdd\xf5\x13\x2c\x97\x38\xcb\xda\x67\x79\x85\x8e\x86\x2d\x7f\xc3\xcb\x0e\xbe\x67\x3f\x36\x70\x58\xc5\x6a\xcf\x67\xe3\x77\xef\xbc\x99\x54\x35\x4f\xc9\xda\xbf\x0f\x22\x12\x06\x39\xd6\x2d\x25\xf3\x57\x24\xa6\xe1\x6a\x88\xa6\x93\x4a\x69\x99\x1d\x2c\xc4\x11\xce\xb1\x34\x22\x2b\x9f\xd0\x4f\xe2\x8a\x43\xc6\x10\x05\x8d\x23\x24\xaf\x5b\x7b\x99\x0c\xb1\x02\x81\x84\x1c\x4f\xae\xbc\x8f\x12\x1e\x55\x4a\xc6\x7a\x80\x8e\x3a\x2b\xd1\x82\x44\x11\x89\xd7\xfe\xb2\xc8\xf2\x64\xcb\x74\x69\xa1\x07\xd2\x6a\xfc\x0f\x43\xa5\x32\x43\xf2\x5b\x81\x53\x82\x33\xf6\xdf\x47\xbf\x88\xc9\x6f\x85\x49\x2a\x36\x0a\x8a\x94\xf8\x24\xfc\x64\x23\xc9\x92\x22\xa5\x0e\xf8\x9a\xc4\x6d\xa4\x54\x07\x7e\x9e\xae\xb7\x36\xa2\x2d\xce\x83\x30\xc8\x03\x17\xc0\x4a\x09\x19\x4e\xef\x09\x9d\x32\x59\x8b\xac\xd5\xf4\x04\x41\xe6\x17\x05\x09\x3b\x90\xd7\x49\x15\x4b\x9f\xda\x12\x11\xf7\x38\xa5\x0f\x7a\xd4\x04\xca\x8c\xa2\xd3\x84\x9f\x4d\x42\x3d\x7e\xcc\x48\xbc\x8e\xf0\x2a\x22\xeb\x4d\xee\x58\xa5\x11\xdf\x3a\xd6\x63\xac\x4d\x72\xeb\x81\x1d\x0f\xc1\x6c\xd4\x9c\xa4\xa2\xb5\x1b\x36\x63\x40\xe3\xb9\xcc\xbf\x27\x19\x59\x44\x98\xcd\xf8\x19\xc9\x93\xf4\x91\xf9\x8e\x24\x6f\xaf\x5a\xec\xa2\x24\x08\x71\xe8\x07\x56\xe2\x12\x8e\xaf\x29\xe5\x0f\x7f\x17\xa4\x54\x67\xfc\x57\xbb\xac\x02\x43\xa9\x67\xa8\xc0\x1c\x14\x3f\x4a\xea\x8c\x72\x3b\x61\x4e\xb6\x38\xcb\x83\xed\xae\x9d\xd4\x62\x4f\x40\x64\x05\x05\x8f\xbb\x20\xdf\x58\xc6\x27\x80\x42\xc2\x6e\xf4\x9a\xd3\xef\x54\xb7\x19\x14\x89\xea\x75\xbc\xd2\x13\x88\xfd\xc7\xad\x32\x10\x24\x75\xab\x47\x95\x9c\xd2\xa5\x20\x4d\x83\x47\x3f\xc2\xf1\x3a\xdf\x74\x84\x70\xd6\xde\x32\x25\x39\x59\xd2\x8d\xa0\x38\xf4\x33\x42\xd7\xe0\x24\x5e\x91\xb5\x7d\xf0\xd5\xeb\x34\xf3\x85\xb2\x3c\x48\x73\xdb\x20\xd2\xe8\x85\x83\xe0\x5c\x01\xa7\x69\x92\x9a\x48\x01\x97\x98\xb7\xbb\x43\x0d\xeb\x64\x56\x57\xa8\xe5\x22\x61\xdb\x62\x29\x6a\xb9\xea\x48\x21\x77\x50\x91\x42\xaf\xbb\xa3\x86\x4a\xaa\xd3\x17\x25\xc9\x5d\xb1\xb3\x7a\x93\x54\x93\x36\x2f\x92\x95\xfb\xbb\x36\xa7\x94\x92\xf9\x3b\x9c\x6e\x49\xd6\xb6\x03\xa2\x93\xb2\xbf\xfd\x84\x7b\xc1\xdc\x2a\xdb\x58\x35\xfc\xaa\x36\x86\x8d\x0a\xfe\xee\x00\xfb\x9a\x4c\x7a\xff\x1e\xa7\x64\x45\x70\x58\x3e\xde\x33\x8f\x38\xf1\xc3\x62\x17\x91\x65\x90\xd3\x1d\x05\x9e\x22\x3f\xd8\x2e\x6a\xab\x96\xba\x6c\x48\xb6\x83\x2d\x37\x78\x1b\xf8\x5b\xb2\x4e\x5b\x33\xeb\x0d\x5a\x7f\x77\xa8\xfd\xc7\x56\xe0\x96\x8c\x2d\x2d\xde\x0f\x08\x5d\xd9\x5d\x06\x49\xd3\x05\xb5\x35\x17\xa0\x76\x11\xd7\xe8\x01\x77\x62\x55\xfb\xcd\xad\x2c\xa1\xfd\x33\x5b\xbb\x40\x7a\x7f\x77\xd0\x2d\xbe\x56\xf0\x4d\xb0\x48\xe9\xe8\x4d\xd2\xd6\x04\x66\x83\xd6\xaf\x8d\xe0\xb0\x7c\x5a\x75\x44\xe3\x16\x0b\x22\x2d\xf6\x77\xfb\xdf\x7b\x3c\x00\x24\x4f\xaf\x09\x57\xca\x85\x81\x14\x25\xb5\x30\x91\xe3\xa9\xdd\x7e\x36\xe9\x5a\x61\xb4\xd0\xcc\x02\xd8\x08\xe2\x9c\xa0\x79\xf8\x66\x93\x54\x8b\xf7\x9c\x60\x59\xac\xd7\x86\xc9\x88\x9a\x81\xa0\x9f\x26\x49\xbe\x57\x16\x6e\x5a\xe6\x31\x5f\x1b\x64\x15\x1a\xb6\x81\xae\xa3\x64\x41\xf3\x24\x79\x90\x63\x0b\xa8\x4c\xd6\x0e\xaa\xa7\x5f\x6c\x1a\x68\xd0\x3a\xc0\x57\x71\xa7\x0d\xb7\x0e\x4e\x5b\x01\x81\x60\x51\x0a\x51\x2d\x4c\xec\x15\xfb\x30\xee\xc6\xac\x0b\x83\x46\x04\xea\xc6\xaa\x19\xb8\x76\x61\x2a\xc2\x47\x37\x5e\x55\xb0\xb9\x3b\xcc\x86\x5c\x2b\xac\x29\x76\xb5\x70\x30\x86\xbb\xed\xcc\x44\xe4\x67\x6b\x40\x4d\xe4\xab\x99\x4d\x12\xba\x78\x65\x5d\x79\xb8\x0b\xdd\xba\xb7\xa1\x10\x76\x92\xb5\x23\xf4\xee\x90\x3b\x7a\x07\x05\xaf\x1f\x90\xd0\xbf\x23\x31\xf3\x05\x9c\x54\xc5\x63\x73\x2b\x37\x41\xe3\xd0\xa9\x9c\x94\x85\xfa\x2e\x98\x25\x61\x07\xe0\xb6\x1e\x95\xe9\x0e\x05\x5b\xfd\xa2\xc2\x8b\xe4\xe3\xbd\x8b\xba\x95\x1c\x87\x85\x9f\x9a\x0b\xb9\x0f\xa2\x02\xfb\xd9\x26\xf8\xea\x9b\x3f\xb6\x3a\x79\xfd\x58\xd8\x34\x45\xdd\xcb\x0c\x95\x65\x97\xd3\xeb\xdb\x5f\x26\x88\x84\xa5\xf0\x57\xde\xdb\x8b\xdb\xeb\xb9\xad\x66\x33\x7d\xd1\x0b\xaa\x91\x08\xe8\x05\x22\x02\xfe\x5e\x95\xd5\x08\xbc\x0f\x04\x35\x95\x9e\x15\xf5\xd0\x33\xdb\x13\x4e\x1d\xf8\xf6\xc1\x6b\x46\x6a\x7d\x50\x68\xc4\xd5\xb7\xa2\x88\x85\x7a\xd7\x97\x03\xa2\x3e\x18\x7a\xc8\xd2\x1b\xa3\x8a\x4d\x7a\x23\x94\x31\x42\xef\xea\x22\x22\xe8\x03\xd0\x70\xbe\xfb\xa1\xd4\xfe\x78\x9f\xea\x80\x5f\x2b\x7b\xdf\xfb\x81\x7c\x2a\x4c\xe5\xc8\xf6\xc1\x31\xba\x95\xbd\xc0\x84\xd3\x90\x3d\xad\x3a\x5b\x14\x9f\x08\xc1\x5d\x9e\x5e\x20\x7c\x35\x7e\x5a\x6d\xee\xb1\x3c\x09\xa2\xb7\x1e\xd4\x35\xdb\x8e\xc0\x7e\xdd\x78\x7f\xba\xf5\x26\x97\x32\x06\x5d\x6b\x33\x9a\x05\xca\xf0\x6f\x9c\x4a\x67\xc3\x48\x2c\x65\xf2\xd6\x86\x9d\x51\x73\x51\x6f\xe7\xdc\xac\x63\x25\xa6\xe1\xa2\x45\x8a\x46\xb6\xdf\xca\x5f\xa7\xe6\x64\x7f\x1e\x7b\x1f\x64\xaa\x7a\x40\x59\x18\x8b\x1d\x01\x3b\x43\x41\xb5\xb8\x2b\x76\xfe\xcb\x6f\x5e\x7e\xfd\xed\x97\x5f\x7d\xfb\xed\x1f\x5b\xc8\x4d\xc5\xfa\x7e\x81\x45\x3c\x35\xfd\x6f\x17\x52\xa1\xb5\x12\xf1\x63\x3d\x66\xbe\x62\xf7\xdd\xcc\x8d\x52\xd8\xea\xeb\x9e\x49\x8b\xf0\x40\x85\x2e\xe8\x75\xea\xbe\x23\x97\xaa\xa2\xa1\x06\xd5\xd4\xa3\x9f\x16\x71\x8c\xd3\x32\xcd\x65\x20\x04\x33\xfc\x2d\xb4\x22\x57\x6f\x6e\x69\x33\x1f\x6e\x6d\x5f\x83\xdc\x8c\x4c\xbd\x34\x3b\x18\xa5\xb0\xd6\x17\xce\x5a\x2b\x4c\x45\x58\x0d\x9f\x6f\xfe\xf0\xc7\x6f\xff\xf0\xb2\xbd\x86\x9d\xbf\x9c\xd3\x6e\x95\x41\x22\x36\x50\xd1\xdd\x0c\x0b\x47\x3d\x47\x6d\xe5\xa8\x11\xb7\xa0\x0a\x67\xd1\x01\x53\x90\xb6\x20\x32\xe7\x51\x85\x33\x91\x8a\x9c\x70\x3b\x6f\x4e\x69\x20\x91\x73\xc1\x66\x96\x0d\xd7\xd2\xce\xb7\x41\x6e\x41\xae\x33\xbb\x76\xc8\x8a\xce\x8c\xd5\x92\xc0\xb5\xe2\xdb\xeb\x76\xe2\xd9\x91\x4f\x3b\x61\x23\x6b\xeb\x24\x4e\x95\x7f\x75\x95\x47\x54\x30\x51\xca\xd9\x57\xb3\x04\xc6\x14\xaa\x55\x0c\x53\x2d\x0b\x1f\xe1\xb7\xb6\x34\xb0\xf2\x6f\x33\x07\x2c\xe6\x46\x3b\xe2\x31\x5a\x17\xcc\xd2\xaf\x76\x45\x2d\xa9\x2d\xb8\xdc\xd3\x6d\x03\x14\x64\xed\x48\x3c\xff\xe7\x04\x57\xd2\x3a\x60\x3a\x28\x52\x26\x35\x23\xaa\x39\x32\x2b\xa2\x42\xca\x69\xde\xde\x4e\x2e\xe7\xe3\xe9\x44\x22\x6b\x3f\x99\x3e\x18\x9a\x6b\x3b\x1e\x45\x77\x81\x10\xec\x55\x04\x01\x6c\x85\x58\x46\x49\x86\xb3\x9c\xed\x36\x0e\xea\xfd\x4c\x94\xe3\x4f\xf9\x08\xd1\x91\x4c\x72\xfe\x83\x9e\xc5\xe4\x7f\xe6\x69\x40\x8f\x25\x07\x91\x1f\x91\x2d\xc9\x11\x89\x73\xbc\xc6\xe9\xd0\xa0\xcf\x7a\x4d\x12\x04\xbf\xbe\x07\x07\x6d\x92\xb2\x31\xfb\xfa\xe4\xe4\x72\xe6\x5d\xcc\x3d\xe4\x7d\x9c\x7b\x93\x1b\x2e\xf2\x64\x3a\xaf\x6a\x10\x2a\x48\x3b\xdd\x26\xcb\x93\x14\xb7\xd3\xed\xd6\xfc\xa4\xb9\x20\x64\x12\xea\x72\xa1\x8b\x1b\xe4\x4d\x6e\x7f\x41\x83\x13\x84\x10\x3a\x15\xe5\xa7\xa3\xf2\x37\xa5\x39\x3d\x19\x4a\x28\xcc\xb0\xea\xe6\xf3\x8a\x24\x14\x1a\x63\x32\x4c\x6e\xaf\xaf\x4b\x04\xad\x03\xb4\x52\xa9\x37\xf4\x7a\x49\xc2\x9f\xf3\xd0\x12\x9d\x9e\xbe\x7a\x05\x10\x8a\x23\xcc\x41\xee\xe7\x64\x87\x16\x49\x12\xe1\x20\x16\x01\x29\x5a\x05\x51\x86\xb5\x2a\xbb\x34\xa1\xa3\x81\x1d\x11\x44\xd5\xe1\x5f\xf4\x40\xa8\x35\x90\x2d\x46\xbf\x27\x31\xae\x10\xe2\xe4\x61\x30\xd4\x10\xa4\xc3\xcf\x66\x00\xb5\x8a\x94\xe5\xae\xb5\xc7\x3d\x90\xf2\x8d\x10\x6a\xdf\x69\x86\x2e\x7f\xf2\x2e\x7f\x46\x83\x01\xd7\xcd\xbf\xd0\xe9\xff\xfc\x25\x38\xfb\xfd\xcb\xb3\xff\xff\xd7\xbf\x7f\xfd\xe5\x3f\x5f\x70\x35\x0c\x87\x36\xdc\x5a\xed\xfe\x72\x83\x97\x77\x15\xaa\xd4\x1f\xdf\x7d\x5f\xe9\x74\x38\x94\xfb\xb8\x1a\x54\xfb\x1d\x4a\x68\xe6\xcd\x6f\x67\x93\x1b\x74\xe3\xcd\xa7\x6f\x25\x69\x59\x3b\xae\x2f\x26\xef\x6e\x2f\xde\x79\x68\x17\xed\xd6\xd9\x6f\x11\x7b\x78\x71\x83\x5e\xf8\x2f\xd8\x9f\x57\xde\xe5\xf5\xc5\xcc\x63\x7f\xd3\xff\x45\x24\xc6\xf4\xa8\x7b\x9a\x3c\xa0\x14\x2f\x93\x34\x7c\x2d\x8a\xe8\xbf\x67\x67\x82\x02\xa5\xc9\x43\x56\x55\x23\x68\x15\x25\x41\xfe\x35\x7a\xf5\x06\x7d\xa9\xd4\x10\xd5\x2a\xf1\x11\x4b\x03\xe0\xb4\xaa\xbb\x4a\x8a\x38\x64\x1d\x27\x09\xff\xf9\x6c\xfa\x81\x0e\xad\xd7\xb4\x2e\x7d\x8c\xe8\x63\xca\x14\x0d\x52\x9c\x17\x69\x8c\xc3\x21\x83\x60\x37\xcc\x54\x60\x6f\xa7\x33\xa5\x0d\x52\x91\x26\x3f\xc9\x50\x40\x9b\x58\xa4\x19\xb9\xc7\xd1\x23\x75\x37\x48\x8c\x43\x74\x39\xf7\x50\xbe\x09\x72\x54\xf2\xc9\x50\x10\x45\x28\xa0\xd7\x07\xe4\x49\x8a\x82\x18\x85\x38\x5b\xe2\x38\x0c\xe2\x3c\xd3\xc1\x93\x15\xca\x37\x18\xad\xc9\x3d\x8e\x45\x4f\xae\x92\x54\x7a\x58\xf7\xf7\x39\x9a\x6f\x48\x86\x36\xc1\x6e\x47\x13\x40\x79\x82\x30\xdd\xf3\xa1\x27\x29\x48\xac\x03\xbf\x4f\xb2\x7c\x9d\xe2\x0c\x05\x54\xec\x28\xf8\xfd\x11\xad\x71\x8c\x53\x7a\xd4\x6a\x84\x1e\x36\x64\xb9\xa1\x72\x26\x0f\x19\x2a\x18\xd6\xae\x88\x22\xc6\xf6\xb3\x18\x7f\xca\x3f\x13\xf3\x37\x17\x4a\x87\x27\x31\xc2\x24\xdf\xe0\x14\x85\x24\xc5\x4b\x1a\xfe\xa0\x55\x9a\x6c\x19\x42\xf9\x2a\x8e\x68\x4e\x90\xa1\x18\xe3\x10\x87\xe7\x0a\xc8\x87\xf1\xfc\x27\x34\xf3\x2e\x6f\x67\x37\xe3\x3f\x7b\x42\xcb\x03\x12\x8e\xa4\xc9\x6a\x84\x3e\x2b\x51\x3e\x1b\x21\xe5\xb5\x83\x51\xcd\x77\x48\x27\xcf\x81\x82\x4d\xff\xbd\xf1\xae\xbd\xcb\x39\x8a\xce\xbf\x40\x6f\x67\x53\x31\xb3\xea\xff\x9c\x9d\xa1\x0c\xe3\xb0\xee\x57\x9a\x64\x29\x67\x10\x2e\x3e\x3d\x62\x4c\xe2\x35\x22\x71\xdd\xa7\x15\x6f\x10\x93\x73\x5e\x9e\x7f\x31\x42\xa7\x17\xa7\x25\x7b\x39\xd0\x41\x4b\xf4\xe1\x27\x6f\xe6\xa1\xe5\x79\xdd\x54\xf4\x06\xbd\x78\x89\x2e\x26\x57\x68\x79\x2e\x1a\x4d\x9f\x7d\x05\xf2\xb8\x9d\x8c\xa7\x93\x13\xa0\xa0\x5b\x8b\x6a\xcb\xec\xd2\xa6\xab\xfd\xb7\x69\x88\xa2\x93\xc6\xc3\xb2\x91\x27\x06\x59\xac\xfd\xca\xac\x2b\xf2\x49\x1c\xe3\x94\xd9\x87\x5a\x89\x5b\xdb\x10\xac\x7b\x76\x86\xd6\x38\x47\x74\x18\x54\x5d\x0e\x12\x72\x4c\xa6\x94\xe8\xbc\x52\x20\x67\xc1\x99\x47\xe8\xbf\xa6\xe3\x89\xae\xab\xe9\x44\xa9\xf1\x86\x19\x4a\xa9\x28\x45\x7d\x91\xfc\xb3\xa1\xc8\xe8\x5c\x19\x14\xdd\x2d\xa5\x6a\x67\x6d\x08\x87\x6f\xe9\xd5\x29\x0a\xe2\xd0\xa1\xa5\x4a\xe3\x58\x73\x45\xdb\x1b\x42\x0e\x51\x56\x2c\x58\x52\x4d\x29\x1a\x9e\x9c\x00\xcd\x50\x8d\x00\x45\x15\xcd\xf5\x74\xfa\x5e\xa9\x70\x76\x86\x7e\xc6\x78\x87\xf2\x34\x58\xde\xa1\x64\x85\x36\xc9\x03\xda\x06\xf1\x23\x5d\x58\x32\xf4\x80\xcb\x69\x93\xcd\x7c\x1c\xee\x1c\x8d\x57\xb4\x60\x43\xd7\xe4\x0d\xae\xd7\x31\x1d\x98\xad\xca\x23\xb4\x08\x48\x84\x92\x22\x47\x71\xf2\xc0\xf4\x52\xae\x22\x74\xdd\xc0\xdb\x5d\xce\x38\x9d\xa3\xab\x24\x3e\xcd\xd1\x1d\x95\x45\x0c\x5f\xba\x4c\x04\x62\x9a\xd6\xc1\xb9\xce\x56\x49\x8a\xef\x71\x3a\xa2\xab\x00\x9d\x99\x57\x41\xca\x26\xed\x07\x2e\x57\x39\xe3\x6f\x93\x14\xa3\x88\xdc\xd1\x45\x8d\xfa\xdc\x6c\x3d\x58\x60\x44\xb6\x3b\xfa\x6a\x5d\x86\xd5\x99\x9b\xd0\x45\x9b\xa0\xff\x8b\x5e\xbe\x56\x9e\x8f\xdf\x22\x82\xbe\x47\x2f\xbe\x46\xf3\x9f\xbc\xa6\xc9\x95\x6e\x87\x5a\xc5\x9b\x5c\xa1\xf1\xdb\xd7\x6a\x2f\x9d\x9d\xa1\x39\xf5\x53\x13\xb4\x22\x71\xc8\x24\x64\x6b\x79\x90\x65\xc9\x92\x04\x39\x0e\xb9\x8b\x47\x57\xc3\xb2\xa1\x4c\xe9\xb4\x65\x98\x4a\x9f\x25\x5b\x9c\x6f\x48\xbc\xd6\x61\xb9\x6a\x49\xce\x14\x8d\x39\xf8\xaa\x88\xd9\x28\xe4\xeb\xeb\x03\x89\x22\x56\x56\xec\x78\x05\xaa\x6d\x2a\xc5\x8a\xa4\x59\x4e\xfb\x43\xc7\x7d\xc0\x6c\xc6\x90\xcd\xa0\x14\xf1\xfa\x66\xfc\x16\xd1\xb7\x48\xcb\x15\x97\xf5\x83\x30\x1a\x66\x41\x0b\x6a\x57\xb4\x9e\x0e\x29\x60\x48\x8c\x92\x34\xc4\x29\x35\xbe\x90\x64\x39\x9d\x90\x0c\x2b\xed\x88\x77\x5e\x8c\xa9\x5b\x1d\xa4\x24\x7a\xd4\x51\x69\x1d\x75\x61\xd7\xe4\x3c\x07\x07\x0c\x55\xff\xf9\x17\x68\x3c\x99\x4f\x65\xef\xab\x5e\x04\xe8\xcf\xac\xf4\xb8\xca\x55\x80\xfe\xa9\x8d\xef\xda\xc9\xd2\x47\x3a\x23\xe6\xe2\xa8\x84\xfc\x21\x25\x7a\xf1\x07\x74\x3d\xfe\xd9\x43\x03\x46\x4d\x8f\xc3\xa2\x7f\xfc\x03\x9d\x7e\x7e\x3a\x6c\x58\x61\x2d\xe2\x39\x09\xd1\xf8\xa6\x0a\x01\x6c\x86\x89\x26\xde\xc7\xb9\xd4\xba\xd7\x92\xc1\xe8\x5d\x2e\x99\xb3\xf8\x59\xfd\x73\x76\x86\xf0\x27\x92\x57\x56\x05\xda\xbb\xfc\x9b\x4e\x3a\x65\x13\xbc\xc9\xd5\xeb\x93\x17\xfe\x0b\x20\x04\x70\x0e\xc8\xb9\x5c\xe5\x2b\xe4\x6b\x9c\xda\x9d\xfa\x17\x80\x47\x7c\xfb\xfe\xea\x62\x5e\x3b\xf8\xa8\xce\xfc\xd4\xde\xeb\x8d\x37\x97\x09\x84\x54\x24\xcc\xd0\x1b\x89\xfe\x5c\x29\x39\x43\xd3\xeb\xab\x73\x12\x96\x81\x53\x55\x9f\x19\x0c\xc8\x4e\xad\xfe\x83\x52\x5d\x9a\x33\x78\xff\x4d\xaf\xaf\x64\x35\xda\xb4\x28\x38\x68\x6a\xe4\xdc\x0e\xa4\x45\x81\x6e\x52\x62\x25\x14\xd7\xa2\xa0\x3f\x57\x4b\x5c\xb5\x68\xa8\xbe\x07\x2d\xba\x64\xa7\xba\x2a\xb0\x0e\x2b\xe9\x9e\x18\x9d\x70\x49\x9c\xbf\x3e\x11\x3a\x15\x0f\x5f\xbd\xe1\xfe\x1e\x9f\x98\x2e\xa7\xb7\x93\xf9\xe0\x8b\x61\xb9\xa0\x57\xf2\xd0\x7c\xdd\x49\xa5\x15\xa4\xbf\x5a\x88\xde\xa0\x89\xf7\xe1\x5c\x7f\xcc\x6a\xd0\xa9\x46\x34\x83\xa6\x15\xe8\xec\x71\x7b\x7d\x7d\x82\x10\x8d\xcc\xd9\xf4\x32\x10\xd2\xfc\x9f\x37\xe8\xcb\x61\x3d\xa7\xcc\x2e\xc6\x37\x1e\xf2\x3e\x5e\x7a\xef\x99\x9e\x4e\x2f\x39\x83\xf7\x51\x10\xff\x7d\x7c\xf5\x0a\x7d\xfe\x4f\xb4\x09\x32\xf4\x39\x2a\x62\xc1\x03\x51\x59\x79\x9a\x07\x81\x62\x8d\x44\xeb\x5f\x9f\xd4\x13\xc8\x49\xd5\x61\x13\xef\xc3\xeb\x93\x46\x67\x95\x89\x32\x25\xdd\xc8\x35\x47\x42\xb4\x20\x6b\x12\xeb\x09\x1c\xed\x9a\x0e\x43\x1e\x49\x3e\x64\x88\x16\x8f\x39\x0e\x34\x82\x38\xc9\x31\x98\x62\xa2\xd7\x73\x3c\x21\xd5\x13\x05\x19\x93\xcd\x8a\x50\xb2\x2a\x47\xb9\x03\xa1\x76\x65\x88\xa1\xc9\xd9\x32\xd9\xe1\x8c\xb5\xe9\x2f\x7f\xad\xca\xe4\x44\x4d\x95\x13\x86\x32\xc1\x0c\xe3\x66\x7e\x31\x9b\x97\x41\xc9\x4b\xf6\x60\x3c\xb9\x9c\x79\xbf\x78\x93\x39\xfa\xf1\x57\xfe\x68\x32\x45\xbf\x8c\x27\x7f\xbe\xb8\xbe\xf5\xaa\xdf\x17\x1f\xeb\xdf\x97\x17\x97\x3f\x79\xd4\xe7\xe2\xc7\x43\xac\x5c\xd1\xf4\xc3\xc4\xbb\xa2\xe0\x4a\xe9\x39\x09\x6b\xb1\x4b\x1b\xa9\xcc\x8d\x1a\x62\x8b\x8d\xe8\xa6\x09\x53\xf1\xad\x2c\x53\xe1\x3d\x64\x1e\x21\x59\xad\xa0\xe7\xec\xbd\x69\xa8\xa0\x7e\x1b\xb9\xa5\x93\xe5\xc1\xec\x60\x0e\x4f\x30\xd1\x62\x17\x3e\xa9\xfe\x22\xc8\xe8\x15\x10\xa0\x1e\xa4\xf4\xa2\xd2\x61\xbe\xa8\xa4\xe5\x17\x2b\x2c\x63\x76\xb1\x32\x1f\x15\xef\x58\x46\x0b\x71\xad\x8d\x56\x29\xb5\x19\x2d\x9d\x23\x9d\xad\x36\x7f\xdc\x1d\x64\x6e\x7a\x6a\xc7\x07\xe9\xba\x60\x17\x27\xb8\xf6\x3c\x6b\x75\xbd\xfc\xd2\x76\x69\xfd\xaf\x94\x75\x31\x02\x79\xa7\xed\x88\x56\x20\xb3\x05\xcc\x80\x15\x5b\xec\xa0\xcd\x04\xe8\x11\x0c\x48\xb9\x21\xae\x0e\xd5\xb0\x62\xde\x1f\xe2\x66\x2f\xc3\x8a\x50\x5f\xba\xa0\xad\x1c\x7a\x31\x3b\x4c\xa2\x95\x3e\xb7\xb1\xa9\xee\xe0\xdf\xb2\x24\x5e\x54\xf4\xa7\x7f\xff\xe7\xe9\xab\x57\xe5\x33\xad\x96\xdc\x13\x8d\x36\x01\x36\xaa\xdf\x34\xa1\x9a\x27\x63\xc1\x6c\x33\x59\x0d\x14\xc2\x21\xcd\x10\x95\x77\x25\x54\x16\x6b\x61\xb2\x09\x32\xff\x65\x7d\x0b\x46\xc5\x60\xd0\xec\x23\xee\xc9\x0d\xe9\x68\x18\x34\xfa\x48\x94\xb6\x8c\x8f\xe3\x0f\x0d\xf3\xa8\x00\x07\x84\x7e\x56\xb6\x65\x6a\x94\x54\x0f\x53\xd0\xd7\x84\xa0\x91\x73\x87\x1f\x0f\x31\x95\x8a\x5b\xb7\x9c\x0d\xf3\xa9\xc3\x41\xb6\x2a\x4d\x77\xf4\x25\x1a\xcd\x6e\x69\xab\x95\xc9\xd4\x01\x84\xbe\x67\xa5\xa1\x50\x9d\x76\x84\xa9\xae\x23\xb3\x0c\x24\x41\x03\x8d\x21\xd8\xaa\x75\x2e\x47\x33\x6e\x98\xb1\x64\xe3\x1a\x81\xd5\xd4\xbb\x78\xae\xad\x04\xe5\xe5\xa0\x06\xa2\x8a\x65\x45\xa1\xbb\xa9\xfb\x19\x06\x4f\xb5\xea\xbd\x7a\xc7\x2d\xa6\x73\x5c\xb7\x11\x62\x0b\x99\x8d\xc9\x71\x14\x04\x1d\x0c\xc6\x7d\x95\x14\x91\x0f\x5f\x1c\xf7\x3c\x37\x3e\xd5\x28\x3a\xcf\xad\xd5\x21\x49\x02\x2e\x01\xfa\x19\x4a\xa3\x7f\x0d\xcd\x6b\xd2\xfb\x91\x6d\xee\x81\x44\xe7\xe6\x1d\xd4\x3c\xa4\x16\x68\x2c\xa4\x12\xd7\xa9\x38\xf3\xc1\x16\xfb\x71\x92\xfb\x8b\x28\x88\x01\x70\x45\x33\xce\x6c\x44\x47\xd9\xb4\xd2\x7f\xae\x7f\x86\xc1\x6a\x19\xa8\xe0\x20\x35\x1c\xb5\xac\x87\x2c\x3c\xc0\x98\x96\x1b\x47\xab\x54\x9a\x65\x12\xe7\xa6\x50\xeb\xf9\x06\x28\xdc\x63\x06\x35\x1c\xaf\xff\xec\x02\x48\xbd\x69\x20\x04\xfa\x56\x39\xa3\x8b\x06\xd6\x69\xf3\x64\xd8\xa8\xdd\x3c\x0b\xdc\x32\x8f\x57\x57\x18\xc2\xc5\xea\x35\x83\x06\xbb\xfa\xf7\xb4\x99\x8e\xc9\x4e\x7e\x27\xa3\xc8\x63\x0a\xcc\x72\x31\x73\x49\x6e\x9a\x0f\x6e\x1f\xde\x12\xcd\xbc\x6b\x23\x04\x68\x20\xfb\xb3\x1c\x5a\xe7\xa6\xc4\x12\xa9\x50\x5f\xdb\xad\xa4\xc5\xd2\x1c\x3a\xca\x6a\xef\xfc\x2c\x7e\x8b\xb9\x3b\xd9\x73\x4e\xf2\x08\x4b\xde\xaa\xfa\x0a\x02\x07\xde\x8f\x79\xd3\x8b\xe8\xc8\xbd\x1d\xe0\x68\x03\xa1\xcd\xac\xb9\x8a\x9f\xc3\xaa\x55\xd6\xa0\x51\x73\x12\xbb\x4d\x03\x6f\x86\x3c\x6d\x82\x34\xcd\xcd\xfc\x08\x6f\x75\xdc\x94\xfd\x5c\xa4\x41\xbc\x94\x1f\xa4\x98\x9e\x0b\x56\x92\x6b\x2c\x34\xf1\xe9\x26\xbf\xc0\xe4\xde\x64\x1c\x02\x4f\x4b\xea\xe5\x26\x48\x83\x65\x8e\xd3\x66\x15\x43\x11\x45\xca\xfc\x05\xa6\x07\x60\x24\xe6\xec\xb1\xfe\xdb\x0f\x56\xb4\x3e\x7d\xea\x6a\x21\xea\x88\x79\x36\x6b\x69\x8a\x61\xb3\x1c\x59\x6a\xc0\x8a\xa4\xd7\xea\xfb\x25\x53\x8b\x34\xea\x3e\x6b\x06\x71\x12\x3f\x6e\x93\xa2\xba\xe9\x18\x82\xe0\xc7\x5c\x80\x12\x91\x3a\x87\x6a\xf1\xfb\xcd\xa1\xa2\xcf\xaa\x29\xe2\xb3\x7e\xb3\x8d\xe4\xb8\xd7\x7a\x2b\xfd\x75\x96\x93\xa4\x8d\xa9\x1c\xf7\xc1\x40\xb4\x8d\x6d\x18\xd3\x2d\xe6\x41\xb3\xdd\x4a\x78\x80\xa6\x33\x54\x57\xfb\xee\x7b\x4b\xbd\x37\xfd\xaa\x29\xec\x86\xc3\xf6\x66\xd1\x6e\x67\xb1\x4e\x79\x2c\x4d\x34\x8e\x3e\x56\xc1\xda\xa1\xca\xfe\x04\xc0\x78\x47\x77\x84\x2b\xd2\x08\xc0\xa2\xe6\xd8\x11\x88\x5b\x0c\x00\x26\x6c\x49\x01\x04\x67\x0b\x09\xf4\x58\x33\x43\x83\x65\x3d\x0b\xd4\x45\xd0\x88\xd7\xc2\xd4\xb6\x81\x6f\xca\x05\x87\x24\xdb\x45\xc1\xa3\x6f\x9a\x18\x78\xf4\xf6\x9f\xec\x3d\x4b\x56\xc3\x47\x43\x12\x97\x26\xc2\x43\x9d\xca\x52\x16\x79\x4a\xb6\x83\xf2\xe1\xd0\xc5\x5e\xf4\x4e\x38\x9e\xd9\x18\x38\x4b\xd6\xa3\x53\x00\x46\x24\xbf\x9e\xcb\xed\x87\x45\x80\x24\x44\xf4\x83\x16\x9a\xc2\x49\x4c\x72\x12\x44\xe4\x77\x1c\x3a\xbd\x23\xb4\x5d\x6f\x73\x7f\x17\x64\xd9\x43\x92\x86\xf4\xd8\x01\x5d\x45\x3e\xf1\xe9\x5e\x54\x14\x1a\xb6\xd6\x5d\x2c\xd3\xc7\x5d\x5b\x45\xb9\x87\xa4\x57\xac\xc4\x69\xe6\x6a\x78\xc0\x6b\x99\x74\xac\x11\x10\x87\x9f\x65\x04\x4a\xd4\x73\xce\xb5\x77\x22\x19\x9d\x2c\x06\xff\xef\x3e\xdf\x56\x12\xc8\x8a\x24\x20\x03\x55\x56\x33\x1f\xd0\xd6\x15\x5e\xc7\x32\x73\x80\x69\x6d\xe1\x72\xa1\x62\xdc\x6a\x75\xf9\xb5\xf7\x23\x49\x2c\xb3\xd4\xe4\x65\x45\xc0\x50\x54\x5e\xe4\x6f\x33\x56\x76\xbb\x38\x38\x5d\x9b\xa6\x71\xb1\x06\xd6\x06\xca\xbf\x46\xd9\xe0\x60\xee\x7c\x21\xdd\x71\x75\xa9\x71\xd5\xd4\x29\x4a\x4d\x1a\xad\x0e\x89\x1e\x45\xa7\x2b\x12\xd1\x68\x04\x3a\x5b\xd7\x5d\xdd\xb5\xe8\xc7\x55\x78\x83\xaf\xa6\xf2\xba\x1c\x50\x3a\xb5\x3f\xa1\x6a\xfa\x37\x7f\x27\x57\x53\x86\xf0\x63\xb9\x32\x4a\x0d\x41\x07\x2e\xa4\xb9\x8e\xa2\x71\x5f\xaf\x9a\xd0\x2a\x6f\x59\x3a\x27\x5e\xfa\xd1\xd2\xc9\x00\xf1\x5c\x9d\xd7\xca\x15\x42\xbb\x04\xa4\xc5\x87\x52\x25\xd4\x0a\xd5\x4f\x02\x1b\x88\x1a\x5f\x3b\x32\xd0\x3d\xd5\xb7\x8a\x93\xbc\xfc\xc8\x43\xab\x73\x54\x5e\x98\x14\xba\x93\x66\x7c\x47\x84\x7b\x00\xa2\xe8\x3e\xb9\x73\xc1\xe8\xef\xb4\x05\x79\xb2\x25\xcb\xea\x82\xa7\xda\x06\x94\x16\x54\x07\x45\xde\xa0\x81\x2a\x6f\x7d\x48\xc4\xc0\xa0\xfc\x78\x95\x5f\xc8\xd8\x06\x70\x16\xcd\x19\xe0\x99\xf9\x49\xfa\x18\xdf\x40\xe6\x57\x0d\x39\xcd\x02\x8f\x36\xd4\x61\xbe\xf5\x50\xd7\xca\x81\xa1\x2e\x5d\xc9\x53\x8f\x1b\xd8\x9c\xad\x03\xe7\xb9\xe3\x08\xd3\x48\x3c\x19\x5a\x5a\x5c\xdf\xfa\x55\x5e\x5b\xd4\xd0\x00\xd4\x70\x85\x9f\x9f\x44\xa1\xb4\x74\x38\x68\xc1\xb9\xb9\x0a\x1f\xc1\xdc\x68\x7d\xa2\x45\xc7\xb4\x3c\x95\xa7\x6a\x75\xbc\x0c\xb6\xb8\xd6\x65\xdc\xbc\xe8\x3c\xb7\x9d\x35\x22\x6d\xbe\x6e\x44\xc1\xf2\xce\x7f\xc0\x8b\x4d\x92\xdc\xf9\x22\x0f\xd7\x7f\xb6\xa4\x57\x74\xf9\x32\x2f\x7f\x1b\x7c\xe2\xdf\xf1\xaa\x26\x36\x9a\x79\xe5\xcf\x06\x32\xed\x10\x7d\xf7\x06\x7d\xf5\xcd\x37\xc0\x24\x59\x7d\x0b\xb0\x0d\x8f\x9d\xcf\x1b\xf2\x58\xc2\x05\x0f\x0a\x55\x28\x06\x9a\xbe\xf7\x66\x17\xf3\xe9\x6c\xf0\xaf\x21\x8f\x55\x2e\xce\xfe\x9b\x86\x2b\x83\x1f\x5e\x49\xbf\xfe\xf1\x97\xb3\xf3\xbf\x0e\x7e\x78\x23\x3d\x1a\x0e\xbf\x38\xfb\x81\x06\x34\xa5\xfb\x61\x9e\x7b\x8f\x6a\xf6\xa0\xbd\x43\x5e\x54\xe3\xce\xb6\x36\xab\x5f\x06\x51\x94\x91\x75\x0c\x5b\x7e\xf5\x5d\x96\x7f\xd3\x81\xe1\x6a\xe5\x55\x92\xda\x29\x07\x50\x69\xbe\xa1\xcd\xa3\xf5\xb9\x89\x73\x6d\x00\x0d\x0a\xc8\x1a\xb4\xab\x01\x6b\x63\x00\x92\x34\xd0\x9d\x83\x3e\x4c\xca\xf1\xfc\xbd\x1f\x07\x3d\x19\x9a\x9a\xa0\xdc\x84\x68\x6d\x87\xb6\x7a\x69\xa5\xe2\x43\xb7\x7a\x2b\xeb\xa9\xf3\xb9\x8d\xda\x65\xf3\xb4\xa9\xa7\xe6\xd5\x92\x5c\x49\xac\x40\x6a\x1e\x7b\xa5\x0a\x7f\xc2\xcb\x82\xbe\x52\x6d\x62\x20\x68\x73\x7a\x03\x4d\x8a\xb3\x22\x32\x0b\xc3\xb7\xe5\x3e\xe1\xa5\x1f\x16\xe5\x47\xcb\xfc\x38\xe3\x81\x50\x53\x52\xcb\xb5\x99\x2d\x51\x14\x50\xd3\x3c\xaf\x39\xbd\xe5\xc5\x19\xfb\x22\x20\x2f\x67\x39\xb5\x2c\x0f\xd6\xf0\xec\xb7\x0d\x62\xb2\xa2\xef\x54\x43\xa7\xe8\x16\x45\x1c\x46\x78\x8f\x66\xe5\xbc\xa2\xf3\x6d\x96\x6d\xb0\x33\x6f\x6e\x5a\xfa\xe0\x68\x53\x5c\xbb\x0c\xf5\x64\x67\xa1\x05\xa6\xbd\x26\x75\xab\xf3\x57\x14\xf0\x64\x52\x7f\x6e\x59\x33\x24\xbd\x18\xf2\xd7\xcd\x0b\x67\x65\x3a\xff\x3e\xd3\x4e\x1f\x8f\x11\x50\x34\xf3\x16\x74\x27\xcf\xe2\xe5\xd5\x9b\x95\x56\x4f\xf0\xe5\x57\xff\x0f\x8a\xbf\x8d\x02\x3c\xdd\x33\xf4\xcf\xcf\x9a\xae\xa1\xec\x18\x3a\x09\xc3\x53\x03\x95\x9d\xd4\x2a\x69\x5a\x96\x88\xf7\xe9\xf6\xd1\xa0\x61\x59\x2d\xd9\x00\x88\xf9\xf3\x8d\x64\x97\x11\x0c\xb9\xaf\xea\xc9\x15\x3e\x98\x34\x43\x35\x8f\x2a\xf8\xbd\xae\x28\x88\xd7\x05\xbd\x54\xa3\x7e\xb4\x4a\xd2\x3b\xb1\x11\x75\xb4\xd1\x27\x96\x49\xbe\xb9\xa6\xf8\x1c\xe6\x43\xd5\x86\x72\xb5\x76\x1c\x2c\x22\x60\x6b\x2d\x4f\x0b\x6c\x70\x2e\x9c\xb6\xe1\x8a\x94\x70\x45\x77\x9c\x22\xca\x25\xc8\xfd\x04\xbd\x38\xd6\xec\x5c\xa1\xb9\x31\x4b\x67\x9d\x38\x89\xd5\xfd\x7b\x75\xc4\x7f\xf7\xfd\x10\x9d\xda\x06\x70\xdd\x3e\xbf\x88\xe9\x5b\xe7\x15\x90\xd4\xf2\x6a\x1c\x36\xaa\x53\xe3\x7d\xf2\x29\x6e\x18\x95\x2b\xd4\x06\xca\x49\x9c\x4f\x86\x1f\xf5\x14\x13\x78\x56\xc9\x70\x22\x49\xb9\x54\xbe\xe1\xc4\x6a\x86\xe0\xe6\xcd\x36\x2a\xb9\xb8\xb5\x5a\x25\x83\x7f\x5b\x51\x9d\x0c\xe1\x86\x88\x2b\xf4\xdb\xa6\x35\x7d\xf2\xd2\x8a\x8d\x1a\x78\x6e\xbf\x81\x6d\x18\x3c\xfa\xc9\x43\x8c\xd3\x6a\x56\x01\x49\x58\xbe\xca\x40\x02\x7a\x57\x90\x4f\x65\x4b\x7a\x49\xa3\x86\xe1\x25\x62\xf1\x64\x47\x7b\x62\x7a\xfd\x92\x18\x3a\x86\x0d\x20\x96\x9e\xd7\x17\x5c\x96\x97\x87\xf6\x85\x4a\xbf\x45\x06\xa2\x4f\x4d\x2b\xb4\x6a\x0e\x47\x1b\x7a\x20\xdb\x7a\x10\xaa\xc5\xd0\x70\xd4\xbf\x2f\x81\x06\xca\x1e\x26\x18\xa9\x85\x24\xcd\x1f\x1b\x5d\x0d\x0c\x11\xfe\x6d\x8b\xb6\xc1\x01\x59\x82\x72\x3e\x7f\x4f\x63\x81\x77\xa5\xc2\x07\x3e\x5c\x6d\x6e\x8b\xfe\x59\x8f\x46\xdb\xcc\x4d\x52\x59\xe9\x79\xfe\x5e\xed\x7d\x52\xc3\x6a\x23\x12\x6d\x3b\x9a\xd5\xaa\x0c\x25\x7b\xe5\x05\x8a\xa5\xb2\x6f\xb5\x48\x6f\xa0\xa0\x8b\x9b\x13\x71\x8b\x99\x7c\x52\xe9\x9c\x9f\x51\x2a\x95\xa2\x94\x48\x47\x94\x68\x21\xbb\x59\x48\x26\x68\xf4\xb6\xf6\xd5\x98\xba\x97\xc1\x01\x01\x76\x00\xfb\xf4\xbd\xd4\x9d\xd9\x92\x9e\x5b\xe6\x24\x1a\x40\x8a\x83\x4c\x71\x6b\x17\x38\x17\xe7\x97\x9f\x6a\xfc\x70\x97\x6b\x0d\x3c\x5e\xd7\xc3\x8c\x25\x13\xd0\x08\x80\x49\x4b\xfa\x4c\x10\x1a\x40\x1d\xa0\x69\xb7\xec\x09\x30\xa8\xe8\xaf\xd6\xea\xac\x07\x59\xd1\x8f\x9d\x53\xb7\x61\x99\x84\xb2\x5b\x5f\x96\xd9\xc1\x4f\x86\x70\xe3\xf4\x0f\x26\xd5\x06\x08\x37\xd1\xae\x80\x46\xdc\x61\x28\x27\x21\x54\xca\x65\x30\x9c\xe3\xa6\xb3\x9a\x5f\xbb\xf7\x6a\x15\xfd\xf9\x73\xbb\x33\x92\xa7\x6f\xaa\xcf\x05\x8d\x08\x06\x9a\x0c\x8e\x25\xb8\xc7\x8e\x36\xa2\xac\xec\xeb\x71\x05\x93\x99\x46\x97\xf4\x3d\x30\xa7\x21\x56\xd3\x43\x36\x52\xde\xfd\x61\x34\x3f\x5e\x4c\xef\x2e\x81\x8e\x48\xb9\x74\x3a\xd4\x41\x75\x73\x9c\x86\x8f\x25\xf3\x20\xed\x84\x4a\xe3\x3b\xb8\x0f\xf2\x20\xd5\xbc\xd4\xff\x18\x0b\x67\x87\x38\xb0\xff\x5b\x91\xe4\x41\xa5\x15\x81\xfc\xf2\x1b\x0d\x96\x1d\xb9\x95\xfd\x95\xf2\x01\x8d\xb2\x70\xae\x4f\x7c\x4a\x19\x63\x6b\x17\x85\xad\xdb\x41\xb8\x25\x71\xe5\x54\xda\x12\x17\x3b\x7a\x93\xe8\x3d\xc1\x0f\x59\x43\xee\x2f\x35\xd2\xd2\xf3\xad\xc2\xcd\x36\xf2\x3c\x58\x57\x97\xc2\x41\x2f\x53\xaa\x9b\x4c\xcb\x22\xcb\x93\x6d\xfd\x82\x0b\x18\xa6\x1c\x64\xcf\x9d\xd9\xb4\x2f\x8c\xb6\x75\xe3\x5d\x10\xb6\x6f\xbe\x6b\xc0\x50\x9e\x55\x14\x1e\x7c\x17\x5e\xfe\xac\xe1\x71\x66\x51\x78\xd2\xe4\x73\xa4\xfc\xfd\x46\xe0\x73\xcb\xfa\xa7\x1b\x6f\xbc\x79\x65\x66\x31\xfe\x94\xdf\x07\xd1\xe0\x54\xa9\xc2\x99\x9d\xbe\x7a\x95\xe2\xf5\x32\x0a\xb2\x6c\x08\xb1\xb1\x7f\x63\x12\x64\xa3\x54\xe9\xca\x06\xfe\x1a\xa6\x9d\x8f\x7c\xd3\x97\x2b\xa3\x8e\x3c\x9c\xe1\xb5\x4b\x66\x1c\xb9\x68\xb5\x3a\x33\xeb\xd0\x39\x4a\x9d\xae\x8c\xba\x32\x71\x56\x9b\xe1\x6d\x7f\x47\x76\x86\xda\x8e\xcc\x81\xb7\xbc\xdd\xf8\x02\x15\xbb\xb3\xe4\x6f\x52\x76\xe6\x28\xde\xc0\xec\xcb\x50\x7e\x75\xb3\x37\x73\x09\xc3\x51\x90\xfa\xed\x31\x37\xa6\x35\xbd\x2b\x03\xe1\x63\xf2\xa8\xc2\x95\x8f\x5e\xcd\x91\x9d\xfc\xb6\x87\x1b\x27\xb9\x46\x17\x26\xf0\x47\xb6\xcd\x2c\xe4\xb7\x3d\x9c\x18\x88\x97\x16\x3a\xf0\x10\x55\xba\xb0\xa9\x0f\xea\x77\x60\x54\x57\x72\x64\xa5\x1d\x14\x76\x63\xa5\x55\xea\xc0\x8a\x9f\x0e\x75\x67\xc3\x2b\xb8\xb3\x70\xd4\x96\x74\x9e\xaf\x0d\xb4\x71\xc8\xcb\x8d\x43\xa3\x9a\x23\x3b\xcb\x31\x0b\x37\xc6\x16\x80\xde\x22\xf4\x66\xed\xaa\x64\xf7\xe9\xb5\xc3\x24\xaa\xe6\xd5\xdd\xe0\xd5\x3a\xae\x8c\x78\x42\xd4\x91\x05\xa7\x76\x05\xd7\x52\x6d\x8e\x4c\xb4\x5a\x8e\xcc\xe0\xfc\x83\x1b\x4b\xb8\x6e\x07\xc6\x1d\xf8\xb8\xc2\x2a\xbe\x3c\x8b\x2d\x2e\xae\xae\xe4\x58\x4a\x75\xf6\x77\xf4\x34\xe5\xfb\xd9\xf8\x97\x8b\xd9\xaf\xe8\x67\xef\x57\x34\x20\xe1\x3e\x70\xe5\xcb\xdb\xd9\x89\xcd\xdb\xc9\xf8\x4f\xb7\x1e\x1a\xc8\x05\x56\x47\x9c\xf9\xa1\x10\x23\x85\xa0\xbe\xda\x8d\x3a\xfb\xa5\xc3\x91\xe2\x7b\xbf\x88\xc9\x6f\x05\xae\xb8\xaa\x54\xe2\xc3\x5f\xfc\x8f\xfb\x21\xed\x5a\x6f\x36\xa3\x5a\xdc\x83\x48\xce\x3a\xad\xaa\x51\xd9\xed\xc0\x8c\xa2\x3b\xb2\x15\xb4\x8b\xa4\x5a\x20\x02\xc2\x6a\x34\xca\xad\xbc\x3e\x7d\xa1\x9f\x1a\x42\xa3\x67\x24\xa2\x11\xa2\x54\x23\x74\x87\x1f\xf7\x27\x45\x8f\x36\x1a\xbb\x59\xa1\x38\x20\xb2\xc9\x7a\xa9\xbd\x56\x3f\xca\xdb\x43\xed\x0c\xed\xcc\xfa\x28\xa7\x0d\x91\x8d\xab\x6a\x4e\x24\xa1\xde\xdf\xd5\xb8\x93\x68\x3a\x85\x7e\x20\x7f\x53\xa0\xe7\xdc\x3e\xe5\xae\x38\x88\x85\x42\x00\xe0\xf2\x76\xb5\x05\x59\x22\x1e\x04\x59\x34\xc9\x3a\x34\xc0\x72\xd9\x58\x0b\xb3\x06\x3d\xc0\x95\xcd\xfb\x8e\x11\x64\x0b\x3b\x11\x23\xf6\x69\x1a\x10\x5f\x3a\x72\x93\x23\x52\x67\xce\x75\x90\x09\x71\x91\x42\x50\x77\x44\x3d\x9c\x04\x81\x75\x22\x77\x89\xe5\xdd\x63\x08\x5a\x2e\x07\x50\xf9\x56\x75\x6b\x44\x0b\x41\x2b\xf1\xab\xb3\xc0\x75\x48\x6a\xc4\x64\xa5\x07\x40\xac\xef\xd0\xe0\x62\xfb\xec\x53\x5f\xf2\x4c\x25\x3e\x0d\x2a\xbe\x7a\x46\x09\xcc\x4c\x45\xd4\x6b\xe4\x2b\x08\x3a\x36\xa6\x0e\x72\x8d\xc8\x52\x1c\xec\x8c\x4d\xd3\xe5\x20\x22\x2b\x00\x64\xa4\xcf\x5d\x82\x6a\x08\x53\x0f\xa1\x9d\xa5\x94\x82\x62\x13\xae\x88\x99\xf9\xb9\x2b\xbe\x25\xa8\xb8\x9d\x65\xd1\x48\x6c\x17\x3e\x95\x53\x17\xe9\x4d\x60\x1d\x50\x1a\xa1\x35\x04\xd9\x8c\xbf\x0f\x8d\x5f\xbd\xa8\xa7\xa8\xba\x7a\x0a\xb3\xd2\xde\x0e\x03\x39\x69\x34\x1d\x1a\x02\xbd\xb8\x65\x63\xa1\x10\xba\xf3\xb1\xa4\x1c\x20\x6e\x16\xf2\xa7\xf0\x74\xe4\xd5\xc1\x10\x4c\x0b\x6a\xdd\xd3\x9a\x3b\xc7\xa6\x84\x96\xf0\xc9\x0a\xea\x2c\x9a\x9a\xa7\x80\xf0\xb4\x4c\x86\x3b\xb2\x7e\xd8\x10\x04\xd7\x89\x00\xa5\xf2\xd3\x89\xd6\x8c\x09\x88\x2d\xf2\x23\xee\x22\x6b\xc9\x0e\x10\x55\x4f\x88\x38\xa3\x4b\x07\x99\x20\x60\xa9\xd8\x8f\x13\x3f\x2c\x76\x11\x9d\x3b\xa8\xca\xf9\x8b\x25\x95\x79\xf0\xe9\x76\x54\x9e\x75\x7a\x2a\xb3\xd2\xf6\xfc\xea\x00\x13\xab\x45\xbf\x82\x77\x7d\x7b\xe5\xa1\xdb\x9b\xf1\xe4\x1d\x5a\xe4\x29\xc6\x68\x50\x16\xb1\xa3\x2d\xd5\x86\xf0\x9b\xe1\x90\x7f\xa5\x6f\x30\x90\x0f\x41\xc9\xa7\x6b\x2d\x22\xea\x79\x1e\xb3\xb4\x3a\x65\x47\xcd\x4b\x87\x5c\x8c\x3c\x24\x1a\xaa\xf5\xad\xcf\x0f\xab\x68\xc3\xb3\xd2\x7f\x4d\x3f\x92\x8f\xbd\x18\x85\x30\xb6\xce\xdc\x18\xbe\x51\x3e\x9e\x5c\x79\x1f\xb5\x6c\x10\xfd\x8e\x70\xb1\x43\xd3\x89\xfa\x9c\x77\xd9\x26\xc8\x36\x5a\x62\x48\xf4\x93\x74\x6c\x44\x9c\x74\xd6\x39\xc9\xf1\x70\xe6\xeb\x89\x1e\xca\x53\xa1\x50\xcd\x44\x27\x6f\x41\x97\x3f\xf0\x66\x07\x96\x28\x5b\x30\xa5\xef\x67\xd8\x21\x6b\x42\x23\xa2\xfe\x25\xa4\x35\xa1\x8d\xfa\x24\xcb\x2a\x40\xd7\x24\x56\x93\x2f\x99\x05\xb5\xf1\x09\x23\x00\x90\x4b\xa9\x93\x3a\xa1\x72\x33\x75\x82\xe5\xb4\x4d\xdc\xaa\x2d\x54\x75\x7e\xf9\x99\x94\xe9\x44\x7b\xae\xa2\x32\xa2\x36\x24\xbd\xd3\x2d\x78\xd6\x5e\x57\x51\xb5\x6e\xb7\x80\x82\xfd\xce\x07\x38\x87\x36\xa5\x3f\xf8\x6c\x30\x9d\x18\x49\x54\x56\xd4\x43\x16\xd3\x82\x22\x3c\x94\x9a\xd0\xce\x8d\x73\x3b\x03\x28\x55\x1e\x6a\x35\x27\x4e\xfc\x3e\x79\x3f\x48\xd3\xe0\x91\x1f\x1f\x72\xe7\x27\x55\x1a\x70\xa4\x11\x7a\x39\x74\xe2\x5c\xdd\x58\xed\xcc\xae\xaa\x61\xc3\x6f\x66\x5c\xd8\x84\x08\x30\x69\x50\x6a\xdc\x44\x5a\xc6\x9d\x93\xe8\x2f\x5b\xd3\x5a\xb8\x72\x88\x11\x72\x6a\xac\xc8\xc0\xb4\xda\x0b\x27\xec\x6b\x2e\x82\x8f\x3b\xb6\x1b\x9e\x9c\x39\x12\x7b\x71\xbb\x20\xdf\x98\xb9\xc8\x55\x54\x8e\xbc\xfe\x08\x51\x80\x06\x73\x29\xa1\x44\xa7\x51\x0a\x5e\x3f\x52\x81\x44\x7c\x65\xaa\x5f\x9f\x6c\xb4\x80\x48\x57\x53\xdb\xb0\xb8\xee\x6d\x48\xa6\xee\x31\xdf\xf8\x49\xd1\xe4\x52\x15\x4f\xce\xbd\x28\xd5\x4c\x53\xa0\xc2\x88\xd6\xe6\x3f\xb4\x6b\x46\xeb\x09\xd1\x8d\xb7\xc8\xfb\x98\x64\x90\x98\x97\x59\xaa\x62\x17\x25\x01\xbf\x7f\x4d\x70\x61\x25\x2a\x0f\x89\xcc\x86\x46\x6f\xb4\x5f\x44\xd8\xaf\x45\xd2\xb5\x07\x60\x37\xe5\x17\x6e\xaf\x80\x0b\x72\x3f\x27\x3b\x9b\x26\xeb\x44\x55\xf9\x87\xb4\x92\x28\xe5\x2a\xe7\xf2\x9e\xcf\x11\xbb\x81\x72\x24\xde\xd5\x82\xdb\x27\xe7\xab\x4a\xac\x0a\xbb\x2e\xea\x83\xae\xe7\x99\x6a\x87\x45\x2b\x51\xc1\xb9\xb3\xd2\xc1\xef\xd4\x19\x35\xef\x4a\x6c\xe3\xd9\xa8\xe1\xc6\x5e\xe9\x2d\x5d\x8a\xf2\xd5\xff\x55\x44\xd6\x9b\xdc\xb1\xd1\x23\x64\x16\xc4\x7e\xa1\x60\xe3\xd2\xc0\xb2\x00\x90\xde\x22\x7e\x3d\xd7\xd1\x1f\xaa\x80\xb4\xc4\x4d\x29\x25\x9c\x2d\xcf\x02\x94\x95\x76\x61\xa9\xa4\x4a\x03\x22\x8c\xe4\x2b\x5b\x46\xf2\x9b\x24\x57\xde\xcd\x65\x8f\x1e\xb5\x88\x53\xdd\x18\xb3\x47\xa9\xc5\x38\x12\x82\x6a\xcf\x95\xe0\xd8\x59\xe2\xcc\xaf\x6f\x92\x10\xbd\x0b\x50\xa9\x82\x0e\x2e\xa7\x17\xd7\xde\xcd\xa5\xd7\xbc\xa5\x62\x84\xbe\x1c\x0e\x47\x08\xa2\x10\x36\xcc\x08\xdc\xed\xa5\xb5\x01\xec\x6e\x16\x17\xb1\x29\xa1\x19\x58\xde\x0c\xe5\xbb\x39\x7c\x2e\x15\xee\x43\xd3\x49\x68\x54\xa0\x2e\xf9\x08\x35\x1e\xeb\xbb\xa8\xa2\xe1\x86\x6f\xc2\xc9\x3d\x59\x0e\xd4\x06\x1d\x09\x6d\x54\x5a\xa9\xd4\x68\xa9\xb5\xd5\x55\x04\x52\xec\x29\x35\x91\x85\x9d\xd5\x55\x04\x20\x02\xed\x44\x3f\x4f\xd7\x5b\xa8\x6a\x94\x3c\xe0\x54\xbb\xa9\x85\x72\xa2\xf4\x7e\xb2\x6b\x06\xb1\xca\x4d\x06\x16\x99\xc4\x4d\x06\x60\xfd\x22\x25\xe6\xee\x2a\x52\x62\xea\x7f\xe5\x56\x01\xf6\xba\x47\x75\xfe\x61\x3a\x51\x4b\x55\x4c\x46\x6a\x42\x35\x24\x99\xf8\x1f\x54\x48\x98\x42\x65\x21\xdb\xc6\x48\x7a\xd5\x6e\x54\xbf\x64\x36\x92\xde\xb1\xeb\x31\xac\xa8\x14\x99\x0f\xbd\x96\xc2\x45\xd4\x24\x02\x28\x7b\x73\xad\x5f\x07\x01\x59\x89\x62\x37\xfc\xf9\x6c\xfc\xee\x9d\x37\x43\x79\x4a\xd6\x7e\xb9\xba\xd5\x89\xa6\xca\x5d\xf1\x69\x4c\x28\xe2\xf8\x0c\x5d\xbc\xa5\x69\xb5\x2b\xef\xda\x9b\x7b\x6a\x62\xe3\xed\x74\x86\xbc\x8b\xcb\x9f\xd0\x6c\xfa\x01\x79\x1f\xbd\xcb\xdb\xb9\x87\xde\xcf\xa6\x97\xde\xd5\xed\xcc\x43\x8e\x0c\x06\x6d\x02\x0a\x4a\x0d\xa0\x12\xa3\x21\x60\x05\xed\x2a\x61\x0b\x07\xa3\x84\xec\xfd\x9d\x40\x6e\x23\x3d\xde\xe4\x93\x3a\xd7\x82\x7e\xf4\xde\x4e\x67\xd4\xe0\x6f\xbc\xd9\x9c\xde\x85\x70\xfb\xfe\xea\xa2\xa3\x22\xdb\xd9\x0c\xc0\xfc\xa7\x92\xa3\x84\xf2\xa0\x0a\x81\xaf\x5d\xfa\xe6\xaf\x68\xb2\x97\x4a\x3f\x7e\x37\x29\xf3\xa3\x1a\xc5\x10\xcd\xe8\x7e\x09\x7d\x9b\xe8\xa6\x34\x4e\x1e\x89\x3e\x55\x92\xac\xe0\x49\x60\xa3\x24\x1a\x85\xbb\x24\x95\x0a\x8d\x27\x98\x64\x02\x5d\xe3\x90\x28\x3a\x89\x22\x8b\x52\xc8\x84\xa2\x16\xca\x6d\xf5\xf2\xe2\xe6\xf2\xe2\xca\xdb\xef\xa1\x3d\x11\xd9\x37\x05\xe5\x25\x8a\x7c\xf4\x19\x93\xca\x4d\x06\x2b\xff\x2a\x33\x02\x72\xaf\xca\xe0\xbe\xea\xad\x96\x16\x91\xf4\xde\x01\x24\xd3\x49\x14\x01\x95\xc2\x6c\x7f\xaa\xaa\xf3\xc0\x7c\xb3\xbf\x29\x97\x4e\xa2\xc8\x45\x63\x8c\x43\xea\xad\x66\xce\x87\x98\x55\x40\xeb\x30\xec\x2e\x62\x9f\xe3\x91\xf5\x03\x50\x56\xb9\x58\x11\xb3\x2a\x78\xaa\xac\xc6\xe1\xa9\x50\xd4\x33\x0a\x2c\x66\x5d\xaa\x4a\xc9\x9f\x3b\x59\x60\x6f\x91\xca\x93\x98\x36\xb1\xf8\x59\x4d\x48\x34\x86\x75\x10\xf1\xaa\x9f\xb0\x6c\x8e\x3d\xeb\x28\x95\x5d\xa2\xde\xf3\x6b\x57\xbb\xea\x78\x8e\xb3\x9f\x54\x20\xe3\xe6\xc6\x00\xc8\xbe\x49\xa6\x27\xc6\x8d\x6b\x80\xeb\x6c\x31\xf3\x6e\xe6\xb3\xf1\xe5\x7c\xcf\x62\xd6\x1b\x06\x4d\x09\xeb\x8d\x00\x59\xb8\x66\x66\x1c\xee\xd3\x16\x41\x1b\xbb\x11\x2d\x12\x37\xe8\xff\x93\x45\x37\x5b\xc5\x9e\xcd\x81\x37\xb3\x45\x40\x4e\xf5\x5c\x36\xdb\x4d\x48\x79\xe7\x86\xf5\xbd\x2a\xa2\x5a\xac\x88\x68\x07\xeb\x65\x0a\x00\x4e\x8f\x66\xd4\x6d\xd1\x15\x6e\x9b\xb0\x9e\x49\xd8\xe3\x0e\x3b\x65\x63\x02\x92\x56\x21\xf0\xf9\x17\xae\x00\xd1\x78\x89\x22\x58\xbd\xd9\xd2\x51\xa0\x2a\x34\x06\x8d\x56\x23\x39\x82\x50\x2d\x87\x7e\x8d\x5e\x75\x8b\x2f\xcd\xc3\x73\xce\x78\x2f\xa2\x98\xe7\x96\xd6\x49\xa5\x8f\x34\xda\x4e\x09\x24\x97\x46\xd2\x4b\x59\x7b\x62\xdd\xd8\xb2\x01\xa4\x00\xb6\x75\x20\x85\xed\x49\x22\xf5\x83\x62\x80\x38\x2a\x41\x37\x59\x1c\x8f\x65\x4b\x23\x89\x06\x7a\xce\x9d\xe2\xb8\x10\x75\x90\xc4\xac\x06\x6b\xfb\x1d\x05\xe9\x77\x88\x1a\x38\xf2\x0c\x8b\x68\xa0\x54\x44\x86\x68\x8c\x5d\x08\x12\xdb\x84\x56\x08\xfb\x2a\x13\x92\xc4\xb2\x6d\x06\xc9\x63\x21\x3f\x64\xde\x6f\x8f\x52\x02\x65\xa0\xac\x20\x9d\x22\x71\x93\xe2\x49\xf3\x2d\x00\xe7\xd6\x36\x79\x73\xd1\x38\x03\xeb\x24\xe0\xb0\x3f\x98\x58\x66\x93\x68\xd0\xb8\x1b\x45\xe7\x03\xf0\xfb\x5a\x9e\x3a\x33\x36\x37\xbf\x7b\xa3\x5d\x8e\xcd\x1f\x33\x18\x71\x12\xe8\xc0\xeb\x90\x93\x0c\x66\x6d\xec\x45\x0d\x7d\x5e\x40\xe8\x29\xd2\x53\xdf\x1a\xd8\x37\x57\x7d\x5f\xd5\x2c\x80\x4e\xf9\x04\x59\x2e\xa7\xbf\xfc\x32\x9e\xbf\x3e\xf9\xdf\x01\x00\x3a\xd4\x4a\x9e\xb5\xf4\x00\x00")
 
 func _1528395617_squashed_migrationsUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -214,7 +224,7 @@ func _1528395617_squashed_migrationsUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395618_add_delete_cascade_to_changeset_jobsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\xce\xbd\x8a\x83\x40\x14\xc5\xf1\x7e\x9e\xe2\x94\x2e\xec\x1b\x58\x8d\xce\x55\x2e\x3b\x1f\xcb\x78\x1b\xab\xc1\x18\x63\x4c\x88\x09\x98\x26\x6f\x1f\x22\x08\x21\x5d\xea\xdf\x39\xf0\x2f\xa8\x66\x9f\x2b\xa5\xad\x50\x84\xe8\xc2\x12\xfa\x63\x37\x8f\xc3\x32\xdc\xd3\xe9\xba\x5b\x60\x62\xf8\x47\x19\x7c\x23\x51\xb3\x97\x0f\x4e\x7d\x77\xb9\x75\xd3\x38\xa7\x69\x9f\x0e\xe7\xe1\xf1\xab\x00\x6d\xcc\x17\x0f\x05\x00\x55\x88\xc4\xb5\xc7\x1f\xb5\xc8\xde\x16\x3f\xab\x46\xaa\x28\x92\x2f\xa9\xc1\x66\x4b\xb6\xa1\x79\x61\x5c\xd3\xd9\xb3\xb0\xb6\xb6\x05\x3b\x47\x86\xb5\x50\xae\x54\x19\x9c\x63\xc9\xd5\x33\x00\x00\xff\xff\xa5\x7f\x5f\x26\xed\x00\x00\x00")
+var __1528395618_add_delete_cascade_to_changeset_jobsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\xce\xbd\x8a\x83\x40\x14\xc5\xf1\x7e\x9e\xe2\x94\x2e\xec\x1b\x58\x8d\xce\x55\x2e\x3b\x1f\xcb\x78\x1b\xab\xc1\x18\x63\x4c\x88\x09\x98\x26\x6f\x1f\x22\x08\x21\x5d\xea\xdf\x39\xf0\x2f\xa8\x66\x9f\x2b\xa5\xad\x50\x84\xe8\xc2\x12\xfa\x63\x37\x8f\xc3\x32\xdc\xd3\xe9\xba\x5b\x60\x62\xf8\x47\x19\x7c\x23\x51\xb3\x97\x0f\x4e\x7d\x77\xb9\x75\xd3\x38\xa7\x69\x9f\x0e\xe7\xe1\xf1\xab\x00\x6d\xcc\x17\x0f\x05\x00\x55\x88\xc4\xb5\xc7\x1f\xb5\xc8\xde\x16\x3f\xab\x46\xaa\x28\x92\x2f\xa9\xc1\x66\x4b\xb6\xa1\x79\x61\x5c\xd3\xd9\xb3\xb0\xb6\xb6\x05\x3b\x47\x86\xb5\x50\xae\x54\x19\x9c\x63\xc9\xd5\x73\x00\xa5\x7f\x5f\x26\xed\x00\x00\x00")
 
 func _1528395618_add_delete_cascade_to_changeset_jobsDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -234,7 +244,7 @@ func _1528395618_add_delete_cascade_to_changeset_jobsDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395618_add_delete_cascade_to_changeset_jobsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\xcf\xbd\x4e\x84\x40\x14\xc5\xf1\x7e\x9e\xe2\x94\x98\xf8\x06\x54\x97\x99\x0b\xb9\x71\x3e\xcc\x70\x1b\xaa\x09\x22\x22\x1a\xd1\x04\x1b\xdf\xde\x2c\x09\xc9\x66\xbb\xad\x7f\xff\x53\x9c\x86\x3b\x89\xb5\x31\xe4\x95\x33\x94\x1a\xcf\x98\xde\xc7\x6d\x99\xf7\xf9\xb7\x7c\x7c\xbf\xec\x70\x39\x3d\xc3\xa6\xd8\x6b\x26\x89\x7a\xc3\x65\x1a\xbf\x7e\xc6\x75\xd9\xca\xfa\x5a\xde\x3e\xe7\xbf\x47\x03\x90\x73\x77\x2c\x0c\x00\xb4\x29\xb3\x74\x11\x4f\x3c\xa0\xba\x2a\x1e\x0e\xcd\xdc\x72\xe6\x68\xb9\xc7\x69\x7b\x75\x62\x8a\x70\xec\x59\x19\x96\x7a\x4b\x8e\xe1\x2e\x79\x3e\xce\x48\x14\x15\xf2\x7e\x80\x84\xc0\x4e\x48\xb9\x36\xc6\xa6\x10\x44\x6b\xf3\x1f\x00\x00\xff\xff\x70\x06\xad\x71\xff\x00\x00\x00")
+var __1528395618_add_delete_cascade_to_changeset_jobsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\xcf\xbd\x4e\x84\x40\x14\xc5\xf1\x7e\x9e\xe2\x94\x98\xf8\x06\x54\x97\x99\x0b\xb9\x71\x3e\xcc\x70\x1b\xaa\x09\x22\x22\x1a\xd1\x04\x1b\xdf\xde\x2c\x09\xc9\x66\xbb\xad\x7f\xff\x53\x9c\x86\x3b\x89\xb5\x31\xe4\x95\x33\x94\x1a\xcf\x98\xde\xc7\x6d\x99\xf7\xf9\xb7\x7c\x7c\xbf\xec\x70\x39\x3d\xc3\xa6\xd8\x6b\x26\x89\x7a\xc3\x65\x1a\xbf\x7e\xc6\x75\xd9\xca\xfa\x5a\xde\x3e\xe7\xbf\x47\x03\x90\x73\x77\x2c\x0c\x00\xb4\x29\xb3\x74\x11\x4f\x3c\xa0\xba\x2a\x1e\x0e\xcd\xdc\x72\xe6\x68\xb9\xc7\x69\x7b\x75\x62\x8a\x70\xec\x59\x19\x96\x7a\x4b\x8e\xe1\x2e\x79\x3e\xce\x48\x14\x15\xf2\x7e\x80\x84\xc0\x4e\x48\xb9\x36\xc6\xa6\x10\x44\x6b\xf3\x3f\x00\x70\x06\xad\x71\xff\x00\x00\x00")
 
 func _1528395618_add_delete_cascade_to_changeset_jobsUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -254,7 +264,7 @@ func _1528395618_add_delete_cascade_to_changeset_jobsUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395619_remove_unused_indexesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x14\xca\xc1\x0d\xc2\x30\x0c\x05\xd0\x3b\x53\xfc\x01\x08\xeb\x70\x36\xf1\x17\x58\x34\xb6\x95\x38\x52\xc7\xaf\x7a\x7f\xad\xe1\x4d\xe8\x8c\x4c\x2a\x74\xe7\x61\x5d\x8a\x30\x57\x9e\x5c\x30\x47\xfd\x88\x9d\x18\xf6\x9d\x52\x16\xfe\xc4\x0a\x78\xc0\x49\x45\x05\x44\xf5\x36\x03\x1f\xe9\xff\xd7\xe3\x0a\x00\x00\xff\xff\x8c\x82\x0a\x03\x52\x00\x00\x00")
+var __1528395619_remove_unused_indexesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x52\x00\xad\xff\x2d\x2d\x20\x57\x65\x20\x64\x72\x6f\x70\x70\x65\x64\x20\x64\x75\x70\x6c\x69\x63\x61\x74\x65\x20\x69\x6e\x64\x65\x78\x65\x73\x20\x69\x6e\x20\x74\x68\x65\x20\x75\x70\x20\x6d\x69\x67\x72\x61\x74\x69\x6f\x6e\x2c\x20\x73\x6f\x20\x6e\x6f\x20\x6e\x65\x65\x64\x20\x74\x6f\x20\x61\x64\x64\x20\x74\x68\x65\x6d\x20\x62\x61\x63\x6b\x2e\x0a\x03\x00\x8c\x82\x0a\x03\x52\x00\x00\x00")
 
 func _1528395619_remove_unused_indexesDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -274,7 +284,7 @@ func _1528395619_remove_unused_indexesDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395619_remove_unused_indexesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x09\xf2\x0f\x50\xf0\xf4\x73\x71\x8d\x50\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\xcf\xca\x4f\x2a\x8e\x87\xf3\x0a\x72\x12\xf3\xe2\x33\x53\xac\xb1\x6b\x4a\xc9\x2c\x4e\x2e\x2d\x2e\xce\xcc\xcf\x8b\xcf\x4d\xcc\xcc\x89\x2f\x4a\x2d\xc8\xa9\x8c\x2f\xc9\xcf\x4e\xcd\x2b\x86\x50\xf1\x99\x29\x15\x84\x35\x97\x64\x14\xa5\x26\xa6\x14\xc7\x67\xa6\x40\xd4\x73\x39\xfb\xfb\xfa\x7a\x86\x58\x73\x01\x02\x00\x00\xff\xff\xf9\x60\xf6\x03\xb3\x00\x00\x00")
+var __1528395619_remove_unused_indexesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x09\xf2\x0f\x50\xf0\xf4\x73\x71\x8d\x50\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\xcf\xca\x4f\x2a\x8e\x87\xf3\x0a\x72\x12\xf3\xe2\x33\x53\xac\xb1\x6b\x4a\xc9\x2c\x4e\x2e\x2d\x2e\xce\xcc\xcf\x8b\xcf\x4d\xcc\xcc\x89\x2f\x4a\x2d\xc8\xa9\x8c\x2f\xc9\xcf\x4e\xcd\x2b\x86\x50\xf1\x99\x29\x15\x84\x35\x97\x64\x14\xa5\x26\xa6\x14\xc7\x67\xa6\x40\xd4\x73\x39\xfb\xfb\xfa\x7a\x86\x58\x73\x01\x06\x00\xf9\x60\xf6\x03\xb3\x00\x00\x00")
 
 func _1528395619_remove_unused_indexesUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -294,7 +304,7 @@ func _1528395619_remove_unused_indexesUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395620_add_description_to_campaign_jobsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\xcf\xca\x4f\x2a\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\x48\x49\x2d\x4e\x2e\xca\x2c\x28\xc9\xcc\xcf\xb3\xe6\xe2\x72\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x04\x00\x00\xff\xff\xe3\x89\x3a\xc0\x44\x00\x00\x00")
+var __1528395620_add_description_to_campaign_jobsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x44\x00\xbb\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x63\x61\x6d\x70\x61\x69\x67\x6e\x5f\x6a\x6f\x62\x73\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x64\x65\x73\x63\x72\x69\x70\x74\x69\x6f\x6e\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xe3\x89\x3a\xc0\x44\x00\x00\x00")
 
 func _1528395620_add_description_to_campaign_jobsDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -314,7 +324,7 @@ func _1528395620_add_description_to_campaign_jobsDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395620_add_description_to_campaign_jobsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\xcf\xca\x4f\x2a\x56\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\x48\x49\x2d\x4e\x2e\xca\x2c\x28\xc9\xcc\xcf\x53\x28\x49\xad\x28\xb1\xe6\xe2\x72\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x04\x00\x00\xff\xff\x2f\x85\x9b\x51\x48\x00\x00\x00")
+var __1528395620_add_description_to_campaign_jobsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x48\x00\xb7\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x63\x61\x6d\x70\x61\x69\x67\x6e\x5f\x6a\x6f\x62\x73\x20\x41\x44\x44\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x64\x65\x73\x63\x72\x69\x70\x74\x69\x6f\x6e\x20\x74\x65\x78\x74\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x2f\x85\x9b\x51\x48\x00\x00\x00")
 
 func _1528395620_add_description_to_campaign_jobsUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -334,7 +344,7 @@ func _1528395620_add_description_to_campaign_jobsUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395621_add_delete_cascade_to_campaign_jobsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\xce\xbd\x8e\x82\x40\x14\xc5\xf1\x7e\x9e\xe2\x94\x6c\xb2\x6f\x40\x35\x30\x17\x72\xb3\xf3\xb1\xb9\xdc\x86\x6a\xc2\xae\x68\xd0\x28\x44\x2b\xdf\xde\x40\xb4\xb0\xb3\xfd\xff\x4e\x71\x2a\x6a\x39\x96\xc6\x58\xaf\x24\x50\x5b\x79\xc2\xff\x70\x5e\x86\xe9\x70\xc9\xc7\xf9\xef\x06\x27\xe9\x17\x75\x8a\x9d\x8a\xe5\xa8\xef\x9a\xaf\xe3\x32\xe7\x69\x97\xf7\xa7\xf1\xfe\x6d\x00\xeb\xdc\x67\x63\x03\x00\x4d\x12\xe2\x36\xe2\x87\x7a\x14\x4f\xfd\xda\x44\xa8\x21\xa1\x58\x53\x87\xb5\x17\xaf\xee\xd6\x2e\xdb\x4d\x8e\xac\x6c\xbd\xef\xc1\x21\x90\x63\xab\x54\x1a\x53\xa7\x10\x58\x4b\xf3\x08\x00\x00\xff\xff\xf2\x14\xae\xbd\xd9\x00\x00\x00")
+var __1528395621_add_delete_cascade_to_campaign_jobsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\xce\xbd\x8e\x82\x40\x14\xc5\xf1\x7e\x9e\xe2\x94\x6c\xb2\x6f\x40\x35\x30\x17\x72\xb3\xf3\xb1\xb9\xdc\x86\x6a\xc2\xae\x68\xd0\x28\x44\x2b\xdf\xde\x40\xb4\xb0\xb3\xfd\xff\x4e\x71\x2a\x6a\x39\x96\xc6\x58\xaf\x24\x50\x5b\x79\xc2\xff\x70\x5e\x86\xe9\x70\xc9\xc7\xf9\xef\x06\x27\xe9\x17\x75\x8a\x9d\x8a\xe5\xa8\xef\x9a\xaf\xe3\x32\xe7\x69\x97\xf7\xa7\xf1\xfe\x6d\x00\xeb\xdc\x67\x63\x03\x00\x4d\x12\xe2\x36\xe2\x87\x7a\x14\x4f\xfd\xda\x44\xa8\x21\xa1\x58\x53\x87\xb5\x17\xaf\xee\xd6\x2e\xdb\x4d\x8e\xac\x6c\xbd\xef\xc1\x21\x90\x63\xab\x54\x1a\x53\xa7\x10\x58\x4b\xf3\x18\x00\xf2\x14\xae\xbd\xd9\x00\x00\x00")
 
 func _1528395621_add_delete_cascade_to_campaign_jobsDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -354,7 +364,7 @@ func _1528395621_add_delete_cascade_to_campaign_jobsDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395621_add_delete_cascade_to_campaign_jobsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\xce\x3d\x6e\x83\x40\x10\xc5\xf1\x7e\x4f\xf1\x4a\x22\xe5\x06\x54\xc3\xee\x80\x46\xd9\x8f\x68\x99\x86\x6a\x45\x12\x12\x11\xcb\x06\xd9\x95\x6f\x6f\x81\xec\xc2\x9d\xdb\xf7\xfb\x17\xaf\xe1\x4e\x62\x6d\x0c\x79\xe5\x0c\xa5\xc6\x33\xbe\xc7\xe3\x3a\xce\x7f\xa7\xf2\xbf\x7c\x5d\xe0\x72\xfa\x84\x4d\xb1\xd7\x4c\x12\xf5\x59\xcb\x79\x5a\x97\x32\xff\x94\xdf\xc3\x74\x7d\x37\x00\x39\xf7\x5a\x6c\x00\xa0\x4d\x99\xa5\x8b\xf8\xe0\x01\xd5\x5d\xdf\x76\xc9\xdc\x72\xe6\x68\xb9\xc7\xb6\x57\x8f\x3d\x45\x38\xf6\xac\x0c\x4b\xbd\x25\xc7\x70\x5b\x99\xf7\xe3\x12\x45\x85\xbc\x1f\x20\x21\xb0\x13\x52\xae\x8d\xb1\x29\x04\xd1\xda\xdc\x02\x00\x00\xff\xff\x0e\x65\x20\x3b\xeb\x00\x00\x00")
+var __1528395621_add_delete_cascade_to_campaign_jobsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\xce\x3d\x6e\x83\x40\x10\xc5\xf1\x7e\x4f\xf1\x4a\x22\xe5\x06\x54\xc3\xee\x80\x46\xd9\x8f\x68\x99\x86\x6a\x45\x12\x12\x11\xcb\x06\xd9\x95\x6f\x6f\x81\xec\xc2\x9d\xdb\xf7\xfb\x17\xaf\xe1\x4e\x62\x6d\x0c\x79\xe5\x0c\xa5\xc6\x33\xbe\xc7\xe3\x3a\xce\x7f\xa7\xf2\xbf\x7c\x5d\xe0\x72\xfa\x84\x4d\xb1\xd7\x4c\x12\xf5\x59\xcb\x79\x5a\x97\x32\xff\x94\xdf\xc3\x74\x7d\x37\x00\x39\xf7\x5a\x6c\x00\xa0\x4d\x99\xa5\x8b\xf8\xe0\x01\xd5\x5d\xdf\x76\xc9\xdc\x72\xe6\x68\xb9\xc7\xb6\x57\x8f\x3d\x45\x38\xf6\xac\x0c\x4b\xbd\x25\xc7\x70\x5b\x99\xf7\xe3\x12\x45\x85\xbc\x1f\x20\x21\xb0\x13\x52\xae\x8d\xb1\x29\x04\xd1\xda\xdc\x06\x00\x0e\x65\x20\x3b\xeb\x00\x00\x00")
 
 func _1528395621_add_delete_cascade_to_campaign_jobsUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -374,7 +384,7 @@ func _1528395621_add_delete_cascade_to_campaign_jobsUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395622_add_more_delete_cascades_to_changeset_jobsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x8f\xbd\x8a\x83\x40\x14\x46\xfb\x79\x8a\x5b\xba\xb0\x6f\x60\x35\x3a\x57\xb9\xec\xfc\x2c\xe3\x6d\xac\x06\xd7\x35\xc6\x84\x98\x80\x69\xf2\xf6\x41\xc1\x20\x26\x45\x24\xe5\xc7\x39\x5f\x71\x12\xcc\xc9\xc6\x42\x48\xcd\xe8\x81\x65\xa2\x11\xea\x7d\xd5\xb7\xcd\xd0\x5c\xc3\xe1\xfc\x37\x80\xf2\xee\x17\x52\x67\x0b\xf6\x92\x2c\xaf\x70\xa8\xab\xd3\xa5\xea\xda\x7e\x5c\xa1\xfb\x0f\xbb\x63\x73\xfb\x16\x00\x52\xa9\x8d\x2f\x01\x00\x90\x39\x8f\x94\x5b\xf8\xc1\x12\xa2\x95\xf5\x35\x19\x1e\x33\xf4\x68\x53\x2c\x60\xc9\x87\x68\x16\xd4\x28\xf8\x29\x85\x2c\x31\x49\xad\x4b\x20\x63\x50\x91\x64\xfc\x2c\xf6\x31\xdf\x2f\x5d\x5f\x5e\x64\x2e\x94\xe7\xc6\x19\x6e\x08\x4c\x9d\x31\xc4\xb1\xb8\x07\x00\x00\xff\xff\x44\xca\x26\x70\xde\x01\x00\x00")
+var __1528395622_add_more_delete_cascades_to_changeset_jobsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x8f\xbd\x8a\x83\x40\x14\x46\xfb\x79\x8a\x5b\xba\xb0\x6f\x60\x35\x3a\x57\xb9\xec\xfc\x2c\xe3\x6d\xac\x06\xd7\x35\xc6\x84\x98\x80\x69\xf2\xf6\x41\xc1\x20\x26\x45\x24\xe5\xc7\x39\x5f\x71\x12\xcc\xc9\xc6\x42\x48\xcd\xe8\x81\x65\xa2\x11\xea\x7d\xd5\xb7\xcd\xd0\x5c\xc3\xe1\xfc\x37\x80\xf2\xee\x17\x52\x67\x0b\xf6\x92\x2c\xaf\x70\xa8\xab\xd3\xa5\xea\xda\x7e\x5c\xa1\xfb\x0f\xbb\x63\x73\xfb\x16\x00\x52\xa9\x8d\x2f\x01\x00\x90\x39\x8f\x94\x5b\xf8\xc1\x12\xa2\x95\xf5\x35\x19\x1e\x33\xf4\x68\x53\x2c\x60\xc9\x87\x68\x16\xd4\x28\xf8\x29\x85\x2c\x31\x49\xad\x4b\x20\x63\x50\x91\x64\xfc\x2c\xf6\x31\xdf\x2f\x5d\x5f\x5e\x64\x2e\x94\xe7\xc6\x19\x6e\x08\x4c\x9d\x31\xc4\xb1\xb8\x0f\x00\x44\xca\x26\x70\xde\x01\x00\x00")
 
 func _1528395622_add_more_delete_cascades_to_changeset_jobsDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -394,7 +404,7 @@ func _1528395622_add_more_delete_cascades_to_changeset_jobsDownSql() (*asset, er
 	return a, nil
 }
 
-var __1528395622_add_more_delete_cascades_to_changeset_jobsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x8f\xbd\x4a\xc4\x40\x14\x85\xfb\x79\x8a\x53\xae\xe0\x1b\x6c\x75\x77\xe6\xee\x72\x71\x7e\x64\x72\x9b\xad\x86\xb8\xc6\x18\xc5\x28\xc4\xc6\xb7\x97\x04\x22\x21\x5a\xb8\xa4\x3c\x9c\xef\x2b\xbe\x03\x9f\x24\xee\x8d\x21\xaf\x9c\xa1\x74\xf0\x8c\xcb\x73\xdd\xb7\xcd\xd0\x7c\x96\x97\xf7\x87\x01\x2e\xa7\x7b\xd8\x14\x2b\xcd\x24\x51\x57\x77\xb9\xd4\x6f\x1f\x75\xd7\xf6\xe3\x2a\xdd\x63\x79\x7a\x6d\xbe\x6e\x0d\x40\xce\x5d\x69\x19\x00\x38\xa6\xcc\x72\x8a\xb8\xe3\x33\x76\x2b\xea\x66\x22\x32\x1f\x39\x73\xb4\x5c\x61\xf9\x0f\xbb\x19\x48\x11\x8e\x3d\x2b\xc3\x52\x65\xc9\x31\xdc\xa8\xe4\x29\x4e\xa2\xa8\x90\xf7\x67\x48\x08\xec\x84\x94\xb7\xe5\xff\xcc\xff\xb7\xaf\x95\x3f\xc2\x17\xc8\xef\xea\xf9\xdc\x94\x6c\x53\x08\xa2\x7b\xf3\x1d\x00\x00\xff\xff\x0a\x39\x5d\x0f\x02\x02\x00\x00")
+var __1528395622_add_more_delete_cascades_to_changeset_jobsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x8f\xbd\x4a\xc4\x40\x14\x85\xfb\x79\x8a\x53\xae\xe0\x1b\x6c\x75\x77\xe6\xee\x72\x71\x7e\x64\x72\x9b\xad\x86\xb8\xc6\x18\xc5\x28\xc4\xc6\xb7\x97\x04\x22\x21\x5a\xb8\xa4\x3c\x9c\xef\x2b\xbe\x03\x9f\x24\xee\x8d\x21\xaf\x9c\xa1\x74\xf0\x8c\xcb\x73\xdd\xb7\xcd\xd0\x7c\x96\x97\xf7\x87\x01\x2e\xa7\x7b\xd8\x14\x2b\xcd\x24\x51\x57\x77\xb9\xd4\x6f\x1f\x75\xd7\xf6\xe3\x2a\xdd\x63\x79\x7a\x6d\xbe\x6e\x0d\x40\xce\x5d\x69\x19\x00\x38\xa6\xcc\x72\x8a\xb8\xe3\x33\x76\x2b\xea\x66\x22\x32\x1f\x39\x73\xb4\x5c\x61\xf9\x0f\xbb\x19\x48\x11\x8e\x3d\x2b\xc3\x52\x65\xc9\x31\xdc\xa8\xe4\x29\x4e\xa2\xa8\x90\xf7\x67\x48\x08\xec\x84\x94\xb7\xe5\xff\xcc\xff\xb7\xaf\x95\x3f\xc2\x17\xc8\xef\xea\xf9\xdc\x94\x6c\x53\x08\xa2\x7b\xf3\x3d\x00\x0a\x39\x5d\x0f\x02\x02\x00\x00")
 
 func _1528395622_add_more_delete_cascades_to_changeset_jobsUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -414,7 +424,7 @@ func _1528395622_add_more_delete_cascades_to_changeset_jobsUpSql() (*asset, erro
 	return a, nil
 }
 
-var __1528395623_add_canceled_at_to_campaign_planDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\x2f\xc8\x49\xcc\x2b\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\x48\x4e\xcc\x4b\x4e\xcd\x49\x4d\x89\x4f\x2c\xb1\xe6\xe2\x72\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x04\x00\x00\xff\xff\xb2\x7c\xc9\x8e\x45\x00\x00\x00")
+var __1528395623_add_canceled_at_to_campaign_planDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x45\x00\xba\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x63\x61\x6d\x70\x61\x69\x67\x6e\x5f\x70\x6c\x61\x6e\x73\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x63\x61\x6e\x63\x65\x6c\x65\x64\x5f\x61\x74\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xb2\x7c\xc9\x8e\x45\x00\x00\x00")
 
 func _1528395623_add_canceled_at_to_campaign_planDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -434,7 +444,7 @@ func _1528395623_add_canceled_at_to_campaign_planDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395623_add_canceled_at_to_campaign_planUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x04\xc0\x39\x0a\xc5\x20\x10\x00\xd0\x7e\x4e\x31\xf7\xb0\x72\xe3\x23\xb8\xc0\xc7\xd4\x32\x18\x09\x82\x8a\xe0\x54\x39\x7d\x9e\xb2\x3f\x17\x05\x80\xf4\xd9\xfe\x31\x4b\xe5\x2d\x56\x9a\x9b\xfa\xb3\xca\x1e\xb4\x0e\x4a\x63\x50\x27\x7f\x85\x88\x95\x56\x6d\xa3\xdd\x85\x18\xb9\xcf\x76\x98\xe6\xe6\x57\x00\xe8\x14\x82\xcb\x02\xbe\x00\x00\x00\xff\xff\x9f\x9f\xa5\xae\x50\x00\x00\x00")
+var __1528395623_add_canceled_at_to_campaign_planUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x50\x00\xaf\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x63\x61\x6d\x70\x61\x69\x67\x6e\x5f\x70\x6c\x61\x6e\x73\x20\x41\x44\x44\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x63\x61\x6e\x63\x65\x6c\x65\x64\x5f\x61\x74\x20\x74\x69\x6d\x65\x73\x74\x61\x6d\x70\x74\x7a\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x9f\x9f\xa5\xae\x50\x00\x00\x00")
 
 func _1528395623_add_canceled_at_to_campaign_planUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -454,7 +464,7 @@ func _1528395623_add_canceled_at_to_campaign_planUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395624_add_closed_at_to_campaignsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x2b\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x48\xce\xc9\x2f\x4e\x4d\x89\x4f\x2c\xb1\xe6\xe2\x72\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x04\x00\x00\xff\xff\x43\x04\x8a\xa2\x48\x00\x00\x00")
+var __1528395624_add_closed_at_to_campaignsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x48\x00\xb7\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x63\x61\x6d\x70\x61\x69\x67\x6e\x73\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x63\x6c\x6f\x73\x65\x64\x5f\x61\x74\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x43\x04\x8a\xa2\x48\x00\x00\x00")
 
 func _1528395624_add_closed_at_to_campaignsDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -474,7 +484,7 @@ func _1528395624_add_closed_at_to_campaignsDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395624_add_closed_at_to_campaignsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x2b\x56\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\x48\xce\xc9\x2f\x4e\x4d\x89\x4f\x2c\x51\x28\xc9\xcc\x4d\x2d\x2e\x49\xcc\x2d\x28\xa9\xb2\xe6\xe2\x72\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x04\x00\x00\xff\xff\x79\xfe\x09\x8b\x49\x00\x00\x00")
+var __1528395624_add_closed_at_to_campaignsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x49\x00\xb6\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x63\x61\x6d\x70\x61\x69\x67\x6e\x73\x20\x41\x44\x44\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x63\x6c\x6f\x73\x65\x64\x5f\x61\x74\x20\x74\x69\x6d\x65\x73\x74\x61\x6d\x70\x74\x7a\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x79\xfe\x09\x8b\x49\x00\x00\x00")
 
 func _1528395624_add_closed_at_to_campaignsUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -494,7 +504,7 @@ func _1528395624_add_closed_at_to_campaignsUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395625_lsif_uploadsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x09\xf2\x0f\x50\x08\x71\x74\xf2\x71\x55\xc8\x29\xce\x4c\x8b\x2f\x2d\xc8\xc9\x4f\x4c\x29\xb6\x86\x4a\x44\x06\xa0\x88\xc7\x17\x97\x24\x96\xa4\x5a\x73\x71\x39\xfb\xfb\xfa\x7a\x86\x58\x73\x01\x02\x00\x00\xff\xff\x0f\x8e\x44\x65\x47\x00\x00\x00")
+var __1528395625_lsif_uploadsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x47\x00\xb8\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x6c\x73\x69\x66\x5f\x75\x70\x6c\x6f\x61\x64\x73\x3b\x0a\x44\x52\x4f\x50\x20\x54\x59\x50\x45\x20\x6c\x73\x69\x66\x5f\x75\x70\x6c\x6f\x61\x64\x5f\x73\x74\x61\x74\x65\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x0f\x8e\x44\x65\x47\x00\x00\x00")
 
 func _1528395625_lsif_uploadsDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -514,7 +524,7 @@ func _1528395625_lsif_uploadsDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395625_lsif_uploadsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x51\xc1\xae\xda\x30\x10\xbc\xe7\x2b\x56\x5c\x02\x12\xf4\x03\xca\x29\x14\x43\xad\x26\x0e\x4a\x8c\x0a\xbd\x04\x2b\x5e\xa8\xd5\x24\x0e\xb6\x53\xda\xbf\xaf\x9a\xc0\x7b\x21\xa0\x27\x8e\x9e\x59\xcf\xcc\xce\xce\x66\xc0\xb4\xc3\xcf\x70\xc8\x75\x59\x2a\x77\x00\x65\x41\x80\x41\x8b\xe6\x37\x4a\xb8\x68\x23\xa7\x60\x35\x28\xe7\x5b\x38\x37\xda\xa1\xfc\xe4\x79\x0b\xb2\xa6\x6c\xee\x79\x5f\x12\x12\x70\x02\x7c\xbf\x21\x50\x58\x75\xcc\x9a\xba\xd0\x42\x66\xd6\x09\x87\x10\xa4\x40\xd8\x36\x82\xb1\x07\x00\xe0\x9f\x1b\x6c\x50\xfa\xd3\xee\x55\x1b\x9d\xa3\xb5\xaa\x3a\xdd\x90\x5c\x97\x75\x81\xee\x7d\x04\x8d\xd1\x06\xa5\xef\x4d\x7a\x56\xc1\x22\xbc\xf3\xb2\x57\x79\x25\x61\x41\xd7\x29\x49\x68\x10\xc2\x26\xa1\x51\x90\xec\xe1\x1b\xd9\x77\x5a\x06\x6b\x6d\x95\xd3\xe6\x2f\x70\xb2\xe3\xc0\x62\x0e\x6c\x1b\x86\x1d\x3b\xea\x96\x1f\x3d\xe3\x8c\xd6\xee\x19\x7e\x54\x05\x56\xa2\xc4\x67\x5c\xb7\xfe\x63\x21\xb7\x29\x58\x92\x55\xb0\x0d\xf9\xa0\x93\xa3\x50\x45\x63\x30\xb3\x4d\x59\x8a\x6b\xd2\x01\xe3\x44\xfe\xcb\x19\x91\x63\x8f\xec\x2c\x50\x66\xc2\x01\xa7\x11\x49\x79\x10\x6d\xe0\x3b\xe5\x5f\xdb\x27\xfc\x88\x19\x79\xb4\xae\xf4\x65\x3c\x79\x8b\x6b\xdc\xc7\xff\x6f\x3b\x57\xca\xfe\x7c\x65\xf2\x7f\x46\x55\x9d\xb2\x5c\x57\x0e\xff\x0c\x0a\xec\xdf\x93\xb2\x25\xd9\x01\x5d\xb5\x24\xd9\xd1\x94\xa7\x77\xd7\xbd\x36\x17\xb3\x3b\x74\xdc\xa2\x93\xf9\xcb\x2a\xfd\x8e\x86\x5a\x3d\xae\x0d\x16\x47\x11\xe5\x73\xef\x5f\x00\x00\x00\xff\xff\xf8\xb1\xf1\xc1\x1b\x03\x00\x00")
+var __1528395625_lsif_uploadsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x91\x51\xef\x9a\x30\x14\xc5\xdf\xf9\x14\x37\xff\x17\x34\xd1\x7d\x80\xf9\x84\xb3\xba\x66\x50\x0c\xd4\x4c\xf7\x82\x0d\xbd\xba\x66\x40\xb1\x2d\x73\xfb\xf6\xcb\x00\x37\x44\xf3\x8f\x8f\x3d\xbf\xdb\x73\x4f\x4f\xe7\x73\x60\xda\xe1\x47\x38\xe6\xba\x2c\x95\x3b\x82\xb2\x20\xc0\xa0\x45\xf3\x13\x25\x5c\xb5\x91\x33\xb0\x1a\x94\xf3\x2d\x5c\x1a\xed\x50\x7e\xf0\xbc\x25\xd9\x50\xb6\xf0\xbc\x4f\x09\x09\x38\x01\x7e\xd8\x12\x28\xac\x3a\x65\x4d\x5d\x68\x21\x33\xeb\x84\x43\x08\x52\x20\x6c\x17\xc1\xc4\x03\x00\xf0\x2f\x0d\x36\x28\xfd\x59\x77\xaa\x8d\xce\xd1\x5a\x55\x9d\x6f\x4a\xae\xcb\xba\x40\xf7\x7f\x04\x8d\xd1\x06\xa5\xef\x4d\x07\xab\x82\x65\x78\xb7\xcb\xf6\xf6\x4a\xc2\x92\x6e\x52\x92\xd0\x20\x84\x6d\x42\xa3\x20\x39\xc0\x17\x72\xe8\xcc\x0d\xd6\xda\x2a\xa7\xcd\x6f\xe0\x64\xcf\x81\xc5\x1c\xd8\x2e\x0c\x3b\xfa\xd6\x3d\xfe\xed\x19\x33\x5a\xbb\x67\xfa\x49\x15\x58\x89\x12\x9f\xb1\xee\xf9\x8f\x85\xdc\xa6\x60\x45\xd6\xc1\x2e\xe4\xa3\x4e\x4e\x42\x15\x8d\xc1\xcc\x36\x65\x29\xfa\xa4\x23\xe2\x44\xfe\xc3\x19\x91\xe3\x00\x76\x9d\xa3\xcc\x84\x03\x4e\x23\x92\xf2\x20\xda\xc2\x57\xca\x3f\xb7\x47\xf8\x16\x33\xf2\xb8\xba\xd2\xd7\xc9\xf4\x5f\x5c\xe3\xde\xbf\xdf\xc7\x50\x95\xb2\xdf\x5f\x99\xfc\x9b\x51\x55\xe7\x2c\xd7\x95\xc3\x5f\xa3\x02\x87\xff\x49\xd9\x8a\xec\x81\xae\x5b\x48\xf6\x34\xe5\xe9\xdd\xef\xf6\xcd\xc5\xec\x4e\x9d\xb4\xea\x74\xf1\xb2\xcb\xb0\xa3\xb1\xd7\x80\xb5\xc1\xe2\x28\xa2\x7c\xe1\xfd\x19\x00\xf8\xb1\xf1\xc1\x1b\x03\x00\x00")
 
 func _1528395625_lsif_uploadsUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -534,7 +544,7 @@ func _1528395625_lsif_uploadsUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395626_create_explicit_repo_permissions_tablesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x90\xc1\x6a\xb4\x30\x14\x85\xf7\x79\x8a\xb3\xfc\x7f\x70\x9e\xc0\x95\x33\xda\x12\xd0\xd8\x6a\x84\xee\xc4\x31\x97\x92\x32\x4d\xd2\x24\xb6\xf4\xed\x4b\xd5\x61\x84\x0e\x65\xa0\x59\x65\x71\xbe\xef\xde\x73\xf7\xc5\x3d\x17\x29\x63\xbb\x1d\x1a\x0a\xd1\x7a\x82\x3d\x29\x4c\x46\xbf\x4d\x84\xd1\x9a\x10\xfd\xa0\x4d\x64\x59\x29\x8b\x06\x32\xdb\x97\x05\xa6\x40\xbe\x77\xe4\x5f\x75\x08\xda\x9a\xc0\x00\x20\x6f\xea\x07\x1c\x6a\xd1\xca\x26\xe3\x42\x82\xdf\xa1\x78\xe2\xad\x6c\x7f\xc4\xe7\x7f\x6f\x8f\x2f\x34\xc6\xde\x79\xfb\xae\x15\xf9\x7e\x19\x99\xfc\xc5\xb5\x55\x64\x79\xbe\x35\xdc\xc0\xcd\xd8\xf7\xeb\x04\x7f\xec\x0a\xfc\x9b\x19\xad\x12\x5c\xb8\x04\x2b\x12\x3f\x1d\xfd\x4f\x7f\xbd\xca\xb9\x45\xd9\x55\x62\xd3\xe0\x5c\x78\x39\x7a\xee\xad\x83\xa1\x0f\xc4\xe1\x78\xa2\x80\xd1\xd3\x10\x49\xb1\x99\x5d\xbc\x17\xd4\x93\xb3\xdb\x09\xe9\xf5\xd8\xba\x88\x51\xda\x3c\xdf\x10\x5f\xad\x57\xe2\xec\x50\x57\x15\x97\x29\xfb\x0a\x00\x00\xff\xff\x24\x53\x96\x04\x26\x02\x00\x00")
+var __1528395626_create_explicit_repo_permissions_tablesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x90\xd1\x6a\xb4\x30\x14\x84\xef\xf3\x14\x73\xf9\xff\xe0\x3e\x81\x57\xee\x6a\x4b\x40\x63\xab\x11\x7a\x27\xae\x39\x94\x94\x6d\x92\x26\xb1\xa5\x6f\x5f\xaa\x2e\x2b\x74\x29\x0b\x85\x73\x39\xdf\xcc\x99\xd9\x17\xf7\x5c\xa4\x8c\xed\x76\x68\x28\x44\xeb\x09\xf6\xa4\x30\x19\xfd\x36\x11\x46\x6b\x42\xf4\x83\x36\x91\x65\xa5\x2c\x1a\xc8\x6c\x5f\x16\x98\x02\xf9\xde\x91\x7f\xd5\x21\x68\x6b\x02\x03\x80\xbc\xa9\x1f\x70\xa8\x45\x2b\x9b\x8c\x0b\x09\x7e\x87\xe2\x89\xb7\xb2\xfd\x21\x9f\xd1\xde\x1e\x5f\x68\x8c\xbd\xf3\xf6\x5d\x2b\xf2\xfd\x12\x99\xfc\xc5\x6b\x6b\x91\xe5\xf9\xd6\xe1\x06\x6e\x4e\xfe\xbe\x4e\xf0\xc7\xae\xc0\xbf\x99\xd1\x2a\xc1\x85\x4b\xb0\x22\xf1\xd3\xd1\xff\xf4\xd7\x55\xce\x2d\xca\xae\x12\x9b\x06\xe7\xc2\xcb\xe8\xb9\xb7\x0e\x86\x3e\x10\x87\xe3\x89\x02\x46\x4f\x43\x24\xc5\x66\x76\x59\xfb\x82\x7a\x72\x76\x9b\x90\x5e\x97\xad\x8f\x18\xa5\xcd\xf3\x0d\xf2\xd5\xf5\x8a\x9c\x1d\xea\xaa\xe2\x32\x65\x5f\x03\x00\x24\x53\x96\x04\x26\x02\x00\x00")
 
 func _1528395626_create_explicit_repo_permissions_tablesDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -554,7 +564,7 @@ func _1528395626_create_explicit_repo_permissions_tablesDownSql() (*asset, error
 	return a, nil
 }
 
-var __1528395626_create_explicit_repo_permissions_tablesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb4\x55\xdb\x72\xe2\x38\x10\x7d\xf7\x57\x74\xe5\x25\x50\xe5\xa4\x6a\x5f\x97\xdd\xad\x32\xa0\xa4\x5c\x05\x86\xb5\xc5\x6e\x66\x5e\x28\x83\x1b\x50\xc6\x48\x1e\x49\x26\xc9\x3c\xe5\x43\x66\x7e\x2e\x5f\x32\x25\xd9\xe6\x7e\x71\xe6\xe2\x17\x8c\xa4\xd3\xea\xee\x73\x4e\xbb\x4d\xee\xfd\xa0\xe5\x38\x37\x37\x70\xc7\xd2\x14\x18\x87\x55\x9c\xe6\xa8\x60\x26\x24\x48\xf1\xa4\x60\x82\xa9\xe0\x73\xd0\x02\xda\x4c\x4f\xf2\xe9\x27\xd4\x10\xa1\x5c\xa1\x04\x2f\xd7\x8b\x2f\x30\x94\x62\xc5\x12\x94\xb7\x8e\xd7\xa3\x24\x04\xea\xb5\x7b\x04\x72\x85\x72\x9c\xa1\x5c\x32\xa5\x98\xe0\x0a\xbc\x6e\x17\x3a\x83\xde\xa8\x1f\x40\x56\x22\x80\x92\x07\xda\x72\x46\xc3\xae\x47\x8f\x20\x22\x42\x37\x47\xff\x86\xeb\x49\x75\x7f\x71\xfd\x75\xeb\xc2\x85\x76\x73\xff\x4a\x13\x34\x18\x50\x08\x46\xbd\x5e\x51\x78\x88\x53\x89\xb1\x46\xc8\x39\xfb\x9c\x23\x4c\x05\x57\x5a\xc6\x8c\x6b\x53\x34\xe3\xd3\x34\x4f\x70\x13\x60\x2a\xd2\x7c\xc9\xcf\x17\xeb\x00\x00\x74\xc3\xc1\x10\x3a\x83\x20\xa2\xa1\xe7\x07\x14\xfc\x3b\x20\x0f\x7e\x44\xa3\x83\xe3\xf6\x7d\x2c\x26\x8f\x38\xd5\xe3\x22\x0b\xf7\x67\x42\x54\xb9\xee\xc4\x2a\xfa\xbf\x0e\xf5\x9e\x00\x16\x6f\x9e\x51\xe0\xff\x3b\x22\xd0\xb0\x60\x96\xb8\xb0\x09\xe0\x42\x89\xd5\x2f\x19\xba\xeb\x76\x35\x8b\x1e\x77\x8a\x0e\xeb\x05\x02\xe3\x2b\x94\x0a\x41\xc7\x93\x14\x41\xcc\x0e\x32\xb9\x75\x3a\x21\x31\x82\x28\x7a\xeb\xdf\x59\xbe\xca\xba\x25\x66\x62\x87\xe5\x86\x4d\xce\x2e\xb3\xc4\x26\xe9\x07\x94\xdc\x93\x70\xcd\x72\x51\xfe\x06\x03\x56\x76\x7b\xdb\x65\x45\xca\xbc\xb7\x3f\x50\xe2\xed\xc3\x2b\xfa\xe1\x38\x3c\x4b\x62\x8d\xc9\x38\xd6\x00\xd4\xef\x93\x88\x7a\xfd\x21\xfd\xb8\x3e\xe5\x98\x3e\x6c\x2b\x66\xbf\x8e\x0b\x74\xef\x1f\x2f\xd8\xaa\xc3\x73\x2d\xe4\x01\xc1\x65\x3f\x77\x09\x3e\xcd\x69\xc1\xa5\x19\x19\x19\xf2\x84\xf1\x39\x6c\x53\x64\xd6\x4d\x7f\x15\x3c\x2d\x04\xc4\x12\x81\x0b\x0d\x2f\xa8\xc1\xc2\xb4\x80\xb9\x8c\xb9\xbe\x35\x41\xe9\x02\x61\xc2\x78\x62\xc8\x64\x0a\x62\xd0\xf8\xac\x4b\xcf\xc1\xd3\x82\x4d\x17\xa6\x22\x89\x0a\xb9\x06\x64\x7a\x81\x45\x6c\x1e\x2f\x11\x84\x04\x5c\xc6\x2c\x75\x21\xc1\x2a\x11\xc1\x6d\x86\x8a\x69\xeb\xec\x19\x9b\xdb\x7b\xc8\x73\xbc\xcc\x52\xa3\x46\x85\x52\xff\x69\x96\x0a\xed\x44\x24\xa4\x46\x42\x83\x4a\x97\x36\xcc\x0e\x55\xe5\x59\x80\x46\x99\xe9\x19\x1f\x94\x7f\x58\xa2\xdc\x2d\x91\x34\xab\x18\xff\x79\xbd\x11\x89\xb6\x22\xbe\xbd\x7e\x7d\x14\xf8\xf6\xfa\xcd\x85\x2b\x89\x71\x72\x65\x7f\x33\xa1\xae\x5c\xf8\x6b\xc2\xf4\x32\xce\x8c\x67\xcc\x12\xf8\x5d\xf5\x8f\x0b\xc1\xe0\xff\x46\xb3\xd9\x3a\x67\x9a\x53\x95\x94\xe6\x29\x7d\x63\x9f\x88\x84\xbe\x57\x8a\xba\xe2\xe1\x84\xe6\x2f\x38\x6a\xab\x0d\x67\xb6\xad\xe5\x8e\x19\xee\xdd\x8e\x3a\x49\x57\xad\x41\x7a\x00\x3b\x39\x93\x8f\xcf\xd1\x3a\xf8\x03\x97\x5d\x92\x4f\xfd\xe1\x79\x48\x6d\x61\x46\x39\x13\x72\x19\x73\x0d\x9d\x70\xd4\xfd\x85\xc2\x3f\x3a\x1f\xaa\x19\x5a\x4f\xe9\x7f\x6c\x14\xbe\x25\x6c\x13\xa3\xbe\xb0\xcb\xe1\x76\x4a\xd8\xbf\xfd\xab\xf0\xa3\x63\xff\xbd\x22\x3d\x05\x2b\x44\x76\x79\xfa\x9f\x05\xd6\x19\xfe\xa6\x8e\xce\xa0\xdf\xf7\x69\xcb\xf9\x1e\x00\x00\xff\xff\xb5\x72\x81\x58\x34\x0a\x00\x00")
+var __1528395626_create_explicit_repo_permissions_tablesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xbc\x55\x4d\x6f\xe3\x36\x10\xbd\xfb\x57\x3c\xe4\xb2\x09\xa0\x0d\xd0\x6b\xdd\x16\x50\x6c\x66\x21\xc0\x96\x53\x89\x6e\xb7\xbd\x18\xb2\x35\x89\xb9\x95\x49\x95\xa4\x93\xdd\x9e\xf6\x87\xb4\x7f\x2e\xbf\xa4\x20\x25\xc5\x8a\xe5\x0f\x05\x2d\x16\x10\x10\x45\x9c\xf7\x38\xf3\xe6\xcd\xf8\x86\x7d\x88\xe2\xe1\x60\xf0\xfe\x3d\x6e\x45\x51\x40\x48\x3c\x66\xc5\x96\x0c\xee\x95\x86\x56\x4f\x06\x4b\x2a\x94\x7c\x80\x55\xb8\x11\x76\xb9\x5d\xfd\x41\x16\x29\xe9\x47\xd2\x08\xb7\x76\xfd\x17\xee\xb4\x7a\x14\x39\xe9\xeb\x41\x38\xe1\x2c\x01\x0f\x6f\x26\x0c\x5b\x43\x7a\x51\x92\xde\x08\x63\x84\x92\x06\xe1\x78\x8c\xd1\x6c\x32\x9f\xc6\x28\x6b\x04\x38\xfb\xc8\x87\x83\xf9\xdd\x38\xe4\x07\x10\x29\xe3\xbb\xd0\x1f\xf1\x6e\xd9\xdc\x5f\x5d\xff\x6e\x78\xe6\x42\x7f\xb8\x7f\xa5\x23\x8d\x67\x1c\xf1\x7c\x32\xa9\x0a\x4f\x68\xa5\x29\xb3\x84\xad\x14\x7f\x6e\x09\x2b\x25\x8d\xd5\x99\x90\xd6\x15\x2d\xe4\xaa\xd8\xe6\xb4\x23\x58\xa9\x62\xbb\x91\xa7\x8b\x1d\x00\xc0\x38\x99\xdd\x61\x34\x8b\x53\x9e\x84\x51\xcc\x11\xdd\x82\x7d\x8c\x52\x9e\x76\xc2\x3d\x74\xa1\x96\x9f\x68\x65\x17\x55\x16\xc1\x7f\xa1\x68\x72\x7d\xc5\x55\xe9\xff\x42\xf5\x16\x02\x8f\x77\xcf\x3c\x8e\x7e\x9e\x33\x5c\x7a\xb0\xc8\x03\xec\x08\x02\xd4\x58\xfb\xa5\xa4\xe0\x45\xae\xab\x4a\xe3\x51\xa5\xb0\x5d\x13\x84\x7c\x24\x6d\x08\x36\x5b\x16\x04\x75\xdf\x29\xe5\x7a\x30\x4a\x98\x33\x44\xa5\x6d\x74\xeb\xfb\x55\xd7\xad\xa9\x54\xed\x60\x5c\xfa\xe4\xfc\x67\x91\xbb\x57\x44\x31\x67\x1f\x58\xf2\xd2\xe5\x4a\xca\x1d\x06\xde\x76\x7b\xc7\x75\x45\xc6\x85\xde\xfc\xc6\x59\xb8\x0f\xaf\xeb\x01\x0e\xc3\xcb\x3c\xb3\x94\x2f\x32\x0b\xf0\x68\xca\x52\x1e\x4e\xef\xf8\xef\x2f\x51\x03\xa7\x43\xdb\x31\xfb\x75\x9c\x69\xf7\x7e\xb8\x7f\xef\xd5\xe7\x5e\xc8\x4e\x83\x6b\x3d\x5f\x37\xf8\x78\x4f\xab\x5e\xba\x95\x51\x92\xcc\x85\x7c\x68\xe1\xaa\x55\xe2\xf4\x35\x78\x5a\x2b\x64\x9a\x20\x95\xc5\x17\xb2\xf0\x30\xab\xf0\xa0\x33\x69\xaf\x9d\x51\xf8\x9a\xb0\x14\x32\x5f\x88\x1c\xc2\x20\x83\xa5\xcf\xb6\x9e\x39\x3c\xad\xc5\x6a\x0d\x4d\xa5\x26\x43\xd2\x82\x84\x5d\x53\xc5\x2d\xb3\x0d\x41\x69\xd0\x26\x13\x45\x80\x9c\x9a\x44\x94\xf4\x19\x1a\x61\xfd\x64\xdf\x8b\x07\x7f\x0f\xfb\x9c\x6d\xca\xc2\xb9\xd1\x90\xb6\xdf\xbb\x4f\x4e\x80\x28\x4e\x59\xc2\x9d\x85\x66\x8d\x2f\x3d\x4d\x5b\xc1\x26\x16\xb8\xac\x33\x3d\x31\x07\xf5\x3f\x22\x37\x41\xcb\x24\x57\x0d\xc7\x2f\xe1\x64\xce\xd2\x16\xe3\xf3\xd7\xbf\x3f\x29\x7a\xfe\xfa\x4f\x80\x0b\x4d\x59\x7e\xe1\xff\x96\xca\x5c\x04\xf8\x61\x29\xec\x26\x2b\xdd\xcc\xb8\xfe\x20\x1a\x9b\x9f\x02\xc4\xb3\x5f\x2f\xaf\xae\x86\xa7\x86\xe6\x58\x25\xf5\xf0\xd4\x73\xe3\x9f\x94\x25\x51\x58\x9b\xba\xe9\xc3\x11\xcf\xef\x88\x0e\x1e\xb7\x64\x38\x71\x2c\x72\x73\x78\xe0\xde\x3c\x51\x47\xdb\xd5\x6b\x91\x76\x60\xaf\xf6\xe1\xf9\x3d\xda\x07\xdf\x99\xb2\x73\xf6\xe9\xbf\x3c\x3b\xd7\xd7\xc3\xa8\xef\x95\xde\x64\xd2\x62\x94\xcc\xc7\xff\xa3\xf1\x0f\xee\x87\x66\x87\xf6\x73\xfa\x77\x3b\x87\xb7\x8c\xed\x38\xfa\x1b\xbb\x5e\x6e\xdd\xea\xbf\xd1\xaf\xc2\x9b\x4d\x7a\x2c\xe1\x7e\xeb\xbf\x03\xf3\x14\xa7\xdc\xd9\x07\xd8\x67\xf9\x3b\x27\x8e\x66\xd3\x69\xc4\x87\x83\x7f\x07\x00\xb5\x72\x81\x58\x34\x0a\x00\x00")
 
 func _1528395626_create_explicit_repo_permissions_tablesUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -574,7 +584,7 @@ func _1528395626_create_explicit_repo_permissions_tablesUpSql() (*asset, error)
 	return a, nil
 }
 
-var __1528395627_add_external_deleted_at_to_changesetsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x04\xc0\x3d\x0e\x42\x21\x0c\x00\xe0\xbd\xa7\xe8\x3d\x98\xde\xc3\x6a\x9a\xf0\x63\xa0\x26\x6e\x84\x48\xa3\x03\x61\x90\x0e\x1e\xdf\xef\xa4\x1b\x27\x07\x70\x04\xa1\x82\x72\x9c\x81\xf0\xf5\xe9\xeb\xad\x5b\x6d\xe3\xa5\xe4\x3b\xfa\x1c\x1e\x31\x21\x5f\x91\x9e\x5c\xa5\xa2\xfe\x4c\xbf\xab\xcf\x36\x74\xaa\xe9\x68\xdd\x1c\x80\xcf\x31\xb2\x38\xf8\x07\x00\x00\xff\xff\x39\x95\x87\xb9\x53\x00\x00\x00")
+var __1528395627_add_external_deleted_at_to_changesetsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x53\x00\xac\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x63\x68\x61\x6e\x67\x65\x73\x65\x74\x73\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x65\x78\x74\x65\x72\x6e\x61\x6c\x5f\x64\x65\x6c\x65\x74\x65\x64\x5f\x61\x74\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x39\x95\x87\xb9\x53\x00\x00\x00")
 
 func _1528395627_add_external_deleted_at_to_changesetsDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -594,7 +604,7 @@ func _1528395627_add_external_deleted_at_to_changesetsDownSql() (*asset, error)
 	return a, nil
 }
 
-var __1528395627_add_external_deleted_at_to_changesetsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x04\xc0\x4d\x0a\x42\x21\x10\x07\xf0\xfd\x9c\x62\xee\xe1\xca\xf7\x94\x10\xfc\x80\xb0\xb5\x0c\xf9\xa7\x02\x95\xc8\x59\x44\xa7\xef\x77\xf8\x4b\xc8\x86\xc8\xc6\xea\xaf\x5c\xed\x11\x3d\xdf\x9f\xb2\x1e\xd8\xd0\xcd\xd6\x39\x3e\x4b\xbc\xa5\xcc\xf8\x2a\x3e\x4b\x46\xeb\x18\x50\xf4\x26\xca\xfa\x9a\xd8\x2a\xf3\xad\x3f\x43\x74\x96\x94\x42\x35\xf4\x0f\x00\x00\xff\xff\x05\xfb\xa4\x2b\x54\x00\x00\x00")
+var __1528395627_add_external_deleted_at_to_changesetsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x54\x00\xab\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x63\x68\x61\x6e\x67\x65\x73\x65\x74\x73\x20\x41\x44\x44\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x65\x78\x74\x65\x72\x6e\x61\x6c\x5f\x64\x65\x6c\x65\x74\x65\x64\x5f\x61\x74\x20\x74\x69\x6d\x65\x73\x74\x61\x6d\x70\x74\x7a\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x05\xfb\xa4\x2b\x54\x00\x00\x00")
 
 func _1528395627_add_external_deleted_at_to_changesetsUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -614,7 +624,7 @@ func _1528395627_add_external_deleted_at_to_changesetsUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395628_reset_changeset_external_deleted_atDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\xd2\xd5\x55\xf0\xcb\x57\x48\xc9\x2f\xcf\x53\xc8\xcd\x4c\x2f\x4a\x2c\xc9\xcc\xcf\x53\x28\xce\xcc\x4b\x4e\x55\x28\xc9\x48\x55\x28\x2d\x40\x12\x2e\x4a\x2d\x4e\x2d\x29\x56\x48\x49\x2c\x49\xd4\xe3\xe2\x72\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x04\x00\x00\xff\xff\x21\xb8\x7c\x6b\x4a\x00\x00\x00")
+var __1528395628_reset_changeset_external_deleted_atDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x4a\x00\xb5\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x2d\x2d\x20\x4e\x6f\x20\x64\x6f\x77\x6e\x20\x6d\x69\x67\x72\x61\x74\x69\x6f\x6e\x20\x73\x69\x6e\x63\x65\x20\x74\x68\x65\x20\x75\x70\x20\x6d\x69\x67\x72\x61\x74\x69\x6f\x6e\x20\x72\x65\x73\x65\x74\x73\x20\x64\x61\x74\x61\x2e\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x21\xb8\x7c\x6b\x4a\x00\x00\x00")
 
 func _1528395628_reset_changeset_external_deleted_atDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -634,7 +644,7 @@ func _1528395628_reset_changeset_external_deleted_atDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395628_reset_changeset_external_deleted_atUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x0a\x0d\x70\x71\x0c\x71\x55\x48\xce\x48\xcc\x4b\x4f\x2d\x4e\x2d\x29\x56\x08\x76\x0d\x51\x48\xad\x28\x49\x2d\xca\x4b\xcc\x89\x4f\x49\xcd\x49\x2d\x49\x4d\x89\x4f\x2c\x51\xb0\x55\xf0\x0b\xf5\xf1\x51\x08\xf7\x70\x0d\x72\xc5\xaa\xc0\x33\x58\xc1\xcf\x3f\x04\xac\xca\x9a\x8b\xcb\xd9\xdf\xd7\xd7\x33\xc4\x9a\x0b\x10\x00\x00\xff\xff\x72\x63\xf7\x6c\x69\x00\x00\x00")
+var __1528395628_reset_changeset_external_deleted_atUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x69\x00\x96\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x55\x50\x44\x41\x54\x45\x20\x63\x68\x61\x6e\x67\x65\x73\x65\x74\x73\x20\x53\x45\x54\x20\x65\x78\x74\x65\x72\x6e\x61\x6c\x5f\x64\x65\x6c\x65\x74\x65\x64\x5f\x61\x74\x20\x3d\x20\x4e\x55\x4c\x4c\x20\x57\x48\x45\x52\x45\x20\x65\x78\x74\x65\x72\x6e\x61\x6c\x5f\x64\x65\x6c\x65\x74\x65\x64\x5f\x61\x74\x20\x49\x53\x20\x4e\x4f\x54\x20\x4e\x55\x4c\x4c\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x72\x63\xf7\x6c\x69\x00\x00\x00")
 
 func _1528395628_reset_changeset_external_deleted_atUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -654,7 +664,7 @@ func _1528395628_reset_changeset_external_deleted_atUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395629_add_published_at_to_campaignsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x2b\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x28\x28\x4d\xca\xc9\x2c\xce\x48\x4d\x89\x4f\x2c\xb1\xe6\xe2\x72\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x04\x00\x00\xff\xff\xbf\xd5\xbb\x2b\x4b\x00\x00\x00")
+var __1528395629_add_published_at_to_campaignsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x4b\x00\xb4\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x63\x61\x6d\x70\x61\x69\x67\x6e\x73\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x70\x75\x62\x6c\x69\x73\x68\x65\x64\x5f\x61\x74\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xbf\xd5\xbb\x2b\x4b\x00\x00\x00")
 
 func _1528395629_add_published_at_to_campaignsDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -674,7 +684,7 @@ func _1528395629_add_published_at_to_campaignsDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395629_add_published_at_to_campaignsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x54\x8c\xbb\x0e\x02\x21\x10\x00\xfb\xfd\x8a\xfd\x0f\x62\xc1\xdd\xad\x4a\xc2\xc3\x1c\x4b\xb4\x33\x78\x12\x25\x11\x43\x04\x1b\xbf\xde\xc2\xc6\x2b\x27\x33\x99\x81\x76\xca\x0a\x00\xa9\x99\x66\x64\x39\x68\xc2\x25\x96\x1a\xf3\xed\xd9\x50\x4e\x13\x8e\x4e\x07\x63\x51\x6d\xd1\x3a\x46\x3a\x29\xcf\x1e\xeb\xfb\xf2\xc8\xed\x9e\xae\xe7\xd8\xb1\xe7\x92\x5a\x8f\xa5\xf6\x8f\x00\x08\x87\x49\xf2\xff\xc4\x13\xaf\xfb\x0d\x2e\xaf\x14\xfb\x0f\x8e\x7b\x9a\x69\xed\x95\x47\x1b\xb4\x16\x00\xa3\x33\x46\xb1\x80\x6f\x00\x00\x00\xff\xff\x24\x0c\xc9\x18\xa6\x00\x00\x00")
+var __1528395629_add_published_at_to_campaignsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x54\x8c\xbb\x0e\x02\x21\x10\x00\xfb\xfd\x8a\xfd\x0f\x62\xc1\xdd\xad\x4a\xc2\xc3\x1c\x4b\xb4\x33\x78\x12\x25\x11\x43\x04\x1b\xbf\xde\xc2\xc6\x2b\x27\x33\x99\x81\x76\xca\x0a\x00\xa9\x99\x66\x64\x39\x68\xc2\x25\x96\x1a\xf3\xed\xd9\x50\x4e\x13\x8e\x4e\x07\x63\x51\x6d\xd1\x3a\x46\x3a\x29\xcf\x1e\xeb\xfb\xf2\xc8\xed\x9e\xae\xe7\xd8\xb1\xe7\x92\x5a\x8f\xa5\xf6\x8f\x00\x08\x87\x49\xf2\xff\xc4\x13\xaf\xfb\x0d\x2e\xaf\x14\xfb\x0f\x8e\x7b\x9a\x69\xed\x95\x47\x1b\xb4\x16\x00\xa3\x33\x46\xb1\x80\xef\x00\x24\x0c\xc9\x18\xa6\x00\x00\x00")
 
 func _1528395629_add_published_at_to_campaignsUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -694,7 +704,7 @@ func _1528395629_add_published_at_to_campaignsUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395630_repo_external_alwaysDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\x8f\xcd\x4e\xc3\x30\x10\x84\xef\x7e\x8a\xb9\xd1\x48\xb4\x2f\x90\x53\x69\x4c\x31\x6a\x1d\xc8\x8f\xe8\xcd\x0a\xdd\x85\x5a\xaa\xec\xe2\xd8\x28\xbc\x3d\x0a\x02\x81\xd2\x8a\xeb\xec\xcc\xb7\xfa\x6e\xe4\x5a\xe9\x5c\x88\xf9\x1c\xe4\xdd\x55\x84\x63\x26\x44\x8f\xe4\xc8\x23\x1e\x18\xfb\x43\xe7\x5e\xb9\x1f\x33\xe2\x97\x2e\x1d\xa3\x09\x7c\xf2\xfd\x02\xf7\xa9\x9f\xf4\x7d\x0a\x23\xc9\x3a\xe2\xe1\x67\xb8\x10\x62\x55\xc9\x65\x23\xd1\x6a\xf5\xd8\x4a\x28\x5d\xc8\x1d\x46\x86\xe1\x21\x72\x70\xdd\xd1\xf4\x1c\xde\xed\x9e\x4d\x72\xf6\x2d\xb1\xb1\x34\xa0\xd4\x5f\x1d\xb4\xb5\xd2\x6b\x3c\xc7\xc0\x8c\xd9\xd9\x20\x7e\x9c\xf8\x1a\x67\xb1\xa5\x3f\xa1\xa5\x0c\x4f\x77\xb2\x92\x98\x5d\x06\x40\xd5\xd0\x65\x03\xdd\x6e\x36\x19\x96\xba\xb8\xf0\xc8\xd2\x7f\xad\xc9\x35\xcb\x85\x28\xaa\xf2\xe1\xdb\x55\xdd\x42\xee\x54\xdd\xd4\x13\xeb\x5f\xdb\x5c\x88\x55\xb9\xdd\xaa\x26\x17\x9f\x01\x00\x00\xff\xff\x3f\xfe\x00\x6d\x92\x01\x00\x00")
+var __1528395630_repo_external_alwaysDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\x8f\xcd\x4e\xc3\x30\x10\x84\xef\x7e\x8a\xb9\xd1\x48\xb4\x2f\x90\x53\x69\x4c\x31\x6a\x1d\xc8\x8f\xe8\xcd\x0a\xdd\x85\x5a\xaa\xec\xe2\xd8\x28\xbc\x3d\x0a\x02\x81\xd2\x8a\xeb\xec\xcc\xb7\xfa\x6e\xe4\x5a\xe9\x5c\x88\xf9\x1c\xe4\xdd\x55\x84\x63\x26\x44\x8f\xe4\xc8\x23\x1e\x18\xfb\x43\xe7\x5e\xb9\x1f\x33\xe2\x97\x2e\x1d\xa3\x09\x7c\xf2\xfd\x02\xf7\xa9\x9f\xf4\x7d\x0a\x23\xc9\x3a\xe2\xe1\x67\xb8\x10\x62\x55\xc9\x65\x23\xd1\x6a\xf5\xd8\x4a\x28\x5d\xc8\x1d\x46\x86\xe1\x21\x72\x70\xdd\xd1\xf4\x1c\xde\xed\x9e\x4d\x72\xf6\x2d\xb1\xb1\x34\xa0\xd4\x5f\x1d\xb4\xb5\xd2\x6b\x3c\xc7\xc0\x8c\xd9\xd9\x20\x7e\x9c\xf8\x1a\x67\xb1\xa5\x3f\xa1\xa5\x0c\x4f\x77\xb2\x92\x98\x5d\x06\x40\xd5\xd0\x65\x03\xdd\x6e\x36\x19\x96\xba\xb8\xf0\xc8\xd2\x7f\xad\xc9\x35\xcb\x85\x28\xaa\xf2\xe1\xdb\x55\xdd\x42\xee\x54\xdd\xd4\x13\xeb\x5f\xdb\x5c\x88\x55\xb9\xdd\xaa\x26\x17\x9f\x03\x00\x3f\xfe\x00\x6d\x92\x01\x00\x00")
 
 func _1528395630_repo_external_alwaysDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -714,7 +724,7 @@ func _1528395630_repo_external_alwaysDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395630_repo_external_alwaysUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x92\xc1\x6e\xdb\x30\x10\x44\xef\xfa\x8a\xb9\xd5\x01\x12\xff\x40\x4e\x8a\x4d\xa7\x42\x1d\xa9\x95\x64\x34\x3d\x19\x1b\x72\x6d\x11\x91\x49\x95\x5a\xc5\xd1\xdf\x17\x94\xdd\x20\x41\x5c\xa0\x17\x1d\x96\xa3\xe1\xf2\xcd\xdc\xa9\xfb\x2c\xbf\x4d\x92\x9b\x1b\x38\x66\x03\xf1\x60\xd7\x0f\x81\x71\x64\x68\xea\x35\x19\x86\xe1\x96\x85\x11\xb8\xf3\x73\xd4\x8d\xed\x21\xf4\xd4\x32\x1a\xea\x41\xd8\xf9\xc0\x76\xef\xf0\xcc\x23\x9e\x06\x89\x56\xc6\x73\xef\xbe\x08\xfa\x8e\xb5\xdd\x8d\x20\x07\xd2\x62\xbd\x83\x77\x67\xb7\x79\x92\xae\x6b\x55\xa2\x4e\xef\xd6\x0a\xd9\x0a\xea\x31\xab\xea\x0a\x86\x77\x34\xb4\xb2\x8d\x97\xf5\x09\x00\x2c\xcb\xe2\x3b\x16\x45\x5e\xd5\x65\x9a\xe5\xf5\x47\xc5\xf4\xdd\x5a\xb3\xdd\x3d\xf3\x78\x3d\xe9\xd3\xe5\xf2\xff\xe4\x58\x15\xa5\xca\xee\x73\x7c\x53\xbf\x30\x3b\x9f\x5c\xa1\x54\x2b\x55\xaa\x7c\xa1\xaa\xe9\xc5\xb3\x38\x2b\x72\x2c\xd5\x5a\xd5\x0a\x8b\xb4\x5a\xa4\x4b\x75\x42\x66\x82\xef\x40\x6e\x44\xe0\x03\x59\x67\xdd\x1e\xc1\x1f\x7b\x48\x43\x82\x86\x5e\x38\x42\x38\xd8\x7d\x20\x61\x13\xd1\x31\xbc\x6b\x47\x74\x2d\x69\x86\x44\x92\x7d\xe3\x87\xd6\x44\xb3\xa8\x9f\xcc\xce\xa8\x6c\x1f\x69\x55\x7e\x08\x9a\xf7\x81\xba\x66\xae\xfd\x21\x39\xaf\xb1\x2a\x8b\x87\x69\x3d\xfc\xfc\xaa\x4a\x85\xd9\x8c\x5f\x85\x83\xa3\x76\xdb\x73\x78\xb1\x9a\xb7\x32\x76\x8c\xac\x42\xbe\x59\xaf\xaf\x50\x94\xf8\x2c\xb1\xe6\x1f\x82\x77\x07\x57\xa7\xa7\x4e\x78\x3e\x19\x0c\xce\xfe\x1e\xa2\xcf\x6b\x5c\x57\x0f\x21\xb0\x93\x76\x04\xa1\xa3\x20\x96\x5a\x58\x67\xf8\xf5\x3a\xb6\xe9\x48\x4e\x20\x3e\x9a\x1d\xe8\x99\x61\x25\x96\x67\x68\xcf\x9a\x39\x6a\x0f\xe3\x4f\xec\x8e\xfc\x56\x47\x1d\x98\x84\x3f\x48\x41\xce\x4c\x20\xa3\x97\x34\xec\xa6\x20\xe6\xc8\x76\xf1\x47\x63\x4d\x1c\xc2\x4b\xc3\x01\x3e\x18\x0e\x53\x99\x23\x67\x90\xd6\xd6\xb0\x13\x6a\xdb\x11\xd6\x49\xf0\x66\xd0\x3c\x65\x39\x74\xad\xd5\x24\xdc\xcf\x93\x64\x51\xaa\xb4\x56\xd8\xe4\xd9\x8f\x8d\x42\x96\x2f\xd5\x63\xec\x68\x5e\xd4\x7f\x7b\xfa\x91\xc7\x3b\x0e\x45\x7e\xca\x65\x53\x65\xf9\x3d\x9e\x24\x30\x5f\x20\x1f\xc3\xb9\xc6\x85\x40\xde\x0d\xad\x89\xec\xa7\xfa\xbf\x6d\x70\xf1\xf6\xcf\x69\xdc\x26\xc9\xa2\x78\x78\xc8\xea\xdb\xe4\x4f\x00\x00\x00\xff\xff\xc4\xd5\x3e\x68\xe2\x03\x00\x00")
+var __1528395630_repo_external_alwaysUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x92\x41\x6f\xdb\x38\x10\x85\xef\xfa\x15\xef\xb6\x36\x90\xf8\x0f\xe4\xa4\xd8\x74\x56\x58\x47\xda\x4a\x32\x9a\x9e\x8c\x09\x39\xb6\x88\xc8\xa4\x4a\x8d\xe2\xe8\xdf\x17\x94\xdd\x20\x81\x53\xa0\x17\x1e\x86\x0f\x6f\x66\xbe\x79\xf7\xea\x21\xcb\xef\x92\xe4\xf6\x16\x8e\xd9\x40\x3c\xd8\xf5\x43\x60\x9c\x18\x9a\x7a\x4d\x86\x61\xb8\x65\x61\x04\xee\xfc\x02\x75\x63\x7b\x08\x3d\xb7\x8c\x86\x7a\x10\xf6\x3e\xb0\x3d\x38\xbc\xf0\x88\xe7\x41\xa2\x95\xf1\xdc\xbb\x7f\x04\x7d\xc7\xda\xee\x47\x90\x03\x69\xb1\xde\xc1\xbb\x8b\xdb\x22\x49\x37\xb5\x2a\x51\xa7\xf7\x1b\x85\x6c\x0d\xf5\x94\x55\x75\x05\xc3\x7b\x1a\x5a\xd9\xc5\x66\x7d\x02\x00\xab\xb2\xf8\x1f\xcb\x22\xaf\xea\x32\xcd\xf2\xfa\xb3\x62\x7a\x77\xd6\xec\xf6\x2f\x3c\xde\x4c\xfa\x74\xb5\xfa\x3b\x39\xd6\x45\xa9\xb2\x87\x1c\xff\xa9\x1f\x98\x5d\x7e\xe6\x28\xd5\x5a\x95\x2a\x5f\xaa\x6a\xda\x78\x66\xcd\x1c\x45\x8e\x95\xda\xa8\x5a\x61\x99\x56\xcb\x74\xa5\xce\xc8\x4c\xf0\x1d\xc8\x8d\x08\x7c\x24\xeb\xac\x3b\x20\xf8\x53\x0f\x69\x48\xd0\xd0\x2b\x47\x08\x47\x7b\x08\x24\x6c\x22\x3a\x86\x77\xed\x88\xae\x25\xcd\x90\x48\xb2\x6f\xfc\xd0\x9a\x68\x16\xf5\x93\xd9\x05\x95\xed\x23\xad\xca\x0f\x41\xf3\x21\x50\xd7\x2c\xb4\x3f\x26\x97\x31\xd6\x65\xf1\x38\x8d\x87\xef\xff\xaa\x52\x61\x36\xe3\x37\xe1\xe0\xa8\xdd\xf5\x1c\x5e\xad\xe6\x9d\x8c\x1d\x23\xab\x90\x6f\x37\x9b\x39\x8a\x12\xd7\x12\x6b\xfe\x20\xf8\xf0\x31\x3f\xaf\x1a\x7b\xed\xae\x0c\x06\x67\x7f\x0e\xd1\xe7\x0d\xb6\x87\x1e\x42\x60\x27\xed\x08\x42\x47\x41\x2c\xb5\xb0\xce\xf0\xdb\x4d\x4c\xd3\x89\x9c\x40\x7c\x34\x3b\xd2\x0b\xc3\x4a\x0c\xcf\xd0\x5e\x34\x0b\xd4\x1e\xc6\x9f\xd9\x9d\xf8\x3d\x8e\x3a\x30\x09\x7f\x92\x82\x9c\x99\x40\x46\x2f\x69\xd8\x4d\x87\x58\x20\xdb\xc7\x3e\xc6\x1a\x48\xc3\xf0\xd2\x70\x80\x0f\x86\x43\x2c\xeb\xc8\x19\xa4\xb5\x35\xec\x84\xda\x76\x84\x75\x12\xbc\x19\x34\x47\x1f\x33\x74\xad\xd5\x24\xdc\x2f\x92\x64\x59\xaa\xb4\x56\xd8\xe6\xd9\xb7\xad\x42\x96\xaf\xd4\x53\xcc\x68\x5e\xd4\xbf\x73\xfa\x99\xc7\x07\x0e\x45\x7e\xbe\xcb\xb6\xca\xf2\x07\x3c\x4b\x60\xfe\x82\x7c\x3c\xce\x0d\xae\xca\xd6\x7c\x28\x5a\x13\xd9\x4f\xf1\x7f\x9f\xe0\xcb\xee\xd7\xd7\xb8\x4b\x92\x65\xf1\xf8\x98\xd5\x77\xc9\xaf\x01\x00\xc4\xd5\x3e\x68\xe2\x03\x00\x00")
 
 func _1528395630_repo_external_alwaysUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -734,7 +744,7 @@ func _1528395630_repo_external_alwaysUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395631_reset_changeset_external_deleted_atDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\xd2\xd5\x55\xf0\xcb\x57\x48\xc9\x2f\xcf\x53\xc8\xcd\x4c\x2f\x4a\x2c\xc9\xcc\xcf\x53\x28\xce\xcc\x4b\x4e\x55\x28\xc9\x48\x55\x28\x2d\x40\x12\x2e\x4a\x2d\x4e\x2d\x29\x56\x48\x49\x2c\x49\xd4\xe3\xe2\x72\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x04\x00\x00\xff\xff\x21\xb8\x7c\x6b\x4a\x00\x00\x00")
+var __1528395631_reset_changeset_external_deleted_atDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x4a\x00\xb5\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x2d\x2d\x20\x4e\x6f\x20\x64\x6f\x77\x6e\x20\x6d\x69\x67\x72\x61\x74\x69\x6f\x6e\x20\x73\x69\x6e\x63\x65\x20\x74\x68\x65\x20\x75\x70\x20\x6d\x69\x67\x72\x61\x74\x69\x6f\x6e\x20\x72\x65\x73\x65\x74\x73\x20\x64\x61\x74\x61\x2e\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x21\xb8\x7c\x6b\x4a\x00\x00\x00")
 
 func _1528395631_reset_changeset_external_deleted_atDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -754,7 +764,7 @@ func _1528395631_reset_changeset_external_deleted_atDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395631_reset_changeset_external_deleted_atUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x0a\x0d\x70\x71\x0c\x71\x55\x48\xce\x48\xcc\x4b\x4f\x2d\x4e\x2d\x29\x56\x08\x76\x0d\x51\x48\xad\x28\x49\x2d\xca\x4b\xcc\x89\x4f\x49\xcd\x49\x2d\x49\x4d\x89\x4f\x2c\x51\xb0\x55\xf0\x0b\xf5\xf1\x51\x08\xf7\x70\x0d\x72\xc5\xaa\xc0\x33\x58\xc1\xcf\x3f\x04\xac\xca\x9a\x8b\xcb\xd9\xdf\xd7\xd7\x33\xc4\x9a\x0b\x10\x00\x00\xff\xff\x72\x63\xf7\x6c\x69\x00\x00\x00")
+var __1528395631_reset_changeset_external_deleted_atUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x69\x00\x96\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x55\x50\x44\x41\x54\x45\x20\x63\x68\x61\x6e\x67\x65\x73\x65\x74\x73\x20\x53\x45\x54\x20\x65\x78\x74\x65\x72\x6e\x61\x6c\x5f\x64\x65\x6c\x65\x74\x65\x64\x5f\x61\x74\x20\x3d\x20\x4e\x55\x4c\x4c\x20\x57\x48\x45\x52\x45\x20\x65\x78\x74\x65\x72\x6e\x61\x6c\x5f\x64\x65\x6c\x65\x74\x65\x64\x5f\x61\x74\x20\x49\x53\x20\x4e\x4f\x54\x20\x4e\x55\x4c\x4c\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x72\x63\xf7\x6c\x69\x00\x00\x00")
 
 func _1528395631_reset_changeset_external_deleted_atUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -774,7 +784,7 @@ func _1528395631_reset_changeset_external_deleted_atUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395632_drop_closest_dumpDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\x54\xef\x6f\x9b\x3a\x14\xfd\xce\x5f\x71\x54\x51\x25\xe9\x4b\x50\x7f\x3e\xbd\x57\x94\x49\x59\xe2\xb4\x6c\xa9\xa9\x08\x59\xb7\x4f\x94\x81\xd3\x79\x72\x70\x6a\x4c\xbb\x4a\xfd\xe3\x27\x20\x0d\xd0\x64\xed\xa6\xce\x9f\xb8\xd7\xe7\xfe\x3a\x07\xdf\x5e\x0f\x54\x6a\x76\x8a\xeb\x48\x2e\x16\x5c\x5f\x83\xa7\x08\xa1\x58\xca\xd4\x1d\x8b\x71\x2f\x55\xdc\x45\x2a\xc1\x75\x2b\xc5\x6d\x26\x35\x8b\x2d\xc3\x78\x4f\xce\x1c\x6a\x1b\x46\xaf\x07\x8f\xf5\x62\x36\xe7\x09\xc3\x3c\x4b\x22\xcd\x65\x82\xd2\x8e\xc1\x13\x1c\x9c\x1c\xfe\x77\xf4\xff\xc9\xbf\xfb\xc7\x81\x48\xf9\x3c\x58\x64\x42\xf3\x60\xa9\xe4\x77\x16\x69\xcb\x18\x7a\x64\xe0\x13\x8c\x67\x74\xe8\x3b\x2e\x45\x24\x64\xca\x52\x1d\xc4\xd9\x62\xd9\x56\x6c\x29\x53\xae\xa5\x7a\x80\x66\x3f\x74\x17\x3b\x65\x8f\x3b\x2b\x73\x19\xea\x6f\xab\x4f\xad\xc2\x3b\xa6\xd2\x50\x04\x82\x2f\xb8\x06\x4f\x34\xbb\x61\xaa\x03\x8f\xf8\x33\x8f\x4e\x31\x25\xbe\x3b\x46\xd1\x42\x9e\x3b\xc5\x60\x0a\xd3\x34\x00\x60\x44\x86\x93\x81\x47\x8a\xef\xfc\x08\x9e\xb0\xf0\x86\x05\x4a\xde\x43\xb1\x48\xaa\xd8\x5e\xdf\x71\xcc\x85\x0c\xf5\x31\x4e\xfb\xd8\xaf\xdc\x73\x99\x25\x71\x91\xb8\x56\x62\xd7\x73\xaf\xfc\x2f\x97\xa4\x84\x15\x8c\xad\x03\xc6\xae\xd7\xa8\x53\xbb\xca\xcf\x95\xe3\x9f\xc3\x23\xc3\x99\x37\x75\x3e\x91\x27\x64\x9b\xc7\x5d\x54\xa4\x54\x7c\xe4\x54\x28\x96\xe8\xa0\xb4\xbb\x88\xb9\x62\x85\x14\x9d\x7c\xce\x76\x23\x77\x7e\xa6\x64\x42\x86\x3e\x84\xb5\x87\xb1\xe7\x5e\x6c\x41\xd4\x50\x91\xb5\xd7\x45\x6b\xd0\x2a\xa1\xc5\x7c\x65\x9d\x14\x11\xae\xce\x89\x47\x10\x59\x35\xad\xfa\x30\x0f\x30\xa0\x23\x44\xd6\x5a\xb0\x3e\xcc\xc3\xad\x35\x66\xd4\x71\xe9\xeb\xd5\x47\x7f\xbf\x7a\x07\xc2\xd8\x70\x96\xed\xfc\x8a\xaf\x17\xd9\x2a\x34\x13\x01\x4f\x12\xa6\x0a\xd6\x9b\x41\x2b\x0d\x3b\xaf\xce\x2a\xac\xb5\x7a\xab\xc8\x55\x4e\x81\x0f\xae\x43\x9f\x53\xe0\xd2\x46\x44\xbf\x50\xaa\x9c\xbf\xc1\x8a\xa8\x9b\x1b\xfc\x08\xab\xf1\x07\xbd\x41\xaa\x37\xb7\x3f\x6a\x21\x4c\xe2\xdf\x68\xbf\xd1\x71\x01\x79\x1a\x68\x8b\xd6\x69\xf6\xf5\x36\x63\xea\xa1\x71\xd5\x69\x2a\xbd\x55\x30\x88\x35\x66\xe2\xba\x97\x8d\x00\x9e\xaf\x01\x8e\x7f\x70\x60\x37\xfc\xce\x18\x1c\xef\x60\x1e\xc3\x3f\x27\x9b\x94\x95\x3b\xa9\x19\x42\xe8\x08\xce\xd8\xde\xda\x50\xbe\x4d\xac\x3d\x38\xd4\x77\xeb\x9b\xa6\x7a\x10\xe5\x46\x2b\x9c\xe5\x8b\x28\x22\x9a\xfc\x55\xcb\xe6\x39\x93\x05\xb8\x62\xb1\x06\x5c\x39\x73\x90\x79\x84\x89\xf3\x91\xa0\x5d\xa6\x96\x52\xe3\xf1\x11\xad\xdd\x56\x67\x63\xf4\xaa\x45\x8b\xc7\x70\xa6\xa0\xae\x0f\x3a\x9b\x4c\x5e\x62\x03\x94\x7c\xf6\x6b\xa1\xf6\x1f\xb1\x56\xb7\x73\x95\x4a\x0f\xa1\x23\xdb\x30\x4d\x4c\x06\xf4\x6c\x36\x38\x23\x58\x8a\xe5\x4d\x7a\x2b\x6c\xc3\x18\xba\x17\x17\x8e\x6f\x1b\x3f\x03\x00\x00\xff\xff\xdd\x6e\x8a\x8f\xff\x06\x00\x00")
+var __1528395632_drop_closest_dumpDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\x54\x6b\x6f\xe2\x38\x14\xfd\xee\x5f\x71\x54\xa5\x02\xba\x10\xf5\xb9\xda\x2d\x62\x25\x16\x4c\x9b\x5d\xea\x54\x21\x4c\x67\x3e\xa5\x99\xc4\x74\x3c\x32\x31\x75\x4c\x3b\x95\xfa\xe3\x47\x79\x94\x24\x85\x56\x23\x75\x84\x85\xfc\x38\xd7\xf7\xdc\x73\xe2\xdb\xeb\x81\x29\xc3\xcf\x71\x1b\xa9\xe5\x52\x98\x5b\x88\x14\x21\x34\x4f\xb9\x7e\xe0\x31\x1e\x95\x8e\xbb\x48\x15\x84\x69\xa5\xb8\x5f\x2b\xc3\x63\x9b\x90\x7f\xe9\x85\xc3\xfa\x84\xf4\x7a\xf0\x78\x2f\xe6\x0b\x91\x70\x2c\xd6\x49\x64\x84\x4a\x50\xac\x63\x88\x04\x47\x67\xc7\x7f\x9d\xfc\x7d\xf6\xe7\xe1\x69\x20\x53\xb1\x08\x96\x6b\x69\x44\xb0\xd2\xea\x3b\x8f\x8c\x4d\x46\x1e\x1d\xfa\x14\x93\x39\x1b\xf9\x8e\xcb\x10\x49\x95\xf2\xd4\x04\xf1\x7a\xb9\x6a\x6b\xbe\x52\xa9\x30\x4a\x3f\xc1\xf0\x1f\xa6\x8b\xbd\x82\xe3\x5e\xb9\x5c\x85\xe6\x5b\x39\x35\x3a\x7c\xe0\x3a\x0d\x65\x20\xc5\x52\x18\x88\xc4\xf0\x3b\xae\x3b\xf0\xa8\x3f\xf7\xd8\x0c\x33\xea\xbb\x13\xe4\x14\xb2\xbb\x53\x0c\x67\xb0\x2c\x02\x00\x63\x3a\x9a\x0e\x3d\x9a\xcf\xb3\x21\x45\xc2\xc3\x3b\x1e\x68\xf5\x08\xcd\x23\xa5\xe3\xfe\xe6\x4c\x60\x21\x55\x68\x4e\x71\x3e\xc0\x61\xb5\xbd\x50\xeb\x24\xce\x49\xd7\x52\xec\x7b\xee\x8d\xff\xe5\x9a\x16\xb0\x5c\xb1\x4d\xc0\xc4\xf5\x1a\x79\x6a\x47\xd9\xb8\x71\xfc\x4b\x78\x74\x34\xf7\x66\xce\x27\xfa\x82\x6c\x8b\xb8\x8b\x4a\x94\x4a\x8f\x2e\x56\xa1\xe6\x89\x09\x0a\x7d\xba\x88\x85\xe6\xb9\x15\x9d\xac\xce\x76\xe3\xee\x6c\xcc\xe8\x94\x8e\x7c\x48\xfb\x00\x13\xcf\xbd\xda\x81\xa8\xa1\x22\xfb\xa0\x8b\xd6\xb0\x55\x40\xf3\xfa\x8a\x3c\x29\x22\xdc\x5c\x52\x8f\x22\xb2\x6b\x5e\x0d\x60\x1d\x61\xc8\xc6\x88\xec\x8d\x61\x03\x58\xc7\x3b\x73\xcc\x99\xe3\x32\xb2\xe3\xa0\x99\x7d\xfc\xfb\xb3\x77\x20\xc9\xd6\x66\x41\x87\xbc\xc1\xe5\x5d\xb5\x72\xcf\x64\x20\x92\x84\xeb\x5c\xf5\x66\x50\xe9\x61\x67\x67\x6c\x09\xcd\x6b\x95\xf6\xc6\xbd\x32\xb2\xbc\x53\xe2\x3f\xd7\x61\xaf\x25\x70\x59\x23\x62\x90\x3b\x55\xd4\xdf\x50\x45\xd6\x97\x5b\xfa\x48\xbb\xf1\x05\x7d\xc0\xaa\x0f\xd3\x1f\xb7\x10\x26\xf1\x2f\xd0\x6f\x30\xce\x6b\x78\x29\x68\x8b\x64\x07\xe9\xfa\xeb\xfd\x9a\xeb\xa7\xc6\x51\x87\x90\x1d\x65\x34\x0d\x83\xdc\x60\xa6\xae\x7b\xdd\x08\x10\x59\x1b\x10\xf8\x03\x47\x55\x2b\xc8\x7e\xce\x04\x02\xff\xc0\x3a\x85\x7f\x49\xb7\x25\x2b\x7a\x52\x33\x84\xb2\x31\x9c\x49\x7f\x27\xa1\xac\x9b\xd8\x07\x70\x98\xef\xd6\x3b\x4d\xf5\x20\x32\x40\x8a\xec\xbf\x7c\x11\xd9\xf4\x95\x7e\x55\xb3\x79\xad\x64\x0e\xae\x54\xac\x01\xcb\xcd\x0c\x64\x9d\x60\xea\xfc\x4f\xd1\xce\xd1\x5a\x29\x83\xe7\x67\xb4\xf6\x5b\x9d\xad\xd2\x2b\x8a\xb6\x88\xe1\xcc\xc0\x5c\x1f\x6c\x3e\x9d\xbe\xa7\x06\x18\xfd\xec\xd7\xaa\xeb\xbf\x81\xdb\xad\x5a\x7d\x9d\xb9\x54\xec\x50\x36\xee\x13\xcb\xc2\x74\xc8\x2e\xe6\xc3\x0b\x8a\x95\x5c\xdd\xa5\xf7\xb2\x4f\xc8\xc8\xbd\xba\x72\xfc\x3e\xf9\x39\x00\xdd\x6e\x8a\x8f\xff\x06\x00\x00")
 
 func _1528395632_drop_closest_dumpDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -794,7 +804,7 @@ func _1528395632_drop_closest_dumpDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395632_drop_closest_dumpUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x09\xf2\x0f\x50\x70\x0b\xf5\x73\x0e\xf1\xf4\xf7\x53\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x48\xce\xc9\x2f\x4e\x2d\x2e\x89\x4f\x29\xcd\x2d\xd0\x28\x49\xad\x28\xd1\x51\x40\x26\x33\xf3\x4a\x52\xd3\x53\x8b\x34\xad\xb9\xb8\x9c\xfd\x7d\x7d\x3d\x43\xac\xb9\x00\x01\x00\x00\xff\xff\xe3\x10\xa3\x75\x52\x00\x00\x00")
+var __1528395632_drop_closest_dumpUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x52\x00\xad\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x46\x55\x4e\x43\x54\x49\x4f\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x63\x6c\x6f\x73\x65\x73\x74\x5f\x64\x75\x6d\x70\x28\x74\x65\x78\x74\x2c\x20\x74\x65\x78\x74\x2c\x20\x74\x65\x78\x74\x2c\x20\x69\x6e\x74\x65\x67\x65\x72\x29\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xe3\x10\xa3\x75\x52\x00\x00\x00")
 
 func _1528395632_drop_closest_dumpUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -814,7 +824,7 @@ func _1528395632_drop_closest_dumpUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395633_drop_repo_deleted_at_unusedDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x4a\x2d\xc8\xe7\x52\x00\x03\x47\x17\x17\x05\x67\x7f\xbf\xe0\x90\x20\x47\x4f\xbf\x10\x85\x94\xd4\x9c\xd4\x92\xd4\x94\xf8\xc4\x92\xf8\xd2\xbc\xd2\xe2\xd4\x14\x05\x67\x0f\x57\x67\x6f\x05\x0d\x0d\x84\x8c\x82\x67\xb0\x82\x5f\xa8\x8f\x8f\xa6\xa6\x35\x17\x97\xb3\xbf\xaf\xaf\x67\x88\x35\x17\x20\x00\x00\xff\xff\xff\xfd\x7a\x6a\x67\x00\x00\x00")
+var __1528395633_drop_repo_deleted_at_unusedDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x67\x00\x98\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x72\x65\x70\x6f\x0a\x20\x20\x20\x20\x20\x20\x41\x44\x44\x20\x43\x4f\x4e\x53\x54\x52\x41\x49\x4e\x54\x20\x64\x65\x6c\x65\x74\x65\x64\x5f\x61\x74\x5f\x75\x6e\x75\x73\x65\x64\x20\x43\x48\x45\x43\x4b\x20\x28\x28\x64\x65\x6c\x65\x74\x65\x64\x5f\x61\x74\x20\x49\x53\x20\x4e\x55\x4c\x4c\x29\x29\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xff\xfd\x7a\x6a\x67\x00\x00\x00")
 
 func _1528395633_drop_repo_deleted_at_unusedDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -834,7 +844,7 @@ func _1528395633_drop_repo_deleted_at_unusedDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395633_drop_repo_deleted_at_unusedUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x04\xc0\x3b\x0e\x02\x21\x10\x06\xe0\x7e\x4e\xf1\xdf\x83\x0a\x70\x34\x93\xf0\x30\x30\x85\x1d\x31\x61\x3a\xa3\x66\x17\xee\xbf\x5f\xe0\x87\x14\x47\xe4\x93\x72\x83\xfa\x90\x18\x87\xfd\x7f\x04\xdc\x5a\x7d\x22\xd6\xd2\xb5\x79\x29\x0a\xb9\x83\x5f\xd2\xb5\x63\xda\xc7\x96\xcd\xf1\x5e\x63\x7f\xf7\x69\xd3\x11\xc5\x9a\xb3\xa8\xa3\x2b\x00\x00\xff\xff\xfc\xa3\x7c\x44\x51\x00\x00\x00")
+var __1528395633_drop_repo_deleted_at_unusedUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x51\x00\xae\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x72\x65\x70\x6f\x0a\x20\x20\x44\x52\x4f\x50\x20\x43\x4f\x4e\x53\x54\x52\x41\x49\x4e\x54\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x64\x65\x6c\x65\x74\x65\x64\x5f\x61\x74\x5f\x75\x6e\x75\x73\x65\x64\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xfc\xa3\x7c\x44\x51\x00\x00\x00")
 
 func _1528395633_drop_repo_deleted_at_unusedUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -854,7 +864,7 @@ func _1528395633_drop_repo_deleted_at_unusedUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395634_lsif_unify_dumps_and_uploadsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x56\xdd\x72\xa3\x38\x13\xbd\xe7\x29\xfa\x9b\x1b\xc7\x55\x98\x07\xf8\x5c\x73\x41\x62\x65\x86\x5a\x7e\xbc\x58\xde\x64\xf6\xc6\xa3\x80\x1c\xab\x02\x88\xe8\xc7\xce\xbc\xfd\x16\xc8\x60\x81\x89\x67\x6b\x2f\x51\xb7\x4e\x9f\x3e\x3a\x6a\xb1\x58\x40\xcc\x15\xfd\x3f\xfc\xcc\x78\x59\x32\xf5\x13\x98\x04\x02\x82\x4a\x2a\x8e\x34\x87\x13\x17\xb9\x0b\x92\x03\x53\x33\x09\xef\x9a\x2b\x9a\x7b\x8e\x73\x8f\xbe\x05\xf1\xd2\x71\x16\x0b\x58\x09\x5e\x83\x3c\xb0\x12\x8e\x8c\x9e\x9c\x55\x9a\xac\xe1\xaf\x00\x3d\x41\x21\xd9\x7e\x97\xeb\xb2\x96\x26\x31\xa5\x52\x71\x41\x21\xd7\x75\xc1\x32\xa2\x28\x64\xbc\xd0\x65\xe5\xf8\x21\x46\x29\x60\xff\x3e\x44\x66\x93\xae\x0b\x4e\x72\x09\xfe\x6a\x05\xb5\xe0\x19\x95\x92\xe6\x3b\xa2\x00\x07\x11\xda\x60\x3f\x5a\xc3\x53\x80\xbf\xb7\x9f\xf0\x77\x12\xa3\xae\x40\xc9\x8f\x14\x2a\x7a\x82\x9c\xee\x89\x2e\x14\xec\x05\x2f\xc1\xc0\xb5\x08\x37\x6a\xb5\x81\x87\x24\xdc\x46\xb1\xbd\x03\xda\x86\x56\xe8\xd1\xdf\x86\xd8\xd4\x59\xf3\x5a\x17\x0d\xff\x71\x27\xf0\x42\xf7\x4d\x87\xa2\x21\xc2\xaa\x57\x90\x5c\x8b\x8c\x3a\xdb\xf5\xca\xc7\xa3\x7a\x1b\x84\x87\xbd\x7d\x85\x3d\xab\x98\x3c\xb4\x5f\x5d\x43\x15\x29\xe9\x70\x9f\xe2\x96\xb0\xe7\x76\x82\x78\x85\x9e\x07\x69\xbb\xfa\x8d\xfe\x82\x14\xc5\x7e\x84\x00\x27\xd6\x96\x36\xb2\xfc\x7c\xa3\xa0\x35\x97\x4c\x71\xf1\x6b\x67\x1c\xb1\x13\x9c\xab\x69\xa8\xe9\xdc\x1b\xe0\xb6\xae\x93\x88\x56\xc2\x0d\x98\x23\x93\xec\xa5\xa0\xd7\xf5\xa7\x41\x3f\x4d\x5f\x7e\x6e\x87\x33\xd0\x43\x12\x6f\x70\xea\x07\x31\x1e\x32\x38\xb7\x7b\x24\x05\xcb\x77\xd9\x81\x08\x39\xaa\x79\x9d\xf0\xdf\x8b\xd9\xbc\x0f\x34\x7b\xbb\x71\x0a\x4d\xf8\xf7\x85\x06\xfb\xad\x5b\xdc\xdc\x1c\x56\xe5\xf4\xc3\xdc\xe2\x09\xe5\xa5\x22\x8a\x0e\xaf\x73\x6f\x4c\xf2\x52\x50\xe7\x21\x45\x8d\xd5\x27\x6a\xdf\x39\x00\x00\x2c\x87\xfb\xe0\xdb\x06\xa5\x81\x1f\xc2\x3a\x0d\x22\x3f\xfd\x01\x7f\xa0\x1f\x6e\x1b\xbd\xb4\x02\x18\x3d\x63\x88\x13\x0c\xf1\x36\x0c\x4d\xf4\x8b\x11\xf5\xcb\x54\xac\x35\xe9\xc4\xfa\x9e\x15\xe6\x12\x4d\xc4\xda\x66\x6c\x92\xa6\xbd\x3e\xab\xbb\xf5\x30\x7b\xd7\x54\xd3\x7c\x76\x86\x24\xac\xd0\x82\xee\xa4\x2e\x4b\x72\x66\x3a\x8a\x28\x92\xbd\x29\x41\x32\x6a\x05\x6d\xef\x7f\x36\xc7\xae\x4b\x57\xfc\x74\x37\xef\xe9\x0a\x75\x7b\x7f\xd7\x73\x3f\x47\x7e\x93\xd9\x70\x64\xd5\xeb\x2e\xe3\x95\xa2\x1f\x23\x01\x9d\xf9\xd2\xe9\xce\xd3\x58\x21\x78\x6c\x83\xe8\x39\xd8\xe0\xcd\x84\x31\x20\x89\x07\xab\x77\xed\xea\x7c\xf9\xaf\x51\x6c\x8d\xc6\x58\x56\x6c\x6e\x1c\x18\x35\xd3\x9e\x14\x05\x54\xbc\x5a\x64\xbc\xac\x0b\xaa\x68\xde\x1b\xf2\x85\x64\x6f\xcd\xb8\x54\x87\xce\xa4\x8d\x84\x8d\x49\x83\x78\x83\x52\x0c\x41\xdc\x5d\x84\xa1\x47\x2f\x2e\x74\x7b\xcf\xb9\xad\xc3\x86\x9e\x72\x8d\x83\xdc\xb1\x23\xdc\x09\x23\x5c\x79\xc0\xb5\xce\xd3\xb5\x8f\xcc\x1d\x9f\x8a\x33\x87\x0d\x0a\xd1\x03\x36\x10\x9e\x4d\x4f\x7b\x17\x82\xda\xbb\x50\xd4\xde\x85\xa4\xf6\xce\x34\xb5\x77\x45\xd4\x5a\x1a\x53\xf5\x06\x64\x5b\x90\x9e\x6e\x03\x6f\x11\xd6\xde\x98\xf2\x63\x9a\x44\xd6\x8c\x01\x3d\x78\xa1\xe9\x87\x12\x04\x04\x3f\x49\xf3\x40\x9b\x1c\x73\x36\x2b\x14\x22\x8c\x60\x0c\xf0\xf4\x1d\xa5\xe8\x7c\x63\xff\xf7\x15\x66\xfd\x71\xcf\xae\xde\x7e\xf3\x16\xcb\xeb\x29\x68\x9e\xcc\xa6\xb9\x76\xbc\xf5\x0a\x5d\x96\x8c\x50\x56\xca\x48\xaf\x89\xc8\x48\xb6\x0e\xa6\x93\xca\x2e\x77\x51\xec\xb2\x3a\x12\x6e\x38\x5a\xb9\x60\xaf\xac\x22\x05\xe8\x8a\xbd\xeb\xe6\x2f\xa3\x92\x4a\x10\x56\x4d\xfc\xc7\x18\x99\x9a\x3f\xa6\xf1\x33\x72\xeb\xad\x86\x6d\x1c\xfc\xb9\x45\x70\x67\x9b\xca\xc4\x8d\xe3\xdb\x19\x90\x44\x51\x80\x97\xce\x3f\x01\x00\x00\xff\xff\x97\xa5\x87\x07\x2c\x0a\x00\x00")
+var __1528395634_lsif_unify_dumps_and_uploadsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x56\xdd\x72\xa3\x38\x13\xbd\xe7\x29\xfa\x9b\x1b\x3b\x55\x98\x07\xf8\x5c\x73\x41\x6c\x65\x86\x5a\x03\x5e\x2c\x6f\x32\x7b\xc3\x28\x20\xc7\xaa\x00\x22\xfa\xb1\x33\x6f\xbf\x05\x32\xb6\xc0\xd8\xb3\xb5\x97\xa8\x5b\xa7\x4f\x1f\x1d\xb5\x98\xcd\x20\xe2\x8a\xfe\x1f\x7e\x66\xbc\x2c\x99\xfa\x09\x4c\x02\x01\x41\x25\x15\x07\x9a\xc3\x91\x8b\xdc\x05\xc9\x81\xa9\x89\x84\x0f\xcd\x15\xcd\x3d\xc7\x79\x44\xdf\x82\x68\xee\x38\xb3\x19\x2c\x05\xaf\x41\xee\x59\x09\x07\x46\x8f\xce\x32\x89\xd7\xf0\x57\x80\x9e\xa1\x90\x6c\x97\xe6\xba\xac\xa5\x49\x4c\xa8\x54\x5c\x50\xc8\x75\x5d\xb0\x8c\x28\x0a\x19\x2f\x74\x59\x39\xfe\x0a\xa3\x04\xb0\xff\xb8\x42\x66\x93\xae\x0b\x4e\x72\x09\xfe\x72\x09\xb5\xe0\x19\x95\x92\xe6\x29\x51\x80\x83\x10\x6d\xb0\x1f\xae\xe1\x39\xc0\xdf\xdb\x4f\xf8\x3b\x8e\x50\x57\xa0\xe4\x07\x0a\x15\x3d\x42\x4e\x77\x44\x17\x0a\x76\x82\x97\x60\xe0\x5a\x84\x3b\xb5\xda\xc0\x22\x5e\x6d\xc3\xc8\xde\x01\x6d\x43\x4b\xf4\xe4\x6f\x57\xd8\xd4\x59\xf3\x5a\x17\x44\x5d\x77\x02\xaf\x74\xd7\x74\x28\x68\xc9\x0f\xac\x7a\x03\xc9\xb5\xc8\xa8\xb3\x5d\x2f\x7d\x3c\xa8\xb7\x41\xb8\xdf\xdb\x57\xd8\xb1\x8a\xc9\x7d\xfb\xd5\x35\x54\x91\x92\xf6\xf7\x29\x6e\x09\x7b\x6a\x27\x88\x96\xe8\xa5\x97\x96\xd6\xef\xf4\x17\x24\x28\xf2\x43\x04\x38\xb6\xb6\xb4\x91\xf9\xed\x8d\x82\xd6\x5c\x32\xc5\xc5\xaf\xd4\x38\x22\x15\x9c\xab\x71\xa8\xf1\xdc\x3b\xe0\xb6\xae\xa3\x88\x56\xc2\x1d\x98\x03\x93\xec\xb5\xa0\xd7\xf5\xc7\x41\x6f\xa6\xcf\x6f\xdb\xe1\x04\xb4\x88\xa3\x0d\x4e\xfc\x20\xc2\x7d\x06\xa7\x76\x0f\xa4\x60\x79\x9a\xed\x89\x90\x83\x9a\xd7\x09\xff\xbd\x98\xcd\x7b\x4f\xb3\xf7\x41\xa9\x61\xf8\xf7\x85\x7a\xfb\xad\x5b\xdc\xdc\x1c\x56\xe5\xf4\xd3\xdc\xe2\x11\xe5\xa5\x22\x8a\x76\xe6\x34\xd7\xb9\xc3\x56\xe4\xb5\xa0\xce\x22\x41\x8d\xd5\x47\x6a\x4f\x1d\x00\x00\x96\xc3\x63\xf0\x6d\x83\x92\xc0\x5f\xc1\x3a\x09\x42\x3f\xf9\x01\x7f\xa0\x1f\x6e\x1b\xbd\xb4\x02\x18\xbd\x60\x88\x62\x0c\xd1\x76\xb5\x32\xd1\x2f\x46\xd4\x2f\x63\xb1\xc6\x78\x63\xeb\x3b\x56\x98\x4b\x34\x12\x6b\x9b\xb1\x49\xa6\x66\xa5\xcb\xea\x6e\x3d\x4c\x3e\x34\xd5\x34\x9f\x9c\x20\x09\x2b\xb4\xa0\xa9\xd4\x65\x49\x4e\x4c\x07\x11\x45\xb2\x77\x25\x48\x46\xad\xa0\xed\xfd\x5b\x73\xec\xba\x74\xc5\x8f\xd3\x87\x33\x5d\xa1\xee\xef\x3f\xd1\xb8\xcc\x91\xdf\x64\x36\x1c\x59\xf5\x96\x66\xbc\x52\xf4\x73\x20\xa0\xf3\x30\x77\xba\xf3\x34\x56\x08\x9e\xda\x20\x7a\x09\x36\x78\xd3\x3b\xdd\x93\x72\x71\xd4\x5b\x9d\xb6\xab\x0f\xf3\x7f\x8d\x62\x6b\x34\xc4\xb2\x62\x0d\xb1\xd9\x0c\xc2\x66\xda\x93\xa2\x80\x8a\x57\xb3\x8c\x97\x75\x41\x15\xcd\xcf\x86\x7c\x25\xd9\x3b\x28\x0e\x6a\xdf\x99\x14\xa4\x31\x69\x10\x6d\x50\x82\x21\x88\x70\xdc\xab\x01\xd3\x81\x0b\xdd\xb3\xe7\xdc\xd6\x61\x9d\xbe\xc6\x53\x6e\x83\xa7\xa8\x3b\x74\x84\x3b\x62\x84\x2b\x0f\xb8\xd6\x79\xba\xf6\x91\xb9\xc3\x53\x71\x1e\x60\x83\x56\x68\x81\x0d\x84\x67\xd3\xd3\xde\x85\xa0\xf6\x2e\x14\xb5\x77\x21\xa9\xbd\x13\x4d\xed\x5d\x11\xd5\xde\x4d\xaa\x5e\x8f\xac\xf6\x6c\xba\xda\xeb\x11\xd6\xde\x90\xf2\x53\x12\x87\xd6\x8c\x01\xdd\xcd\x8c\xf6\x85\xa6\x9f\x4a\x10\x10\xfc\x28\xcd\x03\x6d\x72\xcc\xd9\x2c\xd1\x0a\x61\x04\x43\x80\xe7\xef\x28\x41\x8d\x64\x8a\xc2\xff\xbe\xc2\xe4\x7c\xdc\x93\x1e\x72\x33\xc1\xcc\x5b\x2c\xaf\xa7\x60\x8b\xd4\x8a\xd8\x8e\xb7\xb3\x42\x97\xa5\x16\xdf\xb5\x52\x06\x7a\x8d\x44\x06\xb2\x75\x30\x9d\x54\xd6\x0e\x4b\xb1\xcb\xea\x40\xb8\xae\x19\x33\x5a\xb9\x60\x6f\xac\x22\x05\xe8\x8a\x7d\xe8\xe6\x2f\xa3\x92\x4a\x10\x56\xa9\x1b\xcd\xb5\x7f\x4c\xc3\x67\xe4\xde\x5b\x0d\xdb\x28\xf8\x73\x8b\x60\x6a\x9b\xca\x38\xca\x38\xbe\xb9\x6a\x8b\x38\x0c\x03\x3c\x77\xfe\x19\x00\x97\xa5\x87\x07\x2c\x0a\x00\x00")
 
 func _1528395634_lsif_unify_dumps_and_uploadsDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -874,7 +884,7 @@ func _1528395634_lsif_unify_dumps_and_uploadsDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395634_lsif_unify_dumps_and_uploadsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x55\x4d\x6f\xe3\x36\x10\xbd\xfb\x57\x4c\xf7\xa2\x4d\xa1\xe8\x07\x34\x4d\x01\xaf\xcd\xed\x0a\xb0\xe5\xd4\x96\x9b\x45\x2f\x5e\x46\x1c\xc7\x44\x28\x52\xe1\x87\xb3\x69\xd1\xff\x5e\x48\x94\x6c\x4a\xb1\x90\x45\x8f\x1a\x0d\xdf\x1b\xce\xbc\x79\xbc\xbe\x86\x4c\x59\xfc\x05\xbe\x15\xaa\x2c\xb9\xfd\x06\xdc\x00\x05\x8d\x06\xf5\x11\x19\xbc\x28\xcd\x62\x30\x0a\xb8\x8d\x0c\x3c\x3b\x65\x91\x25\x93\xc9\x27\xf2\x7b\x9a\xdd\x4c\x26\xd7\xd7\x30\x65\x0c\x84\xe1\xfb\x9d\xab\x84\xa2\x0c\x0a\x25\x5c\x29\x0d\x58\xe5\xc3\xcc\x95\x95\x99\x4c\x17\x39\x59\x43\x3e\xfd\xb4\x20\x61\x18\x00\x60\x3a\x9f\xc3\x9e\x0b\x94\xb4\x44\xc8\xc9\xd7\x3c\x3e\x85\x8d\xa5\x16\x43\xf4\x9d\x8f\x64\xab\x1c\xb2\xed\x62\x01\x73\xf2\x79\xba\x5d\xe4\x10\x3d\x3b\x74\xc8\xa2\xf3\xd1\x3d\xe5\xc2\x69\xdc\x19\x57\x96\x54\xbf\x0e\x80\x4f\x7f\x2d\x2d\x9e\xac\xa6\xc5\x05\x66\x6d\x91\xed\xa8\x85\x3c\x5d\x92\x4d\x3e\x5d\xde\xc1\x7d\x9a\x7f\x69\x3e\xe1\xaf\x55\x46\x02\x34\x2e\xb9\x39\xfc\x68\x76\x4d\xc7\xe5\xe3\xae\x50\xd2\xe2\x77\xdb\x10\xfb\x56\xe6\x9a\x4a\xb3\x47\x0d\x0c\xf7\xd4\x09\x0b\x47\x2a\x1c\xc2\x5e\xab\x12\xf6\x1c\x05\x1b\x69\x23\xf8\xf0\x6c\xb5\xd8\x2e\x33\xf0\x9d\xf2\xd5\x6c\x48\x7e\xea\x91\x54\x2f\x1f\xaf\x3c\xd1\x1a\x2b\x51\xdf\xd9\x49\xfe\xec\x10\x0a\x25\x8d\xd5\x94\x4b\x0b\x2f\xdc\x1e\xba\x70\x45\xb5\xe5\x54\x00\x97\x0c\xbf\x8f\x51\xcf\xd7\xab\x3b\x98\xad\xb2\x4d\xbe\x9e\xa6\x59\x1e\xfc\xda\x69\xac\x94\xe1\x56\xe9\xd7\x9d\x17\xd7\x4e\x2b\x65\x6f\x26\xb3\x35\x99\xe6\x04\xb6\x59\xfa\xc7\x96\x40\x9a\xcd\xc9\xd7\xf7\x8f\xc1\x2a\x0b\x69\x3f\x9e\xb3\x62\xf0\x69\x31\xd4\x79\x57\x70\xff\x85\xac\x09\x7c\xf4\x4a\xb9\x85\xa8\x50\x65\x25\xd0\x22\x8b\xda\xdb\x6f\xd0\x02\x73\xe5\x83\x6f\xaf\x81\xbd\xd2\x80\xb4\x38\x40\x24\xf0\x91\x16\xaf\x51\xfd\xb7\x9a\x6c\xef\xe6\x75\x99\x01\xe9\x86\xe4\xcd\x14\x4f\x6a\xbd\x85\xe8\x57\x27\x9f\xa4\x7a\x91\xbf\xb5\xda\xbb\x40\x7b\xfa\xd1\x29\xea\x16\x2a\xad\x0a\x34\xa6\xf9\x8c\x5b\xcc\xb3\x86\x2e\xfd\x1f\xaa\xe6\x16\xa2\x7f\xfe\x8d\xfc\x85\xe6\x5a\x55\xc0\x5c\x25\x78\x51\x93\xfb\x0d\x7c\x67\x60\x8d\x54\x42\x1a\x0f\xb5\x54\x47\x04\x2a\x04\xf8\x85\x8a\x81\xcb\xeb\x4a\xab\x47\x8d\xc6\xc4\x40\x25\x6b\x56\x07\x59\xab\x32\x03\x5c\x5a\x05\xf6\x80\x60\xea\x86\x58\xfa\x20\x70\x92\x66\x1b\xb2\xce\x21\xcd\xf2\x55\x6f\x66\xcd\x45\xc2\xc1\x7d\xf0\x93\xfb\xe0\x47\x17\xf7\x9a\x1b\xfb\x56\xc6\xc3\x4d\x8e\x2f\x2c\xaf\x3f\x19\x08\x3f\x0e\xda\x1d\x87\xbd\x8d\x87\x8d\x9c\x5c\xc1\x86\x2c\xc8\xcc\x4f\xd6\x25\x61\x79\x2e\x39\x17\xe8\x92\x73\x89\x2e\x39\x17\xe9\x92\xb6\x4c\x97\xbc\x29\x34\x08\x0d\x4b\x4d\x7a\xc5\x36\x20\xa7\x72\x6b\xf8\xa0\x60\x97\x0c\x4b\xfe\xbc\x5e\x2d\x43\x57\x34\xe0\x5a\xd5\x7b\xf5\xfd\xd4\x93\x5f\xa0\x11\x25\x58\x3b\xa2\x46\x05\x81\x36\x5a\xa0\xf3\x82\x9c\x2c\xf6\x6c\x0e\x06\xe8\xde\xa2\x86\x4a\x55\x4e\x50\xcb\xd5\xa8\xc6\x7a\x7e\x74\xda\x96\xda\x8c\x3a\xd8\x9b\x1f\x3a\x3a\x14\x7d\x1f\xc1\x3b\x59\x03\x1d\x00\x74\xef\x4e\x7b\xa3\x31\x9e\x35\xc9\xa6\x4b\x02\x9d\x42\x4f\xf7\x7f\x93\xde\xb5\xb8\x3d\x70\xd9\xea\x5a\xa3\x3a\x52\xc1\xd9\xae\x38\x50\x6d\x86\xc8\x17\x52\xfe\x2f\x59\x68\x90\x07\x2c\x9e\xde\x50\x0d\x13\x3a\xa2\x37\x56\x5b\x3d\xe1\xeb\x48\x27\x9a\x7f\xa3\x07\x47\x3c\x7a\x04\x6a\xec\x21\x18\x01\x0f\x1f\xb0\x11\xc4\x20\x65\x14\xe6\xc8\x0d\x7f\x10\xf8\x96\x7d\x0c\x74\xf4\x80\x97\xda\x4c\x63\xbd\x5c\x25\x37\x86\xcb\xc7\xf6\x55\x6c\xdf\xb2\x80\xbc\x43\xf3\xab\xd8\xbd\x5a\x6d\xd4\xbf\x4a\x57\x3d\x40\x0a\x47\x8e\x2f\xde\x48\xfb\x3a\x2e\xb9\xe4\x25\xff\xbb\x76\x74\x86\x50\x1c\xa8\x7c\x44\xd3\x51\xfe\x99\x92\xfb\xde\xe2\x6c\x5a\x23\x03\x97\xfc\x3c\x30\x11\xa0\xa6\xe7\xf5\xf0\x9e\x83\x0c\x0d\x64\xb6\x5a\x2e\xd3\xfc\x66\xf2\x5f\x00\x00\x00\xff\xff\x04\x43\xf1\x1c\x2f\x0a\x00\x00")
+var __1528395634_lsif_unify_dumps_and_uploadsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x56\x4d\x6f\xe3\x36\x10\xbd\xfb\x57\x4c\xf7\xe2\xa4\x50\xf4\x03\x9a\xa6\x80\xd7\xe6\x76\x0d\xd8\x52\x6a\xcb\xcd\xa2\x17\x2f\x23\x8e\x63\x22\x14\xa9\xf0\xc3\xde\xb4\xe8\x7f\x2f\x24\x4a\x16\xad\x58\x48\xd0\xa3\xc8\xe1\x7b\x8f\x33\x6f\x86\xba\xb9\x81\x44\x59\xfc\x05\xbe\xe7\xaa\x28\xb8\xfd\x0e\xdc\x00\x05\x8d\x06\xf5\x01\x19\x1c\x95\x66\x11\x18\x05\xdc\x8e\x0d\xbc\x38\x65\x91\xc5\xa3\xd1\x67\xf2\xfb\x3c\xb9\x1d\x8d\x6e\x6e\x60\xc2\x18\x08\xc3\x77\x5b\x57\x0a\x45\x19\xe4\x4a\xb8\x42\x1a\xb0\xca\x2f\x33\x57\x94\x66\x34\x59\x64\x64\x05\xd9\xe4\xf3\x82\x84\xcb\x00\x00\x93\xd9\x0c\x76\x5c\xa0\xa4\x05\x42\x46\xbe\x65\xd1\x69\xd9\x58\x6a\x31\x44\xdf\xfa\x95\x24\xcd\x20\xd9\x2c\x16\x30\x23\x5f\x26\x9b\x45\x06\xe3\x17\x87\x0e\xd9\xb8\x3b\xba\xa3\x5c\x38\x8d\x5b\xe3\x8a\x82\xea\xd7\x1e\xf0\x69\xd7\xd2\xfc\xd9\x6a\x9a\x5f\x60\xd6\x16\xd9\x96\x5a\xc8\xe6\x4b\xb2\xce\x26\xcb\x7b\x78\x98\x67\x5f\xeb\x4f\xf8\x2b\x4d\x48\x80\xc6\x25\x37\xfb\x8f\x46\x57\x74\x5c\x3e\x6d\x73\x25\x2d\xfe\xb0\x35\xb1\x4f\x65\xa6\xa9\x34\x3b\xd4\xc0\x70\x47\x9d\xb0\x70\xa0\xc2\x21\xec\xb4\x2a\x60\xc7\x51\xb0\x81\x34\x82\x5f\x9e\xa6\x8b\xcd\x32\x01\x9f\x29\xaf\x66\x4d\xb2\x53\x8e\xa4\x3a\x5e\x5d\x7b\xa2\x15\x96\xa2\xba\xb3\x93\xfc\xc5\x21\xe4\x4a\x1a\xab\x29\x97\x16\x8e\xdc\xee\xdb\xe5\x92\x6a\xcb\xa9\x00\x2e\x19\xfe\x18\xa2\x9e\xad\xd2\x7b\x98\xa6\xc9\x3a\x5b\x4d\xe6\x49\x16\x6c\x6d\x35\x96\xca\x70\xab\xf4\xeb\xd6\x9b\x6b\xab\x95\xb2\xb7\xa3\xe9\x8a\x4c\x32\x02\x9b\x64\xfe\xc7\x86\xc0\x3c\x99\x91\x6f\xef\x1f\x83\x34\x09\x82\xe0\xaa\x8b\x8a\xc0\xa3\x47\x50\xc5\x5d\xc3\xc3\x57\xb2\x22\x70\xe5\x9d\x72\x07\xe3\x5c\x15\xa5\x40\x8b\x6c\xdc\xdc\x7e\x8d\x16\x98\x2b\x1e\x7d\x7a\x0d\xec\x94\x06\xa4\xf9\x1e\xc6\x02\x9f\x68\xfe\x3a\xae\x76\xcb\xd1\xe6\x7e\x56\xc9\x0c\x48\xd7\x24\xab\x6b\x7e\x72\xeb\x1d\x8c\x7f\x75\xf2\x59\xaa\xa3\xfc\xad\xf1\xde\x05\xda\xd3\x46\xeb\xa8\x3b\x28\xb5\xca\xd1\x98\xfa\x33\x6a\x30\x3b\x0f\x5d\xda\xef\xbb\xe6\x0e\xc6\xff\xfc\x3b\xf6\x17\x9a\x69\x55\x02\x73\xa5\xe0\x79\x45\xee\x3b\xf0\x9d\x82\xd5\x56\x09\x69\x3c\xd4\x52\x1d\x10\xa8\x10\xe0\x1b\x2a\x02\x2e\x6f\x4a\xad\x9e\x34\x1a\x13\x01\x95\xac\x6e\x1d\x64\x8d\xcb\x0c\x70\x69\x15\xd8\x3d\x82\xa9\xda\xd7\xd2\x47\x81\xa3\x79\xb2\x26\xab\x0c\xe6\x49\x96\x86\xcc\x57\xf5\x45\xc3\xc2\x7d\xf2\x95\xfb\xe4\x4b\xd7\x26\xc2\x27\x37\xf2\xa9\x8c\xfa\x9d\x1c\x5d\x68\x5e\x7f\x32\x30\x7e\x14\xa4\x3b\x0a\x73\x1b\xf5\x13\x39\xba\x86\x35\x59\x90\xa9\xaf\xac\x8b\x43\x79\x2e\xee\x04\xba\xb8\x93\xe8\xe2\x4e\xa4\x8b\x1b\x99\x2e\x7e\x23\xd4\xc5\x83\x52\xe3\x33\xb1\x2e\x0e\xe5\xba\xf8\x4c\xb0\x8b\xfb\x92\xbf\xac\xd2\x65\x38\x15\x0d\xb8\xc6\xf5\xb5\x16\xf8\xe9\xcc\x7e\x81\x47\x94\x60\x4d\x89\x6a\x17\x04\xde\x68\x80\xba\x06\x39\x8d\xd8\x6e\x38\x18\xa0\x3b\x8b\x1a\x4a\x55\x3a\x41\x2d\x57\xf2\x43\xf3\xa8\xcd\x55\x3d\x8c\x5a\xd8\xdb\x0f\x1d\xed\x5d\xbc\x87\x50\x49\x5d\xd5\x5e\x09\x01\xac\x3a\xbb\xd1\x10\xcf\x8a\x24\x93\x25\x81\x2c\x3d\x8b\xbe\x20\xab\xd9\x69\x0f\x5c\x1e\x75\xcd\xa0\x3a\x50\xc1\xd9\x36\xdf\x53\x6d\xfa\xc8\x17\x42\xfe\x2f\x59\x67\xd1\x6d\xbe\xc7\xfc\xf9\x0d\x55\x3f\xa0\x25\x7a\x33\x6a\xcb\x67\x7c\x6d\xb9\xfa\x20\xd5\xde\xe0\xc1\x81\x19\x3d\x00\x75\x39\x7a\x10\x3c\x68\x8d\x21\x71\x41\xc8\x20\xcc\x81\x1b\xfe\x28\xf0\xed\x7b\x32\x04\x3a\x78\xc0\x77\xc5\x54\x63\xd5\x5c\x05\x37\x86\xcb\xa7\xe6\x55\x6c\xde\xb2\x80\xbc\x45\xf3\xad\x98\x26\x67\xab\xfe\x55\xba\x3e\x03\xa4\x70\xe0\x78\xf4\x83\xb4\x93\x5f\xfd\x3f\x15\x5c\xf2\x82\xff\x5d\x4d\x74\x86\x90\xef\xa9\x7c\x42\xd3\x3e\x9f\x7f\xce\xc9\x43\x18\x3f\x59\x37\x83\x0c\x5c\xfc\x73\x6f\x88\x00\x35\x67\x4f\x0a\xbc\x37\x41\xfa\x03\x64\x9a\x2e\x97\xf3\xec\x76\xf4\xdf\x00\x04\x43\xf1\x1c\x2f\x0a\x00\x00")
 
 func _1528395634_lsif_unify_dumps_and_uploadsUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -894,7 +904,7 @@ func _1528395634_lsif_unify_dumps_and_uploadsUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395635_check_campaign_name_not_blankDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x2b\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x0b\x0e\x09\x72\xf4\xf4\x0b\x41\xc8\xc4\xe7\x25\xe6\xa6\xc6\xe7\xe5\x97\xc4\x27\xe5\x24\xe6\x65\x5b\x73\x71\x39\xfb\xfb\xfa\x7a\x86\x58\x73\x01\x02\x00\x00\xff\xff\x1f\xba\xb9\x0c\x51\x00\x00\x00")
+var __1528395635_check_campaign_name_not_blankDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x51\x00\xae\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x63\x61\x6d\x70\x61\x69\x67\x6e\x73\x20\x44\x52\x4f\x50\x20\x43\x4f\x4e\x53\x54\x52\x41\x49\x4e\x54\x20\x63\x61\x6d\x70\x61\x69\x67\x6e\x73\x5f\x6e\x61\x6d\x65\x5f\x6e\x6f\x74\x5f\x62\x6c\x61\x6e\x6b\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x1f\xba\xb9\x0c\x51\x00\x00\x00")
 
 func _1528395635_check_campaign_name_not_blankDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -914,7 +924,7 @@ func _1528395635_check_campaign_name_not_blankDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395635_check_campaign_name_not_blankUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x0a\x0d\x70\x71\x0c\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x2b\x56\x08\x76\x0d\x51\xc8\x4b\xcc\x4d\x55\xb0\x55\x50\x77\x86\x8a\x2a\x28\xab\x2b\xd4\xd4\x20\x14\xe9\x65\xa6\x28\x84\x7b\xb8\x06\x21\xe9\xd3\x83\xe9\x51\xb7\xe6\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x41\x36\xd8\xd1\xc5\x45\xc1\xd9\xdf\x2f\x38\x24\xc8\xd1\xd3\x2f\x04\x21\x11\x0f\xd2\x19\x9f\x97\x5f\x12\x9f\x94\x93\x98\x97\xad\xe0\xec\xe1\xea\xec\xad\xa0\x01\x36\xcf\xc6\x4e\x41\x5d\xdd\xca\xaa\x24\xb5\xa2\x44\xd3\x9a\x8b\xcb\xd9\xdf\xd7\xd7\x33\xc4\x9a\x0b\x10\x00\x00\xff\xff\x46\x8c\x53\x7d\xbd\x00\x00\x00")
+var __1528395635_check_campaign_name_not_blankUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x4c\xcc\xcd\x0a\x82\x40\x14\xc5\xf1\xfd\x3c\xc5\x81\x16\x53\x1b\x1f\xc0\xa9\x60\x1c\x2f\x29\xf9\x11\x7a\xa3\xa5\x4c\x25\x21\xe5\x14\x34\x8b\x16\x3e\x7c\x08\x85\x6e\xff\xf7\xfe\x4e\x44\xbb\xb4\x50\x42\x1c\x0f\xb1\x66\xc2\xc5\xf6\x2f\xdb\xdd\xdc\x1b\x35\x31\x9c\xed\x5b\x6c\x20\xcd\xaf\x62\x21\x31\x0c\xd3\x53\xd0\x5d\x71\x4a\xa8\x9a\xb9\xe0\x6f\xa4\x12\x3a\x63\xaa\xc0\x3a\xca\xe6\xc3\x3a\x8e\x61\xca\xa2\xe6\x4a\xa7\x05\x4f\x87\x66\x94\x8d\x7b\xfa\xe6\xfc\xb0\xee\x0e\x93\x90\xd9\x63\x39\x56\xac\xb7\x90\x32\x0c\x7d\xfb\xf1\x2b\x25\x84\x29\xf3\x3c\x65\x25\xbe\x03\x00\x46\x8c\x53\x7d\xbd\x00\x00\x00")
 
 func _1528395635_check_campaign_name_not_blankUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -934,7 +944,7 @@ func _1528395635_check_campaign_name_not_blankUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395636_add_published_at_to_changeset_jobsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\xce\x48\xcc\x4b\x4f\x2d\x4e\x2d\x89\xcf\xca\x4f\x2a\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x28\x28\x4d\xca\xc9\x2c\xce\x48\x4d\x89\x4f\x2c\xb1\xe6\xe2\x72\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x04\x00\x00\xff\xff\xcb\x01\x9f\x51\x50\x00\x00\x00")
+var __1528395636_add_published_at_to_changeset_jobsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x50\x00\xaf\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x63\x68\x61\x6e\x67\x65\x73\x65\x74\x5f\x6a\x6f\x62\x73\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x70\x75\x62\x6c\x69\x73\x68\x65\x64\x5f\x61\x74\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xcb\x01\x9f\x51\x50\x00\x00\x00")
 
 func _1528395636_add_published_at_to_changeset_jobsDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -954,7 +964,7 @@ func _1528395636_add_published_at_to_changeset_jobsDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395636_add_published_at_to_changeset_jobsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\xce\x48\xcc\x4b\x4f\x2d\x4e\x2d\x89\xcf\xca\x4f\x2a\x56\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\x28\x28\x4d\xca\xc9\x2c\xce\x48\x4d\x89\x4f\x2c\x51\x28\xc9\xcc\x4d\x2d\x2e\x49\xcc\x2d\x28\xa9\xb2\xe6\xe2\x72\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x04\x00\x00\xff\xff\x86\xae\x59\x0b\x51\x00\x00\x00")
+var __1528395636_add_published_at_to_changeset_jobsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x51\x00\xae\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x63\x68\x61\x6e\x67\x65\x73\x65\x74\x5f\x6a\x6f\x62\x73\x20\x41\x44\x44\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x70\x75\x62\x6c\x69\x73\x68\x65\x64\x5f\x61\x74\x20\x74\x69\x6d\x65\x73\x74\x61\x6d\x70\x74\x7a\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x86\xae\x59\x0b\x51\x00\x00\x00")
 
 func _1528395636_add_published_at_to_changeset_jobsUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -974,7 +984,7 @@ func _1528395636_add_published_at_to_changeset_jobsUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395637_lsif_repository_idsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x54\x4f\x6f\x9b\x4e\x14\xbc\xf3\x29\x46\xbe\x38\xfe\xc9\x58\xfa\x5d\xed\xa6\x12\xc1\xdb\x06\x15\x43\x8a\x71\xd3\x1b\xa1\xec\x8b\xb2\x2a\x66\xc9\xee\x12\xb7\xdf\xbe\x02\x9b\x14\xfc\x07\x25\x3d\xc2\xbe\x79\xfb\xe6\xcd\xcc\xda\x36\x02\x69\x68\x8e\x87\x4c\x6e\xb7\xc2\x3c\x40\x68\xa4\x50\xa4\x49\xbd\x10\xc7\x4e\x2a\x3e\x85\x96\x10\x66\xac\xf1\x5c\x49\x43\x7c\x66\x59\x37\xec\xb3\x17\x2c\x2c\xcb\xb6\x2d\xdb\x46\xae\xc5\x63\x52\x95\xb9\x4c\xb9\xae\x7f\xd5\xff\x22\xd2\x46\x2a\x82\xcc\x39\x32\x99\x57\xdb\xc2\x72\xfc\x98\x45\x88\x9d\x1b\x9f\xf5\x20\x88\x58\xe0\xac\x18\xdc\xd0\xdf\xac\x02\x28\x2a\xa5\x16\x46\xaa\xdf\x49\x91\x6e\x29\x49\xcd\xa1\x10\x71\xd8\x39\x5c\x58\x6e\xc4\x9c\x98\x61\x13\x78\x5f\x37\x0c\x5e\xb0\x64\xdf\x7b\x7d\x93\x4e\xa7\x3d\xbb\x44\x49\x69\x20\x8b\x5e\xd9\xd5\xdf\xb2\x29\x46\xfb\xc2\xd1\x14\x75\xe9\x04\xf7\xb7\x2c\x62\xd0\x26\x35\x84\x6b\x8c\x33\xb9\x2d\x73\x32\xc4\xc7\xf3\x79\xa7\x47\xd2\x14\xbc\x4e\x74\x66\x94\x17\xa1\xc5\x8f\x9c\x4e\x47\x7a\xd3\x34\xed\x1c\x6d\x97\xd4\x24\x46\x94\x8b\xcb\x1b\x75\x96\x4b\xb8\x61\xb0\x8e\x23\xc7\x0b\x62\x8c\x2e\xae\xe5\x89\xb2\x9f\x23\xb8\xb7\xcc\xfd\x82\xce\xd5\xf8\xf0\x11\xe3\xf1\x7c\x6e\xe8\x97\x99\x2c\x0e\x82\xda\xa5\x2c\xab\xbc\xde\x44\x47\xd4\xcd\xdd\xb2\xa6\xdc\xbb\xbd\xc2\x9a\xc5\x1d\xa9\x70\x8d\xab\x35\xf3\x99\x1b\xa3\x56\x14\x9f\xa2\x70\xd5\x1c\x43\x1d\x88\xa9\x99\xe0\xb8\x46\x35\xeb\xcc\x26\x38\x7c\x6f\xe5\xc5\xf8\x7f\x32\xc4\xb4\x39\x38\xb5\x8e\xe0\xcd\x10\x41\x18\x23\xd8\xf8\xfe\x9e\xc3\x52\xc9\x12\x2f\x82\x76\xe0\x54\x52\xc1\xa9\x68\xf6\x5f\xd0\xae\xa5\xb3\x8c\xc2\x3b\x7c\xf3\xd8\xfd\xfe\x16\x5e\x6d\x4b\xdd\xc1\x1e\x57\x0e\x9b\x4e\xf0\xae\xef\x16\x17\x21\x67\xcc\xf1\x0a\x1d\xa0\xde\xb4\xeb\x61\x5a\xa5\x32\x45\xb5\x4c\x0d\xd3\x9d\x30\x4f\x9d\xb9\x1b\x05\x74\xeb\xd4\x23\xa6\x70\xd6\x38\x08\x55\xcd\xfe\x9b\xa2\x9a\x3d\x8a\x42\xe8\x27\xe2\x49\x6a\x90\x6a\x94\x4a\x66\xa4\xf5\xfe\xbb\x91\xf1\x48\xf8\x8b\x79\xe9\x3f\x16\x7b\x6e\xef\x7a\x2c\x0e\x90\xc6\xda\x1d\x6b\xd5\x0e\x1d\x78\x0a\x0e\xa8\x66\xb5\xad\x18\x65\xaa\xa8\x30\xed\x57\x55\x88\xe7\x8a\x10\x06\x3d\xc0\x85\x47\xa1\x07\x9d\x9c\x0b\x7c\x7b\x61\xaf\x72\xb0\xfb\x71\xcf\x37\x86\xad\xdd\x47\xf6\xaf\x61\xcb\xde\x1e\xb6\xd7\xdd\xbf\x3b\x6c\x03\x81\xe9\x4a\x73\x12\x98\x73\x1a\x9d\x06\xe8\x7c\x8b\x1e\x76\x80\xcd\xd9\xfc\xb8\xe1\x6a\xe5\xc5\x0b\xeb\x4f\x00\x00\x00\xff\xff\xc1\x11\xd6\x41\x19\x07\x00\x00")
+var __1528395637_lsif_repository_idsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x54\x4d\x6f\x9b\x40\x14\xbc\xf3\x2b\x46\xbe\x38\xae\x8c\xa5\x5e\x4d\x53\x89\xe0\x6d\x83\x8a\x21\xc5\xb8\xe9\x8d\x50\x76\xa3\xac\x8a\x59\xb2\xbb\xc4\xed\xbf\xaf\xcc\x47\x0c\x0e\x46\x4e\x8f\xc0\x9b\x79\x6f\xde\xbc\xc1\x34\xe1\x0b\xcd\x96\x78\x48\xc5\x6e\xc7\xf5\x03\xb8\x42\x02\xc9\x14\x93\x2f\x8c\x62\x2f\x24\x9d\x43\x09\x70\x3d\x55\x78\x2e\x85\x66\x74\x61\x18\x37\xe4\xab\xeb\x5b\x86\x61\x9a\x86\x69\x22\x53\xfc\x31\x2e\x8b\x4c\x24\x54\x1d\x5e\x1d\xde\x85\x4c\x69\x21\x19\x44\x46\x91\x8a\xac\xdc\xe5\x86\xed\x45\x24\x44\x64\xdf\x78\xa4\x07\x41\x48\x7c\x7b\x4d\xe0\x04\xde\x76\xed\x43\xb2\x42\x28\xae\x85\xfc\x1b\xe7\xc9\x8e\xc5\x89\x6e\x0a\x11\x05\x9d\x8f\x96\xe1\x84\xc4\x8e\x08\xb6\xbe\xfb\x7d\x4b\xe0\xfa\x2b\xf2\xb3\xc7\x1b\x77\x98\x6a\x75\xb1\x14\x42\x43\xe4\xbd\xb2\xab\x63\xd9\x1c\x93\xba\x70\x32\xc7\xa1\x74\x86\xfb\x5b\x12\x12\x28\x9d\x68\x86\x6b\x4c\x53\xb1\x2b\x32\xa6\x19\x9d\x2e\x97\x1d\x8e\xb8\x2a\x78\x9d\x68\x60\x94\x17\xae\xf8\xaf\x8c\xbd\x1d\xe9\xa2\x69\xda\x39\x5a\x96\x44\xc7\x9a\x17\xd6\xf9\x8d\xda\xab\x15\x9c\xc0\xdf\x44\xa1\xed\xfa\x11\x26\x67\xd7\xf2\xc4\xd2\xdf\x13\x38\xb7\xc4\xf9\x86\x4e\x6b\x7c\xfa\x8c\xe9\x74\xb9\xd4\xec\x8f\x9e\x59\x8d\xa1\x66\x21\x8a\x32\x4b\x74\xcf\xd4\xed\xdd\xca\x8e\x4e\xba\x97\xd8\x90\xa8\x63\x15\xae\x71\xb5\x21\x1e\x71\x22\x1c\x1c\xc5\x97\x30\x58\x57\x9f\x21\x1b\x61\x72\xc1\x29\xae\x51\x2e\x8e\xa0\x98\x53\x78\xee\xda\x8d\xf0\x71\x36\xa6\xb4\x5a\xc1\xdb\xd3\xe1\xb4\x1a\xc2\x0f\x22\xf8\x5b\xcf\xab\x35\xac\xa4\x28\xf0\xc2\xd9\x1e\x94\x15\x2c\xa7\x2c\xaf\xae\x21\x67\xfb\x56\xce\x2a\x0c\xee\xf0\xc3\x25\xf7\xf5\x3e\x69\xb9\x2b\x54\x07\x7b\x5a\x39\x7e\x74\x9c\x36\x26\x57\x77\x67\x9d\x85\x0c\x1c\xc7\x2b\x74\x44\x7a\x45\xd7\xc3\xb4\x4e\xa5\x92\x1d\x6c\xaa\x94\xee\xb9\x7e\xea\xcc\x5d\x39\xa0\xda\x4b\x3d\x51\x0a\x7b\x83\xc6\xa8\x72\xf1\x61\x8e\x72\xf1\xc8\x73\xae\x9e\x18\x8d\x13\x8d\x44\xa1\x90\x22\x65\x4a\xd5\xcf\x95\x8d\xbd\x89\xca\xf3\x79\xe9\xff\x2c\xea\xb5\xbc\xeb\x67\xd1\x40\xaa\xd3\x3e\x8a\xc6\xe1\x42\x47\x7e\x05\x0d\xaa\x72\xa5\xe9\x1a\x17\x89\x64\xb9\x6e\x9f\xca\x9c\x3f\x97\x0c\x81\xdf\x03\x0c\xc6\x70\x8e\x1e\x74\x36\x14\xf8\xb6\x61\xaf\x72\x94\xfd\x94\xf3\xc2\xb0\x35\x54\x48\xff\x37\x6c\xe9\xe5\x61\x6b\x7b\xbd\x3f\x6c\x23\x81\x69\x48\x87\x03\x33\xe4\x91\x75\x21\x45\x0f\x3b\xa2\x66\x30\x3f\x4e\xb0\x5e\xbb\x91\x65\xfc\x1b\x00\xc1\x11\xd6\x41\x19\x07\x00\x00")
 
 func _1528395637_lsif_repository_idsDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -994,7 +1004,7 @@ func _1528395637_lsif_repository_idsDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395637_lsif_repository_idsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x54\x4d\x73\x9b\x30\x14\xbc\xf3\x2b\xde\xe4\xe2\xa4\x63\xbb\xd3\x6b\x98\x1c\x88\x51\x5b\x66\x30\xa4\x18\x37\xbd\x11\x15\xbd\x8c\x35\xc5\x12\x91\x44\xdc\xfc\xfb\x8e\xf8\x70\xc0\x31\x4e\x3c\x3d\x02\xbb\xef\x2d\xab\xd5\xce\x66\x10\x49\x83\xd7\xf0\x90\xcb\xed\x96\x9b\x07\xe0\x1a\x28\x28\xd4\xa8\x9e\x91\xc1\x4e\x2a\x36\x05\x2d\x81\x9b\x89\x86\xa7\x4a\x1a\x64\x73\xc7\xb9\x25\xdf\x82\xc8\x75\x9c\xd9\xcc\x99\xcd\xa0\xd0\xfc\x31\xab\xca\x42\x52\xa6\xed\x2b\xfb\x2e\x41\x41\xb7\x08\xb9\x2c\xaa\xad\x70\xbc\x30\x25\x09\xa4\xde\x6d\x48\x06\x68\x48\x48\xe4\x2d\x09\x2c\xe2\x70\xbd\x8c\x40\x61\x29\x35\x37\x52\xbd\x40\x1a\xf7\x9e\x32\x3b\x2b\xa3\xa6\xa5\xd5\x8b\xc1\x63\x0c\x04\xee\xfa\x24\xce\x50\x18\xfe\xc8\x51\xbd\xbb\xd8\xf3\xfd\xfe\x02\xce\x80\x0b\xe3\x3a\x8b\x84\x78\x29\x81\x75\x14\xfc\x58\x13\x08\x22\x9f\xfc\x1a\xf0\xb2\x01\x27\x6b\x4c\xcb\x94\x94\x06\xe2\x68\x80\xbc\x1c\x20\xa7\x70\xd1\x60\x2f\xa6\x60\xd1\x57\x70\xff\x9d\x24\x04\xb4\xa1\x06\xe1\x06\x26\xb9\xdc\x96\x05\x1a\x64\x93\xeb\xeb\xde\x98\xac\x06\xec\x75\x1d\x11\xf4\xcc\x35\xff\x5d\xe0\x51\x61\x1f\xd5\xd4\xa9\xe9\x66\x51\x93\x19\x5e\x36\x36\xdf\xc9\xb2\x2a\xac\x48\xeb\x75\xe3\x2a\x50\xc1\x80\xa1\x95\x0b\x54\xbc\x40\xe7\xa9\xd9\x50\x03\x3b\x84\x9c\x8a\x89\x81\x5c\x2a\x85\x96\xe9\xac\xef\x7c\x2b\x7e\xe0\x7f\x05\x2b\x92\x1e\x9c\xc0\x0d\x5c\xae\x48\x48\x16\x29\x70\x06\x5f\x93\x78\x59\x7f\x07\xd5\xaa\x53\xf3\x3a\x52\x37\x50\xcd\x47\xa3\x01\x61\xb0\x0c\x52\xf8\x72\xe5\x3a\x3e\x09\x49\x4a\x9a\x39\x83\xd5\xed\xb4\xc1\xea\x60\x05\xd1\x3a\x0c\xdd\x13\x81\xa9\x3f\xbc\x09\xaa\x25\xdb\x3f\x89\xe2\xb4\x9d\x60\x4d\xf3\x95\x2c\xe1\x99\xe3\x0e\x18\x96\x28\x6c\x2c\x41\x0a\x90\x05\xeb\x82\xe9\x27\xf1\x1d\xfc\x0c\xc8\x7d\xb3\x85\x55\xdb\x52\xf7\xb8\xaf\x48\xc8\xa5\xd0\x46\x51\x2e\x8c\xfe\xcc\x05\xc3\xbf\xa8\x1b\xf6\xe9\x74\xf6\xa2\xe9\x8e\xe2\x8f\x84\xa7\xe1\x9d\x30\xa2\x9e\xb5\x88\xa3\x55\x9a\x78\x41\x94\x8e\x0b\xd8\x60\xfe\xc7\x6d\xcb\x20\x57\x68\x33\x54\x5b\xb2\xe3\x66\xd3\x4f\x93\x3d\x41\xdd\x25\xfc\xc0\x12\xf0\x56\xd0\x66\xa2\x9a\x7f\x9a\x42\x35\x7f\xe4\x82\xeb\x0d\xb2\x8c\x1a\xa0\x1a\x4a\x25\x73\xd4\xba\x79\x7e\x7b\xd6\xd5\xf8\x3d\x1b\xd6\x57\xf3\xdb\xfb\xfa\xea\xca\x65\xac\x46\x5a\xf8\xd9\x35\xd2\xf2\x8e\xd7\x48\x49\x15\x0a\xd3\x3d\x55\x82\x3f\x55\xb8\xbf\xc2\x2d\x73\xbc\x56\x06\xec\xab\x63\x95\x71\x7c\xf9\x80\xf7\xde\xba\xc3\x25\x1f\x6c\x88\xce\xae\x8f\x34\x44\x87\xcd\xff\xa3\x21\xf2\x5e\x43\x9c\xaa\x84\x6e\xd7\x79\x95\xb0\x3f\xfc\xb3\x2b\xe1\xb0\x00\x46\x0e\xe7\x54\x20\xde\x5e\xe6\x8e\x39\x40\x9f\x90\x5d\xf3\x5f\xe5\xba\x8e\xb3\x88\x97\xcb\x20\x75\x9d\x7f\x01\x00\x00\xff\xff\x80\x12\x3a\xd6\x0e\x08\x00\x00")
+var __1528395637_lsif_repository_idsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x54\x4d\x73\xa3\x38\x14\xbc\xf3\x2b\xba\x72\x71\xb2\x65\x7b\x6b\xaf\xa1\x72\x20\xb6\x76\x97\x2a\x0c\x59\x8c\x37\x73\x23\x0c\x7a\x29\xab\xc6\x96\x88\x24\xe2\xc9\xbf\x9f\xe2\x2b\x01\xc7\x38\x49\xcd\x11\xe8\x7e\xdd\x3c\xb5\x7a\x36\x43\xa8\x2c\x5d\xe3\x21\x57\xfb\xbd\xb0\x0f\x10\x06\x19\x34\x19\xd2\xcf\xc4\x71\x50\x9a\x4f\x61\x14\x84\x9d\x18\x3c\x95\xca\x12\x9f\x3b\xce\x2d\xfb\xc7\x0f\x5d\xc7\x99\xcd\x9c\xd9\x0c\x3b\x23\x1e\xd3\xb2\xd8\xa9\x8c\x9b\xea\x55\xf5\x2e\x26\x99\xed\x09\xb9\xda\x95\x7b\xe9\x78\x41\xc2\x62\x24\xde\x6d\xc0\x06\x68\xc4\x2c\xf4\x56\x0c\x8b\x28\xd8\xac\x42\x68\x2a\x94\x11\x56\xe9\x17\x24\x51\xef\x29\xad\x66\xa5\x99\x6d\x69\xb5\x30\x3c\xce\x21\xe9\xd0\x27\x09\x4e\xd2\x8a\x47\x41\xfa\x43\x61\x6f\xb9\xec\x0b\x08\x0e\x21\xad\xeb\x2c\x62\xe6\x25\x0c\x9b\xd0\xff\x6f\xc3\xe0\x87\x4b\xf6\x6d\xc0\x4b\x07\x9c\xb4\x59\x5a\xaa\x95\xb2\x88\xc2\x01\xf2\x72\x80\x9c\xe2\xa2\xc1\x5e\x4c\x51\xa1\xaf\x70\xff\x2f\x8b\x19\x8c\xcd\x2c\xe1\x06\x93\x5c\xed\x8b\x1d\x59\xe2\x93\xeb\xeb\xde\x98\xb4\x06\xbc\xfa\x3a\x61\xe8\x59\x18\xf1\x7d\x47\x27\x8d\x7d\xd6\x53\xe7\xa6\x9b\x95\xd9\xd4\x8a\xa2\x59\xf3\x9d\x2a\xca\x5d\x65\xb2\xda\x75\xb3\x55\x64\x92\x83\x53\x65\x17\x99\x7c\x41\x3b\x1d\x76\x9b\x59\x1c\x08\x79\x26\x27\x16\xb9\xd2\x9a\x2a\xa6\xb3\xb9\x5b\x56\x4b\xed\x3b\x41\x89\x35\x4b\x8e\x4e\xe0\x06\x97\x6b\x16\xb0\x45\x02\xc1\xf1\x77\x1c\xad\xea\xef\xd0\xad\x3b\x3d\xaf\x62\x80\x1b\x94\xf3\xd1\x68\x20\xf0\x57\x7e\x82\xbf\xae\x5c\x67\xc9\x02\x96\xb0\x66\xce\x40\xba\x9d\x36\x90\xf6\xd7\x08\x37\x41\xe0\x9e\x09\x4c\xfd\xe1\x5d\x50\x53\xc1\xeb\x3f\x09\xa3\xa4\x9d\x50\x2d\x6d\xa9\x55\x81\x67\x41\x07\x70\x2a\x48\x56\xb1\x84\x92\x50\x3b\xde\x05\x73\x19\x47\x77\xf8\xdf\x67\xf7\x8d\x0a\x2f\xf7\x85\xe9\x71\xdf\x90\xc8\x95\x34\x56\x67\x42\x5a\xf3\xa7\x90\x9c\x7e\x92\x69\xd8\xe7\xd3\xd9\x8b\xa6\x3b\x8a\x3f\x11\x9e\x86\x77\x66\x11\xf5\xac\x45\x14\xae\x93\xd8\xf3\xc3\x64\xdc\xc0\x96\xf2\x1f\x6e\x5b\x06\xb9\xa6\x2a\x43\xf5\x4a\x0e\xc2\x6e\xfb\x69\xaa\x4e\xd5\x74\x09\x3f\x5a\x09\xbc\x35\xda\x4c\x94\xf3\x3f\xa6\x28\xe7\x8f\x42\x0a\xb3\x25\x9e\x66\x16\x99\x41\xa1\x55\x4e\xc6\x34\xcf\xef\xcf\xba\x1c\xbf\x67\xc3\xfa\x6a\x7e\xfb\xb5\xbe\xba\x72\x19\xab\x91\x16\xfe\xe5\x1a\x69\x79\x27\x6f\x6b\x5a\x64\x9a\xa4\xed\x9e\x4a\x29\x9e\x4a\x7a\xbd\xc2\x2d\x73\xbc\x56\x06\xec\xab\x53\x95\x71\x5a\x7c\xc0\xfb\x48\xee\x58\xe4\x93\x0d\xd1\x4e\xfb\x54\x43\x74\xd8\xfc\x37\x1a\x22\xef\x35\xc4\xb9\x4a\xe8\xb4\xbe\x56\x09\x1d\xeb\xeb\x95\x70\x5c\x00\x23\x87\x73\x2e\x10\xee\x28\x73\x80\x3e\x63\xbb\xe6\xbf\xd9\x75\x1d\x67\x11\xad\x56\x7e\xe2\x3a\xbf\x06\x00\x80\x12\x3a\xd6\x0e\x08\x00\x00")
 
 func _1528395637_lsif_repository_idsUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1014,7 +1024,7 @@ func _1528395637_lsif_repository_idsUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395638_remove_published_at_from_changeset_jobsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\xce\x48\xcc\x4b\x4f\x2d\x4e\x2d\x89\xcf\xca\x4f\x2a\x56\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\x28\x28\x4d\xca\xc9\x2c\xce\x48\x4d\x89\x4f\x2c\x51\x28\xc9\xcc\x4d\x2d\x2e\x49\xcc\x2d\x28\xa9\xb2\xe6\xe2\x72\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x04\x00\x00\xff\xff\x86\xae\x59\x0b\x51\x00\x00\x00")
+var __1528395638_remove_published_at_from_changeset_jobsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x51\x00\xae\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x63\x68\x61\x6e\x67\x65\x73\x65\x74\x5f\x6a\x6f\x62\x73\x20\x41\x44\x44\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x70\x75\x62\x6c\x69\x73\x68\x65\x64\x5f\x61\x74\x20\x74\x69\x6d\x65\x73\x74\x61\x6d\x70\x74\x7a\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x86\xae\x59\x0b\x51\x00\x00\x00")
 
 func _1528395638_remove_published_at_from_changeset_jobsDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1034,7 +1044,7 @@ func _1528395638_remove_published_at_from_changeset_jobsDownSql() (*asset, error
 	return a, nil
 }
 
-var __1528395638_remove_published_at_from_changeset_jobsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\xce\x48\xcc\x4b\x4f\x2d\x4e\x2d\x89\xcf\xca\x4f\x2a\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x28\x28\x4d\xca\xc9\x2c\xce\x48\x4d\x89\x4f\x2c\xb1\xe6\xe2\x72\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x04\x00\x00\xff\xff\xcb\x01\x9f\x51\x50\x00\x00\x00")
+var __1528395638_remove_published_at_from_changeset_jobsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x50\x00\xaf\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x63\x68\x61\x6e\x67\x65\x73\x65\x74\x5f\x6a\x6f\x62\x73\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x70\x75\x62\x6c\x69\x73\x68\x65\x64\x5f\x61\x74\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xcb\x01\x9f\x51\x50\x00\x00\x00")
 
 func _1528395638_remove_published_at_from_changeset_jobsUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1054,7 +1064,7 @@ func _1528395638_remove_published_at_from_changeset_jobsUpSql() (*asset, error)
 	return a, nil
 }
 
-var __1528395639_create_a8n_job_indexesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x09\xf2\x0f\x50\xf0\xf4\x73\x71\x8d\x50\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\xcf\xca\x4f\x2a\x8e\x87\xf3\x0a\x72\x12\xf3\xe2\x33\x53\xac\x71\x68\xca\x48\xcc\x4b\x4f\x2d\x4e\x2d\x41\xd3\x95\x95\x9f\x04\xd6\xc4\xe5\xec\xef\xeb\xeb\x19\x62\xcd\x05\x08\x00\x00\xff\xff\x24\x70\xd1\xd6\x7b\x00\x00\x00")
+var __1528395639_create_a8n_job_indexesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x7b\x00\x84\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x49\x4e\x44\x45\x58\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x63\x61\x6d\x70\x61\x69\x67\x6e\x5f\x6a\x6f\x62\x73\x5f\x63\x61\x6d\x70\x61\x69\x67\x6e\x5f\x70\x6c\x61\x6e\x5f\x69\x64\x3b\x0a\x44\x52\x4f\x50\x20\x49\x4e\x44\x45\x58\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x63\x68\x61\x6e\x67\x65\x73\x65\x74\x5f\x6a\x6f\x62\x73\x5f\x63\x61\x6d\x70\x61\x69\x67\x6e\x5f\x6a\x6f\x62\x5f\x69\x64\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x24\x70\xd1\xd6\x7b\x00\x00\x00")
 
 func _1528395639_create_a8n_job_indexesDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1074,7 +1084,7 @@ func _1528395639_create_a8n_job_indexesDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395639_create_a8n_job_indexesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x0e\x72\x75\x0c\x71\x55\xf0\xf4\x73\x71\x8d\x50\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\xcf\xca\x4f\x2a\x8e\x87\xf3\x0a\x72\x12\xf3\xe2\x33\x53\x14\xfc\xfd\x50\x55\x28\x68\xa0\x2b\xd1\xb4\x46\x33\x30\x23\x31\x2f\x3d\xb5\x38\xb5\x04\xcd\xc4\xac\xfc\x24\x98\x81\x28\x2a\x90\x4c\x84\x28\xd1\x04\x39\xd1\xdf\xd7\xd7\x33\xc4\x9a\x0b\x10\x00\x00\xff\xff\x57\x4e\xa9\x05\xb3\x00\x00\x00")
+var __1528395639_create_a8n_job_indexesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x0e\x72\x75\x0c\x71\x55\xf0\xf4\x73\x71\x8d\x50\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\xcf\xca\x4f\x2a\x8e\x87\xf3\x0a\x72\x12\xf3\xe2\x33\x53\x14\xfc\xfd\x50\x55\x28\x68\xa0\x2b\xd1\xb4\x46\x33\x30\x23\x31\x2f\x3d\xb5\x38\xb5\x04\xcd\xc4\xac\xfc\x24\x98\x81\x28\x2a\x90\x4c\x84\x28\xd1\xb4\xe6\xe2\x72\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x0c\x00\x57\x4e\xa9\x05\xb3\x00\x00\x00")
 
 func _1528395639_create_a8n_job_indexesUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1094,7 +1104,7 @@ func _1528395639_create_a8n_job_indexesUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395640_drop_repository_name_at_uploadDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\x90\x4d\x6b\xc2\x30\x1c\xc6\xef\xf9\x14\xcf\xad\x30\xa6\x5f\xa0\x78\xa8\x36\xdb\x84\x6a\xa5\x8d\x73\xb7\x52\x9a\xbf\x18\xd6\x26\x21\x2f\x2b\xfb\xf6\xa3\xab\x1e\x26\xcc\x63\xf8\x3f\xbf\x3c\x2f\x6b\xfe\xba\xdd\xa7\x8c\x2d\x16\xc8\x9d\xb1\xf8\x52\x34\x42\x92\x25\x2d\x49\x07\x18\x0d\xd3\x4b\x74\xa6\x8f\x83\x66\x79\x55\x1e\xf0\xbe\xe5\x27\xf4\x5e\x9d\x1b\x19\x07\xeb\x67\xb6\x22\x1f\x8c\xa3\x9b\x30\x2b\x04\xaf\x20\xb2\x75\xc1\x67\x69\xb4\xbd\x69\xa5\x47\x96\xe7\x70\x64\x8d\x57\xc1\xb8\xef\x46\xb7\x03\x35\x6d\xb8\x9e\x21\xf8\x87\x48\xd9\xf1\x90\x67\xe2\x8e\xab\xb9\x78\xc0\xad\x90\x44\xfd\xa9\xcd\xa8\x93\xf4\x81\xf7\xef\xe1\xff\x5f\x26\x8f\x7d\x29\xb0\x3f\x16\xc5\xad\x55\xe7\xa8\x0d\x34\xaf\x32\xaa\x70\x81\xa6\xf1\x5a\x12\x13\xee\xd9\xa6\xe2\x53\xda\xbb\x55\x90\xd5\xa8\x79\xc1\x37\x02\x71\xf9\xf4\x8c\xb8\x3c\x2b\xad\xfc\x85\x64\xd3\x06\xb4\x1e\xd6\x99\x8e\xbc\x9f\xdf\x2f\x55\xb9\xfb\x9b\x35\xe2\xf4\xc6\x2b\x0e\x1f\x26\xfb\x15\x92\xce\x0c\xb6\xa7\x40\x32\x49\x19\xdb\x94\xbb\xdd\x56\xa4\xec\x27\x00\x00\xff\xff\x23\xb5\x4b\x44\xbd\x01\x00\x00")
+var __1528395640_drop_repository_name_at_uploadDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\x50\xcd\x6a\xc2\x40\x18\xbc\xef\x53\xcc\x2d\x50\xaa\x2f\x10\x3c\x44\xb3\x6d\x85\x68\x24\x59\x6b\x6f\x21\x64\x3f\x71\x69\xb2\xbb\xec\x4f\x43\xdf\xbe\xa4\xd1\x43\x85\x7a\xfc\x98\x99\x6f\x7e\xd6\xfc\x75\xbb\x4f\x19\x5b\x2c\x90\x3b\x63\xf1\xa5\x68\x84\x24\x4b\x5a\x92\x0e\x30\x1a\xa6\x97\xe8\x4c\x1f\x07\xcd\xf2\xaa\x3c\xe0\x7d\xcb\x4f\xe8\xbd\x3a\x37\x32\x0e\xd6\xcf\xda\x8a\x7c\x30\x8e\x6e\xc4\xac\x10\xbc\x82\xc8\xd6\x05\x9f\xa9\xd1\xf6\xa6\x95\x1e\x59\x9e\xc3\x91\x35\x5e\x05\xe3\xbe\x1b\xdd\x0e\xd4\xb4\xe1\x0a\x43\xf0\x0f\x91\xb2\xe3\x21\xcf\xc4\x9d\xae\xe6\xe2\x81\x6e\x85\x24\xea\x4f\x6d\x46\x9d\xa4\x0f\xbc\x7f\x81\xff\xbf\x4c\x1e\xfb\x52\x60\x7f\x2c\x8a\x5b\xab\xce\x51\x1b\x68\x5e\x65\x54\xe1\x02\x4d\xe3\xb5\x24\xa6\x10\x9e\x6d\x2a\x3e\xa5\xbd\x5b\x05\x59\x8d\x9a\x17\x7c\x23\x10\x97\x4f\xcf\x88\xcb\xb3\xd2\xca\x5f\x48\x36\x6d\x40\xeb\x61\x9d\xe9\xc8\xfb\xf9\x7e\xa9\xca\xdd\xdf\xac\x11\xa7\x37\x5e\x71\xf8\x30\xd9\xaf\x90\x74\x66\xb0\x3d\x05\x92\x49\xca\xd8\xa6\xdc\xed\xb6\x22\x65\x3f\x03\x00\x23\xb5\x4b\x44\xbd\x01\x00\x00")
 
 func _1528395640_drop_repository_name_at_uploadDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1114,7 +1124,7 @@ func _1528395640_drop_repository_name_at_uploadDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395640_drop_repository_name_at_uploadUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x5c\x8e\x41\x6a\xc3\x30\x10\x45\xf7\x3a\xc5\xdf\x05\x4a\x93\x0b\x84\x2e\x9c\x64\xd2\x1a\xec\xba\xc8\xa2\xe9\xce\x08\x6b\x42\x04\xb6\x24\x2c\xa9\xa6\xb7\x2f\xa9\xbd\x68\xbb\x1c\x78\x6f\xfe\x3b\xd0\x73\xf9\xba\x17\x62\xbb\xc5\x69\xf2\x01\x9f\x96\x67\x18\x0e\xec\x0c\xbb\x04\xef\xe0\x07\x83\xde\x0f\x79\x74\xe2\x24\x9b\x37\xbc\x97\x74\xc1\x10\xed\xb5\x33\x79\x0c\xf1\x97\xbb\x52\x45\xa5\x48\x42\x15\x87\x8a\x16\x2e\x87\xc1\x6b\x13\xf1\xa3\x97\x67\xd0\x47\xd9\xaa\x16\x13\x07\x1f\x6d\xf2\xd3\x57\xe7\xf4\xc8\x9d\x4e\x2b\xb9\xbc\x94\xdc\x4f\xac\x13\x2f\x49\xb3\x4d\x37\x38\x9e\xd7\x11\xdc\x8d\x28\x8e\x92\x0a\x45\xff\x93\x50\xb4\x68\xa9\xa2\xa3\x42\xde\x3d\x3c\x22\xef\xae\xd6\xd9\x78\x63\xd3\xe9\x04\x1d\x11\x26\xdf\x73\x8c\xcb\x7d\x96\x4d\xfd\xb7\x33\xe3\xf2\x42\x92\x10\xd3\x7d\xfe\x09\x9b\xde\x8f\x61\xe0\xc4\x66\xb3\x17\xe2\xd8\xd4\x75\xa9\xf6\xe2\x3b\x00\x00\xff\xff\x35\xeb\xab\x98\x3a\x01\x00\x00")
+var __1528395640_drop_repository_name_at_uploadUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x5c\x8e\x41\x6a\xc3\x30\x10\x45\xf7\x3a\xc5\xdf\x05\x4a\x93\x0b\x84\x2e\x9c\x64\xd2\x1a\xec\xba\xc8\xa2\xe9\xce\x08\x6b\x42\x04\xb6\x24\x2c\xa9\xa6\xb7\x2f\xa9\xbd\x68\xbb\x1c\x78\x6f\xfe\x3b\xd0\x73\xf9\xba\x17\x62\xbb\xc5\x69\xf2\x01\x9f\x96\x67\x18\x0e\xec\x0c\xbb\x04\xef\xe0\x07\x83\xde\x0f\x79\x74\xe2\x24\x9b\x37\xbc\x97\x74\xc1\x10\xed\xb5\x33\x79\x0c\xf1\x97\xbb\x52\x45\xa5\x48\x42\x15\x87\x8a\x16\x2e\x87\xc1\x6b\x13\xf1\xa3\x97\x67\xd0\x47\xd9\xaa\x16\x13\x07\x1f\x6d\xf2\xd3\x57\xe7\xf4\xc8\x9d\x4e\x2b\xb9\xbc\x94\xdc\x4f\xac\x13\x2f\x49\xb3\x4d\x37\x38\x9e\xd7\x14\xdc\x8d\x28\x8e\x92\x0a\x45\xff\x93\x50\xb4\x68\xa9\xa2\xa3\x42\xde\x3d\x3c\x22\xef\xae\xd6\xd9\x78\x63\xd3\xe9\x04\x1d\x11\x26\xdf\x73\x8c\xcb\x7d\x96\x4d\xfd\xb7\x33\xe3\xf2\x42\x92\x10\xd3\x7d\xfe\x09\x9b\xde\x8f\x61\xe0\xc4\x66\xb3\x17\xe2\xd8\xd4\x75\xa9\xf6\xe2\x7b\x00\x35\xeb\xab\x98\x3a\x01\x00\x00")
 
 func _1528395640_drop_repository_name_at_uploadUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1134,7 +1144,7 @@ func _1528395640_drop_repository_name_at_uploadUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395641_remove_published_at_from_campaignDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x2b\x56\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\x28\x28\x4d\xca\xc9\x2c\xce\x48\x4d\x89\x4f\x2c\x51\x28\xc9\xcc\x4d\x2d\x2e\x49\xcc\x2d\x28\xa9\xb2\xe6\xe2\x72\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x04\x00\x00\xff\xff\x77\x3f\x4b\x5c\x4c\x00\x00\x00")
+var __1528395641_remove_published_at_from_campaignDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x4c\x00\xb3\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x63\x61\x6d\x70\x61\x69\x67\x6e\x73\x20\x41\x44\x44\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x70\x75\x62\x6c\x69\x73\x68\x65\x64\x5f\x61\x74\x20\x74\x69\x6d\x65\x73\x74\x61\x6d\x70\x74\x7a\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x77\x3f\x4b\x5c\x4c\x00\x00\x00")
 
 func _1528395641_remove_published_at_from_campaignDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1154,7 +1164,7 @@ func _1528395641_remove_published_at_from_campaignDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395641_remove_published_at_from_campaignUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x2b\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x28\x28\x4d\xca\xc9\x2c\xce\x48\x4d\x89\x4f\x2c\xb1\xe6\xe2\x72\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x04\x00\x00\xff\xff\xbf\xd5\xbb\x2b\x4b\x00\x00\x00")
+var __1528395641_remove_published_at_from_campaignUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x4b\x00\xb4\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x63\x61\x6d\x70\x61\x69\x67\x6e\x73\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x70\x75\x62\x6c\x69\x73\x68\x65\x64\x5f\x61\x74\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xbf\xd5\xbb\x2b\x4b\x00\x00\x00")
 
 func _1528395641_remove_published_at_from_campaignUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1174,7 +1184,7 @@ func _1528395641_remove_published_at_from_campaignUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395642_add_user_id_to_campaign_planDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\x2f\xc8\x49\xcc\x2b\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x28\x2d\x4e\x2d\x8a\xcf\x4c\xb1\xe6\xe2\x72\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x04\x00\x00\xff\xff\x4a\xbc\xfa\xff\x4b\x00\x00\x00")
+var __1528395642_add_user_id_to_campaign_planDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x4b\x00\xb4\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x63\x61\x6d\x70\x61\x69\x67\x6e\x5f\x70\x6c\x61\x6e\x73\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x75\x73\x65\x72\x5f\x69\x64\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x4a\xbc\xfa\xff\x4b\x00\x00\x00")
 
 func _1528395642_add_user_id_to_campaign_planDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1194,7 +1204,7 @@ func _1528395642_add_user_id_to_campaign_planDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395642_add_user_id_to_campaign_planUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x84\x52\x4d\x6f\x9c\x30\x14\xbc\xfb\x57\xcc\x71\xf7\x90\x95\x72\x46\x3d\x78\xe1\x6d\x6a\xd5\x98\xca\x36\x6a\xf7\x84\xac\x60\x82\xd5\x04\x22\x70\x54\xe5\xdf\x57\xc0\x06\x09\x54\x25\xdc\xf0\x3c\x8f\xe7\xe3\x9d\xe9\x41\xa8\x84\x31\x2e\x2d\x69\x58\x7e\x96\x84\x47\xf7\xf2\xea\xc2\x53\x57\xbd\x3e\xbb\x6e\x04\xcf\x32\xa4\x85\x2c\x73\x85\xb7\xd1\x0f\x55\xa8\x11\xba\xe8\x9f\xfc\x90\x30\x76\x77\x07\xe3\x23\x62\x8f\x26\x0c\x63\xc4\x18\xa2\x87\xab\x5f\x42\x87\xd0\xa0\xeb\x23\x5c\x8c\xee\xb1\xf5\x35\x2b\x7f\x66\xdc\xee\xd9\x99\x21\xbb\xd2\x7e\xc3\xc1\x90\xa4\xd4\x22\xd4\xb8\xe8\x22\x9f\x91\x11\x7f\x5b\x3f\xf8\x99\xba\x5a\xa8\x0b\x9d\x91\xc6\xf9\x3a\xcd\x71\x93\x42\x8a\x5c\x58\xdc\x1f\xd9\xaf\xef\xa4\x09\xaa\xb0\xa0\xdf\xc2\x58\xc3\x00\xac\xa4\xf7\x0b\xe7\x87\x80\x11\xcb\xf4\xfa\x7f\xda\x48\x5b\x04\x6d\xd5\x9e\x42\x7d\x5c\x4c\x97\xa3\x5f\xb1\x93\x7b\x8b\x6d\x3f\x3b\x68\xfa\x61\x1f\x5f\x6c\x5d\x84\x1b\x3c\xdc\xf3\xe0\x5d\xfd\x3e\xdd\xfe\xc8\x64\xca\xcd\xad\x17\xfe\x9f\x10\xb6\x09\xad\x6f\xb1\xad\x19\xb6\x35\xf3\x99\x85\x4f\xeb\x9e\x13\x5b\x2a\x57\xc6\x6a\x2e\x94\xdd\x4d\x54\x37\x31\x55\xf3\xc7\xbf\xe3\x52\x68\x12\x0f\x0a\x3f\xe8\x8a\xc3\x0d\x39\xce\x24\xd3\xa7\xe9\x42\x9a\x54\x4a\xe6\x56\xe5\x21\xd4\x47\x64\xd3\xa9\x9e\x1f\x17\x4a\x58\xc1\xa5\xbc\x42\xe4\x39\x65\x82\x5b\xfa\x62\x1b\x67\x68\xb7\x8f\x53\x44\x53\xe9\xaa\x94\x32\x61\x2c\x2d\xf2\x5c\xd8\x84\xfd\x0b\x00\x00\xff\xff\x02\x98\xe8\x28\xdd\x02\x00\x00")
+var __1528395642_add_user_id_to_campaign_planUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x52\xc1\x8a\xdb\x30\x14\xbc\xeb\x2b\xe6\x98\x1c\x36\xb0\xe7\xb0\x07\xc5\x7e\xd9\x8a\xca\x72\x91\x14\xda\x9c\x8c\x58\x2b\x1b\xd1\x5d\x67\xb1\xb4\x94\xfd\xfb\x22\x39\x31\xac\x29\x6d\xc1\x07\x4b\xf3\xde\xe8\xcd\xcc\xdb\xd1\xa3\x50\x5b\xc6\xb8\xb4\xa4\x61\xf9\x4e\x12\x9e\xdc\xeb\x9b\x0b\xcf\x43\xf7\xf6\xe2\x86\x08\x5e\xd7\xa8\x5a\x79\x68\x14\xde\xa3\x1f\xbb\xd0\x23\x0c\xc9\x3f\xfb\x71\xcb\xd8\xdd\x1d\x8c\x4f\x48\x17\x9c\xc2\x18\x13\x62\x48\x1e\xae\x7f\x0d\x03\xc2\x09\xc3\x25\xc1\xa5\xe4\x9e\xce\xbe\x67\x87\x6f\x35\xb7\x4b\x76\x66\xc8\xce\xb4\x0f\x58\x19\x92\x54\x59\x84\x1e\x7b\xdd\x36\x05\x89\xf8\x75\xf6\xa3\x2f\xd4\xdd\x44\xdd\xea\x9a\x34\x76\xc7\x5c\xc7\x4d\x05\x29\x1a\x61\x71\xbf\x66\xdf\xbf\x90\x26\xa8\xd6\x82\x7e\x08\x63\x0d\x03\x30\x93\xde\x4f\x9c\xb7\x01\x22\xa6\xea\xf9\xbc\xb9\xfd\x95\xd1\xb2\xce\x87\x19\x2c\x57\x71\x13\xfa\xf5\x24\xfa\x10\xfd\x8c\x6d\xdc\x7b\x3a\x5f\x8a\x82\xd3\x65\x5c\xb4\x20\x9d\x5d\x82\x1b\x3d\xdc\xcb\xe8\x5d\xff\x91\xbb\x6f\x9e\x64\xdf\xdc\xdc\xf0\x67\x87\xf0\xd9\xa1\xf9\x2d\xf6\x59\xcc\x55\xfa\xff\x48\xf8\x6b\xdc\xc5\xb1\x29\x72\x65\xac\xe6\x42\xd9\x45\x45\x77\x1d\xa6\x3b\xfd\xf4\x1f\xd8\xb7\x9a\xc4\xa3\xc2\x57\x3a\x62\x75\x45\xd6\x85\x24\x7f\x9a\xf6\xa4\x49\x55\x64\xae\x51\xae\x42\xbf\x46\x9d\x6f\x75\x79\x5c\x28\x61\x05\x97\xf2\x08\xd1\x34\x54\x0b\x6e\xe9\x1f\xdb\x58\xa0\xc5\x3e\x66\x8b\x72\xe8\xea\x20\xe5\x96\xb1\xaa\x6d\x1a\x61\xb7\xec\xf7\x00\x02\x98\xe8\x28\xdd\x02\x00\x00")
 
 func _1528395642_add_user_id_to_campaign_planUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1214,7 +1224,7 @@ func _1528395642_add_user_id_to_campaign_planUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395643_create_indexes_on_event_logsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x09\xf2\x0f\x50\xf0\xf4\x73\x71\x8d\x50\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x48\x2d\x4b\xcd\x2b\x89\xcf\xc9\x4f\x2f\x8e\x2f\xce\x2f\x2d\x4a\x4e\xb5\x26\xa8\xae\x24\x33\x37\xb5\xb8\x24\x31\xb7\x20\x3e\xb1\x24\x3e\x34\xc4\xd9\x9a\x8b\xcb\xd9\xdf\xd7\xd7\x33\xc4\x9a\x0b\x10\x00\x00\xff\xff\x63\x56\xe0\x2b\x6b\x00\x00\x00")
+var __1528395643_create_indexes_on_event_logsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x6b\x00\x94\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x49\x4e\x44\x45\x58\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x65\x76\x65\x6e\x74\x5f\x6c\x6f\x67\x73\x5f\x73\x6f\x75\x72\x63\x65\x3b\x0a\x44\x52\x4f\x50\x20\x49\x4e\x44\x45\x58\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x65\x76\x65\x6e\x74\x5f\x6c\x6f\x67\x73\x5f\x74\x69\x6d\x65\x73\x74\x61\x6d\x70\x5f\x61\x74\x5f\x55\x54\x43\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x63\x56\xe0\x2b\x6b\x00\x00\x00")
 
 func _1528395643_create_indexes_on_event_logsDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1234,7 +1244,7 @@ func _1528395643_create_indexes_on_event_logsDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395643_create_indexes_on_event_logsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x0e\x72\x75\x0c\x71\x55\xf0\xf4\x73\x71\x8d\x50\xf0\x74\x53\xf0\xf3\x0f\x51\x70\x8d\xf0\x0c\x0e\x09\x56\x48\x2d\x4b\xcd\x2b\x89\xcf\xc9\x4f\x2f\x8e\x2f\xce\x2f\x2d\x4a\x4e\x55\xf0\xf7\x43\x12\xd4\x80\x08\x6a\x5a\x13\x69\x46\x49\x66\x6e\x6a\x71\x49\x62\x6e\x41\x7c\x62\x49\x7c\x68\x88\x33\x9a\x69\x2e\x8e\x21\xae\x1a\x70\x35\x0a\x8e\x21\x0a\x21\x9e\xbe\xae\x0a\x51\xfe\x7e\xae\x0a\xea\xa1\x21\xce\xea\x9a\x9a\x20\xe7\xfa\xfb\xfa\x7a\x86\x58\x73\x01\x02\x00\x00\xff\xff\x8a\x00\x60\xe4\xbf\x00\x00\x00")
+var __1528395643_create_indexes_on_event_logsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\xcc\xb1\x0a\xc2\x30\x10\x87\xf1\x3d\x4f\xf1\xdf\xda\x3e\x43\xa6\x98\x9e\x72\x43\x2e\x60\xaf\x50\x5c\x42\x91\x20\x82\xb5\x62\xa2\xcf\xef\x20\x88\x38\xb9\x7e\x7c\xfc\x36\xb4\x63\xb1\xc6\xf8\x3d\x39\x25\xb0\xf4\x34\x81\xb7\x90\xa8\xa0\x89\x07\x1d\x90\x9f\xf9\x5a\xd3\x65\x3d\x95\x54\xd6\xc7\xfd\x98\x11\xe5\x2b\xb6\xef\xd8\xd9\x3f\x8d\x7a\x5e\x72\xa9\xf3\x72\x4b\x73\x4d\xa3\xfa\x1f\xad\x77\x4a\xed\xe7\x81\x53\x28\x07\xc2\x21\x0a\xa1\x19\xd5\x37\x5d\x67\x8d\xf1\x31\x04\x56\x6b\x5e\x03\x00\x8a\x00\x60\xe4\xbf\x00\x00\x00")
 
 func _1528395643_create_indexes_on_event_logsUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1254,7 +1264,7 @@ func _1528395643_create_indexes_on_event_logsUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395644_remove_default_from_event_logs_timestampDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x2d\x4b\xcd\x2b\x89\xcf\xc9\x4f\x2f\x86\x0a\x3b\xfb\xfb\x84\xfa\xfa\x29\x94\x64\xe6\xa6\x16\x97\x24\xe6\x16\x28\x04\xbb\x86\x28\xb8\xb8\xba\x39\x86\xfa\x84\x28\xe4\xe5\x97\x6b\x68\x5a\x73\x71\x39\xfb\xfb\xfa\x7a\x86\x58\x73\x01\x02\x00\x00\xff\xff\x0f\x3e\xdf\x83\x52\x00\x00\x00")
+var __1528395644_remove_default_from_event_logs_timestampDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x52\x00\xad\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x65\x76\x65\x6e\x74\x5f\x6c\x6f\x67\x73\x0a\x41\x4c\x54\x45\x52\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x74\x69\x6d\x65\x73\x74\x61\x6d\x70\x20\x53\x45\x54\x20\x44\x45\x46\x41\x55\x4c\x54\x20\x6e\x6f\x77\x28\x29\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x0f\x3e\xdf\x83\x52\x00\x00\x00")
 
 func _1528395644_remove_default_from_event_logs_timestampDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1274,7 +1284,7 @@ func _1528395644_remove_default_from_event_logs_timestampDownSql() (*asset, erro
 	return a, nil
 }
 
-var __1528395644_remove_default_from_event_logs_timestampUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x2d\x4b\xcd\x2b\x89\xcf\xc9\x4f\x2f\x86\x0a\x3b\xfb\xfb\x84\xfa\xfa\x29\x94\x64\xe6\xa6\x16\x97\x24\xe6\x16\x28\xb8\x04\xf9\x07\x28\xb8\xb8\xba\x39\x86\xfa\x84\x58\x73\x71\x39\xfb\xfb\xfa\x7a\x86\x58\x73\x01\x02\x00\x00\xff\xff\xc2\x8d\x88\xe2\x4d\x00\x00\x00")
+var __1528395644_remove_default_from_event_logs_timestampUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x4d\x00\xb2\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x65\x76\x65\x6e\x74\x5f\x6c\x6f\x67\x73\x0a\x41\x4c\x54\x45\x52\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x74\x69\x6d\x65\x73\x74\x61\x6d\x70\x20\x44\x52\x4f\x50\x20\x44\x45\x46\x41\x55\x4c\x54\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xc2\x8d\x88\xe2\x4d\x00\x00\x00")
 
 func _1528395644_remove_default_from_event_logs_timestampUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1294,7 +1304,7 @@ func _1528395644_remove_default_from_event_logs_timestampUpSql() (*asset, error)
 	return a, nil
 }
 
-var __1528395645_event_logs_json_argumentsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x2d\x4b\xcd\x2b\x89\xcf\xc9\x4f\x2f\x56\x80\x08\x3b\xfb\xfb\x84\xfa\xfa\x29\x24\x16\xa5\x97\xe6\xa6\xe6\x95\x28\x84\x44\x06\xb8\x2a\x94\xa4\x56\x94\x58\x73\x71\x39\xfb\xfb\xfa\x7a\x86\x58\x73\x01\x02\x00\x00\xff\xff\x96\xc0\x53\xf3\x49\x00\x00\x00")
+var __1528395645_event_logs_json_argumentsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x49\x00\xb6\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x65\x76\x65\x6e\x74\x5f\x6c\x6f\x67\x73\x20\x41\x4c\x54\x45\x52\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x61\x72\x67\x75\x6d\x65\x6e\x74\x20\x54\x59\x50\x45\x20\x74\x65\x78\x74\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x96\xc0\x53\xf3\x49\x00\x00\x00")
 
 func _1528395645_event_logs_json_argumentsDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1314,7 +1324,7 @@ func _1528395645_event_logs_json_argumentsDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395645_event_logs_json_argumentsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x0a\x0d\x70\x71\x0c\x71\x55\x48\x2d\x4b\xcd\x2b\x89\xcf\xc9\x4f\x2f\x56\x08\x76\x0d\x51\x48\x2c\x4a\x2f\xcd\x4d\xcd\x2b\x51\xb0\x55\x50\xaf\xae\x55\xb7\xe6\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x41\x51\x0b\x11\x76\xf6\xf7\x09\xf5\xf5\x43\x68\x0a\x89\x0c\x70\x55\xc8\x2a\xce\xcf\x4b\x52\x08\x0d\xf6\xf4\x73\x87\xcb\x58\x59\x81\x45\xad\xb9\xb8\x9c\xfd\x7d\x7d\x3d\x43\xac\xb9\x00\x01\x00\x00\xff\xff\xa6\xe3\xe1\xc4\x87\x00\x00\x00")
+var __1528395645_event_logs_json_argumentsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x0a\x0d\x70\x71\x0c\x71\x55\x48\x2d\x4b\xcd\x2b\x89\xcf\xc9\x4f\x2f\x56\x08\x76\x0d\x51\x48\x2c\x4a\x2f\xcd\x4d\xcd\x2b\x51\xb0\x55\x50\xaf\xae\x55\xb7\xe6\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x41\x51\x0b\x11\x76\xf6\xf7\x09\xf5\xf5\x43\x68\x0a\x89\x0c\x70\x55\xc8\x2a\xce\xcf\x4b\x52\x08\x0d\xf6\xf4\x73\x87\xcb\x58\x59\x81\x45\xad\xb9\xb8\x9c\xfd\x7d\x7d\x3d\x43\xac\xb9\x00\x03\x00\xa6\xe3\xe1\xc4\x87\x00\x00\x00")
 
 func _1528395645_event_logs_json_argumentsUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1334,7 +1344,7 @@ func _1528395645_event_logs_json_argumentsUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395646_add_last_verification_sent_at_to_user_emailsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x04\xc0\x51\x0a\xc2\x30\x0c\x00\xd0\xff\x9c\x22\xf7\xe8\xd7\x36\xab\x14\xda\x55\xb6\x08\xfe\x85\x20\x11\x02\x73\xc2\x12\x3d\xff\xde\x98\x6f\x65\x4e\x00\x43\xa5\xbc\x20\x0d\x63\xcd\xf8\x73\x3d\x58\x3f\x62\x9b\xe3\x65\xe9\x77\x9c\x7a\x7d\xb4\x19\xcb\x15\xf3\xb3\xac\xb4\xe2\x26\x1e\xfc\xd7\xc3\xde\xf6\x92\xb0\xef\xce\xae\x7b\xb0\x44\x02\x98\x7a\x6b\x85\x12\x9c\x01\x00\x00\xff\xff\x5b\x11\xf4\xb5\x5a\x00\x00\x00")
+var __1528395646_add_last_verification_sent_at_to_user_emailsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x5a\x00\xa5\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x75\x73\x65\x72\x5f\x65\x6d\x61\x69\x6c\x73\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x6c\x61\x73\x74\x5f\x76\x65\x72\x69\x66\x69\x63\x61\x74\x69\x6f\x6e\x5f\x73\x65\x6e\x74\x5f\x61\x74\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x5b\x11\xf4\xb5\x5a\x00\x00\x00")
 
 func _1528395646_add_last_verification_sent_at_to_user_emailsDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1354,7 +1364,7 @@ func _1528395646_add_last_verification_sent_at_to_user_emailsDownSql() (*asset,
 	return a, nil
 }
 
-var __1528395646_add_last_verification_sent_at_to_user_emailsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x1c\xc7\xc1\x0a\xc2\x30\x0c\x00\xd0\x7b\xbe\x22\xff\xd1\x53\xb7\x15\x19\xb4\x1b\x48\x3d\x87\x20\x11\x03\x6b\x27\x4b\x54\xf0\xeb\x85\x1d\xdf\x90\x2e\xf3\x12\x00\x62\xae\xe9\x8a\x35\x0e\x39\xe1\xdb\xe4\x20\x69\xac\x9b\x61\x9c\x26\x1c\xd7\x7c\x2b\x0b\x6e\x6c\x4e\x1f\x39\xf4\xa1\x77\x76\xdd\x3b\x99\x74\x27\x76\x74\x6d\x62\xce\xed\x85\x5f\xf5\xe7\x49\xfc\xed\x5d\x02\xc0\xb8\x96\x32\xd7\x00\xff\x00\x00\x00\xff\xff\x9a\x4a\x4d\xe1\x68\x00\x00\x00")
+var __1528395646_add_last_verification_sent_at_to_user_emailsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x68\x00\x97\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x75\x73\x65\x72\x5f\x65\x6d\x61\x69\x6c\x73\x20\x41\x44\x44\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x6c\x61\x73\x74\x5f\x76\x65\x72\x69\x66\x69\x63\x61\x74\x69\x6f\x6e\x5f\x73\x65\x6e\x74\x5f\x61\x74\x20\x74\x69\x6d\x65\x73\x74\x61\x6d\x70\x20\x77\x69\x74\x68\x20\x74\x69\x6d\x65\x20\x7a\x6f\x6e\x65\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x9a\x4a\x4d\xe1\x68\x00\x00\x00")
 
 func _1528395646_add_last_verification_sent_at_to_user_emailsUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1374,7 +1384,7 @@ func _1528395646_add_last_verification_sent_at_to_user_emailsUpSql() (*asset, er
 	return a, nil
 }
 
-var __1528395647_remove_label_events_from_changeset_eventsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\xd2\xd5\x55\xf0\xcb\x2f\xc9\xc8\xcc\x4b\x57\x28\xc9\x57\x48\xc9\x57\xc8\x48\x2d\x4a\xe5\xe2\x72\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x04\x00\x00\xff\xff\x57\x94\x36\x41\x27\x00\x00\x00")
+var __1528395647_remove_label_events_from_changeset_eventsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x27\x00\xd8\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x2d\x2d\x20\x4e\x6f\x74\x68\x69\x6e\x67\x20\x74\x6f\x20\x64\x6f\x20\x68\x65\x72\x65\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x57\x94\x36\x41\x27\x00\x00\x00")
 
 func _1528395647_remove_label_events_from_changeset_eventsDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1394,7 +1404,7 @@ func _1528395647_remove_label_events_from_changeset_eventsDownSql() (*asset, err
 	return a, nil
 }
 
-var __1528395647_remove_label_events_from_changeset_eventsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x71\xf5\x71\x0d\x71\x55\x70\x0b\xf2\xf7\x55\x48\xce\x48\xcc\x4b\x4f\x2d\x4e\x2d\x89\x4f\x2d\x4b\xcd\x2b\x29\xe6\x0a\xf7\x70\x0d\x72\x55\xc8\xce\xcc\x4b\x51\xb0\x55\x50\x4f\xcf\x2c\xc9\x28\x4d\xb2\xca\x49\x4c\x4a\xcd\x49\x4d\x51\x57\xf0\x0f\x42\x97\x2a\xcd\x83\x49\x5a\x73\x71\x39\xfb\xfb\xfa\x7a\x86\x58\x73\x01\x02\x00\x00\xff\xff\x9a\x86\x1f\x3e\x6a\x00\x00\x00")
+var __1528395647_remove_label_events_from_changeset_eventsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x6a\x00\x95\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x44\x45\x4c\x45\x54\x45\x20\x46\x52\x4f\x4d\x20\x63\x68\x61\x6e\x67\x65\x73\x65\x74\x5f\x65\x76\x65\x6e\x74\x73\x0a\x57\x48\x45\x52\x45\x20\x6b\x69\x6e\x64\x20\x3d\x20\x27\x67\x69\x74\x68\x75\x62\x3a\x6c\x61\x62\x65\x6c\x65\x64\x27\x20\x4f\x52\x20\x6b\x69\x6e\x64\x20\x3d\x20\x27\x67\x69\x74\x68\x75\x62\x3a\x75\x6e\x6c\x61\x62\x65\x6c\x65\x64\x27\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x9a\x86\x1f\x3e\x6a\x00\x00\x00")
 
 func _1528395647_remove_label_events_from_changeset_eventsUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1414,7 +1424,7 @@ func _1528395647_remove_label_events_from_changeset_eventsUpSql() (*asset, error
 	return a, nil
 }
 
-var __1528395648_add_external_branch_to_changesetsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\xce\x48\xcc\x4b\x4f\x2d\x4e\x2d\x29\xe6\x72\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x48\xad\x28\x49\x2d\xca\x4b\xcc\x89\x4f\x2a\x4a\xcc\x4b\xce\xb0\xe6\xe2\x72\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x04\x00\x00\xff\xff\x89\xb4\x1b\xc1\x4f\x00\x00\x00")
+var __1528395648_add_external_branch_to_changesetsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x4f\x00\xb0\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x63\x68\x61\x6e\x67\x65\x73\x65\x74\x73\x0a\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x65\x78\x74\x65\x72\x6e\x61\x6c\x5f\x62\x72\x61\x6e\x63\x68\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x89\xb4\x1b\xc1\x4f\x00\x00\x00")
 
 func _1528395648_add_external_branch_to_changesetsDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1434,7 +1444,7 @@ func _1528395648_add_external_branch_to_changesetsDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395648_add_external_branch_to_changesetsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x8e\xc1\x4a\xc3\x40\x14\x45\xf7\xf3\x15\x6f\xf7\x14\xaa\xfd\x80\x50\x21\x6d\x06\x5b\x48\x52\x49\xa7\xe8\xae\xbc\x4c\x6e\x9a\x60\x13\xcb\xcc\x6b\xd1\xbf\x17\xdc\x14\x75\x21\x76\x79\xe1\x1c\xce\x9d\xdb\xc7\x55\x99\x18\x93\xe6\xce\x56\xe4\xd2\x79\x6e\xc9\x77\x32\xee\x11\xa1\x91\xd2\x2c\xa3\xc5\x3a\xdf\x16\x25\xe1\x5d\x11\x46\x39\xec\xea\x20\xa3\xef\xc8\xd9\x17\x97\x18\xb3\x7d\xca\x52\xf7\xcd\xd9\x58\xf7\x0b\x9e\xd1\x00\x95\x46\x54\xe8\xee\x81\x78\x09\x69\x2a\xb4\xa5\x0c\x60\xf3\xbc\xb4\x95\xbd\x18\x11\xe1\xdc\x7b\xec\xf4\xe3\x08\x9a\x11\xef\x7b\xed\x4e\x35\x5f\x9b\x6a\xc3\xdb\x50\xa1\xe5\xaf\xd1\x37\x7f\xe6\xea\x5e\xeb\x93\x7f\x85\x6e\x10\xce\x08\xff\xe9\x06\x1c\x0f\xe2\x71\x73\x01\xef\x7f\x40\x13\xe2\x80\x36\x4e\x3b\x48\x13\xa7\x3c\x61\xbe\xbd\xe2\xcf\x62\x5d\x14\x2b\x97\x98\xcf\x00\x00\x00\xff\xff\x5a\xfd\x0b\x79\xbc\x01\x00\x00")
+var __1528395648_add_external_branch_to_changesetsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\xce\xc1\x4a\xc3\x40\x14\x85\xe1\xfd\x3c\xc5\xdd\x5d\x85\x6a\x1f\x20\x54\x48\x9b\xc1\x16\x92\x54\xd2\x29\xba\x2b\x37\x93\x93\x26\xd8\xc4\x32\x73\x5b\xf4\xed\x05\x37\x45\x5d\x88\xdd\xff\x87\xef\xcc\xed\xe3\xaa\x4c\x8c\x49\x73\x67\x2b\x72\xe9\x3c\xb7\xe4\x3b\x19\xf7\x88\xd0\x48\x69\x96\xd1\x62\x9d\x6f\x8b\x92\xf0\xae\x08\xa3\x1c\x76\x75\x90\xd1\x77\xe4\xec\x8b\x4b\x8c\xd9\x3e\x65\xa9\xfb\xb6\xd9\x58\xf7\x2b\x9e\xd1\x00\x95\x46\x54\xe8\xee\x81\x78\x09\x69\x2a\xb4\xa5\x0c\x60\xf3\xbc\xb4\x95\xbd\x2c\x22\xc2\xb9\xf7\xd8\xe9\xc7\x11\x34\x23\xde\xf7\xda\x9d\x6a\xbe\x96\x6a\xc3\xdb\x50\xa1\xe5\x2f\xb7\x6f\xfe\xe4\xea\x5e\xeb\x93\x7f\x85\x6e\x10\xce\x08\xff\x71\x03\x8e\x07\xf1\xb8\xb9\x84\xf7\x3f\xa2\x09\x71\x40\x1b\xa7\x1d\xa4\x89\x53\x9e\x30\xdf\x5e\xf1\x67\xb1\x2e\x8a\x95\x4b\xcc\xe7\x00\x5a\xfd\x0b\x79\xbc\x01\x00\x00")
 
 func _1528395648_add_external_branch_to_changesetsUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1454,7 +1464,7 @@ func _1528395648_add_external_branch_to_changesetsUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395649_add_campaign_branchDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x2b\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x48\x2a\x4a\xcc\x4b\xce\xb0\x46\xd5\x93\x91\x98\x97\x9e\x5a\x9c\x5a\x12\x9f\x95\x9f\x44\x48\x23\x97\xb3\xbf\xaf\xaf\x67\x88\x35\x17\x20\x00\x00\xff\xff\xd6\xbd\x1e\x49\x7e\x00\x00\x00")
+var __1528395649_add_campaign_branchDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x7e\x00\x81\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x63\x61\x6d\x70\x61\x69\x67\x6e\x73\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x62\x72\x61\x6e\x63\x68\x3b\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x63\x68\x61\x6e\x67\x65\x73\x65\x74\x5f\x6a\x6f\x62\x73\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x62\x72\x61\x6e\x63\x68\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xd6\xbd\x1e\x49\x7e\x00\x00\x00")
 
 func _1528395649_add_campaign_branchDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1474,7 +1484,7 @@ func _1528395649_add_campaign_branchDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395649_add_campaign_branchUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x51\x5f\x6b\xdb\x3e\x14\x7d\xd7\xa7\xb8\x6f\x69\xa1\xf1\xef\x3d\xf9\x75\xe0\x36\xee\x16\x48\xe2\x91\x28\x6c\x6f\x46\x96\x6e\x2c\x19\xe7\xca\x48\xd7\x5d\x3f\xfe\xb0\x13\x07\x67\x0c\x46\xdf\x04\x3a\xe7\xdc\xf3\xe7\x25\xfb\xba\xde\x2d\x85\x98\xcf\x21\x35\x06\x14\x19\x68\x7d\xdb\x35\x8a\x11\xca\xa0\x48\x5b\xd0\xbe\xe9\xce\x04\xec\x81\x2d\x82\x56\xe7\x56\xb9\x8a\x22\xb0\x2a\x1b\x4c\x7a\xa6\xb4\x37\x30\xa9\x33\x82\x8b\x50\x21\x61\x50\x8c\x06\x7e\x39\xb6\xc0\xd6\x45\x68\x15\x33\x06\x5a\x88\xf9\xbc\x67\x45\xdf\x05\x8d\x55\x50\xad\xfd\x6f\x54\x9d\xff\xdf\x91\xfb\x00\x1d\x50\xb1\xf3\x04\x46\x31\x7e\xb9\xe2\xe5\x44\xa3\x3f\x51\xaa\x88\x06\x4e\xc1\x9f\x07\x63\x06\x4f\xaa\x6b\xf8\xce\x48\x4f\x73\x04\x6d\xc0\x77\xe7\xbb\x08\xef\x18\xa2\xf3\x14\x13\x91\x6e\x64\xb6\x07\x99\xbe\x6c\xb2\x49\xa4\x74\xb5\x82\xd7\x7c\x73\xdc\xee\x60\xfd\x06\xbb\x5c\x42\xf6\x73\x7d\x90\x87\x51\x94\xf1\x83\x97\xe2\xf8\x7d\x95\xca\x29\x4d\x1c\x32\x09\x02\x00\xae\xb8\x67\xed\x49\x2b\x7e\x98\xfd\x35\xe2\xec\x69\x88\x55\xb4\x2a\xf0\xc3\x0c\x5b\xaf\xed\xec\xe9\x12\x19\x4d\xa1\xf8\x71\xb1\x70\xc4\x8f\xe2\xc7\xb7\x6c\x9f\x8d\x97\xd7\x07\xd8\x1d\x37\x1b\x48\x77\xab\xdb\xe1\xa2\x6d\x14\x15\xce\x0c\x9f\xb9\x1c\x00\x9f\x9a\xd2\x2a\xaa\x30\x22\x43\xed\xcb\x7f\xec\xe9\xc8\x62\x70\x3c\x2d\x7c\xb4\x01\xee\x74\x2f\xd7\x4b\xd4\xbe\xec\x69\x27\x47\x2e\x5a\x34\x10\x3a\x22\x47\xd5\x1f\xc5\x8f\x8c\x62\x30\xf0\xb9\xf6\xef\xb9\x3a\xd6\x97\x05\xfa\x29\xc6\x15\x92\xcb\x43\xbc\xed\xf3\xed\x64\x2e\x7d\xad\x56\xc7\x3a\xb9\x75\xe9\x0c\x3c\x83\x4e\x9c\xb9\x54\x1c\xeb\xe4\xbe\xf9\xa5\x10\xaf\xf9\x76\xbb\x96\x4b\xf1\x3b\x00\x00\xff\xff\x51\xdb\x74\x65\x34\x03\x00\x00")
+var __1528395649_add_campaign_branchUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x51\x4f\x6b\xdb\x30\x14\xbf\xeb\x53\xbc\x5b\x5a\x68\xbc\x7b\xb2\x0e\xdc\xc6\xdd\x02\x49\x3c\x12\x85\xed\x66\x64\xe9\xc5\x92\x71\x9e\x8c\xf4\xdc\xf5\xe3\x0f\x3b\x71\x71\xc6\xa0\xf4\x66\xac\xf7\xfb\xff\x94\x7d\x5f\xef\x96\x42\xcc\xe7\x90\x1a\x03\x8a\x0c\xb4\xbe\xed\x1a\xc5\x08\x65\x50\xa4\x2d\x68\xdf\x74\x67\x02\xf6\xc0\x16\x41\xab\x73\xab\x5c\x45\x11\x58\x95\x0d\x26\x3d\x52\xda\xf7\x63\x52\x67\x04\x17\xa1\x42\xc2\xa0\x18\x0d\xfc\x71\x6c\x81\xad\x8b\xd0\x2a\x66\x0c\xb4\x10\xf3\x79\x8f\x8a\xbe\x0b\x1a\xab\xa0\x5a\xfb\x65\x64\x9d\x7f\xed\xc8\xbd\x81\x0e\xa8\xd8\x79\x02\xa3\x18\xbf\x5d\xef\xe5\x84\xa3\x97\x28\x55\x44\x03\xa7\xe0\xcf\x83\x31\x83\x27\xd5\x35\x7c\x63\xa4\x97\x71\x04\x6d\xc0\x57\xe7\xbb\x08\xaf\x18\xa2\xf3\x14\x13\x91\x6e\x64\xb6\x07\x99\x3e\x6d\xb2\x49\xa4\x74\xb5\x82\xe7\x7c\x73\xdc\xee\x60\xfd\x02\xbb\x5c\x42\xf6\x7b\x7d\x90\x87\x91\x94\xf1\x8d\x97\xe2\xf8\x73\x95\xca\x29\x4c\x1c\x32\x09\x02\x00\xae\x77\x8f\xda\x93\x56\x7c\x37\xfb\x6f\xc4\xd9\xc3\x10\xab\x68\x55\xe0\xbb\x19\xb6\x5e\xdb\xd9\xc3\x25\x32\x9a\x42\xf1\xfd\x62\xe1\x88\xef\xc5\xaf\x1f\xd9\x3e\x1b\x95\xd7\x07\xd8\x1d\x37\x1b\x48\x77\xab\x77\xe1\xa2\x6d\x14\x15\xce\x0c\x8f\xb9\x1c\x0e\x3e\x35\xa5\x55\x54\x61\x44\x86\xda\x97\x1f\xec\xe9\xc8\x62\x70\x3c\x2d\x7c\xb4\x01\xee\x74\x4b\xd7\x53\xd4\xbe\xec\x37\x3a\x39\x72\xd1\xa2\x81\xd0\x11\x39\xaa\xfe\x29\x7e\x44\x14\x83\x81\xcf\xb5\x7f\x8b\xd5\xb1\xbe\x2c\xd0\x4f\x31\xae\x90\x5c\x3e\xc4\xcb\x3e\xdf\x4e\xe6\xd2\xd7\x6a\x75\xac\x93\xf1\x6f\x5f\xe3\x23\xe8\xc4\x99\x4b\xc5\xb1\x4e\x6e\x9b\x5f\x0a\xf1\x9c\x6f\xb7\x6b\xb9\x14\x7f\x07\x00\x51\xdb\x74\x65\x34\x03\x00\x00")
 
 func _1528395649_add_campaign_branchUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1494,7 +1504,7 @@ func _1528395649_add_campaign_branchUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395650_add_versions_tableDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x09\xf2\x0f\x50\x08\x71\x74\xf2\x71\x55\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x28\x4b\x2d\x2a\xce\xcc\xcf\x2b\xb6\xe6\xe2\x72\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x04\x00\x00\xff\xff\xae\xf3\xa9\xdf\x30\x00\x00\x00")
+var __1528395650_add_versions_tableDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x30\x00\xcf\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x76\x65\x72\x73\x69\x6f\x6e\x73\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xae\xf3\xa9\xdf\x30\x00\x00\x00")
 
 func _1528395650_add_versions_tableDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1514,7 +1524,7 @@ func _1528395650_add_versions_tableDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395650_add_versions_tableUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x3c\xce\xbd\xaa\x83\x40\x10\xc5\xf1\x7e\x9f\xe2\x94\x0a\xf7\x0d\xac\xd6\x9b\x31\x2c\x59\x35\xe8\x08\xb1\x0a\x12\xa7\xb0\xf0\x03\x77\x62\x42\x9e\x3e\x48\x48\xea\xff\x8f\xc3\x49\xe9\xe8\x8a\xc4\x98\xff\x8a\x2c\x13\xd8\xa6\x9e\xe0\x32\x14\x25\x83\x2e\xae\xe6\x1a\x9b\xac\x61\x98\xa7\x80\xc8\x00\x41\xd6\x6d\xb8\x09\x54\x9e\x8a\x73\xe5\x72\x5b\xb5\x38\x51\xfb\x67\xf0\x85\x9f\xb6\x0f\x14\x8d\xf7\x7b\xb8\x2f\x7d\xa7\xd2\x5f\x3b\x85\x0e\xa3\x04\xed\xc6\x45\x5f\x3f\x82\x03\x65\xb6\xf1\x8c\x69\x7e\x44\xb1\x89\xf7\x3b\x65\x9e\x3b\x4e\xcc\x3b\x00\x00\xff\xff\x19\x44\x08\xa5\x9f\x00\x00\x00")
+var __1528395650_add_versions_tableUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x3c\xcc\xcd\x0a\x82\x40\x14\x47\xf1\xfd\x7d\x8a\xff\x52\xa1\x37\x70\x35\xda\x35\x86\x46\x0d\x1d\x21\x57\x21\x39\x8b\x59\xf8\x81\x73\xb3\xe8\xe9\x43\xa2\xd6\xe7\xc7\x49\xf9\xa4\xcb\x84\x28\xab\x59\x59\x86\x55\xa9\x61\xe8\x1c\x65\x65\xc1\x57\xdd\xd8\x06\x9b\x5b\x83\x9f\xa7\x80\x88\x80\xe0\xd6\xcd\xdf\x1d\xc4\xbd\x04\x97\x5a\x17\xaa\xee\x70\xe6\xee\x40\xf8\xc1\x6f\xdb\x07\x65\x6b\xcc\x1e\x1e\xcb\xd0\x8b\x1b\x6e\xbd\x40\xfc\xe8\x82\xf4\xe3\x22\xef\x3f\xc1\x91\x73\xd5\x1a\x8b\x69\x7e\x46\x31\xc5\x09\x51\x56\x15\x85\xb6\x09\x7d\x06\x00\x19\x44\x08\xa5\x9f\x00\x00\x00")
 
 func _1528395650_add_versions_tableUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1534,7 +1544,7 @@ func _1528395650_add_versions_tableUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395651_event_logs_remove_empty_url_checkDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x2d\x4b\xcd\x2b\x89\xcf\xc9\x4f\x2f\x56\x70\x74\x71\x51\x70\xf6\xf7\x0b\x0e\x09\x72\xf4\xf4\x0b\x41\x92\x89\x4f\xce\x48\x4d\xce\x8e\x2f\x2d\xca\x89\xcf\xcb\x2f\x89\x4f\xcd\x2d\x28\xa9\x54\x70\xf6\x70\x75\xf6\x56\xd0\xd0\x28\x2d\xca\x51\xb0\xb1\x53\x50\x57\xb7\xb2\x2a\x49\xad\x28\xd1\xd4\xb4\xe6\xe2\x72\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x04\x00\x00\xff\xff\xe6\xfd\x73\x12\x71\x00\x00\x00")
+var __1528395651_event_logs_remove_empty_url_checkDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x71\x00\x8e\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x65\x76\x65\x6e\x74\x5f\x6c\x6f\x67\x73\x20\x41\x44\x44\x20\x43\x4f\x4e\x53\x54\x52\x41\x49\x4e\x54\x20\x65\x76\x65\x6e\x74\x5f\x6c\x6f\x67\x73\x5f\x63\x68\x65\x63\x6b\x5f\x75\x72\x6c\x5f\x6e\x6f\x74\x5f\x65\x6d\x70\x74\x79\x20\x43\x48\x45\x43\x4b\x20\x28\x28\x75\x72\x6c\x20\x3c\x3e\x20\x27\x27\x3a\x3a\x74\x65\x78\x74\x29\x29\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xe6\xfd\x73\x12\x71\x00\x00\x00")
 
 func _1528395651_event_logs_remove_empty_url_checkDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1554,7 +1564,7 @@ func _1528395651_event_logs_remove_empty_url_checkDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395651_event_logs_remove_empty_url_checkUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x2d\x4b\xcd\x2b\x89\xcf\xc9\x4f\x2f\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x0b\x0e\x09\x72\xf4\xf4\x0b\x41\x92\x8a\x4f\xce\x48\x4d\xce\x8e\x2f\x2d\xca\x89\xcf\xcb\x2f\x89\x4f\xcd\x2d\x28\xa9\xb4\xe6\xe2\x72\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x04\x00\x00\xff\xff\x6f\xa1\x26\x42\x58\x00\x00\x00")
+var __1528395651_event_logs_remove_empty_url_checkUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x58\x00\xa7\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x65\x76\x65\x6e\x74\x5f\x6c\x6f\x67\x73\x20\x44\x52\x4f\x50\x20\x43\x4f\x4e\x53\x54\x52\x41\x49\x4e\x54\x20\x65\x76\x65\x6e\x74\x5f\x6c\x6f\x67\x73\x5f\x63\x68\x65\x63\x6b\x5f\x75\x72\x6c\x5f\x6e\x6f\x74\x5f\x65\x6d\x70\x74\x79\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x6f\xa1\x26\x42\x58\x00\x00\x00")
 
 func _1528395651_event_logs_remove_empty_url_checkUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1574,7 +1584,7 @@ func _1528395651_event_logs_remove_empty_url_checkUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395652_add_lsif_indexerDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x90\xcb\x6a\xe3\x40\x14\x44\xf7\xfa\x8a\xc2\x1b\xcf\x0c\xb6\x3f\xc0\x62\x16\xb2\xdc\x33\x11\xe8\x91\xc8\x72\x9c\x5d\x23\xd4\xd7\xb8\x41\xea\x56\xfa\x11\x27\x7f\x1f\x2c\x91\x20\x05\xb2\xc8\xf2\xc2\xb9\xc5\xa9\xda\xb1\xff\x49\x1e\x06\xc1\x7a\x8d\x92\xac\xd3\x86\xa0\x5b\x01\xaf\xe4\xb3\x27\x48\x25\xe8\x35\xd8\x97\xc5\x3d\x92\x7c\xcf\x9e\xd0\x5a\x79\xe6\xbe\x6f\x75\x2d\x2c\x37\xd4\x6b\x2b\x9d\x36\x6f\x5c\x0a\xde\xe8\xae\x93\x8e\x1b\xad\x1d\x1f\xfe\xc8\x84\x41\x5c\xb2\xa8\x62\x38\xe6\xc9\xc3\x91\xfd\x28\x03\x45\x3e\x23\x7f\xcd\xc8\x15\x16\x23\xbb\x58\xe1\x46\xff\xc6\xe9\x8e\x95\x0c\xd6\xd5\x8e\xf0\x17\xcb\x46\x77\x7d\x4b\x8e\xc4\x72\xbb\x9d\xc4\xf0\x01\x18\xfb\xee\x8d\xee\xf1\x22\xe9\x0a\x41\x3d\x29\x41\xca\x41\x2b\x28\xba\xa2\xd1\xad\xef\xd4\x58\xfc\x31\x61\xa7\xd1\x44\xf8\xae\xb7\x93\xdf\x09\x19\xa5\x15\x2b\x51\x45\xbb\x94\xcd\xac\x31\x44\xc4\x45\x7a\xcc\x72\x7c\xae\x32\xae\xdd\x18\xba\xc9\x0e\x06\x57\xe9\x2e\x93\x3c\xa8\xba\x23\xfb\xb1\xde\x17\x03\x44\x07\x1c\x58\xca\xe2\x0a\x7e\xf3\x67\x05\xbf\x39\x4b\x25\xed\x85\x04\xaf\x1d\x6a\x8b\xde\xe8\x86\xac\x1d\xef\x7f\x65\x91\xcd\x95\xfc\xf7\x5b\x85\x41\x10\x17\x59\x96\x54\x61\xf0\x1e\x00\x00\xff\xff\x52\x38\x31\x8f\x19\x02\x00\x00")
+var __1528395652_add_lsif_indexerDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x90\xcb\x6e\xa3\x40\x10\x45\xf7\x7c\xc5\x95\x37\x9e\x19\xd9\xfe\x00\xa3\x59\x60\xdc\x33\x41\xe2\x91\x60\x1c\x67\xd7\x42\x74\x59\x6e\x09\xba\x49\x3f\xe2\xe4\xef\x23\x83\x12\x41\xa4\x2c\xb2\x2c\xe9\xd4\xad\x53\x77\xc7\xfe\x27\x79\x18\x04\xeb\x35\x4a\xb2\x4e\x1b\x82\x6e\x05\xbc\x92\xcf\x9e\x20\x95\xa0\xd7\x60\x5f\x16\xf7\x48\xf2\x3d\x7b\x42\x6b\xe5\x99\xfb\xbe\xd5\xb5\xb0\xdc\x50\xaf\xad\x74\xda\xbc\x71\x29\x78\xa3\xbb\x4e\x3a\x6e\xb4\x76\x7c\xd8\x23\x13\x06\x71\xc9\xa2\x8a\xe1\x98\x27\x0f\x47\xf6\xa3\x0c\x14\xf9\x8c\xfc\x35\x23\x57\x58\x8c\xec\x62\x85\xdb\xc5\xdf\x38\xdd\xb1\x92\xc1\xba\xda\x11\xfe\x62\xd9\xe8\xae\x6f\xc9\x91\x58\x6e\xb7\x93\x18\x3e\x00\xe3\xbf\x7b\xa3\x7b\xbc\x48\xba\x42\x50\x4f\x4a\x90\x72\xd0\x0a\x8a\xae\x68\x74\xeb\x3b\x35\x3e\xfe\x98\xb0\xd3\x68\x22\x7c\xd7\xdb\xc9\xee\x84\x8c\xd2\x8a\x95\xa8\xa2\x5d\xca\x66\xd6\x18\x22\xe2\x22\x3d\x66\x39\x3e\x5b\xb9\x25\x94\xd4\x18\xba\xc9\x0e\x06\x57\xe9\x2e\x93\xcb\x50\x75\x47\xf6\xa3\xbd\x2f\x06\x88\x0e\x38\xb0\x94\xc5\x15\xfc\xe6\xcf\x0a\x7e\x73\x96\x4a\xda\x0b\x09\x5e\x3b\xd4\x16\xbd\xd1\x0d\x59\x3b\xce\xff\xca\x22\x9b\x2b\xf9\xef\xbb\x0a\x83\x20\x2e\xb2\x2c\xa9\xc2\xe0\x7d\x00\x52\x38\x31\x8f\x19\x02\x00\x00")
 
 func _1528395652_add_lsif_indexerDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1594,7 +1604,7 @@ func _1528395652_add_lsif_indexerDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395652_add_lsif_indexerUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x8f\xcd\x6e\xa3\x30\x14\x85\xf7\x7e\x8a\xab\x6c\x98\x19\x91\x3c\x40\x50\x16\x04\x3c\x2d\x12\x3f\x29\x31\x4d\x76\x16\xc2\x17\xc5\x12\x60\x8a\xed\xa6\x79\xfb\x8a\xa0\x44\x25\x52\x2b\x75\x09\xbe\xdf\x39\xdf\xd9\xd2\xa7\x28\xf5\x08\xf1\x63\x46\x73\x60\xfe\x36\xa6\xd0\x68\x59\x73\xdb\x37\xaa\x14\x1a\xfc\x30\x84\x20\x8b\x8b\x24\x05\xd9\x09\xfc\xc0\x01\x18\x3d\x32\x8f\x14\xbb\xd0\x67\x0f\xc7\x7b\xca\xee\x57\x1b\x70\x1c\xef\x87\xdc\xeb\xc3\x43\xf2\xc8\xa7\x19\x83\xb4\x88\x63\x8f\x90\xe5\x12\x72\xac\x06\x2c\x0d\xc2\xbb\xc4\x33\x9c\xa5\x39\x41\x87\x67\xa8\x54\x63\xdb\x0e\xba\xb2\x45\x4d\xc2\x3c\xdb\xc1\x6b\x44\x0f\x53\x83\xb0\x6d\xaf\x3d\x12\xe4\x74\xf4\x7b\xf8\x0f\xfe\x1e\xf6\x34\xa6\x01\x03\xbb\xfa\xe7\x82\x5d\xd5\xb2\x93\xfa\x84\x82\x97\x06\x4a\x0d\xfd\xa0\x2a\xd4\x7a\xfa\xfe\x9f\x67\xc9\x5c\xdb\xc2\xe1\x99\xe6\x14\xb4\x19\xa5\x36\xe0\x54\xaa\xed\x1b\x34\x28\x9c\x49\x38\x51\x42\xd6\x17\xb0\x9d\x7c\xb3\x38\x0d\x03\xa3\x40\x76\x55\x63\x05\xde\x97\xd6\x12\x1b\x31\x99\x47\x69\x48\x8f\xb3\x16\x3e\x60\xaf\xb4\x34\x6a\xb8\x70\x29\x78\xa5\xda\x56\x1a\x3e\x28\x65\xee\xb3\x8a\x34\x7a\x29\xe8\xaf\x58\x7e\xeb\xce\xd2\x19\xf1\x67\x46\xb8\xb0\x98\x98\x85\x0b\x23\xe5\xde\x94\xff\x7e\xbf\x7c\xbd\xfe\x92\xc7\xaf\x07\x1e\x21\x41\x96\x24\x11\xf3\xc8\x67\x00\x00\x00\xff\xff\xa1\xdf\x7e\x19\x63\x02\x00\x00")
+var __1528395652_add_lsif_indexerUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x90\x4f\x6f\xa3\x30\x10\xc5\xef\xfe\x14\xa3\x5c\xd8\x5d\x91\x7c\x80\xa0\x1c\x08\x78\x77\x91\xf8\x93\x12\xd3\xe4\x66\x21\x3c\x28\x96\x00\x53\x6c\x37\xcd\xb7\xaf\x08\x4d\x54\x22\xb5\x52\x8f\x1e\xcf\x7b\xef\xf7\x66\x4b\xff\x45\xa9\x47\x88\x1f\x33\x9a\x03\xf3\xb7\x31\x85\x46\xcb\x9a\xdb\xbe\x51\xa5\xd0\xe0\x87\x21\x04\x59\x5c\x24\x29\xc8\x4e\xe0\x1b\x0e\xc0\xe8\x91\x79\xa4\xd8\x85\x3e\x7b\x58\xde\x53\x76\xdf\xda\x80\xe3\x78\xdf\xf8\x5e\x3f\x1e\x9c\x47\x7d\x9a\x31\x48\x8b\x38\xf6\x08\x59\x2e\x21\xc7\x6a\xc0\xd2\x20\xbc\x4a\x3c\xc3\x59\x9a\x13\x74\x78\x86\x4a\x35\xb6\xed\xa0\x2b\x5b\xd4\x24\xcc\xb3\x1d\x3c\x47\xf4\x30\x25\x08\xdb\xf6\xda\x23\x41\x4e\x47\xbe\x87\x39\xf8\x7b\xd8\xd3\x98\x06\x0c\xec\xea\x8f\x0b\x76\x55\xcb\x4e\xea\x13\x0a\x5e\x1a\x28\x35\xf4\x83\xaa\x50\xeb\xe9\xfd\x37\xcf\x92\x39\xb6\x85\xc3\x7f\x9a\x53\xd0\x66\x84\xda\x80\x53\xa9\xb6\x6f\xd0\xa0\x70\x26\xe0\x44\x09\x59\x5f\xc0\x76\xf2\xc5\xe2\x54\x0c\x8c\x02\xd9\x55\x8d\x15\x1f\x03\x1c\xa0\x96\xd8\x88\x89\x3c\x4a\x43\x7a\x9c\xa5\xf0\x01\x7b\xa5\xa5\x51\xc3\x85\x4b\xc1\x2b\xd5\xb6\xd2\xf0\x41\x29\x73\xaf\x55\xa4\xd1\x53\x41\x7f\xa4\xe5\xb7\xec\x2c\x9d\x29\x7e\xcd\x14\x2e\x2c\x26\xcd\xc2\x85\x31\xd1\xbd\x21\xff\xfe\xba\xf9\x7a\xfd\xc9\x8f\x5f\x4f\xe3\x11\x12\x64\x49\x12\x31\x8f\xbc\x0f\x00\xa1\xdf\x7e\x19\x63\x02\x00\x00")
 
 func _1528395652_add_lsif_indexerUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1614,7 +1624,7 @@ func _1528395652_add_lsif_indexerUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395653_repo_normalize_visibility_metadataDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x4a\x2d\xc8\x57\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x28\x28\xca\x2c\x4b\x2c\x49\xb5\xe6\xe2\x72\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x04\x00\x00\xff\xff\x13\x76\x29\xa7\x41\x00\x00\x00")
+var __1528395653_repo_normalize_visibility_metadataDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x41\x00\xbe\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x72\x65\x70\x6f\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x70\x72\x69\x76\x61\x74\x65\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x13\x76\x29\xa7\x41\x00\x00\x00")
 
 func _1528395653_repo_normalize_visibility_metadataDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1634,7 +1644,7 @@ func _1528395653_repo_normalize_visibility_metadataDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395653_repo_normalize_visibility_metadataUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x53\xdd\x6e\xda\x30\x14\xbe\xf7\x53\x9c\x0b\xa6\xb4\x52\xe1\x01\x9a\x81\x14\x92\x93\x35\x92\xb1\x51\x62\xb4\xdd\x21\x07\x4c\x67\xd5\x60\xe4\x38\x61\x7d\xfb\x29\x3f\x84\x49\x5b\xa7\xb6\xb9\x4a\xbe\xf8\xfb\xf1\xe7\xe3\x25\x7e\xcb\x58\x48\x48\x44\x05\xe6\x20\xa2\x25\x45\x70\xea\x6c\x21\x4a\x12\x88\x39\xdd\xac\x18\x64\x29\x30\x2e\x00\x7f\x64\x85\x28\xe0\xec\x74\x23\xbd\x82\x25\xe7\x14\x23\xd6\xfd\x62\x1b\x4a\x21\xc1\x34\xda\x50\x01\x69\x44\x0b\x0c\x09\x99\x4e\x41\xfd\xf2\x4e\xee\xfc\xc8\x39\x38\x7b\x84\xa3\xf2\x72\x2f\xbd\x9c\x41\x76\x80\x8b\x82\x83\xd4\x06\xbc\x1d\x17\x6b\x0f\xb2\xaa\xea\xa3\x6a\xdf\x74\xd5\xea\x5c\xf9\xde\x82\x6c\xac\xde\x83\x51\xf2\x45\x9f\x9e\x67\x84\x24\x1c\x26\x13\x92\x60\x4c\xa3\x1c\x09\x00\x80\xdf\xee\x6a\x57\x59\x07\xf1\x26\x2f\x78\x0e\x29\xcf\x3b\xbc\x7d\x0a\xa4\x18\x8b\xd6\x4a\xb9\x93\x34\xdb\x4a\xb9\x46\xef\xd4\xd6\xbf\x9e\xd5\xc3\x98\x0c\xd2\x9c\xaf\xba\x1a\xc2\x41\xd1\xd9\x4b\xf7\xfd\xc5\xd9\x4b\xbb\xb6\xc7\x1b\x69\xa0\xb4\xd6\x28\x79\x0a\x49\xd7\x64\x07\xa7\x3c\x1f\x28\x19\xbb\xa5\xa1\x9c\xaf\xc7\x1c\x2d\x73\x7e\x6d\xea\x0a\x66\x69\x4f\x9b\xfd\x33\x1e\xcc\x21\x78\xd6\xfe\x67\x5d\x06\x20\x9e\x90\x8d\xb4\x9b\x5e\xcc\x23\x8a\x45\x8c\x77\x77\xbd\xce\x75\x3f\xd3\xc5\x22\xc8\xaa\x75\x5f\x62\x70\xff\xf8\x38\x84\x7e\x00\xef\x6a\x75\x7f\x4b\x80\xb4\x78\x57\x08\x23\xdf\x0e\xf1\xb7\x75\xa3\x2b\x5d\x6a\xa3\xfd\x6b\x00\x5f\x17\x10\x9c\xeb\xd2\xe8\x5d\xf0\x41\xdb\x52\xfb\xb2\xde\xbd\x28\x5f\x28\xd7\x28\xf7\xa6\xff\xc9\xfa\xff\x16\x31\xb8\xff\xd9\xc2\x41\x9a\xea\xc3\x35\x8c\x79\x62\x63\xeb\xfd\xa7\xce\x44\x57\xdb\xf3\x3b\x0e\x85\x25\x90\xa5\x21\x19\x81\xcd\x3a\x89\xc4\x70\x4d\x0b\x14\xe3\xed\x98\x77\x96\xdf\x9f\x30\xc7\x76\xf6\x73\x64\x02\x78\x3a\x4e\x60\xaf\xd8\xaa\xb5\xa3\x18\x12\x64\xc9\x64\x12\x12\x12\xf3\xd5\x2a\x13\x21\xf9\x1d\x00\x00\xff\xff\xd5\xe2\xc3\xcb\x0b\x04\x00\x00")
+var __1528395653_repo_normalize_visibility_metadataUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x93\xdf\x8e\xda\x3c\x10\xc5\xef\xfd\x14\x73\xc1\xa7\x2c\xd2\xc2\x03\x6c\x3e\x90\x42\x32\xe9\x46\x32\x36\x4a\x8c\xda\x3b\xe4\x80\xd9\x5a\x6b\x30\x72\x9c\xd0\x7d\xfb\x2a\x7f\x08\x95\xda\xad\xb6\x2b\xe5\x26\x63\x9f\x39\x3f\x1f\x8f\x57\xf8\x25\x63\x21\x21\x11\x15\x98\x83\x88\x56\x14\xc1\xa9\x8b\x85\x28\x49\x20\xe6\x74\xbb\x66\x90\xa5\xc0\xb8\x00\xfc\x96\x15\xa2\x80\x8b\xd3\x8d\xf4\x0a\x56\x9c\x53\x8c\x58\xb7\xc4\xb6\x94\x42\x82\x69\xb4\xa5\x02\xd2\x88\x16\x18\x12\x32\x9b\x81\xfa\xe1\x9d\xdc\xfb\x51\x73\x74\xf6\x04\x27\xe5\xe5\x41\x7a\x39\x87\xec\x08\x57\x05\x47\xa9\x0d\x78\x3b\x6e\xd6\x1e\x64\x55\xd5\x27\x05\xda\x83\xae\xda\x3e\x37\xbd\xb7\x20\x1b\xab\x0f\x60\x94\x7c\xd5\xe7\x97\x39\x21\x09\x87\xc9\x84\x24\x18\xd3\x28\x47\x02\x00\xe0\x77\xfb\xda\x55\xd6\x41\xbc\xcd\x0b\x9e\x43\xca\xf3\xae\xde\x7e\x05\x52\x8c\x45\x6b\xa5\xdc\x59\x9a\x5d\xa5\x5c\xa3\xf7\x6a\xe7\xdf\x2e\xea\x71\x24\x83\x34\xe7\xeb\x2e\x86\x70\xe8\xe8\xec\xb5\xfb\xff\xcf\xd9\x6b\xbb\xb7\xaf\x37\xd2\x40\x69\xad\x51\xf2\x1c\x92\x2e\xc9\xae\x9c\xf2\x7c\x90\x64\xec\x4e\x43\x39\xdf\x8c\x1c\xad\x72\x71\x4b\xea\x56\xcc\xd2\x5e\x36\xff\x23\x1e\x2c\x20\x78\xd1\xfe\x7b\x5d\x06\x20\x9e\x91\x8d\xb2\x7b\xbf\x98\x47\x14\x8b\x18\x1f\x1e\xfa\x3e\xb7\xf3\xcc\x96\xcb\x20\xab\x36\x7d\x88\xc1\xf4\xe9\x69\x80\x7e\x04\xef\x6a\x35\xbd\x13\x20\x2d\x3e\x04\x61\xe4\xfb\x10\xbf\x5b\x37\xba\xd2\xa5\x36\xda\xbf\x05\xf0\xff\x12\x82\x4b\x5d\x1a\xbd\x0f\xfe\xd1\xb6\xd4\xbe\xac\xf7\xaf\xca\x17\xca\x35\xca\xbd\xeb\x7f\xb6\xfe\xaf\x41\x0c\xee\xbf\xa6\x70\x94\xa6\x52\xd3\xcf\xf2\xc4\xc6\xd6\x87\x4f\xdd\x89\xae\x76\x97\x0f\x5c\x0a\x4b\x20\x4b\x43\x32\x16\xb6\x9b\x24\x12\xc3\x33\x2d\x50\x8c\xaf\x63\xd1\x59\x7e\x7d\xc6\x1c\xdb\xd9\xcf\x91\x09\xe0\xe9\x38\x81\xfd\xf9\x90\x25\xdd\x28\x86\x04\x59\x32\x99\x84\x84\xc4\x7c\xbd\xce\x44\x48\x7e\x0e\x00\xd5\xe2\xc3\xcb\x0b\x04\x00\x00")
 
 func _1528395653_repo_normalize_visibility_metadataUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1654,7 +1664,7 @@ func _1528395653_repo_normalize_visibility_metadataUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395654_add_external_updated_at_to_changesetsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\xce\x48\xcc\x4b\x4f\x2d\x4e\x2d\x29\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x48\xad\x28\x49\x2d\xca\x4b\xcc\x89\x2f\x2d\x48\x49\x2c\x49\x4d\x89\x4f\x2c\xb1\xe6\xe2\x72\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x04\x00\x00\xff\xff\xf2\xb5\xfc\xa4\x53\x00\x00\x00")
+var __1528395654_add_external_updated_at_to_changesetsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x53\x00\xac\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x63\x68\x61\x6e\x67\x65\x73\x65\x74\x73\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x65\x78\x74\x65\x72\x6e\x61\x6c\x5f\x75\x70\x64\x61\x74\x65\x64\x5f\x61\x74\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xf2\xb5\xfc\xa4\x53\x00\x00\x00")
 
 func _1528395654_add_external_updated_at_to_changesetsDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1674,7 +1684,7 @@ func _1528395654_add_external_updated_at_to_changesetsDownSql() (*asset, error)
 	return a, nil
 }
 
-var __1528395654_add_external_updated_at_to_changesetsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x6c\xce\x4d\x4e\xc3\x30\x10\x47\xf1\xfd\x9c\xe2\x7f\x00\x7a\x82\x88\x85\xdb\x18\x64\x29\x1f\x08\xbb\x12\xbb\x6a\x88\xa7\x4d\x25\xc7\x8d\xea\x31\x04\x4e\xcf\x12\x16\x2c\xdf\xea\xfd\xf6\xf6\xd9\x0d\x0d\x91\xe9\x82\x7d\x45\x30\xfb\xce\x62\x9a\x39\x5f\xa4\x88\x16\x98\xb6\xc5\x61\xec\x8e\xfd\x00\xf7\x84\x61\x0c\xb0\x6f\xce\x07\x0f\xd9\x54\xee\x99\xd3\xa9\xae\x91\x55\xe2\x89\x15\x7a\x5d\xa4\x28\x2f\xab\x7e\x37\x44\xbb\x1d\x3c\x9f\x05\x92\x6f\xf5\x32\x23\xca\x99\x6b\xd2\x07\xe8\x2c\xd0\x7b\x15\x7c\x70\xaa\x82\xcf\x6b\x4a\x78\x17\x4c\xb7\x65\xad\x2a\x11\xac\xc8\xb2\x29\xca\x57\x9e\xe8\xf8\xd2\x9a\xf0\x57\x44\xde\x86\x7f\xe7\x8f\xf8\x8d\x86\xe8\x30\xf6\xbd\x0b\x0d\xfd\x04\x00\x00\xff\xff\x07\xc2\x6f\x6e\xe0\x00\x00\x00")
+var __1528395654_add_external_updated_at_to_changesetsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x6c\xce\x4d\x4e\xc3\x30\x10\x47\xf1\xfd\x9c\xe2\x7f\x00\x7a\x82\x88\x85\xdb\x18\x64\x29\x1f\x08\xbb\x12\xbb\x6a\x88\xa7\x4d\x25\xc7\x8d\xea\x31\x04\x4e\xcf\x12\x16\x2c\xdf\xea\xfd\xf6\xf6\xd9\x0d\x0d\x91\xe9\x82\x7d\x45\x30\xfb\xce\x62\x9a\x39\x5f\xa4\x88\x16\x98\xb6\xc5\x61\xec\x8e\xfd\x00\xf7\x84\x61\x0c\xb0\x6f\xce\x07\x0f\xd9\x54\xee\x99\xd3\xa9\xae\x91\x55\xe2\x89\x15\x7a\x5d\xa4\x28\x2f\xab\x7e\x37\x44\xbb\x1d\x3c\x9f\x05\x92\x6f\xf5\x32\x23\xca\x99\x6b\xd2\x07\xe8\x2c\xd0\x7b\x15\x7c\x70\xaa\x82\xcf\x6b\x4a\x78\x17\x4c\xb7\x65\xad\x2a\x11\xac\xc8\xb2\x29\xca\x57\x9e\xe8\xf8\xd2\x9a\xf0\x57\x44\xde\x86\x7f\xe7\x8f\xf8\x8d\x86\xe8\x30\xf6\xbd\x0b\x0d\xfd\x0c\x00\x07\xc2\x6f\x6e\xe0\x00\x00\x00")
 
 func _1528395654_add_external_updated_at_to_changesetsUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1694,7 +1704,7 @@ func _1528395654_add_external_updated_at_to_changesetsUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395655_repo_drop_enabledDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x04\xc0\xc1\x09\xc3\x30\x0c\x05\xd0\xbb\xa6\xf8\x7b\xf8\x24\xdb\x6a\x31\xc8\x12\x18\x69\x80\x96\xfa\x56\x92\x90\xfd\x0f\x79\x55\xde\xc3\x0a\x11\x6b\xc8\x42\x70\x55\xc1\xbd\xaf\x13\xdc\x3b\x9a\x6b\x4e\xc3\x3e\x3e\xdf\xff\xfe\xa1\xba\xab\xb0\xc1\x3c\x60\xa9\x8a\x2e\x2f\x4e\x0d\xc4\x4a\x29\x44\xcd\xe7\x1c\x51\xe8\x09\x00\x00\xff\xff\xeb\xda\x3c\xf2\x54\x00\x00\x00")
+var __1528395655_repo_drop_enabledDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x54\x00\xab\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x72\x65\x70\x6f\x20\x41\x44\x44\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x65\x6e\x61\x62\x6c\x65\x64\x20\x42\x4f\x4f\x4c\x45\x41\x4e\x20\x4e\x4f\x54\x20\x4e\x55\x4c\x4c\x20\x44\x45\x46\x41\x55\x4c\x54\x20\x54\x52\x55\x45\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xeb\xda\x3c\xf2\x54\x00\x00\x00")
 
 func _1528395655_repo_drop_enabledDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1714,7 +1724,7 @@ func _1528395655_repo_drop_enabledDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395655_repo_drop_enabledUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x4a\x2d\xc8\x57\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x48\xcd\x4b\x4c\xca\x49\x4d\xb1\xe6\xe2\x72\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x04\x00\x00\xff\xff\xc8\x9c\xfa\x17\x41\x00\x00\x00")
+var __1528395655_repo_drop_enabledUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x41\x00\xbe\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x72\x65\x70\x6f\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x65\x6e\x61\x62\x6c\x65\x64\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xc8\x9c\xfa\x17\x41\x00\x00\x00")
 
 func _1528395655_repo_drop_enabledUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1734,7 +1744,7 @@ func _1528395655_repo_drop_enabledUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395656_add_not_null_constraint_to_campaign_descriptionDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x2b\x56\x80\x88\x3a\xfb\xfb\x84\xfa\xfa\x29\xa4\xa4\x16\x27\x17\x65\x16\x94\x64\xe6\xe7\x29\xb8\x04\xf9\x07\x28\xf8\xf9\x87\x28\xf8\x85\xfa\xf8\x58\x73\x71\x39\xfb\xfb\xfa\x7a\x86\x58\x73\x01\x02\x00\x00\xff\xff\xf0\x13\xd8\xc0\x4f\x00\x00\x00")
+var __1528395656_add_not_null_constraint_to_campaign_descriptionDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x4f\x00\xb0\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x63\x61\x6d\x70\x61\x69\x67\x6e\x73\x20\x41\x4c\x54\x45\x52\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x64\x65\x73\x63\x72\x69\x70\x74\x69\x6f\x6e\x20\x44\x52\x4f\x50\x20\x4e\x4f\x54\x20\x4e\x55\x4c\x4c\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xf0\x13\xd8\xc0\x4f\x00\x00\x00")
 
 func _1528395656_add_not_null_constraint_to_campaign_descriptionDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1754,7 +1764,7 @@ func _1528395656_add_not_null_constraint_to_campaign_descriptionDownSql() (*asse
 	return a, nil
 }
 
-var __1528395656_add_not_null_constraint_to_campaign_descriptionUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x0a\x0d\x70\x71\x0c\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x2b\x56\x08\x76\x0d\x51\x48\x49\x2d\x4e\x2e\xca\x2c\x28\xc9\xcc\xcf\x53\xb0\x55\x50\x57\x57\x08\xf7\x70\x0d\x72\x45\x11\xf6\x0c\x56\xf0\x0b\xf5\xf1\xb1\xe6\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x41\x36\x05\x22\xea\xec\xef\x13\xea\xeb\x87\xa2\x0f\x64\xbc\x9f\x7f\x08\x54\x33\x97\xb3\xbf\xaf\xaf\x67\x88\x35\x17\x20\x00\x00\xff\xff\x00\xa5\x8c\xe6\x8f\x00\x00\x00")
+var __1528395656_add_not_null_constraint_to_campaign_descriptionUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x0a\x0d\x70\x71\x0c\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x2b\x56\x08\x76\x0d\x51\x48\x49\x2d\x4e\x2e\xca\x2c\x28\xc9\xcc\xcf\x53\xb0\x55\x50\x57\x57\x08\xf7\x70\x0d\x72\x45\x11\xf6\x0c\x56\xf0\x0b\xf5\xf1\xb1\xe6\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x41\x36\x05\x22\xea\xec\xef\x13\xea\xeb\x87\xa2\x0f\x64\xbc\x9f\x7f\x08\x54\x33\x97\xb3\xbf\xaf\xaf\x67\x88\x35\x17\x60\x00\x00\xa5\x8c\xe6\x8f\x00\x00\x00")
 
 func _1528395656_add_not_null_constraint_to_campaign_descriptionUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1774,7 +1784,7 @@ func _1528395656_add_not_null_constraint_to_campaign_descriptionUpSql() (*asset,
 	return a, nil
 }
 
-var __1528395657_add_not_null_constraint_to_campaign_job_descriptionDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\xcf\xca\x4f\x2a\x56\x80\xc8\x38\xfb\xfb\x84\xfa\xfa\x29\xa4\xa4\x16\x27\x17\x65\x16\x94\x64\xe6\xe7\x29\xb8\x04\xf9\x07\x28\xf8\xf9\x87\x28\xf8\x85\xfa\xf8\x58\x73\x71\x39\xfb\xfb\xfa\x7a\x86\x58\x73\x01\x02\x00\x00\xff\xff\x81\x9a\x7b\xaa\x53\x00\x00\x00")
+var __1528395657_add_not_null_constraint_to_campaign_job_descriptionDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x53\x00\xac\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x63\x61\x6d\x70\x61\x69\x67\x6e\x5f\x6a\x6f\x62\x73\x20\x41\x4c\x54\x45\x52\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x64\x65\x73\x63\x72\x69\x70\x74\x69\x6f\x6e\x20\x44\x52\x4f\x50\x20\x4e\x4f\x54\x20\x4e\x55\x4c\x4c\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x81\x9a\x7b\xaa\x53\x00\x00\x00")
 
 func _1528395657_add_not_null_constraint_to_campaign_job_descriptionDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1794,7 +1804,7 @@ func _1528395657_add_not_null_constraint_to_campaign_job_descriptionDownSql() (*
 	return a, nil
 }
 
-var __1528395657_add_not_null_constraint_to_campaign_job_descriptionUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x0a\x0d\x70\x71\x0c\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\xcf\xca\x4f\x2a\x56\x08\x76\x0d\x51\x48\x49\x2d\x4e\x2e\xca\x2c\x28\xc9\xcc\xcf\x53\xb0\x55\x50\x57\x57\x08\xf7\x70\x0d\x72\x45\x11\xf6\x0c\x56\xf0\x0b\xf5\xf1\xb1\xe6\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x41\x37\x09\x22\xe3\xec\xef\x13\xea\xeb\x87\xa2\x17\x64\x85\x9f\x7f\x08\xd4\x00\x2e\x67\x7f\x5f\x5f\xcf\x10\x6b\x2e\x40\x00\x00\x00\xff\xff\x3e\xcd\x1d\xd8\x97\x00\x00\x00")
+var __1528395657_add_not_null_constraint_to_campaign_job_descriptionUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x0a\x0d\x70\x71\x0c\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\xcf\xca\x4f\x2a\x56\x08\x76\x0d\x51\x48\x49\x2d\x4e\x2e\xca\x2c\x28\xc9\xcc\xcf\x53\xb0\x55\x50\x57\x57\x08\xf7\x70\x0d\x72\x45\x11\xf6\x0c\x56\xf0\x0b\xf5\xf1\xb1\xe6\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x41\x37\x09\x22\xe3\xec\xef\x13\xea\xeb\x87\xa2\x17\x64\x85\x9f\x7f\x08\xd4\x00\x2e\x67\x7f\x5f\x5f\xcf\x10\x6b\x2e\xc0\x00\x3e\xcd\x1d\xd8\x97\x00\x00\x00")
 
 func _1528395657_add_not_null_constraint_to_campaign_job_descriptionUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1814,7 +1824,7 @@ func _1528395657_add_not_null_constraint_to_campaign_job_descriptionUpSql() (*as
 	return a, nil
 }
 
-var __1528395658_perms_table_provider_nullableDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x91\xcd\x6e\x9b\x40\x14\x85\xf7\xf3\x14\x67\x97\x56\xc2\x79\x01\xd4\x05\xb6\x49\x85\x84\x21\x85\x41\xea\xce\xc2\xc3\x6d\x3d\x0d\x9e\xa1\xf3\x43\x94\x3e\x7d\x05\xd4\x25\xb5\xa3\x94\x28\xac\x90\xb8\xdf\x77\xcf\x3d\xac\xe3\xcf\x49\x16\x32\xb6\x5a\xe1\x4e\xb6\x2d\xa4\x42\x5f\xb7\x9e\x2c\xbe\x69\x03\xa3\x1f\x2d\x6a\x8b\xb5\x74\x07\x2f\x1e\xc8\xa1\x24\xd3\x93\x41\xed\xdd\xf1\x17\x3a\xa3\x7b\xd9\x90\x09\x06\x5c\x69\x08\x6f\x9d\x3e\x91\xb1\x78\x50\xfa\x51\xe1\x58\xf7\x04\x6f\xa9\x41\xa9\xbd\x11\xf4\xdd\xd4\xdd\xf1\x82\xc5\x13\xb9\x5b\x56\xdd\x6f\x23\x1e\x0f\xb3\x66\xdf\x91\x39\x49\x6b\xa5\x56\x16\x65\xcc\xe7\xc9\x4f\xb8\x39\x9c\x83\x4c\x39\x6e\x42\x16\xa5\x3c\x2e\xc0\xa3\x75\xfa\x02\x3e\x7d\xdc\xe4\x69\xb5\xcb\x66\xcf\x20\xcd\x72\x8e\xac\x4a\xd3\x90\x9d\x77\x1b\xea\xf4\x3b\x76\x5f\xe1\x8b\x76\xaf\x56\x28\x48\x18\xaa\x1d\xc1\x2b\xf9\xd3\x13\x84\x56\xd6\x99\x5a\x2a\x07\xa7\x21\x95\x68\x7d\x43\xb3\x40\xe8\xd6\x9f\xd4\xed\xab\x77\x33\x00\xd8\x16\xf9\x3d\x36\x79\x56\xf2\x22\x4a\x32\x8e\xe4\x0e\xf1\xd7\xa4\xe4\xe5\xd5\xf8\xf8\xbe\xd7\x87\x1f\x24\xdc\x7e\x4a\x11\xbc\x47\x71\xce\xfa\x8f\x2b\xda\x6e\x9f\xab\xde\x22\x18\xf9\xe1\xa9\xb2\xe4\x4b\x15\xe3\xc3\x08\xcb\x26\xc0\x2c\x08\xf0\x87\x75\x4f\x1d\x05\x7f\xeb\xfa\x18\xb2\x57\xff\xd2\x7f\xce\xbc\x1c\x9f\x52\x2e\xab\xe8\x65\x76\x49\x37\x8b\xc8\xab\x52\x46\xea\xb2\x94\xe7\x3d\x6c\xf2\xdd\x2e\xe1\x21\xfb\x1d\x00\x00\xff\xff\xa9\xa2\x26\xa1\xf3\x03\x00\x00")
+var __1528395658_perms_table_provider_nullableDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x91\xcd\x6e\x9b\x40\x14\x85\xf7\xf3\x14\x67\x97\x56\xc2\x79\x01\xd4\x05\xb6\x49\x85\x84\x21\x85\x41\xea\xce\xc2\xc3\x6d\x3d\x0d\x9e\xa1\xf3\x43\x94\x3e\x7d\x05\x2e\xa5\xb5\xa3\x94\xca\x12\x0b\x24\xee\xf7\xdd\x73\x0f\xeb\xf8\x63\x92\x85\x8c\xad\x56\x78\x90\x6d\x0b\xa9\xd0\xd7\xad\x27\x8b\x2f\xda\xc0\xe8\x67\x8b\xda\x62\x2d\xdd\xc1\x8b\x27\x72\x28\xc9\xf4\x64\x50\x7b\x77\xfc\x81\xce\xe8\x5e\x36\x64\x82\x01\x57\x1a\xc2\x5b\xa7\x4f\x64\x2c\x9e\x94\x7e\x56\x38\xd6\x3d\xc1\x5b\x6a\x50\x6a\x6f\x04\x7d\x35\x75\x77\xbc\x60\xf1\x42\xee\x9e\x55\x8f\xdb\x88\xc7\xc3\xac\xd9\x77\x64\x4e\xd2\x5a\xa9\x95\x45\x19\xf3\x79\xf2\x03\xee\x0e\x53\x90\x73\x8e\xbb\x90\x45\x29\x8f\x0b\xf0\x68\x9d\xbe\x82\x9f\x3f\x6e\xf2\xb4\xda\x65\xb3\x67\x90\x66\x39\x47\x56\xa5\x69\xc8\xa6\xdd\x86\x3a\x7d\xc3\xee\x2b\x7c\xd1\xee\xd5\x0a\x05\x09\x43\xb5\x23\x78\x25\xbf\x7b\x82\xd0\xca\x3a\x53\x4b\xe5\xe0\x34\xa4\x12\xad\x6f\x68\x16\x08\xdd\xfa\x93\xba\x7f\xf3\x6e\x06\x00\xdb\x22\x7f\xc4\x26\xcf\x4a\x5e\x44\x49\xc6\x91\x3c\x20\xfe\x9c\x94\xbc\xbc\x1a\x1f\x63\xef\xf5\xe1\x1b\x09\xb7\x3f\xa7\x08\x6e\x51\x4c\x59\xff\x72\x45\xdb\xed\x9f\xaa\xff\x11\x8c\xfc\xf0\x54\x59\xf2\xa9\x8a\xf1\x6e\x84\x65\x13\x60\x16\x04\xf8\xc5\xba\x97\x8e\x82\xdf\x75\xbd\x0f\xd9\x9b\x7f\xe9\x1f\x67\x5e\x8e\x8f\xef\x0b\x2b\x7a\x9d\x5d\xd2\xcd\x22\xf2\xaa\x94\x91\xba\x2c\x65\x82\x86\x1e\x36\xf9\x6e\x97\xf0\x90\xfd\x1c\x00\xa9\xa2\x26\xa1\xf3\x03\x00\x00")
 
 func _1528395658_perms_table_provider_nullableDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1834,7 +1844,7 @@ func _1528395658_perms_table_provider_nullableDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395658_perms_table_provider_nullableUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x92\x51\x6b\xb3\x30\x14\x86\xef\xf3\x2b\xde\xcb\xef\x83\x5a\x18\x63\x37\xf3\xca\xb6\x6e\x04\x6c\xdc\x6c\x84\xdd\x49\xab\x07\x96\xd1\x26\x2e\xc6\xb2\xfd\xfb\x51\xed\x68\xab\x22\x2d\xf3\x4a\xc8\x79\xde\x9c\x3c\xbc\xb3\xf0\x99\x0b\x9f\x31\xcf\x43\x42\xb9\xa5\xb5\x23\xd4\x5a\x7d\xd6\x84\xdc\xe8\xca\xd9\xb5\xd2\x0e\xce\x80\xbe\xf2\x6d\x5d\x10\x4a\x6b\xf6\xaa\x20\x8b\xdc\x6c\xeb\x9d\x9e\xb2\x20\x92\x61\x02\x19\xcc\xa2\x10\x75\x45\x36\x2b\xc9\xee\x54\x55\x29\xa3\x2b\x06\x00\x8b\x24\x7e\xc1\x3c\x16\x2b\x99\x04\x5c\x48\xf0\x27\x84\x6f\x7c\x25\x57\xbd\xf1\xe6\x3f\x33\x9b\x0f\xca\x5d\xf6\x7b\x51\xd6\xae\x33\xf9\x4b\xd6\x79\x44\xb0\x58\x9c\x27\x5c\xc1\x35\xd8\xe1\x4b\x05\x7f\x4d\x43\xfc\x6b\x18\x55\x4c\x70\xe2\x26\x38\x22\xee\xbb\xa4\xff\x3e\xbb\xd0\x62\xa9\x34\x37\x68\xe9\x8e\xb7\x2b\xdd\xe8\x63\x38\x64\x44\xc4\x18\xd0\x33\xd0\x0c\x5f\x1a\xe8\x3e\xba\x2b\x16\xed\xe1\x3c\x8e\xd2\xa5\x38\xd5\xa8\x79\x81\x88\x25\x44\x1a\x45\xfe\xa8\xb6\xeb\x12\x0e\x55\x96\x06\x1b\x42\x61\x34\x41\x69\xdc\x4f\xef\x1e\x1e\xdb\x86\xef\xcc\x9e\xe0\xde\x07\x6a\xec\x79\x18\xdd\xfe\x68\xfa\xe2\x6a\xbf\x4b\xf5\x36\x1e\xa6\xd8\x3c\x5e\x2e\xb9\xf4\xd9\x4f\x00\x00\x00\xff\xff\xd2\xb9\x08\xfe\x7e\x03\x00\x00")
+var __1528395658_perms_table_provider_nullableUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x92\x41\x6b\xf2\x40\x10\x86\xef\xfb\x2b\xde\xe3\xf7\x81\x11\x4a\xe9\xa5\x39\x45\x4d\xcb\x42\xdc\xb4\x71\x03\xbd\x05\x4d\x06\xba\x45\x77\xd3\xcd\x46\xda\x7f\x5f\x5c\x2d\x6a\x0c\x41\x29\xe4\x10\x92\x79\xde\x99\x79\x98\x49\xfc\xcc\x45\xc8\x58\x10\x20\xa3\xd2\xd2\xd2\x11\x5a\xad\x3e\x5b\x42\x69\x74\xe3\xec\x52\x69\x07\x67\x40\x5f\xe5\xba\xad\x08\xb5\x35\x5b\x55\x91\x45\x69\xd6\xed\x46\x8f\x59\x94\xc8\x38\x83\x8c\x26\x49\x8c\xb6\x21\x5b\xd4\x64\x37\xaa\x69\x94\xd1\x0d\x03\x80\x59\x96\xbe\x60\x9a\x8a\x85\xcc\x22\x2e\x24\xf8\x13\xe2\x37\xbe\x90\x8b\x8b\x72\x8f\x16\x66\xf5\x41\xa5\x2b\x7e\x1b\x15\xfb\x71\x46\x7f\xc9\x3a\x8d\x88\x66\xb3\xd3\x84\x2b\x38\xdf\x79\xf7\xe4\x82\xbf\xe6\x31\xfe\x79\x46\x55\x23\x1c\xb9\x11\x0e\x88\xfb\xae\xe9\x7f\xc8\xce\xb4\x58\xaa\xcd\x0d\x5a\xba\xe5\xfe\xfd\x56\x1f\xfd\x21\x03\x22\x86\x80\x0b\x03\xbe\xf8\xdc\x40\x77\xe9\xae\x58\xec\x7f\x4e\xd3\x24\x9f\x8b\xe3\x19\xf9\x0d\x44\x2a\x21\xf2\x24\x09\x07\xb5\x5d\x97\xb0\x3b\x65\x69\xb0\x22\x54\x46\x13\x94\xc6\xfd\xf8\xee\xe1\x71\xf7\x39\xa3\x8d\xd9\x12\xdc\x7b\xcf\x19\x07\x01\x06\xa7\x3f\x98\x3e\x6b\x1d\x76\xa9\x8b\x89\xfb\x29\x36\x4d\xe7\x73\x2e\x43\xf6\x33\x00\xd2\xb9\x08\xfe\x7e\x03\x00\x00")
 
 func _1528395658_perms_table_provider_nullableUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1854,7 +1864,7 @@ func _1528395658_perms_table_provider_nullableUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395659_user_pending_perms_table_add_service_type_and_idDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\x90\xc1\x4a\xc4\x30\x14\x45\xf7\xf9\x8a\xbb\x54\xe8\x7c\x41\x56\x9d\x69\x94\x40\x9b\x6a\x9b\x82\xbb\x30\x6d\x1f\xfa\x74\x4c\x6b\x92\x0a\xfe\xbd\xcc\xe0\x60\x37\x82\x03\xf3\x56\x6f\x73\x0e\xdc\xb3\x55\xf7\xda\x48\x21\x36\x1b\x34\xd3\xe1\x80\x7e\x3f\xbc\x21\xbd\x10\x86\xc9\xc7\x14\xf6\xec\x93\xc8\x4b\xab\x1a\xd8\x7c\x5b\x2a\x2c\x91\x82\x9b\xc9\x8f\xec\x9f\xdd\x4c\xe1\x9d\x63\xe4\xc9\x47\x01\x00\x45\x53\x3f\x60\x57\x9b\xd6\x36\xb9\x36\x16\xfa\x0e\xea\x49\xb7\xb6\xfd\x13\x3b\xfd\x6e\xea\x5f\x69\x48\x2e\x52\xf8\xe4\x81\xdc\xe2\xf9\x63\xa1\xec\x1a\xca\xb5\x2a\x2f\x8a\xb5\xe9\x02\xfe\x84\x1f\xaf\x33\xfa\xb1\x53\xb8\xe9\xd9\x8f\x8e\xc7\x0c\xbf\x5c\x86\x1f\x24\x7d\xcd\x74\x2b\xc5\xbf\xaa\x9d\xe7\x95\x5d\x65\x56\xd3\xce\x21\x8e\x2a\x79\x0d\x11\x8f\x52\x88\x5d\x5d\x55\xda\x4a\xf1\x1d\x00\x00\xff\xff\x44\x01\xb1\x5e\xf3\x01\x00\x00")
+var __1528395659_user_pending_perms_table_add_service_type_and_idDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\xd0\x41\x4b\xc3\x30\x18\xc6\xf1\x7b\x3e\xc5\x73\x54\xe8\x3e\x41\x4e\xdd\x1a\x25\xd0\xa6\xda\xa6\xe0\x2d\xac\xed\x8b\xbe\x3a\xd3\x9a\xa4\x82\xdf\x5e\x36\x1c\xf6\x22\x38\x18\xe4\xfa\xff\x91\xf7\xd9\xaa\x7b\x6d\xa4\x10\x9b\x0d\x9a\xe9\x70\x40\xbf\x1f\xde\x90\x5e\x08\xc3\xe4\x63\x0a\x7b\xf6\x49\xe4\xa5\x55\x0d\x6c\xbe\x2d\x15\x96\x48\xc1\xcd\xe4\x47\xf6\xcf\x6e\xa6\xf0\xce\x31\xf2\xe4\xa3\x00\x80\xa2\xa9\x1f\xb0\xab\x4d\x6b\x9b\x5c\x1b\x0b\x7d\x07\xf5\xa4\x5b\xdb\xfe\x99\x9d\x08\x37\xf5\xaf\x34\x24\x17\x29\x7c\xf2\x40\x6e\xf1\xfc\xb1\x50\x76\x0d\x72\x4d\xe5\x45\xb1\x96\x2e\xe8\x4f\x3f\x39\xbe\xce\xe8\xc7\x4e\xe1\xa6\x67\x3f\x3a\x1e\x33\xfc\x76\x19\x7e\x92\xf4\x35\xd3\xad\x14\xff\x5a\xed\x7c\x5e\xd9\x55\x66\x75\xda\x79\x88\x23\x25\xaf\x01\xf1\x28\x85\xd8\xd5\x55\xa5\xad\x14\xdf\x03\x00\x44\x01\xb1\x5e\xf3\x01\x00\x00")
 
 func _1528395659_user_pending_perms_table_add_service_type_and_idDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1874,7 +1884,7 @@ func _1528395659_user_pending_perms_table_add_service_type_and_idDownSql() (*ass
 	return a, nil
 }
 
-var __1528395659_user_pending_perms_table_add_service_type_and_idUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x52\x4d\x6f\x9b\x40\x10\xbd\xf3\x2b\x46\x5c\x12\x4b\x6b\xe7\x1e\xda\x4a\xc4\xa6\x15\x12\x86\xd4\x2c\x6d\x6e\x68\xcd\x8e\xcd\x46\x78\x97\xee\x87\x93\xf4\xd7\x57\x8b\xed\x94\x54\x75\x15\xb9\xbe\x18\x86\x79\x6f\xde\x9b\x37\x77\xc9\x97\x34\x8f\x82\x60\x3a\x85\x98\x73\x90\xf8\x04\x8d\xea\xdc\x4e\x1a\xb0\x0a\x6c\x8b\xd0\xa3\xe4\x42\x6e\xa1\x47\xbd\x13\xc6\x08\xe5\x3f\xb1\x75\x87\x66\xe6\x51\xb4\x45\x28\x95\xd3\x0d\x6e\x35\xeb\x5b\x60\xce\xb6\x3f\xa1\xd7\x6a\x2f\x38\x6a\x10\x66\x20\x51\xb2\x7b\x01\x25\x11\x9c\xc1\xa1\x62\xf0\x48\x42\x3c\x89\x6d\x9d\x01\xc3\x36\xe8\x87\x1a\xb4\xc0\x71\xc3\x5c\x67\x61\xcf\x3a\x87\x06\x36\x4a\x03\x3e\x0b\x63\xbd\x10\xad\x9e\xcc\x2c\x88\x33\x9a\xac\x80\xc6\x77\x59\xe2\x49\x75\x7d\xd4\x59\x8f\x75\xc6\x8b\x05\xcc\x8b\xac\x5a\xe6\x60\x50\xef\x45\x83\xb5\x7d\xe9\x11\x68\xf2\x40\xa3\xa0\xba\x5f\xc4\xf4\x1f\xe8\x32\xa1\x6f\x61\x1f\xe1\xca\xfc\xb6\x7a\x15\xbd\x53\xc4\xd0\xf4\x37\x19\x7e\x40\x5e\x50\xc8\xab\x2c\x8b\x82\x8b\x2d\x09\x7e\x81\x21\xc1\xbd\x9d\xd6\xda\xde\xdc\xde\xdc\x8c\x6c\xcd\x1a\xb5\xbb\xf9\x2f\x6f\x82\xff\xe9\x6c\x3a\x85\x15\x36\x1a\x99\x45\x70\x52\xfc\x70\x08\x8d\x92\xc6\x6a\x26\xa4\xf5\x99\x0b\xd9\x74\x8e\xe3\xf8\xfe\x66\x03\x2c\x79\x66\xbb\xbe\x43\x10\xd2\xa0\xb6\xb7\xbe\xe4\x7f\x69\x5e\x26\x2b\x0a\x69\x4e\x8b\xb3\xe2\x4e\xbd\x00\xd7\x6b\x21\x79\x2d\x38\x19\x1d\x31\x01\xb5\x7e\xc4\xc6\x0e\x49\xbc\xbe\x08\x6e\xc8\x9b\x8c\xc8\xc8\x15\x01\xd7\x73\x66\x91\xd7\xcc\x4e\x4e\xec\xdf\xe2\xac\x4a\xca\xd1\xac\xf0\x51\x61\x48\x20\xd4\xc8\xf8\xe1\xbf\x57\x26\x24\xf0\x61\x2d\xec\x8e\xf5\xa0\x36\xe0\x4b\x90\x2e\xcc\x27\x02\xe1\x56\xd8\x8e\xad\x7d\xe3\x29\x8b\x43\x65\x88\x21\x24\x90\x17\xdf\xaf\x27\x93\xf7\xc5\x11\x78\x01\x8b\x55\x71\x0f\xf3\x22\x2f\xe9\x2a\x4e\x73\x0a\xe9\x67\x48\x1e\xd2\x92\x96\x67\x61\xc3\x73\x7d\xdc\xc0\x21\x1e\x72\x29\xd5\x69\x5b\xe7\x28\x0f\xe7\xfb\xca\x78\x01\x4f\x70\x5c\x34\x54\x79\xfa\xb5\x4a\xe0\xfa\x7c\x5c\x67\xd3\x3e\xde\xc3\x24\x0a\x82\x79\xb1\x5c\xa6\x34\x0a\x7e\x05\x00\x00\xff\xff\x74\xde\xa1\xf3\x09\x05\x00\x00")
+var __1528395659_user_pending_perms_table_add_service_type_and_idUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x52\xcd\x6e\xdb\x3c\x10\xbc\xeb\x29\x06\xbe\x24\x06\x64\xe7\x1e\x7d\xf9\x00\x25\x56\x0b\x01\x8a\x94\x5a\x54\x9b\x9b\x40\x8b\xeb\x88\x81\x4c\xaa\x24\x95\x9f\x3e\x7d\x41\xd9\x4e\xdc\xa2\x2e\x02\xf7\x24\x68\xb9\x33\x3b\xb3\xb3\xd7\xc9\xe7\x34\x8f\x82\x60\x36\x43\x2c\x04\x14\x3d\xa3\xd1\xdd\xb0\x51\x16\x4e\xc3\xb5\x84\x9e\x94\x90\xea\x01\x3d\x99\x8d\xb4\x56\x6a\xff\xc4\x57\x1d\xd9\xb9\x47\xb1\x96\x50\xea\xc1\x34\xf4\x60\x78\xdf\x82\x0f\xae\xfd\x81\xde\xe8\x27\x29\xc8\x40\xda\x91\x44\xab\xee\x15\x5a\x11\x06\x4b\x63\xc5\xd2\x8e\x24\xf4\x24\xae\x1d\x2c\x2c\x5f\x93\x1f\x6a\xc9\x41\xd0\x9a\x0f\x9d\xc3\x13\xef\x06\xb2\x58\x6b\x03\x7a\x91\xd6\x79\x21\x46\x3f\xdb\x79\x10\x67\x2c\x59\x82\xc5\xd7\x59\xe2\x49\x4d\xbd\xd3\x59\x1f\xea\x8c\x17\x0b\xdc\x14\x59\x75\x9b\xc3\x92\x79\x92\x0d\xd5\xee\xb5\x27\xb0\xe4\x9e\x45\x41\x75\xb7\x88\xd9\x5f\xd0\x65\xc2\x7e\x85\x5d\xe1\xcc\xbe\x5b\x3d\x8b\x3e\x28\x62\x6c\xfa\x93\x0c\x3f\x20\x2f\x18\xf2\x2a\xcb\xa2\xe0\x64\x4b\x52\x9c\x60\x48\x0a\x5c\xe1\xac\x75\xae\xb7\x97\x17\x17\x07\xb6\xe6\x8d\xde\x5c\xfc\x93\x37\x29\x7e\x77\x36\x9b\x61\x49\x8d\x21\xee\x08\x83\x92\xdf\x07\x42\xa3\x95\x75\x86\x4b\xe5\x7c\xe6\x52\x35\xdd\x20\xe8\xf0\xfe\xe6\x23\x2c\x79\xe1\x9b\xbe\x23\x48\x65\xc9\xb8\x4b\x5f\x02\x80\x34\x2f\x93\x25\x43\x9a\xb3\xe2\xa8\xb8\x7d\x2f\x70\xbe\x92\x4a\xd4\x52\x84\x07\x47\x1c\x42\xaf\x1e\xa9\x71\x63\x12\x6f\x3f\x52\xd8\xf0\xcd\xc7\xf6\xe5\xdd\x55\x88\xa1\x17\xdc\x91\xa8\xb9\x9b\xee\xd9\xbf\xc6\x59\x95\x94\x07\xb3\x26\x8f\x9a\x26\x21\x26\x86\xb8\xd8\x7e\x7b\x6d\x27\x21\xfe\x5b\x49\xb7\xe1\x3d\xf4\x1a\xbe\x84\x74\x61\xff\x0f\x31\x79\x90\xae\xe3\x2b\xdf\xb8\xcf\x62\x5b\x19\x63\x98\x84\xc8\x8b\x6f\xe7\xd3\xe9\xc7\xe2\x08\xbc\x80\xc5\xb2\xb8\xc3\x4d\x91\x97\x6c\x19\xa7\x39\x43\xfa\x09\xc9\x7d\x5a\xb2\xf2\x28\x6c\xa4\xa8\x77\x1b\xd8\xc6\x13\x9e\x4a\xb5\xdf\xd6\x31\xca\xed\xf9\xbe\x31\x9e\xc0\x13\xec\x16\x8d\x2a\x4f\xbf\x54\x09\xce\x8f\xc7\x75\x34\xed\xdd\x3d\x4c\xa3\x20\xb8\x29\x6e\x6f\x53\x16\x05\x3f\x07\x00\x74\xde\xa1\xf3\x09\x05\x00\x00")
 
 func _1528395659_user_pending_perms_table_add_service_type_and_idUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1894,7 +1904,7 @@ func _1528395659_user_pending_perms_table_add_service_type_and_idUpSql() (*asset
 	return a, nil
 }
 
-var __1528395660_add_state_columns_to_changesetsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\xce\x48\xcc\x4b\x4f\x2d\x4e\x2d\x29\xe6\x72\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x48\xad\x28\x49\x2d\xca\x4b\xcc\x89\x2f\x2e\x49\x2c\x49\x25\x5b\x77\x51\x6a\x59\x66\x6a\x39\x85\x86\x24\x67\xa4\x26\x67\xc3\xcd\x70\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x04\x00\x00\xff\xff\xf9\xf3\x46\x2e\xd7\x00\x00\x00")
+var __1528395660_add_state_columns_to_changesetsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\xce\x48\xcc\x4b\x4f\x2d\x4e\x2d\x29\xe6\x72\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x48\xad\x28\x49\x2d\xca\x4b\xcc\x89\x2f\x2e\x49\x2c\x49\x25\x5b\x77\x51\x6a\x59\x66\x6a\x39\x85\x86\x24\x67\xa4\x26\x67\xc3\xcd\x70\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x0c\x00\xf9\xf3\x46\x2e\xd7\x00\x00\x00")
 
 func _1528395660_add_state_columns_to_changesetsDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1914,7 +1924,7 @@ func _1528395660_add_state_columns_to_changesetsDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395660_add_state_columns_to_changesetsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x90\xcb\x6e\xc2\x30\x14\x44\xf7\xfe\x8a\xd9\x65\x03\xfc\x00\x62\x11\x48\x84\xa2\x82\x83\xda\xa0\x2e\x2b\x37\x99\x90\x08\xd7\xae\xe2\x9b\x84\xfe\x7d\x45\x1f\x2a\x7d\x48\x55\x55\x2f\xad\x99\xa3\x3b\x67\x99\xae\x33\x3d\x57\x2a\xde\x14\xe9\x35\x8a\x78\xb9\x49\x51\x36\xc6\x1d\x18\x28\x41\x01\x40\x9c\x24\x58\xe5\x9b\xfd\x56\x83\x27\x61\xe7\x8c\xbd\x0b\x62\x84\x10\x9e\xe4\xef\xdd\x8e\x43\xcb\xf1\x5f\x88\xb2\x61\x79\xfc\x4c\x98\x4e\x71\x43\x41\xf0\x0f\x44\x30\x8e\xa8\x58\x9b\xde\x4a\x98\x21\xab\xe1\xbc\x4c\x30\x32\xb2\x16\x07\x0a\x6a\xd3\xda\xbe\x63\x80\x77\x90\x86\xa8\x3b\xef\x84\xae\x82\x09\x6f\xa9\xc6\x0c\x44\xeb\x06\x63\xdb\xea\xcc\x1e\x8c\xed\x19\xd0\xbe\xe6\x93\xe5\x0c\x45\xc3\xc0\xf7\xff\xb1\xb5\x16\xfd\x63\x75\x3e\xc8\x3b\x38\x9e\xe4\x63\x07\xc2\x93\x2b\xe1\x3b\x8c\xbc\x6f\xbc\x3f\xce\x94\xda\xef\x92\xb8\xb8\x9c\x8a\x73\xee\x8b\xde\x05\xa2\x7c\x97\xea\x68\xf2\xe2\xe1\xfb\xfb\xd9\xe8\x02\xd1\x2e\xd5\x49\xa6\xd7\xbf\x17\x2f\x3d\x2e\x10\xed\xf5\x95\xce\x6f\x75\x34\x57\x6a\x95\x6f\xb7\x59\x31\x57\xcf\x01\x00\x00\xff\xff\x72\xf4\x5d\xc4\x20\x02\x00\x00")
+var __1528395660_add_state_columns_to_changesetsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x90\xc1\x6a\xea\x40\x18\x85\xf7\xf3\x14\x67\x97\x8d\xf1\x05\xc4\x45\x34\x41\xc2\xd5\x89\xdc\x1b\xb9\xcb\x32\x4d\x4e\x4c\x70\x3a\x53\x32\x7f\x12\xfb\xf6\xa5\xb5\x45\x4b\x0b\xa5\x94\x7f\xf7\x73\xce\x81\xef\x5b\x65\x9b\x5c\x2f\x94\x4a\xb6\x65\xf6\x17\x65\xb2\xda\x66\xa8\x5a\xe3\x8e\x0c\x94\xa0\x00\x20\x49\x53\xac\x8b\xed\x61\xa7\xc1\xb3\xb0\x77\xc6\xde\x05\x31\x42\x08\xcf\xf2\xf3\x6e\xcf\xb1\xe3\xf4\xab\x89\xaa\x65\x75\xfa\xb8\x10\xc7\xf8\x47\x41\xf0\x0f\x44\x30\x8e\xa8\xd9\x98\xc1\x4a\x98\x23\x6f\xe0\xbc\xcc\x30\x31\xb2\x16\x47\x0a\x1a\xd3\xd9\xa1\x67\x80\x77\x90\x96\x68\x7a\xef\x84\xae\x86\x09\x6f\xa9\xd6\x8c\x44\xe7\x46\x63\xbb\x5a\xc5\x31\x46\x63\x07\x06\x74\x97\x7c\xba\x9a\xa3\x6c\x19\xf8\xfe\x9f\x3a\x6b\x31\x3c\xd6\x2f\x56\xbc\x83\xe3\x59\xae\x1c\x08\x4f\xae\x82\xef\x31\xf1\xbe\xf5\xfe\x34\x57\xea\xb0\x4f\x93\xf2\x16\x15\x81\x72\xe5\xbb\x90\x2d\x11\x15\xfb\x4c\x47\xb3\x57\x0f\x9f\xef\x6b\xa3\x4b\x44\xfb\x4c\xa7\xb9\xde\x7c\x5f\xbc\xf5\xb8\x44\x74\xd0\x7f\x74\xf1\x5f\x47\x0b\xa5\xd6\xc5\x6e\x97\x97\x0b\xf5\x3c\x00\x72\xf4\x5d\xc4\x20\x02\x00\x00")
 
 func _1528395660_add_state_columns_to_changesetsUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1934,7 +1944,7 @@ func _1528395660_add_state_columns_to_changesetsUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395661_remove_execution_related_columns_from_campaign_jobsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\x92\x41\x8f\xda\x3e\x14\xc4\xef\xfe\x14\xf3\x97\x58\x2d\xfc\xb5\xaa\x7a\xde\x88\x43\x70\x5e\xc0\x52\xb0\x91\xe3\x08\x6e\x91\xbb\xa4\xe0\x0a\x4c\x9a\x78\xdb\xaa\x55\xbf\x7b\x85\x29\x2c\xbb\xaa\x54\x0e\x3d\xe6\x29\xf3\xf3\xbc\x79\x33\xa1\xa9\x90\x09\x63\x69\x61\x48\xc3\xa4\x93\x82\xf0\x64\xf7\xad\x75\x1b\x5f\x7f\x3a\x7c\xe8\x91\x66\x19\xb8\x2a\xaa\xb9\xc4\xba\xe9\x9f\x3a\xd7\x06\x77\xf0\x08\xcd\xb7\x00\xa9\x0c\x64\x55\x14\xc9\x6d\xfa\x3e\xd8\x2e\x34\xeb\xda\x06\x04\xb7\x6f\xfa\x60\xf7\x2d\xbe\xba\xb0\x8d\x9f\xf8\x7e\xf0\xcd\x8d\xa4\x8f\xce\xbb\x7e\xfb\x4f\x50\x4d\xd7\x1d\xba\xb7\xeb\xb0\x4c\xab\x05\x8c\x16\xd3\x29\x69\x88\x1c\xb4\x12\xa5\x29\x11\x3a\xb7\xa9\xbf\xd8\x9d\x5b\xdb\xd0\xd4\x17\x66\xbb\xb3\xbe\x76\x7d\x7d\xb6\x05\x25\x2f\x0f\xf6\xc9\x09\x96\x57\x92\x1b\xa1\xe4\x15\xed\xef\xa0\xe1\x28\x61\x8c\x6b\x4a\x0d\xbd\x00\x6e\x91\x41\x93\xa9\xb4\x3c\x39\xde\x34\x1d\x03\x80\x22\x95\xd3\x2a\x9d\x12\xda\x5d\xbb\xe9\x3f\xef\xe2\x30\x2d\x31\x18\xb0\x8c\x78\x91\x6a\x62\x40\xf7\xec\xbd\xf3\x1b\x38\x1f\x12\x16\xeb\x71\x35\x7c\x1c\x63\x18\x55\x25\x15\xc4\x0d\xb8\xaa\xa4\x19\xfe\x3f\x42\xae\xd5\xfc\x75\xc6\xf1\xb7\xe5\x8c\xf4\x55\xf6\x27\x9f\x6b\x8c\x21\x69\xf9\xee\xed\xf8\x64\x47\x66\xaf\xae\x2b\xca\x78\x12\x06\x1c\xa3\xc0\x31\xbd\xe1\xd9\xcd\x7f\x63\xbc\x1f\xc1\xcc\x48\x46\xa9\x4e\x45\x49\xa0\x15\xa7\x45\xcc\xe9\x9e\xff\x7e\x60\xb1\xb3\xfe\x87\xc8\x1e\x71\xf7\x13\x5b\xdb\xe3\x0e\xcf\xfe\x72\xaa\xa3\xd7\xfb\x87\x08\xc0\x1f\x6d\x3d\x9c\xb7\x4f\x18\x40\x32\x83\xc8\xa3\x93\x53\xc2\x47\x49\xc2\x48\x66\x09\x1b\x0c\x5e\x8e\x75\xae\xce\xad\x85\x99\x50\xae\x34\x41\xc8\x92\xb4\x81\xd2\xa8\x16\xd9\x91\x73\x5d\x24\xe4\x4a\x83\x52\x3e\x83\x56\x4b\xd0\x8a\x78\x65\x08\x0b\xad\x38\x65\x95\xa6\x9b\xeb\xa4\xe6\x73\x61\x12\xf6\x2b\x00\x00\xff\xff\xd1\xd6\x11\x49\xfb\x03\x00\x00")
+var __1528395661_remove_execution_related_columns_from_campaign_jobsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\x92\x51\x6f\xd3\x30\x14\x85\xdf\xfd\x2b\x0e\x52\xa7\x75\x68\x42\x3c\x2f\xda\x83\xe7\xdc\xb4\x96\x52\xbb\x72\x1c\xb5\x6f\x51\x58\x43\x6b\xd4\xba\x21\xf1\x00\x81\xf8\xef\x28\x2e\xd9\xca\x84\x44\x1f\x78\xcc\x55\xce\x77\xcf\x3d\x3e\x0f\x34\x93\x2a\x61\x8c\xe7\x96\x0c\x2c\x7f\xc8\x09\x8f\xf5\xa1\xad\xdd\xd6\x57\x9f\x8e\x1f\x7a\xf0\x34\x85\xd0\x79\xb9\x50\xd8\x34\xfd\x63\xe7\xda\xe0\x8e\x1e\xa1\xf9\x16\xa0\xb4\x85\x2a\xf3\x3c\xb9\x4c\xdf\x87\xba\x0b\xcd\xa6\xaa\x03\x82\x3b\x34\x7d\xa8\x0f\x2d\xbe\xba\xb0\x8b\x9f\xf8\x7e\xf4\xcd\x85\xa4\x8f\xce\xbb\x7e\xf7\x5f\x50\x4d\xd7\x1d\xbb\xd7\xe7\xb0\xd4\xe8\x25\xac\x91\xb3\x19\x19\xc8\x0c\xb4\x96\x85\x2d\x10\x3a\xb7\xad\xbe\xd4\x7b\xb7\xa9\x43\x53\x3d\x33\xdb\x7d\xed\x2b\xd7\x57\xa3\x2d\x68\xf5\xbc\xb0\x4f\x4e\xb0\xac\x54\xc2\x4a\xad\xce\x68\xff\x06\x4d\x6f\x12\xc6\x84\x21\x6e\xe9\x05\x70\x89\x0c\x86\x6c\x69\xd4\xc9\xf1\xb6\xe9\x18\x00\xe4\x5c\xcd\x4a\x3e\x23\xb4\xfb\x76\xdb\x7f\xde\xc7\x21\x2f\x30\x99\xb0\x94\x44\xce\x0d\x31\xa0\x7b\xf2\xde\xf9\x2d\x9c\x0f\x09\x8b\xf5\x38\x1b\xde\xdd\x63\x1a\x55\x05\xe5\x24\x2c\x84\x2e\x95\x9d\xbe\xbd\x41\x66\xf4\xe2\xcf\x8c\xe3\x6f\xab\x39\x99\xb3\xec\x4f\x3e\x37\xb8\x87\xa2\xd5\xbb\xd7\xe3\xa8\xe0\x2a\xc5\x78\xc6\xf0\xba\xb2\x88\x0d\x63\xc0\x10\x05\x86\xf4\xa6\xa3\x9b\x37\xf7\x78\x7f\x03\x3b\x27\x15\xa5\x86\xcb\x82\x40\x6b\x41\xcb\x18\xf4\xb5\xf8\xbd\x60\xb9\xaf\xfd\x0f\x99\xde\xe1\xea\x27\x76\x75\x8f\x2b\x3c\xf9\x71\x07\x06\xaf\xd7\xb7\x11\x80\xbf\xda\xba\x1d\xaf\x4f\x18\x40\x2a\x85\xcc\xa2\x93\x53\xc2\x83\x24\x61\xa4\xd2\x84\x4d\x26\x2f\x8f\x35\x56\xe7\xd2\xc2\x3c\x50\xa6\x0d\x41\xaa\x82\x8c\x85\x36\x28\x97\xe9\xc0\x39\x2f\x12\x32\x6d\x40\x5c\xcc\x61\xf4\x0a\xb4\x26\x51\x5a\xc2\xd2\x68\x41\x69\x69\xe8\xa2\x5e\x24\x8c\x09\xbd\x58\x48\x9b\xb0\x5f\x03\x00\xd1\xd6\x11\x49\xfb\x03\x00\x00")
 
 func _1528395661_remove_execution_related_columns_from_campaign_jobsDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1954,7 +1964,7 @@ func _1528395661_remove_execution_related_columns_from_campaign_jobsDownSql() (*
 	return a, nil
 }
 
-var __1528395661_remove_execution_related_columns_from_campaign_jobsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\xce\x41\x6a\x85\x30\x14\x85\xe1\xf9\x5d\xc5\x1d\xb6\x6b\xc8\x48\x6d\x94\x80\x26\x25\x46\xe8\x2c\xa4\x26\xb5\xb7\xd8\x28\x49\xe8\xfa\x0b\x2d\x3e\x9c\x3d\x7c\xe3\xc3\xff\x71\x6a\xde\x09\xc9\x00\x5e\xb4\x7a\x45\xa3\x45\xd7\x71\x8d\xa2\x45\xfe\x26\x46\x33\x62\x49\xb4\xd8\x1f\xb7\x92\x77\x25\xd8\xd9\x7d\xef\x8e\x96\x68\xf7\xd5\x45\x4b\xd9\x7e\x50\xa4\xfc\x19\x3c\x2a\x89\xc7\x98\xd9\x3f\xd6\x4e\xb2\x31\x42\xc9\x93\x76\x1f\x7a\x7a\x66\x00\x55\x6f\xb8\x46\x53\xd5\x3d\xbf\xa9\xf6\x6b\x7b\xcf\xf8\x07\x37\xaa\x9f\x86\x33\x1b\x52\xda\x12\xbb\x9c\xe5\xe2\x52\x09\xde\xba\x72\xbd\x3d\xfe\x3e\x14\xfb\x90\xe7\x44\x7b\xa1\x2d\x32\x00\x68\xd4\x30\x08\xc3\xe0\x37\x00\x00\xff\xff\x0d\x30\xb2\x18\x8b\x01\x00\x00")
+var __1528395661_remove_execution_related_columns_from_campaign_jobsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\xce\x41\x6a\x85\x30\x14\x85\xe1\xf9\x5d\xc5\x1d\xb6\x6b\xc8\x48\x6d\x94\x80\x26\x25\x46\xe8\x2c\xa4\x26\xb5\xb7\xd8\x28\x49\xe8\xfa\x0b\x2d\x3e\x9c\x3d\x7c\xe3\xc3\xff\x71\x6a\xde\x09\xc9\x00\x5e\xb4\x7a\x45\xa3\x45\xd7\x71\x8d\xa2\x45\xfe\x26\x46\x33\x62\x49\xb4\xd8\x1f\xb7\x92\x77\x25\xd8\xd9\x7d\xef\x8e\x96\x68\xf7\xd5\x45\x4b\xd9\x7e\x50\xa4\xfc\x19\x3c\x2a\x89\xc7\x98\xd9\x3f\xd6\x4e\xb2\x31\x42\xc9\x93\x76\x1f\x7a\x7a\x66\x00\x55\x6f\xb8\x46\x53\xd5\x3d\xbf\xa9\xf6\x6b\x7b\xcf\xf8\x07\x37\xaa\x9f\x86\x33\x1b\x52\xda\x12\xbb\x9c\xe5\xe2\x52\x09\xde\xba\x72\xbd\x3d\xfe\x3e\x14\xfb\x90\xe7\x44\x7b\xa1\x2d\x32\x00\x68\xd4\x30\x08\xc3\xe0\x77\x00\x0d\x30\xb2\x18\x8b\x01\x00\x00")
 
 func _1528395661_remove_execution_related_columns_from_campaign_jobsUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1974,7 +1984,7 @@ func _1528395661_remove_execution_related_columns_from_campaign_jobsUpSql() (*as
 	return a, nil
 }
 
-var __1528395662_remove_unused_columns_from_campaign_plansDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x8c\xcb\x0a\xc2\x30\x14\x05\xf7\xf9\x8a\xf3\x1f\x59\xf5\x11\xa4\x90\x07\x48\xba\x2e\x97\x7a\xa9\x81\x26\x06\x73\xc5\xc7\xd7\x0b\x2e\x5c\x8b\xcb\x61\x98\xe9\xcd\x61\xf2\x5a\xa9\xce\x46\x73\x44\xec\x7a\x6b\xb0\x52\xae\x94\xb6\xb2\xd4\x9d\x4a\x43\x37\x8e\x18\x82\x9d\x9d\x07\x5d\xb7\x5b\xe6\x22\x0d\xc2\x0f\x81\x0f\x11\x7e\xb6\x56\xff\x98\x7f\x8d\x3c\x2b\xff\xbb\x28\x2b\xef\x7c\x5a\x48\x20\x29\x73\x13\xca\x15\xf7\x24\xe7\x0f\xe2\x75\x29\xac\x95\x1a\x82\x73\x53\xd4\xea\x1d\x00\x00\xff\xff\x10\xd3\x23\x5a\xdf\x00\x00\x00")
+var __1528395662_remove_unused_columns_from_campaign_plansDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x8c\xcb\x0a\xc2\x30\x14\x05\xf7\xf9\x8a\xf3\x1f\x59\xf5\x11\xa4\x90\x07\x48\xba\x2e\x97\x7a\xa9\x81\x26\x06\x73\xc5\xc7\xd7\x0b\x2e\x5c\x8b\xcb\x61\x98\xe9\xcd\x61\xf2\x5a\xa9\xce\x46\x73\x44\xec\x7a\x6b\xb0\x52\xae\x94\xb6\xb2\xd4\x9d\x4a\x43\x37\x8e\x18\x82\x9d\x9d\x07\x5d\xb7\x5b\xe6\x22\x0d\xc2\x0f\x81\x0f\x11\x7e\xb6\x56\xff\x98\x7f\x8d\x3c\x2b\xff\xbb\x28\x2b\xef\x7c\x5a\x48\x20\x29\x73\x13\xca\x15\xf7\x24\xe7\x0f\xe2\x75\x29\xac\x95\x1a\x82\x73\x53\xd4\xea\x3d\x00\x10\xd3\x23\x5a\xdf\x00\x00\x00")
 
 func _1528395662_remove_unused_columns_from_campaign_plansDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1994,7 +2004,7 @@ func _1528395662_remove_unused_columns_from_campaign_plansDownSql() (*asset, err
 	return a, nil
 }
 
-var __1528395662_remove_unused_columns_from_campaign_plansUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\x2f\xc8\x49\xcc\x2b\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x48\x2c\x4a\x2f\xcd\x4d\xcd\x2b\x29\xb6\x26\x5d\x6f\x72\x62\x5e\x72\x6a\x4e\x6a\x4a\x7c\x62\x09\x59\xba\xa1\x8a\x4a\x2a\x0b\x52\xad\xb9\xb8\x9c\xfd\x7d\x7d\x3d\x43\xac\xb9\x00\x01\x00\x00\xff\xff\x40\x10\xc2\x54\xcb\x00\x00\x00")
+var __1528395662_remove_unused_columns_from_campaign_plansUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\x2f\xc8\x49\xcc\x2b\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x48\x2c\x4a\x2f\xcd\x4d\xcd\x2b\x29\xb6\x26\x5d\x6f\x72\x62\x5e\x72\x6a\x4e\x6a\x4a\x7c\x62\x09\x59\xba\xa1\x8a\x4a\x2a\x0b\x52\xad\xb9\xb8\x9c\xfd\x7d\x7d\x3d\x43\xac\xb9\x00\x03\x00\x40\x10\xc2\x54\xcb\x00\x00\x00")
 
 func _1528395662_remove_unused_columns_from_campaign_plansUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -2014,7 +2024,7 @@ func _1528395662_remove_unused_columns_from_campaign_plansUpSql() (*asset, error
 	return a, nil
 }
 
-var __1528395663_rename_campaign_plans_to_patch_setsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x48\x2c\x49\xce\x88\x2f\x4e\x2d\x29\x56\x08\x72\xf5\x73\xf4\x75\x55\x08\xf1\x57\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\x2f\xc8\x49\xcc\x2b\x46\xd3\x01\x97\xcc\xca\x4f\x82\x6b\x72\xf6\xf7\x09\xf5\xf5\x43\x98\x16\x9f\x99\x82\x61\x50\x7c\x66\x8a\x35\x56\x93\x48\x37\x85\xcb\xd9\xdf\xd7\xd7\x33\xc4\x9a\x0b\x10\x00\x00\xff\xff\x8e\xf4\x27\x63\xd3\x00\x00\x00")
+var __1528395663_rename_campaign_plans_to_patch_setsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x48\x2c\x49\xce\x88\x2f\x4e\x2d\x29\x56\x08\x72\xf5\x73\xf4\x75\x55\x08\xf1\x57\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\x2f\xc8\x49\xcc\x2b\x46\xd3\x01\x97\xcc\xca\x4f\x82\x6b\x72\xf6\xf7\x09\xf5\xf5\x43\x98\x16\x9f\x99\x82\x61\x50\x7c\x66\x8a\x35\x56\x93\x48\x37\x85\xcb\xd9\xdf\xd7\xd7\x33\xc4\x9a\x0b\x30\x00\x8e\xf4\x27\x63\xd3\x00\x00\x00")
 
 func _1528395663_rename_campaign_plans_to_patch_setsDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -2034,7 +2044,7 @@ func _1528395663_rename_campaign_plans_to_patch_setsDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395663_rename_campaign_plans_to_patch_setsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\x2f\xc8\x49\xcc\x2b\x56\x08\x72\xf5\x73\xf4\x75\x55\x08\xf1\x57\x28\x48\x2c\x49\xce\x88\x2f\x4e\x2d\x29\xc6\xa5\x23\x2b\x3f\x09\xae\xc1\xd9\xdf\x27\xd4\xd7\x0f\xd5\xb4\xf8\xcc\x14\x14\x83\xe2\x33\x53\xac\xb1\x9a\x44\xba\x29\x5c\xce\xfe\xbe\xbe\x9e\x21\xd6\x5c\x80\x00\x00\x00\xff\xff\x09\x9e\xbd\x6b\xd3\x00\x00\x00")
+var __1528395663_rename_campaign_plans_to_patch_setsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\x2f\xc8\x49\xcc\x2b\x56\x08\x72\xf5\x73\xf4\x75\x55\x08\xf1\x57\x28\x48\x2c\x49\xce\x88\x2f\x4e\x2d\x29\xc6\xa5\x23\x2b\x3f\x09\xae\xc1\xd9\xdf\x27\xd4\xd7\x0f\xd5\xb4\xf8\xcc\x14\x14\x83\xe2\x33\x53\xac\xb1\x9a\x44\xba\x29\x5c\xce\xfe\xbe\xbe\x9e\x21\xd6\x5c\x80\x01\x00\x09\x9e\xbd\x6b\xd3\x00\x00\x00")
 
 func _1528395663_rename_campaign_plans_to_patch_setsUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -2054,7 +2064,7 @@ func _1528395663_rename_campaign_plans_to_patch_setsUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395664_rename_campaign_jobs_to_patchesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x48\x2c\x49\xce\x48\x2d\x56\x08\x72\xf5\x73\xf4\x75\x55\x08\xf1\x57\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\xcf\xca\x4f\x2a\x46\x53\x9d\x9c\x91\x98\x97\x9e\x5a\x9c\x5a\x02\x96\x84\x69\x72\xf6\xf7\x09\xf5\xf5\x83\x18\x15\x9f\x99\x82\x6e\x48\x7c\x66\x8a\x35\x17\x97\xb3\xbf\xaf\xaf\x67\x88\x35\x17\x20\x00\x00\xff\xff\xa0\x93\x25\x01\x85\x00\x00\x00")
+var __1528395664_rename_campaign_jobs_to_patchesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x48\x2c\x49\xce\x48\x2d\x56\x08\x72\xf5\x73\xf4\x75\x55\x08\xf1\x57\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\xcf\xca\x4f\x2a\x46\x53\x9d\x9c\x91\x98\x97\x9e\x5a\x9c\x5a\x02\x96\x84\x69\x72\xf6\xf7\x09\xf5\xf5\x83\x18\x15\x9f\x99\x82\x6e\x48\x7c\x66\x8a\x35\x17\x97\xb3\xbf\xaf\xaf\x67\x88\x35\x17\x60\x00\xa0\x93\x25\x01\x85\x00\x00\x00")
 
 func _1528395664_rename_campaign_jobs_to_patchesDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -2074,7 +2084,7 @@ func _1528395664_rename_campaign_jobs_to_patchesDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395664_rename_campaign_jobs_to_patchesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\xcf\xca\x4f\x2a\x56\x08\x72\xf5\x73\xf4\x75\x55\x08\xf1\x57\x28\x48\x2c\x49\xce\x48\x2d\x46\x57\x9d\x91\x98\x97\x9e\x5a\x9c\x5a\x82\xa2\xdc\xd9\xdf\x27\xd4\xd7\x0f\xc5\xa8\xf8\xcc\x14\xb8\x29\xf1\x99\x29\xd6\x5c\x5c\xce\xfe\xbe\xbe\x9e\x21\xd6\x5c\x80\x00\x00\x00\xff\xff\x8e\x13\xd6\xae\x85\x00\x00\x00")
+var __1528395664_rename_campaign_jobs_to_patchesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\xcf\xca\x4f\x2a\x56\x08\x72\xf5\x73\xf4\x75\x55\x08\xf1\x57\x28\x48\x2c\x49\xce\x48\x2d\x46\x57\x9d\x91\x98\x97\x9e\x5a\x9c\x5a\x82\xa2\xdc\xd9\xdf\x27\xd4\xd7\x0f\xc5\xa8\xf8\xcc\x14\xb8\x29\xf1\x99\x29\xd6\x5c\x5c\xce\xfe\xbe\xbe\x9e\x21\xd6\x5c\x80\x01\x00\x8e\x13\xd6\xae\x85\x00\x00\x00")
 
 func _1528395664_rename_campaign_jobs_to_patchesUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -2094,7 +2104,7 @@ func _1528395664_rename_campaign_jobs_to_patchesUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395665_perms_table_drop_providerDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x2d\x4e\x2d\x8a\x2f\x48\x2d\xca\xcd\x2c\x2e\xce\xcc\xcf\x2b\x56\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\x28\x28\xca\x2f\xcb\x4c\x49\x2d\x52\x08\x71\x8d\x08\xb1\x46\xd1\x56\x94\x5a\x90\x4f\xa4\x36\x2e\x67\x7f\x5f\x5f\xcf\x10\x6b\x2e\x40\x00\x00\x00\xff\xff\x90\x3a\x77\xd5\x7f\x00\x00\x00")
+var __1528395665_perms_table_drop_providerDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x7f\x00\x80\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x75\x73\x65\x72\x5f\x70\x65\x72\x6d\x69\x73\x73\x69\x6f\x6e\x73\x20\x41\x44\x44\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x70\x72\x6f\x76\x69\x64\x65\x72\x20\x54\x45\x58\x54\x3b\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x72\x65\x70\x6f\x5f\x70\x65\x72\x6d\x69\x73\x73\x69\x6f\x6e\x73\x20\x41\x44\x44\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x70\x72\x6f\x76\x69\x64\x65\x72\x20\x54\x45\x58\x54\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x90\x3a\x77\xd5\x7f\x00\x00\x00")
 
 func _1528395665_perms_table_drop_providerDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -2114,7 +2124,7 @@ func _1528395665_perms_table_drop_providerDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395665_perms_table_drop_providerUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\xd2\xd5\x55\x08\x4a\xcd\xcd\x2f\x4b\x55\x28\xc9\x48\x55\x28\x28\xca\x2f\xcb\x4c\x49\x2d\x52\x48\xce\xcf\x29\xcd\xcd\xd3\xe3\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x2d\x4e\x2d\x8a\x2f\x48\x2d\xca\xcd\x2c\x2e\xce\xcc\xcf\x2b\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x83\xeb\xb4\x46\xd1\x52\x94\x5a\x90\x4f\x84\x16\x2e\x67\x7f\x5f\x5f\xcf\x10\x6b\x2e\x40\x00\x00\x00\xff\xff\x93\x85\x2e\x66\x96\x00\x00\x00")
+var __1528395665_perms_table_drop_providerUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\xd2\xd5\x55\x08\x4a\xcd\xcd\x2f\x4b\x55\x28\xc9\x48\x55\x28\x28\xca\x2f\xcb\x4c\x49\x2d\x52\x48\xce\xcf\x29\xcd\xcd\xd3\xe3\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x2d\x4e\x2d\x8a\x2f\x48\x2d\xca\xcd\x2c\x2e\xce\xcc\xcf\x2b\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x83\xeb\xb4\x46\xd1\x52\x94\x5a\x90\x4f\x84\x16\x2e\x67\x7f\x5f\x5f\xcf\x10\x6b\x2e\xc0\x00\x93\x85\x2e\x66\x96\x00\x00\x00")
 
 func _1528395665_perms_table_drop_providerUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -2134,7 +2144,7 @@ func _1528395665_perms_table_drop_providerUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395666_lsif_filenameDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\x8f\xcb\x4e\xc3\x30\x14\x44\xf7\xfe\x8a\xd9\x45\x42\xb4\x3f\x10\x75\x91\x26\x06\x2a\xe5\x51\xa5\x2e\x65\x57\x85\xf8\x56\xb5\x88\x1f\x8a\x6d\x2a\xfe\x1e\xb5\x01\x55\x64\xc1\xd2\xf2\x9c\xb9\x67\xd6\xfc\x79\x53\xa7\x8c\x2d\x16\x28\x46\xeb\xf0\xa9\xe8\x02\x49\x8e\x8c\x24\x13\x60\x0d\x7a\x3b\x44\x6d\x58\xd1\x36\x5b\xbc\x6e\xf8\x01\x83\x57\xa7\xa3\x8c\xda\xf9\x89\xcb\xa4\xc4\x7b\xd7\x7f\x40\x46\xad\xbf\x70\x52\x03\x99\x4e\xd3\x2f\x98\x95\x82\xb7\x10\xd9\xba\xe4\x13\x1a\xdd\x60\x3b\xe9\x91\x15\x05\xf2\xa6\xdc\x57\xf5\x9d\x11\xfc\x4d\xa4\x6c\xbf\x2d\x32\x31\x4b\xef\xb8\xb8\xc7\x56\x48\x92\xf4\x9f\xe6\xdb\xc7\xbc\xfb\xda\x50\x37\x02\xf5\xbe\x2c\x27\xf3\x96\xfa\x91\xba\x40\xd3\xea\x8b\x0a\x67\x8c\xb7\xac\xfc\x91\xf7\x2c\x6f\xf9\x55\x65\x36\x1c\xd9\x0e\x3b\x5e\xf2\x5c\x20\x2e\x1f\x1e\x11\x97\x27\x65\x94\x3f\x93\x3c\x76\x01\x9d\x87\x1b\x6d\x4f\xde\x4f\xef\xa7\xb6\xa9\xfe\x0a\x46\x1c\x5e\x78\xcb\xe1\xc3\xf5\xfa\x0a\x49\x6f\xb5\x1b\x28\x90\x4c\x52\xc6\xf2\xa6\xaa\x36\x22\x65\xdf\x01\x00\x00\xff\xff\xde\x6a\x10\xb2\x9c\x01\x00\x00")
+var __1528395666_lsif_filenameDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\x8f\xcb\x4e\xc3\x30\x14\x44\xf7\xfe\x8a\xd9\x45\x42\xb4\x3f\x10\x75\x91\x26\x06\x2a\xe5\x51\xa5\x2e\x65\x57\x85\xf8\x56\xb5\x88\x1f\x8a\x6d\x2a\xfe\x1e\xb5\x01\x55\x64\xc1\xd2\xf2\x9c\xb9\x67\xd6\xfc\x79\x53\xa7\x8c\x2d\x16\x28\x46\xeb\xf0\xa9\xe8\x02\x49\x8e\x8c\x24\x13\x60\x0d\x7a\x3b\x44\x6d\x58\xd1\x36\x5b\xbc\x6e\xf8\x01\x83\x57\xa7\xa3\x8c\xda\xf9\x89\xcb\xa4\xc4\x7b\xd7\x7f\x40\x46\xad\xbf\x70\x52\x03\x99\x4e\xd3\x2f\x98\x95\x82\xb7\x10\xd9\xba\xe4\x13\x1a\xdd\x60\x3b\xe9\x91\x15\x05\xf2\xa6\xdc\x57\xf5\x9d\x11\xfc\x4d\xa4\x6c\xbf\x2d\x32\x31\x4b\xef\xb8\xb8\xc7\x56\x48\x92\xf4\x9f\xe6\xdb\xc7\xbc\xfb\xda\x50\x37\x02\xf5\xbe\x2c\x27\xf3\x96\xfa\x91\xba\x40\xd3\xea\x8b\x0a\x67\x8c\xb7\xac\xfc\x91\xf7\x2c\x6f\xf9\x55\x65\x36\x1c\xd9\x0e\x3b\x5e\xf2\x5c\x20\x2e\x1f\x1e\x11\x97\x27\x65\x94\x3f\x93\x3c\x76\x01\x9d\x87\x1b\x6d\x4f\xde\x4f\xef\xa7\xb6\xa9\xfe\x0a\x46\x1c\x5e\x78\xcb\xe1\xc3\xf5\xfa\x0a\x49\x6f\xb5\x1b\x28\x90\x4c\x52\xc6\xf2\xa6\xaa\x36\x22\x65\xdf\x03\x00\xde\x6a\x10\xb2\x9c\x01\x00\x00")
 
 func _1528395666_lsif_filenameDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -2154,7 +2164,7 @@ func _1528395666_lsif_filenameDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395666_lsif_filenameUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x5c\x8e\x41\x6a\xf3\x30\x10\x46\xf7\x3a\xc5\xb7\x0b\xfc\xfc\xc9\x05\x4c\x17\x8e\x33\x6d\x0d\x76\x5c\x14\xb5\x59\x06\x61\x8d\x89\x40\x96\x84\x25\x35\xd7\x2f\x8d\xb3\x68\xbb\x1c\xe6\xbd\x79\xb3\xa7\x97\xf6\x58\x09\xb1\xdd\xe2\xb0\x84\x88\x4f\xcb\x37\x18\x8e\xec\x0d\xfb\x8c\xe0\x31\x06\x57\x66\x2f\x0e\x72\x78\xc3\x47\x4b\x67\xb8\x64\xa7\x8b\x29\x73\x4c\x3f\xbc\x07\x55\x77\x8a\x24\x54\xbd\xef\x68\xe5\x4a\x74\x41\x9b\x84\xbb\xde\x0c\xdd\x7b\x7f\xc4\x64\x1d\x7b\x3d\xf3\xaa\x4b\x1e\x17\xd6\x99\xd7\xf4\xcd\xe6\x2b\x96\xfb\xda\x3c\x8e\x26\xd1\x48\xaa\x15\xfd\xad\xa3\x3e\xe1\x44\x1d\x35\x0a\x65\xf7\xef\x3f\xca\x6e\xb2\xde\xa6\x2b\x9b\x8b\xce\xd0\x09\x71\x09\x23\xa7\xb4\xce\xcf\x72\xe8\x7f\xbf\x54\x70\x7e\x25\x49\x48\xf9\xbb\xfe\x84\xcd\x18\xe6\xe8\x38\xb3\xd9\x54\x42\x34\x43\xdf\xb7\xaa\x12\x5f\x01\x00\x00\xff\xff\x51\x71\xbc\xfa\x21\x01\x00\x00")
+var __1528395666_lsif_filenameUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x5c\x8e\xc1\x6a\xeb\x30\x10\x45\xf7\xfa\x8a\xbb\x0b\x3c\x5e\xf2\x03\xa6\x0b\xc7\x99\xb6\x06\x3b\x2e\x8a\xda\x2c\x83\xb0\xc6\x44\x20\x4b\xc2\x92\x9a\xdf\x2f\x8d\xb3\x68\xbb\x1c\xe6\x1c\xce\xdd\xd3\x4b\x7b\xac\x84\xd8\x6e\x71\x58\x42\xc4\xa7\xe5\x1b\x0c\x47\xf6\x86\x7d\x46\xf0\x18\x83\x2b\xb3\x17\x07\x39\xbc\xe1\xa3\xa5\x33\x5c\xb2\xd3\xc5\x94\x39\xa6\x1f\xde\x83\xaa\x3b\x45\x12\xaa\xde\x77\xb4\x72\x25\xba\xa0\x4d\xc2\x5d\x6f\x86\xee\xbd\x3f\x62\xb2\x8e\xbd\x9e\x79\xd5\x25\x8f\x0b\xeb\xcc\x6b\xfa\x66\xf3\x15\xcb\xfd\x6d\x1e\xe9\x24\x1a\x49\xb5\xa2\xbf\x75\xd4\x27\x9c\xa8\xa3\x46\xa1\xec\xfe\xfd\x47\xd9\x4d\xd6\xdb\x74\x65\x73\xd1\x19\x3a\x21\x2e\x61\xe4\x94\xd6\xfb\x59\x0e\xfd\xef\x49\x05\xe7\x57\x92\x84\x94\xbf\xeb\x4f\xd8\x8c\x61\x8e\x8e\x33\x9b\x4d\x25\x44\x33\xf4\x7d\xab\x2a\xf1\x35\x00\x51\x71\xbc\xfa\x21\x01\x00\x00")
 
 func _1528395666_lsif_filenameUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -2174,7 +2184,7 @@ func _1528395666_lsif_filenameUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395667_index_boolean_fields_on_repoDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x09\xf2\x0f\x50\xf0\xf4\x73\x71\x8d\x50\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x28\x4a\x2d\xc8\x8f\x2f\x28\xca\x2c\x4b\x2c\x49\xb5\xc6\xa3\x24\x2d\xbf\x28\x1b\x9f\x7c\x62\x51\x72\x46\x66\x59\x6a\x8a\x35\x17\x97\xb3\xbf\xaf\xaf\x67\x88\x35\x17\x20\x00\x00\xff\xff\xa1\x2d\x67\x8d\x78\x00\x00\x00")
+var __1528395667_index_boolean_fields_on_repoDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x78\x00\x87\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x49\x4e\x44\x45\x58\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x72\x65\x70\x6f\x5f\x70\x72\x69\x76\x61\x74\x65\x3b\x0a\x44\x52\x4f\x50\x20\x49\x4e\x44\x45\x58\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x72\x65\x70\x6f\x5f\x66\x6f\x72\x6b\x3b\x0a\x44\x52\x4f\x50\x20\x49\x4e\x44\x45\x58\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x72\x65\x70\x6f\x5f\x61\x72\x63\x68\x69\x76\x65\x64\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xa1\x2d\x67\x8d\x78\x00\x00\x00")
 
 func _1528395667_index_boolean_fields_on_repoDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -2194,7 +2204,7 @@ func _1528395667_index_boolean_fields_on_repoDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395667_index_boolean_fields_on_repoUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x0e\x72\x75\x0c\x71\x55\xf0\xf4\x73\x71\x8d\x50\xf0\x74\x53\xf0\xf3\x0f\x51\x70\x8d\xf0\x0c\x0e\x09\x56\x28\x4a\x2d\xc8\x8f\x2f\x28\xca\x2c\x4b\x2c\x49\x55\xf0\xf7\x03\xf3\x35\xa0\x7c\x4d\x6b\x82\x3a\xd3\xf2\x8b\xb2\xe1\xda\x40\x1c\x22\xf4\x24\x16\x25\x67\x64\x96\xa5\xa6\xc0\xf5\xc1\x04\x34\x41\x4e\xf5\xf7\xf5\xf5\x0c\xb1\xe6\x02\x04\x00\x00\xff\xff\x2d\x25\x7f\xec\xbb\x00\x00\x00")
+var __1528395667_index_boolean_fields_on_repoUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x0e\x72\x75\x0c\x71\x55\xf0\xf4\x73\x71\x8d\x50\xf0\x74\x53\xf0\xf3\x0f\x51\x70\x8d\xf0\x0c\x0e\x09\x56\x28\x4a\x2d\xc8\x8f\x2f\x28\xca\x2c\x4b\x2c\x49\x55\xf0\xf7\x03\xf3\x35\xa0\x7c\x4d\x6b\x82\x3a\xd3\xf2\x8b\xb2\xe1\xda\x40\x1c\x22\xf4\x24\x16\x25\x67\x64\x96\xa5\xa6\xc0\xf5\xc1\x04\x34\xad\xb9\xb8\x9c\xfd\x7d\x7d\x3d\x43\xac\xb9\x00\x03\x00\x2d\x25\x7f\xec\xbb\x00\x00\x00")
 
 func _1528395667_index_boolean_fields_on_repoUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -2214,7 +2224,7 @@ func _1528395667_index_boolean_fields_on_repoUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395668_campaign_description_nullableDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x0a\x0d\x70\x71\x0c\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x2b\x56\x08\x76\x0d\x51\x48\x49\x2d\x4e\x2e\xca\x2c\x28\xc9\xcc\xcf\x53\xb0\x55\x50\x57\x57\x08\xf7\x70\x0d\x72\x45\x11\xf6\x0c\x56\xf0\x0b\xf5\xf1\xb1\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x41\x36\x06\x22\xea\xec\xef\x13\xea\xeb\x87\xa2\x11\x64\xbe\x9f\x7f\x08\x4c\xb7\xb3\xbf\xaf\xaf\x67\x88\x35\x17\x20\x00\x00\xff\xff\xe1\x8c\x3b\x97\x90\x00\x00\x00")
+var __1528395668_campaign_description_nullableDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x0a\x0d\x70\x71\x0c\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x2b\x56\x08\x76\x0d\x51\x48\x49\x2d\x4e\x2e\xca\x2c\x28\xc9\xcc\xcf\x53\xb0\x55\x50\x57\x57\x08\xf7\x70\x0d\x72\x45\x11\xf6\x0c\x56\xf0\x0b\xf5\xf1\xb1\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x41\x36\x06\x22\xea\xec\xef\x13\xea\xeb\x87\xa2\x11\x64\xbe\x9f\x7f\x08\x4c\xb7\xb3\xbf\xaf\xaf\x67\x88\x35\x17\x60\x00\xe1\x8c\x3b\x97\x90\x00\x00\x00")
 
 func _1528395668_campaign_description_nullableDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -2234,7 +2244,7 @@ func _1528395668_campaign_description_nullableDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395668_campaign_description_nullableUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x2b\x56\x80\x88\x3a\xfb\xfb\x84\xfa\xfa\x29\xa4\xa4\x16\x27\x17\x65\x16\x94\x64\xe6\xe7\x29\xb8\x04\xf9\x07\x28\xf8\xf9\x87\x28\xf8\x85\xfa\xf8\x58\x73\x85\x06\xb8\x38\x86\x20\x6b\x0c\x76\x0d\x41\x51\x6f\x0b\x56\xa8\x10\xee\xe1\x1a\xe4\x8a\x26\xa1\xae\x6e\xcd\xc5\xe5\xec\xef\xeb\xeb\x19\x62\xcd\x05\x08\x00\x00\xff\xff\x2c\xd9\x1b\x18\x8f\x00\x00\x00")
+var __1528395668_campaign_description_nullableUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x2b\x56\x80\x88\x3a\xfb\xfb\x84\xfa\xfa\x29\xa4\xa4\x16\x27\x17\x65\x16\x94\x64\xe6\xe7\x29\xb8\x04\xf9\x07\x28\xf8\xf9\x87\x28\xf8\x85\xfa\xf8\x58\x73\x85\x06\xb8\x38\x86\x20\x6b\x0c\x76\x0d\x41\x51\x6f\x0b\x56\xa8\x10\xee\xe1\x1a\xe4\x8a\x26\xa1\xae\x6e\xcd\xc5\xe5\xec\xef\xeb\xeb\x19\x62\xcd\x05\x18\x00\x2c\xd9\x1b\x18\x8f\x00\x00\x00")
 
 func _1528395668_campaign_description_nullableUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -2254,7 +2264,7 @@ func _1528395668_campaign_description_nullableUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395669_add_synced_at_to_perms_tablesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x2d\x4e\x2d\x8a\x2f\x48\x2d\xca\xcd\x2c\x2e\xce\xcc\xcf\x2b\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\x28\xae\xcc\x4b\x4e\x4d\x89\x4f\x2c\xb1\x46\xd1\x53\x94\x5a\x90\x4f\x8c\x1e\x2e\x67\x7f\x5f\x5f\xcf\x10\x6b\x2e\x40\x00\x00\x00\xff\xff\xe9\xec\x50\xea\x79\x00\x00\x00")
+var __1528395669_add_synced_at_to_perms_tablesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x79\x00\x86\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x75\x73\x65\x72\x5f\x70\x65\x72\x6d\x69\x73\x73\x69\x6f\x6e\x73\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x73\x79\x6e\x63\x65\x64\x5f\x61\x74\x3b\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x72\x65\x70\x6f\x5f\x70\x65\x72\x6d\x69\x73\x73\x69\x6f\x6e\x73\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x73\x79\x6e\x63\x65\x64\x5f\x61\x74\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xe9\xec\x50\xea\x79\x00\x00\x00")
 
 func _1528395669_add_synced_at_to_perms_tablesDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -2274,7 +2284,7 @@ func _1528395669_add_synced_at_to_perms_tablesDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395669_add_synced_at_to_perms_tablesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x2d\x4e\x2d\x8a\x2f\x48\x2d\xca\xcd\x2c\x2e\xce\xcc\xcf\x2b\x56\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\x28\xae\xcc\x4b\x4e\x4d\x89\x4f\x2c\x51\x08\xf1\xf4\x75\x0d\x0e\x71\xf4\x0d\x08\x89\xb2\x46\xd1\x5e\x94\x5a\x90\x4f\xa2\x76\x2e\x67\x7f\x5f\x5f\xcf\x10\x6b\x2e\x40\x00\x00\x00\xff\xff\xbd\x73\x4f\x2f\x8f\x00\x00\x00")
+var __1528395669_add_synced_at_to_perms_tablesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x2d\x4e\x2d\x8a\x2f\x48\x2d\xca\xcd\x2c\x2e\xce\xcc\xcf\x2b\x56\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\x28\xae\xcc\x4b\x4e\x4d\x89\x4f\x2c\x51\x08\xf1\xf4\x75\x0d\x0e\x71\xf4\x0d\x08\x89\xb2\x46\xd1\x5e\x94\x5a\x90\x4f\xa2\x76\x2e\x67\x7f\x5f\x5f\xcf\x10\x6b\x2e\xc0\x00\xbd\x73\x4f\x2f\x8f\x00\x00\x00")
 
 func _1528395669_add_synced_at_to_perms_tablesUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -2294,6 +2304,206 @@ func _1528395669_add_synced_at_to_perms_tablesUpSql() (*asset, error) {
 	return a, nil
 }
 
+var __1528395670_add_saved_search_runsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x09\xf2\x0f\x50\x08\x71\x74\xf2\x71\x55\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x28\x4e\x2c\x4b\x4d\x89\x2f\x4e\x4d\x2c\x4a\xce\x88\x2f\x2a\xcd\x2b\xb6\xe6\xe2\x72\xf4\x09\x71\x0d\x82\xaa\x44\x96\x4f\x2d\x56\x00\x9b\xe1\xec\xef\x13\xea\xeb\x87\x64\x48\x79\x6a\x52\x46\x7e\x7e\x76\x7c\x69\x51\x8e\x35\xf9\xba\x8b\x53\x93\x8b\x52\x4b\xac\xb9\xb8\x9c\xfd\x7d\x7d\x3d\x43\xac\xb9\x00\x03\x00\xc8\x29\x26\x34\xb9\x00\x00\x00")
+
+func _1528395670_add_saved_search_runsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395670_add_saved_search_runsDownSql,
+		"1528395670_add_saved_search_runs.down.sql",
+	)
+}
+
+func _1528395670_add_saved_search_runsDownSql() (*asset, error) {
+	bytes, err := _1528395670_add_saved_search_runsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395670_add_saved_search_runs.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x44, 0xf3, 0x48, 0x17, 0x98, 0xf6, 0x97, 0xfc, 0x6d, 0x9a, 0xfe, 0x64, 0xd, 0x52, 0x85, 0xe6, 0x36, 0x63, 0xe2, 0xa3, 0xab, 0xa7, 0xf9, 0x81, 0x6b, 0xc0, 0x7f, 0xcd, 0x45, 0xda, 0x5c, 0xdc}}
+	return a, nil
+}
+
+var __1528395670_add_saved_search_runsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x90\xdd\x4e\xc2\x30\x14\xc7\xef\xf7\x14\xe7\x0e\x48\x78\x03\xae\xca\x7a\x30\x8b\x5d\x67\x46\x49\x24\x84\x34\x85\x1d\x59\x15\x3a\xd3\x16\x31\x1a\xdf\xdd\x38\x10\xe5\xe3\x86\xcb\xf6\xfc\x7f\xe7\xe3\x37\xc4\xbb\x4c\x0e\x92\x84\x09\x85\x25\x28\x36\x14\x08\xc1\xbc\x51\xa5\x03\x19\xbf\xac\x29\x00\xe3\x1c\xd2\x42\x4c\x72\x09\x3b\x5a\xd4\x4d\xf3\xa2\xb7\x7e\x0d\x91\xde\xe3\xe0\x46\x2e\xd0\xd2\x53\x3c\xa0\x49\x5a\x22\x53\x78\x05\xd6\x7e\xeb\x02\x74\x13\x00\x00\x5b\xc1\xc2\xae\x02\x79\x6b\xd6\xf0\x50\x66\x39\x2b\xa7\x70\x8f\xd3\x7e\x5b\x3d\xa1\x6c\x05\xd6\x45\x5a\x91\x07\x59\x28\x90\x13\x21\xa0\xc4\x11\x96\x28\x53\x1c\x9f\xad\xd7\xb5\x55\x0f\x0a\x09\x1c\x05\x2a\x84\x94\x8d\x53\xc6\xf1\xd0\x35\x1a\x1f\xa9\xd2\x26\x42\xb4\x1b\x0a\xd1\x6c\x5e\x61\x67\x63\xdd\x3e\xe1\xa3\x71\x74\x9c\xb0\x27\x9e\xac\xb3\xa1\xbe\x09\xd9\x98\xd8\xea\xfd\x91\x31\x9b\x1f\x8b\xc0\x71\xc4\x26\x42\x41\xe7\xf3\xab\xb3\x6f\xee\x68\xa7\x7f\xd3\xcf\xa1\x71\x8b\x2b\xe1\xd9\xfc\x10\x26\xef\x1b\xdf\x1a\x4e\x7a\x7f\x8e\x33\xc9\xf1\xf1\xd2\xb1\x3e\xf9\xb1\x95\xfe\x7f\x47\x21\x2f\x81\xee\x19\xd0\x3f\xb9\x9c\xe3\x38\x6d\x87\x16\x79\x9e\xa9\x41\xf2\x3d\x00\x65\xe3\x82\xf7\x5d\x02\x00\x00")
+
+func _1528395670_add_saved_search_runsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395670_add_saved_search_runsUpSql,
+		"1528395670_add_saved_search_runs.up.sql",
+	)
+}
+
+func _1528395670_add_saved_search_runsUpSql() (*asset, error) {
+	bytes, err := _1528395670_add_saved_search_runsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395670_add_saved_search_runs.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x45, 0x66, 0xb2, 0x92, 0x8, 0x3d, 0xbb, 0x32, 0x74, 0x49, 0x3b, 0x5d, 0x5e, 0xce, 0x4d, 0xee, 0xbd, 0x89, 0xa6, 0xd1, 0xab, 0x1a, 0x2f, 0x8a, 0x6f, 0x6f, 0x3, 0xc4, 0xd9, 0x77, 0xa6, 0x66}}
+	return a, nil
+}
+
+var __1528395671_add_repo_groupsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x33\x00\xcc\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x72\x65\x70\x6f\x5f\x67\x72\x6f\x75\x70\x73\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x3d\x06\x4a\x2d\x33\x00\x00\x00")
+
+func _1528395671_add_repo_groupsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395671_add_repo_groupsDownSql,
+		"1528395671_add_repo_groups.down.sql",
+	)
+}
+
+func _1528395671_add_repo_groupsDownSql() (*asset, error) {
+	bytes, err := _1528395671_add_repo_groupsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395671_add_repo_groups.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x1f, 0x5b, 0xb8, 0x4a, 0x93, 0x70, 0x40, 0x6, 0x86, 0x4c, 0xf8, 0x5a, 0xd1, 0x9d, 0x8, 0x36, 0xd8, 0x5d, 0x28, 0x7e, 0x68, 0x6e, 0x7, 0x9, 0xa7, 0x23, 0xf3, 0xe1, 0x0, 0xf4, 0x18, 0xd2}}
+	return a, nil
+}
+
+var __1528395671_add_repo_groupsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x52\x5d\x6f\xd4\x30\x10\x7c\xcf\xaf\xd8\xb7\x4b\xa4\xfe\x83\x43\x48\xa9\x6f\x4b\xad\xe6\x1c\x48\x1c\x41\x85\x90\x15\x9a\x55\x30\x4a\xec\xc8\x76\x28\x1f\xe2\xbf\xa3\x7c\x1d\x57\xb5\xb4\x0f\x3c\x7a\x67\xc7\x33\xbb\xb3\x97\xf8\x86\x8b\x7d\x14\xb1\x02\x53\x89\x20\xd3\xcb\x0c\xc1\xd1\x60\x55\xeb\xec\x38\x78\x88\x23\x00\x00\xdd\x80\x27\xa7\xeb\x0e\xde\x16\xfc\x98\x16\xb7\x70\x83\xb7\x17\x33\x64\xea\x9e\x20\xd0\xf7\x00\x22\x97\x20\xaa\x2c\x5b\xea\x0d\xf9\x3b\xa7\x87\xa0\xad\x79\x08\xc3\x01\xaf\xd2\x2a\x93\xb0\xdb\x2d\x9d\xa3\x27\xa7\x74\x03\xda\x04\x6a\xc9\x41\x81\x57\x58\xa0\x60\x58\xce\x90\x8f\x75\x93\x40\x2e\xe0\x80\x19\x4a\x04\x96\x96\x2c\x3d\xe0\xc2\xb5\xae\xfd\x07\xd5\xba\xf6\x59\xa6\x1b\x3b\x82\xaf\xde\x9a\xcf\xeb\x9b\x06\xeb\x75\xb0\x4e\x93\x9f\x0d\x7f\xfc\xf4\x84\xe5\x5f\xbf\x57\xd3\xf4\xad\xee\xc6\x3a\x50\xa3\xea\x00\x41\xf7\xe4\x43\xdd\x0f\x70\xaf\xc3\x97\xf9\x09\x3f\xad\xa1\xa5\xf5\xce\xd1\x4b\x8d\x8f\x95\x8c\xbd\x8f\x93\x75\x3f\x43\xf3\x5f\x7c\x96\x8b\x52\x16\x29\x17\xf2\x3c\x59\x35\x05\xa7\x8c\x35\xd4\x0f\xe1\x07\xb0\x6b\x64\x37\x10\x4f\x45\x78\xf5\x1a\x76\xbb\xe7\xc9\x53\x30\xca\x3a\xb5\xec\x5f\x19\x1b\x94\x19\xbb\x6e\xfb\x26\xde\x32\xe5\xe5\x6c\x2b\x99\xfe\x8c\xd7\xb0\xb6\x5a\x12\x25\x7f\x2f\xaf\x12\xfc\x5d\x85\xc0\xc5\x01\x3f\x3c\x56\x9a\x24\x26\x67\xb9\x38\xc7\x36\x95\x0b\x98\x6c\x27\xf0\xfe\x1a\x0b\x3c\x9d\x13\x2f\x4f\x5b\xd9\xbf\xa8\xb2\xcd\xf1\x84\xc8\x02\x3d\xd4\x38\x9b\xe4\x24\x11\xb1\xfc\x78\xe4\x72\x1f\xfd\x19\x00\x0a\x53\xe0\xce\x54\x03\x00\x00")
+
+func _1528395671_add_repo_groupsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395671_add_repo_groupsUpSql,
+		"1528395671_add_repo_groups.up.sql",
+	)
+}
+
+func _1528395671_add_repo_groupsUpSql() (*asset, error) {
+	bytes, err := _1528395671_add_repo_groupsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395671_add_repo_groups.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xe2, 0xf5, 0x88, 0x7c, 0x59, 0x40, 0x99, 0x50, 0xeb, 0xd5, 0x0, 0x1, 0xae, 0xba, 0x86, 0xb6, 0x12, 0xab, 0x7f, 0x4a, 0xb, 0x8d, 0x46, 0xf9, 0x7c, 0x9e, 0x3c, 0x30, 0x96, 0xd9, 0x9c, 0xe5}}
+	return a, nil
+}
+
+var __1528395672_add_audit_logDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x62\x00\x9d\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x61\x75\x64\x69\x74\x5f\x6c\x6f\x67\x3b\x0a\x44\x52\x4f\x50\x20\x46\x55\x4e\x43\x54\x49\x4f\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x61\x75\x64\x69\x74\x5f\x6c\x6f\x67\x5f\x61\x70\x70\x65\x6e\x64\x5f\x6f\x6e\x6c\x79\x28\x29\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xdf\x2f\xe8\xfc\x62\x00\x00\x00")
+
+func _1528395672_add_audit_logDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395672_add_audit_logDownSql,
+		"1528395672_add_audit_log.down.sql",
+	)
+}
+
+func _1528395672_add_audit_logDownSql() (*asset, error) {
+	bytes, err := _1528395672_add_audit_logDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395672_add_audit_log.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x59, 0x81, 0xea, 0x70, 0x10, 0x5c, 0xc8, 0xeb, 0xe5, 0x63, 0x79, 0xcb, 0x1c, 0xc6, 0x7d, 0x2, 0xe7, 0x7b, 0xb6, 0x13, 0x55, 0xd8, 0xba, 0x52, 0x8, 0x94, 0x44, 0x8b, 0x44, 0x56, 0xc, 0xf0}}
+	return a, nil
+}
+
+var __1528395672_add_audit_logUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x84\x53\x4d\x8f\x9b\x30\x10\xbd\xf3\x2b\xe6\x10\x09\x22\x6d\xfa\x03\x9a\xaa\x12\x81\x49\x16\x2d\x81\xc8\x31\x6a\xf6\x84\x9c\x30\x21\xae\x88\x4d\x8d\xb7\x69\xfa\xeb\x2b\x60\xdb\x40\xf7\x4b\xe2\x60\x66\xe6\xbd\x37\x33\xcf\x5e\xe0\x2a\x4a\xe6\x8e\x13\x30\xf4\x39\x02\xf7\x17\x31\x82\x78\x2a\xa4\xcd\x2b\x5d\x82\xe7\x00\x00\xc8\x02\xf6\xb2\x6c\xc8\x48\x51\xc1\x86\x45\x6b\x9f\x3d\xc2\x03\x3e\xde\x75\x59\x71\xb0\xda\xe4\x4f\x0d\x99\x5c\x16\x20\x95\xa5\x92\xcc\x30\xd5\x86\x8c\x12\x15\xec\xb5\xae\x48\x28\x48\x52\x0e\x49\x16\xc7\x10\xe2\xd2\xcf\x62\x0e\x47\x51\x35\x34\x82\xd4\x60\xe9\x97\x7d\x59\xe9\xba\xc3\xb2\xa3\x36\x17\x61\x0a\x2a\xf2\xa3\x36\x1f\x23\xa4\x56\xe3\xa2\x9e\xcb\x0a\x53\x92\xcd\xed\xb5\xa6\x77\xd2\xb2\xf8\x40\x60\x4f\x47\x6d\x08\xbe\x37\x5a\xed\x9f\x25\x8f\x96\xcc\x30\x70\x30\x24\x2c\x15\xb9\xb0\x60\xe5\x99\x1a\x2b\xce\x35\x5c\xa4\x3d\x75\xbf\xf0\x5b\x2b\x7a\xc9\xaf\xf4\xc5\x9b\xf6\xf8\x20\x4d\xb6\x9c\xf9\x51\xc2\x6f\x1e\xe5\xfd\x60\xb9\xd2\x8a\xce\xb5\xbd\x42\x70\x8f\xc1\x03\x78\xcf\xf3\x7e\xf9\x0a\xae\x3b\x75\xa6\x37\x8f\xa3\x24\xc4\xdd\x00\x3f\x68\x2a\x4d\x6e\x71\xef\x16\x9f\xce\xdf\xc2\x8e\xbd\x1f\xc1\x47\xa9\xb7\x19\xfa\xed\x8e\xa1\x03\x43\xee\x6e\xeb\x6f\x47\x98\xcd\x80\x9f\xa8\x57\x81\xf6\x82\xca\x06\x44\x5d\x93\x2a\x66\x5a\x55\xd7\xcf\x40\xca\x1a\x49\x0d\x1c\x84\x72\x2d\xec\x09\x0e\x27\xa1\x4a\x2a\x40\x1b\x30\x74\xd6\x3f\xa9\xf8\xf4\xb7\x95\x65\x96\x04\x3c\x1a\x2a\xe7\x3d\x57\xde\x72\x79\x53\x60\xc8\x33\x96\x6c\xc1\x1a\x59\x96\x64\x3a\x0b\x62\x3f\x59\x65\xfe\x0a\xa1\xae\xea\xb2\xf9\x51\x75\x41\x7f\x0b\x93\x49\x77\xea\xde\x53\x77\x6a\x3f\xe6\x47\x5b\x04\xdc\x05\xb8\xe9\x84\xdc\x7f\x4a\xff\x35\xee\xce\x3b\x0c\x26\xe1\xdc\x99\x4c\x06\xef\x91\x45\xab\x15\xb2\xd7\x3b\x84\x05\x2e\x53\x86\x90\x6d\xc2\xb6\x36\x65\x10\x62\x8c\x1c\x47\xcb\x84\x65\xca\x00\xfd\xe0\x1e\x58\xfa\x0d\x70\x87\x41\xc6\x11\x36\x2c\x0d\x30\xcc\x18\xbe\xce\xec\xb5\xbb\x0e\xd2\xf5\x3a\xe2\x73\xe7\xcf\x00\xe3\xd6\xd9\x14\x23\x04\x00\x00")
+
+func _1528395672_add_audit_logUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395672_add_audit_logUpSql,
+		"1528395672_add_audit_log.up.sql",
+	)
+}
+
+func _1528395672_add_audit_logUpSql() (*asset, error) {
+	bytes, err := _1528395672_add_audit_logUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395672_add_audit_log.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xfe, 0x72, 0xcc, 0x70, 0x37, 0x30, 0xa7, 0x59, 0xf9, 0xf1, 0x1a, 0xcd, 0xb6, 0xa9, 0x6f, 0x28, 0xaf, 0xbc, 0xe9, 0xc2, 0xb0, 0xe7, 0x1e, 0x56, 0xf5, 0xef, 0xb8, 0xba, 0x69, 0x1d, 0xe1, 0xf4}}
+	return a, nil
+}
+
+var __1528395673_add_discussion_thread_anchorsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\xcc\x41\xaa\xc3\x20\x10\x00\xd0\xbd\xa7\x98\x7b\xb8\x4a\xf2\xfd\x45\x48\x62\x49\x2c\x74\x27\x43\x1c\xaa\x50\x34\x8c\x93\x9e\xbf\xf4\x0e\x1e\xe0\xbd\xd1\xdc\xec\xaa\x95\x1a\x66\x6f\x36\xf0\xc3\x38\x1b\x88\xb9\x1d\x57\x6b\xb9\x96\x20\x89\x09\x63\x0b\x82\xfc\x22\x09\x4c\x67\x85\xbf\xcd\xdd\x61\x72\xf3\x63\x59\xc1\xfe\x83\x79\xda\xdd\xef\x80\xe5\x48\x95\x03\xd3\x27\xff\xa4\xee\x36\x9e\x28\xa9\xdf\xd6\x04\x59\xc2\x3b\x17\xea\x77\x52\x89\xbd\xc6\x7a\x49\x44\xa1\xa8\x95\x9a\xdc\xb2\x58\xaf\xd5\x77\x00\xc2\xec\x47\xfe\xa2\x01\x00\x00")
+
+func _1528395673_add_discussion_thread_anchorsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395673_add_discussion_thread_anchorsDownSql,
+		"1528395673_add_discussion_thread_anchors.down.sql",
+	)
+}
+
+func _1528395673_add_discussion_thread_anchorsDownSql() (*asset, error) {
+	bytes, err := _1528395673_add_discussion_thread_anchorsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395673_add_discussion_thread_anchors.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xb8, 0x9d, 0xa1, 0x1d, 0xb3, 0x51, 0x9a, 0xea, 0xec, 0x8b, 0x37, 0xc1, 0x1d, 0x95, 0xc5, 0x1, 0x1, 0x69, 0xb7, 0xa8, 0x20, 0x41, 0x9e, 0x85, 0x54, 0x8a, 0xc4, 0x5e, 0x3c, 0x21, 0x38, 0x20}}
+	return a, nil
+}
+
+var __1528395673_add_discussion_thread_anchorsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\xcf\x51\x6e\xc2\x30\x0c\x06\xe0\xf7\x9e\xe2\x3f\xc0\xd8\x05\xfa\x54\xa0\x9b\x90\x42\x91\xa6\xf0\x5c\x99\xc4\x90\x68\x25\x46\xb1\xd9\x76\xfc\xa9\xc0\x0d\xe0\x35\xf9\xff\xcf\xf6\xb2\xff\xdc\x0c\x6d\xd3\x2c\x16\xf0\x89\x41\x25\x24\xa9\x90\x23\x08\x96\x2a\x53\x44\x56\xfc\x26\xae\x8c\x6c\x0a\xe5\x89\x83\x65\x29\xf3\x33\x19\x2c\x31\xce\xa2\x86\xca\x81\x8b\x21\xc8\xf9\x9c\x6d\xd6\xe4\x78\xfb\x3c\xd4\x99\x44\x36\x18\xd5\x13\x9b\xbe\x81\x14\x56\x29\x7c\x73\x9c\x47\xc8\xf5\x94\x6e\xc9\x7b\x55\x21\x05\x96\xc8\x1e\xcd\xf7\xa6\x73\xbe\xff\x82\xef\x96\xae\x47\xcc\x1a\xae\xaa\x59\xca\x78\xdf\x4e\xc7\x3b\x3b\x56\xbe\x08\xba\xf5\x1a\xab\x9d\xdb\x6f\x87\xc7\x21\x63\xe5\x9f\x3c\xc7\x61\xfc\x67\xed\x73\xd6\x85\x2c\xbd\xc2\x51\xa3\x6a\xe3\x94\x0b\x23\x17\xe3\x13\xd7\x27\x41\x2e\xf1\x25\x9c\x5c\x2d\x92\x71\xc4\x41\x64\x62\x2a\x18\x76\x1e\xc3\xde\x39\xac\xfb\x8f\x6e\xef\x3c\x8e\x34\x29\xb7\x4d\xb3\xda\x6d\xb7\x1b\xdf\x36\xff\x03\x00\x61\xa9\x1a\xa3\x3e\x02\x00\x00")
+
+func _1528395673_add_discussion_thread_anchorsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395673_add_discussion_thread_anchorsUpSql,
+		"1528395673_add_discussion_thread_anchors.up.sql",
+	)
+}
+
+func _1528395673_add_discussion_thread_anchorsUpSql() (*asset, error) {
+	bytes, err := _1528395673_add_discussion_thread_anchorsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395673_add_discussion_thread_anchors.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x4d, 0xcf, 0x98, 0xf7, 0xac, 0x4d, 0x5, 0xca, 0xc5, 0x52, 0x9d, 0xb9, 0xd7, 0x75, 0x15, 0x11, 0x91, 0xc1, 0x5c, 0xeb, 0x10, 0xcb, 0x28, 0xa0, 0x28, 0xf0, 0x24, 0x5d, 0x2, 0x43, 0x54, 0x59}}
+	return a, nil
+}
+
+var __1528395674_add_repo_languagesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x09\xf2\x0f\x50\x08\x71\x74\xf2\x71\x55\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x28\x4a\x2d\xc8\x8f\xcf\x49\xcc\x4b\x2f\x4d\x4c\x4f\x8d\xcf\xc8\x2c\x2e\xc9\x2f\xaa\xb4\x26\x42\x6d\x31\x3e\x45\x99\x79\x65\xa9\x79\x25\xf9\x45\x99\xa9\xc5\xd6\x5c\x5c\xce\xfe\xbe\xbe\x9e\x21\xd6\x5c\x80\x01\x00\xe5\x3a\x47\xb7\x89\x00\x00\x00")
+
+func _1528395674_add_repo_languagesDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395674_add_repo_languagesDownSql,
+		"1528395674_add_repo_languages.down.sql",
+	)
+}
+
+func _1528395674_add_repo_languagesDownSql() (*asset, error) {
+	bytes, err := _1528395674_add_repo_languagesDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395674_add_repo_languages.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xf, 0x63, 0xc6, 0xd9, 0x6, 0x1, 0x67, 0x88, 0x22, 0xe8, 0xb6, 0xc4, 0xfd, 0x90, 0x48, 0x2b, 0x9d, 0xb7, 0xd3, 0x29, 0xc1, 0xeb, 0xc2, 0x81, 0x4d, 0xb1, 0x2f, 0xb5, 0x7, 0x60, 0xd9, 0xac}}
+	return a, nil
+}
+
+var __1528395674_add_repo_languagesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xbc\x92\x41\x6f\xe2\x3e\x10\xc5\xef\xf9\x14\xef\x18\x24\x5a\xfd\xef\x9c\x52\x70\xff\x42\x0b\x61\x45\x53\x69\x7b\x8a\x4c\x3c\xc4\x96\x12\x1b\xc5\x43\x59\xf6\xd3\xaf\x9c\x36\x21\xa0\xd0\xd5\x5e\xf6\x96\x78\x9e\x9f\xe7\xcd\xfc\x9e\xc4\xff\xcb\x74\x16\x45\x0f\x0f\xc8\x34\xa1\x92\xb6\x3c\xca\x92\x60\xec\x3b\x59\x76\xcd\x19\x6e\x0f\xd6\x04\x45\x7b\x79\xac\x18\xbb\x46\xda\x42\x87\x53\x92\x85\x46\x43\x07\xe7\x4d\x10\x4e\x21\x3d\xdc\x3e\x38\x15\xae\xae\x0d\xe7\x46\x3d\x46\xf3\xad\x48\x32\x81\x2c\x79\x5a\x89\x56\x9c\x77\xce\x86\x3c\xe2\x08\xc0\xe7\xb1\x82\xb1\x4c\x25\x35\xf8\xbe\x5d\xae\x93\xed\x1b\xbe\x89\x37\x6c\xc5\xb3\xd8\x8a\x74\x2e\x5e\x5a\x59\x6c\xd4\x04\x9b\x14\x0b\xb1\x12\x99\xc0\x3c\x79\x99\x27\x0b\x31\x6d\x6d\xfa\x57\xc1\xf4\x93\x91\x6e\x32\xa4\xaf\xab\xd5\x47\xf1\x78\x50\x92\x49\xe5\x92\xc1\xa6\x26\xcf\xb2\x3e\xe0\x64\x58\xb7\xbf\xf8\xe5\x2c\xf5\x37\xb0\x10\xcf\xc9\xeb\x2a\x83\x75\xa7\x78\x12\x4d\x66\xd1\x48\x8c\x6e\x52\xf7\x42\xf4\x66\x37\x09\x86\xf9\xe3\xcf\x4b\x77\x23\xf5\xeb\x18\x49\xc4\x8e\x65\x95\x57\xc6\x92\xc7\xce\x94\xc6\x8e\x0b\x76\x67\xbe\x27\x18\xce\xb9\x6b\x65\xda\x23\x70\x15\x7c\x99\x2e\xc4\x8f\x9b\xe0\xfd\x57\xe8\xfe\xba\x14\x57\xee\x44\x4d\xdc\xfd\x4f\x26\x17\xc2\x3c\x4b\x36\x9e\x4d\xe1\x7b\x86\x3a\xd9\x08\x54\x08\xfb\xd2\x04\xb2\xaa\xaf\x2a\x79\x0e\x90\xb1\xa6\x33\x0a\x2d\x6d\x49\xea\x11\xc9\xc5\x85\xb5\x64\x9c\xa4\x47\x43\xb5\x7b\x27\x85\x7d\xe3\x6a\xc8\xa1\xab\x09\xc5\xc2\x35\x8a\x54\x0b\x41\xf0\xfb\x0f\x1f\xb3\x94\x56\xa1\x1d\xda\x18\xbc\xdd\x23\xb9\x36\x3e\x40\xff\x97\xcb\xff\x0a\xdf\xaf\x76\xdd\x35\x9b\x3b\x8b\xc0\xf1\x3f\x46\x61\x3a\x6c\xe0\x4f\x5c\x74\xa3\xb9\x1c\x0c\xbb\xdf\xa4\xe3\xea\x5b\x62\xae\x9f\x9c\x45\xd1\x7c\xb3\x5e\x2f\xb3\x59\xf4\x7b\x00\x7c\xa5\xea\x80\xb1\x04\x00\x00")
+
+func _1528395674_add_repo_languagesUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395674_add_repo_languagesUpSql,
+		"1528395674_add_repo_languages.up.sql",
+	)
+}
+
+func _1528395674_add_repo_languagesUpSql() (*asset, error) {
+	bytes, err := _1528395674_add_repo_languagesUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395674_add_repo_languages.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xf1, 0xd1, 0x3c, 0x88, 0x22, 0x1e, 0x7, 0x2b, 0xd5, 0xe5, 0x8e, 0xfe, 0x8, 0xbc, 0x91, 0x39, 0xf4, 0x91, 0xfa, 0xd4, 0x22, 0xc8, 0xb1, 0xdd, 0xf5, 0xa, 0xdc, 0xb1, 0xc6, 0x34, 0x48, 0xbd}}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -2491,17 +2701,29 @@ var _bindata = map[string]func() (*asset, error){
 	"1528395668_campaign_description_nullable.up.sql":                         _1528395668_campaign_description_nullableUpSql,
 	"1528395669_add_synced_at_to_perms_tables.down.sql":                       _1528395669_add_synced_at_to_perms_tablesDownSql,
 	"1528395669_add_synced_at_to_perms_tables.up.sql":                         _1528395669_add_synced_at_to_perms_tablesUpSql,
+	"1528395670_add_saved_search_runs.down.sql":                               _1528395670_add_saved_search_runsDownSql,
+	"1528395670_add_saved_search_runs.up.sql":                                 _1528395670_add_saved_search_runsUpSql,
+	"1528395671_add_repo_groups.down.sql":                                     _1528395671_add_repo_groupsDownSql,
+	"1528395671_add_repo_groups.up.sql":                                       _1528395671_add_repo_groupsUpSql,
+	"1528395672_add_audit_log.down.sql":                                       _1528395672_add_audit_logDownSql,
+	"1528395672_add_audit_log.up.sql":                                         _1528395672_add_audit_logUpSql,
+	"1528395673_add_discussion_thread_anchors.down.sql":                       _1528395673_add_discussion_thread_anchorsDownSql,
+	"1528395673_add_discussion_thread_anchors.up.sql":                         _1528395673_add_discussion_thread_anchorsUpSql,
+	"1528395674_add_repo_languages.down.sql":                                  _1528395674_add_repo_languagesDownSql,
+	"1528395674_add_repo_languages.up.sql":                                    _1528395674_add_repo_languagesUpSql,
 }
 
 // AssetDir returns the file names below a certain
 // directory embedded in the file by go-bindata.
 // For example if you run go-bindata on data/... and data contains the
 // following hierarchy:
-//     data/
-//       foo.txt
-//       img/
-//         a.png
-//         b.png
+//
+//	data/
+//	  foo.txt
+//	  img/
+//	    a.png
+//	    b.png
+//
 // then AssetDir("data") would return []string{"foo.txt", "img"},
 // AssetDir("data/img") would return []string{"a.png", "b.png"},
 // AssetDir("foo.txt") and AssetDir("notexist") would return an error, and
@@ -2640,6 +2862,16 @@ var _bintree = &bintree{nil, map[string]*bintree{
 	"1528395668_campaign_description_nullable.up.sql":                         {_1528395668_campaign_description_nullableUpSql, map[string]*bintree{}},
 	"1528395669_add_synced_at_to_perms_tables.down.sql":                       {_1528395669_add_synced_at_to_perms_tablesDownSql, map[string]*bintree{}},
 	"1528395669_add_synced_at_to_perms_tables.up.sql":                         {_1528395669_add_synced_at_to_perms_tablesUpSql, map[string]*bintree{}},
+	"1528395670_add_saved_search_runs.down.sql":                               {_1528395670_add_saved_search_runsDownSql, map[string]*bintree{}},
+	"1528395670_add_saved_search_runs.up.sql":                                 {_1528395670_add_saved_search_runsUpSql, map[string]*bintree{}},
+	"1528395671_add_repo_groups.down.sql":                                     {_1528395671_add_repo_groupsDownSql, map[string]*bintree{}},
+	"1528395671_add_repo_groups.up.sql":                                       {_1528395671_add_repo_groupsUpSql, map[string]*bintree{}},
+	"1528395672_add_audit_log.down.sql":                                       {_1528395672_add_audit_logDownSql, map[string]*bintree{}},
+	"1528395672_add_audit_log.up.sql":                                         {_1528395672_add_audit_logUpSql, map[string]*bintree{}},
+	"1528395673_add_discussion_thread_anchors.down.sql":                       {_1528395673_add_discussion_thread_anchorsDownSql, map[string]*bintree{}},
+	"1528395673_add_discussion_thread_anchors.up.sql":                         {_1528395673_add_discussion_thread_anchorsUpSql, map[string]*bintree{}},
+	"1528395674_add_repo_languages.down.sql":                                  {_1528395674_add_repo_languagesDownSql, map[string]*bintree{}},
+	"1528395674_add_repo_languages.up.sql":                                    {_1528395674_add_repo_languagesUpSql, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory.
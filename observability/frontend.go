@@ -194,5 +194,23 @@ func Frontend() *Container {
 				},
 			},
 		},
+		SLOs: []SLO{
+			{
+				Name:            "search_availability",
+				Description:     "search requests without hard errors or hard timeouts",
+				Objective:       99,
+				ErrorQuery:      `sum(rate(src_graphql_search_response{status=~"error|timeout",source="browser"}[$window])) OR on() vector(0)`,
+				TotalQuery:      `sum(rate(src_graphql_search_response{source="browser"}[$window]))`,
+				DataMayNotExist: true,
+			},
+			{
+				Name:            "search_latency",
+				Description:     "successful search requests served in under 5s",
+				Objective:       90,
+				GoodQuery:       `sum(rate(src_graphql_field_seconds_bucket{type="Search",field="results",error="false",source="browser",le="5"}[$window]))`,
+				TotalQuery:      `sum(rate(src_graphql_field_seconds_count{type="Search",field="results",error="false",source="browser"}[$window]))`,
+				DataMayNotExist: true,
+			},
+		},
 	}
 }
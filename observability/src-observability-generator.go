@@ -15,8 +15,10 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/grafana-tools/sdk"
@@ -37,6 +39,10 @@ type Container struct {
 
 	// Groups of observable information about the container.
 	Groups []Group
+
+	// SLOs are the service level objectives of the container. Each one generates recording
+	// rules, burn rate alerts and an error budget panel.
+	SLOs []SLO
 }
 
 func (c *Container) validate() error {
@@ -49,10 +55,25 @@ func (c *Container) validate() error {
 	if c.Description != withPeriod(c.Description) || c.Description != upperFirst(c.Description) {
 		return fmt.Errorf("Container.Description must be sentence starting with an uppercas eletter and ending with period; found \"%s\"", c.Description)
 	}
+	names := map[string]bool{}
 	for _, g := range c.Groups {
 		if err := g.validate(); err != nil {
 			return err
 		}
+		for _, r := range g.Rows {
+			for _, o := range r {
+				names[o.Name] = true
+			}
+		}
+	}
+	for _, s := range c.SLOs {
+		if err := s.validate(); err != nil {
+			return err
+		}
+		if names[s.Name] {
+			return fmt.Errorf("SLO.Name must be unique relative to the service name, including observables; found duplicate \"%s\"", s.Name)
+		}
+		names[s.Name] = true
 	}
 	return nil
 }
@@ -184,6 +205,160 @@ func (o Observable) validate() error {
 	return nil
 }
 
+// SLO describes a service level objective of a container: the percentage of events (e.g.
+// requests) that must be good over a rolling period. The error budget is the percentage of events
+// that may be bad over that period, e.g. 1% for an objective of 99%.
+type SLO struct {
+	// Name is a short and human-readable lower_snake_case name of the objective.
+	//
+	// It must be unique relative to the service name, including the names of its observables.
+	//
+	// Good examples:
+	//
+	// 	search_availability
+	// 	search_latency
+	//
+	Name string
+
+	// Description is a human-readable description of the good events.
+	//
+	// Good examples:
+	//
+	// 	"successful search requests"
+	// 	"successful search requests served in under 5s"
+	//
+	Description string
+
+	// Objective is the percentage of events that must be good, e.g. 99.5. It must be greater
+	// than 0 and less than 100.
+	Objective float64
+
+	// Period is the rolling window that the objective applies to, and over which the error
+	// budget is spent. It must be a whole number of days from 28d to 90d. The default is 30d.
+	//
+	// Prometheus must retain data for at least this long for the error budget panel to be
+	// accurate.
+	Period time.Duration
+
+	// ErrorQuery, GoodQuery and TotalQuery are Prometheus queries of the per-second rate of bad,
+	// good and all events respectively. They must use "$window" as their range, which is
+	// replaced by each of the windows that the rates are recorded over, e.g.:
+	//
+	// 	sum(rate(src_graphql_search_response{status=~"error|timeout"}[$window]))
+	//
+	// Exactly one of ErrorQuery and GoodQuery must be set. GoodQuery is most useful for
+	// latency objectives, which count the events in a histogram bucket as good.
+	ErrorQuery, GoodQuery, TotalQuery string
+
+	// DataMayNotExist indicates if the queries may not return data until some event occurs in
+	// the future. See Observable.DataMayNotExist.
+	DataMayNotExist bool
+}
+
+// minSLOPeriod and maxSLOPeriod are the bounds of SLO.Period. The burn rate alerts are tuned
+// for periods of about 30d.
+const (
+	minSLOPeriod = 28 * 24 * time.Hour
+	maxSLOPeriod = 90 * 24 * time.Hour
+)
+
+func (s SLO) validate() error {
+	if s.Name == "" || strings.Contains(s.Name, " ") || strings.ToLower(s.Name) != s.Name {
+		return fmt.Errorf("SLO.Name must be in lower_snake_case; found \"%s\"", s.Name)
+	}
+	if s.Description == "" {
+		return fmt.Errorf("%s: SLO.Description must be set", s.Name)
+	}
+	if v := string([]rune(s.Description)[0]); v != strings.ToLower(v) {
+		return fmt.Errorf("%s: SLO.Description must start with a lowercase letter; found \"%s\"", s.Name, s.Description)
+	}
+	if s.Objective <= 0 || s.Objective >= 100 {
+		return fmt.Errorf("%s: SLO.Objective must be a percentage greater than 0 and less than 100; found %v", s.Name, s.Objective)
+	}
+	if period := s.period(); period%(24*time.Hour) != 0 || period < minSLOPeriod || period > maxSLOPeriod {
+		return fmt.Errorf("%s: SLO.Period must be a whole number of days from %s to %s; found %s", s.Name, promDuration(minSLOPeriod), promDuration(maxSLOPeriod), period)
+	}
+	if (s.ErrorQuery == "") == (s.GoodQuery == "") {
+		return fmt.Errorf("%s: exactly one of SLO.ErrorQuery and SLO.GoodQuery must be set", s.Name)
+	}
+	for _, q := range []struct{ field, query string }{
+		{"ErrorQuery", s.ErrorQuery},
+		{"GoodQuery", s.GoodQuery},
+		{"TotalQuery", s.TotalQuery},
+	} {
+		if q.query == "" && q.field != "TotalQuery" {
+			continue
+		}
+		if !strings.Contains(q.query, "[$window]") {
+			return fmt.Errorf("%s: SLO.%s must use [$window] as its range; found \"%s\"", s.Name, q.field, q.query)
+		}
+	}
+	return nil
+}
+
+func (s SLO) period() time.Duration {
+	if s.Period == 0 {
+		return 30 * 24 * time.Hour
+	}
+	return s.Period
+}
+
+// errorRatioRecord returns the name of the recording rule of the ratio of bad events over
+// the window, e.g. "slo:sli_error:ratio_rate5m".
+func errorRatioRecord(window time.Duration) string {
+	return "slo:sli_error:ratio_rate" + promDuration(window)
+}
+
+// errorRatioQuery returns the query of the ratio of bad events over the window.
+func (s SLO) errorRatioQuery(window time.Duration) string {
+	total := strings.Replace(s.TotalQuery, "$window", promDuration(window), -1)
+	if s.ErrorQuery != "" {
+		return fmt.Sprintf("(%s) / (%s)", strings.Replace(s.ErrorQuery, "$window", promDuration(window), -1), total)
+	}
+	return fmt.Sprintf("1 - ((%s) / (%s))", strings.Replace(s.GoodQuery, "$window", promDuration(window), -1), total)
+}
+
+// errorBudget returns the PromQL expression of the error budget as a ratio, e.g. "(1 - 99.5 / 100)".
+func (s SLO) errorBudget() string {
+	return fmt.Sprintf("(1 - %v / 100)", s.Objective)
+}
+
+// burnRateAlerts are the multi-window, multi-burn-rate alerts of each SLO, as recommended by
+// https://landing.google.com/sre/workbook/chapters/alerting-on-slos/.
+//
+// Each one fires when the error budget is spent fast enough, over both the long and the short
+// window, to use up budgetPercent of the error budget of the whole period within the long
+// window. The short window makes the alert stop firing soon after the problem is fixed.
+var burnRateAlerts = []struct {
+	level         string
+	long, short   time.Duration
+	budgetPercent int
+}{
+	{level: "critical", long: time.Hour, short: 5 * time.Minute, budgetPercent: 2},
+	{level: "critical", long: 6 * time.Hour, short: 30 * time.Minute, budgetPercent: 5},
+	{level: "warning", long: 24 * time.Hour, short: 2 * time.Hour, budgetPercent: 10},
+	{level: "warning", long: 72 * time.Hour, short: 6 * time.Hour, budgetPercent: 10},
+}
+
+// burnRate returns how many times faster than sustainable for the whole period the error
+// budget must be spent to use up budgetPercent of it within the long window, e.g. 14.4 for 2%
+// within 1h of a 30d period.
+func burnRate(period, long time.Duration, budgetPercent int) float64 {
+	return float64(budgetPercent*int(period/time.Hour)) / float64(100*int(long/time.Hour))
+}
+
+// promDuration formats d as a Prometheus duration, e.g. "5m", "6h" or "30d".
+func promDuration(d time.Duration) string {
+	switch {
+	case d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	default:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+}
+
 // Alert defines when an alert would be considered firing.
 type Alert struct {
 	// GreaterOrEqual, when non-zero, indicates the alert should fire when
@@ -404,6 +579,32 @@ func (c *Container) dashboard() *sdk.Board {
 
 	baseY := 8
 	offsetY := baseY
+	if len(c.SLOs) > 0 {
+		rowPanel := &sdk.Panel{RowPanel: &sdk.RowPanel{}}
+		rowPanel.OfType = sdk.RowType
+		rowPanel.Type = "row"
+		rowPanel.Title = "Service level objectives"
+		offsetY++
+		setPanelPos(rowPanel, 0, offsetY)
+		rowPanel.Panels = []sdk.Panel{} // cannot be null
+		board.Panels = append(board.Panels, rowPanel)
+
+		// Show up to 4 error budget panels per row.
+		for start := 0; start < len(c.SLOs); start += 4 {
+			row := c.SLOs[start:]
+			if len(row) > 4 {
+				row = row[:4]
+			}
+			panelWidth := 24 / len(row)
+			offsetY++
+			for i, s := range row {
+				panel := s.errorBudgetPanel(c.Name)
+				setPanelSize(panel, panelWidth, 5)
+				setPanelPos(panel, i*panelWidth, offsetY)
+				board.Panels = append(board.Panels, panel)
+			}
+		}
+	}
 	for _, group := range c.Groups {
 		// Non-general groups are shown as collapsible panels.
 		var rowPanel *sdk.Panel
@@ -521,6 +722,51 @@ func (c *Container) dashboard() *sdk.Board {
 	return board
 }
 
+// errorBudgetPanel returns the panel showing the percentage of the error budget of the SLO
+// that remains over its period. It is negative when the objective is not met.
+func (s SLO) errorBudgetPanel(serviceName string) *sdk.Panel {
+	panel := sdk.NewGraph(fmt.Sprintf("Error budget remaining for %v%% %s over %s", s.Objective, s.Description, promDuration(s.period())))
+	panel.GraphPanel.Legend.Show = true
+	panel.GraphPanel.Fill = 1
+	panel.GraphPanel.Lines = true
+	panel.GraphPanel.Linewidth = 1
+	panel.GraphPanel.NullPointMode = "connected"
+	panel.GraphPanel.Pointradius = 2
+	panel.GraphPanel.AliasColors = map[string]string{}
+	panel.GraphPanel.Xaxis = sdk.Axis{
+		Show: true,
+	}
+	panel.GraphPanel.Thresholds = []sdk.Threshold{
+		{
+			Value:     0,
+			Op:        "lt",
+			ColorMode: "custom",
+			Fill:      true,
+			Line:      false,
+			FillColor: "rgba(255, 17, 36, 0.8)",
+		},
+	}
+	panel.GraphPanel.Yaxes = []sdk.Axis{
+		{
+			Decimals: 0,
+			Format:   string(Percentage),
+			LogBase:  1,
+			Max:      sdk.NewFloatString(100),
+			Show:     true,
+		},
+		{
+			Format:  "short",
+			LogBase: 1,
+			Show:    true,
+		},
+	}
+	panel.AddTarget(&sdk.Target{
+		Expr:         fmt.Sprintf("100 * (1 - %s%s / %s)", errorRatioRecord(s.period()), s.selector(serviceName), s.errorBudget()),
+		LegendFormat: "remaining",
+	})
+	return panel
+}
+
 // promAlertsFile generates the Prometheus rules file which defines our
 // high-level alerting metrics for the container. For more information about
 // how these work, see:
@@ -530,6 +776,12 @@ func (c *Container) dashboard() *sdk.Board {
 func (c *Container) promAlertsFile() *promRulesFile {
 	f := &promRulesFile{}
 	group := promGroup{Name: c.Name}
+
+	// Rules in a group are evaluated in order, so the ratios recorded for SLOs are up to date
+	// when their burn rate alerts are evaluated.
+	for _, s := range c.SLOs {
+		group.Rules = append(group.Rules, s.recordingRules(c.Name)...)
+	}
 	for _, g := range c.Groups {
 		for _, r := range g.Rows {
 			for _, o := range r {
@@ -546,7 +798,7 @@ func (c *Container) promAlertsFile() *promRulesFile {
 					labels["name"] = o.Name
 
 					// The alertQuery must contribute a query that returns a value < 1 when it is not
-					// firing, or a value of >= 1 when it is firing.
+					// firing, or a value of >= 1 when it is firing (see alertCountExpr).
 					var alertQuery string
 					if alert.GreaterOrEqual != 0 {
 						// e.g. "zoekt-indexserver: 20+ indexed search request errors every 5m by code"
@@ -597,31 +849,116 @@ func (c *Container) promAlertsFile() *promRulesFile {
 						alertQuery = fmt.Sprintf("((%s) >= 0) OR on() vector(%v)", alertQuery, fireOnNan)
 					}
 
-					// This wrapper clamp/floor/default vector should be present on ALL alert_count rule
-					// definitions because:
-					//
-					// 1. Clamping and flooring ensures that a single alert definition can only ever
-					//    contribute a single 0 OR 1 value, and as such cannot artificially inflate
-					//    alert_count or cause it to become a non-whole number.
-					//
-					// 3. "OR on() vector(1)" ensures that the alert is always firing if the inner
-					//    alertQuery does not return values for any reason (e.g. the query is for a
-					//    metric that does not exist.)
-					//
-					expr := "clamp_max(clamp_min(floor(\n" + alertQuery + "\n), 0), 1) OR on() vector(1)"
 					group.Rules = append(group.Rules, promRule{
 						Record: "alert_count",
 						Labels: labels,
-						Expr:   expr,
+						Expr:   alertCountExpr(alertQuery),
 					})
 				}
 			}
 		}
 	}
+	for _, s := range c.SLOs {
+		group.Rules = append(group.Rules, s.burnRateAlertRules(c.Name)...)
+	}
 	f.Groups = append(f.Groups, group)
 	return f
 }
 
+// alertCountExpr returns the expression of an alert_count rule for the alertQuery, which must
+// return a value < 1 when it is not firing, or a value of >= 1 when it is firing.
+//
+// This wrapper clamp/floor/default vector should be present on ALL alert_count rule
+// definitions because:
+//
+//  1. Clamping and flooring ensures that a single alert definition can only ever
+//     contribute a single 0 OR 1 value, and as such cannot artificially inflate
+//     alert_count or cause it to become a non-whole number.
+//
+//  2. "OR on() vector(1)" ensures that the alert is always firing if the inner
+//     alertQuery does not return values for any reason (e.g. the query is for a
+//     metric that does not exist.)
+func alertCountExpr(alertQuery string) string {
+	return "clamp_max(clamp_min(floor(\n" + alertQuery + "\n), 0), 1) OR on() vector(1)"
+}
+
+// selector returns the label selector of the recording rules of the SLO.
+func (s SLO) selector(serviceName string) string {
+	return fmt.Sprintf(`{service_name="%s",slo="%s"}`, serviceName, s.Name)
+}
+
+// recordingRules returns the rules which record the ratio of bad events of the SLO over each
+// window of its burn rate alerts and over its whole period.
+func (s SLO) recordingRules(serviceName string) []promRule {
+	var windows []time.Duration
+	seen := map[time.Duration]bool{}
+	for _, a := range burnRateAlerts {
+		for _, w := range []time.Duration{a.short, a.long} {
+			if !seen[w] {
+				seen[w] = true
+				windows = append(windows, w)
+			}
+		}
+	}
+	sort.Slice(windows, func(i, j int) bool { return windows[i] < windows[j] })
+	windows = append(windows, s.period())
+
+	rules := make([]promRule, 0, len(windows))
+	for _, w := range windows {
+		rules = append(rules, promRule{
+			Record: errorRatioRecord(w),
+			Labels: map[string]string{"service_name": serviceName, "slo": s.Name},
+			Expr:   s.errorRatioQuery(w),
+		})
+	}
+	return rules
+}
+
+// burnRateAlertRules returns the alert_count rules of the burn rate alerts of the SLO, which
+// use the ratios recorded by recordingRules.
+func (s SLO) burnRateAlertRules(serviceName string) []promRule {
+	var rules []promRule
+	for _, level := range []string{"warning", "critical"} {
+		// An alert of a level fires when the error budget is burning too fast over both
+		// windows of any of its pairs. Comparing with bool yields 1 when a window is burning
+		// too fast and 0 otherwise, including when there were no events (NaN).
+		var pairs []string
+		for _, a := range burnRateAlerts {
+			if a.level != level {
+				continue
+			}
+			threshold := fmt.Sprintf("(%v * %s)", burnRate(s.period(), a.long, a.budgetPercent), s.errorBudget())
+			pairs = append(pairs, fmt.Sprintf("(%s%s > bool %s) * (%s%s > bool %s)",
+				errorRatioRecord(a.long), s.selector(serviceName), threshold,
+				errorRatioRecord(a.short), s.selector(serviceName), threshold,
+			))
+		}
+		alertQuery := strings.Join(pairs, " + ")
+
+		// Replace no-data with zero values, so the alert does not fire, if desired.
+		if s.DataMayNotExist {
+			alertQuery = fmt.Sprintf("(%s) OR on() vector(0)", alertQuery)
+		}
+
+		speed := "slow"
+		if level == "critical" {
+			speed = "fast"
+		}
+		rules = append(rules, promRule{
+			Record: "alert_count",
+			Labels: map[string]string{
+				"service_name": serviceName,
+				"level":        level,
+				"name":         s.Name,
+				// e.g. "frontend: fast error budget burn for 99% successful search requests over 30d"
+				"description": fmt.Sprintf("%s: %s error budget burn for %v%% %s over %s", serviceName, speed, s.Objective, s.Description, promDuration(s.period())),
+			},
+			Expr: alertCountExpr(alertQuery),
+		})
+	}
+	return rules
+}
+
 // isValidUID checks if the given string is a valid UID for entry into a Grafana dashboard. This is
 // primarily used in the URL, e.g. /-/debug/grafana/d/syntect-server/<UID> and allows us to have
 // static URLs we can document like:
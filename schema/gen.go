@@ -1,12 +1,14 @@
 package schema
 
 //go:generate env GOBIN=$PWD/.bin GO111MODULE=on go install github.com/sourcegraph/go-jsonschema/cmd/go-jsonschema-compiler
-//go:generate $PWD/.bin/go-jsonschema-compiler -o schema.go -pkg schema aws_codecommit.schema.json bitbucket_cloud.schema.json bitbucket_server.schema.json site.schema.json settings.schema.json github.schema.json gitlab.schema.json gitolite.schema.json other_external_service.schema.json phabricator.schema.json
+//go:generate $PWD/.bin/go-jsonschema-compiler -o schema.go -pkg schema aws_codecommit.schema.json bitbucket_cloud.schema.json bitbucket_server.schema.json gerrit.schema.json gitea.schema.json site.schema.json settings.schema.json github.schema.json gitlab.schema.json gitolite.schema.json other_external_service.schema.json phabricator.schema.json
 //go:generate $PWD/.bin/go-jsonschema-compiler -o critical/schema.go -pkg critical critical/critical.schema.json
 
 //go:generate env GO111MODULE=on go run stringdata.go -i aws_codecommit.schema.json -name AWSCodeCommitSchemaJSON -pkg schema -o aws_codecommit_stringdata.go
 //go:generate env GO111MODULE=on go run stringdata.go -i bitbucket_cloud.schema.json -name BitbucketCloudSchemaJSON -pkg schema -o bitbucket_cloud_stringdata.go
 //go:generate env GO111MODULE=on go run stringdata.go -i bitbucket_server.schema.json -name BitbucketServerSchemaJSON -pkg schema -o bitbucket_server_stringdata.go
+//go:generate env GO111MODULE=on go run stringdata.go -i gerrit.schema.json -name GerritSchemaJSON -pkg schema -o gerrit_stringdata.go
+//go:generate env GO111MODULE=on go run stringdata.go -i gitea.schema.json -name GiteaSchemaJSON -pkg schema -o gitea_stringdata.go
 //go:generate env GO111MODULE=on go run stringdata.go -i critical/critical.schema.json -name CriticalSchemaJSON -pkg critical -o critical/critical_stringdata.go
 //go:generate env GO111MODULE=on go run stringdata.go -i site.schema.json -name SiteSchemaJSON -pkg schema -o site_stringdata.go
 //go:generate env GO111MODULE=on go run stringdata.go -i settings.schema.json -name SettingsSchemaJSON -pkg schema -o settings_stringdata.go
@@ -52,6 +52,23 @@ const GitLabSchemaJSON = `{
         "requestsPerHour": 36000
       }
     },
+    "webhooks": {
+      "description": "An array of configurations defining existing GitLab webhooks that send updates back to Sourcegraph.",
+      "type": "array",
+      "items": {
+        "type": "object",
+        "title": "GitLabWebhook",
+        "required": ["secret"],
+        "properties": {
+          "secret": {
+            "description": "The secret token used when creating the webhook",
+            "type": "string",
+            "minLength": 1
+          }
+        }
+      },
+      "examples": [[{ "secret": "webhook-secret" }]]
+    },
     "gitURLType": {
       "description": "The type of Git URLs to use for cloning and fetching Git repositories on this GitLab instance.\n\nIf \"http\", Sourcegraph will access GitLab repositories using Git URLs of the form http(s)://gitlab.example.com/myteam/myproject.git (using https: if the GitLab instance uses HTTPS).\n\nIf \"ssh\", Sourcegraph will access GitLab repositories using Git URLs of the form git@example.gitlab.com:myteam/myproject.git. See the documentation for how to provide SSH private keys and known_hosts: https://docs.sourcegraph.com/admin/repo/auth#repositories-that-need-http-s-or-ssh-authentication.",
       "type": "string",
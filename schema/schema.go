@@ -8,6 +8,25 @@ import (
 	"fmt"
 )
 
+// APIBudget description: Hourly API usage budget. Unset fields are unlimited.
+type APIBudget struct {
+	// CodeHostRequests description: The number of API requests that are forwarded to code hosts, such as repository refreshes.
+	CodeHostRequests int `json:"codeHostRequests,omitempty"`
+	// GraphQLCost description: The total cost of GraphQL requests. The cost of a request is the number of objects it may return: each field with a selection counts once for each object that may contain it, as bounded by the first: arguments of the enclosing connections.
+	GraphQLCost int `json:"graphQLCost,omitempty"`
+	// SearchRequests description: The number of search requests, made with the search field of the GraphQL API or the streaming search API.
+	SearchRequests int `json:"searchRequests,omitempty"`
+}
+
+// APIBudgetOverride description: The budget of a specific user or organization.
+type APIBudgetOverride struct {
+	Budget APIBudget `json:"budget"`
+	// Org description: The name of the organization.
+	Org string `json:"org,omitempty"`
+	// User description: The username of the user.
+	User string `json:"user,omitempty"`
+}
+
 // AWSCodeCommitConnection description: Configuration for a connection to AWS CodeCommit.
 type AWSCodeCommitConnection struct {
 	// AccessKeyID description: The AWS access key ID to use when listing and updating repositories from AWS CodeCommit. Must have the AWSCodeCommitReadOnly IAM policy.
@@ -46,6 +65,18 @@ type AWSCodeCommitGitCredentials struct {
 	Username string `json:"username"`
 }
 
+// ApiRateLimits description: Hourly budgets for the use of the API by each user, organization and access token. A request that would exceed any of the budgets that apply to it is rejected with HTTP status 429 until the budget is replenished at the start of the next hour. API responses include the X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset headers for the budget that is closest to being exhausted. Budgets that are not set are unlimited. Requests by anonymous users are not limited.
+type ApiRateLimits struct {
+	// AccessToken description: The budget of each access token.
+	AccessToken *APIBudget `json:"accessToken,omitempty"`
+	// Org description: The budget of each organization, which is shared by all of its members.
+	Org *APIBudget `json:"org,omitempty"`
+	// Overrides description: Budgets of specific users and organizations, which replace the budgets above.
+	Overrides []*APIBudgetOverride `json:"overrides,omitempty"`
+	// User description: The budget of each user.
+	User *APIBudget `json:"user,omitempty"`
+}
+
 // AuthAccessTokens description: Settings for access tokens, which enable external tools to access the Sourcegraph API with the privileges of the user.
 type AuthAccessTokens struct {
 	// Allow description: Allow or restrict the use of access tokens. The default is "all-users-create", which enables all users to create access tokens. Use "none" to disable access tokens entirely. Use "site-admin-create" to restrict creation of new tokens to admin users (existing tokens will still work until revoked).
@@ -360,6 +391,12 @@ type ExcludedBitbucketServerRepo struct {
 	// Pattern description: Regular expression which matches against the name of a Bitbucket Server repo.
 	Pattern string `json:"pattern,omitempty"`
 }
+type ExcludedGerritProject struct {
+	// Name description: The name of a Gerrit project ("platform/build") to exclude from mirroring.
+	Name string `json:"name,omitempty"`
+	// Pattern description: Regular expression which matches against the name of a Gerrit project.
+	Pattern string `json:"pattern,omitempty"`
+}
 type ExcludedGitHubRepo struct {
 	// Archived description: If set to true, archived repositories will be excluded.
 	Archived bool `json:"archived,omitempty"`
@@ -378,6 +415,18 @@ type ExcludedGitLabProject struct {
 	// Name description: The name of a GitLab project ("group/name") to exclude from mirroring.
 	Name string `json:"name,omitempty"`
 }
+type ExcludedGiteaRepo struct {
+	// Archived description: If set to true, archived repositories will be excluded.
+	Archived bool `json:"archived,omitempty"`
+	// Forks description: If set to true, forks will be excluded.
+	Forks bool `json:"forks,omitempty"`
+	// Id description: The ID of a Gitea repository (as returned by the Gitea instance's API) to exclude from mirroring. Use this to exclude the repository, even if renamed.
+	Id int `json:"id,omitempty"`
+	// Name description: The name of a Gitea repository ("owner/name") to exclude from mirroring.
+	Name string `json:"name,omitempty"`
+	// Pattern description: Regular expression which matches against the name of a Gitea repository ("owner/name").
+	Pattern string `json:"pattern,omitempty"`
+}
 type ExcludedGitoliteRepo struct {
 	// Name description: The name of a Gitolite repo ("my-repo") to exclude from mirroring.
 	Name string `json:"name,omitempty"`
@@ -391,6 +440,8 @@ type ExperimentalFeatures struct {
 	Automation string `json:"automation,omitempty"`
 	// BitbucketServerFastPerm description: DEPRECATED: Configure in Bitbucket Server config.
 	BitbucketServerFastPerm string `json:"bitbucketServerFastPerm,omitempty"`
+	// CommitSearchIndex description: Enables indexed commit and diff search. gitserver keeps an index of the messages and diffs of the commits on the default branch of each fully cloned repository, and updates it after each fetch. Commit and diff searches of the default branch use the index, which lets them search many more repositories within the search timeout.
+	CommitSearchIndex string `json:"commitSearchIndex,omitempty"`
 	// CustomGitFetch description: JSON array of configuration that maps from Git clone URL domain/path to custom git fetch command.
 	CustomGitFetch []*CustomGitFetchMapping `json:"customGitFetch,omitempty"`
 	// DebugLog description: Turns on debug logging for specific debugging scenarios.
@@ -399,6 +450,8 @@ type ExperimentalFeatures struct {
 	Discussions string `json:"discussions,omitempty"`
 	// EventLogging description: Enables user event logging inside of the Sourcegraph instance. This will allow admins to have greater visibility of user activity, such as frequently viewed pages, frequent searches, and more. These event logs (and any specific user actions) are only stored locally, and never leave this Sourcegraph instance.
 	EventLogging string `json:"eventLogging,omitempty"`
+	// GitCloneModes description: JSON array of configuration that maps from Git clone URL domain/path prefixes to the mode gitserver uses to clone and fetch matching repositories. The mapping with the longest matching prefix is used. Repositories that match no mapping are fully cloned. Repositories that are already cloned are recloned in the background when their mode changes.
+	GitCloneModes []*GitCloneModeMapping `json:"gitCloneModes,omitempty"`
 	// SearchMultipleRevisionsPerRepository description: Enables searching multiple revisions of the same repository (using `repo:myrepo@branch1:branch2`).
 	SearchMultipleRevisionsPerRepository *bool `json:"searchMultipleRevisionsPerRepository,omitempty"`
 	// StructuralSearch description: Enables structural search.
@@ -428,6 +481,89 @@ type ExternalIdentity struct {
 	Type           string `json:"type"`
 }
 
+// GerritAuthorization description: If non-null, enforces Gerrit project read permissions, as defined by the access rights of the projects and their parents.
+type GerritAuthorization struct {
+	// IdentityProvider description: The source of identity to use when computing permissions. This defines how to compute the Gerrit identity to use for a given Sourcegraph user. When 'username' is used, Sourcegraph assumes usernames are identical in Sourcegraph and Gerrit accounts and `auth.enableUsernameChanges` must be set to false for security reasons.
+	IdentityProvider GerritIdentityProvider `json:"identityProvider"`
+	// Ttl description: Duration after which cached group memberships and project access rights will be updated. This is 3 hours by default.
+	//
+	// Decreasing the TTL will increase the load on the code host API.
+	Ttl string `json:"ttl,omitempty"`
+}
+
+// GerritConnection description: Configuration for a connection to Gerrit.
+type GerritConnection struct {
+	// Authorization description: If non-null, enforces Gerrit project read permissions, as defined by the access rights of the projects and their parents.
+	Authorization *GerritAuthorization `json:"authorization,omitempty"`
+	// Exclude description: A list of projects to never mirror from this Gerrit instance. Takes precedence over "projects" configuration.
+	//
+	// Supports excluding by name ({"name": "platform/build"}) or by regular expression ({"pattern": "^experimental/.*"}).
+	Exclude []*ExcludedGerritProject `json:"exclude,omitempty"`
+	// GitURLType description: The type of Git URLs to use for cloning and fetching Git repositories on this Gerrit instance.
+	//
+	// If "http", Sourcegraph will access Gerrit projects using Git URLs of the form https://gerrit.example.com/a/myproject, authenticating with the configured username and HTTP password.
+	//
+	// If "ssh", Sourcegraph will access Gerrit projects using Git URLs of the form ssh://username@gerrit.example.com:29418/myproject. See the documentation for how to provide SSH private keys and known_hosts: https://docs.sourcegraph.com/admin/repo/auth#repositories-that-need-http-s-or-ssh-authentication.
+	GitURLType string `json:"gitURLType,omitempty"`
+	// Password description: The HTTP password of the Gerrit account (generated on the account's HTTP Credentials settings page). Also set the corresponding "username" field.
+	Password string `json:"password"`
+	// Projects description: A list of project name prefixes identifying the Gerrit projects to mirror on Sourcegraph. If empty, all code projects visible to the configured account are mirrored.
+	Projects []string `json:"projects,omitempty"`
+	// RepositoryPathPattern description: The pattern used to generate the corresponding Sourcegraph repository name for a Gerrit project. In the pattern, the variable "{host}" is replaced with the Gerrit URL's host (such as gerrit.example.com), and "{project}" is replaced with the Gerrit project's name (such as "platform/build").
+	//
+	// For example, if your Gerrit is https://gerrit.example.com and your Sourcegraph is https://src.example.com, then a repositoryPathPattern of "{host}/{project}" would mean that a Gerrit project at https://gerrit.example.com/admin/repos/platform/build is available on Sourcegraph at https://src.example.com/gerrit.example.com/platform/build.
+	//
+	// It is important that the Sourcegraph repository name generated with this pattern be unique to this code host. If different code hosts generate repository names that collide, Sourcegraph's behavior is undefined.
+	RepositoryPathPattern string `json:"repositoryPathPattern,omitempty"`
+	// SshPort description: The port of Gerrit's SSH daemon. Only used if "gitURLType" is "ssh".
+	SshPort int `json:"sshPort,omitempty"`
+	// Url description: URL of a Gerrit instance, such as https://gerrit.example.com.
+	Url string `json:"url"`
+	// Username description: The username of the Gerrit account to use when calling the REST API and cloning projects. Also set the corresponding "password" field.
+	//
+	// The account should be able to read all projects that should be mirrored on Sourcegraph and, if "authorization" is set, list the members of all groups and view the access rights of all projects.
+	Username string `json:"username"`
+}
+
+// GerritIdentityProvider description: The source of identity to use when computing permissions. This defines how to compute the Gerrit identity to use for a given Sourcegraph user. When 'username' is used, Sourcegraph assumes usernames are identical in Sourcegraph and Gerrit accounts and `auth.enableUsernameChanges` must be set to false for security reasons.
+type GerritIdentityProvider struct {
+	Username *GerritUsernameIdentity
+}
+
+func (v GerritIdentityProvider) MarshalJSON() ([]byte, error) {
+	if v.Username != nil {
+		return json.Marshal(v.Username)
+	}
+	return nil, errors.New("tagged union type must have exactly 1 non-nil field value")
+}
+func (v *GerritIdentityProvider) UnmarshalJSON(data []byte) error {
+	var d struct {
+		DiscriminantProperty string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &d); err != nil {
+		return err
+	}
+	switch d.DiscriminantProperty {
+	case "username":
+		return json.Unmarshal(data, &v.Username)
+	}
+	return fmt.Errorf("tagged union type must have a %q property whose value is one of %s", "type", []string{"username"})
+}
+
+type GerritUsernameIdentity struct {
+	Type string `json:"type"`
+}
+
+// GitCloneModeMapping description: Mapping from a Git clone URL domain/path prefix to a clone mode. The `domainPath` field matches whole path components, so `somecodehost.com/org` matches all repositories of the organization `org` but not those of `org2`.
+type GitCloneModeMapping struct {
+	// Depth description: The number of commits to clone and fetch when `mode` is `shallow`.
+	Depth int `json:"depth,omitempty"`
+	// DomainPath description: Git clone URL domain/path prefix. Use the code host domain to match all repositories of an external service, or the full domain/path of a repository to match a single repository.
+	DomainPath string `json:"domainPath"`
+	// Mode description: The clone mode. `full` clones the complete history. `blobless` clones the complete history without file contents, which are fetched from the code host when they are first needed. `shallow` clones only the most recent `depth` commits of each branch and tag, which means older commits cannot be searched.
+	Mode string `json:"mode"`
+}
+
 // GitHubAuthProvider description: Configures the GitHub (or GitHub Enterprise) OAuth authentication provider for SSO. In addition to specifying this configuration object, you must also create a OAuth App on your GitHub instance: https://developer.github.com/apps/building-oauth-apps/creating-an-oauth-app/. When a user signs into Sourcegraph or links their GitHub account to their existing Sourcegraph account, GitHub will prompt the user for the repo scope.
 type GitHubAuthProvider struct {
 	// AllowOrgs description: Restricts new logins to members of these GitHub organizations. Existing sessions won't be invalidated. Leave empty or unset for no org restrictions.
@@ -589,6 +725,8 @@ type GitLabConnection struct {
 	Token string `json:"token"`
 	// Url description: URL of a GitLab instance, such as https://gitlab.example.com or (for GitLab.com) https://gitlab.com.
 	Url string `json:"url"`
+	// Webhooks description: An array of configurations defining existing GitLab webhooks that send updates back to Sourcegraph.
+	Webhooks []*GitLabWebhook `json:"webhooks,omitempty"`
 }
 type GitLabNameTransformation struct {
 	// Regex description: The regex to match for the occurrences of its replacement.
@@ -610,6 +748,81 @@ type GitLabRateLimit struct {
 	// RequestsPerHour description: Requests per hour permitted. This is an average, calculated per second.
 	RequestsPerHour float64 `json:"requestsPerHour"`
 }
+type GitLabWebhook struct {
+	// Secret description: The secret token used when creating the webhook
+	Secret string `json:"secret"`
+}
+
+// GiteaAuthorization description: If non-null, enforces Gitea repository permissions. A user can read a repository if it is public, if they own it, if they are a collaborator on it, or if they are a member of one of its teams.
+type GiteaAuthorization struct {
+	// IdentityProvider description: The source of identity to use when computing permissions. This defines how to compute the Gitea identity to use for a given Sourcegraph user. When 'username' is used, Sourcegraph assumes usernames are identical in Sourcegraph and Gitea accounts and `auth.enableUsernameChanges` must be set to false for security reasons.
+	IdentityProvider GiteaIdentityProvider `json:"identityProvider"`
+	// Ttl description: Duration after which cached repository collaborators and team memberships will be updated. This is 3 hours by default.
+	//
+	// Decreasing the TTL will increase the load on the code host API.
+	Ttl string `json:"ttl,omitempty"`
+}
+
+// GiteaConnection description: Configuration for a connection to Gitea or Gogs.
+type GiteaConnection struct {
+	// Authorization description: If non-null, enforces Gitea repository permissions. A user can read a repository if it is public, if they own it, if they are a collaborator on it, or if they are a member of one of its teams.
+	Authorization *GiteaAuthorization `json:"authorization,omitempty"`
+	// Exclude description: A list of repositories to never mirror from this Gitea instance. Takes precedence over "orgs" and "users" configuration.
+	//
+	// Supports excluding by name ({"name": "owner/name"}), by ID ({"id": 42}), by regular expression ({"pattern": "^experimental/.*"}), and excluding all forks ({"forks": true}) or archived repositories ({"archived": true}).
+	Exclude []*ExcludedGiteaRepo `json:"exclude,omitempty"`
+	// GitURLType description: The type of Git URLs to use for cloning and fetching Git repositories on this Gitea instance.
+	//
+	// If "http", Sourcegraph will access Gitea repositories using Git URLs of the form https://gitea.example.com/myteam/myproject.git, authenticating with the configured token.
+	//
+	// If "ssh", Sourcegraph will access Gitea repositories using the SSH clone URLs reported by Gitea, such as git@gitea.example.com:myteam/myproject.git. See the documentation for how to provide SSH private keys and known_hosts: https://docs.sourcegraph.com/admin/repo/auth#repositories-that-need-http-s-or-ssh-authentication.
+	GitURLType string `json:"gitURLType,omitempty"`
+	// Orgs description: A list of organizations whose repositories should be mirrored on Sourcegraph. If neither "orgs" nor "users" is set, all repositories the configured account owns or can access as a collaborator or organization member are mirrored.
+	Orgs []string `json:"orgs,omitempty"`
+	// RepositoryPathPattern description: The pattern used to generate the corresponding Sourcegraph repository name for a Gitea repository. In the pattern, the variable "{host}" is replaced with the Gitea URL's host (such as gitea.example.com), and "{nameWithOwner}" is replaced with the Gitea repository's "owner/name" path (such as "myteam/myproject").
+	//
+	// For example, if your Gitea is https://gitea.example.com and your Sourcegraph is https://src.example.com, then a repositoryPathPattern of "{host}/{nameWithOwner}" would mean that a Gitea repository at https://gitea.example.com/myteam/myproject is available on Sourcegraph at https://src.example.com/gitea.example.com/myteam/myproject.
+	//
+	// It is important that the Sourcegraph repository name generated with this pattern be unique to this code host. If different code hosts generate repository names that collide, Sourcegraph's behavior is undefined.
+	RepositoryPathPattern string `json:"repositoryPathPattern,omitempty"`
+	// Token description: An access token of a Gitea or Gogs account (generated on the account's Applications settings page). It is used to call the API and to clone repositories.
+	//
+	// The account should be able to read all repositories that should be mirrored on Sourcegraph and, if "authorization" is set, list their collaborators and teams.
+	Token string `json:"token"`
+	// Url description: URL of a Gitea or Gogs instance, such as https://gitea.example.com.
+	Url string `json:"url"`
+	// Users description: A list of users whose repositories should be mirrored on Sourcegraph.
+	Users []string `json:"users,omitempty"`
+}
+
+// GiteaIdentityProvider description: The source of identity to use when computing permissions. This defines how to compute the Gitea identity to use for a given Sourcegraph user. When 'username' is used, Sourcegraph assumes usernames are identical in Sourcegraph and Gitea accounts and `auth.enableUsernameChanges` must be set to false for security reasons.
+type GiteaIdentityProvider struct {
+	Username *GiteaUsernameIdentity
+}
+
+func (v GiteaIdentityProvider) MarshalJSON() ([]byte, error) {
+	if v.Username != nil {
+		return json.Marshal(v.Username)
+	}
+	return nil, errors.New("tagged union type must have exactly 1 non-nil field value")
+}
+func (v *GiteaIdentityProvider) UnmarshalJSON(data []byte) error {
+	var d struct {
+		DiscriminantProperty string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &d); err != nil {
+		return err
+	}
+	switch d.DiscriminantProperty {
+	case "username":
+		return json.Unmarshal(data, &v.Username)
+	}
+	return fmt.Errorf("tagged union type must have a %q property whose value is one of %s", "type", []string{"username"})
+}
+
+type GiteaUsernameIdentity struct {
+	Type string `json:"type"`
+}
 
 // GitoliteConnection description: Configuration for a connection to Gitolite.
 type GitoliteConnection struct {
@@ -905,7 +1118,7 @@ type Settings struct {
 	SearchContextLines int `json:"search.contextLines,omitempty"`
 	// SearchDefaultPatternType description: The default pattern type (literal or regexp) that search queries will be intepreted as.
 	SearchDefaultPatternType string `json:"search.defaultPatternType,omitempty"`
-	// SearchRepositoryGroups description: Named groups of repositories that can be referenced in a search query using the repogroup: operator.
+	// SearchRepositoryGroups description: Named groups of repositories that can be referenced in a search query using the repogroup: operator. Repository groups can also be created with the GraphQL API, where they may be defined by a rule that is re-evaluated whenever repositories are synced. Groups created with the GraphQL API take precedence over groups of the same name defined here.
 	SearchRepositoryGroups map[string][]string `json:"search.repositoryGroups,omitempty"`
 	// SearchSavedQueries description: DEPRECATED: Saved search queries
 	SearchSavedQueries []*SearchSavedQueries `json:"search.savedQueries,omitempty"`
@@ -927,6 +1140,8 @@ type SettingsExperimentalFeatures struct {
 
 // SiteConfiguration description: Configuration for a Sourcegraph site.
 type SiteConfiguration struct {
+	// ApiRateLimits description: Hourly budgets for the use of the API by each user, organization and access token. A request that would exceed any of the budgets that apply to it is rejected with HTTP status 429 until the budget is replenished at the start of the next hour. API responses include the X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset headers for the budget that is closest to being exhausted. Budgets that are not set are unlimited. Requests by anonymous users are not limited.
+	ApiRateLimits *ApiRateLimits `json:"apiRateLimits,omitempty"`
 	// AuthAccessTokens description: Settings for access tokens, which enable external tools to access the Sourcegraph API with the privileges of the user.
 	AuthAccessTokens *AuthAccessTokens `json:"auth.accessTokens,omitempty"`
 	// AuthEnableUsernameChanges description: Enables users to change their username after account creation. Warning: setting this to be true has security implications if you have enabled (or will at any point in the future enable) repository permissions with an option that relies on username equivalency between Sourcegraph and an external service or authentication provider. Do NOT set this to true if you are using non-built-in authentication OR rely on username equivalency for repository permissions.
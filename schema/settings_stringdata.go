@@ -87,7 +87,7 @@ const SettingsSchemaJSON = `{
       }
     },
     "search.repositoryGroups": {
-      "description": "Named groups of repositories that can be referenced in a search query using the repogroup: operator.",
+      "description": "Named groups of repositories that can be referenced in a search query using the repogroup: operator. Repository groups can also be created with the GraphQL API, where they may be defined by a rule that is re-evaluated whenever repositories are synced. Groups created with the GraphQL API take precedence over groups of the same name defined here.",
       "type": "object",
       "additionalProperties": {
         "type": "array",
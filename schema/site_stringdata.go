@@ -102,6 +102,12 @@ const SiteSchemaJSON = `{
           "enum": ["enabled", "disabled"],
           "default": "disabled"
         },
+        "commitSearchIndex": {
+          "description": "Enables indexed commit and diff search. gitserver keeps an index of the messages and diffs of the commits on the default branch of each fully cloned repository, and updates it after each fetch. Commit and diff searches of the default branch use the index, which lets them search many more repositories within the search timeout.",
+          "type": "string",
+          "enum": ["enabled", "disabled"],
+          "default": "disabled"
+        },
         "searchMultipleRevisionsPerRepository": {
           "description": "Enables searching multiple revisions of the same repository (using ` + "`" + `repo:myrepo@branch1:branch2` + "`" + `).",
           "type": "boolean",
@@ -162,6 +168,48 @@ const SiteSchemaJSON = `{
               }
             ]
           ]
+        },
+        "gitCloneModes": {
+          "description": "JSON array of configuration that maps from Git clone URL domain/path prefixes to the mode gitserver uses to clone and fetch matching repositories. The mapping with the longest matching prefix is used. Repositories that match no mapping are fully cloned. Repositories that are already cloned are recloned in the background when their mode changes.",
+          "type": "array",
+          "items": {
+            "title": "GitCloneModeMapping",
+            "description": "Mapping from a Git clone URL domain/path prefix to a clone mode. The ` + "`" + `domainPath` + "`" + ` field matches whole path components, so ` + "`" + `somecodehost.com/org` + "`" + ` matches all repositories of the organization ` + "`" + `org` + "`" + ` but not those of ` + "`" + `org2` + "`" + `.",
+            "type": "object",
+            "additionalProperties": false,
+            "required": ["domainPath", "mode"],
+            "properties": {
+              "domainPath": {
+                "description": "Git clone URL domain/path prefix. Use the code host domain to match all repositories of an external service, or the full domain/path of a repository to match a single repository.",
+                "type": "string",
+                "minLength": 1
+              },
+              "mode": {
+                "description": "The clone mode. ` + "`" + `full` + "`" + ` clones the complete history. ` + "`" + `blobless` + "`" + ` clones the complete history without file contents, which are fetched from the code host when they are first needed. ` + "`" + `shallow` + "`" + ` clones only the most recent ` + "`" + `depth` + "`" + ` commits of each branch and tag, which means older commits cannot be searched.",
+                "type": "string",
+                "enum": ["full", "blobless", "shallow"]
+              },
+              "depth": {
+                "description": "The number of commits to clone and fetch when ` + "`" + `mode` + "`" + ` is ` + "`" + `shallow` + "`" + `.",
+                "type": "integer",
+                "minimum": 1,
+                "default": 1
+              }
+            }
+          },
+          "examples": [
+            [
+              {
+                "domainPath": "somecodehost.com",
+                "mode": "blobless"
+              },
+              {
+                "domainPath": "somecodehost.com/path/to/hugerepo",
+                "mode": "shallow",
+                "depth": 50
+              }
+            ]
+          ]
         }
       },
       "examples": [
@@ -205,6 +253,38 @@ const SiteSchemaJSON = `{
       "default": false,
       "group": "Security"
     },
+    "apiRateLimits": {
+      "description": "Hourly budgets for the use of the API by each user, organization and access token. A request that would exceed any of the budgets that apply to it is rejected with HTTP status 429 until the budget is replenished at the start of the next hour. API responses include the X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset headers for the budget that is closest to being exhausted. Budgets that are not set are unlimited. Requests by anonymous users are not limited.",
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "user": {
+          "description": "The budget of each user.",
+          "$ref": "#/definitions/APIBudget"
+        },
+        "org": {
+          "description": "The budget of each organization, which is shared by all of its members.",
+          "$ref": "#/definitions/APIBudget"
+        },
+        "accessToken": {
+          "description": "The budget of each access token.",
+          "$ref": "#/definitions/APIBudget"
+        },
+        "overrides": {
+          "description": "Budgets of specific users and organizations, which replace the budgets above.",
+          "type": "array",
+          "items": { "$ref": "#/definitions/APIBudgetOverride" }
+        }
+      },
+      "examples": [
+        {
+          "user": { "searchRequests": 1000, "graphQLCost": 50000, "codeHostRequests": 100 },
+          "accessToken": { "searchRequests": 500 },
+          "overrides": [{ "user": "ci-bot", "budget": { "searchRequests": 10000 } }]
+        }
+      ],
+      "group": "Security"
+    },
     "disableNonCriticalTelemetry": {
       "description": "Disable aggregated event counts from being sent to Sourcegraph.com via pings.",
       "type": "boolean",
@@ -692,6 +772,47 @@ const SiteSchemaJSON = `{
     }
   },
   "definitions": {
+    "APIBudget": {
+      "description": "Hourly API usage budget. Unset fields are unlimited.",
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "searchRequests": {
+          "description": "The number of search requests, made with the search field of the GraphQL API or the streaming search API.",
+          "type": "integer",
+          "minimum": 1
+        },
+        "graphQLCost": {
+          "description": "The total cost of GraphQL requests. The cost of a request is the number of objects it may return: each field with a selection counts once for each object that may contain it, as bounded by the first: arguments of the enclosing connections.",
+          "type": "integer",
+          "minimum": 1
+        },
+        "codeHostRequests": {
+          "description": "The number of API requests that are forwarded to code hosts, such as repository refreshes.",
+          "type": "integer",
+          "minimum": 1
+        }
+      }
+    },
+    "APIBudgetOverride": {
+      "description": "The budget of a specific user or organization.",
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["budget"],
+      "properties": {
+        "user": {
+          "description": "The username of the user.",
+          "type": "string"
+        },
+        "org": {
+          "description": "The name of the organization.",
+          "type": "string"
+        },
+        "budget": {
+          "$ref": "#/definitions/APIBudget"
+        }
+      }
+    },
     "BrandAssets": {
       "type": "object",
       "properties": {
@@ -35,9 +35,14 @@ export enum FilterType {
     before = 'before',
     after = 'after',
     author = 'author',
+    blameauthor = 'blameauthor',
+    blamebefore = 'blamebefore',
+    blameafter = 'blameafter',
     message = 'message',
     content = 'content',
     patterntype = 'patterntype',
+    rank = 'rank',
+    select = 'select',
     index = 'index',
 }
 
@@ -53,10 +58,16 @@ export enum NegatedFilters {
     f = '-f',
     l = '-l',
     repohasfile = '-repohasfile',
+    blameauthor = '-blameauthor',
 }
 
 /** The list of filters that are able to be negated. */
-export type NegatableFilter = FilterType.repo | FilterType.file | FilterType.repohasfile | FilterType.lang
+export type NegatableFilter =
+    | FilterType.repo
+    | FilterType.file
+    | FilterType.repohasfile
+    | FilterType.lang
+    | FilterType.blameauthor
 
 export const isNegatableFilter = (filter: FilterType): filter is NegatableFilter =>
     Object.keys(NegatedFilters).includes(filter)
@@ -75,6 +86,7 @@ const negatedFilterToNegatableFilter: { [key: string]: NegatableFilter } = {
     '-f': FilterType.file,
     '-l': FilterType.lang,
     '-repohasfile': FilterType.repohasfile,
+    '-blameauthor': FilterType.blameauthor,
 }
 
 export const resolveNegatedFilter = (filter: NegatedFilters): NegatableFilter => negatedFilterToNegatableFilter[filter]
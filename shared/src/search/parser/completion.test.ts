@@ -35,6 +35,10 @@ describe('getCompletionItems()', () => {
             'archived',
             'author',
             'before',
+            'blameafter',
+            'blameauthor',
+            '-blameauthor',
+            'blamebefore',
             'case',
             'content',
             'count',
@@ -46,12 +50,14 @@ describe('getCompletionItems()', () => {
             '-lang',
             'message',
             'patterntype',
+            'rank',
             'repo',
             '-repo',
             'repogroup',
             'repohascommitafter',
             'repohasfile',
             '-repohasfile',
+            'select',
             'timeout',
             'type',
             'visibility',
@@ -89,6 +95,10 @@ describe('getCompletionItems()', () => {
             'archived',
             'author',
             'before',
+            'blameafter',
+            'blameauthor',
+            '-blameauthor',
+            'blamebefore',
             'case',
             'content',
             'count',
@@ -100,12 +110,14 @@ describe('getCompletionItems()', () => {
             '-lang',
             'message',
             'patterntype',
+            'rank',
             'repo',
             '-repo',
             'repogroup',
             'repohascommitafter',
             'repohasfile',
             '-repohasfile',
+            'select',
             'timeout',
             'type',
             'visibility',
@@ -124,6 +136,10 @@ describe('getCompletionItems()', () => {
             'archived',
             'author',
             'before',
+            'blameafter',
+            'blameauthor',
+            '-blameauthor',
+            'blamebefore',
             'case',
             'content',
             'count',
@@ -135,12 +151,14 @@ describe('getCompletionItems()', () => {
             '-lang',
             'message',
             'patterntype',
+            'rank',
             'repo',
             '-repo',
             'repogroup',
             'repohascommitafter',
             'repohasfile',
             '-repohasfile',
+            'select',
             'timeout',
             'type',
             'visibility',
@@ -166,6 +184,10 @@ describe('getCompletionItems()', () => {
             'archived',
             'author',
             'before',
+            'blameafter',
+            'blameauthor',
+            '-blameauthor',
+            'blamebefore',
             'case',
             'content',
             'count',
@@ -177,12 +199,14 @@ describe('getCompletionItems()', () => {
             '-lang',
             'message',
             'patterntype',
+            'rank',
             'repo',
             '-repo',
             'repogroup',
             'repohascommitafter',
             'repohasfile',
             '-repohasfile',
+            'select',
             'timeout',
             'type',
             'visibility',
@@ -204,6 +228,10 @@ describe('getCompletionItems()', () => {
             'archived',
             'author',
             'before',
+            'blameafter',
+            'blameauthor',
+            '-blameauthor',
+            'blamebefore',
             'case',
             'content',
             'count',
@@ -215,12 +243,14 @@ describe('getCompletionItems()', () => {
             '-lang',
             'message',
             'patterntype',
+            'rank',
             'repo',
             '-repo',
             'repogroup',
             'repohascommitafter',
             'repohasfile',
             '-repohasfile',
+            'select',
             'timeout',
             'type',
             'visibility',
@@ -66,6 +66,19 @@ export const FILTERS: Record<NegatableFilter, NegatableFilterDefinition> &
     [FilterType.before]: {
         description: 'Commits made before a certain date',
     },
+    [FilterType.blameafter]: {
+        description: 'Lines last changed after a certain date (according to git blame)',
+        singular: true,
+    },
+    [FilterType.blameauthor]: {
+        negatable: true,
+        description: negated =>
+            `${negated ? 'Exclude' : 'Include only'} lines last changed by a matching author (according to git blame)`,
+    },
+    [FilterType.blamebefore]: {
+        description: 'Lines last changed before a certain date (according to git blame)',
+        singular: true,
+    },
     [FilterType.case]: {
         description: 'Treat the search pattern as case-sensitive.',
         discreteValues: ['yes', 'no'],
@@ -111,6 +124,11 @@ export const FILTERS: Record<NegatableFilter, NegatableFilterDefinition> &
         description: 'The pattern type (regexp, literal, structural) in use',
         singular: true,
     },
+    [FilterType.rank]: {
+        discreteValues: ['relevance', 'lexicographic'],
+        description: 'How results are ordered (relevance, lexicographic)',
+        singular: true,
+    },
     [FilterType.repo]: {
         alias: 'r',
         negatable: true,
@@ -131,6 +149,24 @@ export const FILTERS: Record<NegatableFilter, NegatableFilterDefinition> &
         description: negated =>
             `${negated ? 'Exclude' : 'Include only'} results from repos that contain a matching file`,
     },
+    [FilterType.select]: {
+        discreteValues: [
+            'symbol.function',
+            'symbol.method',
+            'symbol.class',
+            'symbol.struct',
+            'symbol.interface',
+            'symbol.type',
+            'symbol.enum',
+            'symbol.constant',
+            'symbol.variable',
+            'symbol.field',
+            'symbol.module',
+            'symbol.namespace',
+            'symbol.package',
+        ],
+        description: 'Include only symbols of the given kind (requires type:symbol)',
+    },
     [FilterType.timeout]: {
         description: 'Duration before timeout',
         singular: true,
@@ -156,6 +156,10 @@ function serviceTypeDisplayNameAndIcon(
             return { displayName: 'Phabricator', icon: PhabricatorIcon }
         case 'awscodecommit':
             return { displayName: 'AWS CodeCommit' }
+        case 'gerrit':
+            return { displayName: 'Gerrit' }
+        case 'gitea':
+            return { displayName: 'Gitea' }
     }
     return { displayName: serviceType ? upperFirst(serviceType) : 'code host' }
 }
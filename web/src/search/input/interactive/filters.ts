@@ -89,9 +89,14 @@ export const FilterTypeToProseNames: Record<FilterType, string> = {
     before: 'Committed before',
     message: 'Commit message contains',
     author: 'Commit author',
+    blameauthor: 'Last changed by',
+    blamebefore: 'Last changed before',
+    blameafter: 'Last changed after',
     type: 'Type',
     content: 'Content',
     patterntype: 'Pattern type',
+    rank: 'Ranking',
+    select: 'Select',
     index: 'Indexed repos',
     visibility: 'Repository visiblity',
 }
@@ -91,10 +91,34 @@ export const searchFilterSuggestions: SearchFilterSuggestions = {
                 value: 'message:',
                 description: 'commit message contents',
             },
+            {
+                value: 'blameauthor:',
+                description: 'regex-pattern (include lines last changed by a matching author)',
+            },
+            {
+                value: '-blameauthor:',
+                description: 'regex-pattern (exclude lines last changed by a matching author)',
+            },
+            {
+                value: 'blamebefore:',
+                description: '"string specifying time frame" (include lines last changed before)',
+            },
+            {
+                value: 'blameafter:',
+                description: '"string specifying time frame" (include lines last changed after)',
+            },
             {
                 value: 'content:',
                 description: 'override the search pattern',
             },
+            {
+                value: 'rank:',
+                description: 'relevance | lexicographic',
+            },
+            {
+                value: 'select:',
+                description: 'symbol.kind (include only symbols of the given kind, with type:symbol)',
+            },
             {
                 value: 'visibility:',
                 description: 'any | public | private',
@@ -203,6 +227,19 @@ export const searchFilterSuggestions: SearchFilterSuggestions = {
             assign({ type: FilterType.after })
         ),
     },
+    blameauthor: {
+        values: [],
+    },
+    blamebefore: {
+        values: [{ value: '"1 week ago"' }, { value: '"1 month ago"' }, { value: '"1 year ago"' }].map(
+            assign({ type: FilterType.blamebefore })
+        ),
+    },
+    blameafter: {
+        values: [{ value: '"1 week ago"' }, { value: '"1 month ago"' }, { value: '"1 year ago"' }].map(
+            assign({ type: FilterType.blameafter })
+        ),
+    },
     content: {
         values: [],
     },
@@ -211,6 +248,22 @@ export const searchFilterSuggestions: SearchFilterSuggestions = {
             assign({ type: FilterType.patterntype })
         ),
     },
+    rank: {
+        default: 'lexicographic',
+        values: [{ value: 'relevance' }, { value: 'lexicographic' }].map(assign({ type: FilterType.rank })),
+    },
+    select: {
+        values: [
+            { value: 'symbol.function' },
+            { value: 'symbol.method' },
+            { value: 'symbol.class' },
+            { value: 'symbol.struct' },
+            { value: 'symbol.interface' },
+            { value: 'symbol.type' },
+            { value: 'symbol.constant' },
+            { value: 'symbol.variable' },
+        ].map(assign({ type: FilterType.select })),
+    },
     index: {
         default: 'yes',
         values: [{ value: 'no' }, { value: 'only' }, { value: 'yes' }].map(
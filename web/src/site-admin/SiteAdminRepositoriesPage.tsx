@@ -55,6 +55,17 @@ class RepositoryNode extends React.PureComponent<RepositoryNodeProps, Repository
                                 <CloudOutlineIcon className="icon-inline" /> Not yet cloned
                             </small>
                         )}
+                        {this.props.node.mirrorInfo.cloneMode &&
+                            this.props.node.mirrorInfo.cloneMode !== GQL.RepositoryCloneMode.FULL && (
+                                <small
+                                    className="ml-2 text-muted"
+                                    data-tooltip="The repository is not fully cloned. See the gitCloneModes site configuration."
+                                >
+                                    {this.props.node.mirrorInfo.cloneMode === GQL.RepositoryCloneMode.BLOBLESS
+                                        ? 'Blobless clone'
+                                        : 'Shallow clone'}
+                                </small>
+                            )}
                     </div>
                     <div className="repository-node__actions">
                         {!this.props.node.mirrorInfo.cloneInProgress && !this.props.node.mirrorInfo.cloned && (
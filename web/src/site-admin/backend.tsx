@@ -133,6 +133,7 @@ function fetchAllRepositories(args: RepositoryArgs): Observable<GQL.IRepositoryC
                         mirrorInfo {
                             cloned
                             cloneInProgress
+                            cloneMode
                             updatedAt
                         }
                     }
@@ -9,6 +9,8 @@ import React from 'react'
 import awsCodeCommitSchemaJSON from '../../../schema/aws_codecommit.schema.json'
 import bitbucketCloudSchemaJSON from '../../../schema/bitbucket_cloud.schema.json'
 import bitbucketServerSchemaJSON from '../../../schema/bitbucket_server.schema.json'
+import gerritSchemaJSON from '../../../schema/gerrit.schema.json'
+import giteaSchemaJSON from '../../../schema/gitea.schema.json'
 import githubSchemaJSON from '../../../schema/github.schema.json'
 import gitlabSchemaJSON from '../../../schema/gitlab.schema.json'
 import gitoliteSchemaJSON from '../../../schema/gitolite.schema.json'
@@ -98,6 +100,13 @@ const editorActionComments = {
     //    (https://docs.sourcegraph.com/admin/auth).
     // 3. Update the fields below to match the properties of this auth provider
     //    (https://docs.sourcegraph.com/admin/repo/permissions#sudo-access-token).`,
+    enforcePermissionsGerrit: `// Prerequisite: usernames must be identical in Sourcegraph and Gerrit, and
+      // "auth.enableUsernameChanges" must be set to false in the site configuration.
+      // The account in this config must be able to view the access rights of all
+      // projects and the members of all groups.`,
+    enforcePermissionsGitea: `// Prerequisite: usernames must be identical in Sourcegraph and Gitea, and
+      // "auth.enableUsernameChanges" must be set to false in the site configuration.
+      // The token in this config must belong to a Gitea site administrator.`,
 }
 
 const Field = (props: { children: React.ReactChildren | string | string[] }): JSX.Element => (
@@ -941,6 +950,173 @@ const GITOLITE: AddExternalServiceOptions = {
         },
     ],
 }
+const GERRIT: AddExternalServiceOptions = {
+    kind: GQL.ExternalServiceKind.GERRIT,
+    title: 'Gerrit',
+    icon: GitIcon,
+    jsonSchema: gerritSchemaJSON,
+    defaultDisplayName: 'Gerrit',
+    defaultConfig: `{
+  "url": "https://gerrit.example.com",
+  "username": "<username>",
+  "password": "<HTTP password>",
+  "projects": []
+}`,
+    instructions: (
+        <div>
+            <ol>
+                <li>
+                    In the configuration below, set <Field>url</Field> to the URL of your Gerrit instance.
+                </li>
+                <li>
+                    Generate an HTTP password for a Gerrit account that can read the projects you want to mirror on
+                    its <b>HTTP Credentials</b> settings page. Set the <Field>username</Field> and{' '}
+                    <Field>password</Field> fields to the account's username and HTTP password.
+                </li>
+                <li>
+                    Optionally, set the <Field>projects</Field> field to the list of project name prefixes to mirror.
+                    All code projects visible to the account are mirrored by default.
+                </li>
+            </ol>
+            <p>
+                See{' '}
+                <a
+                    rel="noopener noreferrer"
+                    target="_blank"
+                    href="https://docs.sourcegraph.com/admin/external_service/gerrit#configuration"
+                >
+                    the docs for more advanced options
+                </a>
+                , or try one of the buttons below.
+            </p>
+        </div>
+    ),
+    editorActions: [
+        {
+            id: 'setPassword',
+            label: 'Set HTTP password',
+            run: config => {
+                const value = '<HTTP password>'
+                const edits = setProperty(config, ['password'], value, defaultFormattingOptions)
+                return { edits, selectText: value }
+            },
+        },
+        {
+            id: 'addProjects',
+            label: 'Add projects with prefix',
+            run: config => {
+                const value = '<project prefix>/'
+                const edits = setProperty(config, ['projects', -1], value, defaultFormattingOptions)
+                return { edits, selectText: value }
+            },
+        },
+        {
+            id: 'excludeProject',
+            label: 'Exclude a project',
+            run: config => {
+                const value = { name: '<project>' }
+                const edits = setProperty(config, ['exclude', -1], value, defaultFormattingOptions)
+                return { edits, selectText: '<project>' }
+            },
+        },
+        {
+            id: 'enforcePermissions',
+            label: 'Enforce permissions',
+            run: config => {
+                const value = {
+                    COMMENT_SENTINEL: true,
+                    identityProvider: { type: 'username' },
+                }
+                const comment = editorActionComments.enforcePermissionsGerrit
+                const edit = editWithComment(config, ['authorization'], value, comment)
+                return { edits: [edit], selectText: comment }
+            },
+        },
+    ],
+}
+const GITEA: AddExternalServiceOptions = {
+    kind: GQL.ExternalServiceKind.GITEA,
+    title: 'Gitea',
+    icon: GitIcon,
+    jsonSchema: giteaSchemaJSON,
+    defaultDisplayName: 'Gitea',
+    defaultConfig: `{
+  "url": "https://gitea.example.com",
+  "token": "<access token>",
+  "orgs": []
+}`,
+    instructions: (
+        <div>
+            <ol>
+                <li>
+                    In the configuration below, set <Field>url</Field> to the URL of your Gitea or Gogs instance.
+                </li>
+                <li>
+                    Generate an access token for an account that can read the repositories you want to mirror on
+                    its <b>Applications</b> settings page, and set the <Field>token</Field> field to it.
+                </li>
+                <li>
+                    Optionally, set the <Field>orgs</Field> and <Field>users</Field> fields to the organizations and
+                    users whose repositories to mirror. All repositories the account owns or can access as a
+                    collaborator or organization member are mirrored by default.
+                </li>
+            </ol>
+            <p>
+                See{' '}
+                <a
+                    rel="noopener noreferrer"
+                    target="_blank"
+                    href="https://docs.sourcegraph.com/admin/external_service/gitea#configuration"
+                >
+                    the docs for more advanced options
+                </a>
+                , or try one of the buttons below.
+            </p>
+        </div>
+    ),
+    editorActions: [
+        {
+            id: 'setAccessToken',
+            label: 'Set access token',
+            run: config => {
+                const value = '<access token>'
+                const edits = setProperty(config, ['token'], value, defaultFormattingOptions)
+                return { edits, selectText: value }
+            },
+        },
+        {
+            id: 'addOrgRepos',
+            label: 'Add repositories in an organization',
+            run: config => {
+                const value = '<organization name>'
+                const edits = setProperty(config, ['orgs', -1], value, defaultFormattingOptions)
+                return { edits, selectText: value }
+            },
+        },
+        {
+            id: 'excludeRepo',
+            label: 'Exclude a repository',
+            run: config => {
+                const value = { name: '<owner>/<repository>' }
+                const edits = setProperty(config, ['exclude', -1], value, defaultFormattingOptions)
+                return { edits, selectText: '<owner>/<repository>' }
+            },
+        },
+        {
+            id: 'enforcePermissions',
+            label: 'Enforce permissions',
+            run: config => {
+                const value = {
+                    COMMENT_SENTINEL: true,
+                    identityProvider: { type: 'username' },
+                }
+                const comment = editorActionComments.enforcePermissionsGitea
+                const edit = editWithComment(config, ['authorization'], value, comment)
+                return { edits: [edit], selectText: comment }
+            },
+        },
+    ],
+}
 const PHABRICATOR_SERVICE: AddExternalServiceOptions = {
     kind: GQL.ExternalServiceKind.PHABRICATOR,
     title: 'Phabricator connection',
@@ -1055,6 +1231,8 @@ export const codeHostExternalServices: Record<string, AddExternalServiceOptions>
     bitbucket: BITBUCKET_CLOUD,
     bitbucketserver: BITBUCKET_SERVER,
     aws_codecommit: AWS_CODE_COMMIT,
+    gerrit: GERRIT,
+    gitea: GITEA,
     gitolite: GITOLITE,
     git: GENERIC_GIT,
 }
@@ -1072,6 +1250,8 @@ export const defaultExternalServices: Record<GQL.ExternalServiceKind, AddExterna
     [GQL.ExternalServiceKind.GITHUB]: GITHUB_DOTCOM,
     [GQL.ExternalServiceKind.BITBUCKETCLOUD]: BITBUCKET_CLOUD,
     [GQL.ExternalServiceKind.BITBUCKETSERVER]: BITBUCKET_SERVER,
+    [GQL.ExternalServiceKind.GERRIT]: GERRIT,
+    [GQL.ExternalServiceKind.GITEA]: GITEA,
     [GQL.ExternalServiceKind.GITLAB]: GITLAB_DOTCOM,
     [GQL.ExternalServiceKind.GITOLITE]: GITOLITE,
     [GQL.ExternalServiceKind.PHABRICATOR]: PHABRICATOR_SERVICE,